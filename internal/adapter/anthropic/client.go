@@ -458,6 +458,13 @@ func (c *Client) handleStreamingResponseWithTracking(ctx context.Context, body i
 				}
 			case "input_json_delta":
 				bs.InputJSON += ev.Delta.PartialJSON
+				if ev.Delta.PartialJSON != "" {
+					select {
+					case <-ctx.Done():
+						return
+					case ch <- engine.TokenOrToolCall{ArgsDelta: &engine.ToolArgsDelta{ID: bs.ToolID, Name: bs.ToolName, Index: ev.Index, Delta: ev.Delta.PartialJSON}}:
+					}
+				}
 			case "thinking_delta":
 				if ev.Delta.Thinking != "" {
 					bs.ThinkingBuf += ev.Delta.Thinking
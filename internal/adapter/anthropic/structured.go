@@ -0,0 +1,62 @@
+package anthropic
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/loom/loom/internal/adapter/common"
+	"github.com/loom/loom/internal/engine"
+)
+
+// ChatStructured implements engine.StructuredLLM by forcing a call to a tool whose
+// input schema is the requested schema; the tool input is the result.
+func (c *Client) ChatStructured(ctx context.Context, messages []engine.Message, schema engine.StructuredSchema) (json.RawMessage, error) {
+	var system []string
+	var claudeMessages []map[string]interface{}
+	for _, m := range messages {
+		if strings.ToLower(m.Role) == "system" {
+			system = append(system, m.Content)
+			continue
+		}
+		claudeMessages = append(claudeMessages, map[string]interface{}{"role": m.Role, "content": m.Content})
+	}
+	body := map[string]interface{}{
+		"model":      strings.TrimPrefix(c.model, "claude:"),
+		"max_tokens": c.maxTokens,
+		"messages":   claudeMessages,
+		"tools": []map[string]interface{}{{
+			"name":         schema.Name,
+			"description":  schema.Description,
+			"input_schema": schema.Schema,
+		}},
+		"tool_choice": map[string]interface{}{"type": "tool", "name": schema.Name},
+	}
+	if len(system) > 0 {
+		body["system"] = strings.Join(system, "\n\n")
+	}
+	data, err := common.PostJSON(ctx, c.httpClient, c.endpoint, map[string]string{
+		"x-api-key":         c.apiKey,
+		"anthropic-version": c.apiVersion,
+	}, body)
+	if err != nil {
+		return nil, fmt.Errorf("Anthropic %w", err)
+	}
+	var resp struct {
+		Content []struct {
+			Type  string          `json:"type"`
+			Name  string          `json:"name"`
+			Input json.RawMessage `json:"input"`
+		} `json:"content"`
+	}
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil, fmt.Errorf("unexpected Anthropic response: %v", err)
+	}
+	for _, block := range resp.Content {
+		if block.Type == "tool_use" && block.Name == schema.Name {
+			return block.Input, nil
+		}
+	}
+	return nil, fmt.Errorf("Anthropic did not return %s", schema.Name)
+}
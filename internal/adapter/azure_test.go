@@ -0,0 +1,49 @@
+package adapter
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/loom/loom/internal/adapter/openai"
+	"github.com/loom/loom/internal/engine"
+)
+
+func TestNew_AzureRoutesToDeployment(t *testing.T) {
+	var path, query, key, bearer string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path, query = r.URL.Path, r.URL.RawQuery
+		key, bearer = r.Header.Get("api-key"), r.Header.Get("Authorization")
+		_, _ = w.Write([]byte(`{"choices":[{"message":{"content":"{\"title\":\"Fix login\"}"}}]}`))
+	}))
+	defer srv.Close()
+
+	if _, err := New(Config{Provider: ProviderAzureOpenAI, Model: "gpt-4o-prod", APIKey: "k"}); err == nil {
+		t.Fatal("expected an error without an endpoint")
+	}
+	llm, err := New(Config{
+		Provider: ProviderAzureOpenAI,
+		Model:    "gpt-4o-prod",
+		APIKey:   "azure-key",
+		Azure:    openai.AzureConfig{Endpoint: srv.URL + "/openai/", APIVersion: "2024-06-01"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := llm.(engine.StructuredLLM).ChatStructured(context.Background(), []engine.Message{{Role: "user", Content: "hi"}}, testSchema)
+	if err != nil || string(out) != `{"title":"Fix login"}` {
+		t.Fatalf("unexpected result %s, %v", out, err)
+	}
+	if path != "/openai/deployments/gpt-4o-prod/chat/completions" || query != "api-version=2024-06-01" {
+		t.Fatalf("unexpected request %s?%s", path, query)
+	}
+	if key != "azure-key" || bearer != "" {
+		t.Fatalf("expected the api-key header only, got api-key=%q Authorization=%q", key, bearer)
+	}
+
+	prov, id, err := GetProviderFromModel("azure:gpt-4o-prod")
+	if err != nil || prov != ProviderAzureOpenAI || id != "gpt-4o-prod" {
+		t.Fatalf("azure mapping failed: prov=%s id=%s err=%v", prov, id, err)
+	}
+}
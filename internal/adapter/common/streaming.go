@@ -213,6 +213,17 @@ func (sp *StreamProcessor) handleStreamEvent(
 		// Handle tool call deltas
 		if len(event.Delta.ToolCalls) > 0 {
 			sp.handleToolCallDeltas(event.Delta.ToolCalls, partials, lastItemID)
+			for _, tc := range event.Delta.ToolCalls {
+				if tc.Function.Arguments == "" {
+					continue
+				}
+				p := partials[tc.Index]
+				select {
+				case <-ctx.Done():
+					return true
+				case ch <- engine.TokenOrToolCall{ArgsDelta: &engine.ToolArgsDelta{ID: p.ID, Name: p.Name, Index: tc.Index, Delta: tc.Function.Arguments}}:
+				}
+			}
 		}
 	}
 
@@ -0,0 +1,93 @@
+package common
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/loom/loom/internal/engine"
+)
+
+// PostJSON sends body to url and returns the response body of a 200 reply.
+func PostJSON(ctx context.Context, client *http.Client, url string, headers map[string]string, body interface{}) ([]byte, error) {
+	reqBody, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("marshal error: %v", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(reqBody))
+	if err != nil {
+		return nil, fmt.Errorf("request creation error: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("HTTP error: %v", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("API error (%d): %s", resp.StatusCode, string(data))
+	}
+	return data, nil
+}
+
+// JSONSchemaResponseFormat is the Chat Completions response_format that makes
+// OpenAI-compatible providers reply with an object matching schema.
+func JSONSchemaResponseFormat(schema engine.StructuredSchema) map[string]interface{} {
+	return map[string]interface{}{
+		"type": "json_schema",
+		"json_schema": map[string]interface{}{
+			"name":        schema.Name,
+			"description": schema.Description,
+			"schema":      schema.Schema,
+			"strict":      true,
+		},
+	}
+}
+
+// StructuredMessages converts plain system and user messages to the Chat Completions
+// format used for structured requests.
+func StructuredMessages(messages []engine.Message) []map[string]interface{} {
+	out := make([]map[string]interface{}, 0, len(messages))
+	for _, m := range messages {
+		out = append(out, map[string]interface{}{"role": m.Role, "content": m.Content})
+	}
+	return out
+}
+
+// ChatCompletionJSON extracts the JSON object from a Chat Completions reply to a
+// structured request.
+func ChatCompletionJSON(data []byte) (json.RawMessage, error) {
+	var resp struct {
+		Choices []struct {
+			Message struct {
+				Content string `json:"content"`
+				Refusal string `json:"refusal"`
+			} `json:"message"`
+		} `json:"choices"`
+	}
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil, fmt.Errorf("unexpected response: %v", err)
+	}
+	if len(resp.Choices) == 0 {
+		return nil, errors.New("the response has no choices")
+	}
+	msg := resp.Choices[0].Message
+	if msg.Refusal != "" {
+		return nil, fmt.Errorf("the model refused: %s", msg.Refusal)
+	}
+	if !json.Valid([]byte(msg.Content)) {
+		return nil, errors.New("the model did not return JSON")
+	}
+	return json.RawMessage(msg.Content), nil
+}
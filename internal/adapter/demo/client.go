@@ -0,0 +1,157 @@
+package demo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/loom/loom/internal/engine"
+)
+
+// Client implements engine.LLM by replaying a recorded trace. It needs no API key or
+// network access, which makes it suitable for offline demos and documentation.
+type Client struct {
+	trace *Trace
+	// delay paces streamed words so replies look typed; zero streams instantly
+	delay time.Duration
+}
+
+// New creates a demo client for trace (nil uses the built-in trace).
+func New(trace *Trace) *Client {
+	if trace == nil {
+		trace = BuiltinTrace()
+	}
+	return &Client{trace: trace, delay: 25 * time.Millisecond}
+}
+
+// WithDelay sets the pause between streamed words.
+func (c *Client) WithDelay(d time.Duration) *Client {
+	c.delay = d
+	return c
+}
+
+// Chat implements the engine.LLM interface. The turn is chosen by matching the latest
+// user message against the trace (falling back to turn order), and the step by the
+// number of tool calls already made since that message.
+func (c *Client) Chat(
+	ctx context.Context,
+	messages []engine.Message,
+	tools []engine.ToolSchema,
+	stream bool,
+) (<-chan engine.TokenOrToolCall, error) {
+	lastUser, userCount := -1, 0
+	for i, m := range messages {
+		if m.Role == "user" {
+			lastUser = i
+			userCount++
+		}
+	}
+	if lastUser < 0 {
+		return nil, errors.New("demo: no user message to respond to")
+	}
+	steps := 0
+	for _, m := range messages[lastUser+1:] {
+		if m.Role == "assistant" && m.Name != "" && m.Name != "thinking" && m.ToolID != "" {
+			steps++
+		}
+	}
+
+	ch := make(chan engine.TokenOrToolCall)
+	go func() {
+		defer close(ch)
+		turn := c.turnFor(messages[lastUser].Content, userCount-1)
+		if turn < 0 || steps >= len(c.trace.Turns[turn].Steps) {
+			c.emitText(ctx, ch, "", c.unmatchedReply())
+			return
+		}
+		step := c.trace.Turns[turn].Steps[steps]
+		var call *engine.ToolCall
+		if step.ToolCall != nil {
+			args := step.ToolCall.Args
+			if len(args) == 0 {
+				args = json.RawMessage("{}")
+			}
+			call = &engine.ToolCall{ID: fmt.Sprintf("demo-%d-%d-%d", turn, steps, time.Now().UnixNano()), Name: step.ToolCall.Name, Args: args, Version: step.ToolCall.schemaVersion()}
+		}
+		if !c.emitText(ctx, ch, step.Reasoning, step.Text) || call == nil {
+			return
+		}
+		select {
+		case <-ctx.Done():
+		case ch <- engine.TokenOrToolCall{ToolCall: call}:
+		}
+	}()
+	return ch, nil
+}
+
+// turnFor returns the turn recorded for request, or the turn at position index.
+func (c *Client) turnFor(request string, index int) int {
+	want := normalizeRequest(request)
+	for i, t := range c.trace.Turns {
+		if normalizeRequest(t.User) == want {
+			return i
+		}
+	}
+	if index < len(c.trace.Turns) {
+		return index
+	}
+	return -1
+}
+
+func normalizeRequest(s string) string {
+	return strings.ToLower(strings.Join(strings.Fields(strings.TrimRight(s, ".!? ")), " "))
+}
+
+// unmatchedReply lists the recorded requests when the trace has nothing more to say.
+func (c *Client) unmatchedReply() string {
+	var b strings.Builder
+	b.WriteString("Demo mode: this recorded trace has no response for that message.")
+	if len(c.trace.Turns) > 0 {
+		b.WriteString(" Recorded requests:\n")
+		for _, t := range c.trace.Turns {
+			fmt.Fprintf(&b, "- %s\n", t.User)
+		}
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// emitText streams reasoning and text word by word. It reports false when the
+// context was cancelled.
+func (c *Client) emitText(ctx context.Context, ch chan<- engine.TokenOrToolCall, reasoning, text string) bool {
+	send := func(tok string) bool {
+		if c.delay > 0 {
+			select {
+			case <-ctx.Done():
+				return false
+			case <-time.After(c.delay):
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return false
+		case ch <- engine.TokenOrToolCall{Token: tok}:
+			return true
+		}
+	}
+	if reasoning != "" {
+		for _, w := range strings.SplitAfter(reasoning, " ") {
+			if !send("[REASONING] " + w) {
+				return false
+			}
+		}
+		if !send("[REASONING_DONE] ") {
+			return false
+		}
+	}
+	if text != "" {
+		for _, w := range strings.SplitAfter(text, " ") {
+			if !send(w) {
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,104 @@
+package demo
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/loom/loom/internal/engine"
+)
+
+func collect(t *testing.T, c *Client, messages []engine.Message) (string, *engine.ToolCall) {
+	t.Helper()
+	ch, err := c.Chat(context.Background(), messages, nil, true)
+	if err != nil {
+		t.Fatalf("chat: %v", err)
+	}
+	var text strings.Builder
+	var call *engine.ToolCall
+	for item := range ch {
+		if item.ToolCall != nil {
+			call = item.ToolCall
+			continue
+		}
+		text.WriteString(item.Token)
+	}
+	return text.String(), call
+}
+
+func TestClient_ReplaysStepsOfMatchingTurn(t *testing.T) {
+	c := New(nil).WithDelay(0)
+	msgs := []engine.Message{
+		{Role: "system", Content: "prompt"},
+		{Role: "user", Content: "give me a quick tour of this project!"},
+	}
+
+	text, call := collect(t, c, msgs)
+	if call == nil || call.Name != "summarize_tree" {
+		t.Fatalf("expected summarize_tree call, got %#v", call)
+	}
+	if call.Version != 1 {
+		t.Fatalf("calls of unversioned traces should target schema v1, got %d", call.Version)
+	}
+	if !strings.HasPrefix(text, "[REASONING] ") || !strings.Contains(text, "[REASONING_DONE] ") {
+		t.Fatalf("expected streamed reasoning, got %q", text)
+	}
+
+	msgs = append(msgs,
+		engine.Message{Role: "assistant", Name: call.Name, ToolID: call.ID, Content: string(call.Args)},
+		engine.Message{Role: "tool", Name: call.Name, ToolID: call.ID, Content: "tree"},
+	)
+	if _, call = collect(t, c, msgs); call == nil || call.Name != "get_project_profile" {
+		t.Fatalf("expected get_project_profile call, got %#v", call)
+	}
+
+	msgs = append(msgs,
+		engine.Message{Role: "assistant", Name: call.Name, ToolID: call.ID, Content: "{}"},
+		engine.Message{Role: "tool", Name: call.Name, ToolID: call.ID, Content: "profile"},
+	)
+	text, call = collect(t, c, msgs)
+	if call != nil || !strings.Contains(text, "Here's the tour") {
+		t.Fatalf("expected final answer, got text=%q call=%#v", text, call)
+	}
+
+	msgs = append(msgs, engine.Message{Role: "assistant", Content: text}, engine.Message{Role: "user", Content: "and then?"})
+	if _, call = collect(t, c, msgs); call == nil || call.Name != "edit_file" {
+		t.Fatalf("expected the second turn by position, got %#v", call)
+	}
+}
+
+func TestClient_UnmatchedMessageListsRecordedRequests(t *testing.T) {
+	c := New(&Trace{Turns: []Turn{{User: "hello", Steps: []Step{{Text: "hi"}}}}}).WithDelay(0)
+	text, call := collect(t, c, []engine.Message{
+		{Role: "user", Content: "hello"},
+		{Role: "assistant", Content: "hi"},
+		{Role: "user", Content: "something else"},
+	})
+	if call != nil || !strings.Contains(text, "no response for that message") || !strings.Contains(text, "- hello") {
+		t.Fatalf("unexpected reply %q", text)
+	}
+}
+
+func TestTraceFromMessages_RoundTrips(t *testing.T) {
+	msgs := []engine.Message{
+		{Role: "system", Content: "prompt"},
+		{Role: "user", Content: "fix it"},
+		{Role: "assistant", Name: "thinking", Content: `{"thinking":"look first"}`},
+		{Role: "assistant", Name: "read_file", ToolID: "t1", Content: `{"path":"main.go"}`, Version: 2},
+		{Role: "tool", Name: "read_file", ToolID: "t1", Content: "package main"},
+		{Role: "assistant", Content: "Done."},
+	}
+	tr := TraceFromMessages("session", msgs)
+	if len(tr.Turns) != 1 || len(tr.Turns[0].Steps) != 2 {
+		t.Fatalf("unexpected trace %#v", tr)
+	}
+	first := tr.Turns[0].Steps[0]
+	if first.Reasoning != "look first" || first.ToolCall == nil || first.ToolCall.Name != "read_file" {
+		t.Fatalf("unexpected first step %#v", first)
+	}
+
+	text, call := collect(t, New(tr).WithDelay(0), msgs[:2])
+	if call == nil || string(call.Args) != `{"path":"main.go"}` || call.Version != 2 || !strings.Contains(text, "first") {
+		t.Fatalf("replay mismatch: text=%q call=%#v", text, call)
+	}
+}
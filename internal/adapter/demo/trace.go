@@ -0,0 +1,122 @@
+package demo
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/loom/loom/internal/engine"
+)
+
+// Trace is a recorded session replayed by the demo provider.
+type Trace struct {
+	Title string `json:"title,omitempty"`
+	Turns []Turn `json:"turns"`
+}
+
+// Turn is the recorded response to one user message. It may span several steps when
+// the model called tools before answering.
+type Turn struct {
+	// User is the recorded request. A new message matching it replays this turn;
+	// otherwise turns are replayed in order.
+	User  string `json:"user"`
+	Steps []Step `json:"steps"`
+}
+
+// Step is one model response: optional reasoning and text, then optionally a tool call.
+type Step struct {
+	Reasoning string        `json:"reasoning,omitempty"`
+	Text      string        `json:"text,omitempty"`
+	ToolCall  *RecordedCall `json:"tool_call,omitempty"`
+}
+
+// RecordedCall is a tool call made in a recorded step.
+type RecordedCall struct {
+	Name string          `json:"name"`
+	Args json.RawMessage `json:"args,omitempty"`
+	// Version is the tool schema version Args follow; traces without it predate
+	// versioning and use v1
+	Version int `json:"version,omitempty"`
+}
+
+// schemaVersion returns the schema version the call's arguments were recorded for.
+func (c RecordedCall) schemaVersion() int {
+	if c.Version < 1 {
+		return 1
+	}
+	return c.Version
+}
+
+//go:embed sample_trace.json
+var sampleTrace []byte
+
+// BuiltinTrace returns the trace shipped with Loom.
+func BuiltinTrace() *Trace {
+	var t Trace
+	if err := json.Unmarshal(sampleTrace, &t); err != nil {
+		panic("demo: invalid built-in trace: " + err.Error())
+	}
+	return &t
+}
+
+// LoadTrace reads a trace from a JSON file. An empty path returns the built-in trace.
+func LoadTrace(path string) (*Trace, error) {
+	if strings.TrimSpace(path) == "" {
+		return BuiltinTrace(), nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read demo trace: %w", err)
+	}
+	var t Trace
+	if err := json.Unmarshal(data, &t); err != nil {
+		return nil, fmt.Errorf("failed to parse demo trace: %w", err)
+	}
+	if len(t.Turns) == 0 {
+		return nil, errors.New("demo trace has no turns")
+	}
+	return &t, nil
+}
+
+// TraceFromMessages records a trace from a stored conversation, so any real session
+// can be replayed later without a provider.
+func TraceFromMessages(title string, messages []engine.Message) *Trace {
+	t := &Trace{Title: title}
+	var turn *Turn
+	var step Step
+	for _, m := range messages {
+		switch {
+		case m.Role == "user":
+			if turn != nil && (step.Text != "" || step.Reasoning != "") {
+				turn.Steps = append(turn.Steps, step)
+			}
+			t.Turns = append(t.Turns, Turn{User: m.Content})
+			turn, step = &t.Turns[len(t.Turns)-1], Step{}
+		case turn == nil:
+			continue
+		case m.Role == "assistant" && m.Name == "thinking":
+			var payload struct {
+				Thinking string `json:"thinking"`
+			}
+			if json.Unmarshal([]byte(m.Content), &payload) == nil {
+				step.Reasoning += payload.Thinking
+			}
+		case m.Role == "assistant" && m.Name != "" && m.ToolID != "":
+			step.ToolCall = &RecordedCall{Name: m.Name, Args: json.RawMessage(m.Content), Version: m.Version}
+			if !json.Valid(step.ToolCall.Args) {
+				step.ToolCall.Args = json.RawMessage("{}")
+			}
+			turn.Steps = append(turn.Steps, step)
+			step = Step{}
+		case m.Role == "assistant":
+			step.Text += m.Content
+		}
+	}
+	if turn != nil && (step.Text != "" || step.Reasoning != "") {
+		turn.Steps = append(turn.Steps, step)
+	}
+	return t
+}
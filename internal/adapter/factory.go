@@ -7,6 +7,8 @@ import (
 	"strings"
 
 	"github.com/loom/loom/internal/adapter/anthropic"
+	"github.com/loom/loom/internal/adapter/demo"
+	"github.com/loom/loom/internal/adapter/gemini"
 	"github.com/loom/loom/internal/adapter/ollama"
 	"github.com/loom/loom/internal/adapter/openai"
 	responses "github.com/loom/loom/internal/adapter/openai/responses"
@@ -29,14 +31,30 @@ const (
 
 	// OpenRouter provider for multi-model access
 	ProviderOpenRouter Provider = "openrouter"
+
+	// Google Gemini provider (e.g., Gemini 2.5 Pro)
+	ProviderGemini Provider = "gemini"
+
+	// Azure OpenAI provider; the model is the name of a deployment on the resource
+	ProviderAzureOpenAI Provider = "azure"
+
+	// Demo provider replaying a recorded trace; the model is "builtin" or a trace file path
+	ProviderDemo Provider = "demo"
 )
 
+// DemoBuiltinModel selects the trace shipped with Loom for the demo provider.
+const DemoBuiltinModel = "builtin"
+
 // Config holds configuration for an LLM adapter.
 type Config struct {
 	Provider Provider
 	Model    string
 	APIKey   string
 	Endpoint string // For custom endpoints (e.g., Azure OpenAI or Ollama)
+	// Organization is sent to OpenAI as the OpenAI-Organization header
+	Organization string
+	// Azure is the Azure OpenAI resource requests go to with ProviderAzureOpenAI
+	Azure openai.AzureConfig
 }
 
 // DefaultConfig returns a conservative default configuration.
@@ -65,9 +83,23 @@ func New(config Config) (engine.LLM, error) {
 		}
 		// Environment toggle removed; rely on model prefix to choose responses client
 		if useResponses {
-			return responses.New(config.APIKey, config.Model), nil
+			return responses.New(config.APIKey, config.Model).WithOrganization(config.Organization), nil
 		}
-		return openai.New(config.APIKey, config.Model), nil
+		return openai.New(config.APIKey, config.Model).WithOrganization(config.Organization), nil
+
+	case ProviderAzureOpenAI:
+		if config.APIKey == "" {
+			return nil, errors.New("azure OpenAI API key not set; set it in Settings")
+		}
+		if !config.Azure.Enabled() {
+			return nil, errors.New("azure OpenAI endpoint not set; set it in Settings")
+		}
+		azure := config.Azure
+		if config.Model != "" {
+			azure.Deployment = config.Model
+		}
+		// Azure serves every model family, reasoning ones included, through Chat Completions
+		return openai.New(config.APIKey, azure.Deployment).WithAzure(azure), nil
 
 	case ProviderAnthropic:
 		if config.APIKey == "" {
@@ -88,6 +120,23 @@ func New(config Config) (engine.LLM, error) {
 		}
 		return openrouter.New(config.APIKey, config.Model), nil
 
+	case ProviderGemini:
+		if config.APIKey == "" {
+			return nil, errors.New("gemini API key not set; set it in Settings")
+		}
+		return gemini.New(config.APIKey, config.Model), nil
+
+	case ProviderDemo:
+		tracePath := config.Model
+		if tracePath == DemoBuiltinModel {
+			tracePath = ""
+		}
+		trace, err := demo.LoadTrace(tracePath)
+		if err != nil {
+			return nil, err
+		}
+		return demo.New(trace), nil
+
 	default:
 		return nil, errors.New("unknown LLM provider")
 	}
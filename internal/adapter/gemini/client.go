@@ -0,0 +1,459 @@
+package gemini
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/loom/loom/internal/engine"
+)
+
+// Client handles interaction with the Google Gemini API.
+type Client struct {
+	apiKey     string
+	model      string
+	endpoint   string // base URL of the models collection; the model and method are appended
+	httpClient *http.Client
+	maxTokens  int // Maximum tokens in response
+}
+
+// New creates a new Gemini client.
+func New(apiKey string, model string) *Client {
+	if model == "" {
+		model = "gemini-2.5-flash" // Default model
+	}
+
+	return &Client{
+		apiKey:    apiKey,
+		model:     strings.TrimPrefix(model, "gemini:"),
+		endpoint:  "https://generativelanguage.googleapis.com/v1beta/models",
+		maxTokens: 8192,
+		httpClient: &http.Client{
+			Timeout: 120 * time.Second,
+		},
+	}
+}
+
+// WithEndpoint sets a custom base URL for the Gemini API, e.g. a proxy.
+func (c *Client) WithEndpoint(endpoint string) *Client {
+	c.endpoint = strings.TrimRight(endpoint, "/")
+	return c
+}
+
+// WithMaxTokens sets the maximum number of tokens in the response.
+func (c *Client) WithMaxTokens(maxTokens int) *Client {
+	c.maxTokens = maxTokens
+	return c
+}
+
+// part is one piece of a Gemini message: text, a function call or a function response.
+type part struct {
+	Text             string            `json:"text,omitempty"`
+	Thought          bool              `json:"thought,omitempty"`
+	FunctionCall     *functionCall     `json:"functionCall,omitempty"`
+	FunctionResponse *functionResponse `json:"functionResponse,omitempty"`
+}
+
+type functionCall struct {
+	ID   string          `json:"id,omitempty"`
+	Name string          `json:"name"`
+	Args json.RawMessage `json:"args,omitempty"`
+}
+
+type functionResponse struct {
+	Name     string                 `json:"name"`
+	Response map[string]interface{} `json:"response"`
+}
+
+type content struct {
+	Role  string `json:"role,omitempty"`
+	Parts []part `json:"parts"`
+}
+
+// response is a generateContent reply, or one chunk of a streamed reply.
+type response struct {
+	Candidates []struct {
+		Content      content `json:"content"`
+		FinishReason string  `json:"finishReason"`
+	} `json:"candidates"`
+	UsageMetadata *struct {
+		PromptTokenCount     int64 `json:"promptTokenCount"`
+		CandidatesTokenCount int64 `json:"candidatesTokenCount"`
+		ThoughtsTokenCount   int64 `json:"thoughtsTokenCount"`
+	} `json:"usageMetadata"`
+	PromptFeedback *struct {
+		BlockReason string `json:"blockReason"`
+	} `json:"promptFeedback"`
+	Error *struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// Chat implements the engine.LLM interface for Gemini.
+func (c *Client) Chat(
+	ctx context.Context,
+	messages []engine.Message,
+	tools []engine.ToolSchema,
+	stream bool,
+) (<-chan engine.TokenOrToolCall, error) {
+	if c.apiKey == "" {
+		return nil, errors.New("gemini API key not set")
+	}
+
+	resultCh := make(chan engine.TokenOrToolCall)
+	go func() {
+		defer close(resultCh)
+		c.chatWithRetry(ctx, messages, tools, stream, resultCh)
+	}()
+	return resultCh, nil
+}
+
+// chatWithRetry retries an empty response once with the opposite streaming mode.
+func (c *Client) chatWithRetry(ctx context.Context, messages []engine.Message, tools []engine.ToolSchema, stream bool, resultCh chan<- engine.TokenOrToolCall) {
+	contentReceived, toolCallReceived := c.attemptChat(ctx, messages, tools, stream, resultCh)
+	if !contentReceived && !toolCallReceived {
+		select {
+		case <-ctx.Done():
+			return
+		case resultCh <- engine.TokenOrToolCall{Token: "Retrying due to empty response..."}:
+		}
+		c.attemptChat(ctx, messages, tools, !stream, resultCh)
+	}
+}
+
+// attemptChat performs a single chat attempt and returns whether content/toolcalls were received
+func (c *Client) attemptChat(ctx context.Context, messages []engine.Message, tools []engine.ToolSchema, stream bool, resultCh chan<- engine.TokenOrToolCall) (contentReceived, toolCallReceived bool) {
+	system, contents := convertMessages(messages)
+	generation := map[string]interface{}{
+		"temperature":     0.2,
+		"maxOutputTokens": c.maxTokens,
+	}
+	// Thoughts are only shown while streaming
+	if stream && supportsThinking(c.model) {
+		generation["thinkingConfig"] = map[string]interface{}{"includeThoughts": true}
+	}
+	requestBody := map[string]interface{}{
+		"contents":         contents,
+		"generationConfig": generation,
+	}
+	if system != "" {
+		requestBody["systemInstruction"] = content{Parts: []part{{Text: system}}}
+	}
+	if len(tools) > 0 {
+		requestBody["tools"] = []map[string]interface{}{{"functionDeclarations": convertTools(tools)}}
+		requestBody["toolConfig"] = map[string]interface{}{"functionCallingConfig": map[string]interface{}{"mode": "AUTO"}}
+	}
+
+	reqBody, err := json.Marshal(requestBody)
+	if err != nil {
+		return false, false
+	}
+	url := c.endpoint + "/" + c.model + ":generateContent"
+	if stream {
+		url = c.endpoint + "/" + c.model + ":streamGenerateContent?alt=sse"
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(reqBody))
+	if err != nil {
+		return false, false
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if stream {
+		req.Header.Set("Accept", "text/event-stream")
+	}
+	req.Header.Set("x-goog-api-key", c.apiKey)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		send(ctx, resultCh, engine.TokenOrToolCall{Token: fmt.Sprintf("Gemini HTTP error: %v", err)})
+		return false, false
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		errorResponse, _ := io.ReadAll(resp.Body)
+		send(ctx, resultCh, engine.TokenOrToolCall{Token: fmt.Sprintf("Gemini API error (%d): %s", resp.StatusCode, string(errorResponse))})
+		return false, false
+	}
+
+	if stream {
+		return c.handleStreamingResponse(ctx, resp.Body, resultCh)
+	}
+	return c.handleNonStreamingResponse(ctx, resp.Body, resultCh)
+}
+
+// handleStreamingResponse relays the server-sent chunks of a streamGenerateContent reply.
+func (c *Client) handleStreamingResponse(ctx context.Context, body io.Reader, ch chan<- engine.TokenOrToolCall) (contentReceived, toolCallReceived bool) {
+	sc := bufio.NewScanner(body)
+	sc.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+
+	var st replyState
+	for sc.Scan() {
+		line := sc.Text()
+		if !strings.HasPrefix(line, "data: ") {
+			continue
+		}
+		payload := strings.TrimSpace(line[6:])
+		if payload == "" || payload == "[DONE]" {
+			continue
+		}
+		var chunk response
+		if err := json.Unmarshal([]byte(payload), &chunk); err != nil {
+			// Skip malformed SSE chunk silently
+			continue
+		}
+		if !st.relay(ctx, ch, chunk) {
+			return st.content, st.toolCall
+		}
+	}
+	_ = sc.Err()
+	st.finish(ctx, ch, c.model)
+	return st.content, st.toolCall
+}
+
+// handleNonStreamingResponse relays a generateContent reply.
+func (c *Client) handleNonStreamingResponse(ctx context.Context, body io.Reader, ch chan<- engine.TokenOrToolCall) (contentReceived, toolCallReceived bool) {
+	respBody, err := io.ReadAll(body)
+	if err != nil {
+		return false, false
+	}
+	var resp response
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return false, false
+	}
+	var st replyState
+	if st.relay(ctx, ch, resp) {
+		st.finish(ctx, ch, c.model)
+	}
+	return st.content, st.toolCall
+}
+
+// replyState tracks one reply across its chunks.
+type replyState struct {
+	content, toolCall bool
+	thinking          bool
+	calls             int
+	in, out           int64
+}
+
+// relay sends the text, thoughts and function calls of one chunk. It returns false
+// when the reply ended with an error or the context was cancelled.
+func (s *replyState) relay(ctx context.Context, ch chan<- engine.TokenOrToolCall, chunk response) bool {
+	if chunk.Error != nil {
+		send(ctx, ch, engine.TokenOrToolCall{Token: fmt.Sprintf("Gemini API error (%d): %s", chunk.Error.Code, chunk.Error.Message)})
+		return false
+	}
+	if u := chunk.UsageMetadata; u != nil {
+		// Usage is cumulative; thoughts are billed as output
+		s.in, s.out = u.PromptTokenCount, u.CandidatesTokenCount+u.ThoughtsTokenCount
+	}
+	if chunk.PromptFeedback != nil && chunk.PromptFeedback.BlockReason != "" {
+		send(ctx, ch, engine.TokenOrToolCall{Token: "Gemini blocked the prompt: " + chunk.PromptFeedback.BlockReason})
+		return false
+	}
+	if len(chunk.Candidates) == 0 {
+		return true
+	}
+	for _, p := range chunk.Candidates[0].Content.Parts {
+		switch {
+		case p.Thought:
+			if p.Text == "" {
+				continue
+			}
+			s.thinking = true
+			if !send(ctx, ch, engine.TokenOrToolCall{Token: "[REASONING] " + p.Text}) {
+				return false
+			}
+		case p.FunctionCall != nil:
+			if !s.endThinking(ctx, ch) {
+				return false
+			}
+			s.calls++
+			id := p.FunctionCall.ID
+			if id == "" {
+				id = fmt.Sprintf("gemini-call-%d-%d", time.Now().UnixNano(), s.calls)
+			}
+			args := p.FunctionCall.Args
+			if len(bytes.TrimSpace(args)) == 0 || !json.Valid(args) {
+				args = json.RawMessage("{}")
+			}
+			s.toolCall = true
+			if !send(ctx, ch, engine.TokenOrToolCall{ToolCall: &engine.ToolCall{ID: id, Name: p.FunctionCall.Name, Args: args}}) {
+				return false
+			}
+		case p.Text != "":
+			if !s.endThinking(ctx, ch) {
+				return false
+			}
+			if strings.TrimSpace(p.Text) != "" {
+				s.content = true
+			}
+			if !send(ctx, ch, engine.TokenOrToolCall{Token: p.Text}) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// endThinking tells the UI that the thoughts are over once the answer starts.
+func (s *replyState) endThinking(ctx context.Context, ch chan<- engine.TokenOrToolCall) bool {
+	if !s.thinking {
+		return true
+	}
+	s.thinking = false
+	return send(ctx, ch, engine.TokenOrToolCall{Token: "[REASONING_DONE] "})
+}
+
+// finish emits the usage marker the engine uses for costs.
+func (s *replyState) finish(ctx context.Context, ch chan<- engine.TokenOrToolCall, model string) {
+	if !s.endThinking(ctx, ch) {
+		return
+	}
+	send(ctx, ch, engine.TokenOrToolCall{Token: fmt.Sprintf("[USAGE] provider=gemini model=%s in=%d out=%d", model, s.in, s.out)})
+}
+
+func send(ctx context.Context, ch chan<- engine.TokenOrToolCall, item engine.TokenOrToolCall) bool {
+	select {
+	case <-ctx.Done():
+		return false
+	case ch <- item:
+		return true
+	}
+}
+
+// convertMessages transforms engine messages to Gemini contents and returns the
+// system messages joined as the system instruction. Consecutive messages of one role
+// are merged, as Gemini expects user and model turns to alternate.
+func convertMessages(messages []engine.Message) (string, []content) {
+	var system []string
+	var result []content
+	// Tool results are matched to their call by name; take it from the call if missing
+	callNames := map[string]string{}
+	add := func(role string, p part) {
+		if n := len(result); n > 0 && result[n-1].Role == role {
+			result[n-1].Parts = append(result[n-1].Parts, p)
+			return
+		}
+		result = append(result, content{Role: role, Parts: []part{p}})
+	}
+	for _, msg := range messages {
+		switch strings.ToLower(msg.Role) {
+		case "system", "developer":
+			if msg.Content != "" {
+				system = append(system, msg.Content)
+			}
+		case "assistant":
+			// Other providers' thinking blocks cannot be replayed to Gemini
+			if msg.Name == "thinking" {
+				continue
+			}
+			if msg.Name != "" && msg.ToolID != "" {
+				args := json.RawMessage(msg.Content)
+				if !json.Valid(args) {
+					args = json.RawMessage("{}")
+				}
+				callNames[msg.ToolID] = msg.Name
+				add("model", part{FunctionCall: &functionCall{Name: msg.Name, Args: args}})
+				continue
+			}
+			if strings.TrimSpace(msg.Content) != "" {
+				add("model", part{Text: msg.Content})
+			}
+		case "tool", "function":
+			name := msg.Name
+			if name == "" {
+				name = callNames[msg.ToolID]
+			}
+			add("user", part{FunctionResponse: &functionResponse{
+				Name:     name,
+				Response: map[string]interface{}{"content": msg.Content},
+			}})
+		default:
+			add("user", part{Text: msg.Content})
+		}
+	}
+	// Gemini answers the last user turn; nudge it to continue after its own turn
+	if len(result) == 0 || result[len(result)-1].Role != "user" {
+		add("user", part{Text: "Either continue with your task at hand or write finalizing message."})
+	}
+	return strings.Join(system, "\n\n"), result
+}
+
+// convertTools transforms engine tool schemas to Gemini function declarations.
+func convertTools(tools []engine.ToolSchema) []map[string]interface{} {
+	result := make([]map[string]interface{}, 0, len(tools))
+	for _, tool := range tools {
+		decl := map[string]interface{}{
+			"name":        tool.Name,
+			"description": tool.Description,
+		}
+		// Functions without arguments must omit the parameters
+		if props, _ := tool.Schema["properties"].(map[string]interface{}); len(props) > 0 {
+			decl["parameters"] = convertSchema(tool.Schema)
+		}
+		result = append(result, decl)
+	}
+	return result
+}
+
+// unsupportedSchemaKeys are JSON Schema keywords Gemini's OpenAPI subset rejects.
+var unsupportedSchemaKeys = map[string]bool{
+	"additionalProperties": true,
+	"$schema":              true,
+	"$id":                  true,
+	"$ref":                 true,
+	"$defs":                true,
+	"definitions":          true,
+	"patternProperties":    true,
+	"const":                true,
+	"examples":             true,
+}
+
+// convertSchema copies a JSON schema without the keywords Gemini does not accept.
+func convertSchema(v interface{}) interface{} {
+	switch s := v.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(s))
+		for k, val := range s {
+			if unsupportedSchemaKeys[k] {
+				continue
+			}
+			if k == "properties" {
+				// Property names are not keywords
+				props := map[string]interface{}{}
+				if m, ok := val.(map[string]interface{}); ok {
+					for name, p := range m {
+						props[name] = convertSchema(p)
+					}
+				}
+				out[k] = props
+				continue
+			}
+			out[k] = convertSchema(val)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(s))
+		for i, val := range s {
+			out[i] = convertSchema(val)
+		}
+		return out
+	default:
+		return v
+	}
+}
+
+// supportsThinking reports whether model can return its thoughts; 2.5 and later
+// models think, earlier ones reject the thinking config.
+func supportsThinking(model string) bool {
+	m := strings.ToLower(model)
+	return strings.Contains(m, "gemini-2.5") || strings.HasPrefix(m, "gemini-3")
+}
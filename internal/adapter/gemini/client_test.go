@@ -0,0 +1,107 @@
+package gemini
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/loom/loom/internal/engine"
+)
+
+func TestChat_StreamsTextThoughtsAndCalls(t *testing.T) {
+	var path, key string
+	var req struct {
+		SystemInstruction content   `json:"systemInstruction"`
+		Contents          []content `json:"contents"`
+		Tools             []struct {
+			FunctionDeclarations []map[string]interface{} `json:"functionDeclarations"`
+		} `json:"tools"`
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path, key = r.URL.String(), r.Header.Get("x-goog-api-key")
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, chunk := range []string{
+			`{"candidates":[{"content":{"role":"model","parts":[{"text":"Looking at main.go","thought":true}]}}]}`,
+			`{"candidates":[{"content":{"role":"model","parts":[{"text":"Reading it."}]}}]}`,
+			`{"candidates":[{"content":{"role":"model","parts":[{"functionCall":{"name":"read_file","args":{"path":"main.go"}}}]},"finishReason":"STOP"}],"usageMetadata":{"promptTokenCount":12,"candidatesTokenCount":5,"thoughtsTokenCount":3}}`,
+		} {
+			_, _ = w.Write([]byte("data: " + chunk + "\r\n\r\n"))
+		}
+	}))
+	defer srv.Close()
+
+	messages := []engine.Message{
+		{Role: "system", Content: "Be brief."},
+		{Role: "user", Content: "What does main do?"},
+		{Role: "assistant", Name: "list_dir", ToolID: "call-1", Content: `{"path":"."}`},
+		{Role: "tool", ToolID: "call-1", Content: "main.go"},
+	}
+	tools := []engine.ToolSchema{{
+		Name:        "read_file",
+		Description: "Read a file",
+		Schema: map[string]interface{}{
+			"type":                 "object",
+			"properties":           map[string]interface{}{"path": map[string]interface{}{"type": "string"}},
+			"required":             []string{"path"},
+			"additionalProperties": false,
+		},
+	}}
+	ch, err := New("key", "gemini-2.5-flash").WithEndpoint(srv.URL).Chat(context.Background(), messages, tools, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var tokens []string
+	var calls []*engine.ToolCall
+	for item := range ch {
+		if item.ToolCall != nil {
+			calls = append(calls, item.ToolCall)
+			continue
+		}
+		tokens = append(tokens, item.Token)
+	}
+
+	if path != "/gemini-2.5-flash:streamGenerateContent?alt=sse" || key != "key" {
+		t.Fatalf("unexpected request %s with key %q", path, key)
+	}
+	if req.SystemInstruction.Parts[0].Text != "Be brief." || len(req.Contents) != 3 {
+		t.Fatalf("unexpected contents %+v", req)
+	}
+	if resp := req.Contents[2].Parts[0].FunctionResponse; req.Contents[2].Role != "user" || resp == nil || resp.Name != "list_dir" {
+		t.Fatalf("expected the tool result as a function response, got %+v", req.Contents[2])
+	}
+	params, _ := req.Tools[0].FunctionDeclarations[0]["parameters"].(map[string]interface{})
+	if _, ok := params["additionalProperties"]; ok || params["required"] == nil {
+		t.Fatalf("unexpected parameters %v", params)
+	}
+
+	want := []string{"[REASONING] Looking at main.go", "[REASONING_DONE] ", "Reading it.", "[USAGE] provider=gemini model=gemini-2.5-flash in=12 out=8"}
+	if strings.Join(tokens, "|") != strings.Join(want, "|") {
+		t.Fatalf("unexpected tokens %q", tokens)
+	}
+	if len(calls) != 1 || calls[0].Name != "read_file" || string(calls[0].Args) != `{"path":"main.go"}` || calls[0].ID == "" {
+		t.Fatalf("unexpected tool calls %+v", calls)
+	}
+}
+
+func TestChat_ReportsAPIErrors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+		_, _ = w.Write([]byte(`{"error":{"code":429,"status":"RESOURCE_EXHAUSTED"}}`))
+	}))
+	defer srv.Close()
+
+	ch, err := New("key", "gemini-2.0-flash").WithEndpoint(srv.URL).Chat(context.Background(), []engine.Message{{Role: "user", Content: "hi"}}, nil, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	first := <-ch
+	for range ch {
+	}
+	if !strings.Contains(first.Token, "API error (429)") {
+		t.Fatalf("expected the status in the error token, got %q", first.Token)
+	}
+}
@@ -0,0 +1,45 @@
+package gemini
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/loom/loom/internal/adapter/common"
+	"github.com/loom/loom/internal/engine"
+)
+
+// ChatStructured implements engine.StructuredLLM with Gemini's JSON mode: the reply is
+// constrained to the requested schema.
+func (c *Client) ChatStructured(ctx context.Context, messages []engine.Message, schema engine.StructuredSchema) (json.RawMessage, error) {
+	system, contents := convertMessages(messages)
+	body := map[string]interface{}{
+		"contents": contents,
+		"generationConfig": map[string]interface{}{
+			"maxOutputTokens":  c.maxTokens,
+			"responseMimeType": "application/json",
+			"responseSchema":   convertSchema(schema.Schema),
+		},
+	}
+	if system != "" {
+		body["systemInstruction"] = content{Parts: []part{{Text: system}}}
+	}
+	data, err := common.PostJSON(ctx, c.httpClient, c.endpoint+"/"+c.model+":generateContent", map[string]string{
+		"x-goog-api-key": c.apiKey,
+	}, body)
+	if err != nil {
+		return nil, fmt.Errorf("Gemini %w", err)
+	}
+	var resp response
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil, fmt.Errorf("unexpected Gemini response: %v", err)
+	}
+	if len(resp.Candidates) > 0 {
+		for _, p := range resp.Candidates[0].Content.Parts {
+			if !p.Thought && json.Valid([]byte(p.Text)) {
+				return json.RawMessage(p.Text), nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("Gemini did not return %s", schema.Name)
+}
@@ -0,0 +1,177 @@
+package adapter
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/loom/loom/internal/engine"
+)
+
+// Key is one named credential for a provider.
+type Key struct {
+	Name         string
+	APIKey       string
+	Organization string
+}
+
+// KeyRotation reports that an adapter moved on to another key.
+type KeyRotation struct {
+	Provider Provider
+	From     string
+	To       string
+	Reason   string
+}
+
+// NewWithKeys creates an adapter that sends requests with the first key and moves on to
+// the next one when the provider reports the current key out of quota or rate limited.
+// The switch sticks for later requests; onRotate, when set, is told about every switch.
+// With fewer than two keys it behaves like New.
+func NewWithKeys(config Config, keys []Key, onRotate func(KeyRotation)) (engine.LLM, error) {
+	if len(keys) == 0 {
+		return New(config)
+	}
+	var llms []engine.LLM
+	var usable []Key
+	for _, k := range keys {
+		cfg := config
+		cfg.APIKey, cfg.Organization = k.APIKey, k.Organization
+		llm, err := New(cfg)
+		if err != nil {
+			continue
+		}
+		llms = append(llms, llm)
+		usable = append(usable, k)
+	}
+	switch len(llms) {
+	case 0:
+		return nil, errors.New(string(config.Provider) + " API key not set; set it in Settings")
+	case 1:
+		return llms[0], nil
+	}
+	return &rotatingLLM{provider: config.Provider, keys: usable, llms: llms, onRotate: onRotate}, nil
+}
+
+// rotatingLLM tries its keys in turn when a request fails for quota reasons.
+type rotatingLLM struct {
+	provider Provider
+	keys     []Key
+	llms     []engine.LLM
+	onRotate func(KeyRotation)
+
+	mu      sync.Mutex
+	current int
+}
+
+// ActiveKey returns the name of the key requests are currently sent with.
+func (r *rotatingLLM) ActiveKey() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.keys[r.current].Name
+}
+
+// Chat implements engine.LLM. Adapters report request failures as the first and only
+// item of the stream, so only that item is held back to decide whether to rotate.
+func (r *rotatingLLM) Chat(ctx context.Context, messages []engine.Message, tools []engine.ToolSchema, stream bool) (<-chan engine.TokenOrToolCall, error) {
+	r.mu.Lock()
+	idx := r.current
+	r.mu.Unlock()
+	ch, err := r.llms[idx].Chat(ctx, messages, tools, stream)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(chan engine.TokenOrToolCall)
+	go func() {
+		defer close(out)
+		for tried := 1; ; tried++ {
+			var first engine.TokenOrToolCall
+			var ok bool
+			select {
+			case <-ctx.Done():
+				return
+			case first, ok = <-ch:
+			}
+			if !ok {
+				return
+			}
+			reason, quota := quotaError(first)
+			if !quota || tried >= len(r.llms) {
+				if !forward(ctx, out, first) {
+					return
+				}
+				for item := range ch {
+					if !forward(ctx, out, item) {
+						return
+					}
+				}
+				return
+			}
+			// Let the failed request finish on its own
+			go func(ch <-chan engine.TokenOrToolCall) {
+				for range ch {
+				}
+			}(ch)
+			idx = r.rotate(idx, reason)
+			if ch, err = r.llms[idx].Chat(ctx, messages, tools, stream); err != nil {
+				forward(ctx, out, engine.TokenOrToolCall{Token: err.Error()})
+				return
+			}
+		}
+	}()
+	return out, nil
+}
+
+// rotate moves past the key at idx, unless another request already did, and returns
+// the key to use next.
+func (r *rotatingLLM) rotate(idx int, reason string) int {
+	r.mu.Lock()
+	if r.current != idx {
+		next := r.current
+		r.mu.Unlock()
+		return next
+	}
+	r.current = (idx + 1) % len(r.llms)
+	next := r.current
+	r.mu.Unlock()
+	if r.onRotate != nil {
+		r.onRotate(KeyRotation{Provider: r.provider, From: r.keys[idx].Name, To: r.keys[next].Name, Reason: reason})
+	}
+	return next
+}
+
+func forward(ctx context.Context, out chan<- engine.TokenOrToolCall, item engine.TokenOrToolCall) bool {
+	select {
+	case <-ctx.Done():
+		return false
+	case out <- item:
+		return true
+	}
+}
+
+// apiErrorStatus matches the status adapters put in error tokens, e.g. "API error (429)".
+var apiErrorStatus = regexp.MustCompile(`API error \((\d{3})\)`)
+
+// quotaError reports whether a stream item is an API error that another key may not
+// hit: rate limits (429), exhausted credit (402) and quota errors of other statuses.
+func quotaError(item engine.TokenOrToolCall) (string, bool) {
+	if item.ToolCall != nil || item.Token == "" {
+		return "", false
+	}
+	m := apiErrorStatus.FindStringSubmatch(item.Token)
+	if m == nil {
+		return "", false
+	}
+	lower := strings.ToLower(item.Token)
+	switch {
+	case m[1] == "429":
+		return "rate limit or quota exceeded (429)", true
+	case m[1] == "402":
+		return "out of credits (402)", true
+	case strings.Contains(lower, "insufficient_quota"), strings.Contains(lower, "credit balance is too low"):
+		return "quota exhausted (" + m[1] + ")", true
+	}
+	return "", false
+}
@@ -0,0 +1,102 @@
+package adapter
+
+import (
+	"context"
+	"testing"
+
+	"github.com/loom/loom/internal/engine"
+)
+
+// scriptedLLM answers every request with the same tokens and counts its calls.
+type scriptedLLM struct {
+	tokens []string
+	calls  int
+}
+
+func (s *scriptedLLM) Chat(ctx context.Context, _ []engine.Message, _ []engine.ToolSchema, _ bool) (<-chan engine.TokenOrToolCall, error) {
+	s.calls++
+	ch := make(chan engine.TokenOrToolCall, len(s.tokens))
+	for _, tok := range s.tokens {
+		ch <- engine.TokenOrToolCall{Token: tok}
+	}
+	close(ch)
+	return ch, nil
+}
+
+func collect(t *testing.T, llm engine.LLM) string {
+	t.Helper()
+	ch, err := llm.Chat(context.Background(), nil, nil, true)
+	if err != nil {
+		t.Fatalf("chat: %v", err)
+	}
+	out := ""
+	for item := range ch {
+		out += item.Token
+	}
+	return out
+}
+
+func TestRotatingLLM_SwitchesKeyOnQuotaErrors(t *testing.T) {
+	personal := &scriptedLLM{tokens: []string{`OpenAI API error (429): {"error":{"type":"insufficient_quota"}}`}}
+	team := &scriptedLLM{tokens: []string{"Hello", " there"}}
+	var rotations []KeyRotation
+	r := &rotatingLLM{
+		provider: ProviderOpenAI,
+		keys:     []Key{{Name: "personal"}, {Name: "team"}},
+		llms:     []engine.LLM{personal, team},
+		onRotate: func(k KeyRotation) { rotations = append(rotations, k) },
+	}
+
+	if got := collect(t, r); got != "Hello there" {
+		t.Fatalf("expected the team key's answer, got %q", got)
+	}
+	if len(rotations) != 1 || rotations[0].From != "personal" || rotations[0].To != "team" {
+		t.Fatalf("unexpected rotations %+v", rotations)
+	}
+	// The switch sticks for later requests
+	collect(t, r)
+	if personal.calls != 1 || team.calls != 2 || r.ActiveKey() != "team" {
+		t.Fatalf("expected later requests on the team key, got %d/%d (%s)", personal.calls, team.calls, r.ActiveKey())
+	}
+}
+
+func TestRotatingLLM_StopsAfterEveryKeyFailed(t *testing.T) {
+	a := &scriptedLLM{tokens: []string{"Anthropic API error (429): rate limited"}}
+	b := &scriptedLLM{tokens: []string{"OpenRouter API error (402): insufficient credits"}}
+	r := &rotatingLLM{provider: ProviderOpenRouter, keys: []Key{{Name: "a"}, {Name: "b"}}, llms: []engine.LLM{a, b}}
+	if got := collect(t, r); got != "OpenRouter API error (402): insufficient credits" {
+		t.Fatalf("expected the last key's error, got %q", got)
+	}
+	if a.calls != 1 || b.calls != 1 {
+		t.Fatalf("expected one attempt per key, got %d/%d", a.calls, b.calls)
+	}
+}
+
+func TestQuotaError(t *testing.T) {
+	tests := map[string]bool{
+		"OpenAI API error (429): slow down":                                       true,
+		"OpenAI error: API error (429): slow down":                                true,
+		`Anthropic API error (400): {"message":"Your credit balance is too low"}`: true,
+		"Anthropic API error (401): invalid x-api-key":                            false,
+		"OpenAI API error (500): server error":                                    false,
+		"The endpoint returned (429) when I tried it":                             false,
+	}
+	for tok, want := range tests {
+		if _, got := quotaError(engine.TokenOrToolCall{Token: tok}); got != want {
+			t.Errorf("quotaError(%q) = %v, want %v", tok, got, want)
+		}
+	}
+}
+
+func TestNewWithKeys(t *testing.T) {
+	if _, err := NewWithKeys(Config{Provider: ProviderAnthropic}, []Key{{Name: "empty"}}, nil); err == nil {
+		t.Fatal("expected an error without a usable key")
+	}
+	llm, err := NewWithKeys(Config{Provider: ProviderAnthropic, Model: "m"}, []Key{{Name: "a", APIKey: "k1"}, {Name: "b", APIKey: "k2"}}, nil)
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+	if r, ok := llm.(*rotatingLLM); !ok || r.ActiveKey() != "a" {
+		t.Fatalf("expected a rotating adapter starting with the first key, got %T", llm)
+	}
+}
@@ -7,7 +7,7 @@ import (
 
 // Model represents an LLM model with its provider prefix and ID
 type Model struct {
-	ProviderPrefix string // e.g., "claude", "openai", "ollama"
+	ProviderPrefix string // e.g., "claude", "openai", "gemini", "ollama"
 	ID             string // e.g., "claude-opus-4-20250514", "gpt-4.1", "llama3.1:8b"
 }
 
@@ -48,6 +48,12 @@ func GetProviderFromModel(modelString string) (Provider, string, error) {
 		provider = ProviderOllama
 	case "openrouter":
 		provider = ProviderOpenRouter
+	case "gemini":
+		provider = ProviderGemini
+	case "azure":
+		provider = ProviderAzureOpenAI
+	case "demo":
+		provider = ProviderDemo
 	default:
 		return "", "", fmt.Errorf("unknown provider: %s", model.ProviderPrefix)
 	}
@@ -28,6 +28,10 @@ func TestGetProviderFromModel(t *testing.T) {
 	if err != nil || prov != ProviderOllama || id != "llama3.1:8b" {
 		t.Fatalf("ollama mapping failed: prov=%s id=%s err=%v", prov, id, err)
 	}
+	prov, id, err = GetProviderFromModel("gemini:gemini-2.5-pro")
+	if err != nil || prov != ProviderGemini || id != "gemini-2.5-pro" {
+		t.Fatalf("gemini mapping failed: prov=%s id=%s err=%v", prov, id, err)
+	}
 	if _, _, err := GetProviderFromModel("unknown:foo"); err == nil {
 		t.Fatalf("expected error for unknown provider")
 	}
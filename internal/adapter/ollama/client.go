@@ -2,6 +2,7 @@ package ollama
 
 import (
 	"context"
+	"encoding/json"
 
 	"github.com/loom/loom/internal/adapter/openai"
 	"github.com/loom/loom/internal/engine"
@@ -38,3 +39,9 @@ func (c *Client) Chat(
 	// Delegate to the wrapped OpenAI client
 	return c.openaiClient.Chat(ctx, messages, tools, stream)
 }
+
+// ChatStructured implements engine.StructuredLLM; Ollama's OpenAI-compatible API
+// accepts response_format json_schema.
+func (c *Client) ChatStructured(ctx context.Context, messages []engine.Message, schema engine.StructuredSchema) (json.RawMessage, error) {
+	return c.openaiClient.ChatStructured(ctx, messages, schema)
+}
@@ -0,0 +1,69 @@
+package openai
+
+import (
+	"net/url"
+	"strings"
+)
+
+// DefaultAzureAPIVersion is the Azure OpenAI data plane version used when none is set.
+const DefaultAzureAPIVersion = "2024-10-21"
+
+// AzureConfig routes requests to an Azure OpenAI resource instead of api.openai.com.
+type AzureConfig struct {
+	// Endpoint is the resource endpoint, e.g. "https://contoso.openai.azure.com"
+	Endpoint string
+	// Deployment names the model deployment; Azure picks the model by deployment
+	Deployment string
+	// APIVersion is the api-version query parameter; empty uses DefaultAzureAPIVersion
+	APIVersion string
+}
+
+// Enabled reports whether an Azure resource is configured.
+func (a AzureConfig) Enabled() bool {
+	return strings.TrimSpace(a.Endpoint) != ""
+}
+
+// ChatCompletionsURL returns the deployment's Chat Completions URL.
+func (a AzureConfig) ChatCompletionsURL() string {
+	return a.url("/deployments/" + url.PathEscape(strings.TrimSpace(a.Deployment)) + "/chat/completions")
+}
+
+// ModelsURL returns the URL listing the resource's models, a cheap authenticated request.
+func (a AzureConfig) ModelsURL() string {
+	return a.url("/models")
+}
+
+func (a AzureConfig) url(path string) string {
+	version := strings.TrimSpace(a.APIVersion)
+	if version == "" {
+		version = DefaultAzureAPIVersion
+	}
+	// Endpoints copied from the portal sometimes include the /openai path already
+	base := strings.TrimSuffix(strings.TrimRight(strings.TrimSpace(a.Endpoint), "/"), "/openai")
+	return base + "/openai" + path + "?api-version=" + url.QueryEscape(version)
+}
+
+// WithAzure sends requests to an Azure OpenAI deployment, authenticated with the
+// resource's api-key header; the client's key is the Azure key.
+func (c *Client) WithAzure(azure AzureConfig) *Client {
+	if azure.Deployment == "" {
+		azure.Deployment = c.model
+	}
+	// Reasoning models are recognized by the deployment name, e.g. "o4-mini"
+	c.model = azure.Deployment
+	c.endpoint = azure.ChatCompletionsURL()
+	c.azure = true
+	return c
+}
+
+// headers returns the authentication headers of a request.
+func (c *Client) headers() map[string]string {
+	if c.azure {
+		return map[string]string{"api-key": c.apiKey}
+	}
+	h := map[string]string{"Authorization": "Bearer " + c.apiKey}
+	if c.organization != "" {
+		h["OpenAI-Organization"] = c.organization
+	}
+	return h
+}
@@ -17,10 +17,13 @@ import (
 
 // Client handles interaction with OpenAI APIs.
 type Client struct {
-	apiKey     string
-	model      string
-	endpoint   string
-	httpClient *http.Client
+	apiKey       string
+	organization string
+	model        string
+	endpoint     string
+	httpClient   *http.Client
+	// azure authenticates with an Azure OpenAI api-key instead of a bearer token
+	azure bool
 }
 
 // New creates a new OpenAI client.
@@ -46,6 +49,12 @@ func (c *Client) WithEndpoint(endpoint string) *Client {
 	return c
 }
 
+// WithOrganization bills requests to an OpenAI organization other than the key's default.
+func (c *Client) WithOrganization(org string) *Client {
+	c.organization = strings.TrimSpace(org)
+	return c
+}
+
 // isReasoningModel returns true for OpenAI reasoning models that don't support temperature.
 // Treat all o3*, o4*, and gpt-5* variants as reasoning models.
 func isReasoningModel(model string) bool {
@@ -194,7 +203,9 @@ func (c *Client) attemptChat(ctx context.Context, messages []engine.Message, too
 
 	// Set headers
 	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.apiKey))
+	for k, v := range c.headers() {
+		req.Header.Set(k, v)
+	}
 
 	// Make the request
 	resp, err := c.httpClient.Do(req)
@@ -333,6 +344,11 @@ func (c *Client) handleStreamingResponseWithTracking(ctx context.Context, body i
 				}
 				if tc.Function.Arguments != "" {
 					p.args += tc.Function.Arguments
+					select {
+					case <-ctx.Done():
+						return
+					case ch <- engine.TokenOrToolCall{ArgsDelta: &engine.ToolArgsDelta{ID: p.id, Name: p.name, Index: idx, Delta: tc.Function.Arguments}}:
+					}
 				}
 			}
 		}
@@ -544,7 +560,7 @@ func convertMessages(messages []engine.Message) []map[string]interface{} {
 	result := make([]map[string]interface{}, 0, len(messages))
 	for _, msg := range messages {
 		switch msg.Role {
-		case "system", "user":
+		case "system", "developer", "user":
 			result = append(result, map[string]interface{}{
 				"role":    msg.Role,
 				"content": msg.Content,
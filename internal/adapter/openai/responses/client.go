@@ -15,6 +15,7 @@ import (
 	"time"
 
 	"github.com/loom/loom/internal/engine"
+	"github.com/loom/loom/internal/textutil"
 )
 
 // ResponseStatus represents the lifecycle state of a response
@@ -36,11 +37,12 @@ type ResponseTracker struct {
 
 // Client implements the OpenAI Responses API as an engine.LLM.
 type Client struct {
-	apiKey     string
-	model      string
-	endpoint   string
-	httpClient *http.Client
-	debug      bool
+	apiKey       string
+	organization string
+	model        string
+	endpoint     string
+	httpClient   *http.Client
+	debug        bool
 
 	// Response lifecycle tracking
 	mu           sync.RWMutex
@@ -80,6 +82,12 @@ func (c *Client) WithEndpoint(endpoint string) *Client {
 	return c
 }
 
+// WithOrganization bills requests to an OpenAI organization other than the key's default.
+func (c *Client) WithOrganization(org string) *Client {
+	c.organization = strings.TrimSpace(org)
+	return c
+}
+
 // WithDebug enables or disables debug logging to stdout.
 func (c *Client) WithDebug(debug bool) *Client {
 	c.debug = debug
@@ -198,6 +206,9 @@ func (c *Client) makeRequest(ctx context.Context, req responsesRequest) (*http.R
 	}
 	httpReq.Header.Set("Content-Type", "application/json")
 	httpReq.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.apiKey))
+	if c.organization != "" {
+		httpReq.Header.Set("OpenAI-Organization", c.organization)
+	}
 
 	c.debugf("POST %s", c.endpoint)
 	if req.PreviousID != "" {
@@ -242,6 +253,9 @@ func (c *Client) makeRequest(ctx context.Context, req responsesRequest) (*http.R
 		}
 		retryHttpReq.Header.Set("Content-Type", "application/json")
 		retryHttpReq.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.apiKey))
+		if c.organization != "" {
+			retryHttpReq.Header.Set("OpenAI-Organization", c.organization)
+		}
 
 		c.debugf("Retrying POST %s without previous_response_id", c.endpoint)
 		return c.httpClient.Do(retryHttpReq)
@@ -315,7 +329,7 @@ func toResponsesInput(msgs []engine.Message) (instructions string, items []map[s
 	var systems []string
 	for _, m := range msgs {
 		switch m.Role {
-		case "system":
+		case "system", "developer":
 			systems = append(systems, m.Content)
 		case "user":
 			items = append(items, map[string]interface{}{
@@ -474,7 +488,7 @@ func (c *Client) attemptChat(ctx context.Context, messages []engine.Message, too
 		// Truncate to avoid excessive logs
 		dump := string(nonStreamBody)
 		if len(dump) > 4000 {
-			dump = dump[:4000] + "…(truncated)"
+			dump = textutil.ClipBytes(dump, 4000) + "…(truncated)"
 		}
 		c.debugf("Non-streaming body: %s", dump)
 	}
@@ -742,12 +756,17 @@ func (c *Client) handleResponsesStreamWithTracking(ctx context.Context, r io.Rea
 			if pc.CallID == "" && d.CallID != "" {
 				pc.CallID = d.CallID
 			}
-			if d.Delta != "" {
-				pc.Args += d.Delta
-			}
 			if d.OutputIndex != 0 {
 				pc.OutputIndex = d.OutputIndex
 			}
+			if d.Delta != "" {
+				pc.Args += d.Delta
+				select {
+				case <-ctx.Done():
+					return
+				case out <- engine.TokenOrToolCall{ArgsDelta: &engine.ToolArgsDelta{ID: pc.CallID, Name: pc.Name, Index: pc.OutputIndex, Delta: d.Delta}}:
+				}
+			}
 			if c.debug {
 				c.debugf("function_call.arguments.delta: item_id=%s call_id=%s name=%q args+=%dB idx=%d", id, pc.CallID, pc.Name, len(d.Delta), pc.OutputIndex)
 			}
@@ -1085,16 +1104,7 @@ func convertTools(tools []engine.ToolSchema) []map[string]interface{} {
 	return result
 }
 
-// truncate returns a shortened version of s with an ellipsis if it exceeds n runes.
+// truncate returns a shortened version of s with an ellipsis if it exceeds n columns.
 func truncate(s string, n int) string {
-	if n <= 0 {
-		return ""
-	}
-	if len(s) <= n {
-		return s
-	}
-	if n <= 1 {
-		return "…"
-	}
-	return s[:n-1] + "…"
+	return textutil.TruncateWidth(s, n)
 }
@@ -0,0 +1,65 @@
+package responses
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/loom/loom/internal/adapter/common"
+	"github.com/loom/loom/internal/engine"
+)
+
+// ChatStructured implements engine.StructuredLLM with a json_schema text format.
+// The request is not stored and does not continue the conversation's response chain.
+func (c *Client) ChatStructured(ctx context.Context, messages []engine.Message, schema engine.StructuredSchema) (json.RawMessage, error) {
+	body := map[string]interface{}{
+		"model": c.model,
+		"input": common.StructuredMessages(messages),
+		"store": false,
+		"text": map[string]interface{}{
+			"format": map[string]interface{}{
+				"type":        "json_schema",
+				"name":        schema.Name,
+				"description": schema.Description,
+				"schema":      schema.Schema,
+				"strict":      true,
+			},
+		},
+	}
+	headers := map[string]string{"Authorization": fmt.Sprintf("Bearer %s", c.apiKey)}
+	if c.organization != "" {
+		headers["OpenAI-Organization"] = c.organization
+	}
+	data, err := common.PostJSON(ctx, c.httpClient, c.endpoint, headers, body)
+	if err != nil {
+		return nil, fmt.Errorf("OpenAI %w", err)
+	}
+	var resp struct {
+		Output []struct {
+			Type    string `json:"type"`
+			Content []struct {
+				Type    string `json:"type"`
+				Text    string `json:"text"`
+				Refusal string `json:"refusal"`
+			} `json:"content"`
+		} `json:"output"`
+	}
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil, fmt.Errorf("unexpected response: %v", err)
+	}
+	for _, item := range resp.Output {
+		if item.Type != "message" {
+			continue
+		}
+		for _, part := range item.Content {
+			if part.Refusal != "" {
+				return nil, fmt.Errorf("the model refused: %s", part.Refusal)
+			}
+			if part.Type == "output_text" && json.Valid([]byte(part.Text)) {
+				return json.RawMessage(part.Text), nil
+			}
+		}
+	}
+	return nil, errors.New("the model did not return JSON")
+}
@@ -0,0 +1,27 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/loom/loom/internal/adapter/common"
+	"github.com/loom/loom/internal/engine"
+)
+
+// ChatStructured implements engine.StructuredLLM with response_format json_schema.
+func (c *Client) ChatStructured(ctx context.Context, messages []engine.Message, schema engine.StructuredSchema) (json.RawMessage, error) {
+	body := map[string]interface{}{
+		"model":           c.model,
+		"messages":        common.StructuredMessages(messages),
+		"response_format": common.JSONSchemaResponseFormat(schema),
+	}
+	if !isReasoningModel(c.model) {
+		body["temperature"] = 0.2
+	}
+	data, err := common.PostJSON(ctx, c.httpClient, c.endpoint, c.headers(), body)
+	if err != nil {
+		return nil, fmt.Errorf("OpenAI %w", err)
+	}
+	return common.ChatCompletionJSON(data)
+}
@@ -369,6 +369,11 @@ func (c *Client) handleStreamingResponseWithTracking(ctx context.Context, body i
 				}
 				if tc.Function.Arguments != "" {
 					p.args += tc.Function.Arguments
+					select {
+					case <-ctx.Done():
+						return
+					case ch <- engine.TokenOrToolCall{ArgsDelta: &engine.ToolArgsDelta{ID: p.id, Name: p.name, Index: idx, Delta: tc.Function.Arguments}}:
+					}
 				}
 			}
 		}
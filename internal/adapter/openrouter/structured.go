@@ -0,0 +1,32 @@
+package openrouter
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/loom/loom/internal/adapter/common"
+	"github.com/loom/loom/internal/engine"
+)
+
+// ChatStructured implements engine.StructuredLLM with response_format json_schema.
+// Requests are only routed to providers that support it.
+func (c *Client) ChatStructured(ctx context.Context, messages []engine.Message, schema engine.StructuredSchema) (json.RawMessage, error) {
+	body := map[string]interface{}{
+		"model":           c.model,
+		"messages":        common.StructuredMessages(messages),
+		"response_format": common.JSONSchemaResponseFormat(schema),
+		"temperature":     0.2,
+		"provider":        map[string]interface{}{"require_parameters": true},
+	}
+	headers := map[string]string{
+		"Authorization": fmt.Sprintf("Bearer %s", c.apiKey),
+		"HTTP-Referer":  "https://loom.dev",
+		"X-Title":       "Loom",
+	}
+	data, err := common.PostJSON(ctx, c.httpClient, c.endpoint, headers, body)
+	if err != nil {
+		return nil, fmt.Errorf("OpenRouter %w", err)
+	}
+	return common.ChatCompletionJSON(data)
+}
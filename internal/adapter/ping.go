@@ -0,0 +1,155 @@
+package adapter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// pingURLs are cheap authenticated endpoints used to validate credentials.
+var pingURLs = map[Provider]string{
+	ProviderOpenAI:     "https://api.openai.com/v1/models",
+	ProviderAnthropic:  "https://api.anthropic.com/v1/models",
+	ProviderOpenRouter: "https://openrouter.ai/api/v1/key",
+	ProviderGemini:     "https://generativelanguage.googleapis.com/v1beta/models",
+}
+
+// ErrInvalidAPIKey is returned by Ping when the provider rejects the credentials.
+var ErrInvalidAPIKey = errors.New("API key rejected by provider")
+
+// PingResult is the outcome of validating one provider's credentials.
+type PingResult struct {
+	Provider  Provider `json:"provider"`
+	OK        bool     `json:"ok"`
+	LatencyMs int64    `json:"latency_ms"`
+	Error     string   `json:"error,omitempty"`
+}
+
+// Ping checks that config's credentials are accepted by making one lightweight
+// request, and reports the round-trip latency. For Ollama it checks that the local
+// server answers at config.Endpoint.
+func Ping(ctx context.Context, config Config) PingResult {
+	res := PingResult{Provider: config.Provider}
+	url, header, err := pingRequest(config)
+	if err != nil {
+		res.Error = err.Error()
+		return res
+	}
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		res.Error = err.Error()
+		return res
+	}
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+	start := time.Now()
+	resp, err := http.DefaultClient.Do(req)
+	res.LatencyMs = time.Since(start).Milliseconds()
+	if err != nil {
+		res.Error = err.Error()
+		return res
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		res.Error = ErrInvalidAPIKey.Error()
+	case resp.StatusCode >= 300:
+		res.Error = fmt.Sprintf("unexpected status %s", resp.Status)
+	default:
+		res.OK = true
+	}
+	return res
+}
+
+func pingRequest(config Config) (string, map[string]string, error) {
+	key := strings.TrimSpace(config.APIKey)
+	switch config.Provider {
+	case ProviderOpenAI:
+		if key == "" {
+			return "", nil, errors.New("no API key entered")
+		}
+		return pingURLs[ProviderOpenAI], map[string]string{"Authorization": "Bearer " + key}, nil
+	case ProviderAnthropic:
+		if key == "" {
+			return "", nil, errors.New("no API key entered")
+		}
+		return pingURLs[ProviderAnthropic], map[string]string{"x-api-key": key, "anthropic-version": "2023-06-01"}, nil
+	case ProviderOpenRouter:
+		if key == "" {
+			return "", nil, errors.New("no API key entered")
+		}
+		return pingURLs[ProviderOpenRouter], map[string]string{"Authorization": "Bearer " + key}, nil
+	case ProviderAzureOpenAI:
+		if key == "" {
+			return "", nil, errors.New("no API key entered")
+		}
+		if !config.Azure.Enabled() {
+			return "", nil, errors.New("no Azure OpenAI endpoint entered")
+		}
+		return config.Azure.ModelsURL(), map[string]string{"api-key": key}, nil
+	case ProviderGemini:
+		if key == "" {
+			return "", nil, errors.New("no API key entered")
+		}
+		return pingURLs[ProviderGemini], map[string]string{"x-goog-api-key": key}, nil
+	case ProviderOllama:
+		base := strings.TrimSpace(config.Endpoint)
+		if base == "" {
+			base = "http://localhost:11434"
+		}
+		// The chat endpoint is configured; the server's model list lives at /api/tags
+		if i := strings.Index(base, "/v1/"); i >= 0 {
+			base = base[:i]
+		}
+		return strings.TrimRight(base, "/") + "/api/tags", nil, nil
+	default:
+		return "", nil, fmt.Errorf("cannot validate provider %q", config.Provider)
+	}
+}
+
+// slowPingMs is the latency above which a provider's faster model is suggested.
+const slowPingMs = 800
+
+// suggestedModels lists each provider's {fast, flagship} default model.
+var suggestedModels = map[Provider][2]string{
+	ProviderAnthropic:  {"claude:claude-3-5-haiku-20241022", "claude:claude-sonnet-4-20250514"},
+	ProviderOpenAI:     {"openai:gpt-4o-mini", "openai:gpt-4o"},
+	ProviderOpenRouter: {"openrouter:openai/gpt-4o-mini", "openrouter:anthropic/claude-3.5-sonnet"},
+	ProviderGemini:     {"gemini:gemini-2.5-flash", "gemini:gemini-2.5-pro"},
+	ProviderOllama:     {"ollama:llama3.1:8b", "ollama:llama3.1:8b"},
+}
+
+// SuggestModel picks a default model from validated providers: the one that answered
+// fastest, preferring hosted providers over a local Ollama server, and its faster
+// model when even that provider responded slowly. It returns "" when none validated.
+func SuggestModel(results []PingResult) string {
+	var ok []PingResult
+	for _, r := range results {
+		if _, known := suggestedModels[r.Provider]; r.OK && known {
+			ok = append(ok, r)
+		}
+	}
+	if len(ok) == 0 {
+		return ""
+	}
+	sort.SliceStable(ok, func(i, j int) bool {
+		if (ok[i].Provider == ProviderOllama) != (ok[j].Provider == ProviderOllama) {
+			return ok[j].Provider == ProviderOllama
+		}
+		return ok[i].LatencyMs < ok[j].LatencyMs
+	})
+	models := suggestedModels[ok[0].Provider]
+	if ok[0].LatencyMs > slowPingMs {
+		return models[0]
+	}
+	return models[1]
+}
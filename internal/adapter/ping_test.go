@@ -0,0 +1,72 @@
+package adapter
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPing_ValidatesKey(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer good" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte(`{"data":[]}`))
+	}))
+	defer srv.Close()
+	orig := pingURLs[ProviderOpenAI]
+	pingURLs[ProviderOpenAI] = srv.URL
+	defer func() { pingURLs[ProviderOpenAI] = orig }()
+
+	if res := Ping(context.Background(), Config{Provider: ProviderOpenAI, APIKey: "good"}); !res.OK {
+		t.Fatalf("expected valid key, got %+v", res)
+	}
+	res := Ping(context.Background(), Config{Provider: ProviderOpenAI, APIKey: "bad"})
+	if res.OK || res.Error != ErrInvalidAPIKey.Error() {
+		t.Fatalf("expected rejected key, got %+v", res)
+	}
+	if res := Ping(context.Background(), Config{Provider: ProviderOpenAI}); res.OK || res.Error == "" {
+		t.Fatalf("expected missing key error, got %+v", res)
+	}
+}
+
+func TestPing_OllamaUsesServerRoot(t *testing.T) {
+	var path string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		_, _ = w.Write([]byte(`{"models":[]}`))
+	}))
+	defer srv.Close()
+
+	res := Ping(context.Background(), Config{Provider: ProviderOllama, Endpoint: srv.URL + "/v1/chat/completions"})
+	if !res.OK || path != "/api/tags" {
+		t.Fatalf("expected /api/tags to answer, got %+v (path %q)", res, path)
+	}
+}
+
+func TestSuggestModel(t *testing.T) {
+	cases := []struct {
+		name    string
+		results []PingResult
+		want    string
+	}{
+		{"none valid", []PingResult{{Provider: ProviderOpenAI, Error: "bad"}}, ""},
+		{"fastest provider flagship", []PingResult{
+			{Provider: ProviderOpenAI, OK: true, LatencyMs: 400},
+			{Provider: ProviderAnthropic, OK: true, LatencyMs: 200},
+		}, "claude:claude-sonnet-4-20250514"},
+		{"slow provider gets fast model", []PingResult{{Provider: ProviderOpenAI, OK: true, LatencyMs: 1500}}, "openai:gpt-4o-mini"},
+		{"hosted preferred over local", []PingResult{
+			{Provider: ProviderOllama, OK: true, LatencyMs: 5},
+			{Provider: ProviderOpenRouter, OK: true, LatencyMs: 300},
+		}, "openrouter:anthropic/claude-3.5-sonnet"},
+		{"local only", []PingResult{{Provider: ProviderOllama, OK: true, LatencyMs: 5}}, "ollama:llama3.1:8b"},
+	}
+	for _, tc := range cases {
+		if got := SuggestModel(tc.results); got != tc.want {
+			t.Errorf("%s: SuggestModel = %q, want %q", tc.name, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package adapter
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/loom/loom/internal/adapter/anthropic"
+	"github.com/loom/loom/internal/adapter/openai"
+	"github.com/loom/loom/internal/engine"
+)
+
+var testSchema = engine.StructuredSchema{
+	Name: "report",
+	Schema: map[string]interface{}{
+		"type":                 "object",
+		"properties":           map[string]interface{}{"title": map[string]interface{}{"type": "string"}},
+		"required":             []string{"title"},
+		"additionalProperties": false,
+	},
+}
+
+func TestChatStructured_OpenAIUsesJSONSchemaResponseFormat(t *testing.T) {
+	var req map[string]interface{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		_, _ = w.Write([]byte(`{"choices":[{"message":{"content":"{\"title\":\"Fix login\"}"}}]}`))
+	}))
+	defer srv.Close()
+
+	out, err := openai.New("key", "gpt-4.1").WithEndpoint(srv.URL).ChatStructured(context.Background(), []engine.Message{{Role: "user", Content: "hi"}}, testSchema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != `{"title":"Fix login"}` {
+		t.Fatalf("unexpected output %s", out)
+	}
+	format, _ := req["response_format"].(map[string]interface{})
+	spec, _ := format["json_schema"].(map[string]interface{})
+	if format["type"] != "json_schema" || spec["name"] != "report" || spec["strict"] != true {
+		t.Fatalf("unexpected response_format %v", req["response_format"])
+	}
+}
+
+func TestChatStructured_AnthropicForcesTool(t *testing.T) {
+	var req map[string]interface{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		_, _ = w.Write([]byte(`{"content":[{"type":"text","text":"Sure"},{"type":"tool_use","name":"report","input":{"title":"Fix login"}}]}`))
+	}))
+	defer srv.Close()
+
+	out, err := anthropic.New("key", "claude-sonnet-4-20250514").WithEndpoint(srv.URL).ChatStructured(context.Background(), []engine.Message{{Role: "system", Content: "Be brief."}, {Role: "user", Content: "hi"}}, testSchema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != `{"title":"Fix login"}` {
+		t.Fatalf("unexpected output %s", out)
+	}
+	choice, _ := req["tool_choice"].(map[string]interface{})
+	if choice["type"] != "tool" || choice["name"] != "report" || req["system"] != "Be brief." {
+		t.Fatalf("unexpected request %v", req)
+	}
+}
+
+func TestChatStructured_ErrorsAreReturned(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"error":"response_format not supported"}`))
+	}))
+	defer srv.Close()
+	if _, err := openai.New("key", "gpt-4.1").WithEndpoint(srv.URL).ChatStructured(context.Background(), nil, testSchema); err == nil {
+		t.Fatal("expected the API error to be returned")
+	}
+}
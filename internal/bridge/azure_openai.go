@@ -0,0 +1,68 @@
+package bridge
+
+import (
+	"strings"
+
+	"github.com/loom/loom/internal/adapter"
+	"github.com/loom/loom/internal/adapter/openai"
+	"github.com/loom/loom/internal/config"
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// GetAzureOpenAISettings returns the Azure OpenAI resource behind "azure:<deployment>"
+// models.
+func (a *App) GetAzureOpenAISettings() config.AzureOpenAI {
+	a.ensureSettingsLoaded()
+	s := a.settings.AzureOpenAI
+	if s.Deployments == nil {
+		s.Deployments = []string{}
+	}
+	return s
+}
+
+// SetAzureOpenAISettings validates and saves the Azure OpenAI resource. Its
+// deployments appear in the model selector as "azure:<deployment>"; when an Azure
+// model is selected, the adapter is recreated right away. Returns an error message,
+// or "" on success.
+func (a *App) SetAzureOpenAISettings(c config.AzureOpenAI) string {
+	a.ensureSettingsLoaded()
+	c.Endpoint = strings.TrimSpace(c.Endpoint)
+	c.APIKey = strings.TrimSpace(c.APIKey)
+	c.APIVersion = strings.TrimSpace(c.APIVersion)
+	var deployments []string
+	for _, d := range c.Deployments {
+		if d = strings.TrimSpace(d); d != "" {
+			deployments = append(deployments, d)
+		}
+	}
+	c.Deployments = deployments
+	if err := c.Validate(); err != nil {
+		return err.Error()
+	}
+	s := a.settings
+	s.AzureOpenAI = c
+	if err := config.Save(s); err != nil {
+		return err.Error()
+	}
+	a.settings = s
+	// Record the resource, never the key
+	a.audit("settings", map[string]interface{}{
+		"azure_openai_endpoint": c.Endpoint,
+		"deployments":           c.Deployments,
+		"api_version":           c.APIVersion,
+	})
+	if a.config.Provider == adapter.ProviderAzureOpenAI {
+		a.config.Azure = azureConfig(c)
+		a.reloadLLM()
+	}
+	if a.ctx != nil {
+		runtime.EventsEmit(a.ctx, "settings:azure_openai", a.GetAzureOpenAISettings())
+	}
+	return ""
+}
+
+// azureConfig converts the saved resource to the adapter's configuration; the
+// deployment comes from the selected model.
+func azureConfig(c config.AzureOpenAI) openai.AzureConfig {
+	return openai.AzureConfig{Endpoint: c.Endpoint, APIVersion: c.APIVersion}
+}
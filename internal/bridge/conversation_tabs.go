@@ -0,0 +1,213 @@
+package bridge
+
+import (
+	"sync"
+
+	"github.com/loom/loom/internal/engine"
+	"github.com/loom/loom/internal/memory"
+	"github.com/loom/loom/internal/tool"
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// conversationTab is a conversation running next to the main one in the workspace.
+// It is the UI bridge of its forked engine: events about the conversation are emitted
+// as "conversation:event" tagged with its id, so each tab follows its own stream.
+// Workspace-wide events (billing, annotations, run summaries) go out as usual.
+type conversationTab struct {
+	*App
+	id   string
+	fork *engine.Engine
+	mu   sync.Mutex
+	busy bool
+}
+
+// ConversationTabInfo describes an open conversation tab.
+type ConversationTabInfo struct {
+	ID    string `json:"id"`
+	Title string `json:"title"`
+	Busy  bool   `json:"busy"`
+}
+
+// emit sends a conversation event; data is the payload of the main view's event of the
+// same name.
+func (t *conversationTab) emit(event string, data interface{}) {
+	if t.ctx != nil {
+		runtime.EventsEmit(t.ctx, "conversation:event", map[string]interface{}{
+			"conversation_id": t.id,
+			"event":           event,
+			"data":            data,
+		})
+	}
+}
+
+func (t *conversationTab) SendChat(role, text string) {
+	t.emit("chat:new", map[string]interface{}{"role": role, "content": text})
+}
+
+func (t *conversationTab) EmitAssistant(text string) {
+	t.emit("assistant-msg", text)
+}
+
+func (t *conversationTab) EmitReasoning(text string, done bool) {
+	t.emit("assistant-reasoning", map[string]any{"text": text, "done": done})
+}
+
+func (t *conversationTab) PromptApproval(actionID, summary, diff string) bool {
+	t.emit("task:prompt", map[string]string{"id": actionID, "summary": summary, "diff": diff})
+	return false
+}
+
+func (t *conversationTab) PromptChoice(actionID, question string, options []string) int {
+	t.emit("user:choice", map[string]interface{}{"id": actionID, "question": question, "options": options, "type": "choice"})
+	return -1
+}
+
+func (t *conversationTab) PromptQuestion(actionID string, question tool.AskUserArgs) {
+	options := question.Options
+	if options == nil {
+		options = []string{}
+	}
+	t.emit("user:question", map[string]interface{}{
+		"id":        actionID,
+		"question":  question.Question,
+		"options":   options,
+		"free_text": question.FreeText(),
+		"context":   question.Context,
+		"type":      "question",
+	})
+}
+
+func (t *conversationTab) SetBusy(isBusy bool) {
+	t.mu.Lock()
+	t.busy = isBusy
+	t.mu.Unlock()
+	t.emit("system:busy", isBusy)
+}
+
+func (t *conversationTab) EmitStatus(status engine.Status) {
+	t.emit("engine:status", status)
+}
+
+func (t *conversationTab) EmitToolPreview(preview engine.ToolPreview) {
+	t.emit("tool:preview", preview)
+}
+
+func (t *conversationTab) EmitChangedFiles(files []engine.ChangedFile) {
+	t.emit("run:files_changed", map[string]interface{}{"files": files})
+}
+
+func (t *conversationTab) EmitNamedCheckpoint(c memory.NamedCheckpoint) {
+	t.emit("timeline:checkpoint", c)
+}
+
+// OpenConversationTab runs a conversation next to the current one, e.g. a refactor in
+// one tab while another investigates a bug. Each tab has its own context and history,
+// shares the workspace's tools and symbol index, and applies its edits one at a time
+// with the other tabs' through the edit queue. An empty id opens a new conversation; a
+// stored one is replayed to the tab's stream. Returns: { tab, tabs } or { error }.
+func (a *App) OpenConversationTab(id string) map[string]interface{} {
+	if a.engine == nil {
+		return map[string]interface{}{"error": "engine not initialized"}
+	}
+	t := &conversationTab{App: a}
+	fork, err := a.engine.Fork(id, t)
+	if err != nil {
+		return map[string]interface{}{"error": err.Error()}
+	}
+	t.id, t.fork = fork.CurrentConversationID(), fork
+	a.tabsMu.Lock()
+	if a.tabs == nil {
+		a.tabs = map[string]*conversationTab{}
+	}
+	a.tabs[t.id] = t
+	a.tabsMu.Unlock()
+	if id != "" {
+		if msgs, err := fork.GetConversation(id); err == nil {
+			replayMessages(t, msgs)
+		}
+	}
+	a.emitConversationTabs()
+	return map[string]interface{}{"tab": a.tabInfo(t), "tabs": a.GetConversationTabs()}
+}
+
+// GetConversationTabs returns the conversations open in tabs next to the current one.
+func (a *App) GetConversationTabs() []ConversationTabInfo {
+	out := []ConversationTabInfo{}
+	if a.engine == nil {
+		return out
+	}
+	for _, id := range a.engine.Forks() {
+		if t := a.tab(id); t != nil {
+			out = append(out, a.tabInfo(t))
+		}
+	}
+	return out
+}
+
+// SendConversationMessage sends a user message to a tab's conversation. Returns an
+// error message or "".
+func (a *App) SendConversationMessage(id, message string) string {
+	t := a.tab(id)
+	if t == nil {
+		return "conversation is not open in a tab"
+	}
+	t.fork.Enqueue(message)
+	return ""
+}
+
+// StopConversation cancels the running request of a tab's conversation.
+func (a *App) StopConversation(id string) {
+	if t := a.tab(id); t != nil {
+		t.fork.Stop()
+		t.SetBusy(false)
+	}
+}
+
+// CloseConversationTab stops a tab's conversation and closes the tab. The conversation
+// stays in the history. Returns an error message or "".
+func (a *App) CloseConversationTab(id string) string {
+	if a.engine == nil {
+		return "engine not initialized"
+	}
+	if err := a.engine.CloseFork(id); err != nil {
+		return err.Error()
+	}
+	a.tabsMu.Lock()
+	delete(a.tabs, id)
+	a.tabsMu.Unlock()
+	a.emitConversationTabs()
+	return ""
+}
+
+// closeConversationTabs closes every tab, e.g. when the workspace changes.
+func (a *App) closeConversationTabs() {
+	if a.engine == nil {
+		return
+	}
+	if ids := a.engine.CloseForks(); len(ids) == 0 {
+		return
+	}
+	a.tabsMu.Lock()
+	a.tabs = nil
+	a.tabsMu.Unlock()
+	a.emitConversationTabs()
+}
+
+func (a *App) tab(id string) *conversationTab {
+	a.tabsMu.Lock()
+	defer a.tabsMu.Unlock()
+	return a.tabs[id]
+}
+
+func (a *App) tabInfo(t *conversationTab) ConversationTabInfo {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return ConversationTabInfo{ID: t.id, Title: a.engine.ConversationTitle(t.id), Busy: t.busy}
+}
+
+// emitConversationTabs tells the UI that tabs were opened or closed.
+func (a *App) emitConversationTabs() {
+	if a.ctx != nil {
+		runtime.EventsEmit(a.ctx, "conversation:tabs", a.GetConversationTabs())
+	}
+}
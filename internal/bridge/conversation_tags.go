@@ -0,0 +1,55 @@
+package bridge
+
+import (
+	"time"
+
+	"github.com/loom/loom/internal/memory"
+)
+
+// FindConversations returns the conversations matching a filter of tag, task type,
+// package and text, in the same shape as GetConversations.
+func (a *App) FindConversations(filter memory.ConversationFilter) map[string]interface{} {
+	result := map[string]interface{}{
+		"current_id":    "",
+		"conversations": []map[string]interface{}{},
+	}
+	if a.engine == nil {
+		return result
+	}
+	result["current_id"] = a.engine.CurrentConversationID()
+	summaries, err := a.engine.FindConversations(filter)
+	if err != nil {
+		result["error"] = err.Error()
+		return result
+	}
+	result["conversations"] = conversationEntries(summaries)
+	return result
+}
+
+// GetConversationTags returns the tags in use across the workspace's conversations
+// with their counts, most used first, for the session list's filter.
+func (a *App) GetConversationTags() []memory.TagCount {
+	out := []memory.TagCount{}
+	if a.engine == nil {
+		return out
+	}
+	counts, err := a.engine.ConversationTagCounts()
+	if err != nil {
+		return out
+	}
+	return append(out, counts...)
+}
+
+// conversationEntries converts summaries to session list entries.
+func conversationEntries(summaries []memory.ConversationSummary) []map[string]interface{} {
+	list := make([]map[string]interface{}, 0, len(summaries))
+	for _, s := range summaries {
+		list = append(list, map[string]interface{}{
+			"id":         s.ID,
+			"title":      s.Title,
+			"updated_at": s.UpdatedAt.Format(time.RFC3339),
+			"tags":       s.Tags,
+		})
+	}
+	return list
+}
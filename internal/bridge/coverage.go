@@ -0,0 +1,27 @@
+package bridge
+
+import (
+	"github.com/loom/loom/internal/engine"
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// GetCoverageMap returns the covered and uncovered lines of the files changed in the
+// current conversation, from the workspace's newest coverage report, for overlaying
+// in the diff view.
+func (a *App) GetCoverageMap() map[string]interface{} {
+	if a.engine == nil {
+		return map[string]interface{}{"error": "engine not initialized"}
+	}
+	m, err := a.engine.CoverageMap()
+	if err != nil {
+		return map[string]interface{}{"error": err.Error()}
+	}
+	return map[string]interface{}{"report": m.Report, "format": m.Format, "updated_at": m.UpdatedAt, "files": m.Files}
+}
+
+// EmitCoverage tells the UI that a test run wrote a new coverage report.
+func (a *App) EmitCoverage(m *engine.CoverageMap) {
+	if a.ctx != nil {
+		runtime.EventsEmit(a.ctx, "coverage:updated", m)
+	}
+}
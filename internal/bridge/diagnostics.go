@@ -0,0 +1,288 @@
+package bridge
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/loom/loom/internal/adapter"
+	"github.com/loom/loom/internal/engine"
+	"github.com/loom/loom/internal/indexer"
+	"github.com/loom/loom/internal/mcp"
+	"github.com/loom/loom/internal/symbols"
+)
+
+const (
+	// diagnosticsPingTimeout bounds the provider round-trip made for a health report
+	diagnosticsPingTimeout = 5 * time.Second
+	// staleIndexAge is when the symbol index is reported as out of date
+	staleIndexAge = 24 * time.Hour
+	// recentErrorWindow is how far back engine errors turn the errors check into a warning
+	recentErrorWindow = time.Hour
+)
+
+// HealthCheck is one line of the health summary.
+type HealthCheck struct {
+	Name   string `json:"name"`
+	Status string `json:"status"` // "ok", "warning" or "error"
+	Detail string `json:"detail"`
+}
+
+// AdapterDiagnostics describes the configured model provider.
+type AdapterDiagnostics struct {
+	Provider string             `json:"provider"`
+	Model    string             `json:"model"`
+	Ready    bool               `json:"ready"`
+	Ping     adapter.PingResult `json:"ping"`
+}
+
+// IndexDiagnostics describes the symbol index.
+type IndexDiagnostics struct {
+	Available bool      `json:"available"`
+	Files     int       `json:"files"`
+	Symbols   int       `json:"symbols"`
+	IndexedAt time.Time `json:"indexed_at,omitempty"`
+	AgeSec    int64     `json:"age_sec,omitempty"`
+	// Watch is how the index notices changed files
+	Watch *symbols.WatchStatus `json:"watch,omitempty"`
+}
+
+// Diagnostics aggregates the engine's health for the Health panel and `loom doctor`.
+type Diagnostics struct {
+	GeneratedAt  time.Time             `json:"generated_at"`
+	Workspace    string                `json:"workspace"`
+	Adapter      AdapterDiagnostics    `json:"adapter"`
+	Index        IndexDiagnostics      `json:"index"`
+	Ripgrep      indexer.RipgrepBinary `json:"ripgrep"`
+	MCP          []mcp.ServerStatus    `json:"mcp"`
+	Status       engine.Status         `json:"status"`
+	Queues       engine.Queues         `json:"queues"`
+	RecentErrors []engine.EngineError  `json:"recent_errors"`
+	Checks       []HealthCheck         `json:"checks"`
+}
+
+// GetDiagnostics collects adapter status, index freshness, MCP connectivity, queue
+// depths and recent errors, and summarizes them as health checks. The provider is
+// pinged once, so the call may take up to a few seconds.
+func (a *App) GetDiagnostics() Diagnostics {
+	d := Diagnostics{GeneratedAt: time.Now(), MCP: []mcp.ServerStatus{}, RecentErrors: []engine.EngineError{}}
+
+	cfg := a.config
+	if key := a.apiKeyForProvider(cfg.Provider); key != "" {
+		cfg.APIKey = key
+	}
+	d.Adapter = AdapterDiagnostics{Provider: string(cfg.Provider), Model: cfg.Model}
+	if a.engine != nil {
+		d.Workspace = a.engine.Workspace()
+		if label := a.engine.GetModelLabel(); label != "" {
+			d.Adapter.Model = label
+		}
+		d.Status = a.engine.Status()
+		d.Queues = a.engine.Queues()
+		if errs := a.engine.RecentErrors(); len(errs) > 0 {
+			d.RecentErrors = errs
+		}
+	}
+	if cfg.Provider != "" {
+		ctx, cancel := context.WithTimeout(context.Background(), diagnosticsPingTimeout)
+		d.Adapter.Ping = adapter.Ping(ctx, cfg)
+		cancel()
+		d.Adapter.Ready = d.Adapter.Ping.OK
+	}
+
+	if a.symbolsSvc != nil {
+		stats := a.GetIndexStats()
+		d.Index = IndexDiagnostics{Available: true, Files: stats.Files, Symbols: stats.Symbols, IndexedAt: stats.IndexedAt, Watch: stats.Watch}
+		if !stats.IndexedAt.IsZero() {
+			d.Index.AgeSec = int64(d.GeneratedAt.Sub(stats.IndexedAt).Seconds())
+		}
+	}
+
+	d.Ripgrep = indexer.ResolveRipgrep()
+
+	if a.mcpManager != nil {
+		d.MCP = a.mcpManager.Status()
+	}
+
+	d.Checks = healthChecks(d)
+	return d
+}
+
+// healthChecks turns the raw diagnostics into one check per subsystem.
+func healthChecks(d Diagnostics) []HealthCheck {
+	var checks []HealthCheck
+
+	adapterCheck := HealthCheck{Name: "adapter", Status: "ok"}
+	switch {
+	case d.Adapter.Provider == "":
+		adapterCheck.Status, adapterCheck.Detail = "error", "no model provider configured"
+	case !d.Adapter.Ping.OK:
+		adapterCheck.Status, adapterCheck.Detail = "error", fmt.Sprintf("%s: %s", d.Adapter.Provider, d.Adapter.Ping.Error)
+	default:
+		adapterCheck.Detail = fmt.Sprintf("%s reachable in %dms", d.Adapter.Model, d.Adapter.Ping.LatencyMs)
+	}
+	checks = append(checks, adapterCheck)
+
+	indexCheck := HealthCheck{Name: "index", Status: "ok"}
+	switch {
+	case !d.Index.Available:
+		indexCheck.Status, indexCheck.Detail = "warning", "symbol index not started"
+	case d.Index.IndexedAt.IsZero():
+		indexCheck.Status, indexCheck.Detail = "warning", "symbol index has not completed a full pass"
+	case time.Duration(d.Index.AgeSec)*time.Second > staleIndexAge:
+		indexCheck.Status, indexCheck.Detail = "warning", fmt.Sprintf("last full index %s ago", formatAge(d.Index.AgeSec))
+	default:
+		indexCheck.Detail = fmt.Sprintf("%d symbols in %d files, indexed %s ago", d.Index.Symbols, d.Index.Files, formatAge(d.Index.AgeSec))
+	}
+	if w := d.Index.Watch; w != nil && w.Mode == symbols.WatchPoll {
+		indexCheck.Detail += fmt.Sprintf("; polling for changes every %s (%s)", time.Duration(w.IntervalMs)*time.Millisecond, w.Reason)
+	}
+	checks = append(checks, indexCheck)
+
+	searchCheck := HealthCheck{Name: "search", Status: "ok"}
+	rg := d.Ripgrep
+	label := strings.TrimSpace("ripgrep " + rg.Version)
+	switch rg.Source {
+	case indexer.RipgrepBundled:
+		searchCheck.Detail = fmt.Sprintf("bundled %s (%s, integrity checked)", label, rg.Platform)
+	case indexer.RipgrepSystem:
+		searchCheck.Detail = fmt.Sprintf("system %s at %s", label, rg.Path)
+		// A bundled binary that failed its integrity check is worth a look; none bundled is normal
+		if strings.Contains(rg.Fallback, "integrity") || strings.Contains(rg.Fallback, "extract") {
+			searchCheck.Status = "warning"
+			searchCheck.Detail += "; " + rg.Fallback
+		}
+	default:
+		// search_code still works, only slower
+		searchCheck.Status, searchCheck.Detail = "warning", "ripgrep not found, using the built-in search: "+rg.Fallback
+	}
+	checks = append(checks, searchCheck)
+
+	mcpCheck := HealthCheck{Name: "mcp", Status: "ok", Detail: "no servers configured"}
+	var down, starting []string
+	for _, s := range d.MCP {
+		switch {
+		case !s.Running || s.Error != "":
+			down = append(down, s.Name)
+		case !s.Initialized:
+			starting = append(starting, s.Name)
+		}
+	}
+	switch {
+	case len(down) > 0:
+		mcpCheck.Status, mcpCheck.Detail = "error", "unavailable: "+strings.Join(down, ", ")
+	case len(starting) > 0:
+		mcpCheck.Status, mcpCheck.Detail = "warning", "still initializing: "+strings.Join(starting, ", ")
+	case len(d.MCP) > 0:
+		mcpCheck.Detail = fmt.Sprintf("%d server(s) connected", len(d.MCP))
+	}
+	checks = append(checks, mcpCheck)
+
+	errorsCheck := HealthCheck{Name: "errors", Status: "ok", Detail: "no recent errors"}
+	recent := 0
+	for _, e := range d.RecentErrors {
+		if d.GeneratedAt.Sub(e.Time) <= recentErrorWindow {
+			recent++
+		}
+	}
+	if recent > 0 {
+		last := d.RecentErrors[len(d.RecentErrors)-1]
+		errorsCheck.Status = "warning"
+		errorsCheck.Detail = fmt.Sprintf("%d error(s) in the last hour; latest (%s): %s", recent, last.Source, last.Message)
+	}
+	checks = append(checks, errorsCheck)
+
+	return checks
+}
+
+// FormatDiagnostics renders diagnostics as plain text for the `loom doctor` command.
+func FormatDiagnostics(d Diagnostics) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Loom doctor - %s\n", d.GeneratedAt.Format(time.RFC3339))
+	if d.Workspace != "" {
+		fmt.Fprintf(&b, "Workspace: %s\n", d.Workspace)
+	}
+	b.WriteString("\n")
+	for _, c := range d.Checks {
+		fmt.Fprintf(&b, "[%-7s] %-7s %s\n", strings.ToUpper(c.Status), c.Name, c.Detail)
+	}
+
+	if len(d.MCP) > 0 {
+		b.WriteString("\nMCP servers:\n")
+		for _, s := range d.MCP {
+			state := "connected"
+			switch {
+			case !s.Running:
+				state = "not running"
+			case !s.Initialized:
+				state = "initializing"
+			}
+			fmt.Fprintf(&b, "  %s: %s", s.Name, state)
+			if s.PID > 0 {
+				fmt.Fprintf(&b, " (pid %d)", s.PID)
+			}
+			if s.Error != "" {
+				fmt.Fprintf(&b, " - %s", s.Error)
+			}
+			b.WriteString("\n")
+		}
+	}
+
+	q := d.Queues
+	fmt.Fprintf(&b, "\nQueues: %d approval(s), %d choice(s), %d question(s) pending\n", q.PendingApprovals, q.PendingChoices, q.PendingQuestions)
+	for _, s := range q.ToolSlots {
+		fmt.Fprintf(&b, "  %s: %d/%d slots in use\n", s.Tool, s.InUse, s.Max)
+	}
+
+	if len(d.RecentErrors) > 0 {
+		b.WriteString("\nRecent errors:\n")
+		for _, e := range d.RecentErrors {
+			fmt.Fprintf(&b, "  %s [%s] %s\n", e.Time.Format("2006-01-02 15:04:05"), e.Source, e.Message)
+		}
+	}
+	return b.String()
+}
+
+// Healthy reports whether no check is in the error state.
+func (d Diagnostics) Healthy() bool {
+	for _, c := range d.Checks {
+		if c.Status == "error" {
+			return false
+		}
+	}
+	return true
+}
+
+// WaitForMCP blocks until every MCP server has finished initializing or failed, or the
+// timeout passes. Used by `loom doctor`, which reports right after startup.
+func (a *App) WaitForMCP(timeout time.Duration) {
+	if a.mcpManager == nil {
+		return
+	}
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		pending := false
+		for _, s := range a.mcpManager.Status() {
+			if s.Running && !s.Initialized && s.Error == "" {
+				pending = true
+				break
+			}
+		}
+		if !pending {
+			return
+		}
+		time.Sleep(200 * time.Millisecond)
+	}
+}
+
+// StopMCP stops all MCP server processes.
+func (a *App) StopMCP() {
+	if a.mcpManager != nil {
+		a.mcpManager.StopAll()
+	}
+}
+
+func formatAge(sec int64) string {
+	return (time.Duration(sec) * time.Second).String()
+}
@@ -0,0 +1,40 @@
+package bridge
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/loom/loom/internal/editor"
+)
+
+// UpdateUnsavedChanges reports the content of an editor tab with unsaved changes, or
+// dirty=false once it was saved or discarded. The lines that differ from the file on
+// disk are handed to the engine, which keeps agent edits away from them.
+// Returns the dirty ranges in on-disk line numbers: [{ StartLine, EndLine }].
+func (a *App) UpdateUnsavedChanges(path string, content string, dirty bool) []editor.LineRange {
+	if a.engine == nil {
+		return nil
+	}
+	root := strings.TrimSpace(a.engine.Workspace())
+	rel := filepath.ToSlash(filepath.Clean(strings.TrimSpace(path)))
+	if root == "" || rel == "." || rel == "" || strings.HasPrefix(rel, "../") || filepath.IsAbs(rel) {
+		return nil
+	}
+	if !dirty {
+		a.engine.SetDirtyRanges(rel, nil)
+		return nil
+	}
+	var ranges []editor.LineRange
+	onDisk, _, err := editor.ReadText(filepath.Join(root, filepath.FromSlash(rel)))
+	switch {
+	case err == nil:
+		ranges = editor.ChangedLineRanges(onDisk, strings.ReplaceAll(content, "\r\n", "\n"))
+	case os.IsNotExist(err):
+		// A file that only exists in the editor has nothing on disk to protect
+	default:
+		return nil
+	}
+	a.engine.SetDirtyRanges(rel, ranges)
+	return ranges
+}
@@ -0,0 +1,73 @@
+package bridge
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/loom/loom/internal/engine"
+)
+
+// GetEditJournal returns the workspace's journaled edits, newest first, without their
+// contents.
+func (a *App) GetEditJournal() []map[string]interface{} {
+	out := []map[string]interface{}{}
+	if a.engine == nil {
+		return out
+	}
+	entries, _ := a.engine.EditJournal()
+	for _, j := range entries {
+		out = append(out, map[string]interface{}{
+			"id": j.ID, "conversation_id": j.ConversationID, "call_id": j.CallID, "path": j.Path,
+			"source": j.Source, "created": j.Created, "deleted": j.Deleted, "at": j.At,
+		})
+	}
+	return out
+}
+
+// UndoLastEdit undoes the newest journaled edit in the workspace, with the other files
+// of the same approved proposal.
+// Returns: { reverted: [path], error? }.
+func (a *App) UndoLastEdit() map[string]interface{} {
+	if a.engine == nil {
+		return map[string]interface{}{"error": "engine not initialized"}
+	}
+	if err := a.ensureWritable(); err != nil {
+		return map[string]interface{}{"error": err.Error()}
+	}
+	res, err := a.engine.UndoLastEdit()
+	return a.undoResult(res, err, "")
+}
+
+// UndoEditsForConversation undoes every journaled edit a conversation made, newest
+// first. Files changed since are kept and reported in the error.
+// Returns: { reverted: [path], error? }.
+func (a *App) UndoEditsForConversation(id string) map[string]interface{} {
+	if a.engine == nil {
+		return map[string]interface{}{"error": "engine not initialized"}
+	}
+	if err := a.ensureWritable(); err != nil {
+		return map[string]interface{}{"error": err.Error()}
+	}
+	res, err := a.engine.UndoEditsForConversation(id)
+	return a.undoResult(res, err, id)
+}
+
+func (a *App) undoResult(res *engine.UndoResult, err error, conversationID string) map[string]interface{} {
+	reverted := []string{}
+	if res != nil {
+		reverted = res.Reverted
+	}
+	out := map[string]interface{}{"reverted": reverted}
+	if len(reverted) > 0 {
+		fields := map[string]interface{}{"source": "undo", "ok": err == nil, "paths": reverted}
+		if conversationID != "" {
+			fields["conversation_id"] = conversationID
+		}
+		a.audit("edit", fields)
+		a.SendChat("system", fmt.Sprintf("Undid %d edit(s): %s", res.Undone, strings.Join(reverted, ", ")))
+	}
+	if err != nil {
+		out["error"] = err.Error()
+	}
+	return out
+}
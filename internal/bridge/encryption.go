@@ -0,0 +1,54 @@
+package bridge
+
+import (
+	"github.com/loom/loom/internal/engine"
+	"github.com/loom/loom/internal/keychain"
+	"github.com/loom/loom/internal/memory"
+)
+
+// GetSessionEncryption reports whether the current workspace's transcripts, memories
+// and run results are encrypted at rest, and where a new key would be kept.
+func (a *App) GetSessionEncryption() map[string]interface{} {
+	ws := a.workspace()
+	if ws == "" || a.memoryStore == nil {
+		return map[string]interface{}{"error": "no workspace open"}
+	}
+	proj, err := memory.NewProject(a.memoryStore, ws)
+	if err != nil {
+		return map[string]interface{}{"error": err.Error()}
+	}
+	enc := proj.Encryption()
+	return map[string]interface{}{
+		"workspace": ws,
+		"enabled":   enc.Enabled,
+		"algorithm": enc.Algorithm,
+		"key_store": enc.KeyStore,
+		"backend":   keychain.Backend(),
+	}
+}
+
+// SetSessionEncryption turns encryption at rest on or off for the current workspace
+// and re-writes its stored data accordingly. Returns an error message, or "".
+func (a *App) SetSessionEncryption(enabled bool) string {
+	ws := a.workspace()
+	if ws == "" || a.memoryStore == nil {
+		return "no workspace open"
+	}
+	proj, err := memory.NewProject(a.memoryStore, ws)
+	if err != nil {
+		return err.Error()
+	}
+	// Attachments stay in plaintext: they are read-only copies of user-picked files that
+	// read_file serves directly
+	dirs := []string{engine.RunsDir(ws), engine.HandoffsDir(ws), engine.EditJournalDir(ws)}
+	if enabled {
+		err = proj.EnableEncryption(dirs...)
+	} else {
+		err = proj.DisableEncryption(dirs...)
+	}
+	if err != nil {
+		return err.Error()
+	}
+	a.audit("session_encryption", map[string]interface{}{"enabled": enabled, "key_store": proj.Encryption().KeyStore})
+	return ""
+}
@@ -0,0 +1,49 @@
+package bridge
+
+import (
+	"github.com/loom/loom/internal/config"
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// ExplanationLevelsInfo is returned by GetExplanationLevels.
+type ExplanationLevelsInfo struct {
+	Levels []string `json:"levels"`
+	// Default is the level of conversations that did not pick one; "" leaves it to the model
+	Default string `json:"default"`
+	// Current is the level in effect for the current conversation
+	Current string `json:"current"`
+}
+
+// GetExplanationLevels lists the explanation levels, the default from settings and the
+// level of the current conversation.
+func (a *App) GetExplanationLevels() ExplanationLevelsInfo {
+	a.ensureSettingsLoaded()
+	info := ExplanationLevelsInfo{Levels: config.ExplanationLevels, Default: a.settings.ExplanationLevel}
+	if a.engine != nil {
+		info.Current = a.engine.ExplanationLevel()
+	}
+	return info
+}
+
+// SetExplanationLevel switches how deeply the current conversation's replies explain
+// code: beginner (also "ELI5"), intermediate or expert; "default" returns to the
+// default from settings. The switch applies from the next message on and is marked in
+// the transcript. Returns an error message, or "" on success.
+func (a *App) SetExplanationLevel(level string) string {
+	if a.engine == nil {
+		return "engine not initialized"
+	}
+	if err := a.engine.SetExplanationLevel(level); err != nil {
+		return err.Error()
+	}
+	current := a.engine.ExplanationLevel()
+	a.audit("settings", map[string]interface{}{"explanation_level": current})
+	if current == "" {
+		current = "default"
+	}
+	a.SendChat("system", "Explanation level: "+current)
+	if a.ctx != nil {
+		runtime.EventsEmit(a.ctx, "system:explanation_level", current)
+	}
+	return ""
+}
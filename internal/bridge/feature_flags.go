@@ -0,0 +1,48 @@
+package bridge
+
+import (
+	"maps"
+
+	"github.com/loom/loom/internal/config"
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// FeatureFlagState is a feature flag with its current state, as listed in the
+// "Experimental features" settings section.
+type FeatureFlagState struct {
+	config.FeatureFlag
+	Enabled bool `json:"enabled"`
+}
+
+// GetFeatureFlags lists the experimental feature flags and whether each is on.
+func (a *App) GetFeatureFlags() []FeatureFlagState {
+	a.ensureSettingsLoaded()
+	out := make([]FeatureFlagState, 0, len(config.FeatureFlags))
+	for _, f := range config.FeatureFlags {
+		out = append(out, FeatureFlagState{FeatureFlag: f, Enabled: a.settings.FeatureEnabled(f.Name)})
+	}
+	return out
+}
+
+// SetFeatureFlag switches an experimental subsystem on or off. The change applies from
+// the next run on. Returns an error message, or "" on success.
+func (a *App) SetFeatureFlag(name string, enabled bool) string {
+	a.ensureSettingsLoaded()
+	s := a.settings
+	s.FeatureFlags = maps.Clone(s.FeatureFlags)
+	if err := s.SetFeatureFlag(name, enabled); err != nil {
+		return err.Error()
+	}
+	if err := config.Save(s); err != nil {
+		return err.Error()
+	}
+	a.settings = s
+	if a.engine != nil {
+		a.engine.SetFeatureFlags(s.EffectiveFeatureFlags())
+	}
+	a.audit("settings", map[string]interface{}{"feature_flag": name, "enabled": enabled})
+	if a.ctx != nil {
+		runtime.EventsEmit(a.ctx, "settings:feature_flags", a.GetFeatureFlags())
+	}
+	return ""
+}
@@ -0,0 +1,44 @@
+package bridge
+
+import (
+	"github.com/loom/loom/internal/engine"
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// GetHandoffs returns the workspace's handoffs of unfinished runs, newest first.
+func (a *App) GetHandoffs() []engine.Handoff {
+	out := []engine.Handoff{}
+	if a.engine == nil || a.engine.Workspace() == "" {
+		return out
+	}
+	handoffs, err := engine.LoadHandoffs(a.engine.Workspace(), a.engine.Sealer())
+	if err != nil {
+		return out
+	}
+	return append(out, handoffs...)
+}
+
+// ResumeHandoff continues a handoff (the newest one not resumed yet when id is empty)
+// in a new conversation. Returns an error message, or "".
+func (a *App) ResumeHandoff(id string) string {
+	if a.engine == nil {
+		return "engine not initialized"
+	}
+	message, err := a.engine.ResumeHandoff(id)
+	if err != nil {
+		return err.Error()
+	}
+	if a.ctx != nil {
+		runtime.EventsEmit(a.ctx, "chat:clear")
+	}
+	a.audit("handoff", map[string]interface{}{"resumed": id})
+	a.engine.Enqueue(message)
+	return ""
+}
+
+// EmitHandoff tells the UI that a stopped run left a handoff it can resume.
+func (a *App) EmitHandoff(h engine.Handoff) {
+	if a.ctx != nil {
+		runtime.EventsEmit(a.ctx, "run:handoff", h)
+	}
+}
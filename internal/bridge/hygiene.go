@@ -0,0 +1,54 @@
+package bridge
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/loom/loom/internal/hygiene"
+)
+
+// GetHygieneReport audits the workspace for repository hygiene issues: missing ignore
+// rules, committed secrets, large blobs in git history, a missing license and
+// conflicting formatting configuration.
+// Returns: { workspace, generated_at, git, findings: [{ id, check, severity, path, line, message, suggestion, fix? }], error? }.
+func (a *App) GetHygieneReport() map[string]interface{} {
+	if a.engine == nil {
+		return map[string]interface{}{"error": "engine not initialized", "findings": []hygiene.Finding{}}
+	}
+	rep, err := a.engine.HygieneReport(context.Background())
+	res := map[string]interface{}{
+		"workspace":    rep.Workspace,
+		"generated_at": rep.GeneratedAt,
+		"git":          rep.Git,
+		"truncated":    rep.Truncated,
+		"findings":     rep.Findings,
+	}
+	if err != nil {
+		res["error"] = err.Error()
+	}
+	return res
+}
+
+// ApplyHygieneFix applies the fix of a hygiene finding after the user approves its diff.
+// Returns: { edit_id, path, diff, created } or { error }.
+func (a *App) ApplyHygieneFix(id string) map[string]interface{} {
+	if a.engine == nil {
+		return map[string]interface{}{"error": "engine not initialized"}
+	}
+	res, err := a.engine.ApplyHygieneFix(context.Background(), id)
+	if err != nil {
+		return map[string]interface{}{"error": err.Error()}
+	}
+	a.audit("edit", map[string]interface{}{"path": res.Edit.Path, "source": "hygiene", "ok": true, "edit_id": res.Edit.ID, "finding": id, "file_sha256": sha256Hex([]byte(res.Edit.After))})
+	verb := "Updated"
+	if res.Edit.Created {
+		verb = "Created"
+	}
+	a.SendChat("system", fmt.Sprintf("%s %s to fix a hygiene finding (undo available).", verb, res.Edit.Path))
+	return map[string]interface{}{
+		"edit_id": res.Edit.ID,
+		"path":    res.Edit.Path,
+		"diff":    res.Diff,
+		"created": res.Edit.Created,
+	}
+}
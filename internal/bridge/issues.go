@@ -0,0 +1,30 @@
+package bridge
+
+import (
+	"context"
+	"time"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// StartFromIssue starts a new conversation primed with a brief of a GitHub issue or
+// pull request: its description, discussion, linked diffs and the workspace files
+// that look relevant. Returns an error message, or "".
+func (a *App) StartFromIssue(url string) string {
+	if a.engine == nil {
+		return "engine not initialized"
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+	brief, message, err := a.engine.StartFromIssue(ctx, url)
+	if err != nil {
+		return err.Error()
+	}
+	if a.ctx != nil {
+		runtime.EventsEmit(a.ctx, "chat:clear")
+		runtime.EventsEmit(a.ctx, "issue:primed", brief)
+	}
+	a.audit("issue_primer", map[string]interface{}{"url": brief.URL, "files": len(brief.Files), "changes": len(brief.Changes)})
+	a.engine.Enqueue(message)
+	return ""
+}
@@ -0,0 +1,186 @@
+package bridge
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/loom/loom/internal/adapter"
+	"github.com/loom/loom/internal/config"
+	"github.com/loom/loom/internal/engine"
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// KeyProfileInfo describes a key profile to the frontend without its key.
+type KeyProfileInfo struct {
+	Name         string `json:"name"`
+	Provider     string `json:"provider"`
+	Organization string `json:"organization,omitempty"`
+	// KeyHint shows the last characters of the key, e.g. "…3f9a"
+	KeyHint string `json:"key_hint"`
+	// Builtin marks the key stored in the provider's own settings field
+	Builtin bool `json:"builtin,omitempty"`
+}
+
+// KeyProfilesInfo is returned by GetKeyProfiles.
+type KeyProfilesInfo struct {
+	Profiles  []KeyProfileInfo `json:"profiles"`
+	Workspace string           `json:"workspace"`
+	// WorkspaceDefaults maps provider to the profile tried first in this workspace
+	WorkspaceDefaults map[string]string `json:"workspace_defaults"`
+	// Provider is the current model's provider and ActiveKey the profile it uses
+	Provider  string `json:"provider"`
+	ActiveKey string `json:"active_key,omitempty"`
+}
+
+// activeKeyReporter is implemented by adapters rotating over several keys.
+type activeKeyReporter interface {
+	ActiveKey() string
+}
+
+// newLLM creates the adapter for cfg with every key profile of its provider, ordered
+// for the current workspace, so requests move on to the next key on quota errors.
+func (a *App) newLLM(cfg adapter.Config) (engine.LLM, error) {
+	var keys []adapter.Key
+	for _, p := range a.settings.KeyProfilesFor(string(cfg.Provider), a.workspace()) {
+		keys = append(keys, adapter.Key{Name: p.Name, APIKey: p.APIKey, Organization: p.Organization})
+	}
+	return adapter.NewWithKeys(cfg, keys, a.onKeyRotated)
+}
+
+// setEngineLLM hands llm to the engine and remembers whether it rotates keys.
+func (a *App) setEngineLLM(llm engine.LLM) {
+	a.engine.SetLLM(llm)
+	a.activeKeys, _ = llm.(activeKeyReporter)
+}
+
+// reloadLLM recreates the current model's adapter so key profile changes apply.
+func (a *App) reloadLLM() {
+	if a.engine == nil || a.demoMode || a.config.Provider == "" {
+		return
+	}
+	cfg := a.config
+	cfg.APIKey = a.apiKeyForProvider(cfg.Provider)
+	llm, err := a.newLLM(cfg)
+	if err != nil {
+		return
+	}
+	a.setEngineLLM(llm)
+	a.config = cfg
+}
+
+// onKeyRotated tells the user that requests now use another key.
+func (a *App) onKeyRotated(r adapter.KeyRotation) {
+	a.audit("settings", map[string]interface{}{"key_rotated": string(r.Provider), "from": r.From, "to": r.To, "reason": r.Reason})
+	a.SendChat("system", fmt.Sprintf("The %s key %q hit a limit (%s); continuing with key %q.", r.Provider, r.From, r.Reason, r.To))
+	if a.ctx != nil {
+		runtime.EventsEmit(a.ctx, "keys:rotated", map[string]interface{}{
+			"provider": string(r.Provider),
+			"from":     r.From,
+			"to":       r.To,
+			"reason":   r.Reason,
+		})
+	}
+}
+
+// workspace returns the engine's workspace, or "" before one is open.
+func (a *App) workspace() string {
+	if a.engine == nil {
+		return ""
+	}
+	return a.engine.Workspace()
+}
+
+// GetKeyProfiles lists the key profiles of every provider and the current workspace's
+// choices. Keys themselves are never returned.
+func (a *App) GetKeyProfiles() KeyProfilesInfo {
+	a.ensureSettingsLoaded()
+	ws := a.workspace()
+	info := KeyProfilesInfo{Profiles: []KeyProfileInfo{}, Workspace: ws, WorkspaceDefaults: map[string]string{}, Provider: string(a.config.Provider)}
+	for _, provider := range []string{"openai", "anthropic", "openrouter", "gemini"} {
+		for _, p := range a.settings.KeyProfilesFor(provider, "") {
+			info.Profiles = append(info.Profiles, KeyProfileInfo{
+				Name:         p.Name,
+				Provider:     p.Provider,
+				Organization: p.Organization,
+				KeyHint:      keyHint(p.APIKey),
+				Builtin:      p.Name == config.DefaultKeyProfile,
+			})
+		}
+		if name := a.settings.WorkspaceKeyProfile(ws, provider); name != "" {
+			info.WorkspaceDefaults[provider] = name
+		}
+	}
+	if a.activeKeys != nil {
+		info.ActiveKey = a.activeKeys.ActiveKey()
+	} else if keys := a.settings.KeyProfilesFor(info.Provider, ws); len(keys) > 0 {
+		info.ActiveKey = keys[0].Name
+	}
+	return info
+}
+
+// SaveKeyProfiles replaces the named key profiles. A profile submitted without a key
+// keeps the key it already had. Returns an error message, or "" on success.
+func (a *App) SaveKeyProfiles(profiles []config.KeyProfile) string {
+	a.ensureSettingsLoaded()
+	s := a.settings
+	existing := map[string]string{}
+	for _, p := range s.KeyProfiles {
+		existing[p.Provider+":"+strings.ToLower(p.Name)] = p.APIKey
+	}
+	out := make([]config.KeyProfile, 0, len(profiles))
+	for _, p := range profiles {
+		p.Name, p.Provider, p.APIKey, p.Organization = strings.TrimSpace(p.Name), strings.TrimSpace(p.Provider), strings.TrimSpace(p.APIKey), strings.TrimSpace(p.Organization)
+		if p.APIKey == "" {
+			p.APIKey = existing[p.Provider+":"+strings.ToLower(p.Name)]
+		}
+		out = append(out, p)
+	}
+	if err := config.ValidateKeyProfiles(out); err != nil {
+		return err.Error()
+	}
+	s.KeyProfiles = out
+	// Forget workspace choices of profiles that no longer exist
+	for ws, byProvider := range s.WorkspaceKeyProfiles {
+		for provider, name := range byProvider {
+			if s.SetWorkspaceKeyProfile(ws, provider, name) != nil {
+				_ = s.SetWorkspaceKeyProfile(ws, provider, "")
+			}
+		}
+	}
+	if err := config.Save(s); err != nil {
+		return err.Error()
+	}
+	a.settings = s
+	a.audit("settings", map[string]interface{}{"key_profiles": len(out)})
+	a.reloadLLM()
+	return ""
+}
+
+// SetWorkspaceKeyProfile makes the named profile the first key tried for provider in
+// the current workspace; an empty name restores the default order. Returns an error
+// message, or "" on success.
+func (a *App) SetWorkspaceKeyProfile(provider, name string) string {
+	a.ensureSettingsLoaded()
+	s := a.settings
+	if err := s.SetWorkspaceKeyProfile(a.workspace(), provider, strings.TrimSpace(name)); err != nil {
+		return err.Error()
+	}
+	if err := config.Save(s); err != nil {
+		return err.Error()
+	}
+	a.settings = s
+	a.audit("settings", map[string]interface{}{"workspace_key_profile": provider + ":" + name})
+	if string(a.config.Provider) == provider {
+		a.reloadLLM()
+	}
+	return ""
+}
+
+// keyHint returns the last four characters of a key for display.
+func keyHint(key string) string {
+	key = strings.TrimSpace(key)
+	if len(key) <= 8 {
+		return "…"
+	}
+	return "…" + key[len(key)-4:]
+}
@@ -0,0 +1,37 @@
+package bridge
+
+import (
+	"strings"
+
+	"github.com/loom/loom/internal/config"
+)
+
+// GetMacros lists the workspace's edit macros from .loom/macros.json.
+func (a *App) GetMacros() []config.EditMacro {
+	out := []config.EditMacro{}
+	if a.engine == nil {
+		return out
+	}
+	macros, err := config.LoadMacros(a.engine.Workspace())
+	if err != nil {
+		return out
+	}
+	return append(out, macros...)
+}
+
+// SaveMacros replaces the workspace's edit macros, which run_macro replays. Returns an
+// error message, or "" on success.
+func (a *App) SaveMacros(macros []config.EditMacro) string {
+	if a.engine == nil {
+		return "no workspace is open"
+	}
+	for i := range macros {
+		macros[i].Name = strings.TrimSpace(macros[i].Name)
+		macros[i].Files = strings.TrimSpace(macros[i].Files)
+	}
+	if err := config.SaveMacros(a.engine.Workspace(), macros); err != nil {
+		return err.Error()
+	}
+	a.audit("settings", map[string]interface{}{"macros": len(macros)})
+	return ""
+}
@@ -0,0 +1,282 @@
+package bridge
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/loom/loom/internal/config"
+	"github.com/loom/loom/internal/mcp"
+	"github.com/loom/loom/internal/tool"
+)
+
+// registerMCPTools registers the tools of the running MCP servers in reg, curated by
+// each server's tool overrides, and remembers all discovered tools for GetMCPTools.
+func (a *App) registerMCPTools(reg *tool.Registry, cfgs map[string]config.MCPServerConfig) {
+	if a.mcpManager == nil {
+		return
+	}
+	toolsets, err := a.mcpManager.ListTools()
+	if err != nil {
+		return
+	}
+	taken := map[string]bool{}
+	for _, d := range reg.Tools() {
+		taken[d.Name] = true
+	}
+	// Servers in a stable order so name clashes resolve the same way every time
+	aliases := make([]string, 0, len(toolsets))
+	for alias := range toolsets {
+		aliases = append(aliases, alias)
+	}
+	sort.Strings(aliases)
+
+	var all []mcp.CuratedTool
+	for _, alias := range aliases {
+		serverCfg := cfgs[alias]
+		timeout := time.Duration(serverCfg.TimeoutSec) * time.Second
+		if timeout == 0 {
+			timeout = 60 * time.Second
+		}
+		for _, t := range mcp.Curate(alias, serverCfg, toolsets[alias], taken) {
+			all = append(all, t)
+			if t.Hidden || t.Problem != "" {
+				if t.Problem != "" {
+					log.Printf("[mcp] %s: tool %s not registered: %s", alias, t.Tool, t.Problem)
+				}
+				continue
+			}
+			server, toolName := alias, t.Tool
+			_ = reg.Register(tool.Definition{
+				Name:        t.Name,
+				Description: t.Description,
+				JSONSchema:  t.InputSchema,
+				Safe:        t.Safe,
+				Handler: func(ctx context.Context, raw json.RawMessage) (interface{}, error) {
+					out, err := a.mcpManager.Call(server, toolName, raw, timeout)
+					if err != nil {
+						return "Error: " + err.Error(), nil
+					}
+					return out, nil
+				},
+			})
+		}
+	}
+	a.mcpTools = all
+	a.mcpPrompts = a.mcpManager.ListPrompts()
+	if len(a.mcpManager.ListResources()) > 0 {
+		a.registerMCPResourceTools(reg)
+	}
+}
+
+// registerMCPResourceTools lets the model list and read the servers' resources.
+// Resources are read-only by protocol, so neither tool needs approval.
+func (a *App) registerMCPResourceTools(reg *tool.Registry) {
+	_ = reg.Register(tool.Definition{
+		Name:        "list_mcp_resources",
+		Description: "Lists the resources (documents, records, files) the connected MCP servers offer as context, with their server and URI. Read one with read_mcp_resource.",
+		JSONSchema:  map[string]any{"type": "object", "properties": map[string]any{}},
+		Safe:        true,
+		Handler: func(ctx context.Context, raw json.RawMessage) (interface{}, error) {
+			resources := a.mcpManager.ListResources()
+			if len(resources) == 0 {
+				return "No MCP resources available.", nil
+			}
+			var b strings.Builder
+			for _, r := range resources {
+				fmt.Fprintf(&b, "- [%s] %s: %s", r.Server, r.Name, r.URI)
+				if r.Description != "" {
+					fmt.Fprintf(&b, " (%s)", r.Description)
+				}
+				b.WriteString("\n")
+			}
+			return b.String(), nil
+		},
+	})
+	_ = reg.Register(tool.Definition{
+		Name:        "read_mcp_resource",
+		Description: "Reads a resource from an MCP server by its URI, as listed by list_mcp_resources.",
+		JSONSchema: map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"server": map[string]any{"type": "string", "description": "MCP server the resource belongs to"},
+				"uri":    map[string]any{"type": "string", "description": "Resource URI"},
+			},
+			"required": []string{"server", "uri"},
+		},
+		Safe: true,
+		Handler: func(ctx context.Context, raw json.RawMessage) (interface{}, error) {
+			var args struct {
+				Server string `json:"server"`
+				URI    string `json:"uri"`
+			}
+			if err := json.Unmarshal(raw, &args); err != nil || args.Server == "" || args.URI == "" {
+				return "Error: server and uri are required", nil
+			}
+			contents, err := a.mcpManager.ReadResource(args.Server, args.URI, mcpResourceTimeout)
+			if err != nil {
+				return "Error: " + err.Error(), nil
+			}
+			return renderResource(contents), nil
+		},
+	})
+}
+
+// mcpResourceTimeout bounds reading a resource or rendering a prompt
+const mcpResourceTimeout = 60 * time.Second
+
+// maxResourceText caps the text of a resource returned to the model
+const maxResourceText = 100_000
+
+func renderResource(contents []mcp.ResourceContent) string {
+	var b strings.Builder
+	for i, c := range contents {
+		if i > 0 {
+			b.WriteString("\n\n")
+		}
+		if len(contents) > 1 {
+			fmt.Fprintf(&b, "%s:\n", c.URI)
+		}
+		if c.Data != nil {
+			fmt.Fprintf(&b, "[binary %s, %d bytes; attach it to the conversation to use it]", c.MimeType, len(c.Data))
+			continue
+		}
+		text := c.Text
+		if len(text) > maxResourceText {
+			text = text[:maxResourceText] + "\n… truncated"
+		}
+		b.WriteString(text)
+	}
+	return b.String()
+}
+
+// GetMCPResources lists the resources the connected MCP servers offer.
+func (a *App) GetMCPResources() []mcp.ResourceSpec {
+	if a.mcpManager == nil {
+		return []mcp.ResourceSpec{}
+	}
+	out := a.mcpManager.ListResources()
+	if out == nil {
+		out = []mcp.ResourceSpec{}
+	}
+	return out
+}
+
+// AttachMCPResource reads a resource and attaches its contents to the conversation,
+// where the model reads it like any other attachment.
+// Returns: { attachments: [{ name, ref, size, binary }], error? }.
+func (a *App) AttachMCPResource(server, uri string) map[string]interface{} {
+	res := map[string]interface{}{"attachments": []tool.AttachmentInfo{}}
+	if a.engine == nil || a.mcpManager == nil {
+		res["error"] = "no MCP servers connected"
+		return res
+	}
+	contents, err := a.mcpManager.ReadResource(server, uri, mcpResourceTimeout)
+	if err != nil {
+		res["error"] = err.Error()
+		return res
+	}
+	var attached []tool.AttachmentInfo
+	for _, c := range contents {
+		data := c.Data
+		if data == nil {
+			data = []byte(c.Text)
+		}
+		at, err := a.engine.AttachContent(resourceFileName(c.URI), data)
+		if err != nil {
+			res["error"] = err.Error()
+			break
+		}
+		a.audit("attachment", map[string]interface{}{"ref": at.Ref, "size": at.Size, "mcp_server": server, "uri": c.URI})
+		attached = append(attached, at)
+	}
+	if attached != nil {
+		res["attachments"] = attached
+	}
+	return res
+}
+
+// resourceFileName derives an attachment name from the last segment of a resource URI.
+func resourceFileName(uri string) string {
+	name := uri
+	if i := strings.Index(name, "://"); i >= 0 {
+		name = name[i+3:]
+	}
+	name = strings.TrimRight(name, "/")
+	if i := strings.LastIndexAny(name, "/\\"); i >= 0 {
+		name = name[i+1:]
+	}
+	name = strings.Map(func(r rune) rune {
+		if r == ':' || r == '?' || r == '*' || r == '"' || r == '<' || r == '>' || r == '|' {
+			return '_'
+		}
+		return r
+	}, name)
+	if name == "" {
+		return "resource"
+	}
+	return name
+}
+
+// MCPPromptCommand is a server prompt template offered as a slash command.
+type MCPPromptCommand struct {
+	// Command is typed after "/", e.g. "summarize"; prompts whose names clash across
+	// servers are qualified as "<server>:<prompt>"
+	Command string `json:"command"`
+	mcp.PromptSpec
+}
+
+// GetMCPPrompts lists the prompt templates of the connected MCP servers as slash commands.
+func (a *App) GetMCPPrompts() []MCPPromptCommand {
+	count := map[string]int{}
+	for _, p := range a.mcpPrompts {
+		count[p.Name]++
+	}
+	out := make([]MCPPromptCommand, 0, len(a.mcpPrompts))
+	for _, p := range a.mcpPrompts {
+		cmd := strings.ReplaceAll(p.Name, " ", "_")
+		if count[p.Name] > 1 {
+			cmd = p.Server + ":" + cmd
+		}
+		out = append(out, MCPPromptCommand{Command: cmd, PromptSpec: p})
+	}
+	return out
+}
+
+// RunMCPPrompt renders the prompt behind a slash command with the text typed after it
+// and sends the result as a user message. Returns an error message, or "".
+func (a *App) RunMCPPrompt(command, input string) string {
+	if a.mcpManager == nil {
+		return "no MCP servers connected"
+	}
+	for _, p := range a.GetMCPPrompts() {
+		if p.Command != command {
+			continue
+		}
+		args, err := mcp.ParsePromptArgs(p.PromptSpec, input)
+		if err != nil {
+			return err.Error()
+		}
+		msgs, err := a.mcpManager.GetPrompt(p.Server, p.Name, args, mcpResourceTimeout)
+		if err != nil {
+			return err.Error()
+		}
+		text := mcp.PromptText(msgs)
+		if text == "" {
+			return fmt.Sprintf("prompt %s returned no text", p.Name)
+		}
+		a.SendUserMessage(text)
+		return ""
+	}
+	return fmt.Sprintf("unknown prompt /%s", command)
+}
+
+// GetMCPTools lists every tool the connected MCP servers expose, with the name,
+// description and approval policy the model sees and whether it is hidden.
+func (a *App) GetMCPTools() []mcp.CuratedTool {
+	return append([]mcp.CuratedTool{}, a.mcpTools...)
+}
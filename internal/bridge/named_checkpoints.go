@@ -0,0 +1,89 @@
+package bridge
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/loom/loom/internal/memory"
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// GetNamedCheckpoints returns the current conversation's named checkpoints, oldest first.
+func (a *App) GetNamedCheckpoints() []memory.NamedCheckpoint {
+	out := []memory.NamedCheckpoint{}
+	if a.engine == nil {
+		return out
+	}
+	return append(out, a.engine.NamedCheckpoints()...)
+}
+
+// CreateNamedCheckpoint names the current point of the conversation's timeline.
+// Returns: { checkpoint } or { error }.
+func (a *App) CreateNamedCheckpoint(name string) map[string]interface{} {
+	if a.engine == nil {
+		return map[string]interface{}{"error": "engine not initialized"}
+	}
+	c, err := a.engine.CreateNamedCheckpoint(name, "user")
+	if err != nil {
+		return map[string]interface{}{"error": err.Error()}
+	}
+	a.SendChat("system", fmt.Sprintf("Checkpoint %q created at step %d.", c.Name, c.Step))
+	return map[string]interface{}{"checkpoint": c}
+}
+
+// RemoveNamedCheckpoint deletes a named checkpoint; the timeline is not changed.
+// Returns an error message or "".
+func (a *App) RemoveNamedCheckpoint(name string) string {
+	if a.engine == nil {
+		return "engine not initialized"
+	}
+	if err := a.engine.RemoveNamedCheckpoint(name); err != nil {
+		return err.Error()
+	}
+	return ""
+}
+
+// DiffNamedCheckpoint returns the changes made since a named checkpoint.
+// Returns: { from, to, files: [{ path, status, added, removed, omitted, diff }], diff } or { error }.
+func (a *App) DiffNamedCheckpoint(name string) map[string]interface{} {
+	if a.engine == nil {
+		return map[string]interface{}{"error": "engine not initialized"}
+	}
+	res, err := a.engine.DiffNamedCheckpoint(name)
+	if err != nil {
+		return map[string]interface{}{"error": err.Error()}
+	}
+	return map[string]interface{}{"from": res.From, "to": res.To, "files": res.Files, "diff": res.Diff}
+}
+
+// RevertToNamedCheckpoint restores files (all changed since the checkpoint when paths
+// is empty) to their state at a named checkpoint.
+// Returns: { reverted: [path], error? }.
+func (a *App) RevertToNamedCheckpoint(name string, paths []string) map[string]interface{} {
+	if a.engine == nil {
+		return map[string]interface{}{"error": "engine not initialized"}
+	}
+	if err := a.ensureWritable(); err != nil {
+		return map[string]interface{}{"error": err.Error()}
+	}
+	reverted, err := a.engine.RevertToNamedCheckpoint(name, paths)
+	if reverted == nil {
+		reverted = []string{}
+	}
+	out := map[string]interface{}{"reverted": reverted}
+	if len(reverted) > 0 {
+		a.audit("edit", map[string]interface{}{"source": "revert", "ok": err == nil, "checkpoint": name, "paths": reverted})
+		a.SendChat("system", fmt.Sprintf("Reverted %d file(s) to checkpoint %q: %s", len(reverted), name, strings.Join(reverted, ", ")))
+	}
+	if err != nil {
+		out["error"] = err.Error()
+	}
+	return out
+}
+
+// EmitNamedCheckpoint tells the UI that a named checkpoint was created.
+func (a *App) EmitNamedCheckpoint(c memory.NamedCheckpoint) {
+	if a.ctx != nil {
+		runtime.EventsEmit(a.ctx, "timeline:checkpoint", c)
+	}
+}
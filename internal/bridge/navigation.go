@@ -0,0 +1,69 @@
+package bridge
+
+import (
+	"context"
+	"time"
+
+	"github.com/loom/loom/internal/symbols"
+)
+
+// navigationTimeout bounds one editor navigation lookup.
+const navigationTimeout = 5 * time.Second
+
+// navigator returns the workspace's symbol index for editor navigation, or nil
+// before it is ready.
+func (a *App) navigator() symbols.Navigator {
+	nav, _ := a.symbolsSvc.(symbols.Navigator)
+	return nav
+}
+
+// PeekDefinition resolves the identifier at a 1-based line and column of a workspace
+// file to its definitions, best first, each with the code it spans. It uses the symbol
+// index only, without the model.
+// Returns: { word, definitions: [{ symbol, range, snippet }] } or { error }.
+func (a *App) PeekDefinition(path string, line, column int) map[string]interface{} {
+	nav := a.navigator()
+	if nav == nil {
+		return map[string]interface{}{"error": "symbol index not available"}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), navigationTimeout)
+	defer cancel()
+	word, defs, err := symbols.Definitions(ctx, nav, path, line, column)
+	if err != nil {
+		return map[string]interface{}{"error": err.Error()}
+	}
+	return map[string]interface{}{"word": word, "definitions": defs}
+}
+
+// FindReferences returns the indexed references and definitions of the identifier at
+// a 1-based line and column of a workspace file, ordered by file and line.
+// Returns: { word, references: [{ file, line_start, line_end, kind }] } or { error }.
+func (a *App) FindReferences(path string, line, column int) map[string]interface{} {
+	nav := a.navigator()
+	if nav == nil {
+		return map[string]interface{}{"error": "symbol index not available"}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), navigationTimeout)
+	defer cancel()
+	word, refs, err := symbols.References(ctx, nav, path, line, column)
+	if err != nil {
+		return map[string]interface{}{"error": err.Error()}
+	}
+	return map[string]interface{}{"word": word, "references": refs}
+}
+
+// GetDocumentSymbols returns the symbol outline of a workspace file for the editor.
+// Returns: { symbols: [{ name, kind, span, children }] } or { error }.
+func (a *App) GetDocumentSymbols(path string) map[string]interface{} {
+	nav := a.navigator()
+	if nav == nil {
+		return map[string]interface{}{"error": "symbol index not available"}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), navigationTimeout)
+	defer cancel()
+	nodes, err := symbols.DocumentSymbols(ctx, nav, path)
+	if err != nil {
+		return map[string]interface{}{"error": err.Error()}
+	}
+	return map[string]interface{}{"symbols": nodes}
+}
@@ -0,0 +1,300 @@
+package bridge
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/loom/loom/internal/adapter"
+	"github.com/loom/loom/internal/config"
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// Onboarding step statuses.
+const (
+	stepPending = "pending"
+	stepRunning = "running"
+	stepOK      = "ok"
+	stepFailed  = "failed"
+	stepSkipped = "skipped"
+)
+
+// OnboardingStep is one stage of the first-run flow as shown to the UI.
+type OnboardingStep struct {
+	ID         string `json:"id"`
+	Title      string `json:"title"`
+	Status     string `json:"status"`
+	Detail     string `json:"detail,omitempty"`
+	DurationMs int64  `json:"duration_ms,omitempty"`
+}
+
+// OnboardingState is the progress of the first-run flow.
+type OnboardingState struct {
+	// Required is true until onboarding completed or was skipped
+	Required  bool                 `json:"required"`
+	Running   bool                 `json:"running"`
+	Steps     []OnboardingStep     `json:"steps"`
+	Providers []adapter.PingResult `json:"providers,omitempty"`
+	// SuggestedModel is the default model picked from the fastest validated provider
+	SuggestedModel string `json:"suggested_model,omitempty"`
+}
+
+// onboardingSteps are the stages in the order they run.
+var onboardingSteps = []OnboardingStep{
+	{ID: "keys", Title: "Validate API keys"},
+	{ID: "model", Title: "Choose a default model"},
+	{ID: "directory", Title: "Create the .loom directory"},
+	{ID: "sample_tool", Title: "Run a sample tool call"},
+}
+
+// onboardingProviders maps the keys accepted by RunOnboarding to providers.
+var onboardingProviders = map[string]adapter.Provider{
+	"openai":     adapter.ProviderOpenAI,
+	"anthropic":  adapter.ProviderAnthropic,
+	"openrouter": adapter.ProviderOpenRouter,
+	"gemini":     adapter.ProviderGemini,
+	"ollama":     adapter.ProviderOllama,
+}
+
+// GetOnboardingState reports whether first-run onboarding is needed and the progress
+// of the last run.
+func (a *App) GetOnboardingState() OnboardingState {
+	a.onboardingMu.Lock()
+	defer a.onboardingMu.Unlock()
+	a.ensureSettingsLoaded()
+	st := a.onboarding
+	if st.Steps == nil {
+		st.Steps = newOnboardingSteps()
+	}
+	st.Required = !a.settings.OnboardingCompleted
+	return st
+}
+
+// RunOnboarding runs the first-run flow with the entered credentials, keyed by
+// "openai", "anthropic", "openrouter", "gemini" (API keys) and "ollama" (endpoint, empty for the
+// default). Each step's status is emitted as an "onboarding:step" event while it runs;
+// the final state is returned. Valid keys and the suggested model are saved, and
+// onboarding is marked complete when every step passed.
+func (a *App) RunOnboarding(credentials map[string]string) OnboardingState {
+	a.onboardingMu.Lock()
+	if a.onboarding.Running {
+		st := a.onboarding
+		a.onboardingMu.Unlock()
+		return st
+	}
+	a.onboarding = OnboardingState{Required: true, Running: true, Steps: newOnboardingSteps()}
+	a.onboardingMu.Unlock()
+
+	ctx := a.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	a.ensureSettingsLoaded()
+	s := a.settings
+
+	ok := a.runOnboardingStep("keys", func() (string, error) {
+		results := a.validateCredentials(ctx, credentials)
+		a.onboardingMu.Lock()
+		a.onboarding.Providers = results
+		a.onboardingMu.Unlock()
+		var valid, failed []string
+		for _, r := range results {
+			if !r.OK {
+				failed = append(failed, fmt.Sprintf("%s: %s", r.Provider, r.Error))
+				continue
+			}
+			valid = append(valid, fmt.Sprintf("%s (%d ms)", r.Provider, r.LatencyMs))
+			switch r.Provider {
+			case adapter.ProviderOpenAI:
+				s.OpenAIAPIKey = strings.TrimSpace(credentials["openai"])
+			case adapter.ProviderAnthropic:
+				s.AnthropicAPIKey = strings.TrimSpace(credentials["anthropic"])
+			case adapter.ProviderOpenRouter:
+				s.OpenRouterAPIKey = strings.TrimSpace(credentials["openrouter"])
+			case adapter.ProviderGemini:
+				s.GeminiAPIKey = strings.TrimSpace(credentials["gemini"])
+			case adapter.ProviderOllama:
+				if ep := strings.TrimSpace(credentials["ollama"]); ep != "" {
+					s.OllamaEndpoint = ep
+				}
+			}
+		}
+		if len(valid) == 0 {
+			if len(failed) == 0 {
+				return "", errors.New("enter at least one API key or an Ollama endpoint")
+			}
+			return "", fmt.Errorf("no provider accepted the credentials: %s", strings.Join(failed, "; "))
+		}
+		detail := "Validated " + strings.Join(valid, ", ")
+		if len(failed) > 0 {
+			detail += "; failed " + strings.Join(failed, "; ")
+		}
+		return detail, nil
+	})
+
+	ok = ok && a.runOnboardingStep("model", func() (string, error) {
+		a.onboardingMu.Lock()
+		suggested := adapter.SuggestModel(a.onboarding.Providers)
+		a.onboarding.SuggestedModel = suggested
+		a.onboardingMu.Unlock()
+		if suggested == "" {
+			return "", errors.New("no validated provider to choose a model from")
+		}
+		s.LastModel = suggested
+		s.EnsureSelectedModels()
+		if !slices.Contains(s.SelectedModels, suggested) {
+			s.SelectedModels = append(s.SelectedModels, suggested)
+		}
+		return suggested, nil
+	})
+
+	ok = ok && a.runOnboardingStep("directory", func() (string, error) {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		dir := filepath.Join(home, ".loom")
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return "", err
+		}
+		a.applyAndSaveSettings(s)
+		a.SetModel(s.LastModel)
+		return dir, nil
+	})
+
+	ok = ok && a.runOnboardingStep("sample_tool", func() (string, error) {
+		if a.tools == nil || a.engine == nil || strings.TrimSpace(a.engine.Workspace()) == "" {
+			return "", errOnboardingSkip
+		}
+		out, err := a.tools.Invoke(ctx, "list_dir", json.RawMessage(`{"path":"."}`))
+		if err != nil {
+			return "", fmt.Errorf("list_dir failed: %w", err)
+		}
+		data, _ := json.Marshal(out)
+		return fmt.Sprintf("list_dir returned %d bytes for the workspace", len(data)), nil
+	})
+
+	a.onboardingMu.Lock()
+	a.onboarding.Running = false
+	if ok {
+		s = a.settings
+		s.OnboardingCompleted = true
+		_ = config.Save(s)
+		a.settings = s
+	}
+	a.onboarding.Required = !a.settings.OnboardingCompleted
+	st := a.onboarding
+	a.onboardingMu.Unlock()
+
+	a.audit("settings", map[string]interface{}{"onboarding_completed": ok, "suggested_model": st.SuggestedModel})
+	return st
+}
+
+// SkipOnboarding marks first-run onboarding as done without running it.
+func (a *App) SkipOnboarding() {
+	a.onboardingMu.Lock()
+	defer a.onboardingMu.Unlock()
+	a.ensureSettingsLoaded()
+	a.settings.OnboardingCompleted = true
+	_ = config.Save(a.settings)
+	a.audit("settings", map[string]interface{}{"onboarding_skipped": true})
+}
+
+// errOnboardingSkip marks a step that does not apply (e.g. no workspace is open).
+var errOnboardingSkip = errors.New("skipped")
+
+// runOnboardingStep runs one step, publishing its status before and after. It
+// returns false when the step failed; skipped steps count as passed.
+func (a *App) runOnboardingStep(id string, run func() (string, error)) bool {
+	a.setOnboardingStep(id, stepRunning, "", 0)
+	start := time.Now()
+	detail, err := run()
+	elapsed := time.Since(start).Milliseconds()
+	switch {
+	case errors.Is(err, errOnboardingSkip):
+		a.setOnboardingStep(id, stepSkipped, "Open a workspace to try a tool call", elapsed)
+		return true
+	case err != nil:
+		a.setOnboardingStep(id, stepFailed, err.Error(), elapsed)
+		a.skipRemainingOnboardingSteps()
+		return false
+	}
+	a.setOnboardingStep(id, stepOK, detail, elapsed)
+	return true
+}
+
+func (a *App) setOnboardingStep(id, status, detail string, durationMs int64) {
+	a.onboardingMu.Lock()
+	var step OnboardingStep
+	for i := range a.onboarding.Steps {
+		if a.onboarding.Steps[i].ID == id {
+			a.onboarding.Steps[i].Status = status
+			a.onboarding.Steps[i].Detail = detail
+			a.onboarding.Steps[i].DurationMs = durationMs
+			step = a.onboarding.Steps[i]
+		}
+	}
+	a.onboardingMu.Unlock()
+	if a.ctx != nil {
+		runtime.EventsEmit(a.ctx, "onboarding:step", step)
+	}
+}
+
+// skipRemainingOnboardingSteps marks the steps after a failure as skipped.
+func (a *App) skipRemainingOnboardingSteps() {
+	a.onboardingMu.Lock()
+	var pending []string
+	for _, s := range a.onboarding.Steps {
+		if s.Status == stepPending {
+			pending = append(pending, s.ID)
+		}
+	}
+	a.onboardingMu.Unlock()
+	for _, id := range pending {
+		a.setOnboardingStep(id, stepSkipped, "Skipped after a failed step", 0)
+	}
+}
+
+// validateCredentials pings every provider with a non-empty credential concurrently.
+// Ollama is checked only when an endpoint was entered or the key is present.
+func (a *App) validateCredentials(ctx context.Context, credentials map[string]string) []adapter.PingResult {
+	var configs []adapter.Config
+	for _, name := range []string{"anthropic", "openai", "openrouter", "gemini", "ollama"} {
+		value, present := credentials[name]
+		value = strings.TrimSpace(value)
+		provider := onboardingProviders[name]
+		switch {
+		case provider == adapter.ProviderOllama && present:
+			configs = append(configs, adapter.Config{Provider: provider, Endpoint: value})
+		case value != "":
+			configs = append(configs, adapter.Config{Provider: provider, APIKey: value})
+		}
+	}
+	results := make([]adapter.PingResult, len(configs))
+	done := make(chan struct{})
+	for i, cfg := range configs {
+		go func(i int, cfg adapter.Config) {
+			results[i] = adapter.Ping(ctx, cfg)
+			done <- struct{}{}
+		}(i, cfg)
+	}
+	for range configs {
+		<-done
+	}
+	return results
+}
+
+func newOnboardingSteps() []OnboardingStep {
+	steps := make([]OnboardingStep, len(onboardingSteps))
+	copy(steps, onboardingSteps)
+	for i := range steps {
+		steps[i].Status = stepPending
+	}
+	return steps
+}
@@ -0,0 +1,85 @@
+package bridge
+
+import (
+	"strings"
+
+	"github.com/loom/loom/internal/config"
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// OutputStylesInfo is returned by GetOutputStyles.
+type OutputStylesInfo struct {
+	Styles []config.OutputStyle `json:"styles"`
+	// Default is the style of conversations that did not pick one
+	Default string `json:"default"`
+	// Current is the style in effect for the current conversation
+	Current string `json:"current"`
+}
+
+// GetOutputStyles lists the built-in and saved output styles, the default and the style
+// of the current conversation.
+func (a *App) GetOutputStyles() OutputStylesInfo {
+	a.ensureSettingsLoaded()
+	info := OutputStylesInfo{
+		Styles:  a.settings.AllOutputStyles(),
+		Default: a.settings.OutputStyle,
+		Current: config.DefaultOutputStyle,
+	}
+	if info.Default == "" {
+		info.Default = config.DefaultOutputStyle
+	}
+	if a.engine != nil {
+		info.Current = a.engine.OutputStyle()
+	}
+	return info
+}
+
+// SaveOutputStyles replaces the saved output styles and the default style ("" or
+// "default" for none). Returns an error message, or "" on success.
+func (a *App) SaveOutputStyles(styles []config.OutputStyle, defaultStyle string) string {
+	a.ensureSettingsLoaded()
+	s := a.settings
+	out := make([]config.OutputStyle, 0, len(styles))
+	for _, st := range styles {
+		st.Name, st.Language = strings.TrimSpace(st.Name), strings.TrimSpace(st.Language)
+		st.Builtin = false
+		out = append(out, st)
+	}
+	if err := config.ValidateOutputStyles(out); err != nil {
+		return err.Error()
+	}
+	s.OutputStyles = out
+	defaultStyle = strings.TrimSpace(defaultStyle)
+	if defaultStyle == "" || strings.EqualFold(defaultStyle, config.DefaultOutputStyle) {
+		s.OutputStyle = ""
+	} else if st, ok := s.OutputStyleNamed(defaultStyle); ok {
+		s.OutputStyle = st.Name
+	} else {
+		return "unknown output style " + defaultStyle
+	}
+	if err := config.Save(s); err != nil {
+		return err.Error()
+	}
+	a.settings = s
+	a.audit("settings", map[string]interface{}{"output_styles": len(out), "default_output_style": s.OutputStyle})
+	return ""
+}
+
+// SetOutputStyle switches the output style of the current conversation. The switch
+// applies from the next message on and is marked in the transcript. Returns an error
+// message, or "" on success.
+func (a *App) SetOutputStyle(name string) string {
+	if a.engine == nil {
+		return "engine not initialized"
+	}
+	if err := a.engine.SetOutputStyle(name); err != nil {
+		return err.Error()
+	}
+	current := a.engine.OutputStyle()
+	a.audit("settings", map[string]interface{}{"output_style": current})
+	a.SendChat("system", "Output style: "+current)
+	if a.ctx != nil {
+		runtime.EventsEmit(a.ctx, "system:output_style", current)
+	}
+	return ""
+}
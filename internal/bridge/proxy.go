@@ -0,0 +1,50 @@
+package bridge
+
+import (
+	"github.com/loom/loom/internal/config"
+	"github.com/loom/loom/internal/proxy"
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// ProxySettingsState is the proxy configuration shown in the "Network" settings
+// section: what is configured, and what is in effect once the environment fills in
+// the fields left empty.
+type ProxySettingsState struct {
+	Settings  proxy.Config `json:"settings"`
+	Effective proxy.Config `json:"effective"`
+}
+
+// GetProxySettings returns the configured and the effective proxy settings.
+func (a *App) GetProxySettings() ProxySettingsState {
+	a.ensureSettingsLoaded()
+	return ProxySettingsState{Settings: a.settings.Proxy, Effective: a.settings.Proxy.Effective()}
+}
+
+// SetProxySettings validates, saves and applies the proxy settings. Model requests,
+// http_request and import_url use them right away; MCP servers pick them up when they
+// are next started. Returns an error message, or "" on success.
+func (a *App) SetProxySettings(c proxy.Config) string {
+	a.ensureSettingsLoaded()
+	if err := c.Validate(); err != nil {
+		return err.Error()
+	}
+	s := a.settings
+	s.Proxy = c
+	if err := config.Save(s); err != nil {
+		return err.Error()
+	}
+	if err := proxy.Apply(c); err != nil {
+		return err.Error()
+	}
+	a.settings = s
+	// Record whether a proxy is set, never its URL, which may carry credentials
+	a.audit("settings", map[string]interface{}{
+		"proxy":     c.HTTPProxy != "" || c.HTTPSProxy != "",
+		"no_proxy":  c.NoProxy != "",
+		"ca_bundle": c.CABundle,
+	})
+	if a.ctx != nil {
+		runtime.EventsEmit(a.ctx, "settings:proxy", a.GetProxySettings())
+	}
+	return ""
+}
@@ -0,0 +1,272 @@
+package bridge
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	goruntime "runtime"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"github.com/loom/loom/internal/engine"
+	"github.com/loom/loom/internal/hygiene"
+	"github.com/loom/loom/internal/symbols"
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+const (
+	// reportLogLines is how many of the most recent log lines a bug report includes
+	reportLogLines = 400
+	// maxLogFileSize is when the log is rotated to loom.log.1 at startup
+	maxLogFileSize = 1 << 20
+)
+
+// reportSecretKeyRe matches the names of fields whose values are never included in a
+// bug report, only whether they are set.
+var reportSecretKeyRe = regexp.MustCompile(`(?i)(api_?key|token|secret|password|authorization|organization|proxy)`)
+
+// bearerRe matches bearer credentials, which the secret scan does not recognise by format.
+var bearerRe = regexp.MustCompile(`(?i)\bbearer\s+[A-Za-z0-9._~+/-]{12,}=*`)
+
+// ReportVersions identifies the build and platform a bug report was made on.
+type ReportVersions struct {
+	Loom     string `json:"loom"`
+	Revision string `json:"revision,omitempty"`
+	Modified bool   `json:"modified,omitempty"`
+	Go       string `json:"go"`
+	OS       string `json:"os"`
+	Arch     string `json:"arch"`
+	Wails    string `json:"wails,omitempty"`
+}
+
+// BugReport is the diagnostic bundle attached to GitHub issues. It holds no API keys,
+// and paths under the home directory are shortened to ~.
+type BugReport struct {
+	GeneratedAt  time.Time          `json:"generated_at"`
+	Versions     ReportVersions     `json:"versions"`
+	Settings     any                `json:"settings"`
+	FeatureFlags map[string]bool    `json:"feature_flags"`
+	Diagnostics  Diagnostics        `json:"diagnostics"`
+	Index        symbols.IndexStats `json:"index"`
+	LastRun      *engine.RunSummary `json:"last_run,omitempty"`
+	// Logs are the most recent lines of ~/.loom/logs/loom.log
+	Logs []string `json:"logs"`
+}
+
+// LogFilePath returns the log file the app writes next to the settings,
+// ~/.loom/logs/loom.log.
+func LogFilePath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve HOME: %w", err)
+	}
+	return filepath.Join(home, ".loom", "logs", "loom.log"), nil
+}
+
+// CaptureLogs returns a writer that passes log output on to w and appends it to the log
+// file, whose tail goes into bug reports. A log grown past 1 MB is kept as loom.log.1
+// and a new one is started. Without a writable log file, w is returned unchanged.
+func CaptureLogs(w io.Writer) io.Writer {
+	path, err := LogFilePath()
+	if err != nil || os.MkdirAll(filepath.Dir(path), 0o700) != nil {
+		return w
+	}
+	if info, err := os.Stat(path); err == nil && info.Size() > maxLogFileSize {
+		_ = os.Rename(path, path+".1")
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return w
+	}
+	return io.MultiWriter(w, f)
+}
+
+// BuildBugReport collects versions, settings, health diagnostics, index statistics,
+// the last run summary and recent logs, with secrets removed. Like GetDiagnostics it
+// pings the provider, so it may take a few seconds.
+func (a *App) BuildBugReport() BugReport {
+	a.ensureSettingsLoaded()
+	r := BugReport{
+		GeneratedAt:  time.Now(),
+		Versions:     buildVersions(),
+		FeatureFlags: a.settings.EffectiveFeatureFlags(),
+		Diagnostics:  a.GetDiagnostics(),
+		Index:        a.GetIndexStats(),
+		Logs:         recentLogLines(reportLogLines),
+	}
+	r.Settings = redactReportValue(a.settings)
+	if r.Diagnostics.Workspace != "" && a.engine != nil {
+		if runs, err := engine.LoadRunSummaries(r.Diagnostics.Workspace, a.engine.Sealer(), 1); err == nil && len(runs) > 0 {
+			r.LastRun = &runs[0]
+		}
+	}
+	return r
+}
+
+// WriteBugReport writes the report as a zip archive: report.json with everything,
+// diagnostics.txt in the `loom doctor` format and loom.log. Every string in it is
+// passed through the redaction again, including the log lines.
+func WriteBugReport(w io.Writer, r BugReport) error {
+	zw := zip.NewWriter(w)
+	add := func(name string, data []byte) error {
+		f, err := zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: r.GeneratedAt})
+		if err != nil {
+			return err
+		}
+		_, err = f.Write(data)
+		return err
+	}
+	report, err := json.MarshalIndent(redactReportValue(r), "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := add("report.json", report); err != nil {
+		return err
+	}
+	if err := add("diagnostics.txt", []byte(redactReportText(FormatDiagnostics(r.Diagnostics)))); err != nil {
+		return err
+	}
+	logs := make([]string, len(r.Logs))
+	for i, l := range r.Logs {
+		logs[i] = redactReportText(l)
+	}
+	if err := add("loom.log", []byte(strings.Join(logs, "\n"))); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// ExportBugReport asks where to save a bug report bundle and writes it there.
+// Returns: { path, logs } (empty when cancelled) or { error }.
+func (a *App) ExportBugReport() map[string]interface{} {
+	if a.ctx == nil {
+		return map[string]interface{}{"error": "app not initialized"}
+	}
+	path, err := runtime.SaveFileDialog(a.ctx, runtime.SaveDialogOptions{
+		Title:           "Save Bug Report",
+		DefaultFilename: fmt.Sprintf("loom-report-%s.zip", time.Now().Format("20060102-150405")),
+	})
+	if err != nil {
+		return map[string]interface{}{"error": err.Error()}
+	}
+	if path == "" {
+		return map[string]interface{}{}
+	}
+	r := a.BuildBugReport()
+	var buf bytes.Buffer
+	if err := WriteBugReport(&buf, r); err != nil {
+		return map[string]interface{}{"error": err.Error()}
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0o600); err != nil {
+		return map[string]interface{}{"error": err.Error()}
+	}
+	a.audit("bug_report", map[string]interface{}{"path": path})
+	return map[string]interface{}{"path": path, "logs": len(r.Logs)}
+}
+
+// buildVersions reads the module version and VCS stamp from the build info.
+func buildVersions() ReportVersions {
+	v := ReportVersions{Loom: "(devel)", Go: goruntime.Version(), OS: goruntime.GOOS, Arch: goruntime.GOARCH}
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return v
+	}
+	if info.Main.Version != "" {
+		v.Loom = info.Main.Version
+	}
+	for _, s := range info.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			v.Revision = s.Value
+		case "vcs.modified":
+			v.Modified = s.Value == "true"
+		}
+	}
+	for _, dep := range info.Deps {
+		if dep.Path == "github.com/wailsapp/wails/v2" {
+			v.Wails = dep.Version
+		}
+	}
+	return v
+}
+
+// recentLogLines returns the last n lines of the log file and of its rotated
+// predecessor, oldest first.
+func recentLogLines(n int) []string {
+	path, err := LogFilePath()
+	if err != nil {
+		return []string{}
+	}
+	var lines []string
+	for _, p := range []string{path + ".1", path} {
+		data, err := os.ReadFile(p)
+		if err != nil {
+			continue
+		}
+		lines = append(lines, strings.Split(strings.TrimRight(string(data), "\n"), "\n")...)
+	}
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	if lines == nil {
+		return []string{}
+	}
+	return lines
+}
+
+// redactReportValue converts v to its JSON form with secrets removed: fields named like
+// credentials only say whether they are set, and strings lose recognisable credentials
+// and the home directory.
+func redactReportValue(v any) any {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+	var generic any
+	if json.Unmarshal(data, &generic) != nil {
+		return nil
+	}
+	return redactJSON(generic)
+}
+
+func redactJSON(v any) any {
+	switch t := v.(type) {
+	case map[string]any:
+		out := make(map[string]any, len(t))
+		for k, val := range t {
+			if s, ok := val.(string); ok && reportSecretKeyRe.MatchString(k) {
+				if strings.TrimSpace(s) != "" {
+					val = "[set]"
+				}
+			} else {
+				val = redactJSON(val)
+			}
+			out[redactReportText(k)] = val
+		}
+		return out
+	case []any:
+		for i := range t {
+			t[i] = redactJSON(t[i])
+		}
+		return t
+	case string:
+		return redactReportText(t)
+	}
+	return v
+}
+
+// redactReportText removes credentials and shortens paths under the home directory to ~.
+func redactReportText(s string) string {
+	s = hygiene.RedactSecrets(s)
+	s = bearerRe.ReplaceAllString(s, "Bearer [REDACTED]")
+	if home, err := os.UserHomeDir(); err == nil && len(home) > 1 {
+		s = strings.ReplaceAll(s, home, "~")
+	}
+	return s
+}
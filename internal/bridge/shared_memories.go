@@ -0,0 +1,51 @@
+package bridge
+
+import (
+	"fmt"
+	"log"
+)
+
+// ExportMemories writes the project and directory memories to a markdown file that
+// can be committed and shared; path is relative to the workspace, and empty means
+// .loom/memories.md. Returns: { path, count } or { error }.
+func (a *App) ExportMemories(path string) map[string]interface{} {
+	if a.engine == nil {
+		return map[string]interface{}{"error": "engine not initialized"}
+	}
+	abs, n, err := a.engine.ExportSharedMemories(path)
+	if err != nil {
+		return map[string]interface{}{"error": err.Error()}
+	}
+	a.audit("memories_export", map[string]interface{}{"path": abs, "count": n})
+	return map[string]interface{}{"path": abs, "count": n}
+}
+
+// ImportMemories merges the memories of a markdown file into the project's memories,
+// replacing local versions of the memories it contains. Returns: { added, updated,
+// unchanged, kept } or { error }.
+func (a *App) ImportMemories(path string) map[string]interface{} {
+	if a.engine == nil {
+		return map[string]interface{}{"error": "engine not initialized"}
+	}
+	res, err := a.engine.ImportSharedMemories(path, true)
+	if err != nil {
+		return map[string]interface{}{"error": err.Error()}
+	}
+	a.audit("memories_import", map[string]interface{}{"path": path, "added": res.Added, "updated": res.Updated})
+	return map[string]interface{}{"added": res.Added, "updated": res.Updated, "unchanged": res.Unchanged, "kept": res.Kept}
+}
+
+// syncSharedMemories applies changes to the workspace's shared memories file when the
+// workspace opens and reports them in the chat.
+func (a *App) syncSharedMemories() {
+	res, ok, err := a.engine.SyncSharedMemories()
+	switch {
+	case !ok:
+		return
+	case err != nil:
+		log.Printf("Warning: failed to sync shared memories: %v", err)
+		a.SendChat("system", "Shared memories were not synced: "+err.Error())
+	case res.Added+res.Updated > 0:
+		a.SendChat("system", fmt.Sprintf("Synced shared memories: %d added, %d updated.", res.Added, res.Updated))
+	}
+}
@@ -0,0 +1,37 @@
+package bridge
+
+// EstimateMessageBudget projects the request that sending message with attachments
+// (workspace-relative paths or "@attachments/" refs) would make, so the composer can
+// warn before an expensive prompt or one that overflows the model's context window.
+// Returns: { model, system_tokens, tool_tokens, history_tokens, message_tokens,
+// attachment_tokens, hint_tokens, total_tokens, context_window, cost_usd, price_known,
+// compacts, dropped, fits } or { error }.
+func (a *App) EstimateMessageBudget(message string, attachments []string) map[string]interface{} {
+	if a.engine == nil {
+		return map[string]interface{}{"error": "engine not initialized"}
+	}
+	b, err := a.engine.EstimateRequest(message, attachments)
+	if err != nil {
+		return map[string]interface{}{"error": err.Error()}
+	}
+	dropped := b.Dropped
+	if dropped == nil {
+		dropped = []string{}
+	}
+	return map[string]interface{}{
+		"model":             b.Model,
+		"system_tokens":     b.SystemTokens,
+		"tool_tokens":       b.ToolTokens,
+		"history_tokens":    b.HistoryTokens,
+		"message_tokens":    b.MessageTokens,
+		"attachment_tokens": b.AttachmentTokens,
+		"hint_tokens":       b.HintTokens,
+		"total_tokens":      b.TotalTokens,
+		"context_window":    b.ContextWindow,
+		"cost_usd":          b.CostUSD,
+		"price_known":       b.PriceKnown,
+		"compacts":          b.Compacts,
+		"dropped":           dropped,
+		"fits":              b.Fits,
+	}
+}
@@ -0,0 +1,82 @@
+package bridge
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/loom/loom/internal/editor"
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// ListTrash returns the files and directories the agent deleted in the current
+// workspace that can still be restored, most recent first.
+func (a *App) ListTrash() []editor.TrashEntry {
+	ws := a.workspace()
+	if ws == "" {
+		return []editor.TrashEntry{}
+	}
+	entries, err := editor.ListTrash(ws)
+	if err != nil {
+		log.Printf("Warning: failed to list the trash: %v", err)
+		return []editor.TrashEntry{}
+	}
+	return entries
+}
+
+// RestoreTrash moves a trashed item back to its original path. Returns an error
+// message, or "" on success.
+func (a *App) RestoreTrash(id string) string {
+	ws := a.workspace()
+	if ws == "" {
+		return "no workspace open"
+	}
+	entry, err := editor.RestoreFromTrash(ws, id)
+	if err != nil {
+		return err.Error()
+	}
+	a.audit("trash_restore", map[string]interface{}{"path": entry.Path, "trash_id": id})
+	a.SendChat("system", fmt.Sprintf("Restored %s from the trash.", entry.Path))
+	a.emitTrashChanged()
+	return ""
+}
+
+// DiscardTrash permanently deletes one trashed item, or every item when id is empty.
+// Returns an error message, or "" on success.
+func (a *App) DiscardTrash(id string) string {
+	ws := a.workspace()
+	if ws == "" {
+		return "no workspace open"
+	}
+	if id == "" {
+		n, err := editor.PurgeTrash(ws, -1)
+		if err != nil {
+			return err.Error()
+		}
+		a.audit("trash_empty", map[string]interface{}{"removed": n})
+	} else {
+		if err := editor.DiscardTrash(ws, id); err != nil {
+			return err.Error()
+		}
+		a.audit("trash_discard", map[string]interface{}{"trash_id": id})
+	}
+	a.emitTrashChanged()
+	return ""
+}
+
+// purgeTrash removes trash entries past their retention when a workspace opens.
+func (a *App) purgeTrash(ws string) {
+	n, err := editor.PurgeTrash(ws, editor.TrashRetentionFromEnv())
+	if err != nil {
+		log.Printf("Warning: failed to purge the trash: %v", err)
+		return
+	}
+	if n > 0 {
+		log.Printf("Purged %d expired trash entries in %s", n, ws)
+	}
+}
+
+func (a *App) emitTrashChanged() {
+	if a.ctx != nil {
+		runtime.EventsEmit(a.ctx, "trash:changed")
+	}
+}
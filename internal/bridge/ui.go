@@ -1,30 +1,39 @@
 package bridge
 
 import (
+	"bytes"
 	"context"
 	"crypto/sha1"
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"slices"
 	"strings"
+	"sync"
 	"time"
 	"unicode"
 
 	gitignore "github.com/go-git/go-git/v5/plumbing/format/gitignore"
 	"github.com/loom/loom/internal/adapter"
 	"github.com/loom/loom/internal/config"
+	"github.com/loom/loom/internal/editor"
 	"github.com/loom/loom/internal/engine"
 	"github.com/loom/loom/internal/indexer"
+	"github.com/loom/loom/internal/knowledge"
 	"github.com/loom/loom/internal/mcp"
 	"github.com/loom/loom/internal/memory"
+	"github.com/loom/loom/internal/policy"
 	"github.com/loom/loom/internal/profiler"
+	"github.com/loom/loom/internal/proxy"
 	"github.com/loom/loom/internal/symbols"
 	"github.com/loom/loom/internal/tool"
+	"github.com/loom/loom/internal/wslock"
 	"github.com/wailsapp/wails/v2/pkg/runtime"
 )
 
@@ -41,6 +50,10 @@ type App struct {
 	gitMatcher gitignore.Matcher
 	// mcp manager persisted across SetWorkspace calls to avoid double startups
 	mcpManager *mcp.Manager
+	// mcpTools are the curated tools of the MCP servers, including hidden ones
+	mcpTools []mcp.CuratedTool
+	// mcpPrompts are the servers' prompt templates, offered as slash commands
+	mcpPrompts []mcp.PromptSpec
 	// debounce timestamp for SetWorkspace to coalesce rapid calls
 	lastWorkspaceSet time.Time
 	// symbols reporter
@@ -52,8 +65,28 @@ type App struct {
 		IndexAll(context.Context) error
 		Count(context.Context) (int, error)
 	}
+	// roots holds the tools and symbol index of each further workspace root by name
+	// (see workspaces.go)
+	roots map[string]*workspaceRoot
+	// tabs are the conversations running next to the current one, by id
+	// (see conversation_tabs.go)
+	tabs   map[string]*conversationTab
+	tabsMu sync.Mutex
 	// memory store for creating new projects when switching workspaces
 	memoryStore *memory.Store
+	// demoMode replays a recorded trace and keeps every registry read-only
+	demoMode bool
+	// activeKeys reports the key in use when the engine's adapter rotates over key profiles
+	activeKeys activeKeyReporter
+	// wsLock is held while this instance owns its workspace (see workspace_lock.go);
+	// readOnlyReason is set and lockHolder names the owner while another instance does
+	wsLock         *wslock.Lock
+	wsLockPath     string
+	readOnlyReason string
+	lockHolder     *wslock.Holder
+	// first-run onboarding progress
+	onboardingMu sync.Mutex
+	onboarding   OnboardingState
 }
 
 // NewApp creates a new App application struct.
@@ -82,10 +115,16 @@ func (a *App) WithConfig(config adapter.Config) *App {
 // WithSettings sets persisted settings for the UI bridge.
 func (a *App) WithSettings(s config.Settings) *App {
 	a.settings = s
+	if err := proxy.Apply(s.Proxy); err != nil {
+		log.Printf("Warning: proxy settings not applied: %v", err)
+	}
 	// Apply settings to engine if available
 	if a.engine != nil {
 		a.engine.SetAutoApprove(s.AutoApproveShell, s.AutoApproveEdits)
 		a.engine.SetPersonality(s.Personality)
+		a.engine.SetDirtyEditPolicy(s.DirtyEditPolicy)
+		a.engine.SetFeatureFlags(s.EffectiveFeatureFlags())
+		a.applyApprovalPolicies(s.ApprovalPolicies)
 	}
 	return a
 }
@@ -137,6 +176,22 @@ func (a *App) SendUserMessage(message string) {
 	}
 }
 
+// ReproduceIssue starts a reproduce-first bug fix: the model writes a minimal failing
+// test or script and confirms that it fails before it may change code, and the
+// reproduction must pass before the fix is accepted. Returns an error message, or "".
+func (a *App) ReproduceIssue(description string) string {
+	if a.engine == nil {
+		return "engine not initialized"
+	}
+	message, err := a.engine.StartReproduction(description)
+	if err != nil {
+		return err.Error()
+	}
+	a.audit("reproduction", map[string]interface{}{"started": true})
+	a.engine.Enqueue(message)
+	return ""
+}
+
 // SetAttachments receives a list of workspace-relative file paths from the UI
 // and forwards them to the engine to be injected into the system prompt context.
 func (a *App) SetAttachments(paths []string) {
@@ -174,6 +229,16 @@ func (a *App) ResolveChoice(id string, selectedIndex int) {
 	}
 }
 
+// AnswerQuestion resolves an ask_user question with an option index, or -1 and a
+// free-text answer. An index of -1 with empty text skips the question.
+func (a *App) AnswerQuestion(id string, selectedIndex int, text string) {
+	if a.engine != nil {
+		a.engine.ResolveQuestion(id, selectedIndex, text)
+	} else {
+		log.Println("Engine not initialized")
+	}
+}
+
 // GetTools returns a list of available tools.
 func (a *App) GetTools() []map[string]interface{} {
 	if a.tools == nil {
@@ -182,6 +247,13 @@ func (a *App) GetTools() []map[string]interface{} {
 
 	schemas := a.tools.Schemas()
 	result := make([]map[string]interface{}, len(schemas))
+	// Tools switched off for the current conversation are listed as disabled
+	disabled := map[string]bool{}
+	if a.engine != nil {
+		for _, name := range a.engine.DisabledTools() {
+			disabled[name] = true
+		}
+	}
 
 	for i, schema := range schemas {
 		// Convert tool.Schema to a map
@@ -190,6 +262,7 @@ func (a *App) GetTools() []map[string]interface{} {
 			"description": schema.Description,
 			"safe":        schema.Safe,
 			"schema":      schema.Parameters,
+			"enabled":     !disabled[schema.Name],
 		}
 		result[i] = toolInfo
 	}
@@ -197,6 +270,23 @@ func (a *App) GetTools() []map[string]interface{} {
 	return result
 }
 
+// SetToolEnabled switches a tool on or off for the current conversation, e.g. shell
+// and HTTP tools for a documentation-only task. The model is not offered disabled
+// tools. Returns an error message, or "" on success.
+func (a *App) SetToolEnabled(name string, enabled bool) string {
+	if a.engine == nil {
+		return "engine not initialized"
+	}
+	if err := a.engine.SetToolEnabled(name, enabled); err != nil {
+		return err.Error()
+	}
+	a.audit("settings", map[string]interface{}{"tool": name, "enabled": enabled})
+	if a.ctx != nil {
+		runtime.EventsEmit(a.ctx, "system:tools_updated")
+	}
+	return ""
+}
+
 // GetPersonalities returns all available personalities from the registry
 func (a *App) GetPersonalities() map[string]interface{} {
 	result := make(map[string]interface{})
@@ -239,6 +329,12 @@ func (a *App) GetAllAvailableModels() []map[string]interface{} {
 		{"id": "openai:gpt-4.1-mini", "name": "GPT-4.1-mini", "provider": "openai", "group": "Production"},
 		{"id": "openai:gpt-4.1-nano", "name": "GPT-4.1-nano", "provider": "openai", "group": "Production"},
 
+		// Google Gemini models
+		{"id": "gemini:gemini-2.5-pro", "name": "Gemini 2.5 Pro", "provider": "gemini", "group": "Gemini"},
+		{"id": "gemini:gemini-2.5-flash", "name": "Gemini 2.5 Flash", "provider": "gemini", "group": "Gemini"},
+		{"id": "gemini:gemini-2.5-flash-lite", "name": "Gemini 2.5 Flash-Lite", "provider": "gemini", "group": "Gemini"},
+		{"id": "gemini:gemini-2.0-flash", "name": "Gemini 2.0 Flash", "provider": "gemini", "group": "Gemini"},
+
 		// Local models (Ollama)
 		{"id": "ollama:llama3.1:8b", "name": "Llama 3.1 (8B)", "provider": "ollama", "group": "Local"},
 		{"id": "ollama:llama3:8b", "name": "Llama 3 (8B)", "provider": "ollama", "group": "Local"},
@@ -258,6 +354,14 @@ func (a *App) GetAllAvailableModels() []map[string]interface{} {
 		{"id": "openrouter:mistralai/mistral-large", "name": "Mistral Large", "provider": "openrouter", "group": "OpenRouter", "pricing": map[string]interface{}{"input": 3.0, "output": 9.0}},
 	}
 
+	// Azure OpenAI deployments configured in settings
+	a.ensureSettingsLoaded()
+	if a.settings.AzureOpenAI.Enabled() {
+		for _, d := range a.settings.AzureOpenAI.Deployments {
+			models = append(models, map[string]interface{}{"id": "azure:" + d, "name": d, "provider": "azure", "group": "Azure OpenAI"})
+		}
+	}
+
 	return models
 }
 
@@ -269,19 +373,17 @@ func (a *App) SetModel(model string) {
 		return
 	}
 
-	// Determine API key based on provider using persisted settings only
-	var apiKey string
-	switch provider {
-	case adapter.ProviderOpenAI:
-		apiKey = a.settings.OpenAIAPIKey
-	case adapter.ProviderAnthropic:
-		apiKey = a.settings.AnthropicAPIKey
-	case adapter.ProviderOpenRouter:
-		apiKey = a.settings.OpenRouterAPIKey
-	default:
-		apiKey = a.config.APIKey // Keep existing key for other providers like Ollama
+	// The demo provider is never persisted as the last model; it only runs in demo mode
+	if provider == adapter.ProviderDemo {
+		if msg := a.SetDemoMode(true, modelID); msg != "" {
+			log.Printf("Demo mode: %s", msg)
+		}
+		return
 	}
 
+	// Determine API key based on provider using persisted settings only
+	apiKey := a.apiKeyForProvider(provider)
+
 	// Persist last selected model to settings immediately (even if LLM init fails)
 	a.ensureSettingsLoaded()
 	var providerPrefix string
@@ -294,11 +396,16 @@ func (a *App) SetModel(model string) {
 		providerPrefix = "ollama"
 	case adapter.ProviderOpenRouter:
 		providerPrefix = "openrouter"
+	case adapter.ProviderGemini:
+		providerPrefix = "gemini"
+	case adapter.ProviderAzureOpenAI:
+		providerPrefix = "azure"
 	default:
 		providerPrefix = string(provider)
 	}
 	a.settings.LastModel = providerPrefix + ":" + modelID
 	_ = config.Save(a.settings)
+	a.audit("settings", map[string]interface{}{"last_model": a.settings.LastModel})
 
 	// Update the configuration
 	newConfig := adapter.Config{
@@ -306,17 +413,18 @@ func (a *App) SetModel(model string) {
 		Model:    modelID,
 		APIKey:   apiKey,
 		Endpoint: a.config.Endpoint,
+		Azure:    azureConfig(a.settings.AzureOpenAI),
 	}
 
 	// Create a new LLM adapter with the updated model
-	llm, err := adapter.New(newConfig)
+	llm, err := a.newLLM(newConfig)
 	if err != nil {
 		return
 	}
 
 	// Update the engine with the new LLM
 	if a.engine != nil {
-		a.engine.SetLLM(llm)
+		a.setEngineLLM(llm)
 		a.config = newConfig
 		a.engine.SetModelLabel(string(provider) + ":" + modelID)
 	} else {
@@ -324,10 +432,77 @@ func (a *App) SetModel(model string) {
 	}
 }
 
+// SetDemoMode switches demo mode on or off and returns an error message, or "" on
+// success. In demo mode responses are replayed from a recorded trace (tracePath, or the
+// built-in trace when empty) and tools with side effects are disabled, so Loom can be
+// shown offline without API keys or risk to the workspace. Turning it off restores the
+// last selected model.
+func (a *App) SetDemoMode(enabled bool, tracePath string) string {
+	if a.engine == nil {
+		return "engine not initialized"
+	}
+	if !enabled {
+		a.demoMode = false
+		if a.tools != nil {
+			a.tools.SetDemoMode(false)
+		}
+		a.ensureSettingsLoaded()
+		if a.settings.LastModel != "" {
+			a.SetModel(a.settings.LastModel)
+		} else {
+			a.engine.SetLLM(nil)
+		}
+		a.audit("settings", map[string]interface{}{"demo_mode": false})
+		return ""
+	}
+
+	model := strings.TrimSpace(tracePath)
+	if model == "" {
+		model = adapter.DemoBuiltinModel
+	}
+	llm, err := adapter.New(adapter.Config{Provider: adapter.ProviderDemo, Model: model})
+	if err != nil {
+		return err.Error()
+	}
+	a.demoMode = true
+	if a.tools != nil {
+		a.tools.SetDemoMode(true)
+	}
+	a.engine.SetLLM(llm)
+	a.engine.SetModelLabel(string(adapter.ProviderDemo) + ":" + filepath.Base(model))
+	a.audit("settings", map[string]interface{}{"demo_mode": true, "trace": model})
+	a.SendChat("system", "Demo mode is on: responses are replayed from a recorded trace and tools that change the workspace are disabled.")
+	return ""
+}
+
+// IsDemoMode reports whether demo mode is active.
+func (a *App) IsDemoMode() bool {
+	return a.demoMode
+}
+
+// apiKeyForProvider returns the persisted API key for provider, keeping the current
+// key for providers without one of their own (e.g. Ollama).
+func (a *App) apiKeyForProvider(provider adapter.Provider) string {
+	switch provider {
+	case adapter.ProviderOpenAI:
+		return a.settings.OpenAIAPIKey
+	case adapter.ProviderAnthropic:
+		return a.settings.AnthropicAPIKey
+	case adapter.ProviderOpenRouter:
+		return a.settings.OpenRouterAPIKey
+	case adapter.ProviderGemini:
+		return a.settings.GeminiAPIKey
+	case adapter.ProviderAzureOpenAI:
+		return a.settings.AzureOpenAI.APIKey
+	default:
+		return a.config.APIKey
+	}
+}
+
 // ensureSettingsLoaded loads settings from disk into memory if not already loaded.
 func (a *App) ensureSettingsLoaded() {
 	// Check if settings are loaded by checking if any key field is set
-	if a.settings.OpenAIAPIKey != "" || a.settings.AnthropicAPIKey != "" || a.settings.LastWorkspace != "" || len(a.settings.RecentWorkspaces) > 0 {
+	if a.settings.OpenAIAPIKey != "" || a.settings.AnthropicAPIKey != "" || a.settings.GeminiAPIKey != "" || a.settings.AzureOpenAI.APIKey != "" || a.settings.LastWorkspace != "" || len(a.settings.RecentWorkspaces) > 0 || len(a.settings.KeyProfiles) > 0 {
 		return
 	}
 	if s, err := config.Load(); err == nil {
@@ -342,6 +517,9 @@ func (a *App) applyAndSaveSettings(s config.Settings) {
 
 	// Update in-memory settings
 	a.settings = s
+	if err := proxy.Apply(s.Proxy); err != nil {
+		log.Printf("Warning: proxy settings not applied: %v", err)
+	}
 
 	// If current provider uses one of these keys, update config and LLM
 	var updatedConfig = a.config
@@ -358,6 +536,15 @@ func (a *App) applyAndSaveSettings(s config.Settings) {
 		if s.OpenRouterAPIKey != "" {
 			updatedConfig.APIKey = s.OpenRouterAPIKey
 		}
+	case adapter.ProviderGemini:
+		if s.GeminiAPIKey != "" {
+			updatedConfig.APIKey = s.GeminiAPIKey
+		}
+	case adapter.ProviderAzureOpenAI:
+		if s.AzureOpenAI.APIKey != "" {
+			updatedConfig.APIKey = s.AzureOpenAI.APIKey
+		}
+		updatedConfig.Azure = azureConfig(s.AzureOpenAI)
 	case adapter.ProviderOllama:
 		if s.OllamaEndpoint != "" {
 			updatedConfig.Endpoint = s.OllamaEndpoint
@@ -366,21 +553,80 @@ func (a *App) applyAndSaveSettings(s config.Settings) {
 
 	// Recreate LLM if config changed materially
 	if updatedConfig != a.config {
-		llm, err := adapter.New(updatedConfig)
+		llm, err := a.newLLM(updatedConfig)
 		if err != nil {
 			return
 		}
 		if a.engine != nil {
-			a.engine.SetLLM(llm)
+			a.setEngineLLM(llm)
 			a.config = updatedConfig
 		}
 	}
 
+	// Limits take effect on the next invocation of each tool
+	a.applyToolLimits(a.tools)
+
 	// Apply engine flags for auto-approve and personality regardless of LLM update
 	if a.engine != nil {
 		a.engine.SetAutoApprove(s.AutoApproveShell, s.AutoApproveEdits)
 		a.engine.SetPersonality(s.Personality)
+		a.engine.SetDirtyEditPolicy(s.DirtyEditPolicy)
+		a.engine.SetFeatureFlags(s.EffectiveFeatureFlags())
+		a.applyApprovalPolicies(s.ApprovalPolicies)
+	}
+}
+
+// applyApprovalPolicies compiles the user's approval policies and installs them on the engine.
+// Invalid rule sets are logged and ignored so a typo never widens what gets auto-approved.
+func (a *App) applyApprovalPolicies(lines []string) {
+	set, err := policy.Compile(lines)
+	if err != nil {
+		log.Printf("Ignoring approval policies: %v", err)
+		set = nil
+	}
+	a.engine.SetApprovalPolicies(set)
+}
+
+// ValidateApprovalPolicies checks policy rules without saving them and returns
+// the first syntax error, or an empty string when all rules compile.
+func (a *App) ValidateApprovalPolicies(lines []string) string {
+	if _, err := policy.Compile(lines); err != nil {
+		return err.Error()
+	}
+	return ""
+}
+
+// watchOptions converts the file watch settings for the symbol index; they apply when
+// a workspace opens.
+func (a *App) watchOptions() symbols.WatchOptions {
+	a.ensureSettingsLoaded()
+	mode, interval, maxInterval := a.settings.EffectiveFileWatch()
+	return symbols.WatchOptions{Mode: mode, Interval: interval, MaxInterval: maxInterval}
+}
+
+// applyToolLimits converts the configured per-tool limits and installs them, with the
+// edit size limit, on the registry.
+func (a *App) applyToolLimits(reg *tool.Registry) {
+	if reg == nil {
+		return
+	}
+	a.ensureSettingsLoaded()
+	toLimits := func(l config.ToolLimit) tool.Limits {
+		return tool.Limits{
+			Timeout:        time.Duration(l.TimeoutSec) * time.Second,
+			MaxOutputBytes: l.MaxOutputBytes,
+			MaxConcurrent:  l.MaxConcurrent,
+		}
+	}
+	effective := a.settings.EffectiveToolLimits()
+	perTool := make(map[string]tool.Limits, len(effective))
+	for name, l := range effective {
+		if name != config.DefaultToolLimitKey {
+			perTool[name] = toLimits(l)
+		}
 	}
+	reg.SetLimits(toLimits(effective[config.DefaultToolLimitKey]), perTool)
+	reg.SetMaxEditLines(a.settings.EffectiveMaxEditLines())
 }
 
 // SendChat emits a chat message to the UI.
@@ -440,6 +686,96 @@ func (a *App) EmitBilling(provider string, model string, inTokens int64, outToke
 	}
 }
 
+// EmitStatus forwards the engine heartbeat to the UI so it can show the current phase
+// and offer a retry when the provider stops responding.
+func (a *App) EmitStatus(status engine.Status) {
+	if a.ctx != nil {
+		runtime.EventsEmit(a.ctx, "engine:status", status)
+	}
+}
+
+// EmitToolPreview shows the target and first lines of a tool call while the model is
+// still generating its arguments, so the user can stop a wrong edit early.
+func (a *App) EmitToolPreview(preview engine.ToolPreview) {
+	if a.ctx != nil {
+		runtime.EventsEmit(a.ctx, "tool:preview", preview)
+	}
+}
+
+// GetEngineStatus returns the latest engine heartbeat status.
+func (a *App) GetEngineStatus() engine.Status {
+	if a.engine == nil {
+		return engine.Status{Phase: engine.PhaseIdle}
+	}
+	return a.engine.Status()
+}
+
+// RetryLastRequest abandons a stalled request and asks the model again from the current history.
+func (a *App) RetryLastRequest() {
+	if a.engine == nil {
+		return
+	}
+	a.engine.Retry()
+}
+
+// RunPromptExperiment replays the last request of a conversation against two prompt/config
+// variants and returns both traces plus their differences. Payload shape:
+// {"conversation_id": "...", "a": {...}, "b": {...}} where each variant may set
+// "name", "model" ("provider:model_id"), "personality", and "rules" (extra project rules).
+func (a *App) RunPromptExperiment(payload map[string]interface{}) map[string]interface{} {
+	if a.engine == nil {
+		return map[string]interface{}{"error": "engine not initialized"}
+	}
+	a.ensureSettingsLoaded()
+	convID, _ := payload["conversation_id"].(string)
+	variants := make([]engine.ExperimentVariant, 2)
+	for i, key := range []string{"a", "b"} {
+		raw, _ := payload[key].(map[string]interface{})
+		v, err := a.experimentVariant(raw)
+		if err != nil {
+			return map[string]interface{}{"error": fmt.Sprintf("variant %s: %v", key, err)}
+		}
+		variants[i] = v
+	}
+	report, err := a.engine.RunExperiment(context.Background(), convID, variants[0], variants[1])
+	if err != nil {
+		return map[string]interface{}{"error": err.Error()}
+	}
+	return map[string]interface{}{"report": report}
+}
+
+// experimentVariant builds an engine variant from the frontend payload.
+func (a *App) experimentVariant(raw map[string]interface{}) (engine.ExperimentVariant, error) {
+	var v engine.ExperimentVariant
+	v.Name, _ = raw["name"].(string)
+	v.Personality, _ = raw["personality"].(string)
+	if rules, ok := raw["rules"].([]interface{}); ok {
+		for _, r := range rules {
+			if str, ok := r.(string); ok && strings.TrimSpace(str) != "" {
+				v.ExtraRules = append(v.ExtraRules, str)
+			}
+		}
+	}
+	if model, ok := raw["model"].(string); ok && model != "" {
+		provider, modelID, err := adapter.GetProviderFromModel(model)
+		if err != nil {
+			return v, err
+		}
+		llm, err := a.newLLM(adapter.Config{
+			Provider: provider,
+			Model:    modelID,
+			APIKey:   a.apiKeyForProvider(provider),
+			Endpoint: a.config.Endpoint,
+		})
+		if err != nil {
+			return v, err
+		}
+		v.LLM = llm
+		v.ModelLabel = model
+	}
+	return v, nil
+}
+
 // GetSettings exposes persisted settings to the frontend.
 func (a *App) GetSettings() map[string]interface{} {
 	a.ensureSettingsLoaded()
@@ -459,14 +795,22 @@ func (a *App) GetSettings() map[string]interface{} {
 		"openai_api_key":     openaiKey,
 		"anthropic_api_key":  anthropicKey,
 		"openrouter_api_key": openrouterKey,
+		"gemini_api_key":     s.GeminiAPIKey,
 		"ollama_endpoint":    s.OllamaEndpoint,
 		"last_workspace":     lastWorkspace,
 		"last_model":         s.LastModel,
 		"auto_approve_shell": boolToStr(s.AutoApproveShell),
 		"auto_approve_edits": boolToStr(s.AutoApproveEdits),
+		"dirty_edit_policy":  s.DirtyEditPolicy,
+		"max_edit_lines":     s.EffectiveMaxEditLines(),
+		"follow_symlinks":    boolToStr(s.FollowSymlinks),
+		"explanation_level":  s.ExplanationLevel,
 		"theme":              s.Theme,
 		"personality":        s.Personality,
 		"selected_models":    s.SelectedModels,
+		"tool_limits":        s.EffectiveToolLimits(),
+		"approval_policies":  s.ApprovalPolicies,
+		"retention":          s.Retention,
 	}
 }
 
@@ -572,19 +916,20 @@ func (a *App) ResetGlobalUsage() {
 }
 
 // GetMemories returns the user-scoped memories from ~/.loom/memories.json
-func (a *App) GetMemories() []map[string]string {
+func (a *App) GetMemories() []map[string]interface{} {
 	type memoryItem struct {
-		ID   string `json:"id"`
-		Text string `json:"text"`
+		ID   string   `json:"id"`
+		Text string   `json:"text"`
+		Tags []string `json:"tags"`
 	}
 	home, err := os.UserHomeDir()
 	if err != nil {
-		return []map[string]string{}
+		return []map[string]interface{}{}
 	}
 	path := filepath.Join(home, ".loom", "memories.json")
 	data, err := os.ReadFile(path)
 	if err != nil {
-		return []map[string]string{}
+		return []map[string]interface{}{}
 	}
 	var list []memoryItem
 	if json.Unmarshal(data, &list) != nil {
@@ -594,12 +939,12 @@ func (a *App) GetMemories() []map[string]string {
 		if json.Unmarshal(data, &wrapper) == nil && wrapper.Memories != nil {
 			list = wrapper.Memories
 		} else {
-			return []map[string]string{}
+			return []map[string]interface{}{}
 		}
 	}
-	out := make([]map[string]string, 0, len(list))
+	out := make([]map[string]interface{}, 0, len(list))
 	for _, m := range list {
-		out = append(out, map[string]string{"id": m.ID, "text": m.Text})
+		out = append(out, map[string]interface{}{"id": m.ID, "text": m.Text, "tags": m.Tags})
 	}
 	return out
 }
@@ -656,6 +1001,94 @@ func (a *App) DeleteMemory(id string) bool {
 	return true
 }
 
+// GetScopedMemories returns project and directory memories for the current workspace.
+func (a *App) GetScopedMemories() []map[string]string {
+	out := []map[string]string{}
+	if a.engine == nil {
+		return out
+	}
+	for _, m := range a.engine.ScopedMemories() {
+		out = append(out, map[string]string{"id": m.ID, "text": m.Text, "scope": m.Scope, "dir": m.Dir})
+	}
+	return out
+}
+
+// DeleteScopedMemory removes a project or directory memory by id.
+func (a *App) DeleteScopedMemory(id string) bool {
+	if a.engine == nil || strings.TrimSpace(id) == "" {
+		return false
+	}
+	return a.engine.DeleteScopedMemory(id) == nil
+}
+
+// retentionPolicy converts the configured retention settings for the memory store.
+func (a *App) retentionPolicy() memory.RetentionPolicy {
+	a.ensureSettingsLoaded()
+	sessions, age, disk := a.settings.EffectiveRetention()
+	return memory.RetentionPolicy{MaxSessions: sessions, MaxAge: age, MaxDiskBytes: disk}
+}
+
+// GetStorageUsage reports stored conversation data per workspace, plus the size of the
+// current workspace's .loom directory (profiler output, rules, MCP config).
+func (a *App) GetStorageUsage() map[string]interface{} {
+	if a.memoryStore == nil {
+		return map[string]interface{}{"error": "memory store not initialized"}
+	}
+	usage, err := a.memoryStore.DiskUsage()
+	if err != nil {
+		return map[string]interface{}{"error": err.Error()}
+	}
+	var total int64
+	for _, u := range usage {
+		total += u.Bytes
+	}
+	out := map[string]interface{}{
+		"projects":    usage,
+		"total_bytes": total,
+	}
+	if a.engine != nil {
+		if ws := strings.TrimSpace(a.engine.Workspace()); ws != "" {
+			var wsBytes int64
+			_ = filepath.Walk(filepath.Join(ws, ".loom"), func(_ string, info os.FileInfo, err error) error {
+				if err == nil && !info.IsDir() {
+					wsBytes += info.Size()
+				}
+				return nil
+			})
+			out["workspace_loom_bytes"] = wsBytes
+		}
+	}
+	return out
+}
+
+// RunRetentionCleanup applies the retention policy to every workspace's stored
+// conversations and returns what was removed.
+func (a *App) RunRetentionCleanup() map[string]interface{} {
+	if a.memoryStore == nil {
+		return map[string]interface{}{"error": "memory store not initialized"}
+	}
+	// The open workspace goes through its live project so conversations open in tabs are kept
+	var live *memory.Project
+	if a.engine != nil {
+		live = a.engine.Memory()
+	}
+	reports, err := a.memoryStore.ApplyRetentionAll(a.retentionPolicy(), live)
+	if err != nil {
+		return map[string]interface{}{"error": err.Error()}
+	}
+	var freed int64
+	removed := 0
+	for _, r := range reports {
+		freed += r.FreedBytes
+		removed += len(r.Removed)
+	}
+	return map[string]interface{}{
+		"reports":     reports,
+		"removed":     removed,
+		"freed_bytes": freed,
+	}
+}
+
 // SaveSettings saves settings provided by the frontend.
 func (a *App) SaveSettings(settings map[string]interface{}) {
 	// Merge with existing settings to avoid wiping fields (e.g. last_workspace) when omitted by the UI
@@ -671,6 +1104,9 @@ func (a *App) SaveSettings(settings map[string]interface{}) {
 	if v, ok := settings["openrouter_api_key"].(string); ok {
 		s.OpenRouterAPIKey = v
 	}
+	if v, ok := settings["gemini_api_key"].(string); ok {
+		s.GeminiAPIKey = v
+	}
 	if v, ok := settings["ollama_endpoint"].(string); ok {
 		s.OllamaEndpoint = v
 	}
@@ -686,6 +1122,24 @@ func (a *App) SaveSettings(settings map[string]interface{}) {
 	if v, ok := settings["auto_approve_edits"].(string); ok {
 		s.AutoApproveEdits = strToBool(v)
 	}
+	if v, ok := settings["dirty_edit_policy"].(string); ok {
+		s.DirtyEditPolicy = v
+	}
+	if v, ok := settings["max_edit_lines"].(float64); ok {
+		s.MaxEditLines = int(v)
+		if s.MaxEditLines == 0 {
+			// 0 in the UI turns the limit off
+			s.MaxEditLines = -1
+		}
+	}
+	if v, ok := settings["explanation_level"].(string); ok {
+		if level, err := config.ParseExplanationLevel(v); err == nil {
+			s.ExplanationLevel = level
+		}
+	}
+	if v, ok := settings["follow_symlinks"].(string); ok {
+		s.FollowSymlinks = strToBool(v)
+	}
 	if v, ok := settings["theme"].(string); ok {
 		s.Theme = v
 	}
@@ -701,10 +1155,84 @@ func (a *App) SaveSettings(settings map[string]interface{}) {
 		}
 		s.SelectedModels = selectedModels
 	}
+	if v, ok := settings["approval_policies"].([]interface{}); ok {
+		policies := make([]string, 0, len(v))
+		for _, item := range v {
+			if str, ok := item.(string); ok {
+				policies = append(policies, str)
+			}
+		}
+		s.ApprovalPolicies = policies
+	}
+	if v, ok := settings["retention"].(map[string]interface{}); ok {
+		if f, ok := v["max_sessions"].(float64); ok {
+			s.Retention.MaxSessions = int(f)
+		}
+		if f, ok := v["max_age_days"].(float64); ok {
+			s.Retention.MaxAgeDays = int(f)
+		}
+		if f, ok := v["max_disk_mb"].(float64); ok {
+			s.Retention.MaxDiskMB = int(f)
+		}
+	}
+	if v, ok := settings["tool_limits"].(map[string]interface{}); ok {
+		s.ToolLimits = parseToolLimits(v)
+	}
 
+	a.audit("settings", redactSettings(settings))
 	a.applyAndSaveSettings(s)
 }
 
+// audit appends an event to the current workspace's audit log.
+func (a *App) audit(kind string, data map[string]interface{}) {
+	if a.engine == nil {
+		return
+	}
+	if err := a.engine.RecordAudit(kind, data); err != nil {
+		log.Printf("Warning: failed to write audit event %s: %v", kind, err)
+	}
+}
+
+// redactSettings copies a settings payload for the audit log, replacing secrets with
+// whether they were set.
+func redactSettings(settings map[string]interface{}) map[string]interface{} {
+	out := make(map[string]interface{}, len(settings))
+	for k, v := range settings {
+		if strings.HasSuffix(k, "_api_key") {
+			if str, _ := v.(string); strings.TrimSpace(str) != "" {
+				v = "[set]"
+			} else {
+				v = "[cleared]"
+			}
+		}
+		out[k] = v
+	}
+	return out
+}
+
+// parseToolLimits decodes the frontend's tool_limits map into typed per-tool limits.
+func parseToolLimits(raw map[string]interface{}) map[string]config.ToolLimit {
+	limits := make(map[string]config.ToolLimit, len(raw))
+	for name, v := range raw {
+		fields, ok := v.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		var l config.ToolLimit
+		if f, ok := fields["timeout_sec"].(float64); ok {
+			l.TimeoutSec = int(f)
+		}
+		if f, ok := fields["max_output_bytes"].(float64); ok {
+			l.MaxOutputBytes = int(f)
+		}
+		if f, ok := fields["max_concurrent"].(float64); ok {
+			l.MaxConcurrent = int(f)
+		}
+		limits[name] = l
+	}
+	return limits
+}
+
 // SetWorkspace updates the engine and tools for a new workspace and persists it as last workspace.
 func (a *App) SetWorkspace(path string) {
 	if path == "" {
@@ -719,8 +1247,14 @@ func (a *App) SetWorkspace(path string) {
 		return
 	}
 	a.lastWorkspaceSet = now
+	// Only one instance may change a workspace; others stay read-only
+	a.lockWorkspace(norm)
+	// Files the agent deleted long enough ago leave the trash
+	go a.purgeTrash(norm)
 	// Update engine workspace and memory for new workspace
 	if a.engine != nil {
+		// Tabs run in the previous workspace's memory
+		a.closeConversationTabs()
 		a.engine.WithWorkspace(norm)
 		// Reset editor context since we're switching to a new workspace
 		// The old file path and cursor position are no longer relevant
@@ -730,20 +1264,43 @@ func (a *App) SetWorkspace(path string) {
 		if a.memoryStore != nil {
 			if newProject, err := memory.NewProject(a.memoryStore, norm); err == nil {
 				a.engine.WithMemory(newProject)
+				a.audit("workspace", map[string]interface{}{"path": norm})
+				a.syncSharedMemories()
 			} else {
 				log.Printf("Warning: Failed to create project memory for workspace %s: %v", norm, err)
+				if errors.Is(err, memory.ErrEncryptionLocked) {
+					a.SendChat("system", "Could not open this workspace's history: "+err.Error())
+				}
 			}
 		}
+		// Workspaces may prefer a different key profile
+		if len(a.settings.KeyProfiles) > 0 || len(a.settings.WorkspaceKeyProfiles) > 0 {
+			a.reloadLLM()
+		}
 	}
 	// Re-register tools with new workspace paths
 	if a.tools != nil {
 		// Create a new registry to avoid stale state
 		newRegistry := tool.NewRegistry().WithUI(a)
+		a.applyToolLimits(newRegistry)
+		newRegistry.SetDemoMode(a.demoMode)
+		newRegistry.SetReadOnly(a.readOnlyReason)
 		// Register all core tools using centralized function
 		tool.RegisterCoreTools(newRegistry, norm)
 		// Initialize and register Symbols tools with progress reporting
 		if ws := norm; ws != "" {
+			// The previous workspace's index no longer needs to follow its files
+			if old, ok := a.symbolsSvc.(interface{ StopWatching() }); ok {
+				old.StopWatching()
+			}
 			if sqliteSvc, err := symbols.NewSQLiteService(ws); err == nil {
+				sqliteSvc.SetWatchOptions(a.watchOptions())
+				// Externally moved files keep resolving for later tool calls
+				sqliteSvc.OnRename(func(from, to string) {
+					if a.engine != nil {
+						_ = a.engine.RecordRename(from, to)
+					}
+				})
 				go func() { _ = sqliteSvc.StartIndexing(context.Background()) }()
 				_ = tool.RegisterSymbols(newRegistry, sqliteSvc)
 				// store for UI operations
@@ -773,36 +1330,7 @@ func (a *App) SetWorkspace(path string) {
 				if err := a.mcpManager.Start(cfgs); err != nil {
 					return
 				}
-				toolsets, err := a.mcpManager.ListTools()
-				if err != nil {
-					return
-				}
-				for alias, tools := range toolsets {
-					serverCfg := cfgs[alias]
-					timeout := time.Duration(serverCfg.TimeoutSec) * time.Second
-					if timeout == 0 {
-						timeout = 60 * time.Second
-					}
-					for _, t := range tools {
-						server := alias
-						toolName := t.Name
-						safe := serverCfg.Safe
-						name := tool.SanitizeToolName("mcp_" + server + "__" + toolName)
-						_ = reg.Register(tool.Definition{
-							Name:        name,
-							Description: t.Description,
-							JSONSchema:  t.InputSchema,
-							Safe:        safe,
-							Handler: func(ctx context.Context, raw json.RawMessage) (interface{}, error) {
-								out, err := a.mcpManager.Call(server, toolName, raw, timeout)
-								if err != nil {
-									return "Error: " + err.Error(), nil
-								}
-								return out, nil
-							},
-						})
-					}
-				}
+				a.registerMCPTools(reg, cfgs)
 				// Notify frontend that tools changed (optional hook)
 				if a.ctx != nil {
 					runtime.EventsEmit(a.ctx, "system:tools_updated")
@@ -812,7 +1340,10 @@ func (a *App) SetWorkspace(path string) {
 		a.tools = newRegistry
 		if a.engine != nil {
 			a.engine.WithRegistry(newRegistry)
+			a.engine.SetOverlayRegistryFactory(a.overlayRegistry)
 		}
+		// Reopen the further roots saved with this workspace
+		a.restoreWorkspaceRoots(norm)
 	}
 	// Persist as last workspace and add to recent workspaces
 	a.ensureSettingsLoaded()
@@ -836,6 +1367,26 @@ func (a *App) SetWorkspace(path string) {
 	// After switching, log current rules snapshot for debug
 	_, _, _ = config.LoadRules(path)
 
+	// Enforce conversation retention for this workspace in the background, through the
+	// engine's live project so conversations opened in tabs meanwhile are kept
+	if a.memoryStore != nil {
+		var live *memory.Project
+		if a.engine != nil {
+			live = a.engine.Memory()
+		}
+		go func(workspace string, proj *memory.Project, policy memory.RetentionPolicy) {
+			if proj == nil {
+				var err error
+				if proj, err = memory.NewProject(a.memoryStore, workspace); err != nil {
+					return
+				}
+			}
+			if report, err := proj.ApplyRetention(policy); err == nil && len(report.Removed) > 0 {
+				log.Printf("Retention removed %d conversations (%d bytes) for %s", len(report.Removed), report.FreedBytes, workspace)
+			}
+		}(norm, live, a.retentionPolicy())
+	}
+
 	// Check if profiler should run and run it in background
 	go func(workspace string) {
 		runner := profiler.NewRunner(workspace)
@@ -871,6 +1422,9 @@ func (a *App) ReloadMCP() {
 		return
 	}
 	newRegistry := tool.NewRegistry().WithUI(a)
+	a.applyToolLimits(newRegistry)
+	newRegistry.SetDemoMode(a.demoMode)
+	newRegistry.SetReadOnly(a.readOnlyReason)
 	// Register all core tools using centralized function
 	tool.RegisterCoreTools(newRegistry, ws)
 	// Recreate Symbols for current workspace and register
@@ -889,40 +1443,15 @@ func (a *App) ReloadMCP() {
 			a.mcpManager = mcp.NewManager()
 		}
 		if a.mcpManager.Start(cfgs) == nil {
-			if toolsets, err := a.mcpManager.ListTools(); err == nil {
-				for alias, tools := range toolsets {
-					serverCfg := cfgs[alias]
-					timeout := time.Duration(serverCfg.TimeoutSec) * time.Second
-					if timeout == 0 {
-						timeout = 60 * time.Second
-					}
-					for _, t := range tools {
-						server := alias
-						toolName := t.Name
-						safe := serverCfg.Safe
-						name := tool.SanitizeToolName("mcp_" + server + "__" + toolName)
-						_ = newRegistry.Register(tool.Definition{
-							Name:        name,
-							Description: t.Description,
-							JSONSchema:  t.InputSchema,
-							Safe:        safe,
-							Handler: func(ctx context.Context, raw json.RawMessage) (interface{}, error) {
-								out, err := a.mcpManager.Call(server, toolName, raw, timeout)
-								if err != nil {
-									return "Error: " + err.Error(), nil
-								}
-								return out, nil
-							},
-						})
-					}
-				}
-			}
+			a.registerMCPTools(newRegistry, cfgs)
 		}
 	}
 
+	a.attachWorkspaceRoots(newRegistry)
 	a.tools = newRegistry
 	if a.engine != nil {
 		a.engine.WithRegistry(newRegistry)
+		a.engine.SetOverlayRegistryFactory(a.overlayRegistry)
 	}
 }
 
@@ -964,6 +1493,168 @@ func (a *App) ReindexSymbols() {
 	}(a.symbolsSvc)
 }
 
+// GetIndexExclusions reports the directories the last full index skipped because they
+// are large and dominated by binary/media files, with size totals and re-included paths.
+func (a *App) GetIndexExclusions() symbols.ExclusionReport {
+	if svc, ok := a.symbolsSvc.(interface {
+		Exclusions() symbols.ExclusionReport
+	}); ok {
+		return svc.Exclusions()
+	}
+	return symbols.ExclusionReport{}
+}
+
+// ReincludeIndexPaths adds workspace-relative paths that must be indexed even when the
+// size heuristics would exclude them, then reindexes. It returns an error message or "".
+func (a *App) ReincludeIndexPaths(paths []string) string {
+	if a.engine == nil || strings.TrimSpace(a.engine.Workspace()) == "" {
+		return "workspace not set"
+	}
+	ws, err := filepath.Abs(a.engine.Workspace())
+	if err != nil {
+		return err.Error()
+	}
+	if err := symbols.SaveReincluded(ws, append(symbols.LoadReincluded(ws), paths...)); err != nil {
+		return err.Error()
+	}
+	a.audit("settings", map[string]interface{}{"index_reincluded": paths})
+	a.ReindexSymbols()
+	return ""
+}
+
+// GetIndexProfile returns the current workspace's index profile ("full" or "sparse"
+// with the subtrees indexed up front).
+func (a *App) GetIndexProfile() symbols.IndexProfile {
+	if a.engine == nil || strings.TrimSpace(a.engine.Workspace()) == "" {
+		return symbols.IndexProfile{Mode: symbols.ProfileFull}
+	}
+	ws, err := filepath.Abs(a.engine.Workspace())
+	if err != nil {
+		return symbols.IndexProfile{Mode: symbols.ProfileFull}
+	}
+	return symbols.LoadIndexProfile(ws)
+}
+
+// SetIndexProfile switches the workspace between full and sparse indexing. In sparse
+// mode only the top-level structure and the include subtrees are indexed on open;
+// other directories are indexed when tools touch them. It reindexes and returns an
+// error message or "".
+func (a *App) SetIndexProfile(mode string, include []string) string {
+	if a.engine == nil || strings.TrimSpace(a.engine.Workspace()) == "" {
+		return "workspace not set"
+	}
+	if mode != symbols.ProfileFull && mode != symbols.ProfileSparse {
+		return fmt.Sprintf("unknown index mode %q (use %q or %q)", mode, symbols.ProfileFull, symbols.ProfileSparse)
+	}
+	ws, err := filepath.Abs(a.engine.Workspace())
+	if err != nil {
+		return err.Error()
+	}
+	profile := symbols.LoadIndexProfile(ws)
+	profile.Mode = mode
+	profile.Include = include
+	if err := symbols.SaveIndexProfile(ws, profile); err != nil {
+		return err.Error()
+	}
+	a.audit("settings", map[string]interface{}{"index_profile": mode, "index_include": include})
+	a.ReindexSymbols()
+	return ""
+}
+
+// GetIndexStats reports the size of the symbol index per top-level directory, with
+// the directories expanded on demand, so sparse profiles can be tuned.
+func (a *App) GetIndexStats() symbols.IndexStats {
+	if svc, ok := a.symbolsSvc.(interface {
+		Stats(context.Context) symbols.IndexStats
+	}); ok {
+		return svc.Stats(context.Background())
+	}
+	return symbols.IndexStats{}
+}
+
+// ListKnowledgePacks returns the registered documentation packs and the IDs enabled
+// for the current workspace.
+func (a *App) ListKnowledgePacks() map[string]interface{} {
+	lib, err := knowledge.Shared()
+	if err != nil {
+		return map[string]interface{}{"error": err.Error()}
+	}
+	enabled := []string{}
+	if a.engine != nil && strings.TrimSpace(a.engine.Workspace()) != "" {
+		enabled = append(enabled, lib.Enabled(a.engine.Workspace())...)
+	}
+	return map[string]interface{}{"packs": lib.Packs(), "enabled": enabled}
+}
+
+// AddKnowledgePack registers a documentation folder as a read-only knowledge pack and
+// indexes it. An empty path opens a directory picker; an empty name uses the folder name.
+func (a *App) AddKnowledgePack(path, name string) map[string]interface{} {
+	if strings.TrimSpace(path) == "" {
+		if a.ctx == nil {
+			return map[string]interface{}{"error": "no folder selected"}
+		}
+		dir, err := runtime.OpenDirectoryDialog(a.ctx, runtime.OpenDialogOptions{Title: "Select Documentation Folder"})
+		if err != nil || dir == "" {
+			return map[string]interface{}{"error": "no folder selected"}
+		}
+		path = dir
+	}
+	lib, err := knowledge.Shared()
+	if err != nil {
+		return map[string]interface{}{"error": err.Error()}
+	}
+	pack, err := lib.Add(context.Background(), path, name)
+	if err != nil {
+		return map[string]interface{}{"error": err.Error()}
+	}
+	a.audit("settings", map[string]interface{}{"knowledge_pack_added": pack.Path, "files": pack.Files})
+	return map[string]interface{}{"pack": pack}
+}
+
+// ReindexKnowledgePack rebuilds a pack's index after its documents changed.
+func (a *App) ReindexKnowledgePack(id string) map[string]interface{} {
+	lib, err := knowledge.Shared()
+	if err != nil {
+		return map[string]interface{}{"error": err.Error()}
+	}
+	pack, err := lib.Reindex(context.Background(), id)
+	if err != nil {
+		return map[string]interface{}{"error": err.Error()}
+	}
+	return map[string]interface{}{"pack": pack}
+}
+
+// RemoveKnowledgePack unregisters a pack from all workspaces. The folder itself is
+// left untouched. It returns an error message or "".
+func (a *App) RemoveKnowledgePack(id string) string {
+	lib, err := knowledge.Shared()
+	if err != nil {
+		return err.Error()
+	}
+	if err := lib.Remove(id); err != nil {
+		return err.Error()
+	}
+	a.audit("settings", map[string]interface{}{"knowledge_pack_removed": id})
+	return ""
+}
+
+// SetKnowledgePacksEnabled selects the packs search_knowledge uses in the current
+// workspace. It returns an error message or "".
+func (a *App) SetKnowledgePacksEnabled(ids []string) string {
+	if a.engine == nil || strings.TrimSpace(a.engine.Workspace()) == "" {
+		return "workspace not set"
+	}
+	lib, err := knowledge.Shared()
+	if err != nil {
+		return err.Error()
+	}
+	if err := lib.SetEnabled(a.engine.Workspace(), ids); err != nil {
+		return err.Error()
+	}
+	a.audit("settings", map[string]interface{}{"knowledge_packs": ids})
+	return ""
+}
+
 // GetSymbolsCount returns the current number of symbols in the index.
 func (a *App) GetSymbolsCount() int {
 	if a.symbolsSvc == nil {
@@ -1263,6 +1954,7 @@ func (a *App) GetRules() map[string][]string {
 // SaveRules persists rules coming from the frontend. The payload is
 // { user: string[], project: string[] }.
 func (a *App) SaveRules(payload map[string][]string) {
+	a.audit("rules", map[string]interface{}{"user": payload["user"], "project": payload["project"]})
 	// Save user rules
 	if userRules, ok := payload["user"]; ok {
 		_ = config.SaveUserRules(userRules)
@@ -1444,6 +2136,28 @@ func (a *App) PromptChoice(actionID, question string, options []string) int {
 	return -1
 }
 
+// PromptQuestion shows an ask_user question; the answer comes back via AnswerQuestion.
+func (a *App) PromptQuestion(actionID string, question tool.AskUserArgs) {
+	options := question.Options
+	if options == nil {
+		options = []string{}
+	}
+	request := map[string]interface{}{
+		"id":        actionID,
+		"question":  question.Question,
+		"options":   options,
+		"free_text": question.FreeText(),
+		"context":   question.Context,
+		"type":      "question",
+	}
+
+	if a.ctx != nil {
+		runtime.EventsEmit(a.ctx, "user:question", request)
+	} else {
+		log.Println("Warning: Wails context not initialized in PromptQuestion")
+	}
+}
+
 func boolToStr(b bool) string {
 	if b {
 		return "true"
@@ -1464,7 +2178,7 @@ func strToBool(s string) bool {
 func (a *App) GetConversations() map[string]interface{} {
 	result := map[string]interface{}{
 		"current_id":    "",
-		"conversations": []map[string]string{},
+		"conversations": []map[string]interface{}{},
 	}
 	if a.engine == nil {
 		return result
@@ -1475,15 +2189,7 @@ func (a *App) GetConversations() map[string]interface{} {
 	if err != nil {
 		return result
 	}
-	list := make([]map[string]string, 0, len(summaries))
-	for _, s := range summaries {
-		list = append(list, map[string]string{
-			"id":         s.ID,
-			"title":      s.Title,
-			"updated_at": s.UpdatedAt.Format(time.RFC3339),
-		})
-	}
-	result["conversations"] = list
+	result["conversations"] = conversationEntries(summaries)
 	return result
 }
 
@@ -1492,6 +2198,10 @@ func (a *App) LoadConversation(id string) {
 	if a.engine == nil || id == "" {
 		return
 	}
+	if a.engine.ForkOf(id) != nil {
+		a.SendChat("system", "This conversation is open in a tab; close the tab to load it here.")
+		return
+	}
 	if err := a.engine.SetCurrentConversationID(id); err != nil {
 		return
 	}
@@ -1503,10 +2213,25 @@ func (a *App) LoadConversation(id string) {
 	if err != nil {
 		return
 	}
+	replayMessages(a, msgs)
+}
+
+// chatView shows a conversation's messages: the main view or a conversation tab.
+type chatView interface {
+	SendChat(role, text string)
+	EmitReasoning(text string, done bool)
+}
+
+// replayMessages shows a stored conversation's messages in out.
+func replayMessages(out chatView, msgs []engine.Message) {
 	for _, m := range msgs {
 		// Hide system messages from the chat view when loading history
 		// Keep tool messages visible so todo lists and other formatted tool outputs are preserved
 		if m.Role == "system" {
+			// except the markers of output style and explanation level switches
+			if m.Name == engine.OutputStyleMarker || m.Name == engine.ExplanationLevelMarker {
+				out.SendChat("system", m.Content)
+			}
 			continue
 		}
 		// Also hide assistant messages that were used for internal steps (thinking/tool_use)
@@ -1516,13 +2241,29 @@ func (a *App) LoadConversation(id string) {
 			if m.Name == "thinking" {
 				var payload map[string]string
 				if json.Unmarshal([]byte(m.Content), &payload) == nil {
-					a.EmitReasoning(payload["thinking"], true)
+					out.EmitReasoning(payload["thinking"], true)
 				}
 			}
 			continue
 		}
-		a.SendChat(m.Role, m.Content)
+		out.SendChat(m.Role, m.Content)
+	}
+}
+
+// GetMessages returns a page of a conversation's messages (the current one when id is
+// empty), filtered by role, tool, time range and text, so long transcripts can be
+// loaded lazily and searched. Pages run oldest first; pass the first returned index
+// as query.before to load older messages, or the last as query.after for newer ones.
+// Returns: { messages: [{ index, role, content, name, tool_id, timestamp }], total, has_more } or { error }.
+func (a *App) GetMessages(id string, query memory.MessageQuery) map[string]interface{} {
+	if a.engine == nil {
+		return map[string]interface{}{"error": "engine not initialized"}
+	}
+	page, err := a.engine.QueryConversation(id, query)
+	if err != nil {
+		return map[string]interface{}{"error": err.Error()}
 	}
+	return map[string]interface{}{"messages": page.Messages, "total": page.Total, "has_more": page.HasMore}
 }
 
 // NewConversation creates a new conversation and clears the UI.
@@ -1547,6 +2288,14 @@ func (a *App) StopLLM() {
 	a.SetBusy(false)
 }
 
+// CloseShellSessions ends the persistent shells of all conversations; call it when the
+// app shuts down.
+func (a *App) CloseShellSessions() {
+	if a.engine != nil {
+		a.engine.CloseShellSessions()
+	}
+}
+
 // OpenFileInUI emits an event for the frontend to open the given file in the viewer
 func (a *App) OpenFileInUI(path string) {
 	if a.ctx != nil && strings.TrimSpace(path) != "" {
@@ -1576,6 +2325,9 @@ func (a *App) SearchCode(query string, filePattern string, maxResults int) []map
 		return out
 	}
 	idx := indexer.NewRipgrepIndexer(root)
+	if settings, err := config.Load(); err == nil {
+		idx.FollowSymlinks = settings.FollowSymlinks
+	}
 	res, err := idx.Search(query, filePattern, maxResults)
 	if err != nil || res == nil {
 		return out
@@ -1978,6 +2730,12 @@ func computeServerRev(data []byte) string {
 	return hex.EncodeToString(sum[:])
 }
 
+// sha256Hex hashes file content for the audit log.
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
 // WriteWorkspaceFile writes content to a file within the current workspace.
 // Payload: { path: string, content: string, serverRev?: string }
 // Returns: { serverRev: string }
@@ -2015,11 +2773,423 @@ func (a *App) WriteWorkspaceFile(payload map[string]string) map[string]string {
 	if err := os.WriteFile(absCandidate, []byte(content), 0o644); err != nil {
 		return res
 	}
+	a.audit("edit", map[string]interface{}{"path": filepath.ToSlash(rel), "source": "editor", "ok": true, "file_sha256": sha256Hex([]byte(content))})
 	// Compute and return new serverRev
 	res["serverRev"] = computeServerRev([]byte(content))
 	return res
 }
 
+// GetCodeBlocks lists the fenced code blocks in an assistant answer with any target
+// path the answer suggests for them.
+func (a *App) GetCodeBlocks(answer string) []editor.CodeBlock {
+	return editor.ExtractCodeBlocks(answer)
+}
+
+// SaveCodeBlock writes one code block from an assistant answer into the workspace.
+// Payload: { answer: string, index: number, path?: string }
+// Returns: { edit_id, path, diff, created, notes } or { error }.
+func (a *App) SaveCodeBlock(payload map[string]interface{}) map[string]interface{} {
+	if a.engine == nil {
+		return map[string]interface{}{"error": "engine not initialized"}
+	}
+	answer, _ := payload["answer"].(string)
+	path, _ := payload["path"].(string)
+	index := 0
+	if v, ok := payload["index"].(float64); ok {
+		index = int(v)
+	}
+	if err := a.ensureWritable(); err != nil {
+		return map[string]interface{}{"error": err.Error()}
+	}
+	res, err := a.engine.SaveCodeBlock(answer, index, path)
+	if err != nil {
+		return map[string]interface{}{"error": err.Error()}
+	}
+	a.audit("edit", map[string]interface{}{"path": res.Edit.Path, "source": "code_block", "ok": true, "edit_id": res.Edit.ID, "file_sha256": sha256Hex([]byte(res.Edit.After))})
+	verb := "Updated"
+	if res.Edit.Created {
+		verb = "Created"
+	}
+	a.SendChat("system", fmt.Sprintf("%s %s from code block %d (undo available).", verb, res.Edit.Path, index+1))
+	a.OpenFileInUI(res.Edit.Path)
+	return map[string]interface{}{
+		"edit_id": res.Edit.ID,
+		"path":    res.Edit.Path,
+		"diff":    res.Diff,
+		"created": res.Edit.Created,
+		"notes":   res.Notes,
+	}
+}
+
+// GetEditHistory returns undoable edits for the current workspace, newest first.
+func (a *App) GetEditHistory() []map[string]interface{} {
+	out := []map[string]interface{}{}
+	if a.engine == nil {
+		return out
+	}
+	for _, e := range a.engine.EditHistory() {
+		out = append(out, map[string]interface{}{
+			"id": e.ID, "path": e.Path, "created": e.Created, "source": e.Source, "at": e.At,
+		})
+	}
+	return out
+}
+
+// UndoEdit reverts a recorded edit; it returns an error message or "".
+func (a *App) UndoEdit(id string) string {
+	if a.engine == nil {
+		return "engine not initialized"
+	}
+	if err := a.ensureWritable(); err != nil {
+		return err.Error()
+	}
+	if err := a.engine.UndoEdit(id); err != nil {
+		return err.Error()
+	}
+	a.audit("edit", map[string]interface{}{"source": "undo", "ok": true, "edit_id": id})
+	return ""
+}
+
+// GetTimeline returns the current conversation's checkpoints, oldest first. Step N is
+// the N-th file change; checkpoint N is the workspace after it.
+func (a *App) GetTimeline() []map[string]interface{} {
+	out := []map[string]interface{}{}
+	if a.engine == nil {
+		return out
+	}
+	for _, cp := range a.engine.Timeline() {
+		out = append(out, map[string]interface{}{
+			"step": cp.Step, "path": cp.Path, "source": cp.Source, "call_id": cp.CallID,
+			"created": cp.Created, "deleted": cp.Deleted, "at": cp.At,
+		})
+	}
+	return out
+}
+
+// DiffCheckpoints returns the multi-file diff between two checkpoints of the current
+// conversation ("before step 3" is checkpoint 2).
+// Returns: { from, to, files: [{ path, status, added, removed, omitted, diff }], diff } or { error }.
+func (a *App) DiffCheckpoints(from, to int) map[string]interface{} {
+	if a.engine == nil {
+		return map[string]interface{}{"error": "engine not initialized"}
+	}
+	res, err := a.engine.DiffCheckpoints(from, to)
+	if err != nil {
+		return map[string]interface{}{"error": err.Error()}
+	}
+	return map[string]interface{}{"from": res.From, "to": res.To, "files": res.Files, "diff": res.Diff}
+}
+
+// RevertToCheckpoint restores files (all changed since the checkpoint when paths is
+// empty) to their state at checkpoint step.
+// Returns: { reverted: [path], error? }.
+func (a *App) RevertToCheckpoint(step int, paths []string) map[string]interface{} {
+	if a.engine == nil {
+		return map[string]interface{}{"error": "engine not initialized"}
+	}
+	if err := a.ensureWritable(); err != nil {
+		return map[string]interface{}{"error": err.Error()}
+	}
+	reverted, err := a.engine.RevertToCheckpoint(step, paths)
+	if reverted == nil {
+		reverted = []string{}
+	}
+	out := map[string]interface{}{"reverted": reverted}
+	if len(reverted) > 0 {
+		a.audit("edit", map[string]interface{}{"source": "revert", "ok": err == nil, "checkpoint": step, "paths": reverted})
+		a.SendChat("system", fmt.Sprintf("Reverted %d file(s) to checkpoint %d: %s", len(reverted), step, strings.Join(reverted, ", ")))
+	}
+	if err != nil {
+		out["error"] = err.Error()
+	}
+	return out
+}
+
+// GetAnnotations returns the current conversation's annotate_code comments for a
+// workspace-relative path, or all of them when path is empty, for gutter rendering.
+func (a *App) GetAnnotations(path string) []memory.Annotation {
+	out := []memory.Annotation{}
+	if a.engine == nil {
+		return out
+	}
+	return append(out, a.engine.Annotations(path)...)
+}
+
+// RemoveAnnotation dismisses one annotation (all of them when id is empty).
+// Returns: { ok, error? }.
+func (a *App) RemoveAnnotation(id string) map[string]interface{} {
+	if a.engine == nil {
+		return map[string]interface{}{"error": "engine not initialized"}
+	}
+	if err := a.engine.RemoveAnnotation(id); err != nil {
+		return map[string]interface{}{"error": err.Error()}
+	}
+	if a.ctx != nil {
+		runtime.EventsEmit(a.ctx, "annotations:changed", map[string]interface{}{"removed": id})
+	}
+	return map[string]interface{}{"ok": true}
+}
+
+// EmitAnnotation notifies the UI of a new annotation so open editors can show it.
+func (a *App) EmitAnnotation(ann memory.Annotation) {
+	if a.ctx != nil {
+		runtime.EventsEmit(a.ctx, "annotations:changed", map[string]interface{}{"added": ann})
+	}
+}
+
+// EmitModelSuggestion tells the UI that a cheaper default model would likely do as well.
+func (a *App) EmitModelSuggestion(s engine.ModelSuggestion) {
+	if a.ctx != nil {
+		runtime.EventsEmit(a.ctx, "model:suggestion", s)
+	}
+}
+
+// EmitRunSummary sends the summary of a finished run to the UI.
+func (a *App) EmitRunSummary(s engine.RunSummary) {
+	if a.ctx != nil {
+		runtime.EventsEmit(a.ctx, "run:summary", s)
+	}
+}
+
+// EmitChangedFiles sends the current run's changed-files feed to the UI. Each event
+// carries the whole deduplicated list, so the UI can replace its copy.
+func (a *App) EmitChangedFiles(files []engine.ChangedFile) {
+	if a.ctx != nil {
+		runtime.EventsEmit(a.ctx, "run:files_changed", map[string]interface{}{"files": files})
+	}
+}
+
+// GetChangedFiles returns the changed-files feed of the current or most recent run,
+// for a sidebar opened mid-run.
+func (a *App) GetChangedFiles() []engine.ChangedFile {
+	if a.engine == nil {
+		return []engine.ChangedFile{}
+	}
+	return a.engine.ChangedFiles()
+}
+
+// GetRunSummaries returns the latest run summaries of the workspace (from
+// .loom/runs), newest first; limit <= 0 returns all.
+func (a *App) GetRunSummaries(limit int) []engine.RunSummary {
+	if a.engine == nil || a.engine.Workspace() == "" {
+		return []engine.RunSummary{}
+	}
+	runs, err := engine.LoadRunSummaries(a.engine.Workspace(), a.engine.Sealer(), limit)
+	if err != nil {
+		return []engine.RunSummary{}
+	}
+	return runs
+}
+
+// GetModelSuggestion evaluates the usage analytics now and returns a suggested default
+// model with projected monthly savings, or nil when there is none.
+func (a *App) GetModelSuggestion() *engine.ModelSuggestion {
+	if a.engine == nil {
+		return nil
+	}
+	return a.engine.ModelSuggestion()
+}
+
+// DismissModelSuggestion stops suggesting suggested as a replacement for current.
+func (a *App) DismissModelSuggestion(current, suggested string) {
+	a.ensureSettingsLoaded()
+	key := engine.ModelSuggestion{Current: current, Suggested: suggested}.Key()
+	if !slices.Contains(a.settings.DismissedModelSuggestions, key) {
+		a.settings.DismissedModelSuggestions = append(a.settings.DismissedModelSuggestions, key)
+		_ = config.Save(a.settings)
+	}
+	a.audit("settings", map[string]interface{}{"model_suggestion_dismissed": key})
+}
+
+// ChooseExternalAttachments opens a native file picker for files outside the
+// workspace and attaches the selection to the current conversation.
+// Returns: { attachments: [{ name, ref, size, binary }], error? }.
+func (a *App) ChooseExternalAttachments() map[string]interface{} {
+	if a.ctx == nil || a.engine == nil {
+		return map[string]interface{}{"error": "engine not initialized"}
+	}
+	paths, err := runtime.OpenMultipleFilesDialog(a.ctx, runtime.OpenDialogOptions{
+		Title: "Attach Files",
+	})
+	if err != nil {
+		return map[string]interface{}{"error": err.Error()}
+	}
+	return a.AttachExternalFiles(paths)
+}
+
+// AttachExternalFiles copies the given absolute paths into the conversation's
+// attachments directory. They become readable (read-only) as "@attachments/<name>".
+func (a *App) AttachExternalFiles(paths []string) map[string]interface{} {
+	if a.engine == nil {
+		return map[string]interface{}{"error": "engine not initialized"}
+	}
+	attached, err := a.engine.AttachExternalFiles(paths)
+	for _, at := range attached {
+		a.audit("attachment", map[string]interface{}{"ref": at.Ref, "size": at.Size})
+	}
+	if attached == nil {
+		attached = []tool.AttachmentInfo{}
+	}
+	res := map[string]interface{}{"attachments": attached}
+	if err != nil {
+		res["error"] = err.Error()
+	}
+	return res
+}
+
+// GetAttachmentExcerpt returns a size-limited rendering of an attached file for
+// inclusion in a chat message, or "" when it cannot be read.
+func (a *App) GetAttachmentExcerpt(ref string) string {
+	if a.engine == nil {
+		return ""
+	}
+	excerpt, err := a.engine.AttachmentExcerpt(ref)
+	if err != nil {
+		return ""
+	}
+	return excerpt
+}
+
+// GetAuditLog returns the most recent audit events for the current workspace,
+// oldest first, with the result of verifying the hash chain.
+// Returns: { events: [...], verified: number, error? }.
+func (a *App) GetAuditLog(limit int) map[string]interface{} {
+	if a.engine == nil {
+		return map[string]interface{}{"error": "engine not initialized"}
+	}
+	events, err := a.engine.AuditLog(limit)
+	if err != nil {
+		return map[string]interface{}{"error": err.Error()}
+	}
+	if events == nil {
+		events = []memory.AuditEvent{}
+	}
+	res := map[string]interface{}{"events": events}
+	n, err := a.engine.VerifyAuditLog()
+	res["verified"] = n
+	if err != nil {
+		res["error"] = err.Error()
+	}
+	return res
+}
+
+// ExportAuditLog verifies the audit log and writes it as JSON lines to a file the
+// user chooses. Returns: { path, count } or { error }; an empty result means cancelled.
+func (a *App) ExportAuditLog() map[string]interface{} {
+	if a.ctx == nil || a.engine == nil {
+		return map[string]interface{}{"error": "engine not initialized"}
+	}
+	path, err := runtime.SaveFileDialog(a.ctx, runtime.SaveDialogOptions{
+		Title:           "Export Audit Log",
+		DefaultFilename: fmt.Sprintf("loom-audit-%s.jsonl", time.Now().Format("20060102-150405")),
+	})
+	if err != nil {
+		return map[string]interface{}{"error": err.Error()}
+	}
+	if path == "" {
+		return map[string]interface{}{}
+	}
+	var buf bytes.Buffer
+	n, err := a.engine.ExportAuditLog(&buf)
+	if err != nil {
+		return map[string]interface{}{"error": err.Error()}
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0o600); err != nil {
+		return map[string]interface{}{"error": err.Error()}
+	}
+	a.audit("audit_export", map[string]interface{}{"path": path, "count": n})
+	return map[string]interface{}{"path": path, "count": n}
+}
+
+// DescribeChanges generates a PR-ready description of the current conversation's changes.
+// Returns: { title, summary, rationale, files, commits, todos, tests, risks, review_notes,
+// refined, markdown } or { error }.
+func (a *App) DescribeChanges() map[string]interface{} {
+	if a.engine == nil {
+		return map[string]interface{}{"error": "engine not initialized"}
+	}
+	d, err := a.engine.DescribeChanges()
+	if err != nil {
+		return map[string]interface{}{"error": err.Error()}
+	}
+	return changeDescriptionMap(d)
+}
+
+// RefineChangeDescription has the current model write the description's title, summary
+// and review notes as structured output; export and publish use them until more
+// changes are made. Returns the same fields as DescribeChanges, or { error }.
+func (a *App) RefineChangeDescription() map[string]interface{} {
+	if a.engine == nil {
+		return map[string]interface{}{"error": "engine not initialized"}
+	}
+	ctx := a.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	d, err := a.engine.RefineChangeDescription(ctx)
+	if err != nil {
+		return map[string]interface{}{"error": err.Error()}
+	}
+	return changeDescriptionMap(d)
+}
+
+func changeDescriptionMap(d *engine.ChangeDescription) map[string]interface{} {
+	return map[string]interface{}{
+		"title": d.Title, "summary": d.Summary, "rationale": d.Rationale, "files": d.Files, "commits": d.Commits,
+		"todos": d.Todos, "tests": d.Tests, "risks": d.Risks, "review_notes": d.ReviewNotes, "refined": d.Refined,
+		"markdown": d.Markdown,
+	}
+}
+
+// ExportChangeDescription saves the generated description as a Markdown file chosen by the user.
+func (a *App) ExportChangeDescription() map[string]interface{} {
+	if a.ctx == nil || a.engine == nil {
+		return map[string]interface{}{"error": "engine not initialized"}
+	}
+	d, err := a.engine.DescribeChanges()
+	if err != nil {
+		return map[string]interface{}{"error": err.Error()}
+	}
+	path, err := runtime.SaveFileDialog(a.ctx, runtime.SaveDialogOptions{
+		Title:           "Export PR Description",
+		DefaultFilename: "PR_DESCRIPTION.md",
+	})
+	if err != nil {
+		return map[string]interface{}{"error": err.Error()}
+	}
+	if path == "" {
+		return map[string]interface{}{}
+	}
+	if err := os.WriteFile(path, []byte(d.Markdown), 0o644); err != nil {
+		return map[string]interface{}{"error": err.Error()}
+	}
+	return map[string]interface{}{"path": path}
+}
+
+// PublishChangeDescription sets the generated description on the current branch's pull
+// request via the GitHub CLI, creating a pull request (optionally a draft) if needed.
+// Returns: { url, updated } or { error }.
+func (a *App) PublishChangeDescription(draft bool) map[string]interface{} {
+	if a.engine == nil {
+		return map[string]interface{}{"error": "engine not initialized"}
+	}
+	if a.demoMode {
+		return map[string]interface{}{"error": "demo mode: publishing is disabled"}
+	}
+	d, err := a.engine.DescribeChanges()
+	if err != nil {
+		return map[string]interface{}{"error": err.Error()}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+	res, err := tool.PublishPullRequest(ctx, a.engine.Workspace(), d.Title, d.Markdown, draft)
+	if err != nil {
+		return map[string]interface{}{"error": err.Error()}
+	}
+	a.audit("pull_request", map[string]interface{}{"url": res.URL, "updated": res.Updated, "body_sha256": sha256Hex([]byte(d.Markdown))})
+	return map[string]interface{}{"url": res.URL, "updated": res.Updated}
+}
+
 // RunProfiler runs the project profiler on the current workspace
 func (a *App) RunProfiler() map[string]interface{} {
 	result := map[string]interface{}{
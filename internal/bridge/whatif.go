@@ -0,0 +1,108 @@
+package bridge
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/loom/loom/internal/overlay"
+	"github.com/loom/loom/internal/tool"
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// overlayRegistry builds the tools of a what-if overlay with the same limits and demo
+// mode as the workspace registry. Symbols and MCP tools stay bound to the real workspace
+// and are not offered in what-if mode.
+func (a *App) overlayRegistry(root string) *tool.Registry {
+	reg := tool.NewRegistry().WithUI(a)
+	a.applyToolLimits(reg)
+	reg.SetDemoMode(a.demoMode)
+	tool.RegisterCoreTools(reg, root)
+	return reg
+}
+
+// GetWhatIf reports whether the current conversation runs in what-if mode and, if so,
+// which files its overlay changed.
+// Returns: { active, created_at, changes: [{ path, status, conflict }] } or { error }.
+func (a *App) GetWhatIf() map[string]interface{} {
+	if a.engine == nil {
+		return map[string]interface{}{"error": "engine not initialized"}
+	}
+	ov := a.engine.WhatIf()
+	if ov == nil {
+		return map[string]interface{}{"active": false, "changes": []overlay.Change{}}
+	}
+	changes, err := ov.Changes()
+	if err != nil {
+		return map[string]interface{}{"error": err.Error()}
+	}
+	if changes == nil {
+		changes = []overlay.Change{}
+	}
+	return map[string]interface{}{"active": true, "created_at": ov.CreatedAt, "changes": changes}
+}
+
+// EnableWhatIf copies the workspace into an overlay for the current conversation so its
+// edits, builds and tests no longer touch the real files. It returns an error message or "".
+func (a *App) EnableWhatIf() string {
+	if a.engine == nil {
+		return "engine not initialized"
+	}
+	if _, err := a.engine.EnableWhatIf(); err != nil {
+		return err.Error()
+	}
+	a.audit("settings", map[string]interface{}{"what_if": "enabled"})
+	a.SendChat("system", "What-if mode is on: changes in this conversation go to a private copy of the workspace until you apply them.")
+	a.emitWhatIf()
+	return ""
+}
+
+// MaterializeWhatIf writes the overlay's changes to the workspace, all of them when
+// paths is empty. Files that also changed in the workspace are left in the overlay.
+// Returns: { applied: [...], conflicts: [...] } or { error }.
+func (a *App) MaterializeWhatIf(paths []string) map[string]interface{} {
+	if a.engine == nil {
+		return map[string]interface{}{"error": "engine not initialized"}
+	}
+	if err := a.ensureWritable(); err != nil {
+		return map[string]interface{}{"error": err.Error()}
+	}
+	res, err := a.engine.MaterializeWhatIf(paths)
+	if errors.Is(err, overlay.ErrNotFound) {
+		return map[string]interface{}{"error": err.Error()}
+	}
+	out := map[string]interface{}{"applied": res.Applied, "conflicts": res.Conflicts}
+	if len(res.Applied) > 0 {
+		applied := make([]string, 0, len(res.Applied))
+		for _, c := range res.Applied {
+			applied = append(applied, c.Path)
+		}
+		a.audit("edit", map[string]interface{}{"source": "what-if", "ok": err == nil, "paths": applied})
+		a.SendChat("system", fmt.Sprintf("Applied %d what-if change(s) to the workspace.", len(res.Applied)))
+	}
+	if err != nil {
+		out["error"] = err.Error()
+	}
+	a.emitWhatIf()
+	return out
+}
+
+// DiscardWhatIf deletes the current conversation's overlay and leaves what-if mode.
+// It returns an error message or "".
+func (a *App) DiscardWhatIf() string {
+	if a.engine == nil {
+		return "engine not initialized"
+	}
+	if err := a.engine.DiscardWhatIf(); err != nil {
+		return err.Error()
+	}
+	a.audit("settings", map[string]interface{}{"what_if": "discarded"})
+	a.SendChat("system", "What-if changes discarded; this conversation works on the real workspace again.")
+	a.emitWhatIf()
+	return ""
+}
+
+func (a *App) emitWhatIf() {
+	if a.ctx != nil {
+		runtime.EventsEmit(a.ctx, "whatif:changed", a.GetWhatIf())
+	}
+}
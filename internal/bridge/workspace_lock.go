@@ -0,0 +1,132 @@
+package bridge
+
+import (
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/loom/loom/internal/wslock"
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// WorkspaceLockInfo tells the frontend whether this instance owns its workspace.
+type WorkspaceLockInfo struct {
+	Workspace string `json:"workspace"`
+	// ReadOnly is set while another instance owns the workspace
+	ReadOnly bool   `json:"read_only"`
+	Reason   string `json:"reason,omitempty"`
+	// Holder is the instance owning the workspace when this one is read-only
+	Holder *wslock.Holder `json:"holder,omitempty"`
+}
+
+// lockWorkspace releases the previous workspace's lock and locks ws. When another
+// live instance owns ws, this instance stays read-only until the user takes over.
+func (a *App) lockWorkspace(ws string) {
+	if a.wsLock != nil && a.wsLockPath == ws && a.readOnlyReason == "" {
+		return
+	}
+	a.unlockWorkspace()
+	a.wsLockPath = ws
+	l, err := wslock.Acquire(ws, a.onWorkspaceLockLost)
+	var held *wslock.HeldError
+	switch {
+	case err == nil:
+		a.wsLock = l
+	case errors.As(err, &held):
+		a.setReadOnly(&held.Holder, fmt.Sprintf("another Loom instance (%s) is working in this workspace", held.Holder))
+	default:
+		// Without a lock file (e.g. a read-only checkout) the workspace stays usable
+		log.Printf("Warning: failed to lock workspace %s: %v", ws, err)
+	}
+}
+
+// unlockWorkspace releases the workspace lock and leaves read-only mode.
+func (a *App) unlockWorkspace() {
+	if a.wsLock != nil {
+		if err := a.wsLock.Release(); err != nil {
+			log.Printf("Warning: failed to release workspace lock: %v", err)
+		}
+		a.wsLock = nil
+	}
+	a.readOnlyReason, a.lockHolder = "", nil
+	if a.tools != nil {
+		a.tools.SetReadOnly("")
+	}
+}
+
+// setReadOnly stops this instance from changing the workspace and tells the user.
+func (a *App) setReadOnly(holder *wslock.Holder, reason string) {
+	a.readOnlyReason, a.lockHolder = reason, holder
+	if a.tools != nil {
+		a.tools.SetReadOnly(reason)
+	}
+	a.audit("workspace", map[string]interface{}{"read_only": true, "reason": reason})
+	a.SendChat("system", fmt.Sprintf("This workspace is read-only: %s. Take over the workspace to make changes here.", reason))
+	if a.ctx != nil {
+		runtime.EventsEmit(a.ctx, "workspace:locked", a.GetWorkspaceLock())
+	}
+}
+
+// onWorkspaceLockLost is called from the lock's heartbeat when another instance took
+// the workspace over.
+func (a *App) onWorkspaceLockLost(by wslock.Holder) {
+	a.wsLock = nil
+	a.setReadOnly(&by, fmt.Sprintf("another Loom instance (%s) took over this workspace", by))
+}
+
+// ensureWritable returns an error when another instance owns the workspace.
+func (a *App) ensureWritable() error {
+	if a.readOnlyReason == "" {
+		return nil
+	}
+	return fmt.Errorf("workspace is read-only: %s", a.readOnlyReason)
+}
+
+// ReleaseWorkspace releases the workspace lock; call it when the app shuts down.
+func (a *App) ReleaseWorkspace() {
+	if a.wsLock != nil {
+		_ = a.wsLock.Release()
+		a.wsLock = nil
+	}
+}
+
+// GetWorkspaceLock reports whether this instance may change its workspace.
+func (a *App) GetWorkspaceLock() WorkspaceLockInfo {
+	return WorkspaceLockInfo{
+		Workspace: a.wsLockPath,
+		ReadOnly:  a.readOnlyReason != "",
+		Reason:    a.readOnlyReason,
+		Holder:    a.lockHolder,
+	}
+}
+
+// TakeOverWorkspace makes this instance the owner of its workspace; the other
+// instance turns read-only on its next heartbeat. Returns an error message, or "".
+func (a *App) TakeOverWorkspace() string {
+	if a.wsLockPath == "" {
+		return "no workspace open"
+	}
+	if a.readOnlyReason == "" {
+		return ""
+	}
+	l, err := wslock.TakeOver(a.wsLockPath, a.onWorkspaceLockLost)
+	if err != nil {
+		return err.Error()
+	}
+	previous := a.lockHolder
+	a.wsLock = l
+	a.readOnlyReason, a.lockHolder = "", nil
+	if a.tools != nil {
+		a.tools.SetReadOnly("")
+	}
+	entry := map[string]interface{}{"read_only": false, "took_over": true}
+	if previous != nil {
+		entry["previous_pid"] = previous.PID
+	}
+	a.audit("workspace", entry)
+	a.SendChat("system", "Took over the workspace; the other Loom instance is now read-only.")
+	if a.ctx != nil {
+		runtime.EventsEmit(a.ctx, "workspace:locked", a.GetWorkspaceLock())
+	}
+	return ""
+}
@@ -0,0 +1,161 @@
+package bridge
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"slices"
+
+	"github.com/loom/loom/internal/config"
+	"github.com/loom/loom/internal/engine"
+	"github.com/loom/loom/internal/symbols"
+	"github.com/loom/loom/internal/tool"
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// workspaceRoot is a further workspace root with its own tools and symbol index.
+type workspaceRoot struct {
+	path  string
+	tools *tool.Registry
+	// symbols is the root's index, stopped when the root closes
+	symbols interface{}
+}
+
+// close stops the root's symbol index from following its files.
+func (r *workspaceRoot) close() {
+	if w, ok := r.symbols.(interface{ StopWatching() }); ok {
+		w.StopWatching()
+	}
+}
+
+// GetWorkspaces returns the open workspace roots, the primary first.
+func (a *App) GetWorkspaces() []engine.WorkspaceRoot {
+	out := []engine.WorkspaceRoot{}
+	if a.engine == nil {
+		return out
+	}
+	return append(out, a.engine.WorkspaceRoots()...)
+}
+
+// AddWorkspace opens another directory next to the workspace, e.g. the backend of a
+// frontend repository. Its tools and symbol index are kept apart, and tool calls select
+// it with their workspace argument. The root is reopened with the workspace.
+// Returns: { workspace, workspaces } or { error }.
+func (a *App) AddWorkspace(path string) map[string]interface{} {
+	if a.engine == nil || a.tools == nil {
+		return map[string]interface{}{"error": "engine not initialized"}
+	}
+	root, err := a.openWorkspaceRoot(normalizeWorkspacePath(path))
+	if err != nil {
+		return map[string]interface{}{"error": err.Error()}
+	}
+	a.ensureSettingsLoaded()
+	primary := a.engine.Workspace()
+	if a.settings.WorkspaceRoots == nil {
+		a.settings.WorkspaceRoots = map[string][]string{}
+	}
+	if !slices.Contains(a.settings.WorkspaceRoots[primary], root.Path) {
+		a.settings.WorkspaceRoots[primary] = append(a.settings.WorkspaceRoots[primary], root.Path)
+		_ = config.Save(a.settings)
+	}
+	a.audit("workspace", map[string]interface{}{"path": root.Path, "root": root.Name, "action": "add"})
+	a.SendChat("system", fmt.Sprintf("Added workspace root %s (%s).", root.Name, root.Path))
+	a.emitWorkspaces()
+	return map[string]interface{}{"workspace": root, "workspaces": a.GetWorkspaces()}
+}
+
+// RemoveWorkspace closes a root opened with AddWorkspace. Returns an error message or "".
+func (a *App) RemoveWorkspace(name string) string {
+	if a.engine == nil {
+		return "engine not initialized"
+	}
+	root, err := a.engine.RemoveWorkspaceRoot(name)
+	if err != nil {
+		return err.Error()
+	}
+	if r := a.roots[name]; r != nil {
+		r.close()
+		delete(a.roots, name)
+	}
+	a.attachWorkspaceRoots(a.tools)
+	a.ensureSettingsLoaded()
+	primary := a.engine.Workspace()
+	if paths := slices.DeleteFunc(a.settings.WorkspaceRoots[primary], func(p string) bool { return p == root.Path }); len(paths) > 0 {
+		a.settings.WorkspaceRoots[primary] = paths
+	} else {
+		delete(a.settings.WorkspaceRoots, primary)
+	}
+	_ = config.Save(a.settings)
+	a.audit("workspace", map[string]interface{}{"path": root.Path, "root": root.Name, "action": "remove"})
+	a.SendChat("system", fmt.Sprintf("Removed workspace root %s.", root.Name))
+	a.emitWorkspaces()
+	return ""
+}
+
+// openWorkspaceRoot adds a root to the engine, builds its tools and symbol index and
+// offers it to the workspace's tools.
+func (a *App) openWorkspaceRoot(path string) (engine.WorkspaceRoot, error) {
+	root, err := a.engine.AddWorkspaceRoot(path)
+	if err != nil {
+		return engine.WorkspaceRoot{}, err
+	}
+	reg := tool.NewRegistry().WithUI(a)
+	a.applyToolLimits(reg)
+	tool.RegisterCoreTools(reg, root.Path)
+	r := &workspaceRoot{path: root.Path, tools: reg}
+	if svc, err := symbols.NewSQLiteService(root.Path); err == nil {
+		svc.SetWatchOptions(a.watchOptions())
+		go func() { _ = svc.StartIndexing(context.Background()) }()
+		_ = tool.RegisterSymbols(reg, svc)
+		r.symbols = svc
+	} else if svc, err := symbols.NewService(root.Path); err == nil {
+		go func() { _ = svc.StartIndexing(context.Background()) }()
+		_ = tool.RegisterSymbols(reg, svc)
+		r.symbols = svc
+	}
+	if a.roots == nil {
+		a.roots = map[string]*workspaceRoot{}
+	}
+	a.roots[root.Name] = r
+	a.attachWorkspaceRoots(a.tools)
+	return root, nil
+}
+
+// restoreWorkspaceRoots closes the previous workspace's further roots and reopens the
+// ones saved with workspace. Roots that no longer exist are skipped.
+func (a *App) restoreWorkspaceRoots(workspace string) {
+	for name, r := range a.roots {
+		r.close()
+		delete(a.roots, name)
+	}
+	a.ensureSettingsLoaded()
+	for _, p := range a.settings.WorkspaceRoots[workspace] {
+		if _, err := a.openWorkspaceRoot(p); err != nil {
+			log.Printf("Skipping workspace root %s: %v", p, err)
+		}
+	}
+	a.attachWorkspaceRoots(a.tools)
+}
+
+// attachWorkspaceRoots lets the calls of reg's tools run in the open roots.
+func (a *App) attachWorkspaceRoots(reg *tool.Registry) {
+	if reg == nil || a.engine == nil {
+		return
+	}
+	roots := make(map[string]*tool.Registry, len(a.roots))
+	for name, r := range a.roots {
+		roots[name] = r.tools
+	}
+	primary := ""
+	if all := a.engine.WorkspaceRoots(); len(all) > 0 {
+		primary = all[0].Name
+	}
+	reg.SetWorkspaceRoots(primary, roots)
+}
+
+// emitWorkspaces tells the UI that the open roots changed.
+func (a *App) emitWorkspaces() {
+	if a.ctx != nil {
+		runtime.EventsEmit(a.ctx, "workspace:roots", a.GetWorkspaces())
+	}
+}
@@ -0,0 +1,179 @@
+package config
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Usage analytics under $HOME/.loom/usages: one JSON line per completed user request
+// (turns.jsonl) and per model comparison replayed as an experiment (experiments.jsonl).
+
+const (
+	turnsFile       = "turns.jsonl"
+	experimentsFile = "experiments.jsonl"
+	// analyticsRetention is how long analytics records are kept
+	analyticsRetention = 90 * 24 * time.Hour
+	// smallEditLines is the most changed lines a turn may have to count as a small edit
+	smallEditLines = 20
+)
+
+// TurnRecord summarizes one user request: which model answered it, what it cost and
+// how much it changed.
+type TurnRecord struct {
+	Time time.Time `json:"time"`
+	// Model is the "provider:model_id" label active for the request
+	Model     string  `json:"model"`
+	InTokens  int64   `json:"in_tokens"`
+	OutTokens int64   `json:"out_tokens"`
+	CostUSD   float64 `json:"cost_usd"`
+	ToolCalls int     `json:"tool_calls"`
+	// EditedFiles and EditedLines count the file changes Loom applied (added + removed lines)
+	EditedFiles int `json:"edited_files"`
+	EditedLines int `json:"edited_lines"`
+}
+
+// SmallEdit reports whether the turn applied a change of at most a few lines.
+func (t TurnRecord) SmallEdit() bool {
+	return t.EditedFiles > 0 && t.EditedLines <= smallEditLines
+}
+
+// ExperimentOutcome records whether a candidate model behaved like the baseline when
+// the same request was replayed against both.
+type ExperimentOutcome struct {
+	Time      time.Time `json:"time"`
+	Baseline  string    `json:"baseline"`
+	Candidate string    `json:"candidate"`
+	// Equivalent is true when both finished without errors and edited the same files
+	Equivalent bool `json:"equivalent"`
+}
+
+var analyticsMu sync.Mutex
+
+func analyticsPath(name string) (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	dir := filepath.Join(home, ".loom", "usages")
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, name), nil
+}
+
+// RecordTurn appends a turn to the analytics store.
+func RecordTurn(t TurnRecord) error {
+	if t.Time.IsZero() {
+		t.Time = time.Now()
+	}
+	return appendAnalytics(turnsFile, t)
+}
+
+// LoadTurns returns the turns recorded since the given time, oldest first.
+func LoadTurns(since time.Time) []TurnRecord {
+	var out []TurnRecord
+	readAnalytics(turnsFile, func(line []byte) {
+		var t TurnRecord
+		if json.Unmarshal(line, &t) == nil && !t.Time.Before(since) {
+			out = append(out, t)
+		}
+	})
+	return out
+}
+
+// RecordExperimentOutcome appends a model comparison to the analytics store.
+func RecordExperimentOutcome(o ExperimentOutcome) error {
+	if o.Time.IsZero() {
+		o.Time = time.Now()
+	}
+	return appendAnalytics(experimentsFile, o)
+}
+
+// LoadExperimentOutcomes returns the recorded model comparisons, oldest first.
+func LoadExperimentOutcomes() []ExperimentOutcome {
+	var out []ExperimentOutcome
+	cutoff := time.Now().Add(-analyticsRetention)
+	readAnalytics(experimentsFile, func(line []byte) {
+		var o ExperimentOutcome
+		if json.Unmarshal(line, &o) == nil && o.Time.After(cutoff) {
+			out = append(out, o)
+		}
+	})
+	return out
+}
+
+func appendAnalytics(name string, v interface{}) error {
+	analyticsMu.Lock()
+	defer analyticsMu.Unlock()
+	path, err := analyticsPath(name)
+	if err != nil {
+		return err
+	}
+	pruneAnalytics(path)
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = f.Write(append(data, '\n'))
+	return err
+}
+
+func readAnalytics(name string, fn func(line []byte)) {
+	analyticsMu.Lock()
+	defer analyticsMu.Unlock()
+	path, err := analyticsPath(name)
+	if err != nil {
+		return
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		fn(sc.Bytes())
+	}
+}
+
+// pruneAnalytics drops records older than the retention period, at most once a day
+// per file (judged by the file's first record).
+func pruneAnalytics(path string) {
+	f, err := os.Open(path)
+	if err != nil {
+		return
+	}
+	var first struct {
+		Time time.Time `json:"time"`
+	}
+	sc := bufio.NewScanner(f)
+	cutoff := time.Now().Add(-analyticsRetention)
+	if !sc.Scan() || json.Unmarshal(sc.Bytes(), &first) != nil || !first.Time.Before(cutoff.Add(-24*time.Hour)) {
+		f.Close()
+		return
+	}
+	var kept [][]byte
+	for ok := true; ok; ok = sc.Scan() {
+		var rec struct {
+			Time time.Time `json:"time"`
+		}
+		if json.Unmarshal(sc.Bytes(), &rec) == nil && rec.Time.After(cutoff) {
+			kept = append(kept, append([]byte(nil), sc.Bytes()...))
+		}
+	}
+	f.Close()
+	var buf []byte
+	for _, line := range kept {
+		buf = append(append(buf, line...), '\n')
+	}
+	_ = os.WriteFile(path, buf, 0o600)
+}
@@ -0,0 +1,43 @@
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAnalytics_RecordAndLoadTurns(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	now := time.Now()
+	old := TurnRecord{Time: now.Add(-48 * time.Hour), Model: "openai:gpt-4o", EditedFiles: 1, EditedLines: 200}
+	recent := TurnRecord{Time: now.Add(-time.Hour), Model: "openai:gpt-4o", EditedFiles: 1, EditedLines: 4}
+	for _, r := range []TurnRecord{old, recent} {
+		if err := RecordTurn(r); err != nil {
+			t.Fatalf("RecordTurn: %v", err)
+		}
+	}
+	if got := LoadTurns(now.Add(-72 * time.Hour)); len(got) != 2 {
+		t.Fatalf("expected 2 turns, got %d", len(got))
+	}
+	got := LoadTurns(now.Add(-24 * time.Hour))
+	if len(got) != 1 || !got[0].SmallEdit() {
+		t.Fatalf("expected only the recent small edit, got %+v", got)
+	}
+	if old.SmallEdit() || (TurnRecord{}).SmallEdit() {
+		t.Error("large edits and turns without edits are not small edits")
+	}
+}
+
+func TestAnalytics_PrunesExpiredRecords(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	expired := ExperimentOutcome{Time: time.Now().Add(-analyticsRetention - 48*time.Hour), Baseline: "a:x", Candidate: "b:y"}
+	if err := RecordExperimentOutcome(expired); err != nil {
+		t.Fatal(err)
+	}
+	if err := RecordExperimentOutcome(ExperimentOutcome{Baseline: "a:x", Candidate: "b:y", Equivalent: true}); err != nil {
+		t.Fatal(err)
+	}
+	got := LoadExperimentOutcomes()
+	if len(got) != 1 || !got[0].Equivalent {
+		t.Fatalf("expected only the fresh outcome, got %+v", got)
+	}
+}
@@ -0,0 +1,45 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// AzureOpenAI is an Azure OpenAI resource used by "azure:<deployment>" models, for
+// organizations that reach OpenAI models only through Azure.
+type AzureOpenAI struct {
+	// Endpoint is the resource endpoint, e.g. "https://contoso.openai.azure.com"
+	Endpoint string `json:"endpoint,omitempty"`
+	APIKey   string `json:"api_key,omitempty"`
+	// Deployments lists the deployment names offered in the model selector
+	Deployments []string `json:"deployments,omitempty"`
+	// APIVersion is the data plane api-version; empty uses the adapter's default
+	APIVersion string `json:"api_version,omitempty"`
+}
+
+// Enabled reports whether a resource endpoint is configured.
+func (a AzureOpenAI) Enabled() bool {
+	return strings.TrimSpace(a.Endpoint) != ""
+}
+
+// Validate checks that the endpoint is an https URL and the deployment names are
+// usable in a request path.
+func (a AzureOpenAI) Validate() error {
+	if !a.Enabled() {
+		if len(a.Deployments) > 0 {
+			return fmt.Errorf("azure OpenAI: set the resource endpoint for the deployments")
+		}
+		return nil
+	}
+	u, err := url.Parse(strings.TrimSpace(a.Endpoint))
+	if err != nil || u.Scheme != "https" || u.Host == "" {
+		return fmt.Errorf("azure OpenAI endpoint %q must be an https URL like https://<resource>.openai.azure.com", a.Endpoint)
+	}
+	for _, d := range a.Deployments {
+		if d = strings.TrimSpace(d); d == "" || strings.ContainsAny(d, "/?#: ") {
+			return fmt.Errorf("azure OpenAI deployment %q must be a plain deployment name", d)
+		}
+	}
+	return nil
+}
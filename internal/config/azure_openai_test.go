@@ -0,0 +1,26 @@
+package config
+
+import "testing"
+
+func TestAzureOpenAI_Validate(t *testing.T) {
+	valid := []AzureOpenAI{
+		{},
+		{Endpoint: "https://contoso.openai.azure.com", Deployments: []string{"gpt-4o", "o4-mini"}},
+	}
+	for _, a := range valid {
+		if err := a.Validate(); err != nil {
+			t.Errorf("%+v: unexpected error %v", a, err)
+		}
+	}
+	invalid := []AzureOpenAI{
+		{Deployments: []string{"gpt-4o"}},
+		{Endpoint: "http://contoso.openai.azure.com"},
+		{Endpoint: "contoso"},
+		{Endpoint: "https://contoso.openai.azure.com", Deployments: []string{"a/b"}},
+	}
+	for _, a := range invalid {
+		if err := a.Validate(); err == nil {
+			t.Errorf("%+v: expected an error", a)
+		}
+	}
+}
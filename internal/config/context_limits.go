@@ -0,0 +1,44 @@
+package config
+
+import "strings"
+
+// contextWindowPrefixes maps model identifier prefixes to the number of input tokens
+// the model accepts. The longest matching prefix wins, so dated snapshots and variants
+// inherit the window of their family.
+// Keep this list refreshed from provider docs periodically, like PricePerToken.
+var contextWindowPrefixes = map[string]int{
+	// Anthropic
+	"claude-": 200000,
+
+	// OpenAI
+	"gpt-5":       272000,
+	"gpt-4.1":     1047576,
+	"gpt-4o":      128000,
+	"gpt-4-turbo": 128000,
+	"o1":          200000,
+	"o3":          200000,
+	"o4-mini":     200000,
+	"gpt-3.5":     16385,
+	"gpt-oss":     131072,
+	"codex-mini":  200000,
+
+	// Google
+	"gemini-": 1048576,
+}
+
+// ContextWindow returns how many input tokens the model accepts, or 0 when unknown.
+// OpenRouter models use the context length their catalog reports.
+func ContextWindow(model string) int {
+	best, window := 0, 0
+	for prefix, n := range contextWindowPrefixes {
+		if strings.HasPrefix(model, prefix) && len(prefix) > best {
+			best, window = len(prefix), n
+		}
+	}
+	if window > 0 {
+		return window
+	}
+	openRouterCacheMutex.RLock()
+	defer openRouterCacheMutex.RUnlock()
+	return openRouterContext[model]
+}
@@ -0,0 +1,9 @@
+package config
+
+// Policies for agent edits that touch lines the user has changed but not saved.
+const (
+	// DirtyEditRefuse rejects the edit and tells the model to leave those lines alone.
+	DirtyEditRefuse = "refuse"
+	// DirtyEditWarn lets the edit through with a warning to the model and the user.
+	DirtyEditWarn = "warn"
+)
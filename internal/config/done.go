@@ -0,0 +1,52 @@
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// DefinitionOfDone lists the checks a run must pass before the engine accepts
+// finalization. It is read from <workspace>/.loom/done.json; empty commands are skipped.
+type DefinitionOfDone struct {
+	Build string `json:"build,omitempty"`
+	Test  string `json:"test,omitempty"`
+	// Lint output is compared against a baseline taken at the start of the run so only
+	// newly introduced findings fail the check.
+	Lint                 string `json:"lint,omitempty"`
+	RequireTodosComplete bool   `json:"require_todos_complete,omitempty"`
+	TimeoutSec           int    `json:"timeout_sec,omitempty"`
+}
+
+// Enabled reports whether any check is configured.
+func (d DefinitionOfDone) Enabled() bool {
+	return strings.TrimSpace(d.Build) != "" || strings.TrimSpace(d.Test) != "" ||
+		strings.TrimSpace(d.Lint) != "" || d.RequireTodosComplete
+}
+
+// DefinitionOfDonePath returns <workspace>/.loom/done.json.
+func DefinitionOfDonePath(workspace string) string {
+	return filepath.Join(workspace, ".loom", "done.json")
+}
+
+// LoadDefinitionOfDone reads the workspace's definition of done. A missing file
+// yields an empty (disabled) definition without error.
+func LoadDefinitionOfDone(workspace string) (DefinitionOfDone, error) {
+	var d DefinitionOfDone
+	if strings.TrimSpace(workspace) == "" {
+		return d, nil
+	}
+	data, err := os.ReadFile(DefinitionOfDonePath(workspace))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return d, nil
+		}
+		return d, err
+	}
+	if len(data) == 0 {
+		return d, nil
+	}
+	err = json.Unmarshal(data, &d)
+	return d, err
+}
@@ -0,0 +1,32 @@
+package config
+
+import "strings"
+
+// Edit formats a model can be asked to use for file edits.
+const (
+	// EditFormatAnchor is the default: edit_file actions addressed by anchors or lines.
+	EditFormatAnchor = "anchor"
+	// EditFormatSearchReplace asks for SEARCH/REPLACE blocks matched by content.
+	EditFormatSearchReplace = "search_replace"
+)
+
+// EditFormatFor returns the edit format configured for a model label ("provider:model").
+// An entry for the full label wins over one for the provider, which wins over "*";
+// unknown or missing values fall back to EditFormatAnchor.
+func (s Settings) EditFormatFor(modelLabel string) string {
+	label := strings.ToLower(strings.TrimSpace(modelLabel))
+	provider, _, _ := strings.Cut(label, ":")
+	formats := make(map[string]string, len(s.EditFormats))
+	for k, v := range s.EditFormats {
+		formats[strings.ToLower(strings.TrimSpace(k))] = v
+	}
+	for _, key := range []string{label, provider, "*"} {
+		if v, ok := formats[key]; ok && key != "" {
+			switch f := strings.ToLower(strings.TrimSpace(v)); f {
+			case EditFormatAnchor, EditFormatSearchReplace:
+				return f
+			}
+		}
+	}
+	return EditFormatAnchor
+}
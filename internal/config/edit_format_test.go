@@ -0,0 +1,32 @@
+package config
+
+import "testing"
+
+func TestEditFormatFor(t *testing.T) {
+	s := Settings{EditFormats: map[string]string{
+		"ollama":                "search_replace",
+		"ollama:qwen2.5-coder":  "anchor",
+		"OpenRouter:Some/Model": "SEARCH_REPLACE",
+		"openai":                "bogus",
+	}}
+	cases := map[string]string{
+		"ollama:llama3.1":           EditFormatSearchReplace,
+		"ollama:qwen2.5-coder":      EditFormatAnchor,
+		"openrouter:some/model":     EditFormatSearchReplace,
+		"openai:gpt-4.1":            EditFormatAnchor,
+		"anthropic:claude-sonnet-4": EditFormatAnchor,
+	}
+	for label, want := range cases {
+		if got := s.EditFormatFor(label); got != want {
+			t.Errorf("%s: got %q, want %q", label, got, want)
+		}
+	}
+
+	s.EditFormats["*"] = "search_replace"
+	if got := s.EditFormatFor("anthropic:claude-sonnet-4"); got != EditFormatSearchReplace {
+		t.Errorf("wildcard should apply, got %q", got)
+	}
+	if got := (Settings{}).EditFormatFor("openai:gpt-4.1"); got != EditFormatAnchor {
+		t.Errorf("default should be anchor, got %q", got)
+	}
+}
@@ -0,0 +1,17 @@
+package config
+
+// DefaultMaxEditLines bounds the lines a single edit may change when max_edit_lines
+// is not set.
+const DefaultMaxEditLines = 300
+
+// EffectiveMaxEditLines returns the most lines one edit_file proposal may change,
+// counting added and removed lines; 0 means edits are not limited.
+func (s Settings) EffectiveMaxEditLines() int {
+	switch {
+	case s.MaxEditLines < 0:
+		return 0
+	case s.MaxEditLines == 0:
+		return DefaultMaxEditLines
+	}
+	return s.MaxEditLines
+}
@@ -0,0 +1,12 @@
+package config
+
+import "testing"
+
+func TestEffectiveMaxEditLines(t *testing.T) {
+	cases := map[int]int{0: DefaultMaxEditLines, -1: 0, 50: 50}
+	for set, want := range cases {
+		if got := (Settings{MaxEditLines: set}).EffectiveMaxEditLines(); got != want {
+			t.Errorf("max_edit_lines %d: got %d, want %d", set, got, want)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Explanation levels set how deeply replies explain code and which vocabulary they
+// assume, for teams with mixed experience.
+const (
+	ExplanationBeginner     = "beginner"
+	ExplanationIntermediate = "intermediate"
+	ExplanationExpert       = "expert"
+)
+
+// ExplanationLevels lists the levels from the most to the least explained.
+var ExplanationLevels = []string{ExplanationBeginner, ExplanationIntermediate, ExplanationExpert}
+
+// explanationAliases accepts the names people commonly use for a level.
+var explanationAliases = map[string]string{
+	"eli5":     ExplanationBeginner,
+	"novice":   ExplanationBeginner,
+	"junior":   ExplanationBeginner,
+	"mid":      ExplanationIntermediate,
+	"senior":   ExplanationExpert,
+	"advanced": ExplanationExpert,
+}
+
+// ParseExplanationLevel returns the level a name or alias ("ELI5", "senior") selects.
+// "" and "default" return "", which leaves explanations to the model.
+func ParseExplanationLevel(name string) (string, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" || name == "default" {
+		return "", nil
+	}
+	if level, ok := explanationAliases[name]; ok {
+		return level, nil
+	}
+	for _, level := range ExplanationLevels {
+		if name == level {
+			return level, nil
+		}
+	}
+	return "", fmt.Errorf("unknown explanation level %q (use %s)", name, strings.Join(ExplanationLevels, ", "))
+}
@@ -0,0 +1,24 @@
+package config
+
+import "testing"
+
+func TestParseExplanationLevel(t *testing.T) {
+	cases := map[string]string{
+		"":             "",
+		"default":      "",
+		"ELI5":         ExplanationBeginner,
+		" Beginner ":   ExplanationBeginner,
+		"mid":          ExplanationIntermediate,
+		"senior":       ExplanationExpert,
+		"intermediate": ExplanationIntermediate,
+	}
+	for in, want := range cases {
+		got, err := ParseExplanationLevel(in)
+		if err != nil || got != want {
+			t.Errorf("ParseExplanationLevel(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+	if _, err := ParseExplanationLevel("wizard"); err == nil {
+		t.Error("expected an error for an unknown level")
+	}
+}
@@ -0,0 +1,95 @@
+package config
+
+import "fmt"
+
+// Names of the feature flags gating experimental subsystems.
+const (
+	FeatureEmbeddings    = "embeddings"
+	FeatureLSP           = "lsp"
+	FeatureParallelTools = "parallel_tools"
+	FeatureQuickAnswers  = "quick_answers"
+)
+
+// FeatureFlag gates an experimental subsystem so it can be switched on or off in the
+// settings without a rebuild.
+type FeatureFlag struct {
+	Name        string `json:"name"`
+	Default     bool   `json:"default"`
+	Description string `json:"description"`
+}
+
+// FeatureFlags lists every known flag. Settings only store overrides of the defaults.
+var FeatureFlags = []FeatureFlag{
+	{
+		Name:        FeatureEmbeddings,
+		Description: "Semantic code search backed by embeddings of the workspace",
+	},
+	{
+		Name:        FeatureLSP,
+		Description: "Language server integration for diagnostics and navigation",
+	},
+	{
+		Name:        FeatureParallelTools,
+		Default:     true,
+		Description: "Run the likely follow-up reads of a symbol search in parallel in the background",
+	},
+	{
+		Name:        FeatureQuickAnswers,
+		Default:     true,
+		Description: "Answer simple questions about the code from the project profile, symbol index and memories before using tools",
+	},
+}
+
+// LookupFeatureFlag returns the flag with the given name.
+func LookupFeatureFlag(name string) (FeatureFlag, bool) {
+	for _, f := range FeatureFlags {
+		if f.Name == name {
+			return f, true
+		}
+	}
+	return FeatureFlag{}, false
+}
+
+// FeatureEnabled reports whether the named flag is on: the saved override if there is
+// one, the flag's default otherwise. Unknown flags are off.
+func (s Settings) FeatureEnabled(name string) bool {
+	f, ok := LookupFeatureFlag(name)
+	if !ok {
+		return false
+	}
+	if on, set := s.FeatureFlags[name]; set {
+		return on
+	}
+	return f.Default
+}
+
+// EffectiveFeatureFlags returns the state of every known flag. Overrides of flags that
+// no longer exist are ignored.
+func (s Settings) EffectiveFeatureFlags() map[string]bool {
+	out := make(map[string]bool, len(FeatureFlags))
+	for _, f := range FeatureFlags {
+		out[f.Name] = s.FeatureEnabled(f.Name)
+	}
+	return out
+}
+
+// SetFeatureFlag turns the named flag on or off. Only states that differ from the
+// default are stored, so changed defaults reach users who never touched the flag.
+func (s *Settings) SetFeatureFlag(name string, on bool) error {
+	f, ok := LookupFeatureFlag(name)
+	if !ok {
+		return fmt.Errorf("unknown feature flag %s", name)
+	}
+	if on == f.Default {
+		delete(s.FeatureFlags, name)
+		if len(s.FeatureFlags) == 0 {
+			s.FeatureFlags = nil
+		}
+		return nil
+	}
+	if s.FeatureFlags == nil {
+		s.FeatureFlags = make(map[string]bool)
+	}
+	s.FeatureFlags[name] = on
+	return nil
+}
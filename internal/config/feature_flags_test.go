@@ -0,0 +1,40 @@
+package config
+
+import "testing"
+
+func TestFeatureFlags(t *testing.T) {
+	var s Settings
+	if s.FeatureEnabled(FeatureLSP) || !s.FeatureEnabled(FeatureParallelTools) {
+		t.Fatalf("expected the defaults without overrides")
+	}
+	if s.FeatureEnabled("nope") {
+		t.Fatalf("unknown flags are off")
+	}
+
+	if err := s.SetFeatureFlag(FeatureLSP, true); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SetFeatureFlag(FeatureParallelTools, false); err != nil {
+		t.Fatal(err)
+	}
+	flags := s.EffectiveFeatureFlags()
+	if !flags[FeatureLSP] || flags[FeatureParallelTools] || flags[FeatureEmbeddings] {
+		t.Fatalf("unexpected flags: %v", flags)
+	}
+
+	// Returning to the default drops the override
+	_ = s.SetFeatureFlag(FeatureLSP, false)
+	_ = s.SetFeatureFlag(FeatureParallelTools, true)
+	if s.FeatureFlags != nil {
+		t.Fatalf("expected no overrides, got %v", s.FeatureFlags)
+	}
+	if err := s.SetFeatureFlag("nope", true); err == nil {
+		t.Fatalf("expected unknown flags to be rejected")
+	}
+
+	// Overrides of removed flags are ignored
+	s.FeatureFlags = map[string]bool{"removed": true}
+	if _, ok := s.EffectiveFeatureFlags()["removed"]; ok {
+		t.Fatalf("removed flags must not be reported")
+	}
+}
@@ -0,0 +1,51 @@
+package config
+
+import (
+	"strings"
+	"time"
+)
+
+// How the symbol index notices changes to workspace files.
+const (
+	// FileWatchAuto polls on network mounts and WSL Windows drives and uses
+	// filesystem notifications everywhere else.
+	FileWatchAuto = "auto"
+	// FileWatchNotify always uses filesystem notifications (inotify, FSEvents, ...).
+	FileWatchNotify = "notify"
+	// FileWatchPoll always scans the workspace for changes at an adaptive interval.
+	FileWatchPoll = "poll"
+)
+
+const (
+	defaultPollSeconds    = 2
+	defaultMaxPollSeconds = 30
+)
+
+// FileWatch configures how workspace changes reach the symbol index.
+type FileWatch struct {
+	// Mode is "auto", "notify" or "poll"; empty means auto
+	Mode string `json:"mode,omitempty"`
+	// PollSeconds is the polling interval right after a change (default 2)
+	PollSeconds int `json:"poll_seconds,omitempty"`
+	// MaxPollSeconds is the interval polling backs off to while nothing changes (default 30)
+	MaxPollSeconds int `json:"max_poll_seconds,omitempty"`
+}
+
+// EffectiveFileWatch resolves the file watch settings against the defaults. Unknown
+// modes fall back to auto, and the backoff limit is never below the base interval.
+func (s Settings) EffectiveFileWatch() (mode string, interval, maxInterval time.Duration) {
+	w := s.FileWatch
+	mode = strings.ToLower(strings.TrimSpace(w.Mode))
+	if mode != FileWatchNotify && mode != FileWatchPoll {
+		mode = FileWatchAuto
+	}
+	poll, maxPoll := w.PollSeconds, w.MaxPollSeconds
+	if poll <= 0 {
+		poll = defaultPollSeconds
+	}
+	if maxPoll <= 0 {
+		maxPoll = defaultMaxPollSeconds
+	}
+	maxPoll = max(maxPoll, poll)
+	return mode, time.Duration(poll) * time.Second, time.Duration(maxPoll) * time.Second
+}
@@ -0,0 +1,122 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DefaultKeyProfile names the key stored in a provider's own API key field
+// (openai_api_key, anthropic_api_key, openrouter_api_key, gemini_api_key).
+const DefaultKeyProfile = "default"
+
+// KeyProfile is a named API key for a provider, e.g. a personal key next to a team
+// organization's key.
+type KeyProfile struct {
+	Name     string `json:"name"`
+	Provider string `json:"provider"` // openai, anthropic, openrouter or gemini
+	APIKey   string `json:"api_key"`
+	// Organization is sent as the OpenAI-Organization header; other providers ignore it
+	Organization string `json:"organization,omitempty"`
+}
+
+// keyProfileProviders are the providers that take API keys.
+var keyProfileProviders = map[string]bool{"openai": true, "anthropic": true, "openrouter": true, "gemini": true}
+
+// ValidateKeyProfiles checks that every profile has a name, a known provider and a key,
+// and that names are unique per provider.
+func ValidateKeyProfiles(profiles []KeyProfile) error {
+	seen := map[string]bool{}
+	for _, p := range profiles {
+		name := strings.TrimSpace(p.Name)
+		switch {
+		case name == "":
+			return fmt.Errorf("key profile name is required")
+		case strings.EqualFold(name, DefaultKeyProfile):
+			return fmt.Errorf("%q is reserved for the provider's own API key field", DefaultKeyProfile)
+		case !keyProfileProviders[p.Provider]:
+			return fmt.Errorf("key profile %q: unknown provider %q (use openai, anthropic, openrouter or gemini)", name, p.Provider)
+		case strings.TrimSpace(p.APIKey) == "":
+			return fmt.Errorf("key profile %q: API key is required", name)
+		}
+		id := p.Provider + ":" + strings.ToLower(name)
+		if seen[id] {
+			return fmt.Errorf("duplicate key profile %q for %s", name, p.Provider)
+		}
+		seen[id] = true
+	}
+	return nil
+}
+
+// providerKey returns the key stored in the provider's own settings field.
+func (s Settings) providerKey(provider string) string {
+	switch provider {
+	case "openai":
+		return s.OpenAIAPIKey
+	case "anthropic":
+		return s.AnthropicAPIKey
+	case "openrouter":
+		return s.OpenRouterAPIKey
+	case "gemini":
+		return s.GeminiAPIKey
+	}
+	return ""
+}
+
+// KeyProfilesFor returns the keys to try for provider in workspace, in order: the
+// workspace's chosen profile, the provider's own key field as "default", then the other
+// profiles in the order they were saved.
+func (s Settings) KeyProfilesFor(provider, workspace string) []KeyProfile {
+	var all []KeyProfile
+	if key := strings.TrimSpace(s.providerKey(provider)); key != "" {
+		all = append(all, KeyProfile{Name: DefaultKeyProfile, Provider: provider, APIKey: key})
+	}
+	for _, p := range s.KeyProfiles {
+		if p.Provider == provider && strings.TrimSpace(p.APIKey) != "" {
+			all = append(all, p)
+		}
+	}
+	preferred := s.WorkspaceKeyProfile(workspace, provider)
+	for i, p := range all {
+		if i > 0 && strings.EqualFold(p.Name, preferred) {
+			return append([]KeyProfile{p}, append(all[:i:i], all[i+1:]...)...)
+		}
+	}
+	return all
+}
+
+// WorkspaceKeyProfile returns the profile name chosen for provider in workspace, or "".
+func (s Settings) WorkspaceKeyProfile(workspace, provider string) string {
+	return s.WorkspaceKeyProfiles[workspace][provider]
+}
+
+// SetWorkspaceKeyProfile makes name the first key tried for provider in workspace; an
+// empty name clears the choice.
+func (s *Settings) SetWorkspaceKeyProfile(workspace, provider, name string) error {
+	if workspace == "" {
+		return fmt.Errorf("no workspace is open")
+	}
+	if name == "" {
+		delete(s.WorkspaceKeyProfiles[workspace], provider)
+		if len(s.WorkspaceKeyProfiles[workspace]) == 0 {
+			delete(s.WorkspaceKeyProfiles, workspace)
+		}
+		return nil
+	}
+	found := false
+	for _, p := range s.KeyProfilesFor(provider, "") {
+		if strings.EqualFold(p.Name, name) {
+			name, found = p.Name, true
+		}
+	}
+	if !found {
+		return fmt.Errorf("no %s key profile named %q", provider, name)
+	}
+	if s.WorkspaceKeyProfiles == nil {
+		s.WorkspaceKeyProfiles = map[string]map[string]string{}
+	}
+	if s.WorkspaceKeyProfiles[workspace] == nil {
+		s.WorkspaceKeyProfiles[workspace] = map[string]string{}
+	}
+	s.WorkspaceKeyProfiles[workspace][provider] = name
+	return nil
+}
@@ -0,0 +1,68 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func profileNames(ps []KeyProfile) string {
+	var names []string
+	for _, p := range ps {
+		names = append(names, p.Name)
+	}
+	return strings.Join(names, ",")
+}
+
+func TestKeyProfilesFor(t *testing.T) {
+	s := Settings{
+		OpenAIAPIKey: "sk-personal",
+		KeyProfiles: []KeyProfile{
+			{Name: "team", Provider: "openai", APIKey: "sk-team", Organization: "org-1"},
+			{Name: "backup", Provider: "openai", APIKey: "sk-backup"},
+			{Name: "work", Provider: "anthropic", APIKey: "ak-work"},
+		},
+	}
+	if got := profileNames(s.KeyProfilesFor("openai", "/ws")); got != "default,team,backup" {
+		t.Fatalf("unexpected order %q", got)
+	}
+	if got := profileNames(s.KeyProfilesFor("anthropic", "/ws")); got != "work" {
+		t.Fatalf("unexpected anthropic keys %q", got)
+	}
+
+	if err := s.SetWorkspaceKeyProfile("/ws", "openai", "Backup"); err != nil {
+		t.Fatalf("set: %v", err)
+	}
+	if got := profileNames(s.KeyProfilesFor("openai", "/ws")); got != "backup,default,team" {
+		t.Fatalf("expected the workspace choice first, got %q", got)
+	}
+	if got := profileNames(s.KeyProfilesFor("openai", "/other")); got != "default,team,backup" {
+		t.Fatalf("other workspaces keep the default order, got %q", got)
+	}
+	if err := s.SetWorkspaceKeyProfile("/ws", "openai", "missing"); err == nil {
+		t.Fatal("expected unknown profiles to be refused")
+	}
+	if err := s.SetWorkspaceKeyProfile("/ws", "openai", ""); err != nil || len(s.WorkspaceKeyProfiles) != 0 {
+		t.Fatalf("expected the choice to be cleared, got %v, %v", s.WorkspaceKeyProfiles, err)
+	}
+}
+
+func TestValidateKeyProfiles(t *testing.T) {
+	tests := []struct {
+		profiles []KeyProfile
+		want     string
+	}{
+		{[]KeyProfile{{Name: "team", Provider: "openai", APIKey: "k"}}, ""},
+		{[]KeyProfile{{Provider: "openai", APIKey: "k"}}, "name is required"},
+		{[]KeyProfile{{Name: "default", Provider: "openai", APIKey: "k"}}, "reserved"},
+		{[]KeyProfile{{Name: "x", Provider: "ollama", APIKey: "k"}}, "unknown provider"},
+		{[]KeyProfile{{Name: "x", Provider: "openai"}}, "API key is required"},
+		{[]KeyProfile{{Name: "x", Provider: "openai", APIKey: "a"}, {Name: "X", Provider: "openai", APIKey: "b"}}, "duplicate"},
+		{[]KeyProfile{{Name: "x", Provider: "openai", APIKey: "a"}, {Name: "x", Provider: "anthropic", APIKey: "b"}}, ""},
+	}
+	for _, tt := range tests {
+		err := ValidateKeyProfiles(tt.profiles)
+		if (tt.want == "") != (err == nil) || (err != nil && !strings.Contains(err.Error(), tt.want)) {
+			t.Errorf("ValidateKeyProfiles(%+v) = %v, want %q", tt.profiles, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,196 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Macro step actions.
+const (
+	MacroInsertAfter  = "insert_after"
+	MacroInsertBefore = "insert_before"
+	MacroReplace      = "replace"
+	MacroDelete       = "delete"
+)
+
+// EditMacro is a named, parameterized sequence of line edits that run_macro replays over
+// every matching file of the workspace, without asking the model for each file.
+type EditMacro struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	// Files is a glob of workspace-relative paths, where "**" spans directories
+	Files  string       `json:"files"`
+	Params []MacroParam `json:"params,omitempty"`
+	Steps  []MacroStep  `json:"steps"`
+}
+
+// MacroParam is a value filled in for {{name}} in step patterns and contents.
+type MacroParam struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	// Default is used when a run does not set the parameter; without one it is required
+	Default string `json:"default,omitempty"`
+}
+
+// MacroStep is one edit applied to every line its pattern matches, in file order.
+type MacroStep struct {
+	Action string `json:"action"` // insert_after, insert_before, replace or delete
+	// Pattern is a regular expression matched against each line
+	Pattern string `json:"pattern"`
+	// Content is the inserted text, or what replaces the matched text. $1 or ${name}
+	// refer to the pattern's groups and $$ is a literal dollar sign.
+	Content string `json:"content,omitempty"`
+}
+
+var (
+	macroActions   = map[string]bool{MacroInsertAfter: true, MacroInsertBefore: true, MacroReplace: true, MacroDelete: true}
+	macroNameRe    = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]*$`)
+	macroParamRe   = regexp.MustCompile(`\{\{\s*([A-Za-z_][A-Za-z0-9_]*)\s*\}\}`)
+	macroParamName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+)
+
+// ValidateMacros checks names, actions and parameter references. Patterns are compiled
+// when a macro runs, once its parameters are filled in.
+func ValidateMacros(macros []EditMacro) error {
+	seen := map[string]bool{}
+	for _, m := range macros {
+		name := strings.TrimSpace(m.Name)
+		switch {
+		case !macroNameRe.MatchString(name):
+			return fmt.Errorf("macro name %q must be letters, digits, '_', '-' or '.'", name)
+		case seen[strings.ToLower(name)]:
+			return fmt.Errorf("duplicate macro %q", name)
+		case strings.TrimSpace(m.Files) == "":
+			return fmt.Errorf("macro %q: files glob is required", name)
+		case len(m.Steps) == 0:
+			return fmt.Errorf("macro %q has no steps", name)
+		}
+		seen[strings.ToLower(name)] = true
+		params := map[string]bool{}
+		for _, p := range m.Params {
+			if !macroParamName.MatchString(p.Name) {
+				return fmt.Errorf("macro %q: invalid parameter name %q", name, p.Name)
+			}
+			params[p.Name] = true
+		}
+		for i, st := range m.Steps {
+			if !macroActions[st.Action] {
+				return fmt.Errorf("macro %q step %d: unknown action %q (use insert_after, insert_before, replace or delete)", name, i+1, st.Action)
+			}
+			if st.Pattern == "" {
+				return fmt.Errorf("macro %q step %d: pattern is required", name, i+1)
+			}
+			for _, ref := range macroParamRe.FindAllStringSubmatch(st.Pattern+st.Content, -1) {
+				if !params[ref[1]] {
+					return fmt.Errorf("macro %q step %d: unknown parameter {{%s}}", name, i+1, ref[1])
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// Bind returns the macro with {{name}} placeholders filled in from values, falling back
+// to parameter defaults. Values go into patterns verbatim, so they are regular
+// expressions there; in contents they are literal text.
+func (m EditMacro) Bind(values map[string]string) (EditMacro, error) {
+	resolved := map[string]string{}
+	known := map[string]bool{}
+	for _, p := range m.Params {
+		known[p.Name] = true
+		v, ok := values[p.Name]
+		if !ok {
+			if p.Default == "" {
+				return m, fmt.Errorf("macro %q needs parameter %q", m.Name, p.Name)
+			}
+			v = p.Default
+		}
+		resolved[p.Name] = v
+	}
+	for name := range values {
+		if !known[name] {
+			return m, fmt.Errorf("macro %q has no parameter %q", m.Name, name)
+		}
+	}
+	fill := func(s string, escape func(string) string) string {
+		return macroParamRe.ReplaceAllStringFunc(s, func(ref string) string {
+			return escape(resolved[macroParamRe.FindStringSubmatch(ref)[1]])
+		})
+	}
+	bound := m
+	bound.Steps = make([]MacroStep, len(m.Steps))
+	for i, st := range m.Steps {
+		st.Pattern = fill(st.Pattern, func(v string) string { return v })
+		st.Content = fill(st.Content, func(v string) string { return strings.ReplaceAll(v, "$", "$$") })
+		bound.Steps[i] = st
+	}
+	return bound, nil
+}
+
+// MacrosPath returns <workspace>/.loom/macros.json, which is meant to be committed so
+// the team shares its macros.
+func MacrosPath(workspace string) string {
+	return filepath.Join(workspace, ".loom", "macros.json")
+}
+
+// LoadMacros reads the workspace's macros. A missing file yields none without error.
+func LoadMacros(workspace string) ([]EditMacro, error) {
+	if strings.TrimSpace(workspace) == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(MacrosPath(workspace))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if len(data) == 0 {
+		return nil, nil
+	}
+	var macros []EditMacro
+	if err := json.Unmarshal(data, &macros); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", MacrosPath(workspace), err)
+	}
+	return macros, nil
+}
+
+// SaveMacros validates and writes the workspace's macros; an empty list removes the file.
+func SaveMacros(workspace string, macros []EditMacro) error {
+	if strings.TrimSpace(workspace) == "" {
+		return fmt.Errorf("workspace path is empty")
+	}
+	if err := ValidateMacros(macros); err != nil {
+		return err
+	}
+	path := MacrosPath(workspace)
+	if len(macros) == 0 {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	b, err := json.MarshalIndent(macros, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(b, '\n'), 0o644)
+}
+
+// MacroNamed looks up a macro by name, ignoring case.
+func MacroNamed(macros []EditMacro, name string) (EditMacro, bool) {
+	name = strings.TrimSpace(name)
+	for _, m := range macros {
+		if strings.EqualFold(m.Name, name) {
+			return m, true
+		}
+	}
+	return EditMacro{}, false
+}
@@ -0,0 +1,72 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestValidateMacros(t *testing.T) {
+	step := MacroStep{Action: MacroInsertAfter, Pattern: "^func", Content: "\tlog.Print()"}
+	bad := map[string][]EditMacro{
+		"name":          {{Name: "has space", Files: "*.go", Steps: []MacroStep{step}}},
+		"files":         {{Name: "a", Steps: []MacroStep{step}}},
+		"no steps":      {{Name: "a", Files: "*.go"}},
+		"action":        {{Name: "a", Files: "*.go", Steps: []MacroStep{{Action: "append", Pattern: "x"}}}},
+		"pattern":       {{Name: "a", Files: "*.go", Steps: []MacroStep{{Action: MacroDelete}}}},
+		"unknown param": {{Name: "a", Files: "*.go", Steps: []MacroStep{{Action: MacroDelete, Pattern: "{{what}}"}}}},
+		"duplicate":     {{Name: "a", Files: "*.go", Steps: []MacroStep{step}}, {Name: "A", Files: "*.go", Steps: []MacroStep{step}}},
+	}
+	for name, macros := range bad {
+		if err := ValidateMacros(macros); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+	ok := EditMacro{Name: "trace", Files: "**/*.go", Params: []MacroParam{{Name: "fn"}}, Steps: []MacroStep{{Action: MacroReplace, Pattern: "{{ fn }}", Content: "x"}}}
+	if err := ValidateMacros([]EditMacro{ok}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestEditMacro_Bind(t *testing.T) {
+	m := EditMacro{
+		Name:   "log",
+		Files:  "*.go",
+		Params: []MacroParam{{Name: "fn"}, {Name: "logger", Default: "log"}},
+		Steps:  []MacroStep{{Action: MacroInsertAfter, Pattern: `^func ({{fn}})\(`, Content: `	{{logger}}.Print("$1 {{fn}}")`}},
+	}
+	if _, err := m.Bind(nil); err == nil {
+		t.Fatal("expected the missing parameter to be reported")
+	}
+	if _, err := m.Bind(map[string]string{"fn": "x", "other": "y"}); err == nil {
+		t.Fatal("expected the unknown parameter to be reported")
+	}
+	bound, err := m.Bind(map[string]string{"fn": "Handle$"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := MacroStep{Action: MacroInsertAfter, Pattern: `^func (Handle$)\(`, Content: `	log.Print("$1 Handle$$")`}
+	if !reflect.DeepEqual(bound.Steps[0], want) {
+		t.Fatalf("got %+v, want %+v", bound.Steps[0], want)
+	}
+	if m.Steps[0].Content == bound.Steps[0].Content {
+		t.Fatal("Bind modified the macro's steps")
+	}
+}
+
+func TestSaveMacros_RoundTrip(t *testing.T) {
+	ws := t.TempDir()
+	macros := []EditMacro{{Name: "trace", Files: "**/*.go", Steps: []MacroStep{{Action: MacroDelete, Pattern: "TODO"}}}}
+	if err := SaveMacros(ws, macros); err != nil {
+		t.Fatal(err)
+	}
+	got, err := LoadMacros(ws)
+	if err != nil || !reflect.DeepEqual(got, macros) {
+		t.Fatalf("got %+v, %v", got, err)
+	}
+	if err := SaveMacros(ws, nil); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := LoadMacros(ws); err != nil || got != nil {
+		t.Fatalf("expected no macros after clearing, got %+v, %v", got, err)
+	}
+}
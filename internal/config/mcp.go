@@ -14,6 +14,22 @@ type MCPServerConfig struct {
 	Env        []string `json:"env,omitempty"`         // optional KEY=VALUE entries
 	Safe       bool     `json:"safe,omitempty"`        // defaults to false → requires approval
 	TimeoutSec int      `json:"timeout_sec,omitempty"` // per-call timeout; defaults applied by caller
+	// Tools curates the server's tools, keyed by the name the server reports
+	Tools map[string]MCPToolConfig `json:"tools,omitempty"`
+}
+
+// Approval policies of an MCP tool; empty follows the server's Safe flag
+const (
+	MCPApprovalAsk  = "ask"  // every call needs approval
+	MCPApprovalAuto = "auto" // calls run without approval
+)
+
+// MCPToolConfig overrides how one MCP tool is shown to the model
+type MCPToolConfig struct {
+	Name        string `json:"name,omitempty"`        // name exposed to the model instead of mcp_<server>__<tool>
+	Description string `json:"description,omitempty"` // replaces the server's description
+	Hidden      bool   `json:"hidden,omitempty"`      // not exposed to the model at all
+	Approval    string `json:"approval,omitempty"`    // "ask", "auto" or empty for the server default
 }
 
 // ProjectMCP is the on-disk schema for <workspace>/.loom/mcp.json
@@ -0,0 +1,144 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DefaultOutputStyle names the absence of a style: replies follow the system prompt and
+// personality alone.
+const DefaultOutputStyle = "default"
+
+// Verbosity and formality levels of an output style.
+const (
+	VerbosityConcise  = "concise"
+	VerbosityNormal   = "normal"
+	VerbosityDetailed = "detailed"
+
+	FormalityCasual  = "casual"
+	FormalityNeutral = "neutral"
+	FormalityFormal  = "formal"
+)
+
+// OutputStyle shapes how replies are written, independent of the personality: how much
+// is explained, in which language and how formally.
+type OutputStyle struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Verbosity   string `json:"verbosity,omitempty"` // concise, normal or detailed
+	// Language replies are written in, e.g. "German"; empty follows the user's language
+	Language  string `json:"language,omitempty"`
+	Formality string `json:"formality,omitempty"` // casual, neutral or formal
+	// Instructions are added to the prompt section verbatim
+	Instructions string `json:"instructions,omitempty"`
+	// Builtin marks the styles shipped with the app
+	Builtin bool `json:"builtin,omitempty"`
+}
+
+// BuiltinOutputStyles are always available; a saved style with the same name replaces one.
+var BuiltinOutputStyles = []OutputStyle{
+	{
+		Name:         "concise",
+		Description:  "Diff-only: changes and results, no walkthroughs",
+		Verbosity:    VerbosityConcise,
+		Instructions: "After editing, reply with the changed files and a one-line summary per file instead of restating the code. Skip preambles, recaps and offers of further help.",
+		Builtin:      true,
+	},
+	{
+		Name:         "educational",
+		Description:  "Explains the why behind each change for learning",
+		Verbosity:    VerbosityDetailed,
+		Instructions: "Explain the reasoning behind each change, the concepts and APIs involved, and the alternatives you rejected. Point to the relevant lines and suggest what to read next.",
+		Builtin:      true,
+	},
+}
+
+var (
+	verbosityLevels = map[string]bool{VerbosityConcise: true, VerbosityNormal: true, VerbosityDetailed: true}
+	formalityLevels = map[string]bool{FormalityCasual: true, FormalityNeutral: true, FormalityFormal: true}
+)
+
+// ValidateOutputStyles checks that every style has a unique name and known levels.
+func ValidateOutputStyles(styles []OutputStyle) error {
+	seen := map[string]bool{}
+	for _, st := range styles {
+		name := strings.TrimSpace(st.Name)
+		switch {
+		case name == "":
+			return fmt.Errorf("output style name is required")
+		case strings.EqualFold(name, DefaultOutputStyle):
+			return fmt.Errorf("%q is reserved for replies without a style", DefaultOutputStyle)
+		case st.Verbosity != "" && !verbosityLevels[st.Verbosity]:
+			return fmt.Errorf("output style %q: unknown verbosity %q (use concise, normal or detailed)", name, st.Verbosity)
+		case st.Formality != "" && !formalityLevels[st.Formality]:
+			return fmt.Errorf("output style %q: unknown formality %q (use casual, neutral or formal)", name, st.Formality)
+		}
+		if seen[strings.ToLower(name)] {
+			return fmt.Errorf("duplicate output style %q", name)
+		}
+		seen[strings.ToLower(name)] = true
+	}
+	return nil
+}
+
+// AllOutputStyles returns the built-in styles followed by the saved ones; a saved style
+// replaces the built-in style of the same name in place.
+func (s Settings) AllOutputStyles() []OutputStyle {
+	out := make([]OutputStyle, 0, len(BuiltinOutputStyles)+len(s.OutputStyles))
+	out = append(out, BuiltinOutputStyles...)
+	for _, st := range s.OutputStyles {
+		st.Builtin = false
+		replaced := false
+		for i := range out {
+			if strings.EqualFold(out[i].Name, st.Name) {
+				out[i], replaced = st, true
+				break
+			}
+		}
+		if !replaced {
+			out = append(out, st)
+		}
+	}
+	return out
+}
+
+// OutputStyleNamed looks up a style by name, ignoring case.
+func (s Settings) OutputStyleNamed(name string) (OutputStyle, bool) {
+	name = strings.TrimSpace(name)
+	for _, st := range s.AllOutputStyles() {
+		if strings.EqualFold(st.Name, name) {
+			return st, true
+		}
+	}
+	return OutputStyle{}, false
+}
+
+// PromptSection renders the style as a system prompt section.
+func (st OutputStyle) PromptSection() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "\n\n## Output Style: %s\n", st.Name)
+	b.WriteString("Follow this style in every reply until told otherwise; it overrides earlier formatting guidance but never the accuracy of code or tool calls.\n")
+	switch st.Verbosity {
+	case VerbosityConcise:
+		b.WriteString("- Verbosity: minimal. Lead with the result; omit explanations the user did not ask for.\n")
+	case VerbosityDetailed:
+		b.WriteString("- Verbosity: detailed. Explain reasoning and background so the user learns from the answer.\n")
+	case VerbosityNormal:
+		b.WriteString("- Verbosity: normal.\n")
+	}
+	if lang := strings.TrimSpace(st.Language); lang != "" {
+		fmt.Fprintf(&b, "- Language: write all prose in %s. Keep code, identifiers, commands, file paths and tool arguments unchanged.\n", lang)
+	}
+	switch st.Formality {
+	case FormalityCasual:
+		b.WriteString("- Tone: casual and conversational.\n")
+	case FormalityFormal:
+		b.WriteString("- Tone: formal and professional; no slang or emoji.\n")
+	case FormalityNeutral:
+		b.WriteString("- Tone: neutral.\n")
+	}
+	if ins := strings.TrimSpace(st.Instructions); ins != "" {
+		b.WriteString("- " + ins + "\n")
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
@@ -0,0 +1,57 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateOutputStyles(t *testing.T) {
+	bad := map[string][]OutputStyle{
+		"missing name":  {{Verbosity: VerbosityConcise}},
+		"reserved name": {{Name: "Default"}},
+		"verbosity":     {{Name: "a", Verbosity: "chatty"}},
+		"formality":     {{Name: "a", Formality: "stiff"}},
+		"duplicate":     {{Name: "German"}, {Name: "german"}},
+	}
+	for name, styles := range bad {
+		if err := ValidateOutputStyles(styles); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+	if err := ValidateOutputStyles([]OutputStyle{{Name: "German", Language: "German", Formality: FormalityFormal}}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAllOutputStyles_SavedReplaceBuiltins(t *testing.T) {
+	s := Settings{OutputStyles: []OutputStyle{
+		{Name: "Concise", Verbosity: VerbosityConcise, Instructions: "Diff only."},
+		{Name: "German", Language: "German"},
+	}}
+	all := s.AllOutputStyles()
+	if len(all) != len(BuiltinOutputStyles)+1 {
+		t.Fatalf("unexpected styles %+v", all)
+	}
+	if all[0].Name != "Concise" || all[0].Builtin || all[0].Instructions != "Diff only." {
+		t.Fatalf("saved style should replace the built-in in place, got %+v", all[0])
+	}
+	st, ok := s.OutputStyleNamed(" german ")
+	if !ok || st.Language != "German" {
+		t.Fatalf("lookup should ignore case and spaces, got %+v %v", st, ok)
+	}
+	if _, ok := s.OutputStyleNamed("klingon"); ok {
+		t.Fatal("unknown style should not be found")
+	}
+}
+
+func TestOutputStylePromptSection(t *testing.T) {
+	section := OutputStyle{Name: "German", Language: "German", Formality: FormalityFormal, Verbosity: VerbosityDetailed, Instructions: "Cite sources."}.PromptSection()
+	for _, want := range []string{"## Output Style: German", "prose in German", "formal", "detailed", "- Cite sources."} {
+		if !strings.Contains(section, want) {
+			t.Errorf("section misses %q:\n%s", want, section)
+		}
+	}
+	if strings.Contains(OutputStyle{Name: "plain"}.PromptSection(), "Language") {
+		t.Error("no language line expected without a language")
+	}
+}
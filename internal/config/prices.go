@@ -19,9 +19,10 @@ type Price struct {
 
 // OpenRouterModel represents a model from the OpenRouter API
 type OpenRouterModel struct {
-	ID      string `json:"id"`
-	Name    string `json:"name"`
-	Pricing *struct {
+	ID            string `json:"id"`
+	Name          string `json:"name"`
+	ContextLength int    `json:"context_length"`
+	Pricing       *struct {
 		Prompt     string `json:"prompt"`
 		Completion string `json:"completion"`
 	} `json:"pricing"`
@@ -35,6 +36,7 @@ type OpenRouterResponse struct {
 // OpenRouter pricing cache
 var (
 	openRouterPrices     = make(map[string]Price)
+	openRouterContext    = make(map[string]int)
 	openRouterCacheTime  time.Time
 	openRouterCacheMutex sync.RWMutex
 	openRouterCacheTTL   = 1 * time.Hour
@@ -64,6 +66,12 @@ var PricePerToken = map[string]Price{
 	"o3-mini":      {InPerToken: 1.10 / 1e6, OutPerToken: 4.40 / 1e6},
 	"gpt-4.1-mini": {InPerToken: 0.40 / 1e6, OutPerToken: 1.60 / 1e6},
 	"gpt-4.1-nano": {InPerToken: 0.10 / 1e6, OutPerToken: 0.40 / 1e6},
+
+	// Google Gemini (prompts up to 200k tokens; free tier usage is not billed)
+	"gemini-2.5-pro":        {InPerToken: 1.25 / 1e6, OutPerToken: 10.0 / 1e6},
+	"gemini-2.5-flash":      {InPerToken: 0.30 / 1e6, OutPerToken: 2.50 / 1e6},
+	"gemini-2.5-flash-lite": {InPerToken: 0.10 / 1e6, OutPerToken: 0.40 / 1e6},
+	"gemini-2.0-flash":      {InPerToken: 0.10 / 1e6, OutPerToken: 0.40 / 1e6},
 }
 
 // fetchOpenRouterPrices fetches model pricing from OpenRouter API and caches it
@@ -91,8 +99,12 @@ func fetchOpenRouterPrices() error {
 	defer openRouterCacheMutex.Unlock()
 
 	openRouterPrices = make(map[string]Price)
+	openRouterContext = make(map[string]int)
 
 	for _, model := range apiResp.Data {
+		if model.ID != "" && model.ContextLength > 0 {
+			openRouterContext[model.ID] = model.ContextLength
+		}
 		if model.ID == "" || model.Pricing == nil {
 			continue
 		}
@@ -0,0 +1,35 @@
+package config
+
+import "time"
+
+// Retention bounds stored conversation history per workspace. Zero fields use the
+// defaults; a negative value disables that limit.
+type Retention struct {
+	MaxSessions int `json:"max_sessions,omitempty"`
+	MaxAgeDays  int `json:"max_age_days,omitempty"`
+	MaxDiskMB   int `json:"max_disk_mb,omitempty"`
+}
+
+// DefaultRetention keeps a generous history while preventing unbounded growth.
+func DefaultRetention() Retention {
+	return Retention{MaxSessions: 200, MaxAgeDays: 180, MaxDiskMB: 512}
+}
+
+// EffectiveRetention resolves configured retention against the defaults and returns
+// the limits as concrete values, with zero meaning "no limit".
+func (s Settings) EffectiveRetention() (maxSessions int, maxAge time.Duration, maxDiskBytes int64) {
+	r, d := s.Retention, DefaultRetention()
+	pick := func(v, def int) int {
+		switch {
+		case v < 0:
+			return 0
+		case v == 0:
+			return def
+		}
+		return v
+	}
+	maxSessions = pick(r.MaxSessions, d.MaxSessions)
+	maxAge = time.Duration(pick(r.MaxAgeDays, d.MaxAgeDays)) * 24 * time.Hour
+	maxDiskBytes = int64(pick(r.MaxDiskMB, d.MaxDiskMB)) * 1024 * 1024
+	return maxSessions, maxAge, maxDiskBytes
+}
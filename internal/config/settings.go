@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+
+	"github.com/loom/loom/internal/proxy"
 )
 
 // Settings represents persisted application settings such as API keys and endpoints.
@@ -13,23 +15,65 @@ type Settings struct {
 	OpenAIAPIKey     string `json:"openai_api_key"`
 	AnthropicAPIKey  string `json:"anthropic_api_key"`
 	OpenRouterAPIKey string `json:"openrouter_api_key"`
+	GeminiAPIKey     string `json:"gemini_api_key,omitempty"`
 	OllamaEndpoint   string `json:"ollama_endpoint,omitempty"`
 	LastWorkspace    string `json:"last_workspace,omitempty"`
+	// Azure OpenAI resource for "azure:<deployment>" models
+	AzureOpenAI AzureOpenAI `json:"azure_openai,omitempty"`
+	// WorkspaceRoots lists the further roots opened next to a workspace, keyed by its path
+	WorkspaceRoots map[string][]string `json:"workspace_roots,omitempty"`
+	// Additional named keys per provider, tried in order when a key runs out of quota
+	KeyProfiles []KeyProfile `json:"key_profiles,omitempty"`
+	// Key profile tried first, keyed by workspace path and then provider
+	WorkspaceKeyProfiles map[string]map[string]string `json:"workspace_key_profiles,omitempty"`
 	// Last selected model in the format "provider:model_id"
 	LastModel string `json:"last_model,omitempty"`
 	// Feature flags
 	AutoApproveShell bool `json:"auto_approve_shell,omitempty"`
 	AutoApproveEdits bool `json:"auto_approve_edits,omitempty"`
+	// Approval policy rules ("<condition> -> auto_approve|deny|ask"), checked before the toggles
+	ApprovalPolicies []string `json:"approval_policies,omitempty"`
 	// UI preferences
 	Theme string `json:"theme,omitempty"`
 	// AI personality selection
 	Personality string `json:"personality,omitempty"`
+	// Saved output styles, next to the built-in ones
+	OutputStyles []OutputStyle `json:"output_styles,omitempty"`
+	// Output style of conversations that did not pick one ("" or "default" for none)
+	OutputStyle string `json:"output_style,omitempty"`
+	// ExplanationLevel is the default depth of explanations (beginner, intermediate or
+	// expert); empty leaves it to the model
+	ExplanationLevel string `json:"explanation_level,omitempty"`
 	// Recent workspaces (max 10, ordered from most recent)
 	RecentWorkspaces []string `json:"recent_workspaces,omitempty"`
 	// Selected models that should appear in the ModelSelector dropdown
 	SelectedModels []string `json:"selected_models,omitempty"`
 	// UI layout settings
 	UILayout UILayout `json:"ui_layout,omitempty"`
+	// Conversation history retention (max sessions, age, disk usage)
+	Retention Retention `json:"retention,omitempty"`
+	// Per-tool execution limits keyed by tool name ("*" applies to all tools)
+	ToolLimits map[string]ToolLimit `json:"tool_limits,omitempty"`
+	// Edit format per model label, provider or "*" ("anchor" or "search_replace")
+	EditFormats map[string]string `json:"edit_formats,omitempty"`
+	// What happens to agent edits touching lines with unsaved changes in the UI editor
+	// ("refuse" or "warn"; empty means refuse)
+	DirtyEditPolicy string `json:"dirty_edit_policy,omitempty"`
+	// Most lines one edit may add and remove before it must be split (0 uses the
+	// default, negative disables the limit)
+	MaxEditLines int `json:"max_edit_lines,omitempty"`
+	// Outgoing HTTP proxy and extra trusted CAs; empty fields use the environment
+	Proxy proxy.Config `json:"proxy,omitempty"`
+	// How the symbol index watches for file changes (notifications or polling)
+	FileWatch FileWatch `json:"file_watch,omitempty"`
+	// FollowSymlinks makes code search follow symlinks that stay inside the workspace
+	FollowSymlinks bool `json:"follow_symlinks,omitempty"`
+	// Experimental subsystems switched away from their default, keyed by flag name
+	FeatureFlags map[string]bool `json:"feature_flags,omitempty"`
+	// OnboardingCompleted is set once the first-run onboarding finished or was skipped
+	OnboardingCompleted bool `json:"onboarding_completed,omitempty"`
+	// DismissedModelSuggestions holds "current->suggested" model pairs the user declined
+	DismissedModelSuggestions []string `json:"dismissed_model_suggestions,omitempty"`
 }
 
 // UILayout stores the current UI state for restoration
@@ -0,0 +1,54 @@
+package config
+
+// DefaultToolLimitKey is the tool_limits entry applied to every tool without its own override.
+const DefaultToolLimitKey = "*"
+
+// ToolLimit bounds a single tool's execution. Zero fields inherit from the "*" entry,
+// and a zero value there means the limit is disabled.
+type ToolLimit struct {
+	TimeoutSec     int `json:"timeout_sec,omitempty"`
+	MaxOutputBytes int `json:"max_output_bytes,omitempty"`
+	MaxConcurrent  int `json:"max_concurrent,omitempty"`
+}
+
+// DefaultToolLimits returns the built-in limits. The global timeout matches the 10 minute
+// ceiling the shell and HTTP tools already clamp to, so defaults never cut those short.
+func DefaultToolLimits() map[string]ToolLimit {
+	return map[string]ToolLimit{
+		DefaultToolLimitKey: {TimeoutSec: 600, MaxOutputBytes: 256 * 1024},
+		"apply_shell":       {MaxConcurrent: 1},
+		"execute_snippet":   {MaxConcurrent: 2},
+		"http_request":      {MaxConcurrent: 4},
+	}
+}
+
+// EffectiveToolLimits merges user overrides from settings over the defaults field by field.
+// The "*" entry is resolved first and then filled into every per-tool entry.
+func (s Settings) EffectiveToolLimits() map[string]ToolLimit {
+	merged := DefaultToolLimits()
+	for name, override := range s.ToolLimits {
+		merged[name] = mergeToolLimit(merged[name], override)
+	}
+	base := merged[DefaultToolLimitKey]
+	for name, l := range merged {
+		if name == DefaultToolLimitKey {
+			continue
+		}
+		merged[name] = mergeToolLimit(base, l)
+	}
+	return merged
+}
+
+// mergeToolLimit returns base with every non-zero field of override applied on top.
+func mergeToolLimit(base, override ToolLimit) ToolLimit {
+	if override.TimeoutSec != 0 {
+		base.TimeoutSec = override.TimeoutSec
+	}
+	if override.MaxOutputBytes != 0 {
+		base.MaxOutputBytes = override.MaxOutputBytes
+	}
+	if override.MaxConcurrent != 0 {
+		base.MaxConcurrent = override.MaxConcurrent
+	}
+	return base
+}
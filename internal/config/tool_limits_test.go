@@ -0,0 +1,22 @@
+package config
+
+import "testing"
+
+func TestEffectiveToolLimits_MergesOverrides(t *testing.T) {
+	s := Settings{ToolLimits: map[string]ToolLimit{
+		DefaultToolLimitKey: {MaxOutputBytes: 1000},
+		"http_request":      {TimeoutSec: 15},
+		"run_tests":         {TimeoutSec: 600},
+	}}
+	limits := s.EffectiveToolLimits()
+
+	if got := limits[DefaultToolLimitKey]; got.TimeoutSec != 600 || got.MaxOutputBytes != 1000 {
+		t.Errorf("default entry not merged: %+v", got)
+	}
+	if got := limits["http_request"]; got.TimeoutSec != 15 || got.MaxConcurrent != 4 || got.MaxOutputBytes != 1000 {
+		t.Errorf("http_request override lost defaults: %+v", got)
+	}
+	if got := limits["run_tests"]; got.TimeoutSec != 600 || got.MaxOutputBytes != 1000 {
+		t.Errorf("new tool entry should inherit the default entry: %+v", got)
+	}
+}
@@ -0,0 +1,322 @@
+// Package coverage reads line coverage from the reports test runners leave in a
+// workspace: Go cover profiles, LCOV tracefiles and Cobertura XML.
+package coverage
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Report formats.
+const (
+	FormatGo        = "go"
+	FormatLCOV      = "lcov"
+	FormatCobertura = "cobertura"
+)
+
+// ErrNoReport is returned when the workspace holds no coverage report.
+var ErrNoReport = errors.New("no coverage report found; run the tests with coverage first")
+
+// Profile is the line coverage of one report, keyed by workspace-relative path with
+// forward slashes.
+type Profile struct {
+	Path    string
+	Format  string
+	ModTime time.Time
+	Files   map[string]*File
+}
+
+// File maps each instrumented line to whether it was executed.
+type File struct {
+	Lines map[int]bool
+}
+
+// Covered returns the executed lines in order.
+func (f *File) Covered() []int {
+	return f.lines(true)
+}
+
+// Uncovered returns the instrumented lines that never ran, in order.
+func (f *File) Uncovered() []int {
+	return f.lines(false)
+}
+
+func (f *File) lines(covered bool) []int {
+	out := []int{}
+	if f == nil {
+		return out
+	}
+	for n, c := range f.Lines {
+		if c == covered {
+			out = append(out, n)
+		}
+	}
+	sort.Ints(out)
+	return out
+}
+
+// mark records a line; a line stays covered once any block or record executed it.
+func (p *Profile) mark(file string, line int, covered bool) {
+	if file == "" || line <= 0 {
+		return
+	}
+	f := p.Files[file]
+	if f == nil {
+		f = &File{Lines: map[int]bool{}}
+		p.Files[file] = f
+	}
+	f.Lines[line] = f.Lines[line] || covered
+}
+
+// reportNames are the files, relative to the workspace, that test runners write
+// coverage to by default or by common convention.
+var reportNames = []string{
+	"coverage.out", "cover.out", "c.out", "coverage.txt", "cover.profile", "profile.cov",
+	"lcov.info", "coverage/lcov.info", "coverage.lcov",
+	"coverage.xml", "coverage/cobertura-coverage.xml", "coverage/coverage.xml",
+}
+
+// Find returns the most recently written coverage report of the workspace.
+func Find(workspace string) (string, error) {
+	best, bestTime := "", time.Time{}
+	for _, name := range reportNames {
+		p := filepath.Join(workspace, filepath.FromSlash(name))
+		if st, err := os.Stat(p); err == nil && !st.IsDir() && st.ModTime().After(bestTime) {
+			best, bestTime = p, st.ModTime()
+		}
+	}
+	if best == "" {
+		return "", ErrNoReport
+	}
+	return best, nil
+}
+
+// Load parses the report at path, detecting its format, and maps its file names to
+// paths relative to workspace. Files outside the workspace are dropped.
+func Load(workspace, reportPath string) (*Profile, error) {
+	data, err := os.ReadFile(reportPath)
+	if err != nil {
+		return nil, err
+	}
+	st, err := os.Stat(reportPath)
+	if err != nil {
+		return nil, err
+	}
+	p := &Profile{Path: reportPath, ModTime: st.ModTime(), Files: map[string]*File{}}
+	r := newResolver(workspace)
+	trimmed := bytes.TrimSpace(data)
+	switch {
+	case bytes.HasPrefix(trimmed, []byte("mode:")):
+		p.Format = FormatGo
+		err = parseGo(p, data, r)
+	case bytes.HasPrefix(trimmed, []byte("<")):
+		p.Format = FormatCobertura
+		err = parseCobertura(p, data, r)
+	case bytes.Contains(data, []byte("SF:")):
+		p.Format = FormatLCOV
+		err = parseLCOV(p, data, r, filepath.Dir(reportPath))
+	default:
+		return nil, fmt.Errorf("%s is not a Go, LCOV or Cobertura coverage report", filepath.Base(reportPath))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filepath.Base(reportPath), err)
+	}
+	return p, nil
+}
+
+// parseGo reads a Go cover profile: "file.go:startLine.col,endLine.col stmts count".
+func parseGo(p *Profile, data []byte, r *resolver) error {
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	sc.Buffer(make([]byte, 64*1024), 1024*1024)
+	for sc.Scan() {
+		line := sc.Text()
+		if line == "" || strings.HasPrefix(line, "mode:") {
+			continue
+		}
+		colon := strings.LastIndex(line, ":")
+		fields := strings.Fields(line[colon+1:])
+		if colon < 0 || len(fields) != 3 {
+			return fmt.Errorf("malformed line %q", line)
+		}
+		start, end, ok := strings.Cut(fields[0], ",")
+		if !ok {
+			return fmt.Errorf("malformed block %q", fields[0])
+		}
+		from, err1 := strconv.Atoi(strings.SplitN(start, ".", 2)[0])
+		to, err2 := strconv.Atoi(strings.SplitN(end, ".", 2)[0])
+		count, err3 := strconv.Atoi(fields[2])
+		if err1 != nil || err2 != nil || err3 != nil {
+			return fmt.Errorf("malformed line %q", line)
+		}
+		file := r.goFile(line[:colon])
+		for n := from; n <= to; n++ {
+			p.mark(file, n, count > 0)
+		}
+	}
+	return sc.Err()
+}
+
+// parseLCOV reads the SF and DA records of an LCOV tracefile. Relative source paths
+// are taken relative to the report's directory.
+func parseLCOV(p *Profile, data []byte, r *resolver, dir string) error {
+	file := ""
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	sc.Buffer(make([]byte, 64*1024), 1024*1024)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		switch {
+		case strings.HasPrefix(line, "SF:"):
+			name := strings.TrimPrefix(line, "SF:")
+			if !filepath.IsAbs(name) {
+				name = filepath.Join(dir, name)
+			}
+			file = r.rel(name)
+		case strings.HasPrefix(line, "DA:"):
+			parts := strings.Split(strings.TrimPrefix(line, "DA:"), ",")
+			if len(parts) < 2 {
+				continue
+			}
+			n, err1 := strconv.Atoi(parts[0])
+			count, err2 := strconv.ParseFloat(parts[1], 64)
+			if err1 == nil && err2 == nil {
+				p.mark(file, n, count > 0)
+			}
+		case line == "end_of_record":
+			file = ""
+		}
+	}
+	return sc.Err()
+}
+
+type coberturaReport struct {
+	Sources []string `xml:"sources>source"`
+	Classes []struct {
+		Filename string `xml:"filename,attr"`
+		Lines    []struct {
+			Number int   `xml:"number,attr"`
+			Hits   int64 `xml:"hits,attr"`
+		} `xml:"lines>line"`
+	} `xml:"packages>package>classes>class"`
+}
+
+// parseCobertura reads the class lines of a Cobertura report. Class file names are
+// relative to one of the report's sources, or to the workspace.
+func parseCobertura(p *Profile, data []byte, r *resolver) error {
+	var rep coberturaReport
+	if err := xml.Unmarshal(data, &rep); err != nil {
+		return err
+	}
+	for _, c := range rep.Classes {
+		file := ""
+		for _, src := range rep.Sources {
+			candidate := filepath.Join(strings.TrimSpace(src), c.Filename)
+			if !filepath.IsAbs(candidate) {
+				candidate = filepath.Join(r.workspace, candidate)
+			}
+			if _, err := os.Stat(candidate); err == nil {
+				file = r.rel(candidate)
+				break
+			}
+		}
+		if file == "" {
+			file = r.rel(filepath.Join(r.workspace, c.Filename))
+		}
+		for _, l := range c.Lines {
+			p.mark(file, l.Number, l.Hits > 0)
+		}
+	}
+	return nil
+}
+
+// resolver maps report file names to workspace-relative paths.
+type resolver struct {
+	workspace string
+	// modules maps Go module paths to their workspace-relative directories
+	modules map[string]string
+}
+
+func newResolver(workspace string) *resolver {
+	abs, err := filepath.Abs(workspace)
+	if err == nil {
+		workspace = abs
+	}
+	return &resolver{workspace: workspace}
+}
+
+// rel returns abs relative to the workspace, or "" when it lies outside.
+func (r *resolver) rel(abs string) string {
+	rel, err := filepath.Rel(r.workspace, filepath.Clean(abs))
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return ""
+	}
+	return filepath.ToSlash(rel)
+}
+
+// goFile maps a cover profile's import path ("example.com/mod/pkg/file.go") to a
+// workspace file using the go.mod files of the workspace.
+func (r *resolver) goFile(name string) string {
+	if filepath.IsAbs(name) {
+		return r.rel(name)
+	}
+	if r.modules == nil {
+		r.modules = goModules(r.workspace)
+	}
+	best, bestDir := "", ""
+	for mod, dir := range r.modules {
+		if (name == mod || strings.HasPrefix(name, mod+"/")) && len(mod) > len(best) {
+			best, bestDir = mod, dir
+		}
+	}
+	if best == "" {
+		return ""
+	}
+	return path.Join(bestDir, strings.TrimPrefix(strings.TrimPrefix(name, best), "/"))
+}
+
+// maxModuleDepth bounds how deep goModules looks for nested modules.
+const maxModuleDepth = 4
+
+// goModules returns the module path of every go.mod in the workspace, mapped to the
+// module's workspace-relative directory ("." for the root).
+func goModules(workspace string) map[string]string {
+	mods := map[string]string{}
+	_ = filepath.WalkDir(workspace, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		rel, _ := filepath.Rel(workspace, p)
+		if d.IsDir() {
+			name := d.Name()
+			if p != workspace && (strings.HasPrefix(name, ".") || name == "vendor" || name == "node_modules" || strings.Count(rel, string(filepath.Separator)) >= maxModuleDepth) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.Name() != "go.mod" {
+			return nil
+		}
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return nil
+		}
+		for _, line := range strings.Split(string(data), "\n") {
+			if f := strings.Fields(line); len(f) >= 2 && f[0] == "module" {
+				mods[strings.Trim(f[1], `"`)] = filepath.ToSlash(filepath.Dir(rel))
+				break
+			}
+		}
+		return nil
+	})
+	return mods
+}
@@ -0,0 +1,93 @@
+package coverage
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func write(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoad_GoProfile(t *testing.T) {
+	ws := t.TempDir()
+	write(t, filepath.Join(ws, "go.mod"), "module example.com/app\n")
+	write(t, filepath.Join(ws, "tools", "go.mod"), "module example.com/app/tools\n")
+	write(t, filepath.Join(ws, "coverage.out"), `mode: count
+example.com/app/pkg/calc.go:3.20,5.2 2 4
+example.com/app/pkg/calc.go:7.20,9.2 2 0
+example.com/app/pkg/calc.go:5.2,5.3 0 0
+example.com/app/tools/gen/main.go:10.1,10.9 1 1
+other.org/dep/x.go:1.1,2.2 1 1
+`)
+	p, err := Load(ws, filepath.Join(ws, "coverage.out"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	calc := p.Files["pkg/calc.go"]
+	if p.Format != FormatGo || calc == nil {
+		t.Fatalf("unexpected profile %+v", p)
+	}
+	if got := calc.Covered(); !reflect.DeepEqual(got, []int{3, 4, 5}) {
+		t.Errorf("covered = %v; a line stays covered when another block skips it", got)
+	}
+	if got := calc.Uncovered(); !reflect.DeepEqual(got, []int{7, 8, 9}) {
+		t.Errorf("uncovered = %v", got)
+	}
+	if p.Files["tools/gen/main.go"] == nil {
+		t.Error("files of nested modules should resolve to their directory")
+	}
+	if len(p.Files) != 2 {
+		t.Errorf("files outside the workspace should be dropped: %v", p.Files)
+	}
+}
+
+func TestLoad_LCOVAndCobertura(t *testing.T) {
+	ws := t.TempDir()
+	write(t, filepath.Join(ws, "src", "app.ts"), "")
+	write(t, filepath.Join(ws, "coverage", "lcov.info"), "TN:\nSF:../src/app.ts\nDA:1,3\nDA:2,0\nend_of_record\nSF:/elsewhere/lib.ts\nDA:1,1\nend_of_record\n")
+	p, err := Load(ws, filepath.Join(ws, "coverage", "lcov.info"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f := p.Files["src/app.ts"]; p.Format != FormatLCOV || f == nil || !reflect.DeepEqual(f.Uncovered(), []int{2}) || len(p.Files) != 1 {
+		t.Fatalf("unexpected LCOV profile %+v", p.Files)
+	}
+
+	write(t, filepath.Join(ws, "pkg", "mod.py"), "")
+	write(t, filepath.Join(ws, "coverage.xml"), `<?xml version="1.0" ?>
+<coverage><sources><source>`+filepath.Join(ws, "pkg")+`</source></sources>
+<packages><package name="pkg"><classes><class name="mod.py" filename="mod.py">
+<lines><line number="1" hits="1"/><line number="4" hits="0"/></lines>
+</class></classes></package></packages></coverage>`)
+	p, err = Load(ws, filepath.Join(ws, "coverage.xml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f := p.Files["pkg/mod.py"]; p.Format != FormatCobertura || f == nil || !reflect.DeepEqual(f.Covered(), []int{1}) || !reflect.DeepEqual(f.Uncovered(), []int{4}) {
+		t.Fatalf("unexpected Cobertura profile %+v", p.Files)
+	}
+}
+
+func TestFind(t *testing.T) {
+	ws := t.TempDir()
+	if _, err := Find(ws); err != ErrNoReport {
+		t.Fatalf("expected ErrNoReport, got %v", err)
+	}
+	write(t, filepath.Join(ws, "coverage.out"), "mode: set\n")
+	write(t, filepath.Join(ws, "coverage", "lcov.info"), "")
+	old := time.Now().Add(-time.Hour)
+	_ = os.Chtimes(filepath.Join(ws, "coverage.out"), old, old)
+	if got, err := Find(ws); err != nil || got != filepath.Join(ws, "coverage", "lcov.info") {
+		t.Errorf("expected the newest report, got %q, %v", got, err)
+	}
+}
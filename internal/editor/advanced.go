@@ -21,6 +21,9 @@ const (
 	// It locates the target region by optional anchors (before/after) and/or a target block,
 	// with optional whitespace normalization and fuzzy matching.
 	ActionAnchorReplace ActionType = "ANCHOR_REPLACE"
+	// ActionSearchReplaceBlocks applies SEARCH/REPLACE blocks given in Content; each
+	// block's search text must match exactly one place in the file.
+	ActionSearchReplaceBlocks ActionType = "SEARCH_REPLACE_BLOCKS"
 )
 
 // AdvancedEditRequest captures parameters for advanced edits.
@@ -84,23 +87,27 @@ func ProposeAdvancedEdit(workspacePath string, req AdvancedEditRequest) (*EditPl
 				Code:    "FILE_EXISTS",
 			}
 		}
+		// Keep new files consistent with their directory (package names, import paths)
+		placement := CheckNewFilePlacement(workspacePath, absPath, req.Content)
+		content := placement.Content
 		lineCount := 1
-		if req.Content != "" {
-			lineCount = 1 + strings.Count(req.Content, "\n")
+		if content != "" {
+			lineCount = 1 + strings.Count(content, "\n")
 		}
 		return &EditPlan{
 			FilePath:   absPath,
 			OldContent: "",
-			NewContent: req.Content,
-			Diff:       generateDiff("", req.Content, filepath.Base(absPath)),
+			NewContent: content,
+			Diff:       generateDiff("", content, filepath.Base(absPath)),
 			IsCreation: true,
 			ChangedLines: LineRange{
 				StartLine: 1,
 				EndLine:   lineCount,
 			},
+			Notes: placement.Notes,
 		}, nil
 
-	case ActionReplaceLines, ActionInsertAfter, ActionInsertBefore, ActionDeleteLines, ActionSearchReplace, ActionAnchorReplace:
+	case ActionReplaceLines, ActionInsertAfter, ActionInsertBefore, ActionDeleteLines, ActionSearchReplace, ActionAnchorReplace, ActionSearchReplaceBlocks:
 		if !fileExists {
 			return nil, ValidationError{
 				Message: "File does not exist",
@@ -108,8 +115,8 @@ func ProposeAdvancedEdit(workspacePath string, req AdvancedEditRequest) (*EditPl
 			}
 		}
 
-		// Load current content
-		bytes, err := os.ReadFile(absPath)
+		// Load current content as UTF-8/LF; the plan remembers the on-disk format
+		oldContent, format, err := ReadText(absPath)
 		if err != nil {
 			return nil, ValidationError{
 				Message: fmt.Sprintf("Failed to read file: %v", err),
@@ -117,7 +124,6 @@ func ProposeAdvancedEdit(workspacePath string, req AdvancedEditRequest) (*EditPl
 			}
 		}
 
-		oldContent := string(bytes)
 		lines := splitToLinesPreserveEOF(oldContent)
 
 		var newContent string
@@ -253,6 +259,16 @@ func ProposeAdvancedEdit(workspacePath string, req AdvancedEditRequest) (*EditPl
 			}
 			changed = LineRange{StartLine: minLine, EndLine: maxLine}
 
+		case ActionSearchReplaceBlocks:
+			blocks, err := ParseSearchReplaceBlocks(req.Content)
+			if err != nil {
+				return nil, ValidationError{Message: err.Error(), Code: "INVALID_BLOCKS"}
+			}
+			newContent, changed, err = ApplySearchReplaceBlocks(oldContent, blocks)
+			if err != nil {
+				return nil, ValidationError{Message: err.Error(), Code: "BLOCK_NOT_MATCHED"}
+			}
+
 		case ActionAnchorReplace:
 			// Robust anchored replace: find region using anchors/target with optional normalization and fuzzy match
 			if strings.TrimSpace(req.AnchorBefore) == "" && strings.TrimSpace(req.AnchorAfter) == "" && strings.TrimSpace(req.Target) == "" {
@@ -390,6 +406,8 @@ func ProposeAdvancedEdit(workspacePath string, req AdvancedEditRequest) (*EditPl
 			NewContent:   newContent,
 			Diff:         generateDiff(oldContent, newContent, filepath.Base(absPath)),
 			ChangedLines: changed,
+			Format:       format,
+			Notes:        format.lossNotes(newContent),
 		}, nil
 
 	default:
@@ -8,24 +8,52 @@ import (
 	"strings"
 )
 
-// ApplyEdit applies an edit plan to the filesystem.
+// ApplyEdit applies an edit plan to the filesystem atomically, without backups.
 func ApplyEdit(plan *EditPlan) error {
+	return ApplyEditWithOptions(plan, WriteOptions{})
+}
+
+// ApplyEditWithOptions applies an edit plan to the filesystem. When opts names a
+// workspace, the previous version of a modified file is backed up first and a deleted
+// file is moved to the workspace trash. The new content replaces the file atomically
+// so a crash cannot leave it half written.
+func ApplyEditWithOptions(plan *EditPlan, opts WriteOptions) error {
 	// Create the directory structure if needed
 	dir := filepath.Dir(plan.FilePath)
 	if err := os.MkdirAll(dir, 0755); err != nil {
 		return fmt.Errorf("failed to create directory: %w", err)
 	}
 
+	// Deleted files are kept in the trash instead of the backups
+	if plan.IsDeletion && opts.Workspace != "" {
+		if _, err := MoveToTrash(opts.Workspace, plan.FilePath, "apply_edit"); err != nil {
+			return fmt.Errorf("failed to delete file: %w", err)
+		}
+		if opts.Fsync {
+			syncDir(dir)
+		}
+		return nil
+	}
+
+	if !plan.IsCreation {
+		if err := backupFile(opts, plan.FilePath); err != nil {
+			return fmt.Errorf("failed to back up file: %w", err)
+		}
+	}
+
 	// For deletion, remove the file
 	if plan.IsDeletion {
 		if err := os.Remove(plan.FilePath); err != nil {
 			return fmt.Errorf("failed to delete file: %w", err)
 		}
+		if opts.Fsync {
+			syncDir(dir)
+		}
 		return nil
 	}
 
-	// For creation or modification, write the new content
-	if err := os.WriteFile(plan.FilePath, []byte(plan.NewContent), 0644); err != nil {
+	// For creation or modification, write the new content in the file's original encoding
+	if err := WriteFileAtomic(plan.FilePath, plan.NewBytes(), opts.Fsync); err != nil {
 		return fmt.Errorf("failed to write file: %w", err)
 	}
 
@@ -0,0 +1,194 @@
+package editor
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultBackupDays is how long previous versions of edited files are kept.
+const DefaultBackupDays = 7
+
+// backupPruneInterval limits how often a backup directory is scanned for old copies.
+const backupPruneInterval = time.Hour
+
+// WriteOptions controls how edits reach the disk.
+type WriteOptions struct {
+	// Fsync flushes the new file and its directory before the write is reported done
+	Fsync bool
+	// Workspace enables backups under <workspace>/.loom/backups; empty disables them
+	Workspace string
+	// BackupDays is how long backups are kept; 0 uses DefaultBackupDays, negative disables backups
+	BackupDays int
+}
+
+// WriteOptionsFromEnv returns the options for edits in workspace. LOOM_FSYNC_WRITES=1
+// turns on fsync and LOOM_BACKUP_DAYS sets the retention (0 disables backups).
+func WriteOptionsFromEnv(workspace string) WriteOptions {
+	opts := WriteOptions{Workspace: workspace}
+	if v := os.Getenv("LOOM_FSYNC_WRITES"); v == "1" || strings.EqualFold(v, "true") {
+		opts.Fsync = true
+	}
+	if v := os.Getenv("LOOM_BACKUP_DAYS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			opts.BackupDays = n
+			if n == 0 {
+				opts.BackupDays = -1
+			}
+		}
+	}
+	return opts
+}
+
+// BackupDir returns where previous versions of a workspace's files are kept.
+func BackupDir(workspace string) string {
+	return filepath.Join(workspace, ".loom", "backups")
+}
+
+// WriteFileAtomic replaces path with data so that a crash leaves either the old or the
+// new content, never a partial file: the data goes to a temporary file in the same
+// directory, which is renamed over path. Existing permissions are kept, and symlinks
+// are followed so the link itself survives.
+func WriteFileAtomic(path string, data []byte, fsync bool) error {
+	perm := fs.FileMode(0o644)
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		path = resolved
+		if info, err := os.Stat(path); err == nil {
+			perm = info.Mode().Perm()
+		}
+	}
+	dir := filepath.Dir(path)
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".loom-tmp-*")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+	fail := func(err error) error {
+		_ = tmp.Close()
+		_ = os.Remove(tmpPath)
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		return fail(err)
+	}
+	if fsync {
+		if err := tmp.Sync(); err != nil {
+			return fail(err)
+		}
+	}
+	if err := tmp.Chmod(perm); err != nil {
+		return fail(err)
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmpPath)
+		return err
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		_ = os.Remove(tmpPath)
+		return err
+	}
+	if fsync {
+		syncDir(dir)
+	}
+	return nil
+}
+
+// syncDir flushes a directory entry change (create, rename, remove) to disk. Some
+// platforms cannot sync directories, so failures are ignored.
+func syncDir(dir string) {
+	if d, err := os.Open(dir); err == nil {
+		_ = d.Sync()
+		_ = d.Close()
+	}
+}
+
+// backupFile copies the current content of path to the workspace backup directory as
+// <relative path>.<timestamp> and prunes backups past their retention. Files outside
+// the workspace, missing files and the backups themselves are not backed up.
+func backupFile(opts WriteOptions, path string) error {
+	if opts.Workspace == "" || opts.BackupDays < 0 {
+		return nil
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	workspace, err := filepath.Abs(opts.Workspace)
+	if err != nil {
+		return nil
+	}
+	rel, err := filepath.Rel(workspace, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return nil
+	}
+	root := BackupDir(workspace)
+	if rel == filepath.Join(".loom", "backups") || strings.HasPrefix(rel, filepath.Join(".loom", "backups")+string(filepath.Separator)) {
+		return nil
+	}
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		return err
+	}
+	// Keep backups out of version control
+	ignore := filepath.Join(root, ".gitignore")
+	if _, err := os.Stat(ignore); os.IsNotExist(err) {
+		_ = os.WriteFile(ignore, []byte("*\n"), 0o644)
+	}
+	dest := filepath.Join(root, rel+"."+time.Now().Format("20060102-150405.000000000"))
+	if err := os.MkdirAll(filepath.Dir(dest), 0o755); err != nil {
+		return err
+	}
+	if err := WriteFileAtomic(dest, content, opts.Fsync); err != nil {
+		return err
+	}
+	days := opts.BackupDays
+	if days == 0 {
+		days = DefaultBackupDays
+	}
+	pruneBackups(root, time.Duration(days)*24*time.Hour, time.Now())
+	return nil
+}
+
+var (
+	pruneMu   sync.Mutex
+	lastPrune = map[string]time.Time{}
+)
+
+// pruneBackups removes backups older than maxAge, at most once per backupPruneInterval
+// per directory, and drops directories left empty.
+func pruneBackups(root string, maxAge time.Duration, now time.Time) {
+	pruneMu.Lock()
+	if now.Sub(lastPrune[root]) < backupPruneInterval {
+		pruneMu.Unlock()
+		return
+	}
+	lastPrune[root] = now
+	pruneMu.Unlock()
+
+	var dirs []string
+	_ = filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() {
+			if p != root {
+				dirs = append(dirs, p)
+			}
+			return nil
+		}
+		if d.Name() == ".gitignore" && filepath.Dir(p) == root {
+			return nil
+		}
+		if info, err := d.Info(); err == nil && now.Sub(info.ModTime()) > maxAge {
+			_ = os.Remove(p)
+		}
+		return nil
+	})
+	// Deepest first so parents empty out after their children
+	for i := len(dirs) - 1; i >= 0; i-- {
+		_ = os.Remove(dirs[i])
+	}
+}
@@ -0,0 +1,114 @@
+package editor
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWriteFileAtomic_KeepsModeAndSymlinks(t *testing.T) {
+	dir := t.TempDir()
+	target := filepath.Join(dir, "run.sh")
+	if err := os.WriteFile(target, []byte("old"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	link := filepath.Join(dir, "link.sh")
+	if err := os.Symlink(target, link); err != nil {
+		t.Skip("symlinks unsupported:", err)
+	}
+
+	if err := WriteFileAtomic(link, []byte("new"), true); err != nil {
+		t.Fatal(err)
+	}
+	if info, err := os.Lstat(link); err != nil || info.Mode()&os.ModeSymlink == 0 {
+		t.Fatalf("symlink must survive: %v", err)
+	}
+	info, err := os.Stat(target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(target); string(data) != "new" || info.Mode().Perm() != 0o755 {
+		t.Errorf("unexpected target: %q %v", data, info.Mode())
+	}
+	entries, _ := os.ReadDir(dir)
+	for _, e := range entries {
+		if strings.Contains(e.Name(), ".loom-tmp-") {
+			t.Errorf("temporary file left behind: %s", e.Name())
+		}
+	}
+}
+
+func TestApplyEditWithOptions_BacksUpPreviousVersion(t *testing.T) {
+	ws := t.TempDir()
+	path := filepath.Join(ws, "pkg", "main.go")
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte("package main\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	opts := WriteOptions{Workspace: ws}
+
+	plan := &EditPlan{FilePath: path, OldContent: "package main\n", NewContent: "package main\n\nfunc main() {}\n"}
+	if err := ApplyEditWithOptions(plan, opts); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != plan.NewContent {
+		t.Fatalf("edit not written: %q", data)
+	}
+	backups, _ := filepath.Glob(filepath.Join(BackupDir(ws), "pkg", "main.go.*"))
+	if len(backups) != 1 {
+		t.Fatalf("expected one backup, got %v", backups)
+	}
+	if data, _ := os.ReadFile(backups[0]); string(data) != "package main\n" {
+		t.Errorf("backup should hold the previous version: %q", data)
+	}
+	if data, _ := os.ReadFile(filepath.Join(BackupDir(ws), ".gitignore")); string(data) != "*\n" {
+		t.Errorf("backups should be ignored by git: %q", data)
+	}
+
+	// New files have no previous version; disabled backups write none
+	created := &EditPlan{FilePath: filepath.Join(ws, "new.go"), NewContent: "package main\n", IsCreation: true}
+	if err := ApplyEditWithOptions(created, opts); err != nil {
+		t.Fatal(err)
+	}
+	if err := ApplyEditWithOptions(&EditPlan{FilePath: path, NewContent: "x\n"}, WriteOptions{Workspace: ws, BackupDays: -1}); err != nil {
+		t.Fatal(err)
+	}
+	if all, _ := filepath.Glob(filepath.Join(BackupDir(ws), "*.go.*")); len(all) != 0 {
+		t.Errorf("unexpected backups: %v", all)
+	}
+	if again, _ := filepath.Glob(filepath.Join(BackupDir(ws), "pkg", "main.go.*")); len(again) != 1 {
+		t.Errorf("disabled backups must not add copies: %v", again)
+	}
+}
+
+func TestPruneBackups_RemovesExpiredCopies(t *testing.T) {
+	root := t.TempDir()
+	old := filepath.Join(root, "a", "old.go.20200101-000000.000000000")
+	fresh := filepath.Join(root, "fresh.go.20990101-000000.000000000")
+	for _, p := range []string{old, fresh, filepath.Join(root, ".gitignore")} {
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte("x"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	now := time.Now()
+	stale := now.Add(-10 * 24 * time.Hour)
+	_ = os.Chtimes(old, stale, stale)
+	_ = os.Chtimes(filepath.Join(root, ".gitignore"), stale, stale)
+
+	pruneBackups(root, 7*24*time.Hour, now)
+	if _, err := os.Stat(filepath.Join(root, "a")); !os.IsNotExist(err) {
+		t.Errorf("expired backup and its empty directory should be removed: %v", err)
+	}
+	for _, p := range []string{fresh, filepath.Join(root, ".gitignore")} {
+		if _, err := os.Stat(p); err != nil {
+			t.Errorf("%s should be kept: %v", filepath.Base(p), err)
+		}
+	}
+}
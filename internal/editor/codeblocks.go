@@ -0,0 +1,101 @@
+package editor
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// CodeBlock is a fenced code block found in an assistant answer.
+type CodeBlock struct {
+	Index    int    `json:"index"`
+	Lang     string `json:"lang,omitempty"`
+	PathHint string `json:"path_hint,omitempty"` // suggested workspace-relative target, if the answer names one
+	Content  string `json:"content"`
+}
+
+var (
+	fenceOpen = regexp.MustCompile("^(\\s*)(`{3,}|~{3,})\\s*(.*)$")
+	// infoPath matches path hints in the fence info string: go:cmd/main.go,
+	// title="cmd/main.go", file=cmd/main.go, or path: cmd/main.go
+	infoPath = regexp.MustCompile(`(?:^[\w+#-]+:|\b(?:title|file|filename|path)\s*[=:]\s*)["']?([^\s"']+\.[A-Za-z0-9]+)["']?`)
+	// commentPath matches a leading "// file: x.go" or "# path: x.py" line in the block
+	commentPath = regexp.MustCompile(`^\s*(?://|#|--|/\*|<!--)\s*(?:file(?:name)?|path)\s*:\s*([^\s*]+\.[A-Za-z0-9]+)`)
+	// prosePath matches a file name on the line introducing the block, e.g. **src/app.ts** or `src/app.ts`:
+	prosePath = regexp.MustCompile("[`*]+([\\w./-]+\\.[A-Za-z0-9]+)[`*]+:?\\s*$")
+)
+
+// ExtractCodeBlocks returns the fenced code blocks in a markdown answer in order.
+// An unterminated final fence is treated as running to the end of the text.
+func ExtractCodeBlocks(markdown string) []CodeBlock {
+	lines := strings.Split(markdown, "\n")
+	var blocks []CodeBlock
+	for i := 0; i < len(lines); i++ {
+		m := fenceOpen.FindStringSubmatch(lines[i])
+		if m == nil {
+			continue
+		}
+		indent, fence, info := m[1], m[2], strings.TrimSpace(m[3])
+		var body []string
+		j := i + 1
+		for ; j < len(lines); j++ {
+			if t := strings.TrimSpace(lines[j]); strings.HasPrefix(t, fence) && strings.Trim(t, fence[:1]) == "" {
+				break
+			}
+			body = append(body, strings.TrimPrefix(lines[j], indent))
+		}
+
+		if j == len(lines) {
+			// Unterminated fence: drop the trailing blank lines of the answer
+			for len(body) > 0 && strings.TrimSpace(body[len(body)-1]) == "" {
+				body = body[:len(body)-1]
+			}
+		}
+		b := CodeBlock{Index: len(blocks), Content: strings.Join(body, "\n")}
+		if b.Content != "" {
+			b.Content += "\n"
+		}
+		if fields := strings.Fields(info); len(fields) > 0 {
+			b.Lang = strings.ToLower(strings.SplitN(fields[0], ":", 2)[0])
+		}
+		switch {
+		case infoPath.MatchString(info):
+			b.PathHint = infoPath.FindStringSubmatch(info)[1]
+		case len(body) > 0 && commentPath.MatchString(body[0]):
+			b.PathHint = commentPath.FindStringSubmatch(body[0])[1]
+		case i > 0 && prosePath.MatchString(strings.TrimSpace(lines[i-1])):
+			b.PathHint = prosePath.FindStringSubmatch(strings.TrimSpace(lines[i-1]))[1]
+		}
+		blocks = append(blocks, b)
+		i = j
+	}
+	return blocks
+}
+
+// PlanFileWrite builds an edit plan that writes content as the whole file at path,
+// creating it or replacing the existing content. New files get the same placement
+// checks as CREATE edits.
+func PlanFileWrite(workspacePath, path, content string) (*EditPlan, error) {
+	absPath, err := validatePath(workspacePath, path)
+	if err != nil {
+		return nil, err
+	}
+	old, format, err := ReadText(absPath)
+	switch {
+	case os.IsNotExist(err):
+		return ProposeAdvancedEdit(workspacePath, AdvancedEditRequest{FilePath: path, Action: ActionCreate, Content: content})
+	case err != nil:
+		return nil, ValidationError{Message: fmt.Sprintf("Failed to read file: %v", err), Code: "FILE_READ_ERROR"}
+	}
+	return &EditPlan{
+		FilePath:     absPath,
+		OldContent:   old,
+		NewContent:   content,
+		Diff:         generateDiff(old, content, filepath.Base(absPath)),
+		ChangedLines: LineRange{StartLine: 1, EndLine: 1 + strings.Count(content, "\n")},
+		Format:       format,
+		Notes:        format.lossNotes(content),
+	}, nil
+}
@@ -0,0 +1,30 @@
+package editor
+
+import "testing"
+
+func TestExtractCodeBlocks_PathHints(t *testing.T) {
+	answer := "Here is the handler.\n\n" +
+		"```go title=\"cmd/server/main.go\"\npackage main\n```\n\n" +
+		"Update **web/src/app.ts**:\n```ts\nexport const x = 1\n```\n\n" +
+		"```python\n# file: tools/run.py\nprint('hi')\n```\n\n" +
+		"```\nplain\n```\n" +
+		"```rust:src/lib.rs\nfn main() {}\n"
+
+	blocks := ExtractCodeBlocks(answer)
+	want := []struct{ lang, hint, content string }{
+		{"go", "cmd/server/main.go", "package main\n"},
+		{"ts", "web/src/app.ts", "export const x = 1\n"},
+		{"python", "tools/run.py", "# file: tools/run.py\nprint('hi')\n"},
+		{"", "", "plain\n"},
+		{"rust", "src/lib.rs", "fn main() {}\n"},
+	}
+	if len(blocks) != len(want) {
+		t.Fatalf("got %d blocks: %+v", len(blocks), blocks)
+	}
+	for i, w := range want {
+		b := blocks[i]
+		if b.Index != i || b.Lang != w.lang || b.PathHint != w.hint || b.Content != w.content {
+			t.Errorf("block %d: got %+v, want %+v", i, b, w)
+		}
+	}
+}
@@ -0,0 +1,205 @@
+package editor
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Text encodings recognised by DetectFormat.
+const (
+	EncodingUTF8    = "utf-8"
+	EncodingUTF16LE = "utf-16le"
+	EncodingUTF16BE = "utf-16be"
+	EncodingLatin1  = "latin-1"
+)
+
+var (
+	bomUTF8    = []byte{0xEF, 0xBB, 0xBF}
+	bomUTF16LE = []byte{0xFF, 0xFE}
+	bomUTF16BE = []byte{0xFE, 0xFF}
+)
+
+// TextFormat is how a file is stored on disk. Edits work on UTF-8 text with LF line
+// endings; the format converts back so a write only changes the edited lines.
+// The zero value is plain UTF-8 with LF endings and no BOM.
+type TextFormat struct {
+	Encoding string `json:"encoding,omitempty"`
+	BOM      bool   `json:"bom,omitempty"`
+	// CRLF is set only when every line break in the file is CRLF; files with mixed
+	// endings keep their CRs in the text so they round-trip unchanged
+	CRLF bool `json:"crlf,omitempty"`
+}
+
+// IsDefault reports whether f is plain UTF-8 with LF endings.
+func (f TextFormat) IsDefault() bool {
+	return (f.Encoding == "" || f.Encoding == EncodingUTF8) && !f.BOM && !f.CRLF
+}
+
+// String describes f for tool results, e.g. "utf-16le, BOM, CRLF".
+func (f TextFormat) String() string {
+	parts := []string{EncodingUTF8}
+	if f.Encoding != "" {
+		parts[0] = f.Encoding
+	}
+	if f.BOM {
+		parts = append(parts, "BOM")
+	}
+	if f.CRLF {
+		parts = append(parts, "CRLF")
+	} else {
+		parts = append(parts, "LF")
+	}
+	return strings.Join(parts, ", ")
+}
+
+// DetectFormat inspects raw file content. Content that is not text (NUL bytes
+// without a UTF-16 signature) is reported as UTF-8 so it passes through untouched.
+func DetectFormat(data []byte) TextFormat {
+	var f TextFormat
+	switch {
+	case bytes.HasPrefix(data, bomUTF8):
+		f = TextFormat{Encoding: EncodingUTF8, BOM: true}
+	case bytes.HasPrefix(data, bomUTF16LE):
+		f = TextFormat{Encoding: EncodingUTF16LE, BOM: true}
+	case bytes.HasPrefix(data, bomUTF16BE):
+		f = TextFormat{Encoding: EncodingUTF16BE, BOM: true}
+	default:
+		f.Encoding = guessEncoding(data)
+	}
+	text := decodeBytes(data, f)
+	lf := strings.Count(text, "\n")
+	f.CRLF = lf > 0 && strings.Count(text, "\r\n") == lf
+	return f
+}
+
+// guessEncoding handles files without a BOM: UTF-16 is recognised by its zero high
+// bytes, invalid UTF-8 without NULs is taken to be Latin-1.
+func guessEncoding(data []byte) string {
+	sample := data
+	if len(sample) > 8192 {
+		sample = sample[:8192]
+	}
+	if len(sample) >= 4 && len(sample)%2 == 0 {
+		var evenZero, oddZero int
+		for i := 0; i+1 < len(sample); i += 2 {
+			if sample[i] == 0 {
+				evenZero++
+			}
+			if sample[i+1] == 0 {
+				oddZero++
+			}
+		}
+		pairs := len(sample) / 2
+		switch {
+		case oddZero*10 >= pairs*4 && evenZero == 0:
+			return EncodingUTF16LE
+		case evenZero*10 >= pairs*4 && oddZero == 0:
+			return EncodingUTF16BE
+		}
+	}
+	if bytes.IndexByte(data, 0) >= 0 || utf8.Valid(data) {
+		return EncodingUTF8
+	}
+	return EncodingLatin1
+}
+
+// Decode converts raw content in format f to UTF-8 text with LF line endings.
+func (f TextFormat) Decode(data []byte) string {
+	text := decodeBytes(data, f)
+	if f.CRLF {
+		text = strings.ReplaceAll(text, "\r\n", "\n")
+	}
+	return text
+}
+
+// Encode converts UTF-8 text back to format f. CRLF already present in text is not
+// doubled, and runes Latin-1 cannot represent are written as '?'.
+func (f TextFormat) Encode(text string) []byte {
+	if f.CRLF {
+		text = strings.ReplaceAll(strings.ReplaceAll(text, "\r\n", "\n"), "\n", "\r\n")
+	}
+	switch f.Encoding {
+	case EncodingUTF16LE, EncodingUTF16BE:
+		var order binary.AppendByteOrder = binary.LittleEndian
+		if f.Encoding == EncodingUTF16BE {
+			order = binary.BigEndian
+		}
+		out := make([]byte, 0, 2*len(text)+2)
+		if f.BOM {
+			out = order.AppendUint16(out, 0xFEFF)
+		}
+		for _, u := range utf16.Encode([]rune(text)) {
+			out = order.AppendUint16(out, u)
+		}
+		return out
+	case EncodingLatin1:
+		out := make([]byte, 0, len(text))
+		for _, r := range text {
+			if r > 0xFF {
+				r = '?'
+			}
+			out = append(out, byte(r))
+		}
+		return out
+	}
+	if f.BOM {
+		return append(append([]byte{}, bomUTF8...), text...)
+	}
+	return []byte(text)
+}
+
+// lossNotes warns when text contains characters the file's encoding cannot hold.
+func (f TextFormat) lossNotes(text string) []string {
+	if f.Encoding != EncodingLatin1 {
+		return nil
+	}
+	for _, r := range text {
+		if r > 0xFF {
+			return []string{"file is Latin-1 encoded; characters outside Latin-1 will be written as '?'"}
+		}
+	}
+	return nil
+}
+
+func decodeBytes(data []byte, f TextFormat) string {
+	switch f.Encoding {
+	case EncodingUTF16LE, EncodingUTF16BE:
+		var order binary.ByteOrder = binary.LittleEndian
+		if f.Encoding == EncodingUTF16BE {
+			order = binary.BigEndian
+		}
+		if f.BOM && len(data) >= 2 {
+			data = data[2:]
+		}
+		units := make([]uint16, 0, len(data)/2)
+		for i := 0; i+1 < len(data); i += 2 {
+			units = append(units, order.Uint16(data[i:]))
+		}
+		return string(utf16.Decode(units))
+	case EncodingLatin1:
+		runes := make([]rune, len(data))
+		for i, b := range data {
+			runes[i] = rune(b)
+		}
+		return string(runes)
+	}
+	if f.BOM {
+		data = bytes.TrimPrefix(data, bomUTF8)
+	}
+	return string(data)
+}
+
+// ReadText reads path and returns its content as UTF-8 text with LF line endings,
+// along with the format needed to write it back.
+func ReadText(path string) (string, TextFormat, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", TextFormat{}, err
+	}
+	f := DetectFormat(data)
+	return f.Decode(data), f, nil
+}
@@ -0,0 +1,74 @@
+package editor
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDetectFormat(t *testing.T) {
+	cases := []struct {
+		name string
+		data []byte
+		want TextFormat
+	}{
+		{"plain", []byte("a\nb\n"), TextFormat{Encoding: EncodingUTF8}},
+		{"crlf", []byte("a\r\nb\r\n"), TextFormat{Encoding: EncodingUTF8, CRLF: true}},
+		{"mixed endings stay LF", []byte("a\r\nb\n"), TextFormat{Encoding: EncodingUTF8}},
+		{"utf8 bom", []byte("\xEF\xBB\xBFa\n"), TextFormat{Encoding: EncodingUTF8, BOM: true}},
+		{"utf16le bom", []byte{0xFF, 0xFE, 'a', 0, '\n', 0}, TextFormat{Encoding: EncodingUTF16LE, BOM: true}},
+		{"utf16be no bom", []byte{0, 'a', 0, 'b', 0, '\r', 0, '\n'}, TextFormat{Encoding: EncodingUTF16BE, CRLF: true}},
+		{"latin1", []byte("na\xefve\n"), TextFormat{Encoding: EncodingLatin1}},
+		{"binary passes through", []byte{0x89, 'P', 'N', 'G', 0, 0, 0, 1, 2}, TextFormat{Encoding: EncodingUTF8}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := DetectFormat(tc.data); got != tc.want {
+				t.Fatalf("DetectFormat = %+v, want %+v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestTextFormat_RoundTrip(t *testing.T) {
+	inputs := [][]byte{
+		[]byte("line one\r\nline two\r\n"),
+		[]byte("\xEF\xBB\xBFbom\nfile"),
+		[]byte("r\xe9sum\xe9\r\n"),
+		{0xFE, 0xFF, 0, 'h', 0, 'i', 0x00, 0xE9, 0, '\r', 0, '\n'},
+		[]byte("mixed\r\nendings\n"),
+	}
+	for _, data := range inputs {
+		f := DetectFormat(data)
+		text := f.Decode(data)
+		if bytes.Contains([]byte(text), []byte("\r\n")) && f.CRLF {
+			t.Errorf("%q: CRLF not normalised in %q", data, text)
+		}
+		if got := f.Encode(text); !bytes.Equal(got, data) {
+			t.Errorf("round trip changed bytes: got %q want %q (format %s)", got, data, f)
+		}
+	}
+}
+
+func TestProposeAdvancedEdit_KeepsCRLF(t *testing.T) {
+	ws := t.TempDir()
+	path := filepath.Join(ws, "a.txt")
+	if err := os.WriteFile(path, []byte("one\r\ntwo\r\nthree\r\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	plan, err := ProposeAdvancedEdit(ws, AdvancedEditRequest{FilePath: "a.txt", Action: ActionReplaceLines, StartLine: 2, EndLine: 2, Content: "TWO"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plan.NewContent != "one\nTWO\nthree\n" {
+		t.Fatalf("plan content = %q", plan.NewContent)
+	}
+	if err := ApplyEdit(plan); err != nil {
+		t.Fatal(err)
+	}
+	got, _ := os.ReadFile(path)
+	if string(got) != "one\r\nTWO\r\nthree\r\n" {
+		t.Fatalf("written = %q", got)
+	}
+}
@@ -0,0 +1,126 @@
+package editor
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/sergi/go-diff/diffmatchpatch"
+)
+
+// ChangedLineRanges returns the lines of oldContent (1-based, inclusive) that differ
+// in newContent, merged into ranges. A pure insertion between two lines marks both of
+// its neighbours, so any change next to an insertion point overlaps it.
+func ChangedLineRanges(oldContent, newContent string) []LineRange {
+	if oldContent == newContent {
+		return nil
+	}
+	oldLines := len(splitDiffLines(oldContent))
+	dmp := diffmatchpatch.New()
+	oldChars, newChars, lines := dmp.DiffLinesToChars(oldContent, newContent)
+	diffs := dmp.DiffCharsToLines(dmp.DiffMain(oldChars, newChars, false), lines)
+
+	var ranges []LineRange
+	add := func(start, end int) {
+		start, end = max(start, 1), min(end, max(oldLines, 1))
+		if end < start {
+			end = start
+		}
+		if n := len(ranges); n > 0 && start <= ranges[n-1].EndLine+1 {
+			ranges[n-1].EndLine = max(ranges[n-1].EndLine, end)
+			return
+		}
+		ranges = append(ranges, LineRange{StartLine: start, EndLine: end})
+	}
+	line := 1 // next old line
+	for i, d := range diffs {
+		n := len(splitDiffLines(d.Text))
+		switch d.Type {
+		case diffmatchpatch.DiffEqual:
+			line += n
+		case diffmatchpatch.DiffDelete:
+			add(line, line+n-1)
+			line += n
+		case diffmatchpatch.DiffInsert:
+			// Insertions paired with a deletion replace those lines, already marked
+			replacing := (i > 0 && diffs[i-1].Type == diffmatchpatch.DiffDelete) ||
+				(i+1 < len(diffs) && diffs[i+1].Type == diffmatchpatch.DiffDelete)
+			if !replacing {
+				add(line-1, line)
+			}
+		}
+	}
+	return ranges
+}
+
+// OverlappingRanges returns the ranges of a that share at least one line with a range of b.
+func OverlappingRanges(a, b []LineRange) []LineRange {
+	var out []LineRange
+	for _, r := range a {
+		for _, o := range b {
+			if r.StartLine <= o.EndLine && o.StartLine <= r.EndLine {
+				out = append(out, r)
+				break
+			}
+		}
+	}
+	return out
+}
+
+// FormatLineRanges renders ranges as "3-7, 12".
+func FormatLineRanges(ranges []LineRange) string {
+	parts := make([]string, len(ranges))
+	for i, r := range ranges {
+		if r.StartLine == r.EndLine {
+			parts[i] = fmt.Sprint(r.StartLine)
+		} else {
+			parts[i] = fmt.Sprintf("%d-%d", r.StartLine, r.EndLine)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// ChangeHunk is a run of changed lines: the lines of the old content it replaces
+// (1-based, inclusive; EndLine is StartLine-1 for a pure insertion before StartLine)
+// and the number of lines it removes and adds.
+type ChangeHunk struct {
+	StartLine int `json:"start_line"`
+	EndLine   int `json:"end_line"`
+	Removed   int `json:"removed"`
+	Added     int `json:"added"`
+}
+
+// ChangeHunks returns the runs of changed lines between oldContent and newContent in
+// file order.
+func ChangeHunks(oldContent, newContent string) []ChangeHunk {
+	if oldContent == newContent {
+		return nil
+	}
+	dmp := diffmatchpatch.New()
+	oldChars, newChars, lines := dmp.DiffLinesToChars(oldContent, newContent)
+	diffs := dmp.DiffCharsToLines(dmp.DiffMain(oldChars, newChars, false), lines)
+
+	var hunks []ChangeHunk
+	open := false
+	line := 1 // next old line
+	for _, d := range diffs {
+		n := len(splitDiffLines(d.Text))
+		if d.Type == diffmatchpatch.DiffEqual {
+			line += n
+			open = false
+			continue
+		}
+		if !open {
+			hunks = append(hunks, ChangeHunk{StartLine: line, EndLine: line - 1})
+			open = true
+		}
+		h := &hunks[len(hunks)-1]
+		if d.Type == diffmatchpatch.DiffDelete {
+			h.Removed += n
+			h.EndLine += n
+			line += n
+		} else {
+			h.Added += n
+		}
+	}
+	return hunks
+}
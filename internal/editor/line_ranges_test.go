@@ -0,0 +1,57 @@
+package editor
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestChangedLineRanges(t *testing.T) {
+	old := "a\nb\nc\nd\ne\nf\n"
+	cases := []struct {
+		name    string
+		content string
+		want    []LineRange
+	}{
+		{"unchanged", old, nil},
+		{"modified", "a\nB\nc\nd\nE\nf\n", []LineRange{{2, 2}, {5, 5}}},
+		{"adjacent merge", "a\nB\nC\nd\ne\nf\n", []LineRange{{2, 3}}},
+		{"deleted", "a\nb\nf\n", []LineRange{{3, 5}}},
+		{"inserted", "a\nb\nnew\nc\nd\ne\nf\n", []LineRange{{2, 3}}},
+		{"appended", old + "g\n", []LineRange{{6, 6}}},
+	}
+	for _, c := range cases {
+		if got := ChangedLineRanges(old, c.content); !reflect.DeepEqual(got, c.want) {
+			t.Errorf("%s: got %v, want %v", c.name, got, c.want)
+		}
+	}
+}
+
+func TestOverlappingRanges(t *testing.T) {
+	dirty := []LineRange{{2, 4}, {10, 12}}
+	got := OverlappingRanges(dirty, []LineRange{{4, 6}})
+	if !reflect.DeepEqual(got, []LineRange{{2, 4}}) {
+		t.Fatalf("got %v", got)
+	}
+	if got := OverlappingRanges(dirty, []LineRange{{5, 9}}); got != nil {
+		t.Fatalf("expected no overlap, got %v", got)
+	}
+	if s := FormatLineRanges(dirty[:1]) + "; " + FormatLineRanges([]LineRange{{7, 7}}); s != "2-4; 7" {
+		t.Fatalf("unexpected format %q", s)
+	}
+}
+
+func TestChangeHunks(t *testing.T) {
+	old := "a\nb\nc\nd\ne\nf\n"
+	got := ChangeHunks(old, "a\nB\nC\nd\nnew\ne\n")
+	want := []ChangeHunk{
+		{StartLine: 2, EndLine: 3, Removed: 2, Added: 2},
+		{StartLine: 5, EndLine: 4, Added: 1},
+		{StartLine: 6, EndLine: 6, Removed: 1},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %+v, want %+v", got, want)
+	}
+	if got := ChangeHunks(old, old); got != nil {
+		t.Fatalf("expected no hunks, got %+v", got)
+	}
+}
@@ -0,0 +1,284 @@
+package editor
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Placement is the outcome of checking a new file against its surrounding directory.
+// Content may differ from the proposed content when a safe correction was applied.
+type Placement struct {
+	Content string
+	Notes   []string
+}
+
+// CheckNewFilePlacement validates a file that is about to be created against the
+// conventions of its directory: the Go package clause, TypeScript import paths
+// (including tsconfig path aliases), and Python package markers. Unambiguous
+// mistakes are corrected; everything else is reported as a note.
+func CheckNewFilePlacement(workspacePath, absPath, content string) Placement {
+	p := Placement{Content: content}
+	workspacePath = filepath.Clean(workspacePath)
+	switch strings.ToLower(filepath.Ext(absPath)) {
+	case ".go":
+		checkGoPlacement(workspacePath, absPath, &p)
+	case ".ts", ".tsx", ".js", ".jsx", ".mts", ".cts":
+		checkTSPlacement(workspacePath, absPath, &p)
+	case ".py":
+		checkPythonPlacement(workspacePath, absPath, &p)
+	}
+	return p
+}
+
+var (
+	goPackageClause = regexp.MustCompile(`(?m)^package\s+([A-Za-z_][A-Za-z0-9_]*)`)
+	goModuleLine    = regexp.MustCompile(`(?m)^module\s+(\S+)`)
+	goImportPath    = regexp.MustCompile(`"([^"\s]+)"`)
+	goImportBlock   = regexp.MustCompile(`(?s)\bimport\s*(?:\((.*?)\)|([A-Za-z_.]*\s*"[^"]+"))`)
+)
+
+// checkGoPlacement aligns the package clause with sibling files and verifies that
+// imports of the workspace's own module point at existing directories.
+func checkGoPlacement(workspacePath, absPath string, p *Placement) {
+	dir := filepath.Dir(absPath)
+	isTest := strings.HasSuffix(absPath, "_test.go")
+
+	m := goPackageClause.FindStringSubmatchIndex(p.Content)
+	if m == nil {
+		p.Notes = append(p.Notes, "Go file has no package clause")
+		return
+	}
+	declared := p.Content[m[2]:m[3]]
+
+	if sibling := siblingGoPackage(dir); sibling != "" {
+		ok := declared == sibling || isTest && declared == sibling+"_test"
+		if !ok {
+			p.Content = p.Content[:m[2]] + sibling + p.Content[m[3]:]
+			p.Notes = append(p.Notes, fmt.Sprintf("Corrected package clause %q to %q to match the other files in %s", declared, sibling, relOrSelf(workspacePath, dir)))
+		}
+	} else if want := goPackageForDir(dir); declared != want && declared != "main" && !(isTest && declared == want+"_test") {
+		p.Notes = append(p.Notes, fmt.Sprintf("Package %q differs from the directory name %q; Go convention is to name the package after its directory", declared, want))
+	}
+
+	// Imports of this module must resolve to a directory in the workspace
+	modRoot, module := findGoModule(dir, workspacePath)
+	if module == "" {
+		return
+	}
+	for _, imp := range goImports(p.Content) {
+		if imp != module && !strings.HasPrefix(imp, module+"/") {
+			continue
+		}
+		target := filepath.Join(modRoot, filepath.FromSlash(strings.TrimPrefix(imp, module)))
+		if info, err := os.Stat(target); err != nil || !info.IsDir() {
+			p.Notes = append(p.Notes, fmt.Sprintf("Import %q does not match a package directory in this module", imp))
+		}
+	}
+}
+
+// siblingGoPackage returns the package declared by existing non-test files in dir.
+func siblingGoPackage(dir string) string {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return ""
+	}
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasSuffix(name, ".go") || strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			continue
+		}
+		if m := goPackageClause.FindSubmatch(data); m != nil {
+			return string(m[1])
+		}
+	}
+	return ""
+}
+
+// goPackageForDir derives the conventional package name from a directory name.
+func goPackageForDir(dir string) string {
+	name := strings.ToLower(filepath.Base(dir))
+	name = strings.NewReplacer("-", "", ".", "", " ", "").Replace(name)
+	return name
+}
+
+// findGoModule walks up from dir (not above the workspace) to the nearest go.mod.
+func findGoModule(dir, workspacePath string) (root, module string) {
+	for d := dir; ; d = filepath.Dir(d) {
+		if data, err := os.ReadFile(filepath.Join(d, "go.mod")); err == nil {
+			if m := goModuleLine.FindSubmatch(data); m != nil {
+				return d, string(m[1])
+			}
+			return "", ""
+		}
+		if d == workspacePath || filepath.Dir(d) == d || !strings.HasPrefix(d, workspacePath) {
+			return "", ""
+		}
+	}
+}
+
+func goImports(content string) []string {
+	var out []string
+	for _, m := range goImportBlock.FindAllStringSubmatch(content, -1) {
+		for _, im := range goImportPath.FindAllStringSubmatch(m[1]+m[2], -1) {
+			out = append(out, im[1])
+		}
+	}
+	return out
+}
+
+// tsImport matches ES module specifiers in import/export-from statements and require calls.
+var tsImport = regexp.MustCompile(`(?:\bfrom\s+|\bimport\s+|\brequire\s*\(\s*)['"]([^'"]+)['"]`)
+
+var tsExtensions = []string{"", ".ts", ".tsx", ".d.ts", ".js", ".jsx", ".mjs", "/index.ts", "/index.tsx", "/index.js", ".vue", ".svelte"}
+
+// checkTSPlacement reports relative and aliased imports that do not resolve from the
+// new file's location.
+func checkTSPlacement(workspacePath, absPath string, p *Placement) {
+	resolver := NewTSImportResolver(workspacePath)
+	dir := filepath.Dir(absPath)
+	for _, m := range tsImport.FindAllStringSubmatch(p.Content, -1) {
+		spec := m[1]
+		candidates := resolver.candidates(dir, spec)
+		if len(candidates) == 0 {
+			continue // package import
+		}
+		if !anyResolves(candidates) {
+			p.Notes = append(p.Notes, fmt.Sprintf("Import %q does not resolve from %s", spec, relOrSelf(workspacePath, dir)))
+		}
+	}
+}
+
+// TSImportResolver resolves relative and tsconfig-aliased module specifiers to files.
+type TSImportResolver struct {
+	aliases map[string][]string
+	baseDir string
+}
+
+// NewTSImportResolver reads the workspace's tsconfig.json (or jsconfig.json) paths.
+func NewTSImportResolver(workspacePath string) *TSImportResolver {
+	aliases, baseDir := tsPathAliases(workspacePath)
+	return &TSImportResolver{aliases: aliases, baseDir: baseDir}
+}
+
+// Resolve returns the absolute file spec refers to from fromDir, or "" for package
+// imports and specifiers that do not resolve.
+func (r *TSImportResolver) Resolve(fromDir, spec string) string {
+	for _, c := range r.candidates(fromDir, spec) {
+		for _, ext := range tsExtensions {
+			if info, err := os.Stat(c + ext); err == nil && !info.IsDir() {
+				return c + ext
+			}
+		}
+	}
+	return ""
+}
+
+func (r *TSImportResolver) candidates(fromDir, spec string) []string {
+	if strings.HasPrefix(spec, "./") || strings.HasPrefix(spec, "../") {
+		return []string{filepath.Join(fromDir, spec)}
+	}
+	var candidates []string
+	for prefix, targets := range r.aliases {
+		if rest, ok := matchAlias(prefix, spec); ok {
+			for _, t := range targets {
+				candidates = append(candidates, filepath.Join(r.baseDir, strings.Replace(t, "*", rest, 1)))
+			}
+		}
+	}
+	return candidates
+}
+
+// matchAlias matches a tsconfig paths key such as "@/*" or "@app" against a specifier.
+func matchAlias(pattern, spec string) (rest string, ok bool) {
+	if i := strings.Index(pattern, "*"); i >= 0 {
+		prefix, suffix := pattern[:i], pattern[i+1:]
+		if strings.HasPrefix(spec, prefix) && strings.HasSuffix(spec, suffix) && len(spec) >= len(prefix)+len(suffix) {
+			return spec[len(prefix) : len(spec)-len(suffix)], true
+		}
+		return "", false
+	}
+	return "", spec == pattern
+}
+
+func anyResolves(candidates []string) bool {
+	for _, c := range candidates {
+		for _, ext := range tsExtensions {
+			if _, err := os.Stat(c + ext); err == nil {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// tsPathAliases reads compilerOptions.paths from the workspace tsconfig.json (or
+// jsconfig.json) and returns the aliases with the directory they resolve against.
+func tsPathAliases(workspacePath string) (map[string][]string, string) {
+	for _, name := range []string{"tsconfig.json", "jsconfig.json"} {
+		data, err := os.ReadFile(filepath.Join(workspacePath, name))
+		if err != nil {
+			continue
+		}
+		var cfg struct {
+			CompilerOptions struct {
+				BaseURL string              `json:"baseUrl"`
+				Paths   map[string][]string `json:"paths"`
+			} `json:"compilerOptions"`
+		}
+		if json.Unmarshal(stripJSONComments(data), &cfg) != nil {
+			continue
+		}
+		return cfg.CompilerOptions.Paths, filepath.Join(workspacePath, cfg.CompilerOptions.BaseURL)
+	}
+	return nil, workspacePath
+}
+
+var (
+	jsonLineComment  = regexp.MustCompile(`(?m)^\s*//.*$`)
+	jsonBlockComment = regexp.MustCompile(`(?s)/\*.*?\*/`)
+	jsonTrailComma   = regexp.MustCompile(`,(\s*[}\]])`)
+)
+
+// stripJSONComments removes the comments and trailing commas tsconfig files allow.
+func stripJSONComments(data []byte) []byte {
+	data = jsonBlockComment.ReplaceAll(data, nil)
+	data = jsonLineComment.ReplaceAll(data, nil)
+	return jsonTrailComma.ReplaceAll(data, []byte("$1"))
+}
+
+// checkPythonPlacement warns when a module lands in a directory that breaks the
+// surrounding package structure.
+func checkPythonPlacement(workspacePath, absPath string, p *Placement) {
+	if filepath.Base(absPath) == "__init__.py" {
+		return
+	}
+	dir := filepath.Dir(absPath)
+	parent := filepath.Dir(dir)
+	if dir == workspacePath || !strings.HasPrefix(parent, workspacePath) {
+		return
+	}
+	if exists(filepath.Join(parent, "__init__.py")) && !exists(filepath.Join(dir, "__init__.py")) {
+		p.Notes = append(p.Notes, fmt.Sprintf("%s is inside package %s but has no __init__.py; create %s so the module is importable",
+			relOrSelf(workspacePath, dir), relOrSelf(workspacePath, parent), relOrSelf(workspacePath, filepath.Join(dir, "__init__.py"))))
+	}
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+func relOrSelf(base, path string) string {
+	if r, err := filepath.Rel(base, path); err == nil {
+		return filepath.ToSlash(r)
+	}
+	return path
+}
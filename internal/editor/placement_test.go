@@ -0,0 +1,78 @@
+package editor
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeFiles(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for name, body := range files {
+		p := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestCheckNewFilePlacement_GoPackageAndImports(t *testing.T) {
+	ws := t.TempDir()
+	writeFiles(t, ws, map[string]string{
+		"go.mod":               "module example.com/app\n\ngo 1.22\n",
+		"internal/store/db.go": "package store\n",
+		"internal/util/x.go":   "package util\n",
+	})
+
+	src := "package db\n\nimport (\n\t\"fmt\"\n\t\"example.com/app/internal/util\"\n\t\"example.com/app/internal/missing\"\n)\n"
+	p := CheckNewFilePlacement(ws, filepath.Join(ws, "internal/store/cache.go"), src)
+	if !strings.HasPrefix(p.Content, "package store\n") {
+		t.Fatalf("package clause not corrected: %q", p.Content)
+	}
+	joined := strings.Join(p.Notes, "\n")
+	if !strings.Contains(joined, `"db" to "store"`) || !strings.Contains(joined, "internal/missing") || strings.Contains(joined, "internal/util\"") {
+		t.Fatalf("unexpected notes: %v", p.Notes)
+	}
+
+	// External test packages are allowed
+	p = CheckNewFilePlacement(ws, filepath.Join(ws, "internal/store/db_test.go"), "package store_test\n")
+	if p.Content != "package store_test\n" || len(p.Notes) != 0 {
+		t.Fatalf("external test package should be accepted: %+v", p)
+	}
+
+	// New directory: only warn when the name doesn't follow the directory
+	p = CheckNewFilePlacement(ws, filepath.Join(ws, "internal/auth/token.go"), "package tokens\n")
+	if p.Content != "package tokens\n" || len(p.Notes) != 1 {
+		t.Fatalf("expected a warning only: %+v", p)
+	}
+}
+
+func TestCheckNewFilePlacement_TSAliases(t *testing.T) {
+	ws := t.TempDir()
+	writeFiles(t, ws, map[string]string{
+		"tsconfig.json":          "{\n  // comment\n  \"compilerOptions\": { \"baseUrl\": \".\", \"paths\": { \"@/*\": [\"src/*\"], }, },\n}\n",
+		"src/lib/api.ts":         "export const api = 1\n",
+		"src/components/Box.tsx": "export {}\n",
+	})
+	src := "import { api } from '@/lib/api'\nimport Box from './Box'\nimport Gone from '@/lib/gone'\nimport React from 'react'\n"
+	p := CheckNewFilePlacement(ws, filepath.Join(ws, "src/components/Card.tsx"), src)
+	if len(p.Notes) != 1 || !strings.Contains(p.Notes[0], "@/lib/gone") {
+		t.Fatalf("unexpected notes: %v", p.Notes)
+	}
+}
+
+func TestCheckNewFilePlacement_PythonPackage(t *testing.T) {
+	ws := t.TempDir()
+	writeFiles(t, ws, map[string]string{"app/__init__.py": ""})
+	p := CheckNewFilePlacement(ws, filepath.Join(ws, "app/services/billing.py"), "x = 1\n")
+	if len(p.Notes) != 1 || !strings.Contains(p.Notes[0], "app/services/__init__.py") {
+		t.Fatalf("unexpected notes: %v", p.Notes)
+	}
+	if p := CheckNewFilePlacement(ws, filepath.Join(ws, "app/models.py"), "x = 1\n"); len(p.Notes) != 0 {
+		t.Fatalf("module in an existing package should pass: %v", p.Notes)
+	}
+}
@@ -0,0 +1,187 @@
+package editor
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// SearchReplaceBlock is one edit in the SEARCH/REPLACE block format:
+//
+//	<<<<<<< SEARCH
+//	existing lines
+//	=======
+//	new lines
+//	>>>>>>> REPLACE
+//
+// The search text locates the region by content alone, so the edit does not depend on
+// line numbers that may have shifted.
+type SearchReplaceBlock struct {
+	Search  string
+	Replace string
+}
+
+var (
+	searchMarker  = regexp.MustCompile(`^<{5,9}\s*SEARCH\s*$`)
+	dividerMarker = regexp.MustCompile(`^={5,9}\s*$`)
+	replaceMarker = regexp.MustCompile(`^>{5,9}\s*REPLACE\s*$`)
+)
+
+// ParseSearchReplaceBlocks extracts the SEARCH/REPLACE blocks from text. Lines outside
+// blocks (file names, code fences, prose) are ignored.
+func ParseSearchReplaceBlocks(text string) ([]SearchReplaceBlock, error) {
+	const (
+		outside = iota
+		inSearch
+		inReplace
+	)
+	var blocks []SearchReplaceBlock
+	var search, replace []string
+	state := outside
+	for i, line := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		marker := strings.TrimSpace(line)
+		switch state {
+		case outside:
+			if searchMarker.MatchString(marker) {
+				state, search, replace = inSearch, nil, nil
+			}
+		case inSearch:
+			switch {
+			case dividerMarker.MatchString(marker):
+				state = inReplace
+			case searchMarker.MatchString(marker), replaceMarker.MatchString(marker):
+				return nil, fmt.Errorf("line %d: expected ======= before %q", i+1, marker)
+			default:
+				search = append(search, line)
+			}
+		case inReplace:
+			switch {
+			case replaceMarker.MatchString(marker):
+				blocks = append(blocks, SearchReplaceBlock{Search: strings.Join(search, "\n"), Replace: strings.Join(replace, "\n")})
+				state = outside
+			case searchMarker.MatchString(marker):
+				return nil, fmt.Errorf("line %d: expected >>>>>>> REPLACE before a new SEARCH block", i+1)
+			default:
+				replace = append(replace, line)
+			}
+		}
+	}
+	if state != outside {
+		return nil, fmt.Errorf("unterminated SEARCH/REPLACE block")
+	}
+	if len(blocks) == 0 {
+		return nil, fmt.Errorf("no SEARCH/REPLACE blocks found")
+	}
+	return blocks, nil
+}
+
+// ApplySearchReplaceBlocks applies the blocks to content in order, each to the result of
+// the previous one. A block's search text must match exactly one place: an exact match
+// is tried first, then a line-by-line match that ignores indentation and trailing
+// whitespace, in which case the replacement is re-indented to the matched lines. An
+// empty search text is only accepted for an empty file. changed spans the touched lines.
+func ApplySearchReplaceBlocks(content string, blocks []SearchReplaceBlock) (string, LineRange, error) {
+	var changed LineRange
+	for n, b := range blocks {
+		var start, lines int
+		var err error
+		content, start, lines, err = applySearchReplaceBlock(content, b)
+		if err != nil {
+			return "", LineRange{}, fmt.Errorf("block %d: %w", n+1, err)
+		}
+		end := start + max(lines, 1) - 1
+		if changed.StartLine == 0 || start < changed.StartLine {
+			changed.StartLine = start
+		}
+		if end > changed.EndLine {
+			changed.EndLine = end
+		}
+	}
+	return content, changed, nil
+}
+
+// applySearchReplaceBlock applies one block and returns the new content, the 1-based line
+// where the replacement starts and how many lines it has.
+func applySearchReplaceBlock(content string, b SearchReplaceBlock) (string, int, int, error) {
+	replaceLines := strings.Count(b.Replace, "\n") + 1
+	if b.Replace == "" {
+		replaceLines = 0
+	}
+	if strings.TrimSpace(b.Search) == "" {
+		if strings.TrimSpace(content) != "" {
+			return "", 0, 0, fmt.Errorf("SEARCH is empty; quote the existing lines to replace")
+		}
+		return b.Replace, 1, replaceLines, nil
+	}
+
+	switch strings.Count(content, b.Search) {
+	case 1:
+		idx := strings.Index(content, b.Search)
+		return content[:idx] + b.Replace + content[idx+len(b.Search):], 1 + strings.Count(content[:idx], "\n"), replaceLines, nil
+	case 0:
+	default:
+		return "", 0, 0, fmt.Errorf("SEARCH text matches %d places; include more surrounding lines so it matches exactly one", strings.Count(content, b.Search))
+	}
+
+	// Fall back to matching trimmed lines so indentation drift does not break the edit
+	fileLines := strings.Split(content, "\n")
+	searchLines := strings.Split(strings.Trim(b.Search, "\n"), "\n")
+	var matches []int
+	for i := 0; i+len(searchLines) <= len(fileLines); i++ {
+		ok := true
+		for j, s := range searchLines {
+			if strings.TrimSpace(fileLines[i+j]) != strings.TrimSpace(s) {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			matches = append(matches, i)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return "", 0, 0, fmt.Errorf("SEARCH text not found; copy the existing lines exactly as they appear in the file")
+	case 1:
+	default:
+		return "", 0, 0, fmt.Errorf("SEARCH text matches %d places; include more surrounding lines so it matches exactly one", len(matches))
+	}
+
+	at := matches[0]
+	var replacement []string
+	if b.Replace != "" {
+		replacement = reindent(strings.Split(strings.Trim(b.Replace, "\n"), "\n"), searchLines, fileLines[at:at+len(searchLines)])
+	}
+	out := append([]string{}, fileLines[:at]...)
+	out = append(out, replacement...)
+	out = append(out, fileLines[at+len(searchLines):]...)
+	return strings.Join(out, "\n"), at + 1, len(replacement), nil
+}
+
+// reindent shifts replacement lines by the indentation difference between the search
+// text and the file lines it matched, taken from their first non-blank lines.
+func reindent(replacement, search, matched []string) []string {
+	from, to := "", ""
+	for i, s := range search {
+		if strings.TrimSpace(s) != "" {
+			from, to = leadingWhitespace(s), leadingWhitespace(matched[i])
+			break
+		}
+	}
+	if from == to {
+		return replacement
+	}
+	out := make([]string, len(replacement))
+	for i, l := range replacement {
+		if rest, ok := strings.CutPrefix(l, from); ok && strings.TrimSpace(l) != "" {
+			out[i] = to + rest
+		} else {
+			out[i] = l
+		}
+	}
+	return out
+}
+
+func leadingWhitespace(s string) string {
+	return s[:len(s)-len(strings.TrimLeft(s, " \t"))]
+}
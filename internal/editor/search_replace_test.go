@@ -0,0 +1,95 @@
+package editor
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseSearchReplaceBlocks(t *testing.T) {
+	text := "main.go\n```go\n<<<<<<< SEARCH\nfunc a() {\n}\n=======\nfunc a() int {\n\treturn 1\n}\n>>>>>>> REPLACE\n```\n<<<<<<< SEARCH\nvar x = 1\n=======\n>>>>>>> REPLACE\n"
+	blocks, err := ParseSearchReplaceBlocks(text)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(blocks) != 2 {
+		t.Fatalf("expected 2 blocks, got %+v", blocks)
+	}
+	if blocks[0].Search != "func a() {\n}" || blocks[0].Replace != "func a() int {\n\treturn 1\n}" {
+		t.Errorf("unexpected first block: %+v", blocks[0])
+	}
+	if blocks[1].Search != "var x = 1" || blocks[1].Replace != "" {
+		t.Errorf("unexpected second block: %+v", blocks[1])
+	}
+
+	for _, bad := range []string{
+		"no blocks here",
+		"<<<<<<< SEARCH\na\n=======\nb\n",
+		"<<<<<<< SEARCH\na\n>>>>>>> REPLACE\n",
+	} {
+		if _, err := ParseSearchReplaceBlocks(bad); err == nil {
+			t.Errorf("expected an error for %q", bad)
+		}
+	}
+}
+
+func TestApplySearchReplaceBlocks(t *testing.T) {
+	content := "package main\n\nfunc main() {\n\tfmt.Println(\"a\")\n\tfmt.Println(\"b\")\n}\n"
+
+	// Exact match
+	out, changed, err := ApplySearchReplaceBlocks(content, []SearchReplaceBlock{{Search: "\tfmt.Println(\"a\")", Replace: "\tfmt.Println(\"A\")"}})
+	if err != nil || !strings.Contains(out, "\"A\"") || changed.StartLine != 4 || changed.EndLine != 4 {
+		t.Fatalf("exact match failed: %v %q %+v", err, out, changed)
+	}
+
+	// Indentation drift: the model indented with spaces, the replacement is re-indented
+	out, _, err = ApplySearchReplaceBlocks(content, []SearchReplaceBlock{{Search: "    fmt.Println(\"b\")\n}", Replace: "    fmt.Println(\"B\")\n    fmt.Println(\"C\")\n}"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, "\tfmt.Println(\"B\")\n\tfmt.Println(\"C\")\n}") {
+		t.Errorf("replacement not re-indented: %q", out)
+	}
+
+	// Ambiguous and missing search text are rejected
+	if _, _, err := ApplySearchReplaceBlocks(content, []SearchReplaceBlock{{Search: "fmt.Println", Replace: "x"}}); err == nil || !strings.Contains(err.Error(), "matches 2 places") {
+		t.Errorf("expected ambiguity error, got %v", err)
+	}
+	if _, _, err := ApplySearchReplaceBlocks(content, []SearchReplaceBlock{{Search: "missing()", Replace: "x"}}); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("expected not found error, got %v", err)
+	}
+
+	// Blocks apply in order, each to the previous result
+	out, _, err = ApplySearchReplaceBlocks(content, []SearchReplaceBlock{
+		{Search: "\"a\"", Replace: "\"x\""},
+		{Search: "\"x\"", Replace: "\"y\""},
+	})
+	if err != nil || !strings.Contains(out, "\"y\"") {
+		t.Errorf("sequential blocks failed: %v %q", err, out)
+	}
+}
+
+func TestProposeAdvancedEdit_SearchReplaceBlocks(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "main.go")
+	if err := os.WriteFile(path, []byte("package main\n\nconst name = \"old\"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	plan, err := ProposeAdvancedEdit(dir, AdvancedEditRequest{
+		FilePath: "main.go",
+		Action:   ActionSearchReplaceBlocks,
+		Content:  "<<<<<<< SEARCH\nconst name = \"old\"\n=======\nconst name = \"new\"\n>>>>>>> REPLACE",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plan.NewContent != "package main\n\nconst name = \"new\"\n" || plan.ChangedLines.StartLine != 3 {
+		t.Errorf("unexpected plan: %q %+v", plan.NewContent, plan.ChangedLines)
+	}
+
+	_, err = ProposeAdvancedEdit(dir, AdvancedEditRequest{FilePath: "main.go", Action: ActionSearchReplaceBlocks, Content: "const name = \"new\""})
+	if ve, ok := err.(ValidationError); !ok || ve.Code != "INVALID_BLOCKS" {
+		t.Errorf("expected INVALID_BLOCKS, got %v", err)
+	}
+}
@@ -0,0 +1,128 @@
+package editor
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// maxLinkHops bounds the symlinks followed while resolving one path, like the kernel's
+// ELOOP limit.
+const maxLinkHops = 40
+
+// ErrOutsideWorkspace is returned by SecurePath for a path that is not in the workspace.
+var ErrOutsideWorkspace = errors.New("path must be within the workspace")
+
+// SecurePath resolves a path given to a tool (workspace-relative, absolute or a
+// file:// URI) and returns it as an absolute path under workspace. It accepts absolute
+// paths through the real location of a symlinked workspace, follows symlinks that
+// stay inside the workspace and refuses paths that leave it lexically or through a
+// symlink, including a dangling one a write would create the target of.
+func SecurePath(workspace, p string) (string, error) {
+	p, err := fromFileURI(p)
+	if err != nil {
+		return "", err
+	}
+	root := filepath.Clean(workspace)
+	if !filepath.IsAbs(p) {
+		p = filepath.Join(root, p)
+	}
+	p = filepath.Clean(p)
+
+	realRoot := ResolvePath(root)
+	rel, ok := relativeTo(root, p)
+	if !ok {
+		// An absolute path through the workspace's real location
+		if rel, ok = relativeTo(realRoot, p); !ok {
+			return "", ErrOutsideWorkspace
+		}
+		p = filepath.Join(root, rel)
+	}
+	if _, ok := relativeTo(realRoot, ResolvePath(p)); !ok {
+		return "", fmt.Errorf("%s escapes the workspace through a symlink", filepath.ToSlash(rel))
+	}
+	return p, nil
+}
+
+// InWorkspace reports whether the real location of path, after following every
+// symlink, is inside the workspace's real location.
+func InWorkspace(workspace, path string) bool {
+	_, ok := relativeTo(ResolvePath(workspace), ResolvePath(path))
+	return ok
+}
+
+// ResolvePath follows the symlinks in an absolute path like filepath.EvalSymlinks,
+// but also resolves paths that do not exist yet: the missing tail is kept, and a
+// dangling symlink is replaced by the path it points to.
+func ResolvePath(p string) string {
+	p = filepath.Clean(p)
+hops:
+	for hop := 0; hop < maxLinkHops; hop++ {
+		if resolved, err := filepath.EvalSymlinks(p); err == nil {
+			return resolved
+		}
+		// Find the deepest existing part of the path
+		dir, tail := p, ""
+		for {
+			info, err := os.Lstat(dir)
+			if err == nil {
+				if info.Mode()&os.ModeSymlink == 0 {
+					resolved, err := filepath.EvalSymlinks(dir)
+					if err != nil {
+						return p
+					}
+					return filepath.Join(resolved, tail)
+				}
+				target, err := os.Readlink(dir)
+				if err != nil {
+					return p
+				}
+				if !filepath.IsAbs(target) {
+					target = filepath.Join(filepath.Dir(dir), target)
+				}
+				p = filepath.Join(target, tail)
+				continue hops
+			}
+			parent := filepath.Dir(dir)
+			if parent == dir {
+				return p
+			}
+			tail = filepath.Join(filepath.Base(dir), tail)
+			dir = parent
+		}
+	}
+	return p
+}
+
+// relativeTo returns p relative to root when p is root or below it.
+func relativeTo(root, p string) (string, bool) {
+	rel, err := filepath.Rel(root, p)
+	if err != nil || !filepath.IsLocal(rel) {
+		return "", false
+	}
+	return rel, true
+}
+
+// fromFileURI converts a file:// URI to a local path; other paths are returned as is.
+func fromFileURI(p string) (string, error) {
+	if !strings.HasPrefix(strings.ToLower(p), "file://") {
+		return p, nil
+	}
+	u, err := url.Parse(p)
+	if err != nil {
+		return "", fmt.Errorf("invalid file URI %q: %w", p, err)
+	}
+	if u.Host != "" && u.Host != "localhost" {
+		return "", fmt.Errorf("file URI %q points to another host", p)
+	}
+	path := u.Path
+	// file:///C:/dir is C:/dir on Windows
+	if runtime.GOOS == "windows" && len(path) > 2 && path[0] == '/' && path[2] == ':' {
+		path = path[1:]
+	}
+	return filepath.FromSlash(path), nil
+}
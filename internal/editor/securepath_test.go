@@ -0,0 +1,61 @@
+package editor
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestSecurePath_SymlinksAndURIs(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("symlinks need privileges on Windows")
+	}
+	base := t.TempDir()
+	real := filepath.Join(base, "real")
+	outside := filepath.Join(base, "outside")
+	for _, d := range []string{filepath.Join(real, "pkg"), outside} {
+		if err := os.MkdirAll(d, 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ws := filepath.Join(base, "ws")
+	links := map[string]string{
+		ws:                               real,
+		filepath.Join(real, "inner"):     filepath.Join(real, "pkg"),
+		filepath.Join(real, "escape"):    outside,
+		filepath.Join(real, "dangling"):  filepath.Join(outside, "new.txt"),
+		filepath.Join(real, "relinside"): "pkg",
+	}
+	for link, target := range links {
+		if err := os.Symlink(target, link); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ok := map[string]string{
+		"pkg/a.go":                         filepath.Join(ws, "pkg", "a.go"),
+		"inner/a.go":                       filepath.Join(ws, "inner", "a.go"),
+		"relinside/new/b.go":               filepath.Join(ws, "relinside", "new", "b.go"),
+		filepath.Join(real, "pkg", "a.go"): filepath.Join(ws, "pkg", "a.go"),
+		"file://" + filepath.Join(ws, "pkg/a.go"):   filepath.Join(ws, "pkg", "a.go"),
+		"file://localhost" + filepath.Join(ws, "x"): filepath.Join(ws, "x"),
+		".": ws,
+	}
+	for in, want := range ok {
+		got, err := SecurePath(ws, in)
+		if err != nil || got != want {
+			t.Errorf("SecurePath(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+
+	for _, in := range []string{"../outside/x", filepath.Join(outside, "x"), "escape/x", "dangling", "file://other" + filepath.Join(ws, "x"), ws + "-other/x"} {
+		if got, err := SecurePath(ws, in); err == nil {
+			t.Errorf("SecurePath(%q) = %q, want an error", in, got)
+		}
+	}
+	if _, err := SecurePath(ws, "../x"); !errors.Is(err, ErrOutsideWorkspace) {
+		t.Errorf("expected ErrOutsideWorkspace, got %v", err)
+	}
+}
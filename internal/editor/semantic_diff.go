@@ -0,0 +1,399 @@
+package editor
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/sergi/go-diff/diffmatchpatch"
+
+	"github.com/loom/loom/internal/textutil"
+)
+
+// DiffMode selects how changed lines are analyzed.
+type DiffMode string
+
+const (
+	// DiffModeLine reports whole-line additions, removals, and modifications.
+	DiffModeLine DiffMode = "line"
+	// DiffModeWord additionally reports which words changed within a modified line.
+	DiffModeWord DiffMode = "word"
+	// DiffModeSyntax tokenizes code and classifies changes such as renames,
+	// literal edits, and comment-only edits.
+	DiffModeSyntax DiffMode = "syntax"
+)
+
+// DefaultDiffMode returns the mode set by LOOM_DIFF_MODE, or syntax-aware analysis.
+func DefaultDiffMode() DiffMode {
+	switch m := DiffMode(strings.ToLower(os.Getenv("LOOM_DIFF_MODE"))); m {
+	case DiffModeLine, DiffModeWord, DiffModeSyntax:
+		return m
+	}
+	return DiffModeSyntax
+}
+
+// Change kinds reported in LineDiffEntry.Kind.
+const (
+	ChangeAdded    = "added"
+	ChangeRemoved  = "removed"
+	ChangeModified = "modified"
+)
+
+// WordChange is one segment of an intra-line diff.
+type WordChange struct {
+	Op   string `json:"op"` // "equal", "insert", or "delete"
+	Text string `json:"text"`
+}
+
+// LineDiffEntry describes a single changed line. Modified lines pair an old line
+// with the new line that replaced it; line numbers are 1-indexed and zero when absent.
+type LineDiffEntry struct {
+	Kind    string       `json:"kind"`
+	OldLine int          `json:"old_line,omitempty"`
+	NewLine int          `json:"new_line,omitempty"`
+	Old     string       `json:"old,omitempty"`
+	New     string       `json:"new,omitempty"`
+	Words   []WordChange `json:"words,omitempty"`
+	Summary string       `json:"summary,omitempty"`
+}
+
+// LineAnalyzer inspects a modified line pair and returns intra-line changes and a
+// short description. path is provided so analyzers can special-case languages.
+type LineAnalyzer func(oldLine, newLine, path string) ([]WordChange, string)
+
+var (
+	analyzersMu sync.RWMutex
+	analyzers   = map[DiffMode]LineAnalyzer{
+		DiffModeWord:   analyzeWords,
+		DiffModeSyntax: analyzeSyntax,
+	}
+)
+
+// RegisterLineAnalyzer installs or replaces the analyzer used for a diff mode,
+// e.g. a parser-backed implementation for a specific language.
+func RegisterLineAnalyzer(mode DiffMode, fn LineAnalyzer) {
+	analyzersMu.Lock()
+	defer analyzersMu.Unlock()
+	analyzers[mode] = fn
+}
+
+// AnalyzeContentChanges compares two versions of a file and returns one entry per
+// changed line. Deleted lines directly followed by inserted lines are paired up as
+// modifications and passed to the analyzer for mode.
+func AnalyzeContentChanges(oldContent, newContent, path string, mode DiffMode) []LineDiffEntry {
+	dmp := diffmatchpatch.New()
+	a, b, lines := dmp.DiffLinesToChars(oldContent, newContent)
+	diffs := dmp.DiffCharsToLines(dmp.DiffMain(a, b, false), lines)
+
+	analyzersMu.RLock()
+	analyze := analyzers[mode]
+	analyzersMu.RUnlock()
+
+	var entries []LineDiffEntry
+	oldNum, newNum := 1, 1
+	for i := 0; i < len(diffs); i++ {
+		d := diffs[i]
+		switch d.Type {
+		case diffmatchpatch.DiffEqual:
+			n := len(splitDiffLines(d.Text))
+			oldNum += n
+			newNum += n
+		case diffmatchpatch.DiffInsert:
+			for _, l := range splitDiffLines(d.Text) {
+				entries = append(entries, LineDiffEntry{Kind: ChangeAdded, NewLine: newNum, New: l})
+				newNum++
+			}
+		case diffmatchpatch.DiffDelete:
+			removed := splitDiffLines(d.Text)
+			var added []string
+			if i+1 < len(diffs) && diffs[i+1].Type == diffmatchpatch.DiffInsert {
+				added = splitDiffLines(diffs[i+1].Text)
+				i++
+			}
+			for j := 0; j < len(removed) || j < len(added); j++ {
+				switch {
+				case j < len(removed) && j < len(added):
+					e := LineDiffEntry{Kind: ChangeModified, OldLine: oldNum, NewLine: newNum, Old: removed[j], New: added[j]}
+					if analyze != nil {
+						e.Words, e.Summary = analyze(removed[j], added[j], path)
+					}
+					entries = append(entries, e)
+					oldNum++
+					newNum++
+				case j < len(removed):
+					entries = append(entries, LineDiffEntry{Kind: ChangeRemoved, OldLine: oldNum, Old: removed[j]})
+					oldNum++
+				default:
+					entries = append(entries, LineDiffEntry{Kind: ChangeAdded, NewLine: newNum, New: added[j]})
+					newNum++
+				}
+			}
+		}
+	}
+	return entries
+}
+
+// splitDiffLines splits a diff chunk into lines, dropping the trailing newline.
+func splitDiffLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
+
+// FormatChangeSummary renders entries as a compact list for the model, capped at max lines.
+func FormatChangeSummary(entries []LineDiffEntry, max int) string {
+	if len(entries) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	for i, e := range entries {
+		if max > 0 && i == max {
+			fmt.Fprintf(&b, "- ... %d more change(s)\n", len(entries)-max)
+			break
+		}
+		switch e.Kind {
+		case ChangeAdded:
+			fmt.Fprintf(&b, "- line %d added\n", e.NewLine)
+		case ChangeRemoved:
+			fmt.Fprintf(&b, "- line %d removed\n", e.OldLine)
+		default:
+			summary := e.Summary
+			if summary == "" {
+				summary = "changed"
+			}
+			fmt.Fprintf(&b, "- line %d: %s\n", e.NewLine, summary)
+		}
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// wordPattern splits a line into words and the whitespace between them.
+var wordPattern = regexp.MustCompile(`\s+|\S+`)
+
+// analyzeWords diffs a line pair word by word.
+func analyzeWords(oldLine, newLine, _ string) ([]WordChange, string) {
+	changes := diffTokens(wordPattern.FindAllString(oldLine, -1), wordPattern.FindAllString(newLine, -1))
+	// A replaced word shows up as a delete and an insert, so count the larger side
+	removed, added := 0, 0
+	for _, c := range changes {
+		n := len(strings.Fields(c.Text))
+		switch c.Op {
+		case "delete":
+			removed += n
+		case "insert":
+			added += n
+		}
+	}
+	n := removed
+	if added > n {
+		n = added
+	}
+	if n == 0 {
+		return changes, "whitespace only"
+	}
+	return changes, fmt.Sprintf("%d word(s) changed", n)
+}
+
+// Token classes used by the syntax-aware analyzer.
+const (
+	tokSpace = iota
+	tokIdent
+	tokNumber
+	tokString
+	tokComment
+	tokPunct
+)
+
+// codeToken matches, in order: whitespace, line comments, string literals,
+// identifiers, numbers, and any other single character.
+var codeToken = regexp.MustCompile("\\s+|//.*|#.*|--.*|\"(?:\\\\.|[^\"\\\\])*\"|'(?:\\\\.|[^'\\\\])*'|`[^`]*`|[A-Za-z_$][A-Za-z0-9_$]*|[0-9][0-9a-fA-FxX._]*|.")
+
+func tokenClass(tok, path string) int {
+	switch c := tok[0]; {
+	case c == ' ' || c == '\t' || c == '\r':
+		return tokSpace
+	case strings.HasPrefix(tok, "//"):
+		return tokComment
+	case c == '#' && usesHashComments(path), strings.HasPrefix(tok, "--") && usesDashComments(path):
+		return tokComment
+	case c == '"' || c == '\'' || c == '`':
+		return tokString
+	case c >= '0' && c <= '9':
+		return tokNumber
+	case c == '_' || c == '$' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z':
+		return tokIdent
+	}
+	return tokPunct
+}
+
+func usesHashComments(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".py", ".rb", ".sh", ".bash", ".zsh", ".yaml", ".yml", ".toml", ".pl", ".r":
+		return true
+	}
+	return false
+}
+
+func usesDashComments(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".sql", ".lua", ".hs":
+		return true
+	}
+	return false
+}
+
+// tokenizeCode splits a line into code tokens. Comment tokens for languages that
+// don't use the prefix are re-split so "#" or "--" remain ordinary punctuation.
+func tokenizeCode(line, path string) []string {
+	var out []string
+	for _, t := range codeToken.FindAllString(line, -1) {
+		if (t[0] == '#' || strings.HasPrefix(t, "--")) && len(t) > 1 && tokenClass(t, path) != tokComment {
+			out = append(out, t[:1])
+			out = append(out, tokenizeCode(t[1:], path)...)
+			continue
+		}
+		out = append(out, t)
+	}
+	return out
+}
+
+// signaturePattern recognises function declarations across common languages.
+var signaturePattern = regexp.MustCompile(`^\s*(?:func|def|function|fn|async\s+def|(?:public|private|protected|static|export)\b.*\()`)
+
+// analyzeSyntax classifies a modified line pair using code tokens. It recognises
+// renames, literal and value edits, comment-only edits, and insertions or removals
+// of individual tokens; anything else is reported as a modified statement.
+func analyzeSyntax(oldLine, newLine, path string) ([]WordChange, string) {
+	oldToks, newToks := tokenizeCode(oldLine, path), tokenizeCode(newLine, path)
+	changes := diffTokens(oldToks, newToks)
+
+	var removed, added []string
+	for _, c := range changes {
+		if c.Op == "equal" {
+			continue
+		}
+		// Segments merge adjacent tokens, so split them again for classification
+		for _, t := range tokenizeCode(c.Text, path) {
+			if tokenClass(t, path) == tokSpace {
+				continue
+			}
+			if c.Op == "delete" {
+				removed = append(removed, t)
+			} else {
+				added = append(added, t)
+			}
+		}
+	}
+
+	switch {
+	case len(removed) == 0 && len(added) == 0:
+		return changes, "whitespace only"
+	case allClass(removed, tokComment, path) && allClass(added, tokComment, path):
+		return changes, "comment changed"
+	case len(removed) == 1 && len(added) == 1:
+		o, n := removed[0], added[0]
+		oc, nc := tokenClass(o, path), tokenClass(n, path)
+		switch {
+		case oc == tokIdent && nc == tokIdent:
+			if signaturePattern.MatchString(newLine) && insideParens(newToks, n) {
+				return changes, fmt.Sprintf("renamed parameter %s → %s", o, n)
+			}
+			return changes, fmt.Sprintf("renamed %s → %s", o, n)
+		case oc == tokString && nc == tokString:
+			return changes, "changed string literal"
+		case oc == tokNumber && nc == tokNumber:
+			return changes, fmt.Sprintf("changed value %s → %s", o, n)
+		case oc == tokPunct && nc == tokPunct:
+			return changes, fmt.Sprintf("changed operator %s → %s", o, n)
+		}
+	case len(removed) == 0:
+		return changes, fmt.Sprintf("inserted %s", quoteTokens(added))
+	case len(added) == 0:
+		return changes, fmt.Sprintf("removed %s", quoteTokens(removed))
+	}
+	return changes, "modified statement"
+}
+
+func allClass(toks []string, class int, path string) bool {
+	for _, t := range toks {
+		if tokenClass(t, path) != class {
+			return false
+		}
+	}
+	return true
+}
+
+// insideParens reports whether tok appears within the first parenthesised group.
+func insideParens(toks []string, tok string) bool {
+	depth := 0
+	for _, t := range toks {
+		switch t {
+		case "(":
+			depth++
+		case ")":
+			depth--
+			if depth == 0 {
+				return false
+			}
+		case tok:
+			if depth > 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func quoteTokens(toks []string) string {
+	s := strings.Join(toks, " ")
+	s = textutil.TruncateWidth(s, 41)
+	return "`" + s + "`"
+}
+
+// diffTokens computes a token-level diff by mapping each distinct token to a rune
+// and running diffmatchpatch over the resulting strings.
+func diffTokens(oldToks, newToks []string) []WordChange {
+	index := map[string]rune{}
+	var table []string
+	encode := func(toks []string) string {
+		rs := make([]rune, len(toks))
+		for i, t := range toks {
+			r, ok := index[t]
+			if !ok {
+				// Start in a private-use area to stay clear of surrogates
+				r = rune(0xE000 + len(table))
+				index[t] = r
+				table = append(table, t)
+			}
+			rs[i] = r
+		}
+		return string(rs)
+	}
+	a, b := encode(oldToks), encode(newToks)
+
+	dmp := diffmatchpatch.New()
+	var out []WordChange
+	for _, d := range dmp.DiffMain(a, b, false) {
+		op := "equal"
+		switch d.Type {
+		case diffmatchpatch.DiffInsert:
+			op = "insert"
+		case diffmatchpatch.DiffDelete:
+			op = "delete"
+		}
+		var text strings.Builder
+		for _, r := range d.Text {
+			text.WriteString(table[r-0xE000])
+		}
+		if n := len(out); n > 0 && out[n-1].Op == op {
+			out[n-1].Text += text.String()
+			continue
+		}
+		out = append(out, WordChange{Op: op, Text: text.String()})
+	}
+	return out
+}
@@ -0,0 +1,81 @@
+package editor
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAnalyzeContentChanges_Syntax(t *testing.T) {
+	oldContent := "package main\n\nfunc greet(name string) string {\n\treturn \"hi \" + name\n}\n\nconst retries = 3\n"
+	newContent := "package main\n\nfunc greet(user string) string {\n\treturn \"hello \" + name\n}\n\nconst retries = 5\n// done\n"
+
+	entries := AnalyzeContentChanges(oldContent, newContent, "main.go", DiffModeSyntax)
+	want := map[int]string{
+		3: "renamed parameter name → user",
+		4: "changed string literal",
+		7: "changed value 3 → 5",
+	}
+	for _, e := range entries {
+		if e.Kind != ChangeModified {
+			continue
+		}
+		if w, ok := want[e.NewLine]; !ok || e.Summary != w {
+			t.Errorf("line %d: got %q, want %q", e.NewLine, e.Summary, w)
+		}
+		delete(want, e.NewLine)
+	}
+	if len(want) > 0 {
+		t.Errorf("missing entries: %v", want)
+	}
+	last := entries[len(entries)-1]
+	if last.Kind != ChangeAdded || last.NewLine != 8 {
+		t.Errorf("expected line 8 added, got %+v", last)
+	}
+}
+
+func TestAnalyzeContentChanges_Modes(t *testing.T) {
+	oldContent := "alpha beta gamma\n"
+	newContent := "alpha delta gamma\n"
+
+	line := AnalyzeContentChanges(oldContent, newContent, "notes.txt", DiffModeLine)
+	if len(line) != 1 || line[0].Summary != "" || line[0].Words != nil {
+		t.Fatalf("line mode should not annotate words: %+v", line)
+	}
+
+	word := AnalyzeContentChanges(oldContent, newContent, "notes.txt", DiffModeWord)
+	if len(word) != 1 || word[0].Summary != "1 word(s) changed" {
+		t.Fatalf("unexpected word entries: %+v", word)
+	}
+	var ops []string
+	for _, w := range word[0].Words {
+		ops = append(ops, w.Op+":"+strings.TrimSpace(w.Text))
+	}
+	if got := strings.Join(ops, ","); got != "equal:alpha,delete:beta,insert:delta,equal:gamma" {
+		t.Errorf("unexpected word ops %s", got)
+	}
+}
+
+func TestAnalyzeSyntax_CommentsAndWhitespace(t *testing.T) {
+	if _, s := analyzeSyntax("x = 1  # old", "x = 1  # new", "a.py"); s != "comment changed" {
+		t.Errorf("got %q", s)
+	}
+	if _, s := analyzeSyntax("x=1", "x = 1", "a.py"); s != "whitespace only" {
+		t.Errorf("got %q", s)
+	}
+	if _, s := analyzeSyntax("if a < b {", "if a <= b {", "a.go"); s != "inserted `=`" {
+		t.Errorf("got %q", s)
+	}
+}
+
+func TestFormatChangeSummary(t *testing.T) {
+	entries := []LineDiffEntry{
+		{Kind: ChangeModified, NewLine: 2, Summary: "renamed a → b"},
+		{Kind: ChangeAdded, NewLine: 3},
+		{Kind: ChangeRemoved, OldLine: 9},
+	}
+	got := FormatChangeSummary(entries, 2)
+	want := "- line 2: renamed a → b\n- line 3 added\n- ... 1 more change(s)"
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,350 @@
+package editor
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultTrashDays is how long files deleted by the agent stay restorable.
+const DefaultTrashDays = 14
+
+// trashEntryFile holds the metadata of a trash entry, next to the trashed item.
+const trashEntryFile = "entry.json"
+
+// ErrTrashTooLarge is returned by CopyToTrash when a path is larger than the copy limit.
+var ErrTrashTooLarge = errors.New("too large to keep a copy in the trash")
+
+// trashSeq keeps ids of entries trashed within the same instant apart.
+var trashSeq atomic.Uint64
+
+// TrashEntry describes a file or directory moved to the workspace trash.
+type TrashEntry struct {
+	ID string `json:"id"`
+	// Path is where the item was, relative to the workspace
+	Path      string    `json:"path"`
+	Dir       bool      `json:"dir,omitempty"`
+	Size      int64     `json:"size"`
+	DeletedAt time.Time `json:"deleted_at"`
+	// Reason names what deleted it, e.g. "delete_file" or "apply_shell: rm -rf build"
+	Reason string `json:"reason,omitempty"`
+}
+
+// TrashDir returns where files deleted by the agent are kept until they are purged.
+func TrashDir(workspace string) string {
+	return filepath.Join(workspace, ".loom", "trash")
+}
+
+// TrashRetentionFromEnv returns how long trash entries are kept: LOOM_TRASH_DAYS days,
+// or DefaultTrashDays. Zero or a negative value keeps them until they are purged by hand.
+func TrashRetentionFromEnv() time.Duration {
+	days := DefaultTrashDays
+	if v := os.Getenv("LOOM_TRASH_DAYS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			days = n
+		}
+	}
+	if days <= 0 {
+		return 0
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// MoveToTrash moves path out of the workspace's working tree into its trash, from
+// where RestoreFromTrash puts it back. Paths outside the workspace, the workspace
+// itself and the .loom directory cannot be trashed.
+func MoveToTrash(workspace, path, reason string) (TrashEntry, error) {
+	abs, entry, dir, err := prepareTrash(workspace, path, reason)
+	if err != nil {
+		return TrashEntry{}, err
+	}
+	dest := filepath.Join(dir, filepath.Base(abs))
+	if err := os.Rename(abs, dest); err != nil {
+		// A workspace spanning devices cannot rename into .loom; copy instead
+		if err := copyTree(abs, dest); err != nil {
+			_ = os.RemoveAll(dir)
+			return TrashEntry{}, fmt.Errorf("failed to move %s to the trash: %w", entry.Path, err)
+		}
+		if err := os.RemoveAll(abs); err != nil {
+			_ = os.RemoveAll(dir)
+			return TrashEntry{}, fmt.Errorf("failed to delete %s: %w", entry.Path, err)
+		}
+	}
+	if err := writeTrashEntry(dir, entry); err != nil {
+		return TrashEntry{}, err
+	}
+	return entry, nil
+}
+
+// CopyToTrash keeps a copy of path in the trash without touching the original, for
+// deletions the agent is about to make by other means, such as a shell command. Paths
+// larger than maxBytes (when positive) are refused with ErrTrashTooLarge. A copy whose
+// original survives should be dropped with DiscardTrash.
+func CopyToTrash(workspace, path, reason string, maxBytes int64) (TrashEntry, error) {
+	abs, err := trashablePath(workspace, path)
+	if err != nil {
+		return TrashEntry{}, err
+	}
+	if maxBytes > 0 {
+		if size, _ := treeSize(abs, maxBytes); size > maxBytes {
+			return TrashEntry{}, fmt.Errorf("%s: %w", path, ErrTrashTooLarge)
+		}
+	}
+	abs, entry, dir, err := prepareTrash(workspace, path, reason)
+	if err != nil {
+		return TrashEntry{}, err
+	}
+	if err := copyTree(abs, filepath.Join(dir, filepath.Base(abs))); err != nil {
+		_ = os.RemoveAll(dir)
+		return TrashEntry{}, fmt.Errorf("failed to copy %s to the trash: %w", entry.Path, err)
+	}
+	if err := writeTrashEntry(dir, entry); err != nil {
+		return TrashEntry{}, err
+	}
+	return entry, nil
+}
+
+// ListTrash returns the entries in the workspace trash, most recently deleted first.
+func ListTrash(workspace string) ([]TrashEntry, error) {
+	dirs, err := os.ReadDir(TrashDir(workspace))
+	if os.IsNotExist(err) {
+		return []TrashEntry{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	entries := []TrashEntry{}
+	for _, d := range dirs {
+		if !d.IsDir() {
+			continue
+		}
+		if e, err := readTrashEntry(workspace, d.Name()); err == nil {
+			entries = append(entries, e)
+		}
+	}
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].DeletedAt.After(entries[j].DeletedAt) })
+	return entries, nil
+}
+
+// RestoreFromTrash moves a trashed item back to where it was. It refuses to replace
+// anything that has since been created at that path.
+func RestoreFromTrash(workspace, id string) (TrashEntry, error) {
+	entry, err := readTrashEntry(workspace, id)
+	if err != nil {
+		return TrashEntry{}, err
+	}
+	target := filepath.Join(workspace, filepath.FromSlash(entry.Path))
+	if _, err := os.Lstat(target); err == nil {
+		return TrashEntry{}, fmt.Errorf("%s exists again; move it away before restoring", entry.Path)
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return TrashEntry{}, err
+	}
+	dir := filepath.Join(TrashDir(workspace), id)
+	src := filepath.Join(dir, filepath.Base(target))
+	if err := os.Rename(src, target); err != nil {
+		if err := copyTree(src, target); err != nil {
+			return TrashEntry{}, fmt.Errorf("failed to restore %s: %w", entry.Path, err)
+		}
+	}
+	_ = os.RemoveAll(dir)
+	return entry, nil
+}
+
+// DiscardTrash permanently deletes one trash entry.
+func DiscardTrash(workspace, id string) error {
+	if _, err := readTrashEntry(workspace, id); err != nil {
+		return err
+	}
+	return os.RemoveAll(filepath.Join(TrashDir(workspace), id))
+}
+
+// PurgeTrash permanently deletes entries trashed more than maxAge ago, or every entry
+// when maxAge is negative, and returns how many it removed. A zero maxAge keeps all.
+func PurgeTrash(workspace string, maxAge time.Duration) (int, error) {
+	if maxAge == 0 {
+		return 0, nil
+	}
+	entries, err := ListTrash(workspace)
+	if err != nil {
+		return 0, err
+	}
+	now := time.Now()
+	removed := 0
+	for _, e := range entries {
+		if maxAge > 0 && now.Sub(e.DeletedAt) <= maxAge {
+			continue
+		}
+		if err := os.RemoveAll(filepath.Join(TrashDir(workspace), e.ID)); err != nil {
+			return removed, err
+		}
+		removed++
+	}
+	return removed, nil
+}
+
+// trashablePath resolves path against the workspace and checks it may be trashed.
+func trashablePath(workspace, path string) (string, error) {
+	root, err := filepath.Abs(workspace)
+	if err != nil {
+		return "", err
+	}
+	abs := path
+	if !filepath.IsAbs(abs) {
+		abs = filepath.Join(root, path)
+	}
+	abs = filepath.Clean(abs)
+	rel, err := filepath.Rel(root, abs)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%s is not inside the workspace", path)
+	}
+	if rel == ".loom" || strings.HasPrefix(rel, ".loom"+string(filepath.Separator)) {
+		return "", fmt.Errorf("%s is Loom's own state and cannot be trashed", path)
+	}
+	if _, err := os.Lstat(abs); err != nil {
+		return "", err
+	}
+	return abs, nil
+}
+
+// prepareTrash creates the directory of a new trash entry for path and describes it.
+func prepareTrash(workspace, path, reason string) (string, TrashEntry, string, error) {
+	abs, err := trashablePath(workspace, path)
+	if err != nil {
+		return "", TrashEntry{}, "", err
+	}
+	info, err := os.Lstat(abs)
+	if err != nil {
+		return "", TrashEntry{}, "", err
+	}
+	root, _ := filepath.Abs(workspace)
+	rel, _ := filepath.Rel(root, abs)
+	size, _ := treeSize(abs, 0)
+	now := time.Now()
+	entry := TrashEntry{
+		ID:        fmt.Sprintf("%s-%d", now.Format("20060102-150405.000000"), trashSeq.Add(1)),
+		Path:      filepath.ToSlash(rel),
+		Dir:       info.IsDir(),
+		Size:      size,
+		DeletedAt: now,
+		Reason:    reason,
+	}
+	trash := TrashDir(root)
+	if err := os.MkdirAll(trash, 0o755); err != nil {
+		return "", TrashEntry{}, "", err
+	}
+	// Keep the trash out of version control
+	ignore := filepath.Join(trash, ".gitignore")
+	if _, err := os.Stat(ignore); os.IsNotExist(err) {
+		_ = os.WriteFile(ignore, []byte("*\n"), 0o644)
+	}
+	dir := filepath.Join(trash, entry.ID)
+	if err := os.Mkdir(dir, 0o755); err != nil {
+		return "", TrashEntry{}, "", err
+	}
+	return abs, entry, dir, nil
+}
+
+func writeTrashEntry(dir string, entry TrashEntry) error {
+	data, err := json.MarshalIndent(entry, "", "  ")
+	if err != nil {
+		return err
+	}
+	return WriteFileAtomic(filepath.Join(dir, trashEntryFile), data, false)
+}
+
+func readTrashEntry(workspace, id string) (TrashEntry, error) {
+	if id == "" || id != filepath.Base(id) || strings.HasPrefix(id, ".") {
+		return TrashEntry{}, fmt.Errorf("invalid trash id %q", id)
+	}
+	data, err := os.ReadFile(filepath.Join(TrashDir(workspace), id, trashEntryFile))
+	if os.IsNotExist(err) {
+		return TrashEntry{}, fmt.Errorf("no trash entry %q", id)
+	}
+	if err != nil {
+		return TrashEntry{}, err
+	}
+	var entry TrashEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return TrashEntry{}, fmt.Errorf("trash entry %q is corrupt: %w", id, err)
+	}
+	entry.ID = id
+	return entry, nil
+}
+
+// treeSize returns the total size of the regular files at or below path. With a
+// positive limit the walk stops as soon as the total exceeds it, so checking a huge
+// tree against a cap costs no more than reading limit bytes of metadata.
+func treeSize(path string, limit int64) (int64, error) {
+	var size int64
+	err := filepath.WalkDir(path, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.Type().IsRegular() {
+			if info, err := d.Info(); err == nil {
+				size += info.Size()
+			}
+		}
+		if limit > 0 && size > limit {
+			return fs.SkipAll
+		}
+		return nil
+	})
+	return size, err
+}
+
+// copyTree copies a file, symlink or directory to dest, keeping permissions.
+func copyTree(src, dest string) error {
+	return filepath.WalkDir(src, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(src, p)
+		target := filepath.Join(dest, rel)
+		info, err := os.Lstat(p)
+		if err != nil {
+			return err
+		}
+		switch {
+		case d.IsDir():
+			return os.MkdirAll(target, info.Mode().Perm())
+		case info.Mode()&fs.ModeSymlink != 0:
+			link, err := os.Readlink(p)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(link, target)
+		case info.Mode().IsRegular():
+			return copyFile(p, target, info.Mode().Perm())
+		}
+		// Sockets, devices and pipes are not kept
+		return nil
+	})
+}
+
+func copyFile(src, dest string, perm fs.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = in.Close() }()
+	out, err := os.OpenFile(dest, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		_ = out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,113 @@
+package editor
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestTrash_MoveRestoreAndPurge(t *testing.T) {
+	ws := t.TempDir()
+	path := filepath.Join(ws, "pkg", "old.go")
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte("package pkg\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	entry, err := MoveToTrash(ws, "pkg/old.go", "delete_file")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("file must be gone from the working tree: %v", err)
+	}
+	if entry.Path != "pkg/old.go" || entry.Size != 12 || entry.Reason != "delete_file" {
+		t.Fatalf("unexpected entry: %+v", entry)
+	}
+	if data, _ := os.ReadFile(filepath.Join(TrashDir(ws), ".gitignore")); string(data) != "*\n" {
+		t.Errorf("trash must be ignored by git, got %q", data)
+	}
+	if list, _ := ListTrash(ws); len(list) != 1 || list[0].ID != entry.ID {
+		t.Fatalf("unexpected listing: %+v", list)
+	}
+
+	// Restoring never replaces a file created since
+	if err := os.WriteFile(path, []byte("new"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := RestoreFromTrash(ws, entry.ID); err == nil {
+		t.Fatal("expected restore over an existing file to fail")
+	}
+	_ = os.Remove(path)
+	if _, err := RestoreFromTrash(ws, entry.ID); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "package pkg\n" {
+		t.Fatalf("unexpected restored content: %q", data)
+	}
+	if list, _ := ListTrash(ws); len(list) != 0 {
+		t.Fatalf("restored entry must leave the trash: %+v", list)
+	}
+
+	if _, err := MoveToTrash(ws, "pkg", "apply_shell: rm -r pkg"); err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := PurgeTrash(ws, time.Hour); n != 0 {
+		t.Fatalf("recent entries must survive a purge, removed %d", n)
+	}
+	if n, _ := PurgeTrash(ws, -1); n != 1 {
+		t.Fatalf("expected a full purge to remove the entry, removed %d", n)
+	}
+
+	for _, bad := range []string{".", "..", ".loom/trash", filepath.Join(os.TempDir(), "x")} {
+		if _, err := MoveToTrash(ws, bad, ""); err == nil {
+			t.Errorf("expected %q to be refused", bad)
+		}
+	}
+}
+
+func TestCopyToTrash_KeepsOriginalAndHonoursLimit(t *testing.T) {
+	ws := t.TempDir()
+	path := filepath.Join(ws, "data.bin")
+	if err := os.WriteFile(path, make([]byte, 64), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := CopyToTrash(ws, "data.bin", "", 32); !errors.Is(err, ErrTrashTooLarge) {
+		t.Fatalf("expected ErrTrashTooLarge, got %v", err)
+	}
+	entry, err := CopyToTrash(ws, "data.bin", "", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Fatalf("original must survive a copy: %v", err)
+	}
+	if err := DiscardTrash(ws, entry.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := DiscardTrash(ws, "../x"); err == nil {
+		t.Fatal("expected an invalid id to be refused")
+	}
+}
+
+func TestApplyEditWithOptions_DeletionMovesToTrash(t *testing.T) {
+	ws := t.TempDir()
+	path := filepath.Join(ws, "gone.txt")
+	if err := os.WriteFile(path, []byte("keep me\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	plan := &EditPlan{FilePath: path, OldContent: "keep me\n", IsDeletion: true}
+	if err := ApplyEditWithOptions(plan, WriteOptions{Workspace: ws}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("file must be deleted: %v", err)
+	}
+	if list, _ := ListTrash(ws); len(list) != 1 || list[0].Path != "gone.txt" {
+		t.Fatalf("deleted file must be in the trash: %+v", list)
+	}
+}
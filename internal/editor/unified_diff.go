@@ -0,0 +1,109 @@
+package editor
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/sergi/go-diff/diffmatchpatch"
+)
+
+// diffOp is one line of a line-level diff: ' ' equal, '-' removed, '+' added.
+type diffOp struct {
+	kind byte
+	text string
+}
+
+// UnifiedDiff returns a git-style unified diff of one file with context lines around
+// each hunk. Missing files are passed as "" with exists set to false, which renders
+// /dev/null headers. It returns "" when the contents are equal.
+func UnifiedDiff(path, oldContent, newContent string, oldExists, newExists bool, context int) string {
+	if oldContent == newContent && oldExists == newExists {
+		return ""
+	}
+	dmp := diffmatchpatch.New()
+	oldChars, newChars, lines := dmp.DiffLinesToChars(oldContent, newContent)
+	diffs := dmp.DiffCharsToLines(dmp.DiffMain(oldChars, newChars, false), lines)
+
+	var ops []diffOp
+	for _, d := range diffs {
+		kind := byte(' ')
+		switch d.Type {
+		case diffmatchpatch.DiffDelete:
+			kind = '-'
+		case diffmatchpatch.DiffInsert:
+			kind = '+'
+		}
+		for _, l := range splitDiffLines(d.Text) {
+			ops = append(ops, diffOp{kind: kind, text: l})
+		}
+	}
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "diff --git a/%s b/%s\n", path, path)
+	switch {
+	case !oldExists:
+		out.WriteString("new file\n--- /dev/null\n")
+		fmt.Fprintf(&out, "+++ b/%s\n", path)
+	case !newExists:
+		out.WriteString("deleted file\n")
+		fmt.Fprintf(&out, "--- a/%s\n+++ /dev/null\n", path)
+	default:
+		fmt.Fprintf(&out, "--- a/%s\n+++ b/%s\n", path, path)
+	}
+
+	// Group changes into hunks, merging those whose context overlaps
+	oldNum, newNum := make([]int, len(ops)+1), make([]int, len(ops)+1)
+	oldNum[0], newNum[0] = 1, 1
+	for i, op := range ops {
+		oldNum[i+1], newNum[i+1] = oldNum[i], newNum[i]
+		if op.kind != '+' {
+			oldNum[i+1]++
+		}
+		if op.kind != '-' {
+			newNum[i+1]++
+		}
+	}
+	for i := 0; i < len(ops); {
+		if ops[i].kind == ' ' {
+			i++
+			continue
+		}
+		start := max(0, i-context)
+		end := i
+		for end < len(ops) {
+			if ops[end].kind != ' ' {
+				end++
+				continue
+			}
+			run := end
+			for run < len(ops) && ops[run].kind == ' ' {
+				run++
+			}
+			if run == len(ops) || run-end > 2*context {
+				end = min(len(ops), end+context)
+				break
+			}
+			end = run
+		}
+		oldCount, newCount := oldNum[end]-oldNum[start], newNum[end]-newNum[start]
+		fmt.Fprintf(&out, "@@ -%s +%s @@\n", hunkRange(oldNum[start], oldCount), hunkRange(newNum[start], newCount))
+		for _, op := range ops[start:end] {
+			out.WriteByte(op.kind)
+			out.WriteString(op.text)
+			out.WriteByte('\n')
+		}
+		i = end
+	}
+	return out.String()
+}
+
+// hunkRange formats a hunk's line range; empty ranges point at the preceding line.
+func hunkRange(start, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", start-1)
+	}
+	if count == 1 {
+		return fmt.Sprintf("%d", start)
+	}
+	return fmt.Sprintf("%d,%d", start, count)
+}
@@ -0,0 +1,41 @@
+package editor
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestUnifiedDiff_Hunks(t *testing.T) {
+	var oldLines []string
+	for i := 1; i <= 20; i++ {
+		oldLines = append(oldLines, fmt.Sprintf("line %d", i))
+	}
+	newLines := append([]string(nil), oldLines...)
+	newLines[1] = "line 2 changed"
+	newLines[17] = "line 18 changed"
+	oldContent := strings.Join(oldLines, "\n") + "\n"
+	newContent := strings.Join(newLines, "\n") + "\n"
+
+	d := UnifiedDiff("f.txt", oldContent, newContent, true, true, 3)
+	for _, want := range []string{
+		"--- a/f.txt\n+++ b/f.txt\n",
+		"@@ -1,5 +1,5 @@\n line 1\n-line 2\n+line 2 changed\n line 3\n",
+		"@@ -15,6 +15,6 @@\n line 15\n",
+		"+line 18 changed\n line 19\n line 20\n",
+	} {
+		if !strings.Contains(d, want) {
+			t.Errorf("diff missing %q:\n%s", want, d)
+		}
+	}
+	if strings.Count(d, "@@ -") != 2 {
+		t.Errorf("expected two hunks:\n%s", d)
+	}
+
+	if d := UnifiedDiff("f.txt", oldContent, oldContent, true, true, 3); d != "" {
+		t.Errorf("expected no diff, got %q", d)
+	}
+	if d := UnifiedDiff("n.txt", "", "a\nb\n", false, true, 3); !strings.Contains(d, "--- /dev/null") || !strings.Contains(d, "@@ -0,0 +1,2 @@\n+a\n+b\n") {
+		t.Errorf("unexpected new-file diff:\n%s", d)
+	}
+}
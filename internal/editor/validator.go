@@ -1,8 +1,8 @@
 package editor
 
 import (
+	"errors"
 	"fmt"
-	"path/filepath"
 	"strings"
 
 	"github.com/sergi/go-diff/diffmatchpatch"
@@ -23,6 +23,24 @@ type EditPlan struct {
 	IsCreation   bool
 	IsDeletion   bool
 	ChangedLines LineRange
+	// Notes carries placement warnings and corrections for newly created files
+	Notes []string
+	// Format is the file's on-disk encoding and line endings; OldContent and
+	// NewContent are always UTF-8 with LF endings
+	Format TextFormat
+}
+
+// NewBytes returns NewContent encoded in the file's format, as ApplyEdit writes it.
+func (p *EditPlan) NewBytes() []byte {
+	return p.Format.Encode(p.NewContent)
+}
+
+// OldBytes returns OldContent encoded in the file's format.
+func (p *EditPlan) OldBytes() []byte {
+	if p.IsCreation {
+		return nil
+	}
+	return p.Format.Encode(p.OldContent)
 }
 
 // ValidationError represents an error during edit validation.
@@ -37,25 +55,16 @@ func (e ValidationError) Error() string {
 
 // validatePath ensures the file path is valid and within the workspace.
 func validatePath(workspacePath string, filePath string) (string, error) {
-	// Convert to absolute path if needed
-	var absPath string
-	if filepath.IsAbs(filePath) {
-		absPath = filePath
-	} else {
-		absPath = filepath.Join(workspacePath, filePath)
-	}
-
-	// Clean the path to remove ../ and ./ segments
-	absPath = filepath.Clean(absPath)
-
-	// Ensure the path is within the workspace
-	if !strings.HasPrefix(absPath, workspacePath) {
+	absPath, err := SecurePath(workspacePath, filePath)
+	if errors.Is(err, ErrOutsideWorkspace) {
 		return "", ValidationError{
 			Message: "File path must be within the workspace",
 			Code:    "PATH_TRAVERSAL",
 		}
 	}
-
+	if err != nil {
+		return "", ValidationError{Message: err.Error(), Code: "PATH_TRAVERSAL"}
+	}
 	return absPath, nil
 }
 
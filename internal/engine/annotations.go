@@ -0,0 +1,60 @@
+package engine
+
+import (
+	"errors"
+	"path/filepath"
+
+	"github.com/loom/loom/internal/memory"
+	"github.com/loom/loom/internal/tool"
+)
+
+// annotationNotifier is implemented by UI bridges that render annotations as they arrive.
+type annotationNotifier interface {
+	EmitAnnotation(a memory.Annotation)
+}
+
+// annotationSink stores annotate_code results in the current conversation.
+func (e *Engine) annotationSink() tool.AnnotationSink {
+	return func(a memory.Annotation) (memory.Annotation, error) {
+		if e.memory == nil {
+			return a, errors.New("memory not initialized")
+		}
+		stored, err := e.memory.AddAnnotation(e.memory.CurrentConversationID(), a)
+		if err != nil {
+			return stored, err
+		}
+		if n, ok := e.bridge.(annotationNotifier); ok {
+			n.EmitAnnotation(stored)
+		}
+		return stored, nil
+	}
+}
+
+// Annotations returns the current conversation's annotations, optionally only those
+// for one workspace-relative path.
+func (e *Engine) Annotations(path string) []memory.Annotation {
+	if e.memory == nil {
+		return nil
+	}
+	all := e.memory.Annotations(e.memory.CurrentConversationID())
+	if path == "" {
+		return all
+	}
+	path = filepath.ToSlash(filepath.Clean(path))
+	out := []memory.Annotation{}
+	for _, a := range all {
+		if a.Path == path {
+			out = append(out, a)
+		}
+	}
+	return out
+}
+
+// RemoveAnnotation deletes an annotation of the current conversation; an empty id
+// clears them all.
+func (e *Engine) RemoveAnnotation(id string) error {
+	if e.memory == nil {
+		return errors.New("memory not initialized")
+	}
+	return e.memory.RemoveAnnotation(e.memory.CurrentConversationID(), id)
+}
@@ -1,9 +1,12 @@
 package engine
 
 import (
+	"context"
 	"fmt"
 	"sync"
 
+	"github.com/loom/loom/internal/memory"
+	"github.com/loom/loom/internal/policy"
 	"github.com/loom/loom/internal/tool"
 )
 
@@ -12,9 +15,16 @@ type ApprovalHandler struct {
 	bridge           UIBridge
 	approvals        map[string]chan bool
 	choices          map[string]chan int
+	questions        map[string]chan questionReply
 	approvalMu       sync.Mutex
 	autoApproveShell bool
 	autoApproveEdits bool
+	// policies are evaluated before the per-tool toggles; the first matching rule wins
+	policies *policy.Set
+	// audit receives every approval decision; nil disables auditing
+	audit *memory.Project
+	// tools tells which tools are irreversible and always ask (see tool.Definition.AlwaysAsk)
+	tools *tool.Registry
 }
 
 // NewApprovalHandler creates a new approval handler.
@@ -23,9 +33,17 @@ func NewApprovalHandler(bridge UIBridge) *ApprovalHandler {
 		bridge:    bridge,
 		approvals: make(map[string]chan bool),
 		choices:   make(map[string]chan int),
+		questions: make(map[string]chan questionReply),
 	}
 }
 
+// Pending returns how many approvals, choices and questions are waiting for the user.
+func (ah *ApprovalHandler) Pending() (approvals, choices, questions int) {
+	ah.approvalMu.Lock()
+	defer ah.approvalMu.Unlock()
+	return len(ah.approvals), len(ah.choices), len(ah.questions)
+}
+
 // SetAutoApprove toggles auto-approval behaviors based on settings.
 func (ah *ApprovalHandler) SetAutoApprove(shell bool, edits bool) {
 	ah.approvalMu.Lock()
@@ -34,6 +52,91 @@ func (ah *ApprovalHandler) SetAutoApprove(shell bool, edits bool) {
 	ah.autoApproveEdits = edits
 }
 
+// SetPolicies installs compiled approval policy rules (nil clears them).
+func (ah *ApprovalHandler) SetPolicies(set *policy.Set) {
+	ah.approvalMu.Lock()
+	defer ah.approvalMu.Unlock()
+	ah.policies = set
+}
+
+// autoApproves reports whether toolCall is approved without prompting, either by a
+// policy rule or by the per-tool toggles. denied is set when a policy rejects the call.
+func (ah *ApprovalHandler) autoApproves(toolCall *tool.ToolCall) (approved bool, denied *policy.Rule) {
+	ah.approvalMu.Lock()
+	set, shell, edits := ah.policies, ah.autoApproveShell, ah.autoApproveEdits
+	ah.approvalMu.Unlock()
+
+	if action, rule, ok := set.Decide(policy.NewCall(toolCall.Name, toolCall.Args)); ok {
+		switch action {
+		case policy.ActionAutoApprove:
+			// Irreversible tools are always reviewed, whatever the policy says
+			return !ah.alwaysAsks(toolCall.Name), nil
+		case policy.ActionDeny:
+			return false, rule
+		case policy.ActionAsk:
+			return false, nil
+		}
+	}
+	if (toolCall.Name == "run_shell" || toolCall.Name == "apply_shell") && shell {
+		return true, nil
+	}
+	if (toolCall.Name == "edit_file" || toolCall.Name == "apply_edit") && edits {
+		return true, nil
+	}
+	return false, nil
+}
+
+// setRegistry sets the registry consulted for tools that always ask.
+func (ah *ApprovalHandler) setRegistry(tools *tool.Registry) {
+	ah.approvalMu.Lock()
+	defer ah.approvalMu.Unlock()
+	ah.tools = tools
+}
+
+// alwaysAsks reports whether the named tool may never be auto-approved by a policy.
+func (ah *ApprovalHandler) alwaysAsks(name string) bool {
+	ah.approvalMu.Lock()
+	tools := ah.tools
+	ah.approvalMu.Unlock()
+	return tools != nil && tools.AlwaysAsks(name)
+}
+
+// policyDenial returns the policy rule that denies toolCall, or nil. Deny rules are
+// checked for every call, including safe tools that never reach an approval prompt.
+func (ah *ApprovalHandler) policyDenial(toolCall *tool.ToolCall) *policy.Rule {
+	if ah == nil {
+		return nil
+	}
+	ah.approvalMu.Lock()
+	set := ah.policies
+	ah.approvalMu.Unlock()
+	if action, rule, ok := set.Decide(policy.NewCall(toolCall.Name, toolCall.Args)); ok && action == policy.ActionDeny {
+		return rule
+	}
+	return nil
+}
+
+// SetAuditLog records approval decisions in the project's audit log.
+func (ah *ApprovalHandler) SetAuditLog(project *memory.Project) {
+	ah.approvalMu.Lock()
+	defer ah.approvalMu.Unlock()
+	ah.audit = project
+}
+
+// recordApproval writes an approval decision to the audit log.
+func (ah *ApprovalHandler) recordApproval(toolCall *tool.ToolCall, approved bool, decidedBy string) {
+	ah.approvalMu.Lock()
+	audit := ah.audit
+	ah.approvalMu.Unlock()
+	_ = audit.RecordAudit("approval", map[string]any{
+		"tool":       toolCall.Name,
+		"call_id":    toolCall.ID,
+		"args":       auditArgs(toolCall.Args),
+		"approved":   approved,
+		"decided_by": decidedBy,
+	})
+}
+
 // SetBridge updates the UI bridge for the approval handler.
 func (ah *ApprovalHandler) SetBridge(bridge UIBridge) {
 	ah.approvalMu.Lock()
@@ -63,15 +166,39 @@ func (ah *ApprovalHandler) ResolveChoice(id string, selectedIndex int) {
 	}
 }
 
+// questionReply is the user's raw reply to an ask_user question.
+type questionReply struct {
+	selectedIndex int
+	text          string
+}
+
+// ResolveQuestion resolves a pending ask_user question with an option index, or -1
+// and a free-text answer. An index of -1 with empty text skips the question.
+func (ah *ApprovalHandler) ResolveQuestion(id string, selectedIndex int, text string) {
+	ah.approvalMu.Lock()
+	defer ah.approvalMu.Unlock()
+
+	if ch, ok := ah.questions[id]; ok {
+		ch <- questionReply{selectedIndex: selectedIndex, text: text}
+		delete(ah.questions, id)
+	}
+}
+
 // UserApproved prompts for approval and waits for the response.
 func (ah *ApprovalHandler) UserApproved(toolCall *tool.ToolCall, diff string) bool {
-	// Auto-approval rules
+	// Auto-approval rules: policies first, then per-tool toggles
 	if toolCall != nil {
-		if (toolCall.Name == "run_shell" || toolCall.Name == "apply_shell") && ah.autoApproveShell {
+		approved, denied := ah.autoApproves(toolCall)
+		if approved {
+			ah.recordApproval(toolCall, true, "auto")
 			return true
 		}
-		if (toolCall.Name == "edit_file" || toolCall.Name == "apply_edit") && ah.autoApproveEdits {
-			return true
+		if denied != nil {
+			ah.recordApproval(toolCall, false, "policy: "+denied.Source)
+			if ah.bridge != nil {
+				ah.bridge.SendChat("system", fmt.Sprintf("Denied %s by approval policy: %s", toolCall.Name, denied.Source))
+			}
+			return false
 		}
 	}
 
@@ -90,6 +217,7 @@ func (ah *ApprovalHandler) UserApproved(toolCall *tool.ToolCall, diff string) bo
 
 	// Wait for response
 	approved := <-responseCh
+	ah.recordApproval(toolCall, approved, "user")
 	return approved
 }
 
@@ -111,6 +239,29 @@ func (ah *ApprovalHandler) UserChoice(toolCall *tool.ToolCall, question string,
 	return selected
 }
 
+// AskUser shows a clarifying question and waits for the answer. The wait ends with a
+// skipped answer when ctx is cancelled (for example when the user stops the run).
+func (ah *ApprovalHandler) AskUser(ctx context.Context, toolCall *tool.ToolCall, args tool.AskUserArgs) tool.AskUserAnswer {
+	// Buffered so a late reply never blocks the resolver
+	responseCh := make(chan questionReply, 1)
+
+	ah.approvalMu.Lock()
+	ah.questions[toolCall.ID] = responseCh
+	ah.approvalMu.Unlock()
+
+	ah.bridge.PromptQuestion(toolCall.ID, args)
+
+	select {
+	case reply := <-responseCh:
+		return args.Answer(reply.selectedIndex, reply.text)
+	case <-ctx.Done():
+		ah.approvalMu.Lock()
+		delete(ah.questions, toolCall.ID)
+		ah.approvalMu.Unlock()
+		return args.Answer(-1, "")
+	}
+}
+
 // IsAutoApproveEnabled returns the current auto-approval settings.
 func (ah *ApprovalHandler) IsAutoApproveEnabled() (shell, edits bool) {
 	ah.approvalMu.Lock()
@@ -0,0 +1,98 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/loom/loom/internal/memory"
+	"github.com/loom/loom/internal/policy"
+	"github.com/loom/loom/internal/tool"
+)
+
+// chatBridge records chat messages; other UIBridge methods are unused.
+type chatBridge struct {
+	UIBridge
+	chats []string
+}
+
+func (b *chatBridge) SendChat(role, text string) { b.chats = append(b.chats, text) }
+
+func TestExecuteToolCall_DenyPolicyStopsSafeTool(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	ran := 0
+	reg := tool.NewRegistry()
+	if err := reg.Register(tool.Definition{
+		Name: "apply_shell",
+		Safe: true,
+		Handler: func(ctx context.Context, raw json.RawMessage) (interface{}, error) {
+			ran++
+			return "ok", nil
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	set, err := policy.Compile([]string{`tool == "apply_shell" && command.startsWith("rm ") -> deny`})
+	if err != nil {
+		t.Fatal(err)
+	}
+	bridge := &chatBridge{}
+	ah := NewApprovalHandler(bridge)
+	ah.SetPolicies(set)
+	te := NewToolExecutor(bridge, reg, ah)
+	store, err := memory.NewStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	proj, err := memory.NewProject(store, t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	convo := memory.NewConversation(proj, "c1")
+
+	call := &tool.ToolCall{ID: "t1", Name: "apply_shell", Args: json.RawMessage(`{"command":"rm -rf build"}`)}
+	if err := te.ExecuteToolCall(context.Background(), call, convo); err != nil {
+		t.Fatal(err)
+	}
+	if ran != 0 {
+		t.Fatalf("denied call ran %d times", ran)
+	}
+	history := convo.History()
+	if len(history) != 1 || !strings.Contains(history[0].Content, "Denied apply_shell by approval policy") {
+		t.Fatalf("expected a denial tool result, got %+v", history)
+	}
+
+	// Calls the rule does not match still run
+	call = &tool.ToolCall{ID: "t2", Name: "apply_shell", Args: json.RawMessage(`{"command":"ls"}`)}
+	if err := te.ExecuteToolCall(context.Background(), call, convo); err != nil {
+		t.Fatal(err)
+	}
+	if ran != 1 {
+		t.Fatalf("expected the allowed call to run once, ran %d times", ran)
+	}
+}
+
+func TestAutoApproves_PolicySkipsIrreversibleTools(t *testing.T) {
+	reg := tool.NewRegistry()
+	for _, def := range []tool.Definition{{Name: "delete_file", AlwaysAsk: true}, {Name: "edit_file"}} {
+		def.Handler = func(ctx context.Context, raw json.RawMessage) (interface{}, error) { return "ok", nil }
+		if err := reg.Register(def); err != nil {
+			t.Fatal(err)
+		}
+	}
+	set, err := policy.Compile([]string{`tool != "" -> auto_approve`})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ah := NewApprovalHandler(&chatBridge{})
+	ah.SetPolicies(set)
+	NewToolExecutor(nil, reg, ah)
+
+	if ok, _ := ah.autoApproves(&tool.ToolCall{Name: "delete_file", Args: json.RawMessage(`{"path":"a"}`)}); ok {
+		t.Error("a broad auto_approve rule must not approve an irreversible tool")
+	}
+	if ok, _ := ah.autoApproves(&tool.ToolCall{Name: "edit_file", Args: json.RawMessage(`{"path":"a"}`)}); !ok {
+		t.Error("expected the rule to approve ordinary tools")
+	}
+}
@@ -0,0 +1,51 @@
+package engine
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/loom/loom/internal/tool"
+)
+
+// questionBridge records ask_user prompts; other UIBridge methods are unused.
+type questionBridge struct {
+	UIBridge
+	asked chan string
+}
+
+func (b *questionBridge) PromptQuestion(actionID string, question tool.AskUserArgs) {
+	b.asked <- actionID
+}
+
+func TestApprovalHandlerAskUser_ReturnsStructuredAnswer(t *testing.T) {
+	bridge := &questionBridge{asked: make(chan string, 1)}
+	ah := NewApprovalHandler(bridge)
+	args := tool.AskUserArgs{Question: "Which database?", Options: []string{"Postgres", "MySQL"}, AllowOther: true}
+
+	go func() {
+		id := <-bridge.asked
+		ah.ResolveQuestion(id, -1, "SQLite")
+	}()
+	ans := ah.AskUser(context.Background(), &tool.ToolCall{ID: "q1", Name: "ask_user"}, args)
+	if ans.Answer != "SQLite" || ans.SelectedIndex != -1 || ans.Skipped {
+		t.Fatalf("unexpected answer %#v", ans)
+	}
+}
+
+func TestApprovalHandlerAskUser_CancelSkips(t *testing.T) {
+	bridge := &questionBridge{asked: make(chan string, 1)}
+	ah := NewApprovalHandler(bridge)
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	ans := ah.AskUser(ctx, &tool.ToolCall{ID: "q2", Name: "ask_user"}, tool.AskUserArgs{Question: "Name?"})
+	if !ans.Skipped {
+		t.Fatalf("expected skipped answer, got %#v", ans)
+	}
+	// A late reply must not block or resurrect the question
+	ah.ResolveQuestion("q2", -1, "late")
+	if len(ah.questions) != 0 {
+		t.Fatalf("pending question not cleaned up")
+	}
+}
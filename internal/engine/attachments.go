@@ -0,0 +1,85 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/loom/loom/internal/tool"
+)
+
+// attachmentExcerptBudget bounds how many estimated tokens of one attachment are inlined
+// into a message; the model reads the rest with read_file.
+const attachmentExcerptBudget = 3000
+
+// AttachmentsDir returns the directory holding files attached to the current conversation.
+func (e *Engine) AttachmentsDir() string {
+	if e.memory == nil {
+		return ""
+	}
+	id := e.memory.CurrentConversationID()
+	if id == "" {
+		id = "current" // matches the id StartConversation assigns
+	}
+	return e.memory.AttachmentsDir(id)
+}
+
+// AttachExternalFiles copies files from anywhere on disk into the current
+// conversation's attachments directory, where read_file can reach them via their
+// "@attachments/" refs. Files that fail are reported together after the rest are copied.
+func (e *Engine) AttachExternalFiles(paths []string) ([]tool.AttachmentInfo, error) {
+	dir := e.AttachmentsDir()
+	if dir == "" {
+		return nil, errors.New("memory not initialized")
+	}
+	var out []tool.AttachmentInfo
+	var failed []string
+	for _, p := range paths {
+		if strings.TrimSpace(p) == "" {
+			continue
+		}
+		a, err := tool.CopyAttachment(dir, p)
+		if err != nil {
+			failed = append(failed, err.Error())
+			continue
+		}
+		out = append(out, a)
+	}
+	if len(failed) > 0 {
+		return out, fmt.Errorf("could not attach: %s", strings.Join(failed, "; "))
+	}
+	return out, nil
+}
+
+// AttachContent stores data as a file attached to the current conversation, for
+// content that does not come from disk such as an MCP resource.
+func (e *Engine) AttachContent(name string, data []byte) (tool.AttachmentInfo, error) {
+	dir := e.AttachmentsDir()
+	if dir == "" {
+		return tool.AttachmentInfo{}, errors.New("memory not initialized")
+	}
+	return tool.WriteAttachment(dir, name, data)
+}
+
+// ListAttachments returns the files attached to the current conversation.
+func (e *Engine) ListAttachments() ([]tool.AttachmentInfo, error) {
+	dir := e.AttachmentsDir()
+	if dir == "" {
+		return nil, nil
+	}
+	return tool.ListAttachments(dir)
+}
+
+// AttachmentExcerpt renders an attached file for inclusion in a message, sized so
+// large logs do not crowd out the rest of the context.
+func (e *Engine) AttachmentExcerpt(ref string) (string, error) {
+	dir := e.AttachmentsDir()
+	if dir == "" {
+		return "", errors.New("memory not initialized")
+	}
+	name := strings.TrimPrefix(ref, tool.AttachmentPrefix)
+	if name == "" || strings.ContainsAny(name, `/\`) {
+		return "", fmt.Errorf("invalid attachment: %s", ref)
+	}
+	return tool.AttachmentExcerpt(dir, name, attachmentExcerptBudget)
+}
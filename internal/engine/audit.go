@@ -0,0 +1,155 @@
+package engine
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/loom/loom/internal/editor"
+	"github.com/loom/loom/internal/memory"
+	"github.com/loom/loom/internal/tool"
+)
+
+// maxAuditArgs bounds how much of a tool call's arguments is stored verbatim in the
+// audit log; the recorded hash always covers the full arguments.
+const maxAuditArgs = 4096
+
+// RecordAudit appends an event to the workspace's audit log. It is a no-op before a
+// workspace is loaded.
+func (e *Engine) RecordAudit(kind string, data any) error {
+	return e.memory.RecordAudit(kind, data)
+}
+
+// AuditLog returns the most recent limit audit events, oldest first.
+func (e *Engine) AuditLog(limit int) ([]memory.AuditEvent, error) {
+	return e.memory.ListAudit(limit)
+}
+
+// VerifyAuditLog checks the audit log's hash chain and returns the number of intact events.
+func (e *Engine) VerifyAuditLog() (int, error) {
+	if e.memory == nil {
+		return 0, errors.New("memory not initialized")
+	}
+	return e.memory.VerifyAudit()
+}
+
+// ExportAuditLog writes the verified audit log to w as JSON lines.
+func (e *Engine) ExportAuditLog(w io.Writer) (int, error) {
+	if e.memory == nil {
+		return 0, errors.New("memory not initialized")
+	}
+	return e.memory.ExportAudit(w)
+}
+
+// auditArgs summarizes tool arguments for the audit log.
+func auditArgs(args json.RawMessage) map[string]any {
+	out := map[string]any{"sha256": sha256Hex(args)}
+	if len(args) <= maxAuditArgs && json.Valid(args) {
+		out["json"] = args
+	} else {
+		out["truncated"] = string(args[:min(len(args), maxAuditArgs)])
+	}
+	return out
+}
+
+// auditSideEffect records tool calls that changed the workspace: applied edits with
+// the resulting file hash, and executed shell commands with their exit code.
+func auditSideEffect(audit *memory.Project, workspace string, call *tool.ToolCall, res *tool.ExecutionResult) {
+	if audit == nil || res == nil {
+		return
+	}
+	ok := !strings.HasPrefix(res.Content, "Error:")
+	switch call.Name {
+	case "apply_edit":
+		var args tool.ApplyEditArgs
+		_ = json.Unmarshal(call.Args, &args)
+		data := map[string]any{"call_id": call.ID, "path": args.Path, "action": args.Action, "ok": ok}
+		if ok && workspace != "" {
+			if content, err := os.ReadFile(filepath.Join(workspace, args.Path)); err == nil {
+				data["file_sha256"] = sha256Hex(content)
+			} else if os.IsNotExist(err) {
+				data["deleted"] = true
+			}
+		}
+		if !ok {
+			data["error"] = res.Content
+		}
+		_ = audit.RecordAudit("edit", data)
+	case "apply_shell":
+		var args tool.ApplyShellArgs
+		_ = json.Unmarshal(call.Args, &args)
+		data := map[string]any{
+			"call_id":       call.ID,
+			"command":       strings.TrimSpace(args.Command + " " + strings.Join(args.Args, " ")),
+			"cwd":           args.Cwd,
+			"ok":            ok,
+			"output_sha256": sha256Hex([]byte(res.Content)),
+		}
+		var sr tool.ShellResult
+		if json.Unmarshal([]byte(res.Content), &sr) == nil {
+			data["exit_code"] = sr.ExitCode
+		}
+		_ = audit.RecordAudit("shell", data)
+	}
+}
+
+// auditTerraformApply records an approved infrastructure apply.
+func auditTerraformApply(audit *memory.Project, call *tool.ToolCall, res *tool.TerraformApplyResult, err error) {
+	if audit == nil {
+		return
+	}
+	data := map[string]any{"call_id": call.ID, "ok": err == nil && res.ExitCode == 0}
+	if err != nil {
+		data["error"] = err.Error()
+	} else {
+		data["plan_id"] = res.PlanID
+		data["module"] = res.Module
+		data["plan_sha256"] = res.Sha256
+		data["counts"] = res.Counts
+		data["exit_code"] = res.ExitCode
+	}
+	_ = audit.RecordAudit("terraform_apply", data)
+}
+
+// auditURLImport records an approved import_url download written to the workspace.
+func auditURLImport(audit *memory.Project, call *tool.ToolCall, res *tool.URLImportResult, err error) {
+	if audit == nil {
+		return
+	}
+	data := map[string]any{"call_id": call.ID, "ok": err == nil}
+	if err != nil {
+		data["error"] = err.Error()
+	} else {
+		data["url"] = res.URL
+		data["path"] = res.Path
+		data["bytes"] = res.Bytes
+		data["file_sha256"] = res.SHA256
+	}
+	_ = audit.RecordAudit("import_url", data)
+}
+
+// auditDelete records an approved delete_file call and the trash entry it created.
+func auditDelete(audit *memory.Project, call *tool.ToolCall, entry *editor.TrashEntry, err error) {
+	if audit == nil {
+		return
+	}
+	data := map[string]any{"call_id": call.ID, "ok": err == nil}
+	if err != nil {
+		data["error"] = err.Error()
+	} else {
+		data["path"] = entry.Path
+		data["trash_id"] = entry.ID
+		data["bytes"] = entry.Size
+	}
+	_ = audit.RecordAudit("delete", data)
+}
+
+func sha256Hex(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
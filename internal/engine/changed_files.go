@@ -0,0 +1,139 @@
+package engine
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/loom/loom/internal/editor"
+	"github.com/loom/loom/internal/memory"
+)
+
+// ChangedFile is one entry of a run's changed-files feed: the file's net change since
+// the run started.
+type ChangedFile struct {
+	Path string `json:"path"`
+	// Kind is "added", "modified" or "deleted"
+	Kind    string `json:"kind"`
+	Added   int    `json:"added"`
+	Removed int    `json:"removed"`
+	// Omitted is set when the file is too large for its line delta to be counted
+	Omitted bool `json:"omitted,omitempty"`
+	// LastTool is the tool call that changed the file most recently
+	LastTool string `json:"last_tool"`
+	// Changes counts the edits the run made to the file
+	Changes int       `json:"changes"`
+	At      time.Time `json:"at"`
+}
+
+// changedFilesNotifier is implemented by UI bridges that show the feed live.
+type changedFilesNotifier interface {
+	EmitChangedFiles(files []ChangedFile)
+}
+
+// changeFeed keeps one entry per file the current run changed, in the order files
+// were first touched, and tells the UI after every change.
+type changeFeed struct {
+	workspace string
+	notify    func([]ChangedFile)
+
+	mu      sync.Mutex
+	entries map[string]*feedEntry
+	order   []string
+}
+
+// feedEntry is a file's state when the run first changed it and its current delta.
+type feedEntry struct {
+	base    []byte
+	existed bool
+	file    ChangedFile
+}
+
+// beginChangeFeed starts the changed-files feed of a run on root and sends the UI an
+// empty feed so it can reset its list.
+func (e *Engine) beginChangeFeed(root string) *changeFeed {
+	f := &changeFeed{workspace: root, entries: map[string]*feedEntry{}}
+	if n, ok := e.bridge.(changedFilesNotifier); ok {
+		f.notify = n.EmitChangedFiles
+	}
+	e.mu.Lock()
+	e.changes = f
+	e.mu.Unlock()
+	f.emit()
+	return f
+}
+
+// ChangedFiles returns the changed-files feed of the current or most recent run.
+func (e *Engine) ChangedFiles() []ChangedFile {
+	e.mu.RLock()
+	f := e.changes
+	e.mu.RUnlock()
+	return f.snapshot()
+}
+
+// observe updates the feed after a tool call changed pc's file.
+func (f *changeFeed) observe(pc *pendingChange) {
+	if f == nil || pc == nil {
+		return
+	}
+	after, err := os.ReadFile(filepath.Join(f.workspace, pc.rel))
+	if err != nil && !os.IsNotExist(err) {
+		return
+	}
+	exists := err == nil
+
+	f.mu.Lock()
+	entry, ok := f.entries[pc.rel]
+	if !ok {
+		entry = &feedEntry{base: pc.before, existed: pc.existed, file: ChangedFile{Path: pc.rel}}
+		f.entries[pc.rel] = entry
+		f.order = append(f.order, pc.rel)
+	}
+	entry.file.Changes++
+	entry.file.LastTool = pc.call.Name
+	entry.file.At = time.Now()
+	switch {
+	case !entry.existed && exists:
+		entry.file.Kind = "added"
+	case entry.existed && !exists:
+		entry.file.Kind = "deleted"
+	case entry.existed && bytes.Equal(after, entry.base):
+		// Changed back to how the run found it
+		entry.file.Kind = ""
+	case entry.existed:
+		entry.file.Kind = "modified"
+	default:
+		entry.file.Kind = ""
+	}
+	entry.file.Omitted = len(entry.base) > memory.MaxCheckpointContent || len(after) > memory.MaxCheckpointContent
+	entry.file.Added, entry.file.Removed = 0, 0
+	if entry.file.Kind != "" && !entry.file.Omitted {
+		entry.file.Added, entry.file.Removed = countDiffLines(editor.UnifiedDiff(pc.rel, string(entry.base), string(after), entry.existed, exists, 0))
+	}
+	f.mu.Unlock()
+	f.emit()
+}
+
+// snapshot returns the files with a net change, in the order they were first changed.
+func (f *changeFeed) snapshot() []ChangedFile {
+	out := []ChangedFile{}
+	if f == nil {
+		return out
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, rel := range f.order {
+		if e := f.entries[rel]; e.file.Kind != "" {
+			out = append(out, e.file)
+		}
+	}
+	return out
+}
+
+func (f *changeFeed) emit() {
+	if f.notify != nil {
+		f.notify(f.snapshot())
+	}
+}
@@ -0,0 +1,55 @@
+package engine
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/loom/loom/internal/tool"
+)
+
+func TestChangeFeed_DeduplicatesAndAccumulates(t *testing.T) {
+	ws := t.TempDir()
+	write := func(rel, content string) {
+		if err := os.WriteFile(filepath.Join(ws, rel), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("main.go", "a\nb\nc\n")
+
+	var events [][]ChangedFile
+	f := &changeFeed{workspace: ws, entries: map[string]*feedEntry{}, notify: func(files []ChangedFile) { events = append(events, files) }}
+	change := func(rel, content, tool_ string) {
+		before, err := os.ReadFile(filepath.Join(ws, rel))
+		pc := &pendingChange{call: &tool.ToolCall{Name: tool_}, rel: rel, before: before, existed: err == nil}
+		if content == "" {
+			_ = os.Remove(filepath.Join(ws, rel))
+		} else {
+			write(rel, content)
+		}
+		f.observe(pc)
+	}
+
+	change("main.go", "a\nB\nc\n", "apply_edit")
+	change("notes.md", "one\ntwo\n", "apply_edit")
+	change("main.go", "a\nB\nc\nd\n", "apply_shell")
+
+	files := events[len(events)-1]
+	if len(files) != 2 || files[0].Path != "main.go" || files[1].Path != "notes.md" {
+		t.Fatalf("expected one entry per file in first-change order, got %+v", files)
+	}
+	m := files[0]
+	if m.Kind != "modified" || m.Added != 2 || m.Removed != 1 || m.Changes != 2 || m.LastTool != "apply_shell" {
+		t.Fatalf("expected the cumulative delta since the run started, got %+v", m)
+	}
+	if n := files[1]; n.Kind != "added" || n.Added != 2 || n.Removed != 0 {
+		t.Fatalf("unexpected new file entry %+v", n)
+	}
+
+	// Files changed back to their original state, or created and removed, drop out
+	change("main.go", "a\nb\nc\n", "apply_edit")
+	change("notes.md", "", "apply_shell")
+	if files := f.snapshot(); len(files) != 0 {
+		t.Fatalf("expected no net changes, got %+v", files)
+	}
+}
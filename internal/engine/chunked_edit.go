@@ -0,0 +1,166 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/loom/loom/internal/textutil"
+	"github.com/loom/loom/internal/tool"
+)
+
+// defaultLocalContextTokens is assumed for local models when LOOM_LOCAL_CONTEXT_TOKENS is unset.
+// Many Ollama models default to an 8k window.
+const defaultLocalContextTokens = 8192
+
+// editChunking keeps individual edits small enough for a model's context window.
+// Small-context models tend to emit one huge edit whose arguments get truncated; this
+// strategy rejects oversized edits up front, asks for a sequence of smaller ones, and
+// returns a verification read after each applied chunk.
+type editChunking struct {
+	maxTokens int // estimated-token budget per edit (content or replacement text)
+	maxLines  int // line budget per edit
+}
+
+// editChunkingFor returns the strategy for the given model label ("provider:model"),
+// or nil when edits do not need chunking. Ollama models are chunked by default;
+// LOOM_CHUNKED_EDITS=1/0 forces it on or off for any provider.
+func editChunkingFor(modelLabel string) *editChunking {
+	enabled := strings.HasPrefix(modelLabel, "ollama:")
+	if v := os.Getenv("LOOM_CHUNKED_EDITS"); v != "" {
+		enabled = v == "1" || strings.EqualFold(v, "true")
+	}
+	if !enabled {
+		return nil
+	}
+	tokens := localContextTokens()
+	// Allow one edit to use about a quarter of the window
+	ec := &editChunking{maxTokens: tokens / 4, maxLines: tokens / 100}
+	if ec.maxLines < 20 {
+		ec.maxLines = 20
+	}
+	return ec
+}
+
+// localContextTokens returns the context window assumed for local models.
+func localContextTokens() int {
+	if v := os.Getenv("LOOM_LOCAL_CONTEXT_TOKENS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			return n
+		}
+	}
+	return defaultLocalContextTokens
+}
+
+// promptSection explains the chunked editing workflow to the model.
+func (ec *editChunking) promptSection() string {
+	return fmt.Sprintf(`
+
+## Chunked Editing (small context window)
+Your context window is small, so large edits get truncated. Keep every edit_file call under %d lines and about %d tokens of new content (roughly %d characters of code, but only about %d of Chinese, Japanese or Korean text).
+- Split large changes into a sequence of small edits; for a new large file, CREATE it with the first part and append the rest with INSERT_AFTER.
+- When replacing several line ranges in one file, work from the bottom of the file upwards so earlier line numbers stay valid.
+- After each applied chunk, check the verification excerpt in the tool result before sending the next chunk.`, ec.maxLines, ec.maxTokens, ec.maxTokens*4, ec.maxTokens*4/5)
+}
+
+// editPayload is the subset of edit_file arguments the strategy inspects.
+type editPayload struct {
+	Path      string `json:"path"`
+	Action    string `json:"action"`
+	Content   string `json:"content"`
+	NewString string `json:"new_string"`
+	Target    string `json:"target"`
+	StartLine int    `json:"start_line"`
+	EndLine   int    `json:"end_line"`
+	Line      int    `json:"line"`
+}
+
+// check returns a rejection message when an edit_file call exceeds the budget or its
+// arguments look truncated; ok is true when the edit may proceed.
+func (ec *editChunking) check(call *tool.ToolCall) (msg string, ok bool) {
+	var p editPayload
+	if err := json.Unmarshal(call.Args, &p); err != nil {
+		return fmt.Sprintf("Edit rejected: the arguments could not be parsed (%v), which usually means they were truncated. Resend the change as smaller edits of at most %d lines each.", err, ec.maxLines), false
+	}
+	text := p.Content
+	if p.NewString != "" {
+		text = p.NewString
+	}
+	// CJK text costs several times more tokens per byte than code, so count tokens
+	tokens := textutil.EstimateTokens(text)
+	lines := strings.Count(text, "\n") + 1
+	if tokens <= ec.maxTokens && lines <= ec.maxLines {
+		return "", true
+	}
+
+	var how string
+	switch strings.ToUpper(p.Action) {
+	case "CREATE":
+		how = fmt.Sprintf("CREATE %s with roughly the first %d lines, then append the rest with INSERT_AFTER on the last line, one chunk per call.", p.Path, ec.maxLines)
+	case "REPLACE":
+		how = fmt.Sprintf("Split lines %d-%d into consecutive ranges and REPLACE them from the bottom range upwards, %d lines at a time.", p.StartLine, p.EndLine, ec.maxLines)
+	default:
+		how = fmt.Sprintf("Split the change into several %s edits of at most %d lines, each anchored on text that exists after the previous chunk is applied.", p.Action, ec.maxLines)
+	}
+	return fmt.Sprintf("Edit rejected: %d lines / ~%d tokens exceeds the per-edit budget for this model (%d lines / %d tokens). %s", lines, tokens, ec.maxLines, ec.maxTokens, how), false
+}
+
+// verificationExcerpt reads back the region touched by an applied edit so the model can
+// confirm the chunk landed correctly before sending the next one.
+func (ec *editChunking) verificationExcerpt(ctx context.Context, tools *tool.Registry, args json.RawMessage) string {
+	var p editPayload
+	if json.Unmarshal(args, &p) != nil || p.Path == "" {
+		return ""
+	}
+	raw, _ := json.Marshal(tool.ReadFileArgs{Path: p.Path})
+	res, err := tools.Invoke(ctx, "read_file", raw)
+	if err != nil {
+		return ""
+	}
+	rf, ok := res.(*tool.ReadFileResult)
+	if !ok {
+		return ""
+	}
+	lines := strings.Split(rf.Content, "\n")
+
+	// Locate the new text; fall back to the line arguments for deletions and line edits
+	start := 0
+	needle := strings.TrimSpace(firstLine(p.Content + p.NewString))
+	if needle != "" {
+		for i, l := range lines {
+			if strings.Contains(l, needle) {
+				start = i
+				break
+			}
+		}
+	} else if p.StartLine > 0 {
+		start = p.StartLine - 1
+	} else if p.Line > 0 {
+		start = p.Line - 1
+	}
+	span := strings.Count(p.Content+p.NewString, "\n") + 1
+	from, to := start-3, start+span+3
+	if from < 0 {
+		from = 0
+	}
+	if to > len(lines) {
+		to = len(lines)
+	}
+	if to-from > ec.maxLines {
+		to = from + ec.maxLines
+	}
+	if from >= to {
+		return ""
+	}
+	return fmt.Sprintf("Verification read of %s (lines %d-%d):\n%s", p.Path, from+1, to, strings.Join(lines[from:to], "\n"))
+}
+
+func firstLine(s string) string {
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		return s[:i]
+	}
+	return s
+}
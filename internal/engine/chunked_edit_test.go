@@ -0,0 +1,69 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/loom/loom/internal/tool"
+)
+
+func TestEditChunkingFor_OllamaOnlyByDefault(t *testing.T) {
+	t.Setenv("LOOM_CHUNKED_EDITS", "")
+	t.Setenv("LOOM_LOCAL_CONTEXT_TOKENS", "4000")
+	if editChunkingFor("openai:gpt-4o") != nil {
+		t.Fatalf("hosted models should not be chunked by default")
+	}
+	ec := editChunkingFor("ollama:llama3.1:8b")
+	if ec == nil || ec.maxTokens != 1000 || ec.maxLines != 40 {
+		t.Fatalf("unexpected strategy: %+v", ec)
+	}
+	t.Setenv("LOOM_CHUNKED_EDITS", "0")
+	if editChunkingFor("ollama:llama3.1:8b") != nil {
+		t.Fatalf("LOOM_CHUNKED_EDITS=0 should disable chunking")
+	}
+}
+
+func TestEditChunking_CheckAndVerify(t *testing.T) {
+	ec := &editChunking{maxTokens: 50, maxLines: 5}
+
+	small, _ := json.Marshal(map[string]string{"path": "a.go", "action": "CREATE", "content": "a\nb"})
+	if _, ok := ec.check(&tool.ToolCall{Name: "edit_file", Args: small}); !ok {
+		t.Fatalf("small edit should pass")
+	}
+	big, _ := json.Marshal(map[string]string{"path": "a.go", "action": "CREATE", "content": strings.Repeat("x\n", 10)})
+	msg, ok := ec.check(&tool.ToolCall{Name: "edit_file", Args: big})
+	if ok || !strings.Contains(msg, "INSERT_AFTER") {
+		t.Fatalf("expected rejection with CREATE guidance, got %q", msg)
+	}
+	// One line of CJK text exceeds a token budget the same number of ASCII bytes fits in
+	ascii, _ := json.Marshal(map[string]string{"path": "a.go", "action": "CREATE", "content": strings.Repeat("x", 150)})
+	if _, ok := ec.check(&tool.ToolCall{Name: "edit_file", Args: ascii}); !ok {
+		t.Fatalf("150 ASCII characters should fit a 50-token budget")
+	}
+	cjk, _ := json.Marshal(map[string]string{"path": "a.go", "action": "CREATE", "content": strings.Repeat("漢", 50)})
+	if msg, ok := ec.check(&tool.ToolCall{Name: "edit_file", Args: cjk}); ok || !strings.Contains(msg, "tokens") {
+		t.Fatalf("expected 50 CJK characters to exceed the budget, got %q", msg)
+	}
+	if msg, ok := ec.check(&tool.ToolCall{Name: "edit_file", Args: json.RawMessage(`{"path":"a.go","content":"trunc`)}); ok || !strings.Contains(msg, "truncated") {
+		t.Fatalf("expected truncation rejection, got %q", msg)
+	}
+
+	workspace := t.TempDir()
+	content := "package a\n\nfunc A() {}\n\nfunc B() {}\n"
+	if err := os.WriteFile(filepath.Join(workspace, "a.go"), []byte(content), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	reg := tool.NewRegistry()
+	if err := tool.RegisterReadFile(reg, workspace); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	args, _ := json.Marshal(map[string]string{"path": "a.go", "action": "SEARCH_REPLACE", "new_string": "func B() {}"})
+	excerpt := ec.verificationExcerpt(context.Background(), reg, args)
+	if !strings.Contains(excerpt, "Verification read of a.go") || !strings.Contains(excerpt, "L5: func B() {}") {
+		t.Fatalf("unexpected excerpt:\n%s", excerpt)
+	}
+}
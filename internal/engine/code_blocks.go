@@ -0,0 +1,129 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/loom/loom/internal/editor"
+	"github.com/loom/loom/internal/memory"
+)
+
+// errDemoMode is returned by workspace writes while the registry is in demo mode.
+var errDemoMode = errors.New("demo mode: the workspace is read-only")
+
+// SaveCodeBlockResult describes a code block written to the workspace.
+type SaveCodeBlockResult struct {
+	Edit  memory.EditRecord `json:"edit"`
+	Diff  string            `json:"diff"`
+	Notes []string          `json:"notes,omitempty"`
+}
+
+// SaveCodeBlock writes fenced code block index from an assistant answer to path
+// (workspace-relative). An empty path uses the block's path hint. The write is
+// recorded in the project's edit history so it can be undone.
+func (e *Engine) SaveCodeBlock(answer string, index int, path string) (*SaveCodeBlockResult, error) {
+	if e.memory == nil {
+		return nil, errors.New("memory not initialized")
+	}
+	if e.tools.DemoMode() {
+		return nil, errDemoMode
+	}
+	blocks := editor.ExtractCodeBlocks(answer)
+	if index < 0 || index >= len(blocks) {
+		return nil, fmt.Errorf("code block %d not found (answer has %d)", index, len(blocks))
+	}
+	block := blocks[index]
+	if strings.TrimSpace(path) == "" {
+		path = block.PathHint
+	}
+	if strings.TrimSpace(path) == "" {
+		return nil, errors.New("a target path is required")
+	}
+
+	root := e.editRoot()
+	plan, err := editor.PlanFileWrite(root, path, block.Content)
+	if err != nil {
+		return nil, err
+	}
+	rec, err := e.applyRecordedWrite(root, plan, "code_block")
+	if err != nil {
+		return nil, err
+	}
+	return &SaveCodeBlockResult{Edit: rec, Diff: plan.Diff, Notes: plan.Notes}, nil
+}
+
+// applyRecordedWrite applies a whole-file write made outside the tool loop and records
+// it in the edit history and timeline, so it can be undone.
+func (e *Engine) applyRecordedWrite(root string, plan *editor.EditPlan, source string) (memory.EditRecord, error) {
+	if err := editor.ValidateEditSafety(plan); err != nil {
+		return memory.EditRecord{}, fmt.Errorf("safety validation failed: %w", err)
+	}
+	if err := editor.ApplyEditWithOptions(plan, editor.WriteOptionsFromEnv(root)); err != nil {
+		return memory.EditRecord{}, err
+	}
+
+	rel, _ := filepath.Rel(root, plan.FilePath)
+	rec, err := e.memory.RecordEdit(memory.EditRecord{
+		Path:    filepath.ToSlash(rel),
+		Before:  string(plan.OldBytes()),
+		After:   string(plan.NewBytes()),
+		Created: plan.IsCreation,
+		Source:  source,
+	})
+	if err != nil {
+		return memory.EditRecord{}, err
+	}
+	e.recordCheckpoint(rec.Path, plan.OldBytes(), plan.NewBytes(), !plan.IsCreation, true, source)
+	if root == e.Workspace() {
+		j := &editJournal{project: e.memory, workspace: root, conversationID: e.CurrentConversationID()}
+		j.record(newCheckpoint(rec.Path, plan.OldBytes(), plan.NewBytes(), !plan.IsCreation, true, source))
+	}
+	return rec, nil
+}
+
+// EditHistory returns undoable edits for the current workspace, newest first.
+func (e *Engine) EditHistory() []memory.EditRecord {
+	return e.memory.ListEdits()
+}
+
+// UndoEdit reverts a recorded edit. It refuses when the file changed since the edit
+// so later work is never silently discarded.
+func (e *Engine) UndoEdit(id string) error {
+	if e.memory == nil {
+		return errors.New("memory not initialized")
+	}
+	if e.tools.DemoMode() {
+		return errDemoMode
+	}
+	var rec memory.EditRecord
+	for _, it := range e.memory.ListEdits() {
+		if it.ID == id {
+			rec = it
+		}
+	}
+	if rec.ID == "" {
+		return fmt.Errorf("edit %q not found", id)
+	}
+	abs := filepath.Join(e.editRoot(), filepath.FromSlash(rec.Path))
+	current, err := os.ReadFile(abs)
+	if err != nil {
+		return fmt.Errorf("cannot undo: %w", err)
+	}
+	if string(current) != rec.After {
+		return fmt.Errorf("cannot undo: %s changed after the edit", rec.Path)
+	}
+	if rec.Created {
+		err = os.Remove(abs)
+	} else {
+		err = os.WriteFile(abs, []byte(rec.Before), 0o644)
+	}
+	if err != nil {
+		return err
+	}
+	e.recordCheckpoint(rec.Path, current, []byte(rec.Before), true, !rec.Created, "undo")
+	_, err = e.memory.TakeEdit(id)
+	return err
+}
@@ -0,0 +1,51 @@
+package engine
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/loom/loom/internal/memory"
+)
+
+func TestSaveCodeBlock_WritesAndUndoes(t *testing.T) {
+	ws := t.TempDir()
+	store, err := memory.NewStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	proj, err := memory.NewProject(store, ws)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := New(nil, nil).WithWorkspace(ws).WithMemory(proj)
+
+	answer := "```txt\nfirst\n```\n\n```txt notes/todo.txt\nsecond\n```\n"
+	res, err := e.SaveCodeBlock(answer, 0, "notes/todo.txt")
+	if err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	if !res.Edit.Created || res.Edit.Path != "notes/todo.txt" {
+		t.Fatalf("unexpected edit: %+v", res.Edit)
+	}
+	// Overwrite with the second block, then undo back to the first
+	res2, err := e.SaveCodeBlock(answer, 1, "notes/todo.txt")
+	if err != nil {
+		t.Fatalf("overwrite: %v", err)
+	}
+	if err := e.UndoEdit(res2.Edit.ID); err != nil {
+		t.Fatalf("undo overwrite: %v", err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(ws, "notes/todo.txt")); string(data) != "first\n" {
+		t.Fatalf("undo did not restore content: %q", data)
+	}
+
+	// Undo refuses when the file changed since the edit
+	_ = os.WriteFile(filepath.Join(ws, "notes/todo.txt"), []byte("edited by hand\n"), 0o644)
+	if err := e.UndoEdit(res.Edit.ID); err == nil {
+		t.Fatalf("expected undo to refuse after external change")
+	}
+	if _, err := e.SaveCodeBlock(answer, 5, "x.txt"); err == nil {
+		t.Fatalf("expected error for missing block")
+	}
+}
@@ -88,6 +88,15 @@ func (b *ProjectContextBuilder) BuildProjectContextBlock(workspaceRoot string) (
 		ctx.WriteString(fmt.Sprintf("languages: %s\n", strings.Join(profile.Languages, ", ")))
 	}
 
+	// Toolchains shell commands run with
+	if len(profile.Toolchains) > 0 {
+		toolchains := make([]string, 0, len(profile.Toolchains))
+		for _, t := range profile.Toolchains {
+			toolchains = append(toolchains, t.String())
+		}
+		ctx.WriteString(fmt.Sprintf("toolchains: %s\n", strings.Join(toolchains, "; ")))
+	}
+
 	// Entrypoints
 	if len(profile.Entrypoints) > 0 {
 		entrypoints := make([]string, 0, len(profile.Entrypoints))
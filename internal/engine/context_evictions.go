@@ -0,0 +1,191 @@
+package engine
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/loom/loom/internal/memory"
+	"github.com/loom/loom/internal/textutil"
+)
+
+const (
+	// evictionLabelRunes bounds the label of an evicted item
+	evictionLabelRunes = 60
+	// noteItemsPerBlock is how many evicted items the context note names per block
+	noteItemsPerBlock = 3
+)
+
+// subjectArgs are the tool arguments that name what a tool call was about, in order
+// of preference.
+var subjectArgs = []string{"path", "file", "command", "query", "pattern", "url", "sid", "name"}
+
+// ContextEviction explains one item left out of a run's requests to fit the model's
+// context window.
+type ContextEviction struct {
+	// Block is the kind of context, e.g. "tool results of earlier requests"
+	Block string `json:"block"`
+	// Item names what was left out, e.g. "read_file internal/app.go"
+	Item   string `json:"item"`
+	Tokens int    `json:"tokens"`
+	AgeSec int64  `json:"age_sec"`
+	// Score is the item's estimated relevance to the current request, from 0 to 1
+	Score float64 `json:"score"`
+}
+
+// relevanceScorer estimates how much the current request still needs an item of the
+// conversation: recent items and items whose subject the request mentions score higher.
+type relevanceScorer struct {
+	history []memory.Message
+	// current is the text of the current request's messages and tool arguments
+	current string
+	now     time.Time
+}
+
+func newRelevanceScorer(s *contextShrinker, history []memory.Message) *relevanceScorer {
+	var b strings.Builder
+	for _, m := range history {
+		if !s.earlier(m) && (m.Role == "user" || m.Role == "assistant") {
+			b.WriteString(m.Content)
+			b.WriteString("\n")
+		}
+	}
+	return &relevanceScorer{history: history, current: b.String(), now: time.Now()}
+}
+
+// score combines the position of message i in the history (0 oldest, 1 newest) with
+// whether the current request mentions the item's subject.
+func (r *relevanceScorer) score(i int, subject string) float64 {
+	recency := 1.0
+	if len(r.history) > 1 && i >= 0 {
+		recency = float64(i) / float64(len(r.history)-1)
+	}
+	return r.scoreAt(recency, subject)
+}
+
+func (r *relevanceScorer) scoreAt(recency float64, subject string) float64 {
+	s := 0.6 * recency
+	if subject = strings.TrimSpace(subject); len(subject) >= 3 && strings.Contains(r.current, subject) {
+		s += 0.4
+	}
+	return math.Round(s*100) / 100
+}
+
+// message describes message i as an eviction of the given block.
+func (r *relevanceScorer) message(block string, i int) ContextEviction {
+	m := r.history[i]
+	label, subject := r.label(i)
+	return ContextEviction{
+		Block:  block,
+		Item:   label,
+		Tokens: textutil.EstimateTokens(m.Content),
+		AgeSec: r.age(m),
+		Score:  r.score(i, subject),
+	}
+}
+
+func (r *relevanceScorer) age(m memory.Message) int64 {
+	if m.Timestamp.IsZero() {
+		return 0
+	}
+	return max(int64(r.now.Sub(m.Timestamp).Seconds()), 0)
+}
+
+// label names message i and returns its subject: for tool results the tool and its
+// main argument, taken from the matching tool use, otherwise the start of the text.
+func (r *relevanceScorer) label(i int) (label, subject string) {
+	m := r.history[i]
+	switch {
+	case m.Role == "tool":
+		name := m.Name
+		for j := i - 1; j >= 0; j-- {
+			use := r.history[j]
+			if use.Role == "assistant" && use.ToolID != "" && use.ToolID == m.ToolID {
+				name, subject = use.Name, toolSubject(use.Content)
+				break
+			}
+		}
+		if name == "" {
+			name = "tool result"
+		}
+		return truncateRunes(strings.TrimSpace(name+" "+subject), evictionLabelRunes), subject
+	case m.Role == "system" && m.Name != "":
+		return m.Name, ""
+	}
+	return truncateRunes(headline(m.Content), evictionLabelRunes), ""
+}
+
+// toolSubject returns the first argument of a tool call that names what it was about.
+func toolSubject(args string) string {
+	var fields map[string]any
+	if json.Unmarshal([]byte(args), &fields) != nil {
+		return ""
+	}
+	for _, k := range subjectArgs {
+		if v, ok := fields[k].(string); ok && strings.TrimSpace(v) != "" {
+			return strings.TrimSpace(firstLine(v))
+		}
+	}
+	return ""
+}
+
+// contextNote is the one-line note sent with requests after context was left out, so
+// the model asks for or re-reads what it needs instead of guessing. The most relevant
+// items of each block are named first.
+func contextNote(evictions []ContextEviction) string {
+	if len(evictions) == 0 {
+		return ""
+	}
+	var order []string
+	byBlock := map[string][]ContextEviction{}
+	for _, ev := range evictions {
+		if _, ok := byBlock[ev.Block]; !ok {
+			order = append(order, ev.Block)
+		}
+		byBlock[ev.Block] = append(byBlock[ev.Block], ev)
+	}
+	parts := make([]string, 0, len(order))
+	for _, block := range order {
+		items := byBlock[block]
+		sort.SliceStable(items, func(i, j int) bool { return items[i].Score > items[j].Score })
+		var names []string
+		for _, ev := range items {
+			if len(names) == noteItemsPerBlock {
+				names = append(names, fmt.Sprintf("+%d more", len(items)-noteItemsPerBlock))
+				break
+			}
+			if ev.Item != "" {
+				names = append(names, ev.Item)
+			}
+		}
+		part := fmt.Sprintf("%d %s", len(items), block)
+		if len(names) > 0 {
+			part += " (" + strings.Join(names, "; ") + ")"
+		}
+		parts = append(parts, part)
+	}
+	return "Context note: to fit the context window, this request leaves out " + strings.Join(parts, ", ") +
+		". Re-read files, re-run tools or ask the user for any of these details instead of guessing them."
+}
+
+// evictionSummary describes a dropped block for the user: how much, how old and how
+// relevant the dropped items were.
+func evictionSummary(what string, items []ContextEviction) string {
+	if len(items) == 0 {
+		return ""
+	}
+	tokens, oldest, best := 0, int64(0), 0.0
+	for _, ev := range items {
+		tokens += ev.Tokens
+		oldest = max(oldest, ev.AgeSec)
+		best = max(best, ev.Score)
+	}
+	desc := fmt.Sprintf("%s (%d, ~%d tokens", what, len(items), tokens)
+	if oldest > 0 {
+		desc += ", oldest " + (time.Duration(oldest) * time.Second).String()
+	}
+	return desc + fmt.Sprintf(", relevance up to %.2f)", best)
+}
@@ -0,0 +1,288 @@
+package engine
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/loom/loom/internal/memory"
+	"github.com/loom/loom/internal/textutil"
+)
+
+// contextBlock is context the engine can leave out of a request the provider rejected
+// as too large for the model's context window. Blocks are dropped in this order, one
+// per retry, so the context the current request depends on most goes last.
+type contextBlock int
+
+const (
+	// blockHints are the transient UI context and directory memory hints
+	blockHints contextBlock = iota
+	// blockSystemNotes are named system messages such as the state drift note
+	blockSystemNotes
+	// blockOldToolResults are tool results of earlier requests
+	blockOldToolResults
+	// blockProjectContext is the project profile in the system prompt
+	blockProjectContext
+	// blockMemories are the memories in the system prompt
+	blockMemories
+	// blockOldTurns are earlier requests and replies, dropped oldest half first
+	blockOldTurns
+	// blockRunToolResults are the current request's tool results but the latest
+	blockRunToolResults
+	blockCount
+)
+
+// keptRunToolResults is how many of the current request's latest tool results
+// survive blockRunToolResults.
+const keptRunToolResults = 2
+
+const droppedToolResult = "[Tool result dropped to fit the model's context window; call the tool again if you need it.]"
+
+// contextOverflow matches provider errors about a request exceeding the context window.
+var contextOverflow = regexp.MustCompile(`(?i)context[_ ]length|context window|maximum context|prompt is too long|input is too long|too many (input )?tokens|request too large|reduce the length|exceeds? the (model's )?(maximum|limit)|\(413\)`)
+
+// isContextOverflow reports whether text is a provider error saying the request is too
+// large. Adapters surface HTTP errors as "... API error (<status>): <body>" tokens.
+func isContextOverflow(text string) bool {
+	head, _, ok := strings.Cut(text, "API error (")
+	if !ok || len(head) > 40 || strings.Contains(head, "\n") {
+		return false
+	}
+	return contextOverflow.MatchString(text)
+}
+
+// contextShrinker tracks the context blocks dropped from a run's requests. The
+// conversation itself is never changed; each request leaves the dropped blocks out.
+type contextShrinker struct {
+	opts SystemPromptOptions
+	// unified is the system prompt opts generates; the run's base prompt starts with it
+	unified string
+	// runStart separates earlier requests from the current one
+	runStart time.Time
+
+	next         contextBlock
+	dropped      [blockCount]bool
+	droppedTurns int
+	// evictions explain every item left out so far, in the order they were dropped
+	evictions []ContextEviction
+}
+
+func newContextShrinker(opts SystemPromptOptions, unified string, runStart time.Time) *contextShrinker {
+	return &contextShrinker{opts: opts, unified: unified, runStart: runStart}
+}
+
+// hints reports whether transient hints still go with requests.
+func (s *contextShrinker) hints() bool {
+	return !s.dropped[blockHints]
+}
+
+// apply returns history with the dropped blocks left out.
+func (s *contextShrinker) apply(history []memory.Message) []memory.Message {
+	if s.next == 0 {
+		return history
+	}
+	cut := s.turnCut(history)
+	runTools := s.runToolResults(history)
+	out := make([]memory.Message, 0, len(history))
+	for i, m := range history {
+		earlier := s.earlier(m)
+		switch {
+		case m.Role == "system" && m.Name == "":
+			m.Content = s.systemPrompt(m.Content)
+		case m.Role == "system":
+			if s.dropped[blockSystemNotes] {
+				continue
+			}
+		case earlier && i < cut:
+			continue
+		case m.Role == "tool" && earlier && s.dropped[blockOldToolResults]:
+			m.Content = droppedToolResult
+		case m.Role == "tool" && !earlier && s.dropped[blockRunToolResults] && runTools[i]:
+			m.Content = droppedToolResult
+		}
+		out = append(out, m)
+	}
+	return out
+}
+
+// drop leaves out the next block that is present in history, records an eviction for
+// every item in it and describes what it removed. It reports false when nothing is
+// left to drop.
+func (s *contextShrinker) drop(history []memory.Message, hints []string) (string, bool) {
+	scorer := newRelevanceScorer(s, history)
+	for ; s.next < blockCount; s.next++ {
+		var what string
+		var items []ContextEviction
+		switch s.next {
+		case blockHints:
+			what = "UI context and directory hints"
+			for _, h := range hints {
+				items = append(items, ContextEviction{Block: what, Item: truncateRunes(headline(h), evictionLabelRunes), Tokens: textutil.EstimateTokens(h), Score: scorer.scoreAt(1, "")})
+			}
+		case blockSystemNotes:
+			what = "system notes"
+			for i, m := range history {
+				if m.Role == "system" && m.Name != "" {
+					items = append(items, scorer.message(what, i))
+				}
+			}
+		case blockOldToolResults:
+			what = "tool results of earlier requests"
+			for i, m := range history {
+				if m.Role == "tool" && s.earlier(m) && m.Content != droppedToolResult {
+					items = append(items, scorer.message(what, i))
+				}
+			}
+		case blockProjectContext:
+			what = "the project profile"
+			if s.opts.IncludeProjectContext && s.opts.WorkspaceRoot != "" && s.rebuilds(history) {
+				without := s.opts
+				without.IncludeProjectContext = false
+				if saved := textutil.EstimateTokens(s.unified) - textutil.EstimateTokens(GenerateSystemPromptUnified(without)); saved > 0 {
+					items = append(items, ContextEviction{Block: "project profile", Tokens: saved, Score: scorer.scoreAt(1, "")})
+				}
+			}
+		case blockMemories:
+			what = "memories"
+			if s.rebuilds(history) {
+				for _, m := range s.opts.Memories {
+					items = append(items, ContextEviction{Block: what, Item: truncateRunes(headline(m.Text), evictionLabelRunes), Tokens: textutil.EstimateTokens(m.Text), Score: scorer.scoreAt(1, "")})
+				}
+			}
+		case blockOldTurns:
+			turns := s.earlierTurns(history)
+			if remaining := len(turns) - s.droppedTurns; remaining > 0 {
+				n := (remaining + 1) / 2
+				for t := s.droppedTurns; t < s.droppedTurns+n; t++ {
+					end := len(history)
+					if t+1 < len(turns) {
+						end = turns[t+1]
+					}
+					ev := scorer.message("earlier requests and replies", turns[t])
+					for _, m := range history[turns[t]+1 : end] {
+						if m.Role != "system" && s.earlier(m) {
+							ev.Tokens += textutil.EstimateTokens(m.Content)
+						}
+					}
+					items = append(items, ev)
+				}
+				s.droppedTurns += n
+				s.evictions = append(s.evictions, items...)
+				// Stay on this block until every earlier request is gone
+				return evictionSummary("oldest earlier requests and replies", items), true
+			}
+		case blockRunToolResults:
+			what = "older tool results of this request"
+			runTools := s.runToolResults(history)
+			for i := range history {
+				if runTools[i] {
+					items = append(items, scorer.message(what, i))
+				}
+			}
+		}
+		if len(items) > 0 {
+			s.dropped[s.next] = true
+			s.next++
+			s.evictions = append(s.evictions, items...)
+			return evictionSummary(what, items), true
+		}
+	}
+	return "", false
+}
+
+// note returns the context note for the run's requests, or "" while nothing is left out.
+func (s *contextShrinker) note() string {
+	return contextNote(s.evictions)
+}
+
+// earlier reports whether a message belongs to a request before the current run.
+func (s *contextShrinker) earlier(m memory.Message) bool {
+	return !m.Timestamp.IsZero() && m.Timestamp.Before(s.runStart)
+}
+
+// earlierTurns returns the indexes of the user messages that start earlier requests.
+func (s *contextShrinker) earlierTurns(history []memory.Message) []int {
+	var turns []int
+	for i, m := range history {
+		if m.Role == "user" && s.earlier(m) {
+			turns = append(turns, i)
+		}
+	}
+	return turns
+}
+
+// turnCut returns the index before which earlier requests are dropped. Cutting at a
+// user message keeps tool uses together with their results.
+func (s *contextShrinker) turnCut(history []memory.Message) int {
+	if s.droppedTurns == 0 {
+		return 0
+	}
+	turns := s.earlierTurns(history)
+	if s.droppedTurns < len(turns) {
+		return turns[s.droppedTurns]
+	}
+	return len(history)
+}
+
+// runToolResults marks the current request's tool results other than the latest ones.
+func (s *contextShrinker) runToolResults(history []memory.Message) map[int]bool {
+	var idx []int
+	for i, m := range history {
+		if m.Role == "tool" && !s.earlier(m) && m.Content != droppedToolResult {
+			idx = append(idx, i)
+		}
+	}
+	marked := map[int]bool{}
+	for _, i := range idx[:max(0, len(idx)-keptRunToolResults)] {
+		marked[i] = true
+	}
+	return marked
+}
+
+// rebuilds reports whether the conversation's system prompt can be regenerated.
+func (s *contextShrinker) rebuilds(history []memory.Message) bool {
+	for _, m := range history {
+		if m.Role == "system" && m.Name == "" {
+			return strings.HasPrefix(m.Content, strings.TrimSpace(s.unified))
+		}
+	}
+	return false
+}
+
+// systemPrompt regenerates the base system prompt without the dropped blocks, keeping
+// the sections the run appended after it.
+func (s *contextShrinker) systemPrompt(content string) string {
+	if !s.dropped[blockProjectContext] && !s.dropped[blockMemories] {
+		return content
+	}
+	prefix := strings.TrimSpace(s.unified)
+	if !strings.HasPrefix(content, prefix) {
+		return content
+	}
+	opts := s.opts
+	if s.dropped[blockProjectContext] {
+		opts.IncludeProjectContext = false
+	}
+	if s.dropped[blockMemories] {
+		opts.MemoriesOmitted += len(opts.Memories)
+		opts.Memories = nil
+	}
+	return strings.TrimSpace(GenerateSystemPromptUnified(opts)) + strings.TrimPrefix(content, prefix)
+}
+
+// recoverOverflow handles a request rejected as too large: it records the provider
+// error, drops the next context block and tells the user exactly what was left out.
+// It reports false when nothing is left to drop.
+func (e *Engine) recoverOverflow(s *contextShrinker, run *runRecorder, history []memory.Message, hints []string, providerErr string) bool {
+	e.errLog.add("llm", errors.New(providerErr))
+	dropped, ok := s.drop(history, hints)
+	if !ok {
+		e.bridge.SendChat("system", "The request is too large for the model's context window and no more context can be left out.")
+		return false
+	}
+	run.droppedContext = append(run.droppedContext, dropped)
+	run.evictions = s.evictions
+	e.bridge.SendChat("system", "The request is too large for the model's context window; retrying without "+dropped+".")
+	return true
+}
@@ -0,0 +1,139 @@
+package engine
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/loom/loom/internal/memory"
+)
+
+func TestIsContextOverflow(t *testing.T) {
+	for _, text := range []string{
+		`OpenAI API error (400): {"error":{"message":"This model's maximum context length is 128000 tokens.","code":"context_length_exceeded"}}`,
+		`Anthropic API error (400): {"type":"error","error":{"type":"invalid_request_error","message":"prompt is too long: 210000 tokens > 200000 maximum"}}`,
+		`OpenRouter API error (413): request entity too large`,
+	} {
+		if !isContextOverflow(text) {
+			t.Errorf("expected an overflow: %s", text)
+		}
+	}
+	for _, text := range []string{
+		`OpenAI API error (429): rate limited`,
+		"The context length of this function is fine.\nOpenAI API error (400): context_length_exceeded",
+		"Reduce the length of the loop",
+	} {
+		if isContextOverflow(text) {
+			t.Errorf("not an overflow: %s", text)
+		}
+	}
+}
+
+func TestContextShrinker_DropsBlocksInPriorityOrder(t *testing.T) {
+	start := time.Now()
+	before := start.Add(-time.Hour)
+	opts := SystemPromptOptions{Memories: []MemoryEntry{{ID: "m1", Text: "prefers tabs"}}}
+	unified := GenerateSystemPromptUnified(opts)
+	history := []memory.Message{
+		{Role: "system", Content: unified + "\n\n## Output Style: concise", Timestamp: start},
+		{Role: "user", Content: "first", Timestamp: before},
+		{Role: "assistant", Name: "read_file", ToolID: "1", Content: "{}", Timestamp: before},
+		{Role: "tool", Name: "read_file", ToolID: "1", Content: "old file", Timestamp: before},
+		{Role: "assistant", Content: "answer one", Timestamp: before},
+		{Role: "user", Content: "second", Timestamp: before},
+		{Role: "assistant", Content: "answer two", Timestamp: before},
+		{Role: "system", Name: stateDriftNote, Content: "files changed", Timestamp: start},
+		{Role: "user", Content: "now", Timestamp: start},
+		{Role: "tool", ToolID: "a", Content: "r1", Timestamp: start},
+		{Role: "tool", ToolID: "b", Content: "r2", Timestamp: start},
+		{Role: "tool", ToolID: "c", Content: "r3", Timestamp: start},
+	}
+	s := newContextShrinker(opts, unified, start)
+	if got := s.apply(history); len(got) != len(history) {
+		t.Fatal("nothing should be dropped before an overflow")
+	}
+
+	var dropped []string
+	for {
+		desc, ok := s.drop(history, []string{"UI Context: a.go"})
+		if !ok {
+			break
+		}
+		dropped = append(dropped, desc)
+	}
+	want := []string{"UI context", "system notes", "tool results of earlier requests", "memories", "oldest earlier requests", "oldest earlier requests", "older tool results of this request (1,"}
+	if len(dropped) != len(want) {
+		t.Fatalf("unexpected drops %q", dropped)
+	}
+	for i, w := range want {
+		if !strings.HasPrefix(dropped[i], w) {
+			t.Errorf("drop %d: got %q, want %q...", i, dropped[i], w)
+		}
+	}
+	if s.hints() {
+		t.Error("hints should be dropped")
+	}
+
+	got := s.apply(history)
+	var roles []string
+	for _, m := range got {
+		roles = append(roles, m.Role+":"+m.Content)
+	}
+	joined := strings.Join(roles, "|")
+	for _, gone := range []string{"user:first", "user:second", "assistant:answer", "files changed", "old file", "prefers tabs", "tool:r1"} {
+		if strings.Contains(joined, gone) {
+			t.Errorf("%q should be dropped: %s", gone, joined)
+		}
+	}
+	for _, kept := range []string{"user:now", "tool:r2", "tool:r3", "## Output Style: concise", "1 memories are stored"} {
+		if !strings.Contains(joined, kept) {
+			t.Errorf("%q should be kept: %s", kept, joined)
+		}
+	}
+	// The conversation itself is unchanged
+	if history[3].Content != "old file" || len(history) != 12 {
+		t.Fatal("history was modified")
+	}
+}
+
+func TestContextShrinker_ExplainsEvictions(t *testing.T) {
+	start := time.Now()
+	before := start.Add(-2 * time.Hour)
+	history := []memory.Message{
+		{Role: "system", Content: "base", Timestamp: start},
+		{Role: "user", Content: "look at the parser", Timestamp: before},
+		{Role: "assistant", Name: "read_file", ToolID: "1", Content: `{"path":"internal/parse.go"}`, Timestamp: before},
+		{Role: "tool", Name: "read_file", ToolID: "1", Content: strings.Repeat("x", 400), Timestamp: before},
+		{Role: "assistant", Name: "run_shell", ToolID: "2", Content: `{"command":"go test ./..."}`, Timestamp: before},
+		{Role: "tool", Name: "run_shell", ToolID: "2", Content: "ok", Timestamp: before},
+		{Role: "user", Content: "now fix internal/parse.go", Timestamp: start},
+	}
+	s := newContextShrinker(SystemPromptOptions{}, "base", start)
+	if s.note() != "" {
+		t.Fatal("no note before anything is dropped")
+	}
+	desc, ok := s.drop(history, nil)
+	if !ok || !strings.HasPrefix(desc, "tool results of earlier requests (2, ~") || !strings.Contains(desc, "oldest 2h0m0s") {
+		t.Fatalf("unexpected description %q", desc)
+	}
+	if len(s.evictions) != 2 {
+		t.Fatalf("expected an eviction per tool result, got %+v", s.evictions)
+	}
+	read, shell := s.evictions[0], s.evictions[1]
+	if read.Item != "read_file internal/parse.go" || read.Tokens == 0 || read.AgeSec < 7200 {
+		t.Fatalf("unexpected eviction %+v", read)
+	}
+	// The current request mentions the file, so its old read scores higher than the newer test run
+	if read.Score <= shell.Score {
+		t.Fatalf("expected the mentioned file to score higher: %+v", s.evictions)
+	}
+
+	note := s.note()
+	if strings.Contains(note, "\n") {
+		t.Fatalf("the note must be one line: %q", note)
+	}
+	want := "leaves out 2 tool results of earlier requests (read_file internal/parse.go; run_shell go test ./...)."
+	if !strings.Contains(note, want) {
+		t.Fatalf("expected %q in %q", want, note)
+	}
+}
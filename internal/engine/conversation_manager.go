@@ -55,7 +55,7 @@ func (cm *ConversationManager) GetConversation(id string) ([]Message, error) {
 	}
 	msgs := make([]Message, 0, len(memMsgs))
 	for _, m := range memMsgs {
-		msgs = append(msgs, Message{Role: m.Role, Content: m.Content, Name: m.Name, ToolID: m.ToolID})
+		msgs = append(msgs, Message{Role: m.Role, Content: m.Content, Name: m.Name, ToolID: m.ToolID, Version: m.Version})
 	}
 	return msgs, nil
 }
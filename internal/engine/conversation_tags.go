@@ -0,0 +1,240 @@
+package engine
+
+import (
+	"encoding/json"
+	"errors"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/loom/loom/internal/memory"
+)
+
+const (
+	maxTopicTags   = 5
+	maxPackageTags = 5
+	// maxPackageDepth shortens deep directories, e.g. internal/billing/invoice/pdf
+	maxPackageDepth = 3
+)
+
+// taskTypes are checked in order; on a tie the earlier type wins.
+var taskTypes = []struct {
+	name string
+	re   *regexp.Regexp
+}{
+	{"bugfix", regexp.MustCompile(`(?i)\b(fix(es|ed|ing)?|bugs?|broken|crash(es|ing)?|fail(s|ing|ure)?|regression|exception|panics?|not working|doesn'?t work|wrong)\b`)},
+	{"refactor", regexp.MustCompile(`(?i)\b(refactor\w*|clean ?up|rename|extract|restructure|simplify|reorganiz\w*|deduplicate|split up|move)\b`)},
+	{"test", regexp.MustCompile(`(?i)\b(tests?|testing|coverage|specs?|unit tests?|integration tests?)\b`)},
+	{"docs", regexp.MustCompile(`(?i)\b(document\w*|docs|readme|docstrings?|changelog|comments?)\b`)},
+	{"review", regexp.MustCompile(`(?i)\b(review\w*|audit|look over|feedback)\b`)},
+	{"ops", regexp.MustCompile(`(?i)\b(deploy\w*|ci|pipelines?|docker\w*|kubernetes|helm|terraform|dependenc\w*|upgrade|bump|release)\b`)},
+	{"feature", regexp.MustCompile(`(?i)\b(add|implement\w*|create|support|introduce|build|new)\b`)},
+}
+
+var questionStart = regexp.MustCompile(`(?i)^\s*(how|what|why|where|which|who|when|explain|describe|is|are|does|do|can you (tell|explain|show))\b`)
+
+var topicWord = regexp.MustCompile(`[A-Za-z][A-Za-z0-9_-]{3,}`)
+
+// topicStopWords are common English and generic programming words that say nothing
+// about what a conversation is about.
+var topicStopWords = map[string]bool{}
+
+func init() {
+	for _, w := range strings.Fields(`about above after again against also always another because been before being below between
+		both could does doesn doing down during each either else even every from further have having here hers into itself just
+		like make many more most much must need needs only other ours over please same should some such than that their them
+		then there these they this those through under until very want what when where which while whom will with would your
+		yours thanks right left still maybe thing things something anything everything someone works working work done
+		code file files function functions method methods class classes line lines change changes changed update updates
+		updated value values error errors test tests make sure using used into issue issues problem problems help check
+		implement add create remove delete rename refactor fix fixes fixed bug bugs feature support new instead there
+		should would could currently call calls called return returns string strings type types variable variables
+		package packages module modules project repo repository folder directory internal wrong broken
+		failing fails failed crash crashes correct correctly properly today`) {
+		topicStopWords[w] = true
+	}
+}
+
+// genericDirs are directory names that do not name a topic.
+var genericDirs = map[string]bool{
+	"internal": true, "src": true, "lib": true, "pkg": true, "cmd": true, "app": true, "apps": true, "test": true,
+	"tests": true, "components": true, "utils": true, "util": true, "common": true, "core": true, "main": true,
+	"shared": true, "frontend": true, "backend": true, "server": true, "client": true, "api": true,
+}
+
+// ConversationTags returns the current conversation's tags.
+func (e *Engine) ConversationTags() memory.ConversationTags {
+	if e.memory == nil {
+		return memory.ConversationTags{}
+	}
+	return e.memory.ConversationTags(e.memory.CurrentConversationID())
+}
+
+// FindConversations returns the conversations matching a filter, most recent first.
+func (e *Engine) FindConversations(f memory.ConversationFilter) ([]memory.ConversationSummary, error) {
+	if e.memory == nil {
+		return nil, errors.New("memory not initialized")
+	}
+	return e.memory.FindConversations(f)
+}
+
+// ConversationTagCounts returns the tags in use across conversations, most used first.
+func (e *Engine) ConversationTagCounts() ([]memory.TagCount, error) {
+	if e.memory == nil {
+		return nil, errors.New("memory not initialized")
+	}
+	return e.memory.ConversationTagCounts()
+}
+
+// tagConversation derives and stores a conversation's tags from its messages and the
+// files it changed.
+func (e *Engine) tagConversation(id string) {
+	if e.memory == nil || id == "" {
+		return
+	}
+	var changed []string
+	for _, c := range e.memory.Timeline(id) {
+		changed = append(changed, c.Path)
+	}
+	tags := deriveConversationTags(memory.NewConversation(e.memory, id).History(), changed)
+	if tags.TaskType == "" {
+		return
+	}
+	_ = e.memory.SetConversationTags(id, tags)
+}
+
+// deriveConversationTags classifies the task of a conversation and picks its topics
+// and packages from the user's requests and the files read or changed.
+func deriveConversationTags(history []memory.Message, changed []string) memory.ConversationTags {
+	var requests []string
+	files := map[string]int{}
+	for _, m := range history {
+		switch {
+		case m.Role == "user":
+			if strings.HasPrefix(m.Content, doneRejectionPrefix) || strings.HasPrefix(m.Content, handoffResumePrefix) {
+				continue
+			}
+			if text := strings.TrimSpace(strings.TrimPrefix(m.Content, reproductionRequestPrefix)); text != "" {
+				requests = append(requests, text)
+			}
+		case m.Role == "assistant" && (m.Name == "read_file" || m.Name == "edit_file"):
+			var args struct {
+				Path string `json:"path"`
+			}
+			if json.Unmarshal([]byte(m.Content), &args) == nil && args.Path != "" {
+				files[args.Path]++
+			}
+		}
+	}
+	for _, p := range changed {
+		// Changed files say more about the conversation than ones only read
+		files[p] += 3
+	}
+	if len(requests) == 0 {
+		return memory.ConversationTags{}
+	}
+
+	tags := memory.ConversationTags{TaskType: classifyTask(requests, len(changed) > 0)}
+
+	dirs := map[string]int{}
+	for p, n := range files {
+		if d := packageOf(p); d != "" {
+			dirs[d] += n
+		}
+	}
+	tags.Packages = topKeys(dirs, maxPackageTags, 1)
+
+	words := map[string]int{}
+	for i, r := range requests {
+		weight := 1
+		if i == 0 {
+			// The first request usually states what the conversation is about
+			weight = 2
+		}
+		seen := map[string]bool{}
+		for _, w := range topicWord.FindAllString(r, -1) {
+			w = strings.ToLower(w)
+			if topicStopWords[w] || seen[w] {
+				continue
+			}
+			seen[w] = true
+			words[w] += weight
+		}
+	}
+	for d := range dirs {
+		for _, seg := range strings.Split(d, "/") {
+			seg = strings.ToLower(seg)
+			if len(seg) >= 3 && !genericDirs[seg] && !strings.HasPrefix(seg, ".") {
+				words[seg] += 2
+			}
+		}
+	}
+	tags.Topics = topKeys(words, maxTopicTags, 2)
+	return tags
+}
+
+// classifyTask returns the task type the requests score highest for; the first
+// request counts double. Requests matching no type are questions, or features when
+// the conversation changed files.
+func classifyTask(requests []string, changedFiles bool) string {
+	scores := make([]int, len(taskTypes))
+	question := 0
+	for i, r := range requests {
+		weight := 1
+		if i == 0 {
+			weight = 2
+		}
+		for j, t := range taskTypes {
+			scores[j] += weight * len(t.re.FindAllString(r, -1))
+		}
+		if questionStart.MatchString(r) || strings.HasSuffix(strings.TrimSpace(r), "?") {
+			question += weight
+		}
+	}
+	best := -1
+	for j := range taskTypes {
+		if scores[j] > 0 && (best < 0 || scores[j] > scores[best]) {
+			best = j
+		}
+	}
+	switch {
+	case best >= 0 && (changedFiles || scores[best] >= question):
+		return taskTypes[best].name
+	case question > 0 || !changedFiles:
+		return "question"
+	}
+	return "feature"
+}
+
+// packageOf returns the directory of a workspace file, cut to maxPackageDepth.
+func packageOf(p string) string {
+	d := path.Dir(strings.TrimPrefix(path.Clean("/"+strings.ReplaceAll(p, "\\", "/")), "/"))
+	if d == "." || d == "/" || d == "" {
+		return ""
+	}
+	if parts := strings.Split(d, "/"); len(parts) > maxPackageDepth {
+		d = strings.Join(parts[:maxPackageDepth], "/")
+	}
+	return d
+}
+
+// topKeys returns up to n keys scoring at least min, best first.
+func topKeys(scores map[string]int, n, min int) []string {
+	var keys []string
+	for k, v := range scores {
+		if v >= min {
+			keys = append(keys, k)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if scores[keys[i]] != scores[keys[j]] {
+			return scores[keys[i]] > scores[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+	if len(keys) > n {
+		keys = keys[:n]
+	}
+	return keys
+}
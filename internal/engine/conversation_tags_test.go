@@ -0,0 +1,58 @@
+package engine
+
+import (
+	"testing"
+
+	"github.com/loom/loom/internal/memory"
+)
+
+func TestClassifyTask(t *testing.T) {
+	cases := []struct {
+		requests []string
+		changed  bool
+		want     string
+	}{
+		{[]string{"The invoice total is wrong when a discount is applied, please fix it"}, true, "bugfix"},
+		{[]string{"Refactor the payment client and extract the retry logic"}, true, "refactor"},
+		{[]string{"Add a CSV export to the reports page"}, true, "feature"},
+		{[]string{"Write unit tests for the tax calculator"}, true, "test"},
+		{[]string{"How does the billing service compute proration?"}, false, "question"},
+		{[]string{"Make the header sticky"}, true, "feature"},
+		{[]string{"Make the header sticky"}, false, "question"},
+	}
+	for _, c := range cases {
+		if got := classifyTask(c.requests, c.changed); got != c.want {
+			t.Errorf("classifyTask(%q) = %s, want %s", c.requests[0], got, c.want)
+		}
+	}
+}
+
+func TestDeriveConversationTags(t *testing.T) {
+	history := []memory.Message{
+		{Role: "user", Content: "Invoices for the billing service round the tax wrong, please fix it"},
+		{Role: "assistant", Name: "read_file", Content: `{"path":"services/billing/invoice/tax.go"}`},
+		{Role: "assistant", Name: "read_file", Content: `{"path":"README.md"}`},
+		{Role: "assistant", Content: "Fixed the rounding."},
+		{Role: "user", Content: doneRejectionPrefix + " the billing tests still fail"},
+		{Role: "user", Content: "Also check the billing rounding for credit notes"},
+	}
+	tags := deriveConversationTags(history, []string{"services/billing/invoice/tax.go", "services/billing/invoice/tax_test.go"})
+	if tags.TaskType != "bugfix" {
+		t.Fatalf("expected bugfix, got %q", tags.TaskType)
+	}
+	if len(tags.Packages) != 1 || tags.Packages[0] != "services/billing/invoice" {
+		t.Fatalf("unexpected packages: %v", tags.Packages)
+	}
+	if len(tags.Topics) == 0 || tags.Topics[0] != "billing" {
+		t.Fatalf("expected billing as the first topic, got %v", tags.Topics)
+	}
+	for _, topic := range tags.Topics {
+		if topic == "services" || topic == "please" || topic == "fix" {
+			t.Fatalf("unexpected topic %q in %v", topic, tags.Topics)
+		}
+	}
+
+	if tags := deriveConversationTags(nil, nil); tags.TaskType != "" {
+		t.Fatalf("expected no tags without requests, got %+v", tags)
+	}
+}
@@ -0,0 +1,169 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/loom/loom/internal/coverage"
+	"github.com/loom/loom/internal/tool"
+)
+
+// CoverageMap is the line coverage of the files the session changed, from the
+// workspace's most recent coverage report.
+type CoverageMap struct {
+	// Report is the workspace-relative path of the coverage report
+	Report    string         `json:"report"`
+	Format    string         `json:"format"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	Files     []FileCoverage `json:"files"`
+}
+
+// FileCoverage maps one changed file's instrumented lines to whether they ran.
+type FileCoverage struct {
+	Path      string `json:"path"`
+	Covered   []int  `json:"covered"`
+	Uncovered []int  `json:"uncovered"`
+	// Added lists the lines the session added or changed, in the current file
+	Added []int `json:"added"`
+	// AddedCovered and AddedUncovered count the instrumented lines of Added
+	AddedCovered   int `json:"added_covered"`
+	AddedUncovered int `json:"added_uncovered"`
+	// Measured is false when the report has no data for the file
+	Measured bool `json:"measured"`
+	// Stale is set when the file changed after the report was written
+	Stale bool `json:"stale,omitempty"`
+}
+
+// coverageNotifier is implemented by UI bridges that overlay coverage in diffs.
+type coverageNotifier interface {
+	EmitCoverage(m *CoverageMap)
+}
+
+// CoverageMap returns the coverage of the files changed in the current conversation,
+// read from the newest coverage report in the workspace (coverage.out, lcov.info,
+// coverage.xml, ...).
+func (e *Engine) CoverageMap() (*CoverageMap, error) {
+	ws := e.Workspace()
+	if ws == "" {
+		return nil, errors.New("no workspace open")
+	}
+	report, err := coverage.Find(ws)
+	if err != nil {
+		return nil, err
+	}
+	profile, err := coverage.Load(ws, report)
+	if err != nil {
+		return nil, err
+	}
+	diff, err := e.sessionDiff()
+	if err != nil {
+		return nil, err
+	}
+	rel, _ := filepath.Rel(ws, report)
+	m := &CoverageMap{Report: filepath.ToSlash(rel), Format: profile.Format, UpdatedAt: profile.ModTime, Files: []FileCoverage{}}
+	if diff == nil {
+		return m, nil
+	}
+	for _, f := range diff.Files {
+		if f.Status == "deleted" {
+			continue
+		}
+		fc := FileCoverage{Path: f.Path, Added: addedLines(f.Diff), Covered: []int{}, Uncovered: []int{}}
+		if pf := profile.Files[f.Path]; pf != nil {
+			fc.Measured = true
+			fc.Covered, fc.Uncovered = pf.Covered(), pf.Uncovered()
+			for _, n := range fc.Added {
+				if covered, ok := pf.Lines[n]; ok && covered {
+					fc.AddedCovered++
+				} else if ok {
+					fc.AddedUncovered++
+				}
+			}
+		}
+		if st, err := os.Stat(filepath.Join(ws, filepath.FromSlash(f.Path))); err == nil && st.ModTime().After(profile.ModTime) {
+			fc.Stale = true
+		}
+		m.Files = append(m.Files, fc)
+	}
+	return m, nil
+}
+
+// sessionDiff returns the diff over the current conversation's whole timeline, or nil
+// when it changed nothing.
+func (e *Engine) sessionDiff() (*TimelineDiff, error) {
+	if e.memory == nil {
+		return nil, errors.New("memory not initialized")
+	}
+	id := e.memory.CurrentConversationID()
+	if id == "" {
+		return nil, errors.New("no active conversation")
+	}
+	items := e.memory.Timeline(id)
+	if len(items) == 0 {
+		return nil, nil
+	}
+	return e.DiffCheckpoints(items[0].Step-1, items[len(items)-1].Step)
+}
+
+var hunkHeaderRe = regexp.MustCompile(`^@@ -\d+(?:,\d+)? \+(\d+)(?:,\d+)? @@`)
+
+// addedLines returns the new-file line numbers of the lines a unified diff adds.
+func addedLines(diff string) []int {
+	out := []int{}
+	line := 0
+	for _, l := range strings.Split(diff, "\n") {
+		if m := hunkHeaderRe.FindStringSubmatch(l); m != nil {
+			line, _ = strconv.Atoi(m[1])
+			continue
+		}
+		if line == 0 {
+			continue
+		}
+		switch {
+		case strings.HasPrefix(l, "+"):
+			out = append(out, line)
+			line++
+		case strings.HasPrefix(l, " "):
+			line++
+		}
+	}
+	return out
+}
+
+// coverageCommandRe matches test commands that write a coverage report.
+var coverageCommandRe = regexp.MustCompile(`(?i)(^|\s)(-cover\S*|--cov\S*|--coverage\S*|coverage|nyc|c8)(\s|$)`)
+
+// checkCoverage refreshes the coverage overlay after a test command that collected
+// coverage wrote a report since it started, and returns a warning for each changed
+// file whose added code never ran.
+func (e *Engine) checkCoverage(args tool.ApplyShellArgs, started time.Time) []string {
+	if !coverageCommandRe.MatchString(args.Command + " " + strings.Join(args.Args, " ")) {
+		return nil
+	}
+	m, err := e.CoverageMap()
+	if err != nil || m.UpdatedAt.Before(started) {
+		return nil
+	}
+	if n, ok := e.bridge.(coverageNotifier); ok {
+		n.EmitCoverage(m)
+	}
+	return uncoveredWarnings(m)
+}
+
+// uncoveredWarnings names the files whose instrumented added lines all went unexecuted.
+func uncoveredWarnings(m *CoverageMap) []string {
+	var out []string
+	for _, f := range m.Files {
+		if f.Stale || f.AddedUncovered == 0 || f.AddedCovered > 0 {
+			continue
+		}
+		out = append(out, fmt.Sprintf("Coverage: none of the %d new executable line(s) in %s ran in the tests (%s).", f.AddedUncovered, f.Path, m.Report))
+	}
+	return out
+}
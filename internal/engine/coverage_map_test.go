@@ -0,0 +1,62 @@
+package engine
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/loom/loom/internal/memory"
+	"github.com/loom/loom/internal/tool"
+)
+
+func TestAddedLines(t *testing.T) {
+	diff := "diff --git a/x.go b/x.go\n--- a/x.go\n+++ b/x.go\n@@ -1,4 +1,5 @@\n package x\n-func A() {}\n+func A() int {\n+\treturn 1\n+}\n \n@@ -20,2 +21,3 @@ func B() {\n \tb()\n+\tc()\n }\n"
+	if got := addedLines(diff); !reflect.DeepEqual(got, []int{2, 3, 4, 22}) {
+		t.Errorf("addedLines = %v", got)
+	}
+}
+
+func TestCheckCoverage(t *testing.T) {
+	ws := t.TempDir()
+	store, _ := memory.NewStore(t.TempDir())
+	proj, err := memory.NewProject(store, ws)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = proj.SetCurrentConversationID("c1")
+	e := New(nil, nil).WithWorkspace(ws).WithMemory(proj)
+	_ = os.WriteFile(filepath.Join(ws, "go.mod"), []byte("module example.com/app\n"), 0o644)
+	_ = os.WriteFile(filepath.Join(ws, "calc.go"), []byte("package app\n"), 0o644)
+	tr := &timelineRecorder{project: proj, workspace: ws, conversationID: "c1"}
+	args, _ := json.Marshal(map[string]string{"path": "calc.go"})
+	pc := tr.capture(&tool.ToolCall{ID: "t1", Name: "apply_edit", Args: args})
+	_ = os.WriteFile(filepath.Join(ws, "calc.go"), []byte("package app\n\nfunc Double(n int) int {\n\treturn n * 2\n}\n"), 0o644)
+	tr.commit(pc)
+	started := time.Now()
+	// The report is written after the edit
+	time.Sleep(10 * time.Millisecond)
+	_ = os.WriteFile(filepath.Join(ws, "coverage.out"), []byte("mode: set\nexample.com/app/calc.go:3.25,5.2 1 0\n"), 0o644)
+
+	if w := e.checkCoverage(tool.ApplyShellArgs{Command: "go", Args: []string{"test", "./..."}}, started); w != nil {
+		t.Errorf("commands without coverage should not be checked: %v", w)
+	}
+	warnings := e.checkCoverage(tool.ApplyShellArgs{Command: "go test -coverprofile=coverage.out ./...", Shell: true}, started)
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "calc.go") {
+		t.Fatalf("expected a warning about calc.go, got %v", warnings)
+	}
+	m, err := e.CoverageMap()
+	if err != nil || len(m.Files) != 1 {
+		t.Fatalf("unexpected map %+v, %v", m, err)
+	}
+	f := m.Files[0]
+	if !f.Measured || f.Stale || f.AddedUncovered != 3 || f.AddedCovered != 0 || !reflect.DeepEqual(f.Uncovered, []int{3, 4, 5}) {
+		t.Errorf("unexpected file coverage %+v", f)
+	}
+	if w := e.checkCoverage(tool.ApplyShellArgs{Command: "go test -cover ./...", Shell: true}, time.Now().Add(time.Minute)); w != nil {
+		t.Errorf("reports older than the command should be ignored: %v", w)
+	}
+}
@@ -0,0 +1,350 @@
+package engine
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/loom/loom/internal/memory"
+	"github.com/loom/loom/internal/tool"
+)
+
+const (
+	maxTitleLen         = 72
+	maxSummaryLen       = 1500
+	maxRationaleItems   = 10
+	largeChangeLines    = 400
+	maxEvidenceTailLine = 3
+)
+
+// ChangeDescription is a pull request description generated from a session: its
+// applied diffs, commits, todo list and the checks that were run.
+type ChangeDescription struct {
+	Title   string `json:"title"`
+	Summary string `json:"summary"`
+	// Rationale lists the user's requests that led to the changes
+	Rationale []string         `json:"rationale"`
+	Files     []TimelineFile   `json:"files"`
+	Commits   []string         `json:"commits"`
+	Todos     []tool.TodoTask  `json:"todos"`
+	Tests     []CommandOutcome `json:"tests"`
+	Risks     []string         `json:"risks"`
+	// ReviewNotes and Refined are set when the model refined the description
+	ReviewNotes []string `json:"review_notes,omitempty"`
+	Refined     bool     `json:"refined,omitempty"`
+	Markdown    string   `json:"markdown"`
+}
+
+// CommandOutcome is a test or check command run in the session.
+type CommandOutcome struct {
+	Command  string `json:"command"`
+	ExitCode int    `json:"exit_code"`
+	// Tail holds the last lines of output, as evidence
+	Tail string `json:"tail,omitempty"`
+}
+
+// checkCommand matches commands that count as test evidence.
+var checkCommand = regexp.MustCompile(`(?i)(^|[\s/])(test|tests|pytest|phpunit|pest|jest|vitest|mocha|rspec|vet|lint|eslint|golangci-lint|tsc|check|clippy|build)\b`)
+
+var commitMessageFlag = regexp.MustCompile(`-m\s+(?:"([^"]*)"|'([^']*)'|(\S+))`)
+
+// DescribeChanges generates a PR-ready description of the current conversation's changes.
+func (e *Engine) DescribeChanges() (*ChangeDescription, error) {
+	diff, err := e.sessionDiff()
+	if err != nil {
+		return nil, err
+	}
+	d := describeSession(memory.NewConversation(e.memory, e.memory.CurrentConversationID()).History(), diff, tool.TodoTasks())
+	if r, ok := e.cachedRefinement(); ok {
+		d.applyRefinement(r)
+	}
+	return d, nil
+}
+
+// describeSession builds the description from a conversation history, the diff over
+// its whole timeline (nil when nothing changed) and the todo list.
+func describeSession(history []memory.Message, diff *TimelineDiff, todos []tool.TodoTask) *ChangeDescription {
+	d := &ChangeDescription{Rationale: []string{}, Files: []TimelineFile{}, Commits: []string{}, Todos: todos, Tests: []CommandOutcome{}, Risks: []string{}}
+	if d.Todos == nil {
+		d.Todos = []tool.TodoTask{}
+	}
+	if diff != nil {
+		for _, f := range diff.Files {
+			f.Diff = ""
+			d.Files = append(d.Files, f)
+		}
+	}
+
+	calls := map[string]memory.Message{}
+	var finalSummary, lastAnswer string
+	for _, m := range history {
+		switch {
+		case m.Role == "user":
+			if strings.HasPrefix(m.Content, doneRejectionPrefix) {
+				continue
+			}
+			if req := headline(m.Content); req != "" && len(d.Rationale) < maxRationaleItems {
+				d.Rationale = append(d.Rationale, truncateRunes(req, 200))
+			}
+		case m.Role == "assistant" && m.ToolID != "" && m.Name != "" && m.Name != "thinking":
+			calls[m.ToolID] = m
+			if m.Name == "finalize" {
+				var args tool.FinalizeArgs
+				if json.Unmarshal([]byte(m.Content), &args) == nil && strings.TrimSpace(args.Summary) != "" {
+					finalSummary = strings.TrimSpace(args.Summary)
+				}
+			}
+		case m.Role == "assistant" && m.Name == "" && strings.TrimSpace(m.Content) != "":
+			lastAnswer = strings.TrimSpace(m.Content)
+		case m.Role == "tool":
+			call, ok := calls[m.ToolID]
+			if !ok || strings.HasPrefix(m.Content, "Error") {
+				continue
+			}
+			switch call.Name {
+			case "apply_shell":
+				d.recordShell(call, m.Content)
+			case "git_commit":
+				var args tool.GitCommitParams
+				if json.Unmarshal([]byte(call.Content), &args) == nil && strings.TrimSpace(args.Message) != "" {
+					d.Commits = append(d.Commits, headline(args.Message))
+				}
+			}
+		}
+	}
+
+	if len(d.Rationale) > 0 {
+		d.Title = truncateRunes(strings.TrimRight(d.Rationale[0], ".!? "), maxTitleLen)
+	}
+	if d.Title == "" {
+		d.Title = "Changes from Loom session"
+	}
+	d.Summary = finalSummary
+	if d.Summary == "" {
+		d.Summary = lastAnswer
+	}
+	d.Summary = truncateRunes(d.Summary, maxSummaryLen)
+	d.Risks = assessRisks(d)
+	d.Markdown = d.render()
+	return d
+}
+
+// recordShell keeps executed check commands and commits made from the shell.
+func (d *ChangeDescription) recordShell(call memory.Message, result string) {
+	var args tool.ApplyShellArgs
+	var res tool.ShellResult
+	if json.Unmarshal([]byte(call.Content), &args) != nil || json.Unmarshal([]byte(result), &res) != nil {
+		return
+	}
+	cmd := strings.TrimSpace(args.Command + " " + strings.Join(args.Args, " "))
+	if strings.HasPrefix(cmd, "git commit") {
+		if m := commitMessageFlag.FindStringSubmatch(cmd); m != nil && res.ExitCode == 0 {
+			d.Commits = append(d.Commits, headline(m[1]+m[2]+m[3]))
+		}
+		return
+	}
+	if !checkCommand.MatchString(cmd) {
+		return
+	}
+	out := strings.TrimSpace(res.Stdout + "\n" + res.Stderr)
+	lines := strings.Split(out, "\n")
+	if len(lines) > maxEvidenceTailLine {
+		lines = lines[len(lines)-maxEvidenceTailLine:]
+	}
+	d.Tests = append(d.Tests, CommandOutcome{Command: cmd, ExitCode: res.ExitCode, Tail: strings.TrimSpace(strings.Join(lines, "\n"))})
+}
+
+// assessRisks lists what a reviewer should look at closely.
+func assessRisks(d *ChangeDescription) []string {
+	risks := []string{}
+	var deleted, deps, migrations, config, omitted []string
+	changedLines, sourceChanged, testsChanged := 0, false, false
+	for _, f := range d.Files {
+		changedLines += f.Added + f.Removed
+		base := strings.ToLower(path.Base(f.Path))
+		lower := strings.ToLower(f.Path)
+		if f.Status == "deleted" {
+			deleted = append(deleted, f.Path)
+		}
+		switch {
+		case dependencyManifests[base]:
+			deps = append(deps, f.Path)
+		case strings.Contains(lower, "migration") || strings.HasSuffix(lower, ".sql"):
+			migrations = append(migrations, f.Path)
+		case strings.HasPrefix(lower, ".github/") || strings.HasPrefix(base, "dockerfile") || strings.HasPrefix(base, ".env") ||
+			base == "makefile" || strings.Contains(base, "docker-compose"):
+			config = append(config, f.Path)
+		}
+		if f.Omitted {
+			omitted = append(omitted, f.Path)
+		}
+		if isTestPath(lower) {
+			testsChanged = true
+		} else if sourceExtensions[path.Ext(lower)] {
+			sourceChanged = true
+		}
+	}
+	if len(deleted) > 0 {
+		risks = append(risks, fmt.Sprintf("Deletes %s.", codeList(deleted)))
+	}
+	if len(deps) > 0 {
+		risks = append(risks, fmt.Sprintf("Changes dependencies (%s).", codeList(deps)))
+	}
+	if len(migrations) > 0 {
+		risks = append(risks, fmt.Sprintf("Touches migrations or schema (%s); check rollout and rollback.", codeList(migrations)))
+	}
+	if len(config) > 0 {
+		risks = append(risks, fmt.Sprintf("Changes build, CI or environment configuration (%s).", codeList(config)))
+	}
+	if changedLines > largeChangeLines {
+		risks = append(risks, fmt.Sprintf("Large change (%d lines); consider splitting it for review.", changedLines))
+	}
+	if sourceChanged && !testsChanged {
+		risks = append(risks, "Source files changed without changes to tests.")
+	}
+	if len(d.Files) > 0 && len(d.Tests) == 0 {
+		risks = append(risks, "No tests or checks were run in this session.")
+	}
+	// Only the latest run of each command counts; earlier failures may have been fixed
+	latest := map[string]CommandOutcome{}
+	var order []string
+	for _, t := range d.Tests {
+		if _, seen := latest[t.Command]; !seen {
+			order = append(order, t.Command)
+		}
+		latest[t.Command] = t
+	}
+	for _, c := range order {
+		if t := latest[c]; t.ExitCode != 0 {
+			risks = append(risks, fmt.Sprintf("`%s` failed on its last run (exit %d).", c, t.ExitCode))
+		}
+	}
+	var open []string
+	for _, t := range d.Todos {
+		if !t.Completed {
+			open = append(open, t.Task)
+		}
+	}
+	if len(open) > 0 {
+		risks = append(risks, fmt.Sprintf("%d todo item(s) still open: %s.", len(open), strings.Join(open, "; ")))
+	}
+	if len(omitted) > 0 {
+		risks = append(risks, fmt.Sprintf("Diffs not recorded for large files (%s).", codeList(omitted)))
+	}
+	return risks
+}
+
+var dependencyManifests = map[string]bool{
+	"go.mod": true, "go.sum": true, "package.json": true, "package-lock.json": true, "yarn.lock": true,
+	"pnpm-lock.yaml": true, "composer.json": true, "composer.lock": true, "requirements.txt": true,
+	"pyproject.toml": true, "poetry.lock": true, "cargo.toml": true, "cargo.lock": true, "gemfile": true,
+	"gemfile.lock": true, "pom.xml": true, "build.gradle": true,
+}
+
+var sourceExtensions = map[string]bool{
+	".go": true, ".js": true, ".jsx": true, ".ts": true, ".tsx": true, ".vue": true, ".py": true, ".php": true,
+	".rb": true, ".rs": true, ".java": true, ".kt": true, ".cs": true, ".swift": true, ".c": true, ".cpp": true, ".h": true,
+}
+
+func isTestPath(lower string) bool {
+	base := path.Base(lower)
+	return strings.Contains(base, "_test.") || strings.Contains(base, ".test.") || strings.Contains(base, ".spec.") ||
+		strings.HasPrefix(base, "test_") || strings.HasSuffix(strings.TrimSuffix(base, path.Ext(base)), "test") ||
+		strings.Contains(lower, "/tests/") || strings.HasPrefix(lower, "tests/") || strings.Contains(lower, "__tests__/")
+}
+
+// render formats the description as Markdown.
+func (d *ChangeDescription) render() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n## Summary\n\n", d.Title)
+	if d.Summary != "" {
+		b.WriteString(d.Summary + "\n\n")
+	} else {
+		b.WriteString("_No summary was given in this session._\n\n")
+	}
+	if len(d.Rationale) > 0 {
+		b.WriteString("## Rationale\n\nRequested in the session:\n")
+		for _, r := range d.Rationale {
+			fmt.Fprintf(&b, "- %s\n", r)
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString("## Changes\n\n")
+	if len(d.Files) == 0 {
+		b.WriteString("_No file changes were recorded._\n")
+	}
+	for _, f := range d.Files {
+		if f.Omitted {
+			fmt.Fprintf(&b, "- `%s` (%s)\n", f.Path, f.Status)
+		} else {
+			fmt.Fprintf(&b, "- `%s` (%s, +%d −%d)\n", f.Path, f.Status, f.Added, f.Removed)
+		}
+	}
+	if len(d.Commits) > 0 {
+		b.WriteString("\nCommits:\n")
+		for _, c := range d.Commits {
+			fmt.Fprintf(&b, "- %s\n", c)
+		}
+	}
+	b.WriteString("\n## Test evidence\n\n")
+	if len(d.Tests) == 0 {
+		b.WriteString("_No tests or checks were run in this session._\n")
+	}
+	for _, t := range d.Tests {
+		mark := "✅"
+		if t.ExitCode != 0 {
+			mark = "❌"
+		}
+		fmt.Fprintf(&b, "- %s `%s` (exit %d)\n", mark, t.Command, t.ExitCode)
+		if t.Tail != "" {
+			fmt.Fprintf(&b, "  ```\n  %s\n  ```\n", strings.ReplaceAll(t.Tail, "\n", "\n  "))
+		}
+	}
+	if len(d.ReviewNotes) > 0 {
+		b.WriteString("\n## Notes for reviewers\n\n")
+		for _, n := range d.ReviewNotes {
+			fmt.Fprintf(&b, "- %s\n", n)
+		}
+	}
+	b.WriteString("\n## Risks\n\n")
+	if len(d.Risks) == 0 {
+		b.WriteString("_None identified._\n")
+	}
+	for _, r := range d.Risks {
+		fmt.Fprintf(&b, "- %s\n", r)
+	}
+	if len(d.Todos) > 0 {
+		b.WriteString("\n## Tasks\n\n")
+		for _, t := range d.Todos {
+			box := " "
+			if t.Completed {
+				box = "x"
+			}
+			fmt.Fprintf(&b, "- [%s] %s\n", box, t.Task)
+		}
+	}
+	return b.String()
+}
+
+// headline returns the first non-blank line of s, trimmed.
+func headline(s string) string {
+	return strings.TrimSpace(firstLine(strings.TrimSpace(s)))
+}
+
+func truncateRunes(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return strings.TrimSpace(string(r[:n-1])) + "…"
+}
+
+func codeList(paths []string) string {
+	quoted := make([]string, len(paths))
+	for i, p := range paths {
+		quoted[i] = "`" + p + "`"
+	}
+	return strings.Join(quoted, ", ")
+}
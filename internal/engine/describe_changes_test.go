@@ -0,0 +1,72 @@
+package engine
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/loom/loom/internal/memory"
+	"github.com/loom/loom/internal/tool"
+)
+
+func TestDescribeSession_BuildsPRDescription(t *testing.T) {
+	history := []memory.Message{
+		{Role: "system", Content: "prompt"},
+		{Role: "user", Content: "Add rate limiting to the login endpoint.\nUse a token bucket."},
+		{Role: "assistant", Name: "apply_shell", ToolID: "s1", Content: `{"command":"go","args":["test","./..."]}`},
+		{Role: "tool", Name: "apply_shell", ToolID: "s1", Content: `{"stdout":"--- FAIL: TestLogin\nFAIL","exit_code":1}`},
+		{Role: "user", Content: doneRejectionPrefix + "\n{}"},
+		{Role: "assistant", Name: "apply_shell", ToolID: "s2", Content: `{"command":"go test ./...","shell":true}`},
+		{Role: "tool", Name: "apply_shell", ToolID: "s2", Content: `{"stdout":"ok  \tapp/auth\t0.2s","exit_code":0}`},
+		{Role: "assistant", Name: "apply_shell", ToolID: "s3", Content: `{"command":"git commit -m \"Add login rate limiter\"","shell":true}`},
+		{Role: "tool", Name: "apply_shell", ToolID: "s3", Content: `{"exit_code":0}`},
+		{Role: "assistant", Name: "git_commit", ToolID: "g1", Content: `{"message":"Tune limits\n\nDetails"}`},
+		{Role: "tool", Name: "git_commit", ToolID: "g1", Content: "committed"},
+		{Role: "assistant", Name: "finalize", ToolID: "f1", Content: `{"summary":"Login requests are limited per IP."}`},
+		{Role: "assistant", Content: "Done."},
+	}
+	diff := &TimelineDiff{Files: []TimelineFile{
+		{Path: "auth/login.go", Status: "modified", Added: 20, Removed: 2, Diff: "diff"},
+		{Path: "go.mod", Status: "modified", Added: 1},
+		{Path: "auth/old.go", Status: "deleted", Removed: 10},
+	}}
+	todos := []tool.TodoTask{{Task: "Add limiter", Completed: true}, {Task: "Document limits"}}
+
+	d := describeSession(history, diff, todos)
+	if d.Title != "Add rate limiting to the login endpoint" {
+		t.Errorf("unexpected title %q", d.Title)
+	}
+	if d.Summary != "Login requests are limited per IP." {
+		t.Errorf("finalize summary should win: %q", d.Summary)
+	}
+	if len(d.Rationale) != 1 {
+		t.Errorf("definition-of-done feedback is not a request: %v", d.Rationale)
+	}
+	if len(d.Tests) != 2 || d.Tests[0].ExitCode != 1 || d.Tests[1].ExitCode != 0 {
+		t.Fatalf("unexpected test evidence %+v", d.Tests)
+	}
+	if strings.Join(d.Commits, "|") != "Add login rate limiter|Tune limits" {
+		t.Errorf("unexpected commits %v", d.Commits)
+	}
+	risks := strings.Join(d.Risks, "\n")
+	for _, want := range []string{"Deletes `auth/old.go`", "Changes dependencies (`go.mod`)", "without changes to tests", "1 todo item(s) still open: Document limits"} {
+		if !strings.Contains(risks, want) {
+			t.Errorf("risks missing %q:\n%s", want, risks)
+		}
+	}
+	// The failing run was fixed by the later run of the same command
+	if strings.Contains(risks, "failed") {
+		t.Errorf("fixed failure reported as a risk:\n%s", risks)
+	}
+	for _, want := range []string{"# Add rate limiting", "## Summary", "## Rationale", "- `auth/login.go` (modified, +20 −2)", "✅ `go test ./...` (exit 0)", "## Risks", "- [ ] Document limits"} {
+		if !strings.Contains(d.Markdown, want) {
+			t.Errorf("markdown missing %q:\n%s", want, d.Markdown)
+		}
+	}
+}
+
+func TestDescribeSession_NoChanges(t *testing.T) {
+	d := describeSession([]memory.Message{{Role: "user", Content: "What does main.go do?"}, {Role: "assistant", Content: "It starts the server."}}, nil, nil)
+	if len(d.Risks) != 0 || !strings.Contains(d.Markdown, "_No file changes were recorded._") || d.Summary != "It starts the server." {
+		t.Fatalf("unexpected description %+v", d)
+	}
+}
@@ -0,0 +1,68 @@
+package engine
+
+import (
+	"sync"
+	"time"
+
+	"github.com/loom/loom/internal/tool"
+)
+
+// recentErrorLimit is how many errors the engine remembers for diagnostics.
+const recentErrorLimit = 20
+
+// EngineError is a provider or tool failure that ended a run.
+type EngineError struct {
+	Time    time.Time `json:"time"`
+	Source  string    `json:"source"` // "llm" or "tool"
+	Message string    `json:"message"`
+}
+
+// errorLog is a bounded list of the most recent errors, oldest first.
+type errorLog struct {
+	mu      sync.Mutex
+	entries []EngineError
+}
+
+func (l *errorLog) add(source string, err error) {
+	if err == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.entries = append(l.entries, EngineError{Time: time.Now(), Source: source, Message: err.Error()})
+	if len(l.entries) > recentErrorLimit {
+		l.entries = append([]EngineError(nil), l.entries[len(l.entries)-recentErrorLimit:]...)
+	}
+}
+
+func (l *errorLog) snapshot() []EngineError {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]EngineError(nil), l.entries...)
+}
+
+// Queues reports work waiting inside the engine.
+type Queues struct {
+	PendingApprovals int              `json:"pending_approvals"`
+	PendingChoices   int              `json:"pending_choices"`
+	PendingQuestions int              `json:"pending_questions"`
+	ToolSlots        []tool.SlotUsage `json:"tool_slots,omitempty"`
+}
+
+// Queues returns the number of pending user interactions and the occupancy of
+// concurrency-limited tools.
+func (e *Engine) Queues() Queues {
+	var q Queues
+	if e.approvalHandler != nil {
+		q.PendingApprovals, q.PendingChoices, q.PendingQuestions = e.approvalHandler.Pending()
+	}
+	if e.tools != nil {
+		q.ToolSlots = e.tools.SlotUsage()
+	}
+	return q
+}
+
+// RecentErrors returns the latest provider and tool errors, oldest first.
+func (e *Engine) RecentErrors() []EngineError {
+	return e.errLog.snapshot()
+}
@@ -0,0 +1,38 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestErrorLog_KeepsMostRecent(t *testing.T) {
+	var l errorLog
+	l.add("llm", nil)
+	for i := 0; i < recentErrorLimit+5; i++ {
+		l.add("tool", fmt.Errorf("failure %d", i))
+	}
+	got := l.snapshot()
+	if len(got) != recentErrorLimit {
+		t.Fatalf("expected %d entries, got %d", recentErrorLimit, len(got))
+	}
+	if got[0].Message != "failure 5" || got[len(got)-1].Message != fmt.Sprintf("failure %d", recentErrorLimit+4) {
+		t.Fatalf("unexpected window: first=%q last=%q", got[0].Message, got[len(got)-1].Message)
+	}
+}
+
+func TestEngine_QueuesAndRecentErrors(t *testing.T) {
+	e := New(nil, nil)
+	e.approvalHandler.approvals["a"] = make(chan bool, 1)
+	e.approvalHandler.questions["q"] = make(chan questionReply, 1)
+	e.errLog.add("llm", errors.New("rate limited"))
+
+	q := e.Queues()
+	if q.PendingApprovals != 1 || q.PendingChoices != 0 || q.PendingQuestions != 1 {
+		t.Fatalf("unexpected queues: %+v", q)
+	}
+	errs := e.RecentErrors()
+	if len(errs) != 1 || errs[0].Source != "llm" || errs[0].Message != "rate limited" {
+		t.Fatalf("unexpected errors: %+v", errs)
+	}
+}
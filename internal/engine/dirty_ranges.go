@@ -0,0 +1,116 @@
+package engine
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/loom/loom/internal/config"
+	"github.com/loom/loom/internal/editor"
+	"github.com/loom/loom/internal/tool"
+)
+
+// dirtyBuffers holds the line ranges the user has changed but not saved in the UI
+// editor, keyed by workspace-relative path, and decides what happens to edits that
+// would overwrite them.
+type dirtyBuffers struct {
+	mu     sync.Mutex
+	ranges map[string][]editor.LineRange
+	policy string
+}
+
+// SetDirtyRanges records the unsaved line ranges (in on-disk line numbers) of a file
+// open in the UI editor. An empty list marks the file as saved.
+func (e *Engine) SetDirtyRanges(path string, ranges []editor.LineRange) {
+	path = filepath.ToSlash(filepath.Clean(strings.TrimSpace(path)))
+	e.dirty.mu.Lock()
+	defer e.dirty.mu.Unlock()
+	if len(ranges) == 0 {
+		delete(e.dirty.ranges, path)
+		return
+	}
+	if e.dirty.ranges == nil {
+		e.dirty.ranges = make(map[string][]editor.LineRange)
+	}
+	e.dirty.ranges[path] = append([]editor.LineRange(nil), ranges...)
+}
+
+// SetDirtyEditPolicy chooses whether edits touching unsaved lines are refused
+// (config.DirtyEditRefuse, the default) or applied with a warning (config.DirtyEditWarn).
+func (e *Engine) SetDirtyEditPolicy(policy string) {
+	e.dirty.mu.Lock()
+	defer e.dirty.mu.Unlock()
+	e.dirty.policy = policy
+}
+
+// DirtyRanges returns the unsaved line ranges of path, if any.
+func (e *Engine) DirtyRanges(path string) []editor.LineRange {
+	e.dirty.mu.Lock()
+	defer e.dirty.mu.Unlock()
+	return append([]editor.LineRange(nil), e.dirty.ranges[filepath.ToSlash(filepath.Clean(path))]...)
+}
+
+// check compares an edit_file or apply_edit call with the unsaved ranges of its file.
+// It returns a message naming the overlapping lines, or "" when the edit does not touch
+// them; refuse reports whether the policy blocks the edit.
+func (d *dirtyBuffers) check(workspace string, call *tool.ToolCall) (msg string, refuse bool) {
+	if call.Name != "edit_file" && call.Name != "apply_edit" {
+		return "", false
+	}
+	var args tool.EditFileArgs
+	if err := json.Unmarshal(call.Args, &args); err != nil {
+		return "", false
+	}
+	rel := args.Path
+	if filepath.IsAbs(rel) {
+		if r, err := filepath.Rel(workspace, rel); err == nil {
+			rel = r
+		}
+	}
+	rel = filepath.ToSlash(filepath.Clean(rel))
+	d.mu.Lock()
+	dirty, policy := d.ranges[rel], d.policy
+	d.mu.Unlock()
+	if len(dirty) == 0 {
+		return "", false
+	}
+
+	plan, err := editor.ProposeAdvancedEdit(workspace, editor.AdvancedEditRequest{
+		FilePath:            args.Path,
+		Action:              editor.ActionType(args.Action),
+		Content:             args.Content,
+		StartLine:           args.StartLine,
+		EndLine:             args.EndLine,
+		Line:                args.Line,
+		OldString:           args.OldString,
+		NewString:           args.NewString,
+		AnchorBefore:        args.AnchorBefore,
+		Target:              args.Target,
+		AnchorAfter:         args.AnchorAfter,
+		NormalizeWhitespace: args.NormalizeWhitespace,
+		FuzzyThreshold:      args.FuzzyThreshold,
+		Occurrence:          args.Occurrence,
+		OccurrenceBefore:    args.OccurrenceBefore,
+		OccurrenceAfter:     args.OccurrenceAfter,
+	})
+	if err != nil {
+		// The tool reports invalid edits itself
+		return "", false
+	}
+	touched := editor.OverlappingRanges(dirty, editor.ChangedLineRanges(plan.OldContent, plan.NewContent))
+	if len(touched) == 0 {
+		return "", false
+	}
+
+	lines := editor.FormatLineRanges(touched)
+	if policy == config.DirtyEditWarn {
+		// The proposal already carried the warning
+		if call.Name == "apply_edit" {
+			return "", false
+		}
+		return fmt.Sprintf("Warning: this edit changes lines %s of %s, which the user is editing in the Loom editor and has not saved. Their unsaved changes may conflict with it.", lines, rel), false
+	}
+	return fmt.Sprintf("Edit refused: lines %s of %s have unsaved changes in the user's editor. Do not change those lines; edit other parts of the file, or ask the user to save or discard their changes first.", lines, rel), true
+}
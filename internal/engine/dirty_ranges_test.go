@@ -0,0 +1,54 @@
+package engine
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/loom/loom/internal/config"
+	"github.com/loom/loom/internal/editor"
+	"github.com/loom/loom/internal/tool"
+)
+
+func TestDirtyBuffers_Check(t *testing.T) {
+	workspace := t.TempDir()
+	content := "package a\n\nfunc A() {}\n\nfunc B() {}\n"
+	if err := os.WriteFile(filepath.Join(workspace, "a.go"), []byte(content), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	e := New(nil, nil)
+	e.SetDirtyRanges("./a.go", []editor.LineRange{{StartLine: 3, EndLine: 3}})
+	if got := e.DirtyRanges("a.go"); len(got) != 1 {
+		t.Fatalf("expected ranges to be stored under the clean path, got %v", got)
+	}
+
+	call := func(name, target, replacement string) *tool.ToolCall {
+		args, _ := json.Marshal(map[string]string{"path": "a.go", "action": "ANCHOR_REPLACE", "target": target, "content": replacement})
+		return &tool.ToolCall{Name: name, Args: args}
+	}
+
+	msg, refuse := e.dirty.check(workspace, call("edit_file", "func A() {}", "func A() { return }"))
+	if !refuse || !strings.Contains(msg, "lines 3 of a.go") {
+		t.Fatalf("expected refusal for dirty line, got %q refuse=%v", msg, refuse)
+	}
+	if msg, refuse := e.dirty.check(workspace, call("edit_file", "func B() {}", "func B() { return }")); msg != "" || refuse {
+		t.Fatalf("edits outside dirty ranges should pass, got %q", msg)
+	}
+
+	e.SetDirtyEditPolicy(config.DirtyEditWarn)
+	msg, refuse = e.dirty.check(workspace, call("edit_file", "func A() {}", "func A() { return }"))
+	if refuse || !strings.HasPrefix(msg, "Warning:") {
+		t.Fatalf("expected warning, got %q refuse=%v", msg, refuse)
+	}
+	if msg, _ := e.dirty.check(workspace, call("apply_edit", "func A() {}", "func A() { return }")); msg != "" {
+		t.Fatalf("apply_edit should not repeat the warning, got %q", msg)
+	}
+
+	e.SetDirtyRanges("a.go", nil)
+	e.SetDirtyEditPolicy(config.DirtyEditRefuse)
+	if msg, _ := e.dirty.check(workspace, call("edit_file", "func A() {}", "func A() { return }")); msg != "" {
+		t.Fatalf("saved files should not be guarded, got %q", msg)
+	}
+}
@@ -0,0 +1,60 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/loom/loom/internal/tool"
+)
+
+// DisabledTools returns the tools switched off for the current conversation.
+func (e *Engine) DisabledTools() []string {
+	if e.memory == nil {
+		return nil
+	}
+	return e.memory.DisabledTools(e.memory.CurrentConversationID())
+}
+
+// SetToolEnabled switches a tool on or off for the current conversation. Disabled
+// tools are left out of the schemas sent to the model from the next request on.
+func (e *Engine) SetToolEnabled(name string, enabled bool) error {
+	if e.memory == nil {
+		return errors.New("memory not initialized")
+	}
+	if e.tools == nil {
+		return errors.New("tool registry not initialized")
+	}
+	if _, ok := e.tools.Get(name); !ok && !enabled {
+		return fmt.Errorf("unknown tool %q", name)
+	}
+	var names []string
+	for _, n := range e.DisabledTools() {
+		if n != name {
+			names = append(names, n)
+		}
+	}
+	if !enabled {
+		names = append(names, name)
+	}
+	return e.memory.SetDisabledTools(e.memory.CurrentConversationID(), names)
+}
+
+// withoutDisabledTools drops the schemas of disabled tools.
+func withoutDisabledTools(schemas []tool.Schema, disabled map[string]bool) []tool.Schema {
+	if len(disabled) == 0 {
+		return schemas
+	}
+	out := make([]tool.Schema, 0, len(schemas))
+	for _, s := range schemas {
+		if !disabled[s.Name] {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// disabledToolResult answers a call to a tool the user switched off, e.g. one the
+// model remembered from earlier in the conversation.
+func disabledToolResult(name string) string {
+	return fmt.Sprintf("Error: %s is disabled for this conversation. Continue with the available tools, or ask the user to enable it.", name)
+}
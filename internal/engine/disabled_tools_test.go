@@ -0,0 +1,66 @@
+package engine
+
+import (
+	"testing"
+
+	"github.com/loom/loom/internal/memory"
+	"github.com/loom/loom/internal/tool"
+)
+
+func TestSetToolEnabled_IsScopedToConversation(t *testing.T) {
+	ws := t.TempDir()
+	store, err := memory.NewStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	proj, err := memory.NewProject(store, ws)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reg := tool.NewRegistry()
+	tool.RegisterCoreTools(reg, ws)
+	e := New(nil, nil).WithWorkspace(ws).WithMemory(proj).WithRegistry(reg)
+	if e.NewConversation() == "" {
+		t.Fatal("expected a conversation")
+	}
+
+	if err := e.SetToolEnabled("no_such_tool", false); err == nil {
+		t.Fatal("expected unknown tools to be rejected")
+	}
+	if err := e.SetToolEnabled("run_shell", false); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.SetToolEnabled("http_request", false); err != nil {
+		t.Fatal(err)
+	}
+	if got := e.DisabledTools(); len(got) != 2 || got[0] != "http_request" || got[1] != "run_shell" {
+		t.Fatalf("unexpected disabled tools %v", got)
+	}
+
+	disabled := map[string]bool{}
+	for _, name := range e.DisabledTools() {
+		disabled[name] = true
+	}
+	for _, s := range withoutDisabledTools(reg.Schemas(), disabled) {
+		if s.Name == "run_shell" || s.Name == "http_request" {
+			t.Fatalf("%s should not be offered to the model", s.Name)
+		}
+	}
+
+	first := proj.CurrentConversationID()
+	if err := e.SetCurrentConversationID("other"); err != nil {
+		t.Fatal(err)
+	}
+	if got := e.DisabledTools(); len(got) != 0 {
+		t.Fatalf("another conversation keeps every tool, got %v", got)
+	}
+	if err := e.SetCurrentConversationID(first); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.SetToolEnabled("run_shell", true); err != nil {
+		t.Fatal(err)
+	}
+	if got := e.DisabledTools(); len(got) != 1 || got[0] != "http_request" {
+		t.Fatalf("expected only http_request disabled, got %v", got)
+	}
+}
@@ -0,0 +1,307 @@
+package engine
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/loom/loom/internal/config"
+	"github.com/loom/loom/internal/memory"
+	"github.com/loom/loom/internal/tool"
+	"github.com/loom/loom/internal/toolchain"
+)
+
+const (
+	// maxDoneRejections bounds how often a run is sent back before finalization is
+	// accepted anyway, so a check the model cannot fix does not loop forever.
+	maxDoneRejections  = 3
+	defaultDoneTimeout = 10 * time.Minute
+	// doneOutputTail is how much command output is echoed back in a rejection
+	doneOutputTail = 4000
+)
+
+// DoneCheck is the outcome of one definition-of-done item.
+type DoneCheck struct {
+	Name   string `json:"name"`
+	Passed bool   `json:"passed"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// DoneReport is returned to the model when finalization is evaluated.
+type DoneReport struct {
+	Accepted     bool        `json:"accepted"`
+	Checks       []DoneCheck `json:"checks"`
+	Instructions string      `json:"instructions,omitempty"`
+}
+
+// failed lists the names of failing checks.
+func (r DoneReport) failed() []string {
+	var names []string
+	for _, c := range r.Checks {
+		if !c.Passed {
+			names = append(names, c.Name)
+		}
+	}
+	return names
+}
+
+// doneGuard enforces the workspace's definition of done for a single run.
+type doneGuard struct {
+	cfg       config.DefinitionOfDone
+	workspace string
+
+	mu         sync.Mutex
+	dirty      bool // files changed since the last passing evaluation
+	rejections int
+
+	// approve asks before running a command from the repository-controlled config;
+	// nil runs without asking. Approved commands are not asked again during the run.
+	approve  func(command string) bool
+	approved map[string]bool
+
+	baselineOnce sync.Once
+	lintBaseline map[string]bool
+
+	// audit records each command run; nil disables auditing
+	audit *memory.Project
+}
+
+// newDoneGuard loads the definition of done for workspace, returning nil when none
+// is configured.
+func newDoneGuard(workspace string) *doneGuard {
+	cfg, err := config.LoadDefinitionOfDone(workspace)
+	if err != nil || !cfg.Enabled() {
+		return nil
+	}
+	return &doneGuard{cfg: cfg, workspace: workspace}
+}
+
+// promptSection tells the model how finalization works for this workspace.
+func (g *doneGuard) promptSection() string {
+	var items []string
+	if g.cfg.Build != "" {
+		items = append(items, "the build passes (`"+g.cfg.Build+"`)")
+	}
+	if g.cfg.Test != "" {
+		items = append(items, "tests pass (`"+g.cfg.Test+"`)")
+	}
+	if g.cfg.Lint != "" {
+		items = append(items, "no new lint findings (`"+g.cfg.Lint+"`)")
+	}
+	if g.cfg.RequireTodosComplete {
+		items = append(items, "every todo_list task is completed")
+	}
+	return fmt.Sprintf(`
+
+## Definition of Done
+This project requires that %s before a task is finished. When you are done, call the finalize tool with a summary for the user. If it returns a rejection, fix the failing checks and call finalize again.`, strings.Join(items, ", "))
+}
+
+// ensureBaseline records the lint findings present before the run changes anything.
+// The executor calls it before the first edit or shell proposal; later calls are no-ops.
+func (g *doneGuard) ensureBaseline(ctx context.Context) {
+	if g == nil {
+		return
+	}
+	g.baselineOnce.Do(func() {
+		if g.cfg.Lint == "" {
+			return
+		}
+		out, _, _ := g.run(ctx, g.cfg.Lint)
+		g.lintBaseline = lintFindings(out)
+	})
+}
+
+// markDirty records that the workspace changed, so commands must be re-run.
+func (g *doneGuard) markDirty() {
+	if g == nil {
+		return
+	}
+	g.mu.Lock()
+	g.dirty = true
+	g.mu.Unlock()
+}
+
+// needsCheck reports whether a plain final answer should be evaluated: only runs
+// that changed files are checked, and only until the rejection budget is spent.
+func (g *doneGuard) needsCheck() bool {
+	if g == nil {
+		return false
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.dirty && g.rejections < maxDoneRejections
+}
+
+// evaluate runs the configured checks. Commands are skipped when nothing changed
+// since the last passing evaluation. After maxDoneRejections failures the report is
+// accepted with the failing checks still listed.
+func (g *doneGuard) evaluate(ctx context.Context) DoneReport {
+	g.mu.Lock()
+	dirty := g.dirty
+	g.mu.Unlock()
+
+	var report DoneReport
+	if g.cfg.RequireTodosComplete {
+		check := DoneCheck{Name: "todos", Passed: true}
+		if pending := tool.PendingTodos(); len(pending) > 0 {
+			check.Passed = false
+			var tasks []string
+			for _, t := range pending {
+				tasks = append(tasks, fmt.Sprintf("%s (%s)", t.Task, t.ID))
+			}
+			check.Detail = "Incomplete tasks: " + strings.Join(tasks, "; ")
+		}
+		report.Checks = append(report.Checks, check)
+	}
+	if dirty {
+		for _, c := range []struct{ name, cmd string }{{"build", g.cfg.Build}, {"test", g.cfg.Test}} {
+			if strings.TrimSpace(c.cmd) == "" {
+				continue
+			}
+			out, code, err := g.run(ctx, c.cmd)
+			check := DoneCheck{Name: c.name, Passed: err == nil && code == 0}
+			switch {
+			case err != nil:
+				check.Detail = err.Error()
+			case !check.Passed:
+				check.Detail = fmt.Sprintf("`%s` exited with %d:\n%s", c.cmd, code, tail(out, doneOutputTail))
+			}
+			report.Checks = append(report.Checks, check)
+		}
+		if strings.TrimSpace(g.cfg.Lint) != "" {
+			report.Checks = append(report.Checks, g.lintCheck(ctx))
+		}
+	}
+
+	report.Accepted = len(report.failed()) == 0
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	switch {
+	case report.Accepted:
+		g.dirty = false
+	case g.rejections+1 >= maxDoneRejections:
+		report.Accepted = true
+		report.Instructions = "Definition of done still failing after repeated attempts; finalization accepted. Tell the user which checks fail."
+	default:
+		g.rejections++
+		report.Instructions = fmt.Sprintf("Finalization rejected: fix the failing checks (%s), then call finalize again.", strings.Join(report.failed(), ", "))
+	}
+	return report
+}
+
+// lintCheck passes when the linter reports nothing that was absent from the baseline.
+func (g *doneGuard) lintCheck(ctx context.Context) DoneCheck {
+	g.ensureBaseline(ctx)
+	out, _, err := g.run(ctx, g.cfg.Lint)
+	if err != nil {
+		return DoneCheck{Name: "lint", Detail: err.Error()}
+	}
+	var added []string
+	for f := range lintFindings(out) {
+		if !g.lintBaseline[f] {
+			added = append(added, f)
+		}
+	}
+	if len(added) == 0 {
+		return DoneCheck{Name: "lint", Passed: true}
+	}
+	return DoneCheck{Name: "lint", Detail: fmt.Sprintf("%d new finding(s):\n%s", len(added), tail(strings.Join(added, "\n"), doneOutputTail))}
+}
+
+// allowed reports whether command may run, asking for approval the first time.
+func (g *doneGuard) allowed(command string) bool {
+	if g.approve == nil {
+		return true
+	}
+	g.mu.Lock()
+	ok, asked := g.approved[command]
+	g.mu.Unlock()
+	if asked {
+		return ok
+	}
+	ok = g.approve(command)
+	g.mu.Lock()
+	if g.approved == nil {
+		g.approved = map[string]bool{}
+	}
+	g.approved[command] = ok
+	g.mu.Unlock()
+	return ok
+}
+
+// run executes a shell command in the workspace and returns combined output.
+func (g *doneGuard) run(ctx context.Context, command string) (string, int, error) {
+	if !g.allowed(command) {
+		return "", -1, fmt.Errorf("command not approved: %s", command)
+	}
+	timeout := defaultDoneTimeout
+	if g.cfg.TimeoutSec > 0 {
+		timeout = time.Duration(g.cfg.TimeoutSec) * time.Second
+	}
+	return runAuditedCommand(ctx, g.audit, g.workspace, command, "definition_of_done", timeout)
+}
+
+// runAuditedCommand runs command with sh in dir, records it in the audit log under
+// source and returns the combined output and exit code. A non-zero exit is not an error.
+func runAuditedCommand(ctx context.Context, audit *memory.Project, dir, command, source string, timeout time.Duration) (string, int, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	cmd.Dir = dir
+	cmd.Env = toolchain.Env(dir)
+	var buf bytes.Buffer
+	cmd.Stdout = &buf
+	cmd.Stderr = &buf
+	err := cmd.Run()
+	code := 0
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		code, err = exitErr.ExitCode(), nil
+	} else if err != nil {
+		code = -1
+	}
+	_ = audit.RecordAudit("shell", map[string]any{
+		"command":       command,
+		"source":        source,
+		"ok":            err == nil && code == 0,
+		"exit_code":     code,
+		"output_sha256": sha256Hex(buf.Bytes()),
+	})
+	return buf.String(), code, err
+}
+
+// lintPosition strips line:column positions so findings survive unrelated line shifts.
+var lintPosition = regexp.MustCompile(`:\d+(:\d+)?`)
+
+func lintFindings(out string) map[string]bool {
+	set := map[string]bool{}
+	for _, line := range strings.Split(out, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			set[lintPosition.ReplaceAllString(line, "")] = true
+		}
+	}
+	return set
+}
+
+func tail(s string, n int) string {
+	s = strings.TrimSpace(s)
+	if len(s) <= n {
+		return s
+	}
+	return "…" + s[len(s)-n:]
+}
+
+// doneRejectionPrefix starts the user message sent when the definition of done fails.
+const doneRejectionPrefix = "Definition of done not met:"
+
+// rejectionMessage renders a report for the conversation.
+func (r DoneReport) rejectionMessage() string {
+	b, _ := json.MarshalIndent(r, "", "  ")
+	return doneRejectionPrefix + "\n" + string(b)
+}
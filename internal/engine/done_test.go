@@ -0,0 +1,97 @@
+package engine
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeDone(t *testing.T, ws, body string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Join(ws, ".loom"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(ws, ".loom", "done.json"), []byte(body), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDoneGuard_UnapprovedCommandFails(t *testing.T) {
+	ws := t.TempDir()
+	writeDone(t, ws, `{"test": "true"}`)
+	g := newDoneGuard(ws)
+	asked := 0
+	g.approve = func(string) bool { asked++; return false }
+	g.markDirty()
+	for i := 0; i < 2; i++ {
+		if r := g.evaluate(context.Background()); r.Accepted || !strings.Contains(r.Checks[0].Detail, "not approved") {
+			t.Fatalf("expected rejection for unapproved command: %+v", r)
+		}
+	}
+	if asked != 1 {
+		t.Fatalf("approval should be asked once per run, asked %d times", asked)
+	}
+}
+
+func TestDoneGuard_DisabledWithoutConfig(t *testing.T) {
+	if g := newDoneGuard(t.TempDir()); g != nil {
+		t.Fatalf("expected nil guard without done.json")
+	}
+}
+
+func TestDoneGuard_RejectsFailingBuildUntilBudgetSpent(t *testing.T) {
+	ws := t.TempDir()
+	writeDone(t, ws, `{"build": "echo broken >&2; exit 2", "test": "true"}`)
+	g := newDoneGuard(ws)
+	if g == nil {
+		t.Fatal("expected guard")
+	}
+	if g.needsCheck() {
+		t.Fatal("clean run should not need a check")
+	}
+	g.markDirty()
+
+	for i := 1; i < maxDoneRejections; i++ {
+		r := g.evaluate(context.Background())
+		if r.Accepted {
+			t.Fatalf("attempt %d: expected rejection", i)
+		}
+		if got := r.failed(); len(got) != 1 || got[0] != "build" {
+			t.Fatalf("unexpected failing checks %v", got)
+		}
+		if !strings.Contains(r.Checks[0].Detail, "broken") || !strings.Contains(r.Checks[0].Detail, "exited with 2") {
+			t.Fatalf("detail missing output: %q", r.Checks[0].Detail)
+		}
+	}
+	r := g.evaluate(context.Background())
+	if !r.Accepted || len(r.failed()) != 1 {
+		t.Fatalf("expected forced acceptance with failure listed, got %+v", r)
+	}
+}
+
+func TestDoneGuard_LintOnlyFailsOnNewFindings(t *testing.T) {
+	ws := t.TempDir()
+	findings := filepath.Join(ws, "lint.txt")
+	if err := os.WriteFile(findings, []byte("a.go:3:1: old issue\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	writeDone(t, ws, `{"lint": "cat lint.txt"}`)
+	g := newDoneGuard(ws)
+	g.ensureBaseline(context.Background())
+
+	// The old finding moved lines; that alone must not fail the check
+	_ = os.WriteFile(findings, []byte("a.go:9:1: old issue\n"), 0o644)
+	g.markDirty()
+	if r := g.evaluate(context.Background()); !r.Accepted {
+		t.Fatalf("shifted finding should pass: %+v", r)
+	}
+
+	_ = os.WriteFile(findings, []byte("a.go:9:1: old issue\nb.go:1:1: new issue\n"), 0o644)
+	g.markDirty()
+	r := g.evaluate(context.Background())
+	if r.Accepted || !strings.Contains(r.Checks[0].Detail, "new issue") {
+		t.Fatalf("expected new finding to fail: %+v", r)
+	}
+}
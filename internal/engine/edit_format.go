@@ -0,0 +1,27 @@
+package engine
+
+import "github.com/loom/loom/internal/config"
+
+// searchReplacePrompt asks the model for content-matched SEARCH/REPLACE blocks instead
+// of line-addressed edits, for models configured to follow that format more reliably.
+const searchReplacePrompt = `
+
+## Edit Format: SEARCH/REPLACE blocks
+Edit existing files with edit_file action SEARCH_REPLACE_BLOCKS. Put one or more blocks in content:
+<<<<<<< SEARCH
+exact existing lines, copied from the file
+=======
+the lines that replace them
+>>>>>>> REPLACE
+- Each SEARCH section must match exactly one place in the current file; include a few surrounding lines when the text repeats.
+- Blocks are applied in order, each to the result of the previous one.
+- Do not use line numbers. Use CREATE for new files.`
+
+// editFormatFor returns the edit format configured in settings for the model label.
+func editFormatFor(modelLabel string) string {
+	settings, err := config.Load()
+	if err != nil {
+		return config.EditFormatAnchor
+	}
+	return settings.EditFormatFor(modelLabel)
+}
@@ -0,0 +1,351 @@
+package engine
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/loom/loom/internal/editor"
+	"github.com/loom/loom/internal/memory"
+	"github.com/loom/loom/internal/tool"
+)
+
+// maxJournalEntries bounds the edits kept in a workspace's journal; the oldest go first.
+const maxJournalEntries = 500
+
+// journalSeq keeps ids of edits journaled within the same instant apart.
+var journalSeq atomic.Uint64
+
+// JournalEntry is an applied edit in the workspace's edit journal, with the file's
+// contents before and after it.
+type JournalEntry struct {
+	ID             string `json:"id"`
+	ConversationID string `json:"conversation_id"`
+	// CallID is the apply_edit call; the edits of one approved proposal share its prefix
+	CallID string `json:"call_id,omitempty"`
+	Path   string `json:"path"`
+	Before string `json:"before,omitempty"`
+	After  string `json:"after,omitempty"`
+	// Created and Deleted mark a file that did not exist before or after the edit
+	Created bool      `json:"created,omitempty"`
+	Deleted bool      `json:"deleted,omitempty"`
+	Source  string    `json:"source"`
+	At      time.Time `json:"at"`
+}
+
+// group identifies the edits undone together: the files one run_macro approval
+// changed are applied as "<call id>:apply:<n>".
+func (j JournalEntry) group() string {
+	if head, _, ok := strings.Cut(j.CallID, ":apply:"); ok {
+		return j.ConversationID + "/" + head
+	}
+	return j.ID
+}
+
+// EditJournalDir returns where a workspace's edit journal is kept, one JSON file per
+// applied edit.
+func EditJournalDir(workspace string) string {
+	return filepath.Join(workspace, ".loom", "history")
+}
+
+// editJournal writes the edits of one conversation to the workspace's journal.
+type editJournal struct {
+	// project encrypts entries when the workspace's data is encrypted at rest; nil writes plaintext
+	project        *memory.Project
+	workspace      string
+	conversationID string
+}
+
+// record journals an applied change. Failures only cost the undo.
+func (j *editJournal) record(cp memory.Checkpoint) {
+	if j == nil || j.conversationID == "" {
+		return
+	}
+	_ = appendJournal(j.workspace, projectSealer(j.project), JournalEntry{
+		ConversationID: j.conversationID,
+		CallID:         cp.CallID,
+		Path:           cp.Path,
+		Before:         cp.Before,
+		After:          cp.After,
+		Created:        cp.Created,
+		Deleted:        cp.Deleted,
+		Source:         cp.Source,
+		At:             cp.At,
+	})
+}
+
+// projectSealer returns the project's sealer, or nil without a project.
+func projectSealer(p *memory.Project) *memory.Sealer {
+	if p == nil {
+		return nil
+	}
+	return p.Sealer()
+}
+
+// appendJournal writes an entry to the journal, encrypted with sealer unless it is nil,
+// and drops entries past maxJournalEntries.
+func appendJournal(workspace string, sealer *memory.Sealer, entry JournalEntry) error {
+	dir := EditJournalDir(workspace)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	// Keep the journal out of version control
+	ignore := filepath.Join(dir, ".gitignore")
+	if _, err := os.Stat(ignore); os.IsNotExist(err) {
+		_ = os.WriteFile(ignore, []byte("*\n"), 0o644)
+	}
+	if entry.At.IsZero() {
+		entry.At = time.Now()
+	}
+	// Ids sort in the order the edits were made
+	entry.ID = fmt.Sprintf("%s-%06d", entry.At.UTC().Format("20060102T150405.000000000"), journalSeq.Add(1)%1e6)
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	if err := memory.WriteSealed(sealer, filepath.Join(dir, entry.ID+".json"), data); err != nil {
+		return err
+	}
+	ids, err := journalIDs(workspace)
+	if err != nil {
+		return err
+	}
+	for _, id := range ids[min(len(ids), maxJournalEntries):] {
+		_ = os.Remove(filepath.Join(dir, id+".json"))
+	}
+	return nil
+}
+
+// journalIDs returns the ids in the journal, newest first.
+func journalIDs(workspace string) ([]string, error) {
+	files, err := os.ReadDir(EditJournalDir(workspace))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var ids []string
+	for _, f := range files {
+		if id, ok := strings.CutSuffix(f.Name(), ".json"); ok && !f.IsDir() {
+			ids = append(ids, id)
+		}
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(ids)))
+	return ids, nil
+}
+
+// readJournal returns the journal's entries, newest first, decrypting them with sealer.
+// Unreadable entries are skipped.
+func readJournal(workspace string, sealer *memory.Sealer) ([]JournalEntry, error) {
+	ids, err := journalIDs(workspace)
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]JournalEntry, 0, len(ids))
+	for _, id := range ids {
+		data, err := memory.ReadSealed(sealer, filepath.Join(EditJournalDir(workspace), id+".json"))
+		if err != nil {
+			continue
+		}
+		var entry JournalEntry
+		if json.Unmarshal(data, &entry) != nil {
+			continue
+		}
+		entry.ID = id
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// EditJournal returns the workspace's journaled edits, newest first.
+func (e *Engine) EditJournal() ([]JournalEntry, error) {
+	return readJournal(e.Workspace(), e.Sealer())
+}
+
+// UndoResult reports the files an undo restored.
+type UndoResult struct {
+	// Reverted lists the restored files, once each
+	Reverted []string `json:"reverted"`
+	// Undone counts the journal entries undone
+	Undone int `json:"undone"`
+}
+
+// UndoLastEdit undoes the newest edit in the workspace's journal, together with the
+// other files of the same approved proposal.
+func (e *Engine) UndoLastEdit() (*UndoResult, error) {
+	entries, err := e.undoableEdits("")
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) == 0 {
+		return nil, errors.New("no edits to undo")
+	}
+	return e.undoEdits(lastGroup(entries))
+}
+
+// UndoEditsForConversation undoes every journaled edit the conversation made, newest
+// first. A file changed since Loom's last edit keeps all its edits; the others are
+// still undone, and the error lists what was kept.
+func (e *Engine) UndoEditsForConversation(conversationID string) (*UndoResult, error) {
+	if strings.TrimSpace(conversationID) == "" {
+		return nil, errors.New("conversation id is required")
+	}
+	entries, err := e.undoableEdits(conversationID)
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) == 0 {
+		return nil, errors.New("the conversation made no edits to undo")
+	}
+	return e.undoEdits(entries)
+}
+
+// PreviewUndo returns the diff undoing the conversation's latest edit (or all of its
+// edits) would apply, and the entries it would undo.
+func (e *Engine) PreviewUndo(conversationID string, all bool) (string, []JournalEntry, error) {
+	entries, err := e.undoableEdits(conversationID)
+	if err != nil {
+		return "", nil, err
+	}
+	if !all {
+		entries = lastGroup(entries)
+	}
+	var diff strings.Builder
+	for _, j := range entries {
+		diff.WriteString(editor.UnifiedDiff(j.Path, j.After, j.Before, !j.Deleted, !j.Created, timelineDiffContext))
+	}
+	return diff.String(), entries, nil
+}
+
+// undoableEdits returns the journal's entries, newest first, limited to a conversation
+// unless conversationID is empty.
+func (e *Engine) undoableEdits(conversationID string) ([]JournalEntry, error) {
+	if e.tools.DemoMode() {
+		return nil, errDemoMode
+	}
+	if reason := e.tools.ReadOnlyReason(); reason != "" {
+		return nil, fmt.Errorf("the workspace is read-only: %s", reason)
+	}
+	entries, err := readJournal(e.Workspace(), e.Sealer())
+	if err != nil || conversationID == "" {
+		return entries, err
+	}
+	var out []JournalEntry
+	for _, j := range entries {
+		if j.ConversationID == conversationID {
+			out = append(out, j)
+		}
+	}
+	return out, nil
+}
+
+// lastGroup returns the newest entry and the entries applied with it.
+func lastGroup(entries []JournalEntry) []JournalEntry {
+	if len(entries) == 0 {
+		return nil
+	}
+	var out []JournalEntry
+	for _, j := range entries {
+		if j.group() == entries[0].group() {
+			out = append(out, j)
+		}
+	}
+	return out
+}
+
+// undoEdits restores the files of entries (newest first) to their state before each
+// edit and removes the undone entries from the journal. Like UndoEdit it refuses a
+// file that changed after the edit, and then keeps that file's older edits too.
+func (e *Engine) undoEdits(entries []JournalEntry) (*UndoResult, error) {
+	root := e.Workspace()
+	res := &UndoResult{Reverted: []string{}}
+	kept := map[string]bool{}
+	var problems []error
+	for _, j := range entries {
+		if kept[j.Path] {
+			continue
+		}
+		abs := filepath.Join(root, filepath.FromSlash(j.Path))
+		current, err := os.ReadFile(abs)
+		if err != nil && !os.IsNotExist(err) {
+			kept[j.Path] = true
+			problems = append(problems, fmt.Errorf("%s: %w", j.Path, err))
+			continue
+		}
+		exists := err == nil
+		if exists == j.Deleted || (exists && string(current) != j.After) {
+			kept[j.Path] = true
+			problems = append(problems, fmt.Errorf("%s changed after the edit", j.Path))
+			continue
+		}
+		if j.Created {
+			err = os.Remove(abs)
+			if os.IsNotExist(err) {
+				err = nil
+			}
+		} else if err = os.MkdirAll(filepath.Dir(abs), 0o755); err == nil {
+			err = os.WriteFile(abs, []byte(j.Before), 0o644)
+		}
+		if err != nil {
+			kept[j.Path] = true
+			problems = append(problems, fmt.Errorf("%s: %w", j.Path, err))
+			continue
+		}
+		e.recordCheckpoint(j.Path, current, []byte(j.Before), exists, !j.Created, "undo")
+		_ = os.Remove(filepath.Join(EditJournalDir(root), j.ID+".json"))
+		res.Undone++
+		if !slices.Contains(res.Reverted, j.Path) {
+			res.Reverted = append(res.Reverted, j.Path)
+		}
+	}
+	if len(problems) > 0 {
+		return res, fmt.Errorf("cannot undo: %w", errors.Join(problems...))
+	}
+	return res, nil
+}
+
+// undoTool gives the undo_edit tool the current conversation's journaled edits.
+type undoTool struct {
+	e *Engine
+}
+
+// UndoEdits shows the user what the undo restores and undoes only once they approve,
+// through the same approval as an edit_file call.
+func (u undoTool) UndoEdits(all bool) ([]string, error) {
+	e := u.e
+	// What-if edits go to an overlay and are never journaled
+	if e.editRoot() != e.Workspace() {
+		return nil, errors.New("undo is not available in what-if mode; discard the overlay instead")
+	}
+	id := e.CurrentConversationID()
+	if id == "" {
+		return nil, errors.New("no active conversation")
+	}
+	diff, entries, err := e.PreviewUndo(id, all)
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) == 0 {
+		return nil, nil
+	}
+	if e.approvalHandler != nil {
+		args, _ := json.Marshal(map[string]any{"undo": len(entries), "all": all})
+		call := &tool.ToolCall{ID: fmt.Sprintf("undo-%d", time.Now().UnixNano()), Name: "edit_file", Args: args}
+		if !e.approvalHandler.UserApproved(call, fmt.Sprintf("Undo %d edit(s):\n\n%s", len(entries), diff)) {
+			return nil, errors.New("undo not approved")
+		}
+	}
+	res, err := e.undoEdits(entries)
+	if res == nil {
+		return nil, err
+	}
+	return res.Reverted, err
+}
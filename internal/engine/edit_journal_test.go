@@ -0,0 +1,101 @@
+package engine
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/loom/loom/internal/memory"
+	"github.com/loom/loom/internal/tool"
+)
+
+func TestEditJournal_UndoLastAndConversation(t *testing.T) {
+	ws := t.TempDir()
+	store, err := memory.NewStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	proj, err := memory.NewProject(store, ws)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := proj.SetCurrentConversationID("c1"); err != nil {
+		t.Fatal(err)
+	}
+	e := New(nil, nil).WithWorkspace(ws).WithMemory(proj)
+	te := &ToolExecutor{timeline: &timelineRecorder{project: proj, workspace: ws, conversationID: "c1"}}
+
+	read := func(name string) string {
+		data, _ := os.ReadFile(filepath.Join(ws, name))
+		return string(data)
+	}
+	// edit simulates an applied apply_edit call of a conversation writing content to name
+	edit := func(conversation, callID, name, content string) {
+		te.SetEditJournal(proj, ws, conversation)
+		args, _ := json.Marshal(map[string]string{"path": name})
+		pc := te.timeline.capture(&tool.ToolCall{ID: callID, Name: "apply_edit", Args: args})
+		if err := os.WriteFile(filepath.Join(ws, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		te.commitChange(pc)
+	}
+	if err := os.WriteFile(filepath.Join(ws, "a.txt"), []byte("one\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	edit("c1", "t1", "a.txt", "one\ntwo\n")
+	edit("c2", "t2", "c.txt", "other\n")
+	// Two files of one approved macro run
+	edit("c1", "t3:apply:0", "a.txt", "one\ntwo\nthree\n")
+	edit("c1", "t3:apply:1", "b.txt", "b\n")
+
+	entries, err := e.EditJournal()
+	if err != nil || len(entries) != 4 {
+		t.Fatalf("expected 4 journal entries, got %d (%v)", len(entries), err)
+	}
+	if entries[0].Path != "b.txt" || !entries[0].Created || entries[3].Before != "one\n" {
+		t.Fatalf("unexpected entries %+v", entries)
+	}
+	if data, err := os.ReadFile(filepath.Join(EditJournalDir(ws), ".gitignore")); err != nil || string(data) != "*\n" {
+		t.Fatalf("journal should be git-ignored: %q %v", data, err)
+	}
+
+	// The last edit undoes both files of the macro run
+	res, err := e.UndoLastEdit()
+	if err != nil || res.Undone != 2 {
+		t.Fatalf("undo last: %+v %v", res, err)
+	}
+	if read("a.txt") != "one\ntwo\n" {
+		t.Fatalf("a.txt = %q", read("a.txt"))
+	}
+	if _, err := os.Stat(filepath.Join(ws, "b.txt")); !os.IsNotExist(err) {
+		t.Fatal("b.txt should be removed")
+	}
+
+	// A file changed outside Loom is refused
+	if err := os.WriteFile(filepath.Join(ws, "a.txt"), []byte("mine\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := e.UndoEditsForConversation("c1"); err == nil {
+		t.Fatal("expected a conflict")
+	}
+	if read("a.txt") != "mine\n" {
+		t.Fatal("a conflicting file must be kept")
+	}
+	if err := os.WriteFile(filepath.Join(ws, "a.txt"), []byte("one\ntwo\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if res, err := e.UndoEditsForConversation("c1"); err != nil || len(res.Reverted) != 1 {
+		t.Fatalf("undo conversation: %+v %v", res, err)
+	}
+	if read("a.txt") != "one\n" || read("c.txt") != "other\n" {
+		t.Fatalf("unexpected files a=%q c=%q", read("a.txt"), read("c.txt"))
+	}
+	if entries, _ := e.EditJournal(); len(entries) != 1 || entries[0].ConversationID != "c2" {
+		t.Fatalf("only c2's edit should be left, got %+v", entries)
+	}
+	// Undos are part of the timeline
+	if tl := e.Timeline(); tl[len(tl)-1].Source != "undo" {
+		t.Fatalf("expected an undo checkpoint, got %+v", tl[len(tl)-1])
+	}
+}
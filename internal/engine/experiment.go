@@ -0,0 +1,339 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/loom/loom/internal/config"
+	"github.com/loom/loom/internal/tool"
+)
+
+// ExperimentVariant is one arm of a prompt experiment. Empty fields fall back to the
+// engine's current configuration so a variant only needs to state what it changes.
+type ExperimentVariant struct {
+	Name        string
+	LLM         LLM
+	ModelLabel  string
+	Personality string
+	// ExtraRules are appended to the project rules for this variant only
+	ExtraRules []string
+	// MaxSteps bounds the tool loop (default 24)
+	MaxSteps int
+}
+
+// ExperimentToolCall records a single tool call made during an experiment run.
+type ExperimentToolCall struct {
+	Name     string `json:"name"`
+	Args     string `json:"args"`
+	Executed bool   `json:"executed"`
+}
+
+// ExperimentTrace captures the observable behavior of one variant.
+type ExperimentTrace struct {
+	Variant      string               `json:"variant"`
+	Model        string               `json:"model,omitempty"`
+	ToolCalls    []ExperimentToolCall `json:"tool_calls"`
+	EditedFiles  []string             `json:"edited_files"`
+	Steps        int                  `json:"steps"`
+	InputTokens  int64                `json:"input_tokens"`
+	OutputTokens int64                `json:"output_tokens"`
+	FinalAnswer  string               `json:"final_answer"`
+	Error        string               `json:"error,omitempty"`
+}
+
+// ExperimentReport compares two variants run against the same recorded request.
+type ExperimentReport struct {
+	ConversationID string          `json:"conversation_id"`
+	Request        string          `json:"request"`
+	A              ExperimentTrace `json:"a"`
+	B              ExperimentTrace `json:"b"`
+	Differences    []string        `json:"differences"`
+}
+
+// experimentProposalTools only produce a proposal when invoked; running them is free of
+// side effects, and their output shows what the variant would have changed.
+var experimentProposalTools = map[string]bool{
+	"edit_file": true,
+	"run_shell": true,
+}
+
+// RunExperiment replays the last user request of a stored conversation against two
+// variants and reports how their behavior differs. Read-only tools run for real;
+// edits and shell commands are proposed but never applied, so the workspace is untouched.
+func (e *Engine) RunExperiment(ctx context.Context, conversationID string, a, b ExperimentVariant) (*ExperimentReport, error) {
+	if e.tools == nil {
+		return nil, errors.New("tool registry not initialized")
+	}
+	if conversationID == "" {
+		conversationID = e.CurrentConversationID()
+	}
+	history, err := e.GetConversation(conversationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load conversation: %w", err)
+	}
+	recorded, request := recordedRequest(history)
+	if request == "" {
+		return nil, errors.New("conversation has no user request to replay")
+	}
+
+	report := &ExperimentReport{ConversationID: conversationID, Request: request}
+	report.A = e.runVariant(ctx, recorded, a, "A")
+	report.B = e.runVariant(ctx, recorded, b, "B")
+	report.Differences = diffTraces(report.A, report.B)
+	recordModelComparison(report.A, report.B)
+	return report, nil
+}
+
+// recordModelComparison stores whether two different models behaved alike, as input
+// for model suggestions. Runs where the baseline itself failed say nothing and are skipped.
+func recordModelComparison(a, b ExperimentTrace) {
+	if a.Error != "" || a.Model == "" || b.Model == "" || modelIDOf(a.Model) == modelIDOf(b.Model) {
+		return
+	}
+	onlyA, onlyB := setDifference(a.EditedFiles, b.EditedFiles)
+	_ = config.RecordExperimentOutcome(config.ExperimentOutcome{
+		Baseline:   a.Model,
+		Candidate:  b.Model,
+		Equivalent: b.Error == "" && b.FinalAnswer != "" && len(onlyA)+len(onlyB) == 0,
+	})
+}
+
+// recordedRequest returns the conversation up to and including its last user message,
+// without the stored system prompt (each variant builds its own).
+func recordedRequest(history []Message) ([]Message, string) {
+	last := -1
+	for i, m := range history {
+		if m.Role == "user" {
+			last = i
+		}
+	}
+	if last < 0 {
+		return nil, ""
+	}
+	msgs := make([]Message, 0, last+1)
+	for _, m := range history[:last+1] {
+		if m.Role == "system" {
+			continue
+		}
+		msgs = append(msgs, m)
+	}
+	return msgs, history[last].Content
+}
+
+// runVariant drives a headless tool loop for one variant and records what it did.
+func (e *Engine) runVariant(ctx context.Context, recorded []Message, v ExperimentVariant, fallbackName string) ExperimentTrace {
+	trace := ExperimentTrace{Variant: v.Name}
+	if trace.Variant == "" {
+		trace.Variant = fallbackName
+	}
+
+	e.mu.RLock()
+	llm := v.LLM
+	if llm == nil {
+		llm = e.llm
+	}
+	personality := v.Personality
+	if personality == "" {
+		personality = e.personality
+	}
+	modelLabel := v.ModelLabel
+	if modelLabel == "" {
+		modelLabel = e.currentModelLabel
+	}
+	workspace := e.workspaceDir
+	e.mu.RUnlock()
+	trace.Model = modelLabel
+
+	if llm == nil {
+		trace.Error = "llm not configured"
+		return trace
+	}
+
+	schemas := e.tools.Schemas()
+	userRules, projectRules, _ := config.LoadRules(workspace)
+	projectRules = append(append([]string{}, projectRules...), v.ExtraRules...)
+	request := ""
+	for _, m := range recorded {
+		if m.Role == "user" {
+			request = m.Content
+		}
+	}
+	mems, memsOmitted := selectMemoriesForPrompt(append(loadUserMemoriesForPrompt(), projectMemoriesForPrompt(e.memory)...), request)
+	system := GenerateSystemPromptUnified(SystemPromptOptions{
+		Tools:                 schemas,
+		UserRules:             userRules,
+		ProjectRules:          projectRules,
+		Memories:              mems,
+		MemoriesOmitted:       memsOmitted,
+		Personality:           personality,
+		WorkspaceRoot:         workspace,
+		IncludeProjectContext: true,
+		ModelName:             modelLabel,
+	})
+
+	messages := append([]Message{{Role: "system", Content: system}}, recorded...)
+	toolCtx := tool.WithResultCache(ctx, tool.NewResultCache())
+	edited := map[string]bool{}
+
+	maxSteps := v.MaxSteps
+	if maxSteps <= 0 {
+		maxSteps = 24
+	}
+	for trace.Steps < maxSteps {
+		trace.Steps++
+		stream, err := llm.Chat(ctx, messages, convertSchemas(schemas), false)
+		if err != nil {
+			trace.Error = err.Error()
+			break
+		}
+		var content string
+		var call *ToolCall
+		for item := range stream {
+			if item.ToolCall != nil && item.ToolCall.Name != "" && call == nil {
+				call = item.ToolCall
+				continue
+			}
+			if strings.HasPrefix(item.Token, "[USAGE] ") {
+				_, _, in, out := parseUsageToken(item.Token)
+				trace.InputTokens += in
+				trace.OutputTokens += out
+				continue
+			}
+			if strings.HasPrefix(item.Token, "[REASONING") {
+				continue
+			}
+			content += item.Token
+		}
+		if ctx.Err() != nil {
+			trace.Error = ctx.Err().Error()
+			break
+		}
+		if call == nil {
+			trace.FinalAnswer = content
+			break
+		}
+
+		result, executed := e.experimentToolResult(toolCtx, call)
+		trace.ToolCalls = append(trace.ToolCalls, ExperimentToolCall{Name: call.Name, Args: string(call.Args), Executed: executed})
+		if call.Name == "edit_file" {
+			var args struct {
+				Path string `json:"path"`
+			}
+			if json.Unmarshal(call.Args, &args) == nil && args.Path != "" {
+				edited[args.Path] = true
+			}
+		}
+		messages = append(messages,
+			Message{Role: "assistant", Name: call.Name, ToolID: call.ID, Content: string(call.Args)},
+			Message{Role: "tool", Name: call.Name, ToolID: call.ID, Content: result},
+		)
+	}
+	if trace.FinalAnswer == "" && trace.Error == "" {
+		trace.Error = fmt.Sprintf("no final answer within %d steps", maxSteps)
+	}
+
+	for p := range edited {
+		trace.EditedFiles = append(trace.EditedFiles, p)
+	}
+	sort.Strings(trace.EditedFiles)
+	return trace
+}
+
+// experimentToolResult runs read-only and proposal-only tools and stubs out everything
+// else. It reports whether the tool actually ran.
+func (e *Engine) experimentToolResult(ctx context.Context, call *ToolCall) (string, bool) {
+	def, ok := e.tools.Get(call.Name)
+	if !ok {
+		return fmt.Sprintf("Error: unknown tool %q", call.Name), false
+	}
+	if !def.ReadOnly && !experimentProposalTools[call.Name] {
+		return fmt.Sprintf("[experiment] %s was not executed: side effects are disabled while comparing prompt variants. Continue as if it succeeded.", call.Name), false
+	}
+	// Invoke directly rather than via InvokeToolCall so experiment runs stay out of the chat
+	res, err := e.tools.Invoke(ctx, call.Name, call.Args)
+	if err != nil {
+		return "Error: " + err.Error(), true
+	}
+	var content string
+	switch r := res.(type) {
+	case *tool.ExecutionResult:
+		content = r.Content
+		if r.Diff != "" {
+			content += "\n" + r.Diff
+		}
+	case string:
+		content = r
+	default:
+		b, _ := json.MarshalIndent(r, "", "  ")
+		content = string(b)
+	}
+	if experimentProposalTools[call.Name] {
+		content += "\n[experiment] Proposal recorded and approved, but not applied to the workspace."
+	}
+	return content, true
+}
+
+// diffTraces summarizes behavioral differences between two experiment traces.
+func diffTraces(a, b ExperimentTrace) []string {
+	var out []string
+
+	seqA, seqB := toolSequence(a), toolSequence(b)
+	if strings.Join(seqA, ",") != strings.Join(seqB, ",") {
+		out = append(out, fmt.Sprintf("tool sequence: %s: [%s] vs %s: [%s]", a.Variant, strings.Join(seqA, " → "), b.Variant, strings.Join(seqB, " → ")))
+	}
+	if onlyA, onlyB := setDifference(seqA, seqB); len(onlyA)+len(onlyB) > 0 {
+		out = append(out, fmt.Sprintf("tools only used by %s: %v; only by %s: %v", a.Variant, onlyA, b.Variant, onlyB))
+	}
+	if onlyA, onlyB := setDifference(a.EditedFiles, b.EditedFiles); len(onlyA)+len(onlyB) > 0 {
+		out = append(out, fmt.Sprintf("files edited only by %s: %v; only by %s: %v", a.Variant, onlyA, b.Variant, onlyB))
+	}
+	if a.Steps != b.Steps {
+		out = append(out, fmt.Sprintf("steps: %s %d vs %s %d", a.Variant, a.Steps, b.Variant, b.Steps))
+	}
+	if a.InputTokens != b.InputTokens || a.OutputTokens != b.OutputTokens {
+		out = append(out, fmt.Sprintf("tokens (in/out): %s %d/%d vs %s %d/%d", a.Variant, a.InputTokens, a.OutputTokens, b.Variant, b.InputTokens, b.OutputTokens))
+	}
+	if len(a.FinalAnswer) != len(b.FinalAnswer) {
+		out = append(out, fmt.Sprintf("answer length: %s %d chars vs %s %d chars", a.Variant, len(a.FinalAnswer), b.Variant, len(b.FinalAnswer)))
+	}
+	if a.Error != b.Error {
+		out = append(out, fmt.Sprintf("errors: %s %q vs %s %q", a.Variant, a.Error, b.Variant, b.Error))
+	}
+	return out
+}
+
+func toolSequence(t ExperimentTrace) []string {
+	seq := make([]string, 0, len(t.ToolCalls))
+	for _, c := range t.ToolCalls {
+		seq = append(seq, c.Name)
+	}
+	return seq
+}
+
+// setDifference returns the distinct elements only present in a and only present in b.
+func setDifference(a, b []string) (onlyA, onlyB []string) {
+	inA, inB := map[string]bool{}, map[string]bool{}
+	for _, s := range a {
+		inA[s] = true
+	}
+	for _, s := range b {
+		inB[s] = true
+	}
+	for s := range inA {
+		if !inB[s] {
+			onlyA = append(onlyA, s)
+		}
+	}
+	for s := range inB {
+		if !inA[s] {
+			onlyB = append(onlyB, s)
+		}
+	}
+	sort.Strings(onlyA)
+	sort.Strings(onlyB)
+	return onlyA, onlyB
+}
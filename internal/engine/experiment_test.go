@@ -0,0 +1,76 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/loom/loom/internal/tool"
+)
+
+// scriptedLLM replies with a fixed sequence of turns; a turn with a tool name emits a tool call.
+type scriptedLLM struct {
+	turns []ToolCall
+	step  int
+}
+
+func (s *scriptedLLM) Chat(ctx context.Context, messages []Message, tools []ToolSchema, stream bool) (<-chan TokenOrToolCall, error) {
+	ch := make(chan TokenOrToolCall, 2)
+	if s.step < len(s.turns) {
+		tc := s.turns[s.step]
+		ch <- TokenOrToolCall{ToolCall: &tc}
+	} else {
+		ch <- TokenOrToolCall{Token: "done"}
+	}
+	ch <- TokenOrToolCall{Token: "[USAGE] provider=test model=m in=10 out=2"}
+	s.step++
+	close(ch)
+	return ch, nil
+}
+
+func TestRunVariant_RecordsToolsWithoutSideEffects(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	reg := tool.NewRegistry()
+	reads, writes := 0, 0
+	_ = reg.Register(tool.Definition{Name: "read_file", Safe: true, ReadOnly: true,
+		Handler: func(ctx context.Context, raw json.RawMessage) (interface{}, error) { reads++; return "contents", nil }})
+	_ = reg.Register(tool.Definition{Name: "apply_edit", Safe: true,
+		Handler: func(ctx context.Context, raw json.RawMessage) (interface{}, error) { writes++; return "ok", nil }})
+
+	e := New(nil, nil)
+	e.tools = reg
+	e.workspaceDir = t.TempDir()
+
+	recorded, request := recordedRequest([]Message{
+		{Role: "system", Content: "old prompt"},
+		{Role: "user", Content: "fix it"},
+	})
+	if request != "fix it" || len(recorded) != 1 {
+		t.Fatalf("unexpected recorded request: %q %+v", request, recorded)
+	}
+
+	a := e.runVariant(context.Background(), recorded, ExperimentVariant{Name: "baseline", LLM: &scriptedLLM{turns: []ToolCall{
+		{ID: "1", Name: "read_file", Args: json.RawMessage(`{"path":"a.go"}`)},
+		{ID: "2", Name: "apply_edit", Args: json.RawMessage(`{"path":"a.go"}`)},
+	}}}, "A")
+	b := e.runVariant(context.Background(), recorded, ExperimentVariant{LLM: &scriptedLLM{}}, "B")
+
+	if reads != 1 || writes != 0 {
+		t.Fatalf("expected only the read-only tool to run, reads=%d writes=%d", reads, writes)
+	}
+	if len(a.ToolCalls) != 2 || a.ToolCalls[1].Executed {
+		t.Fatalf("unexpected tool calls: %+v", a.ToolCalls)
+	}
+	if a.Steps != 3 || a.InputTokens != 30 || a.FinalAnswer != "done" {
+		t.Fatalf("unexpected trace: %+v", a)
+	}
+	if b.Variant != "B" || len(b.ToolCalls) != 0 {
+		t.Fatalf("unexpected trace for B: %+v", b)
+	}
+
+	diff := strings.Join(diffTraces(a, b), "\n")
+	if !strings.Contains(diff, "tools only used by baseline: [apply_edit read_file]") || !strings.Contains(diff, "steps: baseline 3 vs B 1") {
+		t.Fatalf("unexpected diff:\n%s", diff)
+	}
+}
@@ -0,0 +1,92 @@
+package engine
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/loom/loom/internal/config"
+	"github.com/loom/loom/internal/memory"
+)
+
+// ExplanationLevelMarker names the transcript messages that record a switch of the
+// explanation level; they are shown when a conversation is loaded.
+const ExplanationLevelMarker = "explanation_level"
+
+// explanationSections are swapped into the prompt for each level.
+var explanationSections = map[string]string{
+	config.ExplanationBeginner: `
+
+EXPLANATION LEVEL: beginner
+The user is new to programming or to this stack. When you explain code:
+- Use plain words and define every technical term the first time it appears.
+- Start with what the code is for before how it works, using an everyday analogy where it helps.
+- Walk through one concrete example step by step; prefer short excerpts over whole files.
+- Avoid assuming knowledge of the language's idioms, tooling or design patterns.`,
+	config.ExplanationIntermediate: `
+
+EXPLANATION LEVEL: intermediate
+The user writes code regularly but may not know this codebase or every idiom. When you explain code:
+- Assume general programming knowledge; briefly explain framework- or project-specific concepts.
+- Describe the flow between the relevant files and functions, citing where things live.
+- Mention the main trade-offs and pitfalls without covering basics.`,
+	config.ExplanationExpert: `
+
+EXPLANATION LEVEL: expert
+The user is an experienced engineer. When you explain code:
+- Be dense and precise; skip definitions of common terms and language basics.
+- Focus on invariants, concurrency, failure modes, performance and design trade-offs.
+- Reference files, symbols and lines instead of restating code.`,
+}
+
+// ExplanationLevel returns the explanation level in effect for the current
+// conversation: its own choice, else the default from settings, else "" when the
+// model decides.
+func (e *Engine) ExplanationLevel() string {
+	settings, _ := config.Load()
+	id := ""
+	if e.memory != nil {
+		id = e.memory.CurrentConversationID()
+	}
+	return effectiveExplanationLevel(settings, e.memory, id)
+}
+
+func effectiveExplanationLevel(settings config.Settings, mem *memory.Project, conversationID string) string {
+	for _, name := range []string{mem.ExplanationLevel(conversationID), settings.ExplanationLevel} {
+		if level, err := config.ParseExplanationLevel(name); err == nil && level != "" {
+			return level
+		}
+	}
+	return ""
+}
+
+// SetExplanationLevel switches the current conversation's explanation level; "" or
+// "default" returns to the default from settings. The switch takes effect with the
+// next message, where it is also recorded in the transcript.
+func (e *Engine) SetExplanationLevel(name string) error {
+	if e.memory == nil {
+		return errors.New("memory not initialized")
+	}
+	level, err := config.ParseExplanationLevel(name)
+	if err != nil {
+		return err
+	}
+	return e.memory.SetExplanationLevel(e.memory.CurrentConversationID(), level)
+}
+
+// explanationPrompt returns the prompt section of the level in effect, and records a
+// transcript marker when it differs from the level the conversation last ran with.
+func explanationPrompt(convo *memory.Conversation, level string) string {
+	last, current := "default", level
+	if current == "" {
+		current = "default"
+	}
+	for _, m := range convo.History() {
+		if m.Role == "system" && m.Name == ExplanationLevelMarker {
+			last = strings.TrimPrefix(m.Content, "Explanation level: ")
+		}
+	}
+	if current != last {
+		convo.AddSystemMarker(ExplanationLevelMarker, "Explanation level: "+current)
+	}
+	return explanationSections[level]
+}
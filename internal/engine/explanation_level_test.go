@@ -0,0 +1,57 @@
+package engine
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/loom/loom/internal/config"
+	"github.com/loom/loom/internal/memory"
+)
+
+func TestExplanationLevel_PerConversationWithMarkers(t *testing.T) {
+	store, err := memory.NewStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	proj, err := memory.NewProject(store, t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := New(nil, nil).WithMemory(proj)
+	id := e.NewConversation()
+	settings := config.Settings{ExplanationLevel: config.ExplanationExpert}
+
+	// Without a choice of its own, the conversation uses the default from settings
+	if got := effectiveExplanationLevel(settings, proj, id); got != config.ExplanationExpert {
+		t.Fatalf("expected the settings default, got %q", got)
+	}
+	convo := proj.StartConversation()
+	if section := explanationPrompt(convo, "expert"); !strings.Contains(section, "EXPLANATION LEVEL: expert") {
+		t.Fatalf("unexpected section %q", section)
+	}
+	convo.AddUser("what does this do?")
+	explanationPrompt(convo, "expert")
+
+	if err := proj.SetExplanationLevel(id, "beginner"); err != nil {
+		t.Fatal(err)
+	}
+	level := effectiveExplanationLevel(settings, proj, id)
+	if section := explanationPrompt(convo, level); !strings.Contains(section, "define every technical term") {
+		t.Fatalf("unexpected section %q", section)
+	}
+	_ = proj.SetExplanationLevel(id, "")
+	if section := explanationPrompt(convo, effectiveExplanationLevel(config.Settings{}, proj, id)); section != "" {
+		t.Fatalf("the default level adds no section, got %q", section)
+	}
+
+	var markers []string
+	for _, m := range convo.History() {
+		if m.Name == ExplanationLevelMarker {
+			markers = append(markers, m.Content)
+		}
+	}
+	want := []string{"Explanation level: expert", "Explanation level: beginner", "Explanation level: default"}
+	if strings.Join(markers, "|") != strings.Join(want, "|") {
+		t.Fatalf("expected one marker per switch %v, got %v", want, markers)
+	}
+}
@@ -0,0 +1,35 @@
+package engine
+
+import (
+	"maps"
+	"sync"
+
+	"github.com/loom/loom/internal/config"
+)
+
+// featureFlags holds the state of the experimental feature flags (config.FeatureFlags)
+// as last applied from the settings.
+type featureFlags struct {
+	mu sync.RWMutex
+	on map[string]bool
+}
+
+// SetFeatureFlags applies the state of the experimental feature flags. Runs already in
+// progress keep the state they started with.
+func (e *Engine) SetFeatureFlags(flags map[string]bool) {
+	e.features.mu.Lock()
+	e.features.on = maps.Clone(flags)
+	e.features.mu.Unlock()
+	e.eachFork(func(f *Engine) { f.SetFeatureFlags(flags) })
+}
+
+// FeatureEnabled reports whether the named experimental subsystem is switched on,
+// falling back to the flag's default until the settings were applied.
+func (e *Engine) FeatureEnabled(name string) bool {
+	e.features.mu.RLock()
+	defer e.features.mu.RUnlock()
+	if on, ok := e.features.on[name]; ok {
+		return on
+	}
+	return config.Settings{}.FeatureEnabled(name)
+}
@@ -0,0 +1,150 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"sort"
+)
+
+// editQueue applies the workspace writes of conversations that run at the same time in
+// one workspace one after another. A nil queue applies them directly.
+type editQueue struct {
+	slot chan struct{}
+}
+
+func newEditQueue() *editQueue {
+	return &editQueue{slot: make(chan struct{}, 1)}
+}
+
+// acquire waits for the queue when write is set and returns the function that frees
+// it again. It gives up when ctx ends.
+func (q *editQueue) acquire(ctx context.Context, write bool) (release func(), err error) {
+	if q == nil || !write {
+		return func() {}, nil
+	}
+	select {
+	case q.slot <- struct{}{}:
+		return func() { <-q.slot }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Fork opens a conversation that runs next to e's in the same workspace, e.g. in a
+// second tab. The fork has its own history, context, runs and approvals and reports to
+// bridge. It uses e's model, settings, tools and symbol index, shell sessions and
+// unsaved editor ranges, and its workspace writes wait in e's edit queue. An empty id
+// starts a new conversation. Forks end with CloseFork, or when e's memory changes.
+func (e *Engine) Fork(id string, bridge UIBridge) (*Engine, error) {
+	if e.parent != nil {
+		return nil, errors.New("a forked conversation cannot be forked")
+	}
+	if e.memory == nil || e.tools == nil {
+		return nil, errors.New("engine not initialized")
+	}
+	if id != "" {
+		if id == e.CurrentConversationID() || e.memory.IsConversationOpen(id) {
+			return nil, fmt.Errorf("conversation %s is already open", id)
+		}
+		if !e.memory.Has("conversations/" + id) {
+			return nil, fmt.Errorf("no conversation %q", id)
+		}
+	}
+
+	e.llmMu.Lock()
+	llm := e.llm
+	e.llmMu.Unlock()
+	f := New(llm, bridge)
+	f.parent = e
+	f.workspaceDir = e.Workspace()
+	f.shells = e.shells
+	f.dirty = e.dirty
+	f.edits = e.edits
+	f.WithMemory(e.memory.ForConversation(id))
+	f.tools = e.tools
+	f.streamProcessor = NewStreamProcessor(bridge, f.memory)
+	f.toolExecutor = NewToolExecutor(bridge, e.tools, f.approvalHandler)
+
+	e.mu.RLock()
+	f.personality = e.personality
+	f.currentModelLabel = e.currentModelLabel
+	f.editorCtx = e.editorCtx
+	e.mu.RUnlock()
+	e.features.mu.RLock()
+	f.features.on = maps.Clone(e.features.on)
+	e.features.mu.RUnlock()
+	if e.approvalHandler != nil {
+		shell, edits := e.approvalHandler.IsAutoApproveEnabled()
+		f.approvalHandler.SetAutoApprove(shell, edits)
+		e.approvalHandler.approvalMu.Lock()
+		policies := e.approvalHandler.policies
+		e.approvalHandler.approvalMu.Unlock()
+		f.approvalHandler.SetPolicies(policies)
+	}
+
+	e.forksMu.Lock()
+	if e.forks == nil {
+		e.forks = make(map[string]*Engine)
+	}
+	e.forks[f.CurrentConversationID()] = f
+	e.forksMu.Unlock()
+	return f, nil
+}
+
+// ForkOf returns the fork running conversation id, or nil.
+func (e *Engine) ForkOf(id string) *Engine {
+	e.forksMu.Lock()
+	defer e.forksMu.Unlock()
+	return e.forks[id]
+}
+
+// Forks returns the ids of the conversations running next to e, sorted.
+func (e *Engine) Forks() []string {
+	e.forksMu.Lock()
+	defer e.forksMu.Unlock()
+	ids := make([]string, 0, len(e.forks))
+	for id := range e.forks {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// CloseFork stops the fork running conversation id and releases its conversation. The
+// conversation stays stored and can be loaded again.
+func (e *Engine) CloseFork(id string) error {
+	e.forksMu.Lock()
+	f, ok := e.forks[id]
+	delete(e.forks, id)
+	e.forksMu.Unlock()
+	if !ok {
+		return fmt.Errorf("conversation %s is not open in a tab", id)
+	}
+	f.Stop()
+	f.memory.Close()
+	return nil
+}
+
+// CloseForks closes every fork and returns the ids of their conversations.
+func (e *Engine) CloseForks() []string {
+	ids := e.Forks()
+	for _, id := range ids {
+		_ = e.CloseFork(id)
+	}
+	return ids
+}
+
+// eachFork calls fn for every conversation running next to e.
+func (e *Engine) eachFork(fn func(f *Engine)) {
+	e.forksMu.Lock()
+	forks := make([]*Engine, 0, len(e.forks))
+	for _, f := range e.forks {
+		forks = append(forks, f)
+	}
+	e.forksMu.Unlock()
+	for _, f := range forks {
+		fn(f)
+	}
+}
@@ -0,0 +1,92 @@
+package engine
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/loom/loom/internal/memory"
+	"github.com/loom/loom/internal/tool"
+)
+
+func TestFork_RunsNextToTheMainConversation(t *testing.T) {
+	store, err := memory.NewStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ws := t.TempDir()
+	project, err := memory.NewProject(store, ws)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := New(nil, nil).WithWorkspace(ws).WithRegistry(tool.NewRegistry()).WithMemory(project)
+	main := e.NewConversation()
+	e.SetModelLabel("openai:gpt-4o")
+
+	if _, err := e.Fork(main, nil); err == nil {
+		t.Fatal("the main conversation must not be forked")
+	}
+	f, err := e.Fork("", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := f.CurrentConversationID()
+	if id == "" || id == main || e.CurrentConversationID() != main {
+		t.Fatalf("expected a new conversation next to %s, got %s", main, id)
+	}
+	if f.Workspace() != ws || f.GetModelLabel() != "openai:gpt-4o" || f.edits != e.edits || f.dirty != e.dirty {
+		t.Fatal("the fork must share the workspace, model, edit queue and editor ranges")
+	}
+	// Later settings reach the fork
+	e.SetModelLabel("anthropic:claude-sonnet-4")
+	if f.GetModelLabel() != "anthropic:claude-sonnet-4" {
+		t.Fatalf("fork kept model %s", f.GetModelLabel())
+	}
+	if err := e.SetCurrentConversationID(id); err == nil {
+		t.Fatal("a conversation open in a tab must not be loaded in the main view")
+	}
+	if got := e.Forks(); len(got) != 1 || got[0] != id || e.ForkOf(id) != f {
+		t.Fatalf("unexpected forks %v", got)
+	}
+
+	if err := e.CloseFork(id); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.SetCurrentConversationID(id); err != nil {
+		t.Fatalf("a closed tab's conversation should load: %v", err)
+	}
+	if len(e.Forks()) != 0 {
+		t.Fatal("expected no forks after closing")
+	}
+}
+
+func TestEditQueue_SerializesWrites(t *testing.T) {
+	q := newEditQueue()
+	release, err := q.acquire(context.Background(), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Reads never wait
+	if r, err := q.acquire(context.Background(), false); err != nil {
+		t.Fatal(err)
+	} else {
+		r()
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := q.acquire(ctx, true); err == nil {
+		t.Fatal("a second write must wait for the first")
+	}
+	release()
+	if r, err := q.acquire(context.Background(), true); err != nil {
+		t.Fatal(err)
+	} else {
+		r()
+	}
+	var nilQueue *editQueue
+	if r, err := nilQueue.acquire(context.Background(), true); err != nil {
+		t.Fatal(err)
+	} else {
+		r()
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/loom/loom/internal/config"
 	"github.com/loom/loom/internal/engine"
 	"github.com/loom/loom/internal/memory"
+	"github.com/loom/loom/internal/textutil"
 	"github.com/loom/loom/internal/tool"
 )
 
@@ -66,9 +67,7 @@ func (ch *ConversationHandler) PrepareConversation(
 		if currentID != "" && ch.memory.GetConversationTitle(currentID) == "" {
 			// Title: first (~50 chars) of the user's first message
 			title := userMsg
-			if len(title) > 50 {
-				title = title[:50] + "…"
-			}
+			title = textutil.TruncateWidth(title, 51)
 			_ = ch.memory.SetConversationTitle(currentID, title)
 		}
 	}
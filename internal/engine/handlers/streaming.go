@@ -105,6 +105,11 @@ StreamLoop:
 				continue
 			}
 
+			if item.ArgsDelta != nil {
+				// Argument previews are shown by the engine's stream processor
+				continue
+			}
+
 			// Got a token - process it
 			tok := item.Token
 			handled, _ := sp.uiEmitter.ProcessToken(tok)
@@ -0,0 +1,273 @@
+package engine
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/loom/loom/internal/memory"
+	"github.com/loom/loom/internal/tool"
+)
+
+// errToolLoopDepth ends a run that used up its tool-call budget.
+var errToolLoopDepth = errors.New("tool loop exceeded maximum depth")
+
+// errNoResponse ends a run whose model returned neither text nor tool calls.
+var errNoResponse = errors.New("no response from model")
+
+// Reasons a handoff was written.
+const (
+	HandoffCancelled = "cancelled"
+	HandoffMaxDepth  = "max_depth"
+)
+
+const (
+	maxHandoffFilesRead = 30
+	maxHandoffCommands  = 8
+)
+
+// handoffResumePrefix starts the user message that resumes a handoff.
+const handoffResumePrefix = "Resume unfinished work from an earlier session. The handoff below records what was done and what remains; trust it rather than re-exploring the workspace, read only the files you need to change, and continue with the remaining tasks.\n\n"
+
+// Handoff is the state of a run that stopped before its work was done, written to
+// .loom/handoffs/<id>.json so a fresh conversation can pick it up.
+type Handoff struct {
+	ID             string `json:"id"`
+	ConversationID string `json:"conversation_id"`
+	RunID          string `json:"run_id"`
+	// Reason is cancelled or max_depth
+	Reason string   `json:"reason"`
+	Goals  []string `json:"goals"`
+	// Todos are the unfinished tasks; Done the completed ones
+	Todos     []string         `json:"todos"`
+	Done      []string         `json:"done,omitempty"`
+	Files     []TimelineFile   `json:"files"`
+	FilesRead []string         `json:"files_read,omitempty"`
+	Commands  []CommandOutcome `json:"commands,omitempty"`
+	// Notes is the model's last answer before the run stopped
+	Notes     string     `json:"notes,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	ResumedAt *time.Time `json:"resumed_at,omitempty"`
+	Markdown  string     `json:"markdown"`
+}
+
+// handoffNotifier is implemented by UI bridges that offer to resume handoffs.
+type handoffNotifier interface {
+	EmitHandoff(h Handoff)
+}
+
+// HandoffsDir returns where handoffs of a workspace are written.
+func HandoffsDir(workspace string) string {
+	return filepath.Join(workspace, ".loom", "handoffs")
+}
+
+// buildHandoff turns a run that stopped early into a handoff, or returns nil when the
+// run left nothing to pick up. history holds the messages the run added.
+func buildHandoff(s *RunSummary, reason string, history []memory.Message) *Handoff {
+	h := &Handoff{
+		ID:             s.ID,
+		ConversationID: s.ConversationID,
+		RunID:          s.ID,
+		Reason:         reason,
+		Goals:          s.Goals,
+		Todos:          []string{},
+		Files:          s.Files,
+		Notes:          s.Summary,
+		CreatedAt:      s.FinishedAt,
+	}
+	for _, t := range s.Todos {
+		if t.Completed {
+			h.Done = append(h.Done, t.Task)
+		} else {
+			h.Todos = append(h.Todos, t.Task)
+		}
+	}
+	if len(h.Todos) == 0 && len(h.Files) == 0 {
+		return nil
+	}
+	seen := map[string]bool{}
+	for _, m := range history {
+		if m.Role != "assistant" || m.Name != "read_file" || len(h.FilesRead) >= maxHandoffFilesRead {
+			continue
+		}
+		var args tool.ReadFileArgs
+		if json.Unmarshal([]byte(m.Content), &args) == nil && args.Path != "" && !seen[args.Path] {
+			seen[args.Path] = true
+			h.FilesRead = append(h.FilesRead, args.Path)
+		}
+	}
+	h.Commands = s.Commands
+	if len(h.Commands) > maxHandoffCommands {
+		h.Commands = h.Commands[len(h.Commands)-maxHandoffCommands:]
+	}
+	h.Markdown = h.render()
+	return h
+}
+
+// render formats the handoff as the document a resumed session starts from.
+func (h *Handoff) render() string {
+	var b strings.Builder
+	title := "Unfinished work"
+	if len(h.Goals) > 0 {
+		title = h.Goals[0]
+	}
+	fmt.Fprintf(&b, "# Handoff: %s\n\n", title)
+	switch h.Reason {
+	case HandoffMaxDepth:
+		b.WriteString("The run stopped after reaching the tool-call limit.\n")
+	default:
+		b.WriteString("The run was stopped by the user.\n")
+	}
+	if len(h.Goals) > 0 {
+		b.WriteString("\n## Goal\n\n")
+		for _, g := range h.Goals {
+			fmt.Fprintf(&b, "- %s\n", g)
+		}
+	}
+	if len(h.Todos) > 0 {
+		b.WriteString("\n## Remaining tasks\n\n")
+		for _, t := range h.Todos {
+			fmt.Fprintf(&b, "- [ ] %s\n", t)
+		}
+	}
+	if len(h.Done) > 0 {
+		b.WriteString("\n## Completed tasks\n\n")
+		for _, t := range h.Done {
+			fmt.Fprintf(&b, "- [x] %s\n", t)
+		}
+	}
+	if len(h.Files) > 0 {
+		b.WriteString("\n## Files changed\n\n")
+		for _, f := range h.Files {
+			fmt.Fprintf(&b, "- `%s` (%s, +%d -%d)\n", f.Path, f.Status, f.Added, f.Removed)
+		}
+	}
+	if len(h.FilesRead) > 0 {
+		fmt.Fprintf(&b, "\n## Files read\n\n%s\n", codeList(h.FilesRead))
+	}
+	if len(h.Commands) > 0 {
+		b.WriteString("\n## Last commands\n\n")
+		for _, c := range h.Commands {
+			fmt.Fprintf(&b, "- `%s` (exit %d)\n", c.Command, c.ExitCode)
+		}
+	}
+	if h.Notes != "" {
+		fmt.Fprintf(&b, "\n## Last notes\n\n%s\n", h.Notes)
+	}
+	return b.String()
+}
+
+// writeHandoff stores h as .loom/handoffs/<id>.json in the workspace, encrypted with
+// sealer when the workspace encrypts its data.
+func writeHandoff(workspace string, sealer *memory.Sealer, h *Handoff) error {
+	dir := HandoffsDir(workspace)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	// Keep handoffs out of version control
+	ignore := filepath.Join(dir, ".gitignore")
+	if _, err := os.Stat(ignore); os.IsNotExist(err) {
+		_ = os.WriteFile(ignore, []byte("*\n"), 0o644)
+	}
+	data, err := json.MarshalIndent(h, "", "  ")
+	if err != nil {
+		return err
+	}
+	return memory.WriteSealed(sealer, filepath.Join(dir, h.ID+".json"), data)
+}
+
+// LoadHandoffs returns the handoffs of a workspace, newest first. Encrypted files are
+// decrypted with sealer; unreadable files are skipped.
+func LoadHandoffs(workspace string, sealer *memory.Sealer) ([]Handoff, error) {
+	entries, err := os.ReadDir(HandoffsDir(workspace))
+	if os.IsNotExist(err) {
+		return []Handoff{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	out := []Handoff{}
+	// Ids are run ids, so name order is chronological
+	for i := len(entries) - 1; i >= 0; i-- {
+		name := entries[i].Name()
+		if entries[i].IsDir() || filepath.Ext(name) != ".json" {
+			continue
+		}
+		data, err := memory.ReadSealed(sealer, filepath.Join(HandoffsDir(workspace), name))
+		if err != nil {
+			continue
+		}
+		var h Handoff
+		if json.Unmarshal(data, &h) == nil {
+			out = append(out, h)
+		}
+	}
+	return out, nil
+}
+
+// recordHandoff writes a handoff for a run that was cancelled or ran out of tool
+// calls with work left, and offers to resume it.
+func (e *Engine) recordHandoff(rec *runRecorder, s *RunSummary, runErr error, history []memory.Message) {
+	reason := ""
+	switch {
+	case s.Outcome == RunCancelled:
+		reason = HandoffCancelled
+	case errors.Is(runErr, errToolLoopDepth):
+		reason = HandoffMaxDepth
+	}
+	if reason == "" || rec.workspace == "" || rec.whatIf {
+		return
+	}
+	h := buildHandoff(s, reason, history)
+	if h == nil {
+		return
+	}
+	if err := writeHandoff(rec.workspace, e.Sealer(), h); err != nil {
+		return
+	}
+	if e.bridge != nil {
+		e.bridge.SendChat("system", fmt.Sprintf("Work stopped with %d open task(s) and %d changed file(s); a handoff was saved. Use /resume to continue it in a fresh conversation.", len(h.Todos), len(h.Files)))
+	}
+	if n, ok := e.bridge.(handoffNotifier); ok {
+		n.EmitHandoff(*h)
+	}
+}
+
+// ResumeHandoff starts a new conversation from a handoff (the newest one not resumed
+// yet when id is empty): its remaining tasks become the todo list, and the returned
+// message, which carries the handoff document, should be sent as the first request.
+func (e *Engine) ResumeHandoff(id string) (string, error) {
+	ws := e.Workspace()
+	if ws == "" {
+		return "", errors.New("no workspace open")
+	}
+	handoffs, err := LoadHandoffs(ws, e.Sealer())
+	if err != nil {
+		return "", err
+	}
+	var h *Handoff
+	for i := range handoffs {
+		if (id == "" && handoffs[i].ResumedAt == nil) || (id != "" && handoffs[i].ID == id) {
+			h = &handoffs[i]
+			break
+		}
+	}
+	if h == nil {
+		if id == "" {
+			return "", errors.New("no handoff to resume")
+		}
+		return "", fmt.Errorf("no handoff %q", id)
+	}
+	now := time.Now()
+	h.ResumedAt = &now
+	if err := writeHandoff(ws, e.Sealer(), h); err != nil {
+		return "", err
+	}
+	e.NewConversation()
+	tool.RestoreTodoTasks(h.Todos)
+	return handoffResumePrefix + h.Markdown, nil
+}
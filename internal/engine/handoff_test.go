@@ -0,0 +1,83 @@
+package engine
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/loom/loom/internal/memory"
+	"github.com/loom/loom/internal/tool"
+)
+
+func TestBuildHandoff(t *testing.T) {
+	s := &RunSummary{
+		ID:         "20261016-101500-abc123",
+		Goals:      []string{"Migrate the session store to Redis"},
+		Todos:      []tool.TodoTask{{Task: "Add a Redis client", Completed: true}, {Task: "Move session reads"}, {Task: "Update tests"}},
+		Files:      []TimelineFile{{Path: "session/store.go", Status: "modified", Added: 12, Removed: 3}},
+		Summary:    "The client is in place; reads still go through the file store.",
+		Commands:   []CommandOutcome{{Command: "go test ./session", ExitCode: 1}},
+		FinishedAt: time.Now(),
+	}
+	history := []memory.Message{
+		{Role: "assistant", Name: "read_file", ToolID: "r1", Content: `{"path":"session/store.go"}`},
+		{Role: "assistant", Name: "read_file", ToolID: "r2", Content: `{"path":"session/store.go","offset":40}`},
+		{Role: "assistant", Name: "read_file", ToolID: "r3", Content: `{"path":"config/redis.go"}`},
+	}
+
+	h := buildHandoff(s, HandoffMaxDepth, history)
+	if h == nil {
+		t.Fatal("expected a handoff")
+	}
+	if strings.Join(h.Todos, "|") != "Move session reads|Update tests" || len(h.Done) != 1 {
+		t.Errorf("unexpected tasks %v / %v", h.Todos, h.Done)
+	}
+	if strings.Join(h.FilesRead, ",") != "session/store.go,config/redis.go" {
+		t.Errorf("unexpected files read %v", h.FilesRead)
+	}
+	for _, want := range []string{"# Handoff: Migrate the session store to Redis", "tool-call limit", "- [ ] Update tests", "- [x] Add a Redis client", "`session/store.go` (modified, +12 -3)", "`go test ./session` (exit 1)", "reads still go through"} {
+		if !strings.Contains(h.Markdown, want) {
+			t.Errorf("markdown lacks %q:\n%s", want, h.Markdown)
+		}
+	}
+
+	// A run that stopped with nothing open leaves no handoff
+	s.Todos, s.Files = []tool.TodoTask{{Task: "done", Completed: true}}, nil
+	if buildHandoff(s, HandoffCancelled, nil) != nil {
+		t.Error("expected no handoff without open tasks or changes")
+	}
+}
+
+func TestResumeHandoff(t *testing.T) {
+	ws := t.TempDir()
+	e := New(nil, nil).WithWorkspace(ws)
+	if _, err := e.ResumeHandoff(""); err == nil {
+		t.Fatal("expected an error without handoffs")
+	}
+	for _, id := range []string{"20261016-090000-aaaaaa", "20261016-100000-bbbbbb"} {
+		h := &Handoff{ID: id, Reason: HandoffCancelled, Todos: []string{"task of " + id}}
+		h.Markdown = h.render()
+		if err := writeHandoff(ws, nil, h); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	msg, err := e.ResumeHandoff("")
+	if err != nil || !strings.HasPrefix(msg, handoffResumePrefix) || !strings.Contains(msg, "task of 20261016-100000-bbbbbb") {
+		t.Fatalf("expected the newest handoff, got %q %v", msg, err)
+	}
+	if todos := tool.PendingTodos(); len(todos) != 1 || todos[0].Task != "task of 20261016-100000-bbbbbb" {
+		t.Errorf("remaining tasks should become the todo list: %+v", todos)
+	}
+	// The resumed handoff is skipped next time
+	if msg, _ := e.ResumeHandoff(""); !strings.Contains(msg, "task of 20261016-090000-aaaaaa") {
+		t.Errorf("expected the older handoff, got %q", msg)
+	}
+	handoffs, _ := LoadHandoffs(ws, nil)
+	if len(handoffs) != 2 || handoffs[0].ResumedAt == nil || handoffs[1].ResumedAt == nil {
+		t.Errorf("both handoffs should be marked resumed: %+v", handoffs)
+	}
+	if _, err := e.ResumeHandoff("missing"); err == nil {
+		t.Error("expected an error for an unknown handoff")
+	}
+}
@@ -0,0 +1,218 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Phase describes what the engine is currently doing.
+type Phase string
+
+const (
+	PhaseIdle        Phase = "idle"
+	PhaseWaitingLLM  Phase = "waiting_llm"
+	PhaseStreaming   Phase = "streaming"
+	PhaseRunningTool Phase = "running_tool"
+	PhaseWaitingUser Phase = "waiting_user"
+	PhaseStalled     Phase = "stalled"
+)
+
+// Status is a snapshot of the engine state emitted to the UI as a heartbeat.
+type Status struct {
+	Phase       Phase  `json:"phase"`
+	Tool        string `json:"tool,omitempty"`
+	ElapsedMs   int64  `json:"elapsed_ms"`
+	IdleMs      int64  `json:"idle_ms"` // time since the provider last sent anything
+	Message     string `json:"message,omitempty"`
+	Recoverable bool   `json:"recoverable,omitempty"`
+}
+
+// errRetryRequested is the cancellation cause used when the user retries a stalled request.
+var errRetryRequested = errors.New("retry requested")
+
+const (
+	heartbeatInterval   = time.Second
+	defaultStallTimeout = 90 * time.Second
+)
+
+// stallTimeout returns how long the provider may stay silent before the request
+// is reported as stalled. Override with LOOM_STALL_TIMEOUT_SECONDS.
+func stallTimeout() time.Duration {
+	if v := os.Getenv("LOOM_STALL_TIMEOUT_SECONDS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			return time.Duration(n) * time.Second
+		}
+	}
+	return defaultStallTimeout
+}
+
+// heartbeat tracks the engine phase and periodically reports it to the UI.
+type heartbeat struct {
+	mu           sync.Mutex
+	phase        Phase
+	tool         string
+	since        time.Time
+	lastActivity time.Time
+	stalled      bool
+	emit         func(Status)
+	stop         chan struct{}
+}
+
+func newHeartbeat(emit func(Status)) *heartbeat {
+	return &heartbeat{phase: PhaseIdle, emit: emit}
+}
+
+// set switches to a new phase and emits the change immediately.
+func (h *heartbeat) set(phase Phase, toolName string) {
+	h.mu.Lock()
+	now := time.Now()
+	h.phase = phase
+	h.tool = toolName
+	h.since = now
+	h.lastActivity = now
+	h.stalled = false
+	if phase != PhaseIdle && h.stop == nil {
+		h.stop = make(chan struct{})
+		go h.run(h.stop)
+	}
+	if phase == PhaseIdle && h.stop != nil {
+		close(h.stop)
+		h.stop = nil
+	}
+	st := h.snapshotLocked(now)
+	h.mu.Unlock()
+	h.emit(st)
+}
+
+// toolPhase returns the phase for executing a tool: questions to the user pause the
+// run rather than occupy it.
+func toolPhase(name string) Phase {
+	if name == "ask_user" || name == "user_choice" {
+		return PhaseWaitingUser
+	}
+	return PhaseRunningTool
+}
+
+// touch records provider activity; a stalled request recovers on the next token.
+func (h *heartbeat) touch() {
+	h.mu.Lock()
+	h.lastActivity = time.Now()
+	recovered := h.stalled
+	h.stalled = false
+	if h.phase == PhaseWaitingLLM {
+		h.phase = PhaseStreaming
+	}
+	var st Status
+	if recovered {
+		st = h.snapshotLocked(h.lastActivity)
+	}
+	h.mu.Unlock()
+	if recovered {
+		h.emit(st)
+	}
+}
+
+// Snapshot returns the current status.
+func (h *heartbeat) Snapshot() Status {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.snapshotLocked(time.Now())
+}
+
+func (h *heartbeat) snapshotLocked(now time.Time) Status {
+	st := Status{Phase: h.phase, Tool: h.tool}
+	if h.phase == PhaseIdle {
+		return st
+	}
+	st.ElapsedMs = now.Sub(h.since).Milliseconds()
+	st.IdleMs = now.Sub(h.lastActivity).Milliseconds()
+	switch h.phase {
+	case PhaseRunningTool:
+		st.Message = fmt.Sprintf("Running %s for %ds", h.tool, st.ElapsedMs/1000)
+	case PhaseWaitingLLM:
+		st.Message = "Waiting for model"
+	case PhaseStreaming:
+		st.Message = "Receiving response"
+	case PhaseWaitingUser:
+		st.Message = "Waiting for your answer"
+	}
+	if h.stalled {
+		st.Phase = PhaseStalled
+		st.Recoverable = true
+		st.Message = fmt.Sprintf("Provider not responding for %ds", st.IdleMs/1000)
+	}
+	return st
+}
+
+// run emits a status every heartbeatInterval and flags stalls while waiting on the LLM.
+func (h *heartbeat) run(stop chan struct{}) {
+	ticker := time.NewTicker(heartbeatInterval)
+	defer ticker.Stop()
+	limit := stallTimeout()
+	for {
+		select {
+		case <-stop:
+			return
+		case now := <-ticker.C:
+			h.mu.Lock()
+			if (h.phase == PhaseWaitingLLM || h.phase == PhaseStreaming) && now.Sub(h.lastActivity) >= limit {
+				h.stalled = true
+			}
+			st := h.snapshotLocked(now)
+			h.mu.Unlock()
+			h.emit(st)
+		}
+	}
+}
+
+// watch forwards stream items while recording provider activity.
+func (h *heartbeat) watch(ctx context.Context, in <-chan TokenOrToolCall) <-chan TokenOrToolCall {
+	out := make(chan TokenOrToolCall)
+	go func() {
+		defer close(out)
+		for item := range in {
+			h.touch()
+			select {
+			case out <- item:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out
+}
+
+// Status returns the engine's current heartbeat status.
+func (e *Engine) Status() Status {
+	return e.heartbeat.Snapshot()
+}
+
+// emitStatus forwards a heartbeat status to the UI bridge.
+func (e *Engine) emitStatus(st Status) {
+	e.mu.RLock()
+	bridge := e.bridge
+	e.mu.RUnlock()
+	if bridge != nil {
+		bridge.EmitStatus(st)
+	}
+}
+
+// Retry abandons the in-flight request and asks the model again from the current history.
+func (e *Engine) Retry() {
+	e.ctxMu.Lock()
+	if e.cancelCurrent != nil {
+		e.cancelCurrent(errRetryRequested)
+	}
+	e.currentCtx, e.cancelCurrent = context.WithCancelCause(context.Background())
+	ctx := e.currentCtx
+	e.ctxMu.Unlock()
+
+	go func() {
+		_ = e.processLoop(ctx, "")
+	}()
+}
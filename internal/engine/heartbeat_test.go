@@ -0,0 +1,47 @@
+package engine
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestHeartbeat_FlagsStallAndRecovers(t *testing.T) {
+	t.Setenv("LOOM_STALL_TIMEOUT_SECONDS", "1")
+
+	var mu sync.Mutex
+	var seen []Status
+	hb := newHeartbeat(func(st Status) {
+		mu.Lock()
+		seen = append(seen, st)
+		mu.Unlock()
+	})
+	hb.set(PhaseWaitingLLM, "")
+	defer hb.set(PhaseIdle, "")
+
+	deadline := time.Now().Add(3 * time.Second)
+	for hb.Snapshot().Phase != PhaseStalled {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected stall to be reported, last status %+v", hb.Snapshot())
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	if st := hb.Snapshot(); !st.Recoverable {
+		t.Fatalf("stall should be recoverable: %+v", st)
+	}
+
+	hb.touch()
+	if st := hb.Snapshot(); st.Phase != PhaseStreaming {
+		t.Fatalf("expected streaming after provider activity, got %+v", st)
+	}
+
+	hb.set(PhaseRunningTool, "run_tests")
+	if st := hb.Snapshot(); st.Phase != PhaseRunningTool || st.Tool != "run_tests" {
+		t.Fatalf("unexpected tool status: %+v", st)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(seen) == 0 {
+		t.Fatalf("expected heartbeat emissions")
+	}
+}
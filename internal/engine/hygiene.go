@@ -0,0 +1,73 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/loom/loom/internal/editor"
+	"github.com/loom/loom/internal/hygiene"
+	"github.com/loom/loom/internal/memory"
+	"github.com/loom/loom/internal/tool"
+)
+
+// HygieneFixResult describes a hygiene fix written to the workspace.
+type HygieneFixResult struct {
+	Edit memory.EditRecord `json:"edit"`
+	Diff string            `json:"diff"`
+}
+
+// HygieneReport audits the workspace for repository hygiene issues.
+func (e *Engine) HygieneReport(ctx context.Context) (hygiene.Report, error) {
+	root := e.Workspace()
+	if root == "" {
+		return hygiene.Report{Findings: []hygiene.Finding{}}, errors.New("no workspace open")
+	}
+	return hygiene.Audit(ctx, root)
+}
+
+// ApplyHygieneFix applies the fix of a hygiene finding. The workspace is audited again
+// so the fix matches the current files, and the edit goes through the same approval
+// as an edit_file call. The write is recorded in the edit history so it can be undone.
+func (e *Engine) ApplyHygieneFix(ctx context.Context, id string) (*HygieneFixResult, error) {
+	if e.memory == nil {
+		return nil, errors.New("memory not initialized")
+	}
+	if e.tools.DemoMode() {
+		return nil, errDemoMode
+	}
+	if e.WhatIf() != nil {
+		return nil, errors.New("hygiene fixes change the real workspace; leave what-if mode first")
+	}
+	rep, err := e.HygieneReport(ctx)
+	if err != nil {
+		return nil, err
+	}
+	finding, ok := rep.Find(id)
+	if !ok {
+		return nil, fmt.Errorf("finding %q no longer applies", id)
+	}
+	if finding.Fix == nil {
+		return nil, fmt.Errorf("finding %q has no automatic fix", id)
+	}
+
+	root := rep.Workspace
+	plan, err := editor.PlanFileWrite(root, finding.Fix.Path, finding.Fix.Content)
+	if err != nil {
+		return nil, err
+	}
+	if e.approvalHandler != nil {
+		args, _ := json.Marshal(map[string]string{"path": finding.Fix.Path})
+		call := &tool.ToolCall{ID: fmt.Sprintf("hygiene-%d", time.Now().UnixNano()), Name: "edit_file", Args: args}
+		if !e.approvalHandler.UserApproved(call, finding.Fix.Description+"\n\n"+plan.Diff) {
+			return nil, errors.New("fix not approved")
+		}
+	}
+	rec, err := e.applyRecordedWrite(root, plan, "hygiene")
+	if err != nil {
+		return nil, err
+	}
+	return &HygieneFixResult{Edit: rec, Diff: plan.Diff}, nil
+}
@@ -0,0 +1,307 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/loom/loom/internal/tool"
+)
+
+const (
+	maxIssueSummary    = 800
+	maxIssueErrors     = 6
+	maxIssueMentions   = 15
+	maxIssueComments   = 8
+	maxIssueComment    = 400
+	maxIssueFiles      = 10
+	maxIssueChangeDiff = 6000
+)
+
+// issuePrimerPrefix starts the user message of a conversation primed from an issue.
+const issuePrimerPrefix = "Work on the issue below. The brief distills its discussion, and the relevant files were found by searching the workspace: start from them, confirm the problem in the code, then make the change.\n\n"
+
+// IssueBrief is the distilled form of an issue or pull request that primes a new
+// conversation.
+type IssueBrief struct {
+	URL         string   `json:"url"`
+	Number      int      `json:"number"`
+	Title       string   `json:"title"`
+	PullRequest bool     `json:"pull_request,omitempty"`
+	State       string   `json:"state,omitempty"`
+	Labels      []string `json:"labels,omitempty"`
+	Summary     string   `json:"summary"`
+	// Errors are error messages and stack lines quoted in the discussion
+	Errors []string `json:"errors,omitempty"`
+	// Mentions are code identifiers and paths the discussion refers to
+	Mentions   []string            `json:"mentions,omitempty"`
+	Discussion []string            `json:"discussion,omitempty"`
+	Changes    []tool.LinkedChange `json:"changes,omitempty"`
+	Files      []IssueFile         `json:"files"`
+	Markdown   string              `json:"markdown"`
+}
+
+// IssueFile is a workspace file that is likely relevant to an issue.
+type IssueFile struct {
+	Path   string `json:"path"`
+	Line   int    `json:"line,omitempty"`
+	Reason string `json:"reason"`
+}
+
+// StartFromIssue fetches an issue or pull request, distills it into a brief and starts
+// a new conversation for it. It returns the brief and the user message to send.
+func (e *Engine) StartFromIssue(ctx context.Context, issueURL string) (*IssueBrief, string, error) {
+	ws := e.Workspace()
+	if ws == "" {
+		return nil, "", errors.New("no workspace open")
+	}
+	issue, err := tool.FetchIssue(ctx, ws, issueURL)
+	if err != nil {
+		return nil, "", err
+	}
+	b := buildIssueBrief(ws, issue, func(query string) []tool.LocateCandidate {
+		return e.locate(ctx, query, maxIssueFiles)
+	})
+	e.NewConversation()
+	return b, issuePrimerPrefix + b.Markdown, nil
+}
+
+var (
+	htmlCommentRe = regexp.MustCompile(`(?s)<!--.*?-->`)
+	fenceRe       = regexp.MustCompile("(?s)```[^\n]*\n(.*?)```")
+	backtickRe    = regexp.MustCompile("`([^`\n]{2,80})`")
+	pathLikeRe    = regexp.MustCompile(`(?:^|[\s(])((?:[\w.-]+/)+[\w.-]+\.\w{1,6}|[\w-]+\.(?:go|ts|tsx|js|jsx|py|rb|php|rs|java|kt|cs|cpp|c|h|swift|vue|yaml|yml|json|toml|sql))(?::\d+)?\b`)
+	errorLineRe   = regexp.MustCompile(`(?i)\b(error|panic|exception|traceback|fatal|failed|undefined|cannot|segmentation)\b`)
+	tokenRe       = regexp.MustCompile(`[A-Za-z_][\w.-]*`)
+)
+
+// buildIssueBrief distills an issue into a brief. locate finds workspace files for a
+// plain-words query and may return nil.
+func buildIssueBrief(workspace string, issue *tool.Issue, locate func(query string) []tool.LocateCandidate) *IssueBrief {
+	body := cleanIssueText(issue.Body)
+	b := &IssueBrief{
+		URL:         issue.URL,
+		Number:      issue.Number,
+		Title:       strings.TrimSpace(issue.Title),
+		PullRequest: issue.PullRequest,
+		State:       issue.State,
+		Labels:      issue.Labels,
+		Summary:     issueSummary(body),
+		Changes:     issue.Changes,
+		Files:       []IssueFile{},
+	}
+	texts := []string{body}
+	for _, c := range issue.Comments {
+		text := cleanIssueText(c.Body)
+		texts = append(texts, text)
+		if strings.HasSuffix(c.Author, "[bot]") || len(text) < 20 {
+			continue
+		}
+		b.Discussion = append(b.Discussion, "@"+c.Author+": "+truncateRunes(firstParagraph(text), maxIssueComment))
+	}
+	// The latest comments usually hold the current understanding
+	if len(b.Discussion) > maxIssueComments {
+		b.Discussion = b.Discussion[len(b.Discussion)-maxIssueComments:]
+	}
+	for _, text := range texts {
+		b.Errors = appendUnique(b.Errors, issueErrors(text), maxIssueErrors)
+		b.Mentions = appendUnique(b.Mentions, issueMentions(text), maxIssueMentions)
+	}
+	b.Files = issueFiles(workspace, b, locate)
+	b.Markdown = renderIssueBrief(b)
+	return b
+}
+
+// cleanIssueText drops template comments and normalizes line endings.
+func cleanIssueText(s string) string {
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	return strings.TrimSpace(htmlCommentRe.ReplaceAllString(s, ""))
+}
+
+// issueSummary is the issue body without code blocks, cut to a readable length.
+func issueSummary(body string) string {
+	prose := strings.TrimSpace(fenceRe.ReplaceAllString(body, "[code]"))
+	if prose == "" {
+		return "(no description)"
+	}
+	return truncateRunes(prose, maxIssueSummary)
+}
+
+func firstParagraph(s string) string {
+	s = fenceRe.ReplaceAllString(s, "[code]")
+	if i := strings.Index(s, "\n\n"); i > 0 {
+		s = s[:i]
+	}
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// issueErrors returns the lines of code blocks and quotes that look like errors.
+func issueErrors(text string) []string {
+	var quoted []string
+	for _, m := range fenceRe.FindAllStringSubmatch(text, -1) {
+		quoted = append(quoted, strings.Split(m[1], "\n")...)
+	}
+	for _, line := range strings.Split(text, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), ">") {
+			quoted = append(quoted, strings.TrimPrefix(strings.TrimSpace(line), ">"))
+		}
+	}
+	var out []string
+	for _, line := range quoted {
+		line = strings.TrimSpace(line)
+		if line != "" && errorLineRe.MatchString(line) {
+			out = append(out, truncateRunes(line, 200))
+		}
+	}
+	return out
+}
+
+// issueMentions returns inline code spans and file paths, in order of appearance.
+func issueMentions(text string) []string {
+	var out []string
+	prose := fenceRe.ReplaceAllString(text, "")
+	for _, m := range backtickRe.FindAllStringSubmatch(prose, -1) {
+		out = append(out, strings.TrimSpace(m[1]))
+	}
+	for _, m := range pathLikeRe.FindAllStringSubmatch(prose, -1) {
+		if !strings.Contains(m[1], "://") {
+			out = append(out, m[1])
+		}
+	}
+	return out
+}
+
+// issueFiles picks the workspace files to start from: paths mentioned in the issue,
+// files changed by the linked pull requests, then files found by searching for the
+// title and the mentioned identifiers.
+func issueFiles(workspace string, b *IssueBrief, locate func(string) []tool.LocateCandidate) []IssueFile {
+	files := []IssueFile{}
+	seen := map[string]bool{}
+	add := func(f IssueFile) {
+		if len(files) >= maxIssueFiles || seen[f.Path] {
+			return
+		}
+		seen[f.Path] = true
+		files = append(files, f)
+	}
+	exists := func(rel string) bool {
+		rel = filepath.FromSlash(strings.TrimPrefix(rel, "./"))
+		if filepath.IsAbs(rel) || strings.HasPrefix(filepath.Clean(rel), "..") {
+			return false
+		}
+		info, err := os.Stat(filepath.Join(workspace, rel))
+		return err == nil && !info.IsDir()
+	}
+	for _, m := range b.Mentions {
+		if p := strings.TrimPrefix(m, "./"); exists(p) {
+			add(IssueFile{Path: filepath.ToSlash(p), Reason: "mentioned in the issue"})
+		}
+	}
+	for _, c := range b.Changes {
+		for _, p := range c.Files {
+			if exists(p) {
+				add(IssueFile{Path: p, Reason: "changed by " + c.URL})
+			}
+		}
+	}
+	if locate == nil {
+		return files
+	}
+	query := b.Title
+	for _, m := range b.Mentions {
+		if t := tokenRe.FindString(m); t != "" && !strings.Contains(m, "/") {
+			query += " " + t
+		}
+	}
+	for _, c := range locate(query) {
+		reason := "search match"
+		if len(c.Reasons) > 0 {
+			reason = "search: " + strings.Join(c.Reasons, "; ")
+		}
+		add(IssueFile{Path: c.Path, Line: c.Line, Reason: reason})
+	}
+	return files
+}
+
+// renderIssueBrief writes the brief as the markdown sent to the model.
+func renderIssueBrief(b *IssueBrief) string {
+	var sb strings.Builder
+	kind := "Issue"
+	if b.PullRequest {
+		kind = "Pull request"
+	}
+	fmt.Fprintf(&sb, "## %s #%d: %s\n\n", kind, b.Number, b.Title)
+	fmt.Fprintf(&sb, "Source: %s", b.URL)
+	if b.State != "" {
+		fmt.Fprintf(&sb, " (%s)", strings.ToLower(b.State))
+	}
+	if len(b.Labels) > 0 {
+		fmt.Fprintf(&sb, " · labels: %s", strings.Join(b.Labels, ", "))
+	}
+	sb.WriteString("\n\n### Summary\n\n" + b.Summary + "\n")
+	if len(b.Errors) > 0 {
+		sb.WriteString("\n### Errors reported\n\n```\n" + strings.Join(b.Errors, "\n") + "\n```\n")
+	}
+	if len(b.Mentions) > 0 {
+		sb.WriteString("\n### Mentioned\n\n")
+		for _, m := range b.Mentions {
+			sb.WriteString("- `" + m + "`\n")
+		}
+	}
+	if len(b.Discussion) > 0 {
+		sb.WriteString("\n### Discussion\n\n")
+		for _, d := range b.Discussion {
+			sb.WriteString("- " + d + "\n")
+		}
+	}
+	for _, c := range b.Changes {
+		title := c.URL
+		if c.Title != "" {
+			title = c.Title + " (" + c.URL + ")"
+		}
+		sb.WriteString("\n### Changes: " + title + "\n\n")
+		if len(c.Files) > 0 {
+			sb.WriteString("Files: " + strings.Join(c.Files, ", ") + "\n")
+		}
+		if diff := c.Diff; diff != "" {
+			truncated := c.Truncated
+			if len(diff) > maxIssueChangeDiff {
+				diff, truncated = diff[:maxIssueChangeDiff], true
+			}
+			sb.WriteString("\n```diff\n" + strings.TrimRight(diff, "\n") + "\n```\n")
+			if truncated {
+				sb.WriteString("(diff truncated)\n")
+			}
+		}
+	}
+	sb.WriteString("\n### Relevant files\n\n")
+	if len(b.Files) == 0 {
+		sb.WriteString("None found; search the workspace before changing code.\n")
+	}
+	for _, f := range b.Files {
+		if f.Line > 0 {
+			fmt.Fprintf(&sb, "- %s:%d — %s\n", f.Path, f.Line, f.Reason)
+		} else {
+			fmt.Fprintf(&sb, "- %s — %s\n", f.Path, f.Reason)
+		}
+	}
+	return sb.String()
+}
+
+// appendUnique appends the values of add not in dst yet, up to limit entries.
+func appendUnique(dst, add []string, limit int) []string {
+	for _, v := range add {
+		if len(dst) >= limit {
+			break
+		}
+		if !slices.Contains(dst, v) {
+			dst = append(dst, v)
+		}
+	}
+	return dst
+}
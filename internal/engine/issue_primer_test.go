@@ -0,0 +1,68 @@
+package engine
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/loom/loom/internal/tool"
+)
+
+func TestBuildIssueBrief(t *testing.T) {
+	ws := t.TempDir()
+	for _, p := range []string{"cart/checkout.go", "cart/discount.go", "payments/stripe.go"} {
+		if err := os.MkdirAll(filepath.Join(ws, filepath.Dir(p)), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(ws, p), []byte("package x\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	issue := &tool.Issue{
+		URL:    "https://github.com/acme/shop/issues/12",
+		Number: 12,
+		Title:  "Checkout panics with an empty coupon",
+		State:  "OPEN",
+		Labels: []string{"bug"},
+		Body: "<!-- Describe the bug -->\nApplying an empty coupon in `cart/checkout.go` crashes `ApplyDiscount`.\n\n" +
+			"```\npanic: runtime error: index out of range [0] with length 0\ngoroutine 1 [running]:\n```\n",
+		Comments: []tool.IssueComment{
+			{Author: "dependabot[bot]", Body: "Bumps stripe-go from 74 to 75 and more text here."},
+			{Author: "dana", Body: "+1"},
+			{Author: "lee", Body: "It started after the discount refactor.\n\nDetails below."},
+		},
+		Changes: []tool.LinkedChange{{URL: "https://github.com/acme/shop/pull/9", Title: "Refactor discounts", Files: []string{"cart/discount.go", "gone.go"}, Diff: "--- a/cart/discount.go\n+++ b/cart/discount.go\n"}},
+	}
+	var query string
+	b := buildIssueBrief(ws, issue, func(q string) []tool.LocateCandidate {
+		query = q
+		return []tool.LocateCandidate{{Path: "payments/stripe.go", Line: 3, Reasons: []string{"content: coupon"}}, {Path: "cart/checkout.go"}}
+	})
+
+	if strings.Contains(b.Summary, "Describe the bug") || !strings.Contains(b.Summary, "Applying an empty coupon") {
+		t.Errorf("unexpected summary %q", b.Summary)
+	}
+	if len(b.Errors) != 1 || !strings.HasPrefix(b.Errors[0], "panic: runtime error") {
+		t.Errorf("unexpected errors %v", b.Errors)
+	}
+	if len(b.Discussion) != 1 || b.Discussion[0] != "@lee: It started after the discount refactor." {
+		t.Errorf("bots and short comments should be skipped: %v", b.Discussion)
+	}
+	if !strings.Contains(query, "Checkout panics") || !strings.Contains(query, "ApplyDiscount") {
+		t.Errorf("search should use the title and identifiers: %q", query)
+	}
+	var got []string
+	for _, f := range b.Files {
+		got = append(got, f.Path+"="+f.Reason)
+	}
+	want := "cart/checkout.go=mentioned in the issue,cart/discount.go=changed by https://github.com/acme/shop/pull/9,payments/stripe.go=search: content: coupon"
+	if strings.Join(got, ",") != want {
+		t.Errorf("unexpected files:\n%s", strings.Join(got, ","))
+	}
+	for _, want := range []string{"## Issue #12: Checkout panics", "(open) · labels: bug", "### Errors reported", "```diff", "- payments/stripe.go:3 — search"} {
+		if !strings.Contains(b.Markdown, want) {
+			t.Errorf("markdown lacks %q:\n%s", want, b.Markdown)
+		}
+	}
+}
@@ -0,0 +1,262 @@
+package engine
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/loom/loom/internal/config"
+	"github.com/loom/loom/internal/editor"
+	"github.com/loom/loom/internal/memory"
+)
+
+const (
+	// advisorWindow is how far back turns are considered
+	advisorWindow = 30 * 24 * time.Hour
+	// advisorMinSpan keeps a few days of history from being extrapolated to a month
+	advisorMinSpan = 7 * 24 * time.Hour
+	// advisorInterval limits how often a suggestion is evaluated after turns
+	advisorInterval = 24 * time.Hour
+
+	advisorMinTurns          = 20
+	advisorMinSmallShare     = 0.6
+	advisorMinExperiments    = 3
+	advisorMinEquivalentRate = 0.8
+	advisorMinSavingsUSD     = 1.0
+)
+
+// ModelSuggestion proposes switching the default model to a cheaper one that matched
+// it in replayed experiments, for users whose requests are mostly small edits.
+type ModelSuggestion struct {
+	Current   string `json:"current"`
+	Suggested string `json:"suggested"`
+	// Turns is the number of requests the current model answered in the window
+	Turns          int     `json:"turns"`
+	SmallEditShare float64 `json:"small_edit_share"`
+	Experiments    int     `json:"experiments"`
+	EquivalentRate float64 `json:"equivalent_rate"`
+	// Monthly figures extrapolate the window's spend to 30 days; savings only count the
+	// small-edit requests, where the candidate was shown to perform equally well
+	CurrentMonthlyUSD   float64 `json:"current_monthly_usd"`
+	ProjectedMonthlyUSD float64 `json:"projected_monthly_usd"`
+	MonthlySavingsUSD   float64 `json:"monthly_savings_usd"`
+	Reason              string  `json:"reason"`
+}
+
+// Key identifies the suggestion for dismissal.
+func (s ModelSuggestion) Key() string {
+	return modelIDOf(s.Current) + "->" + modelIDOf(s.Suggested)
+}
+
+// modelSuggestionNotifier is implemented by UI bridges that surface model suggestions.
+type modelSuggestionNotifier interface {
+	EmitModelSuggestion(s ModelSuggestion)
+}
+
+// RecommendModel looks for a cheaper model to use by default. It needs enough recent
+// turns on the current model, most of them small edits, and a candidate that behaved
+// equivalently in most replay experiments against it. It returns nil when there is
+// nothing worth suggesting.
+func RecommendModel(current string, turns []config.TurnRecord, outcomes []config.ExperimentOutcome, now time.Time) *ModelSuggestion {
+	currentID := modelIDOf(current)
+	if currentID == "" {
+		return nil
+	}
+	var mine []config.TurnRecord
+	for _, t := range turns {
+		if modelIDOf(t.Model) == currentID && now.Sub(t.Time) <= advisorWindow {
+			mine = append(mine, t)
+		}
+	}
+	if len(mine) < advisorMinTurns {
+		return nil
+	}
+	var small []config.TurnRecord
+	var spent float64
+	oldest := now
+	for _, t := range mine {
+		spent += t.CostUSD
+		if t.Time.Before(oldest) {
+			oldest = t.Time
+		}
+		if t.SmallEdit() {
+			small = append(small, t)
+		}
+	}
+	share := float64(len(small)) / float64(len(mine))
+	if share < advisorMinSmallShare {
+		return nil
+	}
+	monthly := float64(30*24*time.Hour) / float64(max(now.Sub(oldest), advisorMinSpan))
+
+	type tally struct {
+		label      string
+		runs, same int
+	}
+	tallies := map[string]*tally{}
+	for _, o := range outcomes {
+		other := ""
+		switch {
+		case modelIDOf(o.Baseline) == currentID:
+			other = o.Candidate
+		case modelIDOf(o.Candidate) == currentID:
+			other = o.Baseline
+		}
+		id := modelIDOf(other)
+		if id == "" || id == currentID {
+			continue
+		}
+		t := tallies[id]
+		if t == nil {
+			t = &tally{label: other}
+			tallies[id] = t
+		}
+		t.runs++
+		if o.Equivalent {
+			t.same++
+		}
+	}
+
+	var best *ModelSuggestion
+	ids := make([]string, 0, len(tallies))
+	for id := range tallies {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		t := tallies[id]
+		rate := float64(t.same) / float64(t.runs)
+		if t.runs < advisorMinExperiments || rate < advisorMinEquivalentRate {
+			continue
+		}
+		var before, after float64
+		for _, turn := range small {
+			_, _, cost := config.CostUSDParts(id, turn.InTokens, turn.OutTokens)
+			if cost <= 0 {
+				// Unknown price: savings cannot be projected
+				before, after = 0, 0
+				break
+			}
+			before += turn.CostUSD
+			after += cost
+		}
+		savings := (before - after) * monthly
+		if savings < advisorMinSavingsUSD || (best != nil && savings <= best.MonthlySavingsUSD) {
+			continue
+		}
+		best = &ModelSuggestion{
+			Current:             settingsModelLabel(current),
+			Suggested:           settingsModelLabel(t.label),
+			Turns:               len(mine),
+			SmallEditShare:      share,
+			Experiments:         t.runs,
+			EquivalentRate:      rate,
+			CurrentMonthlyUSD:   spent * monthly,
+			ProjectedMonthlyUSD: spent*monthly - savings,
+			MonthlySavingsUSD:   savings,
+			Reason: fmt.Sprintf("%.0f%% of your last %d requests with %s were small edits (up to 20 changed lines), and %s matched it in %d of %d replayed experiments.",
+				share*100, len(mine), currentID, id, t.same, t.runs),
+		}
+	}
+	return best
+}
+
+// ModelSuggestion evaluates the usage analytics for the current model now.
+func (e *Engine) ModelSuggestion() *ModelSuggestion {
+	now := time.Now()
+	return RecommendModel(e.GetModelLabel(), config.LoadTurns(now.Add(-advisorWindow)), config.LoadExperimentOutcomes(), now)
+}
+
+// turnTracker accumulates what one user request did for the analytics store.
+type turnTracker struct {
+	model string
+	// lastStep is the newest timeline step before the request started
+	lastStep  int
+	toolCalls int
+}
+
+// beginTurn starts tracking a user request.
+func (e *Engine) beginTurn() *turnTracker {
+	if e.streamProcessor != nil {
+		e.streamProcessor.resetTurnUsage()
+	}
+	t := &turnTracker{model: e.GetModelLabel()}
+	if items := e.Timeline(); len(items) > 0 {
+		t.lastStep = items[len(items)-1].Step
+	}
+	return t
+}
+
+// finishTurn records the request in the analytics store and, at most once a day,
+// checks whether a cheaper default model should be suggested.
+func (e *Engine) finishTurn(t *turnTracker) {
+	if t == nil || e.streamProcessor == nil {
+		return
+	}
+	in, out, cost := e.streamProcessor.turnUsage()
+	if in+out == 0 {
+		return
+	}
+	rec := config.TurnRecord{Model: t.model, InTokens: in, OutTokens: out, CostUSD: cost, ToolCalls: t.toolCalls}
+	files := map[string]bool{}
+	for _, cp := range e.Timeline() {
+		if cp.Step <= t.lastStep {
+			continue
+		}
+		files[cp.Path] = true
+		if cp.Omitted {
+			// Contents of large files are not kept; count the change as large
+			rec.EditedLines += memory.MaxCheckpointContent
+			continue
+		}
+		added, removed := countDiffLines(editor.UnifiedDiff(cp.Path, cp.Before, cp.After, !cp.Created, !cp.Deleted, 0))
+		rec.EditedLines += added + removed
+	}
+	rec.EditedFiles = len(files)
+	_ = config.RecordTurn(rec)
+
+	e.mu.Lock()
+	due := time.Since(e.advisorChecked) >= advisorInterval
+	if due {
+		e.advisorChecked = time.Now()
+	}
+	e.mu.Unlock()
+	if due {
+		go e.adviseModel()
+	}
+}
+
+// adviseModel surfaces a model suggestion unless the user dismissed it.
+func (e *Engine) adviseModel() {
+	n, ok := e.bridge.(modelSuggestionNotifier)
+	if !ok {
+		return
+	}
+	s := e.ModelSuggestion()
+	if s == nil {
+		return
+	}
+	if settings, err := config.Load(); err == nil && slices.Contains(settings.DismissedModelSuggestions, s.Key()) {
+		return
+	}
+	n.EmitModelSuggestion(*s)
+}
+
+// modelIDOf strips the provider prefix from a "provider:model_id" label.
+func modelIDOf(label string) string {
+	if _, id, ok := strings.Cut(label, ":"); ok {
+		return id
+	}
+	return label
+}
+
+// settingsModelLabel converts an engine model label to the "provider:model_id" form
+// used by settings and the model selector, which call Anthropic "claude".
+func settingsModelLabel(label string) string {
+	if id, ok := strings.CutPrefix(label, "anthropic:"); ok {
+		return "claude:" + id
+	}
+	return label
+}
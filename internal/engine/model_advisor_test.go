@@ -0,0 +1,99 @@
+package engine
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/loom/loom/internal/config"
+)
+
+func sonnetTurns(now time.Time, small, large int) []config.TurnRecord {
+	var turns []config.TurnRecord
+	add := func(i, lines int) {
+		in, out := int64(40000), int64(2000)
+		_, _, cost := config.CostUSDParts("claude-sonnet-4-20250514", in, out)
+		turns = append(turns, config.TurnRecord{
+			Time:  now.Add(-time.Duration(i) * 12 * time.Hour),
+			Model: "anthropic:claude-sonnet-4-20250514", InTokens: in, OutTokens: out, CostUSD: cost,
+			EditedFiles: 1, EditedLines: lines,
+		})
+	}
+	for i := 0; i < small; i++ {
+		add(i, 6)
+	}
+	for i := 0; i < large; i++ {
+		add(small+i, 300)
+	}
+	return turns
+}
+
+func haikuOutcomes(runs, equivalent int) []config.ExperimentOutcome {
+	var out []config.ExperimentOutcome
+	for i := 0; i < runs; i++ {
+		out = append(out, config.ExperimentOutcome{
+			Baseline:   "anthropic:claude-sonnet-4-20250514",
+			Candidate:  "claude:claude-3-5-haiku-20241022",
+			Equivalent: i < equivalent,
+		})
+	}
+	return out
+}
+
+func TestRecommendModel_SuggestsCheaperEquivalentModel(t *testing.T) {
+	now := time.Now()
+	s := RecommendModel("anthropic:claude-sonnet-4-20250514", sonnetTurns(now, 40, 10), haikuOutcomes(5, 5), now)
+	if s == nil {
+		t.Fatal("expected a suggestion")
+	}
+	if s.Current != "claude:claude-sonnet-4-20250514" || s.Suggested != "claude:claude-3-5-haiku-20241022" {
+		t.Errorf("unexpected models: %+v", s)
+	}
+	if s.Turns != 50 || s.SmallEditShare != 0.8 || s.Experiments != 5 || s.EquivalentRate != 1 {
+		t.Errorf("unexpected stats: %+v", s)
+	}
+	if s.MonthlySavingsUSD <= 0 || s.ProjectedMonthlyUSD >= s.CurrentMonthlyUSD {
+		t.Errorf("expected projected savings: %+v", s)
+	}
+	if !strings.Contains(s.Reason, "80%") || !strings.Contains(s.Reason, "5 of 5") {
+		t.Errorf("unexpected reason: %q", s.Reason)
+	}
+	if s.Key() != "claude-sonnet-4-20250514->claude-3-5-haiku-20241022" {
+		t.Errorf("unexpected key %q", s.Key())
+	}
+}
+
+func TestRecommendModel_RequiresEvidence(t *testing.T) {
+	now := time.Now()
+	cur := "anthropic:claude-sonnet-4-20250514"
+	cases := map[string]*ModelSuggestion{
+		"too few turns":          RecommendModel(cur, sonnetTurns(now, 10, 0), haikuOutcomes(5, 5), now),
+		"mostly large edits":     RecommendModel(cur, sonnetTurns(now, 10, 30), haikuOutcomes(5, 5), now),
+		"too few experiments":    RecommendModel(cur, sonnetTurns(now, 40, 0), haikuOutcomes(2, 2), now),
+		"candidate often worse":  RecommendModel(cur, sonnetTurns(now, 40, 0), haikuOutcomes(5, 3), now),
+		"different current":      RecommendModel("openai:gpt-4o", sonnetTurns(now, 40, 0), haikuOutcomes(5, 5), now),
+		"no turns recorded":      RecommendModel(cur, nil, haikuOutcomes(5, 5), now),
+		"outside of the window":  RecommendModel(cur, sonnetTurns(now.Add(-60*24*time.Hour), 40, 0), haikuOutcomes(5, 5), now),
+		"no experiments at all":  RecommendModel(cur, sonnetTurns(now, 40, 0), nil, now),
+		"unlabelled current run": RecommendModel("", sonnetTurns(now, 40, 0), haikuOutcomes(5, 5), now),
+	}
+	for name, s := range cases {
+		if s != nil {
+			t.Errorf("%s: expected no suggestion, got %+v", name, s)
+		}
+	}
+}
+
+func TestRecordModelComparison(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	base := ExperimentTrace{Model: "anthropic:claude-sonnet-4-20250514", FinalAnswer: "done", EditedFiles: []string{"a.go"}}
+	recordModelComparison(base, ExperimentTrace{Model: "claude:claude-3-5-haiku-20241022", FinalAnswer: "ok", EditedFiles: []string{"a.go"}})
+	recordModelComparison(base, ExperimentTrace{Model: "claude:claude-3-5-haiku-20241022", FinalAnswer: "ok", EditedFiles: []string{"b.go"}})
+	// Same model on both sides is not a model comparison
+	recordModelComparison(base, ExperimentTrace{Model: "claude:claude-sonnet-4-20250514", FinalAnswer: "ok"})
+
+	got := config.LoadExperimentOutcomes()
+	if len(got) != 2 || !got[0].Equivalent || got[1].Equivalent {
+		t.Fatalf("unexpected outcomes: %+v", got)
+	}
+}
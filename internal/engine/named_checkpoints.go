@@ -0,0 +1,140 @@
+package engine
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/loom/loom/internal/memory"
+	"github.com/loom/loom/internal/tool"
+)
+
+// namedCheckpointNotifier is implemented by UI bridges that show named checkpoints in
+// the timeline as they are created.
+type namedCheckpointNotifier interface {
+	EmitNamedCheckpoint(c memory.NamedCheckpoint)
+}
+
+// CreateNamedCheckpoint names the current point of the conversation's timeline.
+// source is "model" or "user".
+func (e *Engine) CreateNamedCheckpoint(name, source string) (memory.NamedCheckpoint, error) {
+	if e.memory == nil {
+		return memory.NamedCheckpoint{}, errors.New("memory not initialized")
+	}
+	c, err := e.memory.AddNamedCheckpoint(e.memory.CurrentConversationID(), memory.NamedCheckpoint{Name: name, Step: e.latestStep(), Source: source})
+	if err != nil {
+		return c, err
+	}
+	if n, ok := e.bridge.(namedCheckpointNotifier); ok {
+		n.EmitNamedCheckpoint(c)
+	}
+	return c, nil
+}
+
+// NamedCheckpoints returns the current conversation's named checkpoints, oldest first.
+func (e *Engine) NamedCheckpoints() []memory.NamedCheckpoint {
+	if e.memory == nil {
+		return nil
+	}
+	return e.memory.NamedCheckpoints(e.memory.CurrentConversationID())
+}
+
+// RemoveNamedCheckpoint deletes a named checkpoint of the current conversation.
+func (e *Engine) RemoveNamedCheckpoint(name string) error {
+	if e.memory == nil {
+		return errors.New("memory not initialized")
+	}
+	return e.memory.RemoveNamedCheckpoint(e.memory.CurrentConversationID(), name)
+}
+
+// namedCheckpoint resolves a checkpoint name of the current conversation.
+func (e *Engine) namedCheckpoint(name string) (memory.NamedCheckpoint, error) {
+	if e.memory == nil {
+		return memory.NamedCheckpoint{}, errors.New("memory not initialized")
+	}
+	c, ok := e.memory.NamedCheckpoint(e.memory.CurrentConversationID(), name)
+	if !ok {
+		return c, fmt.Errorf("no checkpoint named %q", name)
+	}
+	return c, nil
+}
+
+// latestStep returns the newest step of the current conversation's timeline.
+func (e *Engine) latestStep() int {
+	if items := e.Timeline(); len(items) > 0 {
+		return items[len(items)-1].Step
+	}
+	return 0
+}
+
+// DiffNamedCheckpoint returns the changes made since a named checkpoint.
+func (e *Engine) DiffNamedCheckpoint(name string) (*TimelineDiff, error) {
+	c, err := e.namedCheckpoint(name)
+	if err != nil {
+		return nil, err
+	}
+	return e.DiffCheckpoints(c.Step, e.latestStep())
+}
+
+// RevertToNamedCheckpoint restores files to their state at a named checkpoint, like
+// RevertToCheckpoint.
+func (e *Engine) RevertToNamedCheckpoint(name string, paths []string) ([]string, error) {
+	c, err := e.namedCheckpoint(name)
+	if err != nil {
+		return nil, err
+	}
+	return e.RevertToCheckpoint(c.Step, paths)
+}
+
+// checkpointTool gives the checkpoint tool the current conversation's timeline.
+type checkpointTool struct {
+	e *Engine
+}
+
+func (c checkpointTool) CreateCheckpoint(name string) (memory.NamedCheckpoint, error) {
+	return c.e.CreateNamedCheckpoint(name, "model")
+}
+
+func (c checkpointTool) ListCheckpoints() []memory.NamedCheckpoint {
+	return c.e.NamedCheckpoints()
+}
+
+func (c checkpointTool) DiffCheckpoint(name string) (string, int, error) {
+	d, err := c.e.DiffNamedCheckpoint(name)
+	if err != nil {
+		return "", 0, err
+	}
+	return d.Diff, len(d.Files), nil
+}
+
+// RevertCheckpoint shows the user what the revert undoes and reverts only once they
+// approve, through the same approval as an edit_file call.
+func (c checkpointTool) RevertCheckpoint(name string, paths []string) ([]string, error) {
+	e := c.e
+	if e.tools.DemoMode() {
+		return nil, errDemoMode
+	}
+	if reason := e.tools.ReadOnlyReason(); reason != "" {
+		return nil, fmt.Errorf("the workspace is read-only: %s", reason)
+	}
+	cp, err := e.namedCheckpoint(name)
+	if err != nil {
+		return nil, err
+	}
+	preview, err := e.DiffCheckpoints(e.latestStep(), cp.Step)
+	if err != nil {
+		return nil, err
+	}
+	if len(preview.Files) == 0 {
+		return nil, nil
+	}
+	if e.approvalHandler != nil {
+		args, _ := json.Marshal(map[string]any{"checkpoint": cp.Name, "paths": paths})
+		call := &tool.ToolCall{ID: fmt.Sprintf("checkpoint-%d", time.Now().UnixNano()), Name: "edit_file", Args: args}
+		if !e.approvalHandler.UserApproved(call, fmt.Sprintf("Revert to checkpoint %q (step %d):\n\n%s", cp.Name, cp.Step, preview.Diff)) {
+			return nil, errors.New("revert not approved")
+		}
+	}
+	return e.RevertToCheckpoint(cp.Step, paths)
+}
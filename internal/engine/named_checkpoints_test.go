@@ -0,0 +1,114 @@
+package engine
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/loom/loom/internal/memory"
+	"github.com/loom/loom/internal/tool"
+)
+
+// approvalBridge answers approval prompts with approve and keeps the last diff shown.
+type approvalBridge struct {
+	UIBridge
+	ah      *ApprovalHandler
+	approve bool
+	diff    string
+}
+
+func (b *approvalBridge) PromptApproval(actionID, summary, diff string) bool {
+	b.diff = diff
+	go b.ah.ResolveApproval(actionID, b.approve)
+	return false
+}
+
+func TestNamedCheckpoints_DiffAndRevert(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	ws := t.TempDir()
+	store, err := memory.NewStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	proj, err := memory.NewProject(store, ws)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := proj.SetCurrentConversationID("c1"); err != nil {
+		t.Fatal(err)
+	}
+	e := New(nil, nil).WithWorkspace(ws).WithMemory(proj)
+	tr := &timelineRecorder{project: proj, workspace: ws, conversationID: "c1"}
+	edit := func(name, content string) {
+		args, _ := json.Marshal(map[string]string{"path": name})
+		pc := tr.capture(&tool.ToolCall{ID: "t-" + name, Name: "apply_edit", Args: args})
+		if err := os.WriteFile(filepath.Join(ws, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		tr.commit(pc)
+	}
+	bridge := &approvalBridge{}
+	bridge.ah = NewApprovalHandler(bridge)
+	e.approvalHandler = bridge.ah
+	cp := checkpointTool{e}
+
+	edit("auth.go", "v1\n") // step 1
+	before, err := cp.CreateCheckpoint("  before refactor  of auth module ")
+	if err != nil || before.Name != "before refactor of auth module" || before.Step != 1 || before.Source != "model" {
+		t.Fatalf("unexpected checkpoint %+v %v", before, err)
+	}
+	edit("auth.go", "v2\n")   // step 2
+	edit("session.go", "s\n") // step 3
+
+	diff, files, err := cp.DiffCheckpoint("Before Refactor of Auth Module")
+	if err != nil || files != 2 || !strings.Contains(diff, "+v2") || !strings.Contains(diff, "+++ b/session.go") {
+		t.Fatalf("unexpected diff (%d files, %v):\n%s", files, err, diff)
+	}
+	if _, _, err := cp.DiffCheckpoint("missing"); err == nil {
+		t.Fatal("expected an error for an unknown checkpoint")
+	}
+
+	// Reusing a name moves the checkpoint
+	if _, err := e.CreateNamedCheckpoint("latest", "user"); err != nil {
+		t.Fatal(err)
+	}
+	if moved, _ := e.CreateNamedCheckpoint("LATEST", "user"); moved.Step != 3 || len(e.NamedCheckpoints()) != 2 {
+		t.Fatalf("expected two checkpoints, got %+v", e.NamedCheckpoints())
+	}
+
+	// The user sees what the revert undoes and may refuse it
+	if _, err := cp.RevertCheckpoint("before refactor of auth module", nil); err == nil || !strings.Contains(bridge.diff, "-v2") {
+		t.Fatalf("expected a refused revert showing its diff, got %v:\n%s", err, bridge.diff)
+	}
+	if data, _ := os.ReadFile(filepath.Join(ws, "auth.go")); string(data) != "v2\n" {
+		t.Fatal("a refused revert must not change files")
+	}
+	bridge.approve = true
+	reverted, err := cp.RevertCheckpoint("before refactor of auth module", nil)
+	if err != nil || strings.Join(reverted, ",") != "auth.go,session.go" {
+		t.Fatalf("unexpected revert %v %v", reverted, err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(ws, "auth.go")); string(data) != "v1\n" {
+		t.Fatalf("auth.go not reverted: %q", data)
+	}
+	if _, err := os.Stat(filepath.Join(ws, "session.go")); !os.IsNotExist(err) {
+		t.Fatal("session.go should be removed")
+	}
+	// The revert is itself on the timeline, so the later checkpoint still diffs against it
+	if d, err := e.DiffNamedCheckpoint("latest"); err != nil || len(d.Files) != 2 {
+		t.Fatalf("unexpected diff after revert: %+v %v", d, err)
+	}
+	if reverted, err := cp.RevertCheckpoint("before refactor of auth module", nil); err != nil || len(reverted) != 0 {
+		t.Fatalf("a second revert should find nothing to do: %v %v", reverted, err)
+	}
+
+	if err := e.RemoveNamedCheckpoint("latest"); err != nil || len(e.NamedCheckpoints()) != 1 {
+		t.Fatalf("remove failed: %v", err)
+	}
+	_ = proj.DeleteConversation("c1")
+	if len(proj.NamedCheckpoints("c1")) != 0 {
+		t.Fatal("checkpoints should be deleted with the conversation")
+	}
+}
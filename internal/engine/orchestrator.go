@@ -9,9 +9,12 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/loom/loom/internal/config"
 	"github.com/loom/loom/internal/memory"
+	"github.com/loom/loom/internal/policy"
+	"github.com/loom/loom/internal/textutil"
 	"github.com/loom/loom/internal/tool"
 )
 
@@ -21,12 +24,15 @@ type Message struct {
 	Content string `json:"content"`           // text content
 	Name    string `json:"name,omitempty"`    // function/tool name when applicable
 	ToolID  string `json:"tool_id,omitempty"` // ID for tool invocations
+	Version int    `json:"version,omitempty"` // tool schema version of a tool call's arguments
 }
 
 // TokenOrToolCall represents a token from LLM or a tool call request.
 type TokenOrToolCall struct {
 	Token    string
 	ToolCall *ToolCall
+	// ArgsDelta carries a fragment of a tool call's arguments while they stream in
+	ArgsDelta *ToolArgsDelta
 }
 
 // ToolCall represents an LLM's request to call a tool.
@@ -35,6 +41,8 @@ type ToolCall struct {
 	Name   string
 	Args   json.RawMessage
 	IsSafe bool // true if doesn't require approval
+	// Version is the tool schema version Args follow; 0 means the current one
+	Version int
 }
 
 // UIBridge interfaces with the user interface.
@@ -46,9 +54,15 @@ type UIBridge interface {
 	EmitBilling(provider string, model string, inTokens int64, outTokens int64, inUSD float64, outUSD float64, totalUSD float64)
 	PromptApproval(actionID string, summary string, diff string) (approved bool)
 	PromptChoice(actionID string, question string, options []string) (selectedIndex int)
+	// PromptQuestion shows an ask_user question; the answer arrives via ResolveQuestion
+	PromptQuestion(actionID string, question tool.AskUserArgs)
 	SetBusy(isBusy bool)
 	// Request the UI to open a file path (relative to workspace) in the file viewer
 	OpenFileInUI(path string)
+	// EmitStatus reports the engine heartbeat (phase, running tool, stalls)
+	EmitStatus(status Status)
+	// EmitToolPreview shows a tool call whose arguments are still being generated
+	EmitToolPreview(preview ToolPreview)
 }
 
 // ApprovalRequest tracks an outstanding approval request.
@@ -76,7 +90,9 @@ type Engine struct {
 	tools        *tool.Registry
 	memory       *memory.Project
 	workspaceDir string
-	llmMu        sync.Mutex
+	// roots are further workspace roots open next to workspaceDir (see workspaces.go)
+	roots []WorkspaceRoot
+	llmMu sync.Mutex
 	// AI personality setting
 	personality string
 	// model label like "openai:gpt-4o" for titling
@@ -92,9 +108,48 @@ type Engine struct {
 
 	// cancellation support for stopping LLM operations
 	currentCtx    context.Context
-	cancelCurrent context.CancelFunc
+	cancelCurrent context.CancelCauseFunc
 	ctxMu         sync.Mutex
 
+	// heartbeat reports the current phase to the UI and detects stalled providers
+	heartbeat *heartbeat
+
+	// resumeChecked holds conversations whose files were re-verified since they were
+	// last loaded; the first run after loading checks for state drift
+	resumeChecked map[string]bool
+
+	// advisorChecked is when usage analytics were last checked for a model suggestion
+	advisorChecked time.Time
+
+	// whatIf is the current conversation's overlay when what-if mode is on
+	whatIf whatIfRegistry
+
+	// errLog keeps the most recent provider and tool errors for diagnostics
+	errLog errorLog
+
+	// dirty holds unsaved line ranges reported by the UI editor; forks share it
+	dirty *dirtyBuffers
+
+	// features is the state of the experimental feature flags
+	features featureFlags
+
+	// changes is the changed-files feed of the current or most recent run
+	changes *changeFeed
+
+	// shells are the conversations' persistent shell sessions (run_shell session=true)
+	shells *tool.ShellSessions
+
+	// refined is the model's refinement of the current change description
+	refined *refinedDescription
+
+	// edits applies the workspace writes of the engine and its forks one at a time
+	edits *editQueue
+	// parent is the engine a fork runs next to; nil for the main engine (see forks.go)
+	parent *Engine
+	// forks are the conversations running next to this one, by conversation id
+	forks   map[string]*Engine
+	forksMu sync.Mutex
+
 	// extracted modules
 	conversationMgr *ConversationManager
 	approvalHandler *ApprovalHandler
@@ -124,15 +179,21 @@ func New(llm LLM, bridge UIBridge) *Engine {
 		llm:      llm,
 		bridge:   bridge,
 		messages: []Message{},
+		shells:   tool.NewShellSessions(),
+		dirty:    &dirtyBuffers{},
+		edits:    newEditQueue(),
 	}
 	// Initialize modules
 	e.approvalHandler = NewApprovalHandler(bridge)
+	e.heartbeat = newHeartbeat(e.emitStatus)
 	return e
 }
 
 // WithRegistry sets the tool registry for the engine.
 func (e *Engine) WithRegistry(registry *tool.Registry) *Engine {
 	e.tools = registry
+	// Forks share the registry; their activity messages are routed per call
+	e.eachFork(func(f *Engine) { f.tools = registry })
 	// Provide the UI bridge to the tools registry for activity notifications
 	if e.bridge != nil {
 		registry.WithUI(e.bridge)
@@ -146,7 +207,14 @@ func (e *Engine) WithRegistry(registry *tool.Registry) *Engine {
 
 // WithMemory sets the project memory for the engine.
 func (e *Engine) WithMemory(project *memory.Project) *Engine {
+	// Forks hold views of the previous project
+	if project != e.memory {
+		e.CloseForks()
+	}
 	e.memory = project
+	if e.approvalHandler != nil {
+		e.approvalHandler.SetAuditLog(project)
+	}
 	// Initialize conversation manager with memory
 	e.conversationMgr = NewConversationManager(project)
 	// Update stream processor with memory
@@ -156,6 +224,11 @@ func (e *Engine) WithMemory(project *memory.Project) *Engine {
 	return e
 }
 
+// Memory returns the project memory the engine and its forks use, or nil.
+func (e *Engine) Memory() *memory.Project {
+	return e.memory
+}
+
 // GetUsage exposes persisted usage totals for the current project.
 func (e *Engine) GetUsage() memory.UsageTotals {
 	if e.memory == nil {
@@ -172,8 +245,25 @@ func (e *Engine) ResetUsage() error {
 	return e.memory.ResetUsage()
 }
 
+// RecordRename stores a workspace file move so later tool calls using the old path
+// resolve to the new one.
+func (e *Engine) RecordRename(from, to string) error {
+	if e.memory == nil {
+		return errors.New("memory not initialized")
+	}
+	return e.memory.RecordRename(from, to)
+}
+
 // WithWorkspace sets the workspace directory path for the engine.
 func (e *Engine) WithWorkspace(path string) *Engine {
+	if path != e.workspaceDir {
+		// Sessions run in the previous workspace
+		e.shells.CloseAll()
+		// Further roots belong to the previous workspace
+		e.mu.Lock()
+		e.roots = nil
+		e.mu.Unlock()
+	}
 	e.workspaceDir = path
 	// Initialize stream processor
 	e.streamProcessor = NewStreamProcessor(e.bridge, e.memory)
@@ -191,6 +281,15 @@ func (e *Engine) Workspace() string {
 	return e.workspaceDir
 }
 
+// Sealer returns the encryption of the workspace's stored data, or nil when it is
+// stored in plaintext.
+func (e *Engine) Sealer() *memory.Sealer {
+	if e.memory == nil {
+		return nil
+	}
+	return e.memory.Sealer()
+}
+
 // SetBridge sets the UI bridge for the engine.
 func (e *Engine) SetBridge(bridge UIBridge) {
 	e.mu.Lock()
@@ -215,27 +314,39 @@ func (e *Engine) SetAutoApprove(shell bool, edits bool) {
 	if e.approvalHandler != nil {
 		e.approvalHandler.SetAutoApprove(shell, edits)
 	}
+	e.eachFork(func(f *Engine) { f.SetAutoApprove(shell, edits) })
+}
+
+// SetApprovalPolicies installs approval policy rules evaluated before the auto-approve toggles.
+func (e *Engine) SetApprovalPolicies(set *policy.Set) {
+	if e.approvalHandler != nil {
+		e.approvalHandler.SetPolicies(set)
+	}
+	e.eachFork(func(f *Engine) { f.SetApprovalPolicies(set) })
 }
 
 // SetPersonality sets the AI personality for system prompt injection
 func (e *Engine) SetPersonality(personality string) {
 	e.mu.Lock()
-	defer e.mu.Unlock()
 	e.personality = personality
+	e.mu.Unlock()
+	e.eachFork(func(f *Engine) { f.SetPersonality(personality) })
 }
 
 // SetLLM updates the LLM used by the engine.
 func (e *Engine) SetLLM(llm LLM) {
 	e.llmMu.Lock()
-	defer e.llmMu.Unlock()
 	e.llm = llm
+	e.llmMu.Unlock()
+	e.eachFork(func(f *Engine) { f.SetLLM(llm) })
 }
 
 // SetModelLabel sets a human-readable model label (e.g., "openai:gpt-4o").
 func (e *Engine) SetModelLabel(label string) {
 	e.mu.Lock()
-	defer e.mu.Unlock()
 	e.currentModelLabel = label
+	e.mu.Unlock()
+	e.eachFork(func(f *Engine) { f.SetModelLabel(label) })
 }
 
 // GetModelLabel returns the current model label if known.
@@ -249,7 +360,6 @@ func (e *Engine) GetModelLabel() string {
 // The path should be workspace-relative using forward slashes.
 func (e *Engine) SetEditorContext(path string, line, column int) {
 	e.mu.Lock()
-	defer e.mu.Unlock()
 	e.editorCtx.Path = strings.TrimSpace(path)
 	if line < 1 {
 		line = 1
@@ -259,6 +369,8 @@ func (e *Engine) SetEditorContext(path string, line, column int) {
 	}
 	e.editorCtx.Line = line
 	e.editorCtx.Column = column
+	e.mu.Unlock()
+	e.eachFork(func(f *Engine) { f.SetEditorContext(path, line, column) })
 }
 
 // formatEditorContext returns a single-line hint about the user's current editor state.
@@ -296,7 +408,18 @@ func (e *Engine) SetCurrentConversationID(id string) error {
 	if e.conversationMgr == nil {
 		return errors.New("conversation manager not initialized")
 	}
-	return e.conversationMgr.SetCurrentConversationID(id)
+	// Two engines must not write the same conversation
+	if e.memory.IsConversationOpen(id) {
+		return fmt.Errorf("conversation %s is open in another tab", id)
+	}
+	if err := e.conversationMgr.SetCurrentConversationID(id); err != nil {
+		return err
+	}
+	// Loading a conversation is a resume: re-verify its files on the next run
+	e.mu.Lock()
+	delete(e.resumeChecked, id)
+	e.mu.Unlock()
+	return nil
 }
 
 // GetConversation returns the messages for the given conversation id.
@@ -307,6 +430,26 @@ func (e *Engine) GetConversation(id string) ([]Message, error) {
 	return e.conversationMgr.GetConversation(id)
 }
 
+// ConversationTitle returns the stored title of conversation id, or "".
+func (e *Engine) ConversationTitle(id string) string {
+	if e.memory == nil {
+		return ""
+	}
+	return e.memory.GetConversationTitle(id)
+}
+
+// QueryConversation returns a filtered page of a conversation's messages; an empty id
+// selects the current conversation.
+func (e *Engine) QueryConversation(id string, q memory.MessageQuery) (memory.MessagePage, error) {
+	if e.memory == nil {
+		return memory.MessagePage{}, errors.New("memory not initialized")
+	}
+	if id == "" {
+		id = e.CurrentConversationID()
+	}
+	return e.memory.QueryConversation(id, q)
+}
+
 // NewConversation creates and switches to a new conversation.
 func (e *Engine) NewConversation() string {
 	if e.conversationMgr == nil {
@@ -343,9 +486,9 @@ func (e *Engine) Enqueue(message string) {
 	e.ctxMu.Lock()
 	// Cancel any existing operation before starting a new one
 	if e.cancelCurrent != nil {
-		e.cancelCurrent()
+		e.cancelCurrent(nil)
 	}
-	e.currentCtx, e.cancelCurrent = context.WithCancel(context.Background())
+	e.currentCtx, e.cancelCurrent = context.WithCancelCause(context.Background())
 	ctx := e.currentCtx
 	e.ctxMu.Unlock()
 
@@ -355,30 +498,70 @@ func (e *Engine) Enqueue(message string) {
 	}()
 }
 
+// Run sends message like Enqueue but processes it on the calling goroutine and returns
+// the run's error, for headless runs that need the outcome. Cancelling ctx or calling
+// Stop ends the run.
+func (e *Engine) Run(ctx context.Context, message string) error {
+	e.bridge.SendChat("user", message)
+
+	e.ctxMu.Lock()
+	if e.cancelCurrent != nil {
+		e.cancelCurrent(nil)
+	}
+	runCtx, cancel := context.WithCancelCause(ctx)
+	e.currentCtx, e.cancelCurrent = runCtx, cancel
+	e.ctxMu.Unlock()
+	defer cancel(nil)
+
+	return e.processLoop(runCtx, message)
+}
+
+// CloseShellSessions ends all persistent shell sessions, e.g. when the app exits.
+func (e *Engine) CloseShellSessions() {
+	e.shells.CloseAll()
+}
+
 // Stop cancels any running LLM operation.
 func (e *Engine) Stop() {
 	e.ctxMu.Lock()
 	defer e.ctxMu.Unlock()
 
 	if e.cancelCurrent != nil {
-		e.cancelCurrent()
+		e.cancelCurrent(nil)
 		e.cancelCurrent = nil
 		e.currentCtx = nil
 	}
 }
 
-// ResolveApproval resolves a pending approval request.
+// isCurrentRun reports whether ctx belongs to the latest run (or no run is active).
+func (e *Engine) isCurrentRun(ctx context.Context) bool {
+	e.ctxMu.Lock()
+	defer e.ctxMu.Unlock()
+	return e.currentCtx == nil || e.currentCtx == ctx
+}
+
+// ResolveApproval resolves a pending approval request of the engine or its forks.
 func (e *Engine) ResolveApproval(id string, approved bool) {
 	if e.approvalHandler != nil {
 		e.approvalHandler.ResolveApproval(id, approved)
 	}
+	e.eachFork(func(f *Engine) { f.ResolveApproval(id, approved) })
 }
 
-// ResolveChoice resolves a pending choice request.
+// ResolveChoice resolves a pending choice request of the engine or its forks.
 func (e *Engine) ResolveChoice(id string, selectedIndex int) {
 	if e.approvalHandler != nil {
 		e.approvalHandler.ResolveChoice(id, selectedIndex)
 	}
+	e.eachFork(func(f *Engine) { f.ResolveChoice(id, selectedIndex) })
+}
+
+// ResolveQuestion resolves a pending ask_user question of the engine or its forks.
+func (e *Engine) ResolveQuestion(id string, selectedIndex int, text string) {
+	if e.approvalHandler != nil {
+		e.approvalHandler.ResolveQuestion(id, selectedIndex, text)
+	}
+	e.eachFork(func(f *Engine) { f.ResolveQuestion(id, selectedIndex, text) })
 }
 
 // UserApproved prompts for approval and waits for the response.
@@ -397,13 +580,46 @@ func (e *Engine) UserChoice(toolCall *tool.ToolCall, question string, options []
 	return -1
 }
 
+// systemPromptOptions gathers the rules, the memories most relevant to the request and
+// the personality for a run's system prompt.
+func (e *Engine) systemPromptOptions(convo *memory.Conversation, userMsg, root string, toolSchemas []tool.Schema) SystemPromptOptions {
+	userRules, projectRules, _ := config.LoadRules(root)
+	// Mature projects keep many memories; only the most relevant enter the prompt
+	mems, memsOmitted := selectMemoriesForPrompt(append(loadUserMemoriesForPrompt(), projectMemoriesForPrompt(e.memory)...), e.memoryRequest(convo, userMsg))
+	e.mu.RLock()
+	currentPersonality := e.personality
+	e.mu.RUnlock()
+	return SystemPromptOptions{
+		Tools:                 toolSchemas,
+		UserRules:             userRules,
+		ProjectRules:          projectRules,
+		Memories:              mems,
+		MemoriesOmitted:       memsOmitted,
+		Personality:           currentPersonality,
+		WorkspaceRoot:         root,
+		WorkspaceRoots:        e.WorkspaceRoots(),
+		IncludeProjectContext: true,
+		ModelName:             e.GetModelLabel(),
+	}
+}
+
 // processLoop is the main processing loop for the engine.
-func (e *Engine) processLoop(ctx context.Context, userMsg string) error {
+// An empty userMsg resumes from the stored history (used by Retry).
+func (e *Engine) processLoop(ctx context.Context, userMsg string) (err error) {
 	// Indicate busy state to UI during the request lifecycle
 	if e.bridge != nil {
 		e.bridge.SetBusy(true)
-		defer e.bridge.SetBusy(false)
 	}
+	defer func() {
+		// A retried run replaces this one; leave busy state and heartbeat to the newer run
+		if !e.isCurrentRun(ctx) {
+			return
+		}
+		if e.bridge != nil {
+			e.bridge.SetBusy(false)
+		}
+		e.heartbeat.set(PhaseIdle, "")
+	}()
 	// Initialize memory if needed
 	if e.memory == nil {
 		e.bridge.SendChat("system", "Error: Memory not initialized")
@@ -416,45 +632,122 @@ func (e *Engine) processLoop(ctx context.Context, userMsg string) error {
 		return errors.New("tool registry not initialized")
 	}
 
-	// Fetch tool schemas for prompt generation and tool calling
-	toolSchemas := e.tools.Schemas()
+	// In what-if mode tools operate on the conversation's overlay instead of the workspace
+	registry, root := e.runTools()
+	whatIf := root != e.Workspace()
+
+	// Fetch tool schemas for prompt generation and tool calling; tools the user switched
+	// off for this conversation are never offered
+	disabled := map[string]bool{}
+	for _, name := range e.DisabledTools() {
+		disabled[name] = true
+	}
+	toolSchemas := withoutDisabledTools(registry.Schemas(), disabled)
 
 	// Start or load conversation
 	convo := e.memory.StartConversation() // load history & summaries
+	// Tool calls are stored with the schema version their arguments follow
+	convo.SetToolSchemaVersions(registry.SchemaVersions())
+	if e.streamProcessor != nil {
+		e.streamProcessor.SetTools(registry)
+	}
+
+	// Usage analytics record what this request cost and changed
+	turn := e.beginTurn()
+	defer e.finishTurn(turn)
+	// Every run leaves a machine-readable summary under .loom/runs
+	run := e.beginRun(convo, userMsg, whatIf)
+	defer func() { e.finishRun(ctx, run, turn, convo, err) }()
 
 	// Always update the system prompt to reflect current personality and context
 	// This allows personality changes to take effect mid-conversation
-	userRules, projectRules, _ := config.LoadRules(e.workspaceDir)
-	mems := loadUserMemoriesForPrompt()
-	e.mu.RLock()
-	currentPersonality := e.personality
-	e.mu.RUnlock()
-	base := GenerateSystemPromptUnified(SystemPromptOptions{
-		Tools:                 toolSchemas,
-		UserRules:             userRules,
-		ProjectRules:          projectRules,
-		Memories:              mems,
-		Personality:           currentPersonality,
-		WorkspaceRoot:         e.workspaceDir,
-		IncludeProjectContext: true,
-		ModelName:             e.GetModelLabel(),
-	})
+	promptOpts := e.systemPromptOptions(convo, userMsg, root, toolSchemas)
+	mems := promptOpts.Memories
+	base := GenerateSystemPromptUnified(promptOpts)
+	// Requests rejected as too large are retried with the lowest-priority context left out
+	shrink := newContextShrinker(promptOpts, base, run.started)
 	if ui := strings.TrimSpace(e.formatEditorContext()); ui != "" {
 		base = strings.TrimSpace(base) + "\n\nUI Context:\n- " + ui
 	}
+	// Small-context local models get size-limited, verified edits
+	chunking := editChunkingFor(e.GetModelLabel())
+	if chunking != nil {
+		base += chunking.promptSection()
+	}
+	if editFormatFor(e.GetModelLabel()) == config.EditFormatSearchReplace {
+		base += searchReplacePrompt
+	}
+	if whatIf {
+		base += whatIfPrompt
+	}
+	// The conversation's output style shapes verbosity, language and tone of replies
+	if settings, err := config.Load(); err == nil {
+		base += outputStylePrompt(convo, settings, effectiveOutputStyle(settings, e.memory, e.memory.CurrentConversationID()))
+		// The explanation level sets the depth and vocabulary of explanations
+		base += explanationPrompt(convo, effectiveExplanationLevel(settings, e.memory, e.memory.CurrentConversationID()))
+	}
+	// A configured definition of done gates finalization for this run
+	done := newDoneGuard(root)
+	if done != nil {
+		// Commands come from the repository, so they go through the shell approval path
+		done.approve = func(command string) bool {
+			args, _ := json.Marshal(map[string]string{"command": command})
+			call := &tool.ToolCall{ID: fmt.Sprintf("done-%d", time.Now().UnixNano()), Name: "run_shell", Args: args}
+			return e.approvalHandler.UserApproved(call, "Definition of done check:\n$ "+command)
+		}
+		done.audit = e.memory
+		base += done.promptSection()
+	}
+	// A reproduce-first bug fix confirms a failing reproduction before changing code
+	repro := newReproGuard(e.memory, e.memory.CurrentConversationID(), root)
+	if repro != nil {
+		repro.approve = func(command string) bool {
+			args, _ := json.Marshal(map[string]string{"command": command})
+			call := &tool.ToolCall{ID: fmt.Sprintf("repro-%d", time.Now().UnixNano()), Name: "run_shell", Args: args}
+			return e.approvalHandler.UserApproved(call, "Reproduction:\n$ "+command)
+		}
+		base += repro.promptSection()
+	}
+	if e.toolExecutor != nil {
+		e.toolExecutor.SetRegistry(registry)
+		e.toolExecutor.SetReproduction(repro)
+		e.toolExecutor.SetCoverageCheck(e.checkCoverage)
+		e.toolExecutor.SetChangeFeed(e.beginChangeFeed(root))
+		e.toolExecutor.SetDisabledTools(disabled)
+		e.toolExecutor.SetEditChunking(chunking)
+		e.toolExecutor.SetRenameTracking(e.memory, root)
+		e.toolExecutor.SetDoneGuard(done)
+		e.toolExecutor.SetAuditLog(e.memory, root)
+		e.toolExecutor.SetFileSnapshots(e.memory, root, e.memory.CurrentConversationID())
+		e.toolExecutor.SetTimeline(e.memory, root, e.memory.CurrentConversationID())
+		e.toolExecutor.SetPrefetch(e.FeatureEnabled(config.FeatureParallelTools))
+		e.toolExecutor.SetEditQueue(e.edits)
+		if whatIf {
+			e.toolExecutor.SetDirtyGuard(nil, "")
+			e.toolExecutor.SetEditJournal(nil, "", "")
+		} else {
+			e.toolExecutor.SetDirtyGuard(e.dirty, root)
+			e.toolExecutor.SetEditJournal(e.memory, root, e.memory.CurrentConversationID())
+		}
+	}
 	convo.UpdateSystemMessage(base)
 
+	// A resumed conversation learns which files changed on disk while it was inactive
+	if note := e.rehydrate(convo); note != "" {
+		convo.SetSystemNote(stateDriftNote, note)
+	}
+
 	// Add latest user message
-	convo.AddUser(userMsg)
+	if userMsg != "" {
+		convo.AddUser(userMsg)
+	}
 	// After the first user message in a conversation, if no title yet, set a title using the selected model
-	if e.memory != nil {
+	if e.memory != nil && userMsg != "" {
 		currentID := e.memory.CurrentConversationID()
 		if currentID != "" && e.memory.GetConversationTitle(currentID) == "" {
 			// Title: first (~50 chars) of the user's first message + current model label
 			title := userMsg
-			if len(title) > 50 {
-				title = title[:50] + "…"
-			}
+			title = textutil.TruncateWidth(title, 51)
 			_ = e.memory.SetConversationTitle(currentID, title)
 		}
 	}
@@ -471,9 +764,38 @@ func (e *Engine) processLoop(ctx context.Context, userMsg string) error {
 		return errors.New("llm not configured")
 	}
 
+	// Simple questions about the code are first answered from the index, without tools
+	if !whatIf && e.FeatureEnabled(config.FeatureQuickAnswers) && isInformational(userMsg) {
+		e.heartbeat.set(PhaseWaitingLLM, "")
+		if answer, ok := e.quickAnswer(ctx, userMsg, root, mems); ok {
+			convo.AddAssistant(answer)
+			e.bridge.EmitAssistant(answer)
+			return nil
+		}
+	}
+
 	// Track whether any tool has been used since the latest user message
 	toolsUsed := false
 
+	// Identical read-only tool calls within this run are answered from cache
+	toolCtx := tool.WithResultCache(ctx, tool.NewResultCache())
+	// Files the user attached from outside the workspace are readable as @attachments/<name>
+	toolCtx = tool.WithAttachmentsDir(toolCtx, e.AttachmentsDir())
+	// annotate_code comments are kept with the conversation
+	toolCtx = tool.WithAnnotationSink(toolCtx, e.annotationSink())
+	// checkpoint names points of the conversation's timeline
+	toolCtx = tool.WithCheckpoints(toolCtx, checkpointTool{e})
+	// undo_edit restores the conversation's applied edits from the edit journal
+	toolCtx = tool.WithEditUndo(toolCtx, undoTool{e})
+	// run_shell with session=true reuses the conversation's shell across runs
+	shellID := e.CurrentConversationID()
+	if shellID == "" {
+		shellID = "current"
+	}
+	toolCtx = tool.WithShellSession(toolCtx, e.shells, shellID)
+	// Activity messages go to this conversation's view, also when a fork shares the registry
+	toolCtx = tool.WithActivityUI(toolCtx, e.bridge)
+
 	// Track consecutive empty responses after tool usage to prevent pathological cases
 	consecutiveEmptyAfterTools := 0
 	maxConsecutiveEmpty := 3 // Allow up to 3 consecutive empty responses after tools before giving up
@@ -485,9 +807,13 @@ func (e *Engine) processLoop(ctx context.Context, userMsg string) error {
 			maxDepth = n
 		}
 	}
+	// The base system prompt travels in the role the provider expects
+	promptRole := PromptVariantFor(e.GetModelLabel()).Role
+
 	for depth := 0; depth < maxDepth; depth++ {
 		// Convert memory messages to engine messages
-		memoryMessages := convo.History()
+		history := convo.History()
+		memoryMessages := shrink.apply(history)
 		engineMessages := make([]Message, 0, len(memoryMessages))
 
 		for _, msg := range memoryMessages {
@@ -497,27 +823,49 @@ func (e *Engine) processLoop(ctx context.Context, userMsg string) error {
 				Name:    msg.Name,
 				ToolID:  msg.ToolID,
 			}
+			if msg.Role == "system" && msg.Name == "" && promptRole != "" {
+				engineMsg.Role = promptRole
+			}
 			engineMessages = append(engineMessages, engineMsg)
 		}
 
 		// Append up-to-date UI editor context as a transient system hint for this turn
+		var hints []string
 		if ui := strings.TrimSpace(e.formatEditorContext()); ui != "" {
-			engineMessages = append(engineMessages, Message{Role: "system", Content: "UI Context: " + ui})
+			hints = append(hints, "UI Context: "+ui)
+		}
+		// Directory-scoped memories only apply once the run touches files under their path
+		if hint := e.directoryMemoryHint(history); hint != "" {
+			hints = append(hints, hint)
+		}
+		if shrink.hints() {
+			for _, hint := range hints {
+				engineMessages = append(engineMessages, Message{Role: "system", Content: hint})
+			}
+		}
+		// After an overflow, tell the model what it can no longer see
+		if note := shrink.note(); note != "" {
+			engineMessages = append(engineMessages, Message{Role: "system", Content: note})
 		}
 		// No longer inject attachments as system context; they are appended to the user message on send
 
 		// Call the LLM with the conversation history (+ transient UI hint)
+		e.heartbeat.set(PhaseWaitingLLM, "")
 		stream, err := adapter.Chat(ctx, engineMessages, convertSchemas(tools), true)
+		if err != nil && isContextOverflow(err.Error()) && e.recoverOverflow(shrink, run, history, hints, err.Error()) {
+			continue
+		}
 		if err != nil {
+			e.errLog.add("llm", err)
 			e.bridge.SendChat("system", "Error: "+err.Error())
 			return err
 		}
 
 		// Process the LLM response using stream processor
-		result := e.streamProcessor.ProcessStream(ctx, stream, convo)
+		result := e.streamProcessor.ProcessStream(ctx, e.heartbeat.watch(ctx, stream), convo)
 		if ctx.Err() != nil {
-			// Send cancellation message to UI
-			if e.bridge != nil {
+			// Send cancellation message to UI unless the user asked to retry
+			if e.bridge != nil && !errors.Is(context.Cause(ctx), errRetryRequested) {
 				e.bridge.SendChat("system", "Operation stopped by user.")
 			}
 			return ctx.Err()
@@ -527,6 +875,11 @@ func (e *Engine) processLoop(ctx context.Context, userMsg string) error {
 		toolCallReceived := result.ToolCall
 		streamEnded := result.StreamEnded
 
+		// Providers report a request that exceeds the context window as an error token
+		if toolCallReceived == nil && isContextOverflow(currentContent) && e.recoverOverflow(shrink, run, history, hints, currentContent) {
+			continue
+		}
+
 		// If we got a tool call, execute it
 		if toolCallReceived != nil {
 			// Mark that at least one tool was used in this turn
@@ -534,9 +887,17 @@ func (e *Engine) processLoop(ctx context.Context, userMsg string) error {
 			// Reset empty response counter since we got a tool call
 			consecutiveEmptyAfterTools = 0
 			// Execute the tool using the tool executor
-			if err := e.toolExecutor.ExecuteToolCall(ctx, toolCallReceived, convo); err != nil {
+			e.heartbeat.set(toolPhase(toolCallReceived.Name), toolCallReceived.Name)
+			turn.toolCalls++
+			if err := e.toolExecutor.ExecuteToolCall(toolCtx, toolCallReceived, convo); err != nil {
+				e.errLog.add("tool", err)
 				return err
 			}
+			if summary, ok := e.toolExecutor.takeFinalized(); ok {
+				convo.AddAssistant(summary)
+				e.bridge.EmitAssistant(summary)
+				return nil
+			}
 			// Continue the loop to get the next assistant message
 			continue
 		}
@@ -544,6 +905,22 @@ func (e *Engine) processLoop(ctx context.Context, userMsg string) error {
 		// If we reach here with content but no tool call, record it
 		if currentContent != "" {
 			convo.AddAssistant(currentContent)
+			// A reproduce-first fix ends only once its reproduction passes
+			if repro.needsCheck() {
+				if check, _ := repro.finalizeCheck(ctx); !check.Passed && repro.reject() {
+					e.bridge.SendChat("system", "The reproduction does not pass yet; asking the model to fix it.")
+					convo.AddUser(reproRejection(check).rejectionMessage())
+					continue
+				}
+			}
+			// Runs that changed files must still satisfy the definition of done
+			if done.needsCheck() {
+				if report := done.evaluate(ctx); !report.Accepted {
+					e.bridge.SendChat("system", "Definition of done not met ("+strings.Join(report.failed(), ", ")+"); asking the model to fix it.")
+					convo.AddUser(report.rejectionMessage())
+					continue
+				}
+			}
 			// Content received means conversation is complete, regardless of whether tools were used
 			return nil
 		}
@@ -554,15 +931,18 @@ func (e *Engine) processLoop(ctx context.Context, userMsg string) error {
 			if !toolsUsed {
 				e.bridge.SendChat("system", "Retrying without streaming...")
 			}
+			e.heartbeat.set(PhaseWaitingLLM, "")
 			fallbackStream, err := adapter.Chat(ctx, engineMessages, convertSchemas(tools), false)
 			if err != nil {
+				e.errLog.add("llm", err)
 				e.bridge.SendChat("system", "Error: "+err.Error())
 				return err
 			}
 			// Collect the single-shot response
 			for item := range fallbackStream {
 				if item.ToolCall != nil {
-					toolCallReceived = &tool.ToolCall{ID: item.ToolCall.ID, Name: item.ToolCall.Name, Args: item.ToolCall.Args}
+					toolCallReceived = &tool.ToolCall{ID: item.ToolCall.ID, Name: item.ToolCall.Name, Args: item.ToolCall.Args, Version: item.ToolCall.Version}
+					_ = registry.Upgrade(toolCallReceived)
 					if os.Getenv("LOOM_DEBUG_ENGINE") == "1" || strings.EqualFold(os.Getenv("LOOM_DEBUG_ENGINE"), "true") {
 						e.bridge.SendChat("system", fmt.Sprintf("[debug] Non-stream tool call received: id=%s name=%s argsLen=%d", item.ToolCall.ID, item.ToolCall.Name, len(item.ToolCall.Args)))
 					}
@@ -577,9 +957,17 @@ func (e *Engine) processLoop(ctx context.Context, userMsg string) error {
 			}
 			if toolCallReceived != nil {
 				// Execute the tool using the tool executor
-				if err := e.toolExecutor.ExecuteToolCall(ctx, toolCallReceived, convo); err != nil {
+				e.heartbeat.set(toolPhase(toolCallReceived.Name), toolCallReceived.Name)
+				turn.toolCalls++
+				if err := e.toolExecutor.ExecuteToolCall(toolCtx, toolCallReceived, convo); err != nil {
+					e.errLog.add("tool", err)
 					return err
 				}
+				if summary, ok := e.toolExecutor.takeFinalized(); ok {
+					convo.AddAssistant(summary)
+					e.bridge.EmitAssistant(summary)
+					return nil
+				}
 				continue
 			}
 			if currentContent != "" {
@@ -606,11 +994,11 @@ func (e *Engine) processLoop(ctx context.Context, userMsg string) error {
 			}
 			// If no tools were used and we have an empty response, that's an error
 			e.bridge.SendChat("system", "No response from model.")
-			return nil
+			return errNoResponse
 		}
 	}
 
-	return errors.New("tool loop exceeded maximum depth")
+	return errToolLoopDepth
 }
 
 // SetAttachedFiles stores the list of workspace-relative files attached by the user.
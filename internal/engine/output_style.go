@@ -0,0 +1,82 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/loom/loom/internal/config"
+	"github.com/loom/loom/internal/memory"
+)
+
+// OutputStyleMarker names the transcript messages that record a style switch; they
+// are shown when a conversation is loaded.
+const OutputStyleMarker = "output_style"
+
+// OutputStyle returns the output style in effect for the current conversation: its own
+// choice, else the default from settings, else config.DefaultOutputStyle.
+func (e *Engine) OutputStyle() string {
+	settings, _ := config.Load()
+	id := ""
+	if e.memory != nil {
+		id = e.memory.CurrentConversationID()
+	}
+	return effectiveOutputStyle(settings, e.memory, id)
+}
+
+func effectiveOutputStyle(settings config.Settings, mem *memory.Project, conversationID string) string {
+	for _, name := range []string{mem.OutputStyle(conversationID), settings.OutputStyle} {
+		if name = strings.TrimSpace(name); name == "" {
+			continue
+		}
+		if strings.EqualFold(name, config.DefaultOutputStyle) {
+			return config.DefaultOutputStyle
+		}
+		if st, ok := settings.OutputStyleNamed(name); ok {
+			return st.Name
+		}
+	}
+	return config.DefaultOutputStyle
+}
+
+// SetOutputStyle switches the current conversation's output style. The switch takes
+// effect with the next message, where it is also recorded in the transcript.
+func (e *Engine) SetOutputStyle(name string) error {
+	if e.memory == nil {
+		return errors.New("memory not initialized")
+	}
+	name = strings.TrimSpace(name)
+	if !strings.EqualFold(name, config.DefaultOutputStyle) {
+		settings, _ := config.Load()
+		st, ok := settings.OutputStyleNamed(name)
+		if !ok {
+			return fmt.Errorf("unknown output style %q", name)
+		}
+		name = st.Name
+	} else {
+		name = config.DefaultOutputStyle
+	}
+	return e.memory.SetOutputStyle(e.memory.CurrentConversationID(), name)
+}
+
+// outputStylePrompt returns the prompt section of the style in effect, and records a
+// transcript marker when it differs from the style the conversation last ran with.
+func outputStylePrompt(convo *memory.Conversation, settings config.Settings, name string) string {
+	last := config.DefaultOutputStyle
+	for _, m := range convo.History() {
+		if m.Role == "system" && m.Name == OutputStyleMarker {
+			last = strings.TrimPrefix(m.Content, "Output style: ")
+		}
+	}
+	if !strings.EqualFold(last, name) {
+		convo.AddSystemMarker(OutputStyleMarker, "Output style: "+name)
+	}
+	if name == config.DefaultOutputStyle {
+		return ""
+	}
+	st, ok := settings.OutputStyleNamed(name)
+	if !ok {
+		return ""
+	}
+	return st.PromptSection()
+}
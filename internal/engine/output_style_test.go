@@ -0,0 +1,70 @@
+package engine
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/loom/loom/internal/config"
+	"github.com/loom/loom/internal/memory"
+)
+
+func TestOutputStyle_PerConversationWithMarkers(t *testing.T) {
+	store, err := memory.NewStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	proj, err := memory.NewProject(store, t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := New(nil, nil).WithMemory(proj)
+	id := e.NewConversation()
+	settings := config.Settings{
+		OutputStyle:  "educational",
+		OutputStyles: []config.OutputStyle{{Name: "German", Language: "German"}},
+	}
+
+	// Without a choice of its own, the conversation uses the default from settings
+	if got := effectiveOutputStyle(settings, proj, id); got != "educational" {
+		t.Fatalf("expected the settings default, got %q", got)
+	}
+	convo := proj.StartConversation()
+	if section := outputStylePrompt(convo, settings, "educational"); !strings.Contains(section, "## Output Style: educational") {
+		t.Fatalf("unexpected section %q", section)
+	}
+	convo.AddUser("hi")
+	outputStylePrompt(convo, settings, "educational")
+
+	if err := proj.SetOutputStyle(id, "German"); err != nil {
+		t.Fatal(err)
+	}
+	if got := effectiveOutputStyle(settings, proj, id); got != "German" {
+		t.Fatalf("expected the conversation's style, got %q", got)
+	}
+	if section := outputStylePrompt(convo, settings, "German"); !strings.Contains(section, "prose in German") {
+		t.Fatalf("unexpected section %q", section)
+	}
+	if err := proj.SetOutputStyle(id, config.DefaultOutputStyle); err != nil {
+		t.Fatal(err)
+	}
+	if section := outputStylePrompt(convo, settings, effectiveOutputStyle(settings, proj, id)); section != "" {
+		t.Fatalf("the default style adds no section, got %q", section)
+	}
+
+	var markers []string
+	for _, m := range convo.History() {
+		if m.Name == OutputStyleMarker {
+			markers = append(markers, m.Content)
+		}
+	}
+	want := []string{"Output style: educational", "Output style: German", "Output style: default"}
+	if strings.Join(markers, "|") != strings.Join(want, "|") {
+		t.Fatalf("expected one marker per switch %v, got %v", want, markers)
+	}
+
+	// Unknown names left in memory fall back to the default
+	_ = proj.SetOutputStyle(id, "removed")
+	if got := effectiveOutputStyle(config.Settings{}, proj, id); got != config.DefaultOutputStyle {
+		t.Fatalf("expected the default style, got %q", got)
+	}
+}
@@ -0,0 +1,59 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/loom/loom/internal/symbols"
+	"github.com/loom/loom/internal/tool"
+)
+
+// prefetchTopSymbols is how many symbol search hits get their likely follow-up reads
+// prefetched.
+const prefetchTopSymbols = 3
+
+// prefetchCallsFor predicts the reads that usually follow a tool result: after a symbol
+// search, the definition card, the surrounding code and the whole file of the top hits.
+// Arguments mirror what the model sends by default, so the cache keys match.
+func prefetchCallsFor(call *tool.ToolCall, result *tool.ExecutionResult) []*tool.ToolCall {
+	if call == nil || result == nil || call.Name != "symbols_search" {
+		return nil
+	}
+	var cards []symbols.SymbolCard
+	if json.Unmarshal([]byte(result.Content), &cards) != nil {
+		return nil
+	}
+	var calls []*tool.ToolCall
+	add := func(name string, args any) {
+		raw, _ := json.Marshal(args)
+		calls = append(calls, &tool.ToolCall{Name: name, Args: raw})
+	}
+	files := map[string]bool{}
+	for i, c := range cards {
+		if i == prefetchTopSymbols {
+			break
+		}
+		if c.SID != "" {
+			add("symbols_def", map[string]string{"sid": c.SID})
+			add("symbols_neighborhood", map[string]string{"sid": c.SID})
+		}
+		if c.File != "" && !files[c.File] {
+			files[c.File] = true
+			add("read_file", map[string]string{"path": c.File})
+		}
+	}
+	return calls
+}
+
+// prefetch warms the run's result cache with the likely next reads in the background.
+// Nothing is added to the conversation; the results only answer matching calls faster.
+func (te *ToolExecutor) prefetch(ctx context.Context, call *tool.ToolCall, result *tool.ExecutionResult) {
+	if te.noPrefetch {
+		return
+	}
+	calls := prefetchCallsFor(call, result)
+	if len(calls) == 0 || te.tools == nil {
+		return
+	}
+	go te.tools.Prefetch(ctx, calls)
+}
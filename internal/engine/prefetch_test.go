@@ -0,0 +1,50 @@
+package engine
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/loom/loom/internal/symbols"
+	"github.com/loom/loom/internal/tool"
+)
+
+func TestPrefetchCallsFor_SymbolSearch(t *testing.T) {
+	cards := []symbols.SymbolCard{
+		{SID: "s1", File: "a.go"},
+		{SID: "s2", File: "a.go"},
+		{SID: "s3", File: "b.go"},
+		{SID: "s4", File: "c.go"},
+	}
+	content, _ := json.MarshalIndent(cards, "", "  ")
+	calls := prefetchCallsFor(&tool.ToolCall{Name: "symbols_search"}, &tool.ExecutionResult{Content: string(content)})
+
+	var got []string
+	for _, c := range calls {
+		got = append(got, c.Name+" "+string(c.Args))
+	}
+	want := []string{
+		`symbols_def {"sid":"s1"}`,
+		`symbols_neighborhood {"sid":"s1"}`,
+		`read_file {"path":"a.go"}`,
+		`symbols_def {"sid":"s2"}`,
+		`symbols_neighborhood {"sid":"s2"}`,
+		`symbols_def {"sid":"s3"}`,
+		`symbols_neighborhood {"sid":"s3"}`,
+		`read_file {"path":"b.go"}`,
+	}
+	if len(got) != len(want) {
+		t.Fatalf("unexpected calls: %v", got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("call %d: got %s, want %s", i, got[i], want[i])
+		}
+	}
+
+	if calls := prefetchCallsFor(&tool.ToolCall{Name: "read_file"}, &tool.ExecutionResult{Content: string(content)}); calls != nil {
+		t.Errorf("only symbol searches trigger prefetch, got %v", calls)
+	}
+	if calls := prefetchCallsFor(&tool.ToolCall{Name: "symbols_search"}, &tool.ExecutionResult{Content: "[cached: ...]"}); calls != nil {
+		t.Errorf("unparsable results trigger nothing, got %v", calls)
+	}
+}
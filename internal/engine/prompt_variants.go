@@ -0,0 +1,81 @@
+package engine
+
+import "strings"
+
+// PromptVariant adapts the logical system prompt to one provider's conventions, so
+// the same template yields the phrasing and message role each provider follows best.
+type PromptVariant struct {
+	Provider string
+	// Role is the message role that carries the system prompt: "system", or
+	// "developer" for OpenAI reasoning models, which treat it as the instruction channel
+	Role string
+	// ToolUse is the provider-specific tool calling guidance
+	ToolUse string
+	// Compact drops optional sections for small-context local models
+	Compact bool
+}
+
+const anthropicToolUse = `## Tool Use
+- Call tools through tool_use blocks. Tool choice is automatic: whenever a step depends on workspace facts, call a tool instead of guessing or describing the call in prose.
+- Independent read-only calls (several read_file or search_code calls, for example) may be issued together in one turn; wait for their results before dependent steps.
+- Reason briefly before non-trivial edits, then make the edit_file call directly.
+- Reply without a tool call only when the task is complete or you need the user.`
+
+const openAIToolUse = `## Tool Use
+- Use function calls for every workspace action. Arguments must be one JSON object matching the function's parameters exactly, without markdown fences or comments.
+- Do not announce calls ("I will now read…"); make them.
+- Parallel function calls are fine for independent reads.
+- End with a normal assistant message and no function call when the task is complete.`
+
+const openRouterToolUse = `## Tool Use
+- Use the provided functions for workspace actions; arguments must be valid JSON matching each function's parameters.
+- Call one function at a time unless the calls are independent reads.
+- Never invent function names and never write tool calls as plain text.`
+
+const geminiToolUse = `## Tool Use
+- Use function calls for workspace actions; arguments must match each function's declared parameters.
+- Make the call instead of describing it, and wait for its result before steps that depend on it.
+- Reply without a function call only when the task is complete or you need the user.`
+
+const localToolUse = `## Tool Use
+- Call at most one tool per response and wait for its result.
+- Use only the listed tool names. Arguments must be a JSON object with the listed parameters.
+- Never write a tool call as text or inside a code block; use the tool calling interface.
+- Keep answers short.`
+
+const genericToolUse = `## Tool Use
+- Use the provided tools for workspace actions instead of guessing; arguments must match each tool's parameters.
+- Reply without a tool call only when the task is complete or you need the user.`
+
+// PromptVariantFor returns the variant for a model label such as "anthropic:claude-sonnet-4"
+// or "openai:o3". Unknown providers get a neutral variant.
+func PromptVariantFor(modelLabel string) PromptVariant {
+	provider, model, _ := strings.Cut(strings.ToLower(strings.TrimSpace(modelLabel)), ":")
+	switch provider {
+	case "anthropic":
+		return PromptVariant{Provider: provider, Role: "system", ToolUse: anthropicToolUse}
+	case "openai", "azure":
+		v := PromptVariant{Provider: provider, Role: "system", ToolUse: openAIToolUse}
+		if isOpenAIReasoningModel(model) {
+			v.Role = "developer"
+		}
+		return v
+	case "openrouter":
+		return PromptVariant{Provider: provider, Role: "system", ToolUse: openRouterToolUse}
+	case "gemini":
+		return PromptVariant{Provider: provider, Role: "system", ToolUse: geminiToolUse}
+	case "ollama":
+		return PromptVariant{Provider: provider, Role: "system", ToolUse: localToolUse, Compact: true}
+	}
+	return PromptVariant{Provider: provider, Role: "system", ToolUse: genericToolUse}
+}
+
+// isOpenAIReasoningModel reports whether model takes instructions as developer messages.
+func isOpenAIReasoningModel(model string) bool {
+	for _, prefix := range []string{"o1", "o3", "o4", "gpt-5"} {
+		if strings.HasPrefix(model, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,198 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/loom/loom/internal/profiler"
+	"github.com/loom/loom/internal/tool"
+)
+
+// Quick answers reply to informational questions from the project profile, the symbol
+// index and memories in one structured request, before the tool loop. They are only
+// used when the model is confident and cites files that exist; otherwise the question
+// goes through the tool loop as usual.
+const (
+	// quickAnswerCandidates is how many locate hits are offered as evidence
+	quickAnswerCandidates = 8
+	// quickAnswerTimeout bounds the request so a slow provider costs little
+	quickAnswerTimeout = 30 * time.Second
+	// maxQuickQuestion is the longest message treated as a simple question
+	maxQuickQuestion = 300
+)
+
+var (
+	questionStartRe = regexp.MustCompile(`(?i)^\s*(what|where|which|who|why|how|when|is|are|does|do|explain|describe|tell me|show me where)\b`)
+	// actionWordRe marks requests that change or run something, even when phrased as questions
+	actionWordRe = regexp.MustCompile(`(?i)\b(fix|add|implement|change|refactor|create|write|update|remove|delete|rename|run|build|install|deploy|migrate|generate|make|edit|modify|replace|convert|upgrade|commit|push|test|debug)\b`)
+)
+
+// isInformational reports whether msg is a short question about the code that does
+// not ask for changes or commands.
+func isInformational(msg string) bool {
+	msg = strings.TrimSpace(msg)
+	if msg == "" || len(msg) > maxQuickQuestion || strings.Contains(msg, "```") || strings.Count(msg, "\n") > 2 {
+		return false
+	}
+	if !questionStartRe.MatchString(msg) && !strings.HasSuffix(msg, "?") {
+		return false
+	}
+	return !actionWordRe.MatchString(msg)
+}
+
+// quickAnswerReply is what the model returns for a quick answer.
+type quickAnswerReply struct {
+	Answer     string `json:"answer"`
+	Confidence string `json:"confidence"`
+	Citations  []struct {
+		Path string `json:"path"`
+		Line int    `json:"line"`
+	} `json:"citations"`
+}
+
+var quickAnswerSchema = StructuredSchema{
+	Name:        "quick_answer",
+	Description: "Answer to a question about the project, with the files it is based on",
+	Schema: map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"answer": map[string]interface{}{
+				"type":        "string",
+				"description": "The answer in Markdown; empty when the evidence is not enough",
+			},
+			"confidence": map[string]interface{}{
+				"type":        "string",
+				"enum":        []string{"high", "low"},
+				"description": "high only when the evidence alone fully answers the question",
+			},
+			"citations": map[string]interface{}{
+				"type": "array",
+				"items": map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"path": map[string]interface{}{"type": "string", "description": "Workspace-relative file path from the evidence"},
+						"line": map[string]interface{}{"type": "integer", "description": "Line number, or 0 for the whole file"},
+					},
+					"required":             []string{"path", "line"},
+					"additionalProperties": false,
+				},
+			},
+		},
+		"required":             []string{"answer", "confidence", "citations"},
+		"additionalProperties": false,
+	},
+}
+
+const quickAnswerSystem = `You answer questions about a software project using only the evidence provided: the project profile, memories, and code locations from the symbol index. Cite the files your answer relies on. Set confidence to "high" only if the evidence fully answers the question; if reading more code would be needed, set it to "low" and leave the answer empty.`
+
+// quickAnswer tries to answer question from the index without tools. It returns the
+// rendered answer and true, or false when the question should go through the tool loop.
+func (e *Engine) quickAnswer(ctx context.Context, question, root string, memories []MemoryEntry) (string, bool) {
+	evidence, paths := e.quickAnswerEvidence(ctx, question, root, memories)
+	if len(paths) == 0 {
+		return "", false
+	}
+	ctx, cancel := context.WithTimeout(ctx, quickAnswerTimeout)
+	defer cancel()
+	var r quickAnswerReply
+	if err := e.structured(ctx, []Message{
+		{Role: "system", Content: quickAnswerSystem + explanationSections[e.ExplanationLevel()]},
+		{Role: "user", Content: evidence + "\n\nQuestion: " + question},
+	}, quickAnswerSchema, &r); err != nil {
+		return "", false
+	}
+	return renderQuickAnswer(r, root, paths)
+}
+
+// quickAnswerEvidence collects the project profile, memories and the index's best
+// matches for question, and returns it with the set of paths the matches name.
+func (e *Engine) quickAnswerEvidence(ctx context.Context, question, root string, memories []MemoryEntry) (string, map[string]bool) {
+	var b strings.Builder
+	if block, err := profiler.NewFileSystemProjectContextBuilder().BuildProjectContextBlock(root); err == nil {
+		b.WriteString(block)
+		b.WriteString("\n")
+	}
+	addMemories(&b, memories, 0)
+
+	paths := map[string]bool{}
+	candidates := e.locate(ctx, question, quickAnswerCandidates)
+	if len(candidates) > 0 {
+		b.WriteString("\n\nCode locations from the symbol index:\n")
+	}
+	for _, c := range candidates {
+		paths[c.Path] = true
+		loc := c.Path
+		if c.Line > 0 {
+			loc = fmt.Sprintf("%s:%d", c.Path, c.Line)
+		}
+		fmt.Fprintf(&b, "\n- %s", loc)
+		if c.Symbol != "" {
+			fmt.Fprintf(&b, " (%s)", c.Symbol)
+		}
+		if s := strings.TrimSpace(c.Snippet); s != "" {
+			fmt.Fprintf(&b, "\n```\n%s\n```", s)
+		}
+	}
+	return strings.TrimSpace(b.String()), paths
+}
+
+// locate returns the locate tool's candidates for query, or nil when it is unavailable.
+func (e *Engine) locate(ctx context.Context, query string, limit int) []tool.LocateCandidate {
+	if e.tools == nil {
+		return nil
+	}
+	def, ok := e.tools.Get("locate")
+	if !ok || def.Handler == nil {
+		return nil
+	}
+	raw, _ := json.Marshal(tool.LocateArgs{Query: query, Limit: limit})
+	out, err := def.Handler(ctx, raw)
+	if err != nil {
+		return nil
+	}
+	res, _ := out.(*tool.LocateResult)
+	if res == nil {
+		return nil
+	}
+	return res.Candidates
+}
+
+// renderQuickAnswer accepts a confident answer that cites at least one file from the
+// evidence or the workspace, and appends its sources.
+func renderQuickAnswer(r quickAnswerReply, root string, evidence map[string]bool) (string, bool) {
+	answer := strings.TrimSpace(r.Answer)
+	if r.Confidence != "high" || answer == "" {
+		return "", false
+	}
+	var sources []string
+	seen := map[string]bool{}
+	for _, c := range r.Citations {
+		p := filepath.ToSlash(filepath.Clean(strings.TrimSpace(c.Path)))
+		if p == "." || filepath.IsAbs(p) || strings.HasPrefix(p, "../") {
+			continue
+		}
+		if !evidence[p] {
+			if _, err := os.Stat(filepath.Join(root, filepath.FromSlash(p))); err != nil {
+				continue
+			}
+		}
+		loc := p
+		if c.Line > 0 {
+			loc = fmt.Sprintf("%s:%d", p, c.Line)
+		}
+		if !seen[loc] {
+			seen[loc] = true
+			sources = append(sources, "`"+loc+"`")
+		}
+	}
+	if len(sources) == 0 {
+		return "", false
+	}
+	return answer + "\n\nSources: " + strings.Join(sources, ", ") + "\n\n_Answered from the project index without reading files; ask to dig deeper if this is not enough._", true
+}
@@ -0,0 +1,64 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/loom/loom/internal/tool"
+)
+
+func TestIsInformational(t *testing.T) {
+	for msg, want := range map[string]bool{
+		"Where is the session token validated?":       true,
+		"how does the retry backoff work":             true,
+		"Which package owns the symbol index?":        true,
+		"Can you fix the flaky login test?":           false,
+		"How do I add a new adapter?":                 false,
+		"Rename Foo to Bar":                           false,
+		"the build is broken":                         false,
+		"What does this do?\n```go\nfunc x() {}\n```": false,
+		"": false,
+	} {
+		if got := isInformational(msg); got != want {
+			t.Errorf("isInformational(%q) = %v, want %v", msg, got, want)
+		}
+	}
+}
+
+func TestQuickAnswer(t *testing.T) {
+	ws := t.TempDir()
+	registry := tool.NewRegistry()
+	var query string
+	_ = registry.Register(tool.Definition{Name: "locate", Safe: true, ReadOnly: true, Handler: func(_ context.Context, raw json.RawMessage) (interface{}, error) {
+		var args tool.LocateArgs
+		_ = json.Unmarshal(raw, &args)
+		query = args.Query
+		return &tool.LocateResult{Candidates: []tool.LocateCandidate{{Path: "auth/session.go", Line: 42, Symbol: "ValidateToken", Snippet: "func ValidateToken(t string) error {"}}}, nil
+	}})
+	llm := &structuredLLM{reply: `{"answer":"Tokens are checked in ValidateToken.","confidence":"high","citations":[{"path":"auth/session.go","line":42},{"path":"../etc/passwd","line":0},{"path":"made/up.go","line":3}]}`}
+	e := New(llm, nil).WithWorkspace(ws).WithRegistry(registry)
+
+	answer, ok := e.quickAnswer(context.Background(), "Where is the session token validated?", ws, nil)
+	if !ok || llm.schema != "quick_answer" || query != "Where is the session token validated?" {
+		t.Fatalf("expected a quick answer, got %q %v (schema %q)", answer, ok, llm.schema)
+	}
+	if !strings.HasPrefix(answer, "Tokens are checked in ValidateToken.") || !strings.Contains(answer, "Sources: `auth/session.go:42`\n") {
+		t.Errorf("unexpected answer %q", answer)
+	}
+
+	// Low confidence, or no citation that checks out, falls through to the tool loop
+	llm.reply = `{"answer":"Probably in auth.","confidence":"low","citations":[{"path":"auth/session.go","line":42}]}`
+	if _, ok := e.quickAnswer(context.Background(), "Where is the session token validated?", ws, nil); ok {
+		t.Error("low confidence answers should not be used")
+	}
+	llm.reply = `{"answer":"In made/up.go.","confidence":"high","citations":[{"path":"made/up.go","line":1}]}`
+	if _, ok := e.quickAnswer(context.Background(), "Where is the session token validated?", ws, nil); ok {
+		t.Error("answers citing files outside the evidence and workspace should not be used")
+	}
+	e.SetLLM(plainLLM{})
+	if _, ok := e.quickAnswer(context.Background(), "Where is the session token validated?", ws, nil); ok {
+		t.Error("models without structured output should use the tool loop")
+	}
+}
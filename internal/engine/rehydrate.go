@@ -0,0 +1,211 @@
+package engine
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/loom/loom/internal/editor"
+	"github.com/loom/loom/internal/memory"
+	"github.com/loom/loom/internal/tool"
+)
+
+// stateDriftNote names the system note added when a resumed conversation's files
+// changed on disk while it was inactive.
+const stateDriftNote = "state_drift"
+
+// maxDriftFiles bounds the files described in a drift note.
+const maxDriftFiles = 20
+
+// snapshotTracker records the files the agent reads or edits in a conversation, so
+// that resuming the conversation later can tell which of them changed in between.
+type snapshotTracker struct {
+	project        *memory.Project
+	workspace      string
+	conversationID string
+}
+
+// record snapshots the file named by a read_file or apply_edit call as it is on disk now.
+func (st *snapshotTracker) record(call *tool.ToolCall) {
+	if st == nil || st.project == nil || (call.Name != "read_file" && call.Name != "apply_edit") {
+		return
+	}
+	rel := workspacePathArg(call)
+	if rel == "" {
+		return
+	}
+	content, err := os.ReadFile(filepath.Join(st.workspace, rel))
+	if os.IsNotExist(err) {
+		_ = st.project.ForgetFileSnapshot(st.conversationID, rel)
+		return
+	}
+	if err != nil {
+		return
+	}
+	_ = st.project.RecordFileSnapshots(st.conversationID, snapshotOf(rel, content))
+}
+
+// workspacePathArg returns the workspace-relative path argument of a file tool call,
+// or "" for absolute paths and attachments.
+func workspacePathArg(call *tool.ToolCall) string {
+	var args struct {
+		Path      string `json:"path"`
+		Workspace string `json:"workspace"`
+	}
+	// Calls qualified with a workspace root may run in another root
+	if json.Unmarshal(call.Args, &args) != nil || args.Path == "" || filepath.IsAbs(args.Path) ||
+		strings.HasPrefix(args.Path, tool.AttachmentPrefix) || args.Workspace != "" {
+		return ""
+	}
+	return filepath.ToSlash(filepath.Clean(args.Path))
+}
+
+func snapshotOf(rel string, content []byte) memory.FileSnapshot {
+	s := memory.FileSnapshot{Path: rel, SHA256: sha256Hex(content), Lines: countLines(content), At: time.Now()}
+	if len(content) <= memory.MaxSnapshotContent {
+		s.Content = string(content)
+	}
+	return s
+}
+
+func countLines(b []byte) int {
+	if len(b) == 0 {
+		return 0
+	}
+	n := strings.Count(string(b), "\n")
+	if b[len(b)-1] != '\n' {
+		n++
+	}
+	return n
+}
+
+// stateDrift re-verifies the files a conversation has seen and describes those that
+// changed or disappeared on disk. Snapshots are refreshed so the same drift is only
+// reported once. It returns "" when nothing changed.
+func stateDrift(project *memory.Project, workspace, conversationID string, lastActive time.Time) string {
+	snaps := project.FileSnapshots(conversationID)
+	if len(snaps) == 0 || workspace == "" {
+		return ""
+	}
+	paths := make([]string, 0, len(snaps))
+	for p := range snaps {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+
+	var changed, deleted []string
+	var refreshed []memory.FileSnapshot
+	for _, p := range paths {
+		old := snaps[p]
+		content, err := os.ReadFile(filepath.Join(workspace, filepath.FromSlash(p)))
+		if os.IsNotExist(err) {
+			deleted = append(deleted, p)
+			_ = project.ForgetFileSnapshot(conversationID, p)
+			continue
+		}
+		if err != nil || sha256Hex(content) == old.SHA256 {
+			continue
+		}
+		cur := snapshotOf(p, content)
+		refreshed = append(refreshed, cur)
+		changed = append(changed, describeDrift(old, cur, content))
+	}
+	_ = project.RecordFileSnapshots(conversationID, refreshed...)
+	if len(changed) == 0 && len(deleted) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString("State drift: this conversation is being resumed")
+	if !lastActive.IsZero() {
+		fmt.Fprintf(&b, " (last active %s)", lastActive.Local().Format("2006-01-02 15:04"))
+	}
+	fmt.Fprintf(&b, ". %d of the %d files you read or edited earlier changed on disk since. ", len(changed)+len(deleted), len(snaps))
+	b.WriteString("Earlier file contents, line numbers, and edit anchors in this conversation may be stale: re-read these files before relying on them or editing.\n")
+	for i, c := range changed {
+		if i == maxDriftFiles {
+			fmt.Fprintf(&b, "- ... %d more changed file(s)\n", len(changed)-maxDriftFiles)
+			break
+		}
+		b.WriteString(c)
+	}
+	for _, d := range deleted {
+		fmt.Fprintf(&b, "- %s: deleted\n", d)
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// describeDrift summarizes how a file changed, with per-line detail when the earlier
+// content was kept.
+func describeDrift(old, cur memory.FileSnapshot, content []byte) string {
+	if old.Content == "" && old.Lines > 0 {
+		return fmt.Sprintf("- %s: modified (%d → %d lines)\n", old.Path, old.Lines, cur.Lines)
+	}
+	entries := editor.AnalyzeContentChanges(old.Content, string(content), old.Path, editor.DiffModeLine)
+	added, removed := 0, 0
+	for _, e := range entries {
+		switch e.Kind {
+		case editor.ChangeAdded:
+			added++
+		case editor.ChangeRemoved:
+			removed++
+		default:
+			added++
+			removed++
+		}
+	}
+	head := fmt.Sprintf("- %s: modified (+%d −%d lines)\n", old.Path, added, removed)
+	detail := editor.FormatChangeSummary(entries, 5)
+	if detail == "" {
+		return head
+	}
+	return head + "  " + strings.ReplaceAll(detail, "\n", "\n  ") + "\n"
+}
+
+// rehydrate checks the current conversation for state drift on its first run since it
+// was loaded. It returns the drift note, or "" for new, already checked, or unchanged
+// conversations.
+func (e *Engine) rehydrate(convo *memory.Conversation) string {
+	id := e.memory.CurrentConversationID()
+	e.mu.Lock()
+	if e.resumeChecked == nil {
+		e.resumeChecked = make(map[string]bool)
+	}
+	checked := e.resumeChecked[id]
+	e.resumeChecked[id] = true
+	e.mu.Unlock()
+
+	history := convo.History()
+	if checked || !hasPriorTurns(history) {
+		return ""
+	}
+	note := stateDrift(e.memory, e.editRoot(), id, lastActivity(history))
+	if note != "" && e.bridge != nil {
+		e.bridge.SendChat("system", "Some files changed since this conversation was last active; the assistant will re-read them.")
+	}
+	return note
+}
+
+// lastActivity returns the time of the latest non-system message.
+func lastActivity(history []memory.Message) time.Time {
+	for i := len(history) - 1; i >= 0; i-- {
+		if history[i].Role != "system" {
+			return history[i].Timestamp
+		}
+	}
+	return time.Time{}
+}
+
+// hasPriorTurns reports whether the conversation already contains user messages.
+func hasPriorTurns(history []memory.Message) bool {
+	for _, m := range history {
+		if m.Role == "user" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,95 @@
+package engine
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/loom/loom/internal/memory"
+	"github.com/loom/loom/internal/tool"
+)
+
+func TestStateDrift_ReportsFilesChangedSinceLastSeen(t *testing.T) {
+	ws := t.TempDir()
+	store, err := memory.NewStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("store: %v", err)
+	}
+	proj, err := memory.NewProject(store, ws)
+	if err != nil {
+		t.Fatalf("project: %v", err)
+	}
+	write := func(name, content string) {
+		if err := os.WriteFile(filepath.Join(ws, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("main.go", "package main\n\nfunc main() {\n\tprintln(\"hi\")\n}\n")
+	write("util.go", "package main\n")
+	write("same.go", "package main\n")
+
+	st := &snapshotTracker{project: proj, workspace: ws, conversationID: "c1"}
+	for _, p := range []string{"main.go", "util.go", "same.go", "missing.go"} {
+		args, _ := json.Marshal(map[string]string{"path": p})
+		st.record(&tool.ToolCall{Name: "read_file", Args: args})
+	}
+	st.record(&tool.ToolCall{Name: "list_dir", Args: json.RawMessage(`{"path":"."}`)})
+	if n := len(proj.FileSnapshots("c1")); n != 3 {
+		t.Fatalf("expected 3 snapshots, got %d", n)
+	}
+	if note := stateDrift(proj, ws, "c1", lastActivity(nil)); note != "" {
+		t.Fatalf("expected no drift, got %q", note)
+	}
+
+	write("main.go", "package main\n\nfunc main() {\n\tprintln(\"hello\")\n\trun()\n}\n")
+	if err := os.Remove(filepath.Join(ws, "util.go")); err != nil {
+		t.Fatal(err)
+	}
+	note := stateDrift(proj, ws, "c1", lastActivity(nil))
+	for _, want := range []string{"2 of the 3 files", "- main.go: modified (+2 −1 lines)", "line 4", "- util.go: deleted", "re-read"} {
+		if !strings.Contains(note, want) {
+			t.Errorf("drift note missing %q:\n%s", want, note)
+		}
+	}
+	if strings.Contains(note, "same.go") {
+		t.Errorf("unchanged file reported:\n%s", note)
+	}
+	// Snapshots are refreshed, so the same drift is reported only once
+	if again := stateDrift(proj, ws, "c1", lastActivity(nil)); again != "" {
+		t.Fatalf("expected drift to be reported once, got %q", again)
+	}
+}
+
+func TestConversationSetSystemNote_ReplacesEarlierNote(t *testing.T) {
+	store, err := memory.NewStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("store: %v", err)
+	}
+	proj, err := memory.NewProject(store, t.TempDir())
+	if err != nil {
+		t.Fatalf("project: %v", err)
+	}
+	convo := proj.StartConversation()
+	convo.UpdateSystemMessage("base")
+	convo.AddUser("hi")
+	convo.SetSystemNote(stateDriftNote, "old drift")
+	convo.SetSystemNote(stateDriftNote, "new drift")
+	convo.UpdateSystemMessage("base v2")
+
+	var notes, bases []string
+	for _, m := range convo.History() {
+		if m.Role != "system" {
+			continue
+		}
+		if m.Name == stateDriftNote {
+			notes = append(notes, m.Content)
+		} else {
+			bases = append(bases, m.Content)
+		}
+	}
+	if strings.Join(notes, ",") != "new drift" || strings.Join(bases, ",") != "base v2" {
+		t.Fatalf("unexpected system messages: notes=%v bases=%v", notes, bases)
+	}
+}
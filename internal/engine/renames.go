@@ -0,0 +1,150 @@
+package engine
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/loom/loom/internal/memory"
+	"github.com/loom/loom/internal/tool"
+)
+
+// renameTracker keeps tool calls working across file moves. Moves made by the agent's
+// shell commands or observed by the file watcher are recorded in project memory, and
+// later calls naming the old path are redirected to the new one.
+type renameTracker struct {
+	project   *memory.Project
+	workspace string
+}
+
+// pathTools are the tools whose "path" argument is redirected after a rename.
+var pathTools = map[string]bool{
+	"read_file":  true,
+	"edit_file":  true,
+	"apply_edit": true,
+	"list_dir":   true,
+}
+
+// resolve rewrites the path argument of call when it names a file that has since
+// been moved. It returns a note describing the redirect, or "" when nothing changed.
+func (rt *renameTracker) resolve(call *tool.ToolCall) string {
+	if rt == nil || rt.project == nil || !pathTools[call.Name] {
+		return ""
+	}
+	var args map[string]interface{}
+	if json.Unmarshal(call.Args, &args) != nil {
+		return ""
+	}
+	old, _ := args["path"].(string)
+	if old == "" || filepath.IsAbs(old) || rt.exists(old) {
+		return ""
+	}
+	if action, _ := args["action"].(string); strings.EqualFold(action, "CREATE") {
+		return ""
+	}
+	moved, ok := rt.project.ResolveRenamed(old)
+	if !ok || !rt.exists(moved) {
+		return ""
+	}
+	args["path"] = moved
+	raw, err := json.Marshal(args)
+	if err != nil {
+		return ""
+	}
+	call.Args = raw
+	return "Note: " + old + " was renamed to " + moved + "; using the new path."
+}
+
+// recordShellMoves records renames performed by an applied mv or git mv command.
+// A move is only recorded when the source is gone and the destination exists.
+func (rt *renameTracker) recordShellMoves(args json.RawMessage) []memory.RenameRecord {
+	if rt == nil || rt.project == nil {
+		return nil
+	}
+	var sh tool.ApplyShellArgs
+	if json.Unmarshal(args, &sh) != nil {
+		return nil
+	}
+	words := append(strings.Fields(sh.Command), sh.Args...)
+	var recorded []memory.RenameRecord
+	for _, cmd := range splitShellCommands(words) {
+		from, to, ok := parseMove(cmd)
+		if !ok {
+			continue
+		}
+		from, to = rt.rel(sh.Cwd, from), rt.rel(sh.Cwd, to)
+		if from == "" || to == "" {
+			continue
+		}
+		// mv into an existing directory keeps the base name
+		if info, err := os.Stat(filepath.Join(rt.workspace, to)); err == nil && info.IsDir() && !rt.exists(from) {
+			if _, err := os.Stat(filepath.Join(rt.workspace, to, filepath.Base(from))); err == nil {
+				to = filepath.ToSlash(filepath.Join(to, filepath.Base(from)))
+			}
+		}
+		if rt.exists(from) || !rt.exists(to) {
+			continue
+		}
+		if rt.project.RecordRename(from, to) == nil {
+			recorded = append(recorded, memory.RenameRecord{From: from, To: to})
+		}
+	}
+	return recorded
+}
+
+// splitShellCommands splits words on the &&, ||, and ; separators.
+func splitShellCommands(words []string) [][]string {
+	var out [][]string
+	var cur []string
+	for _, w := range words {
+		trimmed := strings.TrimSuffix(w, ";")
+		switch {
+		case w == "&&" || w == "||" || w == ";":
+			out, cur = append(out, cur), nil
+		case trimmed != w:
+			out, cur = append(out, append(cur, trimmed)), nil
+		default:
+			cur = append(cur, w)
+		}
+	}
+	return append(out, cur)
+}
+
+// parseMove extracts the source and destination of "mv a b" or "git mv a b".
+func parseMove(words []string) (from, to string, ok bool) {
+	if len(words) > 0 && words[0] == "git" {
+		words = words[1:]
+	}
+	if len(words) == 0 || words[0] != "mv" {
+		return "", "", false
+	}
+	var operands []string
+	for _, w := range words[1:] {
+		if !strings.HasPrefix(w, "-") {
+			operands = append(operands, strings.Trim(w, `"'`))
+		}
+	}
+	if len(operands) != 2 {
+		return "", "", false
+	}
+	return operands[0], operands[1], true
+}
+
+// rel converts a path relative to cwd into a workspace-relative path, or "" when
+// it falls outside the workspace.
+func (rt *renameTracker) rel(cwd, p string) string {
+	if !filepath.IsAbs(p) {
+		p = filepath.Join(rt.workspace, cwd, p)
+	}
+	r, err := filepath.Rel(rt.workspace, p)
+	if err != nil || r == "." || strings.HasPrefix(r, "..") {
+		return ""
+	}
+	return filepath.ToSlash(r)
+}
+
+func (rt *renameTracker) exists(rel string) bool {
+	_, err := os.Stat(filepath.Join(rt.workspace, rel))
+	return err == nil
+}
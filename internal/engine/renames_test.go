@@ -0,0 +1,67 @@
+package engine
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/loom/loom/internal/memory"
+	"github.com/loom/loom/internal/tool"
+)
+
+func TestRenameTracker_RecordsShellMovesAndRedirectsPaths(t *testing.T) {
+	ws := t.TempDir()
+	store, err := memory.NewStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("store: %v", err)
+	}
+	proj, err := memory.NewProject(store, ws)
+	if err != nil {
+		t.Fatalf("project: %v", err)
+	}
+	rt := &renameTracker{project: proj, workspace: ws}
+
+	if err := os.MkdirAll(filepath.Join(ws, "pkg"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	// Simulate the effect of the applied command
+	if err := os.WriteFile(filepath.Join(ws, "pkg", "new.go"), []byte("package pkg\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	args, _ := json.Marshal(tool.ApplyShellArgs{Command: "git mv old.go pkg/new.go && go build ./...", Shell: true})
+	recorded := rt.recordShellMoves(args)
+	if len(recorded) != 1 || recorded[0].From != "old.go" || recorded[0].To != "pkg/new.go" {
+		t.Fatalf("unexpected records: %+v", recorded)
+	}
+
+	call := &tool.ToolCall{Name: "read_file", Args: json.RawMessage(`{"path":"old.go"}`)}
+	note := rt.resolve(call)
+	if !strings.Contains(note, "pkg/new.go") || !strings.Contains(string(call.Args), `"path":"pkg/new.go"`) {
+		t.Fatalf("path not redirected: note=%q args=%s", note, call.Args)
+	}
+
+	create := &tool.ToolCall{Name: "edit_file", Args: json.RawMessage(`{"path":"old.go","action":"CREATE"}`)}
+	if note := rt.resolve(create); note != "" {
+		t.Fatalf("CREATE must not be redirected, got %q", note)
+	}
+}
+
+func TestParseMove(t *testing.T) {
+	for _, tc := range []struct {
+		words    string
+		from, to string
+		ok       bool
+	}{
+		{"mv -f a.go b.go", "a.go", "b.go", true},
+		{"git mv src/x dst/x", "src/x", "dst/x", true},
+		{"mv a b c", "", "", false},
+		{"cp a b", "", "", false},
+	} {
+		from, to, ok := parseMove(strings.Fields(tc.words))
+		if from != tc.from || to != tc.to || ok != tc.ok {
+			t.Errorf("%q: got (%q, %q, %v)", tc.words, from, to, ok)
+		}
+	}
+}
@@ -0,0 +1,272 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/loom/loom/internal/memory"
+	"github.com/loom/loom/internal/tool"
+)
+
+const (
+	reproductionTimeout = 10 * time.Minute
+	// reproductionOutputTail is how much command output is kept and echoed back
+	reproductionOutputTail = 4000
+)
+
+// reproductionRequestPrefix starts the user message of a reproduce-first bug fix.
+const reproductionRequestPrefix = "Fix this bug, reproducing it first:\n\n"
+
+// StartReproduction begins a reproduce-first bug fix in the current conversation:
+// until a reproduction is confirmed to fail, only tests and new files may be edited,
+// and the reproduction must pass before finalize is accepted. It returns the user
+// message to send for the bug description.
+func (e *Engine) StartReproduction(description string) (string, error) {
+	description = strings.TrimSpace(description)
+	if description == "" {
+		return "", errors.New("describe the bug to reproduce")
+	}
+	if e.memory == nil {
+		return "", errors.New("memory not initialized")
+	}
+	id := e.memory.CurrentConversationID()
+	if id == "" {
+		id = e.NewConversation()
+	}
+	r := memory.Reproduction{Description: description, Status: memory.ReproductionPending, StartedAt: time.Now()}
+	if err := e.memory.SetReproduction(id, r); err != nil {
+		return "", err
+	}
+	return reproductionRequestPrefix + description, nil
+}
+
+// Reproduction returns the current conversation's reproduction, if one was started.
+func (e *Engine) Reproduction() (memory.Reproduction, bool) {
+	if e.memory == nil {
+		return memory.Reproduction{}, false
+	}
+	return e.memory.Reproduction(e.memory.CurrentConversationID())
+}
+
+// reproGuard runs the reproduce tool and holds a conversation to reproduce-first
+// fixing once a reproduction was started.
+type reproGuard struct {
+	project        *memory.Project
+	conversationID string
+	workspace      string
+
+	mu         sync.Mutex
+	rejections int
+
+	// approve asks before running a reproduction command; nil runs without asking
+	approve func(command string) bool
+}
+
+// newReproGuard returns the guard for a conversation, or nil without one.
+func newReproGuard(project *memory.Project, conversationID, workspace string) *reproGuard {
+	if project == nil || conversationID == "" || workspace == "" {
+		return nil
+	}
+	return &reproGuard{project: project, conversationID: conversationID, workspace: workspace}
+}
+
+func (g *reproGuard) load() (memory.Reproduction, bool) {
+	return g.project.Reproduction(g.conversationID)
+}
+
+// promptSection tells the model where the reproduce-first fix stands.
+func (g *reproGuard) promptSection() string {
+	if g == nil {
+		return ""
+	}
+	r, ok := g.load()
+	if !ok {
+		return ""
+	}
+	switch r.Status {
+	case memory.ReproductionPending:
+		return `
+
+## Reproduce First
+The user asked for a bug fix that starts with a reproduction. Before changing any code:
+1. Write a minimal failing test (preferred) or script that demonstrates the bug.
+2. Call the reproduce tool with action "confirm", the command that runs it and the files you wrote. It must fail.
+Until the failure is confirmed, only test files and new files can be edited. Then fix the bug, keep the reproduction files, and call finalize: the reproduction is re-run and must pass.`
+	case memory.ReproductionConfirmed:
+		return fmt.Sprintf(`
+
+## Reproduce First
+The bug is reproduced: `+"`%s`"+` fails. Fix the bug without deleting or weakening the reproduction (%s). Call reproduce with action "verify" to check the fix; finalize re-runs the reproduction and is rejected while it fails.`, r.Command, strings.Join(r.Artifacts, ", "))
+	}
+	return ""
+}
+
+// checkEdit refuses edits to existing non-test files while the reproduction is not
+// confirmed yet.
+func (g *reproGuard) checkEdit(call *tool.ToolCall) (string, bool) {
+	if g == nil || (call.Name != "edit_file" && call.Name != "apply_edit") {
+		return "", false
+	}
+	r, ok := g.load()
+	if !ok || r.Status != memory.ReproductionPending {
+		return "", false
+	}
+	var args tool.EditFileArgs
+	if err := json.Unmarshal(call.Args, &args); err != nil || args.Path == "" {
+		return "", false
+	}
+	rel := args.Path
+	if filepath.IsAbs(rel) {
+		if p, err := filepath.Rel(g.workspace, rel); err == nil {
+			rel = p
+		}
+	}
+	rel = filepath.ToSlash(filepath.Clean(rel))
+	if isReproductionPath(rel) {
+		return "", false
+	}
+	if _, err := os.Stat(filepath.Join(g.workspace, filepath.FromSlash(rel))); os.IsNotExist(err) {
+		return "", false
+	}
+	return fmt.Sprintf("Edit to %s refused: reproduce the bug first. Write a failing test or script, then call reproduce with action \"confirm\"; code can be changed once the failure is confirmed.", rel), true
+}
+
+// run executes the reproduction command after approval.
+func (g *reproGuard) run(ctx context.Context, command string) (string, int, error) {
+	if g.approve != nil && !g.approve(command) {
+		return "", -1, fmt.Errorf("command not approved: %s", command)
+	}
+	return runAuditedCommand(ctx, g.project, g.workspace, command, "reproduction", reproductionTimeout)
+}
+
+// handle runs a reproduce call and returns the result for the model.
+func (g *reproGuard) handle(ctx context.Context, args tool.ReproduceArgs) string {
+	r, ok := g.load()
+	if !ok {
+		// The model may reproduce on its own, without the flow being started
+		r = memory.Reproduction{Status: memory.ReproductionPending, StartedAt: time.Now()}
+	}
+	command := args.Command
+	if command == "" {
+		command = r.Command
+	}
+	if command == "" {
+		return "Error: no reproduction confirmed yet; call reproduce with action \"confirm\" and a command first."
+	}
+	if len(args.Artifacts) > 0 {
+		r.Artifacts = args.Artifacts
+	}
+
+	out, code, err := g.run(ctx, command)
+	r.Runs++
+	if err != nil {
+		_ = g.project.SetReproduction(g.conversationID, r)
+		return "Error: " + err.Error()
+	}
+	output := tail(out, reproductionOutputTail)
+
+	switch args.Action {
+	case "confirm":
+		if code == 0 {
+			_ = g.project.SetReproduction(g.conversationID, r)
+			return fmt.Sprintf("Reproduction not confirmed: `%s` passed, so it does not demonstrate the bug. Make the test or script fail because of the bug, then confirm again.\n%s", command, output)
+		}
+		r.Command, r.Status, r.FailureOutput, r.ConfirmedAt = command, memory.ReproductionConfirmed, output, time.Now()
+		_ = g.project.SetReproduction(g.conversationID, r)
+		return fmt.Sprintf("Reproduction confirmed: `%s` fails (exit %d). Now fix the bug; keep the reproduction, it is re-run before finalize is accepted.\n%s", command, code, output)
+	default:
+		if code != 0 {
+			_ = g.project.SetReproduction(g.conversationID, r)
+			return fmt.Sprintf("Reproduction still fails: `%s` exited with %d.\n%s", command, code, output)
+		}
+		if r.Status == memory.ReproductionPending {
+			_ = g.project.SetReproduction(g.conversationID, r)
+			return fmt.Sprintf("`%s` passes, but it was never confirmed to fail, so it does not prove the fix. Confirm a failing reproduction first.", command)
+		}
+		r.Command, r.Status, r.VerifiedAt = command, memory.ReproductionVerified, time.Now()
+		_ = g.project.SetReproduction(g.conversationID, r)
+		return fmt.Sprintf("Reproduction verified: `%s` passes.", command)
+	}
+}
+
+// finalizeCheck re-runs a confirmed reproduction before finalize is accepted. ok is
+// false when the conversation has no reproduction to hold finalize to.
+func (g *reproGuard) finalizeCheck(ctx context.Context) (check DoneCheck, ok bool) {
+	if g == nil {
+		return DoneCheck{}, false
+	}
+	r, found := g.load()
+	if !found {
+		return DoneCheck{}, false
+	}
+	check = DoneCheck{Name: "reproduction"}
+	switch r.Status {
+	case memory.ReproductionPending:
+		check.Detail = "The bug was never reproduced: write a failing test or script and call reproduce with action \"confirm\" before fixing."
+		return check, true
+	case memory.ReproductionVerified, memory.ReproductionConfirmed:
+	default:
+		return DoneCheck{}, false
+	}
+	for _, a := range r.Artifacts {
+		if _, err := os.Stat(filepath.Join(g.workspace, filepath.FromSlash(a))); err != nil {
+			check.Detail = fmt.Sprintf("Reproduction file %s is missing; restore it so the fix stays verified.", a)
+			return check, true
+		}
+	}
+	res := g.handle(ctx, tool.ReproduceArgs{Action: "verify"})
+	r, _ = g.load()
+	check.Passed = r.Status == memory.ReproductionVerified && strings.HasPrefix(res, "Reproduction verified")
+	if !check.Passed {
+		check.Detail = res
+	}
+	return check, true
+}
+
+// needsCheck reports whether a plain final answer must first re-run the reproduction:
+// only confirmed reproductions are, and only until the rejection budget is spent.
+func (g *reproGuard) needsCheck() bool {
+	if g == nil {
+		return false
+	}
+	r, ok := g.load()
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return ok && r.Status == memory.ReproductionConfirmed && g.rejections < maxDoneRejections
+}
+
+// reproRejection renders a failing reproduction check for the conversation.
+func reproRejection(check DoneCheck) DoneReport {
+	return DoneReport{
+		Checks:       []DoneCheck{check},
+		Instructions: "Finalization rejected: the reproduction must pass before the task is finished. Fix the bug (not the reproduction), then call finalize again.",
+	}
+}
+
+// reject counts a rejected finalize; it reports false once the budget is spent, so a
+// reproduction the model cannot make pass does not loop forever.
+func (g *reproGuard) reject() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.rejections+1 >= maxDoneRejections {
+		return false
+	}
+	g.rejections++
+	return true
+}
+
+// isReproductionPath reports whether a workspace-relative path looks like a test,
+// fixture or reproduction script, which may be written before the bug is reproduced.
+func isReproductionPath(rel string) bool {
+	lower := strings.ToLower(rel)
+	return isTestPath(lower) || strings.Contains(path.Base(lower), "repro") ||
+		strings.HasPrefix(lower, "test/") || strings.Contains(lower, "/test/") || strings.Contains(lower, "testdata/")
+}
@@ -0,0 +1,87 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/loom/loom/internal/memory"
+	"github.com/loom/loom/internal/tool"
+)
+
+func TestReproGuard_ConfirmFixVerify(t *testing.T) {
+	ws := t.TempDir()
+	if err := os.WriteFile(filepath.Join(ws, "parser.go"), []byte("package parser\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	store, err := memory.NewStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	proj, err := memory.NewProject(store, ws)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := New(nil, nil).WithWorkspace(ws).WithMemory(proj)
+	msg, err := e.StartReproduction("parsing empty input panics")
+	if err != nil || !strings.Contains(msg, "parsing empty input panics") {
+		t.Fatalf("start: %q %v", msg, err)
+	}
+	g := newReproGuard(proj, proj.CurrentConversationID(), ws)
+	if !strings.Contains(g.promptSection(), "Reproduce First") {
+		t.Fatal("expected the reproduce-first instructions in the prompt")
+	}
+
+	edit := func(path string) *tool.ToolCall {
+		args, _ := json.Marshal(map[string]string{"path": path, "action": "REPLACE"})
+		return &tool.ToolCall{Name: "edit_file", Args: args}
+	}
+	if _, refuse := g.checkEdit(edit("parser.go")); !refuse {
+		t.Fatal("code edits must wait for a confirmed reproduction")
+	}
+	for _, p := range []string{"parser_test.go", "repro.sh", "new_helper.go"} {
+		if msg, refuse := g.checkEdit(edit(p)); refuse {
+			t.Fatalf("%s should be writable before confirmation: %s", p, msg)
+		}
+	}
+
+	// A passing command does not reproduce the bug
+	res := g.handle(context.Background(), tool.ReproduceArgs{Action: "confirm", Command: "true"})
+	if !strings.Contains(res, "not confirmed") {
+		t.Fatalf("expected the passing command to be rejected, got %q", res)
+	}
+	if err := os.WriteFile(filepath.Join(ws, "repro.sh"), []byte("test -f fixed || { echo panic: empty input; exit 1; }\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	res = g.handle(context.Background(), tool.ReproduceArgs{Action: "confirm", Command: "sh repro.sh", Artifacts: []string{"repro.sh"}})
+	if !strings.Contains(res, "Reproduction confirmed") || !strings.Contains(res, "panic: empty input") {
+		t.Fatalf("expected confirmation with output, got %q", res)
+	}
+	if _, refuse := g.checkEdit(edit("parser.go")); refuse {
+		t.Fatal("code edits are allowed once the bug is reproduced")
+	}
+
+	// Before the fix, finalize is held back
+	if check, ok := g.finalizeCheck(context.Background()); !ok || check.Passed || !g.needsCheck() {
+		t.Fatalf("expected a failing reproduction check, got %+v (%v)", check, ok)
+	}
+	if err := os.WriteFile(filepath.Join(ws, "fixed"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if check, ok := g.finalizeCheck(context.Background()); !ok || !check.Passed {
+		t.Fatalf("expected the reproduction to pass after the fix, got %+v", check)
+	}
+	r, _ := e.Reproduction()
+	if r.Status != memory.ReproductionVerified || r.Command != "sh repro.sh" || r.Runs != 4 {
+		t.Fatalf("unexpected reproduction state %+v", r)
+	}
+
+	// Deleting the reproduction fails verification
+	_ = os.Remove(filepath.Join(ws, "repro.sh"))
+	if check, _ := g.finalizeCheck(context.Background()); check.Passed || !strings.Contains(check.Detail, "repro.sh is missing") {
+		t.Fatalf("expected the missing artifact to fail, got %+v", check)
+	}
+}
@@ -0,0 +1,264 @@
+package engine
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/loom/loom/internal/memory"
+	"github.com/loom/loom/internal/tool"
+)
+
+// Run outcomes recorded in a RunSummary.
+const (
+	RunCompleted = "completed"
+	RunFailed    = "failed"
+	RunCancelled = "cancelled"
+	// RunRetried marks a run abandoned for a retry; the retry writes its own summary
+	RunRetried = "retried"
+)
+
+// Test statuses recorded in a RunSummary.
+const (
+	TestsPassed = "passed"
+	TestsFailed = "failed"
+	TestsNone   = "none"
+)
+
+// RunSummary is the machine-readable record of one user request, written to
+// .loom/runs/<id>.json when the run ends.
+type RunSummary struct {
+	ID             string `json:"id"`
+	ConversationID string `json:"conversation_id"`
+	Model          string `json:"model"`
+	// Goals are the user's requests in the run; Todos is the todo list when it ended
+	Goals []string        `json:"goals"`
+	Todos []tool.TodoTask `json:"todos,omitempty"`
+	// Outcome is completed, failed, cancelled or retried
+	Outcome string `json:"outcome"`
+	Error   string `json:"error,omitempty"`
+	Summary string `json:"summary,omitempty"`
+	WhatIf  bool   `json:"what_if,omitempty"`
+
+	Files    []TimelineFile   `json:"files"`
+	Commands []CommandOutcome `json:"commands"`
+	Tests    []CommandOutcome `json:"tests"`
+	// TestStatus is passed or failed by the last run of each check command, or none
+	TestStatus string   `json:"test_status"`
+	Commits    []string `json:"commits,omitempty"`
+	// DroppedContext lists context left out after the provider rejected a request as too large
+	DroppedContext []string `json:"dropped_context,omitempty"`
+	// Evictions explain each item left out of the dropped context: size, age and relevance
+	Evictions []ContextEviction `json:"evictions,omitempty"`
+
+	ToolCalls  int       `json:"tool_calls"`
+	InTokens   int64     `json:"in_tokens"`
+	OutTokens  int64     `json:"out_tokens"`
+	CostUSD    float64   `json:"cost_usd"`
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at"`
+	DurationMs int64     `json:"duration_ms"`
+}
+
+// ExitCode maps the outcome to a process exit status for scripts: 0 when the run
+// completed and no check failed, 1 otherwise.
+func (s *RunSummary) ExitCode() int {
+	if s.Outcome == RunCompleted && s.TestStatus != TestsFailed {
+		return 0
+	}
+	return 1
+}
+
+// runSummaryNotifier is implemented by UI bridges that show run summaries.
+type runSummaryNotifier interface {
+	EmitRunSummary(s RunSummary)
+}
+
+// runRecorder remembers where a run started so its summary covers only that run.
+type runRecorder struct {
+	started   time.Time
+	workspace string
+	whatIf    bool
+	userMsg   string
+	// historyLen is the conversation length before the run's user message
+	historyLen int
+	// droppedContext describes context blocks left out to fit the context window
+	droppedContext []string
+	evictions      []ContextEviction
+}
+
+// RunsDir returns where run summaries of a workspace are written.
+func RunsDir(workspace string) string {
+	return filepath.Join(workspace, ".loom", "runs")
+}
+
+// beginRun starts recording a run of userMsg against the conversation.
+func (e *Engine) beginRun(convo *memory.Conversation, userMsg string, whatIf bool) *runRecorder {
+	return &runRecorder{started: time.Now(), workspace: e.Workspace(), whatIf: whatIf, userMsg: userMsg, historyLen: len(convo.History())}
+}
+
+// finishRun builds the run's summary, writes it under .loom/runs and sends it to the UI;
+// runs that stop with work left also leave a handoff, and the conversation's tags
+// are refreshed.
+func (e *Engine) finishRun(ctx context.Context, rec *runRecorder, turn *turnTracker, convo *memory.Conversation, runErr error) {
+	if rec == nil || e.memory == nil {
+		return
+	}
+	history := convo.History()
+	if rec.historyLen <= len(history) {
+		history = history[rec.historyLen:]
+	}
+	var diff *TimelineDiff
+	if items := e.Timeline(); len(items) > 0 && items[len(items)-1].Step > turn.lastStep {
+		diff, _ = e.DiffCheckpoints(turn.lastStep, items[len(items)-1].Step)
+	}
+	s := buildRunSummary(rec, history, diff, tool.TodoTasks())
+	s.ConversationID = e.memory.CurrentConversationID()
+	s.Model = turn.model
+	s.ToolCalls = turn.toolCalls
+	s.DroppedContext = rec.droppedContext
+	s.Evictions = rec.evictions
+	if e.streamProcessor != nil {
+		s.InTokens, s.OutTokens, s.CostUSD = e.streamProcessor.turnUsage()
+	}
+	switch {
+	case errors.Is(context.Cause(ctx), errRetryRequested):
+		s.Outcome = RunRetried
+	case ctx.Err() != nil:
+		s.Outcome = RunCancelled
+	case runErr != nil:
+		s.Outcome, s.Error = RunFailed, runErr.Error()
+	}
+
+	if rec.workspace != "" {
+		_ = writeRunSummary(rec.workspace, e.Sealer(), s)
+	}
+	e.recordHandoff(rec, s, runErr, history)
+	e.tagConversation(s.ConversationID)
+	if n, ok := e.bridge.(runSummaryNotifier); ok {
+		n.EmitRunSummary(*s)
+	}
+}
+
+// buildRunSummary derives the summary from the messages the run added to the
+// conversation and the diff of the files it changed (nil when none).
+func buildRunSummary(rec *runRecorder, history []memory.Message, diff *TimelineDiff, todos []tool.TodoTask) *RunSummary {
+	d := describeSession(history, diff, todos)
+	finished := time.Now()
+	s := &RunSummary{
+		ID:         newRunID(rec.started),
+		Goals:      []string{},
+		Todos:      todos,
+		Outcome:    RunCompleted,
+		Summary:    d.Summary,
+		WhatIf:     rec.whatIf,
+		Files:      d.Files,
+		Commands:   []CommandOutcome{},
+		Tests:      d.Tests,
+		Commits:    d.Commits,
+		StartedAt:  rec.started,
+		FinishedAt: finished,
+		DurationMs: finished.Sub(rec.started).Milliseconds(),
+	}
+	if goal := headline(rec.userMsg); goal != "" {
+		s.Goals = append(s.Goals, truncateRunes(goal, 200))
+	} else {
+		s.Goals = append(s.Goals, d.Rationale...)
+	}
+
+	// Every command the run executed, not only the checks
+	calls := map[string]memory.Message{}
+	for _, m := range history {
+		switch {
+		case m.Role == "assistant" && m.ToolID != "" && m.Name == "apply_shell":
+			calls[m.ToolID] = m
+		case m.Role == "tool":
+			call, ok := calls[m.ToolID]
+			if !ok {
+				continue
+			}
+			var args tool.ApplyShellArgs
+			var res tool.ShellResult
+			if json.Unmarshal([]byte(call.Content), &args) != nil || json.Unmarshal([]byte(m.Content), &res) != nil {
+				continue
+			}
+			s.Commands = append(s.Commands, CommandOutcome{Command: strings.TrimSpace(args.Command + " " + strings.Join(args.Args, " ")), ExitCode: res.ExitCode})
+		}
+	}
+
+	s.TestStatus = TestsNone
+	latest := map[string]int{}
+	for _, t := range s.Tests {
+		latest[t.Command] = t.ExitCode
+	}
+	for _, code := range latest {
+		s.TestStatus = TestsPassed
+		if code != 0 {
+			s.TestStatus = TestsFailed
+			break
+		}
+	}
+	return s
+}
+
+// writeRunSummary stores s as .loom/runs/<id>.json in the workspace, encrypted with
+// sealer when the workspace encrypts its data.
+func writeRunSummary(workspace string, sealer *memory.Sealer, s *RunSummary) error {
+	dir := RunsDir(workspace)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	// Keep run records out of version control
+	ignore := filepath.Join(dir, ".gitignore")
+	if _, err := os.Stat(ignore); os.IsNotExist(err) {
+		_ = os.WriteFile(ignore, []byte("*\n"), 0o644)
+	}
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return memory.WriteSealed(sealer, filepath.Join(dir, s.ID+".json"), data)
+}
+
+// LoadRunSummaries returns the run summaries of a workspace, newest first; limit <= 0
+// returns all of them. Encrypted files are decrypted with sealer; unreadable files are
+// skipped.
+func LoadRunSummaries(workspace string, sealer *memory.Sealer, limit int) ([]RunSummary, error) {
+	entries, err := os.ReadDir(RunsDir(workspace))
+	if os.IsNotExist(err) {
+		return []RunSummary{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	out := []RunSummary{}
+	// Ids start with the start time, so name order is chronological
+	for i := len(entries) - 1; i >= 0 && (limit <= 0 || len(out) < limit); i-- {
+		name := entries[i].Name()
+		if entries[i].IsDir() || filepath.Ext(name) != ".json" {
+			continue
+		}
+		data, err := memory.ReadSealed(sealer, filepath.Join(RunsDir(workspace), name))
+		if err != nil {
+			continue
+		}
+		var s RunSummary
+		if json.Unmarshal(data, &s) == nil {
+			out = append(out, s)
+		}
+	}
+	return out, nil
+}
+
+// newRunID returns a sortable id such as 20261016-142530-3f9a1c.
+func newRunID(t time.Time) string {
+	var b [3]byte
+	_, _ = rand.Read(b[:])
+	return t.Format("20060102-150405") + "-" + hex.EncodeToString(b[:])
+}
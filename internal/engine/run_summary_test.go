@@ -0,0 +1,73 @@
+package engine
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/loom/loom/internal/memory"
+)
+
+func TestBuildRunSummary(t *testing.T) {
+	history := []memory.Message{
+		{Role: "user", Content: "Fix the flaky login test\nIt times out on CI."},
+		{Role: "assistant", Name: "apply_shell", ToolID: "s1", Content: `{"command":"go","args":["test","./auth"]}`},
+		{Role: "tool", Name: "apply_shell", ToolID: "s1", Content: `{"stdout":"FAIL","exit_code":1}`},
+		{Role: "assistant", Name: "apply_shell", ToolID: "s2", Content: `{"command":"ls","args":["auth"]}`},
+		{Role: "tool", Name: "apply_shell", ToolID: "s2", Content: `{"stdout":"login.go","exit_code":0}`},
+		{Role: "assistant", Name: "apply_shell", ToolID: "s3", Content: `{"command":"go test ./auth","shell":true}`},
+		{Role: "tool", Name: "apply_shell", ToolID: "s3", Content: `{"stdout":"ok","exit_code":0}`},
+		{Role: "assistant", Content: "The test no longer depends on wall-clock time."},
+	}
+	diff := &TimelineDiff{Files: []TimelineFile{{Path: "auth/login_test.go", Status: "modified", Added: 4, Removed: 2, Diff: "diff"}}}
+	rec := &runRecorder{started: time.Now().Add(-2 * time.Second), userMsg: history[0].Content}
+
+	s := buildRunSummary(rec, history, diff, nil)
+	if len(s.Goals) != 1 || s.Goals[0] != "Fix the flaky login test" {
+		t.Errorf("unexpected goals %v", s.Goals)
+	}
+	if len(s.Commands) != 3 || s.Commands[1].Command != "ls auth" {
+		t.Errorf("expected every command, got %+v", s.Commands)
+	}
+	// The later passing run of the same check wins
+	if len(s.Tests) != 2 || s.TestStatus != TestsPassed {
+		t.Errorf("unexpected tests %+v (%s)", s.Tests, s.TestStatus)
+	}
+	if len(s.Files) != 1 || s.Files[0].Diff != "" {
+		t.Errorf("files should be listed without their diff: %+v", s.Files)
+	}
+	if s.Outcome != RunCompleted || s.ExitCode() != 0 || s.DurationMs < 2000 {
+		t.Errorf("unexpected outcome %+v", s)
+	}
+
+	s.TestStatus = TestsFailed
+	if s.ExitCode() != 1 {
+		t.Errorf("failed checks should fail the exit code")
+	}
+	s.TestStatus, s.Outcome = TestsPassed, RunCancelled
+	if s.ExitCode() != 1 {
+		t.Errorf("cancelled runs should fail the exit code")
+	}
+}
+
+func TestRunSummaries_WriteAndLoad(t *testing.T) {
+	ws := t.TempDir()
+	if runs, err := LoadRunSummaries(ws, nil, 0); err != nil || len(runs) != 0 {
+		t.Fatalf("expected no runs, got %v, %v", runs, err)
+	}
+	start := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	for i, goal := range []string{"first", "second", "third"} {
+		s := &RunSummary{ID: newRunID(start.Add(time.Duration(i) * time.Minute)), Goals: []string{goal}, Outcome: RunCompleted}
+		if err := writeRunSummary(ws, nil, s); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(RunsDir(ws), ".gitignore")); err != nil {
+		t.Errorf("expected the runs directory to be git-ignored: %v", err)
+	}
+	runs, err := LoadRunSummaries(ws, nil, 2)
+	if err != nil || len(runs) != 2 || runs[0].Goals[0] != "third" || runs[1].Goals[0] != "second" {
+		t.Fatalf("expected the two newest runs first, got %+v, %v", runs, err)
+	}
+}
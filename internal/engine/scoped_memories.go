@@ -0,0 +1,144 @@
+package engine
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"strings"
+
+	"github.com/loom/loom/internal/memory"
+)
+
+// memoryPromptLimit is how many memories enter the prompt. Beyond it only the ones most
+// relevant to the request are included; the rest stay reachable through memory search.
+const memoryPromptLimit = 12
+
+// selectMemoriesForPrompt returns every memory when there are few, otherwise the most
+// relevant to the request, together with the number left out.
+func selectMemoriesForPrompt(all []MemoryEntry, request string) ([]MemoryEntry, int) {
+	if len(all) <= memoryPromptLimit {
+		return all, 0
+	}
+	docs := make([]memory.MemoryDoc, len(all))
+	for i, m := range all {
+		docs[i] = memory.MemoryDoc{Text: m.ID + " " + m.Text, Tags: m.Tags}
+	}
+	var picked []MemoryEntry
+	for _, m := range memory.RankMemories(request, nil, docs) {
+		if len(picked) == memoryPromptLimit {
+			break
+		}
+		picked = append(picked, all[m.Index])
+	}
+	return picked, len(all) - len(picked)
+}
+
+// memoryRequest describes the current request for memory ranking: the user's message
+// (or the latest one when continuing) plus the open and attached files.
+func (e *Engine) memoryRequest(convo *memory.Conversation, userMsg string) string {
+	parts := []string{userMsg}
+	if strings.TrimSpace(userMsg) == "" && convo != nil {
+		history := convo.History()
+		for i := len(history) - 1; i >= 0; i-- {
+			if history[i].Role == "user" {
+				parts = []string{history[i].Content}
+				break
+			}
+		}
+	}
+	e.mu.RLock()
+	parts = append(parts, e.editorCtx.Path)
+	parts = append(parts, e.attachedFiles...)
+	e.mu.RUnlock()
+	return strings.Join(parts, " ")
+}
+
+// projectMemoriesForPrompt returns workspace-wide memories as prompt entries.
+func projectMemoriesForPrompt(project *memory.Project) []MemoryEntry {
+	var out []MemoryEntry
+	for _, m := range project.ProjectMemories() {
+		out = append(out, MemoryEntry{ID: m.ID, Text: strings.TrimSpace(m.Text), Tags: m.Tags})
+	}
+	return out
+}
+
+// touchedPaths collects workspace-relative file paths referenced by tool calls in the
+// conversation, plus the file open in the editor and any attachments.
+func touchedPaths(workspaceRoot string, history []memory.Message, extra ...string) []string {
+	seen := make(map[string]bool)
+	var out []string
+	add := func(p string) {
+		p = strings.TrimSpace(p)
+		if filepath.IsAbs(p) && workspaceRoot != "" {
+			rel, err := filepath.Rel(workspaceRoot, p)
+			if err != nil || strings.HasPrefix(rel, "..") {
+				return
+			}
+			p = filepath.ToSlash(rel)
+		}
+		if p == "" || seen[p] {
+			return
+		}
+		seen[p] = true
+		out = append(out, p)
+	}
+	for _, p := range extra {
+		add(p)
+	}
+	for _, msg := range history {
+		if msg.Role != "assistant" || msg.ToolID == "" || msg.Name == "" || msg.Name == "thinking" {
+			continue
+		}
+		var args map[string]interface{}
+		if json.Unmarshal([]byte(msg.Content), &args) != nil {
+			continue
+		}
+		for _, key := range []string{"path", "file", "cwd"} {
+			if v, ok := args[key].(string); ok {
+				add(v)
+			}
+		}
+	}
+	return out
+}
+
+// formatDirectoryMemories renders directory-scoped memories as a transient system hint.
+func formatDirectoryMemories(mems []memory.ScopedMemory) string {
+	if len(mems) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("Directory Memories (apply to files under these paths):\n")
+	for _, m := range mems {
+		b.WriteString("- ")
+		b.WriteString(m.Dir)
+		b.WriteString("/: ")
+		b.WriteString(strings.TrimSpace(m.Text))
+		b.WriteString("\n")
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// directoryMemoryHint returns directory memories relevant to the files touched so far.
+func (e *Engine) directoryMemoryHint(history []memory.Message) string {
+	if e.memory == nil {
+		return ""
+	}
+	e.mu.RLock()
+	extra := append([]string{e.editorCtx.Path}, e.attachedFiles...)
+	root := e.workspaceDir
+	e.mu.RUnlock()
+	return formatDirectoryMemories(e.memory.DirectoryMemoriesFor(touchedPaths(root, history, extra...)))
+}
+
+// ScopedMemories returns the project and directory memories for the current workspace.
+func (e *Engine) ScopedMemories() []memory.ScopedMemory {
+	return e.memory.ListScopedMemories()
+}
+
+// DeleteScopedMemory removes a project or directory memory by id.
+func (e *Engine) DeleteScopedMemory(id string) error {
+	if e.memory == nil {
+		return nil
+	}
+	return e.memory.DeleteScopedMemory(id)
+}
@@ -0,0 +1,78 @@
+package engine
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/loom/loom/internal/memory"
+)
+
+// sharedMemoriesPath resolves the shared memories file: path relative to the
+// workspace, or memory.SharedMemoriesFile when empty.
+func (e *Engine) sharedMemoriesPath(path string) (string, error) {
+	root := e.Workspace()
+	if strings.TrimSpace(root) == "" {
+		return "", errors.New("no workspace is open")
+	}
+	if strings.TrimSpace(path) == "" {
+		path = memory.SharedMemoriesFile
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(root, path)
+	}
+	return filepath.Clean(path), nil
+}
+
+// ExportSharedMemories writes the project and directory memories to a markdown file
+// (memory.SharedMemoriesFile when path is empty) and returns its path and how many
+// memories it holds.
+func (e *Engine) ExportSharedMemories(path string) (string, int, error) {
+	if e.memory == nil {
+		return "", 0, errors.New("project memory not initialized")
+	}
+	abs, err := e.sharedMemoriesPath(path)
+	if err != nil {
+		return "", 0, err
+	}
+	content, n, err := e.memory.ExportSharedMemories()
+	if err != nil {
+		return "", 0, err
+	}
+	if err := os.MkdirAll(filepath.Dir(abs), 0o755); err != nil {
+		return "", 0, err
+	}
+	return abs, n, os.WriteFile(abs, []byte(content), 0o644)
+}
+
+// ImportSharedMemories merges the memories of a markdown file (memory.SharedMemoriesFile
+// when path is empty) into the project's memories; see memory.Project.ImportSharedMemories.
+func (e *Engine) ImportSharedMemories(path string, force bool) (memory.MemoryImport, error) {
+	if e.memory == nil {
+		return memory.MemoryImport{}, errors.New("project memory not initialized")
+	}
+	abs, err := e.sharedMemoriesPath(path)
+	if err != nil {
+		return memory.MemoryImport{}, err
+	}
+	data, err := os.ReadFile(abs)
+	if err != nil {
+		return memory.MemoryImport{}, err
+	}
+	return e.memory.ImportSharedMemories(string(data), force)
+}
+
+// SyncSharedMemories applies the changes to the workspace's shared memories file since
+// the last sync. It reports false when the workspace has no such file.
+func (e *Engine) SyncSharedMemories() (memory.MemoryImport, bool, error) {
+	abs, err := e.sharedMemoriesPath("")
+	if err != nil || e.memory == nil {
+		return memory.MemoryImport{}, false, nil
+	}
+	if _, err := os.Stat(abs); err != nil {
+		return memory.MemoryImport{}, false, nil
+	}
+	res, err := e.ImportSharedMemories(abs, false)
+	return res, true, err
+}
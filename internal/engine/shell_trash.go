@@ -0,0 +1,232 @@
+package engine
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/loom/loom/internal/editor"
+	"github.com/loom/loom/internal/tool"
+)
+
+// maxShellTrashBytes bounds how much a single shell command's deletions copy to the
+// trash; larger targets are deleted without a copy.
+const maxShellTrashBytes = 64 << 20
+
+// trashShellDeletions copies the files an apply_shell command is about to delete with
+// rm, unlink or git rm into the workspace trash. The copies are kept by
+// keepShellDeletions only for paths the command actually removed. Only literal paths
+// are copied: operands with globs, variables or command substitutions are left
+// alone. tooLarge lists the targets that exceeded the copy budget.
+func trashShellDeletions(workspace string, args json.RawMessage) (entries []editor.TrashEntry, tooLarge []string) {
+	if workspace == "" {
+		return nil, nil
+	}
+	var sh tool.ApplyShellArgs
+	if json.Unmarshal(args, &sh) != nil {
+		return nil, nil
+	}
+	command := strings.TrimSpace(sh.Command + " " + strings.Join(sh.Args, " "))
+	var words []shellWord
+	if sh.Shell {
+		var ok bool
+		if words, ok = parseShellWords(command); !ok {
+			return nil, nil
+		}
+	} else {
+		// Without a shell the arguments reach the program exactly as given
+		for _, w := range append([]string{sh.Command}, sh.Args...) {
+			words = append(words, shellWord{text: w, literal: true})
+		}
+	}
+	budget := int64(maxShellTrashBytes)
+	seen := map[string]bool{}
+	for _, cmd := range splitShellWords(words) {
+		for _, target := range parseRemove(cmd) {
+			if !filepath.IsAbs(target) {
+				target = filepath.Join(workspace, sh.Cwd, target)
+			}
+			if seen[target] {
+				continue
+			}
+			seen[target] = true
+			if budget <= 0 {
+				tooLarge = append(tooLarge, target)
+				continue
+			}
+			entry, err := editor.CopyToTrash(workspace, target, "apply_shell: "+truncateRunes(command, 120), budget)
+			if errors.Is(err, editor.ErrTrashTooLarge) {
+				tooLarge = append(tooLarge, target)
+				continue
+			}
+			if err != nil {
+				continue
+			}
+			budget -= entry.Size
+			entries = append(entries, entry)
+		}
+	}
+	return entries, tooLarge
+}
+
+// removedTooLarge returns the workspace-relative paths among targets that no longer
+// exist, i.e. deletions that went ahead without a copy in the trash.
+func removedTooLarge(workspace string, targets []string) []string {
+	var out []string
+	for _, t := range targets {
+		if _, err := os.Lstat(t); !os.IsNotExist(err) {
+			continue
+		}
+		if rel, err := filepath.Rel(workspace, t); err == nil && !strings.HasPrefix(rel, "..") {
+			out = append(out, filepath.ToSlash(rel))
+		}
+	}
+	return out
+}
+
+// keepShellDeletions drops the trash copies of paths that still exist after the
+// command ran and returns the entries of paths it deleted.
+func keepShellDeletions(workspace string, entries []editor.TrashEntry) []editor.TrashEntry {
+	var kept []editor.TrashEntry
+	for _, e := range entries {
+		if _, err := os.Lstat(filepath.Join(workspace, filepath.FromSlash(e.Path))); err == nil {
+			_ = editor.DiscardTrash(workspace, e.ID)
+			continue
+		}
+		kept = append(kept, e)
+	}
+	return kept
+}
+
+// discardShellDeletions drops every trash copy made for a command that did not run.
+func discardShellDeletions(workspace string, entries []editor.TrashEntry) {
+	for _, e := range entries {
+		_ = editor.DiscardTrash(workspace, e.ID)
+	}
+}
+
+// parseRemove returns the literal operands of "rm", "unlink" or "git rm" (without
+// --cached). Other commands yield nothing.
+func parseRemove(words []shellWord) []string {
+	if len(words) > 0 && words[0].text == "sudo" {
+		words = words[1:]
+	}
+	git := len(words) > 0 && words[0].text == "git"
+	if git {
+		words = words[1:]
+	}
+	if len(words) == 0 || (words[0].text != "rm" && (git || words[0].text != "unlink")) {
+		return nil
+	}
+	var operands []string
+	endOfFlags := false
+	for _, w := range words[1:] {
+		switch {
+		case !endOfFlags && w.text == "--":
+			endOfFlags = true
+		case !endOfFlags && strings.HasPrefix(w.text, "-"):
+			if git && w.text == "--cached" {
+				return nil
+			}
+		case w.literal && w.text != "":
+			operands = append(operands, w.text)
+		}
+	}
+	return operands
+}
+
+// shellWord is a word of a shell command with its quotes and escapes removed.
+type shellWord struct {
+	text string
+	// literal is false when the shell would expand the word: an unquoted glob or ~,
+	// or a variable or command substitution outside single quotes
+	literal bool
+	// op marks a control operator (&&, ||, ;, |, &) separating commands
+	op bool
+}
+
+// parseShellWords splits a command line the way a POSIX shell tokenizes it, honouring
+// single and double quotes and backslash escapes. ok is false for unbalanced quotes.
+func parseShellWords(line string) (words []shellWord, ok bool) {
+	var cur strings.Builder
+	inWord, literal := false, true
+	flush := func() {
+		if inWord {
+			words = append(words, shellWord{text: cur.String(), literal: literal})
+		}
+		cur.Reset()
+		inWord, literal = false, true
+	}
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n':
+			flush()
+		case c == '\\':
+			inWord = true
+			if i+1 < len(line) {
+				i++
+				cur.WriteByte(line[i])
+			}
+		case c == '\'':
+			inWord = true
+			end := strings.IndexByte(line[i+1:], '\'')
+			if end < 0 {
+				return nil, false
+			}
+			cur.WriteString(line[i+1 : i+1+end])
+			i += end + 1
+		case c == '"':
+			inWord = true
+			i++
+			for ; i < len(line) && line[i] != '"'; i++ {
+				switch {
+				case line[i] == '\\' && i+1 < len(line) && strings.IndexByte("$`\"\\\n", line[i+1]) >= 0:
+					i++
+				case line[i] == '$' || line[i] == '`':
+					literal = false
+				}
+				cur.WriteByte(line[i])
+			}
+			if i >= len(line) {
+				return nil, false
+			}
+		case c == ';' || c == '&' || c == '|':
+			flush()
+			op := string(c)
+			if i+1 < len(line) && line[i+1] == c && c != ';' {
+				op += string(c)
+				i++
+			}
+			words = append(words, shellWord{text: op, op: true})
+		case c == '<' || c == '>' || c == '(' || c == ')':
+			// Redirections and subshells never name files to delete
+			flush()
+			words = append(words, shellWord{text: string(c), op: true})
+		default:
+			if strings.IndexByte("*?[$`", c) >= 0 || (c == '~' && !inWord) {
+				literal = false
+			}
+			inWord = true
+			cur.WriteByte(c)
+		}
+	}
+	flush()
+	return words, true
+}
+
+// splitShellWords splits words into the commands between control operators.
+func splitShellWords(words []shellWord) [][]shellWord {
+	var out [][]shellWord
+	var cur []shellWord
+	for _, w := range words {
+		if w.op {
+			out, cur = append(out, cur), nil
+			continue
+		}
+		cur = append(cur, w)
+	}
+	return append(out, cur)
+}
@@ -0,0 +1,93 @@
+package engine
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/loom/loom/internal/editor"
+	"github.com/loom/loom/internal/tool"
+)
+
+func TestShellDeletions_KeptOnlyForRemovedPaths(t *testing.T) {
+	ws := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(ws, "tmp"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"a.log", "b.log", "my file.txt", "keep.txt"} {
+		if err := os.WriteFile(filepath.Join(ws, "tmp", name), []byte(name), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	args, _ := json.Marshal(tool.ApplyShellArgs{Command: `rm -f *.log "my file.txt" keep.txt && go build ./...`, Cwd: "tmp", Shell: true})
+	entries, tooLarge := trashShellDeletions(ws, args)
+	if len(entries) != 2 || len(tooLarge) != 0 {
+		t.Fatalf("expected copies of the two literal targets, got %+v %v", entries, tooLarge)
+	}
+
+	// Simulate the command deleting only the quoted file
+	_ = os.Remove(filepath.Join(ws, "tmp", "my file.txt"))
+	kept := keepShellDeletions(ws, entries)
+	if len(kept) != 1 || kept[0].Path != "tmp/my file.txt" {
+		t.Fatalf("unexpected kept entries: %+v", kept)
+	}
+	if list, _ := editor.ListTrash(ws); len(list) != 1 {
+		t.Fatalf("copies of surviving files must be discarded: %+v", list)
+	}
+}
+
+func TestShellDeletions_TooLargeIsNotCopied(t *testing.T) {
+	ws := t.TempDir()
+	dir := filepath.Join(ws, "node_modules")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Create(filepath.Join(dir, "big.bin"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Truncate(maxShellTrashBytes + 1); err != nil {
+		t.Fatal(err)
+	}
+	_ = f.Close()
+
+	args, _ := json.Marshal(tool.ApplyShellArgs{Command: "rm -rf node_modules", Shell: true})
+	entries, tooLarge := trashShellDeletions(ws, args)
+	if len(entries) != 0 || len(tooLarge) != 1 {
+		t.Fatalf("expected the directory to be skipped as too large, got %+v %v", entries, tooLarge)
+	}
+	_ = os.RemoveAll(dir)
+	if got := removedTooLarge(ws, tooLarge); len(got) != 1 || got[0] != "node_modules" {
+		t.Fatalf("unexpected not-trashed paths: %v", got)
+	}
+}
+
+func TestParseRemove(t *testing.T) {
+	for _, tc := range []struct {
+		line string
+		want string
+	}{
+		{"rm -rf build dist", "build,dist"},
+		{"git rm -r old", "old"},
+		{"git rm --cached secrets.env", ""},
+		{"unlink link", "link"},
+		{"rm -- -weird", "-weird"},
+		{"git unlink x", ""},
+		{"cp a b", ""},
+		{`rm "my file" 'it''s' a\ b`, "my file,its,a b"},
+		{`rm *.log $DIR/x "$(pwd)/y" ~/z '$literal'`, "$literal"},
+	} {
+		words, ok := parseShellWords(tc.line)
+		if !ok {
+			t.Fatalf("%q: failed to parse", tc.line)
+		}
+		if got := strings.Join(parseRemove(splitShellWords(words)[0]), ","); got != tc.want {
+			t.Errorf("%q: got %q, want %q", tc.line, got, tc.want)
+		}
+	}
+	if _, ok := parseShellWords(`rm "unterminated`); ok {
+		t.Error("expected unbalanced quotes to fail")
+	}
+}
@@ -26,6 +26,13 @@ type StreamResult struct {
 type StreamProcessor struct {
 	bridge UIBridge
 	memory *memory.Project
+	// tools upgrades calls made against older tool schemas; nil leaves them as received
+	tools *tool.Registry
+	// usage of the current user request, for the analytics store
+	turnIn, turnOut int64
+	turnUSD         float64
+	// previews of tool calls whose arguments are still streaming
+	previews toolPreviews
 }
 
 // NewStreamProcessor creates a new stream processor.
@@ -36,6 +43,11 @@ func NewStreamProcessor(bridge UIBridge, memory *memory.Project) *StreamProcesso
 	}
 }
 
+// SetTools sets the registry whose schema adapters upgrade received tool calls.
+func (sp *StreamProcessor) SetTools(tools *tool.Registry) {
+	sp.tools = tools
+}
+
 // ProcessStream processes a stream of tokens and tool calls from the LLM.
 func (sp *StreamProcessor) ProcessStream(
 	ctx context.Context,
@@ -51,6 +63,7 @@ func (sp *StreamProcessor) ProcessStream(
 	slowTicker := time.NewTicker(20 * time.Second)
 	defer slowTicker.Stop()
 	slowNotified := false
+	defer sp.finishPreviews()
 
 StreamLoop:
 	for {
@@ -74,7 +87,15 @@ StreamLoop:
 				break StreamLoop
 			}
 
+			if item.ArgsDelta != nil {
+				if p, ok := sp.previews.add(item.ArgsDelta); ok {
+					sp.bridge.EmitToolPreview(p)
+				}
+				continue
+			}
+
 			if item.ToolCall != nil {
+				sp.finishPreviews()
 				toolCallReceived = sp.processToolCall(ctx, item.ToolCall, convo)
 				continue
 			}
@@ -98,6 +119,13 @@ StreamLoop:
 	}
 }
 
+// finishPreviews tells the UI that no more argument fragments follow for the previews shown.
+func (sp *StreamProcessor) finishPreviews() {
+	for _, p := range sp.previews.finish() {
+		sp.bridge.EmitToolPreview(p)
+	}
+}
+
 // processToolCall handles a tool call from the stream.
 func (sp *StreamProcessor) processToolCall(ctx context.Context, toolCall *ToolCall, convo *memory.Conversation) *tool.ToolCall {
 	// Guard against empty tool names from partial/ambiguous streams
@@ -113,9 +141,15 @@ func (sp *StreamProcessor) processToolCall(ctx context.Context, toolCall *ToolCa
 	}
 
 	result := &tool.ToolCall{
-		ID:   toolCall.ID,
-		Name: toolCall.Name,
-		Args: toolCall.Args,
+		ID:      toolCall.ID,
+		Name:    toolCall.Name,
+		Args:    toolCall.Args,
+		Version: toolCall.Version,
+	}
+	// Replayed calls may use an older argument schema; the history keeps the current shape.
+	// A call that cannot be upgraded keeps its version and fails when executed.
+	if sp.tools != nil {
+		_ = sp.tools.Upgrade(result)
 	}
 
 	// Workflow functionality removed
@@ -177,13 +211,40 @@ func (sp *StreamProcessor) processSpecialToken(tok string, convo *memory.Convers
 
 // processUsageToken handles usage tokens and emits billing events.
 func (sp *StreamProcessor) processUsageToken(tok string) {
-	// Parse provider/model/in/out from token and emit billing event
-	// Format: [USAGE] provider=xxx model=yyy in=N out=M
+	provider, model, inTok, outTok := parseUsageToken(tok)
+
+	// Compute costs via config table
+	inUSD, outUSD, totalUSD := config.CostUSDParts(model, inTok, outTok)
+	if sp.bridge != nil {
+		sp.bridge.EmitBilling(provider, model, inTok, outTok, inUSD, outUSD, totalUSD)
+	}
+
+	sp.turnIn += inTok
+	sp.turnOut += outTok
+	sp.turnUSD += totalUSD
+
+	// Persist usage to project memory per workspace and to global store
+	if sp.memory != nil {
+		_ = sp.memory.AddUsage(provider, model, inTok, outTok, inUSD, outUSD)
+	}
+	_ = config.AddGlobalUsage(provider, model, inTok, outTok, inUSD, outUSD)
+}
+
+// resetTurnUsage starts counting usage for a new user request.
+func (sp *StreamProcessor) resetTurnUsage() {
+	sp.turnIn, sp.turnOut, sp.turnUSD = 0, 0, 0
+}
+
+// turnUsage returns the tokens and cost of the current user request.
+func (sp *StreamProcessor) turnUsage() (inTok, outTok int64, usd float64) {
+	return sp.turnIn, sp.turnOut, sp.turnUSD
+}
+
+// parseUsageToken extracts provider, model and token counts from a usage token.
+// Format: [USAGE] provider=xxx model=yyy in=N out=M
+func parseUsageToken(tok string) (provider, model string, inTok, outTok int64) {
 	usage := strings.TrimPrefix(tok, "[USAGE] ")
-	var provider, model string
-	var inTok, outTok int64
-	fields := strings.Fields(usage)
-	for _, f := range fields {
+	for _, f := range strings.Fields(usage) {
 		if strings.HasPrefix(f, "provider=") {
 			provider = strings.TrimPrefix(f, "provider=")
 		} else if strings.HasPrefix(f, "model=") {
@@ -198,18 +259,7 @@ func (sp *StreamProcessor) processUsageToken(tok string) {
 			}
 		}
 	}
-
-	// Compute costs via config table
-	inUSD, outUSD, totalUSD := config.CostUSDParts(model, inTok, outTok)
-	if sp.bridge != nil {
-		sp.bridge.EmitBilling(provider, model, inTok, outTok, inUSD, outUSD, totalUSD)
-	}
-
-	// Persist usage to project memory per workspace and to global store
-	if sp.memory != nil {
-		_ = sp.memory.AddUsage(provider, model, inTok, outTok, inUSD, outUSD)
-	}
-	_ = config.AddGlobalUsage(provider, model, inTok, outTok, inUSD, outUSD)
+	return provider, model, inTok, outTok
 }
 
 // processReasoningJSON handles reasoning JSON tokens.
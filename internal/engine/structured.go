@@ -0,0 +1,149 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// StructuredSchema describes the JSON object an engine-internal artifact is returned
+// as. Schemas follow the strict subset providers accept: every property is required
+// and no additional properties are allowed.
+type StructuredSchema struct {
+	Name        string
+	Description string
+	Schema      map[string]interface{}
+}
+
+// StructuredLLM is implemented by adapters whose provider can constrain a reply to a
+// JSON schema: OpenAI-compatible response_format json_schema, or Anthropic's forced
+// tool call. The returned object matches the schema.
+type StructuredLLM interface {
+	ChatStructured(ctx context.Context, messages []Message, schema StructuredSchema) (json.RawMessage, error)
+}
+
+// ErrStructuredUnsupported is returned when the current model cannot produce
+// structured output; callers keep the artifact they derived without the model.
+var ErrStructuredUnsupported = errors.New("the current model does not support structured output")
+
+// structuredTimeout bounds one structured request.
+const structuredTimeout = 90 * time.Second
+
+// structured asks the current model for an object matching schema and decodes it
+// into out.
+func (e *Engine) structured(ctx context.Context, messages []Message, schema StructuredSchema, out interface{}) error {
+	e.llmMu.Lock()
+	llm := e.llm
+	e.llmMu.Unlock()
+	s, ok := llm.(StructuredLLM)
+	if !ok {
+		return ErrStructuredUnsupported
+	}
+	ctx, cancel := context.WithTimeout(ctx, structuredTimeout)
+	defer cancel()
+	raw, err := s.ChatStructured(ctx, messages, schema)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(raw, out); err != nil {
+		return fmt.Errorf("%s: the model returned invalid JSON: %w", schema.Name, err)
+	}
+	return nil
+}
+
+// descriptionRefinement is what the model contributes to a change description.
+type descriptionRefinement struct {
+	Title       string   `json:"title"`
+	Summary     string   `json:"summary"`
+	ReviewNotes []string `json:"review_notes"`
+}
+
+var descriptionSchema = StructuredSchema{
+	Name:        "change_description",
+	Description: "Pull request title, summary and notes for reviewers",
+	Schema: map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"title": map[string]interface{}{
+				"type":        "string",
+				"description": "Imperative pull request title, at most 72 characters",
+			},
+			"summary": map[string]interface{}{
+				"type":        "string",
+				"description": "What changed and why, in 2-5 sentences of plain prose",
+			},
+			"review_notes": map[string]interface{}{
+				"type":        "array",
+				"items":       map[string]interface{}{"type": "string"},
+				"description": "Specific things a reviewer should check, one per entry; empty when there is nothing notable",
+			},
+		},
+		"required":             []string{"title", "summary", "review_notes"},
+		"additionalProperties": false,
+	},
+}
+
+// refinedDescription caches the model's refinement of a conversation's description
+// until the conversation changes more files.
+type refinedDescription struct {
+	conversationID string
+	step           int
+	refinement     descriptionRefinement
+}
+
+// RefineChangeDescription asks the current model for a title, summary and review
+// notes of the session's changes as structured output, and returns the description
+// with them. Later calls to DescribeChanges reuse the refinement until more changes
+// are made. Returns ErrStructuredUnsupported when the model cannot do this.
+func (e *Engine) RefineChangeDescription(ctx context.Context) (*ChangeDescription, error) {
+	d, err := e.DescribeChanges()
+	if err != nil {
+		return nil, err
+	}
+	prompt := "Write the pull request title, summary and review notes for the changes described below. Base them only on this description.\n\n" + d.Markdown
+	var r descriptionRefinement
+	if err := e.structured(ctx, []Message{
+		{Role: "system", Content: "You write concise, factual pull request descriptions."},
+		{Role: "user", Content: prompt},
+	}, descriptionSchema, &r); err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(r.Title) == "" || strings.TrimSpace(r.Summary) == "" {
+		return nil, errors.New("the model returned an empty title or summary")
+	}
+	e.mu.Lock()
+	e.refined = &refinedDescription{conversationID: e.memory.CurrentConversationID(), step: e.latestStep(), refinement: r}
+	e.mu.Unlock()
+	d.applyRefinement(r)
+	return d, nil
+}
+
+// cachedRefinement returns the refinement of the current conversation if no files
+// changed since it was made.
+func (e *Engine) cachedRefinement() (descriptionRefinement, bool) {
+	e.mu.RLock()
+	r := e.refined
+	e.mu.RUnlock()
+	if r == nil || e.memory == nil || r.conversationID != e.memory.CurrentConversationID() || r.step != e.latestStep() {
+		return descriptionRefinement{}, false
+	}
+	return r.refinement, true
+}
+
+// applyRefinement replaces the derived title and summary with the model's and
+// re-renders the Markdown.
+func (d *ChangeDescription) applyRefinement(r descriptionRefinement) {
+	d.Title = truncateRunes(strings.TrimSpace(r.Title), maxTitleLen)
+	d.Summary = truncateRunes(strings.TrimSpace(r.Summary), maxSummaryLen)
+	d.ReviewNotes = []string{}
+	for _, n := range r.ReviewNotes {
+		if n = strings.TrimSpace(n); n != "" {
+			d.ReviewNotes = append(d.ReviewNotes, n)
+		}
+	}
+	d.Refined = true
+	d.Markdown = d.render()
+}
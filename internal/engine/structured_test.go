@@ -0,0 +1,68 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/loom/loom/internal/memory"
+)
+
+// structuredLLM answers structured requests with a fixed object.
+type structuredLLM struct {
+	reply  string
+	schema string
+}
+
+func (s *structuredLLM) Chat(context.Context, []Message, []ToolSchema, bool) (<-chan TokenOrToolCall, error) {
+	return nil, errors.New("not used")
+}
+
+func (s *structuredLLM) ChatStructured(_ context.Context, _ []Message, schema StructuredSchema) (json.RawMessage, error) {
+	s.schema = schema.Name
+	return json.RawMessage(s.reply), nil
+}
+
+// plainLLM has no structured output.
+type plainLLM struct{}
+
+func (plainLLM) Chat(context.Context, []Message, []ToolSchema, bool) (<-chan TokenOrToolCall, error) {
+	return nil, errors.New("not used")
+}
+
+func TestRefineChangeDescription(t *testing.T) {
+	ws := t.TempDir()
+	store, err := memory.NewStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	proj, err := memory.NewProject(store, ws)
+	if err != nil {
+		t.Fatal(err)
+	}
+	llm := &structuredLLM{reply: `{"title":"Guard checkout against empty coupons","summary":"Empty coupon codes no longer crash checkout.","review_notes":["Check the discount rounding", " "]}`}
+	e := New(llm, nil).WithWorkspace(ws).WithMemory(proj)
+	e.NewConversation()
+
+	d, err := e.RefineChangeDescription(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if llm.schema != "change_description" || !d.Refined || d.Title != "Guard checkout against empty coupons" || len(d.ReviewNotes) != 1 {
+		t.Fatalf("unexpected description %+v", d)
+	}
+	if !strings.Contains(d.Markdown, "## Notes for reviewers\n\n- Check the discount rounding") {
+		t.Errorf("markdown lacks the review notes:\n%s", d.Markdown)
+	}
+	// Exports reuse the refinement while nothing changed
+	if again, _ := e.DescribeChanges(); !again.Refined || again.Summary != d.Summary {
+		t.Errorf("expected the cached refinement, got %+v", again)
+	}
+
+	e.SetLLM(plainLLM{})
+	if _, err := e.RefineChangeDescription(context.Background()); !errors.Is(err, ErrStructuredUnsupported) {
+		t.Errorf("expected ErrStructuredUnsupported, got %v", err)
+	}
+}
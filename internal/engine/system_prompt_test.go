@@ -1,6 +1,7 @@
 package engine
 
 import (
+	"fmt"
 	"strings"
 	"testing"
 
@@ -35,3 +36,66 @@ func TestGenerateSystemPromptWithRules_IncludesMemoriesBlock(t *testing.T) {
 		t.Fatalf("missing formatted memory entry")
 	}
 }
+
+func TestGenerateSystemPrompt_ProviderVariants(t *testing.T) {
+	tools := []tool.Schema{{Name: "read_file", Description: "Reads a file", Safe: true}}
+	gen := func(label string) string {
+		return GenerateSystemPromptUnified(SystemPromptOptions{Tools: tools, ModelName: label})
+	}
+
+	claude := gen("anthropic:claude-sonnet-4")
+	if !strings.Contains(claude, "tool_use blocks") || !strings.Contains(claude, "Personality Modes") {
+		t.Fatalf("anthropic variant missing tool guidance or personality modes")
+	}
+	gpt := gen("openai:gpt-4o")
+	if !strings.Contains(gpt, "function calls") || strings.Contains(gpt, "tool_use blocks") {
+		t.Fatalf("openai variant has wrong tool guidance")
+	}
+	local := gen("ollama:qwen2.5-coder")
+	if !strings.Contains(local, "at most one tool per response") || strings.Contains(local, "Personality Modes") {
+		t.Fatalf("ollama variant should be compact with single-call guidance")
+	}
+	// The shared template is identical apart from the provider sections
+	for _, p := range []string{claude, gpt, local, gen("")} {
+		if !strings.Contains(p, "## Workflow Guidelines") || !strings.Contains(p, "- read_file:") {
+			t.Fatalf("variant lost shared template content")
+		}
+	}
+
+	for label, role := range map[string]string{
+		"openai:gpt-4o":       "system",
+		"openai:o3-mini":      "developer",
+		"openai:gpt-5":        "developer",
+		"anthropic:claude-3":  "system",
+		"openrouter:o3":       "system",
+		"custom-without-kind": "system",
+	} {
+		if got := PromptVariantFor(label).Role; got != role {
+			t.Errorf("PromptVariantFor(%q).Role = %q, want %q", label, got, role)
+		}
+	}
+}
+
+func TestSelectMemoriesForPrompt_KeepsMostRelevant(t *testing.T) {
+	var all []MemoryEntry
+	for i := 0; i < memoryPromptLimit; i++ {
+		all = append(all, MemoryEntry{ID: fmt.Sprintf("filler-%d", i), Text: fmt.Sprintf("Note number %d about nothing in particular", i)})
+	}
+	if got, omitted := selectMemoriesForPrompt(all, "anything"); len(got) != memoryPromptLimit || omitted != 0 {
+		t.Fatalf("few memories should all be kept, got %d (omitted %d)", len(got), omitted)
+	}
+
+	all = append(all,
+		MemoryEntry{ID: "deploy", Text: "Deploys go through staging first", Tags: []string{"release"}},
+		MemoryEntry{ID: "migrations", Text: "Run make migrate before the database tests"},
+	)
+	got, omitted := selectMemoriesForPrompt(all, "the database tests fail after my release")
+	if len(got) != 2 || got[0].ID != "migrations" || got[1].ID != "deploy" || omitted != len(all)-2 {
+		t.Fatalf("unexpected selection %+v (omitted %d)", got, omitted)
+	}
+
+	prompt := GenerateSystemPromptUnified(SystemPromptOptions{Memories: got, MemoriesOmitted: omitted})
+	if !strings.Contains(prompt, "- deploy [release]: Deploys go through staging first") || !strings.Contains(prompt, "12 more are stored") {
+		t.Fatalf("unexpected memories block: %q", prompt)
+	}
+}
@@ -0,0 +1,299 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/loom/loom/internal/editor"
+	"github.com/loom/loom/internal/memory"
+	"github.com/loom/loom/internal/tool"
+)
+
+// timelineDiffContext is the number of context lines around each hunk.
+const timelineDiffContext = 3
+
+// timelineRecorder adds a checkpoint to the conversation's timeline for every file
+// change Loom makes, so any two points of the session can be compared or restored.
+type timelineRecorder struct {
+	project        *memory.Project
+	workspace      string
+	conversationID string
+}
+
+// pendingChange is a file's state captured before an edit is applied.
+type pendingChange struct {
+	call    *tool.ToolCall
+	rel     string
+	before  []byte
+	existed bool
+}
+
+// capture reads the file an apply_edit call is about to change. It returns nil for
+// other calls.
+func (tr *timelineRecorder) capture(call *tool.ToolCall) *pendingChange {
+	if tr == nil || tr.project == nil || call.Name != "apply_edit" {
+		return nil
+	}
+	rel := workspacePathArg(call)
+	if rel == "" {
+		return nil
+	}
+	before, err := os.ReadFile(filepath.Join(tr.workspace, rel))
+	if err != nil && !os.IsNotExist(err) {
+		return nil
+	}
+	return &pendingChange{call: call, rel: rel, before: before, existed: err == nil}
+}
+
+// commit records the change when the file differs from its captured state. It returns
+// the change with its full contents, even where the timeline only keeps hashes.
+func (tr *timelineRecorder) commit(pc *pendingChange) (memory.Checkpoint, bool) {
+	if tr == nil || pc == nil {
+		return memory.Checkpoint{}, false
+	}
+	after, err := os.ReadFile(filepath.Join(tr.workspace, pc.rel))
+	if err != nil && !os.IsNotExist(err) {
+		return memory.Checkpoint{}, false
+	}
+	exists := err == nil
+	if exists == pc.existed && string(after) == string(pc.before) {
+		return memory.Checkpoint{}, false
+	}
+	cp := newCheckpoint(pc.rel, pc.before, after, pc.existed, exists, pc.call.Name)
+	cp.CallID = pc.call.ID
+	if recorded, err := tr.project.RecordCheckpoint(tr.conversationID, cp); err == nil {
+		cp.Step, cp.At = recorded.Step, recorded.At
+	}
+	return cp, true
+}
+
+func newCheckpoint(rel string, before, after []byte, existed, exists bool, source string) memory.Checkpoint {
+	return memory.Checkpoint{
+		Path:         rel,
+		Before:       string(before),
+		After:        string(after),
+		Created:      !existed,
+		Deleted:      !exists,
+		BeforeSHA256: sha256Hex(before),
+		AfterSHA256:  sha256Hex(after),
+		Source:       source,
+	}
+}
+
+// recordCheckpoint adds a change made outside the tool loop (saved code blocks, undo,
+// reverts) to the current conversation's timeline.
+func (e *Engine) recordCheckpoint(rel string, before, after []byte, existed, exists bool, source string) {
+	if e.memory == nil {
+		return
+	}
+	_, _ = e.memory.RecordCheckpoint(e.memory.CurrentConversationID(), newCheckpoint(rel, before, after, existed, exists, source))
+}
+
+// Timeline returns the current conversation's checkpoints, oldest first.
+func (e *Engine) Timeline() []memory.Checkpoint {
+	if e.memory == nil {
+		return nil
+	}
+	return e.memory.Timeline(e.memory.CurrentConversationID())
+}
+
+// TimelineFile is one file's change between two checkpoints.
+type TimelineFile struct {
+	Path string `json:"path"`
+	// Status is "added", "deleted" or "modified"
+	Status  string `json:"status"`
+	Added   int    `json:"added"`
+	Removed int    `json:"removed"`
+	// Omitted is set when the file was too large for its contents to be recorded
+	Omitted bool   `json:"omitted,omitempty"`
+	Diff    string `json:"diff,omitempty"`
+}
+
+// TimelineDiff is the workspace diff between two checkpoints.
+type TimelineDiff struct {
+	From  int            `json:"from"`
+	To    int            `json:"to"`
+	Files []TimelineFile `json:"files"`
+	// Diff is the multi-file unified diff of all files
+	Diff string `json:"diff"`
+}
+
+// fileState is a file's recorded state at a checkpoint.
+type fileState struct {
+	content string
+	sha     string
+	exists  bool
+	omitted bool
+}
+
+// timelineRange validates from and to against the recorded steps.
+func timelineRange(items []memory.Checkpoint, from, to int) error {
+	first, last := 0, 0
+	if len(items) > 0 {
+		first, last = items[0].Step-1, items[len(items)-1].Step
+	}
+	for _, n := range []int{from, to} {
+		if n < first || n > last {
+			return fmt.Errorf("checkpoint %d is outside the recorded timeline (%d-%d)", n, first, last)
+		}
+	}
+	return nil
+}
+
+// statesBetween returns, for every file changed by steps lo+1..hi, its state at
+// checkpoint lo and at checkpoint hi.
+func statesBetween(items []memory.Checkpoint, lo, hi int) (paths []string, at map[string][2]fileState) {
+	at = map[string][2]fileState{}
+	for _, cp := range items {
+		if cp.Step <= lo || cp.Step > hi {
+			continue
+		}
+		s, seen := at[cp.Path]
+		if !seen {
+			paths = append(paths, cp.Path)
+			s[0] = fileState{content: cp.Before, sha: cp.BeforeSHA256, exists: !cp.Created, omitted: cp.Omitted}
+		}
+		s[1] = fileState{content: cp.After, sha: cp.AfterSHA256, exists: !cp.Deleted, omitted: cp.Omitted}
+		// The contents of either end are unknown if any step on this file was omitted
+		s[0].omitted = s[0].omitted || cp.Omitted
+		s[1].omitted = s[0].omitted
+		at[cp.Path] = s
+	}
+	sort.Strings(paths)
+	return paths, at
+}
+
+// DiffCheckpoints returns the workspace diff from checkpoint from to checkpoint to of
+// the current conversation. Checkpoint N is the state after step N and 0 the state
+// before the first step, so "before step 3" vs "after step 7" is DiffCheckpoints(2, 7).
+// from may be greater than to, which diffs backwards in time.
+func (e *Engine) DiffCheckpoints(from, to int) (*TimelineDiff, error) {
+	if e.memory == nil {
+		return nil, errors.New("memory not initialized")
+	}
+	items := e.Timeline()
+	if err := timelineRange(items, from, to); err != nil {
+		return nil, err
+	}
+	paths, at := statesBetween(items, min(from, to), max(from, to))
+	res := &TimelineDiff{From: from, To: to, Files: []TimelineFile{}}
+	var combined strings.Builder
+	for _, p := range paths {
+		oldState, newState := at[p][0], at[p][1]
+		if from > to {
+			oldState, newState = newState, oldState
+		}
+		if oldState.exists == newState.exists && oldState.sha == newState.sha {
+			continue
+		}
+		f := TimelineFile{Path: p, Status: "modified", Omitted: oldState.omitted}
+		switch {
+		case !oldState.exists:
+			f.Status = "added"
+		case !newState.exists:
+			f.Status = "deleted"
+		}
+		if f.Omitted {
+			f.Diff = fmt.Sprintf("diff --git a/%s b/%s\n(file too large; contents were not recorded)\n", p, p)
+		} else {
+			f.Diff = editor.UnifiedDiff(p, oldState.content, newState.content, oldState.exists, newState.exists, timelineDiffContext)
+			f.Added, f.Removed = countDiffLines(f.Diff)
+		}
+		res.Files = append(res.Files, f)
+		combined.WriteString(f.Diff)
+	}
+	res.Diff = combined.String()
+	return res, nil
+}
+
+// countDiffLines counts added and removed lines of a unified diff.
+func countDiffLines(diff string) (added, removed int) {
+	for _, l := range strings.Split(diff, "\n") {
+		switch {
+		case strings.HasPrefix(l, "+++"), strings.HasPrefix(l, "---"):
+		case strings.HasPrefix(l, "+"):
+			added++
+		case strings.HasPrefix(l, "-"):
+			removed++
+		}
+	}
+	return added, removed
+}
+
+// RevertToCheckpoint restores files to their state at checkpoint step. paths limits
+// the revert to some of the files changed since; empty reverts all of them. Like
+// UndoEdit it refuses files that changed on disk since Loom last wrote them. Each
+// revert is itself recorded in the timeline, so it can be inspected and undone.
+func (e *Engine) RevertToCheckpoint(step int, paths []string) ([]string, error) {
+	if e.memory == nil {
+		return nil, errors.New("memory not initialized")
+	}
+	if e.tools.DemoMode() {
+		return nil, errDemoMode
+	}
+	items := e.Timeline()
+	last := 0
+	if len(items) > 0 {
+		last = items[len(items)-1].Step
+	}
+	if err := timelineRange(items, step, last); err != nil {
+		return nil, err
+	}
+	changed, at := statesBetween(items, step, last)
+	targets := changed
+	if len(paths) > 0 {
+		targets = nil
+		for _, p := range paths {
+			p = filepath.ToSlash(filepath.Clean(p))
+			if _, ok := at[p]; !ok {
+				return nil, fmt.Errorf("%s was not changed after checkpoint %d", p, step)
+			}
+			targets = append(targets, p)
+		}
+	}
+
+	var reverted []string
+	var problems []error
+	for _, p := range targets {
+		target, latest := at[p][0], at[p][1]
+		if target.omitted {
+			problems = append(problems, fmt.Errorf("%s: file too large; contents were not recorded", p))
+			continue
+		}
+		abs := filepath.Join(e.editRoot(), filepath.FromSlash(p))
+		current, err := os.ReadFile(abs)
+		if err != nil && !os.IsNotExist(err) {
+			problems = append(problems, fmt.Errorf("%s: %w", p, err))
+			continue
+		}
+		exists := err == nil
+		if exists != latest.exists || (exists && sha256Hex(current) != latest.sha) {
+			problems = append(problems, fmt.Errorf("%s changed after Loom's last edit", p))
+			continue
+		}
+		if !exists && !target.exists {
+			continue
+		}
+		if target.exists {
+			if err = os.MkdirAll(filepath.Dir(abs), 0o755); err == nil {
+				err = os.WriteFile(abs, []byte(target.content), 0o644)
+			}
+		} else {
+			err = os.Remove(abs)
+		}
+		if err != nil {
+			problems = append(problems, fmt.Errorf("%s: %w", p, err))
+			continue
+		}
+		e.recordCheckpoint(p, current, []byte(target.content), exists, target.exists, fmt.Sprintf("revert to %d", step))
+		reverted = append(reverted, p)
+	}
+	if len(problems) > 0 {
+		return reverted, fmt.Errorf("cannot revert: %w", errors.Join(problems...))
+	}
+	return reverted, nil
+}
@@ -0,0 +1,98 @@
+package engine
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/loom/loom/internal/memory"
+	"github.com/loom/loom/internal/tool"
+)
+
+func TestTimeline_DiffAndRevertBetweenCheckpoints(t *testing.T) {
+	ws := t.TempDir()
+	store, err := memory.NewStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	proj, err := memory.NewProject(store, ws)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := proj.SetCurrentConversationID("c1"); err != nil {
+		t.Fatal(err)
+	}
+	e := New(nil, nil).WithWorkspace(ws).WithMemory(proj)
+	tr := &timelineRecorder{project: proj, workspace: ws, conversationID: "c1"}
+
+	read := func(name string) string {
+		data, _ := os.ReadFile(filepath.Join(ws, name))
+		return string(data)
+	}
+	// edit simulates an applied apply_edit call writing content to name
+	edit := func(name, content string) {
+		args, _ := json.Marshal(map[string]string{"path": name})
+		pc := tr.capture(&tool.ToolCall{ID: "t-" + name, Name: "apply_edit", Args: args})
+		if err := os.WriteFile(filepath.Join(ws, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		tr.commit(pc)
+	}
+	if err := os.WriteFile(filepath.Join(ws, "a.txt"), []byte("one\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	edit("a.txt", "one\ntwo\n")        // step 1
+	edit("b.txt", "b\n")               // step 2
+	edit("a.txt", "one\ntwo\nthree\n") // step 3
+	edit("a.txt", "one\ntwo\nthree\n") // unchanged: no step
+
+	if n := len(e.Timeline()); n != 3 {
+		t.Fatalf("expected 3 checkpoints, got %d", n)
+	}
+
+	d, err := e.DiffCheckpoints(0, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(d.Files) != 2 || d.Files[0].Path != "a.txt" || d.Files[0].Added != 2 || d.Files[1].Status != "added" {
+		t.Fatalf("unexpected files %+v", d.Files)
+	}
+	for _, want := range []string{"--- a/a.txt", "@@ -1 +1,3 @@", "+three", "--- /dev/null", "+++ b/b.txt"} {
+		if !strings.Contains(d.Diff, want) {
+			t.Errorf("diff missing %q:\n%s", want, d.Diff)
+		}
+	}
+	if d, _ := e.DiffCheckpoints(3, 1); len(d.Files) != 2 || d.Files[1].Status != "deleted" || d.Files[0].Removed != 1 {
+		t.Fatalf("unexpected backwards diff %+v", d.Files)
+	}
+	if _, err := e.DiffCheckpoints(0, 9); err == nil {
+		t.Fatal("expected an out-of-range error")
+	}
+
+	// Selective revert of one file, then everything
+	if got, err := e.RevertToCheckpoint(1, []string{"b.txt"}); err != nil || len(got) != 1 {
+		t.Fatalf("revert b.txt: %v %v", got, err)
+	}
+	if _, err := os.Stat(filepath.Join(ws, "b.txt")); !os.IsNotExist(err) {
+		t.Fatal("b.txt should be removed")
+	}
+	if read("a.txt") != "one\ntwo\nthree\n" {
+		t.Fatal("a.txt must be untouched by a selective revert")
+	}
+	if n := len(e.Timeline()); n != 4 {
+		t.Fatalf("revert should be recorded as a step, got %d", n)
+	}
+
+	// Files edited outside Loom are never overwritten
+	if err := os.WriteFile(filepath.Join(ws, "a.txt"), []byte("mine\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := e.RevertToCheckpoint(0, nil); err == nil || !strings.Contains(err.Error(), "a.txt changed") {
+		t.Fatalf("expected a conflict, got %v", err)
+	}
+	if read("a.txt") != "mine\n" {
+		t.Fatal("conflicting file was overwritten")
+	}
+}
@@ -0,0 +1,216 @@
+package engine
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/loom/loom/internal/config"
+	"github.com/loom/loom/internal/editor"
+	"github.com/loom/loom/internal/memory"
+	"github.com/loom/loom/internal/textutil"
+	"github.com/loom/loom/internal/tool"
+)
+
+const (
+	// budgetOutputReserve is kept free in the context window for the model's reply.
+	budgetOutputReserve = 4096
+	// messageOverheadTokens approximates the role and framing tokens of one message.
+	messageOverheadTokens = 4
+	// attachmentPreviewLines matches the lines of a workspace file the chat inlines.
+	attachmentPreviewLines = 50
+)
+
+// TokenBudget projects the first request a draft message would send, so the UI can
+// warn before an expensive or oversized prompt goes out.
+type TokenBudget struct {
+	Model        string `json:"model"`
+	SystemTokens int    `json:"system_tokens"`
+	ToolTokens   int    `json:"tool_tokens"`
+	// HistoryTokens is the transcript earlier requests already sent
+	HistoryTokens int `json:"history_tokens"`
+	// MessageTokens and AttachmentTokens are what the draft adds to the transcript
+	MessageTokens    int `json:"message_tokens"`
+	AttachmentTokens int `json:"attachment_tokens"`
+	HintTokens       int `json:"hint_tokens"`
+	// TotalTokens is the projected request after any context the engine would leave out
+	TotalTokens int `json:"total_tokens"`
+	// ContextWindow is the model's input limit, or 0 when unknown
+	ContextWindow int `json:"context_window"`
+	// CostUSD is the input cost of the request; 0 when the model's price is unknown
+	CostUSD    float64 `json:"cost_usd"`
+	PriceKnown bool    `json:"price_known"`
+	// Compacts reports that the request exceeds the window, so context would be left out
+	Compacts bool `json:"compacts"`
+	// Dropped describes the context left out, in the order the engine drops it
+	Dropped []string `json:"dropped,omitempty"`
+	// Fits is false when the request exceeds the window even with everything left out
+	Fits bool `json:"fits"`
+}
+
+// EstimateRequest projects the tokens, input cost and context window overflow of
+// sending message with the given attachments (workspace-relative paths or
+// "@attachments/" refs) in the current conversation. Nothing is sent or stored.
+func (e *Engine) EstimateRequest(message string, attachments []string) (*TokenBudget, error) {
+	if e.memory == nil {
+		return nil, errors.New("memory not initialized")
+	}
+	registry, root := e.runTools()
+	if registry == nil {
+		return nil, errors.New("tool registry not initialized")
+	}
+	disabled := map[string]bool{}
+	for _, name := range e.DisabledTools() {
+		disabled[name] = true
+	}
+	toolSchemas := withoutDisabledTools(registry.Schemas(), disabled)
+	id := e.memory.CurrentConversationID()
+	if id == "" {
+		id = "current"
+	}
+	convo := memory.NewConversation(e.memory, id)
+
+	label := e.GetModelLabel()
+	attached := e.attachmentBlock(root, attachments)
+	draft := strings.TrimSpace(message)
+	if attached != "" {
+		draft += "\n\n" + attached
+	}
+	opts := e.systemPromptOptions(convo, draft, root, toolSchemas)
+	base := GenerateSystemPromptUnified(opts)
+
+	// The draft becomes the current request; everything stored is from earlier ones
+	runStart := time.Now()
+	stored := convo.History()
+	history := make([]memory.Message, 0, len(stored)+2)
+	history = append(history, memory.Message{Role: "system", Content: base})
+	for _, m := range stored {
+		if m.Role == "system" && m.Name == "" {
+			continue
+		}
+		history = append(history, m)
+	}
+	history = append(history, memory.Message{Role: "user", Content: draft, Timestamp: runStart})
+
+	var hints []string
+	if ui := strings.TrimSpace(e.formatEditorContext()); ui != "" {
+		hints = append(hints, "UI Context: "+ui)
+	}
+	if hint := e.directoryMemoryHint(history); hint != "" {
+		hints = append(hints, hint)
+	}
+	toolsJSON, _ := json.Marshal(convertSchemas(toolSchemas))
+
+	b := &TokenBudget{
+		Model:            label,
+		SystemTokens:     messageTokens(base),
+		ToolTokens:       textutil.EstimateTokens(string(toolsJSON)),
+		MessageTokens:    messageTokens(message),
+		AttachmentTokens: textutil.EstimateTokens(attached),
+		ContextWindow:    contextWindowFor(label),
+	}
+	for _, m := range history[1 : len(history)-1] {
+		b.HistoryTokens += messageTokens(m.Content)
+	}
+	for _, h := range hints {
+		b.HintTokens += messageTokens(h)
+	}
+
+	shrink := newContextShrinker(opts, base, runStart)
+	b.TotalTokens = requestTokens(shrink, history, hints) + b.ToolTokens
+	b.Fits = true
+	if b.ContextWindow > 0 {
+		for b.TotalTokens > b.ContextWindow-budgetOutputReserve {
+			dropped, ok := shrink.drop(history, hints)
+			if !ok {
+				b.Fits = false
+				break
+			}
+			b.Compacts = true
+			b.Dropped = append(b.Dropped, dropped)
+			b.TotalTokens = requestTokens(shrink, history, hints) + b.ToolTokens
+		}
+	}
+	// Local models are free, and an unknown model would wait for the OpenRouter catalog
+	if label != "" && !strings.HasPrefix(label, "ollama:") {
+		if _, _, cost := config.CostUSDParts(modelIDOf(label), int64(b.TotalTokens), 0); cost > 0 {
+			b.CostUSD, b.PriceKnown = cost, true
+		}
+	}
+	return b, nil
+}
+
+// requestTokens estimates the messages of a request as processLoop assembles them.
+func requestTokens(shrink *contextShrinker, history []memory.Message, hints []string) int {
+	total := 0
+	for _, m := range shrink.apply(history) {
+		total += messageTokens(m.Content)
+	}
+	if shrink.hints() {
+		for _, h := range hints {
+			total += messageTokens(h)
+		}
+	}
+	if note := shrink.note(); note != "" {
+		total += messageTokens(note)
+	}
+	return total
+}
+
+func messageTokens(s string) int {
+	return textutil.EstimateTokens(s) + messageOverheadTokens
+}
+
+// contextWindowFor returns the input limit of the model behind label, or 0 when unknown.
+func contextWindowFor(label string) int {
+	if strings.HasPrefix(label, "ollama:") {
+		return localContextTokens()
+	}
+	return config.ContextWindow(modelIDOf(label))
+}
+
+// attachmentBlock renders attachments the way the chat appends them to a message:
+// an excerpt of files attached from outside the workspace and the first lines of
+// workspace files.
+func (e *Engine) attachmentBlock(root string, refs []string) string {
+	var previews []string
+	for _, ref := range refs {
+		ref = strings.TrimSpace(ref)
+		if ref == "" {
+			continue
+		}
+		if strings.HasPrefix(ref, tool.AttachmentPrefix) {
+			excerpt, err := e.AttachmentExcerpt(ref)
+			if err != nil {
+				continue
+			}
+			previews = append(previews, excerpt+"\n  The user attached this file from outside the workspace. It is read-only.")
+			continue
+		}
+		rel := filepath.FromSlash(ref)
+		if !filepath.IsLocal(rel) {
+			continue
+		}
+		// A symlink must not pull a file from outside the workspace into the prompt
+		abs, err := editor.SecurePath(root, rel)
+		if err != nil {
+			continue
+		}
+		data, err := os.ReadFile(abs)
+		if err != nil {
+			continue
+		}
+		lines := strings.Split(string(data), "\n")
+		if len(lines) > attachmentPreviewLines {
+			lines = lines[:attachmentPreviewLines]
+		}
+		previews = append(previews, "- "+filepath.Base(rel)+" — "+ref+"\n  The user attached this file for additional context. Use it if relevant.\n  First 50 lines:\n    "+strings.Join(lines, "\n    "))
+	}
+	if len(previews) == 0 {
+		return ""
+	}
+	return "<attachments>\nAttachments:\n" + strings.Join(previews, "\n") + "\n</attachments>"
+}
@@ -0,0 +1,86 @@
+package engine
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/loom/loom/internal/memory"
+	"github.com/loom/loom/internal/tool"
+)
+
+func TestEstimateRequest(t *testing.T) {
+	ws := t.TempDir()
+	store, err := memory.NewStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	proj, err := memory.NewProject(store, ws)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reg := tool.NewRegistry()
+	tool.RegisterCoreTools(reg, ws)
+	e := New(nil, nil).WithWorkspace(ws).WithMemory(proj).WithRegistry(reg)
+	e.NewConversation()
+	e.SetModelLabel("openai:gpt-4.1-mini")
+
+	convo := proj.StartConversation()
+	convo.AddUser("read the log")
+	convo.AddAssistantToolUse("read_file", "t1", `{"path":"app.log"}`)
+	convo.AddToolResult("read_file", "t1", strings.Repeat("GET /health 200 OK\n", 4000))
+	convo.AddAssistant("The log only has health checks.")
+	if err := os.WriteFile(filepath.Join(ws, "main.go"), []byte("package main\n\nfunc main() {}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	outside := filepath.Join(t.TempDir(), "secret.txt")
+	if err := os.WriteFile(outside, []byte(strings.Repeat("secret ", 2000)), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	_ = os.Symlink(outside, filepath.Join(ws, "linked.txt"))
+	if block := e.attachmentBlock(ws, []string{"linked.txt"}); block != "" {
+		t.Fatalf("a symlink out of the workspace should not be inlined, got %q", block)
+	}
+
+	b, err := e.EstimateRequest("why does main do nothing?", []string{"main.go", "../outside.txt"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.ContextWindow != 1047576 || b.Compacts || !b.Fits || !b.PriceKnown || b.CostUSD <= 0 {
+		t.Fatalf("unexpected budget %+v", b)
+	}
+	if b.HistoryTokens < 10000 || b.AttachmentTokens == 0 || b.MessageTokens == 0 || b.ToolTokens == 0 {
+		t.Fatalf("unexpected breakdown %+v", b)
+	}
+	if sum := b.SystemTokens + b.ToolTokens + b.HistoryTokens + b.MessageTokens + b.AttachmentTokens + b.HintTokens; b.TotalTokens < sum-10 || b.TotalTokens > sum+10 {
+		t.Fatalf("total %d does not add up to %d", b.TotalTokens, sum)
+	}
+	full := b.TotalTokens
+
+	// A window too small for the old log leaves it out, like an overflowing request would
+	e.SetModelLabel("ollama:llama3")
+	t.Setenv("LOOM_LOCAL_CONTEXT_TOKENS", strconv.Itoa(full+budgetOutputReserve-1000))
+	b, err = e.EstimateRequest("why does main do nothing?", []string{"main.go"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !b.Compacts || !b.Fits || len(b.Dropped) != 1 || !strings.Contains(b.Dropped[0], "tool results of earlier requests") {
+		t.Fatalf("expected the old tool result to be dropped, got %+v", b)
+	}
+	if b.TotalTokens >= full || b.PriceKnown {
+		t.Fatalf("unexpected compacted budget %+v", b)
+	}
+
+	t.Setenv("LOOM_LOCAL_CONTEXT_TOKENS", "5000")
+	if b, err = e.EstimateRequest("why does main do nothing?", nil); err != nil || b.Fits || !b.Compacts {
+		t.Fatalf("expected the request not to fit, got %+v (%v)", b, err)
+	}
+
+	// Estimating leaves the conversation alone
+	if n := len(proj.StartConversation().History()); n != 4 {
+		t.Fatalf("expected 4 stored messages, got %d", n)
+	}
+}
@@ -3,10 +3,14 @@ package engine
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
+	"time"
 
+	"github.com/loom/loom/internal/editor"
 	"github.com/loom/loom/internal/memory"
 	"github.com/loom/loom/internal/tool"
 )
@@ -16,6 +20,38 @@ type ToolExecutor struct {
 	bridge          UIBridge
 	tools           *tool.Registry
 	approvalHandler *ApprovalHandler
+	// editChunking is set for small-context models; nil leaves edits unrestricted
+	editChunking *editChunking
+	// renames redirects stale paths after files are moved; nil disables tracking
+	renames *renameTracker
+	// done enforces the workspace's definition of done; nil accepts finalize immediately
+	done *doneGuard
+	// finalSummary holds an accepted finalize summary until the engine ends the run
+	finalSummary *string
+	// audit records edits and shell commands; nil disables auditing
+	audit     *memory.Project
+	workspace string
+	// snapshots records files the conversation has seen; nil disables tracking
+	snapshots *snapshotTracker
+	// timeline records a checkpoint for every applied edit; nil disables it
+	timeline *timelineRecorder
+	// journal keeps every change the timeline records in .loom/history; nil disables it
+	journal *editJournal
+	// dirty guards lines with unsaved changes in the UI editor; nil disables the check
+	dirty     *dirtyBuffers
+	dirtyRoot string
+	// disabled holds the tools the user switched off for the current conversation
+	disabled map[string]bool
+	// repro runs reproduce calls and holds reproduce-first fixes to their reproduction
+	repro *reproGuard
+	// feed reports the files the current run changed to the UI; nil disables it
+	feed *changeFeed
+	// noPrefetch stops follow-up reads from running in the background
+	noPrefetch bool
+	// coverage checks the coverage report a shell command wrote; nil disables it
+	coverage func(args tool.ApplyShellArgs, started time.Time) []string
+	// edits lines up workspace writes with those of forked conversations; nil applies them directly
+	edits *editQueue
 }
 
 // NewToolExecutor creates a new tool executor.
@@ -24,6 +60,9 @@ func NewToolExecutor(
 	tools *tool.Registry,
 	approvalHandler *ApprovalHandler,
 ) *ToolExecutor {
+	if approvalHandler != nil {
+		approvalHandler.setRegistry(tools)
+	}
 	return &ToolExecutor{
 		bridge:          bridge,
 		tools:           tools,
@@ -31,15 +70,203 @@ func NewToolExecutor(
 	}
 }
 
+// SetRegistry replaces the registry tool calls are dispatched to.
+func (te *ToolExecutor) SetRegistry(tools *tool.Registry) {
+	te.tools = tools
+	if te.approvalHandler != nil {
+		te.approvalHandler.setRegistry(tools)
+	}
+}
+
+// SetChangeFeed installs the changed-files feed of the current run.
+func (te *ToolExecutor) SetChangeFeed(f *changeFeed) {
+	te.feed = f
+}
+
+// SetPrefetch turns the background prefetch of likely follow-up reads on or off.
+func (te *ToolExecutor) SetPrefetch(on bool) {
+	te.noPrefetch = !on
+}
+
+// SetCoverageCheck installs the check run after shell commands that collect coverage.
+func (te *ToolExecutor) SetCoverageCheck(check func(args tool.ApplyShellArgs, started time.Time) []string) {
+	te.coverage = check
+}
+
+// SetReproduction installs the reproduction guard of the current conversation.
+func (te *ToolExecutor) SetReproduction(g *reproGuard) {
+	te.repro = g
+}
+
+// SetDisabledTools refuses calls to the given tools for the current run.
+func (te *ToolExecutor) SetDisabledTools(disabled map[string]bool) {
+	te.disabled = disabled
+}
+
+// SetEditChunking enables (or with nil disables) size-limited, verified edits.
+func (te *ToolExecutor) SetEditChunking(ec *editChunking) {
+	te.editChunking = ec
+}
+
+// SetRenameTracking enables path redirection using the project's rename history.
+func (te *ToolExecutor) SetRenameTracking(project *memory.Project, workspace string) {
+	if project == nil || workspace == "" {
+		te.renames = nil
+		return
+	}
+	te.renames = &renameTracker{project: project, workspace: workspace}
+}
+
+// SetAuditLog records workspace changes made by tools in the project's audit log.
+func (te *ToolExecutor) SetAuditLog(project *memory.Project, workspace string) {
+	te.audit = project
+	te.workspace = workspace
+}
+
+// SetFileSnapshots records the files read or edited in the given conversation so a
+// later resume can detect changes made on disk in the meantime.
+func (te *ToolExecutor) SetFileSnapshots(project *memory.Project, workspace, conversationID string) {
+	if project == nil || workspace == "" || conversationID == "" {
+		te.snapshots = nil
+		return
+	}
+	te.snapshots = &snapshotTracker{project: project, workspace: workspace, conversationID: conversationID}
+}
+
+// SetTimeline records every applied edit as a checkpoint of the given conversation.
+func (te *ToolExecutor) SetTimeline(project *memory.Project, workspace, conversationID string) {
+	if project == nil || workspace == "" || conversationID == "" {
+		te.timeline = nil
+		return
+	}
+	te.timeline = &timelineRecorder{project: project, workspace: workspace, conversationID: conversationID}
+}
+
+// SetEditJournal writes every applied edit of the given conversation to the
+// workspace's edit journal, so it can be undone. An empty workspace disables it, e.g.
+// in what-if mode where edits go to an overlay.
+func (te *ToolExecutor) SetEditJournal(project *memory.Project, workspace, conversationID string) {
+	if workspace == "" || conversationID == "" {
+		te.journal = nil
+		return
+	}
+	te.journal = &editJournal{project: project, workspace: workspace, conversationID: conversationID}
+}
+
+// SetEditQueue makes calls that change the workspace wait for the writes of the other
+// conversations sharing q.
+func (te *ToolExecutor) SetEditQueue(q *editQueue) {
+	te.edits = q
+}
+
+// writes reports whether a call may change the workspace and so goes through the edit queue.
+func (te *ToolExecutor) writes(toolCall *tool.ToolCall) bool {
+	def, ok := te.tools.Get(toolCall.Name)
+	return !ok || !def.ReadOnly
+}
+
+// SetDirtyGuard checks edits in workspace against the user's unsaved editor changes.
+// A nil guard disables the check, e.g. in what-if mode where edits go to an overlay.
+func (te *ToolExecutor) SetDirtyGuard(d *dirtyBuffers, workspace string) {
+	te.dirty = d
+	te.dirtyRoot = workspace
+}
+
+// SetDoneGuard installs the definition-of-done guard for the current run.
+func (te *ToolExecutor) SetDoneGuard(g *doneGuard) {
+	te.done = g
+	te.finalSummary = nil
+}
+
+// takeFinalized returns the summary of an accepted finalize call, if any, and clears it.
+func (te *ToolExecutor) takeFinalized() (string, bool) {
+	if te.finalSummary == nil {
+		return "", false
+	}
+	s := *te.finalSummary
+	te.finalSummary = nil
+	return s, true
+}
+
 // ExecuteToolCall executes a tool call and handles the approval flow.
 func (te *ToolExecutor) ExecuteToolCall(
 	ctx context.Context,
 	toolCall *tool.ToolCall,
 	convo *memory.Conversation,
 ) error {
-	// Execute the tool
+	if te.disabled[toolCall.Name] {
+		convo.AddToolResult(toolCall.Name, toolCall.ID, disabledToolResult(toolCall.Name))
+		return nil
+	}
+
+	// Deny policies stop any call before it runs, whether or not the tool needs approval
+	if rule := te.approvalHandler.policyDenial(toolCall); rule != nil {
+		te.approvalHandler.recordApproval(toolCall, false, "policy: "+rule.Source)
+		msg := fmt.Sprintf("Denied %s by approval policy: %s", toolCall.Name, rule.Source)
+		convo.AddToolResult(toolCall.Name, toolCall.ID, msg)
+		te.bridge.SendChat("system", msg)
+		return nil
+	}
+
+	// Small-context models must split large edits before they reach approval
+	if te.editChunking != nil && toolCall.Name == "edit_file" {
+		if msg, ok := te.editChunking.check(toolCall); !ok {
+			convo.AddToolResult(toolCall.Name, toolCall.ID, msg)
+			te.bridge.SendChat("system", "Edit too large for this model's context; asking for smaller chunks.")
+			return nil
+		}
+	}
+
+	// Lint findings that predate this run's changes do not count against it
+	if toolCall.Name == "edit_file" || toolCall.Name == "run_shell" {
+		te.done.ensureBaseline(ctx)
+	}
+
+	// Redirect paths that were moved earlier in the session
+	renameNote := te.renames.resolve(toolCall)
+	if renameNote != "" {
+		te.bridge.SendChat("system", renameNote)
+	}
+
+	// A reproduce-first fix may only touch tests until the bug is reproduced
+	if msg, refuse := te.repro.checkEdit(toolCall); refuse {
+		convo.AddToolResult(toolCall.Name, toolCall.ID, msg)
+		te.bridge.SendChat("system", msg)
+		return nil
+	}
+
+	// Keep the agent from overwriting lines the user is still editing
+	var dirtyWarning string
+	if te.dirty != nil {
+		if msg, refuse := te.dirty.check(te.dirtyRoot, toolCall); refuse {
+			convo.AddToolResult(toolCall.Name, toolCall.ID, msg)
+			te.bridge.SendChat("system", msg)
+			return nil
+		} else if msg != "" {
+			dirtyWarning = msg
+			te.bridge.SendChat("system", msg)
+		}
+	}
+
+	// Keep a copy of what an approved shell command deletes in the workspace trash
+	var trashed []editor.TrashEntry
+	var tooLarge []string
+	if toolCall.Name == "apply_shell" {
+		trashed, tooLarge = trashShellDeletions(te.workspace, toolCall.Args)
+	}
+
+	// Execute the tool; calls that change the workspace wait for other conversations' writes
+	release, err := te.edits.acquire(ctx, te.writes(toolCall))
+	if err != nil {
+		discardShellDeletions(te.workspace, trashed)
+		return err
+	}
+	started := time.Now()
+	pending := te.timeline.capture(toolCall)
 	execResult, err := te.tools.InvokeToolCall(ctx, toolCall)
+	release()
 	if err != nil {
+		discardShellDeletions(te.workspace, trashed)
 		errorMsg := fmt.Sprintf("Error executing tool %s: %v", toolCall.Name, err)
 		// Attach as tool_result with the same tool_use_id for Anthropic
 		convo.AddToolResult(toolCall.Name, toolCall.ID, errorMsg)
@@ -53,6 +280,77 @@ func (te *ToolExecutor) ExecuteToolCall(
 
 	// Workflow functionality removed
 
+	if toolCall.Name == "finalize" {
+		return te.handleFinalize(ctx, toolCall, execResult, convo)
+	}
+	if toolCall.Name == "reproduce" {
+		// Blocked calls (demo or read-only mode) return a notice instead of arguments
+		if args, perr := tool.ParseReproduceArgs(json.RawMessage(execResult.Content)); perr == nil && te.repro != nil {
+			return te.handleReproduce(ctx, toolCall, args, convo)
+		}
+	}
+	if toolCall.Name == "apply_edit" || toolCall.Name == "apply_shell" {
+		te.done.markDirty()
+		auditSideEffect(te.audit, te.workspace, toolCall, execResult)
+	}
+	te.snapshots.record(toolCall)
+	te.commitChange(pending)
+	te.feed.observe(pending)
+	te.prefetch(ctx, toolCall, execResult)
+
+	if renameNote != "" {
+		execResult.Content = renameNote + "\n\n" + execResult.Content
+	}
+	if dirtyWarning != "" {
+		execResult.Content = dirtyWarning + "\n\n" + execResult.Content
+	}
+	if toolCall.Name == "apply_shell" {
+		var sr tool.ShellResult
+		if json.Unmarshal([]byte(execResult.Content), &sr) == nil && sr.Flakiness != nil {
+			te.bridge.SendChat("system", sr.Flakiness.Summary)
+		}
+		var shellArgs tool.ApplyShellArgs
+		if te.coverage != nil && json.Unmarshal(toolCall.Args, &shellArgs) == nil {
+			if warnings := te.coverage(shellArgs, started); len(warnings) > 0 {
+				te.bridge.SendChat("system", strings.Join(warnings, "\n"))
+				var sr tool.ShellResult
+				if json.Unmarshal([]byte(execResult.Content), &sr) == nil {
+					sr.Coverage = warnings
+					if b, err := json.Marshal(sr); err == nil {
+						execResult.Content = string(b)
+					}
+				}
+			}
+		}
+		for _, r := range te.renames.recordShellMoves(toolCall.Args) {
+			te.bridge.SendChat("system", fmt.Sprintf("Tracking rename %s → %s", r.From, r.To))
+		}
+		if kept := keepShellDeletions(te.workspace, trashed); len(kept) > 0 {
+			paths := make([]string, len(kept))
+			for i, e := range kept {
+				paths[i] = e.Path
+			}
+			te.bridge.SendChat("system", fmt.Sprintf("Kept deleted paths in the trash (Settings → Trash): %s", strings.Join(paths, ", ")))
+			var sr tool.ShellResult
+			if json.Unmarshal([]byte(execResult.Content), &sr) == nil {
+				sr.Trashed = paths
+				if b, err := json.Marshal(sr); err == nil {
+					execResult.Content = string(b)
+				}
+			}
+		}
+		if skipped := removedTooLarge(te.workspace, tooLarge); len(skipped) > 0 {
+			te.bridge.SendChat("system", fmt.Sprintf("Not trashed (too large to keep a copy): %s", strings.Join(skipped, ", ")))
+			var sr tool.ShellResult
+			if json.Unmarshal([]byte(execResult.Content), &sr) == nil {
+				sr.NotTrashed = skipped
+				if b, err := json.Marshal(sr); err == nil {
+					execResult.Content = string(b)
+				}
+			}
+		}
+	}
+
 	// If the tool was file-related, hint UI to open the file
 	te.notifyUIForFileTools(toolCall)
 
@@ -72,12 +370,24 @@ func (te *ToolExecutor) handleToolResult(
 		return te.handleUserChoiceTool(toolCall, convo)
 	}
 
+	// ask_user pauses the run until the user answers
+	if !execResult.Safe && toolCall.Name == "ask_user" {
+		return te.handleAskUser(ctx, toolCall, convo)
+	}
+
 	if !execResult.Safe {
 		// Regular approval path for other tools
 		return te.handleUnsafeTool(ctx, toolCall, execResult, convo)
 	}
 
 	// Safe tool: add to conversation and show in UI
+	if te.editChunking != nil && toolCall.Name == "apply_edit" {
+		if excerpt := te.editChunking.verificationExcerpt(ctx, te.tools, toolCall.Args); excerpt != "" {
+			convo.AddToolResult(toolCall.Name, toolCall.ID, execResult.Content+"\n\n"+excerpt)
+			te.bridge.SendChat("tool", execResult.Content)
+			return nil
+		}
+	}
 	convo.AddToolResult(toolCall.Name, toolCall.ID, execResult.Content)
 	// Send tool result to UI for immediate display
 	if strings.TrimSpace(execResult.Content) != "" {
@@ -86,6 +396,45 @@ func (te *ToolExecutor) handleToolResult(
 	return nil
 }
 
+// handleReproduce runs a reproduction command and reports whether it failed or passed.
+func (te *ToolExecutor) handleReproduce(ctx context.Context, toolCall *tool.ToolCall, args tool.ReproduceArgs, convo *memory.Conversation) error {
+	te.bridge.SendChat("system", "Running reproduction...")
+	result := te.repro.handle(ctx, args)
+	convo.AddToolResult(toolCall.Name, toolCall.ID, result)
+	te.bridge.SendChat("tool", result)
+	return nil
+}
+
+// handleFinalize verifies the reproduction and the definition of done before
+// accepting a finalize call. A rejection is returned to the model as a structured
+// tool result.
+func (te *ToolExecutor) handleFinalize(ctx context.Context, toolCall *tool.ToolCall, execResult *tool.ExecutionResult, convo *memory.Conversation) error {
+	if check, ok := te.repro.finalizeCheck(ctx); ok && !check.Passed {
+		if te.repro.reject() {
+			convo.AddToolResult(toolCall.Name, toolCall.ID, reproRejection(check).rejectionMessage())
+			te.bridge.SendChat("system", "Finalization rejected; the reproduction does not pass yet.")
+			return nil
+		}
+		te.bridge.SendChat("system", "Reproduction still failing; accepting after repeated attempts.")
+	}
+	if te.done != nil {
+		te.bridge.SendChat("system", "Checking definition of done...")
+		report := te.done.evaluate(ctx)
+		if !report.Accepted {
+			convo.AddToolResult(toolCall.Name, toolCall.ID, report.rejectionMessage())
+			te.bridge.SendChat("system", "Finalization rejected; failing checks: "+strings.Join(report.failed(), ", "))
+			return nil
+		}
+		if failed := report.failed(); len(failed) > 0 {
+			te.bridge.SendChat("system", "Definition of done still failing ("+strings.Join(failed, ", ")+"); accepting after repeated attempts.")
+		}
+	}
+	convo.AddToolResult(toolCall.Name, toolCall.ID, "Finalization accepted.")
+	summary := execResult.Content
+	te.finalSummary = &summary
+	return nil
+}
+
 // handleUserChoiceTool handles the special case of user_choice tools.
 func (te *ToolExecutor) handleUserChoiceTool(toolCall *tool.ToolCall, convo *memory.Conversation) error {
 	// Parse the tool args to extract question and options
@@ -113,6 +462,23 @@ func (te *ToolExecutor) handleUserChoiceTool(toolCall *tool.ToolCall, convo *mem
 	return nil
 }
 
+// handleAskUser waits for the answer to an ask_user question and returns it to the
+// model as a structured tool result.
+func (te *ToolExecutor) handleAskUser(ctx context.Context, toolCall *tool.ToolCall, convo *memory.Conversation) error {
+	var args tool.AskUserArgs
+	if err := json.Unmarshal(toolCall.Args, &args); err != nil {
+		convo.AddToolResult(toolCall.Name, toolCall.ID, "Error parsing question arguments")
+		return nil
+	}
+	answer := te.approvalHandler.AskUser(ctx, toolCall, args)
+	b, _ := json.Marshal(answer)
+	convo.AddToolResult(toolCall.Name, toolCall.ID, string(b))
+	if answer.Skipped {
+		te.bridge.SendChat("system", "Question skipped.")
+	}
+	return nil
+}
+
 // handleUnsafeTool handles tools that require approval.
 func (te *ToolExecutor) handleUnsafeTool(
 	ctx context.Context,
@@ -122,6 +488,11 @@ func (te *ToolExecutor) handleUnsafeTool(
 ) error {
 	approved := te.approvalHandler.UserApproved(toolCall, execResult.Diff)
 	// Workflow functionality removed
+	// Another instance may have taken the workspace over while approval was pending
+	if reason := te.tools.ReadOnlyReason(); approved && reason != "" {
+		te.bridge.SendChat("system", fmt.Sprintf("%s was not applied: the workspace is read-only (%s).", toolCall.Name, reason))
+		approved = false
+	}
 
 	payload := map[string]any{
 		"tool":     toolCall.Name,
@@ -129,22 +500,183 @@ func (te *ToolExecutor) handleUnsafeTool(
 		"diff":     execResult.Diff,
 		"message":  execResult.Content,
 	}
+
+	// Approved changes wait for the writes of other conversations in the workspace
+	release, err := te.edits.acquire(ctx, approved)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	// If edits are auto-approved (by toggle or policy) and this was an edit proposal, immediately apply it
+	if approved && toolCall.Name == "edit_file" {
+		if auto, _ := te.approvalHandler.autoApproves(toolCall); auto {
+			err = te.autoApplyEdit(ctx, toolCall)
+			te.done.markDirty()
+			// With chunked edits, show the applied chunk so the model can verify it before the next
+			if te.editChunking != nil {
+				if excerpt := te.editChunking.verificationExcerpt(ctx, te.tools, toolCall.Args); excerpt != "" {
+					payload["verification"] = excerpt
+				}
+			}
+		}
+	}
+
+	// An approved terraform_apply runs the exact plan the user reviewed
+	if approved && toolCall.Name == "terraform_apply" {
+		payload["result"] = te.applyTerraformPlan(ctx, toolCall)
+		te.done.markDirty()
+	}
+
+	// An approved execute_snippet runs the exact code the user reviewed
+	if approved && toolCall.Name == "execute_snippet" {
+		payload["result"] = te.runApprovedSnippet(ctx, toolCall)
+	}
+
+	// An approved import_url downloads the reviewed URL into the reviewed destination
+	if approved && toolCall.Name == "import_url" {
+		payload["result"] = te.applyURLImport(ctx, toolCall)
+		te.done.markDirty()
+	}
+
+	// An approved delete_file moves the reviewed file to the workspace trash
+	if approved && toolCall.Name == "delete_file" {
+		payload["result"] = te.applyDelete(toolCall)
+		te.done.markDirty()
+	}
+
+	// An approved generate_mock creates the exact file the user reviewed
+	if approved && toolCall.Name == "generate_mock" {
+		payload["result"], err = te.applyGeneratedMock(ctx, toolCall)
+		te.done.markDirty()
+	}
+
+	// An approved run_macro edits exactly the files and lines the user reviewed
+	if approved && toolCall.Name == "run_macro" {
+		payload["result"] = te.applyMacroRun(ctx, toolCall)
+		te.done.markDirty()
+	}
+
 	b, _ := json.Marshal(payload)
 	convo.AddToolResult(toolCall.Name, toolCall.ID, string(b))
+	return err
+}
 
-	// If edits are auto-approved and this was an edit proposal, immediately apply it
-	_, autoApproveEdits := te.approvalHandler.IsAutoApproveEnabled()
-	if approved && autoApproveEdits && toolCall.Name == "edit_file" {
-		return te.autoApplyEdit(ctx, toolCall)
+// applyDelete trashes the target of an approved delete_file call and returns the
+// result for the tool payload.
+func (te *ToolExecutor) applyDelete(toolCall *tool.ToolCall) any {
+	var args tool.DeleteFileArgs
+	_ = json.Unmarshal(toolCall.Args, &args)
+	entry, err := tool.ApplyDelete(te.workspace, args)
+	auditDelete(te.audit, toolCall, entry, err)
+	if err != nil {
+		te.bridge.SendChat("system", fmt.Sprintf("delete_file failed: %v", err))
+		return map[string]any{"error": err.Error()}
 	}
+	te.bridge.SendChat("system", fmt.Sprintf("Moved %s to the trash; restore it from Settings → Trash", entry.Path))
+	return entry
+}
 
-	return nil
+// applyGeneratedMock creates an approved mock file through apply_edit, so it is
+// recorded in the timeline like any other edit, and returns the result for the payload.
+func (te *ToolExecutor) applyGeneratedMock(ctx context.Context, toolCall *tool.ToolCall) (any, error) {
+	m, ok := tool.TakeGeneratedMock(toolCall.Args)
+	if !ok {
+		te.bridge.SendChat("system", "generate_mock failed: the proposal expired; run generate_mock again")
+		return map[string]any{"error": "no pending mock for this call; run generate_mock again"}, nil
+	}
+	args, _ := json.Marshal(tool.EditFileArgs{Path: m.Path, Action: "CREATE", Content: m.Content})
+	if err := te.autoApplyEdit(ctx, &tool.ToolCall{ID: toolCall.ID, Name: toolCall.Name, Args: args}); err != nil {
+		return map[string]any{"error": err.Error()}, err
+	}
+	return map[string]any{"path": m.Path, "style": m.Style, "interface": m.Interface}, nil
+}
+
+// applyMacroRun applies an approved macro run file by file through apply_edit, so each
+// file is recorded in the timeline, and returns the result for the payload. Files that
+// changed since the proposal are left alone.
+func (te *ToolExecutor) applyMacroRun(ctx context.Context, toolCall *tool.ToolCall) any {
+	run, ok := tool.TakeMacroRun(toolCall.Args)
+	if !ok {
+		te.bridge.SendChat("system", "run_macro failed: the proposal expired; run run_macro again")
+		return map[string]any{"error": "no pending macro run for this call; run run_macro again"}
+	}
+	var applied, failed []string
+	for i, f := range run.Files {
+		args, _ := json.Marshal(tool.EditFileArgs{Path: f.Path, Action: "SEARCH_REPLACE", OldString: f.Old, NewString: f.New})
+		applyCall := &tool.ToolCall{ID: fmt.Sprintf("%s:apply:%d", toolCall.ID, i), Name: "apply_edit", Args: args}
+		current, _, err := editor.ReadText(filepath.Join(te.workspace, filepath.FromSlash(f.Path)))
+		if err == nil && current != f.Old {
+			err = errors.New("changed since the proposal")
+		}
+		if err == nil {
+			var res *tool.ExecutionResult
+			if res, err = te.applyEditCall(ctx, applyCall); err == nil && strings.HasPrefix(res.Content, "Error: ") {
+				err = errors.New(strings.TrimPrefix(res.Content, "Error: "))
+			}
+		}
+		if err != nil {
+			failed = append(failed, fmt.Sprintf("%s: %v", f.Path, err))
+			continue
+		}
+		applied = append(applied, f.Path)
+	}
+	msg := fmt.Sprintf("Macro %s edited %d files", run.Macro, len(applied))
+	if len(failed) > 0 {
+		msg += fmt.Sprintf("; %d not applied: %s", len(failed), strings.Join(failed, "; "))
+	}
+	te.bridge.SendChat("system", msg)
+	return map[string]any{"macro": run.Macro, "applied": applied, "failed": failed, "skipped": run.Skipped}
+}
+
+// applyTerraformPlan applies an approved plan and returns the result for the tool payload.
+func (te *ToolExecutor) applyTerraformPlan(ctx context.Context, toolCall *tool.ToolCall) any {
+	var args tool.TerraformApplyArgs
+	_ = json.Unmarshal(toolCall.Args, &args)
+	res, err := tool.ApplyTerraformPlan(ctx, args.PlanID)
+	auditTerraformApply(te.audit, toolCall, res, err)
+	if err != nil {
+		te.bridge.SendChat("system", fmt.Sprintf("terraform apply failed: %v", err))
+		return map[string]any{"error": err.Error()}
+	}
+	if res.ExitCode == 0 {
+		te.bridge.SendChat("system", fmt.Sprintf("Applied plan %s in %s", res.PlanID, res.Module))
+	} else {
+		te.bridge.SendChat("system", fmt.Sprintf("terraform apply for plan %s exited with code %d", res.PlanID, res.ExitCode))
+	}
+	return res
+}
+
+// runApprovedSnippet runs an approved snippet and returns the result for the tool payload.
+func (te *ToolExecutor) runApprovedSnippet(ctx context.Context, toolCall *tool.ToolCall) any {
+	var args tool.ExecuteSnippetArgs
+	_ = json.Unmarshal(toolCall.Args, &args)
+	res, err := tool.RunSnippet(ctx, args)
+	if err != nil {
+		te.bridge.SendChat("system", fmt.Sprintf("execute_snippet failed: %v", err))
+		return map[string]any{"error": err.Error()}
+	}
+	return res
+}
+
+// applyURLImport downloads and writes an approved import and returns the result for the tool payload.
+func (te *ToolExecutor) applyURLImport(ctx context.Context, toolCall *tool.ToolCall) any {
+	var args tool.ImportURLArgs
+	_ = json.Unmarshal(toolCall.Args, &args)
+	res, err := tool.ApplyURLImport(ctx, te.workspace, args)
+	auditURLImport(te.audit, toolCall, res, err)
+	if err != nil {
+		te.bridge.SendChat("system", fmt.Sprintf("import_url failed: %v", err))
+		return map[string]any{"error": err.Error()}
+	}
+	te.bridge.SendChat("system", fmt.Sprintf("Imported %s into %s", res.URL, res.Path))
+	return res
 }
 
 // autoApplyEdit automatically applies an edit if auto-approval is enabled.
 func (te *ToolExecutor) autoApplyEdit(ctx context.Context, toolCall *tool.ToolCall) error {
 	applyCall := &tool.ToolCall{ID: toolCall.ID + ":apply", Name: "apply_edit", Args: toolCall.Args}
-	applyResult, applyErr := te.tools.InvokeToolCall(ctx, applyCall)
+	applyResult, applyErr := te.applyEditCall(ctx, applyCall)
 	if applyErr != nil {
 		errorMsg := fmt.Sprintf("Error executing tool %s: %v", applyCall.Name, applyErr)
 		te.bridge.SendChat("system", errorMsg)
@@ -162,6 +694,28 @@ func (te *ToolExecutor) autoApplyEdit(ctx context.Context, toolCall *tool.ToolCa
 	return nil
 }
 
+// applyEditCall runs an apply_edit call and records it in the audit log, snapshots and
+// timeline like an edit the model applied itself.
+func (te *ToolExecutor) applyEditCall(ctx context.Context, applyCall *tool.ToolCall) (*tool.ExecutionResult, error) {
+	pending := te.timeline.capture(applyCall)
+	applyResult, err := te.tools.InvokeToolCall(ctx, applyCall)
+	auditSideEffect(te.audit, te.workspace, applyCall, applyResult)
+	if err != nil {
+		return nil, err
+	}
+	te.snapshots.record(applyCall)
+	te.commitChange(pending)
+	te.feed.observe(pending)
+	return applyResult, nil
+}
+
+// commitChange records an applied change in the timeline and the edit journal.
+func (te *ToolExecutor) commitChange(pending *pendingChange) {
+	if cp, ok := te.timeline.commit(pending); ok {
+		te.journal.record(cp)
+	}
+}
+
 // notifyUIForFileTools opens relevant files in the UI for file-related tools.
 func (te *ToolExecutor) notifyUIForFileTools(toolCall *tool.ToolCall) {
 	if te.bridge == nil {
@@ -0,0 +1,192 @@
+package engine
+
+import (
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	// previewInterval throttles preview updates while arguments stream in
+	previewInterval = 150 * time.Millisecond
+	// previewLines caps how many lines of proposed content a preview carries
+	previewLines = 12
+)
+
+// ToolArgsDelta is a fragment of a tool call's JSON arguments, streamed by providers
+// that send arguments incrementally. ID and Name may be empty on all but the first
+// fragment of a call; Index tells parallel calls apart when they are.
+type ToolArgsDelta struct {
+	ID    string
+	Name  string
+	Index int
+	Delta string
+}
+
+// ToolPreview is what is known about a tool call while the model is still generating
+// its arguments. Done marks the last update, sent once the call is complete or the
+// stream ended without it.
+type ToolPreview struct {
+	ID     string   `json:"id"`
+	Name   string   `json:"name"`
+	Path   string   `json:"path,omitempty"`
+	Action string   `json:"action,omitempty"`
+	Lines  []string `json:"lines,omitempty"`
+	Bytes  int      `json:"bytes"`
+	Done   bool     `json:"done"`
+}
+
+// partialToolCall accumulates the streamed arguments of one tool call.
+type partialToolCall struct {
+	id, name string
+	args     strings.Builder
+	sent     ToolPreview
+	sentAt   time.Time
+}
+
+// toolPreviews turns argument deltas into throttled previews for the UI.
+type toolPreviews struct {
+	calls map[int]*partialToolCall
+	now   func() time.Time
+}
+
+// add records a delta and returns the preview to emit, if one is due.
+func (tp *toolPreviews) add(d *ToolArgsDelta) (ToolPreview, bool) {
+	if tp.calls == nil {
+		tp.calls = make(map[int]*partialToolCall)
+	}
+	if tp.now == nil {
+		tp.now = time.Now
+	}
+	pc := tp.calls[d.Index]
+	if pc == nil {
+		pc = &partialToolCall{}
+		tp.calls[d.Index] = pc
+	}
+	if d.ID != "" {
+		pc.id = d.ID
+	}
+	if d.Name != "" {
+		pc.name = d.Name
+	}
+	pc.args.WriteString(d.Delta)
+	if pc.name == "" {
+		return ToolPreview{}, false
+	}
+
+	p := buildToolPreview(pc.id, pc.name, pc.args.String())
+	// Emit immediately when the target becomes known; content updates are throttled
+	changed := p.Path != pc.sent.Path || p.Action != pc.sent.Action || pc.sentAt.IsZero()
+	if !changed && tp.now().Sub(pc.sentAt) < previewInterval {
+		return ToolPreview{}, false
+	}
+	pc.sent, pc.sentAt = p, tp.now()
+	return p, true
+}
+
+// finish ends all previews in flight, returning their final Done updates.
+func (tp *toolPreviews) finish() []ToolPreview {
+	var out []ToolPreview
+	for _, pc := range tp.calls {
+		if pc.name == "" {
+			continue
+		}
+		out = append(out, ToolPreview{ID: pc.id, Name: pc.name, Path: pc.sent.Path, Action: pc.sent.Action, Bytes: pc.args.Len(), Done: true})
+	}
+	clear(tp.calls)
+	return out
+}
+
+// buildToolPreview extracts the target and the first lines of proposed content from
+// the incomplete JSON arguments of a tool call.
+func buildToolPreview(id, name, args string) ToolPreview {
+	p := ToolPreview{ID: id, Name: name, Bytes: len(args)}
+	if v, ok := partialJSONString(args, "path"); ok {
+		p.Path = v
+	}
+	if v, ok := partialJSONString(args, "action"); ok {
+		p.Action = v
+	}
+	for _, key := range []string{"content", "new_string", "replacement", "command"} {
+		if v, ok := partialJSONString(args, key); ok {
+			lines := strings.Split(v, "\n")
+			if len(lines) > previewLines {
+				lines = lines[:previewLines]
+			}
+			p.Lines = lines
+			break
+		}
+	}
+	return p
+}
+
+// partialJSONString returns the (possibly still incomplete) string value of key in a
+// JSON object prefix. ok is false until the value's opening quote has arrived.
+func partialJSONString(args, key string) (string, bool) {
+	needle := `"` + key + `"`
+	for from := 0; ; {
+		i := strings.Index(args[from:], needle)
+		if i < 0 {
+			return "", false
+		}
+		rest := strings.TrimLeft(args[from+i+len(needle):], " \t\r\n")
+		from += i + len(needle)
+		// A key is followed by a colon; the same text inside a value is not
+		if !strings.HasPrefix(rest, ":") {
+			continue
+		}
+		rest = strings.TrimLeft(rest[1:], " \t\r\n")
+		if !strings.HasPrefix(rest, `"`) {
+			return "", false
+		}
+		return decodePartialJSONString(rest[1:]), true
+	}
+}
+
+// decodePartialJSONString unescapes a JSON string body up to its closing quote or the
+// end of input, dropping an escape sequence that was cut off.
+func decodePartialJSONString(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == '"':
+			return b.String()
+		case c != '\\':
+			r, size := utf8.DecodeRuneInString(s[i:])
+			b.WriteRune(r)
+			i += size
+			continue
+		case i+1 >= len(s):
+			return b.String()
+		}
+		switch esc := s[i+1]; esc {
+		case 'n':
+			b.WriteByte('\n')
+		case 't':
+			b.WriteByte('\t')
+		case 'r':
+			b.WriteByte('\r')
+		case 'b':
+			b.WriteByte('\b')
+		case 'f':
+			b.WriteByte('\f')
+		case 'u':
+			if i+6 > len(s) {
+				return b.String()
+			}
+			n, err := strconv.ParseUint(s[i+2:i+6], 16, 16)
+			if err != nil {
+				return b.String()
+			}
+			b.WriteRune(rune(n))
+			i += 6
+			continue
+		default: // \" \\ \/
+			b.WriteByte(esc)
+		}
+		i += 2
+	}
+	return b.String()
+}
@@ -0,0 +1,77 @@
+package engine
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestPartialJSONString(t *testing.T) {
+	tests := []struct {
+		args, key string
+		want      string
+		ok        bool
+	}{
+		{`{"path": "main.go", "action": "rep`, "path", "main.go", true},
+		{`{"path": "main.go", "action": "rep`, "action", "rep", true},
+		{`{"path": "ma`, "path", "ma", true},
+		{`{"path":`, "path", "", false},
+		{`{"content": "a\nb\t\"c\"é`, "content", "a\nb\t\"c\"é", true},
+		{`{"content": "cut \`, "content", "cut ", true},
+		{`{"content": "x \u00`, "content", "x ", true},
+		// The key's text inside another value is not the key
+		{`{"content": "\"path\" is", "path": "a.go"}`, "path", "a.go", true},
+		{`{"line": 4}`, "line", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := partialJSONString(tt.args, tt.key)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("partialJSONString(%q, %q) = %q, %v; want %q, %v", tt.args, tt.key, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestToolPreviews(t *testing.T) {
+	now := time.Unix(0, 0)
+	tp := &toolPreviews{now: func() time.Time { return now }}
+
+	if _, ok := tp.add(&ToolArgsDelta{Index: 0, Delta: `{"pa`}); ok {
+		t.Fatal("expected no preview before the tool name is known")
+	}
+	p, ok := tp.add(&ToolArgsDelta{ID: "call_1", Name: "edit_file", Index: 0, Delta: `th": "a.go", "content": "one\ntwo`})
+	if !ok || p.Path != "a.go" || p.Name != "edit_file" || !reflect.DeepEqual(p.Lines, []string{"one", "two"}) {
+		t.Fatalf("unexpected first preview: %+v, %v", p, ok)
+	}
+
+	// Content updates are throttled until the interval passes
+	if _, ok := tp.add(&ToolArgsDelta{Index: 0, Delta: `\nthree`}); ok {
+		t.Fatal("expected the content update to be throttled")
+	}
+	now = now.Add(previewInterval)
+	if p, ok = tp.add(&ToolArgsDelta{Index: 0, Delta: `\nfour`}); !ok || len(p.Lines) != 4 {
+		t.Fatalf("expected a throttled update with 4 lines, got %+v, %v", p, ok)
+	}
+
+	done := tp.finish()
+	if len(done) != 1 || !done[0].Done || done[0].ID != "call_1" || done[0].Path != "a.go" {
+		t.Fatalf("unexpected finish: %+v", done)
+	}
+	if len(tp.finish()) != 0 {
+		t.Fatal("expected finish to clear the previews")
+	}
+}
+
+func TestBuildToolPreview_CapsLines(t *testing.T) {
+	args := `{"path": "a.txt", "content": "` + repeatLines(previewLines+5) + `"}`
+	if p := buildToolPreview("id", "create_file", args); len(p.Lines) != previewLines {
+		t.Fatalf("expected %d lines, got %d", previewLines, len(p.Lines))
+	}
+}
+
+func repeatLines(n int) string {
+	s := ""
+	for i := 0; i < n; i++ {
+		s += `line\n`
+	}
+	return s
+}
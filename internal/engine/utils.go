@@ -44,8 +44,9 @@ func convertSchemas(schemas []tool.Schema) []ToolSchema {
 // loadUserMemoriesForPrompt reads ~/.loom/memories.json and returns entries for prompt injection.
 func loadUserMemoriesForPrompt() []MemoryEntry {
 	type mem struct {
-		ID   string `json:"id"`
-		Text string `json:"text"`
+		ID   string   `json:"id"`
+		Text string   `json:"text"`
+		Tags []string `json:"tags"`
 	}
 	home, err := os.UserHomeDir()
 	if err != nil {
@@ -60,7 +61,7 @@ func loadUserMemoriesForPrompt() []MemoryEntry {
 	if json.Unmarshal(data, &list) == nil {
 		out := make([]MemoryEntry, 0, len(list))
 		for _, it := range list {
-			out = append(out, MemoryEntry{ID: strings.TrimSpace(it.ID), Text: strings.TrimSpace(it.Text)})
+			out = append(out, MemoryEntry{ID: strings.TrimSpace(it.ID), Text: strings.TrimSpace(it.Text), Tags: it.Tags})
 		}
 		return out
 	}
@@ -70,7 +71,7 @@ func loadUserMemoriesForPrompt() []MemoryEntry {
 	if json.Unmarshal(data, &wrapper) == nil && wrapper.Memories != nil {
 		out := make([]MemoryEntry, 0, len(wrapper.Memories))
 		for _, it := range wrapper.Memories {
-			out = append(out, MemoryEntry{ID: strings.TrimSpace(it.ID), Text: strings.TrimSpace(it.Text)})
+			out = append(out, MemoryEntry{ID: strings.TrimSpace(it.ID), Text: strings.TrimSpace(it.Text), Tags: it.Tags})
 		}
 		return out
 	}
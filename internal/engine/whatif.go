@@ -0,0 +1,155 @@
+package engine
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/loom/loom/internal/overlay"
+	"github.com/loom/loom/internal/tool"
+)
+
+// whatIfPrompt tells the model its changes are confined to the overlay.
+const whatIfPrompt = "\n\nWhat-if mode is active: edits and shell commands apply to a private copy of the workspace, " +
+	"so risky refactors can be explored freely. Build and test here to validate them. The user decides afterwards " +
+	"whether to apply the changes to the real workspace or discard them. Git history is not available in this copy."
+
+// whatIfState is the overlay of one conversation together with the tools bound to it.
+type whatIfState struct {
+	conversationID string
+	overlay        *overlay.Overlay
+	tools          *tool.Registry
+}
+
+// whatIfRegistry holds the loaded overlay and the factory for its tool registries.
+type whatIfRegistry struct {
+	mu    sync.Mutex
+	state *whatIfState
+	// factory builds the tools for an overlay root; nil registers the core tools only
+	factory func(root string) *tool.Registry
+}
+
+// SetOverlayRegistryFactory sets how tool registries for what-if overlays are built,
+// so they carry the same limits and extra tools as the workspace registry.
+func (e *Engine) SetOverlayRegistryFactory(factory func(root string) *tool.Registry) {
+	e.whatIf.mu.Lock()
+	defer e.whatIf.mu.Unlock()
+	e.whatIf.factory = factory
+	e.whatIf.state = nil
+}
+
+// whatIfFor returns the current conversation's overlay, loading it from disk when the
+// conversation changed. It returns nil when what-if mode is off.
+func (e *Engine) whatIfFor() *whatIfState {
+	if e.memory == nil {
+		return nil
+	}
+	id := e.memory.CurrentConversationID()
+	if id == "" {
+		return nil
+	}
+	e.whatIf.mu.Lock()
+	defer e.whatIf.mu.Unlock()
+	if s := e.whatIf.state; s != nil && s.conversationID == id {
+		return s
+	}
+	e.whatIf.state = nil
+	ov, err := overlay.Load(e.memory.OverlayDir(id))
+	if err != nil {
+		return nil
+	}
+	e.whatIf.state = e.newWhatIfState(id, ov)
+	return e.whatIf.state
+}
+
+// newWhatIfState binds tools to an overlay. The caller holds e.whatIf.mu.
+func (e *Engine) newWhatIfState(id string, ov *overlay.Overlay) *whatIfState {
+	var reg *tool.Registry
+	if e.whatIf.factory != nil {
+		reg = e.whatIf.factory(ov.Root())
+	} else {
+		reg = tool.NewRegistry()
+		if e.bridge != nil {
+			reg.WithUI(e.bridge)
+		}
+		tool.RegisterCoreTools(reg, ov.Root())
+	}
+	return &whatIfState{conversationID: id, overlay: ov, tools: reg}
+}
+
+// runTools returns the registry and root directory tool calls of the current
+// conversation operate on: the overlay in what-if mode, otherwise the workspace.
+func (e *Engine) runTools() (*tool.Registry, string) {
+	if s := e.whatIfFor(); s != nil {
+		return s.tools, s.overlay.Root()
+	}
+	return e.tools, e.Workspace()
+}
+
+// editRoot returns the directory the current conversation's edits apply to.
+func (e *Engine) editRoot() string {
+	_, root := e.runTools()
+	return root
+}
+
+// WhatIf returns the current conversation's overlay, or nil when what-if mode is off.
+func (e *Engine) WhatIf() *overlay.Overlay {
+	if s := e.whatIfFor(); s != nil {
+		return s.overlay
+	}
+	return nil
+}
+
+// EnableWhatIf copies the workspace into an overlay for the current conversation.
+// From the next request on, its edits, builds and tests run against the copy. An
+// existing overlay is kept.
+func (e *Engine) EnableWhatIf() (*overlay.Overlay, error) {
+	if s := e.whatIfFor(); s != nil {
+		return s.overlay, nil
+	}
+	if e.memory == nil {
+		return nil, errors.New("memory not initialized")
+	}
+	id := e.memory.CurrentConversationID()
+	if id == "" {
+		return nil, errors.New("no active conversation")
+	}
+	ov, err := overlay.Create(e.Workspace(), e.memory.OverlayDir(id), id)
+	if err != nil {
+		return nil, err
+	}
+	e.whatIf.mu.Lock()
+	e.whatIf.state = e.newWhatIfState(id, ov)
+	e.whatIf.mu.Unlock()
+	return ov, nil
+}
+
+// WhatIfChanges lists the files the current conversation changed in its overlay.
+func (e *Engine) WhatIfChanges() ([]overlay.Change, error) {
+	s := e.whatIfFor()
+	if s == nil {
+		return nil, overlay.ErrNotFound
+	}
+	return s.overlay.Changes()
+}
+
+// MaterializeWhatIf applies overlay changes to the real workspace; with no paths all
+// of them. What-if mode stays on, so exploration can continue.
+func (e *Engine) MaterializeWhatIf(paths []string) (overlay.MaterializeResult, error) {
+	s := e.whatIfFor()
+	if s == nil {
+		return overlay.MaterializeResult{}, overlay.ErrNotFound
+	}
+	return s.overlay.Materialize(paths)
+}
+
+// DiscardWhatIf deletes the current conversation's overlay and turns what-if mode off.
+func (e *Engine) DiscardWhatIf() error {
+	s := e.whatIfFor()
+	if s == nil {
+		return overlay.ErrNotFound
+	}
+	e.whatIf.mu.Lock()
+	e.whatIf.state = nil
+	e.whatIf.mu.Unlock()
+	return s.overlay.Discard()
+}
@@ -0,0 +1,75 @@
+package engine
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/loom/loom/internal/memory"
+)
+
+func TestWhatIf_EditsGoToOverlayUntilMaterialized(t *testing.T) {
+	ws := t.TempDir()
+	_ = os.WriteFile(filepath.Join(ws, "notes.txt"), []byte("original\n"), 0o644)
+	store, err := memory.NewStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	proj, err := memory.NewProject(store, ws)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := New(nil, nil).WithWorkspace(ws).WithMemory(proj)
+	if e.NewConversation() == "" {
+		t.Fatal("expected a conversation")
+	}
+	if e.WhatIf() != nil {
+		t.Fatal("what-if mode should be off by default")
+	}
+
+	ov, err := e.EnableWhatIf()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, root := e.runTools(); root != ov.Root() {
+		t.Fatalf("tools should run in the overlay, got %s", root)
+	}
+	if _, err := e.SaveCodeBlock("```txt\nrewritten\n```\n", 0, "notes.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(ws, "notes.txt")); string(data) != "original\n" {
+		t.Fatalf("workspace changed in what-if mode: %q", data)
+	}
+	changes, err := e.WhatIfChanges()
+	if err != nil || len(changes) != 1 || changes[0].Path != "notes.txt" {
+		t.Fatalf("unexpected changes: %v %+v", err, changes)
+	}
+
+	// Another conversation works on the real workspace
+	first := proj.CurrentConversationID()
+	if err := e.SetCurrentConversationID("other"); err != nil {
+		t.Fatal(err)
+	}
+	if e.WhatIf() != nil {
+		t.Fatal("what-if mode is scoped to its conversation")
+	}
+	if err := e.SetCurrentConversationID(first); err != nil {
+		t.Fatal(err)
+	}
+
+	if res, err := e.MaterializeWhatIf(nil); err != nil || len(res.Applied) != 1 {
+		t.Fatalf("unexpected materialize result: %v %+v", err, res)
+	}
+	if data, _ := os.ReadFile(filepath.Join(ws, "notes.txt")); string(data) != "rewritten\n" {
+		t.Fatalf("change not applied: %q", data)
+	}
+	if err := e.DiscardWhatIf(); err != nil {
+		t.Fatal(err)
+	}
+	if e.WhatIf() != nil {
+		t.Fatal("what-if mode should be off after discarding")
+	}
+	if _, root := e.runTools(); root != ws {
+		t.Fatalf("tools should run in the workspace again, got %s", root)
+	}
+}
@@ -0,0 +1,90 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// WorkspaceRoot is a directory tools can run in. The primary root is the workspace;
+// further roots, e.g. the backend next to a frontend, are selected by the workspace
+// argument of tool calls.
+type WorkspaceRoot struct {
+	Name    string `json:"name"`
+	Path    string `json:"path"`
+	Primary bool   `json:"primary,omitempty"`
+}
+
+// WorkspaceRoots returns the open roots, the primary first.
+func (e *Engine) WorkspaceRoots() []WorkspaceRoot {
+	// Forks work in their parent's roots
+	if e.parent != nil {
+		return e.parent.WorkspaceRoots()
+	}
+	ws := e.Workspace()
+	if ws == "" {
+		return nil
+	}
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	roots := make([]WorkspaceRoot, 0, len(e.roots)+1)
+	roots = append(roots, WorkspaceRoot{Name: filepath.Base(ws), Path: ws, Primary: true})
+	return append(roots, e.roots...)
+}
+
+// AddWorkspaceRoot opens another directory next to the workspace. Its name is the
+// directory's base name, made unique among the open roots.
+func (e *Engine) AddWorkspaceRoot(path string) (WorkspaceRoot, error) {
+	ws := e.Workspace()
+	if ws == "" {
+		return WorkspaceRoot{}, errors.New("no workspace is open")
+	}
+	path = filepath.Clean(strings.TrimSpace(path))
+	if !filepath.IsAbs(path) {
+		return WorkspaceRoot{}, fmt.Errorf("%s is not an absolute path", path)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return WorkspaceRoot{}, err
+	}
+	if !info.IsDir() {
+		return WorkspaceRoot{}, fmt.Errorf("%s is not a directory", path)
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	taken := map[string]bool{filepath.Base(ws): true}
+	for _, r := range append([]WorkspaceRoot{{Path: ws}}, e.roots...) {
+		if r.Path == path {
+			return WorkspaceRoot{}, fmt.Errorf("%s is already open", path)
+		}
+		taken[r.Name] = true
+	}
+	base := filepath.Base(path)
+	name := base
+	for i := 2; taken[name]; i++ {
+		name = fmt.Sprintf("%s-%d", base, i)
+	}
+	root := WorkspaceRoot{Name: name, Path: path}
+	e.roots = append(e.roots, root)
+	return root, nil
+}
+
+// RemoveWorkspaceRoot closes a root opened with AddWorkspaceRoot. The primary root
+// cannot be removed.
+func (e *Engine) RemoveWorkspaceRoot(name string) (WorkspaceRoot, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for i, r := range e.roots {
+		if r.Name == name {
+			e.roots = append(e.roots[:i:i], e.roots[i+1:]...)
+			return r, nil
+		}
+	}
+	if name == filepath.Base(e.workspaceDir) {
+		return WorkspaceRoot{}, errors.New("the primary workspace cannot be removed")
+	}
+	return WorkspaceRoot{}, fmt.Errorf("no workspace root named %q", name)
+}
@@ -0,0 +1,57 @@
+package engine
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWorkspaceRoots_AddAndRemove(t *testing.T) {
+	base := t.TempDir()
+	ws := filepath.Join(base, "app")
+	other := filepath.Join(base, "api")
+	clash := filepath.Join(base, "nested", "api")
+	for _, d := range []string{ws, other, clash} {
+		if err := os.MkdirAll(d, 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	e := New(nil, nil).WithWorkspace(ws)
+
+	if _, err := e.AddWorkspaceRoot(ws); err == nil {
+		t.Fatal("the primary root must not be added again")
+	}
+	if _, err := e.AddWorkspaceRoot("relative"); err == nil {
+		t.Fatal("expected relative paths to be refused")
+	}
+	if r, err := e.AddWorkspaceRoot(other); err != nil || r.Name != "api" {
+		t.Fatalf("add api: %+v %v", r, err)
+	}
+	if r, err := e.AddWorkspaceRoot(clash); err != nil || r.Name != "api-2" {
+		t.Fatalf("add a second api: %+v %v", r, err)
+	}
+	roots := e.WorkspaceRoots()
+	if len(roots) != 3 || !roots[0].Primary || roots[0].Name != "app" {
+		t.Fatalf("unexpected roots %+v", roots)
+	}
+	prompt := GenerateSystemPromptUnified(SystemPromptOptions{WorkspaceRoots: roots})
+	if !strings.Contains(prompt, "- api-2: "+clash) || !strings.Contains(prompt, "(primary)") {
+		t.Fatalf("prompt misses the roots:\n%s", prompt)
+	}
+
+	if _, err := e.RemoveWorkspaceRoot("app"); err == nil {
+		t.Fatal("the primary root must not be removed")
+	}
+	if _, err := e.RemoveWorkspaceRoot("api"); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(e.WorkspaceRoots()); n != 2 {
+		t.Fatalf("expected 2 roots, got %d", n)
+	}
+	// Switching workspaces closes the further roots
+	e.WithWorkspace(other)
+	if n := len(e.WorkspaceRoots()); n != 1 {
+		t.Fatalf("expected only the new workspace, got %d roots", n)
+	}
+}
@@ -0,0 +1,206 @@
+package hygiene
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// formatterConfigs lists the root config files of formatters and linters that read
+// only one of them; more than one makes which settings apply a surprise.
+var formatterConfigs = []struct {
+	tool string
+	// packageKey is the package.json field the tool also reads its config from
+	packageKey string
+	files      []string
+}{
+	{"Prettier", "prettier", []string{".prettierrc", ".prettierrc.json", ".prettierrc.json5", ".prettierrc.yaml", ".prettierrc.yml", ".prettierrc.toml", ".prettierrc.js", ".prettierrc.cjs", ".prettierrc.mjs", "prettier.config.js", "prettier.config.cjs", "prettier.config.mjs"}},
+	{"ESLint", "eslintConfig", []string{".eslintrc", ".eslintrc.json", ".eslintrc.yaml", ".eslintrc.yml", ".eslintrc.js", ".eslintrc.cjs", "eslint.config.js", "eslint.config.cjs", "eslint.config.mjs", "eslint.config.ts"}},
+	{"Stylelint", "stylelint", []string{".stylelintrc", ".stylelintrc.json", ".stylelintrc.yaml", ".stylelintrc.yml", ".stylelintrc.js", "stylelint.config.js", "stylelint.config.cjs"}},
+	{"rustfmt", "", []string{"rustfmt.toml", ".rustfmt.toml"}},
+	{"clang-format", "", []string{".clang-format", "_clang-format"}},
+}
+
+// checkFormatting reports competing formatter configs, an .editorconfig that
+// contradicts Prettier, and a missing .editorconfig (with a fix that creates one).
+func checkFormatting(ws *workspace) []Finding {
+	var out []Finding
+	pkg := map[string]json.RawMessage{}
+	_ = json.Unmarshal([]byte(ws.read("package.json")), &pkg)
+
+	for _, group := range formatterConfigs {
+		var found []string
+		for _, f := range group.files {
+			if ws.has(f) {
+				found = append(found, f)
+			}
+		}
+		if _, ok := pkg[group.packageKey]; ok && group.packageKey != "" {
+			found = append(found, fmt.Sprintf("package.json (%q)", group.packageKey))
+		}
+		if len(found) > 1 {
+			out = append(out, Finding{
+				ID:         CheckFormatting + ":" + strings.ToLower(group.tool),
+				Check:      CheckFormatting,
+				Severity:   SeverityMedium,
+				Path:       found[0],
+				Message:    fmt.Sprintf("%s is configured in %d places (%s); it uses only one, so the others are silently ignored.", group.tool, len(found), strings.Join(found, ", ")),
+				Suggestion: "Merge the settings into one config file and delete the rest.",
+			})
+		}
+	}
+
+	prettier := prettierIndent(ws, pkg)
+	if ws.has(".editorconfig") {
+		ec := editorconfigIndent(ws.read(".editorconfig"))
+		if prettier.set && ec.set && (prettier.tabs != ec.tabs || (!prettier.tabs && prettier.size != 0 && ec.size != 0 && prettier.size != ec.size)) {
+			out = append(out, Finding{
+				ID:         CheckFormatting + ":editorconfig-prettier",
+				Check:      CheckFormatting,
+				Severity:   SeverityMedium,
+				Path:       ".editorconfig",
+				Message:    fmt.Sprintf(".editorconfig asks for %s but Prettier for %s, so editors and the formatter fight over indentation.", ec, prettier),
+				Suggestion: "Make indent_style and indent_size in .editorconfig match Prettier's useTabs and tabWidth.",
+			})
+		}
+	} else if len(ws.files) > 0 {
+		out = append(out, Finding{
+			ID:       CheckFormatting + ":editorconfig",
+			Check:    CheckFormatting,
+			Severity: SeverityLow,
+			Path:     ".editorconfig",
+			Message:  "No .editorconfig; editors fall back to their own indentation and line ending defaults.",
+			Fix: &Fix{
+				Description: "Create an .editorconfig for the languages in this workspace",
+				Path:        ".editorconfig",
+				Content:     editorconfigFor(ws, prettier),
+			},
+		})
+	}
+	return out
+}
+
+// indent is an indentation setting; set is false when nothing was configured.
+type indent struct {
+	set  bool
+	tabs bool
+	size int
+}
+
+func (i indent) String() string {
+	if i.tabs {
+		return "tabs"
+	}
+	if i.size > 0 {
+		return fmt.Sprintf("%d spaces", i.size)
+	}
+	return "spaces"
+}
+
+// prettierIndent reads useTabs and tabWidth from a JSON Prettier config.
+func prettierIndent(ws *workspace, pkg map[string]json.RawMessage) indent {
+	var cfg struct {
+		UseTabs  *bool `json:"useTabs"`
+		TabWidth *int  `json:"tabWidth"`
+	}
+	var raw []byte
+	for _, f := range []string{".prettierrc", ".prettierrc.json"} {
+		if ws.has(f) {
+			raw = []byte(ws.read(f))
+			break
+		}
+	}
+	if raw == nil {
+		raw = pkg["prettier"]
+	}
+	if raw == nil || json.Unmarshal(raw, &cfg) != nil {
+		return indent{}
+	}
+	var in indent
+	if cfg.UseTabs != nil {
+		in.set, in.tabs = true, *cfg.UseTabs
+	}
+	if cfg.TabWidth != nil {
+		in.set, in.size = true, *cfg.TabWidth
+	}
+	if in.set && !in.tabs && in.size == 0 {
+		// Prettier's default width
+		in.size = 2
+	}
+	return in
+}
+
+// editorconfigIndent reads indent_style and indent_size of the [*] section.
+func editorconfigIndent(content string) indent {
+	var in indent
+	section := ""
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			section = line
+			continue
+		}
+		if section != "[*]" {
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		switch strings.TrimSpace(key) {
+		case "indent_style":
+			in.set, in.tabs = true, strings.TrimSpace(value) == "tab"
+		case "indent_size":
+			if n, err := strconv.Atoi(strings.TrimSpace(value)); err == nil {
+				in.set, in.size = true, n
+			}
+		}
+	}
+	return in
+}
+
+// editorconfigFor builds an .editorconfig with the conventions of the languages found
+// in the workspace, taking web indentation from Prettier when it is configured.
+func editorconfigFor(ws *workspace, prettier indent) string {
+	exts := map[string]bool{}
+	names := map[string]bool{}
+	for _, f := range ws.files {
+		exts[path.Ext(f)] = true
+		names[path.Base(f)] = true
+	}
+	var b strings.Builder
+	b.WriteString("root = true\n\n[*]\ncharset = utf-8\nend_of_line = lf\ninsert_final_newline = true\ntrim_trailing_whitespace = true\n")
+
+	web := "indent_style = space\nindent_size = 2\n"
+	if prettier.tabs {
+		web = "indent_style = tab\n"
+	} else if prettier.size > 0 {
+		web = fmt.Sprintf("indent_style = space\nindent_size = %d\n", prettier.size)
+	}
+	sections := []struct {
+		glob string
+		exts []string
+		body string
+	}{
+		{"*.go", []string{".go"}, "indent_style = tab\n"},
+		{"*.py", []string{".py"}, "indent_style = space\nindent_size = 4\n"},
+		{"*.rs", []string{".rs"}, "indent_style = space\nindent_size = 4\n"},
+		{"*.{js,jsx,ts,tsx,mjs,cjs,json,css,scss,html,vue}", []string{".js", ".jsx", ".ts", ".tsx", ".mjs", ".cjs", ".json", ".css", ".scss", ".html", ".vue"}, web},
+		{"*.{yml,yaml}", []string{".yml", ".yaml"}, "indent_style = space\nindent_size = 2\n"},
+		{"*.md", []string{".md"}, "trim_trailing_whitespace = false\n"},
+	}
+	for _, s := range sections {
+		for _, e := range s.exts {
+			if exts[e] {
+				fmt.Fprintf(&b, "\n[%s]\n%s", s.glob, s.body)
+				break
+			}
+		}
+	}
+	if names["Makefile"] || names["makefile"] || exts[".mk"] {
+		b.WriteString("\n[{Makefile,makefile,*.mk}]\nindent_style = tab\n")
+	}
+	return b.String()
+}
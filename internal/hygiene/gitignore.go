@@ -0,0 +1,200 @@
+package hygiene
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+)
+
+// ignoreEntry is a .gitignore pattern the workspace needs, with why and the
+// directories whose contents call for it.
+type ignoreEntry struct {
+	pattern string
+	reason  string
+	dirs    []string
+}
+
+// expectedIgnores returns the ignore patterns the workspace's contents call for.
+func expectedIgnores(ws *workspace) []ignoreEntry {
+	byPattern := map[string]*ignoreEntry{}
+	want := func(pattern, reason, at string) {
+		e := byPattern[pattern]
+		if e == nil {
+			e = &ignoreEntry{pattern: pattern, reason: reason}
+			byPattern[pattern] = e
+		}
+		e.dirs = append(e.dirs, path.Dir(at))
+	}
+	for _, f := range ws.files {
+		switch base := path.Base(f); {
+		case base == ".env":
+			want(".env", "environment files hold credentials", f)
+		case strings.HasPrefix(base, ".env.") && strings.HasSuffix(base, ".local"):
+			want(".env*.local", "local environment files hold credentials", f)
+		case base == ".DS_Store":
+			want(".DS_Store", "macOS folder metadata", f)
+		case base == "package.json":
+			want("node_modules/", "installed npm packages", f)
+		case base == "Cargo.toml":
+			want("target/", "Rust build output", f)
+		case strings.HasSuffix(base, ".py"):
+			want("__pycache__/", "Python bytecode caches", f)
+		}
+	}
+	for dir := range ws.dirs {
+		if selfIgnored(ws, dir) {
+			continue
+		}
+		switch base := path.Base(dir); base {
+		case "node_modules":
+			want("node_modules/", "installed npm packages", dir)
+		case ".venv", "venv":
+			want(base+"/", "Python virtual environment", dir)
+		case "__pycache__":
+			want("__pycache__/", "Python bytecode caches", dir)
+		case ".idea":
+			want(".idea/", "IDE settings", dir)
+		}
+	}
+	// Loom's own state next to its committable config (rules, done checks, MCP servers)
+	if ws.dirs[".loom/backups"] && !selfIgnored(ws, ".loom/backups") {
+		want(".loom/backups/", "Loom's pre-edit backups", ".loom/backups")
+	}
+	if ws.dirs[".loom/runs"] && !selfIgnored(ws, ".loom/runs") {
+		want(".loom/runs/", "Loom's run summaries", ".loom/runs")
+	}
+	if ws.has(".loom/instance.lock") {
+		want(".loom/instance.lock", "Loom's workspace lock", ".loom/instance.lock")
+	}
+	out := make([]ignoreEntry, 0, len(byPattern))
+	for _, e := range byPattern {
+		out = append(out, *e)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].pattern < out[j].pattern })
+	return out
+}
+
+// selfIgnored reports whether a directory ignores all of its contents with its own
+// .gitignore, as Loom does for its backups and run summaries.
+func selfIgnored(ws *workspace, dir string) bool {
+	for _, rule := range parseIgnore(ws.read(dir + "/.gitignore")) {
+		if rule == "*" || rule == "/*" {
+			return true
+		}
+	}
+	return false
+}
+
+// checkGitignore reports patterns that no .gitignore covers, with a fix that appends
+// them to the root .gitignore. A nested .gitignore counts for the files below it.
+func checkGitignore(ws *workspace) []Finding {
+	existing := ws.read(".gitignore")
+	rules := map[string]ignoreRules{}
+	for _, f := range ws.files {
+		if path.Base(f) == ".gitignore" {
+			rules[path.Dir(f)] = parseIgnore(ws.read(f))
+		}
+	}
+	var missing []ignoreEntry
+	for _, e := range expectedIgnores(ws) {
+		if !ignoredEverywhere(rules, e) {
+			missing = append(missing, e)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	patterns := make([]string, 0, len(missing))
+	reasons := make([]string, 0, len(missing))
+	for _, e := range missing {
+		patterns = append(patterns, e.pattern)
+		reasons = append(reasons, fmt.Sprintf("%s (%s)", e.pattern, e.reason))
+	}
+	content := existing
+	if content != "" && !strings.HasSuffix(content, "\n") {
+		content += "\n"
+	}
+	content += strings.Join(patterns, "\n") + "\n"
+
+	severity := SeverityMedium
+	for _, p := range patterns {
+		if strings.HasPrefix(p, ".env") {
+			severity = SeverityHigh
+		}
+	}
+	msg := ".gitignore is missing " + strings.Join(reasons, ", ")
+	if existing == "" {
+		msg = "No .gitignore; the workspace needs " + strings.Join(reasons, ", ")
+	}
+	return []Finding{{
+		ID:       CheckGitignore,
+		Check:    CheckGitignore,
+		Severity: severity,
+		Path:     ".gitignore",
+		Message:  msg,
+		Fix: &Fix{
+			Description: "Add " + strings.Join(patterns, ", ") + " to .gitignore",
+			Path:        ".gitignore",
+			Content:     content,
+		},
+	}}
+}
+
+// ignoredEverywhere reports whether every place that calls for e is covered by its
+// own or an enclosing directory's .gitignore.
+func ignoredEverywhere(rules map[string]ignoreRules, e ignoreEntry) bool {
+	for _, dir := range e.dirs {
+		covered := false
+		for d := dir; ; d = path.Dir(d) {
+			if rules[d].covers(e.pattern) {
+				covered = true
+				break
+			}
+			if d == "." || d == "/" {
+				break
+			}
+		}
+		if !covered {
+			return false
+		}
+	}
+	return true
+}
+
+// ignoreRules are the patterns of a .gitignore file. Negations are not evaluated,
+// so a pattern re-included by one still counts as covered.
+type ignoreRules []string
+
+func parseIgnore(content string) ignoreRules {
+	var rules ignoreRules
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "!") {
+			continue
+		}
+		rules = append(rules, line)
+	}
+	return rules
+}
+
+// covers reports whether a rule ignores everything pattern does, e.g. "node_modules"
+// or "/node_modules/" for "node_modules/", or ".env*" for ".env".
+func (r ignoreRules) covers(pattern string) bool {
+	want := strings.Trim(pattern, "/")
+	for _, rule := range r {
+		rule = strings.TrimPrefix(strings.TrimPrefix(rule, "**/"), "/")
+		rule = strings.TrimSuffix(strings.TrimSuffix(rule, "/**"), "/")
+		if rule == want {
+			return true
+		}
+		if ok, _ := path.Match(rule, want); ok {
+			return true
+		}
+		// A directory rule covers patterns below it, e.g. ".loom" covers ".loom/runs"
+		if strings.HasPrefix(want, rule+"/") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,95 @@
+package hygiene
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	// largeBlobSize is the size from which a blob in history is reported
+	largeBlobSize = 5 << 20
+	// maxLargeBlobs caps how many large blobs are listed
+	maxLargeBlobs = 20
+)
+
+// checkHistory reports large blobs anywhere in the repository's history. Every clone
+// downloads them, even once the files are deleted.
+func checkHistory(ctx context.Context, ws *workspace) []Finding {
+	out, err := git(ctx, ws.root, "cat-file", "--batch-all-objects", "--batch-check=%(objectname) %(objecttype) %(objectsize)")
+	if err != nil {
+		return nil
+	}
+	type blob struct {
+		sha  string
+		size int64
+	}
+	var large []blob
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 3 || fields[1] != "blob" {
+			continue
+		}
+		if size, err := strconv.ParseInt(fields[2], 10, 64); err == nil && size >= largeBlobSize {
+			large = append(large, blob{fields[0], size})
+		}
+	}
+	if len(large) == 0 {
+		return nil
+	}
+	sort.Slice(large, func(i, j int) bool { return large[i].size > large[j].size })
+	if len(large) > maxLargeBlobs {
+		large = large[:maxLargeBlobs]
+	}
+
+	// Name the blobs by a path they were committed under
+	paths := map[string]string{}
+	if objects, err := git(ctx, ws.root, "rev-list", "--objects", "--all"); err == nil {
+		for _, line := range strings.Split(objects, "\n") {
+			if sha, p, ok := strings.Cut(line, " "); ok {
+				if _, seen := paths[sha]; !seen {
+					paths[sha] = p
+				}
+			}
+		}
+	}
+	tracked := map[string]bool{}
+	for _, p := range ws.tracked {
+		tracked[p] = true
+	}
+
+	var findings []Finding
+	for _, b := range large {
+		p := paths[b.sha]
+		if p == "" {
+			// Unreachable objects disappear with the next gc
+			continue
+		}
+		where := "in history only"
+		if tracked[p] {
+			where = "still tracked"
+		}
+		findings = append(findings, Finding{
+			ID:         fmt.Sprintf("%s:%s:%s", CheckHistory, p, b.sha[:12]),
+			Check:      CheckHistory,
+			Severity:   SeverityMedium,
+			Path:       p,
+			Message:    fmt.Sprintf("%s blob %s (%s) is in the git history, %s; every clone downloads it.", formatSize(b.size), b.sha[:12], p, where),
+			Suggestion: "Track large binaries with Git LFS, or remove the blob from history with git filter-repo and force-push.",
+		})
+	}
+	return findings
+}
+
+func formatSize(n int64) string {
+	switch {
+	case n >= 1<<30:
+		return fmt.Sprintf("%.1f GB", float64(n)/(1<<30))
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(n)/(1<<20))
+	default:
+		return fmt.Sprintf("%.1f KB", float64(n)/(1<<10))
+	}
+}
@@ -0,0 +1,267 @@
+// Package hygiene audits a workspace for repository hygiene issues: missing ignore
+// rules, committed secrets, large blobs in git history, a missing license and
+// conflicting formatting configuration. Findings that can be resolved by editing one
+// file carry the edit as a Fix.
+package hygiene
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Severity of a finding.
+const (
+	SeverityHigh   = "high"
+	SeverityMedium = "medium"
+	SeverityLow    = "low"
+)
+
+// Checks a finding comes from.
+const (
+	CheckGitignore  = "gitignore"
+	CheckSecrets    = "secrets"
+	CheckHistory    = "history"
+	CheckLicense    = "license"
+	CheckFormatting = "formatting"
+)
+
+const (
+	// auditTimeout bounds the git commands of one audit
+	auditTimeout = 60 * time.Second
+	// maxWalkFiles stops the workspace walk in very large trees
+	maxWalkFiles = 50_000
+)
+
+// skipDirs are not descended into; their presence is still noted for the ignore check.
+var skipDirs = map[string]bool{
+	".git": true, "node_modules": true, "vendor": true, ".venv": true, "venv": true,
+	"__pycache__": true, "target": true, "dist": true, "build": true, ".next": true,
+	".idea": true, ".gradle": true, "Pods": true,
+}
+
+// Finding is one hygiene issue.
+type Finding struct {
+	// ID is stable across audits of an unchanged workspace; fixes are applied by ID
+	ID       string `json:"id"`
+	Check    string `json:"check"`
+	Severity string `json:"severity"`
+	Path     string `json:"path,omitempty"`
+	Line     int    `json:"line,omitempty"`
+	Message  string `json:"message"`
+	// Suggestion tells how to resolve the finding by hand
+	Suggestion string `json:"suggestion,omitempty"`
+	Fix        *Fix   `json:"fix,omitempty"`
+}
+
+// Fix resolves a finding by writing one file.
+type Fix struct {
+	Description string `json:"description"`
+	Path        string `json:"path"`
+	// Content is the complete new content of Path
+	Content string `json:"-"`
+}
+
+// Report is the result of an audit.
+type Report struct {
+	Workspace   string    `json:"workspace"`
+	GeneratedAt time.Time `json:"generated_at"`
+	// Git is false when the workspace is not a git repository; history checks are skipped
+	Git      bool      `json:"git"`
+	Findings []Finding `json:"findings"`
+	// Truncated is set when the workspace walk stopped at its file limit
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// workspace is what the checks know about the tree.
+type workspace struct {
+	root string
+	// files are all files on disk outside skipped directories, slash-separated
+	files []string
+	// dirs holds the relative paths of every directory seen, including skipped ones
+	dirs map[string]bool
+	// tracked are the files in the git index; nil without git
+	tracked []string
+	git     bool
+}
+
+// Audit runs every check on the workspace at root.
+func Audit(ctx context.Context, root string) (Report, error) {
+	rep := Report{Workspace: root, GeneratedAt: time.Now(), Findings: []Finding{}}
+	if info, err := os.Stat(root); err != nil || !info.IsDir() {
+		return rep, fmt.Errorf("workspace %s is not a directory", root)
+	}
+	ctx, cancel := context.WithTimeout(ctx, auditTimeout)
+	defer cancel()
+
+	ws, truncated := scan(root)
+	rep.Truncated = truncated
+	if out, err := git(ctx, root, "ls-files", "-z"); err == nil {
+		ws.git = true
+		for _, p := range strings.Split(out, "\x00") {
+			if p != "" {
+				ws.tracked = append(ws.tracked, p)
+			}
+		}
+	}
+	rep.Git = ws.git
+
+	add := func(found []Finding) { rep.Findings = append(rep.Findings, found...) }
+	add(checkGitignore(ws))
+	add(checkSecrets(ws))
+	if ws.git {
+		add(checkHistory(ctx, ws))
+	}
+	add(checkLicense(ws))
+	add(checkFormatting(ws))
+	sortFindings(rep.Findings)
+	return rep, nil
+}
+
+// Find returns the finding with id.
+func (r Report) Find(id string) (Finding, bool) {
+	for _, f := range r.Findings {
+		if f.ID == id {
+			return f, true
+		}
+	}
+	return Finding{}, false
+}
+
+// sortFindings orders findings by severity, keeping the order of the checks within one.
+func sortFindings(findings []Finding) {
+	rank := map[string]int{SeverityHigh: 0, SeverityMedium: 1, SeverityLow: 2}
+	sort.SliceStable(findings, func(i, j int) bool {
+		return rank[findings[i].Severity] < rank[findings[j].Severity]
+	})
+}
+
+// scan walks the workspace, skipping heavy and generated directories.
+func scan(root string) (*workspace, bool) {
+	ws := &workspace{root: root, dirs: map[string]bool{}}
+	truncated := false
+	_ = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if d != nil && d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		rel, _ := filepath.Rel(root, path)
+		rel = filepath.ToSlash(rel)
+		if rel == "." {
+			return nil
+		}
+		if d.IsDir() {
+			ws.dirs[rel] = true
+			if skipDirs[d.Name()] {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if len(ws.files) >= maxWalkFiles {
+			truncated = true
+			return fs.SkipAll
+		}
+		ws.files = append(ws.files, rel)
+		return nil
+	})
+	sort.Strings(ws.files)
+	return ws, truncated
+}
+
+// has reports whether a file exists at rel.
+func (ws *workspace) has(rel string) bool {
+	info, err := os.Stat(filepath.Join(ws.root, filepath.FromSlash(rel)))
+	return err == nil && !info.IsDir()
+}
+
+func (ws *workspace) read(rel string) string {
+	data, _ := os.ReadFile(filepath.Join(ws.root, filepath.FromSlash(rel)))
+	return string(data)
+}
+
+// git runs a git command in dir and returns its stdout.
+func git(ctx context.Context, dir string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return string(out), nil
+}
+
+// checkLicense reports a workspace without a license file at its root.
+func checkLicense(ws *workspace) []Finding {
+	for _, f := range ws.files {
+		if strings.Contains(f, "/") {
+			continue
+		}
+		name := strings.ToUpper(strings.TrimSuffix(f, filepath.Ext(f)))
+		switch name {
+		case "LICENSE", "LICENCE", "COPYING", "UNLICENSE":
+			return nil
+		}
+		if strings.HasPrefix(name, "LICENSE-") || strings.HasPrefix(name, "LICENSE.") {
+			return nil
+		}
+	}
+	return []Finding{{
+		ID:         CheckLicense,
+		Check:      CheckLicense,
+		Severity:   SeverityLow,
+		Message:    "No LICENSE file at the repository root; without one, others have no right to use the code.",
+		Suggestion: "Choose a license (see https://choosealicense.com) and add its text as LICENSE.",
+	}}
+}
+
+// Format renders a report as plain text for the `loom hygiene` command.
+func Format(r Report) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Loom hygiene - %s\n", r.GeneratedAt.Format(time.RFC3339))
+	fmt.Fprintf(&b, "Workspace: %s\n", r.Workspace)
+	if !r.Git {
+		b.WriteString("Not a git repository: history checks skipped\n")
+	}
+	if r.Truncated {
+		fmt.Fprintf(&b, "Workspace walk stopped after %d files\n", maxWalkFiles)
+	}
+	if len(r.Findings) == 0 {
+		b.WriteString("\nNo issues found.\n")
+		return b.String()
+	}
+	b.WriteString("\n")
+	for _, f := range r.Findings {
+		loc := ""
+		if f.Path != "" {
+			loc = " " + f.Path
+			if f.Line > 0 {
+				loc += fmt.Sprintf(":%d", f.Line)
+			}
+		}
+		fmt.Fprintf(&b, "[%-6s] %-10s%s\n  %s\n", strings.ToUpper(f.Severity), f.Check, loc, f.Message)
+		if f.Fix != nil {
+			fmt.Fprintf(&b, "  Fix available: %s\n", f.Fix.Description)
+		} else if f.Suggestion != "" {
+			fmt.Fprintf(&b, "  %s\n", f.Suggestion)
+		}
+	}
+	return b.String()
+}
+
+// HasHigh reports whether any finding is of high severity.
+func (r Report) HasHigh() bool {
+	for _, f := range r.Findings {
+		if f.Severity == SeverityHigh {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,196 @@
+package hygiene
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func write(t *testing.T, root, rel, content string) {
+	t.Helper()
+	p := filepath.Join(root, filepath.FromSlash(rel))
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func runGit(t *testing.T, dir string, args ...string) {
+	t.Helper()
+	cmd := exec.Command("git", append([]string{"-c", "user.name=t", "-c", "user.email=t@example.com"}, args...)...)
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git %v: %v\n%s", args, err, out)
+	}
+}
+
+func TestCheckGitignore_AppendsMissingEntries(t *testing.T) {
+	root := t.TempDir()
+	write(t, root, ".gitignore", "/node_modules\n*.log")
+	write(t, root, "package.json", "{}")
+	write(t, root, "node_modules/x/index.js", "")
+	write(t, root, ".env", "TOKEN=1")
+	write(t, root, ".env.example", "TOKEN=")
+	write(t, root, "tools/gen.py", "")
+	write(t, root, ".loom/runs/1.json", "{}")
+	write(t, root, ".loom/backups/.gitignore", "*\n")
+
+	rep, err := Audit(context.Background(), root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, ok := rep.Find(CheckGitignore)
+	if !ok || f.Fix == nil {
+		t.Fatalf("expected a gitignore finding with a fix, got %+v", rep.Findings)
+	}
+	if f.Severity != SeverityHigh {
+		t.Errorf("an unignored .env should be high severity, got %s", f.Severity)
+	}
+	want := "/node_modules\n*.log\n.env\n.loom/runs/\n__pycache__/\n"
+	if f.Fix.Content != want {
+		t.Fatalf("unexpected fix content %q, want %q", f.Fix.Content, want)
+	}
+
+	write(t, root, ".gitignore", f.Fix.Content)
+	rep, _ = Audit(context.Background(), root)
+	if _, ok := rep.Find(CheckGitignore); ok {
+		t.Fatal("applying the fix should resolve the finding")
+	}
+}
+
+func TestIgnoreRulesCover(t *testing.T) {
+	rules := parseIgnore("# deps\n**/node_modules/\n.env*\n!.env.example\n.loom\n")
+	for _, p := range []string{"node_modules/", ".env", ".env*.local", ".loom/runs/"} {
+		if !rules.covers(p) {
+			t.Errorf("%s should be covered", p)
+		}
+	}
+	if rules.covers("__pycache__/") {
+		t.Error("__pycache__/ should not be covered")
+	}
+}
+
+func TestCheckSecrets(t *testing.T) {
+	root := t.TempDir()
+	// Built from parts so the test source itself holds no credential
+	aws := "AKIA" + "Q3EGRDN4ZKTPLW7X"
+	write(t, root, "config/prod.yaml", "aws_key: "+aws+"\npassword: \"s3cr3t-Pa55word-x\"\n")
+	write(t, root, "config/sample.yaml", "password: \"your-password-here\"\napi_key: \"EXAMPLE_KEY_123456\"\n")
+	write(t, root, "internal/db_test.go", "password := \"hunter2-hunter2-x\"\n")
+	write(t, root, "deploy/id_rsa", "-----BEGIN RSA "+"PRIVATE KEY-----\nabc\n")
+
+	rep, err := Audit(context.Background(), root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, f := range rep.Findings {
+		if f.Check == CheckSecrets {
+			got = append(got, f.ID)
+			if strings.Contains(f.Message, aws) || strings.Contains(f.Message, "s3cr3t-Pa55word") {
+				t.Errorf("secret not masked: %s", f.Message)
+			}
+		}
+	}
+	want := []string{"secrets:config/prod.yaml:1", "secrets:config/prod.yaml:2", "secrets:deploy/id_rsa:1"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
+
+func TestRedactSecrets(t *testing.T) {
+	key := "sk-ant-" + "api03-Q3EGRDN4ZKTPLW7XQ3EGRDN4"
+	in := "request failed with key " + key + ` {"password": "s3cr3t-Pa55word-x"}`
+	got := RedactSecrets(in)
+	want := `request failed with key [REDACTED] {"password": "[REDACTED]"}`
+	if got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestAudit_GitHistoryAndCommittedEnv(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	root := t.TempDir()
+	runGit(t, root, "init", "-q")
+	write(t, root, "LICENSE", "MIT")
+	write(t, root, ".env", "A=1")
+	big := make([]byte, largeBlobSize+1)
+	for i := range big {
+		big[i] = byte(i * 7)
+	}
+	if err := os.WriteFile(filepath.Join(root, "assets.bin"), big, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	runGit(t, root, "add", "-A")
+	runGit(t, root, "commit", "-qm", "init")
+	runGit(t, root, "rm", "-q", "assets.bin")
+	runGit(t, root, "commit", "-qm", "drop")
+
+	rep, err := Audit(context.Background(), root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !rep.Git {
+		t.Fatal("expected a git repository")
+	}
+	if _, ok := rep.Find("secrets:.env"); !ok {
+		t.Errorf("committed .env not reported: %+v", rep.Findings)
+	}
+	var history *Finding
+	for i, f := range rep.Findings {
+		if f.Check == CheckHistory {
+			history = &rep.Findings[i]
+		}
+	}
+	if history == nil || history.Path != "assets.bin" || !strings.Contains(history.Message, "in history only") {
+		t.Fatalf("large deleted blob not reported: %+v", history)
+	}
+	if _, ok := rep.Find(CheckLicense); ok {
+		t.Error("LICENSE exists")
+	}
+	if rep.Findings[0].Severity != SeverityHigh {
+		t.Errorf("findings should be sorted by severity: %+v", rep.Findings)
+	}
+}
+
+func TestCheckFormatting(t *testing.T) {
+	root := t.TempDir()
+	write(t, root, "package.json", `{"name":"x","prettier":{"semi":false}}`)
+	write(t, root, ".prettierrc", `{"useTabs": false, "tabWidth": 4}`)
+	write(t, root, "src/app.ts", "")
+	write(t, root, "main.go", "")
+	write(t, root, "Makefile", "")
+
+	rep, _ := Audit(context.Background(), root)
+	if _, ok := rep.Find("formatting:prettier"); !ok {
+		t.Errorf("duplicate Prettier config not reported: %+v", rep.Findings)
+	}
+	f, ok := rep.Find("formatting:editorconfig")
+	if !ok || f.Fix == nil {
+		t.Fatalf("missing .editorconfig not reported: %+v", rep.Findings)
+	}
+	for _, want := range []string{"root = true", "[*.go]\nindent_style = tab", "[*.{js,jsx,ts,tsx,mjs,cjs,json,css,scss,html,vue}]\nindent_style = space\nindent_size = 4", "[{Makefile,makefile,*.mk}]"} {
+		if !strings.Contains(f.Fix.Content, want) {
+			t.Errorf("generated .editorconfig misses %q:\n%s", want, f.Fix.Content)
+		}
+	}
+	if strings.Contains(f.Fix.Content, "*.py") {
+		t.Error("no Python section expected")
+	}
+
+	write(t, root, ".editorconfig", "root = true\n[*]\nindent_style = tab\n")
+	rep, _ = Audit(context.Background(), root)
+	if _, ok := rep.Find("formatting:editorconfig-prettier"); !ok {
+		t.Errorf("conflicting indentation not reported: %+v", rep.Findings)
+	}
+	if _, ok := rep.Find(CheckLicense); !ok {
+		t.Error("missing LICENSE not reported")
+	}
+}
@@ -0,0 +1,183 @@
+package hygiene
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+const (
+	// maxSecretScanSize skips large files, which are rarely hand-written config
+	maxSecretScanSize = 1 << 20
+	// maxSecretFindings caps the report in repositories full of fixtures
+	maxSecretFindings = 50
+)
+
+// secretPatterns recognise credentials by their format. The first pattern that
+// matches a line wins, so more specific formats come first.
+var secretPatterns = []struct {
+	kind string
+	re   *regexp.Regexp
+	// generic patterns match assignments of any value and skip test data
+	generic bool
+}{
+	{kind: "private key", re: regexp.MustCompile(`-----BEGIN (?:RSA |EC |DSA |OPENSSH |PGP |ENCRYPTED )?PRIVATE KEY(?: BLOCK)?-----`)},
+	{kind: "AWS access key", re: regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	{kind: "GitHub token", re: regexp.MustCompile(`\b(?:gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{40,})\b`)},
+	{kind: "Slack token", re: regexp.MustCompile(`\bxox[abprs]-[A-Za-z0-9-]{10,}`)},
+	{kind: "Anthropic API key", re: regexp.MustCompile(`\bsk-ant-[A-Za-z0-9_-]{20,}`)},
+	{kind: "OpenAI API key", re: regexp.MustCompile(`\bsk-(?:proj-)?[A-Za-z0-9_-]{32,}`)},
+	{kind: "Google API key", re: regexp.MustCompile(`\bAIza[0-9A-Za-z_-]{35}\b`)},
+	{kind: "Stripe secret key", re: regexp.MustCompile(`\b[rs]k_live_[0-9A-Za-z]{20,}`)},
+	{kind: "credential", re: regexp.MustCompile(`(?i)\b(?:password|passwd|secret|api[_-]?key|access[_-]?token|auth[_-]?token|client[_-]?secret)\b["']?\s*[:=]\s*["']([^"'\s]{12,})["']`), generic: true},
+}
+
+// placeholderHints mark values that only look like credentials.
+var placeholderHints = []string{"example", "xxxx", "changeme", "your", "placeholder", "dummy", "sample", "redacted", "<", "${", "{{", "%s", "****"}
+
+// checkSecrets reports credentials in tracked files (all files without git) and
+// environment files committed to the repository.
+func checkSecrets(ws *workspace) []Finding {
+	files := ws.tracked
+	if !ws.git {
+		files = ws.files
+	}
+	var out []Finding
+	for _, rel := range files {
+		base := path.Base(rel)
+		if ws.git && isEnvFile(base) {
+			out = append(out, Finding{
+				ID:         CheckSecrets + ":" + rel,
+				Check:      CheckSecrets,
+				Severity:   SeverityHigh,
+				Path:       rel,
+				Message:    fmt.Sprintf("%s is committed; environment files usually hold credentials.", rel),
+				Suggestion: fmt.Sprintf("Run `git rm --cached %s`, ignore it, rotate the values it holds and commit a %s.example without them instead.", rel, base),
+			})
+			continue
+		}
+		if len(out) >= maxSecretFindings {
+			break
+		}
+		out = append(out, scanSecrets(ws.root, rel)...)
+	}
+	if len(out) > maxSecretFindings {
+		out = out[:maxSecretFindings]
+	}
+	return out
+}
+
+// isEnvFile matches .env and .env.local style files, but not templates like .env.example.
+func isEnvFile(base string) bool {
+	if base == ".env" {
+		return true
+	}
+	if !strings.HasPrefix(base, ".env.") {
+		return false
+	}
+	for _, t := range []string{"example", "sample", "template", "dist", "defaults"} {
+		if strings.Contains(base, t) {
+			return false
+		}
+	}
+	return true
+}
+
+// scanSecrets reports the credentials in one file.
+func scanSecrets(root, rel string) []Finding {
+	switch path.Ext(rel) {
+	case ".lock", ".sum", ".svg", ".map":
+		return nil
+	}
+	if strings.HasSuffix(rel, ".min.js") || path.Base(rel) == "package-lock.json" {
+		return nil
+	}
+	abs := filepath.Join(root, filepath.FromSlash(rel))
+	info, err := os.Stat(abs)
+	if err != nil || info.IsDir() || info.Size() > maxSecretScanSize {
+		return nil
+	}
+	data, err := os.ReadFile(abs)
+	if err != nil || bytes.IndexByte(data[:min(len(data), 8000)], 0) >= 0 {
+		return nil
+	}
+	testData := isTestPath(rel)
+	var out []Finding
+	for i, line := range strings.Split(string(data), "\n") {
+		for _, p := range secretPatterns {
+			if p.generic && testData {
+				continue
+			}
+			m := p.re.FindStringSubmatch(line)
+			if m == nil {
+				continue
+			}
+			value := m[len(m)-1]
+			if looksLikePlaceholder(value) {
+				continue
+			}
+			msg := fmt.Sprintf("Possible %s: %s", p.kind, mask(value))
+			if len(m) == 1 && p.kind == "private key" {
+				msg = "Private key block"
+			}
+			out = append(out, Finding{
+				ID:         fmt.Sprintf("%s:%s:%d", CheckSecrets, rel, i+1),
+				Check:      CheckSecrets,
+				Severity:   SeverityHigh,
+				Path:       rel,
+				Line:       i + 1,
+				Message:    msg,
+				Suggestion: "Revoke and rotate the credential, load it from the environment or a secret store, and purge it from history if it was pushed.",
+			})
+			break
+		}
+	}
+	return out
+}
+
+func isTestPath(rel string) bool {
+	lower := strings.ToLower(rel)
+	for _, hint := range []string{"_test.", ".test.", ".spec.", "test/", "tests/", "testdata/", "fixtures/", "__tests__/"} {
+		if strings.Contains(lower, hint) {
+			return true
+		}
+	}
+	return false
+}
+
+func looksLikePlaceholder(value string) bool {
+	lower := strings.ToLower(value)
+	for _, hint := range placeholderHints {
+		if strings.Contains(lower, hint) {
+			return true
+		}
+	}
+	return false
+}
+
+// mask keeps enough of a secret to recognise it without repeating it.
+func mask(value string) string {
+	if len(value) <= 8 {
+		return strings.Repeat("*", len(value))
+	}
+	return value[:4] + strings.Repeat("*", 8) + fmt.Sprintf(" (%d chars)", len(value))
+}
+
+// RedactSecrets replaces the credentials the secret scan recognises with [REDACTED],
+// keeping the surrounding text, e.g. in logs attached to bug reports.
+func RedactSecrets(text string) string {
+	for _, p := range secretPatterns {
+		text = p.re.ReplaceAllStringFunc(text, func(m string) string {
+			// Generic patterns capture the value after the key name
+			if sub := p.re.FindStringSubmatchIndex(m); len(sub) >= 4 && sub[2] >= 0 {
+				return m[:sub[2]] + "[REDACTED]" + m[sub[3]:]
+			}
+			return "[REDACTED]"
+		})
+	}
+	return text
+}
@@ -0,0 +1,210 @@
+package indexer
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/go-git/go-git/v5/plumbing/format/gitignore"
+)
+
+const (
+	// goSearchMaxLine is the longest line the built-in search scans; files with longer
+	// lines, usually minified bundles, are searched up to that line.
+	goSearchMaxLine = 1 << 20
+	// goSearchBinaryProbe is how much of a file is checked for NUL bytes, as ripgrep does.
+	goSearchBinaryProbe = 8 << 10
+)
+
+// goSearchSkippedDirs mirrors the directories Search excludes from ripgrep.
+var goSearchSkippedDirs = map[string]bool{
+	"node_modules": true,
+	"vendor":       true,
+	"dist":         true,
+	"build":        true,
+}
+
+// searchGo is the built-in fallback for Search when ripgrep cannot run. It follows
+// ripgrep's defaults: case-insensitive regular expressions, hidden files and
+// .gitignore'd paths skipped, binary files skipped, and at most maxResults matches per
+// file. Files are searched concurrently and reported in path order. Symlinks are
+// followed when follow is set and they stay inside the workspace.
+func searchGo(workspacePath, query, filePattern string, maxResults int, follow bool) (*RipgrepResult, error) {
+	re, err := regexp.Compile("(?i)" + query)
+	if err != nil {
+		return nil, fmt.Errorf("invalid search pattern: %w", err)
+	}
+	var glob gitignore.Pattern
+	if filePattern != "" {
+		glob = gitignore.ParsePattern(filePattern, nil)
+	}
+	// WalkDir does not descend into a symlinked root
+	root := realPath(workspacePath)
+	files, err := searchableFiles(root, glob, follow)
+	if err != nil {
+		return nil, err
+	}
+
+	perFile := make([][]RipgrepMatch, len(files))
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < min(runtime.NumCPU(), len(files)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				perFile[i] = searchFile(root, files[i], re, maxResults)
+			}
+		}()
+	}
+	for i := range files {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+
+	var result RipgrepResult
+	for _, matches := range perFile {
+		result.Matches = append(result.Matches, matches...)
+	}
+	return &result, nil
+}
+
+// searchableFiles walks the workspace and returns the relative paths ripgrep would
+// search, sorted. Each directory's .gitignore applies below it, after .git/info/exclude.
+// With follow, symlinks whose target is inside root are searched under the link's path;
+// each linked directory is walked once, so link cycles end.
+func searchableFiles(root string, glob gitignore.Pattern, follow bool) ([]string, error) {
+	patterns := readIgnoreFile(filepath.Join(root, ".git", "info", "exclude"), nil)
+	visited := map[string]bool{root: true}
+	var files []string
+	var walk func(dir string, base []string) error
+	walk = func(dir string, base []string) error {
+		return filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				if p == dir {
+					return err
+				}
+				return nil
+			}
+			if p == dir {
+				patterns = append(patterns, readIgnoreFile(filepath.Join(p, ".gitignore"), base)...)
+				return nil
+			}
+			rel, err := filepath.Rel(dir, p)
+			if err != nil {
+				return nil
+			}
+			segs := append(append([]string{}, base...), strings.Split(filepath.ToSlash(rel), "/")...)
+			name := d.Name()
+			if strings.HasPrefix(name, ".") || gitignore.NewMatcher(patterns).Match(segs, d.IsDir()) {
+				if d.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if d.IsDir() {
+				if goSearchSkippedDirs[name] {
+					return filepath.SkipDir
+				}
+				patterns = append(patterns, readIgnoreFile(filepath.Join(p, ".gitignore"), segs)...)
+				return nil
+			}
+			isFile := d.Type().IsRegular()
+			if d.Type()&fs.ModeSymlink != 0 && follow {
+				target, err := filepath.EvalSymlinks(p)
+				if err != nil || !within(root, target) {
+					return nil
+				}
+				info, err := os.Stat(target)
+				if err != nil {
+					return nil
+				}
+				if info.IsDir() {
+					if visited[target] || goSearchSkippedDirs[name] {
+						return nil
+					}
+					visited[target] = true
+					return walk(target, segs)
+				}
+				isFile = info.Mode().IsRegular()
+			}
+			if !isFile {
+				return nil
+			}
+			if glob != nil && glob.Match(segs, false) != gitignore.Exclude {
+				return nil
+			}
+			files = append(files, filepath.FromSlash(strings.Join(segs, "/")))
+			return nil
+		})
+	}
+	err := walk(root, nil)
+	sort.Strings(files)
+	return files, err
+}
+
+// realPath resolves the symlinks in path, or returns it unchanged when that fails.
+func realPath(path string) string {
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		return resolved
+	}
+	return filepath.Clean(path)
+}
+
+// within reports whether path is root or below it.
+func within(root, path string) bool {
+	rel, err := filepath.Rel(root, path)
+	return err == nil && filepath.IsLocal(rel)
+}
+
+// readIgnoreFile parses a .gitignore whose patterns apply below domain.
+func readIgnoreFile(p string, domain []string) []gitignore.Pattern {
+	data, err := os.ReadFile(p)
+	if err != nil {
+		return nil
+	}
+	var patterns []gitignore.Pattern
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimRight(line, "\r")
+		if trimmed := strings.TrimSpace(line); trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		patterns = append(patterns, gitignore.ParsePattern(line, domain))
+	}
+	return patterns
+}
+
+// searchFile returns up to maxResults matching lines of one file. Unreadable and
+// binary files have none.
+func searchFile(root, rel string, re *regexp.Regexp, maxResults int) []RipgrepMatch {
+	f, err := os.Open(filepath.Join(root, rel))
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+	r := bufio.NewReaderSize(f, goSearchBinaryProbe)
+	if head, _ := r.Peek(goSearchBinaryProbe); bytes.IndexByte(head, 0) >= 0 {
+		return nil
+	}
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64<<10), goSearchMaxLine)
+	var matches []RipgrepMatch
+	for line := 1; sc.Scan() && len(matches) < maxResults; line++ {
+		text := sc.Text()
+		loc := re.FindStringIndex(text)
+		if loc == nil {
+			continue
+		}
+		matches = append(matches, RipgrepMatch{Path: rel, LineNum: line, LineText: text, StartChar: loc[0], EndChar: loc[1]})
+	}
+	return matches
+}
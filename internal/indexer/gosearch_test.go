@@ -0,0 +1,134 @@
+package indexer
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeTree(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for rel, content := range files {
+		p := filepath.Join(root, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func matchedPaths(res *RipgrepResult) []string {
+	var out []string
+	for _, m := range res.Matches {
+		out = append(out, filepath.ToSlash(m.Path))
+	}
+	return out
+}
+
+func TestSearchGo_FollowsRipgrepDefaults(t *testing.T) {
+	ws := t.TempDir()
+	writeTree(t, ws, map[string]string{
+		".gitignore":              "*.log\n/generated/\n",
+		"main.go":                 "package main\n\nfunc main() {\n\tTODO()\n}\n",
+		"pkg/util.go":             "package pkg\n// todo: one\n// TODO: two\n// todo three\n",
+		"pkg/.gitignore":          "skip.go\n",
+		"pkg/skip.go":             "// TODO ignored by the nested .gitignore\n",
+		"pkg/sub/deep.ts":         "// TODO in typescript\n",
+		"app.log":                 "TODO in an ignored log\n",
+		"generated/out.go":        "// TODO generated\n",
+		"node_modules/x/index.js": "// TODO dependency\n",
+		".hidden/secret.go":       "// TODO hidden\n",
+		"data.bin":                "TODO\x00binary",
+	})
+
+	res, err := searchGo(ws, "todo", "", 2, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := strings.Join(matchedPaths(res), ",")
+	if got != "main.go,pkg/sub/deep.ts,pkg/util.go,pkg/util.go" {
+		t.Fatalf("unexpected matches %s", got)
+	}
+	m := res.Matches[0]
+	if m.LineNum != 4 || m.LineText != "\tTODO()" || m.StartChar != 1 || m.EndChar != 5 {
+		t.Fatalf("unexpected match %+v", m)
+	}
+
+	res, err = searchGo(ws, `todo:\s+\w+`, "pkg/**/*.go", 10, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(matchedPaths(res), ","); got != "pkg/util.go,pkg/util.go" {
+		t.Fatalf("unexpected matches with a glob %s", got)
+	}
+	res, err = searchGo(ws, "todo", "*.ts", 10, false)
+	if err != nil || len(res.Matches) != 1 {
+		t.Fatalf("expected a basename glob to match at any depth, got %v (%v)", res, err)
+	}
+
+	if _, err := searchGo(ws, "todo(", "", 10, false); err == nil {
+		t.Fatal("expected an invalid pattern to fail")
+	}
+}
+
+func TestSearch_FallsBackWhenRipgrepCannotRun(t *testing.T) {
+	ws := t.TempDir()
+	writeTree(t, ws, map[string]string{"a.txt": "needle\n"})
+	// Not executable, like a binary that lost its permissions
+	rgPath := filepath.Join(t.TempDir(), "rg")
+	if err := os.WriteFile(rgPath, []byte("not a binary"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	idx := &RipgrepIndexer{WorkspacePath: ws, rgPath: rgPath}
+	res, err := idx.Search("needle", "", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Matches) != 1 || res.Matches[0].Path != "a.txt" {
+		t.Fatalf("unexpected result %+v", res)
+	}
+	if !strings.Contains(idx.Fallback(), "cannot run") {
+		t.Fatalf("expected the fallback to be recorded, got %q", idx.Fallback())
+	}
+}
+
+func TestSearchGo_SymlinkedWorkspace(t *testing.T) {
+	base := t.TempDir()
+	real := filepath.Join(base, "real")
+	writeTree(t, base, map[string]string{
+		"real/a.go":         "// TODO root\n",
+		"real/pkg/b.go":     "// TODO linked\n",
+		"outside/secret.go": "// TODO outside\n",
+	})
+	ws := filepath.Join(base, "ws")
+	for link, target := range map[string]string{
+		ws:                              real,
+		filepath.Join(real, "alias"):    filepath.Join(real, "pkg"),
+		filepath.Join(real, "escape"):   filepath.Join(base, "outside"),
+		filepath.Join(real, "pkg/loop"): real,
+	} {
+		if err := os.Symlink(target, link); err != nil {
+			t.Skip("symlinks are not supported:", err)
+		}
+	}
+
+	res, err := searchGo(ws, "todo", "", 10, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(matchedPaths(res), ","); got != "a.go,pkg/b.go" {
+		t.Fatalf("unexpected matches through a symlinked root: %s", got)
+	}
+
+	res, err = searchGo(ws, "todo", "", 10, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Links inside the workspace are followed once; the escaping link is not
+	if got := strings.Join(matchedPaths(res), ","); got != "a.go,alias/b.go,pkg/b.go" {
+		t.Fatalf("unexpected matches following links: %s", got)
+	}
+}
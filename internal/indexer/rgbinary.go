@@ -0,0 +1,232 @@
+package indexer
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Sources of the ripgrep binary that searches run with.
+const (
+	RipgrepBundled = "bundled"
+	RipgrepSystem  = "system"
+	RipgrepMissing = "missing"
+)
+
+// ripgrepVersionTimeout bounds `rg --version`.
+const ripgrepVersionTimeout = 5 * time.Second
+
+// bundledRipgrep holds the ripgrep binaries packaged with this build under
+// rgbin/<platform>/, and rgbin/checksums.txt with their SHA-256 sums. The sums are
+// written by `make bundle-ripgrep` from the same binaries, so comparing them catches a
+// corrupted embed or extraction, not a tampered bundle; the release archives are
+// checked against upstream's published sums when bundling. Source builds usually
+// bundle none and use the system rg.
+//
+//go:embed rgbin
+var bundledRipgrep embed.FS
+
+// RipgrepBinary describes the ripgrep binary searches run with.
+type RipgrepBinary struct {
+	Path string `json:"path"`
+	// Source is bundled, system or missing
+	Source  string `json:"source"`
+	Version string `json:"version,omitempty"`
+	SHA256  string `json:"sha256,omitempty"`
+	// Intact is set when the bundled and extracted copies match checksums.txt
+	Intact bool `json:"intact"`
+	// Platform is the bundle platform, e.g. darwin-arm64 or linux-x64
+	Platform string `json:"platform"`
+	// Fallback explains why the bundled binary is not used
+	Fallback string `json:"fallback,omitempty"`
+}
+
+var (
+	ripgrepOnce   sync.Once
+	ripgrepBinary RipgrepBinary
+)
+
+// ResolveRipgrep selects the ripgrep binary once per process: the bundled binary for
+// this platform, extracted to ~/.loom/bin after an integrity check, or else rg
+// from PATH.
+func ResolveRipgrep() RipgrepBinary {
+	ripgrepOnce.Do(func() {
+		cacheDir := ""
+		if home, err := os.UserHomeDir(); err == nil {
+			cacheDir = filepath.Join(home, ".loom", "bin")
+		}
+		ripgrepBinary = resolveRipgrep(bundledRipgrep, cacheDir, runtime.GOOS, runtime.GOARCH, exec.LookPath)
+	})
+	return ripgrepBinary
+}
+
+// ripgrepPlatform names the bundle directory for an OS and architecture.
+func ripgrepPlatform(goos, goarch string) string {
+	if goarch == "amd64" {
+		goarch = "x64"
+	}
+	return goos + "-" + goarch
+}
+
+// resolveRipgrep implements ResolveRipgrep against a bundle file system, a cache
+// directory for extracted binaries and a PATH lookup.
+func resolveRipgrep(bundle fs.FS, cacheDir, goos, goarch string, lookPath func(string) (string, error)) RipgrepBinary {
+	platform := ripgrepPlatform(goos, goarch)
+	bin, err := extractBundledRipgrep(bundle, cacheDir, platform, goos)
+	if err == nil {
+		bin.Version = ripgrepVersion(bin.Path)
+		return bin
+	}
+	out := RipgrepBinary{Source: RipgrepMissing, Platform: platform, Fallback: err.Error()}
+	if p, lookErr := lookPath("rg"); lookErr == nil {
+		out.Path, out.Source = p, RipgrepSystem
+		out.Version = ripgrepVersion(p)
+	}
+	return out
+}
+
+// extractBundledRipgrep checks the bundled binary for platform against
+// checksums.txt and extracts it to a directory of cacheDir named after its version.
+// An extracted binary whose checksum still matches is reused; other versions of the
+// same platform are removed, so an upgrade replaces them.
+func extractBundledRipgrep(bundle fs.FS, cacheDir, platform, goos string) (RipgrepBinary, error) {
+	out := RipgrepBinary{Source: RipgrepBundled, Platform: platform}
+	name := "rg"
+	if goos == "windows" {
+		name = "rg.exe"
+	}
+	rel := platform + "/" + name
+	data, err := fs.ReadFile(bundle, path.Join("rgbin", rel))
+	if err != nil {
+		return out, fmt.Errorf("no bundled ripgrep for %s", platform)
+	}
+	version, sums, err := readRipgrepChecksums(bundle)
+	if err != nil {
+		return out, err
+	}
+	want, ok := sums[rel]
+	if !ok {
+		return out, fmt.Errorf("bundled ripgrep for %s has no checksum", platform)
+	}
+	if got := sha256Hex(data); got != want {
+		return out, fmt.Errorf("bundled ripgrep for %s failed its integrity check (got %s, want %s)", platform, got, want)
+	}
+	if cacheDir == "" {
+		return out, fmt.Errorf("no directory to extract ripgrep to")
+	}
+	if version == "" {
+		version = want[:12]
+	}
+	dir := filepath.Join(cacheDir, fmt.Sprintf("rg-%s-%s", version, platform))
+	target := filepath.Join(dir, name)
+	if !fileHasSHA256(target, want) {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return out, fmt.Errorf("extract ripgrep: %w", err)
+		}
+		tmp, err := os.CreateTemp(dir, name+".tmp-*")
+		if err != nil {
+			return out, fmt.Errorf("extract ripgrep: %w", err)
+		}
+		_, werr := tmp.Write(data)
+		cerr := tmp.Close()
+		if werr == nil {
+			werr = cerr
+		}
+		if werr == nil {
+			werr = os.Chmod(tmp.Name(), 0o755)
+		}
+		if werr == nil {
+			werr = os.Rename(tmp.Name(), target)
+		}
+		if werr != nil {
+			_ = os.Remove(tmp.Name())
+			return out, fmt.Errorf("extract ripgrep: %w", werr)
+		}
+		if !fileHasSHA256(target, want) {
+			return out, fmt.Errorf("extracted ripgrep at %s failed its integrity check", target)
+		}
+	}
+	// Earlier versions for this platform are no longer used
+	if old, err := filepath.Glob(filepath.Join(cacheDir, "rg-*-"+platform)); err == nil {
+		for _, d := range old {
+			if d != dir {
+				_ = os.RemoveAll(d)
+			}
+		}
+	}
+	out.Path, out.SHA256, out.Intact = target, want, true
+	return out, nil
+}
+
+// readRipgrepChecksums parses rgbin/checksums.txt: "<sha256>  <platform>/<file>" lines
+// and an optional "version <v>" line; # starts a comment.
+func readRipgrepChecksums(bundle fs.FS) (string, map[string]string, error) {
+	f, err := bundle.Open("rgbin/checksums.txt")
+	if err != nil {
+		return "", nil, fmt.Errorf("bundled ripgrep has no checksums.txt")
+	}
+	defer f.Close()
+	version := ""
+	sums := map[string]string{}
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		fields := strings.Fields(sc.Text())
+		if len(fields) < 2 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		if fields[0] == "version" {
+			version = fields[1]
+			continue
+		}
+		sums[strings.TrimPrefix(fields[1], "*")] = strings.ToLower(fields[0])
+	}
+	return version, sums, sc.Err()
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// fileHasSHA256 reports whether the file at p exists and has the given checksum.
+func fileHasSHA256(p, want string) bool {
+	f, err := os.Open(p)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return false
+	}
+	return hex.EncodeToString(h.Sum(nil)) == want
+}
+
+// ripgrepVersion returns the version reported by `rg --version`, or "".
+func ripgrepVersion(p string) string {
+	ctx, cancel := context.WithTimeout(context.Background(), ripgrepVersionTimeout)
+	defer cancel()
+	out, err := exec.CommandContext(ctx, p, "--version").Output()
+	if err != nil {
+		return ""
+	}
+	// "ripgrep 14.1.1 (rev ...)"
+	fields := strings.Fields(strings.SplitN(string(out), "\n", 2)[0])
+	if len(fields) >= 2 && fields[0] == "ripgrep" {
+		return fields[1]
+	}
+	return ""
+}
@@ -0,0 +1,65 @@
+package indexer
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestResolveRipgrep_BundledAndFallback(t *testing.T) {
+	cache := t.TempDir()
+	binary := []byte("#!/bin/sh\necho 'ripgrep 14.1.1 (rev 4649aa9700)'\n")
+	sum := sha256Hex(binary)
+	bundle := fstest.MapFS{
+		"rgbin/checksums.txt":      {Data: []byte("# test bundle\nversion 14.1.1\n" + sum + "  linux-x64/rg\n")},
+		"rgbin/linux-x64/rg":       {Data: binary},
+		"rgbin/linux-arm64/rg":     {Data: []byte("tampered")},
+		"rgbin/windows-x64/rg.exe": {Data: binary},
+	}
+	noSystem := func(string) (string, error) { return "", errors.New("not found") }
+	system := func(string) (string, error) { return "/usr/bin/rg", nil }
+
+	// A stale earlier version is removed when the new one is extracted
+	stale := filepath.Join(cache, "rg-13.0.0-linux-x64")
+	if err := os.MkdirAll(stale, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	bin := resolveRipgrep(bundle, cache, "linux", "amd64", noSystem)
+	want := filepath.Join(cache, "rg-14.1.1-linux-x64", "rg")
+	if bin.Source != RipgrepBundled || bin.Path != want || !bin.Intact || bin.SHA256 != sum || bin.Platform != "linux-x64" {
+		t.Fatalf("unexpected bundled binary %+v", bin)
+	}
+	if info, err := os.Stat(want); err != nil || info.Mode()&0o100 == 0 {
+		t.Fatalf("extracted binary should be executable: %v", err)
+	}
+	if _, err := os.Stat(stale); !os.IsNotExist(err) {
+		t.Error("the earlier version should be removed")
+	}
+
+	// A modified extraction is replaced on the next resolve
+	if err := os.WriteFile(want, []byte("modified"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if bin := resolveRipgrep(bundle, cache, "linux", "amd64", noSystem); !bin.Intact || !fileHasSHA256(want, sum) {
+		t.Fatalf("expected the binary to be extracted again, got %+v", bin)
+	}
+
+	// A checksum mismatch falls back to the system binary
+	bin = resolveRipgrep(bundle, cache, "linux", "arm64", system)
+	if bin.Source != RipgrepSystem || bin.Path != "/usr/bin/rg" || !strings.Contains(bin.Fallback, "checksum") {
+		t.Fatalf("unexpected fallback %+v", bin)
+	}
+	if _, err := os.Stat(filepath.Join(cache, "rg-14.1.1-linux-arm64")); !os.IsNotExist(err) {
+		t.Error("a binary that failed its integrity check must not be extracted")
+	}
+	// Binaries without a checksum line are not used either
+	if bin := resolveRipgrep(bundle, cache, "windows", "amd64", noSystem); bin.Source != RipgrepMissing || !strings.Contains(bin.Fallback, "no checksum") {
+		t.Fatalf("unexpected result %+v", bin)
+	}
+	if bin := resolveRipgrep(bundle, cache, "darwin", "arm64", system); bin.Source != RipgrepSystem || bin.Platform != "darwin-arm64" || !strings.Contains(bin.Fallback, "no bundled ripgrep") {
+		t.Fatalf("unexpected result %+v", bin)
+	}
+}
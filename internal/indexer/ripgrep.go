@@ -25,19 +25,45 @@ type RipgrepResult struct {
 	Error   string         `json:"error,omitempty"`
 }
 
-// RipgrepIndexer uses ripgrep to search code.
+// RipgrepIndexer uses ripgrep to search code, or the built-in search when ripgrep
+// cannot run.
 type RipgrepIndexer struct {
 	WorkspacePath string
-	mu            sync.Mutex
-	rgPath        string
+	// FollowSymlinks searches the targets of symlinks that stay inside the workspace
+	FollowSymlinks bool
+	mu             sync.Mutex
+	rgPath         string
+	// fallback explains why searches use the built-in search instead of ripgrep
+	fallback string
 }
 
-// NewRipgrepIndexer creates a new indexer using ripgrep.
+// NewRipgrepIndexer creates a new indexer using the binary ResolveRipgrep selects.
 func NewRipgrepIndexer(workspacePath string) *RipgrepIndexer {
-	return &RipgrepIndexer{
+	bin := ResolveRipgrep()
+	idx := &RipgrepIndexer{
 		WorkspacePath: workspacePath,
-		// Default to looking in PATH for ripgrep
-		rgPath: "rg",
+		rgPath:        bin.Path,
+	}
+	if bin.Path == "" {
+		// Neither bundled nor found on PATH
+		idx.fallback = "ripgrep not found: " + bin.Fallback
+	}
+	return idx
+}
+
+// Fallback returns why searches use the built-in search, or "" while ripgrep runs.
+func (rg *RipgrepIndexer) Fallback() string {
+	rg.mu.Lock()
+	defer rg.mu.Unlock()
+	return rg.fallback
+}
+
+// useFallback records that ripgrep cannot run, so later searches skip it.
+func (rg *RipgrepIndexer) useFallback(reason string) {
+	rg.mu.Lock()
+	defer rg.mu.Unlock()
+	if rg.fallback == "" {
+		rg.fallback = reason
 	}
 }
 
@@ -46,11 +72,18 @@ func (rg *RipgrepIndexer) Search(query string, filePattern string, maxResults in
 	rg.mu.Lock()
 	rgPath := rg.rgPath
 	workspacePath := rg.WorkspacePath
+	follow := rg.FollowSymlinks
+	fallback := rg.fallback
 	rg.mu.Unlock()
 
 	if maxResults <= 0 {
 		maxResults = 100 // Default limit
 	}
+	if fallback != "" {
+		return searchGo(workspacePath, query, filePattern, maxResults, follow)
+	}
+	// Search the real location of a symlinked workspace; paths are reported relative to it
+	root := realPath(workspacePath)
 
 	// Build ripgrep command with JSON output
 	args := []string{
@@ -63,6 +96,10 @@ func (rg *RipgrepIndexer) Search(query string, filePattern string, maxResults in
 	// Add max results limit
 	args = append(args, fmt.Sprintf("--max-count=%d", maxResults))
 
+	if follow {
+		args = append(args, "--follow")
+	}
+
 	// Add file pattern if specified
 	if filePattern != "" {
 		args = append(args, "--glob", filePattern)
@@ -78,7 +115,7 @@ func (rg *RipgrepIndexer) Search(query string, filePattern string, maxResults in
 	)
 
 	// Add search query and workspace path
-	args = append(args, query, workspacePath)
+	args = append(args, query, root)
 
 	// Create and execute command
 	cmd := exec.Command(rgPath, args...)
@@ -88,12 +125,16 @@ func (rg *RipgrepIndexer) Search(query string, filePattern string, maxResults in
 	}
 
 	if err := cmd.Start(); err != nil {
-		return nil, fmt.Errorf("failed to start ripgrep: %w", err)
+		// Missing exec permission, a binary for another platform and the like
+		rg.useFallback(fmt.Sprintf("ripgrep at %s cannot run: %v", rgPath, err))
+		return searchGo(workspacePath, query, filePattern, maxResults, follow)
 	}
 
 	// Parse JSON output
 	var result RipgrepResult
 	scanner := bufio.NewScanner(stdout)
+	// --follow also follows links leaving the workspace; their matches are dropped
+	inside := map[string]bool{}
 
 	for scanner.Scan() {
 		line := scanner.Text()
@@ -108,9 +149,19 @@ func (rg *RipgrepIndexer) Search(query string, filePattern string, maxResults in
 			if data, ok := jsonData["data"].(map[string]interface{}); ok {
 				// Get path info
 				path, _ := data["path"].(map[string]interface{})["text"].(string)
+				if follow {
+					ok, seen := inside[path]
+					if !seen {
+						ok = within(root, realPath(path))
+						inside[path] = ok
+					}
+					if !ok {
+						continue
+					}
+				}
 
 				// Make path relative to workspace
-				relPath, err := filepath.Rel(workspacePath, path)
+				relPath, err := filepath.Rel(root, path)
 				if err != nil {
 					relPath = path // Fallback to absolute path
 				}
@@ -151,9 +202,15 @@ func (rg *RipgrepIndexer) Search(query string, filePattern string, maxResults in
 	// Wait for command to complete
 	if err := cmd.Wait(); err != nil {
 		// ripgrep returns exit code 1 when no matches found, which isn't an error for us
-		if _, ok := err.(*exec.ExitError); !ok {
+		exit, ok := err.(*exec.ExitError)
+		if !ok {
 			return nil, fmt.Errorf("ripgrep search failed: %w", err)
 		}
+		// Killed before printing anything, as macOS does to a quarantined binary
+		if exit.ExitCode() == -1 && len(result.Matches) == 0 {
+			rg.useFallback(fmt.Sprintf("ripgrep at %s was killed: %v", rgPath, err))
+			return searchGo(workspacePath, query, filePattern, maxResults, follow)
+		}
 	}
 
 	return &result, nil
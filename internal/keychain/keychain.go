@@ -0,0 +1,230 @@
+// Package keychain stores small secrets in the operating system's credential store:
+// the login keychain on macOS (via `security`) and the Secret Service on Linux (via
+// `secret-tool`). Where neither is available, secrets fall back to owner-only files
+// under ~/.loom/keys.
+package keychain
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// ErrNotFound is returned when no secret is stored for the service and account.
+var ErrNotFound = errors.New("secret not found")
+
+// Backends a secret can be stored in.
+const (
+	BackendMacOS         = "macos-keychain"
+	BackendSecretService = "secret-service"
+	BackendFile          = "file"
+)
+
+// backend talks to one credential store.
+type backend struct {
+	name   string
+	get    func(service, account string) (string, error)
+	set    func(service, account, secret string) error
+	delete func(service, account string) error
+}
+
+// system returns the OS credential store, or nil when none is usable. Replaced in tests.
+var system = func() *backend {
+	switch runtime.GOOS {
+	case "darwin":
+		if _, err := exec.LookPath("security"); err == nil {
+			return macOS
+		}
+	case "linux", "freebsd", "openbsd":
+		// secret-tool needs a session bus to reach the Secret Service
+		if _, err := exec.LookPath("secret-tool"); err == nil && os.Getenv("DBUS_SESSION_BUS_ADDRESS") != "" {
+			return secretService
+		}
+	}
+	return nil
+}
+
+// fileDir is where the file backend keeps secrets. Replaced in tests.
+var fileDir = func() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve HOME: %w", err)
+	}
+	return filepath.Join(home, ".loom", "keys"), nil
+}
+
+// Backend names the store Set writes to.
+func Backend() string {
+	if b := system(); b != nil {
+		return b.name
+	}
+	return BackendFile
+}
+
+// Get returns the secret stored for service and account, looking in the OS
+// credential store first and then in the key files.
+func Get(service, account string) (string, error) {
+	if b := system(); b != nil {
+		secret, err := b.get(service, account)
+		if err == nil {
+			return secret, nil
+		}
+		if !errors.Is(err, ErrNotFound) {
+			return "", err
+		}
+	}
+	return fileBackend.get(service, account)
+}
+
+// Set stores secret for service and account in the OS credential store, or in an
+// owner-only key file when there is none.
+func Set(service, account, secret string) error {
+	if b := system(); b != nil {
+		return b.set(service, account, secret)
+	}
+	return fileBackend.set(service, account, secret)
+}
+
+// Delete removes the secret from every store. A missing secret is not an error.
+func Delete(service, account string) error {
+	if b := system(); b != nil {
+		if err := b.delete(service, account); err != nil && !errors.Is(err, ErrNotFound) {
+			return err
+		}
+	}
+	if err := fileBackend.delete(service, account); err != nil && !errors.Is(err, ErrNotFound) {
+		return err
+	}
+	return nil
+}
+
+var macOS = &backend{
+	name: BackendMacOS,
+	get: func(service, account string) (string, error) {
+		out, err := run(nil, "security", "find-generic-password", "-s", service, "-a", account, "-w")
+		if err != nil {
+			// security exits with 44 when the item does not exist
+			var exit *exec.ExitError
+			if errors.As(err, &exit) && exit.ExitCode() == 44 {
+				return "", ErrNotFound
+			}
+			return "", err
+		}
+		return strings.TrimRight(out, "\n"), nil
+	},
+	set: func(service, account, secret string) error {
+		_, err := run(nil, "security", "add-generic-password", "-U", "-s", service, "-a", account, "-w", secret)
+		return err
+	},
+	delete: func(service, account string) error {
+		_, err := run(nil, "security", "delete-generic-password", "-s", service, "-a", account)
+		var exit *exec.ExitError
+		if errors.As(err, &exit) && exit.ExitCode() == 44 {
+			return ErrNotFound
+		}
+		return err
+	},
+}
+
+var secretService = &backend{
+	name: BackendSecretService,
+	get: func(service, account string) (string, error) {
+		out, err := run(nil, "secret-tool", "lookup", "service", service, "account", account)
+		if err != nil {
+			// lookup exits with 1 and prints nothing when the item does not exist
+			var exit *exec.ExitError
+			if errors.As(err, &exit) && exit.ExitCode() == 1 && out == "" {
+				return "", ErrNotFound
+			}
+			return "", err
+		}
+		if out == "" {
+			return "", ErrNotFound
+		}
+		return strings.TrimRight(out, "\n"), nil
+	},
+	set: func(service, account, secret string) error {
+		// The secret is read from stdin so it never shows up in the process list
+		_, err := run(strings.NewReader(secret), "secret-tool", "store", "--label", service+" "+account, "service", service, "account", account)
+		return err
+	},
+	delete: func(service, account string) error {
+		_, err := run(nil, "secret-tool", "clear", "service", service, "account", account)
+		return err
+	},
+}
+
+var fileBackend = &backend{
+	name: BackendFile,
+	get: func(service, account string) (string, error) {
+		path, err := keyFile(service, account)
+		if err != nil {
+			return "", err
+		}
+		data, err := os.ReadFile(path)
+		if os.IsNotExist(err) {
+			return "", ErrNotFound
+		}
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimRight(string(data), "\n"), nil
+	},
+	set: func(service, account, secret string) error {
+		path, err := keyFile(service, account)
+		if err != nil {
+			return err
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+			return err
+		}
+		return os.WriteFile(path, []byte(secret), 0o600)
+	},
+	delete: func(service, account string) error {
+		path, err := keyFile(service, account)
+		if err != nil {
+			return err
+		}
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	},
+}
+
+// keyFile returns the file holding the secret of service and account.
+func keyFile(service, account string) (string, error) {
+	dir, err := fileDir()
+	if err != nil {
+		return "", err
+	}
+	name := strings.Map(func(r rune) rune {
+		if r == '/' || r == '\\' || r == ':' {
+			return '_'
+		}
+		return r
+	}, service+"-"+account)
+	return filepath.Join(dir, name), nil
+}
+
+// run executes a credential helper and returns its stdout. The error includes stderr.
+func run(stdin *strings.Reader, name string, args ...string) (string, error) {
+	cmd := exec.Command(name, args...)
+	if stdin != nil {
+		cmd.Stdin = stdin
+	}
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return stdout.String(), fmt.Errorf("%s: %w: %s", name, err, msg)
+		}
+		return stdout.String(), fmt.Errorf("%s: %w", name, err)
+	}
+	return stdout.String(), nil
+}
@@ -0,0 +1,38 @@
+package keychain
+
+import (
+	"errors"
+	"os"
+	"testing"
+)
+
+func TestFileBackend(t *testing.T) {
+	dir := t.TempDir()
+	oldSystem, oldDir := system, fileDir
+	system = func() *backend { return nil }
+	fileDir = func() (string, error) { return dir, nil }
+	t.Cleanup(func() { system, fileDir = oldSystem, oldDir })
+
+	if _, err := Get("loom", "workspace-a"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+	if Backend() != BackendFile {
+		t.Errorf("expected the file backend, got %s", Backend())
+	}
+	if err := Set("loom", "workspace-a", "s3cret"); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := Get("loom", "workspace-a"); err != nil || got != "s3cret" {
+		t.Fatalf("unexpected secret %q, %v", got, err)
+	}
+	path, _ := keyFile("loom", "workspace-a")
+	if st, err := os.Stat(path); err != nil || st.Mode().Perm() != 0o600 {
+		t.Errorf("key file should be owner-only: %v %v", st.Mode(), err)
+	}
+	if err := Delete("loom", "workspace-a"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Get("loom", "workspace-a"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected the secret to be gone, got %v", err)
+	}
+}
@@ -0,0 +1,83 @@
+package knowledge
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// maxChunkChars bounds a chunk's text; longer sections are split at blank lines.
+const maxChunkChars = 2000
+
+// docExts are the file types indexed in a knowledge pack.
+var docExts = map[string]bool{
+	".md": true, ".markdown": true, ".mdx": true, ".txt": true, ".rst": true, ".adoc": true,
+}
+
+// isDoc reports whether path is a documentation file a pack indexes.
+func isDoc(path string) bool {
+	return docExts[strings.ToLower(filepath.Ext(path))]
+}
+
+// chunk is a searchable piece of a document: a section under one heading.
+type chunk struct {
+	Heading string
+	Line    int // 1-indexed first line
+	Text    string
+}
+
+// chunkDocument splits a document into sections at markdown headings (ignoring
+// headings inside code fences), then splits oversized sections at blank lines.
+func chunkDocument(content string) []chunk {
+	lines := strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n")
+	var out []chunk
+	heading, start := "", 1
+	var body []string
+	inFence := false
+	flush := func() {
+		out = append(out, splitSection(heading, start, body)...)
+		body = nil
+	}
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			inFence = !inFence
+		}
+		if !inFence && strings.HasPrefix(trimmed, "#") {
+			if title := strings.TrimSpace(strings.TrimLeft(trimmed, "#")); title != "" && strings.HasPrefix(strings.TrimLeft(trimmed, "#"), " ") {
+				flush()
+				heading, start = title, i+1
+			}
+		}
+		body = append(body, line)
+	}
+	flush()
+	return out
+}
+
+// splitSection turns one section into chunks of at most maxChunkChars, breaking at
+// blank lines where possible. Empty sections produce no chunks.
+func splitSection(heading string, start int, lines []string) []chunk {
+	var out []chunk
+	var cur []string
+	size, curStart := 0, start
+	emit := func() {
+		text := strings.TrimSpace(strings.Join(cur, "\n"))
+		if text != "" {
+			out = append(out, chunk{Heading: heading, Line: curStart, Text: text})
+		}
+		cur, size = nil, 0
+	}
+	for i, line := range lines {
+		if size+len(line) > maxChunkChars && size > 0 && (strings.TrimSpace(line) == "" || size > maxChunkChars) {
+			emit()
+			curStart = start + i
+		}
+		if len(cur) == 0 {
+			curStart = start + i
+		}
+		cur = append(cur, line)
+		size += len(line) + 1
+	}
+	emit()
+	return out
+}
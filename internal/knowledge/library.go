@@ -0,0 +1,422 @@
+// Package knowledge indexes external documentation folders (API docs, runbooks,
+// markdown wikis) as read-only knowledge packs that workspaces can search.
+package knowledge
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+const (
+	packsFile      = "packs.json"
+	workspacesFile = "workspaces.json"
+	indexFile      = "index.db"
+	// maxDocBytes skips documents too large to be useful as search results
+	maxDocBytes = 2 << 20
+	// maxPackFiles bounds how many documents one pack indexes
+	maxPackFiles = 20000
+)
+
+// Pack is an external documentation folder registered as a knowledge source.
+// Loom only reads pack folders; nothing ever writes to them.
+type Pack struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	Path      string    `json:"path"`
+	Files     int       `json:"files"`
+	Chunks    int       `json:"chunks"`
+	Bytes     int64     `json:"bytes"`
+	IndexedAt time.Time `json:"indexed_at"`
+}
+
+// Hit is one search result: a section of a document in a pack.
+type Hit struct {
+	Pack     string `json:"pack"`
+	PackName string `json:"pack_name"`
+	// Path is relative to the pack folder
+	Path    string  `json:"path"`
+	Heading string  `json:"heading,omitempty"`
+	Line    int     `json:"line"`
+	Snippet string  `json:"snippet"`
+	Score   float64 `json:"score"`
+}
+
+// Library is the set of registered packs and their full-text index, stored under
+// ~/.loom/knowledge. Workspace selections are kept alongside, keyed by absolute path.
+type Library struct {
+	dir string
+	db  *sql.DB
+	mu  sync.Mutex
+}
+
+var (
+	sharedOnce sync.Once
+	shared     *Library
+	sharedErr  error
+)
+
+// Shared returns the process-wide library in ~/.loom/knowledge, opening it on first use.
+func Shared() (*Library, error) {
+	sharedOnce.Do(func() {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			sharedErr = err
+			return
+		}
+		shared, sharedErr = Open(filepath.Join(home, ".loom", "knowledge"))
+	})
+	return shared, sharedErr
+}
+
+// Open opens (creating if needed) the library stored in dir.
+func Open(dir string) (*Library, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	dsn := fmt.Sprintf("file:%s?_busy_timeout=8000", filepath.ToSlash(filepath.Join(dir, indexFile)))
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, err
+	}
+	stmts := []string{
+		`PRAGMA journal_mode=WAL;`,
+		`CREATE VIRTUAL TABLE IF NOT EXISTS chunks USING fts5(pack UNINDEXED, path UNINDEXED, line UNINDEXED, heading, content, tokenize='porter unicode61');`,
+	}
+	for _, stmt := range stmts {
+		if _, err := db.Exec(stmt); err != nil {
+			_ = db.Close()
+			return nil, fmt.Errorf("init knowledge index: %w", err)
+		}
+	}
+	return &Library{dir: dir, db: db}, nil
+}
+
+// Close releases the index database.
+func (l *Library) Close() error {
+	return l.db.Close()
+}
+
+// Packs returns the registered packs sorted by name.
+func (l *Library) Packs() []Pack {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.loadPacks()
+}
+
+// Add registers dir as a pack and indexes it. Registering the same folder again
+// reindexes the existing pack.
+func (l *Library) Add(ctx context.Context, dir, name string) (Pack, error) {
+	abs, err := filepath.Abs(strings.TrimSpace(dir))
+	if err != nil {
+		return Pack{}, err
+	}
+	info, err := os.Stat(abs)
+	if err != nil {
+		return Pack{}, err
+	}
+	if !info.IsDir() {
+		return Pack{}, fmt.Errorf("%s is not a directory", abs)
+	}
+	name = strings.TrimSpace(name)
+	if name == "" {
+		name = filepath.Base(abs)
+	}
+	sum := sha256.Sum256([]byte(abs))
+	return l.index(ctx, Pack{ID: hex.EncodeToString(sum[:])[:12], Name: name, Path: abs})
+}
+
+// Reindex rebuilds a pack's index from its folder.
+func (l *Library) Reindex(ctx context.Context, id string) (Pack, error) {
+	p, ok := l.pack(id)
+	if !ok {
+		return Pack{}, fmt.Errorf("knowledge pack %q not found", id)
+	}
+	return l.index(ctx, p)
+}
+
+// Remove unregisters a pack, drops its index and deselects it everywhere.
+func (l *Library) Remove(id string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	packs := l.loadPacks()
+	kept := packs[:0]
+	for _, p := range packs {
+		if p.ID != id {
+			kept = append(kept, p)
+		}
+	}
+	if len(kept) == len(packs) {
+		return fmt.Errorf("knowledge pack %q not found", id)
+	}
+	if _, err := l.db.Exec(`DELETE FROM chunks WHERE pack=?`, id); err != nil {
+		return err
+	}
+	sel := l.loadSelections()
+	for ws, ids := range sel {
+		if ids = without(ids, id); len(ids) == 0 {
+			delete(sel, ws)
+		} else {
+			sel[ws] = ids
+		}
+	}
+	if err := l.writeJSON(workspacesFile, sel); err != nil {
+		return err
+	}
+	return l.writeJSON(packsFile, kept)
+}
+
+// Enabled returns the IDs of the packs selected for a workspace.
+func (l *Library) Enabled(workspacePath string) []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.loadSelections()[workspaceKey(workspacePath)]
+}
+
+// SetEnabled selects which packs a workspace searches. Unknown IDs are rejected.
+func (l *Library) SetEnabled(workspacePath string, ids []string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	known := map[string]bool{}
+	for _, p := range l.loadPacks() {
+		known[p.ID] = true
+	}
+	var clean []string
+	for _, id := range ids {
+		if !known[id] {
+			return fmt.Errorf("knowledge pack %q not found", id)
+		}
+		if !slices.Contains(clean, id) {
+			clean = append(clean, id)
+		}
+	}
+	sel := l.loadSelections()
+	if len(clean) == 0 {
+		delete(sel, workspaceKey(workspacePath))
+	} else {
+		sel[workspaceKey(workspacePath)] = clean
+	}
+	return l.writeJSON(workspacesFile, sel)
+}
+
+// Search runs a ranked full-text query over the given packs.
+func (l *Library) Search(ctx context.Context, query string, packIDs []string, limit int) ([]Hit, error) {
+	if limit <= 0 || limit > 50 {
+		limit = 10
+	}
+	match := ftsQuery(query)
+	if match == "" || len(packIDs) == 0 {
+		return nil, nil
+	}
+	names := map[string]string{}
+	for _, p := range l.Packs() {
+		names[p.ID] = p.Name
+	}
+	args := []any{match}
+	marks := make([]string, 0, len(packIDs))
+	for _, id := range packIDs {
+		args = append(args, id)
+		marks = append(marks, "?")
+	}
+	args = append(args, limit)
+	rows, err := l.db.QueryContext(ctx, `
+            SELECT pack, path, line, heading, snippet(chunks, 4, '', '', ' … ', 48), bm25(chunks, 0, 0, 0, 4.0, 1.0)
+            FROM chunks
+            WHERE chunks MATCH ? AND pack IN (`+strings.Join(marks, ",")+`)
+            ORDER BY bm25(chunks, 0, 0, 0, 4.0, 1.0)
+            LIMIT ?`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var hits []Hit
+	for rows.Next() {
+		var h Hit
+		if err := rows.Scan(&h.Pack, &h.Path, &h.Line, &h.Heading, &h.Snippet, &h.Score); err != nil {
+			return nil, err
+		}
+		// bm25 is lower-is-better; report higher-is-better scores
+		h.Score = -h.Score
+		h.PackName = names[h.Pack]
+		hits = append(hits, h)
+	}
+	return hits, rows.Err()
+}
+
+// Section returns the full text of the indexed section of a pack document that
+// starts at or contains line.
+func (l *Library) Section(ctx context.Context, id, path string, line int) (Hit, string, error) {
+	p, ok := l.pack(id)
+	if !ok {
+		return Hit{}, "", fmt.Errorf("knowledge pack %q not found", id)
+	}
+	var h Hit
+	var content string
+	err := l.db.QueryRowContext(ctx, `
+            SELECT path, line, heading, content FROM chunks
+            WHERE pack=? AND path=? AND CAST(line AS INTEGER)<=?
+            ORDER BY CAST(line AS INTEGER) DESC LIMIT 1`, id, filepath.ToSlash(path), max(line, 1)).
+		Scan(&h.Path, &h.Line, &h.Heading, &content)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Hit{}, "", fmt.Errorf("%s is not indexed in pack %s", path, p.Name)
+	}
+	if err != nil {
+		return Hit{}, "", err
+	}
+	h.Pack, h.PackName = p.ID, p.Name
+	return h, content, nil
+}
+
+// index replaces a pack's rows with a fresh walk of its folder and records the pack.
+func (l *Library) index(ctx context.Context, p Pack) (Pack, error) {
+	p.Files, p.Chunks, p.Bytes = 0, 0, 0
+	tx, err := l.db.BeginTx(ctx, nil)
+	if err != nil {
+		return p, err
+	}
+	defer func() { _ = tx.Rollback() }()
+	if _, err := tx.ExecContext(ctx, `DELETE FROM chunks WHERE pack=?`, p.ID); err != nil {
+		return p, err
+	}
+	stmt, err := tx.PrepareContext(ctx, `INSERT INTO chunks(pack, path, line, heading, content) VALUES(?,?,?,?,?)`)
+	if err != nil {
+		return p, err
+	}
+	defer stmt.Close()
+	walkErr := filepath.WalkDir(p.Path, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		if d.IsDir() {
+			if path != p.Path && (strings.HasPrefix(d.Name(), ".") || d.Name() == "node_modules") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !isDoc(path) || p.Files >= maxPackFiles {
+			return nil
+		}
+		if fi, e := d.Info(); e != nil || !fi.Mode().IsRegular() || fi.Size() > maxDocBytes {
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil
+		}
+		rel, _ := filepath.Rel(p.Path, path)
+		rel = filepath.ToSlash(rel)
+		for _, c := range chunkDocument(string(data)) {
+			if _, err := stmt.ExecContext(ctx, p.ID, rel, c.Line, c.Heading, c.Text); err != nil {
+				return err
+			}
+			p.Chunks++
+		}
+		p.Files++
+		p.Bytes += int64(len(data))
+		return nil
+	})
+	if walkErr != nil {
+		return p, walkErr
+	}
+	if err := tx.Commit(); err != nil {
+		return p, err
+	}
+	p.IndexedAt = time.Now()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	packs := l.loadPacks()
+	replaced := false
+	for i := range packs {
+		if packs[i].ID == p.ID {
+			packs[i], replaced = p, true
+		}
+	}
+	if !replaced {
+		packs = append(packs, p)
+	}
+	return p, l.writeJSON(packsFile, packs)
+}
+
+func (l *Library) pack(id string) (Pack, bool) {
+	for _, p := range l.Packs() {
+		if p.ID == id {
+			return p, true
+		}
+	}
+	return Pack{}, false
+}
+
+// loadPacks reads packs.json; callers hold l.mu.
+func (l *Library) loadPacks() []Pack {
+	var packs []Pack
+	if data, err := os.ReadFile(filepath.Join(l.dir, packsFile)); err == nil {
+		_ = json.Unmarshal(data, &packs)
+	}
+	sort.Slice(packs, func(i, j int) bool { return strings.ToLower(packs[i].Name) < strings.ToLower(packs[j].Name) })
+	return packs
+}
+
+// loadSelections reads workspaces.json; callers hold l.mu.
+func (l *Library) loadSelections() map[string][]string {
+	sel := map[string][]string{}
+	if data, err := os.ReadFile(filepath.Join(l.dir, workspacesFile)); err == nil {
+		_ = json.Unmarshal(data, &sel)
+	}
+	return sel
+}
+
+func (l *Library) writeJSON(name string, v any) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(l.dir, name), data, 0o644)
+}
+
+func workspaceKey(workspacePath string) string {
+	if abs, err := filepath.Abs(workspacePath); err == nil {
+		return abs
+	}
+	return filepath.Clean(workspacePath)
+}
+
+var queryTerm = regexp.MustCompile(`[\p{L}\p{N}_]+`)
+
+// ftsQuery turns free text into an FTS5 query matching any of its terms, quoting
+// each so punctuation in the input cannot produce a syntax error.
+func ftsQuery(q string) string {
+	terms := queryTerm.FindAllString(q, 32)
+	for i, t := range terms {
+		terms[i] = `"` + t + `"`
+	}
+	return strings.Join(terms, " OR ")
+}
+
+func without(list []string, s string) []string {
+	out := []string{}
+	for _, v := range list {
+		if v != s {
+			out = append(out, v)
+		}
+	}
+	return out
+}
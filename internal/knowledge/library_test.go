@@ -0,0 +1,129 @@
+package knowledge
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeDocs(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestChunkDocument_SplitsAtHeadingsOutsideFences(t *testing.T) {
+	doc := "intro text\n# Deploy\nrun make deploy\n```sh\n# not a heading\n```\n## Rollback\nrevert the tag\n"
+	chunks := chunkDocument(doc)
+	if len(chunks) != 3 {
+		t.Fatalf("expected 3 chunks, got %d: %+v", len(chunks), chunks)
+	}
+	if chunks[1].Heading != "Deploy" || chunks[1].Line != 2 || !strings.Contains(chunks[1].Text, "# not a heading") {
+		t.Errorf("unexpected deploy chunk: %+v", chunks[1])
+	}
+	if chunks[2].Heading != "Rollback" || chunks[2].Line != 7 {
+		t.Errorf("unexpected rollback chunk: %+v", chunks[2])
+	}
+}
+
+func TestChunkDocument_SplitsLongSections(t *testing.T) {
+	para := strings.Repeat("word ", 200) + "\n\n"
+	chunks := chunkDocument("# Long\n" + strings.Repeat(para, 6))
+	if len(chunks) < 2 {
+		t.Fatalf("expected the section to be split, got %d chunk(s)", len(chunks))
+	}
+	for _, c := range chunks {
+		if len(c.Text) > maxChunkChars+len(para) {
+			t.Errorf("chunk too large: %d chars", len(c.Text))
+		}
+	}
+}
+
+func TestLibrary_AddSearchAndSelect(t *testing.T) {
+	ctx := context.Background()
+	lib, err := Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lib.Close()
+
+	docs := writeDocs(t, map[string]string{
+		"runbooks/payments.md": "# Payments outage\nRestart the ledger worker, then drain the retry queue.\n",
+		"api/users.md":         "# Users API\nGET /users returns a page of users.\n",
+		"image.png":            "not a doc",
+		".git/notes.md":        "# Hidden\nledger worker\n",
+	})
+	before := snapshot(t, docs)
+
+	pack, err := lib.Add(ctx, docs, "Team docs")
+	if err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	if pack.Files != 2 || pack.Chunks != 2 {
+		t.Errorf("expected 2 files and 2 chunks, got %+v", pack)
+	}
+
+	hits, err := lib.Search(ctx, "ledger worker?", []string{pack.ID}, 5)
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if len(hits) != 1 || hits[0].Path != "runbooks/payments.md" || hits[0].Heading != "Payments outage" || hits[0].PackName != "Team docs" {
+		t.Fatalf("unexpected hits: %+v", hits)
+	}
+	if hits, _ := lib.Search(ctx, "ledger", []string{"other"}, 5); len(hits) != 0 {
+		t.Errorf("search must be limited to the given packs, got %+v", hits)
+	}
+
+	_, section, err := lib.Section(ctx, pack.ID, "runbooks/payments.md", 2)
+	if err != nil || !strings.Contains(section, "drain the retry queue") {
+		t.Errorf("Section = %q, %v", section, err)
+	}
+
+	ws := t.TempDir()
+	if err := lib.SetEnabled(ws, []string{pack.ID}); err != nil {
+		t.Fatal(err)
+	}
+	if got := lib.Enabled(ws); len(got) != 1 || got[0] != pack.ID {
+		t.Errorf("Enabled = %v", got)
+	}
+	if err := lib.SetEnabled(ws, []string{"missing"}); err == nil {
+		t.Error("expected unknown pack to be rejected")
+	}
+
+	if err := lib.Remove(pack.ID); err != nil {
+		t.Fatal(err)
+	}
+	if len(lib.Packs()) != 0 || len(lib.Enabled(ws)) != 0 {
+		t.Error("remove should drop the pack and its selections")
+	}
+	if hits, _ := lib.Search(ctx, "ledger", []string{pack.ID}, 5); len(hits) != 0 {
+		t.Errorf("removed pack still searchable: %+v", hits)
+	}
+	if after := snapshot(t, docs); after != before {
+		t.Error("indexing must not modify the pack folder")
+	}
+}
+
+func snapshot(t *testing.T, dir string) string {
+	t.Helper()
+	var b strings.Builder
+	_ = filepath.Walk(dir, func(p string, info os.FileInfo, err error) error {
+		if err == nil && !info.IsDir() {
+			data, _ := os.ReadFile(p)
+			b.WriteString(p + "\x00" + string(data) + "\x00")
+		}
+		return nil
+	})
+	return b.String()
+}
@@ -17,6 +17,7 @@ import (
 	"time"
 
 	"github.com/loom/loom/internal/config"
+	"github.com/loom/loom/internal/proxy"
 )
 
 // ToolSpec represents a single MCP tool as reported by the server
@@ -43,6 +44,12 @@ type Client struct {
 	waitMu   sync.Mutex
 	waiters  map[int64]chan any
 	readOnce sync.Once
+	// exited is set once the read loop stops, i.e. the server closed its stdout
+	exited atomic.Bool
+	// lastErr is the most recent initialization failure, guarded by mu
+	lastErr string
+	// caps are the capabilities the server declared in its initialize result, guarded by mu
+	caps map[string]any
 }
 
 func NewClient(alias string, cfg config.MCPServerConfig) (*Client, error) {
@@ -50,8 +57,9 @@ func NewClient(alias string, cfg config.MCPServerConfig) (*Client, error) {
 		return nil, fmt.Errorf("mcp server %s: command is empty", alias)
 	}
 	cmd := exec.Command(cfg.Command, cfg.Args...)
-	if len(cfg.Env) > 0 {
-		cmd.Env = append(os.Environ(), cfg.Env...)
+	// The configured proxy reaches the server unless its own env overrides it
+	if env := append(proxy.Current().Env(), cfg.Env...); len(env) > 0 {
+		cmd.Env = append(os.Environ(), env...)
 	}
 	stdin, err := cmd.StdinPipe()
 	if err != nil {
@@ -85,6 +93,9 @@ func NewClient(alias string, cfg config.MCPServerConfig) (*Client, error) {
 		defer cancel()
 		if err := c.EnsureInitialized(ctx); err != nil {
 			log.Printf("[mcp] %s: initialization failed: %v", alias, err)
+			c.mu.Lock()
+			c.lastErr = err.Error()
+			c.mu.Unlock()
 		} else {
 			log.Printf("[mcp] %s: initialized", alias)
 		}
@@ -92,6 +103,24 @@ func NewClient(alias string, cfg config.MCPServerConfig) (*Client, error) {
 	return c, nil
 }
 
+// Status reports whether the server process is still connected and initialized.
+func (c *Client) Status() ServerStatus {
+	st := ServerStatus{Name: c.alias, Running: !c.exited.Load()}
+	if c.cmd != nil && c.cmd.Process != nil {
+		st.PID = c.cmd.Process.Pid
+	}
+	c.mu.Lock()
+	st.Initialized = c.inited
+	if !st.Initialized {
+		st.Error = c.lastErr
+	}
+	c.mu.Unlock()
+	if !st.Running && st.Error == "" {
+		st.Error = "server process exited"
+	}
+	return st
+}
+
 func (c *Client) Close() {
 	_ = c.stdin.Close()
 	_ = c.cmd.Process.Kill()
@@ -150,7 +179,12 @@ func (c *Client) initializeWithVersion(ctx context.Context, version string) erro
 	res, err := c.request(ctx, "initialize", params)
 	if err == nil {
 		log.Printf("[mcp] %s: initialize result received", c.alias)
-		_ = res
+		if m, ok := res.(map[string]any); ok {
+			caps, _ := m["capabilities"].(map[string]any)
+			c.mu.Lock()
+			c.caps = caps
+			c.mu.Unlock()
+		}
 	}
 	return err
 }
@@ -332,6 +366,7 @@ func (c *Client) request(ctx context.Context, method string, params any) (any, e
 
 // readLoop runs once per client and dispatches responses/notifications
 func (c *Client) readLoop() {
+	defer c.exited.Store(true)
 	reader := c.stdout
 	for {
 		// Primary: newline-delimited JSON
@@ -0,0 +1,85 @@
+package mcp
+
+import (
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/loom/loom/internal/config"
+	"github.com/loom/loom/internal/tool"
+)
+
+// CuratedTool is a server tool as exposed to the model after the server's tool
+// overrides are applied.
+type CuratedTool struct {
+	Server      string         `json:"server"`
+	Tool        string         `json:"tool"` // name reported by the server
+	Name        string         `json:"name"` // name exposed to the model
+	Description string         `json:"description"`
+	InputSchema map[string]any `json:"-"`
+	Safe        bool           `json:"safe"`
+	Hidden      bool           `json:"hidden,omitempty"`
+	// Problem explains why a tool is not exposed besides being hidden, e.g. a name clash
+	Problem string `json:"problem,omitempty"`
+}
+
+// DefaultToolName is the name a server tool is exposed under without an override.
+func DefaultToolName(server, name string) string {
+	return tool.SanitizeToolName("mcp_" + server + "__" + name)
+}
+
+// Curate applies cfg's tool overrides to the tools a server reported, sorted by
+// server tool name. taken reports names already used by other tools; exposed names are
+// added to it so a later server cannot claim them. Hidden tools and tools whose name
+// clashes are returned with Hidden or Problem set and must not be registered.
+func Curate(server string, cfg config.MCPServerConfig, specs []ToolSpec, taken map[string]bool) []CuratedTool {
+	sorted := append([]ToolSpec(nil), specs...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+
+	seen := map[string]bool{}
+	out := make([]CuratedTool, 0, len(sorted))
+	for _, spec := range sorted {
+		seen[spec.Name] = true
+		override := cfg.Tools[spec.Name]
+		ct := CuratedTool{
+			Server:      server,
+			Tool:        spec.Name,
+			Name:        DefaultToolName(server, spec.Name),
+			Description: spec.Description,
+			InputSchema: spec.InputSchema,
+			Safe:        cfg.Safe,
+			Hidden:      override.Hidden,
+		}
+		if name := strings.TrimSpace(override.Name); name != "" {
+			ct.Name = tool.SanitizeToolName(name)
+		}
+		if desc := strings.TrimSpace(override.Description); desc != "" {
+			ct.Description = desc
+		}
+		switch strings.ToLower(strings.TrimSpace(override.Approval)) {
+		case "":
+		case config.MCPApprovalAuto:
+			ct.Safe = true
+		case config.MCPApprovalAsk:
+			ct.Safe = false
+		default:
+			// An unknown policy never loosens approval
+			log.Printf("[mcp] %s: tool %s has unknown approval %q; asking before each call", server, spec.Name, override.Approval)
+			ct.Safe = false
+		}
+		if !ct.Hidden {
+			if taken[ct.Name] {
+				ct.Problem = "name " + ct.Name + " is already used by another tool"
+			} else if taken != nil {
+				taken[ct.Name] = true
+			}
+		}
+		out = append(out, ct)
+	}
+	for name := range cfg.Tools {
+		if !seen[name] {
+			log.Printf("[mcp] %s: tool override for %q matches no tool of the server", server, name)
+		}
+	}
+	return out
+}
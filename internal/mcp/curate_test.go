@@ -0,0 +1,69 @@
+package mcp
+
+import (
+	"testing"
+
+	"github.com/loom/loom/internal/config"
+)
+
+func TestCurate_AppliesToolOverrides(t *testing.T) {
+	cfg := config.MCPServerConfig{
+		Safe: true,
+		Tools: map[string]config.MCPToolConfig{
+			"jira_get_issue":    {Name: "jira issue", Description: "Fetch one Jira issue by key."},
+			"jira_delete_issue": {Approval: config.MCPApprovalAsk},
+			"confluence_debug":  {Hidden: true},
+		},
+	}
+	specs := []ToolSpec{
+		{Name: "jira_get_issue", Description: "get_issue"},
+		{Name: "jira_delete_issue", Description: "delete"},
+		{Name: "confluence_debug", Description: "debug"},
+		{Name: "jira-search", Description: "search"},
+	}
+	tools := Curate("atlassian", cfg, specs, map[string]bool{})
+	byTool := map[string]CuratedTool{}
+	for _, ct := range tools {
+		byTool[ct.Tool] = ct
+	}
+
+	if got := byTool["jira_get_issue"]; got.Name != "jira_issue" || got.Description != "Fetch one Jira issue by key." || !got.Safe {
+		t.Fatalf("expected the renamed, redescribed tool, got %+v", got)
+	}
+	if got := byTool["jira_delete_issue"]; got.Safe || got.Name != "mcp_atlassian__jira_delete_issue" {
+		t.Fatalf("expected the ask policy to override the safe server, got %+v", got)
+	}
+	if !byTool["confluence_debug"].Hidden {
+		t.Fatal("expected the hidden tool to be marked hidden")
+	}
+	if got := byTool["jira-search"]; got.Name != "mcp_atlassian__jira_search" || got.Description != "search" || !got.Safe {
+		t.Fatalf("expected server defaults without an override, got %+v", got)
+	}
+}
+
+func TestCurate_ReportsNameClashes(t *testing.T) {
+	taken := map[string]bool{"read_file": true}
+	cfg := config.MCPServerConfig{Tools: map[string]config.MCPToolConfig{
+		"read":  {Name: "read_file"},
+		"fetch": {Name: "get", Approval: "sometimes"},
+		"get":   {Name: "get"},
+	}}
+	tools := Curate("fs", cfg, []ToolSpec{{Name: "read"}, {Name: "get"}, {Name: "fetch"}}, taken)
+
+	var problems []string
+	for _, ct := range tools {
+		if ct.Problem != "" {
+			problems = append(problems, ct.Tool)
+		}
+		if ct.Tool == "fetch" && ct.Safe {
+			t.Fatal("an unknown approval policy must not skip approval")
+		}
+	}
+	// Tools are curated in name order, so "fetch" claims "get" before "get" does
+	if len(problems) != 2 || problems[0] != "get" || problems[1] != "read" {
+		t.Fatalf("expected the clashing tools to be reported, got %v", problems)
+	}
+	if !taken["get"] {
+		t.Fatal("expected exposed names to be marked taken")
+	}
+}
@@ -20,12 +20,39 @@ import (
 type Manager struct {
 	mu      sync.RWMutex
 	clients map[string]*Client // alias -> client
+	// failed holds the start error of configured servers that have no client
+	failed map[string]string
 	// lastCfgHash is a stable hash of the last applied config set, used to make Start idempotent
 	lastCfgHash string
 }
 
 func NewManager() *Manager {
-	return &Manager{clients: make(map[string]*Client)}
+	return &Manager{clients: make(map[string]*Client), failed: make(map[string]string)}
+}
+
+// ServerStatus is the connectivity of one configured MCP server.
+type ServerStatus struct {
+	Name        string `json:"name"`
+	Running     bool   `json:"running"`
+	Initialized bool   `json:"initialized"`
+	PID         int    `json:"pid,omitempty"`
+	Error       string `json:"error,omitempty"`
+}
+
+// Status returns the state of every configured server, sorted by name. Servers whose
+// process could not be started are included with their start error.
+func (m *Manager) Status() []ServerStatus {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	out := make([]ServerStatus, 0, len(m.clients)+len(m.failed))
+	for _, c := range m.clients {
+		out = append(out, c.Status())
+	}
+	for alias, msg := range m.failed {
+		out = append(out, ServerStatus{Name: alias, Error: msg})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
 }
 
 // Start creates clients for all configured servers. Idempotent: if the config
@@ -42,6 +69,7 @@ func (m *Manager) Start(cfgs map[string]config.MCPServerConfig) error {
 	// For changed config, restart only the aliases that changed; keep identical ones running
 	// Build current map for comparison
 	newClients := make(map[string]*Client)
+	failed := make(map[string]string)
 	for alias, cfg := range cfgs {
 		// If an existing client for alias exists and its canonicalized config matches, reuse it
 		if existing, ok := m.clients[alias]; ok {
@@ -66,6 +94,7 @@ func (m *Manager) Start(cfgs map[string]config.MCPServerConfig) error {
 		c, err := NewClient(alias, cfg)
 		if err != nil {
 			log.Printf("[mcp] %s: failed to start client: %v", alias, err)
+			failed[alias] = err.Error()
 			continue
 		}
 		log.Printf("[mcp] Start(alias=%s): started pid=%d", alias, c.cmd.Process.Pid)
@@ -78,6 +107,7 @@ func (m *Manager) Start(cfgs map[string]config.MCPServerConfig) error {
 		}
 	}
 	m.clients = newClients
+	m.failed = failed
 	log.Printf("[mcp] Start: new hash=%s (aliases now=%d)", hash[:8], len(newClients))
 	m.lastCfgHash = hash
 	return nil
@@ -90,6 +120,7 @@ func (m *Manager) StopAll() {
 		c.Close()
 	}
 	m.clients = make(map[string]*Client)
+	m.failed = make(map[string]string)
 	m.lastCfgHash = ""
 }
 
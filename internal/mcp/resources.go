@@ -0,0 +1,407 @@
+package mcp
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+)
+
+// maxListPages bounds how many pages of a paginated list are fetched from one server
+const maxListPages = 20
+
+// ResourceSpec is a resource a server offers as context, as reported by resources/list
+type ResourceSpec struct {
+	Server      string `json:"server"`
+	URI         string `json:"uri"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	MimeType    string `json:"mime_type,omitempty"`
+}
+
+// ResourceContent is one item returned by resources/read. Text resources set Text;
+// binary ones set Data.
+type ResourceContent struct {
+	URI      string
+	MimeType string
+	Text     string
+	Data     []byte
+}
+
+// PromptArgument is a parameter of a server prompt template
+type PromptArgument struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Required    bool   `json:"required,omitempty"`
+}
+
+// PromptSpec is a prompt template a server offers, as reported by prompts/list
+type PromptSpec struct {
+	Server      string           `json:"server"`
+	Name        string           `json:"name"`
+	Description string           `json:"description,omitempty"`
+	Arguments   []PromptArgument `json:"arguments,omitempty"`
+}
+
+// PromptMessage is one message of a rendered prompt
+type PromptMessage struct {
+	Role string
+	Text string
+}
+
+// supports reports whether the server declared a capability. Servers that sent no
+// capabilities at all are given the benefit of the doubt.
+func (c *Client) supports(capability string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.caps == nil {
+		return true
+	}
+	_, ok := c.caps[capability]
+	return ok
+}
+
+// listPaged calls a paginated list method and collects the items under key
+func (c *Client) listPaged(ctx context.Context, method, key string) ([]map[string]any, error) {
+	if err := c.EnsureInitialized(ctx); err != nil {
+		return nil, err
+	}
+	var out []map[string]any
+	cursor := ""
+	for page := 0; page < maxListPages; page++ {
+		params := map[string]any{}
+		if cursor != "" {
+			params["cursor"] = cursor
+		}
+		res, err := c.request(ctx, method, params)
+		if err != nil {
+			return nil, err
+		}
+		m, _ := res.(map[string]any)
+		arr, _ := m[key].([]any)
+		for _, it := range arr {
+			if im, ok := it.(map[string]any); ok {
+				out = append(out, im)
+			}
+		}
+		cursor, _ = m["nextCursor"].(string)
+		if cursor == "" {
+			break
+		}
+	}
+	return out, nil
+}
+
+// ListResources queries the server for the resources it offers
+func (c *Client) ListResources(ctx context.Context) ([]ResourceSpec, error) {
+	if !c.supports("resources") {
+		return nil, nil
+	}
+	items, err := c.listPaged(ctx, "resources/list", "resources")
+	if err != nil {
+		return nil, err
+	}
+	var out []ResourceSpec
+	for _, it := range items {
+		uri, _ := it["uri"].(string)
+		if uri == "" {
+			continue
+		}
+		r := ResourceSpec{Server: c.alias, URI: uri}
+		r.Name, _ = it["name"].(string)
+		r.Description, _ = it["description"].(string)
+		r.MimeType, _ = it["mimeType"].(string)
+		if r.Name == "" {
+			r.Name = uri
+		}
+		out = append(out, r)
+	}
+	return out, nil
+}
+
+// ReadResource fetches the contents of a resource
+func (c *Client) ReadResource(ctx context.Context, uri string) ([]ResourceContent, error) {
+	if err := c.EnsureInitialized(ctx); err != nil {
+		return nil, err
+	}
+	res, err := c.request(ctx, "resources/read", map[string]any{"uri": uri})
+	if err != nil {
+		return nil, err
+	}
+	m, _ := res.(map[string]any)
+	arr, _ := m["contents"].([]any)
+	var out []ResourceContent
+	for _, it := range arr {
+		im, ok := it.(map[string]any)
+		if !ok {
+			continue
+		}
+		rc := ResourceContent{URI: uri}
+		if u, _ := im["uri"].(string); u != "" {
+			rc.URI = u
+		}
+		rc.MimeType, _ = im["mimeType"].(string)
+		if text, ok := im["text"].(string); ok {
+			rc.Text = text
+		} else if blob, ok := im["blob"].(string); ok {
+			data, err := base64.StdEncoding.DecodeString(blob)
+			if err != nil {
+				return nil, fmt.Errorf("resource %s: invalid blob: %v", rc.URI, err)
+			}
+			rc.Data = data
+		}
+		out = append(out, rc)
+	}
+	if len(out) == 0 {
+		return nil, fmt.Errorf("resource %s has no contents", uri)
+	}
+	return out, nil
+}
+
+// ListPrompts queries the server for its prompt templates
+func (c *Client) ListPrompts(ctx context.Context) ([]PromptSpec, error) {
+	if !c.supports("prompts") {
+		return nil, nil
+	}
+	items, err := c.listPaged(ctx, "prompts/list", "prompts")
+	if err != nil {
+		return nil, err
+	}
+	var out []PromptSpec
+	for _, it := range items {
+		name, _ := it["name"].(string)
+		if name == "" {
+			continue
+		}
+		p := PromptSpec{Server: c.alias, Name: name}
+		p.Description, _ = it["description"].(string)
+		args, _ := it["arguments"].([]any)
+		for _, a := range args {
+			am, ok := a.(map[string]any)
+			if !ok {
+				continue
+			}
+			arg := PromptArgument{}
+			arg.Name, _ = am["name"].(string)
+			arg.Description, _ = am["description"].(string)
+			arg.Required, _ = am["required"].(bool)
+			if arg.Name != "" {
+				p.Arguments = append(p.Arguments, arg)
+			}
+		}
+		out = append(out, p)
+	}
+	return out, nil
+}
+
+// GetPrompt renders a prompt template with args. Only text content is kept; other
+// content is described by its type.
+func (c *Client) GetPrompt(ctx context.Context, name string, args map[string]string) ([]PromptMessage, error) {
+	if err := c.EnsureInitialized(ctx); err != nil {
+		return nil, err
+	}
+	if args == nil {
+		args = map[string]string{}
+	}
+	res, err := c.request(ctx, "prompts/get", map[string]any{"name": name, "arguments": args})
+	if err != nil {
+		return nil, err
+	}
+	m, _ := res.(map[string]any)
+	arr, _ := m["messages"].([]any)
+	var out []PromptMessage
+	for _, it := range arr {
+		im, ok := it.(map[string]any)
+		if !ok {
+			continue
+		}
+		msg := PromptMessage{}
+		msg.Role, _ = im["role"].(string)
+		content, _ := im["content"].(map[string]any)
+		switch kind, _ := content["type"].(string); kind {
+		case "text":
+			msg.Text, _ = content["text"].(string)
+		case "resource":
+			res, _ := content["resource"].(map[string]any)
+			uri, _ := res["uri"].(string)
+			if text, ok := res["text"].(string); ok {
+				msg.Text = fmt.Sprintf("Resource %s:\n%s", uri, text)
+			} else {
+				msg.Text = fmt.Sprintf("[binary resource %s]", uri)
+			}
+		default:
+			msg.Text = fmt.Sprintf("[%s content]", kind)
+		}
+		out = append(out, msg)
+	}
+	return out, nil
+}
+
+// PromptText joins the messages of a rendered prompt into one user message. Messages
+// of other roles are labelled so the model can tell them apart.
+func PromptText(msgs []PromptMessage) string {
+	parts := make([]string, 0, len(msgs))
+	for _, m := range msgs {
+		text := strings.TrimSpace(m.Text)
+		if text == "" {
+			continue
+		}
+		if m.Role != "" && m.Role != "user" {
+			text = fmt.Sprintf("[%s]\n%s", m.Role, text)
+		}
+		parts = append(parts, text)
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// ParsePromptArgs turns the text after a prompt's slash command into its arguments.
+// Arguments are given as name=value pairs, with double quotes around values containing
+// spaces; a prompt with a single argument also takes the whole text as its value.
+func ParsePromptArgs(p PromptSpec, input string) (map[string]string, error) {
+	input = strings.TrimSpace(input)
+	args := map[string]string{}
+	known := map[string]bool{}
+	for _, a := range p.Arguments {
+		known[a.Name] = true
+	}
+	if input != "" {
+		pairs, ok := splitPromptPairs(input)
+		switch {
+		case ok:
+			for k, v := range pairs {
+				if !known[k] {
+					return nil, fmt.Errorf("prompt %s has no argument %q", p.Name, k)
+				}
+				args[k] = v
+			}
+		case len(p.Arguments) == 1:
+			args[p.Arguments[0].Name] = input
+		default:
+			return nil, fmt.Errorf("pass the arguments of prompt %s as name=value", p.Name)
+		}
+	}
+	var missing []string
+	for _, a := range p.Arguments {
+		if a.Required && strings.TrimSpace(args[a.Name]) == "" {
+			missing = append(missing, a.Name)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("prompt %s needs %s", p.Name, strings.Join(missing, ", "))
+	}
+	return args, nil
+}
+
+// splitPromptPairs parses name=value pairs; ok is false unless every token is a pair.
+func splitPromptPairs(input string) (map[string]string, bool) {
+	out := map[string]string{}
+	for input != "" {
+		eq := strings.IndexByte(input, '=')
+		if eq <= 0 || strings.ContainsAny(input[:eq], " \t\n\"") {
+			return nil, false
+		}
+		key := input[:eq]
+		rest := input[eq+1:]
+		var value string
+		if strings.HasPrefix(rest, `"`) {
+			end := strings.IndexByte(rest[1:], '"')
+			if end < 0 {
+				return nil, false
+			}
+			value, rest = rest[1:end+1], rest[end+2:]
+		} else if sp := strings.IndexAny(rest, " \t\n"); sp >= 0 {
+			value, rest = rest[:sp], rest[sp:]
+		} else {
+			value, rest = rest, ""
+		}
+		out[key] = value
+		input = strings.TrimSpace(rest)
+	}
+	return out, true
+}
+
+// ListResources returns the resources of all servers, sorted by server and name
+func (m *Manager) ListResources() []ResourceSpec {
+	var out []ResourceSpec
+	for alias, c := range m.snapshot() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		res, err := c.ListResources(ctx)
+		cancel()
+		if err != nil {
+			log.Printf("[mcp] %s: ListResources failed: %v", alias, err)
+			continue
+		}
+		out = append(out, res...)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Server != out[j].Server {
+			return out[i].Server < out[j].Server
+		}
+		return out[i].Name < out[j].Name
+	})
+	return out
+}
+
+// ReadResource reads a resource of a specific server
+func (m *Manager) ReadResource(server, uri string, timeout time.Duration) ([]ResourceContent, error) {
+	c := m.client(server)
+	if c == nil {
+		return nil, fmt.Errorf("unknown mcp server: %s", server)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return c.ReadResource(ctx, uri)
+}
+
+// ListPrompts returns the prompt templates of all servers, sorted by server and name
+func (m *Manager) ListPrompts() []PromptSpec {
+	var out []PromptSpec
+	for alias, c := range m.snapshot() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		prompts, err := c.ListPrompts(ctx)
+		cancel()
+		if err != nil {
+			log.Printf("[mcp] %s: ListPrompts failed: %v", alias, err)
+			continue
+		}
+		out = append(out, prompts...)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Server != out[j].Server {
+			return out[i].Server < out[j].Server
+		}
+		return out[i].Name < out[j].Name
+	})
+	return out
+}
+
+// GetPrompt renders a prompt template of a specific server
+func (m *Manager) GetPrompt(server, name string, args map[string]string, timeout time.Duration) ([]PromptMessage, error) {
+	c := m.client(server)
+	if c == nil {
+		return nil, fmt.Errorf("unknown mcp server: %s", server)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return c.GetPrompt(ctx, name, args)
+}
+
+func (m *Manager) client(server string) *Client {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.clients[server]
+}
+
+// snapshot copies the clients so slow servers are queried without holding the lock
+func (m *Manager) snapshot() map[string]*Client {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	out := make(map[string]*Client, len(m.clients))
+	for alias, c := range m.clients {
+		out[alias] = c
+	}
+	return out
+}
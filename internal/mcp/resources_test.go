@@ -0,0 +1,46 @@
+package mcp
+
+import (
+	"testing"
+)
+
+func TestParsePromptArgs(t *testing.T) {
+	review := PromptSpec{Name: "review", Arguments: []PromptArgument{
+		{Name: "file", Required: true},
+		{Name: "focus"},
+	}}
+	args, err := ParsePromptArgs(review, `file=main.go focus="error handling"`)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if args["file"] != "main.go" || args["focus"] != "error handling" {
+		t.Fatalf("unexpected args %v", args)
+	}
+	if _, err := ParsePromptArgs(review, "focus=tests"); err == nil {
+		t.Fatal("expected a missing required argument to fail")
+	}
+	if _, err := ParsePromptArgs(review, "main.go"); err == nil {
+		t.Fatal("expected free text to fail for a prompt with several arguments")
+	}
+	if _, err := ParsePromptArgs(review, "file=a.go color=red"); err == nil {
+		t.Fatal("expected an unknown argument to fail")
+	}
+
+	summarize := PromptSpec{Name: "summarize", Arguments: []PromptArgument{{Name: "topic", Required: true}}}
+	args, err = ParsePromptArgs(summarize, "the release = v2 plan")
+	if err != nil || args["topic"] != "the release = v2 plan" {
+		t.Fatalf("expected the whole text as the only argument, got %v (%v)", args, err)
+	}
+}
+
+func TestPromptText_LabelsNonUserMessages(t *testing.T) {
+	got := PromptText([]PromptMessage{
+		{Role: "user", Text: "Review this diff."},
+		{Role: "assistant", Text: "Which file first?"},
+		{Role: "user", Text: "  "},
+	})
+	want := "Review this diff.\n\n[assistant]\nWhich file first?"
+	if got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,87 @@
+package memory
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Annotation is a comment the model attached to a line range of a workspace file.
+// Annotations never change the file; the UI renders them in the editor gutter.
+type Annotation struct {
+	ID        string `json:"id"`
+	Path      string `json:"path"`
+	StartLine int    `json:"start_line"`
+	EndLine   int    `json:"end_line"`
+	// Kind is one of "note", "explanation", "issue" or "suggestion"
+	Kind    string `json:"kind"`
+	Comment string `json:"comment"`
+	// Snippet is the annotated text when the comment was made, so the UI can tell
+	// whether the lines moved since
+	Snippet   string    `json:"snippet,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+const (
+	annotationsPrefix = "annotations/"
+	// maxAnnotations bounds the annotations kept per conversation; the oldest are dropped
+	maxAnnotations = 500
+)
+
+// Annotations returns a conversation's annotations, oldest first.
+func (p *Project) Annotations(conversationID string) []Annotation {
+	var items []Annotation
+	if p == nil || conversationID == "" || !p.Has(annotationsPrefix+conversationID) {
+		return items
+	}
+	_ = p.Get(annotationsPrefix+conversationID, &items)
+	return items
+}
+
+// AddAnnotation stores an annotation for a conversation and returns it with its ID.
+func (p *Project) AddAnnotation(conversationID string, a Annotation) (Annotation, error) {
+	if p == nil {
+		return a, errors.New("project memory not initialized")
+	}
+	if conversationID == "" {
+		return a, errors.New("no active conversation")
+	}
+	items := p.Annotations(conversationID)
+	next := 1
+	if len(items) > 0 {
+		var last int
+		if _, err := fmt.Sscanf(items[len(items)-1].ID, "ann_%d", &last); err == nil {
+			next = last + 1
+		}
+	}
+	a.ID = fmt.Sprintf("ann_%d", next)
+	if a.CreatedAt.IsZero() {
+		a.CreatedAt = time.Now()
+	}
+	items = append(items, a)
+	if len(items) > maxAnnotations {
+		items = items[len(items)-maxAnnotations:]
+	}
+	return a, p.Set(annotationsPrefix+conversationID, items)
+}
+
+// RemoveAnnotation deletes one annotation; an empty id removes all of them.
+func (p *Project) RemoveAnnotation(conversationID, id string) error {
+	if p == nil || conversationID == "" {
+		return errors.New("no active conversation")
+	}
+	if id == "" {
+		return p.Delete(annotationsPrefix + conversationID)
+	}
+	items := p.Annotations(conversationID)
+	kept := items[:0]
+	for _, a := range items {
+		if a.ID != id {
+			kept = append(kept, a)
+		}
+	}
+	if len(kept) == len(items) {
+		return fmt.Errorf("annotation %q not found", id)
+	}
+	return p.Set(annotationsPrefix+conversationID, kept)
+}
@@ -0,0 +1,37 @@
+package memory
+
+import "testing"
+
+func TestAnnotations_AddRemoveAndDeleteWithConversation(t *testing.T) {
+	store, err := NewStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("store: %v", err)
+	}
+	proj, err := NewProject(store, t.TempDir())
+	if err != nil {
+		t.Fatalf("project: %v", err)
+	}
+	a, err := proj.AddAnnotation("c1", Annotation{Path: "main.go", StartLine: 1, EndLine: 2, Kind: "note", Comment: "entry point"})
+	if err != nil || a.ID != "ann_1" {
+		t.Fatalf("add: %+v %v", a, err)
+	}
+	b, _ := proj.AddAnnotation("c1", Annotation{Path: "main.go", StartLine: 5, EndLine: 5, Kind: "issue", Comment: "unchecked error"})
+	if b.ID != "ann_2" || len(proj.Annotations("c2")) != 0 {
+		t.Fatalf("annotations must be per conversation: %+v", b)
+	}
+
+	if err := proj.RemoveAnnotation("c1", "ann_1"); err != nil {
+		t.Fatalf("remove: %v", err)
+	}
+	if err := proj.RemoveAnnotation("c1", "ann_1"); err == nil {
+		t.Fatal("removing twice should fail")
+	}
+	if c, _ := proj.AddAnnotation("c1", Annotation{Path: "x.go", StartLine: 1, EndLine: 1, Kind: "note", Comment: "x"}); c.ID != "ann_3" {
+		t.Fatalf("ids must not be reused, got %s", c.ID)
+	}
+
+	_ = proj.DeleteConversation("c1")
+	if got := proj.Annotations("c1"); len(got) != 0 {
+		t.Fatalf("annotations survived conversation deletion: %+v", got)
+	}
+}
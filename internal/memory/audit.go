@@ -0,0 +1,263 @@
+package memory
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// AuditEvent is one entry in a workspace's append-only audit log. Each entry's Hash
+// covers its fields and the previous entry's hash, so edits, deletions, and
+// reordering are detected by VerifyAudit.
+type AuditEvent struct {
+	Seq      int64           `json:"seq"`
+	Time     string          `json:"time"` // RFC 3339, UTC
+	Kind     string          `json:"kind"` // approval, edit, shell, settings, workspace, ...
+	Data     json.RawMessage `json:"data,omitempty"`
+	PrevHash string          `json:"prev_hash"`
+	Hash     string          `json:"hash"`
+}
+
+// auditFile is the log's name inside the project directory. It is not a key in the
+// JSON store, so retention and conversation cleanup never touch it. While the project
+// is encrypted at rest each line is sealed and base64-encoded; the hash chain covers
+// the events, not their encoding.
+const auditFile = "audit.jsonl"
+
+// auditHead caches the last sequence number and hash per log file so appends do not
+// rescan the log. Appends from every Project share one lock.
+var (
+	auditMu    sync.Mutex
+	auditHeads = map[string]auditHead{}
+)
+
+type auditHead struct {
+	seq  int64
+	hash string
+}
+
+// AuditPath returns the location of the project's audit log.
+func (p *Project) AuditPath() string {
+	return filepath.Join(p.projectDir(), auditFile)
+}
+
+// RecordAudit appends an event to the audit log. data is stored as JSON; callers
+// must not pass secrets. A nil project records nothing.
+func (p *Project) RecordAudit(kind string, data interface{}) error {
+	if p == nil {
+		return nil
+	}
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("failed to encode audit data: %w", err)
+	}
+
+	// Taken before auditMu: re-sealing holds the store lock while it takes auditMu
+	sealer, open := p.Sealer(), p.auditOpener()
+
+	auditMu.Lock()
+	defer auditMu.Unlock()
+
+	path := p.AuditPath()
+	head, ok := auditHeads[path]
+	if !ok {
+		events, err := readAudit(path, open)
+		if err != nil {
+			return err
+		}
+		if n := len(events); n > 0 {
+			head = auditHead{seq: events[n-1].Seq, hash: events[n-1].Hash}
+		}
+	}
+
+	ev := AuditEvent{
+		Seq:      head.seq + 1,
+		Time:     time.Now().UTC().Format(time.RFC3339Nano),
+		Kind:     kind,
+		Data:     raw,
+		PrevHash: head.hash,
+	}
+	ev.Hash = auditHash(ev)
+	line, err := sealAuditLine(sealer, ev)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open audit log: %w", err)
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("failed to append audit event: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	auditHeads[path] = auditHead{seq: ev.Seq, hash: ev.Hash}
+	return nil
+}
+
+// ListAudit returns the most recent limit events, oldest first; limit <= 0 returns all.
+func (p *Project) ListAudit(limit int) ([]AuditEvent, error) {
+	if p == nil {
+		return nil, nil
+	}
+	open := p.auditOpener()
+	auditMu.Lock()
+	events, err := readAudit(p.AuditPath(), open)
+	auditMu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	if limit > 0 && len(events) > limit {
+		events = events[len(events)-limit:]
+	}
+	return events, nil
+}
+
+// VerifyAudit checks the hash chain and returns the number of intact events. The
+// error names the first event that was altered, removed, or reordered.
+func (p *Project) VerifyAudit() (int, error) {
+	events, err := p.ListAudit(0)
+	if err != nil {
+		return 0, err
+	}
+	prev := ""
+	for i, ev := range events {
+		if ev.Seq != int64(i+1) {
+			return i, fmt.Errorf("audit log broken at entry %d: expected sequence %d, found %d", i+1, i+1, ev.Seq)
+		}
+		if ev.PrevHash != prev || auditHash(ev) != ev.Hash {
+			return i, fmt.Errorf("audit log broken at entry %d: hash mismatch", ev.Seq)
+		}
+		prev = ev.Hash
+	}
+	return len(events), nil
+}
+
+// ExportAudit writes the log to w as JSON lines after verifying it, so an export
+// never silently carries a tampered log.
+func (p *Project) ExportAudit(w io.Writer) (int, error) {
+	if _, err := p.VerifyAudit(); err != nil {
+		return 0, err
+	}
+	events, err := p.ListAudit(0)
+	if err != nil {
+		return 0, err
+	}
+	enc := json.NewEncoder(w)
+	for _, ev := range events {
+		if err := enc.Encode(ev); err != nil {
+			return 0, err
+		}
+	}
+	return len(events), nil
+}
+
+// auditHash hashes an event's content together with the previous hash.
+func auditHash(ev AuditEvent) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%d\n%s\n%s\n%s\n", ev.Seq, ev.Time, ev.Kind, ev.PrevHash)
+	h.Write(ev.Data)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// sealAuditLine encodes an event as one log line, sealed when sealer is set.
+func sealAuditLine(sealer *Sealer, ev AuditEvent) ([]byte, error) {
+	line, err := json.Marshal(ev)
+	if err != nil || sealer == nil {
+		return line, err
+	}
+	sealed, err := sealer.Seal(line)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(base64.StdEncoding.EncodeToString(sealed)), nil
+}
+
+// auditOpener returns the function decrypting sealed log lines. Lines sealed before
+// encryption was turned off elsewhere are opened with the key from the keychain.
+func (p *Project) auditOpener() func([]byte) ([]byte, error) {
+	sealer := p.Sealer()
+	return func(data []byte) ([]byte, error) {
+		if sealer == nil {
+			sealer, _ = projectSealer(p.projectID)
+		}
+		return sealer.Open(data)
+	}
+}
+
+// resealAudit re-writes the audit log with sealer (nil for plaintext), reading it
+// with current. The caller holds the store lock.
+func (p *Project) resealAudit(current, sealer *Sealer) error {
+	auditMu.Lock()
+	defer auditMu.Unlock()
+	path := p.AuditPath()
+	events, err := readAudit(path, current.Open)
+	if err != nil || len(events) == 0 {
+		return err
+	}
+	var buf bytes.Buffer
+	for _, ev := range events {
+		line, err := sealAuditLine(sealer, ev)
+		if err != nil {
+			return err
+		}
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+	if sealer == nil {
+		return os.WriteFile(path, buf.Bytes(), 0o600)
+	}
+	return writeOwnerOnly(path, buf.Bytes())
+}
+
+// readAudit reads the events of a log; open decrypts lines that are not plain JSON.
+func readAudit(path string, open func([]byte) ([]byte, error)) ([]AuditEvent, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	var events []AuditEvent
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 64*1024), 16<<20)
+	for sc.Scan() {
+		if len(sc.Bytes()) == 0 {
+			continue
+		}
+		line := sc.Bytes()
+		if line[0] != '{' {
+			sealed, err := base64.StdEncoding.DecodeString(string(line))
+			if err != nil {
+				return nil, fmt.Errorf("audit log entry %d is not valid: %w", len(events)+1, err)
+			}
+			if line, err = open(sealed); err != nil {
+				return nil, fmt.Errorf("audit log entry %d: %w", len(events)+1, err)
+			}
+		}
+		var ev AuditEvent
+		if err := json.Unmarshal(line, &ev); err != nil {
+			return nil, fmt.Errorf("audit log entry %d is not valid JSON: %w", len(events)+1, err)
+		}
+		events = append(events, ev)
+	}
+	return events, sc.Err()
+}
@@ -0,0 +1,49 @@
+package memory
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestAudit_HashChainDetectsTampering(t *testing.T) {
+	store, err := NewStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("store: %v", err)
+	}
+	proj, err := NewProject(store, t.TempDir())
+	if err != nil {
+		t.Fatalf("project: %v", err)
+	}
+
+	for _, kind := range []string{"approval", "edit", "shell"} {
+		if err := proj.RecordAudit(kind, map[string]string{"tool": kind}); err != nil {
+			t.Fatalf("record %s: %v", kind, err)
+		}
+	}
+	if n, err := proj.VerifyAudit(); err != nil || n != 3 {
+		t.Fatalf("expected 3 intact events, got %d, %v", n, err)
+	}
+
+	var buf bytes.Buffer
+	if n, err := proj.ExportAudit(&buf); err != nil || n != 3 || strings.Count(buf.String(), "\n") != 3 {
+		t.Fatalf("unexpected export: %d, %v\n%s", n, err, buf.String())
+	}
+
+	// Rewrite the second event's data without fixing its hash
+	data, err := os.ReadFile(proj.AuditPath())
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	tampered := strings.Replace(string(data), `{"tool":"edit"}`, `{"tool":"read"}`, 1)
+	if err := os.WriteFile(proj.AuditPath(), []byte(tampered), 0o600); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if n, err := proj.VerifyAudit(); err == nil || n != 1 {
+		t.Fatalf("expected verification to fail at entry 2, got %d, %v", n, err)
+	}
+	if _, err := proj.ExportAudit(&buf); err == nil {
+		t.Fatalf("expected export of a tampered log to fail")
+	}
+}
@@ -13,6 +13,8 @@ type Message struct {
 	ToolID    string      `json:"tool_id,omitempty"`  // ID for tool invocations
 	Metadata  interface{} `json:"metadata,omitempty"` // Optional metadata
 	Timestamp time.Time   `json:"timestamp"`          // When the message was created
+	// Version is the schema version of a tool_use message's arguments; 0 means v1
+	Version int `json:"version,omitempty"`
 }
 
 // Conversation manages a single conversation thread with the LLM.
@@ -20,6 +22,8 @@ type Conversation struct {
 	project  *Project
 	id       string
 	messages []Message
+	// toolVersions are the current tool schema versions, stamped on tool_use messages
+	toolVersions map[string]int
 }
 
 // NewConversation creates a new conversation.
@@ -127,6 +131,12 @@ func (c *Conversation) AddToolResult(name string, toolUseID string, content stri
 	c.save()
 }
 
+// SetToolSchemaVersions sets the tool schema versions recorded with later tool_use
+// messages, so saved sessions can be replayed after a tool's arguments change.
+func (c *Conversation) SetToolSchemaVersions(versions map[string]int) {
+	c.toolVersions = versions
+}
+
 // AddAssistantToolUse adds an assistant tool_use message with the given ID and JSON input (as string)
 func (c *Conversation) AddAssistantToolUse(name string, toolUseID string, inputJSON string) {
 	c.messages = append(c.messages, Message{
@@ -135,6 +145,38 @@ func (c *Conversation) AddAssistantToolUse(name string, toolUseID string, inputJ
 		ToolID:    toolUseID,
 		Content:   inputJSON,
 		Timestamp: time.Now(),
+		Version:   c.toolVersions[name],
+	})
+	c.save()
+}
+
+// SetSystemNote appends a named system message, dropping earlier notes with the same
+// name so that only the latest one reaches the model.
+func (c *Conversation) SetSystemNote(name string, content string) {
+	kept := c.messages[:0]
+	for _, m := range c.messages {
+		if m.Role == "system" && m.Name == name {
+			continue
+		}
+		kept = append(kept, m)
+	}
+	c.messages = append(kept, Message{
+		Role:      "system",
+		Name:      name,
+		Content:   content,
+		Timestamp: time.Now(),
+	})
+	c.save()
+}
+
+// AddSystemMarker appends a named system message that stays in the transcript, e.g. to
+// record a setting that changed mid-conversation.
+func (c *Conversation) AddSystemMarker(name string, content string) {
+	c.messages = append(c.messages, Message{
+		Role:      "system",
+		Name:      name,
+		Content:   content,
+		Timestamp: time.Now(),
 	})
 	c.save()
 }
@@ -153,9 +195,9 @@ func (c *Conversation) Clear() {
 // UpdateSystemMessage updates the first system message if it exists, otherwise adds a new one.
 // This is useful for updating personality or other system-level context mid-conversation.
 func (c *Conversation) UpdateSystemMessage(content string) {
-	// Find the first system message
+	// Find the first system message; named notes are managed by SetSystemNote
 	for i, msg := range c.messages {
-		if msg.Role == "system" {
+		if msg.Role == "system" && msg.Name == "" {
 			// Update existing system message
 			c.messages[i] = Message{
 				Role:      "system",
@@ -0,0 +1,175 @@
+package memory
+
+import (
+	"errors"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ConversationTags organize a conversation in the session list. They are derived
+// from its messages and the files it touched, and refreshed after every run.
+type ConversationTags struct {
+	// TaskType is bugfix, feature, refactor, test, docs, review, ops or question
+	TaskType string `json:"task_type,omitempty"`
+	// Topics are domain words from the requests and touched packages, e.g. "billing"
+	Topics []string `json:"topics,omitempty"`
+	// Packages are the workspace directories of the files read or changed
+	Packages  []string  `json:"packages,omitempty"`
+	UpdatedAt time.Time `json:"updated_at,omitempty"`
+}
+
+// ConversationFilter selects conversations from the session list; empty fields match
+// everything, and all set fields must match.
+type ConversationFilter struct {
+	// Tag matches a topic, the task type, or a path segment of a package
+	Tag      string `json:"tag,omitempty"`
+	TaskType string `json:"task_type,omitempty"`
+	// Package matches packages equal to or below this directory
+	Package string `json:"package,omitempty"`
+	// Text matches the title or any tag, ignoring case
+	Text string `json:"text,omitempty"`
+}
+
+// TagCount is a tag with the number of conversations carrying it.
+type TagCount struct {
+	// Kind is topic, package or task_type
+	Kind  string `json:"kind"`
+	Tag   string `json:"tag"`
+	Count int    `json:"count"`
+}
+
+const conversationTagsPrefix = "conversation_tags/"
+
+// ConversationTags returns a conversation's tags; the zero value when it has none.
+func (p *Project) ConversationTags(conversationID string) ConversationTags {
+	var t ConversationTags
+	if p == nil || conversationID == "" || !p.Has(conversationTagsPrefix+conversationID) {
+		return t
+	}
+	_ = p.Get(conversationTagsPrefix+conversationID, &t)
+	return t
+}
+
+// SetConversationTags stores a conversation's tags.
+func (p *Project) SetConversationTags(conversationID string, t ConversationTags) error {
+	if p == nil {
+		return errors.New("project memory not initialized")
+	}
+	if conversationID == "" {
+		return errors.New("no active conversation")
+	}
+	t.UpdatedAt = time.Now()
+	return p.Set(conversationTagsPrefix+conversationID, t)
+}
+
+// FindConversations returns the summaries matching f, most recently updated first.
+func (p *Project) FindConversations(f ConversationFilter) ([]ConversationSummary, error) {
+	all, err := p.ListConversationSummaries()
+	if err != nil {
+		return nil, err
+	}
+	out := []ConversationSummary{}
+	for _, s := range all {
+		if f.matches(s) {
+			out = append(out, s)
+		}
+	}
+	return out, nil
+}
+
+// ConversationTagCounts returns every tag in use with the number of conversations
+// carrying it, most used first.
+func (p *Project) ConversationTagCounts() ([]TagCount, error) {
+	all, err := p.ListConversationSummaries()
+	if err != nil {
+		return nil, err
+	}
+	counts := map[TagCount]int{}
+	for _, s := range all {
+		if s.Tags.TaskType != "" {
+			counts[TagCount{Kind: "task_type", Tag: s.Tags.TaskType}]++
+		}
+		for _, t := range s.Tags.Topics {
+			counts[TagCount{Kind: "topic", Tag: t}]++
+		}
+		for _, t := range s.Tags.Packages {
+			counts[TagCount{Kind: "package", Tag: t}]++
+		}
+	}
+	out := make([]TagCount, 0, len(counts))
+	for k, n := range counts {
+		k.Count = n
+		out = append(out, k)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Count != out[j].Count {
+			return out[i].Count > out[j].Count
+		}
+		if out[i].Kind != out[j].Kind {
+			return out[i].Kind < out[j].Kind
+		}
+		return out[i].Tag < out[j].Tag
+	})
+	return out, nil
+}
+
+func (f ConversationFilter) matches(s ConversationSummary) bool {
+	t := s.Tags
+	if f.TaskType != "" && !strings.EqualFold(t.TaskType, f.TaskType) {
+		return false
+	}
+	if strings.TrimSpace(f.Package) != "" {
+		pkg := strings.Trim(path.Clean("/"+strings.TrimSpace(f.Package)), "/")
+		found := false
+		for _, p := range t.Packages {
+			if p == pkg || strings.HasPrefix(p, pkg+"/") {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if tag := strings.ToLower(strings.TrimSpace(f.Tag)); tag != "" && !t.hasTag(tag) {
+		return false
+	}
+	if text := strings.ToLower(strings.TrimSpace(f.Text)); text != "" {
+		if !strings.Contains(strings.ToLower(s.Title), text) && !t.containsText(text) {
+			return false
+		}
+	}
+	return true
+}
+
+// hasTag reports whether tag (lower case) is a topic, the task type, or a path
+// segment of a package.
+func (t ConversationTags) hasTag(tag string) bool {
+	if strings.EqualFold(t.TaskType, tag) {
+		return true
+	}
+	for _, topic := range t.Topics {
+		if strings.EqualFold(topic, tag) {
+			return true
+		}
+	}
+	for _, p := range t.Packages {
+		for _, seg := range strings.Split(strings.ToLower(p), "/") {
+			if seg == tag {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (t ConversationTags) containsText(text string) bool {
+	for _, s := range append(append([]string{t.TaskType}, t.Topics...), t.Packages...) {
+		if strings.Contains(strings.ToLower(s), text) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,73 @@
+package memory
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFindConversations_FiltersByTags(t *testing.T) {
+	store, err := NewStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("store: %v", err)
+	}
+	proj, err := NewProject(store, t.TempDir())
+	if err != nil {
+		t.Fatalf("project: %v", err)
+	}
+	now := time.Now()
+	for i, id := range []string{"billing", "auth", "untagged"} {
+		msgs := []Message{{Role: "user", Content: "about " + id, Timestamp: now.Add(time.Duration(i) * time.Minute)}}
+		if err := proj.Set("conversations/"+id, msgs); err != nil {
+			t.Fatalf("set: %v", err)
+		}
+	}
+	_ = proj.SetConversationTags("billing", ConversationTags{TaskType: "bugfix", Topics: []string{"billing", "invoice"}, Packages: []string{"services/billing/invoice"}})
+	_ = proj.SetConversationTags("auth", ConversationTags{TaskType: "feature", Topics: []string{"login"}, Packages: []string{"services/auth"}})
+
+	cases := []struct {
+		filter ConversationFilter
+		want   []string
+	}{
+		{ConversationFilter{}, []string{"untagged", "auth", "billing"}},
+		{ConversationFilter{Tag: "Billing"}, []string{"billing"}},
+		{ConversationFilter{Tag: "invoice"}, []string{"billing"}},
+		{ConversationFilter{Tag: "feature"}, []string{"auth"}},
+		{ConversationFilter{Package: "services"}, []string{"auth", "billing"}},
+		{ConversationFilter{Package: "services/bill"}, nil},
+		{ConversationFilter{TaskType: "bugfix", Package: "services/auth"}, nil},
+		{ConversationFilter{Text: "logi"}, []string{"auth"}},
+	}
+	for _, c := range cases {
+		got, err := proj.FindConversations(c.filter)
+		if err != nil {
+			t.Fatalf("find %+v: %v", c.filter, err)
+		}
+		var ids []string
+		for _, s := range got {
+			ids = append(ids, s.ID)
+		}
+		if len(ids) != len(c.want) {
+			t.Fatalf("filter %+v: got %v, want %v", c.filter, ids, c.want)
+		}
+		for i := range ids {
+			if ids[i] != c.want[i] {
+				t.Fatalf("filter %+v: got %v, want %v", c.filter, ids, c.want)
+			}
+		}
+	}
+
+	counts, err := proj.ConversationTagCounts()
+	if err != nil {
+		t.Fatalf("counts: %v", err)
+	}
+	if len(counts) != 7 {
+		t.Fatalf("expected 7 tags, got %+v", counts)
+	}
+
+	if err := proj.DeleteConversation("billing"); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if proj.Has(conversationTagsPrefix + "billing") {
+		t.Fatalf("expected tags to be deleted with the conversation")
+	}
+}
@@ -0,0 +1,86 @@
+package memory
+
+import (
+	"fmt"
+	"sync"
+)
+
+// openViews counts the conversations pinned by views of a project. The project and its
+// views share it, so cleanups leave conversations that are open in a view alone.
+type openViews struct {
+	mu  sync.Mutex
+	ids map[string]int
+}
+
+func (v *openViews) open(id string) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if v.ids == nil {
+		v.ids = make(map[string]int)
+	}
+	v.ids[id]++
+}
+
+func (v *openViews) close(id string) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if v.ids[id] <= 1 {
+		delete(v.ids, id)
+		return
+	}
+	v.ids[id]--
+}
+
+func (v *openViews) has(id string) bool {
+	if v == nil {
+		return false
+	}
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return v.ids[id] > 0
+}
+
+// ForConversation returns a view of the project whose current conversation is always
+// id, for conversations that run next to the workspace's current one. The view reads
+// and writes the same storage but never changes the current conversation. An empty id
+// creates a new conversation. Release the view with Close.
+func (p *Project) ForConversation(id string) *Project {
+	if id == "" {
+		id = p.newConversationID()
+		_ = p.Set("conversations/"+id, []Message{})
+	}
+	p.views.open(id)
+	return &Project{
+		store:         p.store,
+		workspacePath: p.workspacePath,
+		projectID:     p.projectID,
+		pinned:        id,
+		views:         p.views,
+	}
+}
+
+// Close releases a view made by ForConversation. It is a no-op for other projects.
+func (p *Project) Close() {
+	if p != nil && p.pinned != "" {
+		p.views.close(p.pinned)
+	}
+}
+
+// IsConversationOpen reports whether a view of the project has conversation id open.
+func (p *Project) IsConversationOpen(id string) bool {
+	if p == nil {
+		return false
+	}
+	return p.views.has(id)
+}
+
+// newConversationID returns an id that no stored or open conversation uses; ids made
+// within the same second get a numeric suffix.
+func (p *Project) newConversationID() string {
+	base := generateConversationID()
+	id := base
+	for n := 2; p.Has("conversations/"+id) || p.views.has(id) || id == p.CurrentConversationID(); n++ {
+		id = fmt.Sprintf("%s-%d", base, n)
+	}
+	return id
+}
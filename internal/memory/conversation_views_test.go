@@ -0,0 +1,51 @@
+package memory
+
+import "testing"
+
+func TestForConversation_PinsAView(t *testing.T) {
+	store, err := NewStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("store: %v", err)
+	}
+	proj, err := NewProject(store, t.TempDir())
+	if err != nil {
+		t.Fatalf("project: %v", err)
+	}
+	main := proj.CreateNewConversation()
+
+	view := proj.ForConversation("")
+	id := view.CurrentConversationID()
+	if id == "" || id == main {
+		t.Fatalf("expected a new conversation, got %q (main %q)", id, main)
+	}
+	if proj.CurrentConversationID() != main {
+		t.Fatal("opening a view must not change the current conversation")
+	}
+	if err := view.SetCurrentConversationID(main); err == nil {
+		t.Fatal("a view must stay on its conversation")
+	}
+
+	// Writes through the view land in the shared storage
+	convo := view.StartConversation()
+	convo.AddUser("hello from the tab")
+	var msgs []Message
+	if err := proj.Get("conversations/"+id, &msgs); err != nil || len(msgs) == 0 {
+		t.Fatalf("expected the tab's messages in storage: %v %v", msgs, err)
+	}
+
+	// An empty conversation open in a view survives cleanups until the view closes
+	other := proj.ForConversation("")
+	otherID := other.CurrentConversationID()
+	proj.CleanupEmptyConversations(main)
+	if !proj.Has("conversations/" + otherID) {
+		t.Fatal("cleanup removed a conversation open in a view")
+	}
+	other.Close()
+	if proj.IsConversationOpen(otherID) {
+		t.Fatal("the conversation is still open after Close")
+	}
+	proj.CleanupEmptyConversations(main)
+	if proj.Has("conversations/" + otherID) {
+		t.Fatal("expected the closed empty conversation to be cleaned up")
+	}
+}
@@ -0,0 +1,42 @@
+package memory
+
+import (
+	"errors"
+	"sort"
+)
+
+const disabledToolsPrefix = "disabled_tools/"
+
+// DisabledTools returns the tools switched off for a conversation, sorted by name.
+func (p *Project) DisabledTools(conversationID string) []string {
+	var names []string
+	if p == nil || conversationID == "" || !p.Has(disabledToolsPrefix+conversationID) {
+		return names
+	}
+	_ = p.Get(disabledToolsPrefix+conversationID, &names)
+	return names
+}
+
+// SetDisabledTools replaces the tools switched off for a conversation; an empty list
+// enables every tool again.
+func (p *Project) SetDisabledTools(conversationID string, names []string) error {
+	if p == nil {
+		return errors.New("project memory not initialized")
+	}
+	if conversationID == "" {
+		return errors.New("no active conversation")
+	}
+	if len(names) == 0 {
+		return p.Delete(disabledToolsPrefix + conversationID)
+	}
+	seen := map[string]bool{}
+	out := make([]string, 0, len(names))
+	for _, n := range names {
+		if n != "" && !seen[n] {
+			seen[n] = true
+			out = append(out, n)
+		}
+	}
+	sort.Strings(out)
+	return p.Set(disabledToolsPrefix+conversationID, out)
+}
@@ -0,0 +1,63 @@
+package memory
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// EditRecord is a file change made outside the agent's tool loop (for example a code
+// block saved from an answer) that can be undone. Before is empty when Created is set.
+type EditRecord struct {
+	ID      string    `json:"id"`
+	Path    string    `json:"path"`
+	Before  string    `json:"before"`
+	After   string    `json:"after"`
+	Created bool      `json:"created"`
+	Source  string    `json:"source,omitempty"`
+	At      time.Time `json:"at"`
+}
+
+const (
+	editHistoryKey = "edits/history"
+	// maxEditRecords bounds how many undoable edits are kept per project
+	maxEditRecords = 50
+)
+
+// ListEdits returns recorded edits, newest first.
+func (p *Project) ListEdits() []EditRecord {
+	var items []EditRecord
+	if p == nil || !p.Has(editHistoryKey) {
+		return items
+	}
+	_ = p.Get(editHistoryKey, &items)
+	return items
+}
+
+// RecordEdit stores an undoable edit and returns it with its assigned id.
+func (p *Project) RecordEdit(rec EditRecord) (EditRecord, error) {
+	if p == nil {
+		return rec, errors.New("project memory not initialized")
+	}
+	rec.At = time.Now()
+	if rec.ID == "" {
+		rec.ID = fmt.Sprintf("edit-%d", rec.At.UnixNano())
+	}
+	items := append([]EditRecord{rec}, p.ListEdits()...)
+	if len(items) > maxEditRecords {
+		items = items[:maxEditRecords]
+	}
+	return rec, p.Set(editHistoryKey, items)
+}
+
+// TakeEdit removes an edit from the history and returns it.
+func (p *Project) TakeEdit(id string) (EditRecord, error) {
+	items := p.ListEdits()
+	for i, it := range items {
+		if it.ID == id {
+			rest := append(items[:i:i], items[i+1:]...)
+			return it, p.Set(editHistoryKey, rest)
+		}
+	}
+	return EditRecord{}, fmt.Errorf("edit %q not found", id)
+}
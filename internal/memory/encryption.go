@@ -0,0 +1,361 @@
+package memory
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/loom/loom/internal/keychain"
+)
+
+// sealedMagic starts every encrypted file; files without it are read as plaintext.
+var sealedMagic = []byte("LOOMENC1")
+
+// ErrEncryptionLocked is returned when reading an encrypted file whose key cannot be
+// loaded from the keychain.
+var ErrEncryptionLocked = errors.New("the data is encrypted and its key is not available")
+
+// Encryption records whether a workspace's stored data is encrypted at rest. It is
+// kept in plaintext next to the data so the key can be looked up before reading it.
+type Encryption struct {
+	Enabled   bool   `json:"enabled"`
+	Algorithm string `json:"algorithm"`
+	// KeyStore is where the key lives: a keychain backend name
+	KeyStore string `json:"key_store"`
+}
+
+const (
+	encryptionKey       = "encryption"
+	encryptionAlgorithm = "aes-256-gcm"
+	keychainService     = "loom"
+)
+
+// plaintextKeys are project keys that are never encrypted: the encryption record
+// itself and the workspace path used by disk usage reports.
+var plaintextKeys = map[string]bool{encryptionKey: true, workspaceKey: true}
+
+// Sealer encrypts and decrypts one workspace's data with AES-256-GCM. A nil Sealer
+// leaves data unchanged.
+type Sealer struct {
+	aead cipher.AEAD
+}
+
+func newSealer(key []byte) (*Sealer, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &Sealer{aead: aead}, nil
+}
+
+// Seal encrypts plain as magic, nonce and ciphertext.
+func (s *Sealer) Seal(plain []byte) ([]byte, error) {
+	if s == nil {
+		return plain, nil
+	}
+	nonce := make([]byte, s.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	out := append(append([]byte{}, sealedMagic...), nonce...)
+	return s.aead.Seal(out, nonce, plain, nil), nil
+}
+
+// Open decrypts data written by Seal. Plaintext data is returned as is, so stores
+// keep working while existing files are being migrated.
+func (s *Sealer) Open(data []byte) ([]byte, error) {
+	if !IsSealed(data) {
+		return data, nil
+	}
+	if s == nil {
+		return nil, ErrEncryptionLocked
+	}
+	body := data[len(sealedMagic):]
+	n := s.aead.NonceSize()
+	if len(body) < n {
+		return nil, errors.New("encrypted data is truncated")
+	}
+	plain, err := s.aead.Open(nil, body[:n], body[n:], nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt: %w", err)
+	}
+	return plain, nil
+}
+
+// IsSealed reports whether data was written by Seal.
+func IsSealed(data []byte) bool {
+	return bytes.HasPrefix(data, sealedMagic)
+}
+
+// keyAccount names a project's key in the keychain.
+func keyAccount(projectID string) string {
+	return "workspace-" + projectID
+}
+
+// loadKey and storeKey read and write a project's key. Replaced in tests.
+var (
+	loadKey = func(projectID string) ([]byte, error) {
+		s, err := keychain.Get(keychainService, keyAccount(projectID))
+		if err != nil {
+			return nil, err
+		}
+		return base64.StdEncoding.DecodeString(s)
+	}
+	storeKey = func(projectID string, key []byte) (string, error) {
+		if err := keychain.Set(keychainService, keyAccount(projectID), base64.StdEncoding.EncodeToString(key)); err != nil {
+			return "", err
+		}
+		return keychain.Backend(), nil
+	}
+)
+
+// sealers caches loaded keys by project ID so the keychain is asked once per process.
+var sealers sync.Map
+
+func projectSealer(projectID string) (*Sealer, error) {
+	if s, ok := sealers.Load(projectID); ok {
+		return s.(*Sealer), nil
+	}
+	key, err := loadKey(projectID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrEncryptionLocked, err)
+	}
+	s, err := newSealer(key)
+	if err != nil {
+		return nil, err
+	}
+	sealers.Store(projectID, s)
+	return s, nil
+}
+
+// sealerFor returns the sealer of the project a store key belongs to, or nil when it
+// is stored in plaintext. The caller holds s.mu.
+func (s *Store) sealerFor(key string) *Sealer {
+	for prefix, sealer := range s.sealers {
+		if rest, ok := strings.CutPrefix(key, prefix); ok && !plaintextKeys[rest] {
+			return sealer
+		}
+	}
+	return nil
+}
+
+// setSealer encrypts keys below prefix from now on; nil stops encrypting them.
+func (s *Store) setSealer(prefix string, sealer *Sealer) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if sealer == nil {
+		delete(s.sealers, prefix)
+		return
+	}
+	s.sealers[prefix] = sealer
+}
+
+// openData decrypts file data read for key. The caller holds s.mu.
+func (s *Store) openData(key string, data []byte) ([]byte, error) {
+	if !IsSealed(data) {
+		return data, nil
+	}
+	sealer := s.sealerFor(key)
+	if sealer == nil {
+		// Files may still be encrypted after encryption was turned off elsewhere
+		if id, ok := projectIDOf(key); ok {
+			sealer, _ = projectSealer(id)
+		}
+	}
+	return sealer.Open(data)
+}
+
+// projectIDOf returns the project ID of a "projects/<id>/..." store key.
+func projectIDOf(key string) (string, bool) {
+	rest, ok := strings.CutPrefix(key, "projects/")
+	if !ok {
+		return "", false
+	}
+	id, _, ok := strings.Cut(rest, "/")
+	return id, ok
+}
+
+// Encryption returns the project's encryption record.
+func (p *Project) Encryption() Encryption {
+	var enc Encryption
+	if p.Has(encryptionKey) {
+		_ = p.Get(encryptionKey, &enc)
+	}
+	return enc
+}
+
+// Sealer returns the sealer of the project's data, or nil when it is not encrypted.
+// Use it for project data kept outside the store, such as files in the workspace.
+func (p *Project) Sealer() *Sealer {
+	p.store.mu.RLock()
+	defer p.store.mu.RUnlock()
+	return p.store.sealers[p.keyPrefix()]
+}
+
+func (p *Project) keyPrefix() string {
+	return fmt.Sprintf("projects/%s/", p.projectID)
+}
+
+// loadEncryption starts encrypting the project's keys when its record says so.
+func (p *Project) loadEncryption() error {
+	if !p.Encryption().Enabled {
+		return nil
+	}
+	sealer, err := projectSealer(p.projectID)
+	if err != nil {
+		return err
+	}
+	p.store.setSealer(p.keyPrefix(), sealer)
+	return nil
+}
+
+// EnableEncryption encrypts the project's stored data at rest with a new AES-256-GCM
+// key kept in the OS keychain, and re-writes existing data and the files under
+// extraDirs encrypted. It is a no-op when encryption is already on.
+func (p *Project) EnableEncryption(extraDirs ...string) error {
+	if p.Encryption().Enabled {
+		return nil
+	}
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return err
+	}
+	store, err := storeKey(p.projectID, key)
+	if err != nil {
+		return fmt.Errorf("failed to store the key: %w", err)
+	}
+	sealer, err := newSealer(key)
+	if err != nil {
+		return err
+	}
+	sealers.Store(p.projectID, sealer)
+	if err := p.Set(encryptionKey, Encryption{Enabled: true, Algorithm: encryptionAlgorithm, KeyStore: store}); err != nil {
+		return err
+	}
+	p.store.setSealer(p.keyPrefix(), sealer)
+	return p.reseal(sealer, extraDirs)
+}
+
+// DisableEncryption re-writes the project's data and the files under extraDirs in
+// plaintext. The key stays in the keychain so copies made while encryption was on
+// can still be read.
+func (p *Project) DisableEncryption(extraDirs ...string) error {
+	if !p.Encryption().Enabled {
+		return nil
+	}
+	p.store.setSealer(p.keyPrefix(), nil)
+	if err := p.reseal(nil, extraDirs); err != nil {
+		return err
+	}
+	return p.Set(encryptionKey, Encryption{})
+}
+
+// reseal re-writes every file of the project, its audit log and every .json file
+// under extraDirs with sealer (nil for plaintext). The store stays locked throughout
+// so concurrent writes are neither lost nor overwritten with stale data.
+func (p *Project) reseal(sealer *Sealer, extraDirs []string) error {
+	current, err := projectSealer(p.projectID)
+	if err != nil {
+		current = nil
+	}
+	p.store.mu.Lock()
+	defer p.store.mu.Unlock()
+	if err := p.resealAudit(current, sealer); err != nil {
+		return err
+	}
+	dirs := append([]string{p.projectDir()}, extraDirs...)
+	for i, dir := range dirs {
+		err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				if os.IsNotExist(err) {
+					return nil
+				}
+				return err
+			}
+			if d.IsDir() || filepath.Ext(path) != ".json" {
+				return nil
+			}
+			if i == 0 {
+				rel, _ := filepath.Rel(dir, path)
+				if plaintextKeys[strings.TrimSuffix(filepath.ToSlash(rel), ".json")] {
+					return nil
+				}
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			plain, err := current.Open(data)
+			if err != nil {
+				return fmt.Errorf("%s: %w", path, err)
+			}
+			return WriteSealed(sealer, path, plain)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteSealed writes data to path encrypted with sealer, or in plaintext when sealer
+// is nil. Encrypted files are readable by the owner only.
+func WriteSealed(sealer *Sealer, path string, data []byte) error {
+	if sealer == nil {
+		return os.WriteFile(path, data, 0o644)
+	}
+	sealed, err := sealer.Seal(data)
+	if err != nil {
+		return err
+	}
+	return writeOwnerOnly(path, sealed)
+}
+
+// writeOwnerOnly replaces path with data in a 0600 file. os.WriteFile keeps the mode
+// of an existing file, so the data goes to a temp file that is renamed over path.
+func writeOwnerOnly(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0o600); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	return nil
+}
+
+// ReadSealed reads path and decrypts it with sealer when it is encrypted.
+func ReadSealed(sealer *Sealer, path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return sealer.Open(data)
+}
@@ -0,0 +1,146 @@
+package memory
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// withTestKeys keeps keys in memory instead of the OS keychain.
+func withTestKeys(t *testing.T) map[string][]byte {
+	keys := map[string][]byte{}
+	oldLoad, oldStore := loadKey, storeKey
+	loadKey = func(id string) ([]byte, error) {
+		if k, ok := keys[id]; ok {
+			return k, nil
+		}
+		return nil, errors.New("no key")
+	}
+	storeKey = func(id string, key []byte) (string, error) {
+		keys[id] = key
+		return "test", nil
+	}
+	t.Cleanup(func() {
+		loadKey, storeKey = oldLoad, oldStore
+		sealers.Range(func(k, _ interface{}) bool { sealers.Delete(k); return true })
+	})
+	return keys
+}
+
+func TestProjectEncryption(t *testing.T) {
+	keys := withTestKeys(t)
+	root, ws, extra := t.TempDir(), t.TempDir(), t.TempDir()
+	store, _ := NewStore(root)
+	proj, err := NewProject(store, ws)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := proj.Set("conversations/c1", []Message{{Role: "user", Content: "secret plan"}}); err != nil {
+		t.Fatal(err)
+	}
+	runFile := filepath.Join(extra, "run.json")
+	_ = os.WriteFile(runFile, []byte(`{"goal":"secret plan"}`), 0o644)
+
+	if err := proj.EnableEncryption(extra); err != nil {
+		t.Fatal(err)
+	}
+	if enc := proj.Encryption(); !enc.Enabled || enc.KeyStore != "test" || proj.Sealer() == nil {
+		t.Fatalf("expected encryption to be on, got %+v", enc)
+	}
+	if err := proj.Set("memories/scoped", []string{"secret memory"}); err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{
+		filepath.Join(proj.projectDir(), "conversations", "c1.json"),
+		filepath.Join(proj.projectDir(), "memories", "scoped.json"),
+		runFile,
+	} {
+		data, _ := os.ReadFile(path)
+		if !IsSealed(data) || bytes.Contains(data, []byte("secret")) {
+			t.Errorf("%s should be encrypted, got %q", path, data)
+		}
+		// Files that existed before encryption was turned on become owner-only too
+		if st, err := os.Stat(path); err != nil || st.Mode().Perm() != 0o600 {
+			t.Errorf("%s should be owner-only, got %v", path, st.Mode())
+		}
+	}
+	if data, _ := os.ReadFile(filepath.Join(proj.projectDir(), "workspace.json")); IsSealed(data) {
+		t.Error("the workspace path should stay readable for disk usage reports")
+	}
+	if data, err := ReadSealed(proj.Sealer(), runFile); err != nil || string(data) != `{"goal":"secret plan"}` {
+		t.Errorf("unexpected run file %q, %v", data, err)
+	}
+
+	// A fresh store (another process) loads the key and reads the data
+	sealers.Delete(proj.projectID)
+	other, _ := NewStore(root)
+	reopened, err := NewProject(other, ws)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var msgs []Message
+	if err := reopened.Get("conversations/c1", &msgs); err != nil || msgs[0].Content != "secret plan" {
+		t.Fatalf("expected the decrypted conversation, got %+v, %v", msgs, err)
+	}
+
+	// Without the key the project cannot be opened, so nothing is written in plaintext
+	sealers.Delete(proj.projectID)
+	delete(keys, proj.projectID)
+	locked, _ := NewStore(root)
+	if _, err := NewProject(locked, ws); !errors.Is(err, ErrEncryptionLocked) {
+		t.Fatalf("expected a locked error, got %v", err)
+	}
+}
+
+func TestProjectDisableEncryption(t *testing.T) {
+	withTestKeys(t)
+	store, _ := NewStore(t.TempDir())
+	proj, _ := NewProject(store, t.TempDir())
+	_ = proj.EnableEncryption()
+	_ = proj.Set("conversations/c1", []Message{{Role: "user", Content: "hello"}})
+	if err := proj.DisableEncryption(); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(filepath.Join(proj.projectDir(), "conversations", "c1.json"))
+	if IsSealed(data) || !bytes.Contains(data, []byte("hello")) {
+		t.Errorf("expected plaintext after disabling, got %q", data)
+	}
+	if proj.Encryption().Enabled || proj.Sealer() != nil {
+		t.Error("encryption should be off")
+	}
+}
+
+func TestProjectEncryptionSealsAuditLog(t *testing.T) {
+	withTestKeys(t)
+	store, _ := NewStore(t.TempDir())
+	proj, _ := NewProject(store, t.TempDir())
+	if err := proj.RecordAudit("shell", map[string]string{"command": "echo secret-before"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := proj.EnableEncryption(); err != nil {
+		t.Fatal(err)
+	}
+	if err := proj.RecordAudit("shell", map[string]string{"command": "echo secret-after"}); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(proj.AuditPath())
+	if bytes.Contains(data, []byte("secret")) {
+		t.Fatalf("audit log should be encrypted, got %q", data)
+	}
+	if n, err := proj.VerifyAudit(); err != nil || n != 2 {
+		t.Fatalf("expected 2 intact events, got %d, %v", n, err)
+	}
+
+	if err := proj.DisableEncryption(); err != nil {
+		t.Fatal(err)
+	}
+	data, _ = os.ReadFile(proj.AuditPath())
+	if !bytes.Contains(data, []byte("secret-after")) {
+		t.Fatalf("expected a plaintext audit log after disabling, got %q", data)
+	}
+	if n, err := proj.VerifyAudit(); err != nil || n != 2 {
+		t.Fatalf("expected 2 intact events, got %d, %v", n, err)
+	}
+}
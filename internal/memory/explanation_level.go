@@ -0,0 +1,31 @@
+package memory
+
+import "errors"
+
+const explanationLevelPrefix = "explanation_level/"
+
+// ExplanationLevel returns the explanation level chosen for a conversation, or "" if it
+// uses the default from settings.
+func (p *Project) ExplanationLevel(conversationID string) string {
+	var level string
+	if p == nil || conversationID == "" || !p.Has(explanationLevelPrefix+conversationID) {
+		return level
+	}
+	_ = p.Get(explanationLevelPrefix+conversationID, &level)
+	return level
+}
+
+// SetExplanationLevel records the explanation level chosen for a conversation; ""
+// falls back to the default from settings again.
+func (p *Project) SetExplanationLevel(conversationID, level string) error {
+	if p == nil {
+		return errors.New("project memory not initialized")
+	}
+	if conversationID == "" {
+		return errors.New("no active conversation")
+	}
+	if level == "" {
+		return p.Delete(explanationLevelPrefix + conversationID)
+	}
+	return p.Set(explanationLevelPrefix+conversationID, level)
+}
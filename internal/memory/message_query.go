@@ -0,0 +1,143 @@
+package memory
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+const (
+	defaultMessagePageSize = 50
+	maxMessagePageSize     = 500
+)
+
+// MessageQuery selects a page of a conversation's messages. Filters combine with AND;
+// empty filters match everything.
+type MessageQuery struct {
+	// Roles keeps messages with one of these roles (user, assistant, tool, system)
+	Roles []string `json:"roles,omitempty"`
+	// Tools keeps tool calls and tool results of these tools
+	Tools []string `json:"tools,omitempty"`
+	// Since and Until bound the message timestamps (inclusive)
+	Since time.Time `json:"since,omitempty"`
+	Until time.Time `json:"until,omitempty"`
+	// Text keeps messages whose content contains it, ignoring case
+	Text string `json:"text,omitempty"`
+	// IncludeInternal also returns messages the chat view hides: system prompts and
+	// notes, thinking blocks and tool_use calls
+	IncludeInternal bool `json:"include_internal,omitempty"`
+	// Before and After are exclusive message index cursors. With Before (or neither)
+	// the page holds the newest matches before it; with After, the oldest after it.
+	Before *int `json:"before,omitempty"`
+	After  *int `json:"after,omitempty"`
+	// Limit is the page size (default 50, at most 500)
+	Limit int `json:"limit,omitempty"`
+}
+
+// IndexedMessage is a message with its position in the conversation, which is what
+// the Before and After cursors refer to.
+type IndexedMessage struct {
+	Index int `json:"index"`
+	Message
+}
+
+// MessagePage is one page of query results, oldest first.
+type MessagePage struct {
+	Messages []IndexedMessage `json:"messages"`
+	// Total counts all messages matching the filters, regardless of the cursors
+	Total int `json:"total"`
+	// HasMore reports further matches beyond the page in the direction of the query
+	HasMore bool `json:"has_more"`
+}
+
+// Internal reports whether the chat view hides the message: system messages and the
+// assistant's thinking and tool_use entries.
+func (m Message) Internal() bool {
+	if m.Role == "system" {
+		return true
+	}
+	return m.Role == "assistant" && (strings.TrimSpace(m.Name) != "" || strings.TrimSpace(m.ToolID) != "")
+}
+
+// QueryConversation returns a page of a stored conversation's messages.
+func (p *Project) QueryConversation(conversationID string, q MessageQuery) (MessagePage, error) {
+	if p == nil {
+		return MessagePage{}, errors.New("project memory not initialized")
+	}
+	var msgs []Message
+	if conversationID != "" && p.Has("conversations/"+conversationID) {
+		if err := p.Get("conversations/"+conversationID, &msgs); err != nil {
+			return MessagePage{}, err
+		}
+	}
+	return QueryMessages(msgs, q), nil
+}
+
+// QueryMessages returns the page of msgs selected by q.
+func QueryMessages(msgs []Message, q MessageQuery) MessagePage {
+	limit := q.Limit
+	if limit <= 0 {
+		limit = defaultMessagePageSize
+	}
+	if limit > maxMessagePageSize {
+		limit = maxMessagePageSize
+	}
+	roles := toSet(q.Roles)
+	tools := toSet(q.Tools)
+	text := strings.ToLower(strings.TrimSpace(q.Text))
+
+	var matches []IndexedMessage
+	for i, m := range msgs {
+		if !q.IncludeInternal && m.Internal() {
+			continue
+		}
+		if len(roles) > 0 && !roles[m.Role] {
+			continue
+		}
+		if len(tools) > 0 && (m.Name == "" || !tools[m.Name] || (m.Role != "tool" && m.ToolID == "")) {
+			continue
+		}
+		if !q.Since.IsZero() && m.Timestamp.Before(q.Since) {
+			continue
+		}
+		if !q.Until.IsZero() && m.Timestamp.After(q.Until) {
+			continue
+		}
+		if text != "" && !strings.Contains(strings.ToLower(m.Content), text) {
+			continue
+		}
+		matches = append(matches, IndexedMessage{Index: i, Message: m})
+	}
+
+	page := MessagePage{Messages: []IndexedMessage{}, Total: len(matches)}
+	var window []IndexedMessage
+	for _, m := range matches {
+		if q.Before != nil && m.Index >= *q.Before {
+			continue
+		}
+		if q.After != nil && m.Index <= *q.After {
+			continue
+		}
+		window = append(window, m)
+	}
+	if len(window) > limit {
+		page.HasMore = true
+		if q.After != nil && q.Before == nil {
+			window = window[:limit]
+		} else {
+			window = window[len(window)-limit:]
+		}
+	}
+	page.Messages = append(page.Messages, window...)
+	return page
+}
+
+func toSet(items []string) map[string]bool {
+	set := map[string]bool{}
+	for _, s := range items {
+		if s = strings.TrimSpace(s); s != "" {
+			set[s] = true
+		}
+	}
+	return set
+}
@@ -0,0 +1,68 @@
+package memory
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func queryFixture() []Message {
+	t0 := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	msgs := []Message{{Role: "system", Content: "You are Loom", Timestamp: t0}}
+	for i := 1; i <= 10; i++ {
+		at := t0.Add(time.Duration(i) * time.Minute)
+		msgs = append(msgs,
+			Message{Role: "user", Content: fmt.Sprintf("question %d", i), Timestamp: at},
+			Message{Role: "assistant", Name: "read_file", ToolID: fmt.Sprintf("t%d", i), Content: `{"path":"a.go"}`, Timestamp: at},
+			Message{Role: "tool", Name: "read_file", ToolID: fmt.Sprintf("t%d", i), Content: "package a", Timestamp: at},
+			Message{Role: "assistant", Content: fmt.Sprintf("Answer %d about the Parser", i), Timestamp: at},
+		)
+	}
+	return msgs
+}
+
+func TestQueryMessages_PagesBackwardsFromNewest(t *testing.T) {
+	msgs := queryFixture()
+	page := QueryMessages(msgs, MessageQuery{Limit: 4})
+	// System and tool_use messages are hidden: 10 × (user, tool, answer)
+	if page.Total != 30 || !page.HasMore || len(page.Messages) != 4 {
+		t.Fatalf("unexpected page: total=%d more=%v len=%d", page.Total, page.HasMore, len(page.Messages))
+	}
+	last := page.Messages[len(page.Messages)-1]
+	if last.Index != len(msgs)-1 || last.Content != "Answer 10 about the Parser" {
+		t.Fatalf("expected the newest message last, got %+v", last)
+	}
+
+	before := page.Messages[0].Index
+	older := QueryMessages(msgs, MessageQuery{Limit: 4, Before: &before})
+	if len(older.Messages) != 4 || older.Messages[3].Index >= before {
+		t.Fatalf("expected the 4 matches before %d, got %+v", before, older.Messages)
+	}
+
+	after := older.Messages[3].Index
+	newer := QueryMessages(msgs, MessageQuery{Limit: 2, After: &after})
+	if len(newer.Messages) != 2 || newer.Messages[0].Index != before || !newer.HasMore {
+		t.Fatalf("expected the oldest matches after %d, got %+v", after, newer)
+	}
+}
+
+func TestQueryMessages_Filters(t *testing.T) {
+	msgs := queryFixture()
+	t0 := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	if p := QueryMessages(msgs, MessageQuery{Roles: []string{"user"}}); p.Total != 10 {
+		t.Fatalf("role filter: %d", p.Total)
+	}
+	if p := QueryMessages(msgs, MessageQuery{Tools: []string{"read_file"}}); p.Total != 10 {
+		t.Fatalf("tool filter should keep results only: %d", p.Total)
+	}
+	if p := QueryMessages(msgs, MessageQuery{Tools: []string{"read_file"}, IncludeInternal: true}); p.Total != 20 {
+		t.Fatalf("tool filter with internal messages should keep calls too: %d", p.Total)
+	}
+	if p := QueryMessages(msgs, MessageQuery{Text: "parser", Since: t0.Add(3 * time.Minute), Until: t0.Add(5 * time.Minute)}); p.Total != 3 || p.Messages[0].Content != "Answer 3 about the Parser" {
+		t.Fatalf("text and time filter: %+v", p)
+	}
+	if p := QueryMessages(msgs, MessageQuery{IncludeInternal: true, Roles: []string{"system"}}); p.Total != 1 {
+		t.Fatalf("internal system message: %d", p.Total)
+	}
+}
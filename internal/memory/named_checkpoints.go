@@ -0,0 +1,99 @@
+package memory
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// NamedCheckpoint labels a point of a conversation's timeline, e.g. "before refactor
+// of auth module". Step is the timeline step it was taken after; 0 is the workspace
+// before the conversation changed anything.
+type NamedCheckpoint struct {
+	Name string `json:"name"`
+	Step int    `json:"step"`
+	// Source is "model" for checkpoint tool calls and "user" for ones set in the UI
+	Source string    `json:"source"`
+	At     time.Time `json:"at"`
+}
+
+const (
+	namedCheckpointsPrefix = "named_checkpoints/"
+	// maxNamedCheckpoints bounds the named checkpoints kept per conversation
+	maxNamedCheckpoints = 100
+	// maxCheckpointName bounds the length of a checkpoint name
+	maxCheckpointName = 120
+)
+
+// NamedCheckpoints returns a conversation's named checkpoints, oldest first.
+func (p *Project) NamedCheckpoints(conversationID string) []NamedCheckpoint {
+	var items []NamedCheckpoint
+	if p == nil || conversationID == "" || !p.Has(namedCheckpointsPrefix+conversationID) {
+		return items
+	}
+	_ = p.Get(namedCheckpointsPrefix+conversationID, &items)
+	return items
+}
+
+// NamedCheckpoint finds a conversation's checkpoint by name, ignoring case.
+func (p *Project) NamedCheckpoint(conversationID, name string) (NamedCheckpoint, bool) {
+	name = strings.TrimSpace(name)
+	for _, c := range p.NamedCheckpoints(conversationID) {
+		if strings.EqualFold(c.Name, name) {
+			return c, true
+		}
+	}
+	return NamedCheckpoint{}, false
+}
+
+// AddNamedCheckpoint stores a named checkpoint. A checkpoint with the same name is
+// replaced, so reusing a name moves it to the new point.
+func (p *Project) AddNamedCheckpoint(conversationID string, c NamedCheckpoint) (NamedCheckpoint, error) {
+	if p == nil {
+		return c, errors.New("project memory not initialized")
+	}
+	if conversationID == "" {
+		return c, errors.New("no active conversation")
+	}
+	c.Name = strings.Join(strings.Fields(c.Name), " ")
+	if c.Name == "" {
+		return c, errors.New("checkpoint name is required")
+	}
+	if len(c.Name) > maxCheckpointName {
+		return c, fmt.Errorf("checkpoint name is longer than %d characters", maxCheckpointName)
+	}
+	if c.At.IsZero() {
+		c.At = time.Now()
+	}
+	items := p.NamedCheckpoints(conversationID)
+	kept := items[:0]
+	for _, existing := range items {
+		if !strings.EqualFold(existing.Name, c.Name) {
+			kept = append(kept, existing)
+		}
+	}
+	items = append(kept, c)
+	if len(items) > maxNamedCheckpoints {
+		items = items[len(items)-maxNamedCheckpoints:]
+	}
+	return c, p.Set(namedCheckpointsPrefix+conversationID, items)
+}
+
+// RemoveNamedCheckpoint deletes a named checkpoint; the timeline is not changed.
+func (p *Project) RemoveNamedCheckpoint(conversationID, name string) error {
+	if p == nil || conversationID == "" {
+		return errors.New("no active conversation")
+	}
+	items := p.NamedCheckpoints(conversationID)
+	kept := items[:0]
+	for _, c := range items {
+		if !strings.EqualFold(c.Name, strings.TrimSpace(name)) {
+			kept = append(kept, c)
+		}
+	}
+	if len(kept) == len(items) {
+		return fmt.Errorf("no checkpoint named %q", name)
+	}
+	return p.Set(namedCheckpointsPrefix+conversationID, kept)
+}
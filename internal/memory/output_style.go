@@ -0,0 +1,31 @@
+package memory
+
+import "errors"
+
+const outputStylePrefix = "output_style/"
+
+// OutputStyle returns the output style chosen for a conversation, or "" if it uses the
+// default from settings.
+func (p *Project) OutputStyle(conversationID string) string {
+	var name string
+	if p == nil || conversationID == "" || !p.Has(outputStylePrefix+conversationID) {
+		return name
+	}
+	_ = p.Get(outputStylePrefix+conversationID, &name)
+	return name
+}
+
+// SetOutputStyle records the output style chosen for a conversation; "" falls back to
+// the default from settings again.
+func (p *Project) SetOutputStyle(conversationID, name string) error {
+	if p == nil {
+		return errors.New("project memory not initialized")
+	}
+	if conversationID == "" {
+		return errors.New("no active conversation")
+	}
+	if name == "" {
+		return p.Delete(outputStylePrefix + conversationID)
+	}
+	return p.Set(outputStylePrefix+conversationID, name)
+}
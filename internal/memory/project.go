@@ -10,6 +10,8 @@ import (
 	"strings"
 	"sync"
 	"time"
+
+	"github.com/loom/loom/internal/textutil"
 )
 
 // Project manages workspace-specific persistent storage.
@@ -18,6 +20,10 @@ type Project struct {
 	workspacePath string
 	projectID     string
 	mu            sync.RWMutex
+	// pinned is the conversation of a view made by ForConversation
+	pinned string
+	// views counts the conversations open in views; shared with the views
+	views *openViews
 }
 
 // NewProject creates a new project storage for the given workspace.
@@ -37,11 +43,21 @@ func NewProject(store *Store, workspacePath string) (*Project, error) {
 		return nil, fmt.Errorf("failed to create projects directory: %w", err)
 	}
 
-	return &Project{
+	p := &Project{
 		store:         store,
 		workspacePath: absPath,
 		projectID:     projectID,
-	}, nil
+		views:         &openViews{},
+	}
+	// Encrypted workspaces must not be written before their key is loaded
+	if err := p.loadEncryption(); err != nil {
+		return nil, err
+	}
+	// Remember which workspace this hashed ID belongs to for disk usage reports
+	if !p.Has(workspaceKey) {
+		_ = p.Set(workspaceKey, absPath)
+	}
+	return p, nil
 }
 
 // Get retrieves a value from project storage.
@@ -135,9 +151,10 @@ func (p *Project) GetConversations() (map[string][]interface{}, error) {
 
 // ConversationSummary is a lightweight summary for listing conversations.
 type ConversationSummary struct {
-	ID        string    `json:"id"`
-	Title     string    `json:"title"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID        string           `json:"id"`
+	Title     string           `json:"title"`
+	UpdatedAt time.Time        `json:"updated_at"`
+	Tags      ConversationTags `json:"tags"`
 }
 
 // ConversationMeta stores additional metadata for a conversation
@@ -148,6 +165,9 @@ type ConversationMeta struct {
 
 // CurrentConversationID returns the currently active conversation id.
 func (p *Project) CurrentConversationID() string {
+	if p.pinned != "" {
+		return p.pinned
+	}
 	var id string
 	if p.Has("conversations/current_id") {
 		if err := p.Get("conversations/current_id", &id); err == nil {
@@ -159,12 +179,18 @@ func (p *Project) CurrentConversationID() string {
 
 // SetCurrentConversationID sets the current conversation id.
 func (p *Project) SetCurrentConversationID(id string) error {
+	if p.pinned != "" {
+		if id == p.pinned {
+			return nil
+		}
+		return fmt.Errorf("this view is pinned to conversation %s", p.pinned)
+	}
 	return p.Set("conversations/current_id", id)
 }
 
 // CreateNewConversation creates a new empty conversation, sets it current, and returns its id.
 func (p *Project) CreateNewConversation() string {
-	id := generateConversationID()
+	id := p.newConversationID()
 	// Initialize empty message list
 	_ = p.Set("conversations/"+id, []Message{})
 	_ = p.SetCurrentConversationID(id)
@@ -207,7 +233,7 @@ func (p *Project) ListConversationSummaries() ([]ConversationSummary, error) {
 		if !meta.UpdatedAt.IsZero() && meta.UpdatedAt.After(updated) {
 			updated = meta.UpdatedAt
 		}
-		summaries = append(summaries, ConversationSummary{ID: convID, Title: title, UpdatedAt: updated})
+		summaries = append(summaries, ConversationSummary{ID: convID, Title: title, UpdatedAt: updated, Tags: p.ConversationTags(convID)})
 	}
 	sort.Slice(summaries, func(i, j int) bool {
 		return summaries[i].UpdatedAt.After(summaries[j].UpdatedAt)
@@ -223,10 +249,7 @@ func trimTitle(s string) string {
 	}
 	// limit length
 	const max = 80
-	if len(s) > max {
-		return s[:max] + "…"
-	}
-	return s
+	return textutil.TruncateWidth(s, max+1)
 }
 
 func generateConversationID() string {
@@ -248,14 +271,42 @@ func (p *Project) GetConversationTitle(id string) string {
 	return ""
 }
 
-// DeleteConversation removes a conversation and its metadata.
+// AttachmentsDir returns the directory holding files attached to a conversation
+// from outside the workspace. The directory is not created.
+func (p *Project) AttachmentsDir(conversationID string) string {
+	return filepath.Join(p.store.rootDir, "projects", p.projectID, "attachments", conversationID)
+}
+
+// OverlayDir returns the directory holding a conversation's what-if overlay.
+// The directory is not created.
+func (p *Project) OverlayDir(conversationID string) string {
+	return filepath.Join(p.store.rootDir, "projects", p.projectID, "overlays", conversationID)
+}
+
+// DeleteConversation removes a conversation, its metadata, file snapshots, timeline,
+// named checkpoints, annotations, disabled tools, reproduction, output style,
+// explanation level, attachments, and what-if overlay.
 func (p *Project) DeleteConversation(id string) error {
 	_ = p.Delete("conversations/" + id)
 	_ = p.Delete("conversations_meta/" + id)
+	_ = p.Delete(fileSnapshotsPrefix + id)
+	_ = p.Delete(timelinePrefix + id)
+	_ = p.Delete(namedCheckpointsPrefix + id)
+	_ = p.Delete(annotationsPrefix + id)
+	_ = p.Delete(disabledToolsPrefix + id)
+	_ = p.Delete(reproductionPrefix + id)
+	_ = p.Delete(outputStylePrefix + id)
+	_ = p.Delete(explanationLevelPrefix + id)
+	_ = p.Delete(conversationTagsPrefix + id)
+	if id != "" {
+		_ = os.RemoveAll(p.AttachmentsDir(id))
+		_ = os.RemoveAll(p.OverlayDir(id))
+	}
 	return nil
 }
 
-// CleanupEmptyConversations deletes all non-current conversations that have no user messages.
+// CleanupEmptyConversations deletes all non-current conversations that have no user
+// messages, except those open in a view.
 func (p *Project) CleanupEmptyConversations(currentID string) {
 	keys, err := p.Keys()
 	if err != nil {
@@ -266,7 +317,7 @@ func (p *Project) CleanupEmptyConversations(currentID string) {
 			continue
 		}
 		convID := strings.TrimPrefix(key, "conversations/")
-		if convID == currentID {
+		if convID == currentID || p.views.has(convID) {
 			continue
 		}
 		var messages []Message
@@ -0,0 +1,158 @@
+package memory
+
+import (
+	"math"
+	"slices"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// MemoryDoc is the searchable part of a memory.
+type MemoryDoc struct {
+	Text string
+	Tags []string
+}
+
+// MemoryMatch is a memory that matched a search, by its index in the searched slice.
+type MemoryMatch struct {
+	Index int
+	Score float64
+}
+
+// rankStopwords are too common to tell memories apart.
+var rankStopwords = map[string]bool{
+	"the": true, "and": true, "for": true, "with": true, "this": true, "that": true, "are": true,
+	"was": true, "use": true, "not": true, "you": true, "all": true, "can": true, "from": true,
+	"our": true, "when": true, "should": true, "always": true, "never": true, "please": true,
+	"in": true, "on": true, "of": true, "to": true, "is": true, "it": true, "be": true, "we": true,
+}
+
+const (
+	// BM25 parameters
+	rankK1 = 1.2
+	rankB  = 0.75
+	// tagWeight counts a tag like this many occurrences of the word in the text
+	tagWeight = 3
+)
+
+// RankMemories returns the memories matching a search, most relevant first. Every tag
+// in tags must be present on a memory (case-insensitive). The query is scored with
+// BM25 over the memories' text and tags, tags weighing more; memories sharing no term
+// with a non-empty query do not match. Without a query, tag matches keep their order.
+func RankMemories(query string, tags []string, docs []MemoryDoc) []MemoryMatch {
+	var candidates []int
+	for i, d := range docs {
+		if hasAllTags(d.Tags, tags) {
+			candidates = append(candidates, i)
+		}
+	}
+	var terms []string
+	for _, t := range rankTerms(query) {
+		if !slices.Contains(terms, t) {
+			terms = append(terms, t)
+		}
+	}
+	if len(terms) == 0 {
+		if strings.TrimSpace(query) != "" || len(tags) == 0 {
+			return nil
+		}
+		out := make([]MemoryMatch, 0, len(candidates))
+		for _, i := range candidates {
+			out = append(out, MemoryMatch{Index: i, Score: 1})
+		}
+		return out
+	}
+
+	// Term frequencies per memory; tags are repeated so they weigh more than text
+	tfs := make(map[int]map[string]int, len(candidates))
+	lengths := make(map[int]int, len(candidates))
+	df := map[string]int{}
+	total := 0
+	for _, i := range candidates {
+		words := rankTerms(docs[i].Text)
+		for _, tag := range docs[i].Tags {
+			for _, w := range rankTerms(tag) {
+				for n := 0; n < tagWeight; n++ {
+					words = append(words, w)
+				}
+			}
+		}
+		tf := map[string]int{}
+		for _, w := range words {
+			tf[w]++
+		}
+		for w := range tf {
+			df[w]++
+		}
+		tfs[i], lengths[i] = tf, len(words)
+		total += len(words)
+	}
+	if len(candidates) == 0 {
+		return nil
+	}
+	avg := float64(total) / float64(len(candidates))
+	n := float64(len(candidates))
+
+	var out []MemoryMatch
+	for _, i := range candidates {
+		score := 0.0
+		for _, t := range terms {
+			f := float64(tfs[i][t])
+			if f == 0 {
+				continue
+			}
+			idf := math.Log(1 + (n-float64(df[t])+0.5)/(float64(df[t])+0.5))
+			score += idf * f * (rankK1 + 1) / (f + rankK1*(1-rankB+rankB*float64(lengths[i])/avg))
+		}
+		if score > 0 {
+			out = append(out, MemoryMatch{Index: i, Score: score})
+		}
+	}
+	sort.SliceStable(out, func(a, b int) bool { return out[a].Score > out[b].Score })
+	return out
+}
+
+// NormalizeTags lowercases and trims tags, dropping empty and duplicate ones.
+func NormalizeTags(tags []string) []string {
+	var out []string
+	seen := map[string]bool{}
+	for _, t := range tags {
+		t = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(t), "#")))
+		if t == "" || seen[t] {
+			continue
+		}
+		seen[t] = true
+		out = append(out, t)
+	}
+	return out
+}
+
+func hasAllTags(have, want []string) bool {
+	have = NormalizeTags(have)
+	for _, w := range NormalizeTags(want) {
+		if !slices.Contains(have, w) {
+			return false
+		}
+	}
+	return true
+}
+
+// rankTerms splits text into lowercase words, dropping stopwords and plural endings so
+// "migrations" matches "migration".
+func rankTerms(text string) []string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	out := words[:0]
+	for _, w := range words {
+		if len(w) < 2 || rankStopwords[w] {
+			continue
+		}
+		if len(w) > 3 && strings.HasSuffix(w, "s") && !strings.HasSuffix(w, "ss") {
+			w = strings.TrimSuffix(w, "s")
+		}
+		out = append(out, w)
+	}
+	return out
+}
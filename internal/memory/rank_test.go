@@ -0,0 +1,47 @@
+package memory
+
+import "testing"
+
+func TestRankMemories(t *testing.T) {
+	docs := []MemoryDoc{
+		{Text: "Run database migrations with make migrate before the tests", Tags: []string{"database"}},
+		{Text: "Use tabs for indentation in Go files", Tags: []string{"style", "go"}},
+		{Text: "Deploys go through the staging cluster first", Tags: []string{"Deploy"}},
+		{Text: "Prefer table-driven tests", Tags: []string{"testing", "go"}},
+	}
+
+	got := RankMemories("why do the migration tests fail?", nil, docs)
+	if len(got) != 2 || got[0].Index != 0 || got[1].Index != 3 {
+		t.Fatalf("unexpected ranking: %+v", got)
+	}
+	if got[0].Score <= got[1].Score {
+		t.Errorf("expected descending scores: %+v", got)
+	}
+
+	// Tags filter; without a query matches keep their order
+	got = RankMemories("", []string{"GO"}, docs)
+	if len(got) != 2 || got[0].Index != 1 || got[1].Index != 3 {
+		t.Fatalf("unexpected tag matches: %+v", got)
+	}
+	if got = RankMemories("", []string{"go", "testing"}, docs); len(got) != 1 || got[0].Index != 3 {
+		t.Fatalf("all tags must match: %+v", got)
+	}
+	// Tags weigh more than text
+	if got = RankMemories("deploy", nil, docs); len(got) != 1 || got[0].Index != 2 {
+		t.Fatalf("unexpected deploy match: %+v", got)
+	}
+
+	if got = RankMemories("kubernetes", nil, docs); len(got) != 0 {
+		t.Errorf("unrelated query should not match: %+v", got)
+	}
+	if got = RankMemories("", nil, docs); len(got) != 0 {
+		t.Errorf("empty search should not match: %+v", got)
+	}
+}
+
+func TestNormalizeTags(t *testing.T) {
+	got := NormalizeTags([]string{" Go ", "#testing", "go", ""})
+	if len(got) != 2 || got[0] != "go" || got[1] != "testing" {
+		t.Errorf("unexpected tags: %v", got)
+	}
+}
@@ -0,0 +1,96 @@
+package memory
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+// RenameRecord is a file or directory move observed in the workspace.
+// Paths are workspace-relative and slash-separated.
+type RenameRecord struct {
+	From string    `json:"from"`
+	To   string    `json:"to"`
+	At   time.Time `json:"at"`
+}
+
+const (
+	renamesKey = "renames"
+	// maxRenameRecords bounds the persisted rename history
+	maxRenameRecords = 200
+)
+
+// ListRenames returns recorded renames, oldest first.
+func (p *Project) ListRenames() []RenameRecord {
+	var items []RenameRecord
+	if p == nil {
+		return items
+	}
+	if p.Has(renamesKey) {
+		_ = p.Get(renamesKey, &items)
+	}
+	return items
+}
+
+// RecordRename stores a move from one workspace-relative path to another and
+// rewrites directory memories scoped to the old location.
+func (p *Project) RecordRename(from, to string) error {
+	if p == nil {
+		return errors.New("project memory not initialized")
+	}
+	from, to = NormalizeMemoryDir(from), NormalizeMemoryDir(to)
+	if from == "" || to == "" || from == to {
+		return errors.New("rename requires two distinct workspace-relative paths")
+	}
+	items := append(p.ListRenames(), RenameRecord{From: from, To: to, At: time.Now()})
+	if len(items) > maxRenameRecords {
+		items = items[len(items)-maxRenameRecords:]
+	}
+	if err := p.Set(renamesKey, items); err != nil {
+		return err
+	}
+
+	// Directory memories follow the directory they describe
+	mems := p.ListScopedMemories()
+	changed := false
+	for i := range mems {
+		if mems[i].Scope != ScopeDirectory {
+			continue
+		}
+		if moved, ok := applyRename(mems[i].Dir, from, to); ok {
+			mems[i].Dir = moved
+			changed = true
+		}
+	}
+	if changed {
+		return p.Set(scopedMemoriesKey, mems)
+	}
+	return nil
+}
+
+// ResolveRenamed maps a possibly stale path to its current location by replaying
+// recorded renames in order. ok is false when no rename applies.
+func (p *Project) ResolveRenamed(path string) (string, bool) {
+	cur := NormalizeMemoryDir(path)
+	if cur == "" {
+		return path, false
+	}
+	moved := false
+	for _, r := range p.ListRenames() {
+		if next, ok := applyRename(cur, r.From, r.To); ok {
+			cur, moved = next, true
+		}
+	}
+	return cur, moved
+}
+
+// applyRename rewrites path when it equals from or lies below it.
+func applyRename(path, from, to string) (string, bool) {
+	switch {
+	case path == from:
+		return to, true
+	case strings.HasPrefix(path, from+"/"):
+		return to + strings.TrimPrefix(path, from), true
+	}
+	return path, false
+}
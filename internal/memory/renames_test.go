@@ -0,0 +1,37 @@
+package memory
+
+import "testing"
+
+func TestRecordRename_ResolvesChainsAndMovesDirectoryMemories(t *testing.T) {
+	store, err := NewStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("store: %v", err)
+	}
+	proj, err := NewProject(store, t.TempDir())
+	if err != nil {
+		t.Fatalf("project: %v", err)
+	}
+	if _, err := proj.SaveScopedMemory(ScopedMemory{ID: "pay", Text: "Use cents", Scope: ScopeDirectory, Dir: "services/payments"}); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+
+	if err := proj.RecordRename("services/payments", "services/billing"); err != nil {
+		t.Fatalf("rename dir: %v", err)
+	}
+	if err := proj.RecordRename("services/billing/charge.go", "services/billing/charges.go"); err != nil {
+		t.Fatalf("rename file: %v", err)
+	}
+
+	if got, ok := proj.ResolveRenamed("services/payments/charge.go"); !ok || got != "services/billing/charges.go" {
+		t.Fatalf("unexpected resolution %q %v", got, ok)
+	}
+	if got, ok := proj.ResolveRenamed("services/paymentsx/a.go"); ok {
+		t.Fatalf("prefix must match whole segments, got %q", got)
+	}
+	if mems := proj.DirectoryMemoriesFor([]string{"services/billing/x.go"}); len(mems) != 1 || mems[0].Dir != "services/billing" {
+		t.Fatalf("directory memory did not follow rename: %+v", mems)
+	}
+	if err := proj.RecordRename("a.go", "a.go"); err == nil {
+		t.Fatalf("expected error for identical paths")
+	}
+}
@@ -0,0 +1,57 @@
+package memory
+
+import (
+	"errors"
+	"time"
+)
+
+// Reproduction status values.
+const (
+	// ReproductionPending: the bug is described but no failing reproduction exists yet
+	ReproductionPending = "pending"
+	// ReproductionConfirmed: the reproduction ran and failed as the bug predicts
+	ReproductionConfirmed = "confirmed"
+	// ReproductionVerified: the reproduction passed after the fix
+	ReproductionVerified = "verified"
+)
+
+// Reproduction is a conversation's bug reproduction: the command that demonstrates
+// the bug and the files written for it, kept so the fix can be verified against it.
+type Reproduction struct {
+	Description string   `json:"description,omitempty"`
+	Command     string   `json:"command,omitempty"`
+	Artifacts   []string `json:"artifacts,omitempty"`
+	Status      string   `json:"status"`
+	// FailureOutput is the tail of the output that confirmed the failure
+	FailureOutput string    `json:"failure_output,omitempty"`
+	StartedAt     time.Time `json:"started_at"`
+	ConfirmedAt   time.Time `json:"confirmed_at,omitempty"`
+	VerifiedAt    time.Time `json:"verified_at,omitempty"`
+	// Runs counts how often the reproduction command was executed
+	Runs int `json:"runs"`
+}
+
+const reproductionPrefix = "reproductions/"
+
+// Reproduction returns a conversation's reproduction, if one was started.
+func (p *Project) Reproduction(conversationID string) (Reproduction, bool) {
+	var r Reproduction
+	if p == nil || conversationID == "" || !p.Has(reproductionPrefix+conversationID) {
+		return r, false
+	}
+	if err := p.Get(reproductionPrefix+conversationID, &r); err != nil {
+		return r, false
+	}
+	return r, true
+}
+
+// SetReproduction stores a conversation's reproduction.
+func (p *Project) SetReproduction(conversationID string, r Reproduction) error {
+	if p == nil {
+		return errors.New("project memory not initialized")
+	}
+	if conversationID == "" {
+		return errors.New("no active conversation")
+	}
+	return p.Set(reproductionPrefix+conversationID, r)
+}
@@ -0,0 +1,177 @@
+package memory
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// RetentionPolicy bounds how much conversation history a project keeps.
+// Zero fields disable the corresponding limit.
+type RetentionPolicy struct {
+	MaxSessions  int
+	MaxAge       time.Duration
+	MaxDiskBytes int64
+}
+
+// CleanupReport describes what a retention pass removed.
+type CleanupReport struct {
+	ProjectID  string   `json:"project_id"`
+	Removed    []string `json:"removed"`
+	FreedBytes int64    `json:"freed_bytes"`
+}
+
+// ProjectDiskUsage reports stored data for one workspace.
+type ProjectDiskUsage struct {
+	ProjectID string `json:"project_id"`
+	Workspace string `json:"workspace,omitempty"`
+	Sessions  int    `json:"sessions"`
+	Bytes     int64  `json:"bytes"`
+}
+
+// workspaceKey records the workspace path so disk usage can be attributed to it;
+// project IDs are hashes and cannot be reversed.
+const workspaceKey = "workspace"
+
+// projectDir returns the on-disk directory holding this project's data.
+func (p *Project) projectDir() string {
+	return filepath.Join(p.store.rootDir, "projects", p.projectID)
+}
+
+// conversationBytes returns the on-disk size of a conversation and its metadata.
+func (p *Project) conversationBytes(id string) int64 {
+	var n int64
+	for _, key := range []string{"conversations/" + id, "conversations_meta/" + id} {
+		if st, err := os.Stat(filepath.Join(p.projectDir(), key+".json")); err == nil {
+			n += st.Size()
+		}
+	}
+	return n
+}
+
+// ApplyRetention deletes conversations that exceed the policy, oldest first.
+// The current conversation and conversations open in a view are never removed.
+func (p *Project) ApplyRetention(policy RetentionPolicy) (CleanupReport, error) {
+	report := CleanupReport{ProjectID: p.projectID}
+	summaries, err := p.ListConversationSummaries()
+	if err != nil {
+		return report, err
+	}
+	currentID := p.CurrentConversationID()
+	keep := func(id string) bool { return id == currentID || p.views.has(id) }
+
+	// Summaries are newest first; walk them and decide what to keep
+	var kept []ConversationSummary
+	remove := func(s ConversationSummary) {
+		report.FreedBytes += p.conversationBytes(s.ID)
+		_ = p.DeleteConversation(s.ID)
+		report.Removed = append(report.Removed, s.ID)
+	}
+	now := time.Now()
+	for _, s := range summaries {
+		if keep(s.ID) {
+			kept = append(kept, s)
+			continue
+		}
+		if policy.MaxAge > 0 && !s.UpdatedAt.IsZero() && now.Sub(s.UpdatedAt) > policy.MaxAge {
+			remove(s)
+			continue
+		}
+		if policy.MaxSessions > 0 && len(kept) >= policy.MaxSessions {
+			remove(s)
+			continue
+		}
+		kept = append(kept, s)
+	}
+
+	if policy.MaxDiskBytes > 0 {
+		used := dirSize(p.projectDir())
+		for i := len(kept) - 1; i >= 0 && used > policy.MaxDiskBytes; i-- {
+			if keep(kept[i].ID) {
+				continue
+			}
+			size := p.conversationBytes(kept[i].ID)
+			remove(kept[i])
+			used -= size
+		}
+	}
+	sort.Strings(report.Removed)
+	return report, nil
+}
+
+// DiskUsage reports the stored bytes and session count for every project in the store.
+func (s *Store) DiskUsage() ([]ProjectDiskUsage, error) {
+	entries, err := os.ReadDir(filepath.Join(s.rootDir, "projects"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var out []ProjectDiskUsage
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		p := &Project{store: s, projectID: e.Name()}
+		usage := ProjectDiskUsage{ProjectID: e.Name(), Bytes: dirSize(p.projectDir())}
+		_ = p.Get(workspaceKey, &usage.Workspace)
+		if convs, err := os.ReadDir(filepath.Join(p.projectDir(), "conversations")); err == nil {
+			for _, c := range convs {
+				if !c.IsDir() && c.Name() != "current_id.json" {
+					usage.Sessions++
+				}
+			}
+		}
+		out = append(out, usage)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Bytes > out[j].Bytes })
+	return out, nil
+}
+
+// ApplyRetentionAll runs the policy over every project in the store. Projects that are
+// open should be passed as live, so their open views are left alone.
+func (s *Store) ApplyRetentionAll(policy RetentionPolicy, live ...*Project) ([]CleanupReport, error) {
+	usage, err := s.DiskUsage()
+	if err != nil {
+		return nil, err
+	}
+	open := make(map[string]*Project, len(live))
+	for _, p := range live {
+		if p != nil && p.store == s {
+			open[p.projectID] = p
+		}
+	}
+	var reports []CleanupReport
+	for _, u := range usage {
+		p := open[u.ProjectID]
+		if p == nil {
+			p = &Project{store: s, projectID: u.ProjectID}
+		}
+		r, err := p.ApplyRetention(policy)
+		if err != nil {
+			continue
+		}
+		if len(r.Removed) > 0 {
+			reports = append(reports, r)
+		}
+	}
+	return reports, nil
+}
+
+// dirSize sums the sizes of all regular files under dir.
+func dirSize(dir string) int64 {
+	var total int64
+	_ = filepath.WalkDir(dir, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return nil
+		}
+		if info, err := d.Info(); err == nil {
+			total += info.Size()
+		}
+		return nil
+	})
+	return total
+}
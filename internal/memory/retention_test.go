@@ -0,0 +1,85 @@
+package memory
+
+import (
+	"testing"
+	"time"
+)
+
+func TestApplyRetention_KeepsCurrentAndNewest(t *testing.T) {
+	store, err := NewStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("store: %v", err)
+	}
+	proj, err := NewProject(store, t.TempDir())
+	if err != nil {
+		t.Fatalf("project: %v", err)
+	}
+	now := time.Now()
+	convs := map[string]time.Time{
+		"old":     now.Add(-400 * 24 * time.Hour),
+		"current": now.Add(-300 * 24 * time.Hour),
+		"a":       now.Add(-3 * time.Hour),
+		"b":       now.Add(-2 * time.Hour),
+		"c":       now.Add(-1 * time.Hour),
+	}
+	for id, ts := range convs {
+		if err := proj.Set("conversations/"+id, []Message{{Role: "user", Content: id, Timestamp: ts}}); err != nil {
+			t.Fatalf("set: %v", err)
+		}
+	}
+	_ = proj.SetCurrentConversationID("current")
+
+	report, err := proj.ApplyRetention(RetentionPolicy{MaxSessions: 2, MaxAge: 365 * 24 * time.Hour})
+	if err != nil {
+		t.Fatalf("retention: %v", err)
+	}
+	if len(report.Removed) != 2 || report.Removed[0] != "a" || report.Removed[1] != "old" {
+		t.Fatalf("unexpected removals: %v", report.Removed)
+	}
+	if report.FreedBytes <= 0 {
+		t.Fatalf("expected freed bytes to be reported")
+	}
+	summaries, _ := proj.ListConversationSummaries()
+	if len(summaries) != 3 {
+		t.Fatalf("expected current + 2 newest to remain, got %+v", summaries)
+	}
+
+	usage, err := store.DiskUsage()
+	if err != nil || len(usage) != 1 {
+		t.Fatalf("disk usage: %v %+v", err, usage)
+	}
+	if usage[0].Sessions != 3 || usage[0].Workspace == "" || usage[0].Bytes == 0 {
+		t.Fatalf("unexpected usage: %+v", usage[0])
+	}
+}
+
+func TestApplyRetention_KeepsConversationsOpenInViews(t *testing.T) {
+	store, err := NewStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("store: %v", err)
+	}
+	proj, err := NewProject(store, t.TempDir())
+	if err != nil {
+		t.Fatalf("project: %v", err)
+	}
+	old := time.Now().Add(-400 * 24 * time.Hour)
+	for _, id := range []string{"current", "tab", "stale"} {
+		if err := proj.Set("conversations/"+id, []Message{{Role: "user", Content: id, Timestamp: old}}); err != nil {
+			t.Fatalf("set: %v", err)
+		}
+	}
+	_ = proj.SetCurrentConversationID("current")
+	view := proj.ForConversation("tab")
+	defer view.Close()
+
+	reports, err := store.ApplyRetentionAll(RetentionPolicy{MaxAge: 24 * time.Hour}, proj)
+	if err != nil {
+		t.Fatalf("retention: %v", err)
+	}
+	if len(reports) != 1 || len(reports[0].Removed) != 1 || reports[0].Removed[0] != "stale" {
+		t.Fatalf("expected only the stale conversation to be removed, got %+v", reports)
+	}
+	if !proj.Has("conversations/tab") {
+		t.Fatal("conversation open in a view was removed")
+	}
+}
@@ -0,0 +1,164 @@
+package memory
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Memory scopes. Global memories are user-wide and live outside the project store
+// (~/.loom/memories.json); project and directory memories are stored per workspace.
+const (
+	ScopeGlobal    = "global"
+	ScopeProject   = "project"
+	ScopeDirectory = "directory"
+)
+
+// ScopedMemory is a project-local memory. Directory memories carry a
+// workspace-relative Dir and only apply when work touches files below it.
+type ScopedMemory struct {
+	ID        string    `json:"id"`
+	Text      string    `json:"text"`
+	Scope     string    `json:"scope"`
+	Dir       string    `json:"dir,omitempty"`
+	Tags      []string  `json:"tags,omitempty"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+const scopedMemoriesKey = "memories/scoped"
+
+// NormalizeMemoryDir cleans a workspace-relative directory for use as a memory scope.
+// It returns "" for the workspace root.
+func NormalizeMemoryDir(dir string) string {
+	d := strings.TrimSpace(strings.ReplaceAll(dir, "\\", "/"))
+	d = path.Clean("/" + d)
+	d = strings.Trim(d, "/")
+	if d == "." {
+		return ""
+	}
+	return d
+}
+
+// ListScopedMemories returns all project and directory memories for the workspace.
+func (p *Project) ListScopedMemories() []ScopedMemory {
+	var items []ScopedMemory
+	if p == nil {
+		return items
+	}
+	// Tools write scoped memories through their own Store, so never trust the cache here
+	p.store.Invalidate(fmt.Sprintf("projects/%s/%s", p.projectID, scopedMemoriesKey))
+	if p.Has(scopedMemoriesKey) {
+		_ = p.Get(scopedMemoriesKey, &items)
+	}
+	return items
+}
+
+// SaveScopedMemory adds or replaces a project or directory memory by id.
+func (p *Project) SaveScopedMemory(m ScopedMemory) (ScopedMemory, error) {
+	if p == nil {
+		return m, errors.New("project memory not initialized")
+	}
+	if strings.TrimSpace(m.Text) == "" {
+		return m, errors.New("memory text is required")
+	}
+	switch m.Scope {
+	case ScopeProject:
+		m.Dir = ""
+	case ScopeDirectory:
+		m.Dir = NormalizeMemoryDir(m.Dir)
+		if m.Dir == "" {
+			return m, errors.New("directory memories require a workspace-relative path")
+		}
+	default:
+		return m, fmt.Errorf("unsupported scope for project memory: %q", m.Scope)
+	}
+	if strings.TrimSpace(m.ID) == "" {
+		m.ID = newScopedMemoryID()
+	}
+	m.Tags = NormalizeTags(m.Tags)
+	m.UpdatedAt = time.Now()
+
+	items := p.ListScopedMemories()
+	replaced := false
+	for i := range items {
+		if items[i].ID == m.ID {
+			items[i] = m
+			replaced = true
+			break
+		}
+	}
+	if !replaced {
+		items = append(items, m)
+	}
+	return m, p.Set(scopedMemoriesKey, items)
+}
+
+// newScopedMemoryID returns a time-ordered id with a random suffix, so memories saved
+// within the same instant never replace each other.
+func newScopedMemoryID() string {
+	var b [4]byte
+	_, _ = rand.Read(b[:])
+	return time.Now().Format("20060102-150405.000000000") + "-" + hex.EncodeToString(b[:])
+}
+
+// DeleteScopedMemory removes a project or directory memory by id.
+func (p *Project) DeleteScopedMemory(id string) error {
+	items := p.ListScopedMemories()
+	out := make([]ScopedMemory, 0, len(items))
+	for _, it := range items {
+		if it.ID != id {
+			out = append(out, it)
+		}
+	}
+	if len(out) == len(items) {
+		return fmt.Errorf("memory with id %q not found", id)
+	}
+	return p.Set(scopedMemoriesKey, out)
+}
+
+// ProjectMemories returns memories that apply to the whole workspace.
+func (p *Project) ProjectMemories() []ScopedMemory {
+	var out []ScopedMemory
+	for _, m := range p.ListScopedMemories() {
+		if m.Scope == ScopeProject {
+			out = append(out, m)
+		}
+	}
+	return out
+}
+
+// DirectoryMemoriesFor returns directory memories whose directory contains at least one
+// of the given workspace-relative paths. Results are ordered from the most general
+// directory to the most specific so nested conventions read top-down.
+func (p *Project) DirectoryMemoriesFor(paths []string) []ScopedMemory {
+	if len(paths) == 0 {
+		return nil
+	}
+	normalized := make([]string, 0, len(paths))
+	for _, fp := range paths {
+		if n := NormalizeMemoryDir(fp); n != "" {
+			normalized = append(normalized, n)
+		}
+	}
+	var out []ScopedMemory
+	for _, m := range p.ListScopedMemories() {
+		if m.Scope != ScopeDirectory || m.Dir == "" {
+			continue
+		}
+		for _, fp := range normalized {
+			if fp == m.Dir || strings.HasPrefix(fp, m.Dir+"/") {
+				out = append(out, m)
+				break
+			}
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		return strings.Count(out[i].Dir, "/") < strings.Count(out[j].Dir, "/")
+	})
+	return out
+}
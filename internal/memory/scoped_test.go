@@ -0,0 +1,65 @@
+package memory
+
+import "testing"
+
+func TestDirectoryMemoriesFor_MatchesNestedPaths(t *testing.T) {
+	store, err := NewStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("store: %v", err)
+	}
+	proj, err := NewProject(store, t.TempDir())
+	if err != nil {
+		t.Fatalf("project: %v", err)
+	}
+	for _, m := range []ScopedMemory{
+		{ID: "pay", Text: "Use cents", Scope: ScopeDirectory, Dir: "services/payments/"},
+		{ID: "svc", Text: "Services log via zap", Scope: ScopeDirectory, Dir: "./services"},
+		{ID: "all", Text: "Go 1.23", Scope: ScopeProject},
+	} {
+		if _, err := proj.SaveScopedMemory(m); err != nil {
+			t.Fatalf("save %s: %v", m.ID, err)
+		}
+	}
+
+	got := proj.DirectoryMemoriesFor([]string{"services/payments/charge.go"})
+	if len(got) != 2 || got[0].ID != "svc" || got[1].ID != "pay" {
+		t.Fatalf("unexpected matches: %+v", got)
+	}
+	if got := proj.DirectoryMemoriesFor([]string{"services/paymentsx/a.go"}); len(got) != 1 || got[0].ID != "svc" {
+		t.Fatalf("prefix must match whole path segments: %+v", got)
+	}
+	if got := proj.DirectoryMemoriesFor([]string{"web/index.ts"}); len(got) != 0 {
+		t.Fatalf("expected no matches, got %+v", got)
+	}
+	if pm := proj.ProjectMemories(); len(pm) != 1 || pm[0].ID != "all" {
+		t.Fatalf("unexpected project memories: %+v", pm)
+	}
+	if _, err := proj.SaveScopedMemory(ScopedMemory{Text: "x", Scope: ScopeDirectory, Dir: "."}); err == nil {
+		t.Fatalf("expected error for directory memory at workspace root")
+	}
+}
+
+func TestSaveScopedMemory_BackToBackGetDistinctIDs(t *testing.T) {
+	store, err := NewStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("store: %v", err)
+	}
+	proj, err := NewProject(store, t.TempDir())
+	if err != nil {
+		t.Fatalf("project: %v", err)
+	}
+	first, err := proj.SaveScopedMemory(ScopedMemory{Text: "Use cents", Scope: ScopeProject})
+	if err != nil {
+		t.Fatalf("save first: %v", err)
+	}
+	second, err := proj.SaveScopedMemory(ScopedMemory{Text: "Go 1.23", Scope: ScopeProject})
+	if err != nil {
+		t.Fatalf("save second: %v", err)
+	}
+	if first.ID == second.ID {
+		t.Fatalf("memories saved back to back share id %q", first.ID)
+	}
+	if got := proj.ProjectMemories(); len(got) != 2 {
+		t.Fatalf("expected both memories to be kept, got %+v", got)
+	}
+}
@@ -0,0 +1,228 @@
+package memory
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// SharedMemoriesFile is where a team commits the project memories it shares, relative
+// to the workspace root.
+const SharedMemoriesFile = ".loom/memories.md"
+
+// sharedMemoriesKey maps memory ids to the hash of the version last synced with the
+// shared file, so a sync only applies what changed in the file since then.
+const sharedMemoriesKey = "memories/shared"
+
+// MemoryImport counts what an import did to the local memories.
+type MemoryImport struct {
+	Added     int `json:"added"`
+	Updated   int `json:"updated"`
+	Unchanged int `json:"unchanged"`
+	// Kept are memories changed or deleted locally whose shared version did not change
+	Kept int `json:"kept"`
+}
+
+// FormatMemoriesMarkdown renders project and directory memories as a human-editable
+// markdown file: one "## <id>" section per memory, with its scope, directory and
+// tags in a frontmatter block followed by the text.
+func FormatMemoriesMarkdown(mems []ScopedMemory) string {
+	sorted := append([]ScopedMemory(nil), mems...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].Scope != sorted[j].Scope {
+			return sorted[i].Scope == ScopeProject
+		}
+		if sorted[i].Dir != sorted[j].Dir {
+			return sorted[i].Dir < sorted[j].Dir
+		}
+		return sorted[i].ID < sorted[j].ID
+	})
+	var b strings.Builder
+	b.WriteString("# Project memories\n\n")
+	b.WriteString("<!-- Shared Loom memories, synced into each member's memories when the workspace opens.\n")
+	b.WriteString("     One section per memory: \"## <id>\", a frontmatter block (scope: project or directory,\n")
+	b.WriteString("     dir for directory memories, tags) and the memory text. -->\n")
+	for _, m := range sorted {
+		fmt.Fprintf(&b, "\n## %s\n\n---\nscope: %s\n", m.ID, m.Scope)
+		if m.Dir != "" {
+			fmt.Fprintf(&b, "dir: %s\n", m.Dir)
+		}
+		if len(m.Tags) > 0 {
+			fmt.Fprintf(&b, "tags: %s\n", strings.Join(m.Tags, ", "))
+		}
+		b.WriteString("---\n\n")
+		for _, line := range strings.Split(strings.TrimSpace(m.Text), "\n") {
+			// Keep lines of the text from starting a new section
+			if strings.HasPrefix(line, "#") {
+				line = `\` + line
+			}
+			b.WriteString(line + "\n")
+		}
+	}
+	return b.String()
+}
+
+// ParseMemoriesMarkdown reads memories in the format of FormatMemoriesMarkdown. Text
+// before the first section is ignored; a section without a frontmatter block is a
+// project memory.
+func ParseMemoriesMarkdown(content string) ([]ScopedMemory, error) {
+	var out []ScopedMemory
+	seen := map[string]bool{}
+	var cur *ScopedMemory
+	var text []string
+	inFront, frontDone := false, false
+	flush := func() error {
+		if cur == nil {
+			return nil
+		}
+		cur.Text = strings.TrimSpace(strings.Join(text, "\n"))
+		if cur.Text == "" {
+			return fmt.Errorf("memory %q has no text", cur.ID)
+		}
+		if cur.Scope == "" {
+			cur.Scope = ScopeProject
+			if cur.Dir != "" {
+				cur.Scope = ScopeDirectory
+			}
+		}
+		switch cur.Scope {
+		case ScopeProject:
+			cur.Dir = ""
+		case ScopeDirectory:
+			if cur.Dir = NormalizeMemoryDir(cur.Dir); cur.Dir == "" {
+				return fmt.Errorf("directory memory %q needs a dir", cur.ID)
+			}
+		default:
+			return fmt.Errorf("memory %q: unknown scope %q (use project or directory)", cur.ID, cur.Scope)
+		}
+		cur.Tags = NormalizeTags(cur.Tags)
+		out = append(out, *cur)
+		return nil
+	}
+	for n, line := range strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n") {
+		trimmed := strings.TrimSpace(line)
+		if id, ok := strings.CutPrefix(line, "## "); ok {
+			if err := flush(); err != nil {
+				return nil, err
+			}
+			id = strings.TrimSpace(id)
+			if id == "" || seen[id] {
+				return nil, fmt.Errorf("line %d: missing or duplicate memory id %q", n+1, id)
+			}
+			seen[id] = true
+			cur, text, inFront, frontDone = &ScopedMemory{ID: id}, nil, false, false
+			continue
+		}
+		if cur == nil {
+			continue
+		}
+		switch {
+		case inFront && trimmed == "---":
+			inFront, frontDone = false, true
+		case inFront:
+			key, value, ok := strings.Cut(trimmed, ":")
+			if !ok {
+				return nil, fmt.Errorf("line %d: expected \"key: value\" in the frontmatter of %q", n+1, cur.ID)
+			}
+			value = strings.TrimSpace(value)
+			switch strings.ToLower(strings.TrimSpace(key)) {
+			case "scope":
+				cur.Scope = strings.ToLower(value)
+			case "dir":
+				cur.Dir = value
+			case "tags":
+				cur.Tags = strings.Split(strings.Trim(value, "[]"), ",")
+			}
+		case trimmed == "---" && !frontDone && strings.TrimSpace(strings.Join(text, "")) == "":
+			inFront = true
+		default:
+			if strings.HasPrefix(line, `\#`) {
+				line = line[1:]
+			}
+			text = append(text, line)
+		}
+	}
+	if cur != nil && inFront {
+		return nil, fmt.Errorf("memory %q: unterminated frontmatter", cur.ID)
+	}
+	if err := flush(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ExportSharedMemories renders the project's memories for the shared file and marks
+// them as synced.
+func (p *Project) ExportSharedMemories() (string, int, error) {
+	mems := p.ListScopedMemories()
+	hashes := make(map[string]string, len(mems))
+	for _, m := range mems {
+		hashes[m.ID] = sharedHash(m)
+	}
+	if err := p.Set(sharedMemoriesKey, hashes); err != nil {
+		return "", 0, err
+	}
+	return FormatMemoriesMarkdown(mems), len(mems), nil
+}
+
+// ImportSharedMemories merges memories from the shared file into the project's
+// memories. With force, every memory in the file replaces the local one. Otherwise
+// only memories that changed in the file since the last sync are applied, so local
+// edits and deletions of a shared memory survive until the file changes it again.
+// Local memories missing from the file are never removed.
+func (p *Project) ImportSharedMemories(content string, force bool) (MemoryImport, error) {
+	var res MemoryImport
+	if p == nil {
+		return res, fmt.Errorf("project memory not initialized")
+	}
+	incoming, err := ParseMemoriesMarkdown(content)
+	if err != nil {
+		return res, err
+	}
+	synced := map[string]string{}
+	if p.Has(sharedMemoriesKey) {
+		_ = p.Get(sharedMemoriesKey, &synced)
+	}
+	items := p.ListScopedMemories()
+	index := make(map[string]int, len(items))
+	for i, m := range items {
+		index[m.ID] = i
+	}
+	now := time.Now()
+	for _, m := range incoming {
+		hash := sharedHash(m)
+		fileChanged := synced[m.ID] != hash
+		synced[m.ID] = hash
+		i, exists := index[m.ID]
+		switch {
+		case exists && sharedHash(items[i]) == hash:
+			res.Unchanged++
+		case !force && !fileChanged:
+			res.Kept++
+		case exists:
+			m.UpdatedAt = now
+			items[i] = m
+			res.Updated++
+		default:
+			m.UpdatedAt = now
+			index[m.ID] = len(items)
+			items = append(items, m)
+			res.Added++
+		}
+	}
+	if res.Added+res.Updated > 0 {
+		if err := p.Set(scopedMemoriesKey, items); err != nil {
+			return res, err
+		}
+	}
+	return res, p.Set(sharedMemoriesKey, synced)
+}
+
+// sharedHash identifies the content of a memory as it appears in the shared file.
+func sharedHash(m ScopedMemory) string {
+	sum := sha256.Sum256([]byte(strings.Join([]string{m.Scope, m.Dir, strings.Join(NormalizeTags(m.Tags), ","), strings.TrimSpace(m.Text)}, "\x00")))
+	return hex.EncodeToString(sum[:8])
+}
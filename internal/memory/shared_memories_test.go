@@ -0,0 +1,82 @@
+package memory
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMemoriesMarkdown_RoundTrip(t *testing.T) {
+	mems := []ScopedMemory{
+		{ID: "cents", Text: "Amounts are int64 cents.\n# never floats", Scope: ScopeDirectory, Dir: "services/payments", Tags: []string{"money"}},
+		{ID: "go", Text: "Target Go 1.23.", Scope: ScopeProject, Tags: []string{"go", "build"}},
+	}
+	md := FormatMemoriesMarkdown(mems)
+	if !strings.Contains(md, "## go\n\n---\nscope: project\ntags: go, build\n---\n\nTarget Go 1.23.\n") {
+		t.Fatalf("unexpected markdown:\n%s", md)
+	}
+	got, err := ParseMemoriesMarkdown(md)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[0].ID != "go" || got[1].Text != mems[0].Text || got[1].Dir != "services/payments" || strings.Join(got[0].Tags, ",") != "go,build" {
+		t.Fatalf("round trip changed the memories: %+v", got)
+	}
+
+	// Hand-written sections may skip the frontmatter
+	got, err = ParseMemoriesMarkdown("# Notes\n\nintro\n\n## style\nUse tabs.\n")
+	if err != nil || len(got) != 1 || got[0].Scope != ScopeProject || got[0].Text != "Use tabs." {
+		t.Fatalf("unexpected parse: %+v, %v", got, err)
+	}
+	for _, bad := range []string{"## a\n", "## a\nx\n## a\ny\n", "## a\n---\nscope: global\n---\nx\n", "## a\n---\nscope: directory\n---\nx\n"} {
+		if _, err := ParseMemoriesMarkdown(bad); err == nil {
+			t.Errorf("expected an error for %q", bad)
+		}
+	}
+}
+
+func TestImportSharedMemories(t *testing.T) {
+	store, err := NewStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("store: %v", err)
+	}
+	proj, err := NewProject(store, t.TempDir())
+	if err != nil {
+		t.Fatalf("project: %v", err)
+	}
+	if _, err := proj.SaveScopedMemory(ScopedMemory{ID: "local", Text: "Only mine", Scope: ScopeProject}); err != nil {
+		t.Fatal(err)
+	}
+	shared := "## go\nTarget Go 1.23.\n\n## tabs\nUse tabs.\n"
+	res, err := proj.ImportSharedMemories(shared, false)
+	if err != nil || res.Added != 2 {
+		t.Fatalf("unexpected first sync: %+v, %v", res, err)
+	}
+
+	// A local edit survives syncs until the file changes that memory
+	if _, err := proj.SaveScopedMemory(ScopedMemory{ID: "tabs", Text: "Use spaces.", Scope: ScopeProject}); err != nil {
+		t.Fatal(err)
+	}
+	if res, _ := proj.ImportSharedMemories(shared, false); res.Kept != 1 || res.Unchanged != 1 {
+		t.Fatalf("expected the local edit to be kept: %+v", res)
+	}
+	if res, _ := proj.ImportSharedMemories(strings.Replace(shared, "Use tabs.", "Use tabs, width 4.", 1), false); res.Updated != 1 {
+		t.Fatalf("expected the changed shared memory to be applied: %+v", res)
+	}
+	// A forced import replaces local versions
+	_, _ = proj.SaveScopedMemory(ScopedMemory{ID: "go", Text: "Target Go 1.22.", Scope: ScopeProject})
+	if res, _ := proj.ImportSharedMemories(shared, true); res.Updated != 2 {
+		t.Fatalf("expected a forced import to overwrite: %+v", res)
+	}
+
+	md, n, err := proj.ExportSharedMemories()
+	if err != nil || n != 3 || !strings.Contains(md, "## local") {
+		t.Fatalf("unexpected export (%d): %v\n%s", n, err, md)
+	}
+	texts := map[string]string{}
+	for _, m := range proj.ListScopedMemories() {
+		texts[m.ID] = m.Text
+	}
+	if texts["local"] != "Only mine" || texts["tabs"] != "Use tabs." || texts["go"] != "Target Go 1.23." {
+		t.Fatalf("unexpected memories: %v", texts)
+	}
+}
@@ -0,0 +1,83 @@
+package memory
+
+import (
+	"errors"
+	"sort"
+	"time"
+)
+
+// FileSnapshot records a workspace file as the agent last saw it in a conversation,
+// so a resumed conversation can detect files that changed on disk in the meantime.
+type FileSnapshot struct {
+	Path   string    `json:"path"`
+	SHA256 string    `json:"sha256"`
+	Lines  int       `json:"lines"`
+	At     time.Time `json:"at"`
+	// Content is kept for small files only, to describe what changed later
+	Content string `json:"content,omitempty"`
+}
+
+const (
+	fileSnapshotsPrefix = "file_snapshots/"
+	// MaxSnapshotContent is the largest file whose content is kept in a snapshot
+	MaxSnapshotContent = 32 << 10
+	// maxFileSnapshots bounds the files tracked per conversation
+	maxFileSnapshots = 200
+)
+
+// FileSnapshots returns the tracked files of a conversation keyed by path.
+func (p *Project) FileSnapshots(conversationID string) map[string]FileSnapshot {
+	items := map[string]FileSnapshot{}
+	if p == nil || conversationID == "" {
+		return items
+	}
+	if p.Has(fileSnapshotsPrefix + conversationID) {
+		_ = p.Get(fileSnapshotsPrefix+conversationID, &items)
+	}
+	return items
+}
+
+// RecordFileSnapshots stores snapshots for a conversation, replacing earlier ones for
+// the same paths. The least recently seen files are dropped beyond the limit.
+func (p *Project) RecordFileSnapshots(conversationID string, snaps ...FileSnapshot) error {
+	if p == nil {
+		return errors.New("project memory not initialized")
+	}
+	if conversationID == "" || len(snaps) == 0 {
+		return nil
+	}
+	items := p.FileSnapshots(conversationID)
+	for _, s := range snaps {
+		if s.Path == "" {
+			continue
+		}
+		if s.At.IsZero() {
+			s.At = time.Now()
+		}
+		if len(s.Content) > MaxSnapshotContent {
+			s.Content = ""
+		}
+		items[s.Path] = s
+	}
+	if len(items) > maxFileSnapshots {
+		byAge := make([]FileSnapshot, 0, len(items))
+		for _, s := range items {
+			byAge = append(byAge, s)
+		}
+		sort.Slice(byAge, func(i, j int) bool { return byAge[i].At.Before(byAge[j].At) })
+		for _, s := range byAge[:len(items)-maxFileSnapshots] {
+			delete(items, s.Path)
+		}
+	}
+	return p.Set(fileSnapshotsPrefix+conversationID, items)
+}
+
+// ForgetFileSnapshot stops tracking a path, e.g. after the file was deleted.
+func (p *Project) ForgetFileSnapshot(conversationID, path string) error {
+	items := p.FileSnapshots(conversationID)
+	if _, ok := items[path]; !ok {
+		return nil
+	}
+	delete(items, path)
+	return p.Set(fileSnapshotsPrefix+conversationID, items)
+}
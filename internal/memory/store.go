@@ -14,6 +14,8 @@ type Store struct {
 	rootDir string
 	mu      sync.RWMutex
 	cache   map[string]interface{}
+	// sealers encrypts keys below a project prefix ("projects/<id>/")
+	sealers map[string]*Sealer
 }
 
 // NewStore creates a new Store.
@@ -35,6 +37,7 @@ func NewStore(rootDir string) (*Store, error) {
 	return &Store{
 		rootDir: rootDir,
 		cache:   make(map[string]interface{}),
+		sealers: make(map[string]*Sealer),
 	}, nil
 }
 
@@ -63,6 +66,9 @@ func (s *Store) Get(key string, valuePtr interface{}) error {
 		}
 		return fmt.Errorf("failed to read file: %w", err)
 	}
+	if data, err = s.openData(key, data); err != nil {
+		return fmt.Errorf("%s: %w", key, err)
+	}
 
 	// Parse the JSON data
 	if err := json.Unmarshal(data, valuePtr); err != nil {
@@ -102,7 +108,7 @@ func (s *Store) Set(key string, value interface{}) error {
 	if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
 		return fmt.Errorf("failed to create parent directory: %w", err)
 	}
-	if err := os.WriteFile(filePath, data, 0644); err != nil {
+	if err := WriteSealed(s.sealerFor(key), filePath, data); err != nil {
 		return fmt.Errorf("failed to write file: %w", err)
 	}
 
@@ -130,6 +136,14 @@ func (s *Store) Delete(key string) error {
 	return nil
 }
 
+// Invalidate drops a cached value so the next Get re-reads it from disk.
+// Use it for keys that other Store instances (e.g. tools) may write.
+func (s *Store) Invalidate(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.cache, key)
+}
+
 // Has checks if a key exists.
 func (s *Store) Has(key string) bool {
 	s.mu.RLock()
@@ -0,0 +1,72 @@
+package memory
+
+import (
+	"errors"
+	"time"
+)
+
+// Checkpoint is one file change in a conversation's timeline. Steps are numbered from
+// 1; "checkpoint N" is the workspace after step N, and checkpoint 0 is the workspace
+// before the conversation changed anything.
+type Checkpoint struct {
+	Step   int    `json:"step"`
+	CallID string `json:"call_id,omitempty"`
+	Path   string `json:"path"`
+	Before string `json:"before,omitempty"`
+	After  string `json:"after,omitempty"`
+	// Created and Deleted mark a file that did not exist before or after the step
+	Created bool `json:"created,omitempty"`
+	Deleted bool `json:"deleted,omitempty"`
+	// Omitted is set when the file exceeded MaxCheckpointContent; only hashes are kept
+	Omitted      bool      `json:"omitted,omitempty"`
+	BeforeSHA256 string    `json:"before_sha256"`
+	AfterSHA256  string    `json:"after_sha256"`
+	Source       string    `json:"source"`
+	At           time.Time `json:"at"`
+}
+
+const (
+	timelinePrefix = "timeline/"
+	// MaxCheckpointContent is the largest file whose contents are kept in a checkpoint
+	MaxCheckpointContent = 256 << 10
+	// maxCheckpoints bounds the steps kept per conversation; the oldest are dropped
+	maxCheckpoints = 500
+)
+
+// Timeline returns a conversation's checkpoints, oldest first.
+func (p *Project) Timeline(conversationID string) []Checkpoint {
+	var items []Checkpoint
+	if p == nil || conversationID == "" || !p.Has(timelinePrefix+conversationID) {
+		return items
+	}
+	_ = p.Get(timelinePrefix+conversationID, &items)
+	return items
+}
+
+// RecordCheckpoint appends a step to a conversation's timeline and returns it with its
+// step number. Contents above MaxCheckpointContent are dropped and the step is marked
+// Omitted.
+func (p *Project) RecordCheckpoint(conversationID string, cp Checkpoint) (Checkpoint, error) {
+	if p == nil {
+		return cp, errors.New("project memory not initialized")
+	}
+	if conversationID == "" {
+		return cp, errors.New("no active conversation")
+	}
+	items := p.Timeline(conversationID)
+	cp.Step = 1
+	if len(items) > 0 {
+		cp.Step = items[len(items)-1].Step + 1
+	}
+	if cp.At.IsZero() {
+		cp.At = time.Now()
+	}
+	if len(cp.Before) > MaxCheckpointContent || len(cp.After) > MaxCheckpointContent {
+		cp.Before, cp.After, cp.Omitted = "", "", true
+	}
+	items = append(items, cp)
+	if len(items) > maxCheckpoints {
+		items = items[len(items)-maxCheckpoints:]
+	}
+	return cp, p.Set(timelinePrefix+conversationID, items)
+}
@@ -0,0 +1,360 @@
+// Package overlay implements what-if mode: a copy-on-write copy of the workspace that
+// a conversation edits, builds and tests instead of the real files. The overlay's
+// changes can later be materialized into the workspace or discarded as a whole.
+package overlay
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	manifestFile = "overlay.json"
+	treeDir      = "tree"
+)
+
+// skipDirs are not copied into the overlay. Git metadata stays with the real
+// workspace; build output is regenerated inside the overlay.
+var skipDirs = map[string]bool{
+	".git": true, ".hg": true, ".svn": true,
+	"dist": true, "build": true, "target": true, ".next": true, ".cache": true,
+}
+
+// skipPaths are workspace-relative directories that are neither copied nor reported
+// as changes, such as the backups written next to every edit.
+var skipPaths = map[string]bool{".loom/backups": true}
+
+// linkDirs are installed dependencies. They are symlinked instead of copied so builds
+// and tests work without duplicating them; changes made inside them are shared with
+// the real workspace and never reported as overlay changes.
+var linkDirs = map[string]bool{
+	"node_modules": true, "vendor": true, ".venv": true, "venv": true, "Pods": true,
+}
+
+// ErrNotFound is returned by Load when the conversation has no overlay.
+var ErrNotFound = errors.New("no what-if overlay for this conversation")
+
+// Status describes how an overlay file differs from the workspace it was copied from.
+type Status string
+
+const (
+	Added    Status = "added"
+	Modified Status = "modified"
+	Deleted  Status = "deleted"
+)
+
+// Change is one file that differs between the overlay and its base.
+type Change struct {
+	// Path is relative to the workspace, with forward slashes
+	Path   string `json:"path"`
+	Status Status `json:"status"`
+	// Conflict is set when the real workspace file changed since the overlay was created
+	Conflict bool `json:"conflict,omitempty"`
+}
+
+// MaterializeResult reports which changes were written to the workspace.
+type MaterializeResult struct {
+	Applied []Change `json:"applied"`
+	// Conflicts were left in the overlay because the workspace file changed meanwhile
+	Conflicts []Change `json:"conflicts"`
+}
+
+// Overlay is a conversation's private copy of a workspace.
+type Overlay struct {
+	Workspace      string    `json:"workspace"`
+	ConversationID string    `json:"conversation_id"`
+	CreatedAt      time.Time `json:"created_at"`
+	// Base maps each copied file to its content hash at copy time
+	Base map[string]string `json:"base"`
+	// Linked lists the dependency directories symlinked into the overlay
+	Linked []string `json:"linked,omitempty"`
+
+	dir string
+}
+
+// Create copies workspace into dir and returns the new overlay. An existing overlay in
+// dir is replaced.
+func Create(workspace, dir, conversationID string) (*Overlay, error) {
+	if workspace == "" {
+		return nil, errors.New("no workspace")
+	}
+	if err := os.RemoveAll(dir); err != nil {
+		return nil, err
+	}
+	o := &Overlay{
+		Workspace:      workspace,
+		ConversationID: conversationID,
+		CreatedAt:      time.Now(),
+		Base:           map[string]string{},
+		dir:            dir,
+	}
+	root := o.Root()
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		return nil, err
+	}
+	err := filepath.WalkDir(workspace, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		rel, _ := filepath.Rel(workspace, path)
+		if rel == "." {
+			return nil
+		}
+		target := filepath.Join(root, rel)
+		if d.IsDir() {
+			switch {
+			case skipDirs[d.Name()] || skipPaths[filepath.ToSlash(rel)] || path == dir:
+				return filepath.SkipDir
+			case linkDirs[d.Name()]:
+				o.Linked = append(o.Linked, filepath.ToSlash(rel))
+				if err := os.Symlink(path, target); err != nil {
+					return err
+				}
+				return filepath.SkipDir
+			}
+			return os.MkdirAll(target, 0o755)
+		}
+		sum, err := copyEntry(path, target)
+		if err != nil {
+			return fmt.Errorf("copy %s: %w", rel, err)
+		}
+		o.Base[filepath.ToSlash(rel)] = sum
+		return nil
+	})
+	if err != nil {
+		_ = os.RemoveAll(dir)
+		return nil, err
+	}
+	if err := o.save(); err != nil {
+		_ = os.RemoveAll(dir)
+		return nil, err
+	}
+	return o, nil
+}
+
+// Load opens the overlay stored in dir.
+func Load(dir string) (*Overlay, error) {
+	data, err := os.ReadFile(filepath.Join(dir, manifestFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	var o Overlay
+	if err := json.Unmarshal(data, &o); err != nil {
+		return nil, err
+	}
+	if o.Base == nil {
+		o.Base = map[string]string{}
+	}
+	o.dir = dir
+	return &o, nil
+}
+
+// Root returns the directory holding the overlay's copy of the workspace.
+func (o *Overlay) Root() string {
+	return filepath.Join(o.dir, treeDir)
+}
+
+// Changes lists the files added, modified or deleted in the overlay, sorted by path.
+func (o *Overlay) Changes() ([]Change, error) {
+	root := o.Root()
+	seen := map[string]bool{}
+	var changes []Change
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		rel, _ := filepath.Rel(root, path)
+		if rel == "." {
+			return nil
+		}
+		if d.IsDir() {
+			if skipDirs[d.Name()] || skipPaths[filepath.ToSlash(rel)] {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		key := filepath.ToSlash(rel)
+		if o.linked(key) {
+			return nil
+		}
+		seen[key] = true
+		sum, err := hashEntry(path)
+		if err != nil {
+			return nil
+		}
+		base, ok := o.Base[key]
+		switch {
+		case !ok:
+			changes = append(changes, Change{Path: key, Status: Added, Conflict: o.workspaceHash(key) != ""})
+		case base != sum:
+			changes = append(changes, Change{Path: key, Status: Modified, Conflict: o.workspaceHash(key) != base})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	for key, base := range o.Base {
+		if !seen[key] {
+			changes = append(changes, Change{Path: key, Status: Deleted, Conflict: o.workspaceHash(key) != base})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+	return changes, nil
+}
+
+// Materialize writes the overlay's changes to the workspace. When paths is empty all
+// changes are applied. Conflicting changes are skipped and reported. Applied files
+// become part of the overlay's base, so the overlay stays usable afterwards.
+func (o *Overlay) Materialize(paths []string) (MaterializeResult, error) {
+	res := MaterializeResult{Applied: []Change{}, Conflicts: []Change{}}
+	changes, err := o.Changes()
+	if err != nil {
+		return res, err
+	}
+	want := map[string]bool{}
+	for _, p := range paths {
+		want[filepath.ToSlash(filepath.Clean(p))] = true
+	}
+	for _, c := range changes {
+		if len(want) > 0 && !want[c.Path] {
+			continue
+		}
+		if c.Conflict {
+			res.Conflicts = append(res.Conflicts, c)
+			continue
+		}
+		real := filepath.Join(o.Workspace, filepath.FromSlash(c.Path))
+		if c.Status == Deleted {
+			if err := os.Remove(real); err != nil && !errors.Is(err, os.ErrNotExist) {
+				return res, err
+			}
+			delete(o.Base, c.Path)
+		} else {
+			if err := os.MkdirAll(filepath.Dir(real), 0o755); err != nil {
+				return res, err
+			}
+			sum, err := copyEntry(filepath.Join(o.Root(), filepath.FromSlash(c.Path)), real)
+			if err != nil {
+				return res, err
+			}
+			o.Base[c.Path] = sum
+		}
+		res.Applied = append(res.Applied, c)
+	}
+	return res, o.save()
+}
+
+// Discard removes the overlay and everything in it. The workspace is not touched.
+func (o *Overlay) Discard() error {
+	return os.RemoveAll(o.dir)
+}
+
+func (o *Overlay) save() error {
+	data, err := json.Marshal(o)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(o.dir, manifestFile), data, 0o600)
+}
+
+func (o *Overlay) linked(rel string) bool {
+	for _, l := range o.Linked {
+		if rel == l || strings.HasPrefix(rel, l+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// workspaceHash returns the current hash of a workspace file, or "" if it is missing.
+func (o *Overlay) workspaceHash(rel string) string {
+	sum, err := hashEntry(filepath.Join(o.Workspace, filepath.FromSlash(rel)))
+	if err != nil {
+		return ""
+	}
+	return sum
+}
+
+// copyEntry copies a regular file or symlink, preserving its mode, and returns the
+// source's hash.
+func copyEntry(src, dst string) (string, error) {
+	info, err := os.Lstat(src)
+	if err != nil {
+		return "", err
+	}
+	if info.Mode()&os.ModeSymlink != 0 {
+		link, err := os.Readlink(src)
+		if err != nil {
+			return "", err
+		}
+		_ = os.Remove(dst)
+		if err := os.Symlink(link, dst); err != nil {
+			return "", err
+		}
+		return hashLink(link), nil
+	}
+	if !info.Mode().IsRegular() {
+		return "", fmt.Errorf("unsupported file type %s", info.Mode().Type())
+	}
+	in, err := os.Open(src)
+	if err != nil {
+		return "", err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, info.Mode().Perm())
+	if err != nil {
+		return "", err
+	}
+	h := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(out, h), in); err != nil {
+		out.Close()
+		return "", err
+	}
+	if err := out.Close(); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func hashEntry(path string) (string, error) {
+	info, err := os.Lstat(path)
+	if err != nil {
+		return "", err
+	}
+	if info.Mode()&os.ModeSymlink != 0 {
+		link, err := os.Readlink(path)
+		if err != nil {
+			return "", err
+		}
+		return hashLink(link), nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func hashLink(target string) string {
+	sum := sha256.Sum256([]byte("link:" + target))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,168 @@
+package overlay
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func newWorkspace(t *testing.T) (string, string) {
+	t.Helper()
+	ws := t.TempDir()
+	writeFile(t, filepath.Join(ws, "main.go"), "package main\n")
+	writeFile(t, filepath.Join(ws, "pkg", "util.go"), "package pkg\n")
+	writeFile(t, filepath.Join(ws, "old.txt"), "old\n")
+	writeFile(t, filepath.Join(ws, ".git", "HEAD"), "ref: refs/heads/main\n")
+	writeFile(t, filepath.Join(ws, "node_modules", "dep", "index.js"), "module.exports = 1\n")
+	return ws, filepath.Join(t.TempDir(), "overlay")
+}
+
+func TestCreate_CopiesWorkspace(t *testing.T) {
+	ws, dir := newWorkspace(t)
+	o, err := Create(ws, dir, "conv1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := readFile(t, filepath.Join(o.Root(), "pkg", "util.go")); got != "package pkg\n" {
+		t.Errorf("unexpected copy: %q", got)
+	}
+	if _, err := os.Stat(filepath.Join(o.Root(), ".git")); !os.IsNotExist(err) {
+		t.Errorf(".git should not be copied: %v", err)
+	}
+	if info, err := os.Lstat(filepath.Join(o.Root(), "node_modules")); err != nil || info.Mode()&os.ModeSymlink == 0 {
+		t.Errorf("node_modules should be symlinked: %v", err)
+	}
+	changes, err := o.Changes()
+	if err != nil || len(changes) != 0 {
+		t.Fatalf("fresh overlay should have no changes: %v %+v", err, changes)
+	}
+
+	loaded, err := Load(dir)
+	if err != nil || loaded.Root() != o.Root() || loaded.ConversationID != "conv1" || len(loaded.Base) != 3 {
+		t.Fatalf("unexpected loaded overlay: %v %+v", err, loaded)
+	}
+	if _, err := Load(filepath.Join(t.TempDir(), "missing")); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestChangesAndMaterialize(t *testing.T) {
+	ws, dir := newWorkspace(t)
+	o, err := Create(ws, dir, "conv1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, filepath.Join(o.Root(), "main.go"), "package main\n\nfunc main() {}\n")
+	writeFile(t, filepath.Join(o.Root(), "pkg", "new.go"), "package pkg\n\nvar X = 1\n")
+	if err := os.Remove(filepath.Join(o.Root(), "old.txt")); err != nil {
+		t.Fatal(err)
+	}
+	// Dependencies and build output never count as changes
+	writeFile(t, filepath.Join(o.Root(), "build", "out.bin"), "bin")
+
+	changes, err := o.Changes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Change{{"main.go", Modified, false}, {"old.txt", Deleted, false}, {"pkg/new.go", Added, false}}
+	if len(changes) != len(want) {
+		t.Fatalf("unexpected changes: %+v", changes)
+	}
+	for i := range want {
+		if changes[i] != want[i] {
+			t.Errorf("change %d: got %+v, want %+v", i, changes[i], want[i])
+		}
+	}
+	if got := readFile(t, filepath.Join(ws, "main.go")); got != "package main\n" {
+		t.Fatalf("workspace must be untouched before materializing, got %q", got)
+	}
+
+	res, err := o.Materialize([]string{"main.go"})
+	if err != nil || len(res.Applied) != 1 || len(res.Conflicts) != 0 {
+		t.Fatalf("unexpected result: %v %+v", err, res)
+	}
+	if got := readFile(t, filepath.Join(ws, "main.go")); got != "package main\n\nfunc main() {}\n" {
+		t.Errorf("main.go not applied: %q", got)
+	}
+	if changes, _ := o.Changes(); len(changes) != 2 {
+		t.Errorf("applied change should leave the list: %+v", changes)
+	}
+
+	res, err = o.Materialize(nil)
+	if err != nil || len(res.Applied) != 2 {
+		t.Fatalf("unexpected result: %v %+v", err, res)
+	}
+	if _, err := os.Stat(filepath.Join(ws, "old.txt")); !os.IsNotExist(err) {
+		t.Errorf("old.txt should be deleted: %v", err)
+	}
+	if got := readFile(t, filepath.Join(ws, "pkg", "new.go")); got != "package pkg\n\nvar X = 1\n" {
+		t.Errorf("new.go not applied: %q", got)
+	}
+}
+
+func TestMaterialize_SkipsConflicts(t *testing.T) {
+	ws, dir := newWorkspace(t)
+	o, err := Create(ws, dir, "conv1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, filepath.Join(o.Root(), "main.go"), "package main // overlay\n")
+	writeFile(t, filepath.Join(o.Root(), "pkg", "util.go"), "package pkg // overlay\n")
+	// The user edits main.go in the real workspace meanwhile
+	writeFile(t, filepath.Join(ws, "main.go"), "package main // user\n")
+
+	res, err := o.Materialize(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Applied) != 1 || res.Applied[0].Path != "pkg/util.go" {
+		t.Errorf("unexpected applied: %+v", res.Applied)
+	}
+	if len(res.Conflicts) != 1 || res.Conflicts[0].Path != "main.go" || !res.Conflicts[0].Conflict {
+		t.Errorf("unexpected conflicts: %+v", res.Conflicts)
+	}
+	if got := readFile(t, filepath.Join(ws, "main.go")); got != "package main // user\n" {
+		t.Errorf("conflicting file must not be overwritten: %q", got)
+	}
+}
+
+func TestDiscard(t *testing.T) {
+	ws, dir := newWorkspace(t)
+	o, err := Create(ws, dir, "conv1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, filepath.Join(o.Root(), "main.go"), "changed\n")
+	if err := o.Discard(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Errorf("overlay dir should be removed: %v", err)
+	}
+	if got := readFile(t, filepath.Join(ws, "main.go")); got != "package main\n" {
+		t.Errorf("workspace must be untouched: %q", got)
+	}
+	if _, err := os.Stat(filepath.Join(ws, "node_modules", "dep", "index.js")); err != nil {
+		t.Errorf("linked dependencies must survive discard: %v", err)
+	}
+}
@@ -0,0 +1,315 @@
+package policy
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+type tokKind int
+
+const (
+	tokEOF tokKind = iota
+	tokIdent
+	tokString
+	tokOp
+	tokLParen
+	tokRParen
+	tokDot
+	tokComma
+)
+
+type token struct {
+	kind tokKind
+	text string
+}
+
+// lex splits a condition into tokens. Identifiers may contain dots (args.path) except
+// where the dot starts a method call, which the parser resolves.
+func lex(src string) ([]token, error) {
+	var toks []token
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == ' ' || c == '\t':
+			i++
+		case c == '"' || c == '\'':
+			j := i + 1
+			var b strings.Builder
+			for j < len(src) && src[j] != c {
+				if src[j] == '\\' && j+1 < len(src) {
+					j++
+				}
+				b.WriteByte(src[j])
+				j++
+			}
+			if j >= len(src) {
+				return nil, fmt.Errorf("unterminated string at %d", i)
+			}
+			toks = append(toks, token{tokString, b.String()})
+			i = j + 1
+		case c == '(':
+			toks = append(toks, token{tokLParen, "("})
+			i++
+		case c == ')':
+			toks = append(toks, token{tokRParen, ")"})
+			i++
+		case c == ',':
+			toks = append(toks, token{tokComma, ","})
+			i++
+		case c == '.':
+			toks = append(toks, token{tokDot, "."})
+			i++
+		case strings.HasPrefix(src[i:], "&&"), strings.HasPrefix(src[i:], "||"),
+			strings.HasPrefix(src[i:], "=="), strings.HasPrefix(src[i:], "!="):
+			toks = append(toks, token{tokOp, src[i : i+2]})
+			i += 2
+		case c == '!':
+			toks = append(toks, token{tokOp, "!"})
+			i++
+		case isIdentChar(c):
+			j := i
+			for j < len(src) && isIdentChar(src[j]) {
+				j++
+			}
+			toks = append(toks, token{tokIdent, src[i:j]})
+			i = j
+		default:
+			return nil, fmt.Errorf("unexpected character %q at %d", c, i)
+		}
+	}
+	return append(toks, token{kind: tokEOF}), nil
+}
+
+func isIdentChar(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
+
+// value is the result of evaluating a node: either a string or a boolean.
+type value struct {
+	s      string
+	b      bool
+	isBool bool
+}
+
+func (v value) truthy() bool {
+	if v.isBool {
+		return v.b
+	}
+	return v.s != ""
+}
+
+type node interface {
+	eval(c Call) value
+}
+
+type (
+	litNode   struct{ s string }
+	fieldNode struct{ name string }
+	notNode   struct{ x node }
+	binNode   struct {
+		op   string
+		l, r node
+	}
+	callNode struct {
+		recv   node
+		method string
+		arg    node
+	}
+)
+
+func (n litNode) eval(Call) value     { return value{s: n.s} }
+func (n fieldNode) eval(c Call) value { return value{s: c.field(n.name)} }
+func (n notNode) eval(c Call) value   { return value{b: !n.x.eval(c).truthy(), isBool: true} }
+
+func (n binNode) eval(c Call) value {
+	switch n.op {
+	case "&&":
+		return value{b: n.l.eval(c).truthy() && n.r.eval(c).truthy(), isBool: true}
+	case "||":
+		return value{b: n.l.eval(c).truthy() || n.r.eval(c).truthy(), isBool: true}
+	case "==":
+		return value{b: n.l.eval(c).s == n.r.eval(c).s, isBool: true}
+	default: // "!="
+		return value{b: n.l.eval(c).s != n.r.eval(c).s, isBool: true}
+	}
+}
+
+func (n callNode) eval(c Call) value {
+	recv, arg := n.recv.eval(c).s, n.arg.eval(c).s
+	var ok bool
+	switch n.method {
+	case "matches":
+		ok = GlobMatch(arg, recv)
+	case "startsWith":
+		ok = strings.HasPrefix(recv, arg)
+	case "endsWith":
+		ok = strings.HasSuffix(recv, arg)
+	case "contains":
+		ok = strings.Contains(recv, arg)
+	}
+	return value{b: ok, isBool: true}
+}
+
+var methods = map[string]bool{"matches": true, "startsWith": true, "endsWith": true, "contains": true}
+
+// parser is a recursive-descent parser: or → and → unary → postfix → primary.
+type parser struct {
+	toks []token
+	pos  int
+}
+
+func (p *parser) peek() token { return p.toks[p.pos] }
+func (p *parser) next() token {
+	t := p.toks[p.pos]
+	if t.kind != tokEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) parseOr() (node, error) {
+	l, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == tokOp && p.peek().text == "||" {
+		p.next()
+		r, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		l = binNode{"||", l, r}
+	}
+	return l, nil
+}
+
+func (p *parser) parseAnd() (node, error) {
+	l, err := p.parseCompare()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == tokOp && p.peek().text == "&&" {
+		p.next()
+		r, err := p.parseCompare()
+		if err != nil {
+			return nil, err
+		}
+		l = binNode{"&&", l, r}
+	}
+	return l, nil
+}
+
+func (p *parser) parseCompare() (node, error) {
+	l, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind == tokOp && (t.text == "==" || t.text == "!=") {
+		p.next()
+		r, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		l = binNode{t.text, l, r}
+	}
+	return l, nil
+}
+
+func (p *parser) parseUnary() (node, error) {
+	if t := p.peek(); t.kind == tokOp && t.text == "!" {
+		p.next()
+		x, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return notNode{x}, nil
+	}
+	return p.parsePostfix()
+}
+
+// parsePostfix reads a primary followed by dotted field segments or a method call.
+func (p *parser) parsePostfix() (node, error) {
+	t := p.next()
+	switch t.kind {
+	case tokString:
+		return p.parseMethod(litNode{t.text})
+	case tokLParen:
+		x, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.next().kind != tokRParen {
+			return nil, fmt.Errorf("missing ')'")
+		}
+		return x, nil
+	case tokIdent:
+		name := t.text
+		for p.peek().kind == tokDot && p.toks[p.pos+1].kind == tokIdent && !methods[p.toks[p.pos+1].text] {
+			p.next()
+			name += "." + p.next().text
+		}
+		if name != "tool" && name != "path" && name != "command" && !strings.HasPrefix(name, "args.") {
+			return nil, fmt.Errorf("unknown field %q", name)
+		}
+		return p.parseMethod(fieldNode{name})
+	}
+	if t.kind == tokEOF {
+		return nil, fmt.Errorf("unexpected end of expression")
+	}
+	return nil, fmt.Errorf("unexpected %q", t.text)
+}
+
+func (p *parser) parseMethod(recv node) (node, error) {
+	if p.peek().kind != tokDot {
+		return recv, nil
+	}
+	p.next()
+	m := p.next()
+	if m.kind != tokIdent || !methods[m.text] {
+		return nil, fmt.Errorf("unknown method %q", m.text)
+	}
+	if p.next().kind != tokLParen {
+		return nil, fmt.Errorf("expected '(' after %s", m.text)
+	}
+	arg, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.next().kind != tokRParen {
+		return nil, fmt.Errorf("expected ')' after %s argument", m.text)
+	}
+	return callNode{recv: recv, method: m.text, arg: arg}, nil
+}
+
+// GlobMatch matches a slash-separated path against a glob where "**" spans directories.
+func GlobMatch(pattern, name string) bool {
+	name = path.Clean(strings.TrimPrefix(strings.ReplaceAll(name, "\\", "/"), "./"))
+	if !strings.Contains(pattern, "**") {
+		ok, _ := path.Match(pattern, name)
+		return ok
+	}
+	var b strings.Builder
+	b.WriteString("^")
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		switch {
+		case strings.HasPrefix(pattern[i:], "**/"):
+			b.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(pattern[i:], "**"):
+			b.WriteString(".*")
+			i++
+		case c == '*':
+			b.WriteString("[^/]*")
+		case c == '?':
+			b.WriteString("[^/]")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	b.WriteString("$")
+	re, err := regexp.Compile(b.String())
+	return err == nil && re.MatchString(name)
+}
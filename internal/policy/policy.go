@@ -0,0 +1,155 @@
+// Package policy implements the approval policy language used to auto-approve or deny
+// tool calls without prompting. A rule has the form
+//
+//	<condition> -> <action>
+//
+// where action is one of auto_approve, deny, or ask, and the condition is a boolean
+// expression over the call, e.g.
+//
+//	tool == "edit_file" && path.matches("**/*_test.go") -> auto_approve
+//	tool == "apply_shell" && command.startsWith("rm ") -> deny
+//
+// Available fields are tool, path, command, and args.<name> for any top-level argument.
+// Strings support ==, !=, and the methods matches(glob), startsWith, endsWith, and
+// contains. Conditions combine with &&, ||, !, and parentheses.
+package policy
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Action is the outcome of a matching rule.
+type Action string
+
+const (
+	ActionAutoApprove Action = "auto_approve"
+	ActionDeny        Action = "deny"
+	ActionAsk         Action = "ask"
+)
+
+// Call is the data a rule is evaluated against.
+type Call struct {
+	Tool string
+	Args map[string]interface{}
+}
+
+// NewCall builds a Call from a tool name and its raw JSON arguments.
+func NewCall(tool string, raw json.RawMessage) Call {
+	c := Call{Tool: tool, Args: map[string]interface{}{}}
+	if len(raw) > 0 {
+		_ = json.Unmarshal(raw, &c.Args)
+	}
+	return c
+}
+
+// field resolves an identifier such as tool, path, or args.timeout to a string.
+func (c Call) field(name string) string {
+	switch name {
+	case "tool":
+		return c.Tool
+	case "path", "command":
+		return stringify(c.Args[name])
+	}
+	if strings.HasPrefix(name, "args.") {
+		return stringify(c.Args[strings.TrimPrefix(name, "args.")])
+	}
+	return ""
+}
+
+func stringify(v interface{}) string {
+	switch t := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return t
+	default:
+		b, _ := json.Marshal(t)
+		return string(b)
+	}
+}
+
+// Rule is a single compiled policy line.
+type Rule struct {
+	Source string
+	Action Action
+	cond   node
+}
+
+// Matches reports whether the rule's condition holds for the call.
+func (r *Rule) Matches(c Call) bool {
+	return r.cond.eval(c).truthy()
+}
+
+// Set is an ordered list of rules; the first matching rule decides.
+type Set struct {
+	rules []*Rule
+}
+
+// Compile parses every non-empty, non-comment line into a rule. Lines starting with
+// "#" are comments. The first syntax error aborts compilation.
+func Compile(lines []string) (*Set, error) {
+	s := &Set{}
+	for i, line := range lines {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		r, err := ParseRule(line)
+		if err != nil {
+			return nil, fmt.Errorf("policy %d: %w", i+1, err)
+		}
+		s.rules = append(s.rules, r)
+	}
+	return s, nil
+}
+
+// Decide returns the action of the first matching rule and the rule itself.
+// ok is false when no rule matches, in which case the caller's defaults apply.
+func (s *Set) Decide(c Call) (action Action, rule *Rule, ok bool) {
+	if s == nil {
+		return "", nil, false
+	}
+	for _, r := range s.rules {
+		if r.Matches(c) {
+			return r.Action, r, true
+		}
+	}
+	return "", nil, false
+}
+
+// Len returns the number of compiled rules.
+func (s *Set) Len() int {
+	if s == nil {
+		return 0
+	}
+	return len(s.rules)
+}
+
+// ParseRule compiles a single "<condition> -> <action>" line.
+func ParseRule(src string) (*Rule, error) {
+	idx := strings.LastIndex(src, "->")
+	if idx < 0 {
+		return nil, fmt.Errorf("missing '-> action' in %q", src)
+	}
+	action := Action(strings.TrimSpace(src[idx+2:]))
+	switch action {
+	case ActionAutoApprove, ActionDeny, ActionAsk:
+	default:
+		return nil, fmt.Errorf("unknown action %q (want auto_approve, deny, or ask)", action)
+	}
+	toks, err := lex(src[:idx])
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{toks: toks}
+	cond, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.peek().kind != tokEOF {
+		return nil, fmt.Errorf("unexpected %q", p.peek().text)
+	}
+	return &Rule{Source: src, Action: action, cond: cond}, nil
+}
@@ -0,0 +1,58 @@
+package policy
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestSet_Decide(t *testing.T) {
+	set, err := Compile([]string{
+		"# tests are cheap to change",
+		`tool == "edit_file" && path.matches("**/*_test.go") -> auto_approve`,
+		`tool == "apply_shell" && (command.startsWith("rm ") || command.contains("sudo")) -> deny`,
+		`tool == "apply_shell" && args.cwd == "scripts" -> auto_approve`,
+		`!(tool == "http_request") && tool.endsWith("_shell") -> ask`,
+	})
+	if err != nil {
+		t.Fatalf("compile: %v", err)
+	}
+	if set.Len() != 4 {
+		t.Fatalf("expected 4 rules, got %d", set.Len())
+	}
+
+	cases := []struct {
+		tool string
+		args string
+		want Action
+		ok   bool
+	}{
+		{"edit_file", `{"path":"internal/a/a_test.go"}`, ActionAutoApprove, true},
+		{"edit_file", `{"path":"a_test.go"}`, ActionAutoApprove, true},
+		{"edit_file", `{"path":"internal/a/a.go"}`, "", false},
+		{"apply_shell", `{"command":"rm -rf build"}`, ActionDeny, true},
+		{"apply_shell", `{"command":"sudo make"}`, ActionDeny, true},
+		{"apply_shell", `{"command":"make","cwd":"scripts"}`, ActionAutoApprove, true},
+		{"run_shell", `{"command":"ls"}`, ActionAsk, true},
+	}
+	for _, tc := range cases {
+		got, _, ok := set.Decide(NewCall(tc.tool, json.RawMessage(tc.args)))
+		if got != tc.want || ok != tc.ok {
+			t.Errorf("%s %s: got %q/%v, want %q/%v", tc.tool, tc.args, got, ok, tc.want, tc.ok)
+		}
+	}
+}
+
+func TestParseRule_Errors(t *testing.T) {
+	for _, src := range []string{
+		`tool == "edit_file"`,
+		`tool == "edit_file" -> approve`,
+		`tool == "edit_file -> deny`,
+		`owner == "me" -> deny`,
+		`path.glob("*.go") -> deny`,
+		`(tool == "x" -> deny`,
+	} {
+		if _, err := ParseRule(src); err == nil {
+			t.Errorf("expected error for %q", src)
+		}
+	}
+}
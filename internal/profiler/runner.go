@@ -18,6 +18,7 @@ import (
 	"github.com/loom/loom/internal/profiler/shared"
 	"github.com/loom/loom/internal/profiler/signals"
 	"github.com/loom/loom/internal/profiler/write"
+	"github.com/loom/loom/internal/toolchain"
 )
 
 // Runner orchestrates the entire profiling pipeline
@@ -229,6 +230,7 @@ func (r *Runner) assembleProfile(signals *SignalData, importantFiles []Important
 		Configs:        signals.Configs,
 		Codegen:        signals.Codegen,
 		RoutesServices: signals.RoutesServices,
+		DocSnippets:    signals.DocSnippets,
 		ImportantFiles: importantFiles,
 		Heuristics:     weights,
 		GitStats:       gitStatsMode,
@@ -236,6 +238,7 @@ func (r *Runner) assembleProfile(signals *SignalData, importantFiles []Important
 		InputSignature: inputSignature,
 		Metrics:        metrics,
 		ManualBoosts:   manualBoosts,
+		Toolchains:     toolchain.Detect(r.root).Toolchains,
 		Version:        "2", // Increment version due to schema changes
 	}
 }
@@ -339,6 +342,12 @@ func (r *Runner) calculateInputSignature() shared.InputSignature {
 		"Dockerfile",
 		"docker-compose.yml",
 		".gitignore",
+		// Toolchain files
+		".nvmrc",
+		".node-version",
+		".python-version",
+		"rust-toolchain",
+		"rust-toolchain.toml",
 	}
 
 	var maxMtime int64
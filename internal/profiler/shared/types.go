@@ -3,6 +3,8 @@ package shared
 import (
 	"path/filepath"
 	"strings"
+
+	"github.com/loom/loom/internal/toolchain"
 )
 
 // Graph represents the import/dependency graph
@@ -136,6 +138,7 @@ type SignalData struct {
 	Configs        []ConfigFile
 	Codegen        []CodegenSpec
 	RoutesServices []RouteOrService
+	DocSnippets    []DocSnippet
 }
 
 type EntryPoint struct {
@@ -172,6 +175,17 @@ type RouteOrService struct {
 	Name string `json:"name"`
 }
 
+// DocSnippet is a fenced code block taken from the README or docs, classified as
+// "build" (install/build/test commands), "config" (configuration files) or "example"
+type DocSnippet struct {
+	Kind    string `json:"kind"`
+	Lang    string `json:"lang,omitempty"`
+	Path    string `json:"path"`
+	Line    int    `json:"line"`
+	Section string `json:"section,omitempty"`
+	Content string `json:"content"`
+}
+
 // InputSignature tracks hashes of key files for drift detection
 type InputSignature struct {
 	ManifestHashes map[string]string `json:"manifest_hashes"`
@@ -197,21 +211,23 @@ type ProfilerMetrics struct {
 
 // Profile represents the complete analysis of a workspace
 type Profile struct {
-	WorkspaceRoot  string             `json:"workspace_root"`
-	CreatedAtUnix  int64              `json:"created_at_unix"`
-	Languages      []string           `json:"languages"`
-	Entrypoints    []EntryPoint       `json:"entrypoints"`
-	Scripts        []Script           `json:"scripts"`
-	CI             []CIConfig         `json:"ci"`
-	Configs        []ConfigFile       `json:"configs"`
-	Codegen        []CodegenSpec      `json:"codegen"`
-	RoutesServices []RouteOrService   `json:"routes_services"`
-	ImportantFiles []ImportantFile    `json:"important_files"`
-	Heuristics     HeuristicWeights   `json:"heuristics"`
-	GitStats       GitStatsMode       `json:"gitstats"`
-	GitWindowDays  int                `json:"git_window_days"` // deprecated, use GitStats.WindowDays
-	InputSignature InputSignature     `json:"input_signature"`
-	Metrics        ProfilerMetrics    `json:"metrics"`
-	ManualBoosts   map[string]float64 `json:"manual_boosts,omitempty"` // path -> boost value
-	Version        string             `json:"version"`
+	WorkspaceRoot  string                `json:"workspace_root"`
+	CreatedAtUnix  int64                 `json:"created_at_unix"`
+	Languages      []string              `json:"languages"`
+	Entrypoints    []EntryPoint          `json:"entrypoints"`
+	Scripts        []Script              `json:"scripts"`
+	CI             []CIConfig            `json:"ci"`
+	Configs        []ConfigFile          `json:"configs"`
+	Codegen        []CodegenSpec         `json:"codegen"`
+	RoutesServices []RouteOrService      `json:"routes_services"`
+	DocSnippets    []DocSnippet          `json:"doc_snippets,omitempty"`
+	ImportantFiles []ImportantFile       `json:"important_files"`
+	Heuristics     HeuristicWeights      `json:"heuristics"`
+	GitStats       GitStatsMode          `json:"gitstats"`
+	GitWindowDays  int                   `json:"git_window_days"` // deprecated, use GitStats.WindowDays
+	InputSignature InputSignature        `json:"input_signature"`
+	Metrics        ProfilerMetrics       `json:"metrics"`
+	ManualBoosts   map[string]float64    `json:"manual_boosts,omitempty"` // path -> boost value
+	Toolchains     []toolchain.Toolchain `json:"toolchains,omitempty"`
+	Version        string                `json:"version"`
 }
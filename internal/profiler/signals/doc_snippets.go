@@ -0,0 +1,147 @@
+package signals
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/loom/loom/internal/profiler/shared"
+	"github.com/loom/loom/internal/textutil"
+)
+
+const (
+	// maxDocSnippets caps how many snippets are kept across all documentation files
+	maxDocSnippets = 200
+	// maxSnippetBytes caps the stored content of one snippet
+	maxSnippetBytes = 4000
+	// maxSnippetSourceBytes skips documentation files too large to be hand-written docs
+	maxSnippetSourceBytes = 1 << 20
+)
+
+var (
+	// buildSection matches headings under which shell blocks are build instructions
+	buildSection = regexp.MustCompile(`(?i)install|build|setup|set up|getting started|quick ?start|compil|test|develop|prerequisite|requirement|deploy|running`)
+	// configSection matches headings under which untyped blocks are configuration
+	configSection = regexp.MustCompile(`(?i)config|setting|environment|options`)
+	// buildCommand matches shell commands that build, install or test the project
+	buildCommand = regexp.MustCompile(`(?m)^\s*(?:\$\s*)?(?:make\b|go (?:build|install|test|generate)\b|(?:npm|pnpm|yarn) (?:install|ci|run build|run test|test|build)\b|cargo (?:build|test|install)\b|pip3? install\b|composer install\b|docker(?:-compose| compose)? (?:build|up)\b|wails (?:build|dev)\b|mvn |gradle )`)
+	// commandStart recognizes an untyped block that is really a shell session
+	commandStart = regexp.MustCompile(`^\s*(?:\$ |(?:go|npm|npx|pnpm|yarn|make|docker|pip3?|cargo|git|curl|brew|composer|php|python3?|node|wails|kubectl|terraform|cd) )`)
+)
+
+var (
+	configLangs = map[string]bool{
+		"yaml": true, "yml": true, "json": true, "jsonc": true, "json5": true, "toml": true,
+		"ini": true, "env": true, "dotenv": true, "properties": true, "xml": true, "hcl": true, "conf": true,
+	}
+	shellLangs = map[string]bool{
+		"sh": true, "bash": true, "shell": true, "console": true, "zsh": true, "fish": true,
+		"powershell": true, "ps1": true, "cmd": true, "bat": true, "terminal": true, "shell-session": true,
+	}
+	// skippedLangs hold diagrams and program output rather than anything runnable
+	skippedLangs = map[string]bool{"text": true, "txt": true, "plain": true, "plaintext": true, "mermaid": true, "output": true, "diff": true, "ascii": true}
+)
+
+// isSnippetSource reports whether a documentation file is a Markdown file worth
+// mining for snippets. Changelogs and vendored docs are skipped.
+func isSnippetSource(file *shared.FileInfo) bool {
+	if file.IsVendored || file.IsGenerated || file.Size > maxSnippetSourceBytes {
+		return false
+	}
+	switch strings.ToLower(file.Extension) {
+	case ".md", ".markdown", ".mdx":
+	default:
+		return false
+	}
+	return !strings.HasPrefix(strings.ToLower(filepath.Base(file.Path)), "changelog")
+}
+
+// extractSnippets returns the classified fenced code blocks of a Markdown file,
+// each tagged with the heading it appears under.
+func (d *DocsExtractor) extractSnippets(path string) []shared.DocSnippet {
+	file, err := os.Open(filepath.Join(d.root, path))
+	if err != nil {
+		return nil
+	}
+	defer func() { _ = file.Close() }()
+
+	var (
+		snippets []shared.DocSnippet
+		section  string
+		fence    string // opening fence while inside a block
+		lang     string
+		start    int
+		body     []string
+	)
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxSnippetSourceBytes)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+		if fence != "" {
+			if strings.HasPrefix(trimmed, fence) && strings.Trim(trimmed, fence[:1]) == "" {
+				if s, ok := classifySnippet(lang, section, strings.Join(body, "\n")); ok {
+					s.Path, s.Line = shared.NormalizePath(path), start
+					snippets = append(snippets, s)
+				}
+				fence, body = "", nil
+				continue
+			}
+			body = append(body, line)
+			continue
+		}
+		if f := openingFence(trimmed); f != "" {
+			fence, start = f, lineNo
+			lang = strings.ToLower(strings.TrimSpace(strings.TrimLeft(trimmed, f[:1])))
+			if i := strings.IndexAny(lang, " {,"); i >= 0 {
+				lang = lang[:i]
+			}
+			continue
+		}
+		if strings.HasPrefix(trimmed, "#") {
+			if heading := strings.TrimSpace(strings.TrimLeft(trimmed, "#")); heading != "" {
+				section = heading
+			}
+		}
+	}
+	return snippets
+}
+
+// openingFence returns the fence (``` or ~~~, possibly longer) that opens a code block
+// on line, or "" if line does not open one.
+func openingFence(line string) string {
+	for _, ch := range []string{"`", "~"} {
+		n := len(line) - len(strings.TrimLeft(line, ch))
+		if n >= 3 {
+			return strings.Repeat(ch, n)
+		}
+	}
+	return ""
+}
+
+// classifySnippet decides the kind of a code block from its language, the heading it is
+// under and its content. ok is false for blocks that are empty or not worth keeping.
+func classifySnippet(lang, section, content string) (shared.DocSnippet, bool) {
+	content = strings.Trim(content, "\n")
+	if strings.TrimSpace(content) == "" || skippedLangs[lang] {
+		return shared.DocSnippet{}, false
+	}
+	if len(content) > maxSnippetBytes {
+		content = textutil.ClipBytes(content, maxSnippetBytes) + "\n... [truncated]"
+	}
+	s := shared.DocSnippet{Kind: "example", Lang: lang, Section: section, Content: content}
+	shell := shellLangs[lang] || (lang == "" && commandStart.MatchString(content))
+	switch {
+	case configLangs[lang]:
+		s.Kind = "config"
+	case shell && (buildSection.MatchString(section) || buildCommand.MatchString(content)):
+		s.Kind = "build"
+	case lang == "" && !shell && configSection.MatchString(section):
+		s.Kind = "config"
+	}
+	return s, true
+}
@@ -0,0 +1,58 @@
+package signals
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/loom/loom/internal/profiler/shared"
+)
+
+const snippetReadme = "# Demo\n\n" +
+	"## Installation\n\n" +
+	"```bash\ngo install ./cmd/demo\n```\n\n" +
+	"## Configuration\n\n" +
+	"```yaml\nport: 8080\n```\n\n" +
+	"```\nDEMO_TOKEN=secret\n```\n\n" +
+	"## Usage\n\n" +
+	"```go\nclient := demo.New()\nclient.Run()\n```\n\n" +
+	"````markdown\n```\nnested\n```\n````\n\n" +
+	"```mermaid\ngraph TD; A-->B\n```\n\n" +
+	"```sh\ndemo serve --port 9000\n```\n"
+
+func TestDocsExtractor_Snippets(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "README.md"), []byte(snippetReadme), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "CHANGELOG.md"), []byte("```bash\nmake\n```\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	data := &shared.SignalData{}
+	NewDocsExtractor(root).Extract([]*shared.FileInfo{
+		{Path: "README.md", Extension: ".md", IsDoc: true},
+		{Path: "CHANGELOG.md", Extension: ".md", IsDoc: true},
+	}, data)
+
+	want := []struct{ kind, lang, section, content string }{
+		{"build", "bash", "Installation", "go install ./cmd/demo"},
+		{"config", "yaml", "Configuration", "port: 8080"},
+		{"config", "", "Configuration", "DEMO_TOKEN=secret"},
+		{"example", "go", "Usage", "client := demo.New()\nclient.Run()"},
+		{"example", "markdown", "Usage", "```\nnested\n```"},
+		{"example", "sh", "Usage", "demo serve --port 9000"},
+	}
+	if len(data.DocSnippets) != len(want) {
+		t.Fatalf("expected %d snippets, got %d: %+v", len(want), len(data.DocSnippets), data.DocSnippets)
+	}
+	for i, w := range want {
+		got := data.DocSnippets[i]
+		if got.Kind != w.kind || got.Lang != w.lang || got.Section != w.section || got.Content != w.content || got.Path != "README.md" {
+			t.Errorf("snippet %d = %+v, want %+v", i, got, w)
+		}
+	}
+	if data.DocSnippets[0].Line != 5 {
+		t.Errorf("expected first snippet on line 5, got %d", data.DocSnippets[0].Line)
+	}
+}
@@ -30,8 +30,14 @@ func (d *DocsExtractor) Extract(files []*shared.FileInfo, existing *shared.Signa
 		if file.IsDoc {
 			refs := d.extractDocRefs(file.Path)
 			existing.DocRefs = append(existing.DocRefs, refs...)
+			if len(existing.DocSnippets) < maxDocSnippets && isSnippetSource(file) {
+				existing.DocSnippets = append(existing.DocSnippets, d.extractSnippets(file.Path)...)
+			}
 		}
 	}
+	if len(existing.DocSnippets) > maxDocSnippets {
+		existing.DocSnippets = existing.DocSnippets[:maxDocSnippets]
+	}
 }
 
 // extractDocRefs extracts file references from a documentation file
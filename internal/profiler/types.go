@@ -14,6 +14,7 @@ type CIConfig = shared.CIConfig
 type ConfigFile = shared.ConfigFile
 type CodegenSpec = shared.CodegenSpec
 type RouteOrService = shared.RouteOrService
+type DocSnippet = shared.DocSnippet
 type Profile = shared.Profile
 
 // NewGraph creates a new empty graph
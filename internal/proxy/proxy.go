@@ -0,0 +1,201 @@
+// Package proxy routes Loom's outgoing HTTP traffic through a configured proxy and
+// trusts extra certificate authorities, for networks with TLS-intercepting proxies.
+package proxy
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"golang.org/x/net/http/httpproxy"
+)
+
+// Config is the proxy configuration. Empty fields fall back to the standard
+// environment variables (HTTP_PROXY, HTTPS_PROXY, NO_PROXY and their lowercase forms).
+type Config struct {
+	// HTTPProxy is used for http:// requests, e.g. "http://proxy.corp:3128"
+	HTTPProxy string `json:"http_proxy,omitempty"`
+	// HTTPSProxy is used for https:// requests
+	HTTPSProxy string `json:"https_proxy,omitempty"`
+	// NoProxy lists hosts, domains (".corp.example") and CIDR ranges reached directly,
+	// comma-separated; localhost is always reached directly
+	NoProxy string `json:"no_proxy,omitempty"`
+	// CABundle is a PEM file of certificate authorities trusted in addition to the
+	// system ones, e.g. the root of a TLS-intercepting proxy
+	CABundle string `json:"ca_bundle,omitempty"`
+}
+
+// Effective merges c with the environment: fields left empty take the values of the
+// proxy environment variables.
+func (c Config) Effective() Config {
+	env := httpproxy.FromEnvironment()
+	out := Config{
+		HTTPProxy:  strings.TrimSpace(c.HTTPProxy),
+		HTTPSProxy: strings.TrimSpace(c.HTTPSProxy),
+		NoProxy:    strings.TrimSpace(c.NoProxy),
+		CABundle:   strings.TrimSpace(c.CABundle),
+	}
+	if out.HTTPProxy == "" {
+		out.HTTPProxy = env.HTTPProxy
+	}
+	if out.HTTPSProxy == "" {
+		out.HTTPSProxy = env.HTTPSProxy
+	}
+	if out.NoProxy == "" {
+		out.NoProxy = env.NoProxy
+	}
+	return out
+}
+
+// Validate checks that the proxies are URLs and the CA bundle holds certificates.
+func (c Config) Validate() error {
+	for name, v := range map[string]string{"http_proxy": c.HTTPProxy, "https_proxy": c.HTTPSProxy} {
+		if err := validateProxyURL(v); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	if c.CABundle != "" {
+		if _, err := loadCABundle(c.CABundle); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Env returns environment entries that pass the explicitly configured proxy and CA
+// bundle on to child processes such as MCP servers. Values taken from Loom's own
+// environment are inherited anyway and are not repeated.
+func (c Config) Env() []string {
+	var env []string
+	add := func(v string, keys ...string) {
+		if v = strings.TrimSpace(v); v == "" {
+			return
+		}
+		for _, k := range keys {
+			env = append(env, k+"="+v)
+		}
+	}
+	add(c.HTTPProxy, "HTTP_PROXY", "http_proxy")
+	add(c.HTTPSProxy, "HTTPS_PROXY", "https_proxy")
+	add(c.NoProxy, "NO_PROXY", "no_proxy")
+	// Only Node adds this file to the system roots; REQUESTS_CA_BUNDLE and SSL_CERT_FILE
+	// would replace them, so those are left to the server's own env
+	add(c.CABundle, "NODE_EXTRA_CA_CERTS")
+	return env
+}
+
+var (
+	installOnce sync.Once
+	shared      = &switchTransport{}
+	configured  atomic.Pointer[Config]
+)
+
+// Apply makes every HTTP client that uses http.DefaultTransport (all model adapters,
+// http_request, import_url, pings and model lists) honour c. The first call installs
+// the shared transport; later calls replace its configuration for new connections.
+func Apply(c Config) error {
+	if err := c.Validate(); err != nil {
+		return err
+	}
+	installOnce.Do(func() {
+		if base, ok := http.DefaultTransport.(*http.Transport); ok {
+			shared.base = base.Clone()
+		} else {
+			shared.base = &http.Transport{}
+		}
+		shared.current.Store(shared.base)
+		http.DefaultTransport = shared
+	})
+	t, err := newTransport(shared.base, c.Effective())
+	if err != nil {
+		return err
+	}
+	if old := shared.current.Swap(t); old != nil && old != shared.base {
+		old.CloseIdleConnections()
+	}
+	configured.Store(&c)
+	return nil
+}
+
+// Current returns the configuration last passed to Apply.
+func Current() Config {
+	if c := configured.Load(); c != nil {
+		return *c
+	}
+	return Config{}
+}
+
+// newTransport clones base with the proxy and CA bundle of an effective config.
+func newTransport(base *http.Transport, c Config) (*http.Transport, error) {
+	t := base.Clone()
+	pc := httpproxy.Config{HTTPProxy: c.HTTPProxy, HTTPSProxy: c.HTTPSProxy, NoProxy: c.NoProxy}
+	proxyFunc := pc.ProxyFunc()
+	t.Proxy = func(req *http.Request) (*url.URL, error) { return proxyFunc(req.URL) }
+	if c.CABundle != "" {
+		pool, err := loadCABundle(c.CABundle)
+		if err != nil {
+			return nil, err
+		}
+		if t.TLSClientConfig == nil {
+			t.TLSClientConfig = &tls.Config{}
+		}
+		t.TLSClientConfig.RootCAs = pool
+	}
+	return t, nil
+}
+
+// loadCABundle returns the system roots plus the certificates of a PEM file.
+func loadCABundle(path string) (*x509.CertPool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("ca_bundle: %w", err)
+	}
+	pool, err := x509.SystemCertPool()
+	if err != nil || pool == nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("ca_bundle: %s contains no PEM certificates", path)
+	}
+	return pool, nil
+}
+
+func validateProxyURL(v string) error {
+	if v == "" {
+		return nil
+	}
+	u, err := url.Parse(v)
+	if err != nil || u.Host == "" {
+		// httpproxy accepts "host:port" and assumes http://
+		if u2, err2 := url.Parse("http://" + v); err2 == nil && u2.Host != "" && !strings.Contains(v, "://") {
+			return nil
+		}
+		return fmt.Errorf("invalid proxy URL %q", v)
+	}
+	switch u.Scheme {
+	case "http", "https", "socks5", "socks5h":
+		return nil
+	}
+	return fmt.Errorf("unsupported proxy scheme %q (use http, https or socks5)", u.Scheme)
+}
+
+// switchTransport delegates to a transport that Apply can replace at any time.
+type switchTransport struct {
+	base    *http.Transport
+	current atomic.Pointer[http.Transport]
+}
+
+func (s *switchTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return s.current.Load().RoundTrip(req)
+}
+
+// CloseIdleConnections lets http.Client.CloseIdleConnections reach the transport.
+func (s *switchTransport) CloseIdleConnections() {
+	s.current.Load().CloseIdleConnections()
+}
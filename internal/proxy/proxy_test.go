@@ -0,0 +1,77 @@
+package proxy
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestConfig_EffectiveValidateAndEnv(t *testing.T) {
+	t.Setenv("HTTPS_PROXY", "http://env-proxy:3128")
+	t.Setenv("NO_PROXY", ".internal")
+	c := Config{HTTPProxy: "proxy.corp:8080"}
+	eff := c.Effective()
+	if eff.HTTPProxy != "proxy.corp:8080" || eff.HTTPSProxy != "http://env-proxy:3128" || eff.NoProxy != ".internal" {
+		t.Fatalf("unexpected effective config: %+v", eff)
+	}
+	if err := c.Validate(); err != nil {
+		t.Fatalf("host:port must be accepted: %v", err)
+	}
+	for _, bad := range []Config{{HTTPProxy: "ftp://proxy"}, {HTTPSProxy: "http://"}, {CABundle: filepath.Join(t.TempDir(), "missing.pem")}} {
+		if err := bad.Validate(); err == nil {
+			t.Errorf("expected %+v to be rejected", bad)
+		}
+	}
+	pem := filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(pem, []byte("not a certificate"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := (Config{CABundle: pem}).Validate(); err == nil {
+		t.Error("expected a bundle without certificates to be rejected")
+	}
+
+	// Only explicit settings are passed to child processes
+	env := strings.Join(Config{HTTPSProxy: "http://p:1", CABundle: "/etc/corp.pem"}.Env(), " ")
+	if !strings.Contains(env, "HTTPS_PROXY=http://p:1") || !strings.Contains(env, "NODE_EXTRA_CA_CERTS=/etc/corp.pem") || strings.Contains(env, "HTTP_PROXY=") || strings.Contains(env, "SSL_CERT_FILE=") {
+		t.Fatalf("unexpected env: %s", env)
+	}
+}
+
+func TestApply_RoutesDefaultTransportThroughProxy(t *testing.T) {
+	var seen string
+	p := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// A forward proxy receives the absolute URL of the target
+		seen = r.URL.String()
+		_, _ = io.WriteString(w, "via proxy")
+	}))
+	defer p.Close()
+	defer func() { _ = Apply(Config{}) }()
+
+	if err := Apply(Config{HTTPProxy: p.URL, NoProxy: "direct.example"}); err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.Get("http://api.example.test/v1/models")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if string(body) != "via proxy" || seen != "http://api.example.test/v1/models" {
+		t.Fatalf("request did not go through the proxy: body=%q seen=%q", body, seen)
+	}
+	if Current().HTTPProxy != p.URL {
+		t.Fatalf("unexpected current config: %+v", Current())
+	}
+
+	// Invalid settings leave the working configuration in place
+	if err := Apply(Config{HTTPProxy: "ftp://x"}); err == nil {
+		t.Fatal("expected an invalid proxy to be rejected")
+	}
+	if Current().HTTPProxy != p.URL {
+		t.Fatal("a rejected config must not replace the current one")
+	}
+}
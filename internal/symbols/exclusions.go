@@ -0,0 +1,271 @@
+package symbols
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// excludeMinDirBytes is the subtree size from which a directory is considered for
+	// automatic exclusion; smaller asset folders are cheap enough to walk.
+	excludeMinDirBytes = 50 << 20
+	// excludeBinaryRatio is the share of a subtree's bytes that must be unindexable
+	// (binary/media or over the per-file size cap) for it to be excluded.
+	excludeBinaryRatio = 0.8
+	// maxIndexFileBytes is the per-file cap both services apply before parsing.
+	maxIndexFileBytes = 1_500_000
+)
+
+// DirExclusion describes a directory skipped by the indexer and why.
+type DirExclusion struct {
+	Path        string  `json:"path"` // workspace-relative, slash-separated
+	Reason      string  `json:"reason"`
+	Bytes       int64   `json:"bytes"`
+	Files       int     `json:"files"`
+	BinaryBytes int64   `json:"binary_bytes"`
+	BinaryShare float64 `json:"binary_share"`
+}
+
+// ExclusionReport is the result of the size analysis run before each full index.
+type ExclusionReport struct {
+	Excluded     []DirExclusion `json:"excluded"`
+	Reincluded   []string       `json:"reincluded"`
+	ScannedBytes int64          `json:"scanned_bytes"`
+	SkippedBytes int64          `json:"skipped_bytes"`
+	ScannedFiles int            `json:"scanned_files"`
+	SkippedFiles int            `json:"skipped_files"`
+	AnalyzedAt   time.Time      `json:"analyzed_at"`
+}
+
+// Skips reports whether rel (workspace-relative) lies in an excluded directory.
+func (r ExclusionReport) Skips(rel string) bool {
+	rel = filepath.ToSlash(rel)
+	for _, ex := range r.Excluded {
+		if rel == ex.Path || strings.HasPrefix(rel, ex.Path+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// exclusionState holds a service's latest exclusion report.
+type exclusionState struct {
+	exclMu     sync.RWMutex
+	exclusions ExclusionReport
+}
+
+// Exclusions returns the directories skipped by the last full index.
+func (e *exclusionState) Exclusions() ExclusionReport {
+	e.exclMu.RLock()
+	defer e.exclMu.RUnlock()
+	return e.exclusions
+}
+
+func (e *exclusionState) analyzeExclusions(workspacePath string) ExclusionReport {
+	r := AnalyzeExclusions(workspacePath, LoadReincluded(workspacePath))
+	e.exclMu.Lock()
+	e.exclusions = r
+	e.exclMu.Unlock()
+	return r
+}
+
+// indexExclusions runs the size analysis for a full profile. Sparse profiles skip it:
+// walking a huge monorepo to measure it is the cost sparse indexing avoids.
+func (e *exclusionState) indexExclusions(workspacePath string, profile IndexProfile) ExclusionReport {
+	if !profile.Sparse() {
+		return e.analyzeExclusions(workspacePath)
+	}
+	r := ExclusionReport{Reincluded: LoadReincluded(workspacePath), AnalyzedAt: time.Now()}
+	e.exclMu.Lock()
+	e.exclusions = r
+	e.exclMu.Unlock()
+	return r
+}
+
+func (e *exclusionState) excluded(rel string) bool {
+	e.exclMu.RLock()
+	defer e.exclMu.RUnlock()
+	return e.exclusions.Skips(rel)
+}
+
+// binaryExts are media, archive, and compiled formats the symbol parsers never read.
+var binaryExts = map[string]bool{
+	".png": true, ".jpg": true, ".jpeg": true, ".gif": true, ".bmp": true, ".tif": true, ".tiff": true,
+	".webp": true, ".ico": true, ".psd": true, ".heic": true, ".raw": true, ".exr": true, ".hdr": true,
+	".mp4": true, ".mov": true, ".avi": true, ".mkv": true, ".webm": true, ".m4v": true, ".wmv": true,
+	".mp3": true, ".wav": true, ".flac": true, ".ogg": true, ".aac": true, ".m4a": true,
+	".zip": true, ".tar": true, ".gz": true, ".tgz": true, ".bz2": true, ".xz": true, ".7z": true, ".rar": true,
+	".jar": true, ".war": true, ".exe": true, ".dll": true, ".so": true, ".dylib": true, ".a": true,
+	".o": true, ".bin": true, ".class": true, ".wasm": true, ".pyc": true, ".iso": true, ".dmg": true,
+	".ttf": true, ".otf": true, ".woff": true, ".woff2": true, ".eot": true,
+	".pdf": true, ".db": true, ".sqlite": true, ".parquet": true, ".npy": true, ".npz": true,
+	".pt": true, ".pth": true, ".onnx": true, ".ckpt": true, ".safetensors": true, ".h5": true,
+	".pb": true, ".tflite": true, ".glb": true, ".fbx": true, ".blend": true, ".unitypackage": true,
+}
+
+type dirStats struct {
+	bytes, binBytes int64
+	files           int
+}
+
+// AnalyzeExclusions measures every directory under root and selects the topmost
+// ones that are large and dominated by files the indexer cannot use. Directories
+// equal to, inside, or containing a re-included path are never excluded.
+func AnalyzeExclusions(root string, reincluded []string) ExclusionReport {
+	report := ExclusionReport{Reincluded: normalizeRelPaths(reincluded), AnalyzedAt: time.Now()}
+	stats := map[string]*dirStats{}
+	_ = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		rel, _ := filepath.Rel(root, path)
+		rel = filepath.ToSlash(rel)
+		if d.IsDir() {
+			if rel != "." && (ignoreDirName(d.Name()) || ignorePath(rel)) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil || !info.Mode().IsRegular() {
+			return nil
+		}
+		size := info.Size()
+		unindexable := binaryExts[strings.ToLower(filepath.Ext(rel))] || size > maxIndexFileBytes
+		for dir := filepath.ToSlash(filepath.Dir(rel)); ; dir = filepath.ToSlash(filepath.Dir(dir)) {
+			st := stats[dir]
+			if st == nil {
+				st = &dirStats{}
+				stats[dir] = st
+			}
+			st.bytes += size
+			st.files++
+			if unindexable {
+				st.binBytes += size
+			}
+			if dir == "." {
+				break
+			}
+		}
+		return nil
+	})
+
+	// Parents sort before their children, so the topmost qualifying directory wins
+	dirs := make([]string, 0, len(stats))
+	for dir := range stats {
+		dirs = append(dirs, dir)
+	}
+	sort.Strings(dirs)
+	for _, dir := range dirs {
+		st := stats[dir]
+		if dir == "." || st.bytes < excludeMinDirBytes || report.Skips(dir) || touchesAny(dir, report.Reincluded) {
+			continue
+		}
+		share := float64(st.binBytes) / float64(st.bytes)
+		if share < excludeBinaryRatio {
+			continue
+		}
+		report.Excluded = append(report.Excluded, DirExclusion{
+			Path:        dir,
+			Reason:      fmt.Sprintf("%s in %d files, %.0f%% binary/media", formatBytes(st.bytes), st.files, share*100),
+			Bytes:       st.bytes,
+			Files:       st.files,
+			BinaryBytes: st.binBytes,
+			BinaryShare: share,
+		})
+		report.SkippedBytes += st.bytes
+		report.SkippedFiles += st.files
+	}
+	if st := stats["."]; st != nil {
+		report.ScannedBytes = st.bytes - report.SkippedBytes
+		report.ScannedFiles = st.files - report.SkippedFiles
+	}
+	return report
+}
+
+// touchesAny reports whether dir equals, contains, or lies inside one of paths.
+func touchesAny(dir string, paths []string) bool {
+	for _, p := range paths {
+		if dir == p || strings.HasPrefix(dir, p+"/") || strings.HasPrefix(p, dir+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+func normalizeRelPaths(paths []string) []string {
+	out := []string{}
+	seen := map[string]bool{}
+	for _, p := range paths {
+		p = strings.Trim(filepath.ToSlash(filepath.Clean(strings.TrimSpace(p))), "/")
+		if p == "" || p == "." || strings.HasPrefix(p, "../") || seen[p] {
+			continue
+		}
+		seen[p] = true
+		out = append(out, p)
+	}
+	sort.Strings(out)
+	return out
+}
+
+func formatBytes(n int64) string {
+	switch {
+	case n >= 1<<30:
+		return fmt.Sprintf("%.1f GB", float64(n)/(1<<30))
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(n)/(1<<20))
+	}
+	return fmt.Sprintf("%d KB", n>>10)
+}
+
+// projectDataDir returns ~/.loom/projects/<id> for a workspace, where index data lives.
+func projectDataDir(workspacePath string) (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".loom", "projects", hashPath(workspacePath)), nil
+}
+
+const reincludeFile = "index_reinclude.json"
+
+// LoadReincluded returns the workspace-relative paths the user asked to index even
+// though the size heuristics would exclude them.
+func LoadReincluded(workspacePath string) []string {
+	dir, err := projectDataDir(workspacePath)
+	if err != nil {
+		return nil
+	}
+	data, err := os.ReadFile(filepath.Join(dir, reincludeFile))
+	if err != nil {
+		return nil
+	}
+	var paths []string
+	if json.Unmarshal(data, &paths) != nil {
+		return nil
+	}
+	return normalizeRelPaths(paths)
+}
+
+// SaveReincluded persists the re-included paths for a workspace.
+func SaveReincluded(workspacePath string, paths []string) error {
+	dir, err := projectDataDir(workspacePath)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(normalizeRelPaths(paths), "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, reincludeFile), data, 0o644)
+}
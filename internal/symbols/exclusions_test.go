@@ -0,0 +1,55 @@
+package symbols
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeSized(t *testing.T, path string, size int64) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	// Sparse files keep the test fast while reporting the full size
+	if err := f.Truncate(size); err != nil {
+		t.Fatalf("truncate: %v", err)
+	}
+	_ = f.Close()
+}
+
+func TestAnalyzeExclusions_SkipsLargeMediaDirectories(t *testing.T) {
+	root := t.TempDir()
+	writeSized(t, filepath.Join(root, "assets", "video", "intro.mp4"), 60<<20)
+	writeSized(t, filepath.Join(root, "assets", "textures", "a.png"), 30<<20)
+	writeSized(t, filepath.Join(root, "assets", "README.md"), 1<<10)
+	writeSized(t, filepath.Join(root, "src", "main.go"), 4<<10)
+	// Large but mostly source: stays indexed
+	for i := 0; i < 60; i++ {
+		writeSized(t, filepath.Join(root, "gen", "f"+string(rune('a'+i%26))+string(rune('a'+i/26))+".go"), 1<<20)
+	}
+
+	report := AnalyzeExclusions(root, nil)
+	if len(report.Excluded) != 1 || report.Excluded[0].Path != "assets" {
+		t.Fatalf("expected only assets to be excluded, got %+v", report.Excluded)
+	}
+	if !report.Skips("assets/video/intro.mp4") || report.Skips("src/main.go") || report.Skips("assetsx/a.go") {
+		t.Fatalf("unexpected Skips results")
+	}
+	if report.SkippedFiles != 3 || report.ScannedFiles != 61 {
+		t.Fatalf("unexpected file counts: skipped %d scanned %d", report.SkippedFiles, report.ScannedFiles)
+	}
+
+	// Re-including a subdirectory keeps the parent indexed but still skips heavy siblings
+	report = AnalyzeExclusions(root, []string{"assets/textures/"})
+	if len(report.Excluded) != 1 || report.Excluded[0].Path != "assets/video" {
+		t.Fatalf("expected assets/video to be excluded after re-include, got %+v", report.Excluded)
+	}
+	if len(report.Reincluded) != 1 || report.Reincluded[0] != "assets/textures" {
+		t.Fatalf("unexpected re-included paths: %v", report.Reincluded)
+	}
+}
@@ -0,0 +1,33 @@
+package symbols
+
+import "syscall"
+
+// networkFilesystems are the filesystem type names on which FSEvents misses changes
+// made by other machines.
+var networkFilesystems = map[string]bool{
+	"nfs":     true,
+	"smbfs":   true,
+	"afpfs":   true,
+	"webdav":  true,
+	"cifs":    true,
+	"macfuse": true,
+	"osxfuse": true,
+}
+
+// filesystemType names the filesystem holding path and reports whether it is a
+// network or otherwise remote mount.
+func filesystemType(path string) (string, bool) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(path, &st); err != nil {
+		return "", false
+	}
+	var b []byte
+	for _, c := range st.Fstypename {
+		if c == 0 {
+			break
+		}
+		b = append(b, byte(c))
+	}
+	name := string(b)
+	return name, networkFilesystems[name]
+}
@@ -0,0 +1,45 @@
+package symbols
+
+import (
+	"os"
+	"strings"
+	"syscall"
+)
+
+// networkFilesystems maps statfs magic numbers of filesystems on which inotify misses
+// changes made by other machines (or by Windows, for WSL drives) to their names.
+var networkFilesystems = map[uint32]string{
+	0x6969:     "nfs",
+	0x517b:     "smb",
+	0xff534d42: "cifs",
+	0xfe534d42: "smb2",
+	0x01021997: "9p",
+	0x65735546: "fuse",
+	0x00c36400: "ceph",
+	0x5346414f: "afs",
+	0x564c:     "ncp",
+	0x73757245: "coda",
+}
+
+// filesystemType names the filesystem holding path and reports whether it is a
+// network or otherwise remote mount.
+func filesystemType(path string) (string, bool) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(path, &st); err != nil {
+		return "", false
+	}
+	if name, ok := networkFilesystems[uint32(st.Type)]; ok {
+		return name, true
+	}
+	// WSL 1 serves Windows drives through drvfs, which reports no distinct magic
+	if isWSL() && strings.HasPrefix(path, "/mnt/") && len(path) >= 6 && (len(path) == 6 || path[6] == '/') {
+		return "drvfs", true
+	}
+	return "local", false
+}
+
+// isWSL reports whether Loom runs under the Windows Subsystem for Linux.
+func isWSL() bool {
+	data, err := os.ReadFile("/proc/sys/kernel/osrelease")
+	return err == nil && strings.Contains(strings.ToLower(string(data)), "microsoft")
+}
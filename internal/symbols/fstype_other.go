@@ -0,0 +1,14 @@
+//go:build !linux && !darwin
+
+package symbols
+
+import "strings"
+
+// filesystemType only recognises UNC paths (\\server\share) as network mounts on this
+// platform; elsewhere notifications are used unless polling is configured.
+func filesystemType(path string) (string, bool) {
+	if strings.HasPrefix(path, `\\`) {
+		return "unc", true
+	}
+	return "", false
+}
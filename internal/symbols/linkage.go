@@ -0,0 +1,314 @@
+package symbols
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Cross-file relations for C, C++, and Java. They are derived at index time from the
+// file being indexed plus a handful of counterpart files, so each relation's site is
+// always in the indexed file and is replaced when that file is reindexed:
+//
+//   - "implementation": a function defined here implements a prototype declared in a
+//     header (C/C++), or a class here implements a Java interface.
+//   - "declaration": a prototype here is defined in a source file (C/C++).
+//   - "subclass": a class here extends the target class.
+
+const maxLinkedFiles = 8
+
+var (
+	cIncludeRe    = regexp.MustCompile(`^\s*#\s*include\s*"([^"]+)"`)
+	javaImportRe  = regexp.MustCompile(`^\s*import\s+(static\s+)?([\w.]+(?:\.\*)?)\s*;`)
+	javaExtendsRe = regexp.MustCompile(`\bextends\s+(.+?)(?:\bimplements\b|\bpermits\b|$)`)
+	javaImplRe    = regexp.MustCompile(`\bimplements\s+(.+?)(?:\bpermits\b|$)`)
+	cBasesRe      = regexp.MustCompile(`:\s*(.+)$`)
+	cHeaderExts   = []string{".h", ".hpp", ".hh", ".hxx"}
+	cSourceExts   = []string{".c", ".cpp", ".cc", ".cxx"}
+)
+
+// linkRelations returns cross-file relations for symbols parsed from relPath.
+func linkRelations(workspace, relPath, content, lang string, syms []Symbol) []Relation {
+	switch lang {
+	case "c", "c++":
+		return linkCFamily(workspace, relPath, content, syms)
+	case "java":
+		return linkJava(workspace, relPath, content, syms)
+	}
+	return nil
+}
+
+// linkCFamily pairs prototypes with definitions across header/implementation files
+// and resolves base classes declared in included headers.
+func linkCFamily(workspace, relPath, content string, syms []Symbol) []Relation {
+	rel := filepath.ToSlash(relPath)
+	dir, base := path.Dir(rel), path.Base(rel)
+	stem := strings.TrimSuffix(base, path.Ext(base))
+	isHeader := false
+	for _, ext := range cHeaderExts {
+		if strings.EqualFold(path.Ext(base), ext) {
+			isHeader = true
+		}
+	}
+
+	var candidates []string
+	if isHeader {
+		for _, ext := range cSourceExts {
+			candidates = append(candidates, path.Join(dir, stem+ext))
+			// include/foo.h <-> src/foo.c layouts
+			if swapped := swapDir(dir, "include", "src"); swapped != "" {
+				candidates = append(candidates, path.Join(swapped, stem+ext))
+			}
+		}
+	} else {
+		for _, ext := range cHeaderExts {
+			candidates = append(candidates, path.Join(dir, stem+ext))
+			if swapped := swapDir(dir, "src", "include"); swapped != "" {
+				candidates = append(candidates, path.Join(swapped, stem+ext))
+			}
+		}
+	}
+	for _, line := range strings.Split(content, "\n") {
+		if m := cIncludeRe.FindStringSubmatch(line); m != nil {
+			candidates = append(candidates, path.Join(dir, m[1]), path.Join("include", m[1]), path.Clean(m[1]))
+		}
+	}
+
+	// The file's own symbols take part too: forward declarations and base classes
+	// often live in the same file
+	pool := map[string][]Symbol{}
+	bySID := map[string]Symbol{}
+	for _, other := range append(loadLinked(workspace, rel, candidates), syms) {
+		for _, sy := range other {
+			pool[sy.Name] = append(pool[sy.Name], sy)
+			bySID[sy.SID] = sy
+		}
+	}
+
+	var rels []Relation
+	for _, sy := range syms {
+		switch {
+		case sy.Kind == "func" || sy.Kind == "prototype":
+			want, kind := "prototype", "implementation"
+			if sy.Kind == "prototype" {
+				want, kind = "func", "declaration"
+			}
+			for _, target := range pool[sy.Name] {
+				// Members only link to same-named members of the same class
+				if target.Kind == want && target.SID != sy.SID && ownerName(sy, bySID) == ownerName(target, bySID) {
+					rels = append(rels, linkRelation(sy, target, kind))
+				}
+			}
+		case sy.Kind == "class" || sy.Kind == "struct":
+			m := cBasesRe.FindStringSubmatch(sy.Signature)
+			if m == nil {
+				continue
+			}
+			for _, b := range splitTypeList(m[1]) {
+				b = strings.TrimSpace(strings.TrimPrefix(strings.TrimPrefix(strings.TrimPrefix(b, "public "), "protected "), "private "))
+				b = strings.TrimSpace(strings.TrimPrefix(b, "virtual "))
+				if k := strings.LastIndex(b, "::"); k >= 0 {
+					b = b[k+2:]
+				}
+				for _, target := range pool[b] {
+					if isTypeKind(target.Kind) && target.SID != sy.SID {
+						rels = append(rels, linkRelation(sy, target, "subclass"))
+						break
+					}
+				}
+			}
+		}
+	}
+	return rels
+}
+
+// ownerName returns the name of the type a function belongs to, or "" for free functions.
+func ownerName(sy Symbol, bySID map[string]Symbol) string {
+	// Out-of-line definitions carry their owner in the signature (Foo::bar)
+	if k := strings.Index(sy.Signature, "::"+sy.Name); k >= 0 {
+		owner := sy.Signature[:k]
+		if j := strings.LastIndexAny(owner, " *&:"); j >= 0 {
+			owner = owner[j+1:]
+		}
+		if owner != "" {
+			return owner
+		}
+	}
+	if c, ok := bySID[sy.ContainerSID]; ok && isTypeKind(c.Kind) {
+		return c.Name
+	}
+	return ""
+}
+
+// linkJava resolves extends/implements clauses to the supertypes' class symbols,
+// following imports, the current package, and wildcard imports.
+func linkJava(workspace, relPath, content string, syms []Symbol) []Relation {
+	rel := filepath.ToSlash(relPath)
+	pkg := ""
+	for _, sy := range syms {
+		if sy.Kind == "package" {
+			pkg = sy.Name
+			break
+		}
+	}
+	srcRoot := path.Dir(rel)
+	if pkgDir := strings.ReplaceAll(pkg, ".", "/"); pkgDir != "" {
+		if srcRoot == pkgDir {
+			srcRoot = "."
+		} else if strings.HasSuffix(srcRoot, "/"+pkgDir) {
+			srcRoot = strings.TrimSuffix(srcRoot, "/"+pkgDir)
+		}
+	}
+	imports := map[string]string{}
+	var wildcards []string
+	for _, line := range strings.Split(content, "\n") {
+		m := javaImportRe.FindStringSubmatch(line)
+		if m == nil || m[1] != "" {
+			continue
+		}
+		if strings.HasSuffix(m[2], ".*") {
+			wildcards = append(wildcards, strings.TrimSuffix(m[2], ".*"))
+			continue
+		}
+		imports[m[2][strings.LastIndex(m[2], ".")+1:]] = m[2]
+	}
+	local := map[string]Symbol{}
+	for _, sy := range syms {
+		if isTypeKind(sy.Kind) {
+			local[sy.Name] = sy
+		}
+	}
+
+	// resolve maps a type name as written to the class symbol it refers to
+	resolve := func(name string) (Symbol, bool) {
+		name = strings.TrimSpace(name)
+		if k := strings.Index(name, "<"); k >= 0 {
+			name = name[:k]
+		}
+		simple := name[strings.LastIndex(name, ".")+1:]
+		if sy, ok := local[simple]; ok && !strings.Contains(name, ".") {
+			return sy, true
+		}
+		var fqcns []string
+		switch {
+		case strings.Contains(name, "."):
+			fqcns = append(fqcns, name)
+		case imports[name] != "":
+			fqcns = append(fqcns, imports[name])
+		default:
+			if pkg != "" {
+				fqcns = append(fqcns, pkg+"."+name)
+			} else {
+				fqcns = append(fqcns, name)
+			}
+			for _, w := range wildcards {
+				fqcns = append(fqcns, w+"."+name)
+			}
+		}
+		for _, fqcn := range fqcns {
+			file := path.Join(srcRoot, strings.ReplaceAll(fqcn, ".", "/")+".java")
+			for _, other := range loadLinked(workspace, rel, []string{file}) {
+				for _, sy := range other {
+					if sy.Name == simple && isTypeKind(sy.Kind) {
+						return sy, true
+					}
+				}
+			}
+		}
+		return Symbol{}, false
+	}
+
+	var rels []Relation
+	for _, sy := range syms {
+		if !isTypeKind(sy.Kind) {
+			continue
+		}
+		sig := strings.TrimSpace(javaAnnotation.ReplaceAllString(sy.Signature, ""))
+		extendKind := "subclass"
+		if sy.Kind == "interface" {
+			// interface A extends B: A refines B's contract
+			extendKind = "implementation"
+		}
+		if m := javaExtendsRe.FindStringSubmatch(sig); m != nil {
+			for _, name := range splitTypeList(m[1]) {
+				if target, ok := resolve(name); ok && target.SID != sy.SID {
+					rels = append(rels, linkRelation(sy, target, extendKind))
+				}
+			}
+		}
+		if m := javaImplRe.FindStringSubmatch(sig); m != nil {
+			for _, name := range splitTypeList(m[1]) {
+				if target, ok := resolve(name); ok && target.SID != sy.SID {
+					rels = append(rels, linkRelation(sy, target, "implementation"))
+				}
+			}
+		}
+	}
+	return rels
+}
+
+// loadLinked parses existing candidate files (other than self), at most maxLinkedFiles.
+func loadLinked(workspace, self string, candidates []string) [][]Symbol {
+	var out [][]Symbol
+	seen := map[string]bool{self: true}
+	for _, c := range candidates {
+		c = path.Clean(c)
+		if seen[c] || strings.HasPrefix(c, "../") || len(out) >= maxLinkedFiles {
+			continue
+		}
+		seen[c] = true
+		abs := filepath.Join(workspace, filepath.FromSlash(c))
+		info, err := os.Stat(abs)
+		if err != nil || info.IsDir() || info.Size() > maxIndexFileBytes {
+			continue
+		}
+		data, err := os.ReadFile(abs)
+		if err != nil {
+			continue
+		}
+		syms, _ := parseFile(c, string(data), detectLanguage(abs))
+		out = append(out, syms)
+	}
+	return out
+}
+
+func linkRelation(from, to Symbol, kind string) Relation {
+	return Relation{FromSID: from.SID, ToSID: to.SID, Kind: kind, FilePath: from.FilePath, LineStart: from.LineStart, LineEnd: from.LineStart}
+}
+
+// swapDir replaces the first path segment equal to from with to ("" if absent).
+func swapDir(dir, from, to string) string {
+	parts := strings.Split(dir, "/")
+	for i, p := range parts {
+		if p == from {
+			parts[i] = to
+			return strings.Join(parts, "/")
+		}
+	}
+	return ""
+}
+
+// splitTypeList splits "A, B<C, D>, E" on top-level commas.
+func splitTypeList(s string) []string {
+	var out []string
+	depth, start := 0, 0
+	for i, c := range s {
+		switch c {
+		case '<':
+			depth++
+		case '>':
+			depth--
+		case ',':
+			if depth == 0 {
+				out = append(out, strings.TrimSpace(s[start:i]))
+				start = i + 1
+			}
+		}
+	}
+	if last := strings.TrimSpace(s[start:]); last != "" {
+		out = append(out, last)
+	}
+	return out
+}
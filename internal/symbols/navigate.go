@@ -0,0 +1,191 @@
+package symbols
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/loom/loom/internal/editor"
+)
+
+const (
+	maxPeekDefinitions = 10
+	maxPeekLines       = 40
+)
+
+// Navigator is the part of a symbol service that editor navigation uses; both
+// Service and SQLiteService implement it.
+type Navigator interface {
+	Search(ctx context.Context, q, kind, lang, pathPrefix string, limit int) ([]SymbolCard, error)
+	Refs(ctx context.Context, sid, kind string) ([]RefSite, error)
+	Outline(ctx context.Context, relPath string) ([]OutlineNode, error)
+	Workspace() string
+}
+
+// Peek is a definition with the lines of code it spans, for an editor's peek view.
+type Peek struct {
+	Symbol SymbolCard `json:"symbol"`
+	// Range is the [start_line, end_line] of Snippet, 1-based and inclusive
+	Range   [2]int `json:"range"`
+	Snippet string `json:"snippet"`
+}
+
+// Definitions resolves the identifier at a 1-based line and column of a workspace file
+// to its definitions, best first: the same file, then the same directory, then by
+// index confidence. It returns the identifier too; no identifier yields no definitions.
+func Definitions(ctx context.Context, nav Navigator, relPath string, line, col int) (string, []Peek, error) {
+	lines, err := readWorkspaceLines(nav.Workspace(), relPath)
+	if err != nil {
+		return "", nil, err
+	}
+	word := ""
+	if line >= 1 && line <= len(lines) {
+		word = WordAt(lines[line-1], col)
+	}
+	if word == "" {
+		return "", []Peek{}, nil
+	}
+	cards, err := nav.Search(ctx, word, "", "", "", 100)
+	if err != nil {
+		return word, nil, err
+	}
+	var defs []SymbolCard
+	for _, c := range cards {
+		if c.Name == word {
+			defs = append(defs, c)
+		}
+	}
+	rel := filepath.ToSlash(relPath)
+	rank := func(c SymbolCard) int {
+		switch {
+		case c.File == rel:
+			return 0
+		case path.Dir(c.File) == path.Dir(rel):
+			return 1
+		}
+		return 2
+	}
+	sort.SliceStable(defs, func(i, j int) bool {
+		if ri, rj := rank(defs[i]), rank(defs[j]); ri != rj {
+			return ri < rj
+		}
+		return defs[i].Confidence > defs[j].Confidence
+	})
+	if len(defs) > maxPeekDefinitions {
+		defs = defs[:maxPeekDefinitions]
+	}
+	out := []Peek{}
+	for _, d := range defs {
+		p := Peek{Symbol: d, Range: [2]int{d.Span[0], d.Span[0]}}
+		if src, err := readWorkspaceLines(nav.Workspace(), d.File); err == nil && d.Span[0] >= 1 && d.Span[0] <= len(src) {
+			end := min(len(src), min(max(d.Span[2], d.Span[0]), d.Span[0]+maxPeekLines-1))
+			p.Range[1] = end
+			p.Snippet = strings.Join(src[d.Span[0]-1:end], "\n")
+		}
+		out = append(out, p)
+	}
+	return word, out, nil
+}
+
+// References returns the reference sites the index recorded for the identifier at a
+// 1-based line and column, together with its definitions, ordered by file and line.
+func References(ctx context.Context, nav Navigator, relPath string, line, col int) (string, []RefSite, error) {
+	word, defs, err := Definitions(ctx, nav, relPath, line, col)
+	if err != nil {
+		return word, nil, err
+	}
+	seen := map[string]bool{}
+	out := []RefSite{}
+	add := func(r RefSite) {
+		key := fmt.Sprintf("%s:%d", r.File, r.LineStart)
+		if !seen[key] {
+			seen[key] = true
+			out = append(out, r)
+		}
+	}
+	for _, d := range defs {
+		add(RefSite{File: d.Symbol.File, LineStart: d.Symbol.Span[0], LineEnd: d.Symbol.Span[0], Kind: "definition"})
+		refs, err := nav.Refs(ctx, d.Symbol.SID, "")
+		if err != nil {
+			return word, nil, err
+		}
+		for _, r := range refs {
+			add(r)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].File != out[j].File {
+			return out[i].File < out[j].File
+		}
+		return out[i].LineStart < out[j].LineStart
+	})
+	return word, out, nil
+}
+
+// DocumentSymbols returns the outline of a workspace file.
+func DocumentSymbols(ctx context.Context, nav Navigator, relPath string) ([]OutlineNode, error) {
+	if _, err := workspaceFile(nav.Workspace(), relPath); err != nil {
+		return nil, err
+	}
+	nodes, err := nav.Outline(ctx, filepath.ToSlash(relPath))
+	if nodes == nil {
+		nodes = []OutlineNode{}
+	}
+	return nodes, err
+}
+
+// WordAt returns the identifier that contains, or ends right before, the 1-based
+// column col of line.
+func WordAt(line string, col int) string {
+	runes := []rune(line)
+	i := col - 1
+	if i < 0 || i > len(runes) {
+		return ""
+	}
+	if i == len(runes) || !isIdentRune(runes[i]) {
+		// The cursor sits just after the identifier
+		if i == 0 || !isIdentRune(runes[i-1]) {
+			return ""
+		}
+		i--
+	}
+	start, end := i, i+1
+	for start > 0 && isIdentRune(runes[start-1]) {
+		start--
+	}
+	for end < len(runes) && isIdentRune(runes[end]) {
+		end++
+	}
+	return string(runes[start:end])
+}
+
+func isIdentRune(r rune) bool {
+	return r == '_' || r == '$' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// workspaceFile resolves a workspace-relative path, refusing paths outside it, also
+// through symlinks.
+func workspaceFile(workspace, relPath string) (string, error) {
+	rel := filepath.Clean(filepath.FromSlash(strings.TrimSpace(relPath)))
+	if rel == "." || filepath.IsAbs(rel) || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("invalid workspace path %q", relPath)
+	}
+	return editor.SecurePath(workspace, rel)
+}
+
+func readWorkspaceLines(workspace, relPath string) ([]string, error) {
+	abs, err := workspaceFile(workspace, relPath)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(abs)
+	if err != nil {
+		return nil, err
+	}
+	return strings.Split(string(data), "\n"), nil
+}
@@ -0,0 +1,84 @@
+package symbols
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWordAt(t *testing.T) {
+	line := "\tresult := computeTotal(order)"
+	for col, want := range map[int]string{12: "computeTotal", 24: "computeTotal", 1: "", 25: "order", 30: "order", 31: "", 40: ""} {
+		if got := WordAt(line, col); got != want {
+			t.Errorf("WordAt(col %d) = %q, want %q", col, got, want)
+		}
+	}
+}
+
+func TestNavigation_DefinitionsReferencesAndSymbols(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	root := t.TempDir()
+	files := map[string]string{
+		"billing/total.go": "package billing\n\nfunc computeTotal(o Order) int {\n\treturn o.Amount\n}\n\nfunc charge(o Order) int {\n\treturn computeTotal(o)\n}\n",
+		"report/total.go":  "package report\n\nfunc computeTotal() int {\n\treturn 0\n}\n",
+	}
+	for rel, content := range files {
+		path := filepath.Join(root, rel)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	svc, err := NewService(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	if err := svc.IndexAll(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	// The call in charge resolves to both definitions, the same file first
+	word, defs, err := Definitions(ctx, svc, "billing/total.go", 8, 12)
+	if err != nil || word != "computeTotal" || len(defs) != 2 {
+		t.Fatalf("unexpected definitions %q %+v %v", word, defs, err)
+	}
+	if defs[0].Symbol.File != "billing/total.go" || defs[0].Range[0] != 3 || !strings.HasPrefix(defs[0].Snippet, "func computeTotal(o Order) int {") {
+		t.Errorf("unexpected best definition %+v", defs[0])
+	}
+	if word, defs, err := Definitions(ctx, svc, "billing/total.go", 2, 1); err != nil || word != "" || len(defs) != 0 {
+		t.Errorf("an empty line has no definitions: %q %+v %v", word, defs, err)
+	}
+	if _, _, err := Definitions(ctx, svc, "../outside.go", 1, 1); err == nil {
+		t.Error("expected paths outside the workspace to be refused")
+	}
+	outside := filepath.Join(t.TempDir(), "secret.go")
+	_ = os.WriteFile(outside, []byte("package secret\n"), 0o644)
+	if err := os.Symlink(outside, filepath.Join(root, "link.go")); err == nil {
+		if _, _, err := Definitions(ctx, svc, "link.go", 1, 1); err == nil {
+			t.Error("expected a symlink out of the workspace to be refused")
+		}
+	}
+
+	_, refs, err := References(ctx, svc, "billing/total.go", 3, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var sites []string
+	for _, r := range refs {
+		sites = append(sites, r.File+":"+strings.TrimSpace(r.Kind))
+	}
+	joined := strings.Join(sites, ",")
+	if !strings.Contains(joined, "billing/total.go:definition") || !strings.Contains(joined, "report/total.go:definition") {
+		t.Errorf("unexpected references %v", sites)
+	}
+
+	nodes, err := DocumentSymbols(ctx, svc, "billing/total.go")
+	if err != nil || len(nodes) != 2 || nodes[0].Name != "computeTotal" || nodes[1].Name != "charge" {
+		t.Errorf("unexpected outline %+v %v", nodes, err)
+	}
+}
@@ -0,0 +1,424 @@
+package symbols
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Structured heuristic parsers for C, C++, and Java. Unlike the line matchers in
+// parseFile they track braces, so members get containers and real end lines, and
+// calls inside function bodies are not mistaken for definitions.
+
+// clikeScope is a brace-delimited region opened by a symbol (or an anonymous block
+// such as extern "C" { ... } when sym is -1).
+type clikeScope struct {
+	sym       int
+	name      string
+	bodyDepth int
+	opened    bool
+	container bool // members are detected directly inside it
+	typedef   bool // anonymous typedef struct whose name follows the closing brace
+}
+
+type clikeParser struct {
+	relPath, lang string
+	lines, code   []string
+
+	syms   []Symbol
+	parent []int // index of each symbol's container in syms, or -1
+	scopes []clikeScope
+	depth  int
+	pkg    int // Java package symbol, or -1
+}
+
+var (
+	javaPackageRe  = regexp.MustCompile(`^package\s+([\w.]+)\s*$`)
+	javaTypeRe     = regexp.MustCompile(`^(?:(?:public|protected|private|abstract|static|final|sealed|non-sealed|strictfp)\s+)*(class|interface|enum|record|@interface)\s+(\w+)`)
+	javaMethodRe   = regexp.MustCompile(`^(?:(?:public|protected|private|static|final|abstract|synchronized|native|default|strictfp)\s+)*(?:<[^>]*>\s*)?([\w.<>\[\]?, ]+?)\s+(\w+)\s*\(`)
+	javaCtorRe     = regexp.MustCompile(`^(?:(?:public|protected|private)\s+)?(?:<[^>]*>\s*)?(\w+)\s*\(`)
+	javaConstRe    = regexp.MustCompile(`^(?:(?:public|protected|private)\s+)?static\s+final\s+[\w.<>\[\]?, ]+?\s+(\w+)\s*=`)
+	javaAnnotation = regexp.MustCompile(`@\w+(?:\.\w+)*(?:\s*\([^)]*\))?\s*`)
+
+	cDefineRe    = regexp.MustCompile(`^#\s*define\s+(\w+)`)
+	cTemplateRe  = regexp.MustCompile(`^template\s*<[^>]*>\s*`)
+	cNamespaceRe = regexp.MustCompile(`^(?:inline\s+)?namespace\s+([\w:]+)\s*$`)
+	cTypeRe      = regexp.MustCompile(`^(typedef\s+)?(class|struct|union|enum(?:\s+(?:class|struct))?)\s+((?:[A-Z_][A-Z0-9_]*\s+)*)(\w+)?\s*(?:final\s*)?(:[^{]*)?$`)
+	cFuncPtrDef  = regexp.MustCompile(`^typedef\s+.*\(\s*\*\s*(\w+)\s*\)`)
+	cTypedefRe   = regexp.MustCompile(`^typedef\s+.*?(\w+)\s*(?:\[[^\]]*\])?\s*$`)
+	cUsingRe     = regexp.MustCompile(`^using\s+(\w+)\s*=`)
+	cFuncRe      = regexp.MustCompile(`^(.*?)((?:~?\w+::)*(?:~?[A-Za-z_]\w*|operator\s*[^\s(]+))\s*\(`)
+	cTypedefTail = regexp.MustCompile(`^\s*(\w+)\s*[;,\[]`)
+	clikeLabel   = regexp.MustCompile(`^\w+(?:\s+\w+)?\s*:$`)
+)
+
+// clikeKeywords cannot start or name a function definition.
+var clikeKeywords = map[string]bool{
+	"if": true, "for": true, "while": true, "switch": true, "return": true, "sizeof": true, "else": true,
+	"do": true, "case": true, "catch": true, "throw": true, "new": true, "delete": true, "using": true,
+	"typedef": true, "static_assert": true, "decltype": true, "goto": true, "synchronized": true, "assert": true,
+	"try": true, "alignof": true, "defined": true,
+}
+
+// parseCLike extracts symbols for C, C++ ("c", "c++") and Java ("java") sources.
+func parseCLike(relPath, content, lang string) ([]Symbol, []Relation) {
+	p := &clikeParser{relPath: relPath, lang: lang, lines: strings.Split(content, "\n"), pkg: -1}
+	p.code = stripCLikeComments(p.lines)
+	skipUntil := -1
+	inMacro := false
+	for i := range p.lines {
+		raw := strings.TrimSpace(p.lines[i])
+		if lang != "java" && (inMacro || strings.HasPrefix(raw, "#")) {
+			if !inMacro {
+				if m := cDefineRe.FindStringSubmatch(raw); m != nil {
+					p.add(i, i, m[1], "macro", raw, 0.75, false, false)
+				}
+			}
+			inMacro = strings.HasSuffix(raw, "\\")
+			continue
+		}
+		if i > skipUntil && p.atMemberLevel() {
+			if last := p.detect(i); last > i {
+				skipUntil = last
+			}
+		}
+		p.scanBraces(i)
+	}
+	// Unclosed scopes run to the end of the file
+	for len(p.scopes) > 0 {
+		p.pop(len(p.lines) - 1)
+	}
+	return p.finish()
+}
+
+// atMemberLevel reports whether declarations can start at the current position:
+// at file scope or directly inside a namespace, class, or similar container.
+func (p *clikeParser) atMemberLevel() bool {
+	if len(p.scopes) == 0 {
+		return p.depth == 0
+	}
+	top := p.scopes[len(p.scopes)-1]
+	return top.opened && top.container && p.depth == top.bodyDepth
+}
+
+// statement joins code from line i until the first '{' or ';' (at most 8 lines) and
+// returns the text before it, the terminator, and the last line consumed.
+func (p *clikeParser) statement(i int) (head string, term byte, last int) {
+	var b strings.Builder
+	for j := i; j < len(p.code) && j < i+8; j++ {
+		line := p.code[j]
+		if k := strings.IndexAny(line, "{;"); k >= 0 {
+			b.WriteString(line[:k])
+			return strings.Join(strings.Fields(b.String()), " "), line[k], j
+		}
+		b.WriteString(line)
+		b.WriteByte(' ')
+	}
+	return strings.Join(strings.Fields(b.String()), " "), 0, i
+}
+
+// detect recognizes a declaration starting at line i and returns the last line of
+// its header, or -1.
+func (p *clikeParser) detect(i int) int {
+	// Blank lines, labels such as "public:", and annotation-only lines do not start a
+	// declaration; the following line does
+	code := strings.TrimSpace(p.code[i])
+	if code == "" || clikeLabel.MatchString(code) || (p.lang == "java" && strings.TrimSpace(javaAnnotation.ReplaceAllString(code, "")) == "") {
+		return -1
+	}
+	head, term, last := p.statement(i)
+	if head == "" || term == 0 {
+		return -1
+	}
+	if p.lang == "java" {
+		return p.detectJava(i, head, term, last)
+	}
+	return p.detectC(i, head, term, last)
+}
+
+func (p *clikeParser) detectJava(i int, head string, term byte, last int) int {
+	head = strings.TrimSpace(javaAnnotation.ReplaceAllString(head, ""))
+	if head == "" {
+		return -1
+	}
+	sig := strings.TrimSpace(p.lines[i])
+	if m := javaPackageRe.FindStringSubmatch(head); m != nil && term == ';' {
+		p.pkg = p.add(i, len(p.lines)-1, m[1], "package", sig, 0.9, false, false)
+		return last
+	}
+	if m := javaTypeRe.FindStringSubmatch(head); m != nil && term == '{' {
+		kind := m[1]
+		if kind == "@interface" {
+			kind = "annotation"
+		}
+		p.add(i, last, m[2], kind, head, 0.85, true, true)
+		return last
+	}
+	if m := javaConstRe.FindStringSubmatch(head); m != nil {
+		p.add(i, last, m[1], "const", sig, 0.7, false, false)
+		return last
+	}
+	if strings.Contains(head, "=") || !strings.Contains(head, ")") {
+		return -1
+	}
+	enclosing := p.enclosingName()
+	if m := javaCtorRe.FindStringSubmatch(head); m != nil && m[1] == enclosing && term == '{' {
+		p.add(i, last, m[1], "constructor", head, 0.85, true, false)
+		return last
+	}
+	if m := javaMethodRe.FindStringSubmatch(head); m != nil {
+		words := strings.Fields(m[1])
+		if len(words) == 0 || clikeKeywords[words[len(words)-1]] || clikeKeywords[m[2]] {
+			return -1
+		}
+		p.add(i, last, m[2], "func", head, 0.8, term == '{', false)
+		return last
+	}
+	return -1
+}
+
+func (p *clikeParser) detectC(i int, head string, term byte, last int) int {
+	head = strings.TrimSpace(cTemplateRe.ReplaceAllString(head, ""))
+	head = strings.TrimSpace(strings.TrimPrefix(head, "export "))
+	if head == "" {
+		return -1
+	}
+	// extern "C" { ... } and similar blocks are transparent
+	if strings.HasPrefix(head, "extern \"") && term == '{' {
+		return -1
+	}
+	if m := cNamespaceRe.FindStringSubmatch(head); m != nil && term == '{' {
+		p.add(i, last, m[1], "namespace", head, 0.9, true, true)
+		return last
+	}
+	if m := cTypeRe.FindStringSubmatch(head); m != nil && term == '{' {
+		kind := strings.Fields(m[2])[0]
+		if m[4] == "" && m[1] == "" {
+			return -1 // anonymous struct/union member
+		}
+		idx := p.add(i, last, m[4], kind, head, 0.85, true, kind != "enum")
+		if m[1] != "" {
+			top := &p.scopes[len(p.scopes)-1]
+			top.typedef = true
+			if m[4] == "" {
+				p.syms[idx].Kind = "typedef"
+			}
+		}
+		return last
+	}
+	if term == ';' {
+		if m := cFuncPtrDef.FindStringSubmatch(head); m != nil {
+			p.add(i, last, m[1], "typedef", head, 0.75, false, false)
+			return last
+		}
+		if m := cTypedefRe.FindStringSubmatch(head); m != nil {
+			p.add(i, last, m[1], "typedef", head, 0.75, false, false)
+			return last
+		}
+		if m := cUsingRe.FindStringSubmatch(head); m != nil {
+			p.add(i, last, m[1], "typedef", head, 0.75, false, false)
+			return last
+		}
+	}
+	m := cFuncRe.FindStringSubmatch(head)
+	if m == nil || !strings.Contains(head, ")") {
+		return -1
+	}
+	prefix, qualified := strings.TrimSpace(m[1]), m[2]
+	if strings.Contains(prefix, "=") || strings.ContainsAny(prefix, "(),") {
+		return -1
+	}
+	if words := strings.Fields(prefix); len(words) > 0 && clikeKeywords[words[0]] {
+		return -1
+	}
+	name := qualified
+	if k := strings.LastIndex(qualified, "::"); k >= 0 {
+		name = qualified[k+2:]
+	}
+	if clikeKeywords[name] {
+		return -1
+	}
+	// Without a return type only constructors and destructors qualify
+	if prefix == "" && !strings.Contains(qualified, "::") && name != p.enclosingName() && !strings.HasPrefix(name, "~") {
+		return -1
+	}
+	kind := "func"
+	if term == ';' {
+		kind = "prototype"
+	}
+	idx := p.add(i, last, name, kind, head, 0.8, term == '{', false)
+	// Out-of-line member definitions belong to their class when it is in this file
+	if p.parent[idx] == -1 && strings.Contains(qualified, "::") {
+		owner := strings.TrimSuffix(qualified, "::"+name)
+		if k := strings.LastIndex(owner, "::"); k >= 0 {
+			owner = owner[k+2:]
+		}
+		for j := range p.syms[:idx] {
+			if p.syms[j].Name == owner && isTypeKind(p.syms[j].Kind) {
+				p.parent[idx] = j
+				break
+			}
+		}
+	}
+	return last
+}
+
+// add records a symbol at line start and, when it has a body, opens its scope.
+func (p *clikeParser) add(start, end int, name, kind, sig string, confidence float64, body, container bool) int {
+	idx := len(p.syms)
+	p.syms = append(p.syms, Symbol{
+		FilePath:   p.relPath,
+		LineStart:  start + 1,
+		ColStart:   1,
+		LineEnd:    end + 1,
+		ColEnd:     len(p.lines[end]),
+		Lang:       p.lang,
+		Name:       name,
+		Kind:       kind,
+		Signature:  sig,
+		DocExcerpt: gatherDocAbove(p.lines, start),
+		Confidence: confidence,
+	})
+	p.parent = append(p.parent, p.container())
+	if body {
+		p.scopes = append(p.scopes, clikeScope{sym: idx, name: name, bodyDepth: p.depth + 1, container: container})
+	}
+	return idx
+}
+
+// container returns the innermost enclosing symbol, falling back to the Java package.
+func (p *clikeParser) container() int {
+	for k := len(p.scopes) - 1; k >= 0; k-- {
+		if p.scopes[k].sym >= 0 {
+			return p.scopes[k].sym
+		}
+	}
+	return p.pkg
+}
+
+func (p *clikeParser) enclosingName() string {
+	if c := p.container(); c >= 0 && isTypeKind(p.syms[c].Kind) {
+		return p.syms[c].Name
+	}
+	return ""
+}
+
+// scanBraces updates the depth for line i, opening and closing scopes.
+func (p *clikeParser) scanBraces(i int) {
+	line := p.code[i]
+	for k := 0; k < len(line); k++ {
+		switch line[k] {
+		case '{':
+			memberLevel := p.atMemberLevel()
+			p.depth++
+			if n := len(p.scopes); n > 0 && !p.scopes[n-1].opened && p.depth == p.scopes[n-1].bodyDepth {
+				p.scopes[n-1].opened = true
+			} else if memberLevel {
+				p.scopes = append(p.scopes, clikeScope{sym: -1, bodyDepth: p.depth, opened: true, container: true})
+			}
+		case '}':
+			p.depth--
+			for n := len(p.scopes); n > 0 && p.scopes[n-1].opened && p.depth < p.scopes[n-1].bodyDepth; n = len(p.scopes) {
+				if p.scopes[n-1].typedef {
+					if m := cTypedefTail.FindStringSubmatch(line[k+1:]); m != nil {
+						s := &p.syms[p.scopes[n-1].sym]
+						if s.Name == "" {
+							s.Name = m[1]
+						} else {
+							// typedef struct tag { ... } Alias; also defines Alias
+							p.add(i, i, m[1], "typedef", strings.TrimSpace(p.lines[i]), 0.75, false, false)
+							p.parent[len(p.parent)-1] = p.parent[p.scopes[n-1].sym]
+						}
+					}
+				}
+				p.pop(i)
+			}
+			if p.depth < 0 {
+				p.depth = 0
+			}
+		}
+	}
+}
+
+func (p *clikeParser) pop(line int) {
+	top := p.scopes[len(p.scopes)-1]
+	p.scopes = p.scopes[:len(p.scopes)-1]
+	if top.sym >= 0 {
+		p.syms[top.sym].LineEnd = line + 1
+		p.syms[top.sym].ColEnd = len(p.lines[line])
+	}
+}
+
+// finish assigns ids and containers and samples same-file references.
+func (p *clikeParser) finish() ([]Symbol, []Relation) {
+	var out []Symbol
+	var rels []Relation
+	for i := range p.syms {
+		if p.syms[i].Name == "" {
+			continue
+		}
+		p.syms[i].SID = makeSID(p.relPath, p.syms[i].LineStart, p.syms[i].Kind, p.syms[i].Name)
+	}
+	for i, s := range p.syms {
+		if s.Name == "" {
+			continue
+		}
+		if c := p.parent[i]; c >= 0 {
+			s.ContainerSID = p.syms[c].SID
+		}
+		out = append(out, s)
+		if s.Kind == "package" || s.Kind == "namespace" {
+			continue
+		}
+		for _, r := range sampleRefs(p.lines, s.Name, p.relPath) {
+			rels = append(rels, Relation{FromSID: s.SID, ToSID: s.SID, Kind: r.Kind, FilePath: p.relPath, LineStart: r.LineStart, LineEnd: r.LineEnd})
+		}
+	}
+	return out, rels
+}
+
+func isTypeKind(kind string) bool {
+	switch kind {
+	case "class", "struct", "union", "interface", "enum", "record", "annotation":
+		return true
+	}
+	return false
+}
+
+// stripCLikeComments blanks comments and the contents of string and character
+// literals so braces and keywords inside them are ignored. Line count is preserved.
+func stripCLikeComments(lines []string) []string {
+	out := make([]string, len(lines))
+	inBlock := false
+	for i, line := range lines {
+		var b strings.Builder
+		for j := 0; j < len(line); j++ {
+			c := line[j]
+			if inBlock {
+				if c == '*' && j+1 < len(line) && line[j+1] == '/' {
+					inBlock = false
+					j++
+				}
+				continue
+			}
+			switch {
+			case c == '/' && j+1 < len(line) && line[j+1] == '/':
+				j = len(line)
+			case c == '/' && j+1 < len(line) && line[j+1] == '*':
+				inBlock = true
+				j++
+			case c == '"' || c == '\'':
+				b.WriteByte(c)
+				for j++; j < len(line) && line[j] != c; j++ {
+					if line[j] == '\\' {
+						j++
+					}
+				}
+				b.WriteByte(c)
+			default:
+				b.WriteByte(c)
+			}
+		}
+		out[i] = b.String()
+	}
+	return out
+}
@@ -0,0 +1,236 @@
+package symbols
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeFiles(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for rel, content := range files {
+		abs := filepath.Join(root, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(abs), 0o755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+		if err := os.WriteFile(abs, []byte(content), 0o644); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+}
+
+func findSymbol(t *testing.T, syms []Symbol, name, kind string) Symbol {
+	t.Helper()
+	for _, sy := range syms {
+		if sy.Name == name && sy.Kind == kind {
+			return sy
+		}
+	}
+	t.Fatalf("no %s %q in %+v", kind, name, syms)
+	return Symbol{}
+}
+
+func TestParseCLike_CSymbolsIgnoreCallsAndComments(t *testing.T) {
+	src := `#include "list.h"
+#define LIST_MAX 64
+
+/* int not_a_func(void) { */
+typedef struct node {
+    int value;
+    struct node *next;
+} node_t;
+
+static int helper(int x)
+{
+    if (x > 0) {
+        return helper(x - 1);
+    }
+    printf("}{");
+    return 0;
+}
+
+int list_len(const node_t *head) {
+    return helper(head->value);
+}
+`
+	syms, _ := parseCLike("src/list.c", src, "c")
+	findSymbol(t, syms, "LIST_MAX", "macro")
+	findSymbol(t, syms, "node", "struct")
+	findSymbol(t, syms, "node_t", "typedef")
+	h := findSymbol(t, syms, "helper", "func")
+	if h.LineStart != 10 || h.LineEnd != 17 {
+		t.Fatalf("helper span = %d-%d, want 10-17", h.LineStart, h.LineEnd)
+	}
+	findSymbol(t, syms, "list_len", "func")
+	for _, sy := range syms {
+		if sy.Name == "not_a_func" || sy.Name == "printf" || sy.Name == "if" {
+			t.Fatalf("unexpected symbol %+v", sy)
+		}
+	}
+}
+
+func TestSymbols_CHeaderImplementationLinkage(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"include/shape.hpp": `#pragma once
+namespace geo {
+class Shape {
+public:
+    virtual double area() const = 0;
+};
+
+class Circle : public Shape {
+public:
+    explicit Circle(double r);
+    double area() const;
+private:
+    double r_;
+};
+}
+`,
+		"src/shape.cpp": `#include "shape.hpp"
+
+namespace geo {
+Circle::Circle(double r) : r_(r) {}
+
+double Circle::area() const {
+    return 3.14159 * r_ * r_;
+}
+}
+`,
+	})
+	svc, err := NewService(root)
+	if err != nil {
+		t.Fatalf("service: %v", err)
+	}
+	ctx := context.Background()
+	if err := svc.IndexAll(ctx); err != nil {
+		t.Fatalf("index: %v", err)
+	}
+
+	cards, err := svc.Search(ctx, "area", "prototype", "", "include/", 10)
+	if err != nil {
+		t.Fatalf("search: %v", err)
+	}
+	var circleArea string
+	for _, c := range cards {
+		if c.Span[0] == 11 {
+			circleArea = c.SID
+		}
+	}
+	if circleArea == "" {
+		t.Fatalf("Circle::area prototype not found in %+v", cards)
+	}
+	impls, _ := svc.Refs(ctx, circleArea, "implementation")
+	if len(impls) != 1 || impls[0].File != "src/shape.cpp" || impls[0].LineStart != 6 {
+		t.Fatalf("expected implementation in src/shape.cpp:6, got %+v", impls)
+	}
+
+	// Shape::area is pure virtual and must not pick up Circle's definition
+	for _, c := range cards {
+		if c.Span[0] == 5 {
+			if refs, _ := svc.Refs(ctx, c.SID, "implementation"); len(refs) != 0 {
+				t.Fatalf("Shape::area linked to %+v", refs)
+			}
+		}
+	}
+
+	var shape string
+	classes, _ := svc.Search(ctx, "Shape", "class", "", "", 10)
+	for _, c := range classes {
+		if c.Name == "Shape" {
+			shape = c.SID
+		}
+	}
+	if shape == "" {
+		t.Fatalf("Shape class not found in %+v", classes)
+	}
+	subs, _ := svc.Refs(ctx, shape, "subclass")
+	if len(subs) != 1 || subs[0].LineStart != 8 {
+		t.Fatalf("expected Circle as subclass, got %+v", subs)
+	}
+}
+
+func TestSymbols_JavaPackageHierarchy(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"src/main/java/com/acme/shop/Repository.java": `package com.acme.shop;
+
+public interface Repository<T> {
+    T find(long id);
+}
+`,
+		"src/main/java/com/acme/shop/orders/OrderRepository.java": `package com.acme.shop.orders;
+
+import com.acme.shop.Repository;
+import java.util.List;
+
+/** Stores orders. */
+public class OrderRepository implements Repository<Order> {
+    public static final int PAGE_SIZE = 50;
+
+    public OrderRepository() {
+        super();
+    }
+
+    @Override
+    public Order find(long id) {
+        if (id < 0) {
+            throw new IllegalArgumentException("id");
+        }
+        return load(id);
+    }
+
+    private static class Cache {
+        List<Order> entries() { return List.of(); }
+    }
+}
+`,
+	})
+	svc, err := NewService(root)
+	if err != nil {
+		t.Fatalf("service: %v", err)
+	}
+	ctx := context.Background()
+	if err := svc.IndexAll(ctx); err != nil {
+		t.Fatalf("index: %v", err)
+	}
+
+	outline, err := svc.Outline(ctx, "src/main/java/com/acme/shop/orders/OrderRepository.java")
+	if err != nil {
+		t.Fatalf("outline: %v", err)
+	}
+	if len(outline) != 1 || outline[0].Kind != "package" || outline[0].Name != "com.acme.shop.orders" {
+		t.Fatalf("expected package root, got %+v", outline)
+	}
+	cls := outline[0].Children
+	if len(cls) != 1 || cls[0].Name != "OrderRepository" {
+		t.Fatalf("expected class under package, got %+v", cls)
+	}
+	var members []string
+	for _, m := range cls[0].Children {
+		members = append(members, m.Kind+":"+m.Name)
+		if m.Name == "Cache" && (len(m.Children) != 1 || m.Children[0].Name != "entries") {
+			t.Fatalf("expected entries() under Cache, got %+v", m.Children)
+		}
+	}
+	want := []string{"const:PAGE_SIZE", "constructor:OrderRepository", "func:find", "class:Cache"}
+	if len(members) != len(want) {
+		t.Fatalf("members = %v, want %v", members, want)
+	}
+	for i := range want {
+		if members[i] != want[i] {
+			t.Fatalf("members = %v, want %v", members, want)
+		}
+	}
+
+	repos, _ := svc.Search(ctx, "Repository", "interface", "java", "", 10)
+	if len(repos) != 1 {
+		t.Fatalf("expected one Repository interface, got %+v", repos)
+	}
+	impls, _ := svc.Refs(ctx, repos[0].SID, "implementation")
+	if len(impls) != 1 || impls[0].File != "src/main/java/com/acme/shop/orders/OrderRepository.java" {
+		t.Fatalf("expected OrderRepository to implement Repository, got %+v", impls)
+	}
+}
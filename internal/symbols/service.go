@@ -5,7 +5,6 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
-	"io/fs"
 	"os"
 	"path/filepath"
 	"regexp"
@@ -94,6 +93,8 @@ type Service struct {
 	refs          map[string][]RefSite // sid -> refs
 	lastIndex     time.Time
 	reporter      ProgressReporter
+	exclusionState
+	scopeState
 }
 
 // NewService creates a new in-memory symbol service.
@@ -126,31 +127,12 @@ func (s *Service) IndexAll(ctx context.Context) error {
 	s.symbols = make(map[string]Symbol)
 	s.byFile = make(map[string][]string)
 	s.refs = make(map[string][]RefSite)
-	// First pass: collect candidate files
-	var files []string
-	_ = filepath.WalkDir(s.workspacePath, func(path string, d fs.DirEntry, err error) error {
-		if err != nil {
-			return nil
-		}
-		rel, _ := filepath.Rel(s.workspacePath, path)
-		if d.IsDir() {
-			// Do not skip the workspace root (rel == ".")
-			if rel != "." {
-				if ignoreDirName(d.Name()) || ignorePath(rel) {
-					return filepath.SkipDir
-				}
-			}
-			return nil
-		}
-		if ignorePath(rel) {
-			return nil
-		}
-		// skip very large files quickly
-		if fi, e := os.Stat(path); e == nil && !fi.IsDir() && fi.Size() <= 1_500_000 {
-			files = append(files, rel)
-		}
-		return nil
-	})
+	// Large binary/media directories are measured first and skipped
+	profile := s.resetScope(s.workspacePath)
+	defer s.finishScope()
+	report := s.indexExclusions(s.workspacePath, profile)
+	// First pass: collect candidate files (sparse profiles only cover part of the tree)
+	files := s.scopedFiles(s.workspacePath, ".", report, 0)
 	// Report start
 	if s.reporter != nil {
 		s.reporter.IndexStart(len(files))
@@ -191,7 +173,7 @@ func (s *Service) indexFileUnlocked(ctx context.Context, relPath string) error {
 	if info.IsDir() {
 		return nil
 	}
-	if info.Size() > 1_500_000 {
+	if info.Size() > maxIndexFileBytes {
 		return nil
 	}
 	data, err := os.ReadFile(abs)
@@ -204,6 +186,7 @@ func (s *Service) indexFileUnlocked(ctx context.Context, relPath string) error {
 	}
 	lang := detectLanguage(abs)
 	syms, rels := parseFile(relPath, string(data), lang)
+	links := linkRelations(s.workspacePath, relPath, string(data), lang, syms)
 	// Remove old
 	if old, ok := s.byFile[relPath]; ok {
 		for _, sid := range old {
@@ -211,6 +194,17 @@ func (s *Service) indexFileUnlocked(ctx context.Context, relPath string) error {
 			delete(s.refs, sid)
 		}
 	}
+	// Cross-file sites recorded from this file are replaced by the new links
+	for _, r := range links {
+		kept := s.refs[r.ToSID][:0]
+		for _, site := range s.refs[r.ToSID] {
+			if site.File != r.FilePath || site.Kind != r.Kind {
+				kept = append(kept, site)
+			}
+		}
+		s.refs[r.ToSID] = kept
+	}
+	rels = append(rels, links...)
 	// Insert
 	var ids []string
 	for _, sym := range syms {
@@ -224,9 +218,35 @@ func (s *Service) indexFileUnlocked(ctx context.Context, relPath string) error {
 	}
 	s.fileVersion[relPath] = version
 	s.lastIndex = time.Now()
+	s.observe(relPath, info.Size())
 	return nil
 }
 
+// Expand indexes the subtree around relPath when the sparse profile does not cover
+// it yet. It returns the expanded directory, or "" when nothing was indexed.
+func (s *Service) Expand(ctx context.Context, relPath string) (string, error) {
+	dir := s.expansionRoot(s.workspacePath, relPath)
+	if dir == "" {
+		return "", nil
+	}
+	files := s.scopedFiles(s.workspacePath, dir, s.Exclusions(), maxExpandFiles)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, f := range files {
+		if err := ctx.Err(); err != nil {
+			return dir, err
+		}
+		_ = s.indexFileUnlocked(ctx, f)
+	}
+	return dir, nil
+}
+
+// Stats reports the size of the index and where its files come from.
+func (s *Service) Stats(ctx context.Context) IndexStats {
+	n, _ := s.Count(ctx)
+	return s.stats(n)
+}
+
 // Search returns top matching symbol cards.
 func (s *Service) Search(ctx context.Context, q, kind, lang, pathPrefix string, limit int) ([]SymbolCard, error) {
 	if limit <= 0 || limit > 100 {
@@ -347,26 +367,34 @@ func (s *Service) Outline(ctx context.Context, relPath string) ([]OutlineNode, e
 	}
 	lang := detectLanguage(abs)
 	syms, _ := parseFile(relPath, string(data), lang)
-	idToNode := make(map[string]*OutlineNode)
-	var roots []*OutlineNode
+	return buildOutline(syms), nil
+}
+
+// buildOutline nests symbols under their containers at any depth (package > class >
+// method in Java, namespace > class > method in C++).
+func buildOutline(syms []Symbol) []OutlineNode {
+	known := make(map[string]bool, len(syms))
 	for _, sy := range syms {
-		n := &OutlineNode{Name: sy.Name, Kind: sy.Kind, Span: [2]int{sy.LineStart, sy.LineEnd}}
-		idToNode[sy.SID] = n
+		known[sy.SID] = true
 	}
+	children := make(map[string][]Symbol)
+	var roots []Symbol
 	for _, sy := range syms {
-		n := idToNode[sy.SID]
-		if sy.ContainerSID == "" || idToNode[sy.ContainerSID] == nil {
-			roots = append(roots, n)
+		if sy.ContainerSID == "" || !known[sy.ContainerSID] || sy.ContainerSID == sy.SID {
+			roots = append(roots, sy)
 			continue
 		}
-		parent := idToNode[sy.ContainerSID]
-		parent.Children = append(parent.Children, *n)
+		children[sy.ContainerSID] = append(children[sy.ContainerSID], sy)
 	}
-	var out []OutlineNode
-	for _, r := range roots {
-		out = append(out, *r)
+	var build func(list []Symbol) []OutlineNode
+	build = func(list []Symbol) []OutlineNode {
+		var out []OutlineNode
+		for _, sy := range list {
+			out = append(out, OutlineNode{Name: sy.Name, Kind: sy.Kind, Span: [2]int{sy.LineStart, sy.LineEnd}, Children: build(children[sy.SID])})
+		}
+		return out
 	}
-	return out, nil
+	return build(roots)
 }
 
 // Workspace returns the absolute workspace path backing this service.
@@ -424,7 +452,9 @@ func detectLanguage(path string) string {
 		return "ruby"
 	case ".php":
 		return "php"
-	case ".c", ".h", ".cpp", ".hpp", ".cc":
+	case ".c", ".h":
+		return "c"
+	case ".cpp", ".hpp", ".cc", ".cxx", ".hh", ".hxx", ".ipp":
 		return "c++"
 	default:
 		return "text"
@@ -461,14 +491,14 @@ func sliceWithLineNumbers(lines []string, start, end int) string {
 // ================= Heuristic Parsing =================
 
 var (
-	goFuncRe     = regexp.MustCompile(`^\s*func\s+(\([^)]*\)\s*)?(?P<name>[A-Za-z_][A-Za-z0-9_]*)\s*\(`)
-	tsFuncRe     = regexp.MustCompile(`^\s*(export\s+)?(async\s+)?function\s+(?P<name>[A-Za-z_][A-Za-z0-9_]*)\s*\(`)
-	tsConstRe    = regexp.MustCompile(`^\s*(export\s+)?(const|let|var)\s+(?P<name>[A-Za-z_][A-Za-z0-9_]*)\s*=\s*(async\s*)?\(?[A-Za-z0-9_,\s]*\)?\s*=>`)
-	jsClassRe    = regexp.MustCompile(`^\s*(export\s+)?class\s+(?P<name>[A-Za-z_][A-Za-z0-9_]*)`)
-	pyDefRe      = regexp.MustCompile(`^\s*def\s+(?P<name>[A-Za-z_][A-Za-z0-9_]*)\s*\(`)
-	pyClassRe    = regexp.MustCompile(`^\s*class\s+(?P<name>[A-Za-z_][A-Za-z0-9_]*)\s*\(`)
-	javaClassRe  = regexp.MustCompile(`^\s*(public|private|protected)?\s*(abstract\s+|final\s+)?class\s+(?P<name>[A-Za-z_][A-Za-z0-9_]*)`)
-	javaMethodRe = regexp.MustCompile(`^\s*(public|private|protected)?[\w\<\>\[\]\s]+\s+(?P<name>[A-Za-z_][A-Za-z0-9_]*)\s*\(`)
+	goFuncRe  = regexp.MustCompile(`^\s*func\s+(\([^)]*\)\s*)?(?P<name>[A-Za-z_][A-Za-z0-9_]*)\s*\(`)
+	goIfaceRe = regexp.MustCompile(`^\s*type\s+(?P<name>[A-Za-z_][A-Za-z0-9_]*)(\[[^\]]*\])?\s+interface\s*\{`)
+	tsIfaceRe = regexp.MustCompile(`^\s*(export\s+)?(default\s+)?(declare\s+)?interface\s+(?P<name>[A-Za-z_$][A-Za-z0-9_$]*)`)
+	tsFuncRe  = regexp.MustCompile(`^\s*(export\s+)?(async\s+)?function\s+(?P<name>[A-Za-z_][A-Za-z0-9_]*)\s*\(`)
+	tsConstRe = regexp.MustCompile(`^\s*(export\s+)?(const|let|var)\s+(?P<name>[A-Za-z_][A-Za-z0-9_]*)\s*=\s*(async\s*)?\(?[A-Za-z0-9_,\s]*\)?\s*=>`)
+	jsClassRe = regexp.MustCompile(`^\s*(export\s+)?(default\s+)?(abstract\s+)?class\s+(?P<name>[A-Za-z_][A-Za-z0-9_]*)`)
+	pyDefRe   = regexp.MustCompile(`^\s*def\s+(?P<name>[A-Za-z_][A-Za-z0-9_]*)\s*\(`)
+	pyClassRe = regexp.MustCompile(`^\s*class\s+(?P<name>[A-Za-z_][A-Za-z0-9_]*)\s*\(`)
 )
 
 // reserved for future container/outline improvements
@@ -486,6 +516,10 @@ var (
 
 // parseFile extracts symbols and relations from a file's contents.
 func parseFile(relPath, content, lang string) ([]Symbol, []Relation) {
+	switch lang {
+	case "c", "c++", "java":
+		return parseCLike(relPath, content, lang)
+	}
 	lines := strings.Split(content, "\n")
 	var out []Symbol
 	var rels []Relation
@@ -505,6 +539,12 @@ func parseFile(relPath, content, lang string) ([]Symbol, []Relation) {
 				sig = strings.TrimSpace(line)
 				confidence = 0.9
 			}
+			if name == "" && goIfaceRe.MatchString(line) {
+				name = extractNamedGroup(goIfaceRe, line, "name")
+				kind = "interface"
+				sig = strings.TrimSpace(line)
+				confidence = 0.9
+			}
 		case "typescript", "javascript":
 			if tsFuncRe.MatchString(line) {
 				name = extractNamedGroup(tsFuncRe, line, "name")
@@ -518,6 +558,12 @@ func parseFile(relPath, content, lang string) ([]Symbol, []Relation) {
 				sig = strings.TrimSpace(line)
 				confidence = 0.7
 			}
+			if name == "" && tsIfaceRe.MatchString(line) {
+				name = extractNamedGroup(tsIfaceRe, line, "name")
+				kind = "interface"
+				sig = strings.TrimSpace(line)
+				confidence = 0.85
+			}
 			if name == "" && jsClassRe.MatchString(line) {
 				name = extractNamedGroup(jsClassRe, line, "name")
 				kind = "class"
@@ -537,19 +583,6 @@ func parseFile(relPath, content, lang string) ([]Symbol, []Relation) {
 				sig = strings.TrimSpace(line)
 				confidence = 0.8
 			}
-		case "java":
-			if javaClassRe.MatchString(line) {
-				name = extractNamedGroup(javaClassRe, line, "name")
-				kind = "class"
-				sig = strings.TrimSpace(line)
-				confidence = 0.8
-			}
-			if name == "" && javaMethodRe.MatchString(line) {
-				name = extractNamedGroup(javaMethodRe, line, "name")
-				kind = "func"
-				sig = strings.TrimSpace(line)
-				confidence = 0.7
-			}
 		default:
 			if strings.Contains(line, "(") && strings.Contains(strings.ToLower(line), "function") {
 				kind = "func"
@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"io/fs"
 	"os"
+	"path"
 	"path/filepath"
 	"sort"
 	"strings"
@@ -24,6 +25,20 @@ type SQLiteService struct {
 	db            *sql.DB
 	watcher       *fsnotify.Watcher
 	debounceIndex func(func())
+	// onRename is notified when a watched Rename is followed by a matching Create
+	onRename func(from, to string)
+	exclusionState
+	scopeState
+	watchState
+}
+
+// renamePairWindow is how soon after a Rename event the matching Create must arrive.
+const renamePairWindow = time.Second
+
+// OnRename registers a callback for workspace-relative file moves detected by the
+// watcher. It must be set before StartIndexing.
+func (s *SQLiteService) OnRename(fn func(from, to string)) {
+	s.onRename = fn
 }
 
 // NewSQLiteService creates the DB and initializes schema.
@@ -33,12 +48,10 @@ func NewSQLiteService(workspacePath string) (*SQLiteService, error) {
 		return nil, fmt.Errorf("abs: %w", err)
 	}
 	// ~/.loom/projects/<id>/symbols.db
-	id := hashPath(ws)
-	home, err := os.UserHomeDir()
+	dir, err := projectDataDir(ws)
 	if err != nil {
 		return nil, err
 	}
-	dir := filepath.Join(home, ".loom", "projects", id)
 	if err := os.MkdirAll(dir, 0o755); err != nil {
 		return nil, err
 	}
@@ -100,26 +113,22 @@ func initSQLiteSchema(db *sql.DB) error {
 	return nil
 }
 
-// Close closes watcher and DB.
+// Close stops watching and closes the DB.
 func (s *SQLiteService) Close() error {
-	if s.watcher != nil {
-		_ = s.watcher.Close()
-	}
+	s.StopWatching()
 	if s.db != nil {
 		return s.db.Close()
 	}
 	return nil
 }
 
-// StartIndexing performs initial full index and starts fsnotify watchers for incremental updates.
+// StartIndexing performs initial full index and starts watching for incremental
+// updates, with fsnotify or by polling (see SetWatchOptions).
 func (s *SQLiteService) StartIndexing(ctx context.Context) error {
 	if err := s.IndexAll(ctx); err != nil {
 		return err
 	}
-	if err := s.addWatchesRecursive(s.workspacePath); err != nil {
-		return err
-	}
-	go s.watchLoop(ctx)
+	s.startWatching(ctx)
 	return nil
 }
 
@@ -139,7 +148,8 @@ func (s *SQLiteService) addWatchesRecursive(root string) error {
 			return err
 		}
 		if d.IsDir() {
-			if ignoreDirName(d.Name()) {
+			rel, _ := filepath.Rel(s.workspacePath, path)
+			if ignoreDirName(d.Name()) || s.excluded(rel) || (rel != "." && !s.descend(rel)) {
 				return filepath.SkipDir
 			}
 			return s.watcher.Add(path)
@@ -149,6 +159,9 @@ func (s *SQLiteService) addWatchesRecursive(root string) error {
 }
 
 func (s *SQLiteService) watchLoop(ctx context.Context) {
+	// fsnotify reports a move as Rename(old) then Create(new); pair them up
+	var pendingFrom string
+	var pendingAt time.Time
 	for {
 		select {
 		case <-ctx.Done():
@@ -159,9 +172,18 @@ func (s *SQLiteService) watchLoop(ctx context.Context) {
 			}
 			if ev.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Rename) != 0 {
 				rel, _ := filepath.Rel(s.workspacePath, ev.Name)
-				if rel == "." || ignorePath(rel) {
+				if rel == "." || ignorePath(rel) || s.excluded(rel) || !s.covered(rel) {
 					continue
 				}
+				rel = filepath.ToSlash(rel)
+				if ev.Op&fsnotify.Rename != 0 {
+					pendingFrom, pendingAt = rel, time.Now()
+				} else if ev.Op&fsnotify.Create != 0 && pendingFrom != "" {
+					if s.onRename != nil && time.Since(pendingAt) < renamePairWindow && isLikelyMove(pendingFrom, rel) {
+						s.onRename(pendingFrom, rel)
+					}
+					pendingFrom = ""
+				}
 				s.debounceIndex(func() { _ = s.IndexFile(ctx, rel) })
 			}
 		case <-s.watcher.Errors:
@@ -170,34 +192,76 @@ func (s *SQLiteService) watchLoop(ctx context.Context) {
 	}
 }
 
-// IndexAll walks workspace, deletes per-file rows and reinserts.
+// IndexAll walks workspace, deletes per-file rows and reinserts. Directories the size
+// analysis excludes are skipped and their previously indexed rows removed. A sparse
+// profile starts from an empty index so rows from an earlier full index do not linger.
 func (s *SQLiteService) IndexAll(ctx context.Context) error {
-	return filepath.WalkDir(s.workspacePath, func(path string, d fs.DirEntry, err error) error {
-		if err != nil {
-			return nil
+	profile := s.resetScope(s.workspacePath)
+	defer s.finishScope()
+	report := s.indexExclusions(s.workspacePath, profile)
+	if profile.Sparse() {
+		if err := s.deletePrefix(ctx, ""); err != nil {
+			return err
 		}
-		rel, _ := filepath.Rel(s.workspacePath, path)
-		if d.IsDir() {
-			// Do not skip the workspace root (rel == ".")
-			if rel != "." {
-				if ignoreDirName(d.Name()) || ignorePath(rel) {
-					return filepath.SkipDir
-				}
-			}
-			return nil
+	}
+	for _, ex := range report.Excluded {
+		if err := s.deletePrefix(ctx, ex.Path+"/"); err != nil {
+			return err
 		}
-		if ignorePath(rel) {
-			return nil
+	}
+	for _, rel := range s.scopedFiles(s.workspacePath, ".", report, 0) {
+		if err := s.IndexFile(ctx, rel); err != nil {
+			return err
 		}
-		return s.IndexFile(ctx, rel)
-	})
+	}
+	return nil
+}
+
+// Expand indexes and watches the subtree around relPath when the sparse profile does
+// not cover it yet. It returns the expanded directory, or "" when nothing was indexed.
+func (s *SQLiteService) Expand(ctx context.Context, relPath string) (string, error) {
+	dir := s.expansionRoot(s.workspacePath, relPath)
+	if dir == "" {
+		return "", nil
+	}
+	for _, f := range s.scopedFiles(s.workspacePath, dir, s.Exclusions(), maxExpandFiles) {
+		if err := s.IndexFile(ctx, f); err != nil {
+			return dir, err
+		}
+	}
+	// Polling picks up expanded directories by itself
+	if s.watcher != nil && !s.polling() {
+		_ = s.addWatchesRecursive(filepath.Join(s.workspacePath, filepath.FromSlash(dir)))
+	}
+	return dir, nil
+}
+
+// Stats reports the size of the index and where its files come from.
+func (s *SQLiteService) Stats(ctx context.Context) IndexStats {
+	n, _ := s.Count(ctx)
+	st := s.stats(n)
+	if w := s.watchStatus(); w.Mode != "" {
+		st.Watch = &w
+	}
+	return st
+}
+
+// deletePrefix removes all rows for files under a workspace-relative prefix.
+func (s *SQLiteService) deletePrefix(ctx context.Context, prefix string) error {
+	like := strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(prefix) + "%"
+	for _, table := range []string{"relations", "symbols", "symbols_fts"} {
+		if _, err := s.db.ExecContext(ctx, `DELETE FROM `+table+` WHERE file_path LIKE ? ESCAPE '\'`, like); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 // IndexFile indexes a single file (relative path).
 func (s *SQLiteService) IndexFile(ctx context.Context, relPath string) error {
 	abs := filepath.Join(s.workspacePath, relPath)
 	info, err := os.Stat(abs)
-	if err != nil || info.IsDir() || info.Size() > 1_500_000 {
+	if err != nil || info.IsDir() || info.Size() > maxIndexFileBytes {
 		return nil
 	}
 	data, err := os.ReadFile(abs)
@@ -207,6 +271,7 @@ func (s *SQLiteService) IndexFile(ctx context.Context, relPath string) error {
 	version := hashBytes(data)
 	lang := detectLanguage(abs)
 	syms, rels := parseFile(relPath, string(data), lang)
+	rels = append(rels, linkRelations(s.workspacePath, relPath, string(data), lang, syms)...)
 	for i := range syms {
 		syms[i].Version = version
 	}
@@ -240,6 +305,7 @@ func (s *SQLiteService) IndexFile(ctx context.Context, relPath string) error {
 	if err := tx.Commit(); err != nil {
 		return err
 	}
+	s.observe(relPath, info.Size())
 	return nil
 }
 
@@ -388,26 +454,7 @@ func (s *SQLiteService) Outline(ctx context.Context, relPath string) ([]OutlineN
 	}
 	lang := detectLanguage(abs)
 	syms, _ := parseFile(relPath, string(data), lang)
-	idToNode := make(map[string]*OutlineNode)
-	var roots []*OutlineNode
-	for _, sy := range syms {
-		n := &OutlineNode{Name: sy.Name, Kind: sy.Kind, Span: [2]int{sy.LineStart, sy.LineEnd}}
-		idToNode[sy.SID] = n
-	}
-	for _, sy := range syms {
-		n := idToNode[sy.SID]
-		if sy.ContainerSID == "" || idToNode[sy.ContainerSID] == nil {
-			roots = append(roots, n)
-		} else {
-			parent := idToNode[sy.ContainerSID]
-			parent.Children = append(parent.Children, *n)
-		}
-	}
-	var out []OutlineNode
-	for _, r := range roots {
-		out = append(out, *r)
-	}
-	return out, nil
+	return buildOutline(syms), nil
 }
 
 // Workspace returns the root path.
@@ -418,3 +465,12 @@ func hashPath(p string) string {
 	sum := sha256.Sum256([]byte(p))
 	return hex.EncodeToString(sum[:])[:16]
 }
+
+// isLikelyMove reports whether a Create following a Rename looks like the same file:
+// either the name is kept (moved between directories) or the directory is kept.
+func isLikelyMove(from, to string) bool {
+	if from == to {
+		return false
+	}
+	return path.Base(from) == path.Base(to) || path.Dir(from) == path.Dir(to)
+}
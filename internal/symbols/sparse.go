@@ -0,0 +1,316 @@
+package symbols
+
+import (
+	"encoding/json"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Index profile modes.
+const (
+	// ProfileFull indexes the whole workspace (minus size-based exclusions).
+	ProfileFull = "full"
+	// ProfileSparse indexes the top-level structure and the included subtrees, and
+	// expands on demand when tools touch other directories. Meant for huge monorepos.
+	ProfileSparse = "sparse"
+)
+
+const (
+	indexProfileFile = "index_profile.json"
+	// defaultTopLevelDepth indexes root files and files directly inside top-level directories
+	defaultTopLevelDepth = 1
+	// maxExpandFiles caps how many files a single on-demand expansion indexes
+	maxExpandFiles = 5000
+)
+
+// IndexProfile selects how much of a workspace the symbol index covers.
+type IndexProfile struct {
+	Mode string `json:"mode"`
+	// Include lists workspace-relative subtrees indexed fully in sparse mode
+	Include []string `json:"include,omitempty"`
+	// TopLevelDepth is how many directory levels below the root are indexed in sparse
+	// mode; 0 means the default of 1
+	TopLevelDepth int `json:"top_level_depth,omitempty"`
+}
+
+// Sparse reports whether p limits indexing to the top level and included subtrees.
+func (p IndexProfile) Sparse() bool { return p.Mode == ProfileSparse }
+
+func (p IndexProfile) depth() int {
+	if p.TopLevelDepth > 0 {
+		return p.TopLevelDepth
+	}
+	return defaultTopLevelDepth
+}
+
+// LoadIndexProfile returns the workspace's index profile, defaulting to full indexing.
+func LoadIndexProfile(workspacePath string) IndexProfile {
+	p := IndexProfile{Mode: ProfileFull}
+	dir, err := projectDataDir(workspacePath)
+	if err != nil {
+		return p
+	}
+	data, err := os.ReadFile(filepath.Join(dir, indexProfileFile))
+	if err != nil || json.Unmarshal(data, &p) != nil {
+		return IndexProfile{Mode: ProfileFull}
+	}
+	if p.Mode != ProfileSparse {
+		p.Mode = ProfileFull
+	}
+	p.Include = normalizeRelPaths(p.Include)
+	return p
+}
+
+// SaveIndexProfile persists the index profile for a workspace.
+func SaveIndexProfile(workspacePath string, p IndexProfile) error {
+	dir, err := projectDataDir(workspacePath)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	if p.Mode != ProfileSparse {
+		p.Mode = ProfileFull
+	}
+	p.Include = normalizeRelPaths(p.Include)
+	data, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, indexProfileFile), data, 0o644)
+}
+
+// DirIndexStat is the indexed share of one top-level directory.
+type DirIndexStat struct {
+	Path  string `json:"path"`
+	Files int    `json:"files"`
+	Bytes int64  `json:"bytes"`
+	// Coverage is "full", "top-level", "included" or "expanded"
+	Coverage string `json:"coverage"`
+}
+
+// IndexStats reports how large the index is and where its files come from, so
+// users can tune the included subtrees.
+type IndexStats struct {
+	Mode     string         `json:"mode"`
+	Files    int            `json:"files"`
+	Bytes    int64          `json:"bytes"`
+	Symbols  int            `json:"symbols"`
+	Dirs     []DirIndexStat `json:"dirs"`
+	Include  []string       `json:"include,omitempty"`
+	Expanded []string       `json:"expanded,omitempty"`
+	// DurationMs is how long the last full index took
+	DurationMs int64     `json:"duration_ms"`
+	IndexedAt  time.Time `json:"indexed_at"`
+	// Watch is how the index notices changed files, once indexing started
+	Watch *WatchStatus `json:"watch,omitempty"`
+}
+
+// scopeState holds a service's index profile, the directories expanded on demand,
+// and the size telemetry of what was indexed.
+type scopeState struct {
+	scopeMu   sync.RWMutex
+	profile   IndexProfile
+	expanded  []string
+	indexed   map[string]int64 // rel path -> bytes
+	startedAt time.Time
+	duration  time.Duration
+}
+
+// resetScope loads the profile and clears expansions and telemetry before a full index.
+func (s *scopeState) resetScope(workspacePath string) IndexProfile {
+	p := LoadIndexProfile(workspacePath)
+	s.scopeMu.Lock()
+	defer s.scopeMu.Unlock()
+	s.profile = p
+	s.expanded = nil
+	s.indexed = map[string]int64{}
+	s.startedAt = time.Now()
+	return p
+}
+
+func (s *scopeState) finishScope() {
+	s.scopeMu.Lock()
+	s.duration = time.Since(s.startedAt)
+	s.scopeMu.Unlock()
+}
+
+// Profile returns the index profile the last full index used.
+func (s *scopeState) Profile() IndexProfile {
+	s.scopeMu.RLock()
+	defer s.scopeMu.RUnlock()
+	return s.profile
+}
+
+// descend reports whether the walk enters directory rel (workspace-relative).
+func (s *scopeState) descend(rel string) bool {
+	s.scopeMu.RLock()
+	defer s.scopeMu.RUnlock()
+	if !s.profile.Sparse() {
+		return true
+	}
+	rel = filepath.ToSlash(rel)
+	return relDepth(rel) <= s.profile.depth() || touchesAny(rel, s.profile.Include) || touchesAny(rel, s.expanded)
+}
+
+// covered reports whether file rel belongs in the index under the current profile.
+func (s *scopeState) covered(rel string) bool {
+	s.scopeMu.RLock()
+	defer s.scopeMu.RUnlock()
+	return s.coverageUnlocked(filepath.ToSlash(rel)) != ""
+}
+
+func (s *scopeState) coverageUnlocked(rel string) string {
+	if !s.profile.Sparse() {
+		return "full"
+	}
+	switch {
+	case relDepth(path.Dir(rel)) <= s.profile.depth():
+		return "top-level"
+	case underAny(rel, s.profile.Include):
+		return "included"
+	case underAny(rel, s.expanded):
+		return "expanded"
+	}
+	return ""
+}
+
+// observe records an indexed file for telemetry.
+func (s *scopeState) observe(rel string, size int64) {
+	s.scopeMu.Lock()
+	defer s.scopeMu.Unlock()
+	if s.indexed == nil {
+		s.indexed = map[string]int64{}
+	}
+	s.indexed[filepath.ToSlash(rel)] = size
+}
+
+// expansionRoot returns the directory to expand for a path a tool touched, or ""
+// when nothing needs indexing. The directory is recorded as expanded.
+func (s *scopeState) expansionRoot(workspacePath, rel string) string {
+	rel = strings.Trim(filepath.ToSlash(filepath.Clean(rel)), "/")
+	if rel == "" || rel == "." || strings.HasPrefix(rel, "../") {
+		return ""
+	}
+	dir := rel
+	if info, err := os.Stat(filepath.Join(workspacePath, rel)); err != nil || !info.IsDir() {
+		dir = path.Dir(rel)
+	}
+	s.scopeMu.Lock()
+	defer s.scopeMu.Unlock()
+	if !s.profile.Sparse() || dir == "." || relDepth(dir) <= s.profile.depth() ||
+		underAny(dir, s.profile.Include) || underAny(dir, s.expanded) {
+		return ""
+	}
+	// A wider expansion replaces the narrower ones it contains
+	kept := s.expanded[:0]
+	for _, e := range s.expanded {
+		if !strings.HasPrefix(e, dir+"/") {
+			kept = append(kept, e)
+		}
+	}
+	s.expanded = append(kept, dir)
+	sort.Strings(s.expanded)
+	return dir
+}
+
+// scopedFiles walks root (workspace-relative, "." for the whole workspace) and returns
+// the indexable files the profile covers, skipping ignored and excluded directories.
+// limit <= 0 means no limit.
+func (s *scopeState) scopedFiles(workspacePath, root string, report ExclusionReport, limit int) []string {
+	var files []string
+	_ = filepath.WalkDir(filepath.Join(workspacePath, root), func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if limit > 0 && len(files) >= limit {
+			return filepath.SkipAll
+		}
+		rel, _ := filepath.Rel(workspacePath, p)
+		if d.IsDir() {
+			// Do not skip the workspace root (rel == ".")
+			if rel != "." && (ignoreDirName(d.Name()) || ignorePath(rel) || report.Skips(rel) || !s.descend(rel)) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if ignorePath(rel) || !s.covered(rel) {
+			return nil
+		}
+		// skip very large files quickly
+		if fi, e := d.Info(); e == nil && fi.Mode().IsRegular() && fi.Size() <= maxIndexFileBytes {
+			files = append(files, rel)
+		}
+		return nil
+	})
+	return files
+}
+
+// stats summarises the indexed files per top-level directory.
+func (s *scopeState) stats(symbols int) IndexStats {
+	s.scopeMu.RLock()
+	defer s.scopeMu.RUnlock()
+	st := IndexStats{
+		Mode:       s.profile.Mode,
+		Symbols:    symbols,
+		Include:    s.profile.Include,
+		Expanded:   append([]string(nil), s.expanded...),
+		DurationMs: s.duration.Milliseconds(),
+		IndexedAt:  s.startedAt,
+	}
+	if st.Mode == "" {
+		st.Mode = ProfileFull
+	}
+	dirs := map[string]*DirIndexStat{}
+	for rel, size := range s.indexed {
+		st.Files++
+		st.Bytes += size
+		top, _, nested := strings.Cut(rel, "/")
+		if !nested {
+			top = "."
+		}
+		d := dirs[top]
+		if d == nil {
+			d = &DirIndexStat{Path: top, Coverage: s.coverageUnlocked(rel)}
+			dirs[top] = d
+		}
+		d.Files++
+		d.Bytes += size
+		// Report the widest coverage among the directory's files
+		if c := s.coverageUnlocked(rel); coverageRank[c] > coverageRank[d.Coverage] {
+			d.Coverage = c
+		}
+	}
+	for _, d := range dirs {
+		st.Dirs = append(st.Dirs, *d)
+	}
+	sort.Slice(st.Dirs, func(i, j int) bool { return st.Dirs[i].Bytes > st.Dirs[j].Bytes })
+	return st
+}
+
+var coverageRank = map[string]int{"": 0, "top-level": 1, "expanded": 2, "included": 3, "full": 4}
+
+// relDepth counts the segments of a slash-separated relative directory ("." is 0).
+func relDepth(rel string) int {
+	if rel == "" || rel == "." {
+		return 0
+	}
+	return strings.Count(rel, "/") + 1
+}
+
+// underAny reports whether rel equals or lies inside one of paths.
+func underAny(rel string, paths []string) bool {
+	for _, p := range paths {
+		if rel == p || strings.HasPrefix(rel, p+"/") {
+			return true
+		}
+	}
+	return false
+}
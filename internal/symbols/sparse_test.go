@@ -0,0 +1,102 @@
+package symbols
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeGo(t *testing.T, root, rel, fn string) {
+	t.Helper()
+	path := filepath.Join(root, rel)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(path, []byte("package x\n\nfunc "+fn+"() {}\n"), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+}
+
+func TestSparseProfile_IndexesTopLevelIncludesAndExpansions(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	root := t.TempDir()
+	writeGo(t, root, "main.go", "Main")
+	writeGo(t, root, "services/doc.go", "ServicesDoc")
+	writeGo(t, root, "services/billing/invoice.go", "Invoice")
+	writeGo(t, root, "services/search/query.go", "Query")
+	writeGo(t, root, "libs/core/core.go", "Core")
+
+	if err := SaveIndexProfile(root, IndexProfile{Mode: ProfileSparse, Include: []string{"libs/core/"}}); err != nil {
+		t.Fatalf("save profile: %v", err)
+	}
+	if p := LoadIndexProfile(root); !p.Sparse() || len(p.Include) != 1 || p.Include[0] != "libs/core" {
+		t.Fatalf("unexpected profile: %+v", p)
+	}
+
+	svc, err := NewService(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	if err := svc.IndexAll(ctx); err != nil {
+		t.Fatal(err)
+	}
+	has := func(name string) bool {
+		cards, _ := svc.Search(ctx, name, "", "", "", 5)
+		for _, c := range cards {
+			if c.Name == name {
+				return true
+			}
+		}
+		return false
+	}
+	for name, want := range map[string]bool{"Main": true, "ServicesDoc": true, "Core": true, "Invoice": false, "Query": false} {
+		if has(name) != want {
+			t.Errorf("%s indexed = %v, want %v", name, !want, want)
+		}
+	}
+
+	// Touching a file in an unindexed directory expands that directory only
+	dir, err := svc.Expand(ctx, "services/billing/invoice.go")
+	if err != nil || dir != "services/billing" {
+		t.Fatalf("Expand = %q, %v", dir, err)
+	}
+	if !has("Invoice") || has("Query") {
+		t.Fatalf("expansion indexed the wrong files")
+	}
+	if dir, _ := svc.Expand(ctx, "services/billing/invoice.go"); dir != "" {
+		t.Fatalf("second expansion should be a no-op, got %q", dir)
+	}
+	if dir, _ := svc.Expand(ctx, "main.go"); dir != "" {
+		t.Fatalf("covered paths must not expand, got %q", dir)
+	}
+
+	stats := svc.Stats(ctx)
+	if stats.Mode != ProfileSparse || stats.Files != 4 || len(stats.Expanded) != 1 {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+	coverage := map[string]string{}
+	for _, d := range stats.Dirs {
+		coverage[d.Path] = d.Coverage
+	}
+	if coverage["."] != "top-level" || coverage["libs"] != "included" || coverage["services"] != "expanded" {
+		t.Fatalf("unexpected coverage: %v", coverage)
+	}
+}
+
+func TestFullProfile_IsDefault(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	root := t.TempDir()
+	writeGo(t, root, "a/b/c/deep.go", "Deep")
+	svc, _ := NewService(root)
+	if err := svc.IndexAll(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if st := svc.Stats(context.Background()); st.Mode != ProfileFull || st.Files != 1 {
+		t.Fatalf("unexpected stats: %+v", st)
+	}
+	if dir, _ := svc.Expand(context.Background(), "a/b/c/deep.go"); dir != "" {
+		t.Fatalf("full profile must not expand, got %q", dir)
+	}
+}
@@ -0,0 +1,264 @@
+package symbols
+
+import (
+	"context"
+	"io/fs"
+	"maps"
+	"path/filepath"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Watch modes of the symbol index.
+const (
+	// WatchAuto polls on network mounts and uses notifications elsewhere.
+	WatchAuto = "auto"
+	// WatchNotify uses filesystem notifications.
+	WatchNotify = "notify"
+	// WatchPoll scans the indexed files for changes at an adaptive interval.
+	WatchPoll = "poll"
+)
+
+const (
+	defaultPollInterval    = 2 * time.Second
+	defaultMaxPollInterval = 30 * time.Second
+)
+
+// WatchOptions selects how the index notices changed files.
+type WatchOptions struct {
+	// Mode is WatchAuto, WatchNotify or WatchPoll; empty means WatchAuto
+	Mode string
+	// Interval is how soon polling looks again after a change
+	Interval time.Duration
+	// MaxInterval is what the polling interval backs off to while nothing changes
+	MaxInterval time.Duration
+}
+
+// WatchStatus reports how the index is kept fresh and why.
+type WatchStatus struct {
+	// Mode is WatchNotify or WatchPoll once indexing started
+	Mode string `json:"mode"`
+	// Filesystem names the workspace's filesystem, e.g. "nfs", when it was detected
+	Filesystem string `json:"filesystem,omitempty"`
+	Reason     string `json:"reason,omitempty"`
+	// IntervalMs is the current polling interval
+	IntervalMs int64 `json:"interval_ms,omitempty"`
+}
+
+// fileStamp identifies a version of a file for polling.
+type fileStamp struct {
+	size int64
+	mod  int64 // nanoseconds since the epoch
+}
+
+// watchState holds a service's watch configuration and what it resolved to.
+type watchState struct {
+	watchMu sync.RWMutex
+	opts    WatchOptions
+	status  WatchStatus
+	// stop is closed to end polling
+	stop chan struct{}
+}
+
+// SetWatchOptions configures how changes are detected. It must be set before
+// StartIndexing.
+func (w *watchState) SetWatchOptions(o WatchOptions) {
+	w.watchMu.Lock()
+	defer w.watchMu.Unlock()
+	w.opts = o
+}
+
+func (w *watchState) watchStatus() WatchStatus {
+	w.watchMu.RLock()
+	defer w.watchMu.RUnlock()
+	return w.status
+}
+
+func (w *watchState) setWatchStatus(st WatchStatus) {
+	w.watchMu.Lock()
+	defer w.watchMu.Unlock()
+	w.status = st
+}
+
+// stopCh returns the channel closed when watching stops.
+func (w *watchState) stopCh() chan struct{} {
+	w.watchMu.Lock()
+	defer w.watchMu.Unlock()
+	if w.stop == nil {
+		w.stop = make(chan struct{})
+	}
+	return w.stop
+}
+
+// StopWatching ends change detection, e.g. when another workspace is opened. The
+// index stays usable but is no longer kept fresh.
+func (s *SQLiteService) StopWatching() {
+	stop := s.stopCh()
+	select {
+	case <-stop:
+	default:
+		close(stop)
+	}
+	if s.watcher != nil {
+		_ = s.watcher.Close()
+	}
+}
+
+// polling reports whether changes are detected by polling instead of notifications.
+func (w *watchState) polling() bool {
+	return w.watchStatus().Mode == WatchPoll
+}
+
+// resolveWatch picks the watch mode for the workspace: the configured one, or for
+// auto, polling on network mounts and WSL Windows drives where notifications are
+// unreliable.
+func (w *watchState) resolveWatch(workspacePath string) (WatchStatus, WatchOptions) {
+	w.watchMu.RLock()
+	o := w.opts
+	w.watchMu.RUnlock()
+	if o.Interval <= 0 {
+		o.Interval = defaultPollInterval
+	}
+	if o.MaxInterval <= 0 {
+		o.MaxInterval = defaultMaxPollInterval
+	}
+	if o.MaxInterval < o.Interval {
+		o.MaxInterval = o.Interval
+	}
+	fsType, network := filesystemType(workspacePath)
+	st := WatchStatus{Mode: o.Mode, Filesystem: fsType}
+	switch o.Mode {
+	case WatchNotify, WatchPoll:
+		st.Reason = "configured"
+	default:
+		st.Mode = WatchNotify
+		if network {
+			st.Mode, st.Reason = WatchPoll, "network filesystem ("+fsType+")"
+		}
+	}
+	return st, o
+}
+
+// startWatching begins detecting changes with notifications or polling. When
+// notifications cannot be set up, for example because the watch limit is reached,
+// it falls back to polling.
+func (s *SQLiteService) startWatching(ctx context.Context) {
+	st, opts := s.resolveWatch(s.workspacePath)
+	if st.Mode == WatchNotify {
+		err := s.addWatchesRecursive(s.workspacePath)
+		if err == nil {
+			s.setWatchStatus(st)
+			go s.watchLoop(ctx)
+			return
+		}
+		st.Mode, st.Reason = WatchPoll, "notifications failed: "+err.Error()
+	}
+	st.IntervalMs = opts.Interval.Milliseconds()
+	s.setWatchStatus(st)
+	// Snapshot before returning so changes made right after indexing are seen
+	go s.pollLoop(ctx, opts, s.pollSnapshot())
+}
+
+// pollLoop rescans the indexed files and reindexes those whose size or modification
+// time changed since the previous scan, starting from prev. The interval doubles
+// while nothing changes, up to opts.MaxInterval, and drops back to opts.Interval
+// after a change.
+func (s *SQLiteService) pollLoop(ctx context.Context, opts WatchOptions, prev map[string]fileStamp) {
+	stop := s.stopCh()
+	interval := opts.Interval
+	timer := time.NewTimer(interval)
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-stop:
+			return
+		case <-timer.C:
+		}
+		next := s.pollSnapshot()
+		changed, moves := diffSnapshots(prev, next)
+		prev = next
+		for _, m := range moves {
+			if s.onRename != nil {
+				s.onRename(m[0], m[1])
+			}
+		}
+		for _, rel := range changed {
+			if ctx.Err() != nil {
+				return
+			}
+			_ = s.IndexFile(ctx, rel)
+		}
+		if len(changed) > 0 {
+			interval = opts.Interval
+		} else {
+			if interval *= 2; interval > opts.MaxInterval {
+				interval = opts.MaxInterval
+			}
+		}
+		s.watchMu.Lock()
+		s.status.IntervalMs = interval.Milliseconds()
+		s.watchMu.Unlock()
+		timer.Reset(interval)
+	}
+}
+
+// pollSnapshot records the size and modification time of every file the index covers.
+func (s *SQLiteService) pollSnapshot() map[string]fileStamp {
+	snap := map[string]fileStamp{}
+	_ = filepath.WalkDir(s.workspacePath, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		rel, _ := filepath.Rel(s.workspacePath, p)
+		if d.IsDir() {
+			if rel != "." && (ignoreDirName(d.Name()) || s.excluded(rel) || !s.descend(rel)) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if ignorePath(rel) || s.excluded(rel) || !s.covered(rel) {
+			return nil
+		}
+		if info, err := d.Info(); err == nil && info.Mode().IsRegular() {
+			snap[filepath.ToSlash(rel)] = fileStamp{size: info.Size(), mod: info.ModTime().UnixNano()}
+		}
+		return nil
+	})
+	return snap
+}
+
+// diffSnapshots returns the files created or modified between two snapshots, and the
+// moves among them: a file that disappeared while one with the same size and
+// modification time appeared next to it or under the same name.
+func diffSnapshots(prev, next map[string]fileStamp) (changed []string, moves [][2]string) {
+	var gone []string
+	for rel := range prev {
+		if _, ok := next[rel]; !ok {
+			gone = append(gone, rel)
+		}
+	}
+	sort.Strings(gone)
+	for _, rel := range slices.Sorted(maps.Keys(next)) {
+		st := next[rel]
+		old, ok := prev[rel]
+		if ok && old == st {
+			continue
+		}
+		changed = append(changed, rel)
+		if ok {
+			continue
+		}
+		for i, from := range gone {
+			if prev[from] == st && isLikelyMove(from, rel) {
+				moves = append(moves, [2]string{from, rel})
+				gone = append(gone[:i], gone[i+1:]...)
+				break
+			}
+		}
+	}
+	return changed, moves
+}
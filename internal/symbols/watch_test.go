@@ -0,0 +1,63 @@
+package symbols
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDiffSnapshots(t *testing.T) {
+	prev := map[string]fileStamp{
+		"a.go":     {size: 10, mod: 1},
+		"old.go":   {size: 20, mod: 2},
+		"gone.go":  {size: 30, mod: 3},
+		"stays.go": {size: 40, mod: 4},
+	}
+	next := map[string]fileStamp{
+		"a.go":        {size: 11, mod: 5},
+		"new.go":      {size: 20, mod: 2},
+		"stays.go":    {size: 40, mod: 4},
+		"pkg/b.go":    {size: 50, mod: 6},
+		"pkg/gone.go": {size: 30, mod: 3},
+	}
+	changed, moves := diffSnapshots(prev, next)
+	if got := strings.Join(changed, ","); got != "a.go,new.go,pkg/b.go,pkg/gone.go" {
+		t.Fatalf("unexpected changes: %s", got)
+	}
+	if len(moves) != 2 || moves[0] != [2]string{"old.go", "new.go"} || moves[1] != [2]string{"gone.go", "pkg/gone.go"} {
+		t.Fatalf("unexpected moves: %v", moves)
+	}
+}
+
+func TestSQLiteService_PollsForChanges(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	root := t.TempDir()
+	writeGo(t, root, "main.go", "Main")
+
+	svc, err := NewSQLiteService(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = svc.Close() }()
+	svc.SetWatchOptions(WatchOptions{Mode: WatchPoll, Interval: 10 * time.Millisecond, MaxInterval: 40 * time.Millisecond})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := svc.StartIndexing(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if w := svc.Stats(ctx).Watch; w == nil || w.Mode != WatchPoll || w.Reason != "configured" {
+		t.Fatalf("unexpected watch status: %+v", w)
+	}
+
+	writeGo(t, root, "pkg/added.go", "Added")
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		cards, _ := svc.Search(ctx, "Added", "", "", "", 5)
+		if len(cards) > 0 {
+			return
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	t.Fatal("polling did not index the new file")
+}
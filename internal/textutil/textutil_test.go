@@ -0,0 +1,91 @@
+package textutil
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestWidth(t *testing.T) {
+	tests := map[string]int{
+		"abc":        3,
+		"日本語":        6,
+		"한국어":        6,
+		"カタカナ":       8,
+		"ｆｕｌｌ":       8,
+		"é":         1, // e + combining acute
+		"mixed 中文 x": 12,
+	}
+	for s, want := range tests {
+		if got := Width(s); got != want {
+			t.Errorf("Width(%q) = %d, want %d", s, got, want)
+		}
+	}
+}
+
+func TestTruncateWidth(t *testing.T) {
+	tests := []struct {
+		s     string
+		width int
+		want  string
+	}{
+		{"hello", 10, "hello"},
+		{"hello world", 6, "hello…"},
+		{"日本語のテキスト", 7, "日本語…"},
+		// A wide character that would straddle the limit is dropped, not split
+		{"日本語のテキスト", 6, "日本…"},
+		{"abc", 0, ""},
+	}
+	for _, tt := range tests {
+		got := TruncateWidth(tt.s, tt.width)
+		if got != tt.want {
+			t.Errorf("TruncateWidth(%q, %d) = %q, want %q", tt.s, tt.width, got, tt.want)
+		}
+		if Width(got) > tt.width {
+			t.Errorf("TruncateWidth(%q, %d) is %d columns wide", tt.s, tt.width, Width(got))
+		}
+	}
+}
+
+func TestClipBytes(t *testing.T) {
+	s := "ab日本"
+	for n := 0; n <= len(s); n++ {
+		got := ClipBytes(s, n)
+		if !utf8.ValidString(got) || len(got) > n || !strings.HasPrefix(s, got) {
+			t.Errorf("ClipBytes(%q, %d) = %q", s, n, got)
+		}
+	}
+	if got := ClipBytes(s, 4); got != "ab" {
+		t.Errorf("ClipBytes(%q, 4) = %q, want %q", s, got, "ab")
+	}
+}
+
+func TestEstimateTokens(t *testing.T) {
+	code := strings.Repeat("func main() {}\n", 40)
+	if got := EstimateTokens(code); got != len(code)/4 {
+		t.Errorf("EstimateTokens(code) = %d, want %d", got, len(code)/4)
+	}
+	// 300 CJK characters are 900 bytes; a bytes/4 rule would say 225 tokens
+	cjk := strings.Repeat("漢字かな", 75)
+	if got := EstimateTokens(cjk); got < 300 {
+		t.Errorf("EstimateTokens(cjk) = %d, want at least one token per character", got)
+	}
+	if got := EstimateTokens(""); got != 0 {
+		t.Errorf("EstimateTokens(\"\") = %d", got)
+	}
+}
+
+func TestPrefixSuffixTokens(t *testing.T) {
+	s := "abcd" + strings.Repeat("漢", 10) + "wxyz"
+	head := PrefixTokens(s, 3)
+	if !utf8.ValidString(head) || EstimateTokens(head) > 3 || !strings.HasPrefix(s, head) {
+		t.Errorf("PrefixTokens = %q", head)
+	}
+	tail := SuffixTokens(s, 3)
+	if !utf8.ValidString(tail) || EstimateTokens(tail) > 3 || !strings.HasSuffix(s, tail) {
+		t.Errorf("SuffixTokens = %q", tail)
+	}
+	if PrefixTokens(s, 100) != s || SuffixTokens(s, 100) != s {
+		t.Error("expected the whole string within a large budget")
+	}
+}
@@ -0,0 +1,60 @@
+package textutil
+
+import "unicode/utf8"
+
+// Token costs in quarter tokens. Tokenizers pack about four bytes of ASCII (code,
+// English) into a token, but spend a token or more on each CJK character and roughly
+// half a token on other non-ASCII letters.
+const (
+	asciiCost = 1
+	otherCost = 2
+	wideCost  = 5
+)
+
+func runeCost(r rune) int {
+	switch {
+	case r < utf8.RuneSelf:
+		return asciiCost
+	case IsWide(r):
+		return wideCost
+	}
+	return otherCost
+}
+
+// EstimateTokens approximates how many model tokens s takes. It errs on the high side
+// for CJK text, where byte- or character-based rules of thumb are far off.
+func EstimateTokens(s string) int {
+	units := 0
+	for _, r := range s {
+		units += runeCost(r)
+	}
+	return (units + 3) / 4
+}
+
+// PrefixTokens returns the longest prefix of s estimated at no more than n tokens,
+// ending on a rune boundary.
+func PrefixTokens(s string, n int) string {
+	budget, units := n*4, 0
+	for i, r := range s {
+		units += runeCost(r)
+		if units > budget {
+			return s[:i]
+		}
+	}
+	return s
+}
+
+// SuffixTokens returns the longest suffix of s estimated at no more than n tokens,
+// starting on a rune boundary.
+func SuffixTokens(s string, n int) string {
+	budget, units := n*4, 0
+	for i := len(s); i > 0; {
+		r, size := utf8.DecodeLastRuneInString(s[:i])
+		units += runeCost(r)
+		if units > budget {
+			return s[i:]
+		}
+		i -= size
+	}
+	return s
+}
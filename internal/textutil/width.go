@@ -0,0 +1,103 @@
+// Package textutil measures and cuts text by characters, display columns and estimated
+// model tokens rather than bytes, so multibyte and CJK content is never split mid-rune
+// and is budgeted at its real cost.
+package textutil
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// wideRanges are the East Asian Wide and Fullwidth blocks, which take two terminal columns.
+var wideRanges = []struct{ lo, hi rune }{
+	{0x1100, 0x115F},   // Hangul Jamo initials
+	{0x2E80, 0x303E},   // CJK radicals, Kangxi, CJK symbols and punctuation
+	{0x3041, 0x33FF},   // Hiragana, Katakana, Bopomofo, Hangul compatibility Jamo, CJK compatibility
+	{0x3400, 0x4DBF},   // CJK Extension A
+	{0x4E00, 0x9FFF},   // CJK Unified Ideographs
+	{0xA000, 0xA4CF},   // Yi
+	{0xA960, 0xA97F},   // Hangul Jamo Extended-A
+	{0xAC00, 0xD7A3},   // Hangul syllables
+	{0xF900, 0xFAFF},   // CJK compatibility ideographs
+	{0xFE30, 0xFE4F},   // CJK compatibility forms
+	{0xFF00, 0xFF60},   // Fullwidth forms
+	{0xFFE0, 0xFFE6},   // Fullwidth signs
+	{0x1F300, 0x1F64F}, // Pictographs and emoticons
+	{0x1F900, 0x1F9FF}, // Supplemental symbols and pictographs
+	{0x20000, 0x3FFFD}, // CJK Extensions B and beyond
+}
+
+// IsWide reports whether r is a wide (two-column) character, as used by CJK scripts.
+func IsWide(r rune) bool {
+	if r < 0x1100 {
+		return false
+	}
+	for _, w := range wideRanges {
+		if r < w.lo {
+			return false
+		}
+		if r <= w.hi {
+			return true
+		}
+	}
+	return false
+}
+
+// RuneWidth returns the number of terminal columns r occupies: 0 for combining marks
+// and control characters, 2 for wide characters, 1 otherwise.
+func RuneWidth(r rune) int {
+	switch {
+	case r == 0 || unicode.Is(unicode.Mn, r) || unicode.Is(unicode.Me, r) || unicode.IsControl(r) || r == 0x200B:
+		return 0
+	case IsWide(r):
+		return 2
+	}
+	return 1
+}
+
+// Width returns the number of terminal columns s occupies.
+func Width(s string) int {
+	n := 0
+	for _, r := range s {
+		n += RuneWidth(r)
+	}
+	return n
+}
+
+// TruncateWidth cuts s to at most width columns, ending it with "…" when anything was
+// dropped. Wide characters are never split.
+func TruncateWidth(s string, width int) string {
+	if Width(s) <= width {
+		return s
+	}
+	if width <= 0 {
+		return ""
+	}
+	var b strings.Builder
+	used := 0
+	for _, r := range s {
+		w := RuneWidth(r)
+		// Keep one column for the ellipsis
+		if used+w > width-1 {
+			break
+		}
+		b.WriteRune(r)
+		used += w
+	}
+	return b.String() + "…"
+}
+
+// ClipBytes cuts s to at most n bytes without splitting a rune.
+func ClipBytes(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	if n <= 0 {
+		return ""
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
@@ -0,0 +1,159 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/loom/loom/internal/editor"
+	"github.com/loom/loom/internal/memory"
+)
+
+// annotationKinds are the accepted values of AnnotateCodeArgs.Kind.
+var annotationKinds = map[string]bool{"note": true, "explanation": true, "issue": true, "suggestion": true}
+
+// maxAnnotationSnippetLines caps how much annotated text is stored with an annotation.
+const maxAnnotationSnippetLines = 20
+
+// AnnotateCodeArgs represents the arguments for the annotate_code tool.
+type AnnotateCodeArgs struct {
+	Path      string `json:"path"`
+	StartLine int    `json:"start_line"`
+	EndLine   int    `json:"end_line,omitempty"`
+	Comment   string `json:"comment"`
+	Kind      string `json:"kind,omitempty"`
+}
+
+// AnnotateCodeResult confirms a stored annotation.
+type AnnotateCodeResult struct {
+	ID        string `json:"id"`
+	Path      string `json:"path"`
+	StartLine int    `json:"start_line"`
+	EndLine   int    `json:"end_line"`
+	Kind      string `json:"kind"`
+	Message   string `json:"message"`
+}
+
+// AnnotationSink stores an annotation for the current session and returns it with its ID.
+type AnnotationSink func(memory.Annotation) (memory.Annotation, error)
+
+type annotationSinkKey struct{}
+
+// WithAnnotationSink lets annotate_code calls made with ctx store their annotations.
+func WithAnnotationSink(ctx context.Context, sink AnnotationSink) context.Context {
+	return context.WithValue(ctx, annotationSinkKey{}, sink)
+}
+
+// RegisterAnnotateCode registers the annotate_code tool, which attaches comments to
+// line ranges without modifying the file.
+func RegisterAnnotateCode(registry *Registry, workspacePath string) error {
+	return registry.Register(Definition{
+		Name:        "annotate_code",
+		Description: "Attach a comment to a line range of a workspace file, shown to the user as an editor gutter annotation. Use it for explanations and review findings that should not change the code. Line numbers are 1-indexed, as returned by read_file.",
+		Safe:        true, // Annotations never modify files
+		JSONSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"path": map[string]interface{}{
+					"type":        "string",
+					"description": "Path to the file, relative to the workspace root",
+				},
+				"start_line": map[string]interface{}{
+					"type":        "integer",
+					"description": "First annotated line (1-indexed)",
+				},
+				"end_line": map[string]interface{}{
+					"type":        "integer",
+					"description": "Last annotated line (inclusive, defaults to start_line)",
+				},
+				"comment": map[string]interface{}{
+					"type":        "string",
+					"description": "The annotation text (markdown)",
+				},
+				"kind": map[string]interface{}{
+					"type":        "string",
+					"enum":        []string{"note", "explanation", "issue", "suggestion"},
+					"description": "Kind of annotation (default note)",
+				},
+			},
+			"required": []string{"path", "start_line", "comment"},
+		},
+		Handler: func(ctx context.Context, raw json.RawMessage) (interface{}, error) {
+			var args AnnotateCodeArgs
+			if err := json.Unmarshal(raw, &args); err != nil {
+				return nil, fmt.Errorf("failed to parse arguments: %w", err)
+			}
+			return annotateCode(ctx, workspacePath, args)
+		},
+	})
+}
+
+func annotateCode(ctx context.Context, workspacePath string, args AnnotateCodeArgs) (*AnnotateCodeResult, error) {
+	sink, _ := ctx.Value(annotationSinkKey{}).(AnnotationSink)
+	if sink == nil {
+		return nil, errors.New("annotations need an active conversation")
+	}
+	ann, err := prepareAnnotation(workspacePath, args)
+	if err != nil {
+		return nil, err
+	}
+	ann, err = sink(ann)
+	if err != nil {
+		return nil, fmt.Errorf("failed to store annotation: %w", err)
+	}
+	return &AnnotateCodeResult{
+		ID:        ann.ID,
+		Path:      ann.Path,
+		StartLine: ann.StartLine,
+		EndLine:   ann.EndLine,
+		Kind:      ann.Kind,
+		Message:   fmt.Sprintf("Annotated %s lines %d-%d", ann.Path, ann.StartLine, ann.EndLine),
+	}, nil
+}
+
+// prepareAnnotation validates args against the file and captures the annotated text.
+func prepareAnnotation(workspacePath string, args AnnotateCodeArgs) (memory.Annotation, error) {
+	comment := strings.TrimSpace(args.Comment)
+	if comment == "" {
+		return memory.Annotation{}, errors.New("comment is required")
+	}
+	kind := strings.ToLower(strings.TrimSpace(args.Kind))
+	if kind == "" {
+		kind = "note"
+	}
+	if !annotationKinds[kind] {
+		return memory.Annotation{}, fmt.Errorf("unknown kind %q (use note, explanation, issue or suggestion)", args.Kind)
+	}
+	abs, err := validatePath(workspacePath, args.Path)
+	if err != nil {
+		return memory.Annotation{}, err
+	}
+	content, _, err := editor.ReadText(abs)
+	if err != nil {
+		return memory.Annotation{}, fmt.Errorf("cannot annotate %s: %w", args.Path, err)
+	}
+	lines := strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+	end := args.EndLine
+	if end == 0 {
+		end = args.StartLine
+	}
+	if args.StartLine < 1 || end < args.StartLine || end > len(lines) {
+		return memory.Annotation{}, fmt.Errorf("invalid line range %d-%d (file has %d lines)", args.StartLine, end, len(lines))
+	}
+	snippet := lines[args.StartLine-1 : min(end, args.StartLine-1+maxAnnotationSnippetLines)]
+	rel, err := filepath.Rel(filepath.Clean(workspacePath), abs)
+	if err != nil {
+		return memory.Annotation{}, err
+	}
+	return memory.Annotation{
+		Path:      filepath.ToSlash(rel),
+		StartLine: args.StartLine,
+		EndLine:   end,
+		Kind:      kind,
+		Comment:   comment,
+		Snippet:   strings.Join(snippet, "\n"),
+	}, nil
+}
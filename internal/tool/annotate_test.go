@@ -0,0 +1,65 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/loom/loom/internal/memory"
+)
+
+func TestAnnotateCode_StoresThroughSink(t *testing.T) {
+	workspace := t.TempDir()
+	mustWriteFile(t, workspace, "pkg/calc.go", "package pkg\n\nfunc Add(a, b int) int {\n\treturn a - b\n}\n")
+
+	reg := NewRegistry()
+	if err := RegisterAnnotateCode(reg, workspace); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	var stored []memory.Annotation
+	ctx := WithAnnotationSink(context.Background(), func(a memory.Annotation) (memory.Annotation, error) {
+		a.ID = "ann_1"
+		stored = append(stored, a)
+		return a, nil
+	})
+
+	raw, _ := json.Marshal(AnnotateCodeArgs{Path: "./pkg/calc.go", StartLine: 3, EndLine: 4, Comment: "Subtracts instead of adding", Kind: "issue"})
+	res, err := reg.Invoke(ctx, "annotate_code", raw)
+	if err != nil {
+		t.Fatalf("invoke: %v", err)
+	}
+	if r := res.(*AnnotateCodeResult); r.ID != "ann_1" || r.Path != "pkg/calc.go" {
+		t.Fatalf("unexpected result: %+v", r)
+	}
+	if len(stored) != 1 || stored[0].Snippet != "func Add(a, b int) int {\n\treturn a - b" || stored[0].Kind != "issue" {
+		t.Fatalf("unexpected stored annotation: %+v", stored)
+	}
+
+	// The file is never modified
+	if got := readFileContent(t, workspace, "pkg/calc.go"); !strings.Contains(got, "return a - b") {
+		t.Fatalf("file changed: %q", got)
+	}
+}
+
+func TestAnnotateCode_Validation(t *testing.T) {
+	workspace := t.TempDir()
+	mustWriteFile(t, workspace, "a.txt", "one\ntwo\n")
+	ctx := WithAnnotationSink(context.Background(), func(a memory.Annotation) (memory.Annotation, error) { return a, nil })
+
+	cases := map[string]AnnotateCodeArgs{
+		"line past end": {Path: "a.txt", StartLine: 3, Comment: "x"},
+		"reversed":      {Path: "a.txt", StartLine: 2, EndLine: 1, Comment: "x"},
+		"no comment":    {Path: "a.txt", StartLine: 1},
+		"bad kind":      {Path: "a.txt", StartLine: 1, Comment: "x", Kind: "praise"},
+		"outside":       {Path: "../a.txt", StartLine: 1, Comment: "x"},
+	}
+	for name, args := range cases {
+		if _, err := annotateCode(ctx, workspace, args); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+	if _, err := annotateCode(context.Background(), workspace, AnnotateCodeArgs{Path: "a.txt", StartLine: 1, Comment: "x"}); err == nil {
+		t.Error("expected an error without a session")
+	}
+}
@@ -0,0 +1,126 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const maxAskUserOptions = 6
+
+// AskUserArgs describes a clarifying question for the user.
+type AskUserArgs struct {
+	Question string `json:"question"`
+	// Options are optional multiple-choice answers
+	Options []string `json:"options,omitempty"`
+	// AllowOther accepts a free-text answer alongside the options. Questions without
+	// options always take free text.
+	AllowOther bool `json:"allow_other,omitempty"`
+	// Context explains why the answer matters, shown under the question
+	Context string `json:"context,omitempty"`
+}
+
+// AskUserAnswer is the structured tool result returned to the model.
+type AskUserAnswer struct {
+	Question string `json:"question"`
+	Answer   string `json:"answer"`
+	// SelectedIndex is the chosen option, or -1 for a free-text answer
+	SelectedIndex  int    `json:"selected_index"`
+	SelectedOption string `json:"selected_option,omitempty"`
+	// Skipped is set when the user dismissed the question without answering
+	Skipped bool `json:"skipped,omitempty"`
+}
+
+// FreeText reports whether the question accepts a typed answer.
+func (a AskUserArgs) FreeText() bool {
+	return len(a.Options) == 0 || a.AllowOther
+}
+
+// Answer builds the structured answer from the user's reply: an option index, or -1
+// with free text. Replies the question does not accept count as skipped.
+func (a AskUserArgs) Answer(selectedIndex int, text string) AskUserAnswer {
+	ans := AskUserAnswer{Question: a.Question, SelectedIndex: -1}
+	if selectedIndex >= 0 && selectedIndex < len(a.Options) {
+		ans.SelectedIndex = selectedIndex
+		ans.SelectedOption = a.Options[selectedIndex]
+		ans.Answer = ans.SelectedOption
+		return ans
+	}
+	if text = strings.TrimSpace(text); text != "" && a.FreeText() {
+		ans.Answer = text
+		return ans
+	}
+	ans.Skipped = true
+	return ans
+}
+
+func (a AskUserArgs) validate() error {
+	if strings.TrimSpace(a.Question) == "" {
+		return errors.New("question is required")
+	}
+	if len(a.Options) == 1 || len(a.Options) > maxAskUserOptions {
+		return fmt.Errorf("options must be empty or contain 2-%d items", maxAskUserOptions)
+	}
+	seen := map[string]bool{}
+	for i, option := range a.Options {
+		key := strings.ToLower(strings.TrimSpace(option))
+		if key == "" {
+			return fmt.Errorf("option %d is empty", i+1)
+		}
+		if seen[key] {
+			return fmt.Errorf("option %d duplicates an earlier option", i+1)
+		}
+		seen[key] = true
+	}
+	return nil
+}
+
+// RegisterAskUser registers the ask_user tool, which pauses the run until the user
+// answers a clarifying question.
+func RegisterAskUser(registry *Registry) error {
+	return registry.Register(Definition{
+		Name:        "ask_user",
+		Description: "Ask the user a clarifying question and wait for the answer. Use it instead of guessing when requirements are ambiguous; offer options when the likely answers are known.",
+		Safe:        false, // Requires user interaction
+		JSONSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"question": map[string]interface{}{
+					"type":        "string",
+					"description": "One specific question",
+				},
+				"options": map[string]interface{}{
+					"type":        "array",
+					"items":       map[string]interface{}{"type": "string"},
+					"description": fmt.Sprintf("Optional multiple-choice answers (2-%d)", maxAskUserOptions),
+					"maxItems":    maxAskUserOptions,
+				},
+				"allow_other": map[string]interface{}{
+					"type":        "boolean",
+					"description": "Also accept a free-text answer when options are given",
+				},
+				"context": map[string]interface{}{
+					"type":        "string",
+					"description": "Why the answer matters (one sentence)",
+				},
+			},
+			"required": []string{"question"},
+		},
+		Handler: func(ctx context.Context, raw json.RawMessage) (interface{}, error) {
+			var args AskUserArgs
+			if err := json.Unmarshal(raw, &args); err != nil {
+				return nil, fmt.Errorf("failed to parse arguments: %w", err)
+			}
+			if err := args.validate(); err != nil {
+				return nil, err
+			}
+			// The engine pauses on this unsafe result and collects the answer
+			return &ExecutionResult{
+				Content: fmt.Sprintf("Waiting for the user to answer: %s", args.Question),
+				Safe:    false,
+			}, nil
+		},
+	})
+}
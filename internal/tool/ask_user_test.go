@@ -0,0 +1,58 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestAskUser_ValidatesArguments(t *testing.T) {
+	reg := NewRegistry()
+	if err := RegisterAskUser(reg); err != nil {
+		t.Fatal(err)
+	}
+	for raw, wantErr := range map[string]string{
+		`{"question":"Which database?"}`:                          "",
+		`{"question":"Which database?","options":["pg","mysql"]}`: "",
+		`{"question":" "}`:                                        "question is required",
+		`{"question":"Which?","options":["pg"]}`:                  "2-6 items",
+		`{"question":"Which?","options":["pg","PG "]}`:            "duplicates",
+		`{"question":"Which?","options":["pg",""]}`:               "option 2 is empty",
+	} {
+		res, err := reg.Invoke(context.Background(), "ask_user", json.RawMessage(raw))
+		if wantErr == "" {
+			er, ok := res.(*ExecutionResult)
+			if err != nil || !ok || er.Safe {
+				t.Errorf("%s: expected a pending question, got %#v (err=%v)", raw, res, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), wantErr) {
+			t.Errorf("%s: expected error containing %q, got %v", raw, wantErr, err)
+		}
+	}
+}
+
+func TestAskUserArgs_Answer(t *testing.T) {
+	choice := AskUserArgs{Question: "Which database?", Options: []string{"Postgres", "MySQL"}}
+	if a := choice.Answer(1, ""); a.SelectedIndex != 1 || a.SelectedOption != "MySQL" || a.Answer != "MySQL" || a.Skipped {
+		t.Errorf("unexpected option answer %#v", a)
+	}
+	if a := choice.Answer(-1, "SQLite"); !a.Skipped || a.Answer != "" {
+		t.Errorf("free text must be rejected without allow_other: %#v", a)
+	}
+
+	choice.AllowOther = true
+	if a := choice.Answer(-1, "  SQLite "); a.Skipped || a.Answer != "SQLite" || a.SelectedIndex != -1 {
+		t.Errorf("unexpected free-text answer %#v", a)
+	}
+
+	open := AskUserArgs{Question: "What should the endpoint be called?"}
+	if a := open.Answer(5, "/v2/users"); a.Answer != "/v2/users" || a.SelectedIndex != -1 {
+		t.Errorf("unexpected open answer %#v", a)
+	}
+	if a := open.Answer(-1, ""); !a.Skipped || a.Question != open.Question {
+		t.Errorf("empty reply must skip: %#v", a)
+	}
+}
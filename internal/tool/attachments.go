@@ -0,0 +1,228 @@
+package tool
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/loom/loom/internal/textutil"
+)
+
+// AttachmentPrefix marks read_file paths that refer to files the user attached from
+// outside the workspace. Attachments are readable but never editable.
+const AttachmentPrefix = "@attachments/"
+
+// MaxAttachmentBytes caps the size of a single copied attachment.
+const MaxAttachmentBytes = 25 << 20
+
+// AttachmentInfo describes a file in a session's attachments directory.
+type AttachmentInfo struct {
+	Name   string `json:"name"`
+	Ref    string `json:"ref"` // path to use with read_file, e.g. "@attachments/app.log"
+	Size   int64  `json:"size"`
+	Binary bool   `json:"binary"`
+}
+
+type attachmentsDirKey struct{}
+
+// WithAttachmentsDir makes a session's attachments readable by tools invoked with ctx.
+func WithAttachmentsDir(ctx context.Context, dir string) context.Context {
+	return context.WithValue(ctx, attachmentsDirKey{}, dir)
+}
+
+// resolveAttachmentPath maps an "@attachments/<name>" path to a file in the session's
+// attachments directory. ok is false for ordinary workspace paths.
+func resolveAttachmentPath(ctx context.Context, p string) (abs string, ok bool, err error) {
+	if !strings.HasPrefix(filepath.ToSlash(p), AttachmentPrefix) {
+		return "", false, nil
+	}
+	dir, _ := ctx.Value(attachmentsDirKey{}).(string)
+	if dir == "" {
+		return "", true, errors.New("no attachments in this session")
+	}
+	name := strings.TrimPrefix(filepath.ToSlash(p), AttachmentPrefix)
+	abs = filepath.Join(dir, filepath.Clean("/"+name))
+	if !strings.HasPrefix(abs, filepath.Clean(dir)+string(os.PathSeparator)) {
+		return "", true, errors.New("invalid attachment path")
+	}
+	return abs, true, nil
+}
+
+// CopyAttachment copies src into dir under a unique name and returns its description.
+func CopyAttachment(dir, src string) (AttachmentInfo, error) {
+	info, err := os.Stat(src)
+	if err != nil {
+		return AttachmentInfo{}, err
+	}
+	if info.IsDir() {
+		return AttachmentInfo{}, fmt.Errorf("%s is a directory", src)
+	}
+	if info.Size() > MaxAttachmentBytes {
+		return AttachmentInfo{}, fmt.Errorf("%s is %d MB; attachments are limited to %d MB", filepath.Base(src), info.Size()>>20, MaxAttachmentBytes>>20)
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return AttachmentInfo{}, err
+	}
+	name := uniqueAttachmentName(dir, filepath.Base(src))
+
+	in, err := os.Open(src)
+	if err != nil {
+		return AttachmentInfo{}, err
+	}
+	defer func() { _ = in.Close() }()
+	out, err := os.OpenFile(filepath.Join(dir, name), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o444)
+	if err != nil {
+		return AttachmentInfo{}, err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		_ = out.Close()
+		return AttachmentInfo{}, err
+	}
+	if err := out.Close(); err != nil {
+		return AttachmentInfo{}, err
+	}
+	return describeAttachment(dir, name)
+}
+
+// WriteAttachment stores data in dir as an attachment named after name, e.g. content
+// fetched from an MCP server, and returns its description.
+func WriteAttachment(dir, name string, data []byte) (AttachmentInfo, error) {
+	if len(data) > MaxAttachmentBytes {
+		return AttachmentInfo{}, fmt.Errorf("%s is %d MB; attachments are limited to %d MB", name, len(data)>>20, MaxAttachmentBytes>>20)
+	}
+	base := filepath.Base(filepath.Clean("/" + filepath.ToSlash(name)))
+	if base == "/" || base == "." {
+		base = "attachment"
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return AttachmentInfo{}, err
+	}
+	name = uniqueAttachmentName(dir, base)
+	f, err := os.OpenFile(filepath.Join(dir, name), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o444)
+	if err != nil {
+		return AttachmentInfo{}, err
+	}
+	if _, err := f.Write(data); err != nil {
+		_ = f.Close()
+		return AttachmentInfo{}, err
+	}
+	if err := f.Close(); err != nil {
+		return AttachmentInfo{}, err
+	}
+	return describeAttachment(dir, name)
+}
+
+// uniqueAttachmentName keeps base when it is free in dir and adds a numeric suffix
+// otherwise.
+func uniqueAttachmentName(dir, base string) string {
+	ext := filepath.Ext(base)
+	name := base
+	for i := 2; ; i++ {
+		if _, err := os.Stat(filepath.Join(dir, name)); os.IsNotExist(err) {
+			return name
+		}
+		name = fmt.Sprintf("%s-%d%s", strings.TrimSuffix(base, ext), i, ext)
+	}
+}
+
+// ListAttachments returns the files in a session's attachments directory.
+func ListAttachments(dir string) ([]AttachmentInfo, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var out []AttachmentInfo
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		if a, err := describeAttachment(dir, e.Name()); err == nil {
+			out = append(out, a)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out, nil
+}
+
+func describeAttachment(dir, name string) (AttachmentInfo, error) {
+	p := filepath.Join(dir, name)
+	info, err := os.Stat(p)
+	if err != nil {
+		return AttachmentInfo{}, err
+	}
+	head := make([]byte, 8000)
+	n := 0
+	if f, err := os.Open(p); err == nil {
+		n, _ = io.ReadFull(f, head)
+		_ = f.Close()
+	}
+	return AttachmentInfo{Name: name, Ref: AttachmentPrefix + name, Size: info.Size(), Binary: looksBinary(head[:n])}, nil
+}
+
+// AttachmentExcerpt renders an attachment for the prompt within budget estimated tokens. Small
+// text files are included whole; larger ones show the head and tail (the end of a log
+// is usually what matters) and point at read_file for the rest. Binary files are
+// described only.
+func AttachmentExcerpt(dir, name string, budget int) (string, error) {
+	a, err := describeAttachment(dir, name)
+	if err != nil {
+		return "", err
+	}
+	header := fmt.Sprintf("- %s (%s, %s)", a.Name, a.Ref, humanSize(a.Size))
+	if a.Binary {
+		return header + "\n  Binary file; its contents are not included.", nil
+	}
+	data, err := os.ReadFile(filepath.Join(dir, name))
+	if err != nil {
+		return "", err
+	}
+	text := string(data)
+	if textutil.EstimateTokens(text) <= budget {
+		return header + "\n" + indent(text), nil
+	}
+	half := budget / 2
+	head, tail := textutil.PrefixTokens(text, half), textutil.SuffixTokens(text, half)
+	omitted := strings.Count(text[len(head):len(text)-len(tail)], "\n")
+	return fmt.Sprintf("%s\n%s\n    … %d lines omitted; use read_file on %s with offset/limit to see them …\n%s",
+		header, indent(head), omitted, a.Ref, indent(tail)), nil
+}
+
+// looksBinary treats content with NUL bytes or invalid UTF-8 as binary.
+func looksBinary(head []byte) bool {
+	if bytes.IndexByte(head, 0) >= 0 {
+		return true
+	}
+	// A multi-byte rune may be cut off at the end of the sample
+	for i := 0; i < 3 && len(head) > 0 && !utf8.Valid(head); i++ {
+		head = head[:len(head)-1]
+	}
+	return !utf8.Valid(head)
+}
+
+func indent(s string) string {
+	lines := strings.Split(strings.TrimRight(s, "\n"), "\n")
+	for i, l := range lines {
+		lines[i] = "    " + l
+	}
+	return strings.Join(lines, "\n")
+}
+
+func humanSize(n int64) string {
+	switch {
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(n)/(1<<10))
+	}
+	return fmt.Sprintf("%d B", n)
+}
@@ -0,0 +1,112 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCopyAttachment_DedupesNames(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "app.log")
+	if err := os.WriteFile(src, []byte("boot\n"), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	dir := filepath.Join(t.TempDir(), "attachments")
+
+	first, err := CopyAttachment(dir, src)
+	if err != nil {
+		t.Fatalf("copy: %v", err)
+	}
+	second, err := CopyAttachment(dir, src)
+	if err != nil {
+		t.Fatalf("copy again: %v", err)
+	}
+	if first.Ref != "@attachments/app.log" || second.Ref != "@attachments/app-2.log" {
+		t.Fatalf("unexpected refs: %q, %q", first.Ref, second.Ref)
+	}
+	list, _ := ListAttachments(dir)
+	if len(list) != 2 {
+		t.Fatalf("expected 2 attachments, got %d", len(list))
+	}
+}
+
+func TestWriteAttachment_KeepsNamesInsideDir(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "attachments")
+	a, err := WriteAttachment(dir, "../../etc/issue.md", []byte("# Issue\n"))
+	if err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if a.Ref != "@attachments/issue.md" || a.Binary {
+		t.Fatalf("unexpected attachment %+v", a)
+	}
+	again, _ := WriteAttachment(dir, "issue.md", []byte("other"))
+	if again.Ref != "@attachments/issue-2.md" {
+		t.Fatalf("expected a deduped name, got %q", again.Ref)
+	}
+}
+
+func TestReadFile_Attachment(t *testing.T) {
+	workspace := t.TempDir()
+	src := filepath.Join(t.TempDir(), "spec.md")
+	if err := os.WriteFile(src, []byte("# Spec\nline two\n"), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	dir := filepath.Join(t.TempDir(), "attachments")
+	if _, err := CopyAttachment(dir, src); err != nil {
+		t.Fatalf("copy: %v", err)
+	}
+
+	reg := NewRegistry()
+	if err := RegisterReadFile(reg, workspace); err != nil {
+		t.Fatalf("register read_file: %v", err)
+	}
+	ctx := WithAttachmentsDir(context.Background(), dir)
+
+	raw, _ := json.Marshal(ReadFileArgs{Path: "@attachments/spec.md"})
+	res, err := reg.Invoke(ctx, "read_file", raw)
+	if err != nil {
+		t.Fatalf("invoke: %v", err)
+	}
+	if r := res.(*ReadFileResult); !strings.Contains(r.Content, "L2: line two") {
+		t.Fatalf("unexpected content: %q", r.Content)
+	}
+
+	raw, _ = json.Marshal(ReadFileArgs{Path: "@attachments/../../etc/passwd"})
+	if _, err := reg.Invoke(ctx, "read_file", raw); err == nil {
+		t.Fatalf("expected traversal outside the attachments directory to fail")
+	}
+	if _, err := reg.Invoke(context.Background(), "read_file", raw); err == nil {
+		t.Fatalf("expected attachment read without a session directory to fail")
+	}
+}
+
+func TestAttachmentExcerpt_HeadAndTail(t *testing.T) {
+	dir := t.TempDir()
+	var b strings.Builder
+	for i := 0; i < 1000; i++ {
+		b.WriteString("log line\n")
+	}
+	b.WriteString("FATAL: out of memory\n")
+	if err := os.WriteFile(filepath.Join(dir, "big.log"), []byte(b.String()), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "shot.png"), []byte("\x89PNG\r\n\x1a\n\x00\x00"), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	out, err := AttachmentExcerpt(dir, "big.log", 400)
+	if err != nil {
+		t.Fatalf("excerpt: %v", err)
+	}
+	if !strings.Contains(out, "FATAL: out of memory") || !strings.Contains(out, "lines omitted") {
+		t.Fatalf("expected head, tail and omission marker, got:\n%s", out)
+	}
+
+	out, _ = AttachmentExcerpt(dir, "shot.png", 400)
+	if !strings.Contains(out, "Binary file") {
+		t.Fatalf("expected binary description, got:\n%s", out)
+	}
+}
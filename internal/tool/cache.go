@@ -0,0 +1,135 @@
+package tool
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sync"
+)
+
+// cachedMarker prefixes results served from the per-run cache so the model notices
+// it repeated an identical call.
+const cachedMarker = "[cached: identical call already made in this run; result unchanged]\n"
+
+// ResultCache memoizes read-only tool results for the duration of a single run.
+// Any call to a tool that is not read-only clears it, since files may have changed.
+// It also holds prefetched results of calls the model has not made yet.
+type ResultCache struct {
+	mu      sync.Mutex
+	entries map[string]*ExecutionResult
+	hits    int
+	// prefetched marks entries no call has been served from yet
+	prefetched   map[string]bool
+	prefetchHits int
+	// generation changes on every invalidation so late prefetches are dropped
+	generation uint64
+}
+
+// NewResultCache creates an empty per-run cache.
+func NewResultCache() *ResultCache {
+	return &ResultCache{entries: make(map[string]*ExecutionResult), prefetched: make(map[string]bool)}
+}
+
+type resultCacheKey struct{}
+
+// WithResultCache attaches a per-run cache to the context used for tool invocations.
+func WithResultCache(ctx context.Context, cache *ResultCache) context.Context {
+	return context.WithValue(ctx, resultCacheKey{}, cache)
+}
+
+// resultCacheFrom returns the cache attached to ctx, if any.
+func resultCacheFrom(ctx context.Context) *ResultCache {
+	if ctx == nil {
+		return nil
+	}
+	c, _ := ctx.Value(resultCacheKey{}).(*ResultCache)
+	return c
+}
+
+// cacheKey hashes the tool name with canonicalized arguments so key order and
+// whitespace differences do not defeat the cache.
+func cacheKey(name string, args json.RawMessage) string {
+	canonical := []byte(args)
+	var v interface{}
+	if len(args) > 0 && json.Unmarshal(args, &v) == nil {
+		if b, err := json.Marshal(v); err == nil {
+			canonical = b
+		}
+	}
+	h := sha256.New()
+	h.Write([]byte(name))
+	h.Write([]byte{0})
+	h.Write(canonical)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// get returns the cached result for key. prefetched is true the first time a
+// prefetched result is served; later identical calls count as repeats.
+func (c *ResultCache) get(key string) (res *ExecutionResult, prefetched, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	res, ok = c.entries[key]
+	switch {
+	case !ok:
+	case c.prefetched[key]:
+		delete(c.prefetched, key)
+		c.prefetchHits++
+		prefetched = true
+	default:
+		c.hits++
+	}
+	return res, prefetched, ok
+}
+
+func (c *ResultCache) put(key string, res *ExecutionResult) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[key] = res
+}
+
+// putPrefetched stores a prefetched result unless the cache was invalidated since the
+// prefetch started at generation gen, or a real call already stored the key.
+func (c *ResultCache) putPrefetched(key string, res *ExecutionResult, gen uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.generation != gen {
+		return
+	}
+	if _, ok := c.entries[key]; ok {
+		return
+	}
+	c.entries[key] = res
+	c.prefetched[key] = true
+}
+
+// lookup reports whether key is cached and returns the current generation.
+func (c *ResultCache) lookup(key string) (cached bool, gen uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, cached = c.entries[key]
+	return cached, c.generation
+}
+
+// Invalidate drops all cached results.
+func (c *ResultCache) Invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = make(map[string]*ExecutionResult)
+	c.prefetched = make(map[string]bool)
+	c.generation++
+}
+
+// Hits returns how many calls were served from the cache.
+func (c *ResultCache) Hits() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.hits
+}
+
+// PrefetchHits returns how many calls were answered by a prefetched result.
+func (c *ResultCache) PrefetchHits() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.prefetchHits
+}
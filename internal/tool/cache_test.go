@@ -0,0 +1,52 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestResultCache_ServesRepeatedReadOnlyCalls(t *testing.T) {
+	reg := NewRegistry()
+	calls := 0
+	_ = reg.Register(Definition{
+		Name:     "lookup",
+		Safe:     true,
+		ReadOnly: true,
+		Handler: func(ctx context.Context, raw json.RawMessage) (interface{}, error) {
+			calls++
+			return "value", nil
+		},
+	})
+	_ = reg.Register(Definition{
+		Name: "mutate",
+		Safe: true,
+		Handler: func(ctx context.Context, raw json.RawMessage) (interface{}, error) {
+			return "done", nil
+		},
+	})
+
+	ctx := WithResultCache(context.Background(), NewResultCache())
+	first, _ := reg.InvokeToolCall(ctx, &ToolCall{Name: "lookup", Args: json.RawMessage(`{"a":1,"b":2}`)})
+	second, _ := reg.InvokeToolCall(ctx, &ToolCall{Name: "lookup", Args: json.RawMessage(`{ "b": 2, "a": 1 }`)})
+	if calls != 1 {
+		t.Fatalf("expected handler to run once, ran %d times", calls)
+	}
+	if first.Cached || !second.Cached || !strings.HasPrefix(second.Content, cachedMarker) {
+		t.Fatalf("expected second call to be marked cached: %+v", second)
+	}
+
+	// A call that may change state invalidates the cache
+	_, _ = reg.InvokeToolCall(ctx, &ToolCall{Name: "mutate"})
+	third, _ := reg.InvokeToolCall(ctx, &ToolCall{Name: "lookup", Args: json.RawMessage(`{"a":1,"b":2}`)})
+	if calls != 2 || third.Cached {
+		t.Fatalf("expected fresh call after invalidation, calls=%d cached=%v", calls, third.Cached)
+	}
+
+	// Without a cache in the context every call runs
+	_, _ = reg.InvokeToolCall(context.Background(), &ToolCall{Name: "lookup", Args: json.RawMessage(`{"a":1,"b":2}`)})
+	if calls != 3 {
+		t.Fatalf("expected uncached call to run, calls=%d", calls)
+	}
+}
@@ -0,0 +1,144 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/loom/loom/internal/memory"
+)
+
+// CheckpointArgs represents the arguments for the checkpoint tool.
+type CheckpointArgs struct {
+	// Action is create, list, diff or revert
+	Action string `json:"action"`
+	Name   string `json:"name,omitempty"`
+	// Paths limits a revert to some files; empty reverts every file changed since
+	Paths []string `json:"paths,omitempty"`
+}
+
+// CheckpointResult reports the outcome of a checkpoint action.
+type CheckpointResult struct {
+	Checkpoints []memory.NamedCheckpoint `json:"checkpoints,omitempty"`
+	Diff        string                   `json:"diff,omitempty"`
+	Reverted    []string                 `json:"reverted,omitempty"`
+	Message     string                   `json:"message"`
+}
+
+// Checkpoints gives the checkpoint tool access to the conversation's timeline. Revert
+// asks the user for approval before changing files.
+type Checkpoints interface {
+	CreateCheckpoint(name string) (memory.NamedCheckpoint, error)
+	ListCheckpoints() []memory.NamedCheckpoint
+	// DiffCheckpoint returns the unified diff from the checkpoint to the latest step
+	// and the number of files changed
+	DiffCheckpoint(name string) (string, int, error)
+	RevertCheckpoint(name string, paths []string) ([]string, error)
+}
+
+type checkpointsKey struct{}
+
+// WithCheckpoints lets checkpoint calls made with ctx use the conversation's timeline.
+func WithCheckpoints(ctx context.Context, c Checkpoints) context.Context {
+	return context.WithValue(ctx, checkpointsKey{}, c)
+}
+
+// RegisterCheckpoint registers the checkpoint tool, which names points of the
+// conversation's edit timeline so they can be diffed against or reverted to later.
+func RegisterCheckpoint(registry *Registry) error {
+	return registry.Register(Definition{
+		Name:        "checkpoint",
+		Description: "Name the current point of this conversation's edit history (e.g. \"before refactor of auth module\") before risky or multi-step changes; later list checkpoints, diff the workspace against one, or revert to it.",
+		// Reverts ask for approval themselves; the other actions only keep session state
+		Safe: true,
+		JSONSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"action": map[string]interface{}{
+					"type":        "string",
+					"enum":        []string{"create", "list", "diff", "revert"},
+					"description": "create names the current point; diff shows changes made since a checkpoint; revert restores files to it",
+				},
+				"name": map[string]interface{}{
+					"type":        "string",
+					"description": "Checkpoint name (required except for list); reusing a name moves it",
+				},
+				"paths": map[string]interface{}{
+					"type":        "array",
+					"items":       map[string]interface{}{"type": "string"},
+					"description": "For revert: only these workspace-relative files (default all changed since the checkpoint)",
+				},
+			},
+			"required": []string{"action"},
+		},
+		Usage: "Checkpoints mark points of the timeline of file changes Loom made in this conversation; the user can list, diff and revert them too. Create one before a refactor or an experiment you may want to abandon. diff and revert only cover changes made through Loom. revert refuses files edited outside Loom since, and the user must approve it.",
+		Examples: []string{
+			`{"action":"create","name":"before refactor of auth module"}`,
+			`{"action":"diff","name":"before refactor of auth module"}`,
+			`{"action":"revert","name":"before refactor of auth module","paths":["internal/auth/session.go"]}`,
+		},
+		Handler: func(ctx context.Context, raw json.RawMessage) (interface{}, error) {
+			var args CheckpointArgs
+			if err := json.Unmarshal(raw, &args); err != nil {
+				return nil, fmt.Errorf("failed to parse arguments: %w", err)
+			}
+			return runCheckpoint(ctx, args)
+		},
+	})
+}
+
+func runCheckpoint(ctx context.Context, args CheckpointArgs) (*CheckpointResult, error) {
+	c, _ := ctx.Value(checkpointsKey{}).(Checkpoints)
+	if c == nil {
+		return nil, errors.New("checkpoints need an active conversation")
+	}
+	action := strings.ToLower(strings.TrimSpace(args.Action))
+	name := strings.TrimSpace(args.Name)
+	if name == "" && action != "list" {
+		return nil, fmt.Errorf("name is required for %s", action)
+	}
+	switch action {
+	case "create":
+		cp, err := c.CreateCheckpoint(name)
+		if err != nil {
+			return nil, err
+		}
+		return &CheckpointResult{
+			Checkpoints: []memory.NamedCheckpoint{cp},
+			Message:     fmt.Sprintf("Checkpoint %q created at step %d", cp.Name, cp.Step),
+		}, nil
+	case "list":
+		items := c.ListCheckpoints()
+		msg := fmt.Sprintf("%d checkpoint(s)", len(items))
+		if len(items) == 0 {
+			msg = "No checkpoints in this conversation"
+		}
+		return &CheckpointResult{Checkpoints: items, Message: msg}, nil
+	case "diff":
+		diff, files, err := c.DiffCheckpoint(name)
+		if err != nil {
+			return nil, err
+		}
+		msg := fmt.Sprintf("%d file(s) changed since checkpoint %q", files, name)
+		if files == 0 {
+			msg = fmt.Sprintf("No changes since checkpoint %q", name)
+		}
+		return &CheckpointResult{Diff: diff, Message: msg}, nil
+	case "revert":
+		reverted, err := c.RevertCheckpoint(name, args.Paths)
+		if err != nil {
+			if len(reverted) > 0 {
+				return nil, fmt.Errorf("reverted %s, then: %w", strings.Join(reverted, ", "), err)
+			}
+			return nil, err
+		}
+		msg := fmt.Sprintf("Reverted %d file(s) to checkpoint %q", len(reverted), name)
+		if len(reverted) == 0 {
+			msg = fmt.Sprintf("Nothing changed since checkpoint %q", name)
+		}
+		return &CheckpointResult{Reverted: reverted, Message: msg}, nil
+	}
+	return nil, fmt.Errorf("unknown action %q (use create, list, diff or revert)", args.Action)
+}
@@ -0,0 +1,392 @@
+package tool
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/loom/loom/internal/editor"
+)
+
+// CompareArgs names the two files or directories to compare.
+type CompareArgs struct {
+	Left  string `json:"left"`
+	Right string `json:"right"`
+	// Exclude drops files whose relative path or base name matches one of these globs
+	Exclude []string `json:"exclude,omitempty"`
+	// Diff includes unified diffs of changed text files; always on for two files
+	Diff bool `json:"diff,omitempty"`
+	// Limit caps the files listed (default 100, max 500)
+	Limit int `json:"limit,omitempty"`
+}
+
+// CompareResult summarizes the differences between two files or directories.
+type CompareResult struct {
+	Left  string `json:"left"`
+	Right string `json:"right"`
+	// Kind is files or directories
+	Kind      string        `json:"kind"`
+	Files     []CompareFile `json:"files"`
+	Added     int           `json:"added"`
+	Removed   int           `json:"removed"`
+	Modified  int           `json:"modified"`
+	Renamed   int           `json:"renamed,omitempty"`
+	Unchanged int           `json:"unchanged"`
+	// LinesAdded and LinesRemoved total the text files compared line by line
+	LinesAdded   int `json:"lines_added"`
+	LinesRemoved int `json:"lines_removed"`
+	// Truncated is set when more files differ than are listed or than could be walked
+	Truncated bool   `json:"truncated,omitempty"`
+	Message   string `json:"message"`
+}
+
+// CompareFile is the change of one file between the two sides.
+type CompareFile struct {
+	// Path is relative to the compared directories; for renames it is the right path
+	Path string `json:"path"`
+	// Status is added, removed, modified or renamed
+	Status  string `json:"status"`
+	OldPath string `json:"old_path,omitempty"`
+	Added   int    `json:"added,omitempty"`
+	Removed int    `json:"removed,omitempty"`
+	// Binary and TooLarge files are compared by content hash only
+	Binary   bool `json:"binary,omitempty"`
+	TooLarge bool `json:"too_large,omitempty"`
+	// Changes lists changed lines, with renames and literal edits recognized
+	Changes string `json:"changes,omitempty"`
+	Diff    string `json:"diff,omitempty"`
+}
+
+const (
+	compareDefaultLimit = 100
+	compareMaxLimit     = 500
+	// compareMaxFiles bounds the files walked on each side
+	compareMaxFiles = 10000
+	// compareMaxTextBytes is the largest file compared line by line
+	compareMaxTextBytes = 1 << 20
+	// compareMaxChanges caps the changed lines listed per file
+	compareMaxChanges = 12
+	// compareMaxDiffBytes caps each file's unified diff
+	compareMaxDiffBytes = 16 << 10
+)
+
+// RegisterCompare registers the compare tool, which diffs two files or two directory
+// trees of the workspace.
+func RegisterCompare(registry *Registry, workspacePath string) error {
+	return registry.Register(Definition{
+		Name:        "compare",
+		Description: "Compare two files or two directories of the workspace (e.g. v1/ and v2/ of a vendored library). Directories are compared recursively and the result lists added, removed, renamed and modified files with line counts and a summary of the changed lines, instead of reading both sides.",
+		Safe:        true,
+		ReadOnly:    true,
+		JSONSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"left":  map[string]interface{}{"type": "string", "description": "Old file or directory, relative to the workspace"},
+				"right": map[string]interface{}{"type": "string", "description": "New file or directory, relative to the workspace"},
+				"exclude": map[string]interface{}{
+					"type":        "array",
+					"items":       map[string]interface{}{"type": "string"},
+					"description": "Globs of files to skip, matched against the relative path and the base name (e.g. \"*.min.js\", \"testdata/*\")",
+				},
+				"diff":  map[string]interface{}{"type": "boolean", "description": "Include unified diffs of changed text files (always included for two files)"},
+				"limit": map[string]interface{}{"type": "integer", "description": "Maximum files listed (default 100, max 500)"},
+			},
+			"required": []string{"left", "right"},
+		},
+		Usage: `Both sides must be files or both directories. Files are matched by their path relative to each directory; a removed and an added file with the same content count as a rename. .git directories are skipped.
+Binary files and files over 1 MB are compared by content only. Start without diff to see what changed, then compare single files (or read them) for details.`,
+		Examples: []string{
+			`{"left":"third_party/lib-v1","right":"third_party/lib-v2","exclude":["*_test.go"]}`,
+			`{"left":"config/app.example.yaml","right":"config/app.yaml"}`,
+		},
+		Handler: func(ctx context.Context, raw json.RawMessage) (interface{}, error) {
+			var args CompareArgs
+			if err := json.Unmarshal(raw, &args); err != nil {
+				return nil, fmt.Errorf("failed to parse arguments: %w", err)
+			}
+			return compare(ctx, workspacePath, args)
+		},
+	})
+}
+
+func compare(ctx context.Context, workspacePath string, args CompareArgs) (*CompareResult, error) {
+	if strings.TrimSpace(args.Left) == "" || strings.TrimSpace(args.Right) == "" {
+		return nil, errors.New("left and right are required")
+	}
+	ws := expandWorkspacePath(workspacePath)
+	left, err := validatePath(ws, args.Left)
+	if err != nil {
+		return nil, err
+	}
+	right, err := validatePath(ws, args.Right)
+	if err != nil {
+		return nil, err
+	}
+	li, err := os.Stat(left)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read %s: %w", args.Left, err)
+	}
+	ri, err := os.Stat(right)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read %s: %w", args.Right, err)
+	}
+	if li.IsDir() != ri.IsDir() {
+		return nil, errors.New("left and right must both be files or both be directories")
+	}
+	limit := args.Limit
+	if limit <= 0 {
+		limit = compareDefaultLimit
+	}
+	if limit > compareMaxLimit {
+		limit = compareMaxLimit
+	}
+	res := &CompareResult{Left: args.Left, Right: args.Right, Kind: "files", Files: []CompareFile{}}
+
+	if !li.IsDir() {
+		f, same, err := compareFile(left, right, filepath.ToSlash(args.Right), true)
+		if err != nil {
+			return nil, err
+		}
+		if same {
+			res.Unchanged = 1
+			res.Message = "The files are identical"
+			return res, nil
+		}
+		res.Modified = 1
+		res.LinesAdded, res.LinesRemoved = f.Added, f.Removed
+		res.Files = append(res.Files, f)
+		res.Message = fmt.Sprintf("+%d -%d lines", f.Added, f.Removed)
+		return res, nil
+	}
+
+	res.Kind = "directories"
+	leftFiles, lt, err := walkCompareTree(ctx, left, args.Exclude)
+	if err != nil {
+		return nil, err
+	}
+	rightFiles, rt, err := walkCompareTree(ctx, right, args.Exclude)
+	if err != nil {
+		return nil, err
+	}
+	res.Truncated = lt || rt
+
+	var added, removed []string
+	for rel := range rightFiles {
+		if _, ok := leftFiles[rel]; !ok {
+			added = append(added, rel)
+		}
+	}
+	for rel := range leftFiles {
+		if _, ok := rightFiles[rel]; !ok {
+			removed = append(removed, rel)
+		}
+	}
+	var changed []CompareFile
+	for rel := range leftFiles {
+		if _, ok := rightFiles[rel]; !ok {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		f, same, err := compareFile(leftFiles[rel], rightFiles[rel], rel, args.Diff)
+		if err != nil {
+			return nil, err
+		}
+		if same {
+			res.Unchanged++
+			continue
+		}
+		res.Modified++
+		res.LinesAdded += f.Added
+		res.LinesRemoved += f.Removed
+		changed = append(changed, f)
+	}
+
+	// A removed and an added file with the same content were moved
+	byHash := map[[sha256.Size]byte][]string{}
+	for _, rel := range removed {
+		if h, ok := hashFile(leftFiles[rel]); ok {
+			byHash[h] = append(byHash[h], rel)
+		}
+	}
+	sort.Strings(added)
+	renamedFrom := map[string]bool{}
+	for _, rel := range added {
+		h, ok := hashFile(rightFiles[rel])
+		if !ok || len(byHash[h]) == 0 {
+			res.Added++
+			f := CompareFile{Path: rel, Status: "added"}
+			f.Added = countFileLines(rightFiles[rel], &f)
+			res.LinesAdded += f.Added
+			changed = append(changed, f)
+			continue
+		}
+		sort.Strings(byHash[h])
+		from := byHash[h][0]
+		byHash[h] = byHash[h][1:]
+		renamedFrom[from] = true
+		res.Renamed++
+		changed = append(changed, CompareFile{Path: rel, Status: "renamed", OldPath: from})
+	}
+	for _, rel := range removed {
+		if renamedFrom[rel] {
+			continue
+		}
+		res.Removed++
+		f := CompareFile{Path: rel, Status: "removed"}
+		f.Removed = countFileLines(leftFiles[rel], &f)
+		res.LinesRemoved += f.Removed
+		changed = append(changed, f)
+	}
+
+	sort.Slice(changed, func(i, j int) bool { return changed[i].Path < changed[j].Path })
+	if len(changed) > limit {
+		changed = changed[:limit]
+		res.Truncated = true
+	}
+	res.Files = changed
+	res.Message = fmt.Sprintf("%d added, %d removed, %d renamed, %d modified, %d unchanged (+%d -%d lines)",
+		res.Added, res.Removed, res.Renamed, res.Modified, res.Unchanged, res.LinesAdded, res.LinesRemoved)
+	if res.Added+res.Removed+res.Renamed+res.Modified == 0 {
+		res.Message = fmt.Sprintf("The directories are identical (%d files)", res.Unchanged)
+	}
+	return res, nil
+}
+
+// walkCompareTree maps the relative paths of the regular files under root to their
+// absolute paths, skipping .git and excluded files. It reports whether the walk
+// stopped at compareMaxFiles.
+func walkCompareTree(ctx context.Context, root string, exclude []string) (map[string]string, bool, error) {
+	files := map[string]string{}
+	truncated := false
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if d.Name() == ".git" && p != root {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return nil
+		}
+		rel = filepath.ToSlash(rel)
+		if compareExcluded(rel, exclude) {
+			return nil
+		}
+		if len(files) >= compareMaxFiles {
+			truncated = true
+			return filepath.SkipAll
+		}
+		files[rel] = p
+		return nil
+	})
+	return files, truncated, err
+}
+
+func compareExcluded(rel string, exclude []string) bool {
+	for _, g := range exclude {
+		if ok, _ := path.Match(g, rel); ok {
+			return true
+		}
+		if ok, _ := path.Match(g, path.Base(rel)); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// compareFile compares two files; same is true when their contents are equal.
+func compareFile(left, right, rel string, withDiff bool) (CompareFile, bool, error) {
+	f := CompareFile{Path: rel, Status: "modified"}
+	old, err := os.ReadFile(left)
+	if err != nil {
+		return f, false, err
+	}
+	cur, err := os.ReadFile(right)
+	if err != nil {
+		return f, false, err
+	}
+	if string(old) == string(cur) {
+		return f, true, nil
+	}
+	switch {
+	case len(old) > compareMaxTextBytes || len(cur) > compareMaxTextBytes:
+		f.TooLarge = true
+		return f, false, nil
+	case looksBinary(old) || looksBinary(cur):
+		f.Binary = true
+		return f, false, nil
+	}
+	entries := editor.AnalyzeContentChanges(string(old), string(cur), rel, editor.DiffModeSyntax)
+	for _, e := range entries {
+		switch e.Kind {
+		case editor.ChangeAdded:
+			f.Added++
+		case editor.ChangeRemoved:
+			f.Removed++
+		default:
+			f.Added++
+			f.Removed++
+		}
+	}
+	f.Changes = strings.TrimSpace(editor.FormatChangeSummary(entries, compareMaxChanges))
+	if withDiff {
+		f.Diff = editor.UnifiedDiff(rel, string(old), string(cur), true, true, 3)
+		if len(f.Diff) > compareMaxDiffBytes {
+			f.Diff = f.Diff[:compareMaxDiffBytes] + "\n… diff truncated; compare this file alone or read it for the rest …\n"
+		}
+	}
+	return f, false, nil
+}
+
+// countFileLines returns the line count of a text file, marking binary and large files.
+func countFileLines(p string, f *CompareFile) int {
+	info, err := os.Stat(p)
+	if err != nil {
+		return 0
+	}
+	if info.Size() > compareMaxTextBytes {
+		f.TooLarge = true
+		return 0
+	}
+	data, err := os.ReadFile(p)
+	if err != nil || len(data) == 0 {
+		return 0
+	}
+	if looksBinary(data) {
+		f.Binary = true
+		return 0
+	}
+	n := strings.Count(string(data), "\n")
+	if data[len(data)-1] != '\n' {
+		n++
+	}
+	return n
+}
+
+func hashFile(p string) ([sha256.Size]byte, bool) {
+	data, err := os.ReadFile(p)
+	if err != nil {
+		return [sha256.Size]byte{}, false
+	}
+	return sha256.Sum256(data), true
+}
@@ -0,0 +1,81 @@
+package tool
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestCompare_Directories(t *testing.T) {
+	ws := writeWorkspace(t, map[string]string{
+		"v1/lib.go":              "package lib\n\nconst Version = \"1.0\"\n\nfunc Old() {}\n",
+		"v1/util/strings.go":     "package util\n\nfunc Trim(s string) string { return s }\n",
+		"v1/legacy.go":           "package lib\n\n// legacy\n",
+		"v1/logo.png":            "\x89PNG\x00\x01",
+		"v1/lib_test.go":         "package lib\n",
+		"v1/README.md":           "same\n",
+		"v2/lib.go":              "package lib\n\nconst Version = \"2.0\"\n\nfunc New() {}\n",
+		"v2/internal/strings.go": "package util\n\nfunc Trim(s string) string { return s }\n",
+		"v2/extra.go":            "package lib\n\nfunc Extra() {}\n",
+		"v2/logo.png":            "\x89PNG\x00\x02",
+		"v2/lib_test.go":         "package lib // changed\n",
+		"v2/README.md":           "same\n",
+	})
+
+	res, err := compare(context.Background(), ws, CompareArgs{Left: "v1", Right: "v2", Exclude: []string{"*_test.go"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Kind != "directories" || res.Added != 1 || res.Removed != 1 || res.Renamed != 1 || res.Modified != 2 || res.Unchanged != 1 {
+		t.Fatalf("unexpected counts: %s", res.Message)
+	}
+	byPath := map[string]CompareFile{}
+	for _, f := range res.Files {
+		byPath[f.Path] = f
+	}
+	if f := byPath["internal/strings.go"]; f.Status != "renamed" || f.OldPath != "util/strings.go" {
+		t.Errorf("expected a rename, got %+v", f)
+	}
+	if f := byPath["lib.go"]; f.Status != "modified" || f.Added != 2 || f.Removed != 2 || f.Changes == "" || f.Diff != "" {
+		t.Errorf("unexpected lib.go change %+v", f)
+	}
+	if f := byPath["logo.png"]; !f.Binary || f.Added != 0 {
+		t.Errorf("binary files are compared by content only: %+v", f)
+	}
+	if f := byPath["extra.go"]; f.Status != "added" || f.Added != 3 {
+		t.Errorf("unexpected added file %+v", f)
+	}
+	if f := byPath["legacy.go"]; f.Status != "removed" || f.Removed != 3 {
+		t.Errorf("unexpected removed file %+v", f)
+	}
+	if _, ok := byPath["lib_test.go"]; ok {
+		t.Error("excluded files must be skipped")
+	}
+
+	limited, _ := compare(context.Background(), ws, CompareArgs{Left: "v1", Right: "v2", Limit: 4, Diff: true})
+	if len(limited.Files) != 4 || !limited.Truncated || limited.Files[3].Path != "lib.go" || !strings.Contains(limited.Files[3].Diff, "+func New() {}") {
+		t.Errorf("unexpected limited result %+v", limited.Files)
+	}
+}
+
+func TestCompare_FilesAndErrors(t *testing.T) {
+	ws := writeWorkspace(t, map[string]string{
+		"a.yaml": "port: 80\nhost: local\n",
+		"b.yaml": "port: 8080\nhost: local\n",
+		"c.yaml": "port: 80\nhost: local\n",
+		"dir/x":  "x\n",
+	})
+	res, err := compare(context.Background(), ws, CompareArgs{Left: "a.yaml", Right: "b.yaml"})
+	if err != nil || res.Kind != "files" || len(res.Files) != 1 || !strings.Contains(res.Files[0].Diff, "+port: 8080") {
+		t.Fatalf("unexpected file comparison %+v %v", res, err)
+	}
+	if res, _ := compare(context.Background(), ws, CompareArgs{Left: "a.yaml", Right: "c.yaml"}); res.Unchanged != 1 || len(res.Files) != 0 {
+		t.Errorf("identical files should have no changes: %+v", res)
+	}
+	if _, err := compare(context.Background(), ws, CompareArgs{Left: "a.yaml", Right: "dir"}); err == nil {
+		t.Error("expected an error comparing a file with a directory")
+	}
+	if _, err := compare(context.Background(), ws, CompareArgs{Left: "a.yaml", Right: "../outside"}); err == nil {
+		t.Error("expected paths outside the workspace to be refused")
+	}
+}
@@ -0,0 +1,799 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/loom/loom/internal/editor"
+)
+
+const (
+	maxComponentFiles     = 5000
+	maxComponentFileBytes = 512 << 10
+	maxComponentResults   = 200
+)
+
+// ComponentGraphArgs selects the question component_graph answers.
+type ComponentGraphArgs struct {
+	Component string `json:"component,omitempty"` // where is it rendered
+	File      string `json:"file,omitempty"`      // what does it render
+	Depth     int    `json:"depth,omitempty"`     // levels to follow from file (1-3)
+}
+
+// ComponentInfo is a React or Vue component definition.
+type ComponentInfo struct {
+	Name       string   `json:"name"`
+	File       string   `json:"file"`
+	Line       int      `json:"line"`
+	Framework  string   `json:"framework"` // react or vue
+	Props      []string `json:"props,omitempty"`
+	RenderedIn int      `json:"rendered_in"` // number of render sites
+}
+
+// ComponentUsage is one place a component is rendered.
+type ComponentUsage struct {
+	Component string   `json:"component"`         // name as written at the site
+	Defined   string   `json:"defined,omitempty"` // file#Name of the resolved definition
+	Package   string   `json:"package,omitempty"` // import source for library components
+	File      string   `json:"file"`              // where it is rendered
+	Line      int      `json:"line"`              // line of the opening tag
+	In        string   `json:"in,omitempty"`      // enclosing component
+	Props     []string `json:"props,omitempty"`   // props passed at this site
+	Depth     int      `json:"depth,omitempty"`   // distance from the queried file
+	ByName    bool     `json:"by_name,omitempty"` // resolved by name only (no import seen)
+}
+
+// ComponentGraphResult answers one component_graph question.
+type ComponentGraphResult struct {
+	ComponentCount int              `json:"component_count"`
+	Matches        []ComponentInfo  `json:"matches,omitempty"`
+	RenderedBy     []ComponentUsage `json:"rendered_by,omitempty"`
+	Uses           []ComponentUsage `json:"uses,omitempty"`
+	Top            []ComponentInfo  `json:"top,omitempty"`
+	Truncated      bool             `json:"truncated,omitempty"`
+	Error          string           `json:"error,omitempty"`
+}
+
+// RegisterComponentGraph registers the component_graph tool.
+func RegisterComponentGraph(registry *Registry, workspacePath string) error {
+	return registry.Register(Definition{
+		Name:        "component_graph",
+		Description: "Map React/Vue component relationships from imports and JSX/template usage. Pass 'component' to find where it is rendered (with props passed), 'file' to list the components a page or component renders, or neither for the most-rendered components.",
+		Safe:        true,
+		ReadOnly:    true,
+		JSONSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"component": map[string]interface{}{
+					"type":        "string",
+					"description": "Component name (e.g. UserCard) or the file that defines it",
+				},
+				"file": map[string]interface{}{
+					"type":        "string",
+					"description": "Workspace-relative page or component file whose rendered components to list",
+				},
+				"depth": map[string]interface{}{
+					"type":        "integer",
+					"description": "With 'file', how many levels of child components to follow (default 1, max 3)",
+					"minimum":     1,
+					"maximum":     3,
+				},
+			},
+		},
+		Handler: func(ctx context.Context, raw json.RawMessage) (interface{}, error) {
+			var args ComponentGraphArgs
+			if len(raw) > 0 {
+				if err := json.Unmarshal(raw, &args); err != nil {
+					return nil, fmt.Errorf("failed to parse arguments: %w", err)
+				}
+			}
+			return componentGraph(ctx, workspacePath, args)
+		},
+	})
+}
+
+func componentGraph(ctx context.Context, workspacePath string, args ComponentGraphArgs) (*ComponentGraphResult, error) {
+	g, err := buildComponentGraph(ctx, workspacePath)
+	if err != nil {
+		return nil, err
+	}
+	res := &ComponentGraphResult{ComponentCount: len(g.components)}
+	counts := map[string]int{}
+	for _, u := range g.usages {
+		if u.Defined != "" {
+			counts[u.Defined]++
+		}
+	}
+	info := func(c *componentDef) ComponentInfo {
+		return ComponentInfo{Name: c.name, File: c.file, Line: c.line, Framework: c.framework, Props: c.props, RenderedIn: counts[c.key()]}
+	}
+
+	switch {
+	case strings.TrimSpace(args.Component) != "":
+		want := strings.TrimSpace(args.Component)
+		keys := map[string]bool{}
+		for _, c := range g.components {
+			if strings.EqualFold(c.name, want) || c.file == filepath.ToSlash(filepath.Clean(want)) {
+				keys[c.key()] = true
+				res.Matches = append(res.Matches, info(c))
+			}
+		}
+		for _, u := range g.usages {
+			// Unresolved library components still match by the name written at the site
+			if keys[u.Defined] || (u.Defined == "" && strings.EqualFold(pascalCase(u.Component), pascalCase(want))) {
+				res.RenderedBy = append(res.RenderedBy, u)
+			}
+		}
+		if len(res.Matches) == 0 && len(res.RenderedBy) == 0 {
+			res.Error = fmt.Sprintf("no component named %q found", want)
+		}
+	case strings.TrimSpace(args.File) != "":
+		start := filepath.ToSlash(filepath.Clean(strings.TrimSpace(args.File)))
+		if _, ok := g.files[start]; !ok {
+			res.Error = fmt.Sprintf("%s is not a component or page file (expected .jsx, .tsx, .js, .ts, or .vue)", start)
+			break
+		}
+		for _, c := range g.components {
+			if c.file == start {
+				res.Matches = append(res.Matches, info(c))
+			}
+		}
+		depth := args.Depth
+		if depth < 1 {
+			depth = 1
+		} else if depth > 3 {
+			depth = 3
+		}
+		seen := map[string]bool{start: true}
+		frontier := []string{start}
+		for d := 1; d <= depth && len(frontier) > 0; d++ {
+			var next []string
+			for _, file := range frontier {
+				for _, u := range g.byFile[file] {
+					u.Depth = d
+					res.Uses = append(res.Uses, u)
+					if def := g.components[u.Defined]; def != nil && !seen[def.file] {
+						seen[def.file] = true
+						next = append(next, def.file)
+					}
+				}
+			}
+			frontier = next
+		}
+	default:
+		for _, c := range g.components {
+			res.Top = append(res.Top, info(c))
+		}
+		sort.Slice(res.Top, func(i, j int) bool {
+			if res.Top[i].RenderedIn != res.Top[j].RenderedIn {
+				return res.Top[i].RenderedIn > res.Top[j].RenderedIn
+			}
+			return res.Top[i].File+res.Top[i].Name < res.Top[j].File+res.Top[j].Name
+		})
+		if len(res.Top) > 30 {
+			res.Top = res.Top[:30]
+		}
+	}
+	sort.Slice(res.Matches, func(i, j int) bool {
+		return res.Matches[i].File < res.Matches[j].File || res.Matches[i].File == res.Matches[j].File && res.Matches[i].Line < res.Matches[j].Line
+	})
+	if len(res.RenderedBy) > maxComponentResults {
+		res.RenderedBy, res.Truncated = res.RenderedBy[:maxComponentResults], true
+	}
+	if len(res.Uses) > maxComponentResults {
+		res.Uses, res.Truncated = res.Uses[:maxComponentResults], true
+	}
+	return res, nil
+}
+
+// componentDef is a component found while scanning.
+type componentDef struct {
+	name, file, framework string
+	line                  int
+	props                 []string
+	isDefault             bool
+}
+
+func (c *componentDef) key() string { return c.file + "#" + c.name }
+
+// componentBinding is an imported name: which module it comes from and what it imports.
+type componentBinding struct {
+	spec, imported string // imported is "default", "*", or the exported name
+}
+
+type componentFile struct {
+	rel, abs  string
+	framework string
+	defs      []*componentDef
+	bindings  map[string]componentBinding
+	sites     []componentSite
+}
+
+// componentSite is an unresolved render site.
+type componentSite struct {
+	name  string
+	line  int
+	props []string
+}
+
+type componentGraphData struct {
+	files      map[string]*componentFile
+	components map[string]*componentDef
+	usages     []ComponentUsage
+	byFile     map[string][]ComponentUsage
+}
+
+var (
+	compImportRe    = regexp.MustCompile(`(?s)\bimport\s+(?:type\s+)?([\w$*{}\s,]+?)\s+from\s+['"]([^'"]+)['"]`)
+	compLazyRe      = regexp.MustCompile(`(?:const|let)\s+([A-Z]\w*)\s*=\s*(?:React\.)?(?:lazy|defineAsyncComponent)\(\s*\(\)\s*=>\s*import\(\s*['"]([^'"]+)['"]`)
+	compFuncRe      = regexp.MustCompile(`(?m)^[ \t]*(export\s+)?(default\s+)?(?:async\s+)?function\s*([A-Z][A-Za-z0-9_]*)?\s*(?:<[^>(]*>)?\s*\(`)
+	compConstRe     = regexp.MustCompile(`(?m)^[ \t]*(export\s+)?(?:const|let)\s+([A-Z][a-z]\w*)\s*(?::\s*([^=]+?))?\s*=\s*([^\n]*)`)
+	compClassRe     = regexp.MustCompile(`(?m)^[ \t]*(export\s+)?(default\s+)?class\s+([A-Z]\w*)\s+extends\s+(?:React\.)?(?:Pure)?Component\b`)
+	compDefaultRe   = regexp.MustCompile(`\bexport\s+default\s+(?:[\w.]+\(\s*)*([A-Z]\w*)\s*\)*\s*;?\s*(?:\n|$)`)
+	compPropsTypeRe = regexp.MustCompile(`(?:\w+\s*:\s*|<)([A-Z]\w*)`)
+	compWrapperRe   = regexp.MustCompile(`^(?:React\.)?(?:memo|forwardRef|observer)\s*[(<]`)
+	compTypeNameRe  = regexp.MustCompile(`^([A-Z]\w*)`)
+	compTagRe       = regexp.MustCompile(`<([A-Z][\w]*(?:\.[A-Z]\w*)*|[a-z][a-z0-9]*(?:-[a-z0-9]+)+)[\s/>]`)
+	compAttrRe      = regexp.MustCompile(`(?:^|\s)([:@#]?[A-Za-z_][\w\-:.]*)`)
+	compMemberRe    = regexp.MustCompile(`(?m)^\s*(?:readonly\s+)?['"]?([A-Za-z_$][\w$]*)['"]?\??\s*:`)
+	compArrayItemRe = regexp.MustCompile(`['"]([\w$]+)['"]`)
+	compTemplateRe  = regexp.MustCompile(`(?s)<template[^>]*>(.*)</template>`)
+	compScriptRe    = regexp.MustCompile(`(?s)<script[^>]*>(.*?)</script>`)
+)
+
+// buildComponentGraph scans the workspace for React and Vue components and their
+// render sites. Relationships come from imports plus JSX/template tags; components
+// used without an import (global registration, auto-imports) resolve by unique name.
+func buildComponentGraph(ctx context.Context, workspacePath string) (*componentGraphData, error) {
+	g := &componentGraphData{files: map[string]*componentFile{}, components: map[string]*componentDef{}, byFile: map[string][]ComponentUsage{}}
+	resolver := editor.NewTSImportResolver(workspacePath)
+	var files []*componentFile
+	err := filepath.WalkDir(workspacePath, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if d.IsDir() {
+			if path != workspacePath && (skippedTreeDirs[d.Name()] || strings.HasPrefix(d.Name(), ".")) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		ext := strings.ToLower(filepath.Ext(path))
+		switch ext {
+		case ".jsx", ".tsx", ".js", ".ts", ".vue":
+		default:
+			return nil
+		}
+		if strings.HasSuffix(path, ".d.ts") || len(files) >= maxComponentFiles {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil || info.Size() > maxComponentFileBytes {
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil
+		}
+		rel, _ := filepath.Rel(workspacePath, path)
+		f := parseComponentFile(filepath.ToSlash(rel), path, string(data))
+		files = append(files, f)
+		g.files[f.rel] = f
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	byName := map[string][]*componentDef{}
+	for _, f := range files {
+		for _, c := range f.defs {
+			g.components[c.key()] = c
+			byName[c.name] = append(byName[c.name], c)
+		}
+	}
+
+	for _, f := range files {
+		for _, s := range f.sites {
+			u := ComponentUsage{Component: s.name, File: f.rel, Line: s.line, Props: s.props}
+			if in := enclosingComponent(f, s.line); in != nil {
+				u.In = in.name
+			}
+			head, member, _ := strings.Cut(s.name, ".")
+			b, ok := f.bindings[head]
+			if !ok && f.framework == "vue" {
+				// <user-card> in a template refers to the UserCard binding
+				b, ok = f.bindings[pascalCase(head)]
+			}
+			if ok {
+				target := resolver.Resolve(filepath.Dir(f.abs), b.spec)
+				if target == "" {
+					u.Package = b.spec
+				} else if rel, err := filepath.Rel(workspacePath, target); err == nil {
+					if def := importedComponent(g.files[filepath.ToSlash(rel)], b, member); def != nil {
+						u.Defined = def.key()
+					}
+				}
+			} else if def := localComponent(f, head); def != nil {
+				u.Defined = def.key()
+			} else if defs := byName[pascalCase(head)]; len(defs) == 1 {
+				u.Defined, u.ByName = defs[0].key(), true
+			}
+			g.usages = append(g.usages, u)
+			g.byFile[f.rel] = append(g.byFile[f.rel], u)
+		}
+	}
+	return g, nil
+}
+
+// importedComponent finds the component an import binding refers to in file.
+func importedComponent(file *componentFile, b componentBinding, member string) *componentDef {
+	if file == nil {
+		return nil
+	}
+	name := b.imported
+	if member != "" && (b.imported == "*" || b.imported == "default") {
+		// <UI.Button> from import * as UI, or a compound <Menu.Item>
+		name = member
+	}
+	switch name {
+	case "default":
+		for _, c := range file.defs {
+			if c.isDefault {
+				return c
+			}
+		}
+		if len(file.defs) == 1 {
+			return file.defs[0]
+		}
+	case "*":
+		return nil
+	default:
+		for _, c := range file.defs {
+			if c.name == name {
+				return c
+			}
+		}
+	}
+	return nil
+}
+
+func localComponent(f *componentFile, name string) *componentDef {
+	for _, c := range f.defs {
+		if c.name == name || (f.framework == "vue" && c.name == pascalCase(name)) {
+			return c
+		}
+	}
+	return nil
+}
+
+// enclosingComponent returns the component whose definition precedes line.
+func enclosingComponent(f *componentFile, line int) *componentDef {
+	var best *componentDef
+	for _, c := range f.defs {
+		if c.line <= line && (best == nil || c.line > best.line) {
+			best = c
+		}
+	}
+	return best
+}
+
+// parseComponentFile extracts component definitions, import bindings, and render sites.
+func parseComponentFile(rel, abs, content string) *componentFile {
+	f := &componentFile{rel: rel, abs: abs, framework: "react", bindings: map[string]componentBinding{}}
+	if strings.HasSuffix(rel, ".vue") {
+		f.framework = "vue"
+		parseVueComponent(f, content)
+		return f
+	}
+	code := stripJSComments(content)
+	parseComponentImports(f, code)
+	// JSX is not valid in .ts files, and without JSX there are no components to find
+	hasJSX := !strings.HasSuffix(rel, ".ts") && (strings.Contains(code, "/>") || strings.Contains(code, "</"))
+	if !hasJSX {
+		return f
+	}
+	defaultName := compDefaultName(code)
+	add := func(name string, offset int, params string, typeRef string, isDefault bool) {
+		if name == "" {
+			name = pascalCase(strings.TrimSuffix(filepath.Base(rel), filepath.Ext(rel)))
+			if name == "Index" {
+				name = pascalCase(filepath.Base(filepath.Dir(rel)))
+			}
+		}
+		c := &componentDef{name: name, file: rel, framework: "react", line: lineAt(code, offset), isDefault: isDefault || name == defaultName}
+		c.props = componentProps(code, params, typeRef)
+		f.defs = append(f.defs, c)
+	}
+	for _, m := range compFuncRe.FindAllStringSubmatchIndex(code, -1) {
+		isDefault := m[4] >= 0
+		name := ""
+		if m[6] >= 0 {
+			name = code[m[6]:m[7]]
+		} else if !isDefault {
+			continue
+		}
+		params := balancedFrom(code, m[1]-1)
+		add(name, m[0], params, "", isDefault)
+	}
+	for _, m := range compConstRe.FindAllStringSubmatchIndex(code, -1) {
+		name, rhs := code[m[4]:m[5]], code[m[8]:m[9]]
+		typeRef := ""
+		if m[6] >= 0 {
+			typeRef = code[m[6]:m[7]]
+		}
+		// Arrow functions, memo/forwardRef wrappers, and function expressions
+		arrow := strings.Contains(rhs, "=>") || strings.HasPrefix(rhs, "function")
+		wrapped := compWrapperRe.MatchString(rhs)
+		if !arrow && !wrapped {
+			continue
+		}
+		params := ""
+		if k := strings.Index(code[m[8]:], "("); k >= 0 {
+			open := m[8] + k
+			if wrapped {
+				// memo((props) => ...) : parameters of the inner function
+				if k2 := strings.Index(code[open+1:], "("); k2 >= 0 {
+					open = open + 1 + k2
+				}
+			}
+			params = balancedFrom(code, open)
+		}
+		add(name, m[0], params, typeRef, false)
+	}
+	for _, m := range compClassRe.FindAllStringSubmatchIndex(code, -1) {
+		add(code[m[6]:m[7]], m[0], "", "", m[4] >= 0)
+	}
+	sort.Slice(f.defs, func(i, j int) bool { return f.defs[i].line < f.defs[j].line })
+	f.sites = jsxSites(code, 0, false)
+	return f
+}
+
+// parseVueComponent handles a single-file component: the file is the component.
+func parseVueComponent(f *componentFile, content string) {
+	name := pascalCase(strings.TrimSuffix(filepath.Base(f.rel), ".vue"))
+	if name == "Index" {
+		name = pascalCase(filepath.Base(filepath.Dir(f.rel)))
+	}
+	c := &componentDef{name: name, file: f.rel, framework: "vue", line: 1, isDefault: true}
+	for _, m := range compScriptRe.FindAllStringSubmatch(content, -1) {
+		script := stripJSComments(m[1])
+		parseComponentImports(f, script)
+		c.props = append(c.props, vueProps(script)...)
+	}
+	f.defs = []*componentDef{c}
+	if loc := compTemplateRe.FindStringSubmatchIndex(content); loc != nil {
+		f.sites = jsxSites(content[:loc[3]], loc[2], true)
+	}
+}
+
+func parseComponentImports(f *componentFile, code string) {
+	for _, m := range compImportRe.FindAllStringSubmatch(code, -1) {
+		clause, spec := strings.TrimSpace(m[1]), m[2]
+		named := ""
+		if i := strings.Index(clause, "{"); i >= 0 {
+			if j := strings.Index(clause, "}"); j > i {
+				named = clause[i+1 : j]
+				clause = strings.TrimSpace(clause[:i] + clause[j+1:])
+			}
+		}
+		for _, part := range strings.Split(clause, ",") {
+			part = strings.TrimSpace(part)
+			switch {
+			case part == "":
+			case strings.HasPrefix(part, "*"):
+				if fields := strings.Fields(part); len(fields) == 3 {
+					f.bindings[fields[2]] = componentBinding{spec: spec, imported: "*"}
+				}
+			default:
+				f.bindings[part] = componentBinding{spec: spec, imported: "default"}
+			}
+		}
+		for _, part := range strings.Split(named, ",") {
+			fields := strings.Fields(strings.TrimPrefix(strings.TrimSpace(part), "type "))
+			switch len(fields) {
+			case 1:
+				f.bindings[fields[0]] = componentBinding{spec: spec, imported: fields[0]}
+			case 3:
+				f.bindings[fields[2]] = componentBinding{spec: spec, imported: fields[0]}
+			}
+		}
+	}
+	for _, m := range compLazyRe.FindAllStringSubmatch(code, -1) {
+		f.bindings[m[1]] = componentBinding{spec: m[2], imported: "default"}
+	}
+}
+
+// jsxSites finds component tags in code (offset is added to report lines for
+// templates embedded in a larger file).
+func jsxSites(code string, offset int, template bool) []componentSite {
+	var sites []componentSite
+	for _, m := range compTagRe.FindAllStringSubmatchIndex(code[offset:], -1) {
+		start := offset + m[0]
+		name := code[offset+m[2] : offset+m[3]]
+		if !template {
+			if strings.Contains(name, "-") {
+				continue // custom elements in JSX are not components
+			}
+			// Skip TypeScript generics such as useState<User>() and Array<Item>
+			if start > 0 {
+				prev := code[start-1]
+				if prev == '_' || prev == '$' || prev == '.' || isAlnum(prev) {
+					continue
+				}
+			}
+		}
+		end := tagEnd(code, offset+m[3])
+		sites = append(sites, componentSite{name: name, line: lineAt(code, start), props: tagProps(code[offset+m[3]:end], template)})
+	}
+	return sites
+}
+
+// tagEnd returns the offset of the '>' closing the opening tag that starts before i.
+func tagEnd(code string, i int) int {
+	depth := 0
+	for ; i < len(code); i++ {
+		switch c := code[i]; c {
+		case '{':
+			depth++
+		case '}':
+			depth--
+		case '"', '\'', '`':
+			for i++; i < len(code) && code[i] != c; i++ {
+				if code[i] == '\\' {
+					i++
+				}
+			}
+		case '>':
+			if depth <= 0 {
+				return i
+			}
+		}
+	}
+	return len(code)
+}
+
+// tagProps lists the attribute names in an opening tag, without events and directives.
+func tagProps(attrs string, template bool) []string {
+	// Blank expression and string contents so only attribute names remain
+	var b strings.Builder
+	depth := 0
+	for i := 0; i < len(attrs); i++ {
+		c := attrs[i]
+		switch {
+		case c == '{':
+			depth++
+		case c == '}':
+			depth--
+		case c == '"' || c == '\'' || c == '`':
+			for i++; i < len(attrs) && attrs[i] != c; i++ {
+				if attrs[i] == '\\' {
+					i++
+				}
+			}
+			b.WriteByte(' ')
+		case depth == 0:
+			b.WriteByte(c)
+		}
+	}
+	var props []string
+	seen := map[string]bool{}
+	for _, m := range compAttrRe.FindAllStringSubmatch(strings.ReplaceAll(b.String(), "=", " "), -1) {
+		name := m[1]
+		if template {
+			switch {
+			case strings.HasPrefix(name, "@") || strings.HasPrefix(name, "#") || strings.HasPrefix(name, "v-on:") || strings.HasPrefix(name, "v-slot"):
+				continue
+			case strings.HasPrefix(name, "v-bind:"):
+				name = strings.TrimPrefix(name, "v-bind:")
+			case strings.HasPrefix(name, ":"):
+				name = strings.TrimPrefix(name, ":")
+			case name == "v-model":
+				name = "modelValue"
+			case strings.HasPrefix(name, "v-model:"):
+				name = strings.TrimPrefix(name, "v-model:")
+			case strings.HasPrefix(name, "v-"):
+				continue
+			}
+		}
+		if name == "key" || name == "ref" || name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		props = append(props, name)
+	}
+	return props
+}
+
+// componentProps reads prop names from a destructured parameter list or, failing
+// that, from the props interface or type alias named in the signature.
+func componentProps(code, params, typeRef string) []string {
+	params = strings.TrimSpace(params)
+	if strings.HasPrefix(params, "(") && strings.HasSuffix(params, ")") {
+		params = strings.TrimSpace(params[1 : len(params)-1])
+	}
+	if strings.HasPrefix(params, "{") {
+		end := matchBracket(params, 0)
+		if end > 0 {
+			var props []string
+			for _, part := range splitTopLevel(params[1:end]) {
+				name := strings.TrimSpace(part)
+				if strings.HasPrefix(name, "...") || name == "" {
+					continue
+				}
+				if k := strings.IndexAny(name, ":="); k >= 0 {
+					name = strings.TrimSpace(name[:k])
+				}
+				props = append(props, name)
+			}
+			return props
+		}
+	}
+	for _, src := range []string{typeRef, params} {
+		if m := compPropsTypeRe.FindStringSubmatch(src); m != nil {
+			if props := typeMembers(code, m[1]); len(props) > 0 {
+				return props
+			}
+		}
+	}
+	return nil
+}
+
+// typeMembers lists the top-level members of interface name or type name = {...}.
+func typeMembers(code, name string) []string {
+	re := regexp.MustCompile(`(?:interface\s+` + regexp.QuoteMeta(name) + `\b[^{]*|type\s+` + regexp.QuoteMeta(name) + `\s*(?:<[^>]*>)?\s*=\s*)\{`)
+	loc := re.FindStringIndex(code)
+	if loc == nil {
+		return nil
+	}
+	return objectKeys(balancedFrom(code, loc[1]-1))
+}
+
+// objectKeys lists the top-level keys of a {...} literal or type body.
+func objectKeys(body string) []string {
+	body = strings.TrimSpace(body)
+	if !strings.HasPrefix(body, "{") {
+		return nil
+	}
+	end := matchBracket(body, 0)
+	if end < 0 {
+		return nil
+	}
+	// Keep only depth-1 text so nested object types do not contribute keys
+	var b strings.Builder
+	depth := 0
+	for i := 0; i <= end; i++ {
+		switch c := body[i]; c {
+		case '{', '(', '[':
+			depth++
+			if depth == 1 {
+				continue
+			}
+		case '}', ')', ']':
+			depth--
+		}
+		if depth == 1 {
+			c := body[i]
+			if c == ';' || c == ',' {
+				c = '\n'
+			}
+			b.WriteByte(c)
+		}
+	}
+	var keys []string
+	for _, m := range compMemberRe.FindAllStringSubmatch(b.String(), -1) {
+		keys = append(keys, m[1])
+	}
+	return keys
+}
+
+// vueProps reads defineProps (type-based, object, or array syntax) and the options
+// API props option.
+func vueProps(script string) []string {
+	for _, marker := range []string{"defineProps<", "defineProps(", "props:"} {
+		i := strings.Index(script, marker)
+		if i < 0 {
+			continue
+		}
+		rest := strings.TrimSpace(script[i+len(marker):])
+		switch {
+		case marker == "defineProps<" && strings.HasPrefix(rest, "{"):
+			return objectKeys(balancedFrom(rest, 0))
+		case marker == "defineProps<":
+			if m := compTypeNameRe.FindStringSubmatch(rest); m != nil {
+				return typeMembers(script, m[1])
+			}
+		case strings.HasPrefix(rest, "["):
+			var props []string
+			for _, m := range compArrayItemRe.FindAllStringSubmatch(balancedFrom(rest, 0), -1) {
+				props = append(props, m[1])
+			}
+			return props
+		case strings.HasPrefix(rest, "{"):
+			return objectKeys(balancedFrom(rest, 0))
+		}
+	}
+	return nil
+}
+
+func compDefaultName(code string) string {
+	if m := compDefaultRe.FindStringSubmatch(code); m != nil {
+		return m[1]
+	}
+	return ""
+}
+
+// balancedFrom returns code[open:close+1] for the bracket at open, or "".
+func balancedFrom(code string, open int) string {
+	if open < 0 || open >= len(code) {
+		return ""
+	}
+	end := matchBracket(code, open)
+	if end < 0 {
+		return ""
+	}
+	return code[open : end+1]
+}
+
+func lineAt(code string, offset int) int {
+	return strings.Count(code[:offset], "\n") + 1
+}
+
+// pascalCase turns user-card, user_card, and userCard into UserCard.
+func pascalCase(s string) string {
+	var b strings.Builder
+	upper := true
+	for _, r := range s {
+		if r == '-' || r == '_' || r == '.' || r == ' ' {
+			upper = true
+			continue
+		}
+		if upper {
+			b.WriteString(strings.ToUpper(string(r)))
+			upper = false
+		} else {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// stripJSComments blanks // and /* */ comments, keeping offsets and string contents.
+func stripJSComments(s string) string {
+	b := []byte(s)
+	for i := 0; i < len(b); i++ {
+		switch c := b[i]; {
+		case c == '"' || c == '\'' || c == '`':
+			for i++; i < len(b) && b[i] != c && (c == '`' || b[i] != '\n'); i++ {
+				if b[i] == '\\' {
+					i++
+				}
+			}
+		case c == '/' && i+1 < len(b) && b[i+1] == '/':
+			for ; i < len(b) && b[i] != '\n'; i++ {
+				b[i] = ' '
+			}
+		case c == '/' && i+1 < len(b) && b[i+1] == '*':
+			for ; i < len(b) && !(b[i] == '*' && i+1 < len(b) && b[i+1] == '/'); i++ {
+				if b[i] != '\n' {
+					b[i] = ' '
+				}
+			}
+			if i+1 < len(b) {
+				b[i], b[i+1] = ' ', ' '
+				i++
+			}
+		}
+	}
+	return string(b)
+}
@@ -0,0 +1,120 @@
+package tool
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestComponentGraph_ReactAndVue(t *testing.T) {
+	root := writeWorkspace(t, map[string]string{
+		"tsconfig.json": `{
+  // aliases
+  "compilerOptions": {"baseUrl": ".", "paths": {"@/*": ["src/*"]}},
+}`,
+		"src/components/UserCard.tsx": `import React from 'react';
+
+interface UserCardProps {
+  user: User;
+  compact?: boolean;
+  onSelect: (id: string) => void;
+}
+
+export function UserCard({ user, compact = false, onSelect }: UserCardProps) {
+  return <div onClick={() => onSelect(user.id)}>{user.name}</div>;
+}
+
+export const Avatar: React.FC<AvatarProps> = (props) => <img src={props.src} />;
+
+type AvatarProps = { src: string; size: number };
+`,
+		"src/pages/Users.tsx": `import { useState } from 'react';
+import { UserCard, Avatar } from '@/components/UserCard';
+import { Button } from '@mui/material';
+
+export default function UsersPage() {
+  const [users] = useState<User[]>([]);
+  return (
+    <section>
+      {/* <UserCard user={ghost} /> */}
+      {users.map((u) => (
+        <UserCard key={u.id} user={u} compact onSelect={(id) => console.log(id)} />
+      ))}
+      <Avatar src="/me.png" size={2} />
+      <Button variant="contained">Add</Button>
+    </section>
+  );
+}
+`,
+		"src/App.jsx": `import Users from './pages/Users';
+
+const App = () => <Users />;
+export default App;
+`,
+		"web/src/components/TodoItem.vue": `<template>
+  <li>{{ title }}</li>
+</template>
+<script setup lang="ts">
+const props = defineProps<{ title: string; done?: boolean }>();
+</script>
+`,
+		"web/src/views/TodoList.vue": `<template>
+  <ul>
+    <todo-item v-for="t in todos" :key="t.id" :title="t.title" :done="t.done" @toggle="toggle(t)" />
+  </ul>
+</template>
+<script>
+import TodoItem from '../components/TodoItem.vue';
+export default {
+  components: { TodoItem },
+  props: ['todos'],
+};
+</script>
+`,
+	})
+	ctx := context.Background()
+
+	res, err := componentGraph(ctx, root, ComponentGraphArgs{Component: "UserCard"})
+	if err != nil {
+		t.Fatalf("component_graph: %v", err)
+	}
+	if len(res.Matches) != 1 || strings.Join(res.Matches[0].Props, ",") != "user,compact,onSelect" || res.Matches[0].RenderedIn != 1 {
+		t.Fatalf("unexpected UserCard definition: %+v", res.Matches)
+	}
+	if len(res.RenderedBy) != 1 {
+		t.Fatalf("expected one render site (comment ignored), got %+v", res.RenderedBy)
+	}
+	site := res.RenderedBy[0]
+	if site.File != "src/pages/Users.tsx" || site.Line != 11 || site.In != "UsersPage" || strings.Join(site.Props, ",") != "user,compact,onSelect" {
+		t.Fatalf("unexpected render site: %+v", site)
+	}
+
+	res, _ = componentGraph(ctx, root, ComponentGraphArgs{Component: "Avatar"})
+	if len(res.Matches) != 1 || strings.Join(res.Matches[0].Props, ",") != "src,size" {
+		t.Fatalf("expected Avatar props from its type alias, got %+v", res.Matches)
+	}
+
+	res, _ = componentGraph(ctx, root, ComponentGraphArgs{File: "src/App.jsx", Depth: 2})
+	var uses []string
+	for _, u := range res.Uses {
+		uses = append(uses, u.Component+"@"+u.Defined+u.Package)
+	}
+	want := "Users@src/pages/Users.tsx#UsersPage UserCard@src/components/UserCard.tsx#UserCard Avatar@src/components/UserCard.tsx#Avatar Button@@mui/material"
+	if strings.Join(uses, " ") != want {
+		t.Fatalf("uses = %v\nwant %s", uses, want)
+	}
+
+	res, _ = componentGraph(ctx, root, ComponentGraphArgs{Component: "TodoItem"})
+	if len(res.Matches) != 1 || strings.Join(res.Matches[0].Props, ",") != "title,done" {
+		t.Fatalf("unexpected Vue definition: %+v", res.Matches)
+	}
+	if len(res.RenderedBy) != 1 || res.RenderedBy[0].File != "web/src/views/TodoList.vue" || res.RenderedBy[0].Line != 3 ||
+		strings.Join(res.RenderedBy[0].Props, ",") != "title,done" {
+		t.Fatalf("unexpected Vue render sites: %+v", res.RenderedBy)
+	}
+
+	res, _ = componentGraph(ctx, root, ComponentGraphArgs{})
+	if res.ComponentCount != 6 || len(res.Top) == 0 {
+		t.Fatalf("unexpected summary: %+v", res)
+	}
+}
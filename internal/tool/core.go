@@ -3,7 +3,9 @@ package tool
 import (
 	"log"
 
+	"github.com/loom/loom/internal/config"
 	"github.com/loom/loom/internal/indexer"
+	"github.com/loom/loom/internal/knowledge"
 )
 
 // RegisterCoreTools registers all core tools with the registry for the given workspace.
@@ -11,6 +13,9 @@ import (
 func RegisterCoreTools(registry *Registry, workspacePath string) {
 	// Create indexer
 	idx := indexer.NewRipgrepIndexer(workspacePath)
+	if settings, err := config.Load(); err == nil {
+		idx.FollowSymlinks = settings.FollowSymlinks
+	}
 
 	// Register file tools
 	if err := RegisterReadFile(registry, workspacePath); err != nil {
@@ -29,10 +34,55 @@ func RegisterCoreTools(registry *Registry, workspacePath string) {
 		log.Printf("Failed to register apply_edit tool: %v", err)
 	}
 
+	// Saved multi-file edits replayed without the model
+	if err := RegisterRunMacro(registry, workspacePath); err != nil {
+		log.Printf("Failed to register run_macro tool: %v", err)
+	}
+
 	if err := RegisterListDir(registry, workspacePath); err != nil {
 		log.Printf("Failed to register list_dir tool: %v", err)
 	}
 
+	if err := RegisterExpandDir(registry, workspacePath); err != nil {
+		log.Printf("Failed to register expand_dir tool: %v", err)
+	}
+
+	if err := RegisterSummarizeTree(registry, workspacePath); err != nil {
+		log.Printf("Failed to register summarize_tree tool: %v", err)
+	}
+
+	// Natural-language lookup over file names, symbols and contents
+	if err := RegisterLocate(registry, workspacePath); err != nil {
+		log.Printf("Failed to register locate tool: %v", err)
+	}
+
+	// Aggregates over large log files without reading them into context
+	if err := RegisterQueryLog(registry, workspacePath); err != nil {
+		log.Printf("Failed to register query_log tool: %v", err)
+	}
+
+	// Structured diff of two files or directory trees
+	if err := RegisterCompare(registry, workspacePath); err != nil {
+		log.Printf("Failed to register compare tool: %v", err)
+	}
+
+	if err := RegisterAnnotateCode(registry, workspacePath); err != nil {
+		log.Printf("Failed to register annotate_code tool: %v", err)
+	}
+
+	// Named points of the conversation's edit timeline
+	if err := RegisterCheckpoint(registry); err != nil {
+		log.Printf("Failed to register checkpoint tool: %v", err)
+	}
+	if err := RegisterUndoEdit(registry); err != nil {
+		log.Printf("Failed to register undo_edit tool: %v", err)
+	}
+
+	// Read-only external documentation packs enabled for this workspace
+	if err := RegisterSearchKnowledge(registry, workspacePath, knowledge.Shared); err != nil {
+		log.Printf("Failed to register search_knowledge tool: %v", err)
+	}
+
 	// Shell tools
 	if err := RegisterRunShell(registry, workspacePath); err != nil {
 		log.Printf("Failed to register run_shell tool: %v", err)
@@ -40,6 +90,14 @@ func RegisterCoreTools(registry *Registry, workspacePath string) {
 	if err := RegisterApplyShell(registry, workspacePath); err != nil {
 		log.Printf("Failed to register apply_shell tool: %v", err)
 	}
+	if err := RegisterResetShell(registry); err != nil {
+		log.Printf("Failed to register reset_shell tool: %v", err)
+	}
+
+	// Scratch code execution outside the workspace
+	if err := RegisterExecuteSnippet(registry); err != nil {
+		log.Printf("Failed to register execute_snippet tool: %v", err)
+	}
 
 	// Git tools
 	if err := RegisterGitTools(registry, workspacePath); err != nil {
@@ -51,11 +109,23 @@ func RegisterCoreTools(registry *Registry, workspacePath string) {
 		log.Printf("Failed to register http_request tool: %v", err)
 	}
 
-	// User-scoped tools (workspace-independent)
-	if err := RegisterMemories(registry); err != nil {
+	// Downloads into the workspace, applied after approval
+	if err := RegisterImportURL(registry, workspacePath); err != nil {
+		log.Printf("Failed to register import_url tool: %v", err)
+	}
+
+	// Deletions move files to the workspace trash after approval
+	if err := RegisterDeleteFile(registry, workspacePath); err != nil {
+		log.Printf("Failed to register delete_file tool: %v", err)
+	}
+
+	// Memories are user-scoped by default, with optional project/directory scopes
+	if err := RegisterMemories(registry, workspacePath); err != nil {
 		log.Printf("Failed to register memories tool: %v", err)
 	}
 
+	// User-scoped tools (workspace-independent)
+
 	if err := RegisterTodoList(registry); err != nil {
 		log.Printf("Failed to register todo_list tool: %v", err)
 	}
@@ -64,6 +134,42 @@ func RegisterCoreTools(registry *Registry, workspacePath string) {
 		log.Printf("Failed to register user_choice tool: %v", err)
 	}
 
+	if err := RegisterAskUser(registry); err != nil {
+		log.Printf("Failed to register ask_user tool: %v", err)
+	}
+
+	if err := RegisterFinalize(registry); err != nil {
+		log.Printf("Failed to register finalize tool: %v", err)
+	}
+
+	if err := RegisterReproduce(registry); err != nil {
+		log.Printf("Failed to register reproduce tool: %v", err)
+	}
+
+	// Framework-aware helpers: Laravel wiring and the React/Vue component graph
+	if err := RegisterFrameworkInfo(registry, workspacePath); err != nil {
+		log.Printf("Failed to register framework_info tool: %v", err)
+	}
+
+	if err := RegisterComponentGraph(registry, workspacePath); err != nil {
+		log.Printf("Failed to register component_graph tool: %v", err)
+	}
+
+	// Infrastructure as code: plan freely, apply only after explicit approval
+	if err := RegisterTerraformTools(registry, workspacePath); err != nil {
+		log.Printf("Failed to register terraform tools: %v", err)
+	}
+
+	// Kubernetes: manifest validation and read-only cluster queries
+	if err := RegisterKubernetesTools(registry, workspacePath); err != nil {
+		log.Printf("Failed to register kubernetes tools: %v", err)
+	}
+
+	// Extended documentation for every registered tool, fetched on demand
+	if err := RegisterToolHelp(registry); err != nil {
+		log.Printf("Failed to register tool_help tool: %v", err)
+	}
+
 	// Project profile tools
 	if err := RegisterProjectProfileTools(registry, workspacePath); err != nil {
 		log.Printf("Failed to register project profile tools: %v", err)
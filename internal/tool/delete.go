@@ -0,0 +1,165 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/loom/loom/internal/editor"
+)
+
+const (
+	// deletePreviewLines is how much of a text file the approval shows.
+	deletePreviewLines = 40
+	// deletePreviewEntries is how many files of a directory the approval lists.
+	deletePreviewEntries = 50
+)
+
+// DeleteFileArgs describes a file or directory to delete.
+type DeleteFileArgs struct {
+	Path string `json:"path"` // workspace-relative
+	// Recursive is required to delete a non-empty directory
+	Recursive bool `json:"recursive,omitempty"`
+}
+
+// RegisterDeleteFile registers the delete_file tool for the given workspace.
+func RegisterDeleteFile(registry *Registry, workspacePath string) error {
+	return registry.Register(Definition{
+		Name:        "delete_file",
+		Description: "Delete a file or directory in the workspace after user approval. Deleted items are moved to the workspace trash (.loom/trash), from where the user can restore them. Prefer this over rm in run_shell.",
+		Safe:        false,
+		AlwaysAsk:   true,
+		JSONSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"path": map[string]interface{}{
+					"type":        "string",
+					"description": "Workspace-relative path of the file or directory to delete",
+				},
+				"recursive": map[string]interface{}{
+					"type":        "boolean",
+					"description": "Required to delete a non-empty directory (default false)",
+				},
+			},
+			"required": []string{"path"},
+		},
+		Examples: []string{
+			`{"path":"internal/legacy/old_client.go"}`,
+			`{"path":"scripts/unused","recursive":true}`,
+		},
+		Handler: func(ctx context.Context, raw json.RawMessage) (interface{}, error) {
+			var args DeleteFileArgs
+			if err := json.Unmarshal(raw, &args); err != nil {
+				return nil, fmt.Errorf("failed to parse arguments: %w", err)
+			}
+			return proposeDelete(workspacePath, args)
+		},
+	})
+}
+
+// proposeDelete checks the target and builds the approval request. Nothing is deleted
+// until ApplyDelete.
+func proposeDelete(workspacePath string, args DeleteFileArgs) (*ExecutionResult, error) {
+	target, rel, err := deleteTarget(workspacePath, args)
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Lstat(target)
+	if err != nil {
+		return nil, err
+	}
+
+	var b strings.Builder
+	if !info.IsDir() {
+		fmt.Fprintf(&b, "Delete %s (%s)\n", rel, humanSize(info.Size()))
+		if data, err := os.ReadFile(target); err == nil && !looksBinary(data[:min(len(data), 8<<10)]) {
+			lines := strings.Split(strings.TrimRight(string(data), "\n"), "\n")
+			b.WriteString("\n")
+			for i, line := range lines {
+				if i == deletePreviewLines {
+					b.WriteString("...\n")
+					break
+				}
+				b.WriteString("-" + line + "\n")
+			}
+		}
+	} else {
+		var files []string
+		var size int64
+		_ = filepath.WalkDir(target, func(p string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return nil
+			}
+			if fi, err := d.Info(); err == nil {
+				size += fi.Size()
+			}
+			r, _ := filepath.Rel(target, p)
+			files = append(files, filepath.ToSlash(r))
+			return nil
+		})
+		fmt.Fprintf(&b, "Delete directory %s (%d files, %s)\n", rel, len(files), humanSize(size))
+		for i, f := range files {
+			if i == deletePreviewEntries {
+				fmt.Fprintf(&b, "... and %d more\n", len(files)-deletePreviewEntries)
+				break
+			}
+			b.WriteString("  " + f + "\n")
+		}
+	}
+	b.WriteString("\nThe deleted item is kept in .loom/trash and can be restored.")
+	return &ExecutionResult{
+		Content: fmt.Sprintf("Propose deleting %s", rel),
+		Diff:    b.String(),
+		Safe:    false,
+	}, nil
+}
+
+// ApplyDelete moves the target of an approved delete_file call to the workspace trash.
+// It is called by the engine only after the user approved the proposal.
+func ApplyDelete(workspacePath string, args DeleteFileArgs) (*editor.TrashEntry, error) {
+	target, _, err := deleteTarget(workspacePath, args)
+	if err != nil {
+		return nil, err
+	}
+	entry, err := editor.MoveToTrash(expandWorkspacePath(workspacePath), target, "delete_file")
+	if err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// deleteTarget resolves the path of a delete_file call and refuses the workspace root,
+// version control and Loom state, and non-empty directories without recursive.
+func deleteTarget(workspacePath string, args DeleteFileArgs) (string, string, error) {
+	if strings.TrimSpace(args.Path) == "" {
+		return "", "", errors.New("path is required")
+	}
+	root := expandWorkspacePath(workspacePath)
+	target, err := validatePath(root, args.Path)
+	if err != nil {
+		return "", "", err
+	}
+	rel, _ := filepath.Rel(filepath.Clean(root), target)
+	rel = filepath.ToSlash(rel)
+	if rel == "." || rel == ".git" || strings.HasPrefix(rel, ".git/") || rel == ".loom" || strings.HasPrefix(rel, ".loom/") {
+		return "", "", fmt.Errorf("cannot delete %q", args.Path)
+	}
+	info, err := os.Lstat(target)
+	if os.IsNotExist(err) {
+		return "", "", fmt.Errorf("%s does not exist", rel)
+	}
+	if err != nil {
+		return "", "", err
+	}
+	if info.IsDir() && !args.Recursive {
+		if entries, err := os.ReadDir(target); err != nil || len(entries) > 0 {
+			return "", "", fmt.Errorf("%s is a non-empty directory; set recursive to delete it", rel)
+		}
+	}
+	return target, rel, nil
+}
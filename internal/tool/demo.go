@@ -0,0 +1,45 @@
+package tool
+
+import "fmt"
+
+// demoAllowedTools may run in demo mode although they are not read-only: they only
+// produce proposals (which are never applied) or keep session state.
+var demoAllowedTools = map[string]bool{
+	"edit_file":     true,
+	"generate_mock": true,
+	"run_macro":     true,
+	"run_shell":     true,
+	"todo_list":     true,
+	"user_choice":   true,
+	"ask_user":      true,
+	"annotate_code": true,
+	"checkpoint":    true, // reverts are refused by the engine
+	"finalize":      true,
+}
+
+// SetDemoMode enables or disables demo mode. While enabled, tools with side effects
+// (applying edits, shell commands, git writes, MCP tools, ...) are not executed and
+// return a notice instead, so a demo can never change the workspace.
+func (r *Registry) SetDemoMode(enabled bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.demo = enabled
+}
+
+// DemoMode reports whether demo mode is enabled.
+func (r *Registry) DemoMode() bool {
+	if r == nil {
+		return false
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.demo
+}
+
+// demoBlockedResult stands in for a tool call skipped in demo mode.
+func demoBlockedResult(name string) *ExecutionResult {
+	return &ExecutionResult{
+		Content: fmt.Sprintf("[demo] %s was not executed: demo mode disables tools that change the workspace.", name),
+		Safe:    true,
+	}
+}
@@ -0,0 +1,52 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestRegistryDemoMode_BlocksWriteTools(t *testing.T) {
+	reg := NewRegistry()
+	calls := map[string]int{}
+	for _, def := range []Definition{
+		{Name: "read_file", ReadOnly: true, Safe: true},
+		{Name: "edit_file"},
+		{Name: "http_request"},
+	} {
+		name := def.Name
+		def.JSONSchema = map[string]interface{}{"type": "object"}
+		def.Handler = func(ctx context.Context, raw json.RawMessage) (interface{}, error) {
+			calls[name]++
+			return "ran", nil
+		}
+		if err := reg.Register(def); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	reg.SetDemoMode(true)
+	for _, name := range []string{"read_file", "edit_file", "http_request"} {
+		res, err := reg.Invoke(context.Background(), name, json.RawMessage(`{}`))
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		blocked, ok := res.(*ExecutionResult)
+		if name == "http_request" {
+			if !ok || !strings.Contains(blocked.Content, "demo mode") || !blocked.Safe {
+				t.Fatalf("expected http_request to be blocked, got %#v", res)
+			}
+		} else if ok {
+			t.Fatalf("expected %s to run, got %#v", name, res)
+		}
+	}
+	if calls["read_file"] != 1 || calls["edit_file"] != 1 || calls["http_request"] != 0 {
+		t.Fatalf("unexpected handler calls: %v", calls)
+	}
+
+	reg.SetDemoMode(false)
+	if _, err := reg.Invoke(context.Background(), "http_request", json.RawMessage(`{}`)); err != nil || calls["http_request"] != 1 {
+		t.Fatalf("expected http_request to run outside demo mode (err=%v, calls=%v)", err, calls)
+	}
+}
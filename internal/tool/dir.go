@@ -5,23 +5,30 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
-	"path/filepath"
 	"sort"
 	"strings"
+
+	"github.com/loom/loom/internal/editor"
 )
 
 // ListDirArgs represents the arguments for the list_dir tool.
 type ListDirArgs struct {
-	Path string `json:"path"`
+	Path  string `json:"path"`
+	Depth int    `json:"depth,omitempty"`
+	// Details returns the directory's own entries with sizes and times instead of a tree
+	Details bool `json:"details,omitempty"`
 }
 
 // ListDirResult represents the result of the list_dir tool.
 type ListDirResult struct {
-	Path     string     `json:"path"`
-	Entries  []DirEntry `json:"entries"`
-	IsDir    bool       `json:"is_dir"`
-	Error    string     `json:"error,omitempty"`
-	FullPath string     `json:"-"` // Full absolute path (not sent to LLM)
+	Path string `json:"path"`
+	// Tree is the compressed listing (see dirTree); Entries is set for files and details
+	Tree      string     `json:"tree,omitempty"`
+	Truncated bool       `json:"truncated,omitempty"`
+	Entries   []DirEntry `json:"entries,omitempty"`
+	IsDir     bool       `json:"is_dir"`
+	Error     string     `json:"error,omitempty"`
+	FullPath  string     `json:"-"` // Full absolute path (not sent to LLM)
 }
 
 // DirEntry represents a single entry in a directory.
@@ -36,8 +43,9 @@ type DirEntry struct {
 func RegisterListDir(registry *Registry, workspacePath string) error {
 	return registry.Register(Definition{
 		Name:        "list_dir",
-		Description: "List the contents of a directory in the workspace",
+		Description: "List a workspace directory as a compact tree (2 levels by default). Single-directory chains share a line, related files are brace-grouped (dir{,_test}.go), and deep, crowded or dependency directories are reduced to counts; use expand_dir to open those.",
 		Safe:        true, // Listing directories is safe
+		ReadOnly:    true,
 		JSONSchema: map[string]interface{}{
 			"type": "object",
 			"properties": map[string]interface{}{
@@ -45,6 +53,14 @@ func RegisterListDir(registry *Registry, workspacePath string) error {
 					"type":        "string",
 					"description": "Path to the directory, relative to the workspace root (default: current directory)",
 				},
+				"depth": map[string]interface{}{
+					"type":        "integer",
+					"description": "Directory levels to show (default 2, max 5)",
+				},
+				"details": map[string]interface{}{
+					"type":        "boolean",
+					"description": "List only this directory's entries with sizes and modification times",
+				},
 			},
 		},
 		Handler: func(ctx context.Context, raw json.RawMessage) (interface{}, error) {
@@ -100,6 +116,27 @@ func listDir(ctx context.Context, workspacePath string, args ListDirArgs) (*List
 		}, nil
 	}
 
+	if !args.Details {
+		depth := args.Depth
+		if depth <= 0 {
+			depth = 2
+		}
+		if depth > 5 {
+			depth = 5
+		}
+		tree, truncated, err := renderDirTree(ctx, absPath, depth, false)
+		if err != nil {
+			return nil, err
+		}
+		return &ListDirResult{
+			Path:      args.Path,
+			IsDir:     true,
+			Tree:      tree,
+			Truncated: truncated,
+			FullPath:  absPath,
+		}, nil
+	}
+
 	// Read directory contents
 	entries, err := os.ReadDir(absPath)
 	if err != nil {
@@ -148,24 +185,8 @@ func listDir(ctx context.Context, workspacePath string, args ListDirArgs) (*List
 	}, nil
 }
 
-// validatePath ensures the path is valid and within the workspace.
+// validatePath ensures the path is valid and within the workspace. See
+// editor.SecurePath for URIs and symlinks.
 func validatePath(workspacePath string, dirPath string) (string, error) {
-	// Convert to absolute path if needed
-	var absPath string
-	if filepath.IsAbs(dirPath) {
-		absPath = dirPath
-	} else {
-		absPath = filepath.Join(workspacePath, dirPath)
-	}
-
-	// Clean the path to remove ../ and ./ segments
-	absPath = filepath.Clean(absPath)
-
-	// Ensure the path is within the workspace
-	workspacePath = filepath.Clean(workspacePath)
-	if !strings.HasPrefix(absPath, workspacePath) {
-		return "", fmt.Errorf("path must be within the workspace")
-	}
-
-	return absPath, nil
+	return editor.SecurePath(workspacePath, dirPath)
 }
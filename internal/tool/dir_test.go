@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -26,8 +27,8 @@ func TestListDir_BasicAndFile(t *testing.T) {
 		t.Fatalf("register list_dir: %v", err)
 	}
 
-	// List directory
-	raw, _ := json.Marshal(ListDirArgs{Path: "."})
+	// List directory entries with details
+	raw, _ := json.Marshal(ListDirArgs{Path: ".", Details: true})
 	res, err := reg.Invoke(context.Background(), "list_dir", raw)
 	if err != nil {
 		t.Fatalf("invoke list_dir: %v", err)
@@ -61,3 +62,81 @@ func TestListDir_BasicAndFile(t *testing.T) {
 		t.Fatalf("unexpected file entry: %+v", lr2.Entries)
 	}
 }
+
+func TestListDir_CompressedTree(t *testing.T) {
+	workspace := t.TempDir()
+	files := []string{
+		"cmd/loom/main.go",
+		"internal/tool/dir.go", "internal/tool/dir_test.go", "internal/tool/tree.go",
+		"internal/engine/deep/a.go", "internal/engine/deep/b.go", "internal/engine/e.go",
+		"node_modules/react/index.js", "node_modules/lodash/index.js",
+		"shot1.png", "shot2.png", "README.md",
+	}
+	for _, f := range files {
+		path := filepath.Join(workspace, f)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+		if err := os.WriteFile(path, []byte("x"), 0o644); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+
+	res, err := listDir(context.Background(), workspace, ListDirArgs{Path: "."})
+	if err != nil {
+		t.Fatalf("list_dir: %v", err)
+	}
+	want := strings.Join([]string{
+		"cmd/loom/ main.go",
+		"internal/",
+		"  engine/ (1 dir, 3 files)",
+		"  tool/ dir{,_test}.go, tree.go",
+		"node_modules/ (2 entries, not expanded)",
+		"README.md, shot{1,2}.png",
+	}, "\n")
+	if res.Tree != want || len(res.Entries) != 0 {
+		t.Fatalf("unexpected tree:\n%s\nwant:\n%s", res.Tree, want)
+	}
+
+	exp, err := expandDir(context.Background(), workspace, ExpandDirArgs{Paths: []string{"node_modules", "internal/engine", "missing"}})
+	if err != nil {
+		t.Fatalf("expand_dir: %v", err)
+	}
+	if exp.Dirs[0].Tree != "lodash/ index.js\nreact/ index.js" {
+		t.Fatalf("unexpected node_modules expansion:\n%s", exp.Dirs[0].Tree)
+	}
+	if exp.Dirs[1].Tree != "deep/ a.go, b.go\ne.go" {
+		t.Fatalf("unexpected engine expansion:\n%s", exp.Dirs[1].Tree)
+	}
+	if exp.Dirs[2].Error == "" {
+		t.Fatalf("expected an error for a missing path")
+	}
+}
+
+func TestListDir_SummarizesCrowdedDirectories(t *testing.T) {
+	workspace := t.TempDir()
+	dir := filepath.Join(workspace, "assets", "icons")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	for i := 0; i < 45; i++ {
+		ext := ".svg"
+		if i%9 == 0 {
+			ext = ".png"
+		}
+		name := filepath.Join(dir, "icon-"+strings.Repeat("x", i%7)+string(rune('a'+i%26))+string(rune('a'+i/26))+ext)
+		if err := os.WriteFile(name, nil, 0o644); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(workspace, "assets", "README.md"), nil, 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	res, err := listDir(context.Background(), workspace, ListDirArgs{Path: ".", Depth: 3})
+	if err != nil {
+		t.Fatalf("list_dir: %v", err)
+	}
+	if !strings.Contains(res.Tree, "  icons/\n    (45 files: 40 .svg, 5 .png)") {
+		t.Fatalf("expected the icons to be summarized:\n%s", res.Tree)
+	}
+}
@@ -0,0 +1,401 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const (
+	// dirTreeMaxLines bounds one rendered tree
+	dirTreeMaxLines = 400
+	// dirTreeInlineFiles is how many files a leaf directory may show on its own line
+	dirTreeInlineFiles = 8
+	// dirTreeMaxFiles and dirTreeMaxDirs bound the entries listed per directory
+	// before the rest is summarized; expand_dir lifts these limits
+	dirTreeMaxFiles = 40
+	dirTreeMaxDirs  = 40
+	// dirTreeCountCap stops counting collapsed directories
+	dirTreeCountCap  = 10000
+	dirTreeLineWidth = 100
+)
+
+// ExpandDirArgs represents the arguments for the expand_dir tool.
+type ExpandDirArgs struct {
+	Paths []string `json:"paths"`
+	Depth int      `json:"depth,omitempty"`
+}
+
+// ExpandedDir is the tree of one expanded path.
+type ExpandedDir struct {
+	Path      string `json:"path"`
+	Tree      string `json:"tree,omitempty"`
+	Truncated bool   `json:"truncated,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// ExpandDirResult is returned by the expand_dir tool.
+type ExpandDirResult struct {
+	Dirs []ExpandedDir `json:"dirs"`
+}
+
+// RegisterExpandDir registers the expand_dir tool, which opens the subpaths a list_dir
+// tree collapsed.
+func RegisterExpandDir(registry *Registry, workspacePath string) error {
+	return registry.Register(Definition{
+		Name:        "expand_dir",
+		Description: "Expand specific subpaths of a list_dir tree: lists every entry (no per-directory summaries), including collapsed directories such as node_modules",
+		Safe:        true,
+		ReadOnly:    true,
+		JSONSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"paths": map[string]interface{}{
+					"type":        "array",
+					"items":       map[string]interface{}{"type": "string"},
+					"description": "Directories to expand, relative to the workspace root (max 10)",
+				},
+				"depth": map[string]interface{}{
+					"type":        "integer",
+					"description": "Directory levels to show below each path (default 3, max 8)",
+				},
+			},
+			"required": []string{"paths"},
+		},
+		Handler: func(ctx context.Context, raw json.RawMessage) (interface{}, error) {
+			var args ExpandDirArgs
+			if err := json.Unmarshal(raw, &args); err != nil {
+				return nil, fmt.Errorf("failed to parse arguments: %w", err)
+			}
+			return expandDir(ctx, workspacePath, args)
+		},
+	})
+}
+
+func expandDir(ctx context.Context, workspacePath string, args ExpandDirArgs) (*ExpandDirResult, error) {
+	if len(args.Paths) == 0 {
+		return nil, fmt.Errorf("paths is required")
+	}
+	if len(args.Paths) > 10 {
+		return nil, fmt.Errorf("at most 10 paths can be expanded at once")
+	}
+	depth := args.Depth
+	if depth <= 0 {
+		depth = 3
+	}
+	if depth > 8 {
+		depth = 8
+	}
+	res := &ExpandDirResult{Dirs: make([]ExpandedDir, 0, len(args.Paths))}
+	for _, p := range args.Paths {
+		out := ExpandedDir{Path: p}
+		abs, err := validatePath(workspacePath, p)
+		if err == nil {
+			if st, serr := os.Stat(abs); serr != nil || !st.IsDir() {
+				err = fmt.Errorf("not a directory: %s", p)
+			}
+		}
+		if err != nil {
+			out.Error = err.Error()
+		} else {
+			out.Tree, out.Truncated, err = renderDirTree(ctx, abs, depth, true)
+			if err != nil {
+				return nil, err
+			}
+		}
+		res.Dirs = append(res.Dirs, out)
+	}
+	return res, nil
+}
+
+// dirListing is one directory's visible entries, sorted by name.
+type dirListing struct {
+	dirs  []string
+	files []string
+}
+
+// dirTree renders directories in the compressed notation list_dir and expand_dir
+// return:
+//
+//	cmd/loom/ main.go
+//	internal/
+//	  adapter/ (6 dirs, 41 files)
+//	  tool/ dir{,_test}.go, tree{,_test}.go
+//	node_modules/ (812 entries, not expanded)
+//
+// Chains of directories holding a single directory share one line, files with a
+// common stem and extension are brace-grouped, directories below the depth limit and
+// dependency directories are reduced to counts, and crowded directories are summarized
+// by extension.
+type dirTree struct {
+	ctx       context.Context
+	full      bool
+	lines     []string
+	truncated bool
+	cache     map[string]dirListing
+}
+
+// renderDirTree renders the directory at abs, depth levels deep. full lists every entry
+// instead of summarizing crowded directories.
+func renderDirTree(ctx context.Context, abs string, depth int, full bool) (string, bool, error) {
+	t := &dirTree{ctx: ctx, full: full, cache: map[string]dirListing{}}
+	t.render(abs, "", depth)
+	if err := ctx.Err(); err != nil {
+		return "", false, err
+	}
+	if t.truncated {
+		t.lines = append(t.lines, "… output truncated; use expand_dir on a subpath")
+	}
+	return strings.Join(t.lines, "\n"), t.truncated, nil
+}
+
+func (t *dirTree) line(s string) bool {
+	if len(t.lines) >= dirTreeMaxLines {
+		t.truncated = true
+		return false
+	}
+	t.lines = append(t.lines, s)
+	return true
+}
+
+func (t *dirTree) read(abs string) dirListing {
+	if l, ok := t.cache[abs]; ok {
+		return l
+	}
+	var l dirListing
+	entries, _ := os.ReadDir(abs)
+	for _, e := range entries {
+		// Hidden entries such as .git are left out, as list_dir always has
+		if strings.HasPrefix(e.Name(), ".") {
+			continue
+		}
+		if e.IsDir() {
+			l.dirs = append(l.dirs, e.Name())
+		} else {
+			l.files = append(l.files, e.Name())
+		}
+	}
+	sort.Strings(l.dirs)
+	sort.Strings(l.files)
+	t.cache[abs] = l
+	return l
+}
+
+func (t *dirTree) render(abs, indent string, depth int) {
+	if t.ctx.Err() != nil {
+		return
+	}
+	l := t.read(abs)
+	dirs := l.dirs
+	if !t.full && len(dirs) > dirTreeMaxDirs {
+		dirs = dirs[:dirTreeMaxDirs]
+	}
+	for _, name := range dirs {
+		if t.truncated || t.ctx.Err() != nil {
+			return
+		}
+		label, path := name+"/", filepath.Join(abs, name)
+		if skippedTreeDirs[name] {
+			n := len(t.read(path).dirs) + len(t.read(path).files)
+			t.line(fmt.Sprintf("%s%s (%d entries, not expanded)", indent, label, n))
+			continue
+		}
+		// A directory holding only one directory shares its line
+		sub := t.read(path)
+		for len(sub.files) == 0 && len(sub.dirs) == 1 && !skippedTreeDirs[sub.dirs[0]] {
+			label += sub.dirs[0] + "/"
+			path = filepath.Join(path, sub.dirs[0])
+			sub = t.read(path)
+		}
+		// A few files cost no more than their count, even past the depth limit
+		switch {
+		case len(sub.dirs) == 0 && len(sub.files) == 0:
+			t.line(indent + label + " (empty)")
+		case len(sub.dirs) == 0 && len(sub.files) <= dirTreeInlineFiles:
+			t.line(indent + label + " " + strings.Join(groupFileNames(sub.files), ", "))
+		case depth <= 1:
+			t.line(indent + label + " (" + countTree(t.ctx, path) + ")")
+		default:
+			if t.line(indent + label) {
+				t.render(path, indent+"  ", depth-1)
+			}
+		}
+	}
+	if more := len(l.dirs) - len(dirs); more > 0 {
+		t.line(fmt.Sprintf("%s… %d more directories", indent, more))
+	}
+
+	// The listed directory's own files are always shown
+	if !t.full && indent != "" && len(l.files) > dirTreeMaxFiles {
+		t.line(indent + "(" + summarizeFiles(l.files) + ")")
+		return
+	}
+	cur := indent
+	for _, g := range groupFileNames(l.files) {
+		if cur != indent && len(cur)+len(g)+2 > dirTreeLineWidth {
+			if !t.line(strings.TrimSuffix(cur, ", ")) {
+				return
+			}
+			cur = indent
+		}
+		cur += g + ", "
+	}
+	if cur != indent {
+		t.line(strings.TrimSuffix(cur, ", "))
+	}
+}
+
+// countTree describes the contents of a collapsed directory, e.g. "6 dirs, 41 files".
+func countTree(ctx context.Context, abs string) string {
+	dirs, files, seen := 0, 0, 0
+	_ = filepath.WalkDir(abs, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || path == abs {
+			return nil
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if seen++; seen > dirTreeCountCap {
+			return filepath.SkipAll
+		}
+		if strings.HasPrefix(d.Name(), ".") {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			dirs++
+			if skippedTreeDirs[d.Name()] {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		files++
+		return nil
+	})
+	count := plural(files, "file")
+	if seen > dirTreeCountCap {
+		count = fmt.Sprintf("%d+ files", files)
+	}
+	if dirs == 0 {
+		return count
+	}
+	return plural(dirs, "dir") + ", " + count
+}
+
+func plural(n int, noun string) string {
+	if n == 1 {
+		return "1 " + noun
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
+
+// summarizeFiles counts files by extension, e.g. "214 files: 180 .ts, 30 .json, 4 other".
+func summarizeFiles(files []string) string {
+	byExt := map[string]int{}
+	for _, f := range files {
+		ext := filepath.Ext(f)
+		if ext == "" || ext == f {
+			ext = "no extension"
+		}
+		byExt[ext]++
+	}
+	exts := make([]string, 0, len(byExt))
+	for ext := range byExt {
+		exts = append(exts, ext)
+	}
+	sort.Slice(exts, func(i, j int) bool {
+		if byExt[exts[i]] != byExt[exts[j]] {
+			return byExt[exts[i]] > byExt[exts[j]]
+		}
+		return exts[i] < exts[j]
+	})
+	var parts []string
+	rest := len(files)
+	for i, ext := range exts {
+		if i == 4 {
+			parts = append(parts, fmt.Sprintf("%d other", rest))
+			break
+		}
+		parts = append(parts, fmt.Sprintf("%d %s", byExt[ext], ext))
+		rest -= byExt[ext]
+	}
+	return fmt.Sprintf("%d files: %s", len(files), strings.Join(parts, ", "))
+}
+
+// groupFileNames brace-groups files sharing a stem and extension, so dir.go and
+// dir_test.go become dir{,_test}.go. The stem ends at the first '_', '-' or '.', or
+// before trailing digits (shot1.png, shot2.png become shot{1,2}.png).
+func groupFileNames(files []string) []string {
+	type group struct {
+		stem, ext string
+		suffixes  []string
+	}
+	var order []*group
+	groups := map[string]*group{}
+	for _, f := range files {
+		ext := filepath.Ext(f)
+		if ext == f {
+			ext = ""
+		}
+		name := strings.TrimSuffix(f, ext)
+		stem := name
+		if i := strings.IndexAny(name, "_-."); i > 0 {
+			stem = name[:i]
+		} else if trimmed := strings.TrimRight(name, "0123456789"); trimmed != "" {
+			stem = trimmed
+		}
+		key := stem + "\x00" + ext
+		g := groups[key]
+		if g == nil {
+			g = &group{stem: stem, ext: ext}
+			groups[key] = g
+			order = append(order, g)
+		}
+		g.suffixes = append(g.suffixes, name[len(stem):])
+	}
+	out := make([]string, 0, len(order))
+	for _, g := range order {
+		if len(g.suffixes) == 1 {
+			out = append(out, g.stem+g.suffixes[0]+g.ext)
+			continue
+		}
+		// Move shared text after the stem out of the braces: ask_user{,_test}.go
+		shared := commonSuffixPrefix(g.suffixes)
+		for i := range g.suffixes {
+			g.suffixes[i] = g.suffixes[i][len(shared):]
+		}
+		// The bare stem reads best first: tsconfig{,.app}.json
+		sort.SliceStable(g.suffixes, func(i, j int) bool { return g.suffixes[i] == "" && g.suffixes[j] != "" })
+		out = append(out, g.stem+shared+"{"+strings.Join(g.suffixes, ",")+"}"+g.ext)
+	}
+	return out
+}
+
+// commonSuffixPrefix returns the prefix all suffixes share when it ends a whole suffix
+// or at a separator, so braces never split a word.
+func commonSuffixPrefix(suffixes []string) string {
+	prefix := suffixes[0]
+	for _, s := range suffixes[1:] {
+		n := 0
+		for n < len(prefix) && n < len(s) && prefix[n] == s[n] {
+			n++
+		}
+		prefix = prefix[:n]
+	}
+	for _, s := range suffixes {
+		if s == prefix {
+			return prefix
+		}
+	}
+	if i := strings.LastIndexAny(prefix, "_-."); i >= 0 {
+		return prefix[:i+1]
+	}
+	return ""
+}
@@ -44,7 +44,7 @@ type EditFileResult struct {
 func RegisterEditFile(registry *Registry, workspacePath string) error {
 	return registry.Register(Definition{
 		Name:        "edit_file",
-		Description: "Edit a file with actions: CREATE, REPLACE (line range), INSERT_BEFORE/INSERT_AFTER (line), DELETE (line range), SEARCH_REPLACE, or ANCHOR_REPLACE (content-anchored). Prefer ANCHOR_REPLACE over line numbers when possible.",
+		Description: "Edit a file with actions: CREATE, REPLACE (line range), INSERT_BEFORE/INSERT_AFTER (line), DELETE (line range), SEARCH_REPLACE, ANCHOR_REPLACE (content-anchored), or SEARCH_REPLACE_BLOCKS (SEARCH/REPLACE blocks in content). Prefer ANCHOR_REPLACE over line numbers when possible.",
 		Safe:        false, // Editing files requires approval
 		JSONSchema: map[string]interface{}{
 			"type": "object",
@@ -56,11 +56,11 @@ func RegisterEditFile(registry *Registry, workspacePath string) error {
 				"action": map[string]interface{}{
 					"type":        "string",
 					"description": "Action to perform",
-					"enum":        []string{"CREATE", "REPLACE", "INSERT_AFTER", "INSERT_BEFORE", "DELETE", "SEARCH_REPLACE", "ANCHOR_REPLACE"},
+					"enum":        []string{"CREATE", "REPLACE", "INSERT_AFTER", "INSERT_BEFORE", "DELETE", "SEARCH_REPLACE", "ANCHOR_REPLACE", "SEARCH_REPLACE_BLOCKS"},
 				},
 				"content": map[string]interface{}{
 					"type":        "string",
-					"description": "Content used for CREATE/REPLACE/INSERT actions",
+					"description": "Content used for CREATE/REPLACE/INSERT actions, or the <<<<<<< SEARCH / ======= / >>>>>>> REPLACE blocks for SEARCH_REPLACE_BLOCKS",
 				},
 				"start_line": map[string]interface{}{
 					"type":        "integer",
@@ -119,19 +119,32 @@ func RegisterEditFile(registry *Registry, workspacePath string) error {
 			// We cannot express conditional requirements here; runtime will validate
 			"required": []string{"path", "action"},
 		},
+		Usage: `The edit is proposed with a diff and applied with apply_edit after approval.
+- ANCHOR_REPLACE: give target (the existing block) and/or anchor_before/anchor_after (unchanged text around it). content replaces the target, or everything between the anchors. Anchors survive earlier edits that shift line numbers.
+- SEARCH_REPLACE replaces every occurrence of old_string; make it unique unless that is intended.
+- SEARCH_REPLACE_BLOCKS: content holds one or more <<<<<<< SEARCH / ======= / >>>>>>> REPLACE blocks; each SEARCH must match exactly one place.
+- REPLACE/DELETE use 1-indexed inclusive start_line/end_line; re-read the file first, since earlier edits move lines.
+- CREATE fails if the file exists.
+- Edits of existing files may change a limited number of lines (300 by default); split larger changes into sequential edits.`,
+		Examples: []string{
+			`{"path":"main.go","action":"ANCHOR_REPLACE","target":"func main() {\n}","content":"func main() {\n\trun()\n}"}`,
+			`{"path":"config.go","action":"SEARCH_REPLACE","old_string":"Timeout = 10","new_string":"Timeout = 30"}`,
+			`{"path":"docs/notes.md","action":"CREATE","content":"# Notes\n"}`,
+		},
 		Handler: func(ctx context.Context, raw json.RawMessage) (interface{}, error) {
 			var args EditFileArgs
 			if err := json.Unmarshal(raw, &args); err != nil {
 				return nil, fmt.Errorf("failed to parse arguments: %w", err)
 			}
 
-			return editFile(ctx, workspacePath, args)
+			return editFile(ctx, workspacePath, args, registry.MaxEditLines())
 		},
 	})
 }
 
-// editFile implements the file editing logic.
-func editFile(ctx context.Context, workspacePath string, args EditFileArgs) (*ExecutionResult, error) {
+// editFile implements the file editing logic. Edits of existing files changing more than
+// maxLines lines are rejected (0 disables the limit).
+func editFile(ctx context.Context, workspacePath string, args EditFileArgs, maxLines int) (*ExecutionResult, error) {
 	// Map args to advanced request
 	adv := editor.AdvancedEditRequest{
 		FilePath:            args.Path,
@@ -163,6 +176,18 @@ func editFile(ctx context.Context, workspacePath string, args EditFileArgs) (*Ex
 		return nil, fmt.Errorf("safety validation failed: %w", err)
 	}
 
+	// Keep each proposal small enough to review
+	if !plan.IsCreation {
+		if err := checkEditSize(args.Path, plan.OldContent, plan.NewContent, maxLines); err != nil {
+			return nil, err
+		}
+	}
+
+	// Never propose an edit that breaks a file which parsed before
+	if err := checkEditSyntax(ctx, workspacePath, args.Path, plan); err != nil {
+		return nil, err
+	}
+
 	// Generate a better diff using git if available
 	diff, err := editor.GenerateGitDiff(plan.OldContent, plan.NewContent, plan.FilePath)
 	if err != nil {
@@ -190,15 +215,31 @@ func editFile(ctx context.Context, workspacePath string, args EditFileArgs) (*Ex
 		message = fmt.Sprintf("File will be edited (SEARCH_REPLACE): %s", args.Path)
 	case editor.ActionAnchorReplace:
 		message = fmt.Sprintf("File will be edited (ANCHOR_REPLACE): %s", args.Path)
+	case editor.ActionSearchReplaceBlocks:
+		message = fmt.Sprintf("File will be edited (SEARCH_REPLACE_BLOCKS): %s", args.Path)
 	default:
 		message = fmt.Sprintf("File will be edited: %s", args.Path)
 	}
 
+	for _, note := range plan.Notes {
+		message += "\n⚠️ " + note
+	}
+
+	// Describe what changed within each line so the model sees renames and value edits
+	// rather than whole replaced lines
+	changes := editor.AnalyzeContentChanges(plan.OldContent, plan.NewContent, args.Path, editor.DefaultDiffMode())
+	if !plan.IsCreation {
+		if summary := editor.FormatChangeSummary(changes, maxChangeSummaryLines); summary != "" {
+			message += "\nChanges:\n" + summary
+		}
+	}
+
 	// Create a result that fits the ExecutionResult interface
 	result := &ExecutionResult{
 		Content: message,
 		Diff:    diff,
 		Safe:    false, // Always require approval for edits
+		Changes: changes,
 	}
 
 	return result, nil
@@ -4,7 +4,6 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"os"
 	"path/filepath"
 	"strings"
 
@@ -49,11 +48,11 @@ func RegisterApplyEdit(registry *Registry, workspacePath string) error {
 				"action": map[string]interface{}{
 					"type":        "string",
 					"description": "Action to perform",
-					"enum":        []string{"CREATE", "REPLACE", "INSERT_AFTER", "INSERT_BEFORE", "DELETE", "SEARCH_REPLACE", "ANCHOR_REPLACE"},
+					"enum":        []string{"CREATE", "REPLACE", "INSERT_AFTER", "INSERT_BEFORE", "DELETE", "SEARCH_REPLACE", "ANCHOR_REPLACE", "SEARCH_REPLACE_BLOCKS"},
 				},
 				"content": map[string]interface{}{
 					"type":        "string",
-					"description": "Content used for CREATE/REPLACE/INSERT actions",
+					"description": "Content used for CREATE/REPLACE/INSERT actions, or the <<<<<<< SEARCH / ======= / >>>>>>> REPLACE blocks for SEARCH_REPLACE_BLOCKS",
 				},
 				"start_line": map[string]interface{}{
 					"type":        "integer",
@@ -151,7 +150,7 @@ func applyEdit(ctx context.Context, workspacePath string, args ApplyEditArgs) (*
 	originalContent := plan.OldContent
 
 	// Apply the edit
-	if err := editor.ApplyEdit(plan); err != nil {
+	if err := editor.ApplyEditWithOptions(plan, editor.WriteOptionsFromEnv(workspacePath)); err != nil {
 		return nil, fmt.Errorf("failed to apply edit: %w", err)
 	}
 
@@ -185,27 +184,42 @@ func applyEdit(ctx context.Context, workspacePath string, args ApplyEditArgs) (*
 		message = fmt.Sprintf("✅ Edited file (SEARCH_REPLACE): %s", args.Path)
 	case editor.ActionAnchorReplace:
 		message = fmt.Sprintf("✅ Edited file (ANCHOR_REPLACE): %s", args.Path)
+	case editor.ActionSearchReplaceBlocks:
+		message = fmt.Sprintf("✅ Edited file (SEARCH_REPLACE_BLOCKS): %s", args.Path)
 	default:
 		message = fmt.Sprintf("✅ Edited file: %s", args.Path)
 	}
 
+	for _, note := range plan.Notes {
+		message += "\n⚠️ " + note
+	}
+
 	// Include verification in the message
 	message += "\n\n" + verificationDiff
 
+	changes := editor.AnalyzeContentChanges(originalContent, actualContent, args.Path, editor.DefaultDiffMode())
+	if originalContent != "" {
+		if summary := editor.FormatChangeSummary(changes, maxChangeSummaryLines); summary != "" {
+			message += "\nLine changes:\n" + summary
+		}
+	}
+
 	return &ExecutionResult{
 		Content: message,
 		Diff:    verificationDiff,
 		Safe:    true,
+		Changes: changes,
 	}, nil
 }
 
-// readFileForVerification reads the file content after an edit for verification purposes.
+// maxChangeSummaryLines caps the per-line change list included in edit results.
+const maxChangeSummaryLines = 20
+
+// readFileForVerification reads the file content after an edit for verification purposes,
+// decoded the same way the edit plan was.
 func readFileForVerification(filePath string) (string, error) {
-	content, err := os.ReadFile(filePath)
-	if err != nil {
-		return "", err
-	}
-	return string(content), nil
+	content, _, err := editor.ReadText(filePath)
+	return content, err
 }
 
 // generateVerificationDiff creates a focused diff showing the changes with context.
@@ -0,0 +1,96 @@
+package tool
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/loom/loom/internal/editor"
+)
+
+// maxListedHunks bounds the changed regions listed when an edit is rejected as too large.
+const maxListedHunks = 12
+
+// SetMaxEditLines limits how many lines one edit_file proposal may add and remove;
+// n <= 0 removes the limit. New files are not limited.
+func (r *Registry) SetMaxEditLines(n int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.maxEditLines = max(n, 0)
+}
+
+// MaxEditLines returns the edit size limit, 0 when edits are not limited.
+func (r *Registry) MaxEditLines() int {
+	if r == nil {
+		return 0
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.maxEditLines
+}
+
+// EditTooLargeError rejects an edit_file proposal that changes more lines than the
+// configured limit. Its message tells the model how to split the edit.
+type EditTooLargeError struct {
+	Path    string
+	Limit   int
+	Added   int
+	Removed int
+	// Hunks are the edit's changed regions in the current file
+	Hunks []editor.ChangeHunk
+}
+
+// checkEditSize returns an EditTooLargeError when the change from oldContent to
+// newContent adds and removes more than limit lines; limit <= 0 disables the check.
+func checkEditSize(path, oldContent, newContent string, limit int) error {
+	if limit <= 0 {
+		return nil
+	}
+	hunks := editor.ChangeHunks(oldContent, newContent)
+	e := &EditTooLargeError{Path: path, Limit: limit, Hunks: hunks}
+	for _, h := range hunks {
+		e.Added += h.Added
+		e.Removed += h.Removed
+	}
+	if e.Added+e.Removed <= limit {
+		return nil
+	}
+	return e
+}
+
+func (e *EditTooLargeError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "edit too large: it changes %d lines of %s (+%d -%d), more than the limit of %d changed lines per edit.\n",
+		e.Added+e.Removed, e.Path, e.Added, e.Removed, e.Limit)
+	fmt.Fprintf(&b, "Split it into smaller sequential edit_file calls of at most %d changed lines each, proposing the next one after the previous one is applied.", e.Limit)
+	if len(e.Hunks) > 1 {
+		b.WriteString(" Group neighbouring regions into one edit. The changed regions are:\n")
+		for i, h := range e.Hunks {
+			if i == maxListedHunks {
+				fmt.Fprintf(&b, "- ... %d more\n", len(e.Hunks)-i)
+				break
+			}
+			fmt.Fprintf(&b, "- %s: +%d -%d\n", hunkLocation(h), h.Added, h.Removed)
+		}
+	} else {
+		b.WriteString("\n")
+	}
+	for _, h := range e.Hunks {
+		if h.Added+h.Removed > e.Limit {
+			fmt.Fprintf(&b, "The change at %s alone exceeds the limit; split it at function or block boundaries.\n", hunkLocation(h))
+			break
+		}
+	}
+	b.WriteString("Prefer ANCHOR_REPLACE, whose anchors survive the line shifts of earlier edits, and re-read the file before using line numbers.")
+	return b.String()
+}
+
+// hunkLocation describes where a hunk is in the current file, e.g. "lines 12-40".
+func hunkLocation(h editor.ChangeHunk) string {
+	switch {
+	case h.Removed == 0:
+		return fmt.Sprintf("insertion before line %d", h.StartLine)
+	case h.StartLine == h.EndLine:
+		return fmt.Sprintf("line %d", h.StartLine)
+	}
+	return fmt.Sprintf("lines %d-%d", h.StartLine, h.EndLine)
+}
@@ -0,0 +1,55 @@
+package tool
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestEditFile_RejectsOversizedEdits(t *testing.T) {
+	dir := t.TempDir()
+	var b strings.Builder
+	for i := 1; i <= 40; i++ {
+		fmt.Fprintf(&b, "line %d\n", i)
+	}
+	original := b.String()
+	mustWriteFile(t, dir, "big.txt", original)
+
+	reg := setupRegistryForTests(t, dir)
+	reg.SetMaxEditLines(10)
+
+	// Two regions of 4 replaced lines each: 16 changed lines
+	updated := strings.NewReplacer("line 3\nline 4\n", "x\ny\n", "line 30\nline 31\n", "p\nq\n",
+		"line 5\nline 6\n", "z\nw\n", "line 32\nline 33\n", "r\ns\n").Replace(original)
+	res := invokeTool(t, reg, "edit_file", map[string]any{"path": "big.txt", "action": "REPLACE", "start_line": 1, "end_line": 40, "content": strings.TrimSuffix(updated, "\n")})
+	if res.Diff != "" {
+		t.Fatalf("expected the oversized edit to be rejected, got a diff")
+	}
+	for _, want := range []string{"changes 16 lines of big.txt", "limit of 10", "lines 3-6: +4 -4", "lines 30-33: +4 -4"} {
+		if !strings.Contains(res.Content, want) {
+			t.Fatalf("expected %q in %q", want, res.Content)
+		}
+	}
+
+	var tooLarge *EditTooLargeError
+	err := checkEditSize("big.txt", original, updated, 10)
+	if !errors.As(err, &tooLarge) || tooLarge.Added != 8 || tooLarge.Removed != 8 || len(tooLarge.Hunks) != 2 {
+		t.Fatalf("unexpected error: %#v", err)
+	}
+
+	// Each region on its own fits
+	res = invokeTool(t, reg, "edit_file", map[string]any{"path": "big.txt", "action": "REPLACE", "start_line": 3, "end_line": 6, "content": "x\ny\nz\nw\n"})
+	if res.Diff == "" {
+		t.Fatalf("expected a diff for the smaller edit")
+	}
+
+	// New files are not limited, and 0 disables the limit
+	if res := invokeTool(t, reg, "edit_file", map[string]any{"path": "new.txt", "action": "CREATE", "content": original}); res.Diff == "" {
+		t.Fatalf("expected new files to be exempt, got %q", res.Content)
+	}
+	reg.SetMaxEditLines(0)
+	if res := invokeTool(t, reg, "edit_file", map[string]any{"path": "big.txt", "action": "REPLACE", "start_line": 1, "end_line": 40, "content": strings.TrimSuffix(updated, "\n")}); res.Diff == "" {
+		t.Fatalf("expected no limit, got %q", res.Content)
+	}
+}
@@ -0,0 +1,26 @@
+package tool
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/loom/loom/internal/editor"
+	"github.com/loom/loom/internal/validation"
+)
+
+// checkEditSyntax rejects an edit whose result no longer parses as the file's language.
+// Files that already failed to parse before the edit are let through, so a partly
+// written file can still be fixed in several steps.
+func checkEditSyntax(ctx context.Context, workspacePath, path string, plan *editor.EditPlan) error {
+	if plan.IsDeletion || !validation.Supported(path) {
+		return nil
+	}
+	err := validation.Check(ctx, workspacePath, path, []byte(plan.NewContent))
+	if err == nil {
+		return nil
+	}
+	if !plan.IsCreation && validation.Check(ctx, workspacePath, path, []byte(plan.OldContent)) != nil {
+		return nil
+	}
+	return fmt.Errorf("edit rejected: the result would not parse: %w. The file was left unchanged; fix the syntax and propose the edit again", err)
+}
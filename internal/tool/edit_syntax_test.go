@@ -0,0 +1,28 @@
+package tool
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEditFile_RejectsSyntaxErrors(t *testing.T) {
+	dir := t.TempDir()
+	mustWriteFile(t, dir, "config.json", "{\n  \"a\": 1\n}\n")
+	mustWriteFile(t, dir, "broken.json", "{\n  \"a\": \n}\n")
+	reg := setupRegistryForTests(t, dir)
+
+	res := invokeTool(t, reg, "edit_file", map[string]any{"path": "config.json", "action": "SEARCH_REPLACE", "old_string": "\"a\": 1", "new_string": "\"a\": 1,"})
+	if res.Diff != "" || !strings.Contains(res.Content, "would not parse") || !strings.Contains(res.Content, "config.json:3") {
+		t.Fatalf("expected the breaking edit to be rejected, got %q", res.Content)
+	}
+
+	// A file that was already broken can be edited towards a fix
+	res = invokeTool(t, reg, "edit_file", map[string]any{"path": "broken.json", "action": "SEARCH_REPLACE", "old_string": "{", "new_string": "{\n  \"b\": 2,"})
+	if res.Diff == "" {
+		t.Fatalf("expected edits of an already broken file to be allowed, got %q", res.Content)
+	}
+
+	if res := invokeTool(t, reg, "edit_file", map[string]any{"path": "new.go", "action": "CREATE", "content": "package main\n\nfunc main() {\n"}); res.Diff != "" {
+		t.Fatalf("expected an unparseable new file to be rejected")
+	}
+}
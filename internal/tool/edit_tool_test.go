@@ -7,6 +7,8 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+
+	"github.com/loom/loom/internal/editor"
 )
 
 func setupRegistryForTests(t *testing.T, workspace string) *Registry {
@@ -285,3 +287,21 @@ func TestEditTool_AnchoredReplace_TargetMatch(t *testing.T) {
 		t.Fatalf("file content mismatch: got %q want %q", got, want)
 	}
 }
+
+func TestEditTool_PreservesEncodingAndLineEndings(t *testing.T) {
+	workspace := t.TempDir()
+	reg := setupRegistryForTests(t, workspace)
+
+	// UTF-16LE with BOM and CRLF endings
+	utf16 := editor.TextFormat{Encoding: editor.EncodingUTF16LE, BOM: true, CRLF: true}
+	mustWriteFile(t, workspace, "win.txt", string(utf16.Encode("first\nsecond\nthird\n")))
+
+	args := ApplyEditArgs{Path: "win.txt", Action: "SEARCH_REPLACE", OldString: "second", NewString: "zweite"}
+	_ = invokeTool(t, reg, "apply_edit", args)
+
+	got := []byte(readFileContent(t, workspace, "win.txt"))
+	want := utf16.Encode("first\nzweite\nthird\n")
+	if string(got) != string(want) {
+		t.Fatalf("file not written back as UTF-16LE/CRLF:\n got %q\nwant %q", got, want)
+	}
+}
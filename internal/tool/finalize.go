@@ -0,0 +1,44 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// FinalizeArgs describes a request to finish the current task.
+type FinalizeArgs struct {
+	Summary string `json:"summary"`
+}
+
+// RegisterFinalize registers the finalize tool. The tool itself only validates its
+// arguments; the engine runs the workspace's definition of done before accepting it.
+func RegisterFinalize(registry *Registry) error {
+	return registry.Register(Definition{
+		Name:        "finalize",
+		Description: "Declare the task complete with a short summary for the user. When the project defines a definition of done (build, tests, lint, todo list), it is verified first and a structured rejection is returned if any check fails.",
+		Safe:        true,
+		JSONSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"summary": map[string]interface{}{
+					"type":        "string",
+					"description": "Final answer shown to the user: what was changed and how it was verified",
+				},
+			},
+			"required": []string{"summary"},
+		},
+		Handler: func(ctx context.Context, raw json.RawMessage) (interface{}, error) {
+			var args FinalizeArgs
+			if err := json.Unmarshal(raw, &args); err != nil {
+				return nil, fmt.Errorf("failed to parse arguments: %w", err)
+			}
+			if strings.TrimSpace(args.Summary) == "" {
+				return nil, errors.New("summary is required")
+			}
+			return &ExecutionResult{Content: args.Summary, Safe: true}, nil
+		},
+	})
+}
@@ -0,0 +1,316 @@
+package tool
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// maxRerunFailed caps how often failed tests are re-run after a failing test command.
+const maxRerunFailed = 5
+
+// Flakiness verdicts of a failed test.
+const (
+	// TestFlaky marks a test that passed at least once when re-run
+	TestFlaky = "flaky"
+	// TestFailing marks a test that failed on every re-run
+	TestFailing = "failing"
+)
+
+// FlakyReport is the outcome of re-running the failures of a test command.
+type FlakyReport struct {
+	// Runner is "go", "pytest", or "command" when the runner is not recognised and
+	// the whole command was re-run
+	Runner string `json:"runner"`
+	Reruns int    `json:"reruns"`
+	// Tests lists each failed test with its re-run results; empty for Runner "command"
+	Tests []FlakyTest `json:"tests,omitempty"`
+	// Passes counts the re-runs of the whole command that succeeded (Runner "command")
+	Passes int `json:"passes,omitempty"`
+	// Flaky and Failing count the tests (or, for Runner "command", the command) by verdict
+	Flaky   int    `json:"flaky"`
+	Failing int    `json:"failing"`
+	Summary string `json:"summary"`
+}
+
+// FlakyTest is one failed test and how it fared when re-run.
+type FlakyTest struct {
+	Name   string `json:"name"`
+	Passes int    `json:"passes"`
+	Fails  int    `json:"fails"`
+	// Status is TestFlaky or TestFailing
+	Status string `json:"status"`
+}
+
+// testRunner recognises a test command, finds its failed tests and narrows the
+// command to them.
+type testRunner struct {
+	name string
+	// match reports whether tokens (binary first) run this test runner
+	match func(tokens []string) bool
+	// failed returns the failed tests named in the output
+	failed func(output string) []string
+	// narrow returns the command tokens that run only the given tests
+	narrow func(tokens, tests []string) []string
+	// outcomes returns the tests the output reports as passed and as failed
+	outcomes func(output string) (passed, failed map[string]bool)
+}
+
+var (
+	goFailRe     = regexp.MustCompile(`(?m)^\s*--- FAIL: (\S+)`)
+	goOutcomeRe  = regexp.MustCompile(`(?m)^--- (PASS|FAIL): (\S+)`)
+	pytestFailRe = regexp.MustCompile(`(?m)^(?:FAILED|ERROR) (\S+::\S+)`)
+	pytestOutRe  = regexp.MustCompile(`(?m)^(PASSED|FAILED|ERROR) (\S+::\S+)`)
+)
+
+var testRunners = []testRunner{
+	{
+		name: "go",
+		match: func(t []string) bool {
+			return len(t) > 1 && filepath.Base(t[0]) == "go" && t[1] == "test"
+		},
+		failed: func(out string) []string {
+			var names []string
+			for _, m := range goFailRe.FindAllStringSubmatch(out, -1) {
+				// Subtests are re-run through their top-level test
+				names = append(names, strings.SplitN(m[1], "/", 2)[0])
+			}
+			return names
+		},
+		narrow: func(t, tests []string) []string {
+			quoted := make([]string, len(tests))
+			for i, name := range tests {
+				quoted[i] = regexp.QuoteMeta(name)
+			}
+			out := []string{t[0], "test", "-v", "-count=1", "-run", "^(" + strings.Join(quoted, "|") + ")$"}
+			return append(out, withoutFlags(t[2:], "-run", "-count", "-v")...)
+		},
+		outcomes: func(out string) (map[string]bool, map[string]bool) {
+			passed, failed := map[string]bool{}, map[string]bool{}
+			for _, m := range goOutcomeRe.FindAllStringSubmatch(out, -1) {
+				if m[1] == "PASS" {
+					passed[m[2]] = true
+				} else {
+					failed[m[2]] = true
+				}
+			}
+			return passed, failed
+		},
+	},
+	{
+		name: "pytest",
+		match: func(t []string) bool {
+			return pytestIndex(t) >= 0
+		},
+		failed: func(out string) []string {
+			var ids []string
+			for _, m := range pytestFailRe.FindAllStringSubmatch(out, -1) {
+				ids = append(ids, m[1])
+			}
+			return ids
+		},
+		narrow: func(t, tests []string) []string {
+			// Keep the interpreter and pytest options, replace the selected paths, and
+			// drop selections and early exits that would skip the failed tests
+			i := pytestIndex(t)
+			out := append([]string{}, t[:i+1]...)
+			rest := withoutFlags(t[i+1:], "-k", "-m", "-x", "--exitfirst", "--lf", "--last-failed")
+			for j, a := range rest {
+				option := strings.HasPrefix(a, "-")
+				value := j > 0 && strings.HasPrefix(rest[j-1], "-") && !strings.Contains(rest[j-1], "=")
+				if option || (value && !strings.ContainsAny(a, "/:") && !strings.HasSuffix(a, ".py")) {
+					out = append(out, a)
+				}
+			}
+			out = append(out, "-rA")
+			return append(out, tests...)
+		},
+		outcomes: func(out string) (map[string]bool, map[string]bool) {
+			passed, failed := map[string]bool{}, map[string]bool{}
+			for _, m := range pytestOutRe.FindAllStringSubmatch(out, -1) {
+				if m[1] == "PASSED" {
+					passed[m[2]] = true
+				} else {
+					failed[m[2]] = true
+				}
+			}
+			return passed, failed
+		},
+	},
+}
+
+// pytestIndex returns the index of the token that names pytest, or -1.
+func pytestIndex(t []string) int {
+	if len(t) == 0 {
+		return -1
+	}
+	if b := filepath.Base(t[0]); b == "pytest" || b == "py.test" {
+		return 0
+	}
+	if len(t) > 2 && strings.HasPrefix(filepath.Base(t[0]), "python") && t[1] == "-m" && t[2] == "pytest" {
+		return 2
+	}
+	return -1
+}
+
+// flagSwitches are the flags withoutFlags drops that take no value.
+var flagSwitches = map[string]bool{"-v": true, "-x": true, "--exitfirst": true, "--lf": true, "--last-failed": true}
+
+// withoutFlags drops the named flags and their values from args.
+func withoutFlags(args []string, names ...string) []string {
+	var out []string
+	for i := 0; i < len(args); i++ {
+		a := args[i]
+		drop := false
+		for _, n := range names {
+			if a == n || strings.HasPrefix(a, n+"=") {
+				drop = true
+				// "-run X" takes the next argument; switches and "-run=X" do not
+				if a == n && !flagSwitches[n] && i+1 < len(args) {
+					i++
+				}
+			}
+		}
+		if !drop {
+			out = append(out, a)
+		}
+	}
+	return out
+}
+
+// commandTokens returns the command's binary and arguments, or nil for shell
+// command lines that are more than a single command.
+func commandTokens(args ApplyShellArgs) []string {
+	if !args.Shell {
+		return append([]string{args.Command}, args.Args...)
+	}
+	if strings.ContainsAny(args.Command, "|&;<>()$`\"'\\\n") {
+		return nil
+	}
+	return strings.Fields(args.Command)
+}
+
+// rerunFailedTests re-runs the failed tests of a failed test command up to
+// args.RerunFailed times, using run to execute each re-run the way the original
+// command ran. Recognised runners (go test, pytest) re-run only the failed tests;
+// other commands are re-run as a whole. It returns nil when the output names no
+// failed test of a recognised runner, e.g. after a build error.
+func rerunFailedTests(ctx context.Context, args ApplyShellArgs, res *ShellResult, run func(ApplyShellArgs) (*ShellResult, error)) *FlakyReport {
+	n := min(args.RerunFailed, maxRerunFailed)
+	if n <= 0 || res.ExitCode == 0 {
+		return nil
+	}
+	base := args
+	base.RerunFailed = 0
+	tokens := commandTokens(args)
+	for _, r := range testRunners {
+		if tokens == nil || !r.match(tokens) {
+			continue
+		}
+		tests := uniqueStrings(r.failed(res.Stdout + "\n" + res.Stderr))
+		if len(tests) == 0 {
+			return nil
+		}
+		narrowed := r.narrow(tokens, tests)
+		rerun := base
+		rerun.Shell, rerun.Command, rerun.Args = false, narrowed[0], narrowed[1:]
+		stats := make(map[string]*FlakyTest, len(tests))
+		for _, t := range tests {
+			stats[t] = &FlakyTest{Name: t}
+		}
+		report := &FlakyReport{Runner: r.name}
+		for i := 0; i < n && ctx.Err() == nil; i++ {
+			out, err := run(rerun)
+			if err != nil {
+				break
+			}
+			report.Reruns++
+			passed, failed := r.outcomes(out.Stdout + "\n" + out.Stderr)
+			for _, t := range tests {
+				// A test missing from the output did not get to run, which counts as failing
+				if passed[t] && !failed[t] {
+					stats[t].Passes++
+				} else {
+					stats[t].Fails++
+				}
+			}
+		}
+		for _, t := range tests {
+			s := stats[t]
+			s.Status = TestFailing
+			if s.Passes > 0 {
+				s.Status = TestFlaky
+				report.Flaky++
+			} else {
+				report.Failing++
+			}
+			report.Tests = append(report.Tests, *s)
+		}
+		report.Summary = flakySummary(report)
+		return report
+	}
+	// Unknown runner: re-run the whole command
+	report := &FlakyReport{Runner: "command"}
+	for i := 0; i < n && ctx.Err() == nil; i++ {
+		out, err := run(base)
+		if err != nil {
+			break
+		}
+		report.Reruns++
+		if out.ExitCode == 0 {
+			report.Passes++
+		}
+	}
+	if report.Passes > 0 {
+		report.Flaky = 1
+	} else {
+		report.Failing = 1
+	}
+	report.Summary = flakySummary(report)
+	return report
+}
+
+// flakySummary describes a report in one sentence the model can act on.
+func flakySummary(r *FlakyReport) string {
+	if r.Reruns == 0 {
+		return "The failed tests could not be re-run."
+	}
+	if r.Runner == "command" {
+		if r.Passes > 0 {
+			return fmt.Sprintf("The command passed %d of %d re-runs: the failure is flaky, not necessarily caused by the change.", r.Passes, r.Reruns)
+		}
+		return fmt.Sprintf("The command failed all %d re-runs: treat the failure as real.", r.Reruns)
+	}
+	var flaky, failing []string
+	for _, t := range r.Tests {
+		if t.Status == TestFlaky {
+			flaky = append(flaky, fmt.Sprintf("%s (passed %d/%d)", t.Name, t.Passes, t.Passes+t.Fails))
+		} else {
+			failing = append(failing, t.Name)
+		}
+	}
+	var parts []string
+	if len(failing) > 0 {
+		parts = append(parts, fmt.Sprintf("failed every re-run, likely real: %s", strings.Join(failing, ", ")))
+	}
+	if len(flaky) > 0 {
+		parts = append(parts, fmt.Sprintf("flaky: %s", strings.Join(flaky, ", ")))
+	}
+	return fmt.Sprintf("Re-ran %d failed test(s) %d time(s); %s.", len(r.Tests), r.Reruns, strings.Join(parts, "; "))
+}
+
+func uniqueStrings(in []string) []string {
+	seen := map[string]bool{}
+	var out []string
+	for _, s := range in {
+		if !seen[s] {
+			seen[s] = true
+			out = append(out, s)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
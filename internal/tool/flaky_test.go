@@ -0,0 +1,69 @@
+package tool
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestRerunFailedTests_Go(t *testing.T) {
+	first := &ShellResult{ExitCode: 1, Stdout: "--- FAIL: TestCache (0.01s)\n    --- FAIL: TestCache/expiry (0.00s)\n--- FAIL: TestParse (0.00s)\nFAIL\nFAIL\texample.com/pkg\t0.02s\n"}
+	var commands []string
+	run := 0
+	report := rerunFailedTests(context.Background(), ApplyShellArgs{Command: "go", Args: []string{"test", "-count", "3", "./...", "-run", "Cache|Parse"}, RerunFailed: 9}, first, func(a ApplyShellArgs) (*ShellResult, error) {
+		commands = append(commands, a.Command+" "+strings.Join(a.Args, " "))
+		run++
+		out := "--- FAIL: TestParse (0.00s)\n"
+		if run == 2 {
+			out += "--- PASS: TestCache (0.01s)\n"
+		} else {
+			out += "--- FAIL: TestCache (0.01s)\n"
+		}
+		return &ShellResult{ExitCode: 1, Stdout: out}, nil
+	})
+	if report == nil || report.Runner != "go" || report.Reruns != maxRerunFailed {
+		t.Fatalf("unexpected report %+v", report)
+	}
+	if commands[0] != "go test -v -count=1 -run ^(TestCache|TestParse)$ ./..." {
+		t.Errorf("unexpected re-run command %q", commands[0])
+	}
+	if report.Flaky != 1 || report.Failing != 1 || report.Tests[0] != (FlakyTest{Name: "TestCache", Passes: 1, Fails: 4, Status: TestFlaky}) || report.Tests[1].Status != TestFailing {
+		t.Errorf("unexpected stats %+v", report.Tests)
+	}
+	if !strings.Contains(report.Summary, "likely real: TestParse") || !strings.Contains(report.Summary, "flaky: TestCache (passed 1/5)") {
+		t.Errorf("unexpected summary %q", report.Summary)
+	}
+}
+
+func TestRerunFailedTests_PytestAndFallback(t *testing.T) {
+	first := &ShellResult{ExitCode: 1, Stdout: "FAILED tests/test_api.py::test_login - AssertionError\nERROR tests/test_db.py::test_pool\n"}
+	var rerun ApplyShellArgs
+	report := rerunFailedTests(context.Background(), ApplyShellArgs{Command: "python -m pytest -x -q tests/ --maxfail 1", Shell: true, RerunFailed: 1}, first, func(a ApplyShellArgs) (*ShellResult, error) {
+		rerun = a
+		return &ShellResult{ExitCode: 0, Stdout: "PASSED tests/test_api.py::test_login\nPASSED tests/test_db.py::test_pool\n"}, nil
+	})
+	if got := rerun.Command + " " + strings.Join(rerun.Args, " "); rerun.Shell || got != "python -m pytest -q --maxfail 1 -rA tests/test_api.py::test_login tests/test_db.py::test_pool" {
+		t.Errorf("unexpected re-run command %q", got)
+	}
+	if report == nil || report.Flaky != 2 || report.Failing != 0 {
+		t.Fatalf("unexpected report %+v", report)
+	}
+
+	// Build errors name no failed test, so there is nothing to re-run
+	if r := rerunFailedTests(context.Background(), ApplyShellArgs{Command: "go", Args: []string{"test", "./..."}, RerunFailed: 2}, &ShellResult{ExitCode: 1, Stderr: "./x.go:3:1: syntax error"}, nil); r != nil {
+		t.Errorf("expected no report for a build error, got %+v", r)
+	}
+
+	// Unknown runners are re-run as a whole
+	runs := 0
+	report = rerunFailedTests(context.Background(), ApplyShellArgs{Command: "npm test && npm run e2e", Shell: true, RerunFailed: 2}, &ShellResult{ExitCode: 1}, func(a ApplyShellArgs) (*ShellResult, error) {
+		runs++
+		if a.Command != "npm test && npm run e2e" || a.RerunFailed != 0 {
+			t.Errorf("unexpected re-run %+v", a)
+		}
+		return &ShellResult{ExitCode: 1}, nil
+	})
+	if runs != 2 || report.Runner != "command" || report.Failing != 1 || !strings.Contains(report.Summary, "failed all 2 re-runs") {
+		t.Errorf("unexpected fallback report %+v", report)
+	}
+}
@@ -0,0 +1,143 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// maxFrameworkRoutes bounds the routes returned in one framework_info call.
+const maxFrameworkRoutes = 300
+
+// FrameworkInfoArgs selects which part of the framework summary to return.
+type FrameworkInfoArgs struct {
+	Section string `json:"section,omitempty"` // summary, routes, migrations, commands, tasks (default: all)
+	Filter  string `json:"filter,omitempty"`  // case-insensitive substring for routes and commands
+}
+
+// FrameworkInfo is the framework_info result.
+type FrameworkInfo struct {
+	Framework        string           `json:"framework"`
+	Version          string           `json:"version,omitempty"`
+	RouteCount       int              `json:"route_count"`
+	Routes           []FrameworkRoute `json:"routes,omitempty"`
+	RoutesTruncated  bool             `json:"routes_truncated,omitempty"`
+	Migrations       []Migration      `json:"migrations,omitempty"`
+	Commands         []CLICommand     `json:"commands,omitempty"`
+	SuggestedActions []ShellAction    `json:"suggested_actions,omitempty"`
+	Notes            []string         `json:"notes,omitempty"`
+}
+
+// FrameworkRoute maps an HTTP route to the code that handles it.
+type FrameworkRoute struct {
+	Methods        []string `json:"methods"`
+	URI            string   `json:"uri"`
+	Name           string   `json:"name,omitempty"`
+	Action         string   `json:"action"` // Controller@method or Closure
+	Middleware     []string `json:"middleware,omitempty"`
+	File           string   `json:"file"`
+	Line           int      `json:"line"`
+	ControllerFile string   `json:"controller_file,omitempty"`
+	ControllerLine int      `json:"controller_line,omitempty"`
+}
+
+// Migration is a schema migration file and the tables it creates or alters.
+type Migration struct {
+	Name    string   `json:"name"`
+	File    string   `json:"file"`
+	Creates []string `json:"creates,omitempty"`
+	Alters  []string `json:"alters,omitempty"`
+}
+
+// CLICommand is a project-defined console command.
+type CLICommand struct {
+	Name        string `json:"name"`
+	Signature   string `json:"signature"`
+	Description string `json:"description,omitempty"`
+	File        string `json:"file"`
+	Line        int    `json:"line"`
+}
+
+// ShellAction is a suggested command, to be proposed with run_shell.
+type ShellAction struct {
+	Description string   `json:"description"`
+	Command     string   `json:"command"`
+	Args        []string `json:"args"`
+	Mutating    bool     `json:"mutating"` // changes the database, caches, or files
+}
+
+// RegisterFrameworkInfo registers the framework_info tool.
+func RegisterFrameworkInfo(registry *Registry, workspacePath string) error {
+	return registry.Register(Definition{
+		Name:        "framework_info",
+		Description: "Detect the web framework (currently Laravel) and return route → controller mappings, migrations, console commands, and common tasks as suggested run_shell actions. Prefer this over search_code for framework wiring questions.",
+		Safe:        true,
+		ReadOnly:    true,
+		JSONSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"section": map[string]interface{}{
+					"type":        "string",
+					"description": "Part to return (default: all)",
+					"enum":        []string{"summary", "routes", "migrations", "commands", "tasks"},
+				},
+				"filter": map[string]interface{}{
+					"type":        "string",
+					"description": "Case-insensitive substring matched against route URIs, names, and actions, and command names",
+				},
+			},
+		},
+		Handler: func(ctx context.Context, raw json.RawMessage) (interface{}, error) {
+			var args FrameworkInfoArgs
+			if len(raw) > 0 {
+				if err := json.Unmarshal(raw, &args); err != nil {
+					return nil, fmt.Errorf("failed to parse arguments: %w", err)
+				}
+			}
+			return frameworkInfo(workspacePath, args)
+		},
+	})
+}
+
+func frameworkInfo(workspacePath string, args FrameworkInfoArgs) (*FrameworkInfo, error) {
+	info, ok := detectLaravel(workspacePath)
+	if !ok {
+		return &FrameworkInfo{Notes: []string{"No supported framework detected (supported: Laravel)"}}, nil
+	}
+
+	if f := strings.ToLower(strings.TrimSpace(args.Filter)); f != "" {
+		var routes []FrameworkRoute
+		for _, r := range info.Routes {
+			if strings.Contains(strings.ToLower(r.URI+" "+r.Name+" "+r.Action), f) {
+				routes = append(routes, r)
+			}
+		}
+		info.Routes = routes
+		var cmds []CLICommand
+		for _, c := range info.Commands {
+			if strings.Contains(strings.ToLower(c.Name), f) {
+				cmds = append(cmds, c)
+			}
+		}
+		info.Commands = cmds
+	}
+	if len(info.Routes) > maxFrameworkRoutes {
+		info.Routes = info.Routes[:maxFrameworkRoutes]
+		info.RoutesTruncated = true
+	}
+
+	switch args.Section {
+	case "summary":
+		info.Routes, info.Migrations, info.Commands = nil, nil, nil
+	case "routes":
+		info.Migrations, info.Commands, info.SuggestedActions = nil, nil, nil
+	case "migrations":
+		info.Routes, info.Commands, info.SuggestedActions = nil, nil, nil
+	case "commands":
+		info.Routes, info.Migrations, info.SuggestedActions = nil, nil, nil
+	case "tasks":
+		info.Routes, info.Migrations, info.Commands = nil, nil, nil
+	}
+	return info, nil
+}
@@ -113,8 +113,9 @@ func RegisterGitTools(registry *Registry, workspacePath string) error {
 					},
 				},
 			},
-			Safe:    true, // Read-only operation
-			Handler: createGitHandler(workspacePath, handleGitStatus),
+			Safe:     true, // Read-only operation
+			ReadOnly: true,
+			Handler:  createGitHandler(workspacePath, handleGitStatus),
 		},
 		{
 			Name:        "git_add",
@@ -293,8 +294,9 @@ func RegisterGitTools(registry *Registry, workspacePath string) error {
 					},
 				},
 			},
-			Safe:    true, // Read-only operation
-			Handler: createGitHandler(workspacePath, handleGitLog),
+			Safe:     true, // Read-only operation
+			ReadOnly: true,
+			Handler:  createGitHandler(workspacePath, handleGitLog),
 		},
 		{
 			Name:        "git_diff",
@@ -334,8 +336,9 @@ func RegisterGitTools(registry *Registry, workspacePath string) error {
 					},
 				},
 			},
-			Safe:    true, // Read-only operation
-			Handler: createGitHandler(workspacePath, handleGitDiff),
+			Safe:     true, // Read-only operation
+			ReadOnly: true,
+			Handler:  createGitHandler(workspacePath, handleGitDiff),
 		},
 		{
 			Name:        "git_branch",
@@ -0,0 +1,136 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// ToolHelpArgs are the arguments of the tool_help tool.
+type ToolHelpArgs struct {
+	Name string `json:"name"`
+}
+
+// ToolParam describes one argument of a tool.
+type ToolParam struct {
+	Name        string   `json:"name"`
+	Type        string   `json:"type,omitempty"`
+	Required    bool     `json:"required,omitempty"`
+	Description string   `json:"description,omitempty"`
+	Enum        []string `json:"enum,omitempty"`
+}
+
+// ToolHelp is the extended documentation of a tool.
+type ToolHelp struct {
+	Name        string      `json:"name"`
+	Description string      `json:"description"`
+	Approval    string      `json:"approval"` // "none" or "required"
+	ReadOnly    bool        `json:"read_only,omitempty"`
+	Version     int         `json:"schema_version"`
+	Parameters  []ToolParam `json:"parameters,omitempty"`
+	Usage       string      `json:"usage,omitempty"`
+	Examples    []string    `json:"examples,omitempty"`
+}
+
+// Help returns the documentation of a registered tool: its parameters from the JSON
+// schema plus the tool's own usage notes and examples, if it has any.
+func (r *Registry) Help(name string) (*ToolHelp, error) {
+	def, ok := r.Get(name)
+	if !ok {
+		return nil, fmt.Errorf("unknown tool %q; available tools: %s", name, strings.Join(r.names(), ", "))
+	}
+	h := &ToolHelp{
+		Name:        def.Name,
+		Description: def.Description,
+		Approval:    "required",
+		ReadOnly:    def.ReadOnly,
+		Version:     def.schemaVersion(),
+		Usage:       def.Usage,
+		Examples:    def.Examples,
+	}
+	if def.Safe {
+		h.Approval = "none"
+	}
+	required := schemaStrings(def.JSONSchema["required"])
+	props, _ := def.JSONSchema["properties"].(map[string]interface{})
+	for pname, raw := range props {
+		spec, _ := raw.(map[string]interface{})
+		p := ToolParam{Name: pname, Required: slices.Contains(required, pname)}
+		p.Type, _ = spec["type"].(string)
+		p.Description, _ = spec["description"].(string)
+		p.Enum = schemaStrings(spec["enum"])
+		h.Parameters = append(h.Parameters, p)
+	}
+	// Required parameters first, then alphabetical
+	sort.Slice(h.Parameters, func(i, j int) bool {
+		a, b := h.Parameters[i], h.Parameters[j]
+		if a.Required != b.Required {
+			return a.Required
+		}
+		return a.Name < b.Name
+	})
+	return h, nil
+}
+
+// names returns the registered tool names, sorted.
+func (r *Registry) names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	out := make([]string, 0, len(r.tools))
+	for name := range r.tools {
+		out = append(out, name)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// schemaStrings reads a JSON schema string list written as []string or []any.
+func schemaStrings(v interface{}) []string {
+	switch list := v.(type) {
+	case []string:
+		return list
+	case []interface{}:
+		out := make([]string, 0, len(list))
+		for _, item := range list {
+			if s, ok := item.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	}
+	return nil
+}
+
+// RegisterToolHelp registers the tool_help tool, which returns detailed usage and
+// examples for any tool in the registry, including ones registered later (MCP, symbols).
+func RegisterToolHelp(registry *Registry) error {
+	return registry.Register(Definition{
+		Name:        "tool_help",
+		Description: "Get detailed usage, parameters and examples for a tool before using it for the first time",
+		Safe:        true,
+		ReadOnly:    true,
+		JSONSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"name": map[string]interface{}{
+					"type":        "string",
+					"description": "Name of the tool to explain",
+				},
+			},
+			"required": []string{"name"},
+		},
+		Handler: func(ctx context.Context, raw json.RawMessage) (interface{}, error) {
+			var args ToolHelpArgs
+			if err := json.Unmarshal(raw, &args); err != nil {
+				return nil, fmt.Errorf("failed to parse arguments: %w", err)
+			}
+			if strings.TrimSpace(args.Name) == "" {
+				return nil, fmt.Errorf("name is required; available tools: %s", strings.Join(registry.names(), ", "))
+			}
+			return registry.Help(strings.TrimSpace(args.Name))
+		},
+	})
+}
@@ -0,0 +1,74 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestToolHelp_DescribesRegisteredTools(t *testing.T) {
+	reg := NewRegistry()
+	if err := RegisterToolHelp(reg); err != nil {
+		t.Fatal(err)
+	}
+	// Tools registered after tool_help are documented too
+	_ = reg.Register(Definition{
+		Name:        "deploy",
+		Description: "Deploy the app",
+		Version:     2,
+		JSONSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"target":  map[string]interface{}{"type": "string", "description": "Environment", "enum": []any{"staging", "prod"}},
+				"dry_run": map[string]interface{}{"type": "boolean"},
+			},
+			"required": []any{"target"},
+		},
+		Usage:    "Deploys the current commit.",
+		Examples: []string{`{"target":"staging"}`},
+		Handler: func(ctx context.Context, raw json.RawMessage) (interface{}, error) {
+			return "ok", nil
+		},
+	})
+
+	res, err := reg.InvokeToolCall(context.Background(), &ToolCall{Name: "tool_help", Args: json.RawMessage(`{"name":"deploy"}`)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var h ToolHelp
+	if err := json.Unmarshal([]byte(res.Content), &h); err != nil {
+		t.Fatalf("unexpected result %q: %v", res.Content, err)
+	}
+	if h.Name != "deploy" || h.Approval != "required" || h.Version != 2 || h.Usage != "Deploys the current commit." || len(h.Examples) != 1 {
+		t.Errorf("unexpected help: %+v", h)
+	}
+	if len(h.Parameters) != 2 || h.Parameters[0].Name != "target" || !h.Parameters[0].Required ||
+		strings.Join(h.Parameters[0].Enum, ",") != "staging,prod" || h.Parameters[1].Name != "dry_run" {
+		t.Errorf("unexpected parameters: %+v", h.Parameters)
+	}
+
+	res, _ = reg.InvokeToolCall(context.Background(), &ToolCall{Name: "tool_help", Args: json.RawMessage(`{"name":"deplo"}`)})
+	if !strings.Contains(res.Content, "unknown tool") || !strings.Contains(res.Content, "deploy, tool_help") {
+		t.Errorf("unknown tools should list the available ones: %q", res.Content)
+	}
+}
+
+func TestToolHelp_CoreToolsHaveUsage(t *testing.T) {
+	reg := NewRegistry()
+	RegisterCoreTools(reg, t.TempDir())
+	for _, name := range []string{"edit_file", "run_shell", "memories"} {
+		h, err := reg.Help(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if h.Usage == "" || len(h.Examples) == 0 {
+			t.Errorf("%s should document usage and examples", name)
+		}
+		for _, ex := range h.Examples {
+			if !json.Valid([]byte(ex)) {
+				t.Errorf("%s example is not valid JSON: %s", name, ex)
+			}
+		}
+	}
+}
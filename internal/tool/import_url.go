@@ -0,0 +1,353 @@
+package tool
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	neturl "net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/loom/loom/internal/editor"
+)
+
+const (
+	// defaultImportMaxBytes is the download limit when the call sets none.
+	defaultImportMaxBytes = 10 << 20
+	// maxImportMaxBytes caps max_bytes.
+	maxImportMaxBytes = 100 << 20
+	// importTimeout bounds one download.
+	importTimeout = 120 * time.Second
+	// maxImportRedirects bounds the redirects followed within the approved host.
+	maxImportRedirects = 5
+)
+
+// importClient downloads approved URLs. Redirects must stay on the scheme and host the
+// user approved, so an approved https URL cannot lead to plain http or another host.
+var importClient = &http.Client{
+	CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		approved := via[0].URL
+		if req.URL.Scheme != approved.Scheme || !strings.EqualFold(req.URL.Host, approved.Host) {
+			return fmt.Errorf("redirected to %s, outside the approved %s://%s; import that URL instead so it can be approved", req.URL.Redacted(), approved.Scheme, approved.Host)
+		}
+		if len(via) > maxImportRedirects {
+			return fmt.Errorf("stopped after %d redirects", maxImportRedirects)
+		}
+		return nil
+	},
+}
+
+// defaultImportTypes are the media types downloads may have unless the call narrows them.
+// A trailing "/" matches a whole family.
+var defaultImportTypes = []string{
+	"text/", "image/", "font/",
+	"application/json", "application/xml", "application/yaml", "application/x-yaml", "application/toml",
+	"application/javascript", "application/pdf", "application/wasm", "application/octet-stream",
+}
+
+// executableMagic are file signatures that are never imported: native executables
+// and libraries (ELF, PE, Mach-O).
+var executableMagic = [][]byte{
+	[]byte("\x7fELF"),
+	[]byte("MZ"),
+	{0xfe, 0xed, 0xfa, 0xce}, {0xfe, 0xed, 0xfa, 0xcf},
+	{0xce, 0xfa, 0xed, 0xfe}, {0xcf, 0xfa, 0xed, 0xfe},
+	{0xca, 0xfe, 0xba, 0xbe},
+}
+
+// ImportURLArgs describes a file to download into the workspace.
+type ImportURLArgs struct {
+	URL          string   `json:"url"`
+	Path         string   `json:"path"`                    // workspace-relative destination
+	SHA256       string   `json:"sha256,omitempty"`        // expected hex digest of the download
+	MaxBytes     int64    `json:"max_bytes,omitempty"`     // default 10 MiB, max 100 MiB
+	ContentTypes []string `json:"content_types,omitempty"` // allowed media types; "text/" matches a family
+	Overwrite    bool     `json:"overwrite,omitempty"`
+}
+
+// URLImportResult is the outcome of an approved import.
+type URLImportResult struct {
+	URL         string `json:"url"`
+	Path        string `json:"path"`
+	Bytes       int64  `json:"bytes"`
+	ContentType string `json:"content_type"`
+	SHA256      string `json:"sha256"`
+	Replaced    bool   `json:"replaced,omitempty"`
+}
+
+// stagedImport is a download in a temporary file, checked but not yet written.
+type stagedImport struct {
+	file   string // temporary copy of the download
+	result URLImportResult
+}
+
+// RegisterImportURL registers the import_url tool for the given workspace.
+func RegisterImportURL(registry *Registry, workspacePath string) error {
+	return registry.Register(Definition{
+		Name:        "import_url",
+		Description: "Download a file (schema, fixture, vendored asset) from an http(s) URL into the workspace. The URL and destination are shown to the user for approval; only then is the file downloaded, checked against size and media type limits and an optional sha256, and written. Prefer this over curl or wget in run_shell.",
+		Safe:        false,
+		AlwaysAsk:   true,
+		JSONSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"url": map[string]interface{}{
+					"type":        "string",
+					"description": "http or https URL to download",
+				},
+				"path": map[string]interface{}{
+					"type":        "string",
+					"description": "Workspace-relative destination file path",
+				},
+				"sha256": map[string]interface{}{
+					"type":        "string",
+					"description": "Expected SHA-256 hex digest; the import is refused if the download differs",
+				},
+				"max_bytes": map[string]interface{}{
+					"type":        "integer",
+					"description": "Largest accepted download in bytes (default 10485760, max 104857600)",
+				},
+				"content_types": map[string]interface{}{
+					"type":        "array",
+					"items":       map[string]interface{}{"type": "string"},
+					"description": "Accepted media types, e.g. [\"application/json\"] or [\"image/\"] for a family (default: text, images, fonts, JSON, XML, YAML, JavaScript, PDF, wasm and octet-stream)",
+				},
+				"overwrite": map[string]interface{}{
+					"type":        "boolean",
+					"description": "Replace the file if it already exists (default false)",
+				},
+			},
+			"required": []string{"url", "path"},
+		},
+		Examples: []string{
+			`{"url":"https://json.schemastore.org/package.json","path":"schemas/package.schema.json","content_types":["application/json"]}`,
+			`{"url":"https://example.com/fixtures/users.csv","path":"testdata/users.csv","sha256":"<64 hex digits>"}`,
+		},
+		Handler: func(ctx context.Context, raw json.RawMessage) (interface{}, error) {
+			var args ImportURLArgs
+			if err := json.Unmarshal(raw, &args); err != nil {
+				return nil, fmt.Errorf("failed to parse arguments: %w", err)
+			}
+			return proposeURLImport(workspacePath, args)
+		},
+	})
+}
+
+// importRequest is a validated import_url call.
+type importRequest struct {
+	url      string
+	target   string // absolute destination in the workspace
+	rel      string
+	sha256   string // expected digest, lower-case; empty when unchecked
+	limit    int64
+	replaced bool
+}
+
+// checkURLImport validates an import_url call without touching the network.
+func checkURLImport(workspacePath string, args ImportURLArgs) (*importRequest, error) {
+	parsed, err := neturl.Parse(strings.TrimSpace(args.URL))
+	if err != nil || parsed.Host == "" || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+		return nil, fmt.Errorf("invalid url %q: only absolute http and https URLs are supported", args.URL)
+	}
+	if strings.TrimSpace(args.Path) == "" {
+		return nil, errors.New("path is required")
+	}
+	target, err := validatePath(workspacePath, args.Path)
+	if err != nil {
+		return nil, err
+	}
+	rel, _ := filepath.Rel(filepath.Clean(workspacePath), target)
+	rel = filepath.ToSlash(rel)
+	if rel == "." || rel == ".git" || strings.HasPrefix(rel, ".git/") {
+		return nil, fmt.Errorf("cannot import into %q", args.Path)
+	}
+	req := &importRequest{url: parsed.String(), target: target, rel: rel}
+	if info, err := os.Stat(target); err == nil {
+		if info.IsDir() {
+			return nil, fmt.Errorf("%s is a directory", rel)
+		}
+		if !args.Overwrite {
+			return nil, fmt.Errorf("%s already exists; set overwrite to replace it", rel)
+		}
+		req.replaced = true
+	}
+	req.sha256 = strings.ToLower(strings.TrimSpace(args.SHA256))
+	if req.sha256 != "" {
+		if b, err := hex.DecodeString(req.sha256); err != nil || len(b) != sha256.Size {
+			return nil, fmt.Errorf("sha256 must be a 64-character hex digest")
+		}
+	}
+	req.limit = args.MaxBytes
+	if req.limit <= 0 {
+		req.limit = defaultImportMaxBytes
+	}
+	req.limit = min(req.limit, maxImportMaxBytes)
+	return req, nil
+}
+
+// proposeURLImport validates the call and builds the approval request. Nothing is
+// downloaded, and the workspace is not touched, until ApplyURLImport.
+func proposeURLImport(workspacePath string, args ImportURLArgs) (*ExecutionResult, error) {
+	req, err := checkURLImport(workspacePath, args)
+	if err != nil {
+		return nil, err
+	}
+	types := args.ContentTypes
+	if len(types) == 0 {
+		types = defaultImportTypes
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "Download %s\n", req.url)
+	fmt.Fprintf(&b, "into %s", req.rel)
+	if req.replaced {
+		b.WriteString(" (replaces the existing file)")
+	}
+	fmt.Fprintf(&b, "\n\nLimit: %s\nAccepted types: %s", humanSize(req.limit), strings.Join(types, ", "))
+	if req.sha256 != "" {
+		fmt.Fprintf(&b, "\nExpected sha256: %s", req.sha256)
+	}
+	b.WriteString("\n\nThe URL is requested only after approval; the download is refused if it breaks these limits.")
+	return &ExecutionResult{
+		Content: fmt.Sprintf("Propose importing %s into %s", req.url, req.rel),
+		Diff:    b.String(),
+		Safe:    false,
+	}, nil
+}
+
+// downloadToTemp fetches url into a temporary file, refusing responses larger than
+// limit, of a media type not allowed, or that look like native executables.
+func downloadToTemp(ctx context.Context, url string, limit int64, allowed []string) (*stagedImport, error) {
+	ctx, cancel := context.WithTimeout(ctx, importTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build request: %w", err)
+	}
+	resp, err := importClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("download failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("download failed: %s", resp.Status)
+	}
+	if resp.ContentLength > limit {
+		return nil, fmt.Errorf("download is %s, above the %s limit", humanSize(resp.ContentLength), humanSize(limit))
+	}
+
+	tmp, err := os.CreateTemp("", "loom-import-*")
+	if err != nil {
+		return nil, err
+	}
+	staged := &stagedImport{file: tmp.Name()}
+	fail := func(err error) (*stagedImport, error) {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return nil, err
+	}
+	hash := sha256.New()
+	var head bytes.Buffer
+	n, err := io.Copy(io.MultiWriter(tmp, hash, &headWriter{buf: &head, max: 8 << 10}), io.LimitReader(resp.Body, limit+1))
+	if err != nil {
+		return fail(fmt.Errorf("download failed: %w", err))
+	}
+	if n > limit {
+		return fail(fmt.Errorf("download exceeds the %s limit", humanSize(limit)))
+	}
+	if err := tmp.Close(); err != nil {
+		return fail(err)
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if mediaType == "" {
+		mediaType, _, _ = mime.ParseMediaType(http.DetectContentType(head.Bytes()))
+	}
+	for _, magic := range executableMagic {
+		if bytes.HasPrefix(head.Bytes(), magic) {
+			_ = os.Remove(tmp.Name())
+			return nil, errors.New("download looks like a native executable; import_url does not fetch executables")
+		}
+	}
+	if !contentTypeAllowed(mediaType, allowed) {
+		_ = os.Remove(tmp.Name())
+		return nil, fmt.Errorf("media type %q is not allowed; pass content_types to accept it", mediaType)
+	}
+	staged.result = URLImportResult{Bytes: n, ContentType: mediaType, SHA256: hex.EncodeToString(hash.Sum(nil))}
+	return staged, nil
+}
+
+// ApplyURLImport downloads the file of an approved import_url call, checks it and
+// writes it into the workspace. It is called by the engine only after the user
+// approved the proposal.
+func ApplyURLImport(ctx context.Context, workspacePath string, args ImportURLArgs) (*URLImportResult, error) {
+	req, err := checkURLImport(workspacePath, args)
+	if err != nil {
+		return nil, err
+	}
+	staged, err := downloadToTemp(ctx, req.url, req.limit, args.ContentTypes)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = os.Remove(staged.file) }()
+	if req.sha256 != "" && staged.result.SHA256 != req.sha256 {
+		return nil, fmt.Errorf("checksum mismatch: expected sha256 %s, downloaded %s", req.sha256, staged.result.SHA256)
+	}
+
+	data, err := os.ReadFile(staged.file)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(req.target), 0o755); err != nil {
+		return nil, err
+	}
+	opts := editor.WriteOptionsFromEnv(workspacePath)
+	if err := editor.WriteFileAtomic(req.target, data, opts.Fsync); err != nil {
+		return nil, err
+	}
+	res := staged.result
+	res.URL, res.Path, res.Replaced = args.URL, req.rel, req.replaced
+	return &res, nil
+}
+
+// contentTypeAllowed matches a media type against an allow list; entries ending in
+// "/" match a family, and "+json"/"+xml" suffix types count as JSON and XML.
+func contentTypeAllowed(mediaType string, allowed []string) bool {
+	if len(allowed) == 0 {
+		allowed = defaultImportTypes
+	}
+	candidates := []string{mediaType}
+	if i := strings.LastIndexByte(mediaType, '+'); i >= 0 {
+		candidates = append(candidates, "application/"+mediaType[i+1:])
+	}
+	for _, a := range allowed {
+		a = strings.ToLower(strings.TrimSpace(a))
+		for _, c := range candidates {
+			if c == a || (strings.HasSuffix(a, "/") && strings.HasPrefix(c, a)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// headWriter keeps the first max bytes written to it.
+type headWriter struct {
+	buf *bytes.Buffer
+	max int
+}
+
+func (w *headWriter) Write(p []byte) (int, error) {
+	if room := w.max - w.buf.Len(); room > 0 {
+		w.buf.Write(p[:min(room, len(p))])
+	}
+	return len(p), nil
+}
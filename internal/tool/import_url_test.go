@@ -0,0 +1,176 @@
+package tool
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func newImportServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(serveImportFile))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func serveImportFile(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/schema.json":
+		w.Header().Set("Content-Type", "application/schema+json; charset=utf-8")
+		_, _ = w.Write([]byte(`{"type": "object"}` + "\n"))
+	case "/big.txt":
+		w.Header().Set("Content-Type", "text/plain")
+		_, _ = w.Write([]byte(strings.Repeat("x", 2048)))
+	case "/tool":
+		w.Header().Set("Content-Type", "application/octet-stream")
+		_, _ = w.Write([]byte("\x7fELF\x02\x01\x01\x00"))
+	case "/page.html":
+		w.Header().Set("Content-Type", "text/html")
+		_, _ = w.Write([]byte("<html></html>"))
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func TestImportURL_ProposeThenApply(t *testing.T) {
+	requests := 0
+	counted := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		// Redirects within the approved host are followed
+		if rest, ok := strings.CutPrefix(r.URL.Path, "/moved"); ok {
+			http.Redirect(w, r, rest, http.StatusFound)
+			return
+		}
+		serveImportFile(w, r)
+	}))
+	t.Cleanup(counted.Close)
+	ws := t.TempDir()
+	args := ImportURLArgs{URL: counted.URL + "/moved/schema.json", Path: "schemas/app.schema.json", ContentTypes: []string{"application/json"}}
+
+	res, err := proposeURLImport(ws, args)
+	if err != nil {
+		t.Fatalf("propose: %v", err)
+	}
+	if res.Safe || !strings.Contains(res.Diff, counted.URL+"/moved/schema.json") || !strings.Contains(res.Diff, "into schemas/app.schema.json") {
+		t.Fatalf("unexpected proposal: %+v", res)
+	}
+	if requests != 0 {
+		t.Fatalf("the proposal must not download anything, made %d requests", requests)
+	}
+	if _, err := os.Stat(filepath.Join(ws, "schemas")); !os.IsNotExist(err) {
+		t.Fatalf("proposal must not touch the workspace")
+	}
+
+	out, err := ApplyURLImport(context.Background(), ws, args)
+	if err != nil {
+		t.Fatalf("apply: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(ws, "schemas", "app.schema.json"))
+	if err != nil || string(data) != "{\"type\": \"object\"}\n" {
+		t.Fatalf("unexpected file: %q, %v", data, err)
+	}
+	if out.SHA256 != sha256Hex(data) || out.Path != "schemas/app.schema.json" || out.ContentType != "application/schema+json" {
+		t.Fatalf("unexpected result: %+v", out)
+	}
+	if requests != 2 {
+		t.Fatalf("expected the redirect and one download after approval, made %d requests", requests)
+	}
+}
+
+func TestImportURL_RefusesRedirectsOffTheApprovedHost(t *testing.T) {
+	srv := newImportServer(t)
+	elsewhere := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, srv.URL+r.URL.Path, http.StatusFound)
+	}))
+	t.Cleanup(elsewhere.Close)
+	ws := t.TempDir()
+	_, err := ApplyURLImport(context.Background(), ws, ImportURLArgs{URL: elsewhere.URL + "/schema.json", Path: "app.schema.json"})
+	if err == nil || !strings.Contains(err.Error(), "outside the approved") {
+		t.Fatalf("expected the redirect to another host to be refused, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(ws, "app.schema.json")); !os.IsNotExist(err) {
+		t.Fatal("nothing may be written after a refused redirect")
+	}
+
+	loop := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, r.URL.Path+"x", http.StatusFound)
+	}))
+	t.Cleanup(loop.Close)
+	if _, err := ApplyURLImport(context.Background(), ws, ImportURLArgs{URL: loop.URL + "/a", Path: "a.txt"}); err == nil || !strings.Contains(err.Error(), "redirects") {
+		t.Fatalf("expected a redirect loop to stop, got %v", err)
+	}
+}
+
+func TestImportURL_Checks(t *testing.T) {
+	srv := newImportServer(t)
+	ws := t.TempDir()
+	if err := os.WriteFile(filepath.Join(ws, "existing.json"), []byte("{}"), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	// Argument errors are caught by the proposal, before approval
+	for _, tt := range []struct {
+		name string
+		args ImportURLArgs
+		want string
+	}{
+		{"bad checksum", ImportURLArgs{URL: srv.URL + "/schema.json", Path: "a.json", SHA256: "abc"}, "64-character"},
+		{"exists", ImportURLArgs{URL: srv.URL + "/schema.json", Path: "existing.json"}, "already exists"},
+		{"outside", ImportURLArgs{URL: srv.URL + "/schema.json", Path: "../escape.json"}, "within the workspace"},
+		{"git", ImportURLArgs{URL: srv.URL + "/schema.json", Path: ".git/config"}, "cannot import"},
+		{"scheme", ImportURLArgs{URL: "file:///etc/passwd", Path: "passwd"}, "http and https"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := proposeURLImport(ws, tt.args)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+	// Download checks run when the approved import is applied
+	for _, tt := range []struct {
+		name string
+		args ImportURLArgs
+		want string
+	}{
+		{"checksum", ImportURLArgs{URL: srv.URL + "/schema.json", Path: "a.json", SHA256: strings.Repeat("0", 64)}, "checksum mismatch"},
+		{"size", ImportURLArgs{URL: srv.URL + "/big.txt", Path: "big.txt", MaxBytes: 1024}, "limit"},
+		{"executable", ImportURLArgs{URL: srv.URL + "/tool", Path: "bin/tool"}, "executable"},
+		{"type", ImportURLArgs{URL: srv.URL + "/page.html", Path: "page.html", ContentTypes: []string{"application/json"}}, "not allowed"},
+		{"status", ImportURLArgs{URL: srv.URL + "/missing", Path: "missing.txt"}, "404"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ApplyURLImport(context.Background(), ws, tt.args)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("expected error containing %q, got %v", tt.want, err)
+			}
+			if _, err := os.Stat(filepath.Join(ws, tt.args.Path)); !os.IsNotExist(err) {
+				t.Fatalf("a refused download must not be written")
+			}
+		})
+	}
+
+	// overwrite replaces an existing file
+	args := ImportURLArgs{URL: srv.URL + "/schema.json", Path: "existing.json", Overwrite: true}
+	if _, err := proposeURLImport(ws, args); err != nil {
+		t.Fatalf("propose overwrite: %v", err)
+	}
+	if res, err := ApplyURLImport(context.Background(), ws, args); err != nil || !res.Replaced {
+		t.Fatalf("apply overwrite: %+v, %v", res, err)
+	}
+}
+
+func TestContentTypeAllowed(t *testing.T) {
+	if !contentTypeAllowed("text/csv", nil) || !contentTypeAllowed("application/vnd.api+json", nil) {
+		t.Fatal("expected text and +json types to be allowed by default")
+	}
+	if contentTypeAllowed("application/x-msdownload", nil) {
+		t.Fatal("expected unknown application types to be refused by default")
+	}
+	if !contentTypeAllowed("image/png", []string{"image/"}) || contentTypeAllowed("text/plain", []string{"image/"}) {
+		t.Fatal("expected family matching to follow the allow list")
+	}
+}
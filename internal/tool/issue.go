@@ -0,0 +1,209 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	// issueMaxDiff caps each pull request diff kept for a primer
+	issueMaxDiff = 12000
+	// issueMaxLinked caps the linked pull requests fetched for an issue
+	issueMaxLinked = 2
+)
+
+// Issue is a GitHub issue or pull request with its discussion.
+type Issue struct {
+	URL    string `json:"url"`
+	Number int    `json:"number"`
+	// PullRequest is true when the URL names a pull request
+	PullRequest bool           `json:"pull_request,omitempty"`
+	Title       string         `json:"title"`
+	Body        string         `json:"body"`
+	State       string         `json:"state"`
+	Labels      []string       `json:"labels,omitempty"`
+	Comments    []IssueComment `json:"comments,omitempty"`
+	// Changes holds the pull request itself, or the pull requests an issue links to
+	Changes []LinkedChange `json:"changes,omitempty"`
+}
+
+// IssueComment is one comment of an issue's discussion.
+type IssueComment struct {
+	Author string `json:"author"`
+	Body   string `json:"body"`
+}
+
+// LinkedChange is a pull request's changed files and (truncated) diff.
+type LinkedChange struct {
+	URL   string   `json:"url"`
+	Title string   `json:"title,omitempty"`
+	Files []string `json:"files"`
+	Diff  string   `json:"diff,omitempty"`
+	// Truncated is true when Diff was cut to issueMaxDiff bytes
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// IssueRef identifies an issue or pull request by its URL.
+type IssueRef struct {
+	Host        string
+	Owner       string
+	Repo        string
+	Number      int
+	PullRequest bool
+}
+
+// URL returns the canonical web URL of the reference.
+func (r IssueRef) URL() string {
+	kind := "issues"
+	if r.PullRequest {
+		kind = "pull"
+	}
+	return fmt.Sprintf("https://%s/%s/%s/%s/%d", r.Host, r.Owner, r.Repo, kind, r.Number)
+}
+
+// ParseIssueURL parses https://<host>/<owner>/<repo>/issues/<n> and .../pull/<n>
+// URLs; GitHub Enterprise hosts are accepted as well.
+func ParseIssueURL(raw string) (IssueRef, error) {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err == nil && u.Host != "" {
+		parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+		if len(parts) >= 4 && (parts[2] == "issues" || parts[2] == "pull") {
+			if n, err := strconv.Atoi(parts[3]); err == nil && n > 0 {
+				return IssueRef{Host: u.Host, Owner: parts[0], Repo: parts[1], Number: n, PullRequest: parts[2] == "pull"}, nil
+			}
+		}
+	}
+	return IssueRef{}, fmt.Errorf("expected an issue or pull request URL like https://github.com/owner/repo/issues/123, got %q", raw)
+}
+
+// FetchIssue loads an issue or pull request with its comments using the GitHub CLI.
+// For a pull request its diff is included; for an issue, the diffs of up to two pull
+// requests linked from its body or comments.
+func FetchIssue(ctx context.Context, workspace, raw string) (*Issue, error) {
+	ref, err := ParseIssueURL(raw)
+	if err != nil {
+		return nil, err
+	}
+	gh, err := exec.LookPath("gh")
+	if err != nil {
+		return nil, errors.New("the GitHub CLI (gh) is not installed; install it and run gh auth login")
+	}
+	if ref.PullRequest {
+		c, issue, err := fetchPullRequest(ctx, gh, workspace, ref.URL())
+		if err != nil {
+			return nil, err
+		}
+		issue.Changes = []LinkedChange{*c}
+		return issue, nil
+	}
+	out, err := runGh(ctx, gh, workspace, "", "issue", "view", ref.URL(), "--json", "number,title,body,state,url,labels,comments")
+	if err != nil {
+		return nil, fmt.Errorf("gh issue view failed: %s", tail(out, 500))
+	}
+	issue, err := parseGhIssue([]byte(out))
+	if err != nil {
+		return nil, err
+	}
+	for _, link := range linkedPullRequests(ref, issue) {
+		if c, _, err := fetchPullRequest(ctx, gh, workspace, link.URL()); err == nil {
+			issue.Changes = append(issue.Changes, *c)
+		}
+	}
+	return issue, nil
+}
+
+// fetchPullRequest loads a pull request and its diff.
+func fetchPullRequest(ctx context.Context, gh, workspace, prURL string) (*LinkedChange, *Issue, error) {
+	out, err := runGh(ctx, gh, workspace, "", "pr", "view", prURL, "--json", "number,title,body,state,url,labels,comments,files")
+	if err != nil {
+		return nil, nil, fmt.Errorf("gh pr view failed: %s", tail(out, 500))
+	}
+	issue, err := parseGhIssue([]byte(out))
+	if err != nil {
+		return nil, nil, err
+	}
+	issue.PullRequest = true
+	var files struct {
+		Files []struct {
+			Path string `json:"path"`
+		} `json:"files"`
+	}
+	_ = json.Unmarshal([]byte(out), &files)
+	c := &LinkedChange{URL: issue.URL, Title: issue.Title, Files: []string{}}
+	for _, f := range files.Files {
+		c.Files = append(c.Files, f.Path)
+	}
+	// A missing diff still leaves the file list
+	if diff, err := runGh(ctx, gh, workspace, "", "pr", "diff", prURL, "--color", "never"); err == nil {
+		c.Diff = diff
+		if len(c.Diff) > issueMaxDiff {
+			c.Diff, c.Truncated = c.Diff[:issueMaxDiff], true
+		}
+	}
+	return c, issue, nil
+}
+
+// parseGhIssue decodes the JSON printed by gh issue view and gh pr view.
+func parseGhIssue(data []byte) (*Issue, error) {
+	var raw struct {
+		Number int    `json:"number"`
+		Title  string `json:"title"`
+		Body   string `json:"body"`
+		State  string `json:"state"`
+		URL    string `json:"url"`
+		Labels []struct {
+			Name string `json:"name"`
+		} `json:"labels"`
+		Comments []struct {
+			Author struct {
+				Login string `json:"login"`
+			} `json:"author"`
+			Body string `json:"body"`
+		} `json:"comments"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("unexpected gh output: %w", err)
+	}
+	issue := &Issue{URL: raw.URL, Number: raw.Number, Title: raw.Title, Body: raw.Body, State: raw.State}
+	for _, l := range raw.Labels {
+		issue.Labels = append(issue.Labels, l.Name)
+	}
+	for _, c := range raw.Comments {
+		issue.Comments = append(issue.Comments, IssueComment{Author: c.Author.Login, Body: c.Body})
+	}
+	return issue, nil
+}
+
+var pullURLRe = regexp.MustCompile(`https?://[^\s/]+/[^\s/]+/[^\s/]+/pull/\d+`)
+
+// linkedPullRequests returns the pull requests of the issue's repository that its
+// body or comments link to, first mention first.
+func linkedPullRequests(ref IssueRef, issue *Issue) []IssueRef {
+	texts := []string{issue.Body}
+	for _, c := range issue.Comments {
+		texts = append(texts, c.Body)
+	}
+	var out []IssueRef
+	seen := map[int]bool{}
+	for _, text := range texts {
+		for _, m := range pullURLRe.FindAllString(text, -1) {
+			pr, err := ParseIssueURL(m)
+			if err != nil || pr.Host != ref.Host || !strings.EqualFold(pr.Owner, ref.Owner) || !strings.EqualFold(pr.Repo, ref.Repo) || seen[pr.Number] {
+				continue
+			}
+			seen[pr.Number] = true
+			out = append(out, pr)
+			if len(out) == issueMaxLinked {
+				return out
+			}
+		}
+	}
+	return out
+}
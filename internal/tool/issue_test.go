@@ -0,0 +1,48 @@
+package tool
+
+import (
+	"testing"
+)
+
+func TestParseIssueURL(t *testing.T) {
+	ref, err := ParseIssueURL("https://github.com/acme/shop/pull/42/files")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ref != (IssueRef{Host: "github.com", Owner: "acme", Repo: "shop", Number: 42, PullRequest: true}) {
+		t.Fatalf("unexpected ref %+v", ref)
+	}
+	if ref.URL() != "https://github.com/acme/shop/pull/42" {
+		t.Errorf("unexpected canonical URL %s", ref.URL())
+	}
+	if ref, err := ParseIssueURL(" https://git.corp.example/team/api/issues/7 "); err != nil || ref.PullRequest || ref.Number != 7 {
+		t.Errorf("unexpected enterprise ref %+v, %v", ref, err)
+	}
+	for _, bad := range []string{"", "#42", "https://github.com/acme/shop", "https://github.com/acme/shop/issues/x", "https://github.com/acme/shop/commit/42"} {
+		if _, err := ParseIssueURL(bad); err == nil {
+			t.Errorf("expected %q to be rejected", bad)
+		}
+	}
+}
+
+func TestParseGhIssueAndLinkedPullRequests(t *testing.T) {
+	issue, err := parseGhIssue([]byte(`{
+		"number": 12, "title": "Checkout fails", "body": "Broken since https://github.com/acme/shop/pull/9.", "state": "OPEN",
+		"url": "https://github.com/acme/shop/issues/12", "labels": [{"name": "bug"}],
+		"comments": [
+			{"author": {"login": "dana"}, "body": "Also see https://github.com/acme/shop/pull/10 and https://github.com/other/repo/pull/3"},
+			{"author": {"login": "lee"}, "body": "Dup of https://github.com/acme/shop/pull/9, and https://github.com/acme/shop/pull/11"}
+		]
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if issue.Title != "Checkout fails" || len(issue.Labels) != 1 || len(issue.Comments) != 2 || issue.Comments[0].Author != "dana" {
+		t.Fatalf("unexpected issue %+v", issue)
+	}
+	ref, _ := ParseIssueURL(issue.URL)
+	links := linkedPullRequests(ref, issue)
+	if len(links) != 2 || links[0].Number != 9 || links[1].Number != 10 {
+		t.Fatalf("unexpected linked pull requests %+v", links)
+	}
+}
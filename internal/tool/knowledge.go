@@ -0,0 +1,118 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/loom/loom/internal/knowledge"
+)
+
+// SearchKnowledgeArgs represents the arguments for the search_knowledge tool.
+type SearchKnowledgeArgs struct {
+	Query      string `json:"query,omitempty"`
+	Pack       string `json:"pack,omitempty"`
+	Path       string `json:"path,omitempty"`
+	Line       int    `json:"line,omitempty"`
+	MaxResults int    `json:"max_results,omitempty"`
+}
+
+// SearchKnowledgeResult lists matching sections, or holds one section's full text
+// when a path was requested.
+type SearchKnowledgeResult struct {
+	Hits    []knowledge.Hit `json:"hits,omitempty"`
+	Section *knowledge.Hit  `json:"section,omitempty"`
+	Content string          `json:"content,omitempty"`
+	Packs   []string        `json:"packs"`
+	Message string          `json:"message,omitempty"`
+}
+
+// RegisterSearchKnowledge registers the search_knowledge tool, which searches the
+// documentation packs enabled for the workspace. open returns the knowledge library.
+func RegisterSearchKnowledge(registry *Registry, workspacePath string, open func() (*knowledge.Library, error)) error {
+	return registry.Register(Definition{
+		Name:        "search_knowledge",
+		Description: "Search the external documentation (API docs, runbooks, wikis) enabled as knowledge packs for this workspace. Returns ranked sections with snippets; pass pack, path and line from a hit to read that whole section. Pack files are read-only and outside the workspace.",
+		Safe:        true,
+		ReadOnly:    true,
+		JSONSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"query": map[string]interface{}{
+					"type":        "string",
+					"description": "Words to search for",
+				},
+				"pack": map[string]interface{}{
+					"type":        "string",
+					"description": "Restrict to one pack ID (required with path)",
+				},
+				"path": map[string]interface{}{
+					"type":        "string",
+					"description": "Document path from a hit; returns the full section instead of searching",
+				},
+				"line": map[string]interface{}{
+					"type":        "integer",
+					"description": "Line of the section to read, from a hit",
+				},
+				"max_results": map[string]interface{}{
+					"type":        "integer",
+					"description": "Maximum number of hits (default: 10)",
+				},
+			},
+		},
+		Handler: func(ctx context.Context, raw json.RawMessage) (interface{}, error) {
+			var args SearchKnowledgeArgs
+			if err := json.Unmarshal(raw, &args); err != nil {
+				return nil, fmt.Errorf("failed to parse arguments: %w", err)
+			}
+			lib, err := open()
+			if err != nil {
+				return nil, fmt.Errorf("knowledge packs unavailable: %w", err)
+			}
+			return searchKnowledge(ctx, lib, workspacePath, args)
+		},
+	})
+}
+
+func searchKnowledge(ctx context.Context, lib *knowledge.Library, workspacePath string, args SearchKnowledgeArgs) (*SearchKnowledgeResult, error) {
+	enabled := lib.Enabled(workspacePath)
+	res := &SearchKnowledgeResult{Packs: enabled}
+	if len(enabled) == 0 {
+		res.Message = "No knowledge packs are enabled for this workspace."
+		return res, nil
+	}
+	if args.Pack != "" {
+		if !slices.Contains(enabled, args.Pack) {
+			return nil, fmt.Errorf("knowledge pack %q is not enabled for this workspace", args.Pack)
+		}
+		enabled = []string{args.Pack}
+	}
+
+	if strings.TrimSpace(args.Path) != "" {
+		if args.Pack == "" {
+			return nil, errors.New("pack is required to read a section")
+		}
+		hit, content, err := lib.Section(ctx, args.Pack, args.Path, args.Line)
+		if err != nil {
+			return nil, err
+		}
+		res.Section, res.Content = &hit, content
+		return res, nil
+	}
+
+	if strings.TrimSpace(args.Query) == "" {
+		return nil, errors.New("query is required")
+	}
+	hits, err := lib.Search(ctx, args.Query, enabled, args.MaxResults)
+	if err != nil {
+		return nil, fmt.Errorf("knowledge search failed: %w", err)
+	}
+	res.Hits = hits
+	if len(hits) == 0 {
+		res.Message = "No matching sections."
+	}
+	return res, nil
+}
@@ -0,0 +1,68 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/loom/loom/internal/knowledge"
+)
+
+func TestSearchKnowledge_OnlyEnabledPacks(t *testing.T) {
+	ctx := context.Background()
+	lib, err := knowledge.Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lib.Close()
+
+	docs := t.TempDir()
+	if err := os.WriteFile(filepath.Join(docs, "oncall.md"), []byte("# Pager rotation\nEscalate to the database team after 15 minutes.\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	pack, err := lib.Add(ctx, docs, "Runbooks")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ws := t.TempDir()
+	registry := NewRegistry()
+	if err := RegisterSearchKnowledge(registry, ws, func() (*knowledge.Library, error) { return lib, nil }); err != nil {
+		t.Fatal(err)
+	}
+	search := func(args SearchKnowledgeArgs) *SearchKnowledgeResult {
+		t.Helper()
+		raw, _ := json.Marshal(args)
+		out, err := registry.Invoke(ctx, "search_knowledge", raw)
+		if err != nil {
+			t.Fatalf("search_knowledge: %v", err)
+		}
+		return out.(*SearchKnowledgeResult)
+	}
+
+	if res := search(SearchKnowledgeArgs{Query: "escalate"}); len(res.Hits) != 0 || res.Message == "" {
+		t.Fatalf("packs must not be searched before they are enabled: %+v", res)
+	}
+
+	if err := lib.SetEnabled(ws, []string{pack.ID}); err != nil {
+		t.Fatal(err)
+	}
+	res := search(SearchKnowledgeArgs{Query: "escalate database"})
+	if len(res.Hits) != 1 || res.Hits[0].Path != "oncall.md" {
+		t.Fatalf("unexpected hits: %+v", res)
+	}
+
+	hit := res.Hits[0]
+	res = search(SearchKnowledgeArgs{Pack: hit.Pack, Path: hit.Path, Line: hit.Line})
+	if res.Section == nil || !strings.Contains(res.Content, "15 minutes") {
+		t.Fatalf("expected the full section, got %+v", res)
+	}
+
+	raw, _ := json.Marshal(SearchKnowledgeArgs{Query: "x", Pack: "nope"})
+	if _, err := registry.Invoke(ctx, "search_knowledge", raw); err == nil {
+		t.Error("expected an error for a pack that is not enabled")
+	}
+}
@@ -0,0 +1,670 @@
+package tool
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// kubectlTimeout bounds a single read-only cluster query.
+const kubectlTimeout = 30 * time.Second
+
+// K8sValidateArgs selects manifests for k8s_validate.
+type K8sValidateArgs struct {
+	Paths             []string `json:"paths,omitempty"` // files or directories; default: whole workspace
+	KubernetesVersion string   `json:"kubernetes_version,omitempty"`
+	Strict            bool     `json:"strict,omitempty"`
+}
+
+// K8sValidateResult reports problems found in Kubernetes manifests.
+type K8sValidateResult struct {
+	Validator string          `json:"validator"` // "kubeconform+builtin" or "builtin"
+	Files     int             `json:"files"`
+	Resources int             `json:"resources"`
+	Valid     int             `json:"valid"`
+	Issues    []ManifestIssue `json:"issues,omitempty"`
+	Skipped   []string        `json:"skipped,omitempty"` // templated files that cannot be validated as-is
+	Notes     []string        `json:"notes,omitempty"`
+}
+
+// ManifestIssue is one problem in a manifest document.
+type ManifestIssue struct {
+	File     string `json:"file"`
+	Line     int    `json:"line,omitempty"`
+	Kind     string `json:"kind,omitempty"`
+	Name     string `json:"name,omitempty"`
+	Severity string `json:"severity"` // error or warning
+	Message  string `json:"message"`
+}
+
+// KubectlArgs describes a read-only kubectl query.
+type KubectlArgs struct {
+	Verb          string `json:"verb"` // get, describe, logs, contexts
+	Resource      string `json:"resource,omitempty"`
+	Name          string `json:"name,omitempty"`
+	Namespace     string `json:"namespace,omitempty"`
+	AllNamespaces bool   `json:"all_namespaces,omitempty"`
+	Selector      string `json:"selector,omitempty"`
+	Context       string `json:"context,omitempty"`
+	Output        string `json:"output,omitempty"` // wide, yaml, json, name
+	Container     string `json:"container,omitempty"`
+	Tail          int    `json:"tail,omitempty"`
+	Previous      bool   `json:"previous,omitempty"`
+}
+
+// KubectlResult is the output of a kubectl query.
+type KubectlResult struct {
+	Context  string `json:"context"`
+	Command  string `json:"command"`
+	ExitCode int    `json:"exit_code"`
+	Output   string `json:"output"`
+}
+
+// removedAPIs maps apiVersion/kind pairs that current clusters no longer serve to
+// their replacement.
+var removedAPIs = map[string]string{
+	"extensions/v1beta1/Deployment":                        "apps/v1",
+	"extensions/v1beta1/DaemonSet":                         "apps/v1",
+	"extensions/v1beta1/ReplicaSet":                        "apps/v1",
+	"extensions/v1beta1/Ingress":                           "networking.k8s.io/v1",
+	"extensions/v1beta1/NetworkPolicy":                     "networking.k8s.io/v1",
+	"apps/v1beta1/Deployment":                              "apps/v1",
+	"apps/v1beta1/StatefulSet":                             "apps/v1",
+	"apps/v1beta2/Deployment":                              "apps/v1",
+	"apps/v1beta2/DaemonSet":                               "apps/v1",
+	"apps/v1beta2/StatefulSet":                             "apps/v1",
+	"networking.k8s.io/v1beta1/Ingress":                    "networking.k8s.io/v1",
+	"batch/v1beta1/CronJob":                                "batch/v1",
+	"policy/v1beta1/PodDisruptionBudget":                   "policy/v1",
+	"policy/v1beta1/PodSecurityPolicy":                     "removed; use Pod Security Admission",
+	"autoscaling/v2beta1/HorizontalPodAutoscaler":          "autoscaling/v2",
+	"autoscaling/v2beta2/HorizontalPodAutoscaler":          "autoscaling/v2",
+	"rbac.authorization.k8s.io/v1beta1/Role":               "rbac.authorization.k8s.io/v1",
+	"rbac.authorization.k8s.io/v1beta1/ClusterRole":        "rbac.authorization.k8s.io/v1",
+	"rbac.authorization.k8s.io/v1beta1/RoleBinding":        "rbac.authorization.k8s.io/v1",
+	"rbac.authorization.k8s.io/v1beta1/ClusterRoleBinding": "rbac.authorization.k8s.io/v1",
+}
+
+// RegisterKubernetesTools registers k8s_validate and the read-only kubectl tool.
+func RegisterKubernetesTools(registry *Registry, workspacePath string) error {
+	if err := registry.Register(Definition{
+		Name:        "k8s_validate",
+		Description: "Validate Kubernetes manifests in the workspace. Uses kubeconform for schema validation when installed, and always checks for missing apiVersion/kind/name, removed API versions, unpinned images, and selector/label mismatches.",
+		Safe:        true,
+		ReadOnly:    true,
+		JSONSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"paths": map[string]interface{}{
+					"type":        "array",
+					"items":       map[string]interface{}{"type": "string"},
+					"description": "Workspace-relative files or directories (default: all YAML manifests)",
+				},
+				"kubernetes_version": map[string]interface{}{
+					"type":        "string",
+					"description": "Kubernetes version for schema validation, e.g. 1.29.0",
+				},
+				"strict": map[string]interface{}{
+					"type":        "boolean",
+					"description": "Reject unknown fields (kubeconform -strict)",
+				},
+			},
+		},
+		Handler: func(ctx context.Context, raw json.RawMessage) (interface{}, error) {
+			var args K8sValidateArgs
+			if len(raw) > 0 {
+				if err := json.Unmarshal(raw, &args); err != nil {
+					return nil, fmt.Errorf("failed to parse arguments: %w", err)
+				}
+			}
+			return validateK8s(ctx, workspacePath, args)
+		},
+	}); err != nil {
+		return err
+	}
+
+	return registry.Register(Definition{
+		Name:        "kubectl",
+		Description: "Run a read-only kubectl query (get, describe, logs) against the current or a named context, or list contexts. Writes such as apply, delete, edit, scale, or exec are not available; the user performs them manually. Secret contents are never returned.",
+		Safe:        true,
+		JSONSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"verb": map[string]interface{}{
+					"type":        "string",
+					"enum":        []string{"get", "describe", "logs", "contexts"},
+					"description": "Query type; contexts lists available kubeconfig contexts",
+				},
+				"resource":       map[string]interface{}{"type": "string", "description": "Resource type, e.g. pods, deploy, svc, events (for logs: optional, e.g. deploy/web)"},
+				"name":           map[string]interface{}{"type": "string", "description": "Resource name (pod name for logs)"},
+				"namespace":      map[string]interface{}{"type": "string"},
+				"all_namespaces": map[string]interface{}{"type": "boolean"},
+				"selector":       map[string]interface{}{"type": "string", "description": "Label selector, e.g. app=web"},
+				"context":        map[string]interface{}{"type": "string", "description": "kubeconfig context (default: current context)"},
+				"output":         map[string]interface{}{"type": "string", "enum": []string{"wide", "yaml", "json", "name"}, "description": "Output format for get"},
+				"container":      map[string]interface{}{"type": "string", "description": "Container for logs"},
+				"tail":           map[string]interface{}{"type": "integer", "description": "Log lines to return (default 200, max 2000)"},
+				"previous":       map[string]interface{}{"type": "boolean", "description": "Logs of the previous container instance"},
+			},
+			"required": []string{"verb"},
+		},
+		Handler: func(ctx context.Context, raw json.RawMessage) (interface{}, error) {
+			var args KubectlArgs
+			if err := json.Unmarshal(raw, &args); err != nil {
+				return nil, fmt.Errorf("failed to parse arguments: %w", err)
+			}
+			return runKubectl(ctx, workspacePath, args)
+		},
+	})
+}
+
+// k8sDoc is one YAML document of a manifest file.
+type k8sDoc struct {
+	line                 int // first line of the document, 1-based
+	apiVersion, kind     string
+	name                 string
+	selector, podLabels  map[string]string
+	images               []imageRef
+	containersWithoutRes []string
+	hasTabs              bool
+}
+
+type imageRef struct {
+	image string
+	line  int
+}
+
+func validateK8s(ctx context.Context, workspacePath string, args K8sValidateArgs) (*K8sValidateResult, error) {
+	root := expandWorkspacePath(workspacePath)
+	files, skipped, err := collectManifests(root, args.Paths)
+	if err != nil {
+		return nil, err
+	}
+	res := &K8sValidateResult{Validator: "builtin", Files: len(files), Skipped: skipped}
+	if len(files) == 0 {
+		res.Notes = append(res.Notes, "No Kubernetes manifests (YAML with apiVersion and kind) found")
+		return res, nil
+	}
+
+	invalid := map[string]bool{} // file#kind/name of resources with an error
+	for _, rel := range files {
+		content, err := os.ReadFile(filepath.Join(root, rel))
+		if err != nil {
+			continue
+		}
+		for _, doc := range splitK8sDocs(string(content)) {
+			if doc.apiVersion == "" && doc.kind == "" && doc.name == "" {
+				continue
+			}
+			res.Resources++
+			for _, issue := range checkK8sDoc(rel, doc) {
+				if issue.Severity == "error" {
+					invalid[rel+"#"+doc.kind+"/"+doc.name] = true
+				}
+				res.Issues = append(res.Issues, issue)
+			}
+		}
+	}
+
+	if bin, err := exec.LookPath("kubeconform"); err == nil {
+		res.Validator = "kubeconform+builtin"
+		issues, err := runKubeconform(ctx, bin, root, files, args)
+		if err != nil {
+			res.Notes = append(res.Notes, "kubeconform failed: "+err.Error())
+		}
+		for _, issue := range issues {
+			if issue.Severity == "error" {
+				invalid[issue.File+"#"+issue.Kind+"/"+issue.Name] = true
+			}
+			res.Issues = append(res.Issues, issue)
+		}
+	} else {
+		res.Notes = append(res.Notes, "kubeconform is not installed; only built-in checks ran (no schema validation)")
+	}
+	res.Valid = res.Resources - len(invalid)
+	if res.Valid < 0 {
+		res.Valid = 0
+	}
+	sort.SliceStable(res.Issues, func(i, j int) bool {
+		if res.Issues[i].File != res.Issues[j].File {
+			return res.Issues[i].File < res.Issues[j].File
+		}
+		return res.Issues[i].Line < res.Issues[j].Line
+	})
+	return res, nil
+}
+
+// collectManifests returns workspace-relative YAML files that look like Kubernetes
+// manifests, and separately those that are Helm or other templates.
+func collectManifests(root string, paths []string) (files, skipped []string, err error) {
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+	seen := map[string]bool{}
+	consider := func(abs string) {
+		ext := strings.ToLower(filepath.Ext(abs))
+		if ext != ".yaml" && ext != ".yml" {
+			return
+		}
+		rel, _ := filepath.Rel(root, abs)
+		rel = filepath.ToSlash(rel)
+		if seen[rel] {
+			return
+		}
+		seen[rel] = true
+		content, err := os.ReadFile(abs)
+		if err != nil {
+			return
+		}
+		text := string(content)
+		if !hasTopLevelKey(text, "apiVersion") || !hasTopLevelKey(text, "kind") {
+			return
+		}
+		if strings.Contains(text, "{{") {
+			skipped = append(skipped, rel)
+			return
+		}
+		files = append(files, rel)
+	}
+	for _, p := range paths {
+		abs, err := validatePath(root, p)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid path %q: %w", p, err)
+		}
+		info, err := os.Stat(abs)
+		if err != nil {
+			return nil, nil, fmt.Errorf("path not found: %s", p)
+		}
+		if !info.IsDir() {
+			consider(abs)
+			continue
+		}
+		_ = filepath.WalkDir(abs, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return nil
+			}
+			if d.IsDir() {
+				if path != abs && (strings.HasPrefix(d.Name(), ".") || skippedTreeDirs[d.Name()]) {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			consider(path)
+			return nil
+		})
+	}
+	sort.Strings(files)
+	sort.Strings(skipped)
+	return files, skipped, nil
+}
+
+func hasTopLevelKey(text, key string) bool {
+	for _, line := range strings.Split(text, "\n") {
+		if strings.HasPrefix(line, key+":") {
+			return true
+		}
+	}
+	return false
+}
+
+// splitK8sDocs scans multi-document YAML line by line, extracting the fields the
+// built-in checks need. It is not a general YAML parser: flow-style mappings and
+// anchors are not expanded.
+func splitK8sDocs(content string) []k8sDoc {
+	var docs []k8sDoc
+	cur := k8sDoc{line: 1}
+	// stack holds the keys of the enclosing mappings, by indentation
+	type frame struct {
+		indent int
+		key    string
+	}
+	var stack []frame
+	container := ""   // name of the container being scanned
+	containerInd := 0 // indentation of its "- " item
+	hasResources := false
+	flushContainer := func() {
+		if container != "" && !hasResources {
+			cur.containersWithoutRes = append(cur.containersWithoutRes, container)
+		}
+		container, hasResources = "", false
+	}
+	lines := strings.Split(content, "\n")
+	for i, raw := range lines {
+		raw = strings.TrimRight(raw, "\r")
+		if raw == "---" || strings.HasPrefix(raw, "--- ") {
+			flushContainer()
+			docs = append(docs, cur)
+			cur = k8sDoc{line: i + 2}
+			stack = nil
+			continue
+		}
+		trimmed := strings.TrimSpace(raw)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		if strings.HasPrefix(raw, "\t") {
+			cur.hasTabs = true
+			continue
+		}
+		indent := len(raw) - len(strings.TrimLeft(raw, " "))
+		item := false
+		if strings.HasPrefix(trimmed, "- ") {
+			item = true
+			trimmed = strings.TrimSpace(trimmed[2:])
+			indent += 2
+		}
+		for len(stack) > 0 && stack[len(stack)-1].indent >= indent {
+			stack = stack[:len(stack)-1]
+		}
+		if container != "" && indent <= containerInd {
+			flushContainer()
+		}
+		key, value, ok := strings.Cut(trimmed, ":")
+		if !ok || strings.ContainsAny(key, " \"'{[") {
+			continue
+		}
+		value = strings.Trim(strings.TrimSpace(stripYAMLComment(value)), `"'`)
+		parent := ""
+		if len(stack) > 0 {
+			parent = stack[len(stack)-1].key
+		}
+		keyPath := make([]string, 0, len(stack)+1)
+		for _, f := range stack {
+			keyPath = append(keyPath, f.key)
+		}
+		joined := strings.Join(keyPath, ".")
+
+		switch {
+		case len(stack) == 0 && key == "apiVersion":
+			cur.apiVersion = value
+		case len(stack) == 0 && key == "kind":
+			cur.kind = value
+		case joined == "metadata" && key == "name":
+			cur.name = value
+		case strings.HasSuffix(joined, "selector.matchLabels") || (joined == "spec.selector" && cur.kind == "Service"):
+			if value != "" {
+				if cur.selector == nil {
+					cur.selector = map[string]string{}
+				}
+				cur.selector[key] = value
+			}
+		case strings.HasSuffix(joined, "template.metadata.labels"):
+			if cur.podLabels == nil {
+				cur.podLabels = map[string]string{}
+			}
+			cur.podLabels[key] = value
+		case item && (parent == "containers" || parent == "initContainers"):
+			flushContainer()
+			containerInd = indent - 2
+			if key == "name" {
+				container = value
+			} else {
+				container = "(unnamed)"
+			}
+			if key == "image" {
+				cur.images = append(cur.images, imageRef{image: value, line: i + 1})
+			}
+		case container != "" && key == "name" && indent == containerInd+2 && container == "(unnamed)":
+			container = value
+		case container != "" && key == "image" && indent == containerInd+2:
+			cur.images = append(cur.images, imageRef{image: value, line: i + 1})
+		case container != "" && key == "resources" && indent == containerInd+2:
+			hasResources = true
+		}
+		if value == "" {
+			stack = append(stack, frame{indent: indent, key: key})
+		}
+	}
+	flushContainer()
+	docs = append(docs, cur)
+	return docs
+}
+
+func stripYAMLComment(v string) string {
+	if i := strings.Index(v, " #"); i >= 0 {
+		return v[:i]
+	}
+	return v
+}
+
+// checkK8sDoc applies the built-in manifest checks to one document.
+func checkK8sDoc(file string, doc k8sDoc) []ManifestIssue {
+	var issues []ManifestIssue
+	add := func(line int, severity, msg string) {
+		issues = append(issues, ManifestIssue{File: file, Line: line, Kind: doc.kind, Name: doc.name, Severity: severity, Message: msg})
+	}
+	if doc.hasTabs {
+		add(doc.line, "error", "tab characters used for indentation (invalid YAML)")
+	}
+	if doc.apiVersion == "" {
+		add(doc.line, "error", "missing apiVersion")
+	}
+	if doc.kind == "" {
+		add(doc.line, "error", "missing kind")
+	}
+	if doc.name == "" && doc.kind != "Kustomization" && !strings.HasSuffix(doc.kind, "List") {
+		add(doc.line, "error", "missing metadata.name")
+	}
+	if repl, ok := removedAPIs[doc.apiVersion+"/"+doc.kind]; ok {
+		add(doc.line, "error", fmt.Sprintf("%s %s is no longer served by current Kubernetes versions (use %s)", doc.apiVersion, doc.kind, repl))
+	}
+	if doc.kind != "Service" && len(doc.selector) > 0 && doc.podLabels != nil {
+		for k, v := range doc.selector {
+			if doc.podLabels[k] != v {
+				add(doc.line, "error", fmt.Sprintf("selector %s=%s does not match the pod template labels", k, v))
+			}
+		}
+	}
+	for _, img := range doc.images {
+		ref := img.image
+		if i := strings.LastIndex(ref, "/"); i >= 0 {
+			ref = ref[i+1:]
+		}
+		switch {
+		case strings.Contains(ref, "@"):
+		case !strings.Contains(ref, ":"):
+			add(img.line, "warning", fmt.Sprintf("image %q has no tag (defaults to latest)", img.image))
+		case strings.HasSuffix(ref, ":latest"):
+			add(img.line, "warning", fmt.Sprintf("image %q uses the latest tag", img.image))
+		}
+	}
+	for _, c := range doc.containersWithoutRes {
+		add(doc.line, "warning", fmt.Sprintf("container %q has no resource requests/limits", c))
+	}
+	return issues
+}
+
+// runKubeconform validates files against the Kubernetes JSON schemas.
+func runKubeconform(ctx context.Context, bin, root string, files []string, args K8sValidateArgs) ([]ManifestIssue, error) {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Minute)
+	defer cancel()
+	// CRDs without a published schema are reported as skipped rather than failing
+	cmdArgs := []string{"-output", "json", "-summary", "-ignore-missing-schemas"}
+	if args.Strict {
+		cmdArgs = append(cmdArgs, "-strict")
+	}
+	if v := strings.TrimPrefix(strings.TrimSpace(args.KubernetesVersion), "v"); v != "" {
+		cmdArgs = append(cmdArgs, "-kubernetes-version", v)
+	}
+	cmd := exec.CommandContext(ctx, bin, append(cmdArgs, files...)...)
+	cmd.Dir = root
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	runErr := cmd.Run()
+
+	var out struct {
+		Resources []struct {
+			Filename string `json:"filename"`
+			Kind     string `json:"kind"`
+			Name     string `json:"name"`
+			Status   string `json:"status"`
+			Msg      string `json:"msg"`
+		} `json:"resources"`
+	}
+	if err := json.Unmarshal(stdout.Bytes(), &out); err != nil {
+		if runErr != nil {
+			return nil, fmt.Errorf("%v: %s", runErr, tail(stderr.String(), 2000))
+		}
+		return nil, fmt.Errorf("unexpected kubeconform output: %w", err)
+	}
+	var issues []ManifestIssue
+	for _, r := range out.Resources {
+		severity := ""
+		switch r.Status {
+		case "statusInvalid", "statusError":
+			severity = "error"
+		case "statusSkipped":
+			severity = "warning"
+			if r.Msg == "" {
+				r.Msg = "no schema found; skipped"
+			}
+		default:
+			continue
+		}
+		issues = append(issues, ManifestIssue{
+			File: filepath.ToSlash(r.Filename), Kind: r.Kind, Name: r.Name, Severity: severity, Message: "schema: " + r.Msg,
+		})
+	}
+	return issues, nil
+}
+
+// kubectlArgs builds the argument list for a read-only query, rejecting anything
+// that could change cluster state or leak secret data.
+func kubectlArgs(args KubectlArgs) ([]string, error) {
+	for _, v := range []string{args.Resource, args.Name, args.Namespace, args.Selector, args.Context, args.Container} {
+		if strings.HasPrefix(strings.TrimSpace(v), "-") {
+			return nil, fmt.Errorf("argument %q must not start with '-'", v)
+		}
+	}
+	var out []string
+	if args.Context != "" {
+		out = append(out, "--context", args.Context)
+	}
+	verb := strings.ToLower(strings.TrimSpace(args.Verb))
+	switch verb {
+	case "contexts":
+		return append(out, "config", "get-contexts"), nil
+	case "get", "describe":
+		if args.Resource == "" {
+			return nil, fmt.Errorf("%s requires a resource", verb)
+		}
+		out = append(out, verb, args.Resource)
+	case "logs":
+		target := args.Name
+		if args.Resource != "" {
+			target = strings.TrimSuffix(args.Resource, "/") + "/" + args.Name
+			if args.Name == "" {
+				target = args.Resource
+			}
+		}
+		if target == "" && args.Selector == "" {
+			return nil, errors.New("logs requires a pod name, a resource like deploy/web, or a selector")
+		}
+		out = append(out, "logs")
+		if target != "" {
+			out = append(out, target)
+		}
+		tailN := args.Tail
+		if tailN <= 0 {
+			tailN = 200
+		} else if tailN > 2000 {
+			tailN = 2000
+		}
+		out = append(out, fmt.Sprintf("--tail=%d", tailN))
+		if args.Container != "" {
+			out = append(out, "-c", args.Container)
+		}
+		if args.Previous {
+			out = append(out, "--previous")
+		}
+	default:
+		return nil, fmt.Errorf("unsupported verb %q: only get, describe, logs, and contexts are allowed", args.Verb)
+	}
+	if verb != "logs" && args.Name != "" {
+		out = append(out, args.Name)
+	}
+	if args.AllNamespaces {
+		out = append(out, "--all-namespaces")
+	} else if args.Namespace != "" {
+		out = append(out, "-n", args.Namespace)
+	}
+	if args.Selector != "" {
+		out = append(out, "-l", args.Selector)
+	}
+	if args.Output != "" {
+		if verb != "get" {
+			return nil, errors.New("output is only supported for get")
+		}
+		switch args.Output {
+		case "wide", "yaml", "json", "name":
+		default:
+			return nil, fmt.Errorf("unsupported output %q", args.Output)
+		}
+		// A type/name in the name slot ("secret/db") selects that type too
+		secret := isSecretResource(args.Resource) || (strings.Contains(args.Name, "/") && isSecretResource(args.Name))
+		if secret && args.Output != "name" && args.Output != "wide" {
+			return nil, errors.New("secret contents are not returned; use describe to see keys and sizes")
+		}
+		out = append(out, "-o", args.Output)
+	}
+	return append(out, "--request-timeout=20s"), nil
+}
+
+// isSecretResource reports whether resource names secrets in any spelling kubectl
+// accepts: lists ("pods,secrets"), type/name ("secret/db") and qualified forms
+// ("secret.v1", "secrets.v1.").
+func isSecretResource(resource string) bool {
+	for _, r := range strings.Split(strings.ToLower(resource), ",") {
+		r, _, _ = strings.Cut(strings.TrimSpace(r), "/")
+		r, _, _ = strings.Cut(r, ".")
+		if r == "secret" || r == "secrets" || r == "all" {
+			return true
+		}
+	}
+	return false
+}
+
+func runKubectl(ctx context.Context, workspacePath string, args KubectlArgs) (*KubectlResult, error) {
+	cmdArgs, err := kubectlArgs(args)
+	if err != nil {
+		return nil, err
+	}
+	bin, err := exec.LookPath("kubectl")
+	if err != nil {
+		return nil, errors.New("kubectl was not found on PATH")
+	}
+	ctx, cancel := context.WithTimeout(ctx, kubectlTimeout)
+	defer cancel()
+
+	kubeContext := args.Context
+	if kubeContext == "" {
+		if out, err := exec.CommandContext(ctx, bin, "config", "current-context").Output(); err == nil {
+			kubeContext = strings.TrimSpace(string(out))
+		}
+	}
+	cmd := exec.CommandContext(ctx, bin, cmdArgs...)
+	cmd.Dir = expandWorkspacePath(workspacePath)
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+	runErr := cmd.Run()
+	res := &KubectlResult{Context: kubeContext, Command: "kubectl " + strings.Join(cmdArgs, " "), Output: out.String()}
+	var ee *exec.ExitError
+	switch {
+	case errors.As(runErr, &ee):
+		res.ExitCode = ee.ExitCode()
+	case runErr != nil:
+		return nil, runErr
+	}
+	return res, nil
+}
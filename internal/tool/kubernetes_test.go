@@ -0,0 +1,114 @@
+package tool
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestK8sValidate_BuiltinChecks(t *testing.T) {
+	t.Setenv("PATH", "") // no kubeconform: built-in checks only
+	root := writeWorkspace(t, map[string]string{
+		"deploy/app.yaml": `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  selector:
+    matchLabels:
+      app: web
+  template:
+    metadata:
+      labels:
+        app: frontend # mismatch
+    spec:
+      containers:
+        - name: web
+          image: nginx:latest
+          ports:
+            - containerPort: 80
+        - name: sidecar
+          image: ghcr.io/acme/proxy:1.4
+          resources:
+            limits:
+              cpu: 100m
+---
+apiVersion: extensions/v1beta1
+kind: Ingress
+metadata:
+  name: web
+---
+apiVersion: v1
+kind: Service
+metadata:
+  labels:
+    app: web
+spec:
+  selector:
+    app: web
+`,
+		"chart/templates/svc.yaml": "apiVersion: v1\nkind: Service\nmetadata:\n  name: {{ .Release.Name }}\n",
+		"config/settings.yaml":     "debug: true\n",
+	})
+
+	res, err := validateK8s(context.Background(), root, K8sValidateArgs{})
+	if err != nil {
+		t.Fatalf("k8s_validate: %v", err)
+	}
+	if res.Validator != "builtin" || res.Files != 1 || res.Resources != 3 || res.Valid != 0 {
+		t.Fatalf("unexpected totals: %+v", res)
+	}
+	if len(res.Skipped) != 1 || res.Skipped[0] != "chart/templates/svc.yaml" {
+		t.Fatalf("expected helm template skipped, got %v", res.Skipped)
+	}
+	var msgs []string
+	for _, is := range res.Issues {
+		msgs = append(msgs, is.Severity+":"+is.Kind+":"+is.Message)
+	}
+	joined := strings.Join(msgs, "\n")
+	for _, want := range []string{
+		`error:Deployment:selector app=web does not match the pod template labels`,
+		`warning:Deployment:image "nginx:latest" uses the latest tag`,
+		`warning:Deployment:container "web" has no resource requests/limits`,
+		`error:Ingress:extensions/v1beta1 Ingress is no longer served by current Kubernetes versions (use networking.k8s.io/v1)`,
+		`error:Service:missing metadata.name`,
+	} {
+		if !strings.Contains(joined, want) {
+			t.Errorf("missing issue %q in:\n%s", want, joined)
+		}
+	}
+	if strings.Contains(joined, `"sidecar"`) || strings.Contains(joined, "proxy:1.4") {
+		t.Errorf("sidecar has a pinned image and resources:\n%s", joined)
+	}
+}
+
+func TestKubectlArgs_ReadOnly(t *testing.T) {
+	got, err := kubectlArgs(KubectlArgs{Verb: "get", Resource: "pods", Namespace: "prod", Selector: "app=web", Output: "wide", Context: "staging"})
+	if err != nil {
+		t.Fatalf("kubectlArgs: %v", err)
+	}
+	if strings.Join(got, " ") != "--context staging get pods -n prod -l app=web -o wide --request-timeout=20s" {
+		t.Fatalf("unexpected args: %v", got)
+	}
+	got, _ = kubectlArgs(KubectlArgs{Verb: "logs", Resource: "deploy", Name: "web", Tail: 50, Previous: true})
+	if strings.Join(got, " ") != "logs deploy/web --tail=50 --previous --request-timeout=20s" {
+		t.Fatalf("unexpected logs args: %v", got)
+	}
+	for _, bad := range []KubectlArgs{
+		{Verb: "delete", Resource: "pod", Name: "web"},
+		{Verb: "apply", Resource: "-f"},
+		{Verb: "get", Resource: "pods", Name: "--kubeconfig=/tmp/x"},
+		{Verb: "get", Resource: "secrets", Output: "yaml"},
+		{Verb: "get", Resource: "secret/db", Output: "json"},
+		{Verb: "get", Resource: "secret.v1", Output: "yaml"},
+		{Verb: "get", Resource: "secrets.v1.", Output: "json"},
+		{Verb: "get", Resource: "Secret.v1/db", Output: "json"},
+		{Verb: "get", Resource: "pods, secrets.v1", Output: "yaml"},
+		{Verb: "get", Resource: "pods", Name: "secret/db", Output: "yaml"},
+		{Verb: "describe", Resource: "pods", Output: "json"},
+	} {
+		if _, err := kubectlArgs(bad); err == nil {
+			t.Errorf("expected %+v to be rejected", bad)
+		}
+	}
+}
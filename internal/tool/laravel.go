@@ -0,0 +1,773 @@
+package tool
+
+import (
+	"encoding/json"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Static Laravel analysis for framework_info. Routes are read from routes/*.php
+// without booting the application, so route groups, resource routes, and
+// controller groups are expanded here the way the router would.
+
+type composerJSON struct {
+	Require  map[string]string `json:"require"`
+	Autoload struct {
+		PSR4 map[string]any `json:"psr-4"`
+	} `json:"autoload"`
+}
+
+var (
+	phpUseRe          = regexp.MustCompile(`(?m)^\s*use\s+\\?([\w\\]+)(?:\s+as\s+(\w+))?\s*;`)
+	phpClassConstRe   = regexp.MustCompile(`^\\?([\w\\]+)::class$`)
+	phpArrayPairRe    = regexp.MustCompile(`['"](\w+)['"]\s*=>\s*(.+)`)
+	laravelSchemaRe   = regexp.MustCompile(`Schema::(?:connection\([^)]*\)\s*->\s*)?(create|table)\(\s*['"]([^'"]+)['"]`)
+	laravelSigRe      = regexp.MustCompile(`\$signature\s*=\s*(?:'([^']*)'|"([^"]*)")`)
+	laravelDescRe     = regexp.MustCompile(`\$description\s*=\s*(?:'([^']*)'|"([^"]*)")`)
+	laravelAsCmdRe    = regexp.MustCompile(`AsCommand\(\s*name:\s*['"]([^'"]+)['"](?:\s*,\s*description:\s*['"]([^'"]*)['"])?`)
+	laravelClosureCmd = regexp.MustCompile(`Artisan::command\(\s*(?:'([^']*)'|"([^"]*)")`)
+	laravelPurposeRe  = regexp.MustCompile(`->\s*(?:purpose|describe)\(\s*(?:'([^']*)'|"([^"]*)")`)
+)
+
+// laravelVerbs maps Route:: methods to HTTP methods.
+var laravelVerbs = map[string][]string{
+	"get": {"GET", "HEAD"}, "post": {"POST"}, "put": {"PUT"}, "patch": {"PATCH"}, "delete": {"DELETE"},
+	"options": {"OPTIONS"}, "any": {"ANY"}, "view": {"GET", "HEAD"}, "redirect": {"ANY"}, "permanentRedirect": {"ANY"},
+}
+
+// laravelResourceActions are the routes registered by Route::resource, in order.
+var laravelResourceActions = []struct {
+	action, uri string
+	methods     []string
+	api         bool
+}{
+	{"index", "", []string{"GET", "HEAD"}, true},
+	{"create", "/create", []string{"GET", "HEAD"}, false},
+	{"store", "", []string{"POST"}, true},
+	{"show", "/{param}", []string{"GET", "HEAD"}, true},
+	{"edit", "/{param}/edit", []string{"GET", "HEAD"}, false},
+	{"update", "/{param}", []string{"PUT", "PATCH"}, true},
+	{"destroy", "/{param}", []string{"DELETE"}, true},
+}
+
+// detectLaravel returns the Laravel summary when root is a Laravel application.
+func detectLaravel(root string) (*FrameworkInfo, bool) {
+	var composer composerJSON
+	if data, err := os.ReadFile(filepath.Join(root, "composer.json")); err == nil {
+		_ = json.Unmarshal(data, &composer)
+	}
+	version, ok := composer.Require["laravel/framework"]
+	if !ok {
+		if _, err := os.Stat(filepath.Join(root, "artisan")); err != nil {
+			return nil, false
+		}
+	}
+	info := &FrameworkInfo{Framework: "laravel", Version: version}
+	psr4 := composerPSR4(composer)
+	controllers := map[string]string{} // file contents cache
+
+	files, _ := filepath.Glob(filepath.Join(root, "routes", "*.php"))
+	sort.Strings(files)
+	for _, abs := range files {
+		data, err := os.ReadFile(abs)
+		if err != nil {
+			continue
+		}
+		rel := filepath.ToSlash(filepath.Join("routes", filepath.Base(abs)))
+		switch filepath.Base(abs) {
+		case "console.php":
+			info.Commands = append(info.Commands, parseClosureCommands(rel, string(data))...)
+			continue
+		case "channels.php":
+			continue
+		}
+		var base laravelGroup
+		if filepath.Base(abs) == "api.php" {
+			// RouteServiceProvider / bootstrap/app.php register api.php under /api
+			base = laravelGroup{prefix: "api", middleware: []string{"api"}}
+		}
+		for _, r := range parseLaravelRoutes(rel, string(data), base) {
+			if r.ControllerFile == "" && r.Action != "" && r.Action != "Closure" {
+				resolveLaravelAction(root, psr4, controllers, &r)
+			}
+			info.Routes = append(info.Routes, r)
+		}
+	}
+	info.RouteCount = len(info.Routes)
+	info.Migrations = laravelMigrations(root)
+	info.Commands = append(info.Commands, laravelCommands(root)...)
+	sort.SliceStable(info.Commands, func(i, j int) bool { return info.Commands[i].Name < info.Commands[j].Name })
+	info.SuggestedActions = laravelActions(root)
+	info.Notes = append(info.Notes, "Routes are read statically from routes/*.php; run `php artisan route:list` to include routes registered by packages or service providers.")
+	return info, true
+}
+
+// composerPSR4 returns namespace prefix → directories, defaulting to App\ → app/.
+func composerPSR4(c composerJSON) map[string][]string {
+	out := map[string][]string{}
+	for ns, v := range c.Autoload.PSR4 {
+		switch dirs := v.(type) {
+		case string:
+			out[ns] = append(out[ns], dirs)
+		case []any:
+			for _, d := range dirs {
+				if s, ok := d.(string); ok {
+					out[ns] = append(out[ns], s)
+				}
+			}
+		}
+	}
+	if len(out) == 0 {
+		out[`App\`] = []string{"app/"}
+	}
+	return out
+}
+
+// resolvePHPClass maps a fully qualified class name to a workspace-relative file.
+func resolvePHPClass(root string, psr4 map[string][]string, fqcn string) string {
+	fqcn = strings.TrimPrefix(fqcn, `\`)
+	best := ""
+	for ns := range psr4 {
+		if strings.HasPrefix(fqcn, ns) && len(ns) > len(best) {
+			best = ns
+		}
+	}
+	if best == "" {
+		return ""
+	}
+	rest := strings.ReplaceAll(strings.TrimPrefix(fqcn, best), `\`, "/") + ".php"
+	for _, dir := range psr4[best] {
+		rel := filepath.ToSlash(filepath.Join(dir, rest))
+		if _, err := os.Stat(filepath.Join(root, rel)); err == nil {
+			return rel
+		}
+	}
+	return ""
+}
+
+// resolveLaravelAction fills in the controller file and method line for a route.
+func resolveLaravelAction(root string, psr4 map[string][]string, cache map[string]string, r *FrameworkRoute) {
+	class, method, _ := strings.Cut(r.Action, "@")
+	file := resolvePHPClass(root, psr4, class)
+	if file == "" {
+		return
+	}
+	r.ControllerFile = file
+	content, ok := cache[file]
+	if !ok {
+		data, _ := os.ReadFile(filepath.Join(root, file))
+		content = string(data)
+		cache[file] = content
+	}
+	re := regexp.MustCompile(`function\s+` + regexp.QuoteMeta(method) + `\s*\(`)
+	if loc := re.FindStringIndex(content); loc != nil {
+		r.ControllerLine = strings.Count(content[:loc[0]], "\n") + 1
+	}
+}
+
+// laravelGroup holds the attributes a route group applies to the routes inside it.
+type laravelGroup struct {
+	start, end int // offsets of the group's closure
+	prefix     string
+	namePrefix string
+	controller string
+	namespace  string
+	middleware []string
+}
+
+// phpCall is one call in a Route::a(...)->b(...) chain.
+type phpCall struct {
+	name       string
+	args       []string
+	start, end int // offsets of the argument list
+}
+
+// parseLaravelRoutes extracts the routes declared in one routes file.
+func parseLaravelRoutes(rel, content string, base laravelGroup) []FrameworkRoute {
+	code := stripPHPComments(content)
+	imports := phpImports(code)
+
+	type stmt struct {
+		pos   int
+		calls []phpCall
+	}
+	var stmts []stmt
+	groups := []laravelGroup{}
+	for off := 0; ; {
+		k := strings.Index(code[off:], "Route::")
+		if k < 0 {
+			break
+		}
+		pos := off + k
+		off = pos + len("Route::")
+		calls := parseRouteChain(code, off)
+		if len(calls) == 0 {
+			continue
+		}
+		group := -1
+		for i, c := range calls {
+			if c.name == "group" {
+				group = i
+			}
+		}
+		if group < 0 {
+			stmts = append(stmts, stmt{pos: pos, calls: calls})
+			continue
+		}
+		g := laravelGroup{start: calls[group].start, end: calls[group].end}
+		attrs := append([]phpCall(nil), calls[:group]...)
+		if len(calls[group].args) > 1 {
+			// Route::group(['prefix' => ..., 'as' => ...], function () { ... })
+			attrs = append(attrs, phpArrayCalls(calls[group].args[0])...)
+		}
+		for _, c := range attrs {
+			arg := ""
+			if len(c.args) > 0 {
+				arg = c.args[0]
+			}
+			switch c.name {
+			case "prefix":
+				g.prefix, _ = phpString(arg)
+			case "name", "as":
+				g.namePrefix, _ = phpString(arg)
+			case "middleware":
+				g.middleware = phpStringList(arg)
+			case "controller":
+				g.controller = phpClassName(arg, imports)
+			case "namespace":
+				g.namespace, _ = phpString(arg)
+			}
+		}
+		groups = append(groups, g)
+	}
+
+	var routes []FrameworkRoute
+	for _, s := range stmts {
+		// Outer groups first: they were found first and start earlier
+		eff := base
+		eff.middleware = append([]string(nil), base.middleware...)
+		for _, g := range groups {
+			if g.start > s.pos || s.pos >= g.end {
+				continue
+			}
+			eff.prefix = joinURI(eff.prefix, g.prefix)
+			eff.namePrefix += g.namePrefix
+			eff.middleware = append(eff.middleware, g.middleware...)
+			if g.controller != "" {
+				eff.controller = g.controller
+			}
+			if g.namespace != "" {
+				eff.namespace = strings.Trim(eff.namespace+`\`+g.namespace, `\`)
+			}
+		}
+		line := strings.Count(code[:s.pos], "\n") + 1
+		routes = append(routes, laravelRoutesFromChain(rel, line, s.calls, eff, imports)...)
+	}
+	return routes
+}
+
+// laravelRoutesFromChain turns one Route::verb(...)->name(...) chain into routes.
+func laravelRoutesFromChain(rel string, line int, calls []phpCall, g laravelGroup, imports map[string]string) []FrameworkRoute {
+	verb := calls[0]
+	var name string
+	middleware := append([]string(nil), g.middleware...)
+	var only, except []string
+	for _, c := range calls[1:] {
+		if len(c.args) == 0 {
+			continue
+		}
+		switch c.name {
+		case "name":
+			name, _ = phpString(c.args[0])
+		case "middleware":
+			middleware = append(middleware, phpStringList(c.args[0])...)
+		case "only":
+			only = phpStringList(c.args[0])
+		case "except":
+			except = phpStringList(c.args[0])
+		}
+	}
+	if len(verb.args) == 0 {
+		return nil
+	}
+
+	switch verb.name {
+	case "resource", "apiResource":
+		resource, _ := phpString(verb.args[0])
+		if resource == "" || len(verb.args) < 2 {
+			return nil
+		}
+		class := qualifyController(phpClassName(verb.args[1], imports), g.namespace)
+		// photos.comments → photos/{photo}/comments
+		parts := strings.Split(resource, ".")
+		uri := ""
+		for i, p := range parts {
+			uri = joinURI(uri, p)
+			if i < len(parts)-1 {
+				uri = joinURI(uri, "{"+singularize(p)+"}")
+			}
+		}
+		param := singularize(strings.ReplaceAll(parts[len(parts)-1], "-", "_"))
+		var out []FrameworkRoute
+		for _, a := range laravelResourceActions {
+			if (verb.name == "apiResource" && !a.api) || (len(only) > 0 && !containsString(only, a.action)) || containsString(except, a.action) {
+				continue
+			}
+			suffix := strings.ReplaceAll(a.uri, "{param}", "{"+param+"}")
+			out = append(out, FrameworkRoute{
+				Methods:    a.methods,
+				URI:        "/" + strings.Trim(joinURI(g.prefix, uri)+suffix, "/"),
+				Name:       g.namePrefix + resource + "." + a.action,
+				Action:     class + "@" + a.action,
+				Middleware: middleware,
+				File:       rel,
+				Line:       line,
+			})
+		}
+		return out
+	}
+
+	methods, ok := laravelVerbs[verb.name]
+	args := verb.args
+	if verb.name == "match" {
+		if len(args) < 2 {
+			return nil
+		}
+		methods = nil
+		for _, m := range phpStringList(args[0]) {
+			methods = append(methods, strings.ToUpper(m))
+		}
+		args, ok = args[1:], true
+	}
+	if !ok {
+		return nil
+	}
+	uri, isString := phpString(args[0])
+	if !isString {
+		return nil
+	}
+	action := ""
+	switch {
+	case verb.name == "view" && len(args) > 1:
+		view, _ := phpString(args[1])
+		action = "view:" + view
+	case verb.name == "redirect" || verb.name == "permanentRedirect":
+		if len(args) > 1 {
+			to, _ := phpString(args[1])
+			action = "redirect:" + to
+		}
+	case len(args) > 1:
+		action = laravelAction(args[1], g, imports)
+	}
+	return []FrameworkRoute{{
+		Methods:    methods,
+		URI:        "/" + strings.Trim(joinURI(g.prefix, uri), "/"),
+		Name:       prefixedName(g.namePrefix, name),
+		Action:     action,
+		Middleware: middleware,
+		File:       rel,
+		Line:       line,
+	}}
+}
+
+// laravelAction normalizes a route action to Controller@method or Closure.
+func laravelAction(arg string, g laravelGroup, imports map[string]string) string {
+	arg = strings.TrimSpace(arg)
+	switch {
+	case strings.HasPrefix(arg, "function") || strings.HasPrefix(arg, "fn") || strings.HasPrefix(arg, "static"):
+		return "Closure"
+	case strings.HasPrefix(arg, "["):
+		parts := splitTopLevel(strings.TrimSuffix(strings.TrimPrefix(arg, "["), "]"))
+		if len(parts) == 2 {
+			method, _ := phpString(parts[1])
+			return qualifyController(phpClassName(parts[0], imports), g.namespace) + "@" + method
+		}
+	case phpClassConstRe.MatchString(arg):
+		return qualifyController(phpClassName(arg, imports), g.namespace) + "@__invoke"
+	}
+	s, ok := phpString(arg)
+	if !ok {
+		return ""
+	}
+	if class, method, found := strings.Cut(s, "@"); found {
+		return qualifyController(resolveImport(class, imports), g.namespace) + "@" + method
+	}
+	if g.controller != "" {
+		// Route::controller(X::class)->group(...) routes name only the method
+		return g.controller + "@" + s
+	}
+	return ""
+}
+
+// qualifyController applies the legacy controller namespace to unqualified names.
+func qualifyController(class, namespace string) string {
+	if class == "" || strings.Contains(class, `\`) {
+		return class
+	}
+	if namespace == "" {
+		namespace = `App\Http\Controllers`
+	} else if !strings.HasPrefix(namespace, `App\`) {
+		namespace = `App\Http\Controllers\` + namespace
+	}
+	return namespace + `\` + class
+}
+
+// parseRouteChain parses a(...)->b(...) starting at offset pos.
+func parseRouteChain(code string, pos int) []phpCall {
+	var calls []phpCall
+	for {
+		j := pos
+		for j < len(code) && (code[j] == '_' || isAlnum(code[j])) {
+			j++
+		}
+		if j == pos {
+			return calls
+		}
+		name := code[pos:j]
+		for j < len(code) && isSpace(code[j]) {
+			j++
+		}
+		if j >= len(code) || code[j] != '(' {
+			return calls
+		}
+		end := matchBracket(code, j)
+		if end < 0 {
+			return calls
+		}
+		calls = append(calls, phpCall{name: name, args: splitTopLevel(code[j+1 : end]), start: j + 1, end: end})
+		pos = end + 1
+		for pos < len(code) && isSpace(code[pos]) {
+			pos++
+		}
+		if !strings.HasPrefix(code[pos:], "->") {
+			return calls
+		}
+		pos += 2
+		for pos < len(code) && isSpace(code[pos]) {
+			pos++
+		}
+	}
+}
+
+// matchBracket returns the offset of the bracket closing the one at open, or -1.
+func matchBracket(code string, open int) int {
+	depth := 0
+	for i := open; i < len(code); i++ {
+		switch c := code[i]; c {
+		case '(', '[', '{':
+			depth++
+		case ')', ']', '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		case '\'', '"':
+			for i++; i < len(code) && code[i] != c; i++ {
+				if code[i] == '\\' {
+					i++
+				}
+			}
+		}
+	}
+	return -1
+}
+
+// splitTopLevel splits an argument list on top-level commas.
+func splitTopLevel(s string) []string {
+	var out []string
+	depth, start := 0, 0
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '(', '[', '{':
+			depth++
+		case ')', ']', '}':
+			depth--
+		case '\'', '"':
+			for i++; i < len(s) && s[i] != c; i++ {
+				if s[i] == '\\' {
+					i++
+				}
+			}
+		case ',':
+			if depth == 0 {
+				out = append(out, strings.TrimSpace(s[start:i]))
+				start = i + 1
+			}
+		}
+	}
+	if last := strings.TrimSpace(s[min(start, len(s)):]); last != "" {
+		out = append(out, last)
+	}
+	return out
+}
+
+// phpArrayCalls turns ['prefix' => 'x', 'as' => 'y.'] into pseudo calls.
+func phpArrayCalls(arr string) []phpCall {
+	arr = strings.TrimSpace(arr)
+	if !strings.HasPrefix(arr, "[") {
+		return nil
+	}
+	var out []phpCall
+	for _, item := range splitTopLevel(strings.TrimSuffix(strings.TrimPrefix(arr, "["), "]")) {
+		if m := phpArrayPairRe.FindStringSubmatch(item); m != nil {
+			out = append(out, phpCall{name: m[1], args: []string{strings.TrimSpace(m[2])}})
+		}
+	}
+	return out
+}
+
+func phpString(arg string) (string, bool) {
+	arg = strings.TrimSpace(arg)
+	if len(arg) >= 2 && (arg[0] == '\'' || arg[0] == '"') && arg[len(arg)-1] == arg[0] {
+		return arg[1 : len(arg)-1], true
+	}
+	return "", false
+}
+
+// phpStringList reads 'a' or ['a', 'b'].
+func phpStringList(arg string) []string {
+	arg = strings.TrimSpace(arg)
+	if s, ok := phpString(arg); ok {
+		return []string{s}
+	}
+	var out []string
+	if strings.HasPrefix(arg, "[") {
+		for _, item := range splitTopLevel(strings.TrimSuffix(strings.TrimPrefix(arg, "["), "]")) {
+			if s, ok := phpString(item); ok {
+				out = append(out, s)
+			}
+		}
+	}
+	return out
+}
+
+// phpClassName resolves X::class through the file's use statements.
+func phpClassName(arg string, imports map[string]string) string {
+	m := phpClassConstRe.FindStringSubmatch(strings.TrimSpace(arg))
+	if m == nil {
+		return ""
+	}
+	return resolveImport(m[1], imports)
+}
+
+func resolveImport(class string, imports map[string]string) string {
+	first, rest, nested := strings.Cut(class, `\`)
+	if fq, ok := imports[first]; ok {
+		if nested {
+			return fq + `\` + rest
+		}
+		return fq
+	}
+	return class
+}
+
+func phpImports(code string) map[string]string {
+	out := map[string]string{}
+	for _, m := range phpUseRe.FindAllStringSubmatch(code, -1) {
+		alias := m[2]
+		if alias == "" {
+			alias = m[1][strings.LastIndex(m[1], `\`)+1:]
+		}
+		out[alias] = m[1]
+	}
+	return out
+}
+
+// stripPHPComments blanks comments while keeping offsets, newlines, and strings intact.
+func stripPHPComments(s string) string {
+	b := []byte(s)
+	for i := 0; i < len(b); i++ {
+		switch c := b[i]; {
+		case c == '\'' || c == '"':
+			for i++; i < len(b) && b[i] != c; i++ {
+				if b[i] == '\\' {
+					i++
+				}
+			}
+		case c == '#' && (i+1 >= len(b) || b[i+1] != '['), c == '/' && i+1 < len(b) && b[i+1] == '/':
+			for ; i < len(b) && b[i] != '\n'; i++ {
+				b[i] = ' '
+			}
+		case c == '/' && i+1 < len(b) && b[i+1] == '*':
+			for ; i < len(b) && !(b[i] == '*' && i+1 < len(b) && b[i+1] == '/'); i++ {
+				if b[i] != '\n' {
+					b[i] = ' '
+				}
+			}
+			if i+1 < len(b) {
+				b[i], b[i+1] = ' ', ' '
+				i++
+			}
+		}
+	}
+	return string(b)
+}
+
+func joinURI(a, b string) string {
+	a, b = strings.Trim(a, "/"), strings.Trim(b, "/")
+	switch {
+	case a == "":
+		return b
+	case b == "":
+		return a
+	}
+	return a + "/" + b
+}
+
+func prefixedName(prefix, name string) string {
+	if name == "" {
+		return ""
+	}
+	return prefix + name
+}
+
+// singularize approximates Str::singular for resource parameter names.
+func singularize(word string) string {
+	switch {
+	case strings.HasSuffix(word, "ies"):
+		return strings.TrimSuffix(word, "ies") + "y"
+	case strings.HasSuffix(word, "sses"), strings.HasSuffix(word, "xes"), strings.HasSuffix(word, "ches"), strings.HasSuffix(word, "shes"):
+		return strings.TrimSuffix(word, "es")
+	case strings.HasSuffix(word, "ss"):
+		return word
+	}
+	return strings.TrimSuffix(word, "s")
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func isAlnum(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
+
+// laravelMigrations lists database/migrations in run order with their tables.
+func laravelMigrations(root string) []Migration {
+	files, _ := filepath.Glob(filepath.Join(root, "database", "migrations", "*.php"))
+	sort.Strings(files)
+	var out []Migration
+	for _, abs := range files {
+		data, err := os.ReadFile(abs)
+		if err != nil {
+			continue
+		}
+		m := Migration{
+			Name: strings.TrimSuffix(filepath.Base(abs), ".php"),
+			File: filepath.ToSlash(filepath.Join("database", "migrations", filepath.Base(abs))),
+		}
+		for _, s := range laravelSchemaRe.FindAllStringSubmatch(stripPHPComments(string(data)), -1) {
+			if s[1] == "create" && !containsString(m.Creates, s[2]) {
+				m.Creates = append(m.Creates, s[2])
+			} else if s[1] == "table" && !containsString(m.Alters, s[2]) {
+				m.Alters = append(m.Alters, s[2])
+			}
+		}
+		out = append(out, m)
+	}
+	return out
+}
+
+// laravelCommands finds console command classes under app/Console.
+func laravelCommands(root string) []CLICommand {
+	var out []CLICommand
+	_ = filepath.WalkDir(filepath.Join(root, "app", "Console"), func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || filepath.Ext(path) != ".php" {
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil
+		}
+		code := string(data)
+		rel, _ := filepath.Rel(root, path)
+		cmd := CLICommand{File: filepath.ToSlash(rel)}
+		if loc := laravelSigRe.FindStringSubmatchIndex(code); loc != nil {
+			cmd.Signature = strings.Join(strings.Fields(firstGroup(code, loc)), " ")
+			cmd.Line = strings.Count(code[:loc[0]], "\n") + 1
+		} else if loc := laravelAsCmdRe.FindStringSubmatchIndex(code); loc != nil {
+			cmd.Signature = code[loc[2]:loc[3]]
+			cmd.Line = strings.Count(code[:loc[0]], "\n") + 1
+			if loc[4] >= 0 {
+				cmd.Description = code[loc[4]:loc[5]]
+			}
+		} else {
+			return nil
+		}
+		if cmd.Description == "" {
+			if loc := laravelDescRe.FindStringSubmatchIndex(code); loc != nil {
+				cmd.Description = firstGroup(code, loc)
+			}
+		}
+		cmd.Name = strings.Fields(cmd.Signature + " ")[0]
+		out = append(out, cmd)
+		return nil
+	})
+	return out
+}
+
+// parseClosureCommands reads Artisan::command(...) definitions in routes/console.php.
+func parseClosureCommands(rel, content string) []CLICommand {
+	code := stripPHPComments(content)
+	locs := laravelClosureCmd.FindAllStringSubmatchIndex(code, -1)
+	var out []CLICommand
+	for i, loc := range locs {
+		sig := strings.Join(strings.Fields(firstGroup(code, loc)), " ")
+		if sig == "" {
+			continue
+		}
+		cmd := CLICommand{Name: strings.Fields(sig)[0], Signature: sig, File: rel, Line: strings.Count(code[:loc[0]], "\n") + 1}
+		end := len(code)
+		if i+1 < len(locs) {
+			end = locs[i+1][0]
+		}
+		if p := laravelPurposeRe.FindStringSubmatchIndex(code[loc[1]:end]); p != nil {
+			cmd.Description = firstGroup(code[loc[1]:end], p)
+		}
+		out = append(out, cmd)
+	}
+	return out
+}
+
+// firstGroup returns the first participating capture group of a match.
+func firstGroup(s string, loc []int) string {
+	for g := 2; g+1 < len(loc); g += 2 {
+		if loc[g] >= 0 {
+			return s[loc[g]:loc[g+1]]
+		}
+	}
+	return ""
+}
+
+// laravelActions lists common artisan tasks as run_shell proposals.
+func laravelActions(root string) []ShellAction {
+	var out []ShellAction
+	if _, err := os.Stat(filepath.Join(root, "vendor")); err != nil {
+		out = append(out, ShellAction{Description: "Install PHP dependencies (vendor/ is missing, artisan will not run)", Command: "composer", Args: []string{"install"}, Mutating: true})
+	}
+	artisan := func(desc string, mutating bool, args ...string) {
+		out = append(out, ShellAction{Description: desc, Command: "php", Args: append([]string{"artisan"}, args...), Mutating: mutating})
+	}
+	artisan("List all registered routes, including package routes", false, "route:list")
+	artisan("Show which migrations have run", false, "migrate:status")
+	artisan("Run the test suite", false, "test")
+	artisan("Run pending migrations", true, "migrate")
+	artisan("Roll back the last migration batch", true, "migrate:rollback")
+	artisan("Clear cached config, routes, events, and views", true, "optimize:clear")
+	artisan("Generate a controller (replace the name)", true, "make:controller", "ExampleController")
+	artisan("Generate a migration (replace the name)", true, "make:migration", "create_examples_table")
+	return out
+}
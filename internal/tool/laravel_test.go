@@ -0,0 +1,156 @@
+package tool
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeWorkspace(t *testing.T, files map[string]string) string {
+	t.Helper()
+	root := t.TempDir()
+	for rel, content := range files {
+		abs := filepath.Join(root, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(abs), 0o755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+		if err := os.WriteFile(abs, []byte(content), 0o644); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+	return root
+}
+
+func TestFrameworkInfo_LaravelRoutesAndCommands(t *testing.T) {
+	root := writeWorkspace(t, map[string]string{
+		"composer.json": `{"require": {"laravel/framework": "^11.0"}, "autoload": {"psr-4": {"App\\": "app/"}}}`,
+		"routes/web.php": `<?php
+use App\Http\Controllers\UserController;
+use App\Http\Controllers\Admin\ReportController as Reports;
+use Illuminate\Support\Facades\Route;
+
+Route::get('/', function () {
+    return view('welcome');
+});
+
+// Route::get('/disabled', [UserController::class, 'disabled']);
+Route::get('/users/{user}', [UserController::class, 'show'])->name('users.show');
+
+Route::prefix('admin')->middleware(['auth', 'can:admin'])->name('admin.')->group(function () {
+    Route::get('/reports', [Reports::class, 'index'])->name('reports');
+    Route::resource('photos', PhotoController::class)->only(['index', 'show']);
+});
+
+Route::controller(UserController::class)->group(function () {
+    Route::post('/users', 'store');
+});
+`,
+		"routes/api.php": `<?php
+Route::apiResource('orders', \App\Http\Controllers\OrderController::class);
+`,
+		"routes/console.php": `<?php
+Artisan::command('inspire', function () {
+    $this->comment('ok');
+})->purpose('Display an inspiring quote');
+`,
+		"app/Http/Controllers/UserController.php": `<?php
+namespace App\Http\Controllers;
+
+class UserController extends Controller
+{
+    public function show(User $user)
+    {
+    }
+
+    public function store()
+    {
+    }
+}
+`,
+		"app/Console/Commands/PruneUsers.php": `<?php
+class PruneUsers extends Command
+{
+    protected $signature = 'users:prune
+                            {--days=30 : Age in days}';
+    protected $description = 'Remove inactive users';
+}
+`,
+		"database/migrations/2024_01_01_000000_create_users_table.php": `<?php
+Schema::create('users', function (Blueprint $table) {});
+`,
+		"database/migrations/2024_02_01_000000_add_role_to_users.php": `<?php
+Schema::table('users', function (Blueprint $table) {});
+`,
+	})
+
+	info, err := frameworkInfo(root, FrameworkInfoArgs{})
+	if err != nil {
+		t.Fatalf("framework_info: %v", err)
+	}
+	if info.Framework != "laravel" || info.Version != "^11.0" {
+		t.Fatalf("unexpected detection: %q %q", info.Framework, info.Version)
+	}
+
+	byURI := map[string]FrameworkRoute{}
+	for _, r := range info.Routes {
+		byURI[strings.Join(r.Methods, ",")+" "+r.URI] = r
+	}
+	if _, ok := byURI["GET,HEAD /disabled"]; ok {
+		t.Fatalf("commented-out route was parsed")
+	}
+	show := byURI["GET,HEAD /users/{user}"]
+	if show.Action != `App\Http\Controllers\UserController@show` || show.Name != "users.show" ||
+		show.ControllerFile != "app/Http/Controllers/UserController.php" || show.ControllerLine != 6 {
+		t.Fatalf("unexpected users.show route: %+v", show)
+	}
+	if r := byURI["GET,HEAD /"]; r.Action != "Closure" {
+		t.Fatalf("expected closure route, got %+v", r)
+	}
+	reports := byURI["GET,HEAD /admin/reports"]
+	if reports.Name != "admin.reports" || reports.Action != `App\Http\Controllers\Admin\ReportController@index` ||
+		strings.Join(reports.Middleware, ",") != "auth,can:admin" {
+		t.Fatalf("unexpected grouped route: %+v", reports)
+	}
+	if r := byURI["GET,HEAD /admin/photos/{photo}"]; r.Name != "admin.photos.show" {
+		t.Fatalf("expected resource show route, got %+v", r)
+	}
+	if _, ok := byURI["POST /admin/photos"]; ok {
+		t.Fatalf("only() should exclude store")
+	}
+	if r := byURI["POST /users"]; r.Action != `App\Http\Controllers\UserController@store` || r.ControllerLine != 10 {
+		t.Fatalf("unexpected controller group route: %+v", r)
+	}
+	if r := byURI["PUT,PATCH /api/orders/{order}"]; r.Action != `App\Http\Controllers\OrderController@update` {
+		t.Fatalf("unexpected api resource route: %+v", r)
+	}
+	if _, ok := byURI["GET,HEAD /api/orders/create"]; ok {
+		t.Fatalf("apiResource should not register create")
+	}
+	if info.RouteCount != 11 {
+		t.Fatalf("expected 11 routes, got %d: %+v", info.RouteCount, info.Routes)
+	}
+
+	if len(info.Commands) != 2 || info.Commands[0].Name != "inspire" || info.Commands[1].Name != "users:prune" ||
+		info.Commands[1].Description != "Remove inactive users" || info.Commands[0].Description != "Display an inspiring quote" {
+		t.Fatalf("unexpected commands: %+v", info.Commands)
+	}
+	if len(info.Migrations) != 2 || info.Migrations[0].Creates[0] != "users" || info.Migrations[1].Alters[0] != "users" {
+		t.Fatalf("unexpected migrations: %+v", info.Migrations)
+	}
+	if len(info.SuggestedActions) == 0 || info.SuggestedActions[0].Command != "composer" {
+		t.Fatalf("expected composer install first without vendor/, got %+v", info.SuggestedActions)
+	}
+
+	filtered, _ := frameworkInfo(root, FrameworkInfoArgs{Section: "routes", Filter: "admin."})
+	if len(filtered.Routes) != 3 || filtered.Commands != nil || filtered.SuggestedActions != nil {
+		t.Fatalf("unexpected filtered result: %+v", filtered)
+	}
+}
+
+func TestFrameworkInfo_NoFramework(t *testing.T) {
+	info, err := frameworkInfo(t.TempDir(), FrameworkInfoArgs{})
+	if err != nil || info.Framework != "" || len(info.Notes) == 0 {
+		t.Fatalf("expected no framework, got %+v, %v", info, err)
+	}
+}
@@ -0,0 +1,342 @@
+package tool
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/loom/loom/internal/textutil"
+)
+
+// Limits bounds how long a tool may run, how much output it may return to the model,
+// and how many invocations of it may run at once. Zero fields disable that limit.
+type Limits struct {
+	Timeout        time.Duration
+	MaxOutputBytes int
+	MaxConcurrent  int
+}
+
+// SetLimits installs per-tool limits. Tools without an entry use defaults.
+// Replacing limits resets concurrency slots; in-flight calls keep their old slot.
+func (r *Registry) SetLimits(defaults Limits, perTool map[string]Limits) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.defaultLimits = defaults
+	r.limits = make(map[string]Limits, len(perTool))
+	for name, l := range perTool {
+		r.limits[name] = l
+	}
+	r.slots = make(map[string]chan struct{})
+}
+
+// LimitsFor returns the limits that apply to the named tool.
+func (r *Registry) LimitsFor(name string) Limits {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if l, ok := r.limits[name]; ok {
+		return l
+	}
+	return r.defaultLimits
+}
+
+// SlotUsage is how many concurrency slots of a limited tool are taken.
+type SlotUsage struct {
+	Tool  string `json:"tool"`
+	InUse int    `json:"in_use"`
+	Max   int    `json:"max"`
+}
+
+// SlotUsage returns the slot occupancy of every concurrency-limited tool that has been
+// invoked since limits were last set, sorted by tool name.
+func (r *Registry) SlotUsage() []SlotUsage {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	out := make([]SlotUsage, 0, len(r.slots))
+	for name, slot := range r.slots {
+		out = append(out, SlotUsage{Tool: name, InUse: len(slot), Max: cap(slot)})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Tool < out[j].Tool })
+	return out
+}
+
+// acquireSlot blocks until a concurrency slot for the tool is free. The returned
+// release func must be called when the invocation finishes.
+func (r *Registry) acquireSlot(ctx context.Context, name string, max int) (func(), error) {
+	if max <= 0 {
+		return func() {}, nil
+	}
+	r.mu.Lock()
+	slot, ok := r.slots[name]
+	if !ok || cap(slot) != max {
+		slot = make(chan struct{}, max)
+		if r.slots == nil {
+			r.slots = make(map[string]chan struct{})
+		}
+		r.slots[name] = slot
+	}
+	r.mu.Unlock()
+
+	select {
+	case slot <- struct{}{}:
+		return func() { <-slot }, nil
+	case <-ctx.Done():
+		return nil, fmt.Errorf("tool %q: waiting for a free slot: %w", name, ctx.Err())
+	}
+}
+
+// invokeLimited runs the handler under the tool's timeout and concurrency limits.
+// Read-only handlers are abandoned at the timeout even if they ignore context
+// cancellation. Handlers with side effects only get the deadline through ctx and are
+// waited for: one still running after the model was told it failed could change the
+// workspace behind its back.
+func (r *Registry) invokeLimited(ctx context.Context, def Definition, args json.RawMessage) (interface{}, error) {
+	limits := r.LimitsFor(def.Name)
+
+	release, err := r.acquireSlot(ctx, def.Name, limits.MaxConcurrent)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	if limits.Timeout <= 0 {
+		return def.Handler(ctx, args)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, limits.Timeout)
+	defer cancel()
+
+	if !def.ReadOnly {
+		res, err := def.Handler(ctx, args)
+		if err != nil && ctx.Err() == context.DeadlineExceeded {
+			return nil, fmt.Errorf("tool %q timed out after %s: %w", def.Name, limits.Timeout, err)
+		}
+		return res, err
+	}
+
+	type outcome struct {
+		result interface{}
+		err    error
+	}
+	done := make(chan outcome, 1)
+	go func() {
+		res, err := def.Handler(ctx, args)
+		done <- outcome{res, err}
+	}()
+
+	select {
+	case o := <-done:
+		return o.result, o.err
+	case <-ctx.Done():
+		if ctx.Err() == context.DeadlineExceeded {
+			return nil, fmt.Errorf("tool %q timed out after %s", def.Name, limits.Timeout)
+		}
+		return nil, ctx.Err()
+	}
+}
+
+// truncateOutput caps content at max bytes, noting how much was dropped. JSON content
+// is shortened inside its longest strings instead, so it stays parseable. Content that
+// was truncated before keeps a single marker with its original size.
+func truncateOutput(content string, max int) string {
+	if max <= 0 || len(content) <= max {
+		return content
+	}
+	if fitted, ok := truncateJSON(content, max); ok {
+		return fitted
+	}
+	text, total := stripTruncation(outputMarker, content)
+	return textutil.ClipBytes(text, max) + fmt.Sprintf("\n... [output truncated: %d of %d bytes shown]", max, total)
+}
+
+var (
+	// outputMarker ends text output cut by truncateOutput
+	outputMarker = regexp.MustCompile(`\n\.\.\. \[output truncated: \d+ of (\d+) bytes shown\]$`)
+	// stringMarker ends a JSON string value cut by truncateJSON
+	stringMarker = regexp.MustCompile(`\.\.\. \[truncated: \d+ of (\d+) bytes shown\]$`)
+)
+
+// stripTruncation removes a truncation marker matched by marker from the end of s and
+// returns the remaining text with the size s had before it was first truncated.
+func stripTruncation(marker *regexp.Regexp, s string) (string, int) {
+	m := marker.FindStringSubmatchIndex(s)
+	if m == nil {
+		return s, len(s)
+	}
+	total, err := strconv.Atoi(s[m[2]:m[3]])
+	if err != nil {
+		return s, len(s)
+	}
+	return s[:m[0]], total
+}
+
+// truncateJSON shortens the longest string values of a JSON object or array until its
+// encoding fits max bytes. ok is false when content is not JSON. Key order, numbers and
+// characters such as < and & are kept as they were; structure and short fields are
+// kept too, so the result may stay above max when they alone exceed it.
+func truncateJSON(content string, max int) (string, bool) {
+	trimmed := strings.TrimSpace(content)
+	if trimmed == "" || (trimmed[0] != '{' && trimmed[0] != '[') {
+		return "", false
+	}
+	dec := json.NewDecoder(strings.NewReader(trimmed))
+	dec.UseNumber()
+	v, err := decodeOrdered(dec)
+	if err != nil || dec.More() {
+		return "", false
+	}
+	indent := strings.Contains(trimmed, "\n")
+	encode := func() []byte {
+		b, _ := encodeJSON(v, indent)
+		return b
+	}
+	b := encode()
+	for attempt := 0; attempt < 32 && len(b) > max; attempt++ {
+		var strs []jsonString
+		collectJSONStrings(v, &strs)
+		longest := -1
+		for i := range strs {
+			if longest < 0 || len(strs[i].get()) > len(strs[longest].get()) {
+				longest = i
+			}
+		}
+		if longest < 0 {
+			break
+		}
+		s, total := stripTruncation(stringMarker, strs[longest].get())
+		if len(s) <= 64 {
+			break
+		}
+		keep := len(s) - (len(b) - max) - 64
+		if keep < 0 {
+			keep = 0
+		}
+		strs[longest].set(textutil.ClipBytes(s, keep) + fmt.Sprintf("... [truncated: %d of %d bytes shown]", keep, total))
+		b = encode()
+	}
+	return string(b), true
+}
+
+// encodeJSON encodes v without escaping HTML characters, indented like the tool
+// results that are pretty-printed.
+func encodeJSON(v interface{}, indent bool) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if indent {
+		enc.SetIndent("", "  ")
+	}
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+// jsonObject is a decoded JSON object that keeps the order of its keys.
+type jsonObject []jsonMember
+
+type jsonMember struct {
+	key   string
+	value interface{}
+}
+
+func (o jsonObject) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, m := range o {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		k, err := encodeJSON(m.key, false)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(k)
+		buf.WriteByte(':')
+		v, err := encodeJSON(m.value, false)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(v)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// decodeOrdered reads one JSON value from dec, decoding objects as jsonObject and
+// numbers as json.Number.
+func decodeOrdered(dec *json.Decoder) (interface{}, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch tok {
+	case json.Delim('{'):
+		obj := jsonObject{}
+		for dec.More() {
+			keyTok, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			key, _ := keyTok.(string)
+			val, err := decodeOrdered(dec)
+			if err != nil {
+				return nil, err
+			}
+			obj = append(obj, jsonMember{key: key, value: val})
+		}
+		_, err := dec.Token()
+		return obj, err
+	case json.Delim('['):
+		arr := []interface{}{}
+		for dec.More() {
+			val, err := decodeOrdered(dec)
+			if err != nil {
+				return nil, err
+			}
+			arr = append(arr, val)
+		}
+		_, err := dec.Token()
+		return arr, err
+	}
+	return tok, nil
+}
+
+// jsonString reads and replaces one string value inside decoded JSON.
+type jsonString struct {
+	get func() string
+	set func(string)
+}
+
+// collectJSONStrings lists the string values nested in v.
+func collectJSONStrings(v interface{}, out *[]jsonString) {
+	switch t := v.(type) {
+	case jsonObject:
+		for i, m := range t {
+			if _, ok := m.value.(string); ok {
+				*out = append(*out, jsonString{
+					get: func() string { return t[i].value.(string) },
+					set: func(s string) { t[i].value = s },
+				})
+			} else {
+				collectJSONStrings(m.value, out)
+			}
+		}
+	case []interface{}:
+		for i, val := range t {
+			if _, ok := val.(string); ok {
+				*out = append(*out, jsonString{
+					get: func() string { return t[i].(string) },
+					set: func(s string) { t[i] = s },
+				})
+			} else {
+				collectJSONStrings(val, out)
+			}
+		}
+	}
+}
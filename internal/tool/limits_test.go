@@ -0,0 +1,150 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestLimits_TimeoutAndOutput(t *testing.T) {
+	reg := NewRegistry()
+	_ = reg.Register(Definition{
+		Name:     "slow",
+		Safe:     true,
+		ReadOnly: true,
+		Handler: func(ctx context.Context, raw json.RawMessage) (interface{}, error) {
+			time.Sleep(time.Second) // deliberately ignores ctx
+			return "done", nil
+		},
+	})
+	_ = reg.Register(Definition{
+		Name: "loud",
+		Safe: true,
+		Handler: func(ctx context.Context, raw json.RawMessage) (interface{}, error) {
+			return strings.Repeat("x", 100), nil
+		},
+	})
+	reg.SetLimits(Limits{MaxOutputBytes: 10}, map[string]Limits{
+		"slow": {Timeout: 20 * time.Millisecond},
+	})
+
+	start := time.Now()
+	res, err := reg.InvokeToolCall(context.Background(), &ToolCall{Name: "slow"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if time.Since(start) > 500*time.Millisecond {
+		t.Fatalf("timeout not enforced for handler ignoring ctx")
+	}
+	if !strings.Contains(res.Content, "timed out") {
+		t.Fatalf("expected timeout error, got %q", res.Content)
+	}
+
+	res, _ = reg.InvokeToolCall(context.Background(), &ToolCall{Name: "loud"})
+	if !strings.HasPrefix(res.Content, "xxxxxxxxxx\n... [output truncated") {
+		t.Fatalf("expected truncated output, got %q", res.Content)
+	}
+}
+
+func TestLimits_TimeoutWaitsForToolsWithSideEffects(t *testing.T) {
+	reg := NewRegistry()
+	var finished atomic.Bool
+	_ = reg.Register(Definition{
+		Name: "write",
+		Handler: func(ctx context.Context, raw json.RawMessage) (interface{}, error) {
+			<-ctx.Done()
+			time.Sleep(20 * time.Millisecond) // cleanup after cancellation
+			finished.Store(true)
+			return nil, ctx.Err()
+		},
+	})
+	reg.SetLimits(Limits{}, map[string]Limits{"write": {Timeout: 10 * time.Millisecond}})
+
+	_, err := reg.Invoke(context.Background(), "write", nil)
+	if err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Fatalf("expected a timeout error, got %v", err)
+	}
+	if !finished.Load() {
+		t.Fatal("the handler must have exited before the timeout is reported")
+	}
+}
+
+func TestTruncateOutput_KeepsJSONValid(t *testing.T) {
+	payload, _ := json.MarshalIndent(map[string]interface{}{
+		"command":   "go test ./...",
+		"exit_code": 1,
+		"stdout":    strings.Repeat("ok line\n", 500),
+		"stderr":    strings.Repeat("FAIL \"quoted\"\n", 200),
+	}, "", "  ")
+	out := truncateOutput(string(payload), 1024)
+	if len(out) > 1024 {
+		t.Fatalf("expected at most 1024 bytes, got %d", len(out))
+	}
+	var sr ShellResult
+	if err := json.Unmarshal([]byte(out), &sr); err != nil {
+		t.Fatalf("truncated JSON does not parse: %v\n%s", err, out)
+	}
+	if sr.ExitCode != 1 || !strings.Contains(sr.Stdout+sr.Stderr, "[truncated:") {
+		t.Fatalf("unexpected result %+v", sr)
+	}
+}
+
+func TestTruncateOutput_KeepsKeysAndCharacters(t *testing.T) {
+	content := `{"z_first":"<a href=\"x\">&amp;</a>","id":12345678901234567890,"body":"` + strings.Repeat("x", 4000) + `"}`
+	out := truncateOutput(content, 1024)
+	if !strings.HasPrefix(out, `{"z_first":"<a href=\"x\">&amp;</a>","id":12345678901234567890,"body":"xxx`) {
+		t.Fatalf("keys, numbers or characters changed: %s", out[:120])
+	}
+	again := truncateOutput(out, 512)
+	if n := strings.Count(again, "[truncated:"); n != 1 || !strings.Contains(again, "of 4000 bytes shown]") {
+		t.Fatalf("expected one marker with the original size, got %d in %s", n, again)
+	}
+
+	text := truncateOutput(strings.Repeat("line\n", 1000), 1000)
+	text = truncateOutput(text, 500)
+	if n := strings.Count(text, "[output truncated:"); n != 1 || !strings.HasSuffix(text, "[output truncated: 500 of 5000 bytes shown]") {
+		t.Fatalf("expected one text marker, got %q", text[len(text)-80:])
+	}
+}
+
+func TestLimits_MaxConcurrent(t *testing.T) {
+	reg := NewRegistry()
+	var active, peak int32
+	_ = reg.Register(Definition{
+		Name: "busy",
+		Handler: func(ctx context.Context, raw json.RawMessage) (interface{}, error) {
+			n := atomic.AddInt32(&active, 1)
+			for {
+				p := atomic.LoadInt32(&peak)
+				if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+					break
+				}
+			}
+			time.Sleep(10 * time.Millisecond)
+			atomic.AddInt32(&active, -1)
+			return "ok", nil
+		},
+	})
+	reg.SetLimits(Limits{}, map[string]Limits{"busy": {MaxConcurrent: 1}})
+
+	done := make(chan struct{})
+	for i := 0; i < 4; i++ {
+		go func() {
+			_, _ = reg.Invoke(context.Background(), "busy", nil)
+			done <- struct{}{}
+		}()
+	}
+	for i := 0; i < 4; i++ {
+		<-done
+	}
+	if p := atomic.LoadInt32(&peak); p != 1 {
+		t.Fatalf("expected at most 1 concurrent call, saw %d", p)
+	}
+	usage := reg.SlotUsage()
+	if len(usage) != 1 || usage[0].Tool != "busy" || usage[0].InUse != 0 || usage[0].Max != 1 {
+		t.Fatalf("unexpected slot usage: %+v", usage)
+	}
+}
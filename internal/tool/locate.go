@@ -0,0 +1,544 @@
+package tool
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/loom/loom/internal/symbols"
+	"github.com/loom/loom/internal/textutil"
+)
+
+// LocateArgs represents the arguments for the locate tool.
+type LocateArgs struct {
+	Query      string `json:"query"`
+	Limit      int    `json:"limit,omitempty"`
+	PathPrefix string `json:"path_prefix,omitempty"`
+}
+
+// LocateCandidate is one ranked place that may be what the query describes.
+type LocateCandidate struct {
+	Path    string   `json:"path"`
+	Line    int      `json:"line,omitempty"`
+	Score   float64  `json:"score"`
+	Symbol  string   `json:"symbol,omitempty"`
+	Snippet string   `json:"snippet,omitempty"`
+	Reasons []string `json:"reasons"`
+}
+
+// LocateResult is returned by the locate tool.
+type LocateResult struct {
+	Query        string            `json:"query"`
+	Terms        []string          `json:"terms"`
+	Candidates   []LocateCandidate `json:"candidates"`
+	FilesScanned int               `json:"files_scanned"`
+	Truncated    bool              `json:"truncated,omitempty"`
+}
+
+const (
+	locateMaxFiles    = 5000
+	locateMaxFileSize = 512 * 1024
+	// locateMaxLines caps the matching lines remembered per file
+	locateMaxLines = 200
+	locateMaxTerms = 8
+)
+
+// locateStopwords carry no information about where something lives.
+var locateStopwords = map[string]bool{
+	"the": true, "a": true, "an": true, "and": true, "or": true, "of": true, "for": true, "to": true,
+	"in": true, "on": true, "at": true, "by": true, "with": true, "is": true, "are": true, "be": true,
+	"it": true, "its": true, "this": true, "that": true, "where": true, "what": true, "which": true,
+	"how": true, "who": true, "does": true, "do": true, "we": true, "our": true, "my": true, "me": true,
+	"find": true, "open": true, "show": true, "locate": true, "file": true, "files": true, "code": true,
+	"defined": true, "define": true, "implemented": true, "implement": true, "handled": true, "done": true,
+}
+
+// locateAliases are abbreviations and synonyms common in code; they count half.
+var locateAliases = map[string][]string{
+	"db": {"database"}, "database": {"db"}, "config": {"settings", "cfg"}, "settings": {"config"},
+	"auth": {"login"}, "login": {"auth"}, "retry": {"backoff"}, "err": {"error"}, "error": {"err"},
+	"test": {"spec"}, "spec": {"test"}, "env": {"environment"}, "msg": {"message"}, "message": {"msg"},
+}
+
+// locateConfigExts are file types that usually hold configuration.
+var locateConfigExts = map[string]bool{
+	".yaml": true, ".yml": true, ".toml": true, ".ini": true, ".env": true, ".conf": true, ".cfg": true,
+	".json": true, ".properties": true,
+}
+
+// locateTerm is a query word to look for; alias terms weigh less than the user's own words.
+type locateTerm struct {
+	text   string
+	weight float64
+	alias  bool
+}
+
+// locateLine is a line that matched some terms, as a bit mask over the term list.
+type locateLine struct {
+	num  int
+	mask uint32
+	text string
+}
+
+// locateFile collects the evidence for one file.
+type locateFile struct {
+	path   string
+	base   uint32 // terms in the file name
+	dir    uint32 // terms in the directory path
+	counts []int  // occurrences per term in the content
+	lines  []locateLine
+	// symbol evidence from symbols_search
+	symbolScore float64
+	symbolName  string
+	symbolLine  int
+}
+
+// RegisterLocate registers the locate tool. Symbol search is used when a symbols_search
+// tool is registered on the same registry at call time.
+func RegisterLocate(registry *Registry, workspacePath string) error {
+	return registry.Register(Definition{
+		Name:        "locate",
+		Description: "Resolve a natural-language description (\"the retry middleware\", \"where the DB pool size is configured\") into ranked file/line candidates. Combines file name matching, symbol search and content matching in one call; use it before search_code when you do not know the exact identifier.",
+		Safe:        true,
+		ReadOnly:    true,
+		JSONSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"query": map[string]interface{}{
+					"type":        "string",
+					"description": "What to find, in plain words",
+				},
+				"limit": map[string]interface{}{
+					"type":        "integer",
+					"description": "Maximum candidates to return (default 10, max 30)",
+				},
+				"path_prefix": map[string]interface{}{
+					"type":        "string",
+					"description": "Only look under this directory, relative to the workspace root",
+				},
+			},
+			"required": []string{"query"},
+		},
+		Usage: "Matching is lexical: query words are compared with file names, symbol names and file contents, and rarer words count more. Candidates that match every word rank above those matching one. Each candidate lists the reasons it matched.",
+		Examples: []string{
+			`{"query": "retry middleware"}`,
+			`{"query": "where is the db pool size configured", "limit": 5}`,
+		},
+		Handler: func(ctx context.Context, raw json.RawMessage) (interface{}, error) {
+			var args LocateArgs
+			if err := json.Unmarshal(raw, &args); err != nil {
+				return nil, fmt.Errorf("failed to parse arguments: %w", err)
+			}
+			return locate(ctx, workspacePath, args, func(ctx context.Context, q, prefix string) []symbols.SymbolCard {
+				def, ok := registry.Get("symbols_search")
+				if !ok || def.Handler == nil {
+					return nil
+				}
+				raw, _ := json.Marshal(SymbolsSearchArgs{Q: q, PathPrefix: prefix, Limit: 30})
+				out, err := def.Handler(ctx, raw)
+				if err != nil {
+					return nil
+				}
+				cards, _ := out.([]symbols.SymbolCard)
+				return cards
+			})
+		},
+	})
+}
+
+// symbolSearchFunc looks up symbols matching q; it returns nil when no index is available.
+type symbolSearchFunc func(ctx context.Context, q, pathPrefix string) []symbols.SymbolCard
+
+func locate(ctx context.Context, workspacePath string, args LocateArgs, searchSymbols symbolSearchFunc) (*LocateResult, error) {
+	terms := locateTerms(args.Query)
+	if len(terms) == 0 {
+		return nil, fmt.Errorf("query must contain at least one meaningful word")
+	}
+	limit := args.Limit
+	if limit <= 0 {
+		limit = 10
+	}
+	if limit > 30 {
+		limit = 30
+	}
+	root := workspacePath
+	prefix := strings.Trim(filepath.ToSlash(args.PathPrefix), "/")
+	if prefix != "" && prefix != "." {
+		abs, err := validatePath(workspacePath, prefix)
+		if err != nil {
+			return nil, err
+		}
+		root = abs
+	} else {
+		prefix = ""
+	}
+
+	res := &LocateResult{Query: args.Query, Candidates: []LocateCandidate{}}
+	for _, t := range terms {
+		if !t.alias {
+			res.Terms = append(res.Terms, t.text)
+		}
+	}
+
+	files := map[string]*locateFile{}
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		name := d.Name()
+		if d.IsDir() {
+			if path != root && ((strings.HasPrefix(name, ".") && name != ".github") || skippedTreeDirs[name]) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		if res.FilesScanned >= locateMaxFiles {
+			res.Truncated = true
+			return filepath.SkipAll
+		}
+		res.FilesScanned++
+		rel, err := filepath.Rel(workspacePath, path)
+		if err != nil {
+			return nil
+		}
+		if f := scanLocateFile(path, filepath.ToSlash(rel), terms); f != nil {
+			files[f.path] = f
+		}
+		return nil
+	})
+	if err != nil && ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	if searchSymbols != nil {
+		addSymbolEvidence(ctx, files, terms, prefix, searchSymbols)
+	}
+
+	// Rarer terms tell files apart better; df counts files with any evidence of a term
+	df := make([]int, len(terms))
+	for _, f := range files {
+		for i := range terms {
+			if f.base&(1<<i) != 0 || f.dir&(1<<i) != 0 || f.counts[i] > 0 {
+				df[i]++
+			}
+		}
+	}
+	idf := make([]float64, len(terms))
+	for i, t := range terms {
+		idf[i] = t.weight * math.Log(1+float64(res.FilesScanned+1)/float64(df[i]+1))
+	}
+	maskScore := func(mask uint32) float64 {
+		s := 0.0
+		for i := range terms {
+			if mask&(1<<i) != 0 {
+				s += idf[i]
+			}
+		}
+		return s
+	}
+
+	primary := len(res.Terms)
+	configIntent := false
+	for _, t := range terms {
+		if !t.alias && (strings.HasPrefix(t.text, "config") || strings.HasPrefix(t.text, "setting")) {
+			configIntent = true
+		}
+	}
+	for _, f := range files {
+		c := LocateCandidate{Path: f.path}
+		var matched uint32
+		var found []string
+
+		if s := 3*maskScore(f.base) + maskScore(f.dir); s > 0 {
+			c.Score += s
+			matched |= f.base | f.dir
+			c.Reasons = append(c.Reasons, "name matches "+joinTerms(terms, f.base|f.dir))
+		}
+
+		var content uint32
+		for i, n := range f.counts {
+			if n > 0 {
+				content |= 1 << i
+				c.Score += idf[i] * (1 + math.Log(float64(n))) * 0.5
+			}
+		}
+		var best locateLine
+		bestScore := 0.0
+		for _, l := range f.lines {
+			if s := maskScore(l.mask); s > bestScore {
+				best, bestScore = l, s
+			}
+		}
+		if bestScore > 0 {
+			c.Score += 1.5 * bestScore
+			matched |= content
+			c.Line, c.Snippet = best.num, best.text
+			found = append(found, fmt.Sprintf("line %d mentions %s", best.num, joinTerms(terms, best.mask)))
+		}
+
+		if f.symbolScore > 0 {
+			c.Score += f.symbolScore
+			c.Symbol = f.symbolName
+			c.Reasons = append(c.Reasons, "defines symbol "+f.symbolName)
+			for i, t := range terms {
+				if strings.Contains(strings.ToLower(f.symbolName), t.text) {
+					matched |= 1 << i
+				}
+			}
+			// Prefer the definition unless a line matches clearly more of the query
+			if f.symbolScore >= 1.5*bestScore {
+				c.Line, c.Snippet = f.symbolLine, ""
+			}
+		}
+		c.Reasons = append(c.Reasons, found...)
+		if c.Score == 0 {
+			continue
+		}
+
+		if configIntent && (locateConfigExts[strings.ToLower(filepath.Ext(f.path))] || strings.Contains(strings.ToLower(f.path), "config")) {
+			c.Score *= 1.25
+			c.Reasons = append(c.Reasons, "configuration file")
+		}
+		// Files covering more of the user's words rank above files repeating one of them
+		covered := 0
+		for i, t := range terms {
+			if !t.alias && matched&(1<<i) != 0 {
+				covered++
+			}
+		}
+		if primary > 0 {
+			c.Score *= 0.25 + 0.75*float64(covered)/float64(primary)
+		}
+		c.Score = math.Round(c.Score*100) / 100
+		res.Candidates = append(res.Candidates, c)
+	}
+
+	sort.SliceStable(res.Candidates, func(i, j int) bool {
+		a, b := res.Candidates[i], res.Candidates[j]
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		return a.Path < b.Path
+	})
+	if len(res.Candidates) > limit {
+		res.Candidates = res.Candidates[:limit]
+	}
+	return res, nil
+}
+
+// locateTerms turns a description into search terms: lowercased words without stopwords,
+// reduced to a stem, followed by aliases of those words.
+func locateTerms(query string) []locateTerm {
+	var out []locateTerm
+	seen := map[string]bool{}
+	add := func(text string, alias bool) {
+		if text == "" || seen[text] || len(out) >= locateMaxTerms {
+			return
+		}
+		seen[text] = true
+		w := 1.0
+		if alias {
+			w = 0.5
+		}
+		out = append(out, locateTerm{text: text, weight: w, alias: alias})
+	}
+	var words []string
+	for _, w := range splitIdentifier(query) {
+		if len(w) < 2 || locateStopwords[w] {
+			continue
+		}
+		words = append(words, locateStem(w))
+	}
+	for _, w := range words {
+		add(w, false)
+	}
+	for _, w := range words {
+		for _, a := range locateAliases[w] {
+			add(a, true)
+		}
+	}
+	return out
+}
+
+// locateStem strips common English suffixes so "configured" finds "configuration" and
+// "retries" finds "retry"; terms are matched as substrings, so the stem need not be a word.
+func locateStem(w string) string {
+	switch {
+	case strings.HasSuffix(w, "ies") && len(w) > 5:
+		return w[:len(w)-3] + "y"
+	case strings.HasSuffix(w, "ation") && len(w) > 8:
+		return w[:len(w)-5]
+	case strings.HasSuffix(w, "ing") && len(w) > 6:
+		return w[:len(w)-3]
+	case strings.HasSuffix(w, "ed") && len(w) > 5:
+		return w[:len(w)-2]
+	case strings.HasSuffix(w, "s") && !strings.HasSuffix(w, "ss") && len(w) > 4:
+		return w[:len(w)-1]
+	}
+	return w
+}
+
+// splitIdentifier lowercases s and splits it into words at non-alphanumerics and
+// camelCase boundaries, so "DBPoolSize" and "db_pool_size" both give db, pool, size.
+func splitIdentifier(s string) []string {
+	var words []string
+	var cur []rune
+	flush := func() {
+		if len(cur) > 0 {
+			words = append(words, strings.ToLower(string(cur)))
+			cur = cur[:0]
+		}
+	}
+	rs := []rune(s)
+	for i, r := range rs {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			flush()
+			continue
+		}
+		if unicode.IsUpper(r) && len(cur) > 0 {
+			prev := rs[i-1]
+			nextLower := i+1 < len(rs) && unicode.IsLower(rs[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextLower) {
+				flush()
+			}
+		}
+		cur = append(cur, r)
+	}
+	flush()
+	return words
+}
+
+// termMask returns the terms found in text. Short terms such as "db" must be a whole
+// word of the text; longer ones may be part of one.
+func termMask(text string, terms []locateTerm) uint32 {
+	lower := strings.ToLower(text)
+	var words map[string]bool
+	var mask uint32
+	for i, t := range terms {
+		if len(t.text) > 3 {
+			if strings.Contains(lower, t.text) {
+				mask |= 1 << i
+			}
+			continue
+		}
+		if !strings.Contains(lower, t.text) {
+			continue
+		}
+		if words == nil {
+			words = map[string]bool{}
+			for _, w := range splitIdentifier(text) {
+				words[w] = true
+			}
+		}
+		if words[t.text] {
+			mask |= 1 << i
+		}
+	}
+	return mask
+}
+
+// scanLocateFile matches the terms against a file's path and text. It returns nil for
+// files with no evidence and skips large or binary files' contents.
+func scanLocateFile(path, rel string, terms []locateTerm) *locateFile {
+	base := filepath.Base(rel)
+	f := &locateFile{
+		path:   rel,
+		base:   termMask(strings.TrimSuffix(base, filepath.Ext(base)), terms),
+		dir:    termMask(filepath.Dir(rel), terms),
+		counts: make([]int, len(terms)),
+	}
+	f.dir &^= f.base
+
+	if st, err := os.Stat(path); err == nil && st.Size() <= locateMaxFileSize {
+		if fh, err := os.Open(path); err == nil {
+			defer fh.Close()
+			head := make([]byte, 512)
+			n, _ := fh.Read(head)
+			if !looksBinary(head[:n]) {
+				_, _ = fh.Seek(0, 0)
+				sc := bufio.NewScanner(fh)
+				sc.Buffer(make([]byte, 64*1024), locateMaxFileSize)
+				for num := 1; sc.Scan(); num++ {
+					line := sc.Text()
+					mask := termMask(line, terms)
+					if mask == 0 {
+						continue
+					}
+					for i := range terms {
+						if mask&(1<<i) != 0 {
+							f.counts[i]++
+						}
+					}
+					if len(f.lines) < locateMaxLines {
+						f.lines = append(f.lines, locateLine{num: num, mask: mask, text: textutil.TruncateWidth(strings.TrimSpace(line), 160)})
+					}
+				}
+			}
+		}
+	}
+	if f.base == 0 && f.dir == 0 && len(f.lines) == 0 {
+		return nil
+	}
+	return f
+}
+
+// addSymbolEvidence searches the symbol index for every user term and credits files
+// defining symbols whose names contain the query's words.
+func addSymbolEvidence(ctx context.Context, files map[string]*locateFile, terms []locateTerm, prefix string, search symbolSearchFunc) {
+	seen := map[string]bool{}
+	for _, t := range terms {
+		if t.alias {
+			continue
+		}
+		for _, card := range search(ctx, t.text, prefix) {
+			if seen[card.SID] {
+				continue
+			}
+			seen[card.SID] = true
+			name := termMask(strings.Join(splitIdentifier(card.Name), " "), terms)
+			if name == 0 {
+				continue
+			}
+			score := 0.0
+			for i, tt := range terms {
+				if name&(1<<i) != 0 {
+					score += 4 * tt.weight
+				}
+			}
+			path := filepath.ToSlash(card.File)
+			f := files[path]
+			if f == nil {
+				f = &locateFile{path: path, counts: make([]int, len(terms))}
+				files[path] = f
+			}
+			if score > f.symbolScore {
+				f.symbolScore, f.symbolName, f.symbolLine = score, card.Name, card.Span[0]
+			}
+		}
+	}
+}
+
+// joinTerms lists the terms in mask for a reason string.
+func joinTerms(terms []locateTerm, mask uint32) string {
+	var parts []string
+	for i, t := range terms {
+		if mask&(1<<i) != 0 {
+			parts = append(parts, `"`+t.text+`"`)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,80 @@
+package tool
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/loom/loom/internal/symbols"
+)
+
+func TestLocateTerms(t *testing.T) {
+	var got []string
+	for _, term := range locateTerms("Where is the DB pool size configured?") {
+		got = append(got, term.text)
+	}
+	want := []string{"db", "pool", "size", "configur", "database"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("locateTerms = %v, want %v", got, want)
+	}
+	if got := splitIdentifier("HTTPRetryMiddleware_v2"); !reflect.DeepEqual(got, []string{"http", "retry", "middleware", "v2"}) {
+		t.Fatalf("splitIdentifier = %v", got)
+	}
+}
+
+func TestLocate_RanksFilesCoveringTheQuery(t *testing.T) {
+	ws := writeWorkspace(t, map[string]string{
+		"config/database.yaml":        "database:\n  host: localhost\n  pool_size: 20\n",
+		"internal/db/conn.go":         "package db\n\n// Open connects to the database.\nfunc Open() {}\n",
+		"internal/http/retry.go":      "package http\n\n// RetryMiddleware retries failed requests with backoff.\nfunc RetryMiddleware() {}\n",
+		"internal/http/middleware.go": "package http\n\n// Logging middleware.\nfunc Logging() {}\n",
+		"docs/feedback.md":            "Send feedback about pools.\n",
+		"node_modules/x/pool_size.js": "pool size",
+	})
+
+	res, err := locate(context.Background(), ws, LocateArgs{Query: "where is the DB pool size configured"}, nil)
+	if err != nil {
+		t.Fatalf("locate: %v", err)
+	}
+	if len(res.Candidates) == 0 || res.Candidates[0].Path != "config/database.yaml" || res.Candidates[0].Line != 3 {
+		t.Fatalf("expected the pool size setting first, got %+v", res.Candidates)
+	}
+	for _, c := range res.Candidates {
+		if c.Path == "node_modules/x/pool_size.js" {
+			t.Fatalf("dependency directories must be skipped")
+		}
+	}
+
+	res, err = locate(context.Background(), ws, LocateArgs{Query: "the retry middleware", Limit: 1}, nil)
+	if err != nil {
+		t.Fatalf("locate: %v", err)
+	}
+	if len(res.Candidates) != 1 || res.Candidates[0].Path != "internal/http/retry.go" {
+		t.Fatalf("expected retry.go, got %+v", res.Candidates)
+	}
+
+	if _, err := locate(context.Background(), ws, LocateArgs{Query: "where is the"}, nil); err == nil {
+		t.Fatalf("expected an error for a query of stopwords")
+	}
+	if _, err := locate(context.Background(), ws, LocateArgs{Query: "pool", PathPrefix: "../"}, nil); err == nil {
+		t.Fatalf("expected path_prefix outside the workspace to be refused")
+	}
+}
+
+func TestLocate_UsesSymbols(t *testing.T) {
+	ws := writeWorkspace(t, map[string]string{
+		"pkg/limits.go": "package pkg\n\nvar x = 1\n\nfunc NewRateLimiter() {}\n",
+		"pkg/other.go":  "package pkg\n\n// the limiter is configured elsewhere\n",
+	})
+	search := func(ctx context.Context, q, prefix string) []symbols.SymbolCard {
+		return []symbols.SymbolCard{{SID: "1", Name: "NewRateLimiter", Kind: "func", File: "pkg/limits.go", Span: [4]int{5, 1, 5, 27}}}
+	}
+	res, err := locate(context.Background(), ws, LocateArgs{Query: "rate limiter"}, search)
+	if err != nil {
+		t.Fatalf("locate: %v", err)
+	}
+	top := res.Candidates[0]
+	if top.Path != "pkg/limits.go" || top.Symbol != "NewRateLimiter" || top.Line != 5 {
+		t.Fatalf("expected the symbol definition first, got %+v", res.Candidates)
+	}
+}
@@ -0,0 +1,641 @@
+package tool
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// QueryLogArgs selects lines of a log file and aggregates them.
+type QueryLogArgs struct {
+	Path string `json:"path"`
+	// Match and Exclude are regular expressions a line must and must not match
+	Match   string `json:"match,omitempty"`
+	Exclude string `json:"exclude,omitempty"`
+	// Level keeps lines at this level or above (debug, info, warn, error, fatal)
+	Level string `json:"level,omitempty"`
+	// Since and Until bound line timestamps (RFC 3339 or "2006-01-02 15:04:05")
+	Since string `json:"since,omitempty"`
+	Until string `json:"until,omitempty"`
+	// Op is count (default), group, histogram or lines
+	Op string `json:"op,omitempty"`
+	// GroupBy is level, message, a JSON field name, or a regular expression whose
+	// first capture group is the key
+	GroupBy string `json:"group_by,omitempty"`
+	// Bucket is the histogram bucket size, e.g. "1m", "15m", "1h"; chosen from the
+	// time range when empty
+	Bucket string `json:"bucket,omitempty"`
+	// Limit caps groups and lines (default 20, max 200)
+	Limit int `json:"limit,omitempty"`
+}
+
+// LogQueryResult is a compact aggregate of the matching lines.
+type LogQueryResult struct {
+	Path         string `json:"path"`
+	LinesScanned int    `json:"lines_scanned"`
+	Matched      int    `json:"matched"`
+	// First and Last are the timestamps of the first and last matching lines
+	First   string         `json:"first,omitempty"`
+	Last    string         `json:"last,omitempty"`
+	Levels  map[string]int `json:"levels,omitempty"`
+	Groups  []LogGroup     `json:"groups,omitempty"`
+	Buckets []LogBucket    `json:"buckets,omitempty"`
+	Lines   []LogLine      `json:"lines,omitempty"`
+	// OtherGroups counts matching lines in groups beyond the limit
+	OtherGroups int `json:"other_groups,omitempty"`
+	// Truncated is set when more groups or lines matched than were returned
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// LogGroup counts matching lines sharing a key.
+type LogGroup struct {
+	Key       string `json:"key"`
+	Count     int    `json:"count"`
+	FirstLine int    `json:"first_line"`
+	LastLine  int    `json:"last_line"`
+	First     string `json:"first,omitempty"`
+	Last      string `json:"last,omitempty"`
+	Example   string `json:"example"`
+}
+
+// LogBucket counts matching lines in a time interval.
+type LogBucket struct {
+	Start  string `json:"start"`
+	Count  int    `json:"count"`
+	Errors int    `json:"errors,omitempty"`
+}
+
+// LogLine is one matching line.
+type LogLine struct {
+	Line int    `json:"line"`
+	Text string `json:"text"`
+}
+
+const (
+	logQueryDefaultLimit = 20
+	logQueryMaxLimit     = 200
+	// logMaxLineBytes clips long lines before matching; the rest is ignored
+	logMaxLineBytes = 8 << 10
+	// logMaxGroups bounds distinct group keys kept in memory
+	logMaxGroups = 20000
+	// logMaxBuckets bounds histogram buckets in the result
+	logMaxBuckets = 120
+	// logMaxShownLine clips lines and examples in the result
+	logMaxShownLine = 500
+)
+
+// logLevels orders normalized levels by severity.
+var logLevels = map[string]int{"trace": 0, "debug": 1, "info": 2, "warn": 3, "error": 4, "fatal": 5}
+
+var logLevelAliases = map[string]string{
+	"trace": "trace", "debug": "debug", "dbg": "debug", "info": "info", "notice": "info",
+	"warn": "warn", "warning": "warn", "error": "error", "err": "error", "severe": "error",
+	"fatal": "fatal", "critical": "fatal", "crit": "fatal", "panic": "fatal", "emergency": "fatal",
+}
+
+var (
+	logLevelRe = regexp.MustCompile(`(?i)\b(trace|debug|dbg|info|notice|warn|warning|error|err|severe|fatal|critical|crit|panic|emergency)\b`)
+	// Timestamps: ISO 8601 / RFC 3339, and the common (Apache/nginx) log format
+	logISOTimeRe = regexp.MustCompile(`\d{4}-\d{2}-\d{2}[T ]\d{2}:\d{2}:\d{2}(?:[.,]\d+)?(?:Z|[+-]\d{2}:?\d{2})?`)
+	logCLFTimeRe = regexp.MustCompile(`\d{2}/[A-Z][a-z]{2}/\d{4}:\d{2}:\d{2}:\d{2} [+-]\d{4}`)
+	// Variable parts of messages, replaced when grouping by message
+	logUUIDRe   = regexp.MustCompile(`\b[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}\b`)
+	logHexRe    = regexp.MustCompile(`\b0x[0-9a-fA-F]+\b|\b[0-9a-fA-F]{16,}\b`)
+	logNumberRe = regexp.MustCompile(`\d+(?:\.\d+)?`)
+	logQuoteRe  = regexp.MustCompile(`"[^"]*"|'[^']*'`)
+	logSpaceRe  = regexp.MustCompile(`\s+`)
+)
+
+var logTimeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.999999999Z0700",
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999Z0700",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04:05.999999999",
+	"02/Jan/2006:15:04:05 -0700",
+	"2006-01-02",
+}
+
+// RegisterQueryLog registers query_log, which answers questions about large log
+// files by streaming them and returning aggregates instead of content.
+func RegisterQueryLog(registry *Registry, workspacePath string) error {
+	return registry.Register(Definition{
+		Name:        "query_log",
+		Description: "Filter, count, group and bucket the lines of a large log file (plain, JSON lines or .gz) without reading it into context. Returns compact aggregates: counts per level, top groups with examples, a time histogram, or a sample of matching lines.",
+		Safe:        true,
+		ReadOnly:    true,
+		JSONSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"path":     map[string]interface{}{"type": "string", "description": "Log file in the workspace, or an @attachments/ file"},
+				"match":    map[string]interface{}{"type": "string", "description": "Regular expression lines must match"},
+				"exclude":  map[string]interface{}{"type": "string", "description": "Regular expression lines must not match"},
+				"level":    map[string]interface{}{"type": "string", "description": "Minimum level: trace, debug, info, warn, error or fatal"},
+				"since":    map[string]interface{}{"type": "string", "description": "Only lines at or after this time (RFC 3339 or 2006-01-02 15:04:05)"},
+				"until":    map[string]interface{}{"type": "string", "description": "Only lines at or before this time"},
+				"op":       map[string]interface{}{"type": "string", "enum": []string{"count", "group", "histogram", "lines"}, "description": "count (default), group, histogram or lines"},
+				"group_by": map[string]interface{}{"type": "string", "description": "For op=group: level, message (numbers, ids and quoted values masked), a JSON field name, or a regex whose first capture group is the key"},
+				"bucket":   map[string]interface{}{"type": "string", "description": "For op=histogram: bucket size such as 1m, 15m or 1h (default: chosen from the time range)"},
+				"limit":    map[string]interface{}{"type": "integer", "description": "Maximum groups or lines returned (default 20, max 200)"},
+			},
+			"required": []string{"path"},
+		},
+		Usage: `The file is streamed once; only aggregates come back. Lines without a timestamp (stack trace continuations) take the timestamp and level of the line before them.
+JSON lines are parsed: level, time and message come from the usual fields (level/severity, time/ts/timestamp, msg/message), and group_by may name any top-level field.
+Start broad (op=count or op=group with group_by=message and level=error), then narrow with match and since/until, and use op=lines to see the actual lines.`,
+		Examples: []string{
+			`{"path":"logs/app.log","level":"error","op":"group","group_by":"message"}`,
+			`{"path":"logs/app.log","match":"timeout","op":"histogram","bucket":"5m"}`,
+			`{"path":"logs/access.log.gz","op":"group","group_by":"\" (\\d{3}) "}`,
+			`{"path":"logs/app.log","since":"2024-05-01T10:00:00Z","until":"2024-05-01T10:05:00Z","op":"lines"}`,
+		},
+		Handler: func(ctx context.Context, raw json.RawMessage) (interface{}, error) {
+			var args QueryLogArgs
+			if err := json.Unmarshal(raw, &args); err != nil {
+				return nil, fmt.Errorf("failed to parse arguments: %w", err)
+			}
+			return queryLog(ctx, workspacePath, args)
+		},
+	})
+}
+
+// logEntry is a parsed line.
+type logEntry struct {
+	text    string
+	level   string
+	at      time.Time
+	message string
+	fields  map[string]interface{}
+}
+
+type logGroupAcc struct {
+	LogGroup
+	firstAt, lastAt time.Time
+}
+
+func queryLog(ctx context.Context, workspacePath string, args QueryLogArgs) (*LogQueryResult, error) {
+	path, err := resolveLogPath(ctx, workspacePath, args.Path)
+	if err != nil {
+		return nil, err
+	}
+	op := strings.ToLower(strings.TrimSpace(args.Op))
+	if op == "" {
+		op = "count"
+	}
+	if op != "count" && op != "group" && op != "histogram" && op != "lines" {
+		return nil, fmt.Errorf("unknown op %q: use count, group, histogram or lines", args.Op)
+	}
+	limit := args.Limit
+	if limit <= 0 {
+		limit = logQueryDefaultLimit
+	}
+	if limit > logQueryMaxLimit {
+		limit = logQueryMaxLimit
+	}
+	var match, exclude, groupRe *regexp.Regexp
+	if args.Match != "" {
+		if match, err = regexp.Compile(args.Match); err != nil {
+			return nil, fmt.Errorf("invalid match: %w", err)
+		}
+	}
+	if args.Exclude != "" {
+		if exclude, err = regexp.Compile(args.Exclude); err != nil {
+			return nil, fmt.Errorf("invalid exclude: %w", err)
+		}
+	}
+	minLevel := -1
+	if args.Level != "" {
+		lvl, ok := logLevelAliases[strings.ToLower(args.Level)]
+		if !ok {
+			return nil, fmt.Errorf("unknown level %q", args.Level)
+		}
+		minLevel = logLevels[lvl]
+	}
+	var since, until time.Time
+	if args.Since != "" {
+		if since, err = parseLogTime(args.Since); err != nil {
+			return nil, fmt.Errorf("invalid since: %w", err)
+		}
+	}
+	if args.Until != "" {
+		if until, err = parseLogTime(args.Until); err != nil {
+			return nil, fmt.Errorf("invalid until: %w", err)
+		}
+	}
+	groupBy := strings.TrimSpace(args.GroupBy)
+	if op == "group" {
+		if groupBy == "" {
+			groupBy = "message"
+		}
+		if groupBy != "level" && groupBy != "message" && !isLogFieldName(groupBy) {
+			if groupRe, err = regexp.Compile(groupBy); err != nil {
+				return nil, fmt.Errorf("invalid group_by: %w", err)
+			}
+			if groupRe.NumSubexp() < 1 {
+				return nil, errors.New("group_by regex needs a capture group for the key")
+			}
+		}
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open log: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+	var r io.Reader = f
+	if strings.HasSuffix(strings.ToLower(path), ".gz") {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read gzip log: %w", err)
+		}
+		defer func() { _ = gz.Close() }()
+		r = gz
+	}
+
+	res := &LogQueryResult{Path: args.Path, Levels: map[string]int{}}
+	groups := map[string]*logGroupAcc{}
+	var points []logPoint
+	var firstAt, lastAt, prevAt time.Time
+	var prevLevel string
+
+	reader := bufio.NewReaderSize(r, 64<<10)
+	lineNo := 0
+	for {
+		line, readErr := readLogLine(reader)
+		if line == "" && readErr != nil {
+			if readErr != io.EOF {
+				return nil, readErr
+			}
+			break
+		}
+		lineNo++
+		if lineNo%10000 == 0 && ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		e := parseLogLine(line)
+		// Continuation lines (stack frames, wrapped messages) belong to the entry before them
+		if e.at.IsZero() && !prevAt.IsZero() {
+			e.at = prevAt
+			if e.level == "" {
+				e.level = prevLevel
+			}
+		} else {
+			prevAt, prevLevel = e.at, e.level
+		}
+
+		if match != nil && !match.MatchString(line) {
+			continue
+		}
+		if exclude != nil && exclude.MatchString(line) {
+			continue
+		}
+		if minLevel >= 0 && (e.level == "" || logLevels[e.level] < minLevel) {
+			continue
+		}
+		if !since.IsZero() && (e.at.IsZero() || e.at.Before(since)) {
+			continue
+		}
+		if !until.IsZero() && (e.at.IsZero() || e.at.After(until)) {
+			continue
+		}
+
+		res.Matched++
+		if e.level != "" {
+			res.Levels[e.level]++
+		}
+		if !e.at.IsZero() {
+			if firstAt.IsZero() {
+				firstAt = e.at
+			}
+			lastAt = e.at
+		}
+
+		switch op {
+		case "lines":
+			if len(res.Lines) < limit {
+				res.Lines = append(res.Lines, LogLine{Line: lineNo, Text: clipLogText(line)})
+			}
+		case "histogram":
+			if !e.at.IsZero() {
+				points = append(points, logPoint{at: e.at.UnixNano(), err: e.level != "" && logLevels[e.level] >= logLevels["error"]})
+			}
+		case "group":
+			key, ok := logGroupKey(e, groupBy, groupRe)
+			if !ok {
+				continue
+			}
+			g := groups[key]
+			if g == nil {
+				if len(groups) >= logMaxGroups {
+					res.OtherGroups++
+					continue
+				}
+				g = &logGroupAcc{LogGroup: LogGroup{Key: key, FirstLine: lineNo, Example: clipLogText(line)}, firstAt: e.at}
+				groups[key] = g
+			}
+			g.Count++
+			g.LastLine = lineNo
+			if !e.at.IsZero() {
+				if g.firstAt.IsZero() {
+					g.firstAt = e.at
+				}
+				g.lastAt = e.at
+			}
+		}
+	}
+	res.LinesScanned = lineNo
+	if !firstAt.IsZero() {
+		res.First, res.Last = formatLogTime(firstAt), formatLogTime(lastAt)
+	}
+	if len(res.Levels) == 0 {
+		res.Levels = nil
+	}
+
+	switch op {
+	case "lines":
+		res.Truncated = res.Matched > len(res.Lines)
+	case "group":
+		list := make([]LogGroup, 0, len(groups))
+		for _, g := range groups {
+			if !g.firstAt.IsZero() {
+				g.First, g.Last = formatLogTime(g.firstAt), formatLogTime(g.lastAt)
+			}
+			list = append(list, g.LogGroup)
+		}
+		sort.Slice(list, func(i, j int) bool {
+			if list[i].Count != list[j].Count {
+				return list[i].Count > list[j].Count
+			}
+			return list[i].FirstLine < list[j].FirstLine
+		})
+		if len(list) > limit {
+			for _, g := range list[limit:] {
+				res.OtherGroups += g.Count
+			}
+			list = list[:limit]
+			res.Truncated = true
+		}
+		res.Groups = list
+	case "histogram":
+		buckets, err := logHistogram(points, args.Bucket, firstAt, lastAt)
+		if err != nil {
+			return nil, err
+		}
+		res.Buckets = buckets
+	}
+	return res, nil
+}
+
+// resolveLogPath maps the path argument to a file in the workspace or the attachments.
+func resolveLogPath(ctx context.Context, workspacePath, p string) (string, error) {
+	if strings.TrimSpace(p) == "" {
+		return "", errors.New("path is required")
+	}
+	attachment, isAttachment, err := resolveAttachmentPath(ctx, p)
+	if err != nil {
+		return "", err
+	}
+	path := attachment
+	if !isAttachment {
+		if path, err = validatePath(expandWorkspacePath(workspacePath), p); err != nil {
+			return "", err
+		}
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", fmt.Errorf("file not found: %s", p)
+		}
+		return "", err
+	}
+	if info.IsDir() {
+		return "", fmt.Errorf("%s is a directory", p)
+	}
+	return path, nil
+}
+
+// readLogLine reads one line without its newline, clipped to logMaxLineBytes.
+func readLogLine(r *bufio.Reader) (string, error) {
+	var b []byte
+	for {
+		chunk, isPrefix, err := r.ReadLine()
+		if len(b) < logMaxLineBytes {
+			b = append(b, chunk...)
+		}
+		if err != nil {
+			return string(clipBytes(b)), err
+		}
+		if !isPrefix {
+			return string(clipBytes(b)), nil
+		}
+	}
+}
+
+func clipBytes(b []byte) []byte {
+	if len(b) > logMaxLineBytes {
+		return b[:logMaxLineBytes]
+	}
+	return b
+}
+
+// parseLogLine extracts the level, timestamp and message of a plain or JSON line.
+func parseLogLine(line string) logEntry {
+	e := logEntry{text: line, message: line}
+	trimmed := strings.TrimSpace(line)
+	if strings.HasPrefix(trimmed, "{") {
+		var fields map[string]interface{}
+		if json.Unmarshal([]byte(trimmed), &fields) == nil {
+			e.fields = fields
+			for _, k := range []string{"level", "severity", "lvl", "log.level"} {
+				if s, ok := fields[k].(string); ok {
+					e.level = logLevelAliases[strings.ToLower(s)]
+					break
+				}
+			}
+			for _, k := range []string{"time", "ts", "timestamp", "@timestamp", "t"} {
+				switch v := fields[k].(type) {
+				case string:
+					e.at, _ = parseLogTime(v)
+				case float64:
+					e.at = epochTime(v)
+				}
+				if !e.at.IsZero() {
+					break
+				}
+			}
+			for _, k := range []string{"msg", "message", "error", "err"} {
+				if s, ok := fields[k].(string); ok {
+					e.message = s
+					break
+				}
+			}
+			return e
+		}
+	}
+	if m := logISOTimeRe.FindString(line); m != "" {
+		e.at, _ = parseLogTime(m)
+		e.message = strings.Replace(e.message, m, "", 1)
+	} else if m := logCLFTimeRe.FindString(line); m != "" {
+		e.at, _ = time.Parse("02/Jan/2006:15:04:05 -0700", m)
+		e.message = strings.Replace(e.message, m, "", 1)
+	}
+	// The level is the first level word, which precedes the message in common layouts
+	if m := logLevelRe.FindStringSubmatchIndex(e.message); m != nil {
+		e.level = logLevelAliases[strings.ToLower(e.message[m[2]:m[3]])]
+		if m[0] < 40 {
+			e.message = e.message[m[1]:]
+		}
+	}
+	return e
+}
+
+// epochTime reads seconds or milliseconds since the epoch.
+func epochTime(v float64) time.Time {
+	if v > 1e12 {
+		return time.UnixMilli(int64(v)).UTC()
+	}
+	sec := int64(v)
+	return time.Unix(sec, int64((v-float64(sec))*1e9)).UTC()
+}
+
+func parseLogTime(s string) (time.Time, error) {
+	s = strings.Replace(strings.TrimSpace(s), ",", ".", 1)
+	for _, layout := range logTimeLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unrecognized time %q", s)
+}
+
+func formatLogTime(t time.Time) string {
+	return t.Format(time.RFC3339)
+}
+
+// isLogFieldName reports whether group_by looks like a JSON field name rather than a regex.
+func isLogFieldName(s string) bool {
+	for _, r := range s {
+		if !(r == '_' || r == '.' || r == '@' || r == '-' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9') {
+			return false
+		}
+	}
+	return s != ""
+}
+
+func logGroupKey(e logEntry, groupBy string, re *regexp.Regexp) (string, bool) {
+	switch {
+	case re != nil:
+		m := re.FindStringSubmatch(e.text)
+		if m == nil {
+			return "", false
+		}
+		return m[1], true
+	case groupBy == "level":
+		if e.level == "" {
+			return "(none)", true
+		}
+		return e.level, true
+	case groupBy == "message":
+		return logTemplate(e.message), true
+	default:
+		v, ok := e.fields[groupBy]
+		if !ok || v == nil {
+			return "(missing)", true
+		}
+		if s, ok := v.(string); ok {
+			return s, true
+		}
+		b, _ := json.Marshal(v)
+		return string(b), true
+	}
+}
+
+// logTemplate masks the variable parts of a message so repeats of one event group together.
+func logTemplate(msg string) string {
+	t := logUUIDRe.ReplaceAllString(msg, "<id>")
+	t = logHexRe.ReplaceAllString(t, "<hex>")
+	t = logQuoteRe.ReplaceAllString(t, `"…"`)
+	t = logNumberRe.ReplaceAllString(t, "<n>")
+	t = strings.TrimLeft(strings.TrimSpace(logSpaceRe.ReplaceAllString(t, " ")), ":]-| ")
+	return clipLogText(t)
+}
+
+func clipLogText(s string) string {
+	if len(s) <= logMaxShownLine {
+		return s
+	}
+	return s[:logMaxShownLine] + "…"
+}
+
+// logBucketSizes are the histogram bucket sizes chosen from when none is given.
+var logBucketSizes = []time.Duration{
+	time.Second, 10 * time.Second, time.Minute, 5 * time.Minute, 15 * time.Minute,
+	time.Hour, 6 * time.Hour, 24 * time.Hour, 7 * 24 * time.Hour,
+}
+
+// logPoint is a timestamped matching line kept for the histogram.
+type logPoint struct {
+	at  int64 // unix nanoseconds
+	err bool
+}
+
+func logHistogram(points []logPoint, bucket string, first, last time.Time) ([]LogBucket, error) {
+	if len(points) == 0 {
+		return nil, nil
+	}
+	var size time.Duration
+	if bucket != "" {
+		d, err := time.ParseDuration(bucket)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("invalid bucket %q: use a duration such as 1m or 1h", bucket)
+		}
+		size = d
+	} else {
+		span := last.Sub(first)
+		size = logBucketSizes[len(logBucketSizes)-1]
+		for _, s := range logBucketSizes {
+			if span/s < 60 {
+				size = s
+				break
+			}
+		}
+	}
+	if n := last.Sub(first)/size + 1; n > logMaxBuckets {
+		// Widen the buckets rather than returning hundreds of them
+		size = (last.Sub(first)/logMaxBuckets + time.Second).Round(time.Second)
+	}
+	start := first.Truncate(size)
+	counts := map[int64]*LogBucket{}
+	for _, p := range points {
+		idx := int64(time.Unix(0, p.at).Sub(start) / size)
+		b := counts[idx]
+		if b == nil {
+			b = &LogBucket{Start: formatLogTime(start.Add(time.Duration(idx) * size))}
+			counts[idx] = b
+		}
+		b.Count++
+		if p.err {
+			b.Errors++
+		}
+	}
+	keys := make([]int64, 0, len(counts))
+	for k := range counts {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+	out := make([]LogBucket, 0, len(keys))
+	for _, k := range keys {
+		out = append(out, *counts[k])
+	}
+	return out, nil
+}
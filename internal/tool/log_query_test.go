@@ -0,0 +1,114 @@
+package tool
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const sampleLog = `2024-05-01T10:00:01Z INFO request id=1 took 12ms
+2024-05-01T10:00:30Z ERROR db timeout after 5000ms (conn 7)
+    at db.Query (db.go:42)
+2024-05-01T10:01:10Z WARN slow request id=2 took 900ms
+2024-05-01T10:02:05Z ERROR db timeout after 3000ms (conn 9)
+2024-05-01T10:02:06Z ERROR user "bob" not found
+`
+
+func writeLog(t *testing.T, name, content string) string {
+	t.Helper()
+	ws := t.TempDir()
+	if err := os.WriteFile(filepath.Join(ws, name), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return ws
+}
+
+func TestQueryLog_CountsAndGroupsMessages(t *testing.T) {
+	ws := writeLog(t, "app.log", sampleLog)
+
+	count, err := queryLog(context.Background(), ws, QueryLogArgs{Path: "app.log"})
+	if err != nil {
+		t.Fatalf("count: %v", err)
+	}
+	if count.LinesScanned != 6 || count.Matched != 6 || count.Levels["error"] != 4 || count.Levels["warn"] != 1 {
+		t.Fatalf("unexpected count %+v", count)
+	}
+	if count.First != "2024-05-01T10:00:01Z" || count.Last != "2024-05-01T10:02:06Z" {
+		t.Fatalf("unexpected range %s – %s", count.First, count.Last)
+	}
+
+	grouped, err := queryLog(context.Background(), ws, QueryLogArgs{Path: "app.log", Level: "error", Op: "group", Match: "timeout|not found"})
+	if err != nil {
+		t.Fatalf("group: %v", err)
+	}
+	if len(grouped.Groups) != 2 {
+		t.Fatalf("expected two message groups, got %+v", grouped.Groups)
+	}
+	top := grouped.Groups[0]
+	if top.Key != "db timeout after <n>ms (conn <n>)" || top.Count != 2 || top.FirstLine != 2 || top.LastLine != 5 {
+		t.Fatalf("unexpected top group %+v", top)
+	}
+	if grouped.Groups[1].Key != `user "…" not found` {
+		t.Fatalf("expected quoted values to be masked, got %q", grouped.Groups[1].Key)
+	}
+}
+
+func TestQueryLog_TimeRangeAndContinuations(t *testing.T) {
+	ws := writeLog(t, "app.log", sampleLog)
+	res, err := queryLog(context.Background(), ws, QueryLogArgs{
+		Path: "app.log", Level: "error", Op: "lines",
+		Since: "2024-05-01T10:00:00Z", Until: "2024-05-01 10:01:00",
+	})
+	if err != nil {
+		t.Fatalf("lines: %v", err)
+	}
+	// The stack frame carries the timestamp and level of the error above it
+	if len(res.Lines) != 2 || res.Lines[0].Line != 2 || res.Lines[1].Line != 3 {
+		t.Fatalf("unexpected lines %+v", res.Lines)
+	}
+}
+
+func TestQueryLog_HistogramAndJSONFields(t *testing.T) {
+	jsonLog := `{"ts":"2024-05-01T10:00:10Z","level":"error","msg":"boom","service":"api"}
+{"ts":"2024-05-01T10:00:50Z","level":"info","msg":"ok","service":"api"}
+{"ts":"2024-05-01T10:03:20Z","level":"error","msg":"boom","service":"worker"}
+`
+	var gz bytes.Buffer
+	w := gzip.NewWriter(&gz)
+	_, _ = w.Write([]byte(jsonLog))
+	_ = w.Close()
+	ws := writeLog(t, "app.log.gz", gz.String())
+
+	hist, err := queryLog(context.Background(), ws, QueryLogArgs{Path: "app.log.gz", Op: "histogram", Bucket: "1m"})
+	if err != nil {
+		t.Fatalf("histogram: %v", err)
+	}
+	if len(hist.Buckets) != 2 || hist.Buckets[0].Count != 2 || hist.Buckets[0].Errors != 1 || hist.Buckets[1].Start != "2024-05-01T10:03:00Z" {
+		t.Fatalf("unexpected buckets %+v", hist.Buckets)
+	}
+
+	bySvc, err := queryLog(context.Background(), ws, QueryLogArgs{Path: "app.log.gz", Op: "group", GroupBy: "service", Level: "error"})
+	if err != nil {
+		t.Fatalf("group by field: %v", err)
+	}
+	if len(bySvc.Groups) != 2 || bySvc.Groups[0].Key != "api" || bySvc.Groups[1].Key != "worker" {
+		t.Fatalf("unexpected field groups %+v", bySvc.Groups)
+	}
+}
+
+func TestQueryLog_RejectsBadArguments(t *testing.T) {
+	ws := writeLog(t, "app.log", sampleLog)
+	for _, args := range []QueryLogArgs{
+		{Path: "../outside.log"},
+		{Path: "app.log", Op: "median"},
+		{Path: "app.log", Op: "group", GroupBy: `status \d+`},
+		{Path: "app.log", Level: "loud"},
+	} {
+		if _, err := queryLog(context.Background(), ws, args); err == nil {
+			t.Errorf("expected an error for %+v", args)
+		}
+	}
+}
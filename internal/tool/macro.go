@@ -0,0 +1,316 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/loom/loom/internal/config"
+	"github.com/loom/loom/internal/editor"
+	"github.com/loom/loom/internal/policy"
+	"github.com/loom/loom/internal/validation"
+)
+
+const (
+	// maxMacroFiles bounds the files one run_macro call may change.
+	maxMacroFiles = 200
+	// maxMacroScanned bounds the files read while looking for matches.
+	maxMacroScanned = 5000
+	// maxMacroFileSize skips generated and vendored blobs.
+	maxMacroFileSize = 1 << 20
+)
+
+// RunMacroArgs are the arguments of run_macro.
+type RunMacroArgs struct {
+	Name   string            `json:"name"`
+	Params map[string]string `json:"params,omitempty"`
+	// Files overrides the macro's glob, e.g. to limit a run to one directory
+	Files string `json:"files,omitempty"`
+}
+
+// MacroFileEdit is the change a macro makes to one file.
+type MacroFileEdit struct {
+	Path string `json:"path"`
+	Old  string `json:"-"`
+	New  string `json:"-"`
+	// Matches counts the lines the macro's steps matched
+	Matches int `json:"matches"`
+}
+
+// MacroRun is a macro expanded over the workspace by run_macro and applied once approved.
+type MacroRun struct {
+	Macro string          `json:"macro"`
+	Files []MacroFileEdit `json:"files"`
+	// Skipped lists files left alone because the result would not parse
+	Skipped []string `json:"skipped,omitempty"`
+}
+
+var (
+	macroRunsMu sync.Mutex
+	// pendingMacroRuns holds proposed macro runs by the raw arguments of their call
+	pendingMacroRuns = map[string]*MacroRun{}
+)
+
+// RegisterRunMacro registers the run_macro tool, which replays the edit macros defined
+// in <workspace>/.loom/macros.json.
+func RegisterRunMacro(registry *Registry, workspacePath string) error {
+	return registry.Register(Definition{
+		Name:        "run_macro",
+		Description: "Replay a saved edit macro (a named sequence of pattern-based line edits from .loom/macros.json) over every matching file, proposed as one diff for approval. Cheaper and more reliable than editing many files one by one.",
+		Safe:        false,
+		AlwaysAsk:   true,
+		JSONSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"name": map[string]interface{}{
+					"type":        "string",
+					"description": "Macro name; an unknown name returns the list of macros",
+				},
+				"params": map[string]interface{}{
+					"type":                 "object",
+					"description":          "Values for the macro's {{parameters}}",
+					"additionalProperties": map[string]interface{}{"type": "string"},
+				},
+				"files": map[string]interface{}{
+					"type":        "string",
+					"description": "Glob overriding the macro's files, e.g. internal/api/**/*.go",
+				},
+			},
+			"required": []string{"name"},
+		},
+		Usage: `Macros are defined by the user in .loom/macros.json. Each step matches a regular expression against every line:
+- insert_after / insert_before add content next to each matching line, unless that exact text is already there, so replays are idempotent.
+- replace substitutes the matched text; delete removes the line.
+- $1 or ${name} in content refer to the pattern's groups; capture leading whitespace to keep indentation.
+Files whose result would no longer parse are skipped and listed. Once approved, every file is edited without further model calls.`,
+		Examples: []string{
+			`{"name":"trace-handlers","params":{"logger":"slog"}}`,
+			`{"name":"rename-import","params":{"from":"old/pkg","to":"new/pkg"},"files":"cmd/**/*.go"}`,
+		},
+		Handler: func(ctx context.Context, raw json.RawMessage) (interface{}, error) {
+			var args RunMacroArgs
+			if err := json.Unmarshal(raw, &args); err != nil {
+				return nil, fmt.Errorf("failed to parse arguments: %w", err)
+			}
+			macros, err := config.LoadMacros(workspacePath)
+			if err != nil {
+				return nil, err
+			}
+			m, ok := config.MacroNamed(macros, args.Name)
+			if !ok {
+				return &ExecutionResult{Content: listMacros(args.Name, macros), Safe: true}, nil
+			}
+			run, err := expandMacro(ctx, workspacePath, m, args)
+			if err != nil {
+				return nil, err
+			}
+			if len(run.Files) == 0 {
+				msg := fmt.Sprintf("Macro %s changes no files", m.Name)
+				if len(run.Skipped) > 0 {
+					msg += fmt.Sprintf("; skipped because the result would not parse: %s", strings.Join(run.Skipped, ", "))
+				}
+				return &ExecutionResult{Content: msg, Safe: true}, nil
+			}
+			macroRunsMu.Lock()
+			pendingMacroRuns[string(raw)] = run
+			macroRunsMu.Unlock()
+
+			var diff strings.Builder
+			matches := 0
+			for _, f := range run.Files {
+				diff.WriteString(editor.UnifiedDiff(f.Path, f.Old, f.New, true, true, 3))
+				matches += f.Matches
+			}
+			msg := fmt.Sprintf("Propose macro %s: %d edits in %d files", m.Name, matches, len(run.Files))
+			if len(run.Skipped) > 0 {
+				msg += fmt.Sprintf("\nSkipped because the result would not parse: %s", strings.Join(run.Skipped, ", "))
+			}
+			return &ExecutionResult{Content: msg, Diff: diff.String(), Safe: false}, nil
+		},
+	})
+}
+
+// TakeMacroRun returns the macro run proposed by the run_macro call with these raw
+// arguments and forgets it. The engine calls it once the user approved the proposal.
+func TakeMacroRun(raw json.RawMessage) (*MacroRun, bool) {
+	macroRunsMu.Lock()
+	defer macroRunsMu.Unlock()
+	run, ok := pendingMacroRuns[string(raw)]
+	delete(pendingMacroRuns, string(raw))
+	return run, ok
+}
+
+// listMacros describes the available macros after an unknown name.
+func listMacros(name string, macros []config.EditMacro) string {
+	if len(macros) == 0 {
+		return fmt.Sprintf("No macros are defined; the user can add them to %s", filepath.ToSlash(filepath.Join(".loom", "macros.json")))
+	}
+	var b strings.Builder
+	if strings.TrimSpace(name) != "" {
+		fmt.Fprintf(&b, "No macro named %q. ", name)
+	}
+	b.WriteString("Available macros:\n")
+	for _, m := range macros {
+		fmt.Fprintf(&b, "- %s (%s)", m.Name, m.Files)
+		if m.Description != "" {
+			b.WriteString(": " + m.Description)
+		}
+		for _, p := range m.Params {
+			fmt.Fprintf(&b, "\n  param %s", p.Name)
+			if p.Default != "" {
+				fmt.Fprintf(&b, " (default %q)", p.Default)
+			}
+			if p.Description != "" {
+				b.WriteString(": " + p.Description)
+			}
+		}
+		b.WriteString("\n")
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// compiledStep is a macro step with its pattern compiled.
+type compiledStep struct {
+	config.MacroStep
+	re *regexp.Regexp
+}
+
+func compileMacroStep(st config.MacroStep) (compiledStep, error) {
+	re, err := regexp.Compile(st.Pattern)
+	return compiledStep{MacroStep: st, re: re}, err
+}
+
+// expandMacro applies the macro to every matching file in memory.
+func expandMacro(ctx context.Context, workspace string, m config.EditMacro, args RunMacroArgs) (*MacroRun, error) {
+	bound, err := m.Bind(args.Params)
+	if err != nil {
+		return nil, err
+	}
+	steps := make([]compiledStep, len(bound.Steps))
+	for i, st := range bound.Steps {
+		if steps[i], err = compileMacroStep(st); err != nil {
+			return nil, fmt.Errorf("macro %s step %d: %w", m.Name, i+1, err)
+		}
+	}
+	glob := m.Files
+	if strings.TrimSpace(args.Files) != "" {
+		glob = strings.TrimSpace(args.Files)
+	}
+
+	var paths []string
+	scanned := 0
+	err = filepath.WalkDir(workspace, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if p != workspace && (strings.HasPrefix(d.Name(), ".") || skippedTreeDirs[d.Name()]) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		rel, err := filepath.Rel(workspace, p)
+		if err != nil || !d.Type().IsRegular() || !policy.GlobMatch(glob, filepath.ToSlash(rel)) {
+			return nil
+		}
+		if scanned++; scanned > maxMacroScanned {
+			return filepath.SkipAll
+		}
+		if info, err := d.Info(); err != nil || info.Size() > maxMacroFileSize {
+			return nil
+		}
+		paths = append(paths, filepath.ToSlash(rel))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+
+	run := &MacroRun{Macro: m.Name}
+	for _, rel := range paths {
+		old, _, err := editor.ReadText(filepath.Join(workspace, filepath.FromSlash(rel)))
+		if err != nil || strings.ContainsRune(old, 0) {
+			continue
+		}
+		updated, matches := applyMacroSteps(old, steps)
+		if updated == old {
+			continue
+		}
+		if validation.Check(ctx, workspace, rel, []byte(updated)) != nil && validation.Check(ctx, workspace, rel, []byte(old)) == nil {
+			run.Skipped = append(run.Skipped, rel)
+			continue
+		}
+		if len(run.Files) == maxMacroFiles {
+			return nil, fmt.Errorf("macro %s would change more than %d files; narrow it with files", m.Name, maxMacroFiles)
+		}
+		run.Files = append(run.Files, MacroFileEdit{Path: rel, Old: old, New: updated, Matches: matches})
+	}
+	return run, nil
+}
+
+// applyMacroSteps runs the steps over content in order and counts the matched lines.
+func applyMacroSteps(content string, steps []compiledStep) (string, int) {
+	lines := strings.Split(content, "\n")
+	matches := 0
+	for _, st := range steps {
+		out := make([]string, 0, len(lines))
+		for i := 0; i < len(lines); i++ {
+			line := lines[i]
+			idx := st.re.FindStringSubmatchIndex(line)
+			if idx == nil {
+				out = append(out, line)
+				continue
+			}
+			switch st.Action {
+			case config.MacroReplace:
+				replaced := st.re.ReplaceAllString(line, st.Content)
+				if replaced != line {
+					matches++
+				}
+				out = append(out, replaced)
+			case config.MacroDelete:
+				matches++
+			case config.MacroInsertBefore:
+				insert := strings.Split(string(st.re.ExpandString(nil, st.Content, line, idx)), "\n")
+				if !hasLines(out, len(out)-len(insert), insert) {
+					out = append(out, insert...)
+					matches++
+				}
+				out = append(out, line)
+			case config.MacroInsertAfter:
+				insert := strings.Split(string(st.re.ExpandString(nil, st.Content, line, idx)), "\n")
+				out = append(out, line)
+				if hasLines(lines, i+1, insert) {
+					continue
+				}
+				out = append(out, insert...)
+				matches++
+			}
+		}
+		lines = out
+	}
+	return strings.Join(lines, "\n"), matches
+}
+
+// hasLines reports whether lines[at:] starts with want.
+func hasLines(lines []string, at int, want []string) bool {
+	if at < 0 || at+len(want) > len(lines) {
+		return false
+	}
+	for i, w := range want {
+		if lines[at+i] != w {
+			return false
+		}
+	}
+	return true
+}
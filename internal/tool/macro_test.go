@@ -0,0 +1,78 @@
+package tool
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/loom/loom/internal/config"
+)
+
+func TestRunMacro_ProposesEditsAcrossFiles(t *testing.T) {
+	dir := t.TempDir()
+	mustWriteFile(t, dir, "api/users.go", "package api\n\nfunc HandleUsers() {\n\tlist()\n}\n\nfunc helper() {\n}\n")
+	mustWriteFile(t, dir, "api/orders.go", "package api\n\nfunc HandleOrders() {\n\tlog.Print(\"enter HandleOrders\")\n}\n")
+	mustWriteFile(t, dir, "web/app.js", "function HandleX() {}\n")
+	err := config.SaveMacros(dir, []config.EditMacro{{
+		Name:   "trace",
+		Files:  "**/*.go",
+		Params: []config.MacroParam{{Name: "prefix", Default: "Handle"}},
+		Steps: []config.MacroStep{
+			{Action: config.MacroInsertAfter, Pattern: `^func ({{prefix}}\w*)\(\) \{$`, Content: "\tlog.Print(\"enter $1\")"},
+		},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	reg := NewRegistry()
+	if err := RegisterRunMacro(reg, dir); err != nil {
+		t.Fatal(err)
+	}
+
+	args := map[string]any{"name": "trace"}
+	res := invokeTool(t, reg, "run_macro", args)
+	if res.Safe || !strings.Contains(res.Content, "1 edits in 1 files") {
+		t.Fatalf("unexpected proposal: %q", res.Content)
+	}
+	if !strings.Contains(res.Diff, `+	log.Print("enter HandleUsers")`) || strings.Contains(res.Diff, "orders.go") {
+		t.Fatalf("unexpected diff:\n%s", res.Diff)
+	}
+	// orders.go already logs on entry, so replaying the insert leaves it alone
+	raw, _ := json.Marshal(args)
+	run, ok := TakeMacroRun(raw)
+	if !ok || len(run.Files) != 1 || run.Files[0].Path != "api/users.go" {
+		t.Fatalf("unexpected pending run: %+v", run)
+	}
+	if _, ok := TakeMacroRun(raw); ok {
+		t.Fatal("expected the run to be forgotten once taken")
+	}
+
+	res = invokeTool(t, reg, "run_macro", map[string]any{"name": "nope"})
+	if !res.Safe || !strings.Contains(res.Content, "- trace (**/*.go)") {
+		t.Fatalf("expected the macro list, got %q", res.Content)
+	}
+}
+
+func TestApplyMacroSteps(t *testing.T) {
+	steps := []compiledStep{}
+	for _, st := range []config.MacroStep{
+		{Action: config.MacroReplace, Pattern: `oldpkg`, Content: "newpkg"},
+		{Action: config.MacroInsertBefore, Pattern: `^(\s*)return`, Content: "${1}// done"},
+		{Action: config.MacroDelete, Pattern: `^\s*// TODO`},
+	} {
+		compiled, err := compileMacroStep(st)
+		if err != nil {
+			t.Fatal(err)
+		}
+		steps = append(steps, compiled)
+	}
+	in := "import \"oldpkg\"\n\tx()\n\t// TODO remove\n\treturn x\n"
+	got, matches := applyMacroSteps(in, steps)
+	want := "import \"newpkg\"\n\tx()\n\t// done\n\treturn x\n"
+	if got != want || matches != 3 {
+		t.Fatalf("got %q (%d matches), want %q", got, matches, want)
+	}
+	if again, n := applyMacroSteps(got, steps); again != got || n != 0 {
+		t.Fatalf("replay was not idempotent: %q (%d matches)", again, n)
+	}
+}
@@ -0,0 +1,261 @@
+package tool
+
+import (
+	"bufio"
+	"encoding/json"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ManifestSummary is the distilled view of a well-known project file returned by
+// read_file instead of its raw text unless the caller asks for full content.
+type ManifestSummary struct {
+	Format          string            `json:"format"`
+	Name            string            `json:"name,omitempty"`
+	Version         string            `json:"version,omitempty"`
+	Toolchain       string            `json:"toolchain,omitempty"`
+	Dependencies    []string          `json:"dependencies,omitempty"`
+	DevDependencies []string          `json:"dev_dependencies,omitempty"`
+	Scripts         map[string]string `json:"scripts,omitempty"`
+	Services        []ComposeService  `json:"services,omitempty"`
+}
+
+// ComposeService is one service from a docker-compose file.
+type ComposeService struct {
+	Name      string   `json:"name"`
+	Image     string   `json:"image,omitempty"`
+	Build     string   `json:"build,omitempty"`
+	Ports     []string `json:"ports,omitempty"`
+	DependsOn []string `json:"depends_on,omitempty"`
+}
+
+// summarizeManifest returns a summary for known manifest formats, or nil when the file
+// is not one of them or cannot be parsed (callers then fall back to raw content).
+func summarizeManifest(path, content string) *ManifestSummary {
+	switch base := strings.ToLower(filepath.Base(path)); {
+	case base == "package.json":
+		return summarizePackageJSON(content)
+	case base == "go.mod":
+		return summarizeGoMod(content)
+	case base == "cargo.toml":
+		return summarizeCargoToml(content)
+	case base == "docker-compose.yml" || base == "docker-compose.yaml" || base == "compose.yml" || base == "compose.yaml":
+		return summarizeCompose(content)
+	}
+	return nil
+}
+
+func summarizePackageJSON(content string) *ManifestSummary {
+	var pkg struct {
+		Name            string            `json:"name"`
+		Version         string            `json:"version"`
+		Scripts         map[string]string `json:"scripts"`
+		Dependencies    map[string]string `json:"dependencies"`
+		DevDependencies map[string]string `json:"devDependencies"`
+		Engines         map[string]string `json:"engines"`
+	}
+	if err := json.Unmarshal([]byte(content), &pkg); err != nil {
+		return nil
+	}
+	s := &ManifestSummary{
+		Format:          "package.json",
+		Name:            pkg.Name,
+		Version:         pkg.Version,
+		Scripts:         pkg.Scripts,
+		Dependencies:    versionedList(pkg.Dependencies),
+		DevDependencies: versionedList(pkg.DevDependencies),
+	}
+	if node, ok := pkg.Engines["node"]; ok {
+		s.Toolchain = "node " + node
+	}
+	return s
+}
+
+func summarizeGoMod(content string) *ManifestSummary {
+	s := &ManifestSummary{Format: "go.mod"}
+	inRequire := false
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if i := strings.Index(line, "//"); i >= 0 {
+			// Keep "// indirect" markers out of the summary; indirect deps are noise here
+			if strings.Contains(line[i:], "indirect") {
+				continue
+			}
+			line = strings.TrimSpace(line[:i])
+		}
+		switch {
+		case line == "":
+		case inRequire && line == ")":
+			inRequire = false
+		case inRequire:
+			s.Dependencies = append(s.Dependencies, strings.Join(strings.Fields(line), "@"))
+		case strings.HasPrefix(line, "module "):
+			s.Name = strings.TrimSpace(strings.TrimPrefix(line, "module "))
+		case strings.HasPrefix(line, "go "):
+			s.Toolchain = line
+		case line == "require (":
+			inRequire = true
+		case strings.HasPrefix(line, "require "):
+			s.Dependencies = append(s.Dependencies, strings.Join(strings.Fields(strings.TrimPrefix(line, "require ")), "@"))
+		}
+	}
+	if s.Name == "" {
+		return nil
+	}
+	return s
+}
+
+func summarizeCargoToml(content string) *ManifestSummary {
+	s := &ManifestSummary{Format: "Cargo.toml"}
+	section := ""
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			section = strings.Trim(line, "[] ")
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		key = strings.TrimSpace(key)
+		value = strings.Trim(strings.TrimSpace(value), `"`)
+		switch section {
+		case "package":
+			switch key {
+			case "name":
+				s.Name = value
+			case "version":
+				s.Version = value
+			case "rust-version":
+				s.Toolchain = "rust " + value
+			}
+		case "dependencies":
+			s.Dependencies = append(s.Dependencies, key+"@"+cargoVersion(value))
+		case "dev-dependencies":
+			s.DevDependencies = append(s.DevDependencies, key+"@"+cargoVersion(value))
+		}
+	}
+	if s.Name == "" && len(s.Dependencies) == 0 {
+		return nil
+	}
+	return s
+}
+
+// cargoVersion extracts the version from either `"1.0"` or `{ version = "1.0", ... }`.
+func cargoVersion(value string) string {
+	if !strings.HasPrefix(value, "{") {
+		return value
+	}
+	for _, part := range strings.Split(strings.Trim(value, "{} "), ",") {
+		if k, v, ok := strings.Cut(part, "="); ok && strings.TrimSpace(k) == "version" {
+			return strings.Trim(strings.TrimSpace(v), `"`)
+		}
+	}
+	return "*"
+}
+
+// summarizeCompose reads the services block of a docker-compose file using indentation,
+// which covers the conventional layout without a full YAML parser.
+func summarizeCompose(content string) *ManifestSummary {
+	s := &ManifestSummary{Format: "docker-compose"}
+	var cur *ComposeService
+	inServices := false
+	serviceIndent, list := -1, ""
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for scanner.Scan() {
+		raw := scanner.Text()
+		trimmed := strings.TrimSpace(raw)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		indent := len(raw) - len(strings.TrimLeft(raw, " "))
+		if indent == 0 {
+			inServices = trimmed == "services:"
+			cur = nil
+			continue
+		}
+		if !inServices {
+			continue
+		}
+		if serviceIndent < 0 {
+			serviceIndent = indent
+		}
+		if indent == serviceIndent && strings.HasSuffix(trimmed, ":") {
+			s.Services = append(s.Services, ComposeService{Name: strings.TrimSuffix(trimmed, ":")})
+			cur = &s.Services[len(s.Services)-1]
+			list = ""
+			continue
+		}
+		if cur == nil {
+			continue
+		}
+		if strings.HasPrefix(trimmed, "- ") {
+			item := strings.Trim(strings.TrimSpace(strings.TrimPrefix(trimmed, "- ")), `"'`)
+			switch list {
+			case "ports":
+				cur.Ports = append(cur.Ports, item)
+			case "depends_on":
+				cur.DependsOn = append(cur.DependsOn, item)
+			}
+			continue
+		}
+		key, value, _ := strings.Cut(trimmed, ":")
+		value = strings.Trim(strings.TrimSpace(value), `"'`)
+		list = key
+		switch key {
+		case "image":
+			cur.Image = value
+		case "build":
+			cur.Build = value
+		}
+	}
+	if len(s.Services) == 0 {
+		return nil
+	}
+	return s
+}
+
+// versionedList renders a name→version map as sorted "name@version" entries.
+func versionedList(deps map[string]string) []string {
+	if len(deps) == 0 {
+		return nil
+	}
+	out := make([]string, 0, len(deps))
+	for name, v := range deps {
+		out = append(out, name+"@"+v)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// parseFrontmatter returns the key/value pairs of a leading YAML frontmatter block
+// (between "---" lines) in markdown files. Nested values are kept as raw text.
+func parseFrontmatter(content string) map[string]string {
+	if !strings.HasPrefix(content, "---\n") && !strings.HasPrefix(content, "---\r\n") {
+		return nil
+	}
+	lines := strings.Split(content, "\n")
+	fm := map[string]string{}
+	for _, line := range lines[1:] {
+		line = strings.TrimRight(line, "\r")
+		if line == "---" {
+			if len(fm) == 0 {
+				return nil
+			}
+			return fm
+		}
+		if strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t") {
+			continue
+		}
+		if k, v, ok := strings.Cut(line, ":"); ok {
+			fm[strings.TrimSpace(k)] = strings.Trim(strings.TrimSpace(v), `"'`)
+		}
+	}
+	// No closing delimiter: not frontmatter
+	return nil
+}
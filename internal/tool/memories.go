@@ -5,17 +5,34 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"math"
 	"os"
 	"path/filepath"
 	"strings"
 	"sync"
 	"time"
+
+	"github.com/loom/loom/internal/memory"
 )
 
 // MemoryItem represents a single user memory item.
 type MemoryItem struct {
-	ID   string `json:"id"`
-	Text string `json:"text"`
+	ID   string   `json:"id"`
+	Text string   `json:"text"`
+	Tags []string `json:"tags,omitempty"`
+}
+
+// memorySearchLimit is how many matches a search returns by default.
+const memorySearchLimit = 10
+
+// MemoryHit is a memory found by a search, with its relevance score.
+type MemoryHit struct {
+	ID    string   `json:"id"`
+	Text  string   `json:"text"`
+	Tags  []string `json:"tags,omitempty"`
+	Scope string   `json:"scope"`
+	Dir   string   `json:"dir,omitempty"`
+	Score float64  `json:"score"`
 }
 
 // memoriesFilePath returns the absolute path to the user's memories JSON file: ~/.loom/memories.json
@@ -75,11 +92,24 @@ func saveMemories(items []MemoryItem) error {
 	return nil
 }
 
-// RegisterMemories registers the `memories` tool providing add/list/update/delete actions.
-func RegisterMemories(registry *Registry) error {
+// openProjectMemory opens the per-workspace memory store used for project and directory scoped memories.
+func openProjectMemory(workspacePath string) (*memory.Project, error) {
+	if strings.TrimSpace(workspacePath) == "" {
+		return nil, errors.New("no workspace is open")
+	}
+	store, err := memory.NewStore("")
+	if err != nil {
+		return nil, err
+	}
+	return memory.NewProject(store, workspacePath)
+}
+
+// RegisterMemories registers the `memories` tool providing add/list/search/update/delete
+// actions. Memories are global by default; project and directory scopes are stored per workspace.
+func RegisterMemories(registry *Registry, workspacePath string) error {
 	return registry.Register(Definition{
 		Name:        "memories",
-		Description: "Manage user memories (add, list, update, delete). User-specific and persistent across projects.",
+		Description: "Manage memories (add, list, search, update, delete). Only the memories most relevant to a request are shown in the prompt; use action=search with a query and/or tags to find others.",
 		Safe:        true,
 		JSONSchema: map[string]interface{}{
 			"type": "object",
@@ -87,7 +117,7 @@ func RegisterMemories(registry *Registry) error {
 				"action": map[string]interface{}{
 					"type":        "string",
 					"description": "Action to perform",
-					"enum":        []string{"add", "list", "update", "delete"},
+					"enum":        []string{"add", "list", "search", "update", "delete"},
 				},
 				"id": map[string]interface{}{
 					"type":        "string",
@@ -97,19 +127,65 @@ func RegisterMemories(registry *Registry) error {
 					"type":        "string",
 					"description": "Memory text (required for add/update)",
 				},
+				"scope": map[string]interface{}{
+					"type":        "string",
+					"description": "Where the memory applies (default global)",
+					"enum":        []string{memory.ScopeGlobal, memory.ScopeProject, memory.ScopeDirectory},
+				},
+				"path": map[string]interface{}{
+					"type":        "string",
+					"description": "Workspace-relative directory for scope=directory",
+				},
+				"tags": map[string]interface{}{
+					"type":        "array",
+					"items":       map[string]interface{}{"type": "string"},
+					"description": "Topic tags (add/update); for search, memories must carry all of them",
+				},
+				"query": map[string]interface{}{
+					"type":        "string",
+					"description": "Search text; matches are ranked by relevance (search)",
+				},
+				"limit": map[string]interface{}{
+					"type":        "integer",
+					"description": "Maximum number of search results (default 10)",
+				},
 			},
 			"required": []string{"action"},
 		},
+		Usage: `Scope 'global' (default) applies across projects, 'project' to this workspace, 'directory' only when working on files under 'path' (e.g. conventions for services/payments/). Pick the narrowest scope that fits.
+Tag memories with short topics (e.g. 'testing', 'deploy'). search ranks by query relevance; every given tag must match. update keeps the tags unless new ones are given.`,
+		Examples: []string{
+			`{"action":"add","text":"Run integration tests with make itest","scope":"project","tags":["testing"]}`,
+			`{"action":"search","query":"deploy staging","tags":["deploy"]}`,
+		},
 		Handler: func(ctx context.Context, raw json.RawMessage) (interface{}, error) {
 			var args struct {
-				Action string `json:"action"`
-				ID     string `json:"id"`
-				Text   string `json:"text"`
+				Action string   `json:"action"`
+				ID     string   `json:"id"`
+				Text   string   `json:"text"`
+				Scope  string   `json:"scope"`
+				Path   string   `json:"path"`
+				Tags   []string `json:"tags"`
+				Query  string   `json:"query"`
+				Limit  int      `json:"limit"`
 			}
 			if err := json.Unmarshal(raw, &args); err != nil {
 				return nil, fmt.Errorf("failed to parse arguments: %w", err)
 			}
 			action := strings.ToLower(strings.TrimSpace(args.Action))
+			scope := strings.ToLower(strings.TrimSpace(args.Scope))
+			if action == "search" {
+				return searchMemories(workspacePath, scope, args.Query, args.Tags, args.Limit)
+			}
+			if scope == "" && strings.TrimSpace(args.Path) != "" {
+				scope = memory.ScopeDirectory
+			}
+			if scope == memory.ScopeProject || scope == memory.ScopeDirectory {
+				return handleScopedMemory(workspacePath, action, scope, args.ID, args.Text, args.Path, args.Tags)
+			}
+			if scope != "" && scope != memory.ScopeGlobal {
+				return nil, fmt.Errorf("unsupported scope: %s", args.Scope)
+			}
 			switch action {
 			case "list":
 				memoriesMu.Lock()
@@ -118,7 +194,14 @@ func RegisterMemories(registry *Registry) error {
 				if err != nil {
 					return nil, err
 				}
-				return map[string]interface{}{"memories": items, "count": len(items)}, nil
+				result := map[string]interface{}{"memories": items, "count": len(items)}
+				// Listing without a scope also shows what is stored for this workspace
+				if scope == "" {
+					if proj, perr := openProjectMemory(workspacePath); perr == nil {
+						result["scoped_memories"] = proj.ListScopedMemories()
+					}
+				}
+				return result, nil
 			case "add":
 				if strings.TrimSpace(args.Text) == "" {
 					return nil, errors.New("text is required for add")
@@ -127,7 +210,7 @@ func RegisterMemories(registry *Registry) error {
 				if id == "" {
 					id = time.Now().Format("20060102-150405")
 				}
-				newItem := MemoryItem{ID: id, Text: args.Text}
+				newItem := MemoryItem{ID: id, Text: args.Text, Tags: memory.NormalizeTags(args.Tags)}
 				memoriesMu.Lock()
 				items, err := loadMemories()
 				if err != nil {
@@ -169,6 +252,10 @@ func RegisterMemories(registry *Registry) error {
 				for i := range items {
 					if items[i].ID == args.ID {
 						items[i].Text = args.Text
+						// Tags are kept unless new ones are given
+						if args.Tags != nil {
+							items[i].Tags = memory.NormalizeTags(args.Tags)
+						}
 						found = true
 						break
 					}
@@ -218,3 +305,115 @@ func RegisterMemories(registry *Registry) error {
 		},
 	})
 }
+
+// handleScopedMemory implements the memories actions for project and directory scopes.
+func handleScopedMemory(workspacePath, action, scope, id, text, dir string, tags []string) (interface{}, error) {
+	proj, err := openProjectMemory(workspacePath)
+	if err != nil {
+		return nil, err
+	}
+	switch action {
+	case "list":
+		items := make([]memory.ScopedMemory, 0)
+		want := memory.NormalizeMemoryDir(dir)
+		for _, m := range proj.ListScopedMemories() {
+			if m.Scope != scope {
+				continue
+			}
+			if want != "" && m.Dir != want {
+				continue
+			}
+			items = append(items, m)
+		}
+		return map[string]interface{}{"memories": items, "count": len(items), "scope": scope}, nil
+	case "add", "update":
+		if strings.TrimSpace(text) == "" {
+			return nil, fmt.Errorf("text is required for %s", action)
+		}
+		if action == "update" {
+			if strings.TrimSpace(id) == "" {
+				return nil, errors.New("id is required for update")
+			}
+			found := false
+			for _, m := range proj.ListScopedMemories() {
+				if m.ID == id {
+					found = true
+					if dir == "" {
+						dir = m.Dir
+					}
+					if tags == nil {
+						tags = m.Tags
+					}
+					break
+				}
+			}
+			if !found {
+				return nil, fmt.Errorf("memory with id %q not found", id)
+			}
+		}
+		saved, err := proj.SaveScopedMemory(memory.ScopedMemory{ID: strings.TrimSpace(id), Text: text, Scope: scope, Dir: dir, Tags: tags})
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"status": "ok", "memory": saved}, nil
+	case "delete":
+		if strings.TrimSpace(id) == "" {
+			return nil, errors.New("id is required for delete")
+		}
+		if err := proj.DeleteScopedMemory(id); err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"status": "ok", "id": id}, nil
+	default:
+		return nil, fmt.Errorf("unsupported action: %s", action)
+	}
+}
+
+// searchMemories ranks global and workspace memories against a query and tags. An
+// empty scope searches all of them.
+func searchMemories(workspacePath, scope, query string, tags []string, limit int) (interface{}, error) {
+	if strings.TrimSpace(query) == "" && len(memory.NormalizeTags(tags)) == 0 {
+		return nil, errors.New("query or tags are required for search")
+	}
+	if limit <= 0 {
+		limit = memorySearchLimit
+	}
+	var hits []MemoryHit
+	if scope == "" || scope == memory.ScopeGlobal {
+		memoriesMu.Lock()
+		items, err := loadMemories()
+		memoriesMu.Unlock()
+		if err != nil {
+			return nil, err
+		}
+		for _, it := range items {
+			hits = append(hits, MemoryHit{ID: it.ID, Text: it.Text, Tags: it.Tags, Scope: memory.ScopeGlobal})
+		}
+	}
+	if scope != memory.ScopeGlobal {
+		if proj, err := openProjectMemory(workspacePath); err == nil {
+			for _, m := range proj.ListScopedMemories() {
+				if scope == "" || m.Scope == scope {
+					hits = append(hits, MemoryHit{ID: m.ID, Text: m.Text, Tags: m.Tags, Scope: m.Scope, Dir: m.Dir})
+				}
+			}
+		} else if scope != "" {
+			return nil, err
+		}
+	}
+	docs := make([]memory.MemoryDoc, len(hits))
+	for i, h := range hits {
+		docs[i] = memory.MemoryDoc{Text: h.Text, Tags: h.Tags}
+	}
+	matches := memory.RankMemories(query, tags, docs)
+	out := make([]MemoryHit, 0, min(len(matches), limit))
+	for _, m := range matches {
+		if len(out) == limit {
+			break
+		}
+		h := hits[m.Index]
+		h.Score = math.Round(m.Score*100) / 100
+		out = append(out, h)
+	}
+	return map[string]interface{}{"memories": out, "count": len(out), "total_matches": len(matches)}, nil
+}
@@ -0,0 +1,59 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+)
+
+func TestMemories_SearchByTextAndTags(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	ws := t.TempDir()
+	reg := NewRegistry()
+	if err := RegisterMemories(reg, ws); err != nil {
+		t.Fatal(err)
+	}
+	invoke := func(args string) map[string]interface{} {
+		t.Helper()
+		res, err := reg.Invoke(context.Background(), "memories", json.RawMessage(args))
+		if err != nil {
+			t.Fatalf("%s: %v", args, err)
+		}
+		var out map[string]interface{}
+		data, _ := json.Marshal(res)
+		_ = json.Unmarshal(data, &out)
+		return out
+	}
+	invoke(`{"action":"add","id":"tabs","text":"Use tabs in Go files","tags":["Style"]}`)
+	invoke(`{"action":"add","id":"migrate","text":"Run make migrate before database tests","tags":["database","testing"]}`)
+	invoke(`{"action":"add","scope":"project","id":"staging","text":"Deploys go through staging","tags":["deploy"]}`)
+	// Updating the text keeps the tags
+	invoke(`{"action":"update","id":"tabs","text":"Use tabs for indentation in Go files"}`)
+
+	ids := func(out map[string]interface{}) []string {
+		var got []string
+		for _, m := range out["memories"].([]interface{}) {
+			got = append(got, m.(map[string]interface{})["id"].(string))
+		}
+		return got
+	}
+	if got := ids(invoke(`{"action":"search","query":"why do database tests fail"}`)); len(got) != 1 || got[0] != "migrate" {
+		t.Errorf("text search: %v", got)
+	}
+	if got := ids(invoke(`{"action":"search","tags":["style"]}`)); len(got) != 1 || got[0] != "tabs" {
+		t.Errorf("tag search: %v", got)
+	}
+	out := invoke(`{"action":"search","query":"deploy"}`)
+	if got := ids(out); len(got) != 1 || got[0] != "staging" {
+		t.Errorf("project memories should be searched: %v", got)
+	}
+	if scope := out["memories"].([]interface{})[0].(map[string]interface{})["scope"]; scope != "project" {
+		t.Errorf("unexpected scope %v", scope)
+	}
+	if got := ids(invoke(`{"action":"search","query":"deploy","scope":"global"}`)); len(got) != 0 {
+		t.Errorf("scope should restrict the search: %v", got)
+	}
+	if _, err := reg.Invoke(context.Background(), "memories", json.RawMessage(`{"action":"search"}`)); err == nil {
+		t.Error("expected an error without query or tags")
+	}
+}
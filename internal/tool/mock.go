@@ -0,0 +1,378 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/loom/loom/internal/editor"
+	"github.com/loom/loom/internal/symbols"
+)
+
+// Mock styles generate_mock can produce.
+const (
+	MockStyleGomock   = "gomock"
+	MockStyleTestify  = "testify"
+	MockStyleJest     = "jest"
+	MockStyleVitest   = "vitest"
+	MockStyleUnittest = "unittest"
+	MockStyleStub     = "stub"
+)
+
+const (
+	// maxMockStyleFiles bounds the test files read to detect the mocking style.
+	maxMockStyleFiles = 400
+	defaultGomockPath = "go.uber.org/mock/gomock"
+)
+
+// GenerateMockArgs are the arguments of generate_mock.
+type GenerateMockArgs struct {
+	// Name is the interface or abstract class, or a symbol id from symbols_search
+	Name string `json:"name"`
+	// File narrows the lookup when several declarations share the name
+	File string `json:"file,omitempty"`
+	// Style overrides the style detected from the project's tests
+	Style string `json:"style,omitempty"`
+	// Path is the file to create, workspace-relative
+	Path string `json:"path,omitempty"`
+}
+
+// GeneratedMock is a mock proposed by generate_mock and applied once approved.
+type GeneratedMock struct {
+	Interface string `json:"interface"`
+	Source    string `json:"source"`
+	Lang      string `json:"lang"`
+	Style     string `json:"style"`
+	// StyleEvidence names the test file the style was detected from, "" when defaulted
+	StyleEvidence string `json:"style_evidence,omitempty"`
+	Path          string `json:"path"`
+	Members       int    `json:"members"`
+	Content       string `json:"content"`
+}
+
+var (
+	mocksMu sync.Mutex
+	// pendingMocks holds proposed mocks by the raw arguments of their call
+	pendingMocks = map[string]*GeneratedMock{}
+)
+
+// RegisterGenerateMock registers the generate_mock tool, which finds interfaces through
+// the symbol index.
+func RegisterGenerateMock(registry *Registry, svc SymbolService) error {
+	return registry.Register(Definition{
+		Name:        "generate_mock",
+		Description: "Generate a mock or stub of an interface or abstract class (Go, TypeScript, JavaScript, Python) in the mocking style the project's tests already use, proposed as a new file for approval",
+		Safe:        false,
+		JSONSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"name": map[string]interface{}{
+					"type":        "string",
+					"description": "Interface or abstract class name, or a sid from symbols_search",
+				},
+				"file": map[string]interface{}{
+					"type":        "string",
+					"description": "File declaring it, when the name is ambiguous",
+				},
+				"style": map[string]interface{}{
+					"type":        "string",
+					"description": "Mocking style; detected from existing tests when omitted",
+					"enum":        []string{MockStyleGomock, MockStyleTestify, MockStyleJest, MockStyleVitest, MockStyleUnittest, MockStyleStub},
+				},
+				"path": map[string]interface{}{
+					"type":        "string",
+					"description": "File to create; defaults to a file next to the declaration",
+				},
+			},
+			"required": []string{"name"},
+		},
+		Usage: `The mock is proposed as a new file with a diff; once approved it is created.
+- The style follows the project's tests: gomock or testify for Go, jest or vitest for TypeScript/JavaScript, unittest.mock for Python, and a hand-written stub otherwise.
+- Go mocks are written into the interface's package, by default as mock_<name>_test.go; embedded interfaces must come from the same package.
+- The target file must not exist.`,
+		Examples: []string{
+			`{"name":"OrderStore"}`,
+			`{"name":"PaymentGateway","file":"src/payments/gateway.ts","style":"vitest"}`,
+		},
+		Handler: func(ctx context.Context, raw json.RawMessage) (interface{}, error) {
+			var args GenerateMockArgs
+			if err := json.Unmarshal(raw, &args); err != nil {
+				return nil, fmt.Errorf("failed to parse arguments: %w", err)
+			}
+			m, err := generateMock(ctx, svc, args)
+			if err != nil {
+				return nil, err
+			}
+			mocksMu.Lock()
+			pendingMocks[string(raw)] = m
+			mocksMu.Unlock()
+
+			msg := fmt.Sprintf("Propose %s mock of %s (%s, %d members) as %s", m.Style, m.Interface, m.Source, m.Members, m.Path)
+			if m.StyleEvidence != "" {
+				msg += fmt.Sprintf("\nStyle detected from %s", m.StyleEvidence)
+			} else if strings.TrimSpace(args.Style) == "" {
+				msg += "\nNo mocking library found in the project's tests; generated a hand-written stub"
+			}
+			return &ExecutionResult{
+				Content: msg,
+				Diff:    editor.UnifiedDiff(m.Path, "", m.Content, false, true, 3),
+				Safe:    false,
+			}, nil
+		},
+	})
+}
+
+// TakeGeneratedMock returns the mock proposed by the generate_mock call with these raw
+// arguments and forgets it. The engine calls it once the user approved the proposal.
+func TakeGeneratedMock(raw json.RawMessage) (*GeneratedMock, bool) {
+	mocksMu.Lock()
+	defer mocksMu.Unlock()
+	m, ok := pendingMocks[string(raw)]
+	delete(pendingMocks, string(raw))
+	return m, ok
+}
+
+// generateMock finds the declaration, detects the style and renders the mock.
+func generateMock(ctx context.Context, svc SymbolService, args GenerateMockArgs) (*GeneratedMock, error) {
+	workspace := svc.Workspace()
+	card, err := findMockTarget(ctx, svc, args)
+	if err != nil {
+		return nil, err
+	}
+	lang := card.Lang
+	switch lang {
+	case "go", "typescript", "javascript", "python":
+	default:
+		return nil, fmt.Errorf("mocks for %s are not supported; generate_mock handles Go, TypeScript, JavaScript and Python", lang)
+	}
+
+	style, evidence, gomockPath := strings.ToLower(strings.TrimSpace(args.Style)), "", defaultGomockPath
+	if style == "" {
+		style, evidence, gomockPath = detectMockStyle(workspace, lang)
+	} else if !mockStyleFits(style, lang) {
+		return nil, fmt.Errorf("style %s does not apply to %s", style, lang)
+	}
+
+	out := strings.TrimSpace(args.Path)
+	if out == "" {
+		out = defaultMockPath(card.File, card.Name, lang)
+	}
+	target, err := validatePath(workspace, out)
+	if err != nil {
+		return nil, err
+	}
+	rel, _ := filepath.Rel(filepath.Clean(workspace), target)
+	rel = filepath.ToSlash(rel)
+	if _, err := os.Stat(target); err == nil {
+		return nil, fmt.Errorf("%s already exists; pass another path", rel)
+	}
+
+	m := &GeneratedMock{Interface: card.Name, Source: card.File, Lang: lang, Style: style, StyleEvidence: evidence, Path: rel}
+	if lang == "go" {
+		if path.Dir(rel) != path.Dir(card.File) || !strings.HasSuffix(rel, ".go") {
+			return nil, fmt.Errorf("Go mocks are generated into the package of %s; choose a .go path in %s", card.Name, path.Dir(card.File))
+		}
+		iface, err := parseGoInterface(workspace, card.File, card.Name)
+		if err != nil {
+			return nil, err
+		}
+		if m.Content, err = generateGoMock(iface, style, gomockPath, card.File); err != nil {
+			return nil, err
+		}
+		m.Members = len(iface.Methods)
+		return m, nil
+	}
+
+	data, err := os.ReadFile(filepath.Join(workspace, filepath.FromSlash(card.File)))
+	if err != nil {
+		return nil, err
+	}
+	t, err := parseScriptTarget(string(data), card.File, lang, card.Name, card.Span[0])
+	if err != nil {
+		return nil, err
+	}
+	if len(t.Members) == 0 {
+		return nil, fmt.Errorf("%s declares no methods to mock", card.Name)
+	}
+	if m.Content, err = generateScriptMock(t, style, rel); err != nil {
+		return nil, err
+	}
+	m.Members = len(t.Members)
+	return m, nil
+}
+
+// findMockTarget resolves the declaration to mock through the symbol index: a symbol
+// id, or an interface or class with exactly this name.
+func findMockTarget(ctx context.Context, svc SymbolService, args GenerateMockArgs) (*symbols.SymbolCard, error) {
+	name := strings.TrimSpace(args.Name)
+	if name == "" {
+		return nil, fmt.Errorf("name is required")
+	}
+	if card, err := svc.Def(ctx, name); err == nil && card != nil {
+		return card, nil
+	}
+	file := filepath.ToSlash(strings.TrimPrefix(strings.TrimSpace(args.File), "./"))
+	var matches []symbols.SymbolCard
+	for _, kind := range []string{"interface", "class"} {
+		cards, err := svc.Search(ctx, name, kind, "", "", 100)
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range cards {
+			if c.Name == name && (file == "" || c.File == file) {
+				matches = append(matches, c)
+			}
+		}
+		if len(matches) > 0 {
+			// Interfaces win over classes of the same name
+			break
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("no interface or class named %s in the symbol index", name)
+	case 1:
+		return &matches[0], nil
+	}
+	files := make([]string, len(matches))
+	for i, c := range matches {
+		files[i] = c.File
+	}
+	return nil, fmt.Errorf("%s is declared in several files (%s); pass file to choose one", name, strings.Join(files, ", "))
+}
+
+// defaultMockPath places the mock next to the declaration.
+func defaultMockPath(source, name, lang string) string {
+	dir := path.Dir(source)
+	switch lang {
+	case "go":
+		return path.Join(dir, "mock_"+snakeCase(name)+"_test.go")
+	case "python":
+		return path.Join(dir, "mock_"+snakeCase(name)+".py")
+	}
+	ext := path.Ext(source)
+	switch ext {
+	case ".tsx":
+		ext = ".ts"
+	case ".jsx":
+		ext = ".js"
+	}
+	return path.Join(dir, name+".mock"+ext)
+}
+
+func mockStyleFits(style, lang string) bool {
+	switch style {
+	case MockStyleStub:
+		return true
+	case MockStyleGomock, MockStyleTestify:
+		return lang == "go"
+	case MockStyleJest, MockStyleVitest:
+		return lang == "typescript" || lang == "javascript"
+	case MockStyleUnittest:
+		return lang == "python"
+	}
+	return false
+}
+
+// detectMockStyle picks the mocking style the workspace's tests for lang use most,
+// with the test file it was first seen in. For gomock it also returns the import path
+// in use (go.uber.org/mock or the older github.com/golang/mock).
+func detectMockStyle(workspace, lang string) (style, evidence, gomockPath string) {
+	type marker struct {
+		style, needle, gomock string
+	}
+	var markers []marker
+	isTest := func(name string) bool { return false }
+	switch lang {
+	case "go":
+		markers = []marker{
+			{MockStyleGomock, `"go.uber.org/mock/gomock"`, "go.uber.org/mock/gomock"},
+			{MockStyleGomock, `"github.com/golang/mock/gomock"`, "github.com/golang/mock/gomock"},
+			{MockStyleTestify, `"github.com/stretchr/testify/mock"`, ""},
+		}
+		isTest = func(name string) bool {
+			return strings.HasSuffix(name, "_test.go") || (strings.HasPrefix(name, "mock") && strings.HasSuffix(name, ".go"))
+		}
+	case "typescript", "javascript":
+		markers = []marker{
+			{MockStyleVitest, "vi.fn(", ""},
+			{MockStyleVitest, "from 'vitest'", ""},
+			{MockStyleVitest, `from "vitest"`, ""},
+			{MockStyleJest, "jest.fn(", ""},
+			{MockStyleJest, "jest.mock(", ""},
+		}
+		isTest = func(name string) bool {
+			return strings.Contains(name, ".test.") || strings.Contains(name, ".spec.")
+		}
+	case "python":
+		markers = []marker{
+			{MockStyleUnittest, "unittest.mock", ""},
+			{MockStyleUnittest, "from mock import", ""},
+			{MockStyleUnittest, "mocker.", ""},
+		}
+		isTest = func(name string) bool {
+			return strings.HasSuffix(name, ".py") && (strings.HasPrefix(name, "test_") || strings.HasSuffix(name, "_test.py") || name == "conftest.py")
+		}
+	}
+
+	counts := map[string]int{}
+	first := map[string]string{}
+	gomockPath = defaultGomockPath
+	gomockCounts := map[string]int{}
+	read := 0
+	_ = filepath.WalkDir(workspace, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() {
+			if p != workspace && (strings.HasPrefix(d.Name(), ".") || skippedTreeDirs[d.Name()]) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !isTest(d.Name()) {
+			return nil
+		}
+		if read++; read > maxMockStyleFiles {
+			return filepath.SkipAll
+		}
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return nil
+		}
+		text := string(data)
+		for _, mk := range markers {
+			if strings.Contains(text, mk.needle) {
+				counts[mk.style]++
+				if first[mk.style] == "" {
+					rel, _ := filepath.Rel(workspace, p)
+					first[mk.style] = filepath.ToSlash(rel)
+				}
+				if mk.gomock != "" {
+					gomockCounts[mk.gomock]++
+				}
+			}
+		}
+		return nil
+	})
+	style = MockStyleStub
+	best := 0
+	for _, mk := range markers {
+		if counts[mk.style] > best {
+			style, best = mk.style, counts[mk.style]
+		}
+	}
+	if best > 0 {
+		evidence = first[style]
+	}
+	if gomockCounts["github.com/golang/mock/gomock"] > gomockCounts[defaultGomockPath] {
+		gomockPath = "github.com/golang/mock/gomock"
+	}
+	return style, evidence, gomockPath
+}
@@ -0,0 +1,433 @@
+package tool
+
+import (
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// goMockMethod is one method of a Go interface.
+type goMockMethod struct {
+	Name    string
+	Params  []goMockParam
+	Results []string
+	// Variadic marks the last parameter as ...T; its Type holds T
+	Variadic bool
+}
+
+type goMockParam struct {
+	Name string
+	Type string
+}
+
+// goMockInterface is an interface parsed for mock generation.
+type goMockInterface struct {
+	Name    string
+	Package string
+	Methods []goMockMethod
+	// Imports maps the package qualifiers used by the method signatures to import paths
+	Imports map[string]string
+}
+
+// reservedMockNames are identifiers the generated method bodies use; parameters with
+// these names are renamed.
+var reservedMockNames = map[string]bool{
+	"m": true, "mr": true, "s": true, "ret": true, "args": true, "varargs": true, "mock": true,
+	"gomock": true, "reflect": true, "v": true,
+}
+
+var goVersionSuffix = regexp.MustCompile(`^v[0-9]+$`)
+
+// parseGoInterface reads the interface name from the Go files of the package that
+// declares it in rel. Interfaces it embeds are resolved within the same package, and
+// the built-in error interface is supported.
+func parseGoInterface(workspace, rel, name string) (*goMockInterface, error) {
+	dir := filepath.Join(workspace, filepath.FromSlash(path.Dir(rel)))
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	fset := token.NewFileSet()
+	ifaces := map[string]*ast.InterfaceType{}
+	fileOf := map[string]*ast.File{}
+	var pkg string
+	for _, e := range entries {
+		n := e.Name()
+		if e.IsDir() || !strings.HasSuffix(n, ".go") || (strings.HasSuffix(n, "_test.go") && n != path.Base(rel)) {
+			continue
+		}
+		f, err := parser.ParseFile(fset, filepath.Join(dir, n), nil, parser.SkipObjectResolution)
+		if err != nil {
+			if n == path.Base(rel) {
+				return nil, fmt.Errorf("parse %s: %w", rel, err)
+			}
+			continue
+		}
+		if n == path.Base(rel) {
+			pkg = f.Name.Name
+		}
+		for _, decl := range f.Decls {
+			gd, ok := decl.(*ast.GenDecl)
+			if !ok || gd.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range gd.Specs {
+				ts := spec.(*ast.TypeSpec)
+				it, ok := ts.Type.(*ast.InterfaceType)
+				if !ok {
+					continue
+				}
+				if ts.TypeParams != nil && ts.Name.Name == name {
+					return nil, fmt.Errorf("%s has type parameters; generic interfaces are not supported", name)
+				}
+				ifaces[ts.Name.Name] = it
+				fileOf[ts.Name.Name] = f
+			}
+		}
+	}
+	if _, ok := ifaces[name]; !ok {
+		return nil, fmt.Errorf("no interface %s in %s", name, path.Dir(rel))
+	}
+
+	out := &goMockInterface{Name: name, Package: pkg, Imports: map[string]string{}}
+	seen := map[string]bool{}
+	var collect func(iface string) error
+	collect = func(iface string) error {
+		if seen[iface] {
+			return nil
+		}
+		seen[iface] = true
+		it, f := ifaces[iface], fileOf[iface]
+		for _, field := range it.Methods.List {
+			switch t := field.Type.(type) {
+			case *ast.FuncType:
+				for _, n := range field.Names {
+					m := goMethodFromFunc(n.Name, t)
+					addGoImports(out.Imports, f, t)
+					out.Methods = append(out.Methods, m)
+				}
+			case *ast.Ident:
+				if t.Name == "error" {
+					out.Methods = append(out.Methods, goMockMethod{Name: "Error", Results: []string{"string"}})
+					continue
+				}
+				if _, ok := ifaces[t.Name]; !ok {
+					return fmt.Errorf("%s embeds %s, which is not an interface of package %s", iface, t.Name, pkg)
+				}
+				if err := collect(t.Name); err != nil {
+					return err
+				}
+			default:
+				return fmt.Errorf("%s embeds %s; only interfaces of the same package can be embedded", iface, types.ExprString(field.Type))
+			}
+		}
+		return nil
+	}
+	if err := collect(name); err != nil {
+		return nil, err
+	}
+	sort.SliceStable(out.Methods, func(i, j int) bool { return out.Methods[i].Name < out.Methods[j].Name })
+	return out, nil
+}
+
+// goMethodFromFunc converts a method signature, naming unnamed or reserved parameters
+// arg0, arg1, ...
+func goMethodFromFunc(name string, ft *ast.FuncType) goMockMethod {
+	m := goMockMethod{Name: name}
+	i := 0
+	if ft.Params != nil {
+		for _, field := range ft.Params.List {
+			typ := field.Type
+			if el, ok := typ.(*ast.Ellipsis); ok {
+				m.Variadic = true
+				typ = el.Elt
+			}
+			names := field.Names
+			if len(names) == 0 {
+				names = []*ast.Ident{{Name: "_"}}
+			}
+			for _, n := range names {
+				pname := n.Name
+				if pname == "_" || reservedMockNames[pname] {
+					pname = "arg" + strconv.Itoa(i)
+				}
+				m.Params = append(m.Params, goMockParam{Name: pname, Type: types.ExprString(typ)})
+				i++
+			}
+		}
+	}
+	if ft.Results != nil {
+		for _, field := range ft.Results.List {
+			for range max(len(field.Names), 1) {
+				m.Results = append(m.Results, types.ExprString(field.Type))
+			}
+		}
+	}
+	return m
+}
+
+// addGoImports records the imports of f used by package qualifiers in ft.
+func addGoImports(imports map[string]string, f *ast.File, ft *ast.FuncType) {
+	used := map[string]bool{}
+	ast.Inspect(ft, func(n ast.Node) bool {
+		if sel, ok := n.(*ast.SelectorExpr); ok {
+			if id, ok := sel.X.(*ast.Ident); ok {
+				used[id.Name] = true
+			}
+		}
+		return true
+	})
+	for _, spec := range f.Imports {
+		p, _ := strconv.Unquote(spec.Path.Value)
+		qual := goImportName(p)
+		if spec.Name != nil {
+			qual = spec.Name.Name
+		}
+		if used[qual] {
+			imports[qual] = p
+		}
+	}
+}
+
+// goImportName guesses the package name of an import path: its last element, skipping
+// a major version suffix such as /v2.
+func goImportName(p string) string {
+	parts := strings.Split(p, "/")
+	name := parts[len(parts)-1]
+	if goVersionSuffix.MatchString(name) && len(parts) > 1 {
+		name = parts[len(parts)-2]
+	}
+	name = strings.TrimPrefix(name, "go-")
+	return strings.NewReplacer("-", "", ".", "").Replace(name)
+}
+
+// generateGoMock renders a Go mock of iface in the given style: gomock (mockgen's
+// layout), testify (mock.Mock) or stub (func fields). gomockImport is the gomock
+// package path for the gomock style.
+func generateGoMock(iface *goMockInterface, style, gomockImport, source string) (string, error) {
+	imports := map[string]string{}
+	for q, p := range iface.Imports {
+		imports[q] = p
+	}
+	var body strings.Builder
+	var mockName string
+	switch style {
+	case MockStyleGomock:
+		mockName = "Mock" + iface.Name
+		imports["gomock"] = gomockImport
+		imports["reflect"] = "reflect"
+		writeGomock(&body, iface, mockName)
+	case MockStyleTestify:
+		mockName = "Mock" + iface.Name
+		imports["mock"] = "github.com/stretchr/testify/mock"
+		writeTestifyMock(&body, iface, mockName)
+	default:
+		mockName = "Stub" + iface.Name
+		writeGoStub(&body, iface, mockName)
+	}
+	fmt.Fprintf(&body, "\nvar _ %s = (*%s)(nil)\n", iface.Name, mockName)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "// Code generated by loom generate_mock from %s. Regenerate or edit it by hand\n// when %s changes.\n\n", source, iface.Name)
+	fmt.Fprintf(&b, "package %s\n\n", iface.Package)
+	if len(imports) > 0 {
+		quals := make([]string, 0, len(imports))
+		for q := range imports {
+			quals = append(quals, q)
+		}
+		// Standard library first, then other modules, as goimports groups them
+		std := func(p string) bool { return !strings.Contains(strings.SplitN(p, "/", 2)[0], ".") }
+		sort.Slice(quals, func(i, j int) bool {
+			pi, pj := imports[quals[i]], imports[quals[j]]
+			if std(pi) != std(pj) {
+				return std(pi)
+			}
+			return pi < pj
+		})
+		b.WriteString("import (\n")
+		for i, q := range quals {
+			if i > 0 && std(imports[quals[i-1]]) && !std(imports[q]) {
+				b.WriteString("\n")
+			}
+			if goImportName(imports[q]) == q {
+				fmt.Fprintf(&b, "\t%q\n", imports[q])
+			} else {
+				fmt.Fprintf(&b, "\t%s %q\n", q, imports[q])
+			}
+		}
+		b.WriteString(")\n\n")
+	}
+	b.WriteString(body.String())
+	src, err := format.Source([]byte(b.String()))
+	if err != nil {
+		return "", fmt.Errorf("generated Go code does not parse: %w", err)
+	}
+	return string(src), nil
+}
+
+// goSignature renders a method's parameter list and results.
+func goSignature(m goMockMethod) string {
+	params := make([]string, len(m.Params))
+	for i, p := range m.Params {
+		typ := p.Type
+		if m.Variadic && i == len(m.Params)-1 {
+			typ = "..." + typ
+		}
+		params[i] = p.Name + " " + typ
+	}
+	sig := "(" + strings.Join(params, ", ") + ")"
+	switch len(m.Results) {
+	case 0:
+	case 1:
+		sig += " " + m.Results[0]
+	default:
+		sig += " (" + strings.Join(m.Results, ", ") + ")"
+	}
+	return sig
+}
+
+// goCallArgs renders the parameters as call arguments, spreading a variadic one.
+func goCallArgs(m goMockMethod) string {
+	names := make([]string, len(m.Params))
+	for i, p := range m.Params {
+		names[i] = p.Name
+		if m.Variadic && i == len(m.Params)-1 {
+			names[i] += "..."
+		}
+	}
+	return strings.Join(names, ", ")
+}
+
+func writeGoStub(b *strings.Builder, iface *goMockInterface, name string) {
+	fmt.Fprintf(b, "// %s is a stub of %s. Each method calls the matching func field, or returns\n// zero values when it is nil.\n", name, iface.Name)
+	fmt.Fprintf(b, "type %s struct {\n", name)
+	for _, m := range iface.Methods {
+		fmt.Fprintf(b, "\t%sFunc func%s\n", m.Name, goSignature(m))
+	}
+	b.WriteString("}\n")
+	for _, m := range iface.Methods {
+		fmt.Fprintf(b, "\n// %s calls %sFunc.\n", m.Name, m.Name)
+		fmt.Fprintf(b, "func (s *%s) %s%s {\n", name, m.Name, goSignature(m))
+		fmt.Fprintf(b, "\tif s.%sFunc == nil {\n", m.Name)
+		if len(m.Results) > 0 {
+			rets := make([]string, len(m.Results))
+			for i, r := range m.Results {
+				fmt.Fprintf(b, "\t\tvar r%d %s\n", i, r)
+				rets[i] = fmt.Sprintf("r%d", i)
+			}
+			fmt.Fprintf(b, "\t\treturn %s\n", strings.Join(rets, ", "))
+		} else {
+			b.WriteString("\t\treturn\n")
+		}
+		b.WriteString("\t}\n")
+		if len(m.Results) > 0 {
+			fmt.Fprintf(b, "\treturn s.%sFunc(%s)\n", m.Name, goCallArgs(m))
+		} else {
+			fmt.Fprintf(b, "\ts.%sFunc(%s)\n", m.Name, goCallArgs(m))
+		}
+		b.WriteString("}\n")
+	}
+}
+
+func writeTestifyMock(b *strings.Builder, iface *goMockInterface, name string) {
+	fmt.Fprintf(b, "// %s is a testify mock of %s.\n", name, iface.Name)
+	fmt.Fprintf(b, "type %s struct {\n\tmock.Mock\n}\n", name)
+	for _, m := range iface.Methods {
+		names := make([]string, len(m.Params))
+		for i, p := range m.Params {
+			names[i] = p.Name
+		}
+		fmt.Fprintf(b, "\n// %s records the call and returns the values set with On.\n", m.Name)
+		fmt.Fprintf(b, "func (m *%s) %s%s {\n", name, m.Name, goSignature(m))
+		if len(m.Results) == 0 {
+			fmt.Fprintf(b, "\tm.Called(%s)\n}\n", strings.Join(names, ", "))
+			continue
+		}
+		fmt.Fprintf(b, "\targs := m.Called(%s)\n", strings.Join(names, ", "))
+		rets := make([]string, len(m.Results))
+		for i, r := range m.Results {
+			if r == "error" {
+				rets[i] = fmt.Sprintf("args.Error(%d)", i)
+				continue
+			}
+			fmt.Fprintf(b, "\tvar r%d %s\n\tif v := args.Get(%d); v != nil {\n\t\tr%d = v.(%s)\n\t}\n", i, r, i, i, r)
+			rets[i] = fmt.Sprintf("r%d", i)
+		}
+		fmt.Fprintf(b, "\treturn %s\n}\n", strings.Join(rets, ", "))
+	}
+}
+
+func writeGomock(b *strings.Builder, iface *goMockInterface, name string) {
+	rec := name + "MockRecorder"
+	fmt.Fprintf(b, "// %s is a mock of the %s interface.\n", name, iface.Name)
+	fmt.Fprintf(b, "type %s struct {\n\tctrl     *gomock.Controller\n\trecorder *%s\n}\n\n", name, rec)
+	fmt.Fprintf(b, "// %s is the mock recorder for %s.\n", rec, name)
+	fmt.Fprintf(b, "type %s struct {\n\tmock *%s\n}\n\n", rec, name)
+	fmt.Fprintf(b, "// New%s creates a new mock instance.\n", name)
+	fmt.Fprintf(b, "func New%s(ctrl *gomock.Controller) *%s {\n\tmock := &%s{ctrl: ctrl}\n\tmock.recorder = &%s{mock}\n\treturn mock\n}\n\n", name, name, name, rec)
+	b.WriteString("// EXPECT returns an object that allows the caller to indicate expected use.\n")
+	fmt.Fprintf(b, "func (m *%s) EXPECT() *%s {\n\treturn m.recorder\n}\n", name, rec)
+
+	for _, m := range iface.Methods {
+		fixed := m.Params
+		var variadic *goMockParam
+		if m.Variadic {
+			fixed, variadic = m.Params[:len(m.Params)-1], &m.Params[len(m.Params)-1]
+		}
+		fixedNames := make([]string, len(fixed))
+		for i, p := range fixed {
+			fixedNames[i] = p.Name
+		}
+
+		fmt.Fprintf(b, "\n// %s mocks base method.\n", m.Name)
+		fmt.Fprintf(b, "func (m *%s) %s%s {\n\tm.ctrl.T.Helper()\n", name, m.Name, goSignature(m))
+		callArgs := ""
+		if len(fixedNames) > 0 {
+			callArgs = ", " + strings.Join(fixedNames, ", ")
+		}
+		if variadic != nil {
+			fmt.Fprintf(b, "\tvarargs := []any{%s}\n\tfor _, a := range %s {\n\t\tvarargs = append(varargs, a)\n\t}\n", strings.Join(fixedNames, ", "), variadic.Name)
+			callArgs = ", varargs..."
+		}
+		if len(m.Results) == 0 {
+			fmt.Fprintf(b, "\tm.ctrl.Call(m, %q%s)\n}\n", m.Name, callArgs)
+		} else {
+			fmt.Fprintf(b, "\tret := m.ctrl.Call(m, %q%s)\n", m.Name, callArgs)
+			rets := make([]string, len(m.Results))
+			for i, r := range m.Results {
+				fmt.Fprintf(b, "\tret%d, _ := ret[%d].(%s)\n", i, i, r)
+				rets[i] = fmt.Sprintf("ret%d", i)
+			}
+			fmt.Fprintf(b, "\treturn %s\n}\n", strings.Join(rets, ", "))
+		}
+
+		recParams := ""
+		if len(fixedNames) > 0 {
+			recParams = strings.Join(fixedNames, ", ") + " any"
+		}
+		if variadic != nil {
+			if recParams != "" {
+				recParams += ", "
+			}
+			recParams += variadic.Name + " ...any"
+		}
+		fmt.Fprintf(b, "\n// %s indicates an expected call of %s.\n", m.Name, m.Name)
+		fmt.Fprintf(b, "func (mr *%s) %s(%s) *gomock.Call {\n\tmr.mock.ctrl.T.Helper()\n", rec, m.Name, recParams)
+		recArgs := callArgs
+		if variadic != nil {
+			fmt.Fprintf(b, "\tvarargs := append([]any{%s}, %s...)\n", strings.Join(fixedNames, ", "), variadic.Name)
+			recArgs = ", varargs..."
+		}
+		fmt.Fprintf(b, "\treturn mr.mock.ctrl.RecordCallWithMethodType(mr.mock, %q, reflect.TypeOf((*%s)(nil).%s)%s)\n}\n", m.Name, name, m.Name, recArgs)
+	}
+}
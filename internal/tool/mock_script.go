@@ -0,0 +1,598 @@
+package tool
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// scriptMockMember is a method or property of a TypeScript/JavaScript interface or
+// class, or a method of a Python class.
+type scriptMockMember struct {
+	Name string
+	// Params is the raw parameter list, without parentheses
+	Params string
+	// Returns is the declared return type (or the property type), "" when unknown
+	Returns  string
+	Property bool
+	Async    bool
+}
+
+// scriptMockTarget is an interface or abstract class parsed for mock generation.
+type scriptMockTarget struct {
+	Name    string
+	Lang    string
+	File    string
+	Members []scriptMockMember
+	// Class is true for classes (abstract or not), false for TypeScript interfaces
+	Class bool
+	// Lines holds the source file, for copying the imports the members need
+	Lines []string
+}
+
+var (
+	tsDeclRe = regexp.MustCompile(`^\s*(?:export\s+)?(?:default\s+)?(?:declare\s+)?(abstract\s+)?(interface|class)\s+([A-Za-z_$][\w$]*)\s*(?:<[^{]*>)?\s*(?:extends\s+([^{]+?))?\s*(?:implements\s+[^{]+)?\{?\s*$`)
+	// name(params): type, name?<T>(params): type, with optional modifiers
+	tsMethodRe = regexp.MustCompile(`^(?:(?:public|protected|private|abstract|readonly|static|async|override|declare)\s+)*([A-Za-z_$][\w$]*)\??\s*(?:<[^(]*>)?\s*\(([\s\S]*)\)\s*(?::\s*([\s\S]+?))?\s*(?:\{[\s\S]*\})?$`)
+	// name: (params) => type
+	tsFuncPropRe = regexp.MustCompile(`^(?:(?:public|protected|private|abstract|readonly|declare)\s+)*([A-Za-z_$][\w$]*)\??\s*:\s*\(([\s\S]*)\)\s*=>\s*([\s\S]+)$`)
+	tsPropRe     = regexp.MustCompile(`^(?:(?:public|protected|private|abstract|readonly|declare)\s+)*([A-Za-z_$][\w$]*)\??\s*:\s*([\s\S]+)$`)
+	tsImportRe   = regexp.MustCompile(`^\s*import\s+(?:type\s+)?(.+?)\s+from\s+['"]([^'"]+)['"]`)
+	tsTypeNameRe = regexp.MustCompile(`\b[A-Z][\w$]*\b`)
+
+	pyClassDeclRe = regexp.MustCompile(`^(\s*)class\s+([A-Za-z_]\w*)\s*(?:\((.*)\))?\s*:`)
+	pyDefRe       = regexp.MustCompile(`^(\s*)(async\s+)?def\s+([A-Za-z_]\w*)\s*\(`)
+)
+
+// tsBuiltinTypes are type names that need no import.
+var tsBuiltinTypes = map[string]bool{
+	"Promise": true, "Array": true, "Record": true, "Partial": true, "Readonly": true, "Map": true, "Set": true,
+	"Date": true, "Error": true, "Omit": true, "Pick": true, "Required": true, "ReadonlyArray": true,
+	"Uint8Array": true, "ArrayBuffer": true, "Buffer": true, "RegExp": true, "Function": true, "Object": true,
+	"AsyncIterable": true, "Iterable": true, "ReturnType": true, "Parameters": true, "NonNullable": true,
+}
+
+// parseScriptTarget reads the interface or class name declared at line (1-based) of
+// a TypeScript, JavaScript or Python file.
+func parseScriptTarget(content, rel, lang, name string, line int) (*scriptMockTarget, error) {
+	lines := strings.Split(content, "\n")
+	t := &scriptMockTarget{Name: name, Lang: lang, File: rel, Lines: lines}
+	if lang == "python" {
+		return t, parsePythonClass(t, lines, name, line)
+	}
+	return t, parseTSDecl(t, lines, name, line, map[string]bool{})
+}
+
+// tsDeclLine finds the declaration of name, preferring the given line.
+func tsDeclLine(lines []string, name string, line int) int {
+	if line >= 1 && line <= len(lines) {
+		if m := tsDeclRe.FindStringSubmatch(lines[line-1]); m != nil && m[3] == name {
+			return line - 1
+		}
+	}
+	for i, l := range lines {
+		if m := tsDeclRe.FindStringSubmatch(l); m != nil && m[3] == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// parseTSDecl collects the members of an interface or class, including those of
+// interfaces it extends that are declared in the same file.
+func parseTSDecl(t *scriptMockTarget, lines []string, name string, line int, seen map[string]bool) error {
+	if seen[name] {
+		return nil
+	}
+	seen[name] = true
+	idx := tsDeclLine(lines, name, line)
+	if idx < 0 {
+		return fmt.Errorf("no interface or class %s in %s", name, t.File)
+	}
+	decl := tsDeclRe.FindStringSubmatch(lines[idx])
+	abstract, isClass := decl[1] != "", decl[2] == "class"
+	if len(seen) == 1 {
+		t.Class = isClass
+		if isClass && !abstract && t.Lang == "typescript" {
+			return fmt.Errorf("%s is a concrete class; generate mocks for interfaces or abstract classes", name)
+		}
+	}
+	if decl[4] != "" && !isClass {
+		for _, base := range strings.Split(decl[4], ",") {
+			base = strings.TrimSpace(base)
+			if i := strings.IndexAny(base, "<"); i >= 0 {
+				base = base[:i]
+			}
+			if tsDeclLine(lines, base, 0) >= 0 {
+				if err := parseTSDecl(t, lines, base, 0, seen); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	body := tsBody(lines, idx)
+	for _, member := range splitTSMembers(body) {
+		isAbstract := strings.Contains(" "+member+" ", " abstract ")
+		if isClass && abstract && !isAbstract {
+			continue
+		}
+		if strings.HasPrefix(member, "[") || strings.HasPrefix(member, "static ") || strings.HasPrefix(member, "private ") ||
+			strings.HasPrefix(member, "#") || strings.HasPrefix(member, "constructor") || strings.HasPrefix(member, "get ") ||
+			strings.HasPrefix(member, "set ") {
+			continue
+		}
+		var m scriptMockMember
+		if sm := tsFuncPropRe.FindStringSubmatch(member); sm != nil {
+			m = scriptMockMember{Name: sm[1], Params: strings.TrimSpace(sm[2]), Returns: strings.TrimSpace(sm[3])}
+		} else if sm := tsMethodRe.FindStringSubmatch(member); sm != nil {
+			m = scriptMockMember{Name: sm[1], Params: strings.TrimSpace(sm[2]), Returns: strings.TrimSpace(sm[3])}
+			m.Async = strings.Contains(member[:strings.Index(member, sm[1])], "async")
+		} else if sm := tsPropRe.FindStringSubmatch(member); sm != nil {
+			m = scriptMockMember{Name: sm[1], Returns: strings.TrimSpace(sm[2]), Property: true}
+		} else {
+			continue
+		}
+		t.Members = append(t.Members, m)
+	}
+	return nil
+}
+
+// tsBody returns the text between the braces of the declaration starting at line idx.
+func tsBody(lines []string, idx int) string {
+	text := strings.Join(lines[idx:], "\n")
+	start := strings.Index(text, "{")
+	if start < 0 {
+		return ""
+	}
+	depth := 0
+	for i := start; i < len(text); i++ {
+		switch text[i] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return text[start+1 : i]
+			}
+		}
+	}
+	return text[start+1:]
+}
+
+// splitTSMembers splits a declaration body into members at semicolons, commas and line
+// breaks outside brackets, dropping comments and decorators.
+func splitTSMembers(body string) []string {
+	var cleaned strings.Builder
+	for _, l := range strings.Split(body, "\n") {
+		if i := strings.Index(l, "//"); i >= 0 && !strings.Contains(l[:i], "'") && !strings.Contains(l[:i], "\"") {
+			l = l[:i]
+		}
+		cleaned.WriteString(l + "\n")
+	}
+	text := regexp.MustCompile(`(?s)/\*.*?\*/`).ReplaceAllString(cleaned.String(), "")
+
+	var out []string
+	var cur strings.Builder
+	depth := 0
+	flush := func() {
+		s := strings.TrimSpace(cur.String())
+		cur.Reset()
+		for strings.HasPrefix(s, "@") {
+			// Decorators precede the member on the same or previous line
+			if i := strings.IndexAny(s, " \n"); i > 0 {
+				s = strings.TrimSpace(s[i:])
+			} else {
+				s = ""
+			}
+		}
+		if s != "" {
+			out = append(out, s)
+		}
+	}
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		switch c {
+		case '(', '{', '[', '<':
+			depth++
+		case ')', '}', ']':
+			depth--
+			if c == '}' && depth == 0 {
+				// A method body ends the member
+				cur.WriteByte(c)
+				flush()
+				continue
+			}
+		case '>':
+			if i > 0 && text[i-1] != '=' {
+				depth--
+			}
+		case ';', ',', '\n':
+			if depth <= 0 {
+				if c == '\n' && tsContinues(cur.String(), text[i+1:]) {
+					cur.WriteByte(' ')
+					continue
+				}
+				flush()
+				continue
+			}
+		}
+		cur.WriteByte(c)
+	}
+	flush()
+	return out
+}
+
+// tsContinues reports whether a member continues on the next line, e.g. after "=>" or
+// before a "{" opening a method body.
+func tsContinues(cur, rest string) bool {
+	cur, rest = strings.TrimSpace(cur), strings.TrimSpace(rest)
+	if cur == "" || strings.HasPrefix(cur, "@") && !strings.Contains(cur, " ") {
+		return cur != ""
+	}
+	return strings.HasSuffix(cur, "=>") || strings.HasSuffix(cur, ":") || strings.HasSuffix(cur, "|") ||
+		strings.HasPrefix(rest, "{") || strings.HasPrefix(rest, "=>") || strings.HasPrefix(rest, "|") || strings.HasPrefix(rest, ":")
+}
+
+// parsePythonClass collects the methods of a class: its abstract methods when it has
+// any, otherwise its public methods.
+func parsePythonClass(t *scriptMockTarget, lines []string, name string, line int) error {
+	idx := -1
+	if line >= 1 && line <= len(lines) {
+		if m := pyClassDeclRe.FindStringSubmatch(lines[line-1]); m != nil && m[2] == name {
+			idx = line - 1
+		}
+	}
+	for i := 0; idx < 0 && i < len(lines); i++ {
+		if m := pyClassDeclRe.FindStringSubmatch(lines[i]); m != nil && m[2] == name {
+			idx = i
+		}
+	}
+	if idx < 0 {
+		return fmt.Errorf("no class %s in %s", name, t.File)
+	}
+	t.Class = true
+	indent := len(pyClassDeclRe.FindStringSubmatch(lines[idx])[1])
+
+	var all, abstract []scriptMockMember
+	var decorators []string
+	for i := idx + 1; i < len(lines); i++ {
+		l := lines[i]
+		trim := strings.TrimSpace(l)
+		if trim == "" || strings.HasPrefix(trim, "#") {
+			continue
+		}
+		if len(l)-len(strings.TrimLeft(l, " \t")) <= indent {
+			break
+		}
+		if strings.HasPrefix(trim, "@") {
+			decorators = append(decorators, trim)
+			continue
+		}
+		m := pyDefRe.FindStringSubmatch(l)
+		if m == nil {
+			decorators = nil
+			continue
+		}
+		// The signature may span lines; it ends at the ":" after the closing parenthesis
+		sig := trim
+		for j := i + 1; !pySignatureDone(sig) && j < len(lines); j++ {
+			sig += " " + strings.TrimSpace(lines[j])
+		}
+		member := scriptMockMember{Name: m[3], Async: m[2] != ""}
+		open := strings.Index(sig, "(")
+		if closeIdx := pyCloseParen(sig, open); closeIdx > open {
+			member.Params = strings.TrimSpace(sig[open+1 : closeIdx])
+			rest := strings.TrimSpace(sig[closeIdx+1:])
+			if strings.HasPrefix(rest, "->") {
+				member.Returns = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(rest[2:]), ":"))
+			}
+		}
+		isAbstract, skip := false, false
+		for _, d := range decorators {
+			switch {
+			case strings.Contains(d, "abstractmethod"):
+				isAbstract = true
+			case d == "@property", d == "@staticmethod", d == "@classmethod", strings.HasSuffix(d, ".setter"):
+				skip = true
+			}
+		}
+		decorators = nil
+		if skip || strings.HasPrefix(member.Name, "_") {
+			continue
+		}
+		all = append(all, member)
+		if isAbstract {
+			abstract = append(abstract, member)
+		}
+	}
+	t.Members = all
+	if len(abstract) > 0 {
+		t.Members = abstract
+	}
+	return nil
+}
+
+func pySignatureDone(sig string) bool {
+	open := strings.Index(sig, "(")
+	c := pyCloseParen(sig, open)
+	return c > 0 && strings.HasSuffix(strings.TrimSpace(sig), ":")
+}
+
+// pyCloseParen returns the index of the parenthesis closing the one at open, or -1.
+func pyCloseParen(s string, open int) int {
+	if open < 0 {
+		return -1
+	}
+	depth := 0
+	for i := open; i < len(s); i++ {
+		switch s[i] {
+		case '(', '[', '{':
+			depth++
+		case ')', ']', '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// generateScriptMock renders a mock of t for the given style, to be written at out
+// (workspace-relative).
+func generateScriptMock(t *scriptMockTarget, style, out string) (string, error) {
+	if t.Lang == "python" {
+		return generatePythonMock(t, style), nil
+	}
+	return generateTSMock(t, style, out), nil
+}
+
+// tsModuleSpecifier returns the import specifier of the module at target (a
+// workspace-relative file) from a file in dir.
+func tsModuleSpecifier(dir, target string) string {
+	target = strings.TrimSuffix(target, path.Ext(target))
+	rel := relSlash(dir, target)
+	if !strings.HasPrefix(rel, ".") {
+		rel = "./" + rel
+	}
+	return rel
+}
+
+// relSlash returns target relative to dir, both workspace-relative slash paths.
+func relSlash(dir, target string) string {
+	d := strings.Split(path.Clean(dir), "/")
+	t := strings.Split(path.Clean(target), "/")
+	if path.Clean(dir) == "." {
+		d = nil
+	}
+	i := 0
+	for i < len(d) && i < len(t)-1 && d[i] == t[i] {
+		i++
+	}
+	parts := make([]string, 0, len(d)-i+len(t)-i)
+	for range d[i:] {
+		parts = append(parts, "..")
+	}
+	return strings.Join(append(parts, t[i:]...), "/")
+}
+
+func generateTSMock(t *scriptMockTarget, style, out string) string {
+	outDir := path.Dir(out)
+	srcDir := path.Dir(t.File)
+	source := tsModuleSpecifier(outDir, t.File)
+	typed := t.Lang == "typescript"
+	cjs := !typed && strings.Contains(strings.Join(t.Lines, "\n"), "module.exports")
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "// Generated by loom generate_mock from %s. Regenerate or edit it by hand when %s changes.\n", t.File, t.Name)
+	var methods []scriptMockMember
+	for _, m := range t.Members {
+		if !m.Property {
+			methods = append(methods, m)
+		}
+	}
+
+	if style == MockStyleJest || style == MockStyleVitest {
+		fn := "jest.fn()"
+		mocked := "jest.Mocked<" + t.Name + ">"
+		if style == MockStyleVitest {
+			fn = "vi.fn()"
+			mocked = "Mocked<" + t.Name + ">"
+			if typed {
+				b.WriteString("import { vi, type Mocked } from 'vitest';\n")
+			} else if cjs {
+				b.WriteString("const { vi } = require('vitest');\n")
+			} else {
+				b.WriteString("import { vi } from 'vitest';\n")
+			}
+		}
+		if typed {
+			fmt.Fprintf(&b, "import type { %s } from '%s';\n", t.Name, source)
+		}
+		b.WriteString("\n")
+		fmt.Fprintf(&b, "/** Creates a mock of %s whose methods are %s mocks. */\n", t.Name, strings.TrimSuffix(fn, "()"))
+		export := "export "
+		if cjs {
+			export = ""
+		}
+		if typed {
+			fmt.Fprintf(&b, "%sfunction createMock%s(): %s {\n  return {\n", export, t.Name, mocked)
+		} else {
+			fmt.Fprintf(&b, "%sfunction createMock%s() {\n  return {\n", export, t.Name)
+		}
+		for _, m := range methods {
+			fmt.Fprintf(&b, "    %s: %s,\n", m.Name, fn)
+		}
+		if typed {
+			fmt.Fprintf(&b, "  } as unknown as %s;\n}\n", mocked)
+		} else {
+			b.WriteString("  };\n}\n")
+		}
+		if cjs {
+			fmt.Fprintf(&b, "\nmodule.exports = { createMock%s };\n", t.Name)
+		}
+		return b.String()
+	}
+
+	// Stub: a class implementing every member, with methods that throw until overridden
+	stub := "Stub" + t.Name
+	if typed {
+		imports := tsSignatureImports(t, outDir, srcDir)
+		for _, l := range imports {
+			b.WriteString(l + "\n")
+		}
+	} else if cjs {
+		fmt.Fprintf(&b, "const { %s } = require('%s');\n", t.Name, source)
+	} else {
+		fmt.Fprintf(&b, "import { %s } from '%s';\n", t.Name, source)
+	}
+	b.WriteString("\n")
+	fmt.Fprintf(&b, "/** Stub of %s. Methods throw until a test overrides them. */\n", t.Name)
+	export := "export "
+	if cjs {
+		export = ""
+	}
+	relation := "extends"
+	if typed && !t.Class {
+		relation = "implements"
+	}
+	fmt.Fprintf(&b, "%sclass %s %s %s {\n", export, stub, relation, t.Name)
+	for _, m := range t.Members {
+		if m.Property {
+			if typed {
+				fmt.Fprintf(&b, "  %s!: %s;\n", m.Name, m.Returns)
+			}
+			continue
+		}
+		sig := m.Name + "(" + m.Params + ")"
+		if typed && m.Returns != "" {
+			sig += ": " + m.Returns
+		}
+		if m.Async {
+			sig = "async " + sig
+		}
+		fmt.Fprintf(&b, "  %s {\n    throw new Error('%s.%s is not implemented');\n  }\n", sig, stub, m.Name)
+	}
+	b.WriteString("}\n")
+	if cjs {
+		fmt.Fprintf(&b, "\nmodule.exports = { %s };\n", stub)
+	}
+	return b.String()
+}
+
+// tsSignatureImports returns the import lines a TypeScript stub in outDir needs: the
+// target itself, exported types of its file used by the members, and the source
+// file's imports of other names the members use, re-pointed from srcDir to outDir.
+func tsSignatureImports(t *scriptMockTarget, outDir, srcDir string) []string {
+	used := map[string]bool{}
+	for _, m := range t.Members {
+		for _, n := range tsTypeNameRe.FindAllString(m.Params+" "+m.Returns, -1) {
+			if !tsBuiltinTypes[n] && n != t.Name {
+				used[n] = true
+			}
+		}
+	}
+	fromSource := []string{t.Name}
+	imported := map[string]bool{}
+	var lines []string
+	for _, l := range t.Lines {
+		m := tsImportRe.FindStringSubmatch(l)
+		if m == nil {
+			continue
+		}
+		need := false
+		for _, n := range regexp.MustCompile(`[A-Za-z_$][\w$]*`).FindAllString(m[1], -1) {
+			if used[n] {
+				need, imported[n] = true, true
+			}
+		}
+		if !need {
+			continue
+		}
+		spec := m[2]
+		if strings.HasPrefix(spec, ".") {
+			spec = tsModuleSpecifier(outDir, path.Join(srcDir, spec)+".ts")
+		}
+		lines = append(lines, strings.Replace(strings.TrimSpace(l), m[2], spec, 1))
+	}
+	source := strings.Join(t.Lines, "\n")
+	names := make([]string, 0, len(used))
+	for n := range used {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	for _, n := range names {
+		if imported[n] {
+			continue
+		}
+		if regexp.MustCompile(`(?m)^\s*export\s+(?:declare\s+)?(?:abstract\s+)?(?:interface|type|class|enum)\s+` + regexp.QuoteMeta(n) + `\b`).MatchString(source) {
+			fromSource = append(fromSource, n)
+		}
+	}
+	kw := "import type"
+	if t.Class {
+		// extends needs the class value
+		kw = "import"
+	}
+	first := fmt.Sprintf("%s { %s } from '%s';", kw, strings.Join(fromSource, ", "), tsModuleSpecifier(outDir, t.File))
+	return append([]string{first}, lines...)
+}
+
+// pythonModule returns the dotted module path of a workspace-relative .py file.
+func pythonModule(rel string) string {
+	rel = strings.TrimSuffix(strings.TrimPrefix(rel, "src/"), ".py")
+	rel = strings.TrimSuffix(rel, "/__init__")
+	return strings.ReplaceAll(rel, "/", ".")
+}
+
+func generatePythonMock(t *scriptMockTarget, style string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "\"\"\"Generated by loom generate_mock from %s. Regenerate or edit it by hand when %s changes.\"\"\"\n\n", t.File, t.Name)
+	snake := snakeCase(t.Name)
+	if style == MockStyleUnittest {
+		b.WriteString("from unittest.mock import create_autospec\n\n")
+		fmt.Fprintf(&b, "from %s import %s\n\n\n", pythonModule(t.File), t.Name)
+		fmt.Fprintf(&b, "def make_mock_%s():\n", snake)
+		fmt.Fprintf(&b, "    \"\"\"Return a mock of %s that checks the arguments of every call.\"\"\"\n", t.Name)
+		fmt.Fprintf(&b, "    return create_autospec(%s, instance=True)\n", t.Name)
+		return b.String()
+	}
+	// Annotations stay unevaluated, so the stub needs no imports for their types
+	b.WriteString("from __future__ import annotations\n\n")
+	fmt.Fprintf(&b, "from %s import %s\n\n\n", pythonModule(t.File), t.Name)
+	fmt.Fprintf(&b, "class Stub%s(%s):\n", t.Name, t.Name)
+	fmt.Fprintf(&b, "    \"\"\"Stub of %s: every method returns None until a test overrides it.\"\"\"\n", t.Name)
+	for _, m := range t.Members {
+		def := "def"
+		if m.Async {
+			def = "async def"
+		}
+		sig := fmt.Sprintf("%s %s(%s)", def, m.Name, m.Params)
+		if m.Returns != "" {
+			sig += " -> " + m.Returns
+		}
+		fmt.Fprintf(&b, "\n    %s:\n        return None\n", sig)
+	}
+	if len(t.Members) == 0 {
+		b.WriteString("\n    pass\n")
+	}
+	return b.String()
+}
+
+// snakeCase converts a CamelCase name to snake_case, e.g. OrderStore -> order_store.
+func snakeCase(name string) string {
+	var b strings.Builder
+	for i, r := range name {
+		if r >= 'A' && r <= 'Z' {
+			if i > 0 && (name[i-1] < 'A' || name[i-1] > 'Z' || (i+1 < len(name) && name[i+1] >= 'a' && name[i+1] <= 'z')) {
+				b.WriteByte('_')
+			}
+			r += 'a' - 'A'
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,212 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/loom/loom/internal/symbols"
+)
+
+func mockRegistry(t *testing.T, files map[string]string) (*Registry, string) {
+	t.Helper()
+	dir := t.TempDir()
+	for rel, content := range files {
+		mustWriteFile(t, dir, rel, content)
+	}
+	svc, err := symbols.NewService(dir)
+	if err != nil {
+		t.Fatalf("symbols: %v", err)
+	}
+	if err := svc.IndexAll(context.Background()); err != nil {
+		t.Fatalf("index: %v", err)
+	}
+	reg := NewRegistry()
+	if err := RegisterGenerateMock(reg, svc); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	return reg, dir
+}
+
+const orderStoreGo = `package billing
+
+import (
+	"context"
+
+	"example.com/shop/money"
+)
+
+type Closer interface {
+	Close() error
+}
+
+// OrderStore persists orders.
+type OrderStore interface {
+	Closer
+	Get(ctx context.Context, id string) (*Order, error)
+	Total(ctx context.Context, ids ...string) money.Amount
+	Reset()
+}
+
+type Order struct{ ID string }
+`
+
+func TestGenerateMock_GoStyles(t *testing.T) {
+	reg, _ := mockRegistry(t, map[string]string{
+		"billing/store.go":         orderStoreGo,
+		"billing/invoice_test.go":  "package billing\n\nimport \"github.com/stretchr/testify/mock\"\n\nvar _ mock.Mock\n",
+		"shipping/carrier_test.go": "package shipping\n\nimport \"github.com/stretchr/testify/mock\"\n",
+	})
+
+	raw, _ := json.Marshal(GenerateMockArgs{Name: "OrderStore"})
+	res, err := reg.InvokeToolCall(context.Background(), &ToolCall{Name: "generate_mock", Args: raw})
+	if err != nil {
+		t.Fatalf("invoke: %v", err)
+	}
+	if !strings.Contains(res.Content, "testify mock of OrderStore") || !strings.Contains(res.Content, "billing/mock_order_store_test.go") {
+		t.Fatalf("unexpected summary: %s", res.Content)
+	}
+	m, ok := TakeGeneratedMock(raw)
+	if !ok {
+		t.Fatalf("expected the proposal to be staged")
+	}
+	if _, again := TakeGeneratedMock(raw); again {
+		t.Fatalf("a staged mock is applied once")
+	}
+	for _, want := range []string{
+		"package billing",
+		`"github.com/stretchr/testify/mock"`,
+		`"example.com/shop/money"`,
+		"type MockOrderStore struct {\n\tmock.Mock\n}",
+		"func (m *MockOrderStore) Close() error {\n\targs := m.Called()\n\treturn args.Error(0)\n}",
+		"\tvar r0 *Order\n\tif v := args.Get(0); v != nil {\n\t\tr0 = v.(*Order)\n\t}\n\treturn r0, args.Error(1)",
+		"func (m *MockOrderStore) Reset() {\n\tm.Called()\n}",
+		"var _ OrderStore = (*MockOrderStore)(nil)",
+	} {
+		if !strings.Contains(m.Content, want) {
+			t.Fatalf("expected %q in:\n%s", want, m.Content)
+		}
+	}
+	if strings.Contains(m.Content, `"context"`) == false {
+		t.Fatalf("expected the context import to be kept:\n%s", m.Content)
+	}
+
+	raw, _ = json.Marshal(GenerateMockArgs{Name: "OrderStore", Style: MockStyleGomock})
+	if res, _ := reg.InvokeToolCall(context.Background(), &ToolCall{Name: "generate_mock", Args: raw}); res.Diff == "" {
+		t.Fatalf("gomock generation failed: %s", res.Content)
+	}
+	m, _ = TakeGeneratedMock(raw)
+	for _, want := range []string{
+		`"go.uber.org/mock/gomock"`,
+		"func NewMockOrderStore(ctrl *gomock.Controller) *MockOrderStore {",
+		"\tvarargs := []any{ctx}\n\tfor _, a := range ids {",
+		"func (mr *MockOrderStoreMockRecorder) Total(ctx any, ids ...any) *gomock.Call {",
+	} {
+		if !strings.Contains(m.Content, want) {
+			t.Fatalf("expected %q in:\n%s", want, m.Content)
+		}
+	}
+
+	raw, _ = json.Marshal(GenerateMockArgs{Name: "OrderStore", Style: MockStyleStub})
+	reg.InvokeToolCall(context.Background(), &ToolCall{Name: "generate_mock", Args: raw})
+	m, _ = TakeGeneratedMock(raw)
+	if !strings.Contains(m.Content, "\tTotalFunc func(ctx context.Context, ids ...string) money.Amount") ||
+		!strings.Contains(m.Content, "return s.TotalFunc(ctx, ids...)") {
+		t.Fatalf("unexpected stub:\n%s", m.Content)
+	}
+
+	raw, _ = json.Marshal(GenerateMockArgs{Name: "OrderStore", Path: "other/mock.go"})
+	if res, _ := reg.InvokeToolCall(context.Background(), &ToolCall{Name: "generate_mock", Args: raw}); !strings.Contains(res.Content, "package of OrderStore") {
+		t.Fatalf("expected Go mocks outside the package to be refused, got %s", res.Content)
+	}
+}
+
+func TestGenerateMock_TypeScriptAndPython(t *testing.T) {
+	reg, _ := mockRegistry(t, map[string]string{
+		"src/payments/gateway.ts": `import { Money } from '../money';
+
+export interface Receipt { id: string }
+
+export interface PaymentGateway {
+  readonly name: string;
+  charge(amount: Money, token: string): Promise<Receipt>;
+  refund: (id: string) => Promise<void>;
+}
+`,
+		"src/payments/gateway.test.ts": "const charge = vi.fn();\n",
+		"app/billing/store.py": `from abc import ABC, abstractmethod
+
+
+class InvoiceStore(ABC):
+    @abstractmethod
+    def save(self, invoice: Invoice) -> str:
+        ...
+
+    @abstractmethod
+    async def load(self,
+                   invoice_id: str) -> Invoice:
+        ...
+
+    def helper(self):
+        return 1
+`,
+	})
+
+	raw, _ := json.Marshal(GenerateMockArgs{Name: "PaymentGateway"})
+	res, _ := reg.InvokeToolCall(context.Background(), &ToolCall{Name: "generate_mock", Args: raw})
+	m, ok := TakeGeneratedMock(raw)
+	if !ok {
+		t.Fatalf("generation failed: %s", res.Content)
+	}
+	if m.Style != MockStyleVitest || m.Path != "src/payments/PaymentGateway.mock.ts" || m.StyleEvidence != "src/payments/gateway.test.ts" {
+		t.Fatalf("unexpected mock: %+v", m)
+	}
+	for _, want := range []string{
+		"import { vi, type Mocked } from 'vitest';",
+		"import type { PaymentGateway } from './gateway';",
+		"    charge: vi.fn(),\n    refund: vi.fn(),\n",
+	} {
+		if !strings.Contains(m.Content, want) {
+			t.Fatalf("expected %q in:\n%s", want, m.Content)
+		}
+	}
+
+	raw, _ = json.Marshal(GenerateMockArgs{Name: "PaymentGateway", Style: MockStyleStub, Path: "test/stubs/gateway.ts"})
+	res, _ = reg.InvokeToolCall(context.Background(), &ToolCall{Name: "generate_mock", Args: raw})
+	m, ok = TakeGeneratedMock(raw)
+	if !ok {
+		t.Fatalf("stub generation failed: %s", res.Content)
+	}
+	for _, want := range []string{
+		"import type { PaymentGateway, Receipt } from '../../src/payments/gateway';",
+		"import { Money } from '../../src/money';",
+		"export class StubPaymentGateway implements PaymentGateway {",
+		"  name!: string;",
+		"  charge(amount: Money, token: string): Promise<Receipt> {\n    throw new Error('StubPaymentGateway.charge is not implemented');",
+	} {
+		if !strings.Contains(m.Content, want) {
+			t.Fatalf("expected %q in:\n%s", want, m.Content)
+		}
+	}
+
+	raw, _ = json.Marshal(GenerateMockArgs{Name: "InvoiceStore"})
+	res, _ = reg.InvokeToolCall(context.Background(), &ToolCall{Name: "generate_mock", Args: raw})
+	m, ok = TakeGeneratedMock(raw)
+	if !ok {
+		t.Fatalf("python generation failed: %s", res.Content)
+	}
+	if m.Style != MockStyleStub || m.Path != "app/billing/mock_invoice_store.py" || m.Members != 2 {
+		t.Fatalf("unexpected mock: %+v", m)
+	}
+	for _, want := range []string{
+		"from app.billing.store import InvoiceStore",
+		"class StubInvoiceStore(InvoiceStore):",
+		"    def save(self, invoice: Invoice) -> str:\n        return None",
+		"    async def load(self, invoice_id: str) -> Invoice:",
+	} {
+		if !strings.Contains(m.Content, want) {
+			t.Fatalf("expected %q in:\n%s", want, m.Content)
+		}
+	}
+}
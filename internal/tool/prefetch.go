@@ -0,0 +1,34 @@
+package tool
+
+import "context"
+
+// Prefetch runs read-only calls the model is likely to make next and stores their
+// results in the per-run cache attached to ctx, without adding anything to the
+// conversation. A later identical call is then answered from the cache. Calls to
+// unknown or state-changing tools, calls already cached, and failed calls are skipped;
+// results of a prefetch that overlaps a state-changing call are discarded.
+func (r *Registry) Prefetch(ctx context.Context, calls []*ToolCall) {
+	cache := resultCacheFrom(ctx)
+	if cache == nil {
+		return
+	}
+	for _, call := range calls {
+		if ctx.Err() != nil {
+			return
+		}
+		r.mu.RLock()
+		def, known := r.tools[call.Name]
+		r.mu.RUnlock()
+		if !known || !def.ReadOnly {
+			continue
+		}
+		key := cacheKey(call.Name, call.Args)
+		cached, gen := cache.lookup(key)
+		if cached {
+			continue
+		}
+		if res, ok := r.execute(ctx, call); ok {
+			cache.putPrefetched(key, res, gen)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestPrefetch_AnswersTheNextCall(t *testing.T) {
+	reg := NewRegistry()
+	calls := 0
+	_ = reg.Register(Definition{
+		Name:     "lookup",
+		Safe:     true,
+		ReadOnly: true,
+		Handler: func(ctx context.Context, raw json.RawMessage) (interface{}, error) {
+			calls++
+			return "value", nil
+		},
+	})
+	_ = reg.Register(Definition{
+		Name: "mutate",
+		Safe: true,
+		Handler: func(ctx context.Context, raw json.RawMessage) (interface{}, error) {
+			calls++
+			return "done", nil
+		},
+	})
+
+	cache := NewResultCache()
+	ctx := WithResultCache(context.Background(), cache)
+	reg.Prefetch(ctx, []*ToolCall{
+		{Name: "lookup", Args: json.RawMessage(`{"a":1}`)},
+		{Name: "mutate"}, // never run speculatively
+		{Name: "missing"},
+	})
+	if calls != 1 {
+		t.Fatalf("expected only the read-only call to run, ran %d", calls)
+	}
+
+	first, _ := reg.InvokeToolCall(ctx, &ToolCall{Name: "lookup", Args: json.RawMessage(`{ "a": 1 }`)})
+	if calls != 1 || first.Cached || first.Content != "value" {
+		t.Fatalf("prefetched result should answer the first call as fresh: calls=%d %+v", calls, first)
+	}
+	second, _ := reg.InvokeToolCall(ctx, &ToolCall{Name: "lookup", Args: json.RawMessage(`{"a":1}`)})
+	if !second.Cached || !strings.HasPrefix(second.Content, cachedMarker) {
+		t.Fatalf("repeated call should be marked cached: %+v", second)
+	}
+	if cache.PrefetchHits() != 1 || cache.Hits() != 1 {
+		t.Errorf("unexpected hit counts: prefetch=%d cache=%d", cache.PrefetchHits(), cache.Hits())
+	}
+
+	// Prefetching without a cache does nothing
+	reg.Prefetch(context.Background(), []*ToolCall{{Name: "lookup", Args: json.RawMessage(`{"a":2}`)}})
+	if calls != 1 {
+		t.Errorf("prefetch without a cache should not run tools, ran %d", calls)
+	}
+}
+
+func TestResultCache_DropsStalePrefetch(t *testing.T) {
+	cache := NewResultCache()
+	_, gen := cache.lookup("k")
+	// A state-changing call lands while the prefetch is running
+	cache.Invalidate()
+	cache.putPrefetched("k", &ExecutionResult{Content: "stale"}, gen)
+	if _, _, ok := cache.get("k"); ok {
+		t.Fatal("prefetch started before an invalidation must be discarded")
+	}
+
+	_, gen = cache.lookup("k")
+	cache.put("k", &ExecutionResult{Content: "real"})
+	cache.putPrefetched("k", &ExecutionResult{Content: "prefetched"}, gen)
+	if res, prefetched, _ := cache.get("k"); res.Content != "real" || prefetched {
+		t.Errorf("prefetch must not replace a real result: %+v %v", res, prefetched)
+	}
+}
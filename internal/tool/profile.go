@@ -351,8 +351,9 @@ func RegisterProjectProfileTools(registry *Registry, workspaceRoot string) error
 				},
 			},
 		},
-		Safe:    true,
-		Handler: tool.GetProjectProfile,
+		Safe:     true,
+		ReadOnly: true,
+		Handler:  tool.GetProjectProfile,
 	})
 	if err != nil {
 		return err
@@ -373,8 +374,9 @@ func RegisterProjectProfileTools(registry *Registry, workspaceRoot string) error
 				},
 			},
 		},
-		Safe:    true,
-		Handler: tool.GetHotlist,
+		Safe:     true,
+		ReadOnly: true,
+		Handler:  tool.GetHotlist,
 	})
 	if err != nil {
 		return err
@@ -394,8 +396,45 @@ func RegisterProjectProfileTools(registry *Registry, workspaceRoot string) error
 			},
 			"required": []string{"path"},
 		},
-		Safe:    true,
-		Handler: tool.ExplainFileImportance,
+		Safe:     true,
+		ReadOnly: true,
+		Handler:  tool.ExplainFileImportance,
+	})
+	if err != nil {
+		return err
+	}
+
+	// project_docs tool
+	err = registry.Register(Definition{
+		Name:        "project_docs",
+		Description: "Get build instructions, configuration snippets and runnable examples extracted from the README and docs/. Prefer this over reading README.md.",
+		JSONSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"kind": map[string]interface{}{
+					"type":        "string",
+					"description": "Only snippets of this kind",
+					"enum":        []string{"build", "config", "example"},
+				},
+				"query": map[string]interface{}{
+					"type":        "string",
+					"description": "Words that must all appear in the snippet, its heading or its file path",
+				},
+				"path": map[string]interface{}{
+					"type":        "string",
+					"description": "Only snippets from this documentation file (workspace-relative)",
+				},
+				"limit": map[string]interface{}{
+					"type":        "integer",
+					"description": "Maximum snippets to return (default 10)",
+					"minimum":     1,
+					"maximum":     maxProjectDocsLimit,
+				},
+			},
+		},
+		Safe:     true,
+		ReadOnly: true,
+		Handler:  tool.ProjectDocs,
 	})
 	if err != nil {
 		return err
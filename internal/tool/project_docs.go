@@ -0,0 +1,93 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/loom/loom/internal/profiler"
+	"github.com/loom/loom/internal/profiler/shared"
+)
+
+const (
+	defaultProjectDocsLimit = 10
+	maxProjectDocsLimit     = 50
+)
+
+// ProjectDocsArgs represents arguments for the project_docs tool
+type ProjectDocsArgs struct {
+	Kind  string `json:"kind,omitempty"`  // "build", "config" or "example"
+	Query string `json:"query,omitempty"` // words matched against section, path and content
+	Path  string `json:"path,omitempty"`  // only snippets from this documentation file
+	Limit int    `json:"limit,omitempty"`
+}
+
+// ProjectDocs returns the code snippets extracted from the README and docs during
+// profiling, filtered by kind, file and query words.
+func (t *ProjectProfileTool) ProjectDocs(ctx context.Context, raw json.RawMessage) (interface{}, error) {
+	var args ProjectDocsArgs
+	if len(raw) > 0 {
+		if err := json.Unmarshal(raw, &args); err != nil {
+			return nil, fmt.Errorf("failed to parse args: %w", err)
+		}
+	}
+
+	loader := &profiler.FileSystemProfileLoader{}
+	profile, err := loader.LoadProfile(t.workspaceRoot)
+	if err != nil {
+		return map[string]interface{}{
+			"mode":  "none",
+			"error": "No project profile available. Run profiling first.",
+		}, nil
+	}
+
+	matches := filterDocSnippets(profile.DocSnippets, args)
+	limit := args.Limit
+	if limit <= 0 {
+		limit = defaultProjectDocsLimit
+	}
+	limit = min(limit, maxProjectDocsLimit)
+	total := len(matches)
+	if len(matches) > limit {
+		matches = matches[:limit]
+	}
+
+	result := map[string]interface{}{
+		"snippets": matches,
+		"total":    total,
+	}
+	if total == 0 {
+		result["note"] = "No matching snippets. Omit filters to list everything extracted from the README and docs."
+	}
+	return result, nil
+}
+
+// filterDocSnippets keeps the snippets matching every given filter. Query words must all
+// appear (case-insensitively) in the snippet's section, path or content.
+func filterDocSnippets(snippets []shared.DocSnippet, args ProjectDocsArgs) []shared.DocSnippet {
+	kind := strings.ToLower(strings.TrimSpace(args.Kind))
+	path := shared.NormalizePath(strings.TrimSpace(args.Path))
+	words := strings.Fields(strings.ToLower(args.Query))
+	out := make([]shared.DocSnippet, 0)
+	for _, s := range snippets {
+		if kind != "" && s.Kind != kind {
+			continue
+		}
+		if args.Path != "" && s.Path != path {
+			continue
+		}
+		haystack := strings.ToLower(s.Section + "\n" + s.Path + "\n" + s.Content)
+		ok := true
+		for _, w := range words {
+			if !strings.Contains(haystack, w) {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			out = append(out, s)
+		}
+	}
+	return out
+}
@@ -0,0 +1,28 @@
+package tool
+
+import (
+	"testing"
+
+	"github.com/loom/loom/internal/profiler/shared"
+)
+
+func TestFilterDocSnippets(t *testing.T) {
+	snippets := []shared.DocSnippet{
+		{Kind: "build", Path: "README.md", Section: "Installation", Content: "make build"},
+		{Kind: "config", Path: "docs/config.md", Section: "Database", Content: "db:\n  host: localhost"},
+		{Kind: "example", Path: "README.md", Section: "Usage", Content: "loom --help"},
+	}
+
+	if got := filterDocSnippets(snippets, ProjectDocsArgs{Kind: "Build"}); len(got) != 1 || got[0].Content != "make build" {
+		t.Fatalf("kind filter: %+v", got)
+	}
+	if got := filterDocSnippets(snippets, ProjectDocsArgs{Query: "database HOST"}); len(got) != 1 || got[0].Kind != "config" {
+		t.Fatalf("query filter: %+v", got)
+	}
+	if got := filterDocSnippets(snippets, ProjectDocsArgs{Path: "./README.md"}); len(got) != 2 {
+		t.Fatalf("path filter: %+v", got)
+	}
+	if got := filterDocSnippets(snippets, ProjectDocsArgs{Kind: "example", Query: "make"}); len(got) != 0 {
+		t.Fatalf("combined filters: %+v", got)
+	}
+}
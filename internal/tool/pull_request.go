@@ -0,0 +1,58 @@
+package tool
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// PullRequestResult reports where a description was published.
+type PullRequestResult struct {
+	URL string `json:"url"`
+	// Updated is true when an existing pull request's body was replaced
+	Updated bool `json:"updated"`
+}
+
+// PublishPullRequest sets body as the description of the current branch's pull
+// request using the GitHub CLI, creating the pull request when the branch has none.
+// The branch must already be pushed.
+func PublishPullRequest(ctx context.Context, workspace, title, body string, draft bool) (*PullRequestResult, error) {
+	gh, err := exec.LookPath("gh")
+	if err != nil {
+		return nil, errors.New("the GitHub CLI (gh) is not installed; export the description instead")
+	}
+	if out, err := runGh(ctx, gh, workspace, "", "pr", "view", "--json", "url", "--jq", ".url"); err == nil {
+		url := strings.TrimSpace(out)
+		if out, err := runGh(ctx, gh, workspace, body, "pr", "edit", "--body-file", "-"); err != nil {
+			return nil, fmt.Errorf("gh pr edit failed: %s", tail(out, 500))
+		}
+		return &PullRequestResult{URL: url, Updated: true}, nil
+	}
+	args := []string{"pr", "create", "--title", title, "--body-file", "-"}
+	if draft {
+		args = append(args, "--draft")
+	}
+	out, err := runGh(ctx, gh, workspace, body, args...)
+	if err != nil {
+		return nil, fmt.Errorf("gh pr create failed: %s", tail(out, 500))
+	}
+	// gh prints the new pull request's URL last
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	return &PullRequestResult{URL: strings.TrimSpace(lines[len(lines)-1])}, nil
+}
+
+func runGh(ctx context.Context, gh, dir, stdin string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, gh, args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GH_PROMPT_DISABLED=1", "NO_COLOR=1")
+	cmd.Stdin = strings.NewReader(stdin)
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+	err := cmd.Run()
+	return out.String(), err
+}
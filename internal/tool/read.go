@@ -9,6 +9,7 @@ import (
 	"path/filepath"
 	"strings"
 
+	"github.com/loom/loom/internal/editor"
 	"github.com/loom/loom/internal/symbols"
 )
 
@@ -19,6 +20,8 @@ type ReadFileArgs struct {
 	Limit  int    `json:"limit,omitempty"`
 	// IncludeLineNumbers controls whether to add line numbers to each returned line. Defaults to true.
 	IncludeLineNumbers *bool `json:"include_line_numbers,omitempty"`
+	// Full disables the distilled summary for known manifests (package.json, go.mod, ...).
+	Full bool `json:"full,omitempty"`
 }
 
 // ReadFileResult represents the result of the read_file tool.
@@ -30,6 +33,13 @@ type ReadFileResult struct {
 	// A brief summary of symbols found in this file (first 20 max), plus a hint about symbol tools
 	SymbolsSummary string           `json:"symbols_summary,omitempty"`
 	Symbols        []SymbolListItem `json:"symbols,omitempty"`
+	// Summary replaces Content for known manifests unless full content was requested
+	Summary *ManifestSummary `json:"summary,omitempty"`
+	// Frontmatter holds the leading YAML metadata block of markdown files
+	Frontmatter map[string]string `json:"frontmatter,omitempty"`
+	// Encoding describes files that are not plain UTF-8 with LF endings (e.g. "utf-16le, BOM, CRLF").
+	// Content is always UTF-8 with LF endings; edits are written back in the original format.
+	Encoding string `json:"encoding,omitempty"`
 }
 
 // SymbolListItem is a compact representation of a symbol for embedding alongside read_file content
@@ -59,8 +69,9 @@ func flattenOutline(nodes []symbols.OutlineNode) []SymbolListItem {
 func RegisterReadFile(registry *Registry, workspacePath string) error {
 	return registry.Register(Definition{
 		Name:        "read_file",
-		Description: "Reads the content of a file in the workspace",
+		Description: "Reads the content of a file in the workspace, or a file the user attached (paths starting with @attachments/, read-only). Known manifests (package.json, go.mod, Cargo.toml, docker-compose.yml) return a structured summary unless full=true.",
 		Safe:        true, // Reading files is a safe operation
+		ReadOnly:    true,
 		JSONSchema: map[string]interface{}{
 			"type": "object",
 			"properties": map[string]interface{}{
@@ -80,6 +91,10 @@ func RegisterReadFile(registry *Registry, workspacePath string) error {
 					"type":        "boolean",
 					"description": "Whether to prefix line numbers to each line in the response (default true)",
 				},
+				"full": map[string]interface{}{
+					"type":        "boolean",
+					"description": "Return raw content for manifests (package.json, go.mod, Cargo.toml, docker-compose.yml) instead of the default distilled summary",
+				},
 			},
 			"required": []string{"path"},
 		},
@@ -98,16 +113,15 @@ func RegisterReadFile(registry *Registry, workspacePath string) error {
 func readFile(ctx context.Context, workspacePath string, args ReadFileArgs) (*ReadFileResult, error) {
 	// Normalize and validate the path
 	path := args.Path
-	if !filepath.IsAbs(path) {
-		path = filepath.Join(workspacePath, path)
+	attachment, isAttachment, err := resolveAttachmentPath(ctx, path)
+	if err != nil {
+		return nil, err
 	}
-
-	// Clean the path to remove ../ and ./ segments
-	path = filepath.Clean(path)
-
-	// Ensure the path is within the workspace
-	if !strings.HasPrefix(path, workspacePath) {
-		return nil, errors.New("file path must be within the workspace")
+	if isAttachment {
+		// Attachments live outside the workspace and are read-only
+		path = attachment
+	} else if path, err = validatePath(workspacePath, path); err != nil {
+		return nil, err
 	}
 
 	// Check if the file exists
@@ -130,12 +144,40 @@ func readFile(ctx context.Context, workspacePath string, args ReadFileArgs) (*Re
 		return nil, fmt.Errorf("failed to read file: %w", err)
 	}
 
-	// Convert content to string
-	contentStr := string(content)
+	format := editor.DetectFormat(content)
+	if isAttachment && format.Encoding == editor.EncodingUTF8 && looksBinary(content[:min(len(content), 8000)]) {
+		return nil, fmt.Errorf("%s is a binary file and cannot be read as text", args.Path)
+	}
+
+	// Convert content to UTF-8 text with LF endings
+	contentStr := format.Decode(content)
+	var encoding string
+	if !format.IsDefault() {
+		encoding = format.String()
+	}
 
 	// Count lines
 	lines := strings.Count(contentStr, "\n") + 1
 
+	// Known manifests are mostly boilerplate; return the distilled view unless asked otherwise
+	if !args.Full && args.Offset == 0 && args.Limit == 0 {
+		if summary := summarizeManifest(path, contentStr); summary != nil {
+			return &ReadFileResult{
+				Content:  fmt.Sprintf("Distilled %s summary (%d lines). Call read_file with full=true for the raw content.", summary.Format, lines),
+				Language: detectLanguage(path),
+				Lines:    lines,
+				Path:     args.Path,
+				Summary:  summary,
+				Encoding: encoding,
+			}, nil
+		}
+	}
+
+	var frontmatter map[string]string
+	if ext := strings.ToLower(filepath.Ext(path)); ext == ".md" || ext == ".mdx" || ext == ".markdown" {
+		frontmatter = parseFrontmatter(contentStr)
+	}
+
 	// Apply offset and limit if specified
 	startLineForNumbering := 1
 	if args.Offset > 0 || args.Limit > 0 {
@@ -209,6 +251,8 @@ func readFile(ctx context.Context, workspacePath string, args ReadFileArgs) (*Re
 		Path:           args.Path,
 		SymbolsSummary: symSummary,
 		Symbols:        symItems,
+		Frontmatter:    frontmatter,
+		Encoding:       encoding,
 	}, nil
 }
 
@@ -66,3 +66,87 @@ func TestReadFile_OffsetLimitAndRaw(t *testing.T) {
 		t.Fatalf("unexpected slice content: %q", r.Content)
 	}
 }
+
+func TestReadFile_ManifestSummaryAndFull(t *testing.T) {
+	workspace := t.TempDir()
+	pkg := `{"name":"web","version":"1.2.0","scripts":{"dev":"vite"},"dependencies":{"react":"^18.0.0"},"devDependencies":{"vite":"^5.0.0"}}`
+	if err := os.WriteFile(filepath.Join(workspace, "package.json"), []byte(pkg), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	r, err := readFile(context.Background(), workspace, ReadFileArgs{Path: "package.json"})
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if r.Summary == nil || r.Summary.Name != "web" || r.Summary.Scripts["dev"] != "vite" {
+		t.Fatalf("expected package.json summary, got %+v", r.Summary)
+	}
+	if len(r.Summary.Dependencies) != 1 || r.Summary.Dependencies[0] != "react@^18.0.0" {
+		t.Fatalf("unexpected dependencies: %v", r.Summary.Dependencies)
+	}
+
+	r, err = readFile(context.Background(), workspace, ReadFileArgs{Path: "package.json", Full: true})
+	if err != nil {
+		t.Fatalf("read full: %v", err)
+	}
+	if r.Summary != nil || !strings.Contains(r.Content, `"react"`) {
+		t.Fatalf("expected raw content with full=true, got %q", r.Content)
+	}
+}
+
+func TestSummarizeManifest_GoModCargoCompose(t *testing.T) {
+	gomod := "module example.com/app\n\ngo 1.22\n\nrequire (\n\tgithub.com/a/b v1.0.0\n\tgithub.com/c/d v0.2.0 // indirect\n)\n"
+	s := summarizeManifest("go.mod", gomod)
+	if s == nil || s.Name != "example.com/app" || s.Toolchain != "go 1.22" || len(s.Dependencies) != 1 || s.Dependencies[0] != "github.com/a/b@v1.0.0" {
+		t.Fatalf("unexpected go.mod summary: %+v", s)
+	}
+
+	cargo := "[package]\nname = \"tool\"\nversion = \"0.1.0\"\n\n[dependencies]\nserde = { version = \"1.0\", features = [\"derive\"] }\nanyhow = \"1\"\n"
+	s = summarizeManifest("Cargo.toml", cargo)
+	if s == nil || s.Name != "tool" || strings.Join(s.Dependencies, ",") != "serde@1.0,anyhow@1" {
+		t.Fatalf("unexpected Cargo.toml summary: %+v", s)
+	}
+
+	compose := "version: '3'\nservices:\n  db:\n    image: postgres:16\n    ports:\n      - \"5432:5432\"\n  api:\n    build: .\n    depends_on:\n      - db\n"
+	s = summarizeManifest("docker-compose.yml", compose)
+	if s == nil || len(s.Services) != 2 {
+		t.Fatalf("unexpected compose summary: %+v", s)
+	}
+	if s.Services[0].Image != "postgres:16" || s.Services[0].Ports[0] != "5432:5432" || s.Services[1].DependsOn[0] != "db" {
+		t.Fatalf("unexpected services: %+v", s.Services)
+	}
+}
+
+func TestReadFile_MarkdownFrontmatter(t *testing.T) {
+	workspace := t.TempDir()
+	md := "---\ntitle: Guide\ntags:\n  - a\n---\n# Body\n"
+	if err := os.WriteFile(filepath.Join(workspace, "doc.md"), []byte(md), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	r, err := readFile(context.Background(), workspace, ReadFileArgs{Path: "doc.md"})
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if r.Frontmatter["title"] != "Guide" || !strings.Contains(r.Content, "# Body") {
+		t.Fatalf("unexpected frontmatter/content: %+v %q", r.Frontmatter, r.Content)
+	}
+}
+
+func TestReadFile_DecodesLatin1AndCRLF(t *testing.T) {
+	workspace := t.TempDir()
+	// "café" in Latin-1 with CRLF endings
+	if err := os.WriteFile(filepath.Join(workspace, "legacy.txt"), []byte("caf\xe9\r\nok\r\n"), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	res, err := readFile(context.Background(), workspace, ReadFileArgs{Path: "legacy.txt"})
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if !strings.HasPrefix(res.Content, "L1: café\nL2: ok") {
+		t.Fatalf("content not decoded: %q", res.Content)
+	}
+	if res.Encoding != "latin-1, CRLF" {
+		t.Fatalf("unexpected encoding: %q", res.Encoding)
+	}
+}
@@ -0,0 +1,41 @@
+package tool
+
+import "fmt"
+
+// readOnlyAllowedTools may run in a read-only workspace although they are not
+// read-only: they only keep session state.
+var readOnlyAllowedTools = map[string]bool{
+	"todo_list":     true,
+	"user_choice":   true,
+	"ask_user":      true,
+	"annotate_code": true,
+	"checkpoint":    true, // reverts are refused by the engine
+	"finalize":      true,
+}
+
+// SetReadOnly blocks every tool that could change the workspace, including ones that
+// only propose changes, while reason is non-empty; the reason is shown to the model.
+// An empty reason lifts the restriction.
+func (r *Registry) SetReadOnly(reason string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.readOnly = reason
+}
+
+// ReadOnlyReason returns why the workspace is read-only, or "" when it is writable.
+func (r *Registry) ReadOnlyReason() string {
+	if r == nil {
+		return ""
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.readOnly
+}
+
+// readOnlyBlockedResult stands in for a tool call skipped in a read-only workspace.
+func readOnlyBlockedResult(name, reason string) *ExecutionResult {
+	return &ExecutionResult{
+		Content: fmt.Sprintf("[read-only] %s was not executed: %s. Only read-only tools are available.", name, reason),
+		Safe:    true,
+	}
+}
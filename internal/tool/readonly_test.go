@@ -0,0 +1,52 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestRegistryReadOnly_BlocksProposals(t *testing.T) {
+	reg := NewRegistry()
+	calls := map[string]int{}
+	for _, def := range []Definition{
+		{Name: "read_file", ReadOnly: true, Safe: true},
+		{Name: "edit_file"},
+		{Name: "todo_list"},
+	} {
+		name := def.Name
+		def.JSONSchema = map[string]interface{}{"type": "object"}
+		def.Handler = func(ctx context.Context, raw json.RawMessage) (interface{}, error) {
+			calls[name]++
+			return "ran", nil
+		}
+		if err := reg.Register(def); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	reg.SetReadOnly("another Loom instance owns this workspace")
+	for _, name := range []string{"read_file", "edit_file", "todo_list"} {
+		res, err := reg.Invoke(context.Background(), name, json.RawMessage(`{}`))
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		blocked, ok := res.(*ExecutionResult)
+		if name == "edit_file" {
+			if !ok || !strings.Contains(blocked.Content, "another Loom instance") {
+				t.Fatalf("expected edit_file to be blocked, got %#v", res)
+			}
+		} else if ok {
+			t.Fatalf("expected %s to run, got %#v", name, res)
+		}
+	}
+	if calls["read_file"] != 1 || calls["todo_list"] != 1 || calls["edit_file"] != 0 {
+		t.Fatalf("unexpected handler calls: %v", calls)
+	}
+
+	reg.SetReadOnly("")
+	if _, err := reg.Invoke(context.Background(), "edit_file", json.RawMessage(`{}`)); err != nil || calls["edit_file"] != 1 {
+		t.Fatalf("expected edit_file to run once writable (err=%v, calls=%v)", err, calls)
+	}
+}
@@ -5,7 +5,11 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"path/filepath"
+	"strings"
 	"sync"
+
+	"github.com/loom/loom/internal/editor"
 )
 
 // Schema represents the schema for a tool as exposed to the LLM.
@@ -22,8 +26,17 @@ type Definition struct {
 	Description string
 	JSONSchema  map[string]interface{}
 	Safe        bool // true = no user confirmation required
+	ReadOnly    bool // true = no side effects; identical calls may be served from the per-run cache
+	AlwaysAsk   bool // true = irreversible; approval policies never auto-approve it
 	Handler     func(ctx context.Context, raw json.RawMessage) (interface{}, error)
 	Schema      Schema // Pre-computed schema for LLM
+	// Version is the current argument schema version; 0 means 1 (see versions.go)
+	Version int
+	// Adapters upgrade arguments of older versions, keyed by the version they read
+	Adapters map[int]ArgsAdapter
+	// Usage and Examples (JSON arguments) are returned by tool_help, not sent with every request
+	Usage    string
+	Examples []string
 }
 
 // Registry manages the available tools.
@@ -32,6 +45,21 @@ type Registry struct {
 	mu    sync.RWMutex
 	// Optional UI bridge for emitting human-readable activity messages
 	ui engineUIBridge
+	// Execution limits enforced on every invocation (see limits.go)
+	defaultLimits Limits
+	limits        map[string]Limits
+	slots         map[string]chan struct{}
+	// demo disables every tool with side effects (see demo.go)
+	demo bool
+	// readOnly, when set, explains why tools changing the workspace are blocked (see readonly.go)
+	readOnly string
+	// maxEditLines rejects larger edit_file proposals (see edit_size.go)
+	maxEditLines int
+	// pathHook is told about workspace paths touched by successful tool calls
+	pathHook func(rel string)
+	// roots are the registries of other open workspace roots by name (see workspaces.go)
+	roots       map[string]*Registry
+	primaryRoot string
 }
 
 // Minimal interface for emitting UI messages without importing engine package to avoid cyclic deps
@@ -54,6 +82,44 @@ func (r *Registry) WithUI(ui engineUIBridge) *Registry {
 	return r
 }
 
+type activityUIKey struct{}
+
+// WithActivityUI sends the activity messages of tool calls invoked with ctx to ui
+// instead of the registry's UI, so conversations sharing a registry each show their own.
+func WithActivityUI(ctx context.Context, ui engineUIBridge) context.Context {
+	if ui == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, activityUIKey{}, ui)
+}
+
+// SetPathHook installs a callback for the workspace-relative paths that file tools
+// touch; sparse symbol indexes use it to expand on demand.
+func (r *Registry) SetPathHook(fn func(rel string)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.pathHook = fn
+}
+
+// notifyPath passes the path argument of a file tool call to the path hook.
+func (r *Registry) notifyPath(call *ToolCall) {
+	r.mu.RLock()
+	hook := r.pathHook
+	r.mu.RUnlock()
+	if hook == nil {
+		return
+	}
+	var args struct {
+		Path      string `json:"path"`
+		Workspace string `json:"workspace"`
+	}
+	if json.Unmarshal(call.Args, &args) != nil || args.Path == "" || filepath.IsAbs(args.Path) ||
+		strings.HasPrefix(args.Path, AttachmentPrefix) || (args.Workspace != "" && args.Workspace != r.primaryRootName()) {
+		return
+	}
+	hook(filepath.ToSlash(filepath.Clean(args.Path)))
+}
+
 // Register adds a tool to the registry.
 func (r *Registry) Register(def Definition) error {
 	r.mu.Lock()
@@ -90,6 +156,13 @@ func (r *Registry) Get(name string) (Definition, bool) {
 	return def, ok
 }
 
+// AlwaysAsks reports whether calls to the named tool must always be approved by the user.
+func (r *Registry) AlwaysAsks(name string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.tools[name].AlwaysAsk
+}
+
 // GetHandler retrieves a tool handler function by name.
 func (r *Registry) GetHandler(name string) func(ctx context.Context, raw json.RawMessage) (interface{}, error) {
 	r.mu.RLock()
@@ -119,8 +192,13 @@ func (r *Registry) Schemas() []Schema {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
+	names := r.rootNamesLocked()
 	schemas := make([]Schema, 0, len(r.tools))
 	for _, t := range r.tools {
+		if names != nil {
+			schemas = append(schemas, withWorkspaceArg(t.Schema, r.primaryRoot, names))
+			continue
+		}
 		schemas = append(schemas, t.Schema)
 	}
 	return schemas
@@ -131,6 +209,9 @@ type ExecutionResult struct {
 	Content string `json:"content"` // The content to return to the LLM
 	Diff    string `json:"diff"`    // Diff representation for approvals
 	Safe    bool   `json:"safe"`    // Whether this execution is safe
+	Cached  bool   `json:"cached,omitempty"`
+	// Changes annotates edited lines (renames, literal edits, ...) for UI rendering
+	Changes []editor.LineDiffEntry `json:"changes,omitempty"`
 }
 
 // ToolCall represents a request to invoke a tool
@@ -138,6 +219,8 @@ type ToolCall struct {
 	ID   string          `json:"id"`
 	Name string          `json:"name"`
 	Args json.RawMessage `json:"args"`
+	// Version is the schema version Args were written for; 0 means the current one
+	Version int `json:"version,omitempty"`
 }
 
 // Invoke executes a tool by name with the given arguments.
@@ -149,21 +232,43 @@ func (r *Registry) Invoke(ctx context.Context, name string, args json.RawMessage
 	if !ok {
 		return nil, fmt.Errorf("unknown tool %q", name)
 	}
+	if r.DemoMode() && !def.ReadOnly && !demoAllowedTools[name] {
+		return demoBlockedResult(name), nil
+	}
+	if reason := r.ReadOnlyReason(); reason != "" && !def.ReadOnly && !readOnlyAllowedTools[name] {
+		return readOnlyBlockedResult(name, reason), nil
+	}
 
 	// Default empty args to an empty JSON object for tools that accept optional params
 	if len(args) == 0 {
 		args = json.RawMessage([]byte("{}"))
 	}
 
-	return def.Handler(ctx, args)
+	// Calls qualified with another workspace root run in that root's registry
+	root, args, err := r.rootFor(name, args)
+	if err != nil {
+		return nil, err
+	}
+	if root != nil {
+		return root.Invoke(ctx, name, args)
+	}
+	return r.invokeLimited(ctx, def, args)
 }
 
 // InvokeToolCall executes a tool call and returns a structured result.
 func (r *Registry) InvokeToolCall(ctx context.Context, call *ToolCall) (*ExecutionResult, error) {
+	// Calls recorded against an older schema run through the tool's adapters
+	if err := r.Upgrade(call); err != nil {
+		return &ExecutionResult{Content: fmt.Sprintf("Error: %v", err), Safe: true}, nil
+	}
+
 	// Emit an informational message to the UI about the upcoming tool action
 	r.mu.RLock()
 	ui := r.ui
 	r.mu.RUnlock()
+	if u, ok := ctx.Value(activityUIKey{}).(engineUIBridge); ok {
+		ui = u
+	}
 	if ui != nil {
 		// DEAD SIMPLE: Always show action message
 		var args map[string]interface{}
@@ -186,6 +291,12 @@ func (r *Registry) InvokeToolCall(ctx context.Context, call *ToolCall) (*Executi
 			} else {
 				ui.SendChat("system", "LISTING .")
 			}
+		case "summarize_tree":
+			if path, ok := args["path"].(string); ok && path != "" {
+				ui.SendChat("system", fmt.Sprintf("SUMMARIZING %s", path))
+			} else {
+				ui.SendChat("system", "SUMMARIZING .")
+			}
 		case "search_code":
 			if query, ok := args["query"].(string); ok && query != "" {
 				ui.SendChat("system", fmt.Sprintf("SEARCHING %q", query))
@@ -217,18 +328,68 @@ func (r *Registry) InvokeToolCall(ctx context.Context, call *ToolCall) (*Executi
 		}
 	}
 
+	// Serve repeated read-only calls from the per-run cache; anything else may change state
+	r.mu.RLock()
+	callDef, known := r.tools[call.Name]
+	r.mu.RUnlock()
+	cache := resultCacheFrom(ctx)
+	var key string
+	if cache != nil && known {
+		if callDef.ReadOnly {
+			key = cacheKey(call.Name, call.Args)
+			if hit, prefetched, ok := cache.get(key); ok {
+				// A prefetched result answers the first real call as if it had just run
+				if prefetched {
+					res := *hit
+					return &res, nil
+				}
+				return &ExecutionResult{
+					Content: cachedMarker + hit.Content,
+					Diff:    hit.Diff,
+					Safe:    hit.Safe,
+					Cached:  true,
+				}, nil
+			}
+		} else {
+			cache.Invalidate()
+		}
+	}
+
+	execResult, ok := r.execute(ctx, call)
+	if !ok {
+		return execResult, nil
+	}
+	r.notifyPath(call)
+	if key != "" {
+		cache.put(key, execResult)
+	}
+	return execResult, nil
+}
+
+// execute runs a call and converts its result for the model, truncated to the tool's
+// output limit. ok is false when the tool failed and the result carries the error.
+func (r *Registry) execute(ctx context.Context, call *ToolCall) (*ExecutionResult, bool) {
 	result, err := r.Invoke(ctx, call.Name, call.Args)
 	if err != nil {
 		return &ExecutionResult{
 			Content: fmt.Sprintf("Error: %v", err),
 			Diff:    "",
 			Safe:    true, // Errors are safe to show
-		}, nil
+		}, false
 	}
 
+	maxOutput := r.LimitsFor(call.Name).MaxOutputBytes
+
 	// Convert result to string if not already an ExecutionResult
 	if execResult, ok := result.(*ExecutionResult); ok {
-		return execResult, nil
+		execResult.Content = truncateOutput(execResult.Content, maxOutput)
+		return execResult, true
+	}
+
+	// Shell-like results shrink their own output so the JSON stays valid and the
+	// errors survive; the byte cut below remains as a backstop
+	if c, ok := result.(outputCondenser); ok {
+		fitOutput(c, maxOutput)
 	}
 
 	// Convert to string representation
@@ -250,9 +411,10 @@ func (r *Registry) InvokeToolCall(ctx context.Context, call *ToolCall) (*Executi
 
 	safe := ok && def.Safe
 
-	return &ExecutionResult{
-		Content: content,
+	execResult := &ExecutionResult{
+		Content: truncateOutput(content, maxOutput),
 		Diff:    "", // No diff for regular tools
 		Safe:    safe,
-	}, nil
+	}
+	return execResult, true
 }
@@ -0,0 +1,97 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// ReproduceArgs describes a reproduce call.
+type ReproduceArgs struct {
+	// Action is "confirm" (the command must fail before the fix) or "verify" (it must
+	// pass after the fix)
+	Action string `json:"action"`
+	// Command runs the reproduction from the workspace root; verify reuses the
+	// confirmed command when empty
+	Command string `json:"command,omitempty"`
+	// Artifacts are the workspace-relative files written for the reproduction
+	Artifacts []string `json:"artifacts,omitempty"`
+}
+
+// ParseReproduceArgs validates reproduce arguments and cleans artifact paths.
+func ParseReproduceArgs(raw json.RawMessage) (ReproduceArgs, error) {
+	var args ReproduceArgs
+	if err := json.Unmarshal(raw, &args); err != nil {
+		return args, fmt.Errorf("failed to parse arguments: %w", err)
+	}
+	args.Action = strings.ToLower(strings.TrimSpace(args.Action))
+	args.Command = strings.TrimSpace(args.Command)
+	switch args.Action {
+	case "confirm":
+		if args.Command == "" {
+			return args, errors.New("command is required to confirm a reproduction")
+		}
+	case "verify":
+	default:
+		return args, fmt.Errorf("unknown action %q (use confirm or verify)", args.Action)
+	}
+	var artifacts []string
+	for _, a := range args.Artifacts {
+		a = filepath.ToSlash(filepath.Clean(strings.TrimSpace(a)))
+		if a == "." || a == "" {
+			continue
+		}
+		if filepath.IsAbs(a) || strings.HasPrefix(a, "../") {
+			return args, fmt.Errorf("artifact %q must be a workspace-relative path", a)
+		}
+		artifacts = append(artifacts, a)
+	}
+	args.Artifacts = artifacts
+	return args, nil
+}
+
+// RegisterReproduce registers the reproduce tool. The tool only validates its
+// arguments; the engine runs the command (after shell approval) and tracks the
+// reproduction with the conversation.
+func RegisterReproduce(registry *Registry) error {
+	return registry.Register(Definition{
+		Name:        "reproduce",
+		Description: "Confirm and verify a bug reproduction. Before fixing a bug, write a minimal failing test or script, then call action 'confirm' with the command that runs it: it must fail. After the fix call action 'verify' (or just finalize): the same command must now pass. Keep the reproduction files; they are re-run as verification.",
+		JSONSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"action": map[string]interface{}{
+					"type":        "string",
+					"enum":        []string{"confirm", "verify"},
+					"description": "confirm: the command must fail, demonstrating the bug. verify: the command must pass after the fix",
+				},
+				"command": map[string]interface{}{
+					"type":        "string",
+					"description": "Shell command running the reproduction from the workspace root, e.g. `go test ./parser -run TestEmptyInput`. Optional for verify",
+				},
+				"artifacts": map[string]interface{}{
+					"type":        "array",
+					"items":       map[string]interface{}{"type": "string"},
+					"description": "Workspace-relative files written for the reproduction (tests, scripts, fixtures)",
+				},
+			},
+			"required": []string{"action"},
+		},
+		Usage: "Use when asked to fix a bug: reproduce first, fix second, verify last.",
+		Examples: []string{
+			`{"action": "confirm", "command": "go test ./internal/parser -run TestParseEmptyInput", "artifacts": ["internal/parser/empty_input_test.go"]}`,
+			`{"action": "verify"}`,
+		},
+		Handler: func(ctx context.Context, raw json.RawMessage) (interface{}, error) {
+			args, err := ParseReproduceArgs(raw)
+			if err != nil {
+				return nil, err
+			}
+			b, _ := json.Marshal(args)
+			return &ExecutionResult{Content: string(b), Safe: true}, nil
+		},
+	})
+}
@@ -0,0 +1,28 @@
+package tool
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParseReproduceArgs(t *testing.T) {
+	args, err := ParseReproduceArgs(json.RawMessage(`{"action": "Confirm", "command": " go test ./x ", "artifacts": ["./x/a_test.go", ""]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if args.Action != "confirm" || args.Command != "go test ./x" || len(args.Artifacts) != 1 || args.Artifacts[0] != "x/a_test.go" {
+		t.Fatalf("unexpected args %+v", args)
+	}
+	for _, raw := range []string{
+		`{"action": "confirm"}`,
+		`{"action": "fix", "command": "true"}`,
+		`{"action": "verify", "artifacts": ["../outside.sh"]}`,
+	} {
+		if _, err := ParseReproduceArgs(json.RawMessage(raw)); err == nil {
+			t.Errorf("expected %s to be rejected", raw)
+		}
+	}
+	if _, err := ParseReproduceArgs(json.RawMessage(`{"action": "verify"}`)); err != nil {
+		t.Errorf("verify reuses the confirmed command: %v", err)
+	}
+}
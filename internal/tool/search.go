@@ -28,6 +28,7 @@ func RegisterSearchCode(registry *Registry, idx *indexer.RipgrepIndexer) error {
 		Name:        "search_code",
 		Description: "Search the codebase for specific text patterns",
 		Safe:        true, // Searching is a safe operation
+		ReadOnly:    true,
 		JSONSchema: map[string]interface{}{
 			"type": "object",
 			"properties": map[string]interface{}{
@@ -22,6 +22,12 @@ type RunShellArgs struct {
 	Cwd string `json:"cwd,omitempty"`
 	// TimeoutSeconds is the maximum time to allow the command to run. Defaults to 60 seconds.
 	TimeoutSeconds int `json:"timeout_seconds,omitempty"`
+	// Session runs the command in the conversation's persistent shell, which keeps cwd,
+	// environment variables, virtualenv activation and nvm selections between commands.
+	Session bool `json:"session,omitempty"`
+	// RerunFailed re-runs the failed tests this many times (at most 5) when the command
+	// is a test run that fails, and reports which failures are flaky.
+	RerunFailed int `json:"rerun_failed,omitempty"`
 }
 
 // RegisterRunShell registers the run_shell tool which proposes a shell command for approval.
@@ -54,9 +60,28 @@ func RegisterRunShell(registry *Registry, workspacePath string) error {
 					"type":        "integer",
 					"description": "Maximum execution time in seconds (default 60)",
 				},
+				"session": map[string]interface{}{
+					"type":        "boolean",
+					"description": "If true, run in this conversation's persistent shell session: cwd, exported variables, source/activate and nvm use carry over to later session commands.",
+				},
+				"rerun_failed": map[string]interface{}{
+					"type":        "integer",
+					"description": "For test commands: when the run fails, re-run only the failed tests this many times (max 5) and report which failures are flaky",
+				},
 			},
 			"required": []string{"command"},
 		},
+		Usage: `Without shell, command is a binary and args its arguments; no pipes, globs or redirects. Set shell=true to run the whole command string through sh -c.
+cwd is resolved inside the workspace. Long output is condensed to the first and last lines plus every error, warning and failing test.
+With session=true the command runs in a shell that persists for the conversation, so "source .venv/bin/activate" or "nvm use 18" applies to later session commands; a cwd given with a session command stays in effect. The session ends after 30 idle minutes, after 4 hours, when a command times out, or with reset_shell.
+With rerun_failed=N on a test command that fails, the failed tests are re-run N times: go test via -run, pytest via the failed test ids, any other command as a whole. The result's "flakiness" lists each failed test as "flaky" (passed at least once) or "failing" (failed every time). Treat failing tests as real regressions; do not try to fix a change for a flaky test, report it instead.`,
+		Examples: []string{
+			`{"command":"go","args":["test","./..."]}`,
+			`{"command":"grep -rn TODO src | head -20","shell":true}`,
+			`{"command":"npm","args":["run","build"],"cwd":"web","timeout_seconds":300}`,
+			`{"command":"source .venv/bin/activate && pip install -e .","shell":true,"session":true}`,
+			`{"command":"go","args":["test","./internal/..."],"rerun_failed":3}`,
+		},
 		Handler: func(ctx context.Context, raw json.RawMessage) (interface{}, error) {
 			var args RunShellArgs
 			if err := json.Unmarshal(raw, &args); err != nil {
@@ -78,11 +103,21 @@ func RegisterRunShell(registry *Registry, workspacePath string) error {
 			// Create a diff-like summary for approval UI
 			summary := "Propose running command"
 			var content string
-			if args.Shell {
+			if args.Session {
+				cwd := "session's current directory"
+				if args.Cwd != "" {
+					cwd = absCwd
+				}
+				command := strings.TrimSpace(args.Command + " " + strings.Join(args.Args, " "))
+				content = fmt.Sprintf("Will run in the persistent shell session:\n  cwd: %s\n  timeout: %ds\n+ $ %s", cwd, normalizeTimeout(args.TimeoutSeconds), command)
+			} else if args.Shell {
 				content = fmt.Sprintf("Will run via shell:\n  cwd: %s\n  timeout: %ds\n+ $ %s", absCwd, normalizeTimeout(args.TimeoutSeconds), args.Command)
 			} else {
 				content = fmt.Sprintf("Will exec binary:\n  cwd: %s\n  timeout: %ds\n+ $ %s %v", absCwd, normalizeTimeout(args.TimeoutSeconds), args.Command, args.Args)
 			}
+			if n := min(args.RerunFailed, maxRerunFailed); n > 0 {
+				content += fmt.Sprintf("\n  if it fails: re-runs the failed tests up to %d times", n)
+			}
 
 			return &ExecutionResult{
 				Content: summary,
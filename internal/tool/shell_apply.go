@@ -10,6 +10,8 @@ import (
 	"os/exec"
 	"strings"
 	"time"
+
+	"github.com/loom/loom/internal/toolchain"
 )
 
 // ApplyShellArgs are the same as RunShellArgs; duplicated to keep schema explicit.
@@ -19,6 +21,8 @@ type ApplyShellArgs struct {
 	Args           []string `json:"args,omitempty"`
 	Cwd            string   `json:"cwd,omitempty"`
 	TimeoutSeconds int      `json:"timeout_seconds,omitempty"`
+	Session        bool     `json:"session,omitempty"`
+	RerunFailed    int      `json:"rerun_failed,omitempty"`
 }
 
 // ShellResult captures stdout, stderr and exit code.
@@ -28,6 +32,22 @@ type ShellResult struct {
 	ExitCode   int    `json:"exit_code"`
 	DurationMs int    `json:"duration_ms"`
 	Cwd        string `json:"cwd"`
+	// Truncated is set when output was condensed to fit the tool's output budget
+	Truncated bool `json:"truncated,omitempty"`
+	// Session is set for commands run in the conversation's persistent shell, and
+	// NewSession when that shell was started for this command
+	Session    bool `json:"session,omitempty"`
+	NewSession bool `json:"new_session,omitempty"`
+	// Trashed lists the workspace paths the command deleted that were kept in the trash
+	Trashed []string `json:"trashed,omitempty"`
+	// NotTrashed lists deleted paths that were too large to keep a copy of
+	NotTrashed []string `json:"not_trashed,omitempty"`
+	// Flakiness reports the re-runs of failed tests requested with rerun_failed
+	Flakiness *FlakyReport `json:"flakiness,omitempty"`
+	// Coverage warns about changed files whose new code the command's tests never ran
+	Coverage []string `json:"coverage,omitempty"`
+	// command selects the error matchers used when condensing output
+	command string
 }
 
 // RegisterApplyShell registers the apply_shell tool that actually executes commands after approval.
@@ -60,6 +80,14 @@ func RegisterApplyShell(registry *Registry, workspacePath string) error {
 					"type":        "integer",
 					"description": "Maximum execution time in seconds (default 60, max 600)",
 				},
+				"session": map[string]interface{}{
+					"type":        "boolean",
+					"description": "Run in the conversation's persistent shell session",
+				},
+				"rerun_failed": map[string]interface{}{
+					"type":        "integer",
+					"description": "When a test command fails, re-run the failed tests this many times (max 5)",
+				},
 			},
 			"required": []string{"command"},
 		},
@@ -68,7 +96,18 @@ func RegisterApplyShell(registry *Registry, workspacePath string) error {
 			if err := json.Unmarshal(raw, &args); err != nil {
 				return nil, fmt.Errorf("failed to parse arguments: %w", err)
 			}
-			return applyShell(ctx, workspacePath, args)
+			run := func(a ApplyShellArgs) (*ShellResult, error) {
+				if a.Session {
+					return applyShellInSession(ctx, workspacePath, a)
+				}
+				return applyShell(ctx, workspacePath, a)
+			}
+			res, err := run(args)
+			if err != nil {
+				return nil, err
+			}
+			res.Flakiness = rerunFailedTests(ctx, args, res, run)
+			return res, nil
 		},
 	})
 }
@@ -111,6 +150,8 @@ func applyShell(parentCtx context.Context, workspacePath string, args ApplyShell
 		cmd = exec.CommandContext(timeoutCtx, args.Command, args.Args...)
 	}
 	cmd.Dir = absCwd
+	// Run with the project's virtualenv and toolchain versions
+	cmd.Env = toolchain.Env(expandWorkspacePath(workspacePath))
 
 	// Capture output
 	var stdoutBuf, stderrBuf bytes.Buffer
@@ -144,6 +185,7 @@ func applyShell(parentCtx context.Context, workspacePath string, args ApplyShell
 		ExitCode:   exitCode,
 		DurationMs: int(duration / time.Millisecond),
 		Cwd:        absCwd,
+		command:    strings.TrimSpace(args.Command + " " + strings.Join(args.Args, " ")),
 	}
 	return result, nil
 }
@@ -0,0 +1,266 @@
+package tool
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/loom/loom/internal/textutil"
+)
+
+// When command output exceeds its budget it is condensed rather than cut: the head
+// and tail are kept together with every line that looks like an error, warning,
+// failed test or stack frame, so the failure that matters survives.
+const (
+	// condenseHeadShare and condenseTailShare are the budget shares (in percent)
+	// reserved for the first and last lines; the rest goes to matched lines
+	condenseHeadShare = 20
+	condenseTailShare = 30
+	// maxCondensedLine clips single huge lines (minified code, progress bars)
+	maxCondensedLine = 2000
+)
+
+// outputMatchers recognise lines worth keeping, per toolchain. "generic" always applies.
+var outputMatchers = map[string][]*regexp.Regexp{
+	"generic": {
+		regexp.MustCompile(`(?i)\b(error|errors|fatal|panic|exception|failed|failure|failing)\b`),
+		regexp.MustCompile(`(?i)\bwarn(ing)?s?\b`),
+		regexp.MustCompile(`(?i)^\s*(FAIL|FAILED|ERROR|ERR!)\b`),
+	},
+	"go": {
+		regexp.MustCompile(`^\S+\.go:\d+(:\d+)?: `),
+		regexp.MustCompile(`^\s*--- FAIL`),
+		regexp.MustCompile(`^(panic|fatal error): `),
+		regexp.MustCompile(`^goroutine \d+ \[`),
+		regexp.MustCompile(`^\s+\S+\.go:\d+( \+0x[0-9a-f]+)?$`),
+	},
+	"node": {
+		regexp.MustCompile(`\(\d+,\d+\): error TS\d+`),
+		regexp.MustCompile(`^\S+\.[cm]?[jt]sx?:\d+:\d+`),
+		regexp.MustCompile(`^\s+at .+(\(.+:\d+:\d+\)|:\d+:\d+)$`),
+		regexp.MustCompile(`^\s*(●|✕|✗|×) `),
+		regexp.MustCompile(`^npm ERR!`),
+	},
+	"python": {
+		regexp.MustCompile(`^Traceback \(most recent call last\)`),
+		regexp.MustCompile(`^\s+File ".+", line \d+`),
+		regexp.MustCompile(`^\w+(\.\w+)*(Error|Exception|Warning):`),
+		regexp.MustCompile(`^E\s{2,}`),
+		regexp.MustCompile(`^(FAILED|ERROR) \S+`),
+	},
+	"rust": {
+		regexp.MustCompile(`^(error|warning)(\[E\d+\])?:`),
+		regexp.MustCompile(`^\s+--> \S+:\d+:\d+`),
+		regexp.MustCompile(`^thread '.+' panicked at`),
+	},
+	"java": {
+		regexp.MustCompile(`^\s+at [\w.$<>]+\(.*\)$`),
+		regexp.MustCompile(`^Caused by: `),
+		regexp.MustCompile(`^\[(ERROR|WARNING)\]`),
+		regexp.MustCompile(`^\S+\.(java|kt):\d+: (error|warning)`),
+	},
+	"php": {
+		regexp.MustCompile(`PHP (Fatal|Parse|Warning|Notice)`),
+		regexp.MustCompile(`^\d+\) [\w\\]+::\w+`),
+		regexp.MustCompile(`^\S+\.php:\d+$`),
+	},
+	"c": {
+		regexp.MustCompile(`^\S+:\d+:\d+: (fatal error|error|warning):`),
+		regexp.MustCompile(`^make(\[\d+\])?: \*\*\*`),
+		regexp.MustCompile(`undefined reference to`),
+	},
+}
+
+// toolchainHints maps command names to matcher sets.
+var toolchainHints = map[string]string{
+	"go": "go", "gofmt": "go", "golangci-lint": "go", "staticcheck": "go",
+	"node": "node", "npm": "node", "npx": "node", "yarn": "node", "pnpm": "node", "bun": "node", "tsc": "node",
+	"jest": "node", "vitest": "node", "eslint": "node", "javascript": "node", "nodejs": "node",
+	"python": "python", "python3": "python", "pytest": "python", "pip": "python", "uv": "python", "poetry": "python", "tox": "python",
+	"cargo": "rust", "rustc": "rust",
+	"java": "java", "javac": "java", "mvn": "java", "gradle": "java", "gradlew": "java", "kotlinc": "java",
+	"php": "php", "composer": "php", "phpunit": "php", "pest": "php", "artisan": "php",
+	"gcc": "c", "g++": "c", "clang": "c", "clang++": "c", "make": "c", "cmake": "c", "ninja": "c",
+}
+
+// matchersFor returns the matchers for a command line or language name. Commands
+// that name no known toolchain get every matcher.
+func matchersFor(command string) []*regexp.Regexp {
+	out := append([]*regexp.Regexp(nil), outputMatchers["generic"]...)
+	seen := map[string]bool{}
+	fields := strings.Fields(command)
+	if len(fields) > 4 {
+		fields = fields[:4]
+	}
+	for _, f := range fields {
+		if set, ok := toolchainHints[strings.ToLower(filepath.Base(f))]; ok && !seen[set] {
+			seen[set] = true
+			out = append(out, outputMatchers[set]...)
+		}
+	}
+	if len(seen) == 0 {
+		for name, set := range outputMatchers {
+			if name != "generic" {
+				out = append(out, set...)
+			}
+		}
+	}
+	return out
+}
+
+// condenseOutput shrinks s to about budget bytes, keeping the head, the tail and the
+// lines matched by matchers (with a line of context before and two after), with a
+// marker for every omitted run. It reports whether s was condensed.
+func condenseOutput(s string, budget int, matchers []*regexp.Regexp) (string, bool) {
+	if budget <= 0 || len(s) <= budget {
+		return s, false
+	}
+	// A clipped line must still fit in the head share, or a single-line output would vanish
+	headBudget := budget * condenseHeadShare / 100
+	lineLimit := maxCondensedLine
+	if lineLimit > headBudget-40 {
+		lineLimit = headBudget - 40
+	}
+	if lineLimit < 80 {
+		lineLimit = 80
+	}
+	lines := strings.Split(s, "\n")
+	for i, l := range lines {
+		if len(l) > lineLimit {
+			lines[i] = textutil.ClipBytes(l, lineLimit) + fmt.Sprintf(" …[+%d bytes]", len(l)-lineLimit)
+		}
+	}
+	keep := make([]bool, len(lines))
+	used := 0
+	take := func(i int) bool {
+		if keep[i] {
+			return true
+		}
+		if used+len(lines[i])+1 > budget {
+			return false
+		}
+		keep[i] = true
+		used += len(lines[i]) + 1
+		return true
+	}
+
+	for i := 0; i < len(lines) && used+len(lines[i]) < headBudget; i++ {
+		take(i)
+	}
+	tailBudget := used + budget*condenseTailShare/100
+	for i := len(lines) - 1; i >= 0 && used+len(lines[i]) < tailBudget; i-- {
+		take(i)
+	}
+
+	// Matched lines in order: the first error is usually the root cause
+	matched := 0
+	for i, l := range lines {
+		if keep[i] || !matchesAny(l, matchers) {
+			continue
+		}
+		if !take(i) {
+			break
+		}
+		matched++
+		for _, j := range []int{i - 1, i + 1, i + 2} {
+			if j >= 0 && j < len(lines) && strings.TrimSpace(lines[j]) != "" {
+				take(j)
+			}
+		}
+	}
+
+	var b strings.Builder
+	kept, omitted := 0, 0
+	flush := func() {
+		if omitted > 0 {
+			fmt.Fprintf(&b, "... [%d lines omitted] ...\n", omitted)
+			omitted = 0
+		}
+	}
+	for i, l := range lines {
+		if !keep[i] {
+			omitted++
+			continue
+		}
+		flush()
+		kept++
+		b.WriteString(l)
+		if i < len(lines)-1 {
+			b.WriteByte('\n')
+		}
+	}
+	flush()
+	fmt.Fprintf(&b, "\n[output condensed: kept %d of %d lines (head, tail and %d error/warning lines)]", kept, len(lines), matched)
+	return b.String(), true
+}
+
+func matchesAny(line string, matchers []*regexp.Regexp) bool {
+	for _, m := range matchers {
+		if m.MatchString(line) {
+			return true
+		}
+	}
+	return false
+}
+
+// outputCondenser is implemented by results whose text fields can be condensed to an
+// output budget, which beats cutting their JSON at a byte offset.
+type outputCondenser interface {
+	condense(budget int)
+}
+
+// fitOutput condenses a result until its JSON form fits max bytes. JSON escaping
+// makes the encoded size larger than the text budget, so the budget is tightened
+// by the overshoot and retried a few times.
+func fitOutput(c outputCondenser, max int) {
+	if max <= 0 {
+		return
+	}
+	budget := max
+	for attempt := 0; attempt < 4 && budget > 0; attempt++ {
+		b, _ := json.MarshalIndent(c, "", "  ")
+		if len(b) <= max {
+			return
+		}
+		if attempt > 0 {
+			budget -= len(b) - max + 256
+		}
+		c.condense(budget)
+	}
+}
+
+// splitBudget divides budget between two streams, giving each at least half unless
+// it needs less.
+func splitBudget(a, b, budget int) (int, int) {
+	if a+b <= budget {
+		return a, b
+	}
+	half := budget / 2
+	switch {
+	case a <= half:
+		return a, budget - a
+	case b <= half:
+		return budget - b, b
+	}
+	return half, budget - half
+}
+
+func (r *ShellResult) condense(budget int) {
+	outBudget, errBudget := splitBudget(len(r.Stdout), len(r.Stderr), budget)
+	matchers := matchersFor(r.command)
+	var c1, c2 bool
+	r.Stdout, c1 = condenseOutput(r.Stdout, outBudget, matchers)
+	r.Stderr, c2 = condenseOutput(r.Stderr, errBudget, matchers)
+	r.Truncated = r.Truncated || c1 || c2
+}
+
+func (r *SnippetResult) condense(budget int) {
+	outBudget, errBudget := splitBudget(len(r.Stdout), len(r.Stderr), budget)
+	matchers := matchersFor(r.Language)
+	var c1, c2 bool
+	r.Stdout, c1 = condenseOutput(r.Stdout, outBudget, matchers)
+	r.Stderr, c2 = condenseOutput(r.Stderr, errBudget, matchers)
+	r.Truncated = r.Truncated || c1 || c2
+}
@@ -0,0 +1,109 @@
+package tool
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func noisyOutput(n int, inject map[int]string) string {
+	var b strings.Builder
+	for i := 0; i < n; i++ {
+		if line, ok := inject[i]; ok {
+			b.WriteString(line + "\n")
+			continue
+		}
+		fmt.Fprintf(&b, "compiling module %04d of the project, all good here\n", i)
+	}
+	return b.String()
+}
+
+func TestCondenseOutput_KeepsErrorsHeadAndTail(t *testing.T) {
+	out := noisyOutput(2000, map[int]string{
+		1000: "internal/foo/bar.go:42:7: undefined: frobnicate",
+		1500: "--- FAIL: TestThing (0.00s)",
+	})
+	got, condensed := condenseOutput(out, 4000, matchersFor("go test ./..."))
+	if !condensed {
+		t.Fatal("expected output to be condensed")
+	}
+	if len(got) > 4400 {
+		t.Fatalf("condensed output too large: %d bytes", len(got))
+	}
+	for _, want := range []string{
+		"module 0000",
+		"module 1999",
+		"bar.go:42:7: undefined: frobnicate",
+		"--- FAIL: TestThing",
+		"lines omitted",
+		"output condensed",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("condensed output missing %q", want)
+		}
+	}
+}
+
+func TestCondenseOutput_SmallOutputUntouched(t *testing.T) {
+	got, condensed := condenseOutput("ok\n", 100, matchersFor("go test"))
+	if condensed || got != "ok\n" {
+		t.Fatalf("small output changed: %q, %v", got, condensed)
+	}
+}
+
+func TestCondenseOutput_LanguageMatchers(t *testing.T) {
+	cases := map[string]string{
+		"pytest":       `  File "app/models.py", line 12, in save`,
+		"cargo build":  "  --> src/main.rs:3:5",
+		"npx tsc":      "src/app.ts(4,10): error TS2304: Cannot find name 'x'.",
+		"mvn test":     "\tat com.example.App.main(App.java:10)",
+		"make":         "make: *** [Makefile:3: all] Error 1",
+		"phpunit":      "1) Tests\\UserTest::testCreate",
+		"/usr/bin/gcc": "main.c:3:1: error: expected ';'",
+	}
+	for command, line := range cases {
+		out := noisyOutput(1000, map[int]string{500: line})
+		got, _ := condenseOutput(out, 3000, matchersFor(command))
+		if !strings.Contains(got, line) {
+			t.Errorf("%s: condensed output lost %q", command, line)
+		}
+	}
+}
+
+func TestCondenseOutput_ClipsHugeLines(t *testing.T) {
+	got, condensed := condenseOutput(strings.Repeat("x", 50000), 5000, nil)
+	if !condensed || len(got) > 5000 {
+		t.Fatalf("huge line not clipped: %d bytes", len(got))
+	}
+	if !strings.HasPrefix(got, "xxxx") || !strings.Contains(got, "bytes]") {
+		t.Errorf("huge line not kept with a clip marker: %q", got)
+	}
+}
+
+func TestFitOutput_ShellResultStaysValidJSON(t *testing.T) {
+	r := &ShellResult{
+		Stdout:   noisyOutput(3000, map[int]string{10: "warning: deprecated flag"}),
+		Stderr:   noisyOutput(3000, map[int]string{2500: "Traceback (most recent call last):"}),
+		ExitCode: 1,
+		command:  "python3 manage.py test",
+	}
+	fitOutput(r, 8000)
+	b, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(b) > 8000 {
+		t.Fatalf("result still %d bytes", len(b))
+	}
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(b, &decoded); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if decoded["truncated"] != true {
+		t.Error("truncated flag not set")
+	}
+	if !strings.Contains(r.Stdout, "warning: deprecated flag") || !strings.Contains(r.Stderr, "Traceback") {
+		t.Error("matched lines were dropped")
+	}
+}
@@ -0,0 +1,378 @@
+package tool
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/loom/loom/internal/toolchain"
+)
+
+// Persistent shell sessions keep cwd, exported variables, activated virtualenvs and
+// nvm selections between run_shell calls of one conversation. A session ends when it
+// was idle for too long, reached its maximum age, a command timed out, or the model
+// called reset_shell; the next session command starts a fresh shell.
+var (
+	shellSessionIdle   = 30 * time.Minute
+	shellSessionMaxAge = 4 * time.Hour
+)
+
+// maxSessionOutput caps what is kept of one stream of a session command; the rest is dropped
+const maxSessionOutput = 8 << 20
+
+// ShellSessions holds the persistent shells of all conversations.
+type ShellSessions struct {
+	mu       sync.Mutex
+	sessions map[string]*shellSession
+}
+
+// NewShellSessions returns an empty session pool.
+func NewShellSessions() *ShellSessions {
+	return &ShellSessions{sessions: map[string]*shellSession{}}
+}
+
+type shellSession struct {
+	workspace string
+	started   time.Time
+
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	stdout *bufio.Reader
+	stderr *bufio.Reader
+	// pipes are closed when the session is killed, so readers blocked on output from
+	// processes the shell left behind return
+	pipes []io.Closer
+	idle  *time.Timer
+
+	mu     sync.Mutex
+	closed bool
+}
+
+type shellSessionKey struct{}
+
+type shellSessionRef struct {
+	pool *ShellSessions
+	id   string
+}
+
+// WithShellSession makes the conversation's persistent shell available to tools invoked
+// with ctx. Without it, session commands fail and run in no shell.
+func WithShellSession(ctx context.Context, pool *ShellSessions, conversationID string) context.Context {
+	if pool == nil || conversationID == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, shellSessionKey{}, shellSessionRef{pool: pool, id: conversationID})
+}
+
+func shellSessionFrom(ctx context.Context) (shellSessionRef, bool) {
+	ref, ok := ctx.Value(shellSessionKey{}).(shellSessionRef)
+	return ref, ok
+}
+
+// get returns the conversation's live session in workspace, starting one if needed.
+func (p *ShellSessions) get(id, workspace string) (*shellSession, bool, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if s := p.sessions[id]; s != nil {
+		if s.alive() && s.workspace == workspace && time.Since(s.started) < shellSessionMaxAge {
+			return s, false, nil
+		}
+		s.kill()
+		delete(p.sessions, id)
+	}
+	s, err := startShellSession(workspace)
+	if err != nil {
+		return nil, false, err
+	}
+	s.idle = time.AfterFunc(shellSessionIdle, func() { p.drop(id, s) })
+	p.sessions[id] = s
+	return s, true, nil
+}
+
+// Reset ends a conversation's session; it reports whether one was running.
+func (p *ShellSessions) Reset(conversationID string) bool {
+	p.mu.Lock()
+	s := p.sessions[conversationID]
+	delete(p.sessions, conversationID)
+	p.mu.Unlock()
+	if s == nil {
+		return false
+	}
+	s.kill()
+	return true
+}
+
+// drop ends s if it is still the conversation's session.
+func (p *ShellSessions) drop(id string, s *shellSession) {
+	p.mu.Lock()
+	if p.sessions[id] == s {
+		delete(p.sessions, id)
+	}
+	p.mu.Unlock()
+	s.kill()
+}
+
+// CloseAll ends every session, e.g. when the workspace changes or the app exits.
+func (p *ShellSessions) CloseAll() {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	sessions := p.sessions
+	p.sessions = map[string]*shellSession{}
+	p.mu.Unlock()
+	for _, s := range sessions {
+		s.kill()
+	}
+}
+
+func startShellSession(workspace string) (*shellSession, error) {
+	var cmd *exec.Cmd
+	if bash, err := exec.LookPath("bash"); err == nil {
+		cmd = exec.Command(bash, "--noprofile", "--norc")
+	} else {
+		cmd = exec.Command("sh")
+	}
+	cmd.Dir = workspace
+	cmd.Env = append(toolchain.Env(workspace), "PS1=", "PS2=", "TERM=dumb")
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start shell session: %w", err)
+	}
+	return &shellSession{
+		workspace: workspace,
+		started:   time.Now(),
+		cmd:       cmd,
+		stdin:     stdin,
+		stdout:    bufio.NewReader(stdout),
+		stderr:    bufio.NewReader(stderr),
+		pipes:     []io.Closer{stdout, stderr},
+	}, nil
+}
+
+func (s *shellSession) alive() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return !s.closed
+}
+
+func (s *shellSession) kill() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return
+	}
+	s.closed = true
+	if s.idle != nil {
+		s.idle.Stop()
+	}
+	_ = s.stdin.Close()
+	if s.cmd.Process != nil {
+		_ = s.cmd.Process.Kill()
+	}
+	for _, c := range s.pipes {
+		_ = c.Close()
+	}
+	go func() { _ = s.cmd.Wait() }()
+}
+
+type sessionStream struct {
+	out       string
+	marker    string
+	truncated bool
+	err       error
+}
+
+// readUntilMarker reads r up to the line starting with token. The newline written
+// before the marker is not part of the command's output.
+func readUntilMarker(r *bufio.Reader, token string) sessionStream {
+	var b strings.Builder
+	var res sessionStream
+	for {
+		line, err := r.ReadString('\n')
+		if strings.HasPrefix(line, token) {
+			res.marker = strings.TrimRight(line, "\n")
+			res.out = strings.TrimSuffix(b.String(), "\n")
+			return res
+		}
+		if b.Len()+len(line) <= maxSessionOutput {
+			b.WriteString(line)
+		} else {
+			res.truncated = true
+		}
+		if err != nil {
+			res.out, res.err = b.String(), err
+			return res
+		}
+	}
+}
+
+// run executes command in the session's shell and returns its output, exit code and
+// the shell's working directory afterwards. A timeout kills the session.
+func (s *shellSession) run(ctx context.Context, command string, timeout time.Duration) (*ShellResult, error) {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return nil, errors.New("shell session ended")
+	}
+	if s.idle != nil {
+		s.idle.Reset(shellSessionIdle)
+	}
+	s.mu.Unlock()
+
+	var tok [8]byte
+	_, _ = rand.Read(tok[:])
+	token := "__LOOM_SESSION_" + hex.EncodeToString(tok[:])
+	// The brace group runs in the session's shell itself, so cd, export and source
+	// persist; stdin is detached so commands cannot swallow the marker lines
+	script := fmt.Sprintf("{ %s\n} </dev/null\nprintf '\\n%%s %%d %%s\\n' %s \"$?\" \"$PWD\"\nprintf '\\n%%s\\n' %s >&2\n", command, token, token)
+
+	start := time.Now()
+	if _, err := io.WriteString(s.stdin, script); err != nil {
+		s.kill()
+		return nil, fmt.Errorf("shell session ended: %w", err)
+	}
+	stdoutCh := make(chan sessionStream, 1)
+	stderrCh := make(chan sessionStream, 1)
+	go func() { stdoutCh <- readUntilMarker(s.stdout, token) }()
+	go func() { stderrCh <- readUntilMarker(s.stderr, token) }()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	var stdout, stderr sessionStream
+	for got := 0; got < 2; got++ {
+		select {
+		case stdout = <-stdoutCh:
+		case stderr = <-stderrCh:
+		case <-timer.C:
+			s.kill()
+			return &ShellResult{
+				Stderr:     fmt.Sprintf("command timed out after %s; the shell session was ended and the next session command starts a fresh shell", timeout),
+				ExitCode:   -1,
+				DurationMs: int(time.Since(start) / time.Millisecond),
+				Cwd:        s.workspace,
+				Session:    true,
+			}, nil
+		case <-ctx.Done():
+			s.kill()
+			return nil, ctx.Err()
+		}
+	}
+
+	result := &ShellResult{
+		Stdout:     stdout.out,
+		Stderr:     stderr.out,
+		ExitCode:   -1,
+		DurationMs: int(time.Since(start) / time.Millisecond),
+		Cwd:        s.workspace,
+		Truncated:  stdout.truncated || stderr.truncated,
+		Session:    true,
+	}
+	if stdout.marker == "" {
+		// The command ended the shell, e.g. with exit or set -e
+		s.kill()
+		result.Stderr = strings.TrimSpace(result.Stderr + "\nthe shell session ended; the next session command starts a fresh shell")
+		return result, nil
+	}
+	fields := strings.SplitN(strings.TrimPrefix(stdout.marker, token+" "), " ", 2)
+	if code, err := strconv.Atoi(fields[0]); err == nil {
+		result.ExitCode = code
+	}
+	if len(fields) == 2 && fields[1] != "" {
+		result.Cwd = fields[1]
+	}
+	return result, nil
+}
+
+// applyShellInSession runs a command in the conversation's persistent shell.
+func applyShellInSession(ctx context.Context, workspacePath string, args ApplyShellArgs) (*ShellResult, error) {
+	if strings.TrimSpace(args.Command) == "" {
+		return nil, errors.New("command is required")
+	}
+	ref, ok := shellSessionFrom(ctx)
+	if !ok {
+		return nil, errors.New("persistent shell sessions are not available here; run the command without session")
+	}
+	workspace := expandWorkspacePath(workspacePath)
+	command := args.Command
+	if !args.Shell {
+		parts := []string{shellQuote(args.Command)}
+		for _, a := range args.Args {
+			parts = append(parts, shellQuote(a))
+		}
+		command = strings.Join(parts, " ")
+	}
+	if args.Cwd != "" {
+		absCwd, err := validatePath(workspace, args.Cwd)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cwd: %w", err)
+		}
+		// The directory change persists like any other cd in the session
+		command = "cd " + shellQuote(absCwd) + " && " + command
+	}
+
+	s, started, err := ref.pool.get(ref.id, workspace)
+	if err != nil {
+		return nil, err
+	}
+	timeout := time.Duration(normalizeTimeout(args.TimeoutSeconds)) * time.Second
+	res, err := s.run(ctx, command, timeout)
+	if err != nil {
+		return nil, err
+	}
+	res.NewSession = started
+	res.command = strings.TrimSpace(args.Command + " " + strings.Join(args.Args, " "))
+	return res, nil
+}
+
+// shellQuote quotes s as a single POSIX shell word.
+func shellQuote(s string) string {
+	if s != "" && strings.IndexFunc(s, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("-_./=:@+,", r))
+	}) < 0 {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// RegisterResetShell registers reset_shell, which ends the conversation's persistent
+// shell session so the next session command starts from a clean environment.
+func RegisterResetShell(registry *Registry) error {
+	return registry.Register(Definition{
+		Name:        "reset_shell",
+		Description: "End the persistent shell session of this conversation (started by run_shell with session=true). The next session command starts a fresh shell in the workspace root with the default environment.",
+		Safe:        true,
+		JSONSchema: map[string]interface{}{
+			"type":       "object",
+			"properties": map[string]interface{}{},
+		},
+		Handler: func(ctx context.Context, raw json.RawMessage) (interface{}, error) {
+			ref, ok := shellSessionFrom(ctx)
+			if !ok || !ref.pool.Reset(ref.id) {
+				return "No shell session was running.", nil
+			}
+			return "Shell session ended; the next session command starts a fresh shell.", nil
+		},
+	})
+}
@@ -0,0 +1,95 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func runSession(t *testing.T, ctx context.Context, reg *Registry, args ApplyShellArgs) *ShellResult {
+	t.Helper()
+	args.Session = true
+	raw, _ := json.Marshal(args)
+	res, err := reg.Invoke(ctx, "apply_shell", raw)
+	if err != nil {
+		t.Fatalf("invoke %q: %v", args.Command, err)
+	}
+	sr, ok := res.(*ShellResult)
+	if !ok {
+		t.Fatalf("unexpected result type: %T", res)
+	}
+	return sr
+}
+
+func TestApplyShell_SessionKeepsState(t *testing.T) {
+	workspace := t.TempDir()
+	if err := os.Mkdir(filepath.Join(workspace, "web"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	reg := NewRegistry()
+	_ = RegisterApplyShell(reg, workspace)
+	_ = RegisterResetShell(reg)
+	pool := NewShellSessions()
+	defer pool.CloseAll()
+	ctx := WithShellSession(context.Background(), pool, "conv-1")
+
+	first := runSession(t, ctx, reg, ApplyShellArgs{Shell: true, Command: "export GREETING=hello", Cwd: "web"})
+	if !first.NewSession || first.ExitCode != 0 {
+		t.Fatalf("expected a new session, got %+v", first)
+	}
+	second := runSession(t, ctx, reg, ApplyShellArgs{Shell: true, Command: `printf '%s' "$GREETING"; pwd`})
+	if second.NewSession || !strings.HasPrefix(second.Stdout, "hello") || filepath.Base(second.Cwd) != "web" {
+		t.Fatalf("expected env and cwd to persist, got %+v", second)
+	}
+	failed := runSession(t, ctx, reg, ApplyShellArgs{Command: "false"})
+	if failed.ExitCode != 1 {
+		t.Fatalf("expected the exit code of the command, got %d", failed.ExitCode)
+	}
+
+	// Another conversation gets its own shell
+	other := runSession(t, WithShellSession(context.Background(), pool, "conv-2"), reg, ApplyShellArgs{Shell: true, Command: `printf '%s' "$GREETING"`})
+	if other.Stdout != "" {
+		t.Fatalf("expected a separate session, got %q", other.Stdout)
+	}
+
+	if res, _ := reg.Invoke(ctx, "reset_shell", json.RawMessage(`{}`)); !strings.HasPrefix(res.(string), "Shell session ended") {
+		t.Fatalf("unexpected reset result %v", res)
+	}
+	fresh := runSession(t, ctx, reg, ApplyShellArgs{Shell: true, Command: `printf '%s' "$GREETING"`})
+	if !fresh.NewSession || fresh.Stdout != "" || fresh.Cwd != workspace {
+		t.Fatalf("expected a fresh shell after reset, got %+v", fresh)
+	}
+}
+
+func TestApplyShell_SessionEndsOnTimeoutAndExit(t *testing.T) {
+	workspace := t.TempDir()
+	reg := NewRegistry()
+	_ = RegisterApplyShell(reg, workspace)
+	pool := NewShellSessions()
+	defer pool.CloseAll()
+	ctx := WithShellSession(context.Background(), pool, "conv")
+
+	timedOut := runSession(t, ctx, reg, ApplyShellArgs{Shell: true, Command: "sleep 5", TimeoutSeconds: 1})
+	if timedOut.ExitCode != -1 || !strings.Contains(timedOut.Stderr, "timed out") {
+		t.Fatalf("expected a timeout, got %+v", timedOut)
+	}
+	exited := runSession(t, ctx, reg, ApplyShellArgs{Shell: true, Command: "exit 3"})
+	if !exited.NewSession || !strings.Contains(exited.Stderr, "session ended") {
+		t.Fatalf("expected exit to end the fresh session, got %+v", exited)
+	}
+	if next := runSession(t, ctx, reg, ApplyShellArgs{Command: "true"}); !next.NewSession || next.ExitCode != 0 {
+		t.Fatalf("expected a new session after exit, got %+v", next)
+	}
+}
+
+func TestApplyShell_SessionNeedsContext(t *testing.T) {
+	reg := NewRegistry()
+	_ = RegisterApplyShell(reg, t.TempDir())
+	raw, _ := json.Marshal(ApplyShellArgs{Command: "true", Session: true})
+	if _, err := reg.Invoke(context.Background(), "apply_shell", raw); err == nil {
+		t.Fatal("expected session commands to fail without a session pool")
+	}
+}
@@ -0,0 +1,302 @@
+package tool
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ExecuteSnippetArgs describes a scratch snippet to run outside the workspace.
+type ExecuteSnippetArgs struct {
+	Language       string `json:"language"`
+	Code           string `json:"code"`
+	Stdin          string `json:"stdin,omitempty"`
+	TimeoutSeconds int    `json:"timeout_seconds,omitempty"`
+}
+
+// SnippetResult captures the outcome of a scratch snippet run.
+type SnippetResult struct {
+	Language        string `json:"language"`
+	Stdout          string `json:"stdout"`
+	Stderr          string `json:"stderr"`
+	ExitCode        int    `json:"exit_code"`
+	DurationMs      int    `json:"duration_ms"`
+	TimedOut        bool   `json:"timed_out,omitempty"`
+	Truncated       bool   `json:"truncated,omitempty"`
+	NetworkIsolated bool   `json:"network_isolated"`
+}
+
+const (
+	snippetDefaultTimeout = 10
+	snippetMaxTimeout     = 60
+	snippetMaxOutput      = 64 * 1024
+	snippetMaxCode        = 100 * 1024
+	// CPU seconds and file size (KB) limits applied via ulimit on POSIX systems
+	snippetFileSizeKB = 20 * 1024
+	// Address space cap for Python; Node reserves large virtual ranges so it is left uncapped
+	snippetPythonMemKB = 1024 * 1024
+)
+
+// RegisterExecuteSnippet registers the execute_snippet tool.
+func RegisterExecuteSnippet(registry *Registry) error {
+	return registry.Register(Definition{
+		Name:        "execute_snippet",
+		Description: "Propose running a short Python or Node.js snippet and return its stdout/stderr once the user approves. Use it to verify algorithms or transform data. It starts in a throwaway temp directory with a time limit, but it is not a filesystem sandbox: it can read and write any path the user can. Network access is blocked only where an OS sandbox is available.",
+		Safe:        false, // Arbitrary code; the temp dir is only the working directory
+		AlwaysAsk:   true,
+		JSONSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"language": map[string]interface{}{
+					"type":        "string",
+					"description": "Snippet language",
+					"enum":        []string{"python", "node"},
+				},
+				"code": map[string]interface{}{
+					"type":        "string",
+					"description": "Source code to run",
+				},
+				"stdin": map[string]interface{}{
+					"type":        "string",
+					"description": "Optional data passed on standard input",
+				},
+				"timeout_seconds": map[string]interface{}{
+					"type":        "integer",
+					"description": "Wall-clock limit in seconds (default 10, max 60)",
+				},
+			},
+			"required": []string{"language", "code"},
+		},
+		Handler: func(ctx context.Context, raw json.RawMessage) (interface{}, error) {
+			var args ExecuteSnippetArgs
+			if err := json.Unmarshal(raw, &args); err != nil {
+				return nil, fmt.Errorf("failed to parse arguments: %w", err)
+			}
+			return proposeSnippet(args)
+		},
+	})
+}
+
+// proposeSnippet validates a snippet and builds the approval request showing its code.
+func proposeSnippet(args ExecuteSnippetArgs) (*ExecutionResult, error) {
+	if err := validateSnippet(args); err != nil {
+		return nil, err
+	}
+	lang, _, _, err := resolveSnippetInterpreter(args.Language)
+	if err != nil {
+		return nil, err
+	}
+	network := "network blocked by " + snippetSandboxName()
+	if snippetSandboxName() == "" {
+		network = "no network sandbox available: the snippet can reach the network"
+	}
+	if os.Getenv("LOOM_SNIPPET_ALLOW_NETWORK") == "1" {
+		network = "network allowed (LOOM_SNIPPET_ALLOW_NETWORK=1)"
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "Will run this %s snippet (%s). It is not confined to its temp directory.\n\n", lang, network)
+	b.WriteString(args.Code)
+	return &ExecutionResult{
+		Content: fmt.Sprintf("Propose running a %d-line %s snippet", strings.Count(strings.TrimRight(args.Code, "\n"), "\n")+1, lang),
+		Diff:    b.String(),
+		Safe:    false,
+	}, nil
+}
+
+// RunSnippet runs an approved snippet. It is called by the engine only after the user
+// approved the execute_snippet proposal.
+func RunSnippet(ctx context.Context, args ExecuteSnippetArgs) (*SnippetResult, error) {
+	return executeSnippet(ctx, args)
+}
+
+func validateSnippet(args ExecuteSnippetArgs) error {
+	if strings.TrimSpace(args.Code) == "" {
+		return errors.New("code is required")
+	}
+	if len(args.Code) > snippetMaxCode {
+		return fmt.Errorf("code exceeds %d bytes", snippetMaxCode)
+	}
+	return nil
+}
+
+func executeSnippet(parentCtx context.Context, args ExecuteSnippetArgs) (*SnippetResult, error) {
+	if err := validateSnippet(args); err != nil {
+		return nil, err
+	}
+
+	lang, interpreter, ext, err := resolveSnippetInterpreter(args.Language)
+	if err != nil {
+		return nil, err
+	}
+
+	dir, err := os.MkdirTemp("", "loom-snippet-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp dir: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	script := filepath.Join(dir, "snippet"+ext)
+	if err := os.WriteFile(script, []byte(args.Code), 0o600); err != nil {
+		return nil, fmt.Errorf("failed to write snippet: %w", err)
+	}
+
+	timeoutSec := args.TimeoutSeconds
+	if timeoutSec <= 0 {
+		timeoutSec = snippetDefaultTimeout
+	}
+	if timeoutSec > snippetMaxTimeout {
+		timeoutSec = snippetMaxTimeout
+	}
+	ctx, cancel := context.WithTimeout(parentCtx, time.Duration(timeoutSec)*time.Second)
+	defer cancel()
+
+	argv, isolated := snippetCommand(lang, interpreter, script, timeoutSec)
+	cmd := exec.CommandContext(ctx, argv[0], argv[1:]...)
+	cmd.Dir = dir
+	cmd.Env = snippetEnv(dir, isolated)
+	if args.Stdin != "" {
+		cmd.Stdin = strings.NewReader(args.Stdin)
+	}
+	var stdoutBuf, stderrBuf bytes.Buffer
+	cmd.Stdout = &stdoutBuf
+	cmd.Stderr = &stderrBuf
+
+	start := time.Now()
+	runErr := cmd.Run()
+	duration := time.Since(start)
+
+	result := &SnippetResult{
+		Language:        lang,
+		DurationMs:      int(duration / time.Millisecond),
+		NetworkIsolated: isolated,
+	}
+	if runErr != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			result.TimedOut = true
+			result.ExitCode = -1
+		} else if ee, ok := runErr.(*exec.ExitError); ok {
+			result.ExitCode = ee.ExitCode()
+		} else {
+			result.ExitCode = -1
+			stderrBuf.WriteString(runErr.Error())
+		}
+	}
+	var truncOut, truncErr bool
+	matchers := matchersFor(lang)
+	result.Stdout, truncOut = condenseOutput(stdoutBuf.String(), snippetMaxOutput, matchers)
+	result.Stderr, truncErr = condenseOutput(stderrBuf.String(), snippetMaxOutput, matchers)
+	result.Truncated = truncOut || truncErr
+	if result.TimedOut {
+		result.Stderr = strings.TrimSpace(result.Stderr + fmt.Sprintf("\n[killed after %ds timeout]", timeoutSec))
+	}
+	return result, nil
+}
+
+// resolveSnippetInterpreter maps a language to an available interpreter binary.
+func resolveSnippetInterpreter(language string) (lang, interpreter, ext string, err error) {
+	switch strings.ToLower(strings.TrimSpace(language)) {
+	case "python", "python3", "py":
+		for _, bin := range []string{"python3", "python"} {
+			if p, lerr := exec.LookPath(bin); lerr == nil {
+				return "python", p, ".py", nil
+			}
+		}
+		return "", "", "", errors.New("python interpreter not found in PATH")
+	case "node", "javascript", "js", "nodejs":
+		if p, lerr := exec.LookPath("node"); lerr == nil {
+			return "node", p, ".js", nil
+		}
+		return "", "", "", errors.New("node interpreter not found in PATH")
+	default:
+		return "", "", "", fmt.Errorf("unsupported language %q (use python or node)", language)
+	}
+}
+
+// snippetCommand wraps the interpreter with resource limits and, where the platform
+// allows it, a network sandbox. It reports whether network isolation is in effect.
+// Windows has neither; only the wall-clock timeout of the caller's context applies.
+func snippetCommand(lang, interpreter, script string, timeoutSec int) ([]string, bool) {
+	if runtime.GOOS == "windows" {
+		return []string{interpreter, script}, false
+	}
+	limits := fmt.Sprintf("ulimit -t %d 2>/dev/null; ulimit -f %d 2>/dev/null;", timeoutSec, snippetFileSizeKB)
+	if lang == "python" {
+		limits += fmt.Sprintf(" ulimit -v %d 2>/dev/null;", snippetPythonMemKB)
+	}
+	argv := []string{"sh", "-c", limits + ` exec "$0" "$@"`, interpreter, script}
+	if os.Getenv("LOOM_SNIPPET_ALLOW_NETWORK") == "1" {
+		return argv, false
+	}
+	if prefix := networkSandboxPrefix(); len(prefix) > 0 {
+		return append(append([]string{}, prefix...), argv...), true
+	}
+	return argv, false
+}
+
+var (
+	sandboxOnce   sync.Once
+	sandboxPrefix []string
+)
+
+// networkSandboxPrefix returns a command prefix that denies network access, or nil
+// when no sandbox is available (unprivileged user namespaces on Linux, sandbox-exec on macOS).
+func networkSandboxPrefix() []string {
+	sandboxOnce.Do(func() {
+		switch runtime.GOOS {
+		case "linux":
+			if p, err := exec.LookPath("unshare"); err == nil {
+				if exec.Command(p, "-rn", "true").Run() == nil {
+					sandboxPrefix = []string{p, "-rn"}
+				}
+			}
+		case "darwin":
+			if p, err := exec.LookPath("sandbox-exec"); err == nil {
+				sandboxPrefix = []string{p, "-p", "(version 1)(allow default)(deny network*)"}
+			}
+		}
+	})
+	return sandboxPrefix
+}
+
+// snippetSandboxName names the network sandbox in use, or "" when there is none.
+func snippetSandboxName() string {
+	if runtime.GOOS == "windows" {
+		return ""
+	}
+	if prefix := networkSandboxPrefix(); len(prefix) > 0 {
+		return filepath.Base(prefix[0])
+	}
+	return ""
+}
+
+// snippetEnv builds the child environment: secrets are dropped, temp paths point at the
+// scratch dir, and proxies are blackholed when no real network sandbox exists. The dead
+// proxy only stops HTTP clients that honour it; the approval prompt says so.
+func snippetEnv(dir string, isolated bool) []string {
+	env := make([]string, 0, len(os.Environ()))
+	for _, kv := range os.Environ() {
+		name := strings.ToUpper(strings.SplitN(kv, "=", 2)[0])
+		if strings.Contains(name, "KEY") || strings.Contains(name, "TOKEN") || strings.Contains(name, "SECRET") || strings.Contains(name, "PASSWORD") {
+			continue
+		}
+		if name == "TMPDIR" || name == "TEMP" || name == "TMP" {
+			continue
+		}
+		env = append(env, kv)
+	}
+	env = append(env, "TMPDIR="+dir, "TEMP="+dir, "TMP="+dir, "PYTHONDONTWRITEBYTECODE=1")
+	if !isolated && os.Getenv("LOOM_SNIPPET_ALLOW_NETWORK") != "1" {
+		dead := "http://127.0.0.1:9"
+		env = append(env, "HTTP_PROXY="+dead, "HTTPS_PROXY="+dead, "http_proxy="+dead, "https_proxy="+dead, "NO_PROXY=", "no_proxy=")
+	}
+	return env
+}
@@ -0,0 +1,66 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestExecuteSnippet_PythonStdoutAndStdin(t *testing.T) {
+	if _, err := exec.LookPath("python3"); err != nil {
+		t.Skip("python3 not available")
+	}
+	reg := NewRegistry()
+	if err := RegisterExecuteSnippet(reg); err != nil {
+		t.Fatalf("register execute_snippet: %v", err)
+	}
+
+	raw, _ := json.Marshal(ExecuteSnippetArgs{
+		Language: "python",
+		Code:     "import sys, os\nprint(sum(int(x) for x in sys.stdin.read().split()))\nprint(os.getcwd())",
+		Stdin:    "1 2 3",
+	})
+	res, err := reg.Invoke(context.Background(), "execute_snippet", raw)
+	if err != nil {
+		t.Fatalf("invoke: %v", err)
+	}
+	proposal := res.(*ExecutionResult)
+	if proposal.Safe || !strings.Contains(proposal.Diff, "sys.stdin.read()") {
+		t.Fatalf("expected an approval request showing the code, got %+v", proposal)
+	}
+
+	var args ExecuteSnippetArgs
+	_ = json.Unmarshal(raw, &args)
+	r, err := RunSnippet(context.Background(), args)
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if r.ExitCode != 0 {
+		t.Fatalf("unexpected exit code %d, stderr=%q", r.ExitCode, r.Stderr)
+	}
+	lines := strings.Split(strings.TrimSpace(r.Stdout), "\n")
+	if len(lines) != 2 || lines[0] != "6" {
+		t.Fatalf("unexpected stdout: %q", r.Stdout)
+	}
+	if !strings.Contains(lines[1], "loom-snippet-") {
+		t.Fatalf("snippet should run in a scratch dir, got cwd %q", lines[1])
+	}
+}
+
+func TestExecuteSnippet_TimeoutAndBadLanguage(t *testing.T) {
+	if _, err := exec.LookPath("python3"); err != nil {
+		t.Skip("python3 not available")
+	}
+	res, err := executeSnippet(context.Background(), ExecuteSnippetArgs{Language: "python", Code: "while True: pass", TimeoutSeconds: 1})
+	if err != nil {
+		t.Fatalf("execute: %v", err)
+	}
+	if !res.TimedOut && res.ExitCode == 0 {
+		t.Fatalf("expected the busy loop to be killed: %+v", res)
+	}
+	if _, err := executeSnippet(context.Background(), ExecuteSnippetArgs{Language: "ruby", Code: "puts 1"}); err == nil {
+		t.Fatalf("expected unsupported language error")
+	}
+}
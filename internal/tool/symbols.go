@@ -56,18 +56,37 @@ type SymbolService interface {
 	Workspace() string
 }
 
-// RegisterSymbols registers all symbol tools.
+// symbolExpander is implemented by services with a sparse index profile; Expand
+// indexes the directory around a path that is not covered yet.
+type symbolExpander interface {
+	Expand(ctx context.Context, relPath string) (string, error)
+}
+
+// RegisterSymbols registers all symbol tools. Services that index sparsely expand
+// when a symbol tool or a file tool touches an unindexed directory.
 func RegisterSymbols(registry *Registry, svc SymbolService) error {
+	expand := func(ctx context.Context, rel string) {}
+	if ex, ok := svc.(symbolExpander); ok {
+		expand = func(ctx context.Context, rel string) {
+			if rel = strings.TrimSpace(rel); rel != "" {
+				_, _ = ex.Expand(ctx, rel)
+			}
+		}
+		registry.SetPathHook(func(rel string) {
+			go expand(context.Background(), rel)
+		})
+	}
 	// symbols_search
 	if err := registry.Register(Definition{
 		Name:        "symbols_search",
 		Description: "Search indexed project symbols by name or doc excerpt",
 		Safe:        true,
+		ReadOnly:    true,
 		JSONSchema: map[string]any{
 			"type": "object",
 			"properties": map[string]any{
 				"q":           map[string]any{"type": "string", "description": "query string (name or text)"},
-				"kind":        map[string]any{"type": "string", "description": "optional symbol kind (func, class, var, ...)"},
+				"kind":        map[string]any{"type": "string", "description": "optional symbol kind (func, class, var, prototype, struct, namespace, interface, ...)"},
 				"lang":        map[string]any{"type": "string", "description": "optional language filter"},
 				"path_prefix": map[string]any{"type": "string", "description": "limit to files under this prefix"},
 				"limit":       map[string]any{"type": "integer", "description": "max results (default 20)"},
@@ -79,6 +98,7 @@ func RegisterSymbols(registry *Registry, svc SymbolService) error {
 			if err := json.Unmarshal(raw, &args); err != nil {
 				return nil, fmt.Errorf("parse args: %w", err)
 			}
+			expand(ctx, args.PathPrefix)
 			return svc.Search(ctx, args.Q, args.Kind, args.Lang, args.PathPrefix, args.Limit)
 		},
 	}); err != nil {
@@ -90,6 +110,7 @@ func RegisterSymbols(registry *Registry, svc SymbolService) error {
 		Name:        "symbols_def",
 		Description: "Get a symbol card by id",
 		Safe:        true,
+		ReadOnly:    true,
 		JSONSchema: map[string]any{
 			"type":       "object",
 			"properties": map[string]any{"sid": map[string]any{"type": "string"}},
@@ -111,9 +132,10 @@ func RegisterSymbols(registry *Registry, svc SymbolService) error {
 		Name:        "symbols_refs",
 		Description: "Find reference/call/import sites for a symbol",
 		Safe:        true,
+		ReadOnly:    true,
 		JSONSchema: map[string]any{
 			"type":       "object",
-			"properties": map[string]any{"sid": map[string]any{"type": "string"}, "kind": map[string]any{"type": "string"}},
+			"properties": map[string]any{"sid": map[string]any{"type": "string"}, "kind": map[string]any{"type": "string", "description": "optional relation kind (references, implementation, declaration, subclass)"}},
 			"required":   []any{"sid"},
 		},
 		Handler: func(ctx context.Context, raw json.RawMessage) (interface{}, error) {
@@ -132,6 +154,7 @@ func RegisterSymbols(registry *Registry, svc SymbolService) error {
 		Name:        "symbols_neighborhood",
 		Description: "Get a small code slice around the symbol definition",
 		Safe:        true,
+		ReadOnly:    true,
 		JSONSchema: map[string]any{
 			"type":       "object",
 			"properties": map[string]any{"sid": map[string]any{"type": "string"}, "radius_lines": map[string]any{"type": "integer"}},
@@ -153,6 +176,7 @@ func RegisterSymbols(registry *Registry, svc SymbolService) error {
 		Name:        "symbols_outline",
 		Description: "Return a hierarchical outline of a file",
 		Safe:        true,
+		ReadOnly:    true,
 		JSONSchema: map[string]any{
 			"type":       "object",
 			"properties": map[string]any{"file": map[string]any{"type": "string"}},
@@ -163,6 +187,7 @@ func RegisterSymbols(registry *Registry, svc SymbolService) error {
 			if err := json.Unmarshal(raw, &args); err != nil {
 				return nil, err
 			}
+			expand(ctx, args.File)
 			return svc.Outline(ctx, args.File)
 		},
 	}); err != nil {
@@ -174,6 +199,7 @@ func RegisterSymbols(registry *Registry, svc SymbolService) error {
 		Name:        "symbols_context_pack",
 		Description: "Pack a symbol definition slice and limited callsite slices into a compact context within a line budget",
 		Safe:        true,
+		ReadOnly:    true,
 		JSONSchema: map[string]any{
 			"type": "object",
 			"properties": map[string]any{
@@ -232,6 +258,11 @@ func RegisterSymbols(registry *Registry, svc SymbolService) error {
 		return err
 	}
 
+	// generate_mock finds the interfaces it mocks through the index
+	if err := RegisterGenerateMock(registry, svc); err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -0,0 +1,491 @@
+package tool
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/loom/loom/internal/textutil"
+)
+
+const (
+	// maxPlanText bounds the rendered plan attached to an apply approval.
+	maxPlanText = 200 << 10
+	// terraformTimeout is the default for plan and apply.
+	terraformTimeout = 300 * time.Second
+)
+
+// TerraformPlanArgs selects the module and options for terraform_plan.
+type TerraformPlanArgs struct {
+	Module         string   `json:"module,omitempty"` // workspace-relative directory
+	VarFiles       []string `json:"var_files,omitempty"`
+	Targets        []string `json:"targets,omitempty"`
+	Destroy        bool     `json:"destroy,omitempty"`
+	TimeoutSeconds int      `json:"timeout_seconds,omitempty"`
+}
+
+// TerraformApplyArgs names a plan produced by terraform_plan.
+type TerraformApplyArgs struct {
+	PlanID string `json:"plan_id"`
+}
+
+// PlanSummary is the structured result of terraform_plan.
+type PlanSummary struct {
+	PlanID    string            `json:"plan_id,omitempty"`
+	Module    string            `json:"module"`
+	Binary    string            `json:"binary"`
+	Counts    map[string]int    `json:"counts"` // create, update, delete, replace, read
+	Changes   []ResourceChange  `json:"changes,omitempty"`
+	Outputs   map[string]string `json:"outputs,omitempty"` // output name → action
+	Drift     []string          `json:"drift,omitempty"`   // resources changed outside Terraform
+	NoChanges bool              `json:"no_changes,omitempty"`
+	Notes     []string          `json:"notes,omitempty"`
+}
+
+// ResourceChange is one planned resource action.
+type ResourceChange struct {
+	Address    string   `json:"address"`
+	Action     string   `json:"action"` // create, update, delete, replace, read
+	Reason     string   `json:"reason,omitempty"`
+	Attributes []string `json:"attributes,omitempty"` // top-level attributes that change
+	ForcesNew  []string `json:"forces_replacement,omitempty"`
+}
+
+// storedPlan is a saved plan awaiting approval.
+type storedPlan struct {
+	module, file, binary string
+	sha256               string
+	summary              PlanSummary
+	text                 string
+}
+
+var (
+	plansMu sync.Mutex
+	plans   = map[string]*storedPlan{}
+)
+
+// RegisterTerraformTools registers terraform_plan and terraform_apply.
+func RegisterTerraformTools(registry *Registry, workspacePath string) error {
+	if err := registry.Register(Definition{
+		Name:        "terraform_plan",
+		Description: "Run `terraform plan` (or OpenTofu) in a module and return a structured summary of resource changes with a plan_id. The module must already be initialized; propose `terraform init` through run_shell first if it is not. Does not change infrastructure or take the state lock.",
+		Safe:        true,
+		JSONSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"module": map[string]interface{}{
+					"type":        "string",
+					"description": "Workspace-relative module directory (default: the workspace root, or the only module found)",
+				},
+				"var_files": map[string]interface{}{
+					"type":        "array",
+					"items":       map[string]interface{}{"type": "string"},
+					"description": "Variable files relative to the module (-var-file)",
+				},
+				"targets": map[string]interface{}{
+					"type":        "array",
+					"items":       map[string]interface{}{"type": "string"},
+					"description": "Resource addresses to limit the plan to (-target)",
+				},
+				"destroy": map[string]interface{}{
+					"type":        "boolean",
+					"description": "Plan destruction of all managed resources",
+				},
+				"timeout_seconds": map[string]interface{}{
+					"type":        "integer",
+					"description": "Maximum time for the plan (default 300, max 600)",
+				},
+			},
+		},
+		Handler: func(ctx context.Context, raw json.RawMessage) (interface{}, error) {
+			var args TerraformPlanArgs
+			if len(raw) > 0 {
+				if err := json.Unmarshal(raw, &args); err != nil {
+					return nil, fmt.Errorf("failed to parse arguments: %w", err)
+				}
+			}
+			return terraformPlan(ctx, workspacePath, args)
+		},
+	}); err != nil {
+		return err
+	}
+
+	return registry.Register(Definition{
+		Name:        "terraform_apply",
+		Description: "Propose applying a saved plan from terraform_plan. Always requires explicit user approval; the full plan is shown for review, and the approved plan file is applied exactly as planned.",
+		Safe:        false,
+		AlwaysAsk:   true,
+		JSONSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"plan_id": map[string]interface{}{
+					"type":        "string",
+					"description": "plan_id returned by terraform_plan",
+				},
+			},
+			"required": []string{"plan_id"},
+		},
+		Handler: func(ctx context.Context, raw json.RawMessage) (interface{}, error) {
+			var args TerraformApplyArgs
+			if err := json.Unmarshal(raw, &args); err != nil {
+				return nil, fmt.Errorf("failed to parse arguments: %w", err)
+			}
+			return proposeTerraformApply(args)
+		},
+	})
+}
+
+// terraformBinary prefers terraform and falls back to OpenTofu.
+func terraformBinary() (string, error) {
+	for _, name := range []string{"terraform", "tofu"} {
+		if path, err := exec.LookPath(name); err == nil {
+			return path, nil
+		}
+	}
+	return "", errors.New("neither terraform nor tofu was found on PATH")
+}
+
+// findTerraformModules lists workspace-relative directories that contain .tf files.
+func findTerraformModules(workspacePath string) []string {
+	seen := map[string]bool{}
+	_ = filepath.WalkDir(workspacePath, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() {
+			if path != workspacePath && (strings.HasPrefix(d.Name(), ".") || skippedTreeDirs[d.Name()]) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if filepath.Ext(path) == ".tf" {
+			rel, _ := filepath.Rel(workspacePath, filepath.Dir(path))
+			seen[filepath.ToSlash(rel)] = true
+		}
+		return nil
+	})
+	out := make([]string, 0, len(seen))
+	for m := range seen {
+		out = append(out, m)
+	}
+	sort.Strings(out)
+	return out
+}
+
+func terraformPlan(ctx context.Context, workspacePath string, args TerraformPlanArgs) (*PlanSummary, error) {
+	root := expandWorkspacePath(workspacePath)
+	module := strings.TrimSpace(args.Module)
+	if module == "" {
+		modules := findTerraformModules(root)
+		switch {
+		case len(modules) == 0:
+			return nil, errors.New("no Terraform modules (*.tf) found in the workspace")
+		case len(modules) == 1:
+			module = modules[0]
+		case containsString(modules, "."):
+			module = "."
+		default:
+			return nil, fmt.Errorf("several modules found; pass module as one of: %s", strings.Join(modules, ", "))
+		}
+	}
+	dir, err := validatePath(root, module)
+	if err != nil {
+		return nil, fmt.Errorf("invalid module: %w", err)
+	}
+	if matches, _ := filepath.Glob(filepath.Join(dir, "*.tf")); len(matches) == 0 {
+		return nil, fmt.Errorf("%s contains no .tf files", module)
+	}
+	binary, err := terraformBinary()
+	if err != nil {
+		return nil, err
+	}
+	timeout := terraformTimeout
+	if args.TimeoutSeconds > 0 {
+		timeout = time.Duration(normalizeTimeout(args.TimeoutSeconds)) * time.Second
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	summary := &PlanSummary{Module: filepath.ToSlash(module), Binary: filepath.Base(binary)}
+	// init downloads providers and modules and may configure a remote backend, so it
+	// goes through run_shell and its approval rather than running here
+	if _, err := os.Stat(filepath.Join(dir, ".terraform")); os.IsNotExist(err) {
+		return nil, fmt.Errorf("%s is not initialized; propose `%s init -input=false` in it with run_shell, then plan again", summary.Module, summary.Binary)
+	}
+
+	id := newPlanID()
+	planDir := filepath.Join(os.TempDir(), "loom-terraform")
+	if err := os.MkdirAll(planDir, 0o700); err != nil {
+		return nil, err
+	}
+	planFile := filepath.Join(planDir, id+".tfplan")
+	planArgs := []string{"plan", "-input=false", "-no-color", "-lock=false", "-out=" + planFile}
+	if args.Destroy {
+		planArgs = append(planArgs, "-destroy")
+	}
+	for _, f := range args.VarFiles {
+		planArgs = append(planArgs, "-var-file="+f)
+	}
+	for _, t := range args.Targets {
+		planArgs = append(planArgs, "-target="+t)
+	}
+	if out, err := runTerraform(ctx, binary, dir, planArgs...); err != nil {
+		return nil, fmt.Errorf("%s plan failed: %v\n%s", summary.Binary, err, tail(out, 4000))
+	}
+	planJSON, err := runTerraform(ctx, binary, dir, "show", "-json", planFile)
+	if err != nil {
+		return nil, fmt.Errorf("%s show -json failed: %v", summary.Binary, err)
+	}
+	if err := summarizePlanJSON([]byte(planJSON), summary); err != nil {
+		return nil, err
+	}
+	text, err := runTerraform(ctx, binary, dir, "show", "-no-color", planFile)
+	if err != nil {
+		return nil, fmt.Errorf("%s show failed: %v", summary.Binary, err)
+	}
+	data, err := os.ReadFile(planFile)
+	if err != nil {
+		return nil, err
+	}
+
+	if summary.NoChanges {
+		_ = os.Remove(planFile)
+		return summary, nil
+	}
+	summary.PlanID = id
+	storePlan(id, &storedPlan{module: dir, file: planFile, binary: binary, sha256: sha256Hex(data), summary: *summary, text: text})
+	return summary, nil
+}
+
+// summarizePlanJSON fills summary from `terraform show -json` output.
+func summarizePlanJSON(data []byte, summary *PlanSummary) error {
+	var plan struct {
+		ResourceChanges []struct {
+			Address      string `json:"address"`
+			ActionReason string `json:"action_reason"`
+			Change       struct {
+				Actions      []string        `json:"actions"`
+				Before       json.RawMessage `json:"before"`
+				After        json.RawMessage `json:"after"`
+				AfterUnknown json.RawMessage `json:"after_unknown"`
+				ReplacePaths [][]any         `json:"replace_paths"`
+			} `json:"change"`
+		} `json:"resource_changes"`
+		ResourceDrift []struct {
+			Address string `json:"address"`
+		} `json:"resource_drift"`
+		OutputChanges map[string]struct {
+			Actions []string `json:"actions"`
+		} `json:"output_changes"`
+	}
+	if err := json.Unmarshal(data, &plan); err != nil {
+		return fmt.Errorf("failed to parse plan JSON: %w", err)
+	}
+	summary.Counts = map[string]int{}
+	for _, rc := range plan.ResourceChanges {
+		action := planAction(rc.Change.Actions)
+		if action == "no-op" {
+			continue
+		}
+		summary.Counts[action]++
+		change := ResourceChange{Address: rc.Address, Action: action, Reason: strings.TrimPrefix(rc.ActionReason, "replace_because_")}
+		if action == "update" || action == "replace" {
+			change.Attributes = changedAttributes(rc.Change.Before, rc.Change.After, rc.Change.AfterUnknown)
+		}
+		for _, p := range rc.Change.ReplacePaths {
+			if len(p) > 0 {
+				change.ForcesNew = append(change.ForcesNew, fmt.Sprint(p[0]))
+			}
+		}
+		summary.Changes = append(summary.Changes, change)
+	}
+	for _, d := range plan.ResourceDrift {
+		summary.Drift = append(summary.Drift, d.Address)
+	}
+	for name, oc := range plan.OutputChanges {
+		if a := planAction(oc.Actions); a != "no-op" {
+			if summary.Outputs == nil {
+				summary.Outputs = map[string]string{}
+			}
+			summary.Outputs[name] = a
+		}
+	}
+	summary.NoChanges = len(summary.Changes) == 0 && len(summary.Outputs) == 0
+	return nil
+}
+
+// planAction collapses a change's action list; ["delete","create"] is a replacement.
+func planAction(actions []string) string {
+	switch {
+	case len(actions) == 2:
+		return "replace"
+	case len(actions) == 1:
+		return actions[0]
+	}
+	return "no-op"
+}
+
+// changedAttributes lists top-level attributes whose value differs or becomes unknown.
+func changedAttributes(before, after, unknown json.RawMessage) []string {
+	var b, a, u map[string]any
+	_ = json.Unmarshal(before, &b)
+	_ = json.Unmarshal(after, &a)
+	_ = json.Unmarshal(unknown, &u)
+	set := map[string]bool{}
+	for k, v := range a {
+		if !reflect.DeepEqual(b[k], v) {
+			set[k] = true
+		}
+	}
+	for k := range b {
+		if _, ok := a[k]; !ok {
+			set[k] = true
+		}
+	}
+	for k, v := range u {
+		if v != false && v != nil {
+			set[k] = true
+		}
+	}
+	out := make([]string, 0, len(set))
+	for k := range set {
+		out = append(out, k)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// proposeTerraformApply builds the approval request for a stored plan.
+func proposeTerraformApply(args TerraformApplyArgs) (*ExecutionResult, error) {
+	p, ok := lookupPlan(args.PlanID)
+	if !ok {
+		return nil, fmt.Errorf("unknown plan_id %q; run terraform_plan first", args.PlanID)
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "Will apply saved plan %s in %s\n", args.PlanID, p.summary.Module)
+	fmt.Fprintf(&b, "Plan file sha256: %s\n", p.sha256)
+	b.WriteString(formatPlanCounts(p.summary.Counts) + "\n\n")
+	text := p.text
+	if len(text) > maxPlanText {
+		text = textutil.ClipBytes(text, maxPlanText) + "\n... plan truncated for display; the full saved plan will be applied\n"
+	}
+	b.WriteString(text)
+	return &ExecutionResult{
+		Content: fmt.Sprintf("Propose applying plan %s (%s)", args.PlanID, formatPlanCounts(p.summary.Counts)),
+		Diff:    b.String(),
+		Safe:    false,
+	}, nil
+}
+
+// TerraformApplyResult is the outcome of an approved apply.
+type TerraformApplyResult struct {
+	PlanID   string         `json:"plan_id"`
+	Module   string         `json:"module"`
+	Counts   map[string]int `json:"counts"`
+	ExitCode int            `json:"exit_code"`
+	Output   string         `json:"output"`
+	Sha256   string         `json:"plan_sha256"`
+}
+
+// ApplyTerraformPlan applies an approved plan. It is called by the engine only after
+// the user approved the terraform_apply proposal, and consumes the plan either way.
+func ApplyTerraformPlan(ctx context.Context, planID string) (*TerraformApplyResult, error) {
+	plansMu.Lock()
+	p, ok := plans[planID]
+	delete(plans, planID)
+	plansMu.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown plan_id %q", planID)
+	}
+	defer func() { _ = os.Remove(p.file) }()
+
+	data, err := os.ReadFile(p.file)
+	if err != nil {
+		return nil, err
+	}
+	if sha256Hex(data) != p.sha256 {
+		return nil, errors.New("plan file changed after it was reviewed; run terraform_plan again")
+	}
+	ctx, cancel := context.WithTimeout(ctx, 2*terraformTimeout)
+	defer cancel()
+	out, runErr := runTerraform(ctx, p.binary, p.module, "apply", "-input=false", "-no-color", "-lock-timeout=30s", p.file)
+	res := &TerraformApplyResult{PlanID: planID, Module: p.summary.Module, Counts: p.summary.Counts, Output: tail(out, 8000), Sha256: p.sha256}
+	var ee *exec.ExitError
+	switch {
+	case errors.As(runErr, &ee):
+		res.ExitCode = ee.ExitCode()
+	case runErr != nil:
+		return nil, runErr
+	}
+	return res, nil
+}
+
+func formatPlanCounts(counts map[string]int) string {
+	var parts []string
+	for _, a := range []string{"create", "update", "replace", "delete", "read"} {
+		if counts[a] > 0 {
+			parts = append(parts, fmt.Sprintf("%d to %s", counts[a], a))
+		}
+	}
+	if len(parts) == 0 {
+		return "no resource changes"
+	}
+	return strings.Join(parts, ", ")
+}
+
+func runTerraform(ctx context.Context, binary, dir string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, binary, args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "TF_IN_AUTOMATION=1", "TF_INPUT=0")
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+	err := cmd.Run()
+	return out.String(), err
+}
+
+func storePlan(id string, p *storedPlan) {
+	plansMu.Lock()
+	defer plansMu.Unlock()
+	plans[id] = p
+}
+
+func lookupPlan(id string) (*storedPlan, bool) {
+	plansMu.Lock()
+	defer plansMu.Unlock()
+	p, ok := plans[id]
+	return p, ok
+}
+
+func newPlanID() string {
+	var b [6]byte
+	_, _ = rand.Read(b[:])
+	return "plan-" + hex.EncodeToString(b[:])
+}
+
+func sha256Hex(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+// tail keeps the last n bytes of s, where errors and summaries appear.
+func tail(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return "...\n" + s[len(s)-n:]
+}
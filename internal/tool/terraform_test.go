@@ -0,0 +1,73 @@
+package tool
+
+import (
+	"strings"
+	"testing"
+)
+
+const samplePlanJSON = `{
+  "format_version": "1.2",
+  "resource_drift": [{"address": "aws_s3_bucket.logs"}],
+  "resource_changes": [
+    {"address": "aws_instance.web", "change": {"actions": ["update"],
+      "before": {"ami": "ami-1", "tags": {"env": "dev"}, "id": "i-1"},
+      "after": {"ami": "ami-1", "tags": {"env": "prod"}, "id": "i-1"},
+      "after_unknown": {}}},
+    {"address": "aws_db_instance.main", "action_reason": "replace_because_cannot_update",
+      "change": {"actions": ["delete", "create"],
+      "before": {"engine": "postgres", "engine_version": "14"},
+      "after": {"engine": "postgres", "engine_version": "16"},
+      "after_unknown": {"id": true}, "replace_paths": [["engine_version"]]}},
+    {"address": "aws_s3_bucket.assets", "change": {"actions": ["create"], "before": null, "after": {"bucket": "a"}}},
+    {"address": "aws_vpc.main", "change": {"actions": ["no-op"]}}
+  ],
+  "output_changes": {"web_ip": {"actions": ["update"]}, "vpc_id": {"actions": ["no-op"]}}
+}`
+
+func TestSummarizePlanJSON(t *testing.T) {
+	var s PlanSummary
+	if err := summarizePlanJSON([]byte(samplePlanJSON), &s); err != nil {
+		t.Fatalf("summarize: %v", err)
+	}
+	if s.Counts["update"] != 1 || s.Counts["replace"] != 1 || s.Counts["create"] != 1 || len(s.Changes) != 3 || s.NoChanges {
+		t.Fatalf("unexpected counts: %+v", s)
+	}
+	if c := s.Changes[0]; c.Address != "aws_instance.web" || strings.Join(c.Attributes, ",") != "tags" {
+		t.Fatalf("unexpected update: %+v", c)
+	}
+	db := s.Changes[1]
+	if db.Action != "replace" || db.Reason != "cannot_update" || strings.Join(db.Attributes, ",") != "engine_version,id" ||
+		strings.Join(db.ForcesNew, ",") != "engine_version" {
+		t.Fatalf("unexpected replacement: %+v", db)
+	}
+	if len(s.Outputs) != 1 || s.Outputs["web_ip"] != "update" || len(s.Drift) != 1 {
+		t.Fatalf("unexpected outputs/drift: %+v %+v", s.Outputs, s.Drift)
+	}
+	if got := formatPlanCounts(s.Counts); got != "1 to create, 1 to update, 1 to replace" {
+		t.Fatalf("formatPlanCounts = %q", got)
+	}
+}
+
+func TestTerraformApply_RequiresKnownPlanAndAttachesPlan(t *testing.T) {
+	if _, err := proposeTerraformApply(TerraformApplyArgs{PlanID: "plan-missing"}); err == nil {
+		t.Fatalf("expected error for unknown plan")
+	}
+	storePlan("plan-test", &storedPlan{
+		summary: PlanSummary{Module: "infra", Counts: map[string]int{"delete": 2}},
+		text:    "  # aws_instance.web will be destroyed\nPlan: 0 to add, 0 to change, 2 to destroy.",
+		sha256:  "abc",
+	})
+	defer func() {
+		plansMu.Lock()
+		delete(plans, "plan-test")
+		plansMu.Unlock()
+	}()
+	res, err := proposeTerraformApply(TerraformApplyArgs{PlanID: "plan-test"})
+	if err != nil {
+		t.Fatalf("propose: %v", err)
+	}
+	if res.Safe || !strings.Contains(res.Diff, "2 to destroy.") || !strings.Contains(res.Diff, "sha256: abc") ||
+		!strings.Contains(res.Content, "2 to delete") {
+		t.Fatalf("unexpected proposal: %+v", res)
+	}
+}
@@ -302,3 +302,40 @@ func removeTodoTask(taskID string) (*TodoResponse, error) {
 		List:    currentTodoList,
 	}, nil
 }
+
+// PendingTodos returns the tasks in the current todo list that are not completed yet.
+func PendingTodos() []TodoTask {
+	todoListMutex.RLock()
+	defer todoListMutex.RUnlock()
+	if currentTodoList == nil {
+		return nil
+	}
+	var out []TodoTask
+	for _, t := range currentTodoList.Tasks {
+		if !t.Completed {
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+// TodoTasks returns a copy of all tasks in the current todo list.
+func TodoTasks() []TodoTask {
+	todoListMutex.RLock()
+	defer todoListMutex.RUnlock()
+	if currentTodoList == nil {
+		return nil
+	}
+	return append([]TodoTask(nil), currentTodoList.Tasks...)
+}
+
+// RestoreTodoTasks replaces the current todo list with open tasks, such as the
+// remaining tasks of a resumed handoff.
+func RestoreTodoTasks(tasks []string) {
+	todoListMutex.Lock()
+	defer todoListMutex.Unlock()
+	_, _ = createTodoList()
+	for _, t := range tasks {
+		_, _ = addTodoTask(t)
+	}
+}
@@ -0,0 +1,273 @@
+package tool
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/loom/loom/internal/textutil"
+)
+
+// SummarizeTreeArgs represents the arguments for the summarize_tree tool.
+type SummarizeTreeArgs struct {
+	Path       string `json:"path,omitempty"`
+	Depth      int    `json:"depth,omitempty"`
+	MaxEntries int    `json:"max_entries,omitempty"`
+}
+
+// SummarizeTreeResult is the annotated tree returned to the model.
+type SummarizeTreeResult struct {
+	Path       string `json:"path"`
+	Depth      int    `json:"depth"`
+	TotalFiles int    `json:"total_files"`
+	TotalDirs  int    `json:"total_dirs"`
+	Tree       string `json:"tree"`
+	Truncated  bool   `json:"truncated,omitempty"`
+}
+
+// treeNode holds aggregated statistics for one directory.
+type treeNode struct {
+	name     string
+	files    int // files in this directory and below
+	langs    map[string]int
+	purpose  string
+	children []*treeNode
+}
+
+// skippedTreeDirs are never descended into; they are noise for structural overviews.
+var skippedTreeDirs = map[string]bool{
+	"node_modules": true, "vendor": true, "dist": true, "build": true, "target": true,
+	"__pycache__": true, "venv": true, ".venv": true, "coverage": true,
+}
+
+// dirPurposes maps conventional directory names to a short description.
+var dirPurposes = map[string]string{
+	"cmd": "command entrypoints", "internal": "private packages", "pkg": "library packages",
+	"src": "source code", "lib": "library code", "app": "application code", "api": "API definitions/handlers",
+	"test": "tests", "tests": "tests", "__tests__": "tests", "spec": "tests", "e2e": "end-to-end tests",
+	"docs": "documentation", "doc": "documentation", "examples": "examples", "example": "examples",
+	"scripts": "scripts/tooling", "tools": "tooling", "bin": "executables/scripts",
+	"config": "configuration", "configs": "configuration", "deploy": "deployment", "deployments": "deployment",
+	"migrations": "database migrations", "db": "database", "models": "data models", "model": "data models",
+	"controllers": "controllers", "routes": "routing", "handlers": "request handlers", "services": "services",
+	"components": "UI components", "pages": "pages/routes", "views": "views/templates", "templates": "templates",
+	"public": "static assets", "static": "static assets", "assets": "assets", "styles": "stylesheets",
+	"ui": "user interface", "web": "web frontend", "frontend": "frontend", "backend": "backend", "server": "server",
+	"client": "client", "utils": "utilities", "util": "utilities", "helpers": "helpers", "types": "type definitions",
+	"hooks": "hooks", "store": "state management", "fixtures": "test fixtures", "testdata": "test fixtures",
+	"k8s": "Kubernetes manifests", "terraform": "infrastructure as code", "infra": "infrastructure",
+	".github": "CI/workflows", "adapter": "adapters", "adapters": "adapters", "middleware": "middleware",
+}
+
+// RegisterSummarizeTree registers the summarize_tree tool with the registry.
+func RegisterSummarizeTree(registry *Registry, workspacePath string) error {
+	return registry.Register(Definition{
+		Name:        "summarize_tree",
+		Description: "Structural overview of a directory: depth-limited tree with per-directory file counts, dominant languages, and a guessed purpose (from READMEs and names). Prefer this over repeated list_dir calls when orienting in a large repo.",
+		Safe:        true,
+		ReadOnly:    true,
+		JSONSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"path": map[string]interface{}{
+					"type":        "string",
+					"description": "Directory to summarize, relative to the workspace root (default: root)",
+				},
+				"depth": map[string]interface{}{
+					"type":        "integer",
+					"description": "How many directory levels to show (default 3, max 6)",
+				},
+				"max_entries": map[string]interface{}{
+					"type":        "integer",
+					"description": "Maximum directories to print (default 200)",
+				},
+			},
+		},
+		Handler: func(ctx context.Context, raw json.RawMessage) (interface{}, error) {
+			var args SummarizeTreeArgs
+			if err := json.Unmarshal(raw, &args); err != nil {
+				return nil, fmt.Errorf("failed to parse arguments: %w", err)
+			}
+			return summarizeTree(ctx, workspacePath, args)
+		},
+	})
+}
+
+func summarizeTree(ctx context.Context, workspacePath string, args SummarizeTreeArgs) (*SummarizeTreeResult, error) {
+	if args.Path == "" {
+		args.Path = "."
+	}
+	depth := args.Depth
+	if depth <= 0 {
+		depth = 3
+	}
+	if depth > 6 {
+		depth = 6
+	}
+	maxEntries := args.MaxEntries
+	if maxEntries <= 0 {
+		maxEntries = 200
+	}
+
+	absPath, err := validatePath(workspacePath, args.Path)
+	if err != nil {
+		return nil, err
+	}
+	if st, err := os.Stat(absPath); err != nil || !st.IsDir() {
+		return nil, fmt.Errorf("not a directory: %s", args.Path)
+	}
+
+	dirs := 0
+	root, err := buildTreeNode(ctx, absPath, filepath.Base(absPath), &dirs)
+	if err != nil {
+		return nil, err
+	}
+
+	var b strings.Builder
+	printed := 0
+	truncated := false
+	var render func(n *treeNode, level int)
+	render = func(n *treeNode, level int) {
+		if printed >= maxEntries {
+			truncated = true
+			return
+		}
+		printed++
+		name := n.name + "/"
+		if level == 0 {
+			name = args.Path
+			if !strings.HasSuffix(name, "/") {
+				name += "/"
+			}
+		}
+		fmt.Fprintf(&b, "%s%s (%d files%s)", strings.Repeat("  ", level), name, n.files, formatLangShare(n.langs, n.files))
+		if n.purpose != "" {
+			b.WriteString(" — ")
+			b.WriteString(n.purpose)
+		}
+		b.WriteString("\n")
+		if level+1 > depth {
+			if len(n.children) > 0 {
+				fmt.Fprintf(&b, "%s… %d subdirectories\n", strings.Repeat("  ", level+1), len(n.children))
+			}
+			return
+		}
+		for _, c := range n.children {
+			render(c, level+1)
+		}
+	}
+	render(root, 0)
+
+	return &SummarizeTreeResult{
+		Path:       args.Path,
+		Depth:      depth,
+		TotalFiles: root.files,
+		TotalDirs:  dirs,
+		Tree:       strings.TrimRight(b.String(), "\n"),
+		Truncated:  truncated,
+	}, nil
+}
+
+// buildTreeNode walks dir recursively and aggregates file and language counts.
+func buildTreeNode(ctx context.Context, dir, name string, dirs *int) (*treeNode, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read directory: %w", err)
+	}
+	n := &treeNode{name: name, langs: make(map[string]int)}
+	readme := ""
+	for _, e := range entries {
+		ename := e.Name()
+		if e.IsDir() {
+			if (strings.HasPrefix(ename, ".") && ename != ".github") || skippedTreeDirs[ename] {
+				continue
+			}
+			*dirs++
+			child, err := buildTreeNode(ctx, filepath.Join(dir, ename), ename, dirs)
+			if err != nil {
+				if ctx.Err() != nil {
+					return nil, err
+				}
+				continue
+			}
+			n.files += child.files
+			for l, c := range child.langs {
+				n.langs[l] += c
+			}
+			n.children = append(n.children, child)
+			continue
+		}
+		if strings.HasPrefix(ename, ".") {
+			continue
+		}
+		n.files++
+		if lang := detectLanguage(ename); lang != "text" {
+			n.langs[lang]++
+		}
+		if readme == "" && strings.HasPrefix(strings.ToLower(ename), "readme") {
+			readme = readmeSummary(filepath.Join(dir, ename))
+		}
+	}
+	sort.Slice(n.children, func(i, j int) bool { return n.children[i].name < n.children[j].name })
+	n.purpose = readme
+	if n.purpose == "" {
+		n.purpose = dirPurposes[strings.ToLower(name)]
+	}
+	return n, nil
+}
+
+// readmeSummary returns the first prose line of a README, trimmed for display.
+func readmeSummary(path string) string {
+	f, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for i := 0; scanner.Scan() && i < 40; i++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "<") || strings.HasPrefix(line, "[!") || strings.HasPrefix(line, "![") || strings.HasPrefix(line, "=") || strings.HasPrefix(line, "-") {
+			continue
+		}
+		line = textutil.TruncateWidth(line, 101)
+		return line
+	}
+	return ""
+}
+
+// formatLangShare renders the top languages as percentages of all files.
+func formatLangShare(langs map[string]int, total int) string {
+	if len(langs) == 0 || total == 0 {
+		return ""
+	}
+	type lc struct {
+		lang  string
+		count int
+	}
+	list := make([]lc, 0, len(langs))
+	for l, c := range langs {
+		list = append(list, lc{l, c})
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].count != list[j].count {
+			return list[i].count > list[j].count
+		}
+		return list[i].lang < list[j].lang
+	})
+	if len(list) > 3 {
+		list = list[:3]
+	}
+	parts := make([]string, 0, len(list))
+	for _, it := range list {
+		parts = append(parts, fmt.Sprintf("%s %d%%", it.lang, it.count*100/total))
+	}
+	return "; " + strings.Join(parts, ", ")
+}
@@ -0,0 +1,67 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSummarizeTree_CountsAndPurpose(t *testing.T) {
+	workspace := t.TempDir()
+	files := map[string]string{
+		"main.go":                 "package main",
+		"cmd/app/main.go":         "package main",
+		"internal/a/a.go":         "package a",
+		"internal/a/a_test.go":    "package a",
+		"docs/README.md":          "# Docs\n\nUser-facing guides for the project.\n",
+		"node_modules/x/index.js": "ignored",
+	}
+	for rel, content := range files {
+		p := filepath.Join(workspace, rel)
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+
+	reg := NewRegistry()
+	if err := RegisterSummarizeTree(reg, workspace); err != nil {
+		t.Fatalf("register summarize_tree: %v", err)
+	}
+	raw, _ := json.Marshal(SummarizeTreeArgs{Depth: 1})
+	res, err := reg.Invoke(context.Background(), "summarize_tree", raw)
+	if err != nil {
+		t.Fatalf("invoke summarize_tree: %v", err)
+	}
+	sr := res.(*SummarizeTreeResult)
+	if sr.TotalFiles != 5 {
+		t.Fatalf("expected 5 files (node_modules skipped), got %d", sr.TotalFiles)
+	}
+	if !strings.Contains(sr.Tree, "internal/ (2 files; go 100%) — private packages") {
+		t.Fatalf("expected annotated internal dir, got:\n%s", sr.Tree)
+	}
+	if !strings.Contains(sr.Tree, "docs/ (1 files; markdown 100%) — User-facing guides for the project.") {
+		t.Fatalf("expected README-derived purpose, got:\n%s", sr.Tree)
+	}
+	if !strings.Contains(sr.Tree, "… 1 subdirectories") {
+		t.Fatalf("expected depth-limited collapse marker, got:\n%s", sr.Tree)
+	}
+	if strings.Contains(sr.Tree, "node_modules") {
+		t.Fatalf("node_modules should be skipped:\n%s", sr.Tree)
+	}
+}
+
+func TestSummarizeTree_RejectsFile(t *testing.T) {
+	workspace := t.TempDir()
+	if err := os.WriteFile(filepath.Join(workspace, "f.txt"), []byte("x"), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if _, err := summarizeTree(context.Background(), workspace, SummarizeTreeArgs{Path: "f.txt"}); err == nil {
+		t.Fatalf("expected error for non-directory path")
+	}
+}
@@ -0,0 +1,86 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// UndoEditArgs represents the arguments for the undo_edit tool.
+type UndoEditArgs struct {
+	// All undoes every edit of the conversation instead of only the latest
+	All bool `json:"all,omitempty"`
+}
+
+// UndoEditResult reports the files an undo restored.
+type UndoEditResult struct {
+	Reverted []string `json:"reverted,omitempty"`
+	Message  string   `json:"message"`
+}
+
+// EditUndo gives the undo_edit tool the conversation's edit journal. UndoEdits asks
+// the user for approval before changing files.
+type EditUndo interface {
+	UndoEdits(all bool) ([]string, error)
+}
+
+type editUndoKey struct{}
+
+// WithEditUndo lets undo_edit calls made with ctx undo the conversation's edits.
+func WithEditUndo(ctx context.Context, u EditUndo) context.Context {
+	return context.WithValue(ctx, editUndoKey{}, u)
+}
+
+// RegisterUndoEdit registers the undo_edit tool, which restores files changed by this
+// conversation's applied edits from the workspace's edit journal.
+func RegisterUndoEdit(registry *Registry) error {
+	return registry.Register(Definition{
+		Name:        "undo_edit",
+		Description: "Undo this conversation's latest applied edit (all files of one approved proposal), or with all=true every edit it made, restoring the files from the edit journal.",
+		// The undo asks for approval itself
+		Safe: true,
+		JSONSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"all": map[string]interface{}{
+					"type":        "boolean",
+					"description": "Undo every edit of this conversation instead of only the latest (default false)",
+				},
+			},
+		},
+		Usage: "Use undo_edit when an edit you applied turned out wrong, rather than writing the old content back by hand. Only edits applied through Loom are journaled. Files changed outside Loom since the edit are refused, and the user must approve the undo.",
+		Examples: []string{
+			`{}`,
+			`{"all":true}`,
+		},
+		Handler: func(ctx context.Context, raw json.RawMessage) (interface{}, error) {
+			var args UndoEditArgs
+			if len(raw) > 0 {
+				if err := json.Unmarshal(raw, &args); err != nil {
+					return nil, fmt.Errorf("failed to parse arguments: %w", err)
+				}
+			}
+			return runUndoEdit(ctx, args)
+		},
+	})
+}
+
+func runUndoEdit(ctx context.Context, args UndoEditArgs) (*UndoEditResult, error) {
+	u, _ := ctx.Value(editUndoKey{}).(EditUndo)
+	if u == nil {
+		return nil, errors.New("undo needs an active conversation")
+	}
+	reverted, err := u.UndoEdits(args.All)
+	if err != nil {
+		if len(reverted) > 0 {
+			return nil, fmt.Errorf("reverted %s, then: %w", strings.Join(reverted, ", "), err)
+		}
+		return nil, err
+	}
+	if len(reverted) == 0 {
+		return &UndoEditResult{Message: "No edits of this conversation to undo"}, nil
+	}
+	return &UndoEditResult{Reverted: reverted, Message: fmt.Sprintf("Reverted %d file(s): %s", len(reverted), strings.Join(reverted, ", "))}, nil
+}
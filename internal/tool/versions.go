@@ -0,0 +1,100 @@
+package tool
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// ArgsAdapter rewrites arguments written for one schema version of a tool into the
+// shape of the next version.
+type ArgsAdapter func(args map[string]any) (map[string]any, error)
+
+// schemaVersion returns the tool's current argument schema version.
+func (d Definition) schemaVersion() int {
+	if d.Version < 1 {
+		return 1
+	}
+	return d.Version
+}
+
+// SchemaVersion returns the current argument schema version of a tool, or 0 if the
+// tool is unknown.
+func (r *Registry) SchemaVersion(name string) int {
+	def, ok := r.Get(name)
+	if !ok {
+		return 0
+	}
+	return def.schemaVersion()
+}
+
+// SchemaVersions returns the current argument schema version of every tool.
+func (r *Registry) SchemaVersions() map[string]int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	out := make(map[string]int, len(r.tools))
+	for name, def := range r.tools {
+		out[name] = def.schemaVersion()
+	}
+	return out
+}
+
+// Negotiate checks that calls written against the given schema version of a tool can
+// still be executed, and returns the version they will run as. Version 0 asks for the
+// current version. Newer versions than the registry knows, and older ones whose
+// adapter chain is incomplete, are rejected.
+func (r *Registry) Negotiate(name string, version int) (int, error) {
+	def, ok := r.Get(name)
+	if !ok {
+		return 0, fmt.Errorf("unknown tool %q", name)
+	}
+	current := def.schemaVersion()
+	if version == 0 || version == current {
+		return current, nil
+	}
+	if version > current {
+		return 0, fmt.Errorf("tool %q supports schema versions up to v%d, not v%d", name, current, version)
+	}
+	for v := version; v < current; v++ {
+		if def.Adapters[v] == nil {
+			return 0, fmt.Errorf("tool %q can no longer run v%d arguments (no adapter from v%d)", name, version, v)
+		}
+	}
+	return current, nil
+}
+
+// Upgrade rewrites the arguments of a call made against an older schema version into
+// the tool's current shape, one adapter at a time, and marks the call as current.
+// Calls that are already current, or name unknown tools, are left unchanged.
+func (r *Registry) Upgrade(call *ToolCall) error {
+	if call == nil || call.Version == 0 {
+		return nil
+	}
+	def, ok := r.Get(call.Name)
+	if !ok {
+		return nil
+	}
+	current, err := r.Negotiate(call.Name, call.Version)
+	if err != nil {
+		return err
+	}
+	if call.Version < current {
+		args := map[string]any{}
+		if len(call.Args) > 0 {
+			if err := json.Unmarshal(call.Args, &args); err != nil {
+				return fmt.Errorf("invalid v%d arguments for %s: %w", call.Version, call.Name, err)
+			}
+		}
+		for v := call.Version; v < current; v++ {
+			if args, err = def.Adapters[v](args); err != nil {
+				return fmt.Errorf("upgrade %s arguments from v%d: %w", call.Name, v, err)
+			}
+		}
+		raw, err := json.Marshal(args)
+		if err != nil {
+			return err
+		}
+		call.Args = raw
+	}
+	call.Version = 0
+	return nil
+}
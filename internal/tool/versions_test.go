@@ -0,0 +1,106 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// registerGreet registers a tool at schema v3: v1 took "who", v2 renamed it to "name",
+// v3 split it into "first" and "last".
+func registerGreet(t *testing.T, reg *Registry) {
+	t.Helper()
+	err := reg.Register(Definition{
+		Name:    "greet",
+		Safe:    true,
+		Version: 3,
+		Adapters: map[int]ArgsAdapter{
+			1: func(args map[string]any) (map[string]any, error) {
+				return map[string]any{"name": args["who"]}, nil
+			},
+			2: func(args map[string]any) (map[string]any, error) {
+				first, last, _ := strings.Cut(args["name"].(string), " ")
+				return map[string]any{"first": first, "last": last}, nil
+			},
+		},
+		Handler: func(ctx context.Context, raw json.RawMessage) (interface{}, error) {
+			var args struct{ First, Last string }
+			if err := json.Unmarshal(raw, &args); err != nil {
+				return nil, err
+			}
+			return "hello " + args.Last + ", " + args.First, nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestUpgrade_RunsOlderArgumentShapes(t *testing.T) {
+	reg := NewRegistry()
+	registerGreet(t, reg)
+	calls := map[int]string{
+		0: `{"first":"Ada","last":"Lovelace"}`,
+		1: `{"who":"Ada Lovelace"}`,
+		2: `{"name":"Ada Lovelace"}`,
+		3: `{"first":"Ada","last":"Lovelace"}`,
+	}
+	for version, args := range calls {
+		res, err := reg.InvokeToolCall(context.Background(), &ToolCall{Name: "greet", Args: json.RawMessage(args), Version: version})
+		if err != nil || res.Content != "hello Lovelace, Ada" {
+			t.Errorf("v%d: unexpected result %v %+v", version, err, res)
+		}
+	}
+
+	call := &ToolCall{Name: "greet", Args: json.RawMessage(`{"who":"Ada Lovelace"}`), Version: 1}
+	if err := reg.Upgrade(call); err != nil {
+		t.Fatal(err)
+	}
+	if call.Version != 0 || string(call.Args) != `{"first":"Ada","last":"Lovelace"}` {
+		t.Errorf("unexpected upgraded call: %+v", call)
+	}
+}
+
+func TestNegotiate(t *testing.T) {
+	reg := NewRegistry()
+	registerGreet(t, reg)
+	_ = reg.Register(Definition{
+		Name: "plain",
+		Handler: func(ctx context.Context, raw json.RawMessage) (interface{}, error) {
+			return "ok", nil
+		},
+	})
+	_ = reg.Register(Definition{
+		Name:     "broken",
+		Version:  3,
+		Adapters: map[int]ArgsAdapter{2: func(args map[string]any) (map[string]any, error) { return args, nil }},
+		Handler: func(ctx context.Context, raw json.RawMessage) (interface{}, error) {
+			return "ok", nil
+		},
+	})
+
+	if v, err := reg.Negotiate("greet", 1); err != nil || v != 3 {
+		t.Errorf("greet v1: got %d %v", v, err)
+	}
+	if v, err := reg.Negotiate("plain", 0); err != nil || v != 1 {
+		t.Errorf("plain: got %d %v", v, err)
+	}
+	if _, err := reg.Negotiate("greet", 4); err == nil {
+		t.Error("expected newer version to be rejected")
+	}
+	if _, err := reg.Negotiate("broken", 1); err == nil {
+		t.Error("expected missing adapter to be rejected")
+	}
+	if _, err := reg.Negotiate("missing", 1); err == nil {
+		t.Error("expected unknown tool to be rejected")
+	}
+	if got := reg.SchemaVersions(); got["greet"] != 3 || got["plain"] != 1 {
+		t.Errorf("unexpected versions: %v", got)
+	}
+
+	res, _ := reg.InvokeToolCall(context.Background(), &ToolCall{Name: "broken", Args: json.RawMessage(`{}`), Version: 1})
+	if !strings.HasPrefix(res.Content, "Error:") {
+		t.Errorf("expected an error result, got %q", res.Content)
+	}
+}
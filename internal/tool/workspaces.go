@@ -0,0 +1,129 @@
+package tool
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"sort"
+	"strings"
+)
+
+// WorkspaceArg is the argument naming the workspace root a tool call runs in when
+// more than one root is open.
+const WorkspaceArg = "workspace"
+
+// rootlessTools keep session state or work outside the workspace, so they take no
+// workspace qualifier.
+var rootlessTools = map[string]bool{
+	"todo_list":        true,
+	"user_choice":      true,
+	"ask_user":         true,
+	"finalize":         true,
+	"annotate_code":    true,
+	"checkpoint":       true,
+	"undo_edit":        true,
+	"tool_help":        true,
+	"memories":         true,
+	"search_knowledge": true,
+	"http_request":     true,
+	"execute_snippet":  true,
+	"reset_shell":      true,
+}
+
+// SetWorkspaceRoots lets calls of the registry's tools run in other workspace roots:
+// a call whose workspace argument names one of roots is handed to that root's
+// registry; without it, or with primary, the call runs here. Empty roots turn the
+// qualifier off.
+func (r *Registry) SetWorkspaceRoots(primary string, roots map[string]*Registry) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.primaryRoot = primary
+	r.roots = maps.Clone(roots)
+}
+
+// WorkspaceRootNames returns the names a workspace argument accepts, the primary root
+// first, or nil when only one root is open.
+func (r *Registry) WorkspaceRootNames() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.rootNamesLocked()
+}
+
+func (r *Registry) primaryRootName() string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.primaryRoot
+}
+
+func (r *Registry) rootNamesLocked() []string {
+	if len(r.roots) == 0 {
+		return nil
+	}
+	names := make([]string, 0, len(r.roots))
+	for name := range r.roots {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return append([]string{r.primaryRoot}, names...)
+}
+
+// withWorkspaceArg adds the workspace argument to a tool's schema.
+func withWorkspaceArg(s Schema, primary string, names []string) Schema {
+	if rootlessTools[s.Name] || s.Parameters == nil {
+		return s
+	}
+	params := maps.Clone(s.Parameters)
+	props, _ := params["properties"].(map[string]interface{})
+	props = maps.Clone(props)
+	if props == nil {
+		props = map[string]interface{}{}
+	}
+	props[WorkspaceArg] = map[string]interface{}{
+		"type":        "string",
+		"enum":        names,
+		"description": fmt.Sprintf("Workspace root to run in; paths are relative to it (default %s)", primary),
+	}
+	params["properties"] = props
+	s.Parameters = params
+	return s
+}
+
+// rootFor returns the registry a call of the named tool runs in and its arguments
+// without the workspace qualifier. A nil registry means this one.
+func (r *Registry) rootFor(name string, args json.RawMessage) (*Registry, json.RawMessage, error) {
+	r.mu.RLock()
+	primary, roots := r.primaryRoot, r.roots
+	r.mu.RUnlock()
+	if len(roots) == 0 || rootlessTools[name] {
+		return nil, args, nil
+	}
+	var fields map[string]json.RawMessage
+	if json.Unmarshal(args, &fields) != nil {
+		return nil, args, nil
+	}
+	raw, ok := fields[WorkspaceArg]
+	if !ok {
+		return nil, args, nil
+	}
+	var ws string
+	if err := json.Unmarshal(raw, &ws); err != nil {
+		return nil, nil, fmt.Errorf("%s must be a string", WorkspaceArg)
+	}
+	delete(fields, WorkspaceArg)
+	rest, err := json.Marshal(fields)
+	if err != nil {
+		return nil, nil, err
+	}
+	ws = strings.TrimSpace(ws)
+	if ws == "" || ws == primary {
+		return nil, rest, nil
+	}
+	root, ok := roots[ws]
+	if !ok {
+		return nil, nil, fmt.Errorf("unknown workspace %q; open roots: %s", ws, strings.Join(r.WorkspaceRootNames(), ", "))
+	}
+	if _, ok := root.Get(name); !ok {
+		return nil, nil, fmt.Errorf("%s is not available in workspace %q", name, ws)
+	}
+	return root, rest, nil
+}
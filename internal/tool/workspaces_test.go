@@ -0,0 +1,72 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRegistry_WorkspaceRoots(t *testing.T) {
+	frontend, backend := t.TempDir(), t.TempDir()
+	if err := os.WriteFile(filepath.Join(frontend, "app.ts"), []byte("front\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(backend, "main.go"), []byte("back\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	primary := NewRegistry()
+	if err := RegisterReadFile(primary, frontend); err != nil {
+		t.Fatal(err)
+	}
+	if err := RegisterTodoList(primary); err != nil {
+		t.Fatal(err)
+	}
+	root := NewRegistry()
+	if err := RegisterReadFile(root, backend); err != nil {
+		t.Fatal(err)
+	}
+
+	// A single root adds no argument
+	for _, s := range primary.Schemas() {
+		if _, ok := s.Parameters["properties"].(map[string]interface{})[WorkspaceArg]; ok {
+			t.Fatalf("%s has a workspace argument with one root", s.Name)
+		}
+	}
+
+	primary.SetWorkspaceRoots("frontend", map[string]*Registry{"backend": root})
+	if got := strings.Join(primary.WorkspaceRootNames(), ","); got != "frontend,backend" {
+		t.Fatalf("unexpected roots %s", got)
+	}
+	for _, s := range primary.Schemas() {
+		_, ok := s.Parameters["properties"].(map[string]interface{})[WorkspaceArg]
+		if ok != (s.Name == "read_file") {
+			t.Fatalf("%s: workspace argument = %v", s.Name, ok)
+		}
+	}
+	if def, _ := primary.Get("read_file"); def.Schema.Parameters["properties"].(map[string]interface{})[WorkspaceArg] != nil {
+		t.Fatal("the registered schema must not change")
+	}
+
+	read := func(args string) string {
+		res, err := primary.InvokeToolCall(context.Background(), &ToolCall{Name: "read_file", Args: json.RawMessage(args)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return res.Content
+	}
+	if got := read(`{"path":"main.go","workspace":"backend"}`); !strings.Contains(got, "back") {
+		t.Fatalf("expected the backend file, got %s", got)
+	}
+	if got := read(`{"path":"app.ts","workspace":"frontend"}`); !strings.Contains(got, "front") {
+		t.Fatalf("expected the frontend file, got %s", got)
+	}
+	if got := read(`{"path":"app.ts"}`); !strings.Contains(got, "front") {
+		t.Fatalf("expected the primary root by default, got %s", got)
+	}
+	if got := read(`{"path":"main.go","workspace":"nope"}`); !strings.Contains(got, `unknown workspace "nope"`) {
+		t.Fatalf("expected an unknown root error, got %s", got)
+	}
+}
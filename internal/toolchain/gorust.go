@@ -0,0 +1,60 @@
+package toolchain
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// detectGo reads the toolchain or go directive of go.mod. The go command switches to
+// that version by itself; a matching golang.org/dl install in ~/sdk is used directly.
+func detectGo(root string) (Toolchain, bool) {
+	data, err := os.ReadFile(filepath.Join(root, "go.mod"))
+	if err != nil {
+		return Toolchain{}, false
+	}
+	var goVersion, toolchain string
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		switch fields[0] {
+		case "go":
+			goVersion = fields[1]
+		case "toolchain":
+			toolchain = strings.TrimPrefix(fields[1], "go")
+		}
+	}
+	version := toolchain
+	if version == "" {
+		version = goVersion
+	}
+	if version == "" {
+		return Toolchain{}, false
+	}
+	t := Toolchain{Language: Go, Version: version, Source: "go.mod"}
+	if sdk := filepath.Join(homeDir(), "sdk", "go"+version); isDir(sdk) {
+		t.Bin = binDir(sdk)
+	} else {
+		t.Note = "the go command selects it"
+	}
+	return t, true
+}
+
+// detectRust reads the channel of rust-toolchain.toml or a legacy rust-toolchain
+// file. rustup honours the file itself, so nothing is changed for commands.
+func detectRust(root string) (Toolchain, bool) {
+	for _, source := range []string{"rust-toolchain.toml", "rust-toolchain"} {
+		data, err := os.ReadFile(filepath.Join(root, source))
+		if err != nil {
+			continue
+		}
+		channel := tomlString(string(data), "toolchain", "channel")
+		if channel == "" && !strings.Contains(string(data), "[toolchain]") {
+			channel, _ = readFirstLine(filepath.Join(root, source))
+		}
+		return Toolchain{Language: Rust, Version: channel, Source: source, Note: "rustup selects it"}, true
+	}
+	return Toolchain{}, false
+}
@@ -0,0 +1,99 @@
+package toolchain
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// detectNode reads the Node version from .nvmrc or .node-version and finds a
+// matching install from nvm or fnm.
+func detectNode(root string) (Toolchain, bool) {
+	for _, source := range []string{".nvmrc", ".node-version"} {
+		version, ok := readFirstLine(filepath.Join(root, source))
+		if !ok {
+			continue
+		}
+		t := Toolchain{Language: Node, Version: version, Source: source}
+		want := strings.TrimPrefix(strings.TrimPrefix(version, "v"), "V")
+		if _, err := strconv.Atoi(strings.SplitN(want, ".", 2)[0]); err != nil {
+			// Aliases such as lts/* or node need nvm itself to resolve
+			t.Note = "version aliases are not resolved; commands use the default on PATH"
+			return t, true
+		}
+		if prefix := nodeInstall(want); prefix != "" {
+			t.Bin = binDir(prefix)
+		} else {
+			t.Note = notInstalled("Node " + version)
+		}
+		return t, true
+	}
+	return Toolchain{}, false
+}
+
+// nodeInstall returns the newest nvm or fnm install matching a version prefix such
+// as "18" or "18.17".
+func nodeInstall(want string) string {
+	home := homeDir()
+	nvm := os.Getenv("NVM_DIR")
+	if nvm == "" {
+		nvm = filepath.Join(home, ".nvm")
+	}
+	fnm := os.Getenv("FNM_DIR")
+	if fnm == "" {
+		fnm = filepath.Join(home, ".local", "share", "fnm")
+	}
+
+	best, bestVersion := "", []int(nil)
+	consider := func(dir string, layout func(string) string) {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return
+		}
+		for _, e := range entries {
+			v := strings.TrimPrefix(e.Name(), "v")
+			if !e.IsDir() || (v != want && !strings.HasPrefix(v, want+".")) {
+				continue
+			}
+			parsed := parseVersion(v)
+			if best == "" || compareVersions(parsed, bestVersion) > 0 {
+				best, bestVersion = layout(filepath.Join(dir, e.Name())), parsed
+			}
+		}
+	}
+	consider(filepath.Join(nvm, "versions", "node"), func(p string) string { return p })
+	consider(filepath.Join(fnm, "node-versions"), func(p string) string { return filepath.Join(p, "installation") })
+	return best
+}
+
+func parseVersion(v string) []int {
+	var out []int
+	for _, part := range strings.Split(v, ".") {
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			break
+		}
+		out = append(out, n)
+	}
+	return out
+}
+
+func compareVersions(a, b []int) int {
+	for i := 0; i < len(a) || i < len(b); i++ {
+		var x, y int
+		if i < len(a) {
+			x = a[i]
+		}
+		if i < len(b) {
+			y = b[i]
+		}
+		if x != y {
+			if x > y {
+				return 1
+			}
+			return -1
+		}
+	}
+	return 0
+}
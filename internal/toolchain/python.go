@@ -0,0 +1,176 @@
+package toolchain
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+// detectPython finds the project's Python environment: an in-project virtualenv,
+// then the Poetry environment for pyproject.toml, then a pyenv .python-version.
+func detectPython(root string) (Toolchain, bool) {
+	for _, name := range []string{".venv", "venv"} {
+		venv := filepath.Join(root, name)
+		if t, ok := virtualenv(venv, name); ok {
+			return t, true
+		}
+	}
+
+	pyproject, err := os.ReadFile(filepath.Join(root, "pyproject.toml"))
+	if err == nil && strings.Contains(string(pyproject), "[tool.poetry]") {
+		if venv := poetryVenv(root, string(pyproject)); venv != "" {
+			if t, ok := virtualenv(venv, "pyproject.toml"); ok {
+				t.Note = "Poetry environment"
+				return t, true
+			}
+		}
+		return Toolchain{Language: Python, Source: "pyproject.toml", Note: notInstalled("the Poetry environment")}, true
+	}
+
+	if version, ok := readFirstLine(filepath.Join(root, ".python-version")); ok {
+		t := Toolchain{Language: Python, Version: version, Source: ".python-version"}
+		pyenvRoot := os.Getenv("PYENV_ROOT")
+		if pyenvRoot == "" {
+			pyenvRoot = filepath.Join(homeDir(), ".pyenv")
+		}
+		if prefix := filepath.Join(pyenvRoot, "versions", version); isDir(prefix) {
+			t.Bin = binDir(prefix)
+		} else {
+			t.Note = notInstalled("Python " + version)
+		}
+		return t, true
+	}
+	return Toolchain{}, false
+}
+
+// virtualenv describes the virtualenv at dir, if there is one.
+func virtualenv(dir, source string) (Toolchain, bool) {
+	cfg, err := os.ReadFile(filepath.Join(dir, "pyvenv.cfg"))
+	if err != nil {
+		return Toolchain{}, false
+	}
+	t := Toolchain{
+		Language: Python,
+		Source:   source,
+		Bin:      binDir(dir),
+		Env:      map[string]string{"VIRTUAL_ENV": dir},
+	}
+	for _, line := range strings.Split(string(cfg), "\n") {
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		// venv writes "version", virtualenv writes "version_info"
+		switch strings.TrimSpace(key) {
+		case "version", "version_info":
+			t.Version = strings.TrimSpace(value)
+		}
+	}
+	return t, true
+}
+
+// poetryVenv returns the newest Poetry environment for the project at root. Poetry
+// names it after the project and a hash of the project path, so it can be found
+// without running poetry.
+func poetryVenv(root, pyproject string) string {
+	dir := poetryVenvDir()
+	if dir == "" {
+		return ""
+	}
+	name := tomlString(pyproject, "tool.poetry", "name")
+	if name == "" {
+		name = tomlString(pyproject, "project", "name")
+	}
+	if name == "" {
+		name = "virtualenv"
+	}
+	name = strings.Map(func(r rune) rune {
+		if strings.ContainsRune(" $`!*@\"\\\r\n\t", r) {
+			return '_'
+		}
+		return r
+	}, strings.ToLower(name))
+	if len(name) > 42 {
+		name = name[:42]
+	}
+
+	abs, err := filepath.Abs(root)
+	if err != nil {
+		return ""
+	}
+	if resolved, err := filepath.EvalSymlinks(abs); err == nil {
+		abs = resolved
+	}
+	prefix := name + "-" + poetryHash(abs) + "-py"
+
+	matches, _ := filepath.Glob(filepath.Join(dir, globEscape(prefix)+"*"))
+	if len(matches) == 0 {
+		return ""
+	}
+	sort.Strings(matches)
+	return matches[len(matches)-1]
+}
+
+// poetryHash is the part of a Poetry environment name derived from the project path.
+func poetryHash(path string) string {
+	sum := sha256.Sum256([]byte(path))
+	return base64.URLEncoding.EncodeToString(sum[:])[:8]
+}
+
+// poetryVenvDir is where Poetry keeps environments that are not in-project.
+func poetryVenvDir() string {
+	if dir := os.Getenv("POETRY_VIRTUALENVS_PATH"); dir != "" {
+		return dir
+	}
+	if dir := os.Getenv("POETRY_CACHE_DIR"); dir != "" {
+		return filepath.Join(dir, "virtualenvs")
+	}
+	home := homeDir()
+	switch runtime.GOOS {
+	case "darwin":
+		return filepath.Join(home, "Library", "Caches", "pypoetry", "virtualenvs")
+	case "windows":
+		return filepath.Join(os.Getenv("LOCALAPPDATA"), "pypoetry", "Cache", "virtualenvs")
+	}
+	if dir := os.Getenv("XDG_CACHE_HOME"); dir != "" {
+		return filepath.Join(dir, "pypoetry", "virtualenvs")
+	}
+	if home == "" {
+		return ""
+	}
+	return filepath.Join(home, ".cache", "pypoetry", "virtualenvs")
+}
+
+func globEscape(s string) string {
+	return strings.NewReplacer("*", `\*`, "?", `\?`, "[", `\[`).Replace(s)
+}
+
+// tomlString returns a quoted string value of key in a TOML section. It reads only
+// simple key = "value" lines, which is all the toolchain files need.
+func tomlString(content, section, key string) string {
+	current := ""
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			current = strings.TrimSpace(strings.Trim(line, "[]"))
+			continue
+		}
+		if current != section {
+			continue
+		}
+		k, v, ok := strings.Cut(line, "=")
+		if !ok || strings.TrimSpace(k) != key {
+			continue
+		}
+		v = strings.TrimSpace(v)
+		if i := strings.Index(v, " #"); i >= 0 {
+			v = strings.TrimSpace(v[:i])
+		}
+		return strings.Trim(v, `"'`)
+	}
+	return ""
+}
@@ -0,0 +1,187 @@
+// Package toolchain detects the language toolchains a project asks for (a Python
+// virtualenv or Poetry environment, a Node version from .nvmrc, a Go version from
+// go.mod, a Rust toolchain file) and builds the environment that makes commands run
+// with them.
+package toolchain
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+// Languages a toolchain is detected for.
+const (
+	Python = "python"
+	Node   = "node"
+	Go     = "go"
+	Rust   = "rust"
+)
+
+// Toolchain is the toolchain a project asks for in one language.
+type Toolchain struct {
+	Language string `json:"language"`
+	// Version is the requested version as written in Source, e.g. "18" or "1.22.3"
+	Version string `json:"version,omitempty"`
+	// Source is the workspace file the toolchain was detected from
+	Source string `json:"source"`
+	// Bin is the directory put first on PATH; empty when the language's own tools
+	// already select the version (go, rustup) or no matching install was found
+	Bin string `json:"bin,omitempty"`
+	// Env holds variables set for commands, e.g. VIRTUAL_ENV
+	Env map[string]string `json:"env,omitempty"`
+	// Note explains how the toolchain is selected or why it is not used
+	Note string `json:"note,omitempty"`
+}
+
+// String describes the toolchain in one line.
+func (t Toolchain) String() string {
+	s := t.Language
+	if t.Version != "" {
+		s += " " + t.Version
+	}
+	s += " (" + t.Source
+	if t.Note != "" {
+		s += "; " + t.Note
+	}
+	return s + ")"
+}
+
+// Environment is the set of toolchains detected in a workspace.
+type Environment struct {
+	Toolchains []Toolchain `json:"toolchains"`
+}
+
+// Detect looks for toolchain files in the workspace root. Detection only reads
+// files; it never runs the toolchains.
+func Detect(root string) Environment {
+	var env Environment
+	for _, detect := range []func(string) (Toolchain, bool){detectPython, detectNode, detectGo, detectRust} {
+		if t, ok := detect(root); ok {
+			env.Toolchains = append(env.Toolchains, t)
+		}
+	}
+	return env
+}
+
+// Apply returns base with the toolchains' bin directories put first on PATH and
+// their variables set. Variables already in base are replaced.
+func (e Environment) Apply(base []string) []string {
+	if len(e.Toolchains) == 0 {
+		return base
+	}
+	set := map[string]string{}
+	var bins []string
+	for _, t := range e.Toolchains {
+		if t.Bin != "" {
+			bins = append(bins, t.Bin)
+		}
+		for k, v := range t.Env {
+			set[k] = v
+		}
+	}
+	if len(bins) > 0 {
+		path := ""
+		for _, kv := range base {
+			if k, v, ok := strings.Cut(kv, "="); ok && envKey(k) == envKey("PATH") {
+				path = v
+			}
+		}
+		if path != "" {
+			bins = append(bins, path)
+		}
+		set["PATH"] = strings.Join(bins, string(os.PathListSeparator))
+	}
+
+	out := make([]string, 0, len(base)+len(set))
+	for _, kv := range base {
+		k, _, _ := strings.Cut(kv, "=")
+		if _, ok := lookup(set, k); ok {
+			continue
+		}
+		out = append(out, kv)
+	}
+	keys := make([]string, 0, len(set))
+	for k := range set {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		out = append(out, k+"="+set[k])
+	}
+	return out
+}
+
+// Summary lists the detected toolchains, or returns "" when there are none.
+func (e Environment) Summary() string {
+	parts := make([]string, 0, len(e.Toolchains))
+	for _, t := range e.Toolchains {
+		parts = append(parts, t.String())
+	}
+	return strings.Join(parts, "; ")
+}
+
+// Env returns the current process environment with the workspace's toolchains applied.
+func Env(root string) []string {
+	return Detect(root).Apply(os.Environ())
+}
+
+// envKey normalizes a variable name; Windows names are case-insensitive.
+func envKey(k string) string {
+	if runtime.GOOS == "windows" {
+		return strings.ToUpper(k)
+	}
+	return k
+}
+
+func lookup(set map[string]string, k string) (string, bool) {
+	for name, v := range set {
+		if envKey(name) == envKey(k) {
+			return v, true
+		}
+	}
+	return "", false
+}
+
+// readFirstLine returns the first non-empty, non-comment line of a file.
+func readFirstLine(path string) (string, bool) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", false
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, "#") {
+			return line, true
+		}
+	}
+	return "", false
+}
+
+func isDir(path string) bool {
+	st, err := os.Stat(path)
+	return err == nil && st.IsDir()
+}
+
+func homeDir() string {
+	home, _ := os.UserHomeDir()
+	return home
+}
+
+// binDir returns the directory holding a virtualenv's or install's executables.
+func binDir(prefix string) string {
+	if runtime.GOOS == "windows" {
+		if isDir(filepath.Join(prefix, "Scripts")) {
+			return filepath.Join(prefix, "Scripts")
+		}
+		return prefix
+	}
+	return filepath.Join(prefix, "bin")
+}
+
+func notInstalled(what string) string {
+	return fmt.Sprintf("%s is not installed; commands use the default on PATH", what)
+}
@@ -0,0 +1,128 @@
+package toolchain
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func write(t *testing.T, root, rel, content string) {
+	t.Helper()
+	p := filepath.Join(root, filepath.FromSlash(rel))
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func find(env Environment, language string) (Toolchain, bool) {
+	for _, t := range env.Toolchains {
+		if t.Language == language {
+			return t, true
+		}
+	}
+	return Toolchain{}, false
+}
+
+func TestDetect_VenvNodeGoRust(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("NVM_DIR", filepath.Join(home, ".nvm"))
+	t.Setenv("FNM_DIR", filepath.Join(home, "fnm"))
+	for _, v := range []string{"v18.2.0", "v18.17.1", "v20.1.0"} {
+		if err := os.MkdirAll(filepath.Join(home, ".nvm", "versions", "node", v, "bin"), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	root := t.TempDir()
+	write(t, root, ".venv/pyvenv.cfg", "home = /usr/bin\nversion = 3.11.4\n")
+	write(t, root, ".nvmrc", "v18\n")
+	write(t, root, "go.mod", "module x\n\ngo 1.21\n\ntoolchain go1.22.3\n")
+	write(t, root, "rust-toolchain.toml", "[toolchain]\nchannel = \"1.75.0\" # pinned\n")
+
+	env := Detect(root)
+	py, ok := find(env, Python)
+	if !ok || py.Version != "3.11.4" || py.Bin != filepath.Join(root, ".venv", "bin") || py.Env["VIRTUAL_ENV"] != filepath.Join(root, ".venv") {
+		t.Errorf("unexpected python toolchain %+v", py)
+	}
+	node, _ := find(env, Node)
+	if node.Bin != filepath.Join(home, ".nvm", "versions", "node", "v18.17.1", "bin") {
+		t.Errorf("expected the newest v18 install, got %+v", node)
+	}
+	goTC, _ := find(env, Go)
+	if goTC.Version != "1.22.3" || goTC.Bin != "" {
+		t.Errorf("unexpected go toolchain %+v", goTC)
+	}
+	rust, _ := find(env, Rust)
+	if rust.Version != "1.75.0" || rust.Source != "rust-toolchain.toml" {
+		t.Errorf("unexpected rust toolchain %+v", rust)
+	}
+	if s := env.Summary(); !strings.Contains(s, "python 3.11.4 (.venv)") || !strings.Contains(s, "node v18 (.nvmrc)") {
+		t.Errorf("unexpected summary %q", s)
+	}
+}
+
+func TestDetect_PoetryEnvironment(t *testing.T) {
+	// Poetry: urlsafe_b64encode(sha256(path))[:8]
+	if got := poetryHash("/home/dev/my-app"); got != "VShRumw0" {
+		t.Fatalf("poetryHash = %s", got)
+	}
+
+	cache := t.TempDir()
+	t.Setenv("POETRY_VIRTUALENVS_PATH", cache)
+	root := t.TempDir()
+	write(t, root, "pyproject.toml", "[tool.poetry]\nname = \"My App\"\nversion = \"0.1.0\"\n")
+
+	py, ok := find(Detect(root), Python)
+	if !ok || py.Bin != "" || !strings.Contains(py.Note, "not installed") {
+		t.Fatalf("expected a missing Poetry environment, got %+v", py)
+	}
+
+	resolved, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	venv := filepath.Join(cache, "my_app-"+poetryHash(resolved)+"-py3.12")
+	write(t, venv, "pyvenv.cfg", "version_info = 3.12.1\n")
+	py, _ = find(Detect(root), Python)
+	if py.Bin != filepath.Join(venv, "bin") || py.Version != "3.12.1" || py.Note != "Poetry environment" {
+		t.Fatalf("unexpected poetry toolchain %+v", py)
+	}
+}
+
+func TestEnvironmentApply(t *testing.T) {
+	env := Environment{Toolchains: []Toolchain{
+		{Language: Python, Bin: "/p/.venv/bin", Env: map[string]string{"VIRTUAL_ENV": "/p/.venv"}},
+		{Language: Node, Bin: "/n/bin"},
+		{Language: Rust},
+	}}
+	got := env.Apply([]string{"HOME=/h", "PATH=/usr/bin", "VIRTUAL_ENV=/old"})
+	want := "HOME=/h PATH=/p/.venv/bin" + string(os.PathListSeparator) + "/n/bin" + string(os.PathListSeparator) + "/usr/bin VIRTUAL_ENV=/p/.venv"
+	if strings.Join(got, " ") != want {
+		t.Fatalf("got %v, want %s", got, want)
+	}
+	if base := []string{"A=1"}; len(Environment{}.Apply(base)) != 1 {
+		t.Fatal("an empty environment should leave the base unchanged")
+	}
+}
+
+func TestDetect_NodeAliasAndPythonVersionFile(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("PYENV_ROOT", t.TempDir())
+	root := t.TempDir()
+	write(t, root, ".node-version", "lts/*\n")
+	write(t, root, ".python-version", "3.10.2\n")
+	env := Detect(root)
+	node, _ := find(env, Node)
+	if node.Bin != "" || !strings.Contains(node.Note, "aliases") {
+		t.Errorf("unexpected node toolchain %+v", node)
+	}
+	py, _ := find(env, Python)
+	if py.Version != "3.10.2" || py.Source != ".python-version" || py.Bin != "" {
+		t.Errorf("unexpected python toolchain %+v", py)
+	}
+}
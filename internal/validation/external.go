@@ -0,0 +1,297 @@
+package validation
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// runTool runs name with content on stdin from dir. A non-zero exit is reported as an
+// *exec.ExitError alongside the captured output; a tool that is missing or does not
+// finish before ctx expires returns errUnavailable.
+func runTool(ctx context.Context, dir string, content []byte, name string, args ...string) (string, string, error) {
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Dir = dir
+	cmd.Stdin = bytes.NewReader(content)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+	if ctx.Err() != nil {
+		return "", "", errUnavailable
+	}
+	var exit *exec.ExitError
+	if err != nil && !errors.As(err, &exit) {
+		return "", "", errUnavailable
+	}
+	return stdout.String(), stderr.String(), err
+}
+
+// lookTool finds name in the workspace's node_modules/.bin, then on PATH.
+func lookTool(workspace, name string) (string, bool) {
+	if workspace != "" {
+		local := filepath.Join(workspace, "node_modules", ".bin", name)
+		if info, err := os.Stat(local); err == nil && !info.IsDir() {
+			return local, true
+		}
+	}
+	bin, err := exec.LookPath(name)
+	return bin, err == nil
+}
+
+// locatedRe matches the "line:column:message" lines printed by the inline checker
+// scripts below.
+var locatedRe = regexp.MustCompile(`(?m)^(\d+):(\d+):(.*)$`)
+
+// locatedError parses the first "line:column:message" line of out.
+func locatedError(p, checker, out string) error {
+	m := locatedRe.FindStringSubmatch(out)
+	if m == nil {
+		return errUnavailable
+	}
+	line, _ := strconv.Atoi(m[1])
+	col, _ := strconv.Atoi(m[2])
+	return &SyntaxError{Path: p, Line: line, Column: col, Message: strings.TrimSpace(m[3]), Checker: checker}
+}
+
+const pythonScript = `import ast, sys
+try:
+    ast.parse(sys.stdin.buffer.read(), sys.argv[1])
+except SyntaxError as e:
+    print("%d:%d:%s" % (e.lineno or 0, e.offset or 0, e.msg))
+    sys.exit(1)
+`
+
+func checkPython(ctx context.Context, workspace, p string, content []byte) error {
+	python, ok := lookTool("", "python3")
+	if !ok {
+		return errUnavailable
+	}
+	out, _, err := runTool(ctx, workspace, content, python, "-c", pythonScript, p)
+	if err == nil || errors.Is(err, errUnavailable) {
+		return err
+	}
+	return locatedError(p, "python ast", out)
+}
+
+// tomlScript exits 3 on Pythons older than 3.11, which lack tomllib.
+const tomlScript = `import re, sys
+try:
+    import tomllib
+except ImportError:
+    sys.exit(3)
+try:
+    tomllib.loads(sys.stdin.buffer.read().decode("utf-8"))
+except tomllib.TOMLDecodeError as e:
+    msg = str(e)
+    m = re.search(r"\s*\(at line (\d+), column (\d+)\)$", msg)
+    if m:
+        print("%s:%s:%s" % (m.group(1), m.group(2), msg[:m.start()]))
+    else:
+        print("0:0:" + msg)
+    sys.exit(1)
+`
+
+func checkTOML(ctx context.Context, workspace, p string, content []byte) error {
+	python, ok := lookTool("", "python3")
+	if !ok {
+		return errUnavailable
+	}
+	out, _, err := runTool(ctx, workspace, content, python, "-c", tomlScript)
+	if err == nil || errors.Is(err, errUnavailable) {
+		return err
+	}
+	return locatedError(p, "tomllib", out)
+}
+
+// esbuildLoader picks the esbuild loader for a script extension. Plain .js files
+// often contain JSX, so all JavaScript is parsed with the jsx loader.
+func esbuildLoader(ext string) string {
+	switch ext {
+	case ".ts", ".mts", ".cts":
+		return "ts"
+	case ".tsx":
+		return "tsx"
+	}
+	return "jsx"
+}
+
+func isTypeScript(ext string) bool {
+	return strings.HasSuffix(ext, "ts") || ext == ".tsx"
+}
+
+// checkScript parses JavaScript and TypeScript with esbuild, falling back to the
+// workspace's typescript package and, for JavaScript without JSX, to node --check.
+func checkScript(ctx context.Context, workspace, p string, content []byte) error {
+	ext := strings.ToLower(path.Ext(p))
+	if esbuild, ok := lookTool(workspace, "esbuild"); ok {
+		if err := checkEsbuild(ctx, workspace, esbuild, p, ext, content); !errors.Is(err, errUnavailable) {
+			return err
+		}
+	}
+	node, ok := lookTool("", "node")
+	if !ok {
+		return errUnavailable
+	}
+	if ts := filepath.Join(workspace, "node_modules", "typescript"); workspace != "" && isDir(ts) {
+		if err := checkTypeScript(ctx, workspace, node, ts, p, content); !errors.Is(err, errUnavailable) {
+			return err
+		}
+	}
+	if isTypeScript(ext) || ext == ".jsx" {
+		return errUnavailable
+	}
+	return checkNode(ctx, workspace, node, p, ext, content)
+}
+
+var (
+	esbuildErrorRe    = regexp.MustCompile(`\[ERROR\] (.+)`)
+	esbuildLocationRe = regexp.MustCompile(`<stdin>:(\d+):(\d+):`)
+)
+
+func checkEsbuild(ctx context.Context, workspace, esbuild, p, ext string, content []byte) error {
+	_, stderr, err := runTool(ctx, workspace, content, esbuild,
+		"--loader="+esbuildLoader(ext), "--log-level=error", "--log-limit=1", "--color=false")
+	if err == nil || errors.Is(err, errUnavailable) {
+		return err
+	}
+	m := esbuildErrorRe.FindStringSubmatch(stderr)
+	if m == nil {
+		return errUnavailable
+	}
+	se := &SyntaxError{Path: p, Message: strings.TrimSpace(m[1]), Checker: "esbuild"}
+	if loc := esbuildLocationRe.FindStringSubmatch(stderr); loc != nil {
+		se.Line, _ = strconv.Atoi(loc[1])
+		se.Column, _ = strconv.Atoi(loc[2])
+		// esbuild columns are 0-based
+		se.Column++
+	}
+	return se
+}
+
+// typeScriptScript reports the first syntactic diagnostic of transpileModule, which
+// parses without type checking.
+const typeScriptScript = `const ts = require(process.argv[1]);
+let src = "";
+process.stdin.setEncoding("utf8");
+process.stdin.on("data", (d) => (src += d)).on("end", () => {
+  const r = ts.transpileModule(src, { fileName: process.argv[2], reportDiagnostics: true, compilerOptions: { jsx: "preserve" } });
+  const d = (r.diagnostics || []).find((d) => d.category === ts.DiagnosticCategory.Error && d.file);
+  if (d) {
+    const pos = d.file.getLineAndCharacterOfPosition(d.start);
+    console.log((pos.line + 1) + ":" + (pos.character + 1) + ":" + ts.flattenDiagnosticMessageText(d.messageText, " "));
+    process.exit(1);
+  }
+});
+`
+
+func checkTypeScript(ctx context.Context, workspace, node, ts, p string, content []byte) error {
+	out, _, err := runTool(ctx, workspace, content, node, "-e", typeScriptScript, ts, path.Base(p))
+	if err == nil || errors.Is(err, errUnavailable) {
+		return err
+	}
+	return locatedError(p, "typescript", out)
+}
+
+var (
+	nodeLocationRe = regexp.MustCompile(`(?m):(\d+)$`)
+	nodeErrorRe    = regexp.MustCompile(`(?m)^SyntaxError: (.+)$`)
+)
+
+// checkNode runs node --check on a temporary copy, keeping the extension so .mjs
+// and .cjs files are parsed as the right module kind.
+func checkNode(ctx context.Context, workspace, node, p, ext string, content []byte) error {
+	f, err := os.CreateTemp("", "loom-check-*"+ext)
+	if err != nil {
+		return errUnavailable
+	}
+	defer os.Remove(f.Name())
+	_, err = f.Write(content)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return errUnavailable
+	}
+	_, stderr, err := runTool(ctx, workspace, nil, node, "--check", f.Name())
+	if err == nil || errors.Is(err, errUnavailable) {
+		return err
+	}
+	m := nodeErrorRe.FindStringSubmatch(stderr)
+	if m == nil {
+		return errUnavailable
+	}
+	se := &SyntaxError{Path: p, Message: strings.TrimSpace(m[1]), Checker: "node --check"}
+	if loc := nodeLocationRe.FindStringSubmatch(stderr); loc != nil {
+		se.Line, _ = strconv.Atoi(loc[1])
+	}
+	// node does not understand JSX, which many projects write in plain .js files
+	if lines := strings.Split(string(content), "\n"); se.Line > 0 && se.Line <= len(lines) && strings.Contains(lines[se.Line-1], "<") {
+		return errUnavailable
+	}
+	return se
+}
+
+// shellcheckRe matches shellcheck's gcc format. Only SC1xxx codes are parse errors;
+// the rest are lint findings that should not block an edit.
+var shellcheckRe = regexp.MustCompile(`(?m)^-:(\d+):(\d+): error: (.*) \[SC1\d{3}\]$`)
+
+// bashErrorRe matches bash -n diagnostics such as "bash: line 3: syntax error ...".
+var bashErrorRe = regexp.MustCompile(`line (\d+): (.+)`)
+
+// checkShell parses shell scripts with shellcheck, falling back to bash -n. Scripts
+// whose shebang names another shell family are skipped.
+func checkShell(ctx context.Context, workspace, p string, content []byte) error {
+	shebang, _, _ := bytes.Cut(content, []byte("\n"))
+	if !bytes.HasPrefix(shebang, []byte("#!")) {
+		shebang = nil
+	}
+	for _, other := range []string{"zsh", "fish", "csh", "tcsh"} {
+		if bytes.Contains(shebang, []byte(other)) {
+			return errUnavailable
+		}
+	}
+	if shellcheck, ok := lookTool("", "shellcheck"); ok {
+		args := []string{"--format=gcc", "--severity=error"}
+		if shebang == nil {
+			args = append(args, "--shell=bash")
+		}
+		out, _, err := runTool(ctx, workspace, content, shellcheck, append(args, "-")...)
+		if err == nil || errors.Is(err, errUnavailable) {
+			return err
+		}
+		if m := shellcheckRe.FindStringSubmatch(out); m != nil {
+			line, _ := strconv.Atoi(m[1])
+			col, _ := strconv.Atoi(m[2])
+			return &SyntaxError{Path: p, Line: line, Column: col, Message: m[3], Checker: "shellcheck"}
+		}
+		// Error-level lint findings only: the script parses
+		return nil
+	}
+	bash, ok := lookTool("", "bash")
+	if !ok {
+		return errUnavailable
+	}
+	_, stderr, err := runTool(ctx, workspace, content, bash, "-n")
+	if err == nil || errors.Is(err, errUnavailable) {
+		return err
+	}
+	se := &SyntaxError{Path: p, Message: strings.TrimSpace(stderr), Checker: "bash -n"}
+	if m := bashErrorRe.FindStringSubmatch(stderr); m != nil {
+		se.Line, _ = strconv.Atoi(m[1])
+		se.Message = strings.TrimSpace(m[2])
+	}
+	return se
+}
+
+func isDir(p string) bool {
+	info, err := os.Stat(p)
+	return err == nil && info.IsDir()
+}
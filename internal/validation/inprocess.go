@@ -0,0 +1,96 @@
+package validation
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"go/parser"
+	"go/scanner"
+	"go/token"
+	"io"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+func checkGo(_ context.Context, _, p string, content []byte) error {
+	_, err := parser.ParseFile(token.NewFileSet(), p, content, parser.SkipObjectResolution)
+	if err == nil {
+		return nil
+	}
+	var list scanner.ErrorList
+	if errors.As(err, &list) && len(list) > 0 {
+		return &SyntaxError{Path: p, Line: list[0].Pos.Line, Column: list[0].Pos.Column, Message: list[0].Msg, Checker: "go/parser"}
+	}
+	return &SyntaxError{Path: p, Message: err.Error(), Checker: "go/parser"}
+}
+
+// isJSONC reports whether a .json file is commonly written with comments and trailing
+// commas, which strict JSON parsing rejects.
+func isJSONC(p string) bool {
+	base := path.Base(p)
+	return strings.HasPrefix(base, "tsconfig") || strings.HasPrefix(base, "jsconfig") ||
+		base == "devcontainer.json" || base == ".eslintrc.json" || base == ".babelrc.json" ||
+		strings.Contains("/"+p, "/.vscode/")
+}
+
+func checkJSON(_ context.Context, _, p string, content []byte) error {
+	if isJSONC(p) || json.Valid(content) {
+		return nil
+	}
+	dec := json.NewDecoder(bytes.NewReader(content))
+	for {
+		var v json.RawMessage
+		err := dec.Decode(&v)
+		if err == io.EOF {
+			return nil
+		}
+		if err == nil {
+			continue
+		}
+		se := &SyntaxError{Path: p, Message: err.Error(), Checker: "encoding/json"}
+		var syn *json.SyntaxError
+		if errors.As(err, &syn) {
+			se.Line, se.Column = position(content, syn.Offset)
+			se.Message = syn.Error()
+		}
+		return se
+	}
+}
+
+// position converts a byte offset into a 1-based line and column.
+func position(content []byte, offset int64) (int, int) {
+	offset = min(max(offset, 0), int64(len(content)))
+	before := content[:offset]
+	line := bytes.Count(before, []byte("\n")) + 1
+	return line, len(before) - bytes.LastIndexByte(before, '\n')
+}
+
+var yamlLineRe = regexp.MustCompile(`^yaml: line (\d+): `)
+
+func checkYAML(_ context.Context, _, p string, content []byte) error {
+	// Helm charts and other templated YAML only parse after rendering
+	if bytes.Contains(content, []byte("{{")) {
+		return nil
+	}
+	dec := yaml.NewDecoder(bytes.NewReader(content))
+	for {
+		var n yaml.Node
+		err := dec.Decode(&n)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			se := &SyntaxError{Path: p, Message: strings.TrimPrefix(err.Error(), "yaml: "), Checker: "yaml"}
+			if m := yamlLineRe.FindStringSubmatch(err.Error()); m != nil {
+				se.Line, _ = strconv.Atoi(m[1])
+				se.Message = err.Error()[len(m[0]):]
+			}
+			return se
+		}
+	}
+}
@@ -0,0 +1,91 @@
+// Package validation checks that source files still parse after an edit. Go, JSON
+// and YAML are parsed in process; Python, TOML, JavaScript/TypeScript and shell
+// scripts are handed to the interpreter or checker installed on the machine or in the
+// workspace (python3, esbuild, the workspace's typescript, node, shellcheck, bash).
+// Languages without a checker, and checkers that are not installed, always pass.
+package validation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// SyntaxError describes the first syntax error a checker found.
+type SyntaxError struct {
+	Path    string
+	Line    int
+	Column  int
+	Message string
+	// Checker names what found the error, e.g. "go/parser" or "shellcheck"
+	Checker string
+}
+
+func (e *SyntaxError) Error() string {
+	loc := e.Path
+	if e.Line > 0 {
+		loc = fmt.Sprintf("%s:%d", loc, e.Line)
+		if e.Column > 0 {
+			loc = fmt.Sprintf("%s:%d", loc, e.Column)
+		}
+	}
+	return fmt.Sprintf("%s: %s (%s)", loc, e.Message, e.Checker)
+}
+
+// errUnavailable is returned by checkers whose tool is not installed.
+var errUnavailable = errors.New("checker not available")
+
+// checkTimeout bounds each external checker so a hung tool never blocks an edit.
+const checkTimeout = 10 * time.Second
+
+// checker parses content as the language of path. It returns a *SyntaxError for
+// invalid content, errUnavailable when it cannot run, and nil otherwise.
+type checker func(ctx context.Context, workspace, path string, content []byte) error
+
+var checkers = map[string]checker{
+	".go":   checkGo,
+	".json": checkJSON,
+	".yaml": checkYAML,
+	".yml":  checkYAML,
+	".toml": checkTOML,
+	".py":   checkPython,
+	".pyi":  checkPython,
+	".js":   checkScript,
+	".mjs":  checkScript,
+	".cjs":  checkScript,
+	".jsx":  checkScript,
+	".ts":   checkScript,
+	".mts":  checkScript,
+	".cts":  checkScript,
+	".tsx":  checkScript,
+	".sh":   checkShell,
+	".bash": checkShell,
+}
+
+// Supported reports whether files like path have a syntax checker.
+func Supported(path string) bool {
+	_, ok := checkers[strings.ToLower(filepath.Ext(path))]
+	return ok
+}
+
+// Check returns a *SyntaxError when content does not parse as the language of path,
+// which is relative to workspace. Workspace tools such as node_modules/.bin/esbuild
+// are preferred over those on PATH. Unknown languages and missing checkers pass.
+func Check(ctx context.Context, workspace, path string, content []byte) error {
+	check, ok := checkers[strings.ToLower(filepath.Ext(path))]
+	if !ok {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, checkTimeout)
+	defer cancel()
+	err := check(ctx, workspace, filepath.ToSlash(path), content)
+	var se *SyntaxError
+	if errors.As(err, &se) {
+		return se
+	}
+	// A checker that is missing, crashed or timed out says nothing about the file
+	return nil
+}
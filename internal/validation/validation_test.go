@@ -0,0 +1,88 @@
+package validation
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"testing"
+)
+
+func syntaxError(t *testing.T, path, content string) *SyntaxError {
+	t.Helper()
+	err := Check(context.Background(), t.TempDir(), path, []byte(content))
+	if err == nil {
+		return nil
+	}
+	var se *SyntaxError
+	if !errors.As(err, &se) {
+		t.Fatalf("Check(%s) = %v, want *SyntaxError", path, err)
+	}
+	return se
+}
+
+func TestCheck_InProcess(t *testing.T) {
+	cases := []struct {
+		path, valid, invalid string
+		line                 int
+	}{
+		{"main.go", "package main\n\nfunc main() {}\n", "package main\n\nfunc main() {\n", 3},
+		{"data.json", `{"a": [1, 2]}`, "{\n  \"a\": [1, 2,]\n}", 2},
+		{"ci.yml", "jobs:\n  test:\n    steps: []\n", "jobs:\n  test: a\n   b: c\n", 3},
+	}
+	for _, c := range cases {
+		if se := syntaxError(t, c.path, c.valid); se != nil {
+			t.Errorf("%s: valid content rejected: %v", c.path, se)
+		}
+		se := syntaxError(t, c.path, c.invalid)
+		if se == nil {
+			t.Errorf("%s: invalid content accepted", c.path)
+			continue
+		}
+		if se.Line != c.line {
+			t.Errorf("%s: line = %d, want %d (%v)", c.path, se.Line, c.line, se)
+		}
+	}
+}
+
+func TestCheck_SkipsLenientFormats(t *testing.T) {
+	if se := syntaxError(t, "tsconfig.json", "{\n  // comment\n  \"a\": 1,\n}"); se != nil {
+		t.Errorf("tsconfig with comments rejected: %v", se)
+	}
+	if se := syntaxError(t, "chart/templates/svc.yaml", "name: {{ .Values.name }}\n  - [\n"); se != nil {
+		t.Errorf("templated YAML rejected: %v", se)
+	}
+	if se := syntaxError(t, "notes.txt", "{{{"); se != nil || Supported("notes.txt") {
+		t.Errorf("unknown language checked: %v", se)
+	}
+}
+
+func TestCheck_External(t *testing.T) {
+	cases := []struct {
+		tool, path, valid, invalid string
+		line                       int
+	}{
+		{"python3", "app.py", "def f():\n    return 1\n", "def f():\nreturn 1\n", 2},
+		{"python3", "pyproject.toml", "[tool]\nname = \"x\"\n", "[tool]\nname = \n", 2},
+		{"node", "index.js", "const a = () => 1;\n", "const a = (;\n", 1},
+		{"bash", "run.sh", "#!/bin/bash\nif true; then echo ok; fi\n", "#!/bin/bash\nif true; then echo ok\n", 3},
+	}
+	for _, c := range cases {
+		if _, err := exec.LookPath(c.tool); err != nil {
+			continue
+		}
+		if se := syntaxError(t, c.path, c.valid); se != nil {
+			t.Errorf("%s: valid content rejected: %v", c.path, se)
+		}
+		se := syntaxError(t, c.path, c.invalid)
+		if se == nil {
+			// tomllib needs Python 3.11
+			if c.path != "pyproject.toml" {
+				t.Errorf("%s: invalid content accepted", c.path)
+			}
+			continue
+		}
+		if se.Line != c.line && se.Line != 0 {
+			t.Errorf("%s: line = %d, want %d (%v)", c.path, se.Line, c.line, se)
+		}
+	}
+}
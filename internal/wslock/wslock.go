@@ -0,0 +1,244 @@
+// Package wslock keeps two Loom instances from driving the same workspace. The
+// instance owning a workspace holds <workspace>/.loom/instance.lock and refreshes its
+// heartbeat; others see the live holder and stay read-only or take over.
+package wslock
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Heartbeat timing; a lock whose heartbeat is older than staleAfter belongs to an
+// instance that exited without releasing it (crash, kill, sleep) and may be replaced.
+var (
+	heartbeatInterval = 5 * time.Second
+	staleAfter        = 20 * time.Second
+)
+
+// Holder identifies the instance owning a workspace.
+type Holder struct {
+	ID          string    `json:"id"`
+	PID         int       `json:"pid"`
+	Host        string    `json:"host"`
+	StartedAt   time.Time `json:"started_at"`
+	HeartbeatAt time.Time `json:"heartbeat_at"`
+}
+
+// Stale reports whether the holder stopped refreshing its heartbeat.
+func (h Holder) Stale(now time.Time) bool {
+	return now.Sub(h.HeartbeatAt) > staleAfter
+}
+
+// String describes the holder for messages, e.g. "pid 4242 on mbp".
+func (h Holder) String() string {
+	if h.PID == 0 {
+		return "an unknown process"
+	}
+	if h.Host == "" {
+		return fmt.Sprintf("pid %d", h.PID)
+	}
+	return fmt.Sprintf("pid %d on %s", h.PID, h.Host)
+}
+
+// HeldError is returned by Acquire when another live instance owns the workspace.
+type HeldError struct {
+	Holder Holder
+}
+
+func (e *HeldError) Error() string {
+	return fmt.Sprintf("workspace is in use by another Loom instance (%s)", e.Holder)
+}
+
+// Lock is a held workspace lock. Its heartbeat runs until Release, or until another
+// instance takes the workspace over, in which case the onLost callback is called.
+type Lock struct {
+	path   string
+	mu     sync.Mutex
+	self   Holder
+	onLost func(by Holder)
+	stop   chan struct{}
+	done   bool
+}
+
+// Path returns the lock file of workspace.
+func Path(workspace string) string {
+	return filepath.Join(workspace, ".loom", "instance.lock")
+}
+
+// Read returns the current holder of workspace's lock.
+func Read(workspace string) (Holder, error) {
+	return readHolder(Path(workspace))
+}
+
+// Acquire locks workspace for this process. A lock left behind by an instance whose
+// heartbeat went stale is replaced; a live one yields a *HeldError. onLost may be nil.
+func Acquire(workspace string, onLost func(by Holder)) (*Lock, error) {
+	path := Path(workspace)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+	self := newHolder()
+	for attempt := 0; attempt < 2; attempt++ {
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+		if err == nil {
+			data, _ := json.Marshal(self)
+			_, werr := f.Write(data)
+			cerr := f.Close()
+			if werr == nil {
+				werr = cerr
+			}
+			if werr != nil {
+				_ = os.Remove(path)
+				return nil, werr
+			}
+			return start(path, self, onLost), nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, err
+		}
+		holder, rerr := readHolder(path)
+		if rerr != nil {
+			// A half-written file is only trusted while it is fresh
+			info, serr := os.Stat(path)
+			if serr == nil && time.Since(info.ModTime()) <= staleAfter {
+				return nil, &HeldError{}
+			}
+		} else if !holder.Stale(time.Now()) {
+			return nil, &HeldError{Holder: holder}
+		}
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+	}
+	return nil, fmt.Errorf("could not create %s", path)
+}
+
+// TakeOver locks workspace for this process whoever holds it. The previous holder
+// notices on its next heartbeat and stops changing the workspace.
+func TakeOver(workspace string, onLost func(by Holder)) (*Lock, error) {
+	path := Path(workspace)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+	self := newHolder()
+	if err := writeHolder(path, self); err != nil {
+		return nil, err
+	}
+	return start(path, self, onLost), nil
+}
+
+// Holder returns the identity this lock was written with.
+func (l *Lock) Holder() Holder {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.self
+}
+
+// Release stops the heartbeat and removes the lock file if it is still ours.
+func (l *Lock) Release() error {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.done {
+		return nil
+	}
+	l.done = true
+	close(l.stop)
+	if h, err := readHolder(l.path); err == nil && h.ID != l.self.ID {
+		return nil
+	}
+	if err := os.Remove(l.path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func start(path string, self Holder, onLost func(by Holder)) *Lock {
+	l := &Lock{path: path, self: self, onLost: onLost, stop: make(chan struct{})}
+	go l.heartbeat()
+	return l
+}
+
+func (l *Lock) heartbeat() {
+	ticker := time.NewTicker(heartbeatInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-l.stop:
+			return
+		case <-ticker.C:
+		}
+		if by, lost := l.beat(); lost {
+			if l.onLost != nil {
+				l.onLost(by)
+			}
+			return
+		}
+	}
+}
+
+// beat refreshes the heartbeat; it reports the new holder when the lock was taken over.
+func (l *Lock) beat() (Holder, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.done {
+		return Holder{}, false
+	}
+	if h, err := readHolder(l.path); err == nil && h.ID != l.self.ID {
+		l.done = true
+		close(l.stop)
+		return h, true
+	}
+	l.self.HeartbeatAt = time.Now()
+	_ = writeHolder(l.path, l.self)
+	return Holder{}, false
+}
+
+func newHolder() Holder {
+	var b [8]byte
+	_, _ = rand.Read(b[:])
+	host, _ := os.Hostname()
+	now := time.Now()
+	return Holder{ID: hex.EncodeToString(b[:]), PID: os.Getpid(), Host: host, StartedAt: now, HeartbeatAt: now}
+}
+
+func readHolder(path string) (Holder, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Holder{}, err
+	}
+	var h Holder
+	if err := json.Unmarshal(data, &h); err != nil {
+		return Holder{}, err
+	}
+	if h.ID == "" {
+		return Holder{}, fmt.Errorf("%s has no instance id", path)
+	}
+	return h, nil
+}
+
+// writeHolder replaces the lock file atomically so readers never see partial JSON.
+func writeHolder(path string, h Holder) error {
+	data, err := json.Marshal(h)
+	if err != nil {
+		return err
+	}
+	tmp := fmt.Sprintf("%s.%s.tmp", path, h.ID)
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	return nil
+}
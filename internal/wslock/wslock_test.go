@@ -0,0 +1,92 @@
+package wslock
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestAcquire_SecondInstanceSeesHolder(t *testing.T) {
+	ws := t.TempDir()
+	first, err := Acquire(ws, nil)
+	if err != nil {
+		t.Fatalf("acquire: %v", err)
+	}
+	defer first.Release()
+
+	_, err = Acquire(ws, nil)
+	var held *HeldError
+	if !errors.As(err, &held) {
+		t.Fatalf("expected a HeldError, got %v", err)
+	}
+	if held.Holder.ID != first.Holder().ID || held.Holder.PID != os.Getpid() {
+		t.Fatalf("unexpected holder %+v", held.Holder)
+	}
+
+	if err := first.Release(); err != nil {
+		t.Fatalf("release: %v", err)
+	}
+	if _, err := os.Stat(Path(ws)); !os.IsNotExist(err) {
+		t.Fatalf("expected the lock file to be removed, got %v", err)
+	}
+	second, err := Acquire(ws, nil)
+	if err != nil {
+		t.Fatalf("acquire after release: %v", err)
+	}
+	second.Release()
+}
+
+func TestAcquire_ReplacesStaleLock(t *testing.T) {
+	ws := t.TempDir()
+	if err := os.MkdirAll(ws+"/.loom", 0o755); err != nil {
+		t.Fatal(err)
+	}
+	old := Holder{ID: "crashed", PID: 1, HeartbeatAt: time.Now().Add(-time.Minute)}
+	data, _ := json.Marshal(old)
+	if err := os.WriteFile(Path(ws), data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	l, err := Acquire(ws, nil)
+	if err != nil {
+		t.Fatalf("expected the stale lock to be replaced, got %v", err)
+	}
+	defer l.Release()
+	if h, _ := Read(ws); h.ID != l.Holder().ID {
+		t.Fatalf("expected our lock on disk, got %+v", h)
+	}
+}
+
+func TestTakeOver_NotifiesPreviousHolder(t *testing.T) {
+	defer func(d time.Duration) { heartbeatInterval = d }(heartbeatInterval)
+	heartbeatInterval = 10 * time.Millisecond
+
+	ws := t.TempDir()
+	lost := make(chan Holder, 1)
+	first, err := Acquire(ws, func(by Holder) { lost <- by })
+	if err != nil {
+		t.Fatalf("acquire: %v", err)
+	}
+	second, err := TakeOver(ws, nil)
+	if err != nil {
+		t.Fatalf("take over: %v", err)
+	}
+	defer second.Release()
+
+	select {
+	case by := <-lost:
+		if by.ID != second.Holder().ID {
+			t.Fatalf("expected the new holder, got %+v", by)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("previous holder was not told about the takeover")
+	}
+	// Releasing the old lock must not remove the new holder's file
+	if err := first.Release(); err != nil {
+		t.Fatalf("release: %v", err)
+	}
+	if h, err := Read(ws); err != nil || h.ID != second.Holder().ID {
+		t.Fatalf("expected the new holder to keep the lock, got %+v (%v)", h, err)
+	}
+}
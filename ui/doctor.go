@@ -0,0 +1,28 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"time"
+
+	"github.com/loom/loom/internal/bridge"
+)
+
+// doctorMCPWait is how long `loom doctor` lets MCP servers finish starting.
+const doctorMCPWait = 15 * time.Second
+
+// runDoctor prints the diagnostics report and returns the process exit code:
+// 1 when any health check is in the error state, 0 otherwise.
+func runDoctor(app *bridge.App) int {
+	// Keep startup logging out of the report
+	log.SetOutput(io.Discard)
+	app.WaitForMCP(doctorMCPWait)
+	d := app.GetDiagnostics()
+	app.StopMCP()
+	fmt.Print(bridge.FormatDiagnostics(d))
+	if !d.Healthy() {
+		return 1
+	}
+	return 0
+}
@@ -7,6 +7,7 @@ import { Box } from '@mui/material';
 import Sidebar from './components/left/Sidebar';
 import EditorPanel from './components/center/EditorPanel';
 import ChatPanel from './components/right/Chat/ChatPanel';
+import ConversationTabs from './components/right/Chat/ConversationTabs';
 import ApprovalDialog from './components/dialogs/ApprovalDialog';
 import RulesDialog from './components/dialogs/RulesDialog';
 import CostsDialog from './components/dialogs/CostsDialog';
@@ -14,7 +15,13 @@ import WorkspaceDialog from './components/dialogs/WorkspaceDialog';
 import NewProjectDialog, { NewProjectConfig } from './components/dialogs/NewProjectDialog';
 import SearchDialog from './components/dialogs/SearchDialog';
 import MemoriesDialog from './components/dialogs/MemoriesDialog';
-import { ChatMessage, ApprovalRequest, UIFileEntry, UIListDirResult, ConversationListItem, EditorTabItem } from './types/ui';
+import WhatIfDialog from './components/dialogs/WhatIfDialog';
+import HealthDialog from './components/dialogs/HealthDialog';
+import HygieneDialog from './components/dialogs/HygieneDialog';
+import OnboardingDialog from './components/dialogs/OnboardingDialog';
+import ModelSuggestionSnackbar from './components/dialogs/ModelSuggestionSnackbar';
+import WorkspaceLockSnackbar from './components/dialogs/WorkspaceLockSnackbar';
+import { ChatMessage, ApprovalRequest, UIFileEntry, UIListDirResult, ConversationListItem, EditorTabItem, ConversationTabItem } from './types/ui';
 import { guessLanguage } from './utils/language';
 import { writeFile } from './services/files';
 import { DynamicThemeProvider } from './components/DynamicThemeProvider';
@@ -28,6 +35,10 @@ const App: React.FC = () => {
     const [currentModel, setCurrentModel] = useState<string>('');
     const messagesEndRef = useRef<HTMLDivElement>(null);
     const [busy, setBusy] = useState<boolean>(false);
+    // Conversations running next to the main one; '' selects the main conversation
+    const [convTabs, setConvTabs] = useState<ConversationTabItem[]>([]);
+    const [activeTab, setActiveTab] = useState<string>('');
+    const [tabMessages, setTabMessages] = useState<Record<string, ChatMessage[]>>({});
     const [workspaceOpen, setWorkspaceOpen] = useState<boolean>(false);
     const [workspacePath, setWorkspacePath] = useState<string>('');
     const [newProjectOpen, setNewProjectOpen] = useState<boolean>(false);
@@ -35,6 +46,7 @@ const App: React.FC = () => {
     const [openaiKey, setOpenaiKey] = useState<string>('');
     const [anthropicKey, setAnthropicKey] = useState<string>('');
     const [openrouterKey, setOpenrouterKey] = useState<string>('');
+    const [geminiKey, setGeminiKey] = useState<string>('');
     const [ollamaEndpoint, setOllamaEndpoint] = useState<string>('');
     const [autoApproveShell, setAutoApproveShell] = useState<boolean>(false);
     const [autoApproveEdits, setAutoApproveEdits] = useState<boolean>(false);
@@ -44,6 +56,9 @@ const App: React.FC = () => {
     const [selectedModels, setSelectedModels] = useState<string[]>([]);
     const [rulesOpen, setRulesOpen] = useState<boolean>(false);
     const [memoriesOpen, setMemoriesOpen] = useState<boolean>(false);
+    const [whatIfOpen, setWhatIfOpen] = useState<boolean>(false);
+    const [healthOpen, setHealthOpen] = useState<boolean>(false);
+    const [hygieneOpen, setHygieneOpen] = useState<boolean>(false);
     const [userRules, setUserRules] = useState<string[]>([]);
     const [projectRules, setProjectRules] = useState<string[]>([]);
     const [newUserRule, setNewUserRule] = useState<string>('');
@@ -231,7 +246,7 @@ const App: React.FC = () => {
                 .then((res: any) => {
                     setCurrentConversationId(res?.current_id || '');
                     const list = Array.isArray(res?.conversations) ? res.conversations : [];
-                    setConversations(list.map((c: any) => ({ id: String(c.id), title: String(c.title || c.id), updated_at: String(c.updated_at || '') })));
+                    setConversations(list.map((c: any) => ({ id: String(c.id), title: String(c.title || c.id), updated_at: String(c.updated_at || ''), tags: c.tags || undefined })));
                 })
                 .catch(() => { });
         });
@@ -307,6 +322,7 @@ const App: React.FC = () => {
                 setOpenaiKey(s?.openai_api_key || '');
                 setAnthropicKey(s?.anthropic_api_key || '');
                 setOpenrouterKey(s?.openrouter_api_key || '');
+                setGeminiKey(s?.gemini_api_key || '');
                 setOllamaEndpoint(s?.ollama_endpoint || '');
                 setAutoApproveShell(String(s?.auto_approve_shell).toLowerCase() === 'true');
                 setAutoApproveEdits(String(s?.auto_approve_edits).toLowerCase() === 'true');
@@ -362,7 +378,7 @@ const App: React.FC = () => {
             .then((res: any) => {
                 setCurrentConversationId(res?.current_id || '');
                 const list = Array.isArray(res?.conversations) ? res.conversations : [];
-                setConversations(list.map((c: any) => ({ id: String(c.id), title: String(c.title || c.id), updated_at: String(c.updated_at || '') })));
+                setConversations(list.map((c: any) => ({ id: String(c.id), title: String(c.title || c.id), updated_at: String(c.updated_at || ''), tags: c.tags || undefined })));
             })
             .catch(() => { });
 
@@ -449,6 +465,21 @@ const App: React.FC = () => {
 
     }, []);
 
+    // MCP prompt templates, typed as "/<command> args"
+    const [mcpPromptCommands, setMcpPromptCommands] = useState<string[]>([]);
+    useEffect(() => {
+        const load = () => {
+            (AppBridge as any).GetMCPPrompts?.()
+                .then((list: { command: string }[]) => setMcpPromptCommands((list || []).map(p => p.command)))
+                .catch(() => { });
+        };
+        load();
+        const unsubscribe = EventsOn('system:tools_updated', load);
+        return () => {
+            if (unsubscribe) unsubscribe();
+        };
+    }, []);
+
     // Scroll to bottom when messages change
     useEffect(() => {
         if (messagesEndRef.current) {
@@ -478,8 +509,223 @@ const App: React.FC = () => {
             clearTimeout(collapseTimerRef.current);
             collapseTimerRef.current = null;
         }
+        // "/style <name>" switches the conversation's output style; "/style" lists them
+        const style = text.trim().match(/^\/style(?:\s+(.+))?$/);
+        if (style) {
+            if (style[1]) {
+                (AppBridge as any).SetOutputStyle?.(style[1].trim())
+                    .then((err: string) => {
+                        if (err) {
+                            setMessages(prev => [...prev, { role: 'system', content: `Output style not changed: ${err}` }]);
+                        }
+                    })
+                    .catch(() => { });
+            } else {
+                (AppBridge as any).GetOutputStyles?.()
+                    .then((info: any) => {
+                        const names = (info?.styles || []).map((s: any) => s.name).join(', ');
+                        setMessages(prev => [...prev, { role: 'system', content: `Output style: ${info?.current}. Available: default, ${names}` }]);
+                    })
+                    .catch(() => { });
+            }
+            return;
+        }
+        // "/explain <level>" sets how deeply replies explain code; "/explain <level> <question>"
+        // also asks the question, and "/explain" lists the levels
+        const explain = text.trim().match(/^\/explain(?:\s+(\S+))?(?:\s+([\s\S]+))?$/);
+        if (explain) {
+            const say = (content: string) => setMessages(prev => [...prev, { role: 'system', content }]);
+            if (!explain[1]) {
+                (AppBridge as any).GetExplanationLevels?.()
+                    .then((info: any) => {
+                        say(`Explanation level: ${info?.current || 'default'}. Available: default, ${(info?.levels || []).join(', ')}`);
+                    })
+                    .catch(() => { });
+                return;
+            }
+            (AppBridge as any).SetExplanationLevel?.(explain[1])
+                .then((err: string) => {
+                    if (err) {
+                        say(`Explanation level not changed: ${err}`);
+                    } else if (explain[2]) {
+                        SendUserMessage(explain[2]);
+                    }
+                })
+                .catch(() => { });
+            return;
+        }
+        // "/checkpoint <name>" names the current point of the timeline; "/checkpoint" lists
+        // them, "/checkpoint diff <name>" and "/checkpoint revert <name>" compare or go back
+        const checkpoint = text.trim().match(/^\/checkpoint(?:\s+(.+))?$/);
+        if (checkpoint) {
+            const say = (content: string) => setMessages(prev => [...prev, { role: 'system', content }]);
+            const arg = (checkpoint[1] || '').trim();
+            const sub = arg.match(/^(diff|revert)\s+(.+)$/);
+            if (!arg) {
+                (AppBridge as any).GetNamedCheckpoints?.()
+                    .then((items: any[]) => {
+                        const list = (items || []).map((c: any) => `- ${c.name} (step ${c.step}, ${c.source})`).join('\n');
+                        say(list ? `Checkpoints:\n${list}` : 'No checkpoints in this conversation.');
+                    })
+                    .catch(() => { });
+            } else if (sub && sub[1] === 'diff') {
+                (AppBridge as any).DiffNamedCheckpoint?.(sub[2])
+                    .then((res: any) => {
+                        if (res?.error) {
+                            say(`Checkpoint diff failed: ${res.error}`);
+                        } else {
+                            say(res?.diff ? `Changes since checkpoint "${sub[2]}":\n\n\`\`\`diff\n${res.diff}\`\`\`` : `No changes since checkpoint "${sub[2]}".`);
+                        }
+                    })
+                    .catch(() => { });
+            } else if (sub) {
+                (AppBridge as any).RevertToNamedCheckpoint?.(sub[2], [])
+                    .then((res: any) => {
+                        if (res?.error) {
+                            say(`Revert failed: ${res.error}`);
+                        } else if (!res?.reverted?.length) {
+                            say(`Nothing changed since checkpoint "${sub[2]}".`);
+                        }
+                    })
+                    .catch(() => { });
+            } else {
+                (AppBridge as any).CreateNamedCheckpoint?.(arg)
+                    .then((res: any) => {
+                        if (res?.error) {
+                            say(`Checkpoint not created: ${res.error}`);
+                        }
+                    })
+                    .catch(() => { });
+            }
+            return;
+        }
+        // "/resume [id]" continues a stopped run's handoff in a new conversation; "/resume list" shows them
+        const resume = text.trim().match(/^\/resume(?:\s+(\S+))?$/);
+        if (resume) {
+            const say = (content: string) => setMessages(prev => [...prev, { role: 'system', content }]);
+            if (resume[1] === 'list') {
+                (AppBridge as any).GetHandoffs?.()
+                    .then((items: any[]) => {
+                        const list = (items || []).map((h: any) => `- ${h.id}: ${h.goals?.[0] || 'unfinished work'} (${h.todos?.length || 0} open task(s)${h.resumed_at ? ', resumed' : ''})`).join('\n');
+                        say(list ? `Handoffs:\n${list}` : 'No handoffs in this workspace.');
+                    })
+                    .catch(() => { });
+            } else {
+                (AppBridge as any).ResumeHandoff?.(resume[1] || '')
+                    .then((err: string) => {
+                        if (err) {
+                            say(`Resume failed: ${err}`);
+                        }
+                    })
+                    .catch(() => { });
+            }
+            return;
+        }
+        // "/repro <bug>" fixes the bug only after a failing reproduction is confirmed
+        const repro = text.trim().match(/^\/repro\s+([\s\S]+)/);
+        if (repro) {
+            (AppBridge as any).ReproduceIssue?.(repro[1]);
+            return;
+        }
+        // "/issue <url>" starts a new conversation primed with a GitHub issue or pull request
+        const issue = text.trim().match(/^\/issue\s+(\S+)$/);
+        if (issue) {
+            setMessages(prev => [...prev, { role: 'system', content: `Fetching ${issue[1]}…` }]);
+            (AppBridge as any).StartFromIssue?.(issue[1])
+                .then((err: string) => {
+                    if (err) {
+                        setMessages(prev => [...prev, { role: 'system', content: `Could not start from the issue: ${err}` }]);
+                    }
+                })
+                .catch(() => { });
+            return;
+        }
+        // "/<prompt> args" renders an MCP server's prompt template and sends it
+        const slash = text.trim().match(/^\/(\S+)\s*([\s\S]*)$/);
+        if (slash && mcpPromptCommands.includes(slash[1])) {
+            (AppBridge as any).RunMCPPrompt?.(slash[1], slash[2])
+                .then((err: string) => {
+                    if (err) {
+                        setMessages(prev => [...prev, { role: 'system', content: `MCP prompt /${slash[1]} failed: ${err}` }]);
+                    }
+                })
+                .catch(() => { });
+            return;
+        }
         SendUserMessage(text);
-    }, [busy]);
+    }, [busy, mcpPromptCommands]);
+
+    // Conversation tabs receive their events tagged with the conversation id
+    useEffect(() => {
+        const offTabs = EventsOn('conversation:tabs', (list: ConversationTabItem[]) => {
+            const tabs = Array.isArray(list) ? list : [];
+            setConvTabs(tabs);
+            const open = new Set(tabs.map(t => t.id));
+            setActiveTab(prev => (prev && !open.has(prev) ? '' : prev));
+            setTabMessages(prev => Object.fromEntries(Object.entries(prev).filter(([id]) => open.has(id))));
+        });
+        const offEvents = EventsOn('conversation:event', (payload: any) => {
+            const id = String(payload?.conversation_id || '');
+            const data = payload?.data;
+            const update = (fn: (prev: ChatMessage[]) => ChatMessage[]) =>
+                setTabMessages(prev => ({ ...prev, [id]: fn(prev[id] || []) }));
+            switch (payload?.event) {
+                case 'chat:new':
+                    update(prev => [...prev, data as ChatMessage]);
+                    break;
+                case 'assistant-msg':
+                    update(prev => {
+                        const last = prev[prev.length - 1];
+                        if (last && last.role === 'assistant') {
+                            return [...prev.slice(0, -1), { ...last, content: String(data || '') }];
+                        }
+                        return [...prev, { role: 'assistant', content: String(data || '') }];
+                    });
+                    break;
+                case 'task:prompt':
+                    setApprovalRequest(data as ApprovalRequest);
+                    break;
+                case 'user:question':
+                    window.dispatchEvent(new CustomEvent('loom:user-question', { detail: data }));
+                    break;
+                case 'user:choice':
+                    window.dispatchEvent(new CustomEvent('loom:user-choice', { detail: data }));
+                    break;
+                case 'system:busy':
+                    setConvTabs(prev => prev.map(t => (t.id === id ? { ...t, busy: !!data } : t)));
+                    // The first request names the conversation
+                    if (!data) {
+                        (AppBridge as any).GetConversationTabs?.().then((list: ConversationTabItem[]) => setConvTabs(list || [])).catch(() => { });
+                    }
+                    break;
+            }
+        });
+        return () => { offTabs(); offEvents(); };
+    }, []);
+
+    const handleOpenTab = useCallback(() => {
+        (AppBridge as any).OpenConversationTab('')
+            .then((res: any) => {
+                if (res?.error) {
+                    setMessages(prev => [...prev, { role: 'system', content: `Could not open a conversation tab: ${res.error}` }]);
+                    return;
+                }
+                setConvTabs(res?.tabs || []);
+                setActiveTab(String(res?.tab?.id || ''));
+            })
+            .catch(() => { });
+    }, []);
+
+    const handleSendToTab = useCallback((text: string) => {
+        if (!text.trim() || !activeTab) return;
+        (AppBridge as any).SendConversationMessage(activeTab, text)
+            .then((err: string) => {
+                if (err) setTabMessages(prev => ({ ...prev, [activeTab]: [...(prev[activeTab] || []), { role: 'system', content: err }] }));
+            })
+            .catch(() => { });
+    }, [activeTab]);
+
+    const activeTabBusy = !!convTabs.find(t => t.id === activeTab)?.busy;
 
     const handleApproval = (approved: boolean) => {
         if (approvalRequest) {
@@ -553,7 +799,8 @@ const App: React.FC = () => {
                 setConversations(list.map((c: any) => ({
                     id: String(c.id),
                     title: String(c.title || c.id),
-                    updated_at: String(c.updated_at || '')
+                    updated_at: String(c.updated_at || ''),
+                    tags: c.tags || undefined
                 })));
 
                 // Close the new project dialog
@@ -628,7 +875,8 @@ const App: React.FC = () => {
             setConversations(list.map((c: any) => ({
                 id: String(c.id),
                 title: String(c.title || c.id),
-                updated_at: String(c.updated_at || '')
+                updated_at: String(c.updated_at || ''),
+                tags: c.tags || undefined
             })));
         } catch (error) {
             console.error('Failed to open recent workspace:', error);
@@ -779,6 +1027,26 @@ const App: React.FC = () => {
         }
     }, [openTabs]);
 
+    // Report unsaved editor changes so agent edits stay away from those lines (debounced)
+    const reportedDirtyRef = useRef<Map<string, string>>(new Map());
+    useEffect(() => {
+        const timer = window.setTimeout(() => {
+            const reported = reportedDirtyRef.current;
+            const dirtyTabs = new Map(openTabs.filter((t) => t.isDirty).map((t) => [t.path, t.content] as [string, string]));
+            dirtyTabs.forEach((content, path) => {
+                if (reported.get(path) === content) return;
+                reported.set(path, content);
+                (AppBridge as any).UpdateUnsavedChanges?.(path, content, true)?.catch?.(() => {});
+            });
+            Array.from(reported.keys()).forEach((path) => {
+                if (dirtyTabs.has(path)) return;
+                reported.delete(path);
+                (AppBridge as any).UpdateUnsavedChanges?.(path, '', false)?.catch?.(() => {});
+            });
+        }, 400);
+        return () => window.clearTimeout(timer);
+    }, [openTabs]);
+
     // Keep latest state in refs for menu handlers
     const activeTabRef = useRef<string>(activeTab);
     useEffect(() => { activeTabRef.current = activeTab; }, [activeTab]);
@@ -970,6 +1238,9 @@ const App: React.FC = () => {
                         onOpenWorkspace={() => setWorkspaceOpen(true)}
                         onOpenRules={() => setRulesOpen(true)}
                         onOpenMemories={() => setMemoriesOpen(true)}
+                        onOpenWhatIf={() => setWhatIfOpen(true)}
+                        onOpenHealth={() => setHealthOpen(true)}
+                        onOpenHygiene={() => setHygieneOpen(true)}
                         onOpenSettings={openSettingsTab}
                         onOpenCosts={() => setCostsOpen(true)}
                         totalInUSD={gTotalInUSD}
@@ -1016,6 +1287,8 @@ const App: React.FC = () => {
                         setAnthropicKey={setAnthropicKey}
                         openrouterKey={openrouterKey}
                         setOpenrouterKey={setOpenrouterKey}
+                        geminiKey={geminiKey}
+                        setGeminiKey={setGeminiKey}
                         ollamaEndpoint={ollamaEndpoint}
                         setOllamaEndpoint={setOllamaEndpoint}
                         autoApproveShell={autoApproveShell}
@@ -1030,6 +1303,7 @@ const App: React.FC = () => {
                                 openai_api_key: openaiKey,
                                 anthropic_api_key: anthropicKey,
                                 openrouter_api_key: openrouterKey,
+                                gemini_api_key: geminiKey,
                                 ollama_endpoint: ollamaEndpoint,
                                 auto_approve_shell: String(autoApproveShell),
                                 auto_approve_edits: String(autoApproveEdits),
@@ -1048,15 +1322,23 @@ const App: React.FC = () => {
                 />
 
                 {/* Right: Chat */}
-                <Box sx={{ minWidth: CHAT_MIN_WIDTH, width: chatWidth, borderLeft: 1, borderColor: 'divider' }}>
+                <Box sx={{ minWidth: CHAT_MIN_WIDTH, width: chatWidth, borderLeft: 1, borderColor: 'divider', display: 'flex', flexDirection: 'column', height: '100vh' }}>
+                    <ConversationTabs
+                        tabs={convTabs}
+                        active={activeTab}
+                        mainBusy={busy}
+                        onSelect={setActiveTab}
+                        onOpen={handleOpenTab}
+                        onClose={(id) => { (AppBridge as any).CloseConversationTab(id).catch(() => { }); }}
+                    />
                     <ChatPanel
-                        messages={messages}
-                        busy={busy}
+                        messages={activeTab ? (tabMessages[activeTab] || []) : messages}
+                        busy={activeTab ? activeTabBusy : busy}
                         lastUserIdx={lastUserIdx}
-                        reasoningText={reasoningText}
-                        reasoningOpen={reasoningOpen}
+                        reasoningText={activeTab ? '' : reasoningText}
+                        reasoningOpen={activeTab ? false : reasoningOpen}
                         onToggleReasoning={setReasoningOpen}
-                        onSend={handleSend}
+                        onSend={activeTab ? handleSendToTab : handleSend}
                         onClear={() => { setMessages([]); ClearConversation(); }}
                         messagesEndRef={messagesEndRef}
                         onNewConversation={handleNewConversation}
@@ -1094,6 +1376,12 @@ const App: React.FC = () => {
                     onClose={() => setRulesOpen(false)}
                 />
                 <MemoriesDialog open={memoriesOpen} onClose={() => setMemoriesOpen(false)} />
+                <WhatIfDialog open={whatIfOpen} onClose={() => setWhatIfOpen(false)} />
+                <HealthDialog open={healthOpen} onClose={() => setHealthOpen(false)} />
+                <HygieneDialog open={hygieneOpen} onClose={() => setHygieneOpen(false)} />
+                <OnboardingDialog onFinished={(model) => { if (model) setCurrentModel(model); }} />
+                <ModelSuggestionSnackbar onSwitch={handleModelSelect} />
+                <WorkspaceLockSnackbar />
                 <WorkspaceDialog
                     open={workspaceOpen}
                     workspacePath={workspacePath}
@@ -1131,7 +1419,7 @@ const App: React.FC = () => {
                                 .then((res: any) => {
                                     setCurrentConversationId(res?.current_id || '');
                                     const list = Array.isArray(res?.conversations) ? res.conversations : [];
-                                    setConversations(list.map((c: any) => ({ id: String(c.id), title: String(c.title || c.id), updated_at: String(c.updated_at || '') })));
+                                    setConversations(list.map((c: any) => ({ id: String(c.id), title: String(c.title || c.id), updated_at: String(c.updated_at || ''), tags: c.tags || undefined })));
                                 })
                                 .finally(() => setWorkspaceOpen(false));
                         }
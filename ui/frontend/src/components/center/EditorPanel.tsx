@@ -4,7 +4,19 @@ import { EditorTabItem } from '../../types/ui';
 import Editor, { OnMount } from '@monaco-editor/react';
 import React from 'react';
 import { guessLanguage } from '../../utils/language';
+import { registerSymbolNavigation } from '../../utils/symbolNavigation';
 import SettingsTab from './SettingsTab';
+import { EventsOn } from '../../../wailsjs/runtime/runtime';
+import * as Bridge from '../../../wailsjs/go/bridge/App';
+
+type Annotation = {
+    id: string;
+    path: string;
+    start_line: number;
+    end_line: number;
+    kind: string;
+    comment: string;
+};
 
 type Props = {
     openTabs: EditorTabItem[];
@@ -21,6 +33,8 @@ type Props = {
     setAnthropicKey: (v: string) => void;
     openrouterKey: string;
     setOpenrouterKey: (v: string) => void;
+    geminiKey: string;
+    setGeminiKey: (v: string) => void;
     ollamaEndpoint: string;
     setOllamaEndpoint: (v: string) => void;
     autoApproveShell: boolean;
@@ -47,6 +61,8 @@ function EditorPanel({
     setAnthropicKey,
     openrouterKey,
     setOpenrouterKey,
+    geminiKey,
+    setGeminiKey,
     ollamaEndpoint,
     setOllamaEndpoint,
     autoApproveShell,
@@ -61,6 +77,9 @@ function EditorPanel({
     const tab = openTabs.find((t) => t.path === activeTab);
     const editorRef = React.useRef<any>(null);
     const monacoRef = React.useRef<any>(null);
+    const decorationsRef = React.useRef<string[]>([]);
+    const [annotations, setAnnotations] = React.useState<Annotation[]>([]);
+    const [editorReady, setEditorReady] = React.useState(0);
 
     const loadMonacoTheme = async (monaco: any, theme: string) => {
         const themeMap: Record<string, { file: string, name: string }> = {
@@ -94,12 +113,48 @@ function EditorPanel({
                 try { window.dispatchEvent(new CustomEvent('loom:focus-composer')); } catch { }
             });
         } catch { }
+        // Definitions, references and the outline come from the symbol index, not the model
+        registerSymbolNavigation(monaco, () => pathRef.current);
         editorRef.current = editor;
         monacoRef.current = monaco;
+        decorationsRef.current = [];
+        setEditorReady((n) => n + 1);
         if (tab?.cursor) editor.setPosition({ lineNumber: tab.cursor.line, column: tab.cursor.column });
         setTimeout(() => editor.focus(), 0);
     };
 
+    // Model annotations (annotate_code) for the active file, refreshed as they change
+    const path = tab && !tab.path.startsWith('settings://') ? tab.path : '';
+    const pathRef = React.useRef(path);
+    pathRef.current = path;
+    React.useEffect(() => {
+        if (!path) {
+            setAnnotations([]);
+            return;
+        }
+        const load = () => (Bridge as any).GetAnnotations(path).then((list: Annotation[]) => setAnnotations(list || []));
+        load();
+        const off = EventsOn('annotations:changed', load);
+        return () => { try { off(); } catch { } };
+    }, [path]);
+
+    React.useEffect(() => {
+        const editor = editorRef.current;
+        const monaco = monacoRef.current;
+        if (!editor || !monaco) return;
+        const decorations = annotations.map((a) => ({
+            range: new monaco.Range(a.start_line, 1, a.end_line, 1),
+            options: {
+                isWholeLine: true,
+                glyphMarginClassName: `loom-annotation-glyph loom-annotation-${a.kind}`,
+                className: `loom-annotation-line loom-annotation-${a.kind}`,
+                glyphMarginHoverMessage: { value: `**${a.kind}**\n\n${a.comment}` },
+                hoverMessage: { value: `**${a.kind}**\n\n${a.comment}` },
+            },
+        }));
+        decorationsRef.current = editor.deltaDecorations(decorationsRef.current, decorations);
+    }, [annotations, editorReady]);
+
     React.useEffect(() => {
         const editor = editorRef.current;
         if (!editor || !tab?.cursor) return;
@@ -172,6 +227,8 @@ function EditorPanel({
                             setAnthropicKey={setAnthropicKey}
                             openrouterKey={openrouterKey}
                             setOpenrouterKey={setOpenrouterKey}
+                            geminiKey={geminiKey}
+                            setGeminiKey={setGeminiKey}
                             ollamaEndpoint={ollamaEndpoint}
                             setOllamaEndpoint={setOllamaEndpoint}
                             autoApproveShell={autoApproveShell}
@@ -199,6 +256,7 @@ function EditorPanel({
                                     minimap: { enabled: false },
                                     wordWrap: 'off',
                                     lineNumbers: 'on',
+                                    glyphMargin: annotations.length > 0,
                                     automaticLayout: true,
                                     renderWhitespace: 'selection',
                                     tabSize: 4,
@@ -236,6 +294,8 @@ export default React.memo(EditorPanel, (prev, next) => {
         prev.setAnthropicKey === next.setAnthropicKey &&
         prev.openrouterKey === next.openrouterKey &&
         prev.setOpenrouterKey === next.setOpenrouterKey &&
+        prev.geminiKey === next.geminiKey &&
+        prev.setGeminiKey === next.setGeminiKey &&
         prev.ollamaEndpoint === next.ollamaEndpoint &&
         prev.setOllamaEndpoint === next.setOllamaEndpoint &&
         prev.autoApproveShell === next.autoApproveShell &&
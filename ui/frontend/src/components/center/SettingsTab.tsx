@@ -28,8 +28,47 @@ import RefreshIcon from '@mui/icons-material/Refresh';
 import SearchIcon from '@mui/icons-material/Search';
 import { AVAILABLE_THEMES } from '../../themes/themeConfig';
 import { OpenProjectDataDir } from '../../../wailsjs/go/bridge/App';
+import * as Bridge from '../../../wailsjs/go/bridge/App';
 import { ALL_AVAILABLE_MODELS, getAllModels, ModelOption } from '../../models';
 
+type FeatureFlagState = {
+    name: string;
+    description: string;
+    default: boolean;
+    enabled: boolean;
+};
+
+type TrashEntry = {
+    id: string;
+    path: string;
+    dir?: boolean;
+    size: number;
+    deleted_at: string;
+    reason?: string;
+};
+
+type ProxyConfig = {
+    http_proxy?: string;
+    https_proxy?: string;
+    no_proxy?: string;
+    ca_bundle?: string;
+};
+
+type AzureOpenAIConfig = {
+    endpoint?: string;
+    api_key?: string;
+    deployments?: string[];
+    api_version?: string;
+};
+
+type SessionEncryption = {
+    workspace?: string;
+    enabled?: boolean;
+    key_store?: string;
+    backend?: string;
+    error?: string;
+};
+
 type Props = {
     openaiKey: string;
     setOpenaiKey: (v: string) => void;
@@ -37,6 +76,8 @@ type Props = {
     setAnthropicKey: (v: string) => void;
     openrouterKey: string;
     setOpenrouterKey: (v: string) => void;
+    geminiKey: string;
+    setGeminiKey: (v: string) => void;
     ollamaEndpoint: string;
     setOllamaEndpoint: (v: string) => void;
     autoApproveShell: boolean;
@@ -57,6 +98,8 @@ export default function SettingsTab({
     setAnthropicKey,
     openrouterKey,
     setOpenrouterKey,
+    geminiKey,
+    setGeminiKey,
     ollamaEndpoint,
     setOllamaEndpoint,
     autoApproveShell,
@@ -72,12 +115,28 @@ export default function SettingsTab({
     const [showOpenAI, setShowOpenAI] = React.useState(false);
     const [showAnthropic, setShowAnthropic] = React.useState(false);
     const [showOpenRouter, setShowOpenRouter] = React.useState(false);
+    const [showGemini, setShowGemini] = React.useState(false);
+    const [auditStatus, setAuditStatus] = React.useState<string>('');
     const [allModels, setAllModels] = React.useState<ModelOption[]>(ALL_AVAILABLE_MODELS);
     const [loadingModels, setLoadingModels] = React.useState(false);
     const [modelLoadError, setModelLoadError] = React.useState<string | null>(null);
     const [modelSearchQuery, setModelSearchQuery] = React.useState<string>('');
     const [visibleCounts, setVisibleCounts] = React.useState<Record<string, number>>({});
     const [activeSection, setActiveSection] = React.useState('Appearance');
+    const [featureFlags, setFeatureFlags] = React.useState<FeatureFlagState[]>([]);
+    const [featureFlagError, setFeatureFlagError] = React.useState<string>('');
+    const [trash, setTrash] = React.useState<TrashEntry[]>([]);
+    const [trashError, setTrashError] = React.useState<string>('');
+    const [proxySettings, setProxySettings] = React.useState<ProxyConfig>({});
+    const [proxyEffective, setProxyEffective] = React.useState<ProxyConfig>({});
+    const [proxyError, setProxyError] = React.useState<string>('');
+    const [azure, setAzure] = React.useState<AzureOpenAIConfig>({});
+    const [azureDeployments, setAzureDeployments] = React.useState<string>('');
+    const [azureError, setAzureError] = React.useState<string>('');
+    const [showAzure, setShowAzure] = React.useState(false);
+    const [encryption, setEncryption] = React.useState<SessionEncryption>({});
+    const [encryptionBusy, setEncryptionBusy] = React.useState(false);
+    const [encryptionError, setEncryptionError] = React.useState<string>('');
 
     // Load all models including dynamic OpenRouter models
     React.useEffect(() => {
@@ -100,6 +159,108 @@ export default function SettingsTab({
         loadAllModels();
     }, []);
 
+    React.useEffect(() => {
+        (Bridge as any).GetFeatureFlags?.()
+            .then((flags: FeatureFlagState[]) => setFeatureFlags(flags || []))
+            .catch(() => setFeatureFlags([]));
+    }, []);
+
+    const toggleFeatureFlag = async (flag: FeatureFlagState) => {
+        setFeatureFlagError('');
+        const err: string = await (Bridge as any).SetFeatureFlag(flag.name, !flag.enabled);
+        if (err) {
+            setFeatureFlagError(err);
+            return;
+        }
+        setFeatureFlags(prev => prev.map(f => f.name === flag.name ? { ...f, enabled: !f.enabled } : f));
+    };
+
+    const loadTrash = React.useCallback(() => {
+        (Bridge as any).ListTrash?.()
+            .then((entries: TrashEntry[]) => setTrash(entries || []))
+            .catch(() => setTrash([]));
+    }, []);
+
+    React.useEffect(() => {
+        if (activeSection === 'Trash') loadTrash();
+    }, [activeSection, loadTrash]);
+
+    const trashAction = async (method: 'RestoreTrash' | 'DiscardTrash', id: string) => {
+        setTrashError('');
+        const err: string = await (Bridge as any)[method](id);
+        if (err) setTrashError(err);
+        loadTrash();
+    };
+
+    const loadEncryption = React.useCallback(() => {
+        (Bridge as any).GetSessionEncryption?.()
+            .then((info: SessionEncryption) => setEncryption(info || {}))
+            .catch(() => setEncryption({}));
+    }, []);
+
+    React.useEffect(() => {
+        if (activeSection === 'Privacy') loadEncryption();
+    }, [activeSection, loadEncryption]);
+
+    const toggleEncryption = async () => {
+        setEncryptionError('');
+        setEncryptionBusy(true);
+        const err: string = await (Bridge as any).SetSessionEncryption(!encryption.enabled);
+        setEncryptionBusy(false);
+        if (err) setEncryptionError(err);
+        loadEncryption();
+    };
+
+    React.useEffect(() => {
+        if (activeSection !== 'Network') return;
+        (Bridge as any).GetProxySettings?.()
+            .then((state: { settings: ProxyConfig; effective: ProxyConfig }) => {
+                setProxySettings(state?.settings || {});
+                setProxyEffective(state?.effective || {});
+            })
+            .catch(() => setProxySettings({}));
+    }, [activeSection]);
+
+    React.useEffect(() => {
+        if (activeSection !== 'API Keys') return;
+        (Bridge as any).GetAzureOpenAISettings?.()
+            .then((c: AzureOpenAIConfig) => {
+                setAzure(c || {});
+                setAzureDeployments((c?.deployments || []).join(', '));
+            })
+            .catch(() => setAzure({}));
+    }, [activeSection]);
+
+    const saveAzureSettings = async () => {
+        setAzureError('');
+        const deployments = azureDeployments.split(',').map(d => d.trim()).filter(Boolean);
+        const err: string = await (Bridge as any).SetAzureOpenAISettings({ ...azure, deployments });
+        if (err) setAzureError(err);
+    };
+
+    const saveProxySettings = async () => {
+        setProxyError('');
+        const err: string = await (Bridge as any).SetProxySettings(proxySettings);
+        if (err) {
+            setProxyError(err);
+            return;
+        }
+        const state = await (Bridge as any).GetProxySettings();
+        setProxyEffective(state?.effective || {});
+    };
+
+    const proxyField = (key: keyof ProxyConfig, label: string, example: string) => (
+        <TextField
+            label={label}
+            value={proxySettings[key] || ''}
+            onChange={(e) => setProxySettings(prev => ({ ...prev, [key]: e.target.value }))}
+            onBlur={saveProxySettings}
+            placeholder={proxyEffective[key] ? `${proxyEffective[key]} (from environment)` : example}
+            InputLabelProps={{ shrink: true }}
+            fullWidth
+        />
+    );
+
     // Reset visible counts when search changes to avoid confusion
     React.useEffect(() => {
         if (modelSearchQuery.trim()) {
@@ -145,7 +306,7 @@ export default function SettingsTab({
             if (a === 'FREE') return -1;
             if (b === 'FREE') return 1;
             // Keep original ordering for other groups
-            const order = ['Flagship', 'Reasoning', 'Production', 'Local', 'OpenRouter'];
+            const order = ['Flagship', 'Reasoning', 'Production', 'Gemini', 'Azure OpenAI', 'Local', 'OpenRouter'];
             const aIndex = order.indexOf(a);
             const bIndex = order.indexOf(b);
             if (aIndex !== -1 && bIndex !== -1) return aIndex - bIndex;
@@ -183,7 +344,7 @@ export default function SettingsTab({
     // Auto-save when any setting changes
     React.useEffect(() => {
         onSave();
-    }, [openaiKey, anthropicKey, openrouterKey, ollamaEndpoint, autoApproveShell, autoApproveEdits, currentTheme, selectedModels, onSave]);
+    }, [openaiKey, anthropicKey, openrouterKey, geminiKey, ollamaEndpoint, autoApproveShell, autoApproveEdits, currentTheme, selectedModels, onSave]);
 
     const SectionTitle = ({ children }: { children: React.ReactNode }) => (
         <Typography variant="h6" sx={{
@@ -211,6 +372,10 @@ export default function SettingsTab({
         { id: 'Available Models', label: 'Models', icon: '🤖' },
         { id: 'API Keys', label: 'API Credentials', icon: '🔑' },
         { id: 'Local Models', label: 'Ollama', icon: '💻' },
+        { id: 'Experimental', label: 'Experimental features', icon: '🧪' },
+        { id: 'Network', label: 'Network', icon: '🌐' },
+        { id: 'Privacy', label: 'Privacy', icon: '🔒' },
+        { id: 'Trash', label: 'Trash', icon: '🗑️' },
     ];
 
     return (
@@ -341,6 +506,86 @@ export default function SettingsTab({
                                     )
                                 }}
                             />
+                            <TextField
+                                label="Gemini API Key"
+                                type={showGemini ? 'text' : 'password'}
+                                autoComplete="off"
+                                value={geminiKey}
+                                onChange={(e) => setGeminiKey(e.target.value)}
+                                placeholder="AIza..."
+                                fullWidth
+                                InputProps={{
+                                    startAdornment: keyAdornment,
+                                    endAdornment: (
+                                        <InputAdornment position="end">
+                                            <IconButton onClick={() => setShowGemini((v) => !v)} edge="end">
+                                                {showGemini ? <VisibilityOff /> : <Visibility />}
+                                            </IconButton>
+                                        </InputAdornment>
+                                    )
+                                }}
+                            />
+                        </Stack>
+                    </Paper>
+                )}
+
+                {/* Azure OpenAI Section */}
+                {activeSection === 'API Keys' && (
+                    <Paper elevation={0} sx={{ p: 3, mt: 3, border: 1, borderColor: 'divider', borderRadius: 2 }}>
+                        <SectionTitle>Azure OpenAI</SectionTitle>
+                        <Typography variant="body2" color="text.secondary" sx={{ mb: 3 }}>
+                            For organizations that reach OpenAI models through an Azure OpenAI resource. Each deployment appears under Models as azure:&lt;deployment&gt;; name deployments after their model (e.g. o4-mini) so reasoning models are recognized.
+                        </Typography>
+                        <Stack spacing={2.5}>
+                            <TextField
+                                label="Resource endpoint"
+                                value={azure.endpoint || ''}
+                                onChange={(e) => setAzure(prev => ({ ...prev, endpoint: e.target.value }))}
+                                onBlur={saveAzureSettings}
+                                placeholder="https://contoso.openai.azure.com"
+                                fullWidth
+                            />
+                            <TextField
+                                label="Azure OpenAI API Key"
+                                type={showAzure ? 'text' : 'password'}
+                                autoComplete="off"
+                                value={azure.api_key || ''}
+                                onChange={(e) => setAzure(prev => ({ ...prev, api_key: e.target.value }))}
+                                onBlur={saveAzureSettings}
+                                fullWidth
+                                InputProps={{
+                                    startAdornment: keyAdornment,
+                                    endAdornment: (
+                                        <InputAdornment position="end">
+                                            <IconButton onClick={() => setShowAzure((v) => !v)} edge="end">
+                                                {showAzure ? <VisibilityOff /> : <Visibility />}
+                                            </IconButton>
+                                        </InputAdornment>
+                                    )
+                                }}
+                            />
+                            <TextField
+                                label="Deployments"
+                                value={azureDeployments}
+                                onChange={(e) => setAzureDeployments(e.target.value)}
+                                onBlur={saveAzureSettings}
+                                placeholder="gpt-4o, o4-mini"
+                                helperText="Comma-separated deployment names"
+                                fullWidth
+                            />
+                            <TextField
+                                label="API version"
+                                value={azure.api_version || ''}
+                                onChange={(e) => setAzure(prev => ({ ...prev, api_version: e.target.value }))}
+                                onBlur={saveAzureSettings}
+                                placeholder="2024-10-21"
+                                fullWidth
+                            />
+                            {azureError && (
+                                <Typography variant="caption" color="error">
+                                    {azureError}
+                                </Typography>
+                            )}
                         </Stack>
                     </Paper>
                 )}
@@ -715,6 +960,203 @@ export default function SettingsTab({
                             >
                                 Open project data folder <LaunchIcon fontSize="small" />
                             </Link>
+                            <Link
+                                component="button"
+                                underline="hover"
+                                onClick={async () => {
+                                    try {
+                                        const res: any = await (Bridge as any).ExportAuditLog();
+                                        if (res?.error) setAuditStatus(`Audit export failed: ${res.error}`);
+                                        else if (res?.path) setAuditStatus(`Exported ${res.count} audit events to ${res.path}`);
+                                    } catch (error) {
+                                        setAuditStatus(`Audit export failed: ${String(error)}`);
+                                    }
+                                }}
+                                sx={{ alignSelf: 'flex-start', textAlign: 'left' }}
+                            >
+                                Export audit log
+                            </Link>
+                            <Link
+                                component="button"
+                                underline="hover"
+                                onClick={async () => {
+                                    setAuditStatus('Collecting diagnostics…');
+                                    try {
+                                        const res: any = await (Bridge as any).ExportBugReport();
+                                        if (res?.error) setAuditStatus(`Bug report failed: ${res.error}`);
+                                        else if (res?.path) setAuditStatus(`Saved bug report to ${res.path}. Review it before attaching it to an issue.`);
+                                        else setAuditStatus('');
+                                    } catch (error) {
+                                        setAuditStatus(`Bug report failed: ${String(error)}`);
+                                    }
+                                }}
+                                sx={{ alignSelf: 'flex-start', textAlign: 'left' }}
+                            >
+                                Save bug report bundle
+                            </Link>
+                            {auditStatus && (
+                                <Typography variant="caption" color="text.secondary">
+                                    {auditStatus}
+                                </Typography>
+                            )}
+                        </Stack>
+                    </Paper>
+                )}
+
+                {/* Experimental Features Section */}
+                {activeSection === 'Experimental' && (
+                    <Paper elevation={0} sx={{ p: 3, border: 1, borderColor: 'divider', borderRadius: 2 }}>
+                        <SectionTitle>Experimental features</SectionTitle>
+                        <Typography variant="body2" color="text.secondary" sx={{ mb: 3 }}>
+                            Subsystems that are still being developed. Changes apply from the next message on.
+                        </Typography>
+                        <Stack spacing={2}>
+                            {featureFlags.map((flag) => (
+                                <Box key={flag.name} sx={{
+                                    p: 2,
+                                    bgcolor: 'action.hover',
+                                    borderRadius: 1,
+                                    border: 1,
+                                    borderColor: 'divider'
+                                }}>
+                                    <FormControlLabel
+                                        control={
+                                            <Switch
+                                                checked={flag.enabled}
+                                                onChange={() => toggleFeatureFlag(flag)}
+                                                size="medium"
+                                            />
+                                        }
+                                        label={
+                                            <Box>
+                                                <Typography variant="body1" fontWeight={600}>
+                                                    {flag.name}{flag.enabled !== flag.default ? ' (changed)' : ''}
+                                                </Typography>
+                                                <Typography variant="body2" color="text.secondary">
+                                                    {flag.description}
+                                                </Typography>
+                                            </Box>
+                                        }
+                                        sx={{ alignItems: 'flex-start', m: 0 }}
+                                    />
+                                </Box>
+                            ))}
+                            {featureFlagError && (
+                                <Typography variant="caption" color="error">
+                                    {featureFlagError}
+                                </Typography>
+                            )}
+                        </Stack>
+                    </Paper>
+                )}
+
+                {/* Network Section */}
+                {activeSection === 'Network' && (
+                    <Paper elevation={0} sx={{ p: 3, border: 1, borderColor: 'divider', borderRadius: 2 }}>
+                        <SectionTitle>Proxy</SectionTitle>
+                        <Typography variant="body2" color="text.secondary" sx={{ mb: 3 }}>
+                            Model requests, http_request and import_url go through these proxies. Empty fields use HTTP_PROXY, HTTPS_PROXY and NO_PROXY from the environment. MCP servers receive the settings when they are next started.
+                        </Typography>
+                        <Stack spacing={2.5}>
+                            {proxyField('http_proxy', 'HTTP proxy', 'http://proxy.example.com:3128')}
+                            {proxyField('https_proxy', 'HTTPS proxy', 'http://proxy.example.com:3128')}
+                            {proxyField('no_proxy', 'No proxy', '.internal.example.com,10.0.0.0/8')}
+                            {proxyField('ca_bundle', 'CA bundle', '/etc/ssl/certs/corporate-root.pem')}
+                            {proxyError && (
+                                <Typography variant="caption" color="error">
+                                    {proxyError}
+                                </Typography>
+                            )}
+                        </Stack>
+                    </Paper>
+                )}
+
+                {/* Privacy Section */}
+                {activeSection === 'Privacy' && (
+                    <Paper elevation={0} sx={{ p: 3, border: 1, borderColor: 'divider', borderRadius: 2 }}>
+                        <SectionTitle>Encryption at rest</SectionTitle>
+                        <Typography variant="body2" color="text.secondary" sx={{ mb: 3 }}>
+                            Encrypts this workspace's conversations, project memories, run summaries and handoffs with AES-256-GCM. The key is kept in the OS keychain (macOS Keychain or the Secret Service), or in an owner-only file under ~/.loom/keys when neither is available.
+                        </Typography>
+                        {encryption.error ? (
+                            <Typography variant="body2" color="text.secondary">
+                                Open a workspace to configure encryption.
+                            </Typography>
+                        ) : (
+                            <Stack spacing={2}>
+                                <FormControlLabel
+                                    control={
+                                        <Switch
+                                            checked={!!encryption.enabled}
+                                            disabled={encryptionBusy}
+                                            onChange={toggleEncryption}
+                                        />
+                                    }
+                                    label={encryption.enabled ? `Encrypted (key in ${encryption.key_store})` : `Store in plaintext (a new key would go to ${encryption.backend})`}
+                                />
+                                <Typography variant="caption" color="text.secondary">
+                                    {encryption.workspace}
+                                </Typography>
+                                {encryptionError && (
+                                    <Typography variant="caption" color="error">
+                                        {encryptionError}
+                                    </Typography>
+                                )}
+                            </Stack>
+                        )}
+                    </Paper>
+                )}
+
+                {/* Trash Section */}
+                {activeSection === 'Trash' && (
+                    <Paper elevation={0} sx={{ p: 3, border: 1, borderColor: 'divider', borderRadius: 2 }}>
+                        <SectionTitle>Trash</SectionTitle>
+                        <Typography variant="body2" color="text.secondary" sx={{ mb: 3 }}>
+                            Files the agent deleted in this workspace are kept in .loom/trash for 14 days (LOOM_TRASH_DAYS) before they are purged.
+                        </Typography>
+                        <Stack spacing={1}>
+                            {trash.length === 0 && (
+                                <Typography variant="body2" color="text.secondary">
+                                    The trash is empty.
+                                </Typography>
+                            )}
+                            {trash.map((entry) => (
+                                <Box key={entry.id} sx={{
+                                    p: 2,
+                                    bgcolor: 'action.hover',
+                                    borderRadius: 1,
+                                    border: 1,
+                                    borderColor: 'divider',
+                                    display: 'flex',
+                                    alignItems: 'center',
+                                    gap: 2
+                                }}>
+                                    <Box sx={{ flex: 1, minWidth: 0 }}>
+                                        <Typography variant="body1" fontWeight={600} noWrap>
+                                            {entry.path}{entry.dir ? '/' : ''}
+                                        </Typography>
+                                        <Typography variant="body2" color="text.secondary" noWrap>
+                                            {new Date(entry.deleted_at).toLocaleString()} · {Math.max(1, Math.round(entry.size / 1024))} KB{entry.reason ? ` · ${entry.reason}` : ''}
+                                        </Typography>
+                                    </Box>
+                                    <Link component="button" underline="hover" onClick={() => trashAction('RestoreTrash', entry.id)}>
+                                        Restore
+                                    </Link>
+                                    <Link component="button" underline="hover" color="error" onClick={() => trashAction('DiscardTrash', entry.id)}>
+                                        Delete forever
+                                    </Link>
+                                </Box>
+                            ))}
+                            {trash.length > 0 && (
+                                <Link component="button" underline="hover" color="error" onClick={() => trashAction('DiscardTrash', '')} sx={{ alignSelf: 'flex-start' }}>
+                                    Empty trash
+                                </Link>
+                            )}
+                            {trashError && (
+                                <Typography variant="caption" color="error">
+                                    {trashError}
+                                </Typography>
+                            )}
                         </Stack>
                     </Paper>
                 )}
@@ -0,0 +1,97 @@
+import { Dialog, DialogTitle, DialogContent, DialogActions, Button, Stack, Typography, Chip, Divider, LinearProgress } from '@mui/material';
+import { useEffect, useState } from 'react';
+import * as AppBridge from '../../../wailsjs/go/bridge/App';
+
+type Props = {
+    open: boolean;
+    onClose: () => void;
+};
+
+type Check = { name: string; status: 'ok' | 'warning' | 'error'; detail: string };
+type Server = { name: string; running: boolean; initialized: boolean; pid?: number; error?: string };
+type Slot = { tool: string; in_use: number; max: number };
+type EngineError = { time: string; source: string; message: string };
+
+type Diagnostics = {
+    generated_at: string;
+    workspace: string;
+    checks: Check[];
+    mcp: Server[];
+    status: { phase: string; tool?: string };
+    queues: { pending_approvals: number; pending_choices: number; pending_questions: number; tool_slots?: Slot[] };
+    recent_errors: EngineError[];
+};
+
+const chipColor = (status: string) => (status === 'ok' ? 'success' : status === 'warning' ? 'warning' : 'error');
+
+export default function HealthDialog(props: Props) {
+    const { open, onClose } = props;
+    const [diag, setDiag] = useState<Diagnostics | null>(null);
+    const [busy, setBusy] = useState<boolean>(false);
+
+    const refresh = () => {
+        const p = (AppBridge as any).GetDiagnostics?.();
+        if (!p) return;
+        setBusy(true);
+        p.then((d: Diagnostics) => setDiag(d)).catch(() => {}).finally(() => setBusy(false));
+    };
+
+    useEffect(() => {
+        if (open) refresh();
+        // eslint-disable-next-line react-hooks/exhaustive-deps
+    }, [open]);
+
+    const q = diag?.queues;
+
+    return (
+        <Dialog open={open} onClose={onClose} maxWidth="sm" fullWidth>
+            <DialogTitle>Health</DialogTitle>
+            <DialogContent dividers>
+                {busy && <LinearProgress sx={{ mb: 1 }} />}
+                {diag && (
+                    <Stack spacing={1} sx={{ mt: 1 }}>
+                        {diag.checks.map((c) => (
+                            <Stack key={c.name} direction="row" spacing={1} alignItems="center">
+                                <Chip size="small" label={c.status} color={chipColor(c.status)} sx={{ minWidth: 72 }} />
+                                <Typography variant="body2" sx={{ fontWeight: 600, minWidth: 64 }}>{c.name}</Typography>
+                                <Typography variant="body2" color="text.secondary" sx={{ flex: 1 }}>{c.detail}</Typography>
+                            </Stack>
+                        ))}
+                        <Divider />
+                        <Typography variant="body2">
+                            Engine: {diag.status?.phase || 'idle'}{diag.status?.tool ? ` (${diag.status.tool})` : ''}
+                        </Typography>
+                        {q && (
+                            <Typography variant="body2" color="text.secondary">
+                                Pending: {q.pending_approvals} approval(s), {q.pending_choices} choice(s), {q.pending_questions} question(s)
+                            </Typography>
+                        )}
+                        {(q?.tool_slots || []).map((s) => (
+                            <Typography key={s.tool} variant="body2" color="text.secondary" sx={{ fontFamily: 'monospace' }}>
+                                {s.tool}: {s.in_use}/{s.max} slots in use
+                            </Typography>
+                        ))}
+                        {diag.mcp.length > 0 && <Divider />}
+                        {diag.mcp.map((s) => (
+                            <Stack key={s.name} direction="row" spacing={1} alignItems="center">
+                                <Chip size="small" variant="outlined" label={!s.running ? 'down' : s.initialized ? 'connected' : 'starting'} color={!s.running || s.error ? 'error' : s.initialized ? 'success' : 'warning'} />
+                                <Typography variant="body2" sx={{ fontFamily: 'monospace' }}>{s.name}</Typography>
+                                {s.error && <Typography variant="body2" color="error" sx={{ flex: 1 }}>{s.error}</Typography>}
+                            </Stack>
+                        ))}
+                        {diag.recent_errors.length > 0 && <Divider />}
+                        {diag.recent_errors.slice().reverse().map((e, i) => (
+                            <Typography key={i} variant="body2" color="text.secondary" sx={{ fontFamily: 'monospace' }}>
+                                {new Date(e.time).toLocaleTimeString()} [{e.source}] {e.message}
+                            </Typography>
+                        ))}
+                    </Stack>
+                )}
+            </DialogContent>
+            <DialogActions>
+                <Button onClick={refresh} disabled={busy}>Refresh</Button>
+                <Button onClick={onClose} color="inherit">Close</Button>
+            </DialogActions>
+        </Dialog>
+    );
+}
@@ -0,0 +1,103 @@
+import { Dialog, DialogTitle, DialogContent, DialogActions, Button, Stack, Typography, Chip, LinearProgress, Alert } from '@mui/material';
+import { useEffect, useState } from 'react';
+import * as AppBridge from '../../../wailsjs/go/bridge/App';
+
+type Props = {
+    open: boolean;
+    onClose: () => void;
+};
+
+type Finding = {
+    id: string;
+    check: string;
+    severity: 'high' | 'medium' | 'low';
+    path?: string;
+    line?: number;
+    message: string;
+    suggestion?: string;
+    fix?: { description: string; path: string };
+};
+
+type Report = { workspace: string; git: boolean; truncated?: boolean; findings: Finding[]; error?: string };
+
+const chipColor = (severity: string) => (severity === 'high' ? 'error' : severity === 'medium' ? 'warning' : 'default');
+
+export default function HygieneDialog(props: Props) {
+    const { open, onClose } = props;
+    const [report, setReport] = useState<Report | null>(null);
+    const [busy, setBusy] = useState<boolean>(false);
+    const [applying, setApplying] = useState<string>('');
+    const [error, setError] = useState<string>('');
+
+    const refresh = () => {
+        const p = (AppBridge as any).GetHygieneReport?.();
+        if (!p) return;
+        setBusy(true);
+        p.then((r: Report) => setReport(r)).catch(() => {}).finally(() => setBusy(false));
+    };
+
+    useEffect(() => {
+        if (open) {
+            setError('');
+            refresh();
+        }
+        // eslint-disable-next-line react-hooks/exhaustive-deps
+    }, [open]);
+
+    // The fix is shown as a diff in the approval dialog before it is written
+    const applyFix = (id: string) => {
+        setApplying(id);
+        setError('');
+        (AppBridge as any).ApplyHygieneFix?.(id)
+            .then((res: any) => {
+                if (res?.error) setError(res.error);
+                refresh();
+            })
+            .catch(() => {})
+            .finally(() => setApplying(''));
+    };
+
+    return (
+        <Dialog open={open} onClose={onClose} maxWidth="md" fullWidth>
+            <DialogTitle>Repository hygiene</DialogTitle>
+            <DialogContent dividers>
+                {busy && <LinearProgress sx={{ mb: 1 }} />}
+                {(error || report?.error) && <Alert severity="error" sx={{ mb: 1 }}>{error || report?.error}</Alert>}
+                {report && !report.git && <Alert severity="info" sx={{ mb: 1 }}>Not a git repository: history checks were skipped.</Alert>}
+                {report && report.findings.length === 0 && !busy && (
+                    <Typography variant="body2" color="text.secondary">No issues found.</Typography>
+                )}
+                <Stack spacing={1.5} sx={{ mt: 1 }}>
+                    {(report?.findings || []).map((f) => (
+                        <Stack key={f.id} spacing={0.5}>
+                            <Stack direction="row" spacing={1} alignItems="center">
+                                <Chip size="small" label={f.severity} color={chipColor(f.severity) as any} sx={{ minWidth: 72 }} />
+                                <Typography variant="body2" sx={{ fontWeight: 600 }}>{f.check}</Typography>
+                                {f.path && (
+                                    <Typography variant="body2" color="text.secondary" sx={{ fontFamily: 'monospace' }}>
+                                        {f.path}{f.line ? `:${f.line}` : ''}
+                                    </Typography>
+                                )}
+                            </Stack>
+                            <Typography variant="body2">{f.message}</Typography>
+                            {f.fix ? (
+                                <Stack direction="row" spacing={1} alignItems="center">
+                                    <Button size="small" variant="outlined" disabled={!!applying} onClick={() => applyFix(f.id)}>
+                                        {applying === f.id ? 'Waiting for approval…' : 'Fix'}
+                                    </Button>
+                                    <Typography variant="body2" color="text.secondary">{f.fix.description}</Typography>
+                                </Stack>
+                            ) : f.suggestion && (
+                                <Typography variant="body2" color="text.secondary">{f.suggestion}</Typography>
+                            )}
+                        </Stack>
+                    ))}
+                </Stack>
+            </DialogContent>
+            <DialogActions>
+                <Button onClick={refresh} disabled={busy}>Re-run</Button>
+                <Button onClick={onClose} color="inherit">Close</Button>
+            </DialogActions>
+        </Dialog>
+    );
+}
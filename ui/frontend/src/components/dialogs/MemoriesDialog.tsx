@@ -1,4 +1,4 @@
-import { Dialog, DialogTitle, DialogContent, DialogActions, Button, Stack, Paper, Typography, IconButton } from '@mui/material';
+import { Dialog, DialogTitle, DialogContent, DialogActions, Button, Stack, Paper, Typography, IconButton, Chip } from '@mui/material';
 import DeleteIcon from '@mui/icons-material/DeleteOutline';
 import { useEffect, useState } from 'react';
 import * as AppBridge from '../../../wailsjs/go/bridge/App';
@@ -8,16 +8,17 @@ type Props = {
     onClose: () => void;
 };
 
-type Memory = { id: string; text: string };
+type Memory = { id: string; text: string; tags: string[] };
 
 export default function MemoriesDialog(props: Props) {
     const { open, onClose } = props;
     const [memories, setMemories] = useState<Memory[]>([]);
+    const [shareStatus, setShareStatus] = useState('');
 
     const refresh = () => {
         (AppBridge as any).GetMemories?.().then((list: any) => {
             const arr = Array.isArray(list) ? list : [];
-            setMemories(arr.map((m: any) => ({ id: String(m?.id || ''), text: String(m?.text || '') })));
+            setMemories(arr.map((m: any) => ({ id: String(m?.id || ''), text: String(m?.text || ''), tags: Array.isArray(m?.tags) ? m.tags.map(String) : [] })));
         }).catch(() => { setMemories([]); });
     };
 
@@ -32,6 +33,19 @@ export default function MemoriesDialog(props: Props) {
         }).catch(() => {});
     };
 
+    // Project memories are shared through .loom/memories.md in the workspace
+    const onExport = () => {
+        (AppBridge as any).ExportMemories?.('').then((res: any) => {
+            setShareStatus(res?.error ? `Export failed: ${res.error}` : `Exported ${res?.count ?? 0} project memories to ${res?.path}`);
+        }).catch((e: any) => setShareStatus(`Export failed: ${String(e)}`));
+    };
+
+    const onImport = () => {
+        (AppBridge as any).ImportMemories?.('').then((res: any) => {
+            setShareStatus(res?.error ? `Import failed: ${res.error}` : `Imported project memories: ${res?.added ?? 0} added, ${res?.updated ?? 0} updated, ${res?.unchanged ?? 0} unchanged`);
+        }).catch((e: any) => setShareStatus(`Import failed: ${String(e)}`));
+    };
+
     return (
         <Dialog open={open} onClose={onClose} maxWidth="sm" fullWidth>
             <DialogTitle>Memories</DialogTitle>
@@ -46,6 +60,11 @@ export default function MemoriesDialog(props: Props) {
                                 <Stack sx={{ flex: 1 }}>
                                     <Typography variant="caption" color="text.secondary">{m.id}</Typography>
                                     <Typography variant="body2">{m.text}</Typography>
+                                    {m.tags.length > 0 && (
+                                        <Stack direction="row" spacing={0.5} sx={{ mt: 0.5 }}>
+                                            {m.tags.map((t) => <Chip key={t} size="small" variant="outlined" label={t} />)}
+                                        </Stack>
+                                    )}
                                 </Stack>
                                 <IconButton size="small" color="error" onClick={() => onDelete(m.id)}>
                                     <DeleteIcon fontSize="small" />
@@ -56,6 +75,11 @@ export default function MemoriesDialog(props: Props) {
                 </Stack>
             </DialogContent>
             <DialogActions>
+                {shareStatus && (
+                    <Typography variant="caption" color="text.secondary" sx={{ flex: 1, pl: 1 }}>{shareStatus}</Typography>
+                )}
+                <Button onClick={onImport} color="inherit">Import shared</Button>
+                <Button onClick={onExport} color="inherit">Export shared</Button>
                 <Button onClick={onClose} color="inherit">Close</Button>
             </DialogActions>
         </Dialog>
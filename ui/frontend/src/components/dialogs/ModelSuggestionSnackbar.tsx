@@ -0,0 +1,49 @@
+import { Snackbar, Alert, AlertTitle, Button, Stack } from '@mui/material';
+import { useEffect, useState } from 'react';
+import * as AppBridge from '../../../wailsjs/go/bridge/App';
+import { EventsOn } from '../../../wailsjs/runtime/runtime';
+
+type Props = {
+    onSwitch: (model: string) => void;
+};
+
+type Suggestion = {
+    current: string;
+    suggested: string;
+    reason: string;
+    monthly_savings_usd: number;
+    current_monthly_usd: number;
+    projected_monthly_usd: number;
+};
+
+export default function ModelSuggestionSnackbar(props: Props) {
+    const { onSwitch } = props;
+    const [suggestion, setSuggestion] = useState<Suggestion | null>(null);
+
+    useEffect(() => {
+        const off = EventsOn('model:suggestion', (s: Suggestion) => {
+            if (s && s.suggested) setSuggestion(s);
+        });
+        return () => { off(); };
+    }, []);
+
+    const dismiss = () => {
+        if (suggestion) (AppBridge as any).DismissModelSuggestion?.(suggestion.current, suggestion.suggested);
+        setSuggestion(null);
+    };
+
+    return (
+        <Snackbar open={!!suggestion} anchorOrigin={{ vertical: 'bottom', horizontal: 'left' }}>
+            <Alert severity="info" variant="outlined" sx={{ maxWidth: 480, bgcolor: 'background.paper' }}>
+                <AlertTitle>
+                    Save about ${(suggestion?.monthly_savings_usd || 0).toFixed(2)}/month with {suggestion?.suggested.split(':').slice(1).join(':')}
+                </AlertTitle>
+                {suggestion?.reason} Projected spend: ${(suggestion?.projected_monthly_usd || 0).toFixed(2)} instead of ${(suggestion?.current_monthly_usd || 0).toFixed(2)} per month.
+                <Stack direction="row" spacing={1} sx={{ mt: 1 }}>
+                    <Button size="small" variant="contained" onClick={() => { if (suggestion) onSwitch(suggestion.suggested); setSuggestion(null); }}>Switch default</Button>
+                    <Button size="small" color="inherit" onClick={dismiss}>Not now</Button>
+                </Stack>
+            </Alert>
+        </Snackbar>
+    );
+}
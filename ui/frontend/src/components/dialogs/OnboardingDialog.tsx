@@ -0,0 +1,103 @@
+import { Dialog, DialogTitle, DialogContent, DialogActions, Button, Stack, TextField, Typography, CircularProgress } from '@mui/material';
+import CheckIcon from '@mui/icons-material/CheckCircleOutline';
+import ErrorIcon from '@mui/icons-material/ErrorOutline';
+import SkipIcon from '@mui/icons-material/RemoveCircleOutline';
+import PendingIcon from '@mui/icons-material/RadioButtonUnchecked';
+import { useEffect, useState } from 'react';
+import * as AppBridge from '../../../wailsjs/go/bridge/App';
+import { EventsOn } from '../../../wailsjs/runtime/runtime';
+
+type Props = {
+    // Called with the model chosen by onboarding once it completes
+    onFinished: (model: string) => void;
+};
+
+type Step = { id: string; title: string; status: string; detail?: string; duration_ms?: number };
+
+function StepIcon({ status }: { status: string }) {
+    switch (status) {
+        case 'running': return <CircularProgress size={16} />;
+        case 'ok': return <CheckIcon fontSize="small" color="success" />;
+        case 'failed': return <ErrorIcon fontSize="small" color="error" />;
+        case 'skipped': return <SkipIcon fontSize="small" color="disabled" />;
+        default: return <PendingIcon fontSize="small" color="disabled" />;
+    }
+}
+
+export default function OnboardingDialog(props: Props) {
+    const { onFinished } = props;
+    const [open, setOpen] = useState<boolean>(false);
+    const [running, setRunning] = useState<boolean>(false);
+    const [steps, setSteps] = useState<Step[]>([]);
+    const [keys, setKeys] = useState<Record<string, string>>({ anthropic: '', openai: '', openrouter: '', gemini: '', ollama: '' });
+    const [suggested, setSuggested] = useState<string>('');
+
+    useEffect(() => {
+        (AppBridge as any).GetOnboardingState?.().then((st: any) => {
+            setSteps(Array.isArray(st?.steps) ? st.steps : []);
+            setOpen(!!st?.required);
+        }).catch(() => {});
+        const off = EventsOn('onboarding:step', (step: Step) => {
+            setSteps(prev => prev.map(s => (s.id === step.id ? step : s)));
+        });
+        return () => { off(); };
+    }, []);
+
+    const run = () => {
+        const credentials: Record<string, string> = {};
+        Object.entries(keys).forEach(([k, v]) => { if (v.trim()) credentials[k] = v.trim(); });
+        setRunning(true);
+        (AppBridge as any).RunOnboarding?.(credentials).then((st: any) => {
+            setSteps(Array.isArray(st?.steps) ? st.steps : []);
+            setSuggested(String(st?.suggested_model || ''));
+            if (!st?.required) onFinished(String(st?.suggested_model || ''));
+        }).catch(() => {}).finally(() => setRunning(false));
+    };
+
+    const skip = () => {
+        (AppBridge as any).SkipOnboarding?.();
+        setOpen(false);
+    };
+
+    const done = steps.length > 0 && steps.every(s => s.status === 'ok' || s.status === 'skipped') && !!suggested;
+    const setKey = (k: string) => (e: React.ChangeEvent<HTMLInputElement>) => setKeys(prev => ({ ...prev, [k]: e.target.value }));
+
+    return (
+        <Dialog open={open} maxWidth="sm" fullWidth>
+            <DialogTitle>Welcome to Loom</DialogTitle>
+            <DialogContent dividers>
+                <Stack spacing={1.5}>
+                    <Typography variant="body2" color="text.secondary">
+                        Enter at least one API key, or an Ollama endpoint for local models. Keys are checked with a live request before they are saved.
+                    </Typography>
+                    <TextField size="small" label="Anthropic API key" type="password" value={keys.anthropic} onChange={setKey('anthropic')} disabled={running} />
+                    <TextField size="small" label="OpenAI API key" type="password" value={keys.openai} onChange={setKey('openai')} disabled={running} />
+                    <TextField size="small" label="OpenRouter API key" type="password" value={keys.openrouter} onChange={setKey('openrouter')} disabled={running} />
+                    <TextField size="small" label="Gemini API key" type="password" value={keys.gemini} onChange={setKey('gemini')} disabled={running} />
+                    <TextField size="small" label="Ollama endpoint" placeholder="http://localhost:11434" value={keys.ollama} onChange={setKey('ollama')} disabled={running} />
+                    <Stack spacing={0.5} sx={{ pt: 1 }}>
+                        {steps.map(s => (
+                            <Stack key={s.id} direction="row" spacing={1} alignItems="flex-start">
+                                <StepIcon status={s.status} />
+                                <Stack sx={{ flex: 1 }}>
+                                    <Typography variant="body2">{s.title}</Typography>
+                                    {s.detail && <Typography variant="caption" color={s.status === 'failed' ? 'error' : 'text.secondary'}>{s.detail}</Typography>}
+                                </Stack>
+                            </Stack>
+                        ))}
+                    </Stack>
+                </Stack>
+            </DialogContent>
+            <DialogActions>
+                {done ? (
+                    <Button variant="contained" onClick={() => setOpen(false)}>Start</Button>
+                ) : (
+                    <>
+                        <Button onClick={skip} color="inherit" disabled={running}>Skip</Button>
+                        <Button variant="contained" onClick={run} disabled={running}>{running ? 'Checking…' : 'Continue'}</Button>
+                    </>
+                )}
+            </DialogActions>
+        </Dialog>
+    );
+}
@@ -0,0 +1,88 @@
+import { Dialog, DialogTitle, DialogContent, DialogActions, Button, Stack, Typography, Chip, Alert } from '@mui/material';
+import { useEffect, useState } from 'react';
+import * as AppBridge from '../../../wailsjs/go/bridge/App';
+import { EventsOn } from '../../../wailsjs/runtime/runtime';
+
+type Props = {
+    open: boolean;
+    onClose: () => void;
+};
+
+type Change = { path: string; status: 'added' | 'modified' | 'deleted'; conflict?: boolean };
+
+type State = { active: boolean; changes: Change[]; error?: string };
+
+export default function WhatIfDialog(props: Props) {
+    const { open, onClose } = props;
+    const [state, setState] = useState<State>({ active: false, changes: [] });
+    const [busy, setBusy] = useState<boolean>(false);
+    const [message, setMessage] = useState<string>('');
+
+    const apply = (s: any) => {
+        setState({ active: !!s?.active, changes: Array.isArray(s?.changes) ? s.changes : [], error: s?.error });
+    };
+
+    const refresh = () => {
+        (AppBridge as any).GetWhatIf?.().then(apply).catch(() => {});
+    };
+
+    useEffect(() => {
+        if (open) { setMessage(''); refresh(); }
+        // eslint-disable-next-line react-hooks/exhaustive-deps
+    }, [open]);
+
+    useEffect(() => {
+        const off = EventsOn('whatif:changed', apply);
+        return () => { off(); };
+    }, []);
+
+    const run = (p: Promise<any> | undefined, done: (r: any) => void) => {
+        if (!p) return;
+        setBusy(true);
+        p.then(done).catch((e: any) => setMessage(String(e))).finally(() => { setBusy(false); refresh(); });
+    };
+
+    const enable = () => run((AppBridge as any).EnableWhatIf?.(), (err: string) => setMessage(err || ''));
+    const discard = () => run((AppBridge as any).DiscardWhatIf?.(), (err: string) => setMessage(err || ''));
+    const materialize = () => run((AppBridge as any).MaterializeWhatIf?.([]), (r: any) => {
+        const conflicts = Array.isArray(r?.conflicts) ? r.conflicts.length : 0;
+        setMessage(r?.error || (conflicts > 0 ? `${conflicts} file(s) changed in the workspace meanwhile and were not applied.` : ''));
+    });
+
+    return (
+        <Dialog open={open} onClose={onClose} maxWidth="sm" fullWidth>
+            <DialogTitle>What-if mode</DialogTitle>
+            <DialogContent dividers>
+                <Stack spacing={1} sx={{ mt: 1 }}>
+                    <Typography variant="body2" color="text.secondary">
+                        {state.active
+                            ? 'This conversation edits, builds and tests a private copy of the workspace. Apply the changes when they work, or discard them.'
+                            : 'Explore risky refactors safely: this conversation edits, builds and tests a private copy of the workspace until you apply or discard its changes.'}
+                    </Typography>
+                    {(message || state.error) && <Alert severity="warning">{message || state.error}</Alert>}
+                    {state.active && state.changes.length === 0 && (
+                        <Typography variant="body2" color="text.secondary">No changes yet.</Typography>
+                    )}
+                    {state.changes.map((c) => (
+                        <Stack key={c.path} direction="row" spacing={1} alignItems="center">
+                            <Chip size="small" label={c.status} color={c.status === 'deleted' ? 'error' : c.status === 'added' ? 'success' : 'default'} />
+                            <Typography variant="body2" sx={{ fontFamily: 'monospace', flex: 1 }}>{c.path}</Typography>
+                            {c.conflict && <Chip size="small" variant="outlined" color="warning" label="changed in workspace" />}
+                        </Stack>
+                    ))}
+                </Stack>
+            </DialogContent>
+            <DialogActions>
+                {state.active ? (
+                    <>
+                        <Button onClick={discard} color="error" disabled={busy}>Discard all</Button>
+                        <Button onClick={materialize} variant="contained" disabled={busy || state.changes.length === 0}>Apply to workspace</Button>
+                    </>
+                ) : (
+                    <Button onClick={enable} variant="contained" disabled={busy}>Start what-if mode</Button>
+                )}
+                <Button onClick={onClose} color="inherit">Close</Button>
+            </DialogActions>
+        </Dialog>
+    );
+}
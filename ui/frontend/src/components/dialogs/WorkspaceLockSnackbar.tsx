@@ -0,0 +1,45 @@
+import { Snackbar, Alert, AlertTitle, Button, Stack } from '@mui/material';
+import { useEffect, useState } from 'react';
+import * as AppBridge from '../../../wailsjs/go/bridge/App';
+import { EventsOn } from '../../../wailsjs/runtime/runtime';
+
+type LockInfo = {
+    workspace: string;
+    read_only: boolean;
+    reason?: string;
+    holder?: { pid: number; host: string; started_at: string; heartbeat_at: string };
+};
+
+export default function WorkspaceLockSnackbar() {
+    const [lock, setLock] = useState<LockInfo | null>(null);
+    const [error, setError] = useState('');
+
+    useEffect(() => {
+        const apply = (info: LockInfo) => { setLock(info && info.read_only ? info : null); setError(''); };
+        (AppBridge as any).GetWorkspaceLock?.().then(apply).catch(() => {});
+        const off = EventsOn('workspace:locked', apply);
+        const offChanged = EventsOn('workspace:changed', () => {
+            (AppBridge as any).GetWorkspaceLock?.().then(apply).catch(() => {});
+        });
+        return () => { off(); offChanged(); };
+    }, []);
+
+    const takeOver = async () => {
+        const err: string = await (AppBridge as any).TakeOverWorkspace?.();
+        if (err) setError(err); else setLock(null);
+    };
+
+    return (
+        <Snackbar open={!!lock} anchorOrigin={{ vertical: 'top', horizontal: 'center' }}>
+            <Alert severity="warning" variant="outlined" sx={{ maxWidth: 560, bgcolor: 'background.paper' }}>
+                <AlertTitle>Workspace is read-only</AlertTitle>
+                {lock?.reason}. Edits, undo and checkpoint reverts are disabled here so the two instances do not overwrite each other.
+                {error && <div>{error}</div>}
+                <Stack direction="row" spacing={1} sx={{ mt: 1 }}>
+                    <Button size="small" variant="contained" color="warning" onClick={takeOver}>Take over</Button>
+                    <Button size="small" color="inherit" onClick={() => setLock(null)}>Stay read-only</Button>
+                </Stack>
+            </Alert>
+        </Snackbar>
+    );
+}
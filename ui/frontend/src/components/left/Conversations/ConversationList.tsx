@@ -1,5 +1,5 @@
 import React from 'react';
-import { List, ListItem, ListItemButton, ListItemText, Box, Button } from '@mui/material';
+import { List, ListItem, ListItemButton, ListItemText, Box, Button, TextField } from '@mui/material';
 import { ConversationListItem } from '../../../types/ui';
 
 type Props = {
@@ -10,11 +10,26 @@ type Props = {
 
 function ConversationListComponent({ conversations, currentConversationId, onSelect }: Props) {
     const [visibleCount, setVisibleCount] = React.useState(5);
-    const visible = React.useMemo(() => conversations.slice(0, visibleCount), [conversations, visibleCount]);
-    const hasMore = visibleCount < conversations.length;
+    const [filter, setFilter] = React.useState('');
+    const tagged = React.useMemo(() => conversations.some((c) => c.tags?.task_type), [conversations]);
+    const filtered = React.useMemo(() => filterConversations(conversations, filter), [conversations, filter]);
+    const visible = React.useMemo(() => filtered.slice(0, visibleCount), [filtered, visibleCount]);
+    const hasMore = visibleCount < filtered.length;
 
     return (
         <List dense disablePadding sx={{ width: '100%' }}>
+            {tagged && (
+                <ListItem sx={{ px: 1, py: 0.5 }}>
+                    <TextField
+                        size="small"
+                        fullWidth
+                        placeholder="Filter by tag or title"
+                        value={filter}
+                        onChange={(e) => setFilter(e.target.value)}
+                        inputProps={{ style: { fontSize: '0.75rem' } }}
+                    />
+                </ListItem>
+            )}
             {visible.map((c) => {
                 const selected = c.id === currentConversationId;
                 return (
@@ -47,7 +62,7 @@ function ConversationListComponent({ conversations, currentConversationId, onSel
                             />
                             <ListItemText
                                 primary={c.title || c.id}
-                                secondary={c.updated_at ? new Date(c.updated_at).toLocaleString() : undefined}
+                                secondary={[c.updated_at ? new Date(c.updated_at).toLocaleString() : '', tagLabel(c)].filter(Boolean).join(' · ') || undefined}
                                 primaryTypographyProps={{
                                     fontWeight: selected ? 700 : 500,
                                     fontSize: '0.8rem',
@@ -68,10 +83,10 @@ function ConversationListComponent({ conversations, currentConversationId, onSel
                 );
             })}
 
-            {conversations.length === 0 && (
+            {filtered.length === 0 && (
                 <ListItem disableGutters sx={{ px: 1, py: 0.5 }}>
                     <ListItemText
-                        primary="No conversations yet"
+                        primary={conversations.length === 0 ? 'No conversations yet' : 'No matching conversations'}
                         primaryTypographyProps={{ fontSize: '0.8rem', color: 'text.secondary' }}
                     />
                 </ListItem>
@@ -82,7 +97,7 @@ function ConversationListComponent({ conversations, currentConversationId, onSel
                     <Button
                         size="small"
                         variant="text"
-                        onClick={() => setVisibleCount((n) => Math.min(n + 5, conversations.length))}
+                        onClick={() => setVisibleCount((n) => Math.min(n + 5, filtered.length))}
                         sx={{ textTransform: 'none', px: 1 }}
                     >
                         Show more
@@ -93,4 +108,24 @@ function ConversationListComponent({ conversations, currentConversationId, onSel
     );
 }
 
+// tagLabel shows a conversation's task type and first topics, e.g. "bugfix · billing, invoice".
+function tagLabel(c: ConversationListItem): string {
+    const t = c.tags;
+    if (!t?.task_type) return '';
+    const topics = (t.topics || []).slice(0, 2).join(', ');
+    return topics ? `${t.task_type} · ${topics}` : t.task_type;
+}
+
+// filterConversations keeps conversations whose title, task type, topics or packages
+// contain every word of the filter.
+function filterConversations(conversations: ConversationListItem[], filter: string): ConversationListItem[] {
+    const words = filter.toLowerCase().split(/\s+/).filter(Boolean);
+    if (words.length === 0) return conversations;
+    return conversations.filter((c) => {
+        const t = c.tags || {};
+        const hay = [c.title, t.task_type || '', ...(t.topics || []), ...(t.packages || [])].join(' ').toLowerCase();
+        return words.every((w) => hay.includes(w));
+    });
+}
+
 export default React.memo(ConversationListComponent);
@@ -3,6 +3,9 @@ import { Box, Typography, IconButton, Tooltip, LinearProgress } from '@mui/mater
 import SettingsIcon from '@mui/icons-material/Settings';
 import RuleIcon from '@mui/icons-material/Rule';
 import MemoryIcon from '@mui/icons-material/BookmarkBorder';
+import WhatIfIcon from '@mui/icons-material/Science';
+import HealthIcon from '@mui/icons-material/MonitorHeartOutlined';
+import HygieneIcon from '@mui/icons-material/CleaningServicesOutlined';
 import { EventsOn } from '../../../wailsjs/runtime/runtime';
 import FileExplorer from './Files/FileExplorer';
 import ProfileDialog from '../dialogs/ProfileDialog';
@@ -12,6 +15,9 @@ type SidebarProps = {
     onOpenWorkspace: () => void;
     onOpenRules: () => void;
     onOpenMemories?: () => void;
+    onOpenWhatIf?: () => void;
+    onOpenHealth?: () => void;
+    onOpenHygiene?: () => void;
     onOpenSettings: () => void;
     onOpenCosts: () => void;
     totalInUSD: number;
@@ -29,6 +35,9 @@ function Sidebar(props: SidebarProps) {
         onOpenRules,
         onOpenSettings,
         onOpenMemories,
+        onOpenWhatIf,
+        onOpenHealth,
+        onOpenHygiene,
         onOpenCosts,
         totalInUSD,
         totalOutUSD,
@@ -168,6 +177,57 @@ function Sidebar(props: SidebarProps) {
                             <MemoryIcon fontSize="small" />
                         </IconButton>
                     </Tooltip>
+                    <Tooltip title="What-if mode">
+                        <IconButton
+                            size="small"
+                            onClick={onOpenWhatIf}
+                            sx={{
+                                color: 'text.secondary',
+                                '&:hover': {
+                                    backgroundColor: 'primary.main',
+                                    '& .MuiSvgIcon-root': {
+                                        color: 'primary.contrastText'
+                                    }
+                                }
+                            }}
+                        >
+                            <WhatIfIcon fontSize="small" />
+                        </IconButton>
+                    </Tooltip>
+                    <Tooltip title="Health">
+                        <IconButton
+                            size="small"
+                            onClick={onOpenHealth}
+                            sx={{
+                                color: 'text.secondary',
+                                '&:hover': {
+                                    backgroundColor: 'primary.main',
+                                    '& .MuiSvgIcon-root': {
+                                        color: 'primary.contrastText'
+                                    }
+                                }
+                            }}
+                        >
+                            <HealthIcon fontSize="small" />
+                        </IconButton>
+                    </Tooltip>
+                    <Tooltip title="Repository hygiene">
+                        <IconButton
+                            size="small"
+                            onClick={onOpenHygiene}
+                            sx={{
+                                color: 'text.secondary',
+                                '&:hover': {
+                                    backgroundColor: 'primary.main',
+                                    '& .MuiSvgIcon-root': {
+                                        color: 'primary.contrastText'
+                                    }
+                                }
+                            }}
+                        >
+                            <HygieneIcon fontSize="small" />
+                        </IconButton>
+                    </Tooltip>
                     <Tooltip title="Settings">
                         <IconButton
                             size="small"
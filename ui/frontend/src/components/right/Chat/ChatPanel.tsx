@@ -1,13 +1,16 @@
 import React from 'react';
-import { Box, Divider, Typography, Popover, TextField, List, ListItemButton, ListItemText, IconButton, Button, Card, CardContent } from '@mui/material';
+import { Box, Divider, Typography, Popover, TextField, List, ListItemButton, ListItemText, IconButton, Button, Card, CardContent, Alert } from '@mui/material';
 import { EventsOn } from '../../../../wailsjs/runtime/runtime';
 import * as AppBridge from '../../../../wailsjs/go/bridge/App';
 import * as Bridge from '../../../../wailsjs/go/bridge/App';
 import MessageList from './MessageList';
 import Composer from './Composer2';
+import QuestionCard, { QuestionRequest } from './QuestionCard';
+import DescribeChangesDialog from './DescribeChangesDialog';
+import ConversationToolsDialog from './ConversationToolsDialog';
 import { ChatMessage, ConversationListItem } from '../../../types/ui';
 import ConversationList from '@/components/left/Conversations/ConversationList';
-import { AddRounded, SettingsSuggestRounded, CheckCircleRounded } from '@mui/icons-material';
+import { AddRounded, SettingsSuggestRounded, CheckCircleRounded, DescriptionRounded, BuildRounded } from '@mui/icons-material';
 
 type Props = {
     messages: ChatMessage[];
@@ -113,6 +116,83 @@ function ChatPanelComponent(props: Props) {
         selectedIndex?: number;
     } | null>(null);
 
+    const [describeOpen, setDescribeOpen] = React.useState(false);
+    const [conversationToolsOpen, setConversationToolsOpen] = React.useState(false);
+
+    // ask_user question awaiting an answer
+    const [questionRequest, setQuestionRequest] = React.useState<QuestionRequest | null>(null);
+
+    // Engine heartbeat: current phase, running tool, and stalled-provider state
+    const [engineStatus, setEngineStatus] = React.useState<{
+        phase: string;
+        tool?: string;
+        message?: string;
+        recoverable?: boolean;
+    } | null>(null);
+
+    React.useEffect(() => {
+        EventsOn('engine:status', (status: any) => {
+            setEngineStatus(status && status.phase !== 'idle' ? status : null);
+            // A stopped run abandons its pending question
+            if (!status || status.phase === 'idle') setQuestionRequest(null);
+        });
+    }, []);
+
+    // Tool call whose arguments are still streaming; lets the user stop a wrong edit early
+    const [toolPreview, setToolPreview] = React.useState<{
+        id: string;
+        name: string;
+        path?: string;
+        action?: string;
+        lines?: string[];
+        bytes: number;
+    } | null>(null);
+
+    React.useEffect(() => {
+        EventsOn('tool:preview', (p: any) => {
+            if (!p || p.done) {
+                setToolPreview(null);
+                return;
+            }
+            setToolPreview(p);
+        });
+    }, []);
+
+    React.useEffect(() => {
+        if (!busy) setToolPreview(null);
+    }, [busy]);
+
+    // Listen for ask_user questions from backend
+    React.useEffect(() => {
+        const handler = (data: any) => {
+            if (data && data.type === 'question' && data.id && data.question) {
+                setQuestionRequest({
+                    id: data.id,
+                    question: data.question,
+                    options: Array.isArray(data.options) ? data.options : [],
+                    freeText: !!data.free_text,
+                    context: data.context || undefined,
+                });
+            }
+        };
+        EventsOn('user:question', handler);
+        // Questions of conversation tabs arrive on the local event bus
+        const local = (e: Event) => handler((e as CustomEvent).detail);
+        window.addEventListener('loom:user-question', local);
+        return () => window.removeEventListener('loom:user-question', local);
+    }, []);
+
+    const handleQuestionAnswer = React.useCallback(async (selectedIndex: number, text: string) => {
+        if (!questionRequest) return;
+        const id = questionRequest.id;
+        setQuestionRequest(null);
+        try {
+            await (Bridge as any).AnswerQuestion(id, selectedIndex, text);
+        } catch (error) {
+            console.error('Failed to answer question:', error);
+        }
+    }, [questionRequest]);
+
     // Load MCP tools from backend and keep them updated when the backend refreshes
     React.useEffect(() => {
         const load = async () => {
@@ -176,9 +256,13 @@ function ChatPanelComponent(props: Props) {
         };
 
         EventsOn('user:choice', handler);
+        // Choices of conversation tabs arrive on the local event bus
+        const local = (e: Event) => handler((e as CustomEvent).detail);
+        window.addEventListener('loom:user-choice', local);
 
         return () => {
             // EventsOff doesn't take specific handlers in Wails v2
+            window.removeEventListener('loom:user-choice', local);
         };
     }, []);
 
@@ -236,7 +320,7 @@ function ChatPanelComponent(props: Props) {
     }, [choiceRequest]);
 
     return (
-        <Box sx={{ minWidth: 450, width: '100%', display: 'flex', flexDirection: 'column', height: '100vh' }}>
+        <Box sx={{ minWidth: 450, width: '100%', display: 'flex', flexDirection: 'column', flex: 1, minHeight: 0 }}>
             <Box sx={{ flex: 1, overflowY: 'auto', p: 2, minHeight: 0, boxSizing: 'border-box' }}>
                 <Box
                     sx={{
@@ -262,6 +346,39 @@ function ChatPanelComponent(props: Props) {
                     >
                         <AddRounded />
                     </IconButton>
+                    <Box sx={{ flex: 1 }} />
+                    <IconButton
+                        size="small"
+                        title="Describe changes"
+                        onClick={() => setDescribeOpen(true)}
+                        sx={{
+                            color: 'text.secondary',
+                            '&:hover': {
+                                backgroundColor: 'primary.main',
+                                '& .MuiSvgIcon-root': {
+                                    color: 'primary.contrastText'
+                                }
+                            }
+                        }}
+                    >
+                        <DescriptionRounded />
+                    </IconButton>
+                    <IconButton
+                        size="small"
+                        title="Tools for this conversation"
+                        onClick={() => setConversationToolsOpen(true)}
+                        sx={{
+                            color: 'text.secondary',
+                            '&:hover': {
+                                backgroundColor: 'primary.main',
+                                '& .MuiSvgIcon-root': {
+                                    color: 'primary.contrastText'
+                                }
+                            }
+                        }}
+                    >
+                        <BuildRounded />
+                    </IconButton>
                     <IconButton
                         size="small"
                         onClick={(e) => { setToolsAnchor(e.currentTarget); setToolsOpen(true); }}
@@ -278,6 +395,8 @@ function ChatPanelComponent(props: Props) {
                         <SettingsSuggestRounded />
                     </IconButton>
                 </Box>
+                <DescribeChangesDialog open={describeOpen} onClose={() => setDescribeOpen(false)} />
+                <ConversationToolsDialog open={conversationToolsOpen} onClose={() => setConversationToolsOpen(false)} />
                 <MessageList
                     messages={messages}
                     busy={busy}
@@ -367,6 +486,9 @@ function ChatPanelComponent(props: Props) {
                         </CardContent>
                     </Card>
                 )}
+                {questionRequest && (
+                    <QuestionCard request={questionRequest} onAnswer={handleQuestionAnswer} />
+                )}
                 {messages.length === 0 &&
                     <Box>
                         <Typography
@@ -387,6 +509,49 @@ function ChatPanelComponent(props: Props) {
                 }
             </Box>
             <Divider />
+            {engineStatus?.phase === 'stalled' && (
+                <Alert
+                    severity="warning"
+                    sx={{ mx: 3, mt: 2 }}
+                    action={
+                        <Button color="inherit" size="small" onClick={() => (Bridge as any).RetryLastRequest()}>
+                            Retry
+                        </Button>
+                    }
+                >
+                    {engineStatus.message || 'Provider not responding'}
+                </Alert>
+            )}
+            {busy && engineStatus && engineStatus.phase !== 'stalled' && engineStatus.message && (
+                <Typography variant="caption" color="text.secondary" sx={{ px: 3, pt: 1 }}>
+                    {engineStatus.message}
+                </Typography>
+            )}
+            {busy && toolPreview && (
+                <Card variant="outlined" sx={{ mx: 3, mt: 2 }}>
+                    <CardContent sx={{ py: 1.5, '&:last-child': { pb: 1.5 } }}>
+                        <Box sx={{ display: 'flex', alignItems: 'center', gap: 1 }}>
+                            <Typography variant="caption" color="text.secondary" sx={{ flex: 1, minWidth: 0 }} noWrap>
+                                {toolPreview.name}
+                                {toolPreview.action ? ` (${toolPreview.action})` : ''}
+                                {toolPreview.path ? ` · ${toolPreview.path}` : ''}
+                                {` · ${toolPreview.bytes} bytes so far`}
+                            </Typography>
+                            <Button size="small" color="inherit" onClick={() => (Bridge as any).StopLLM()}>
+                                Stop
+                            </Button>
+                        </Box>
+                        {toolPreview.lines && toolPreview.lines.length > 0 && (
+                            <Box
+                                component="pre"
+                                sx={{ m: 0, mt: 1, fontFamily: 'monospace', fontSize: 12, color: 'text.secondary', overflow: 'hidden', whiteSpace: 'pre' }}
+                            >
+                                {toolPreview.lines.join('\n')}
+                            </Box>
+                        )}
+                    </CardContent>
+                </Card>
+            )}
             <Box sx={{ px: 3, py: 2, boxSizing: 'border-box', }} >
                 <Composer
                     input={localInput}
@@ -400,6 +565,13 @@ function ChatPanelComponent(props: Props) {
                             // Fetch first 50 lines for each attachment
                             const previews = await Promise.all(
                                 attachments.map(async (p) => {
+                                    if (p.startsWith('@attachments/')) {
+                                        // External files: the backend sizes the excerpt; read_file can page through the rest
+                                        const excerpt = await (Bridge as any).GetAttachmentExcerpt(p).catch(() => '');
+                                        return excerpt
+                                            ? `${excerpt}\n  The user attached this file from outside the workspace. It is read-only.`
+                                            : `- ${p.slice('@attachments/'.length)} — ${p}\n  (unreadable)`;
+                                    }
                                     try {
                                         const res: any = await Bridge.ReadWorkspaceFile(p);
                                         const content = String(res?.content || '');
@@ -491,6 +663,28 @@ function ChatPanelComponent(props: Props) {
                         </ListItemButton>
                     ))}
                 </List>
+                <Divider sx={{ my: 0.5 }} />
+                <ListItemButton
+                    dense
+                    onClick={async () => {
+                        setAttachOpen(false);
+                        setAttachQuery('');
+                        try {
+                            const res: any = await (Bridge as any).ChooseExternalAttachments();
+                            const refs: string[] = (res?.attachments || []).map((a: any) => String(a.ref));
+                            if (refs.length > 0) {
+                                setAttachments((prev) => [...prev, ...refs.filter((r) => !prev.includes(r))]);
+                            }
+                            if (res?.error) {
+                                console.error('Failed to attach files:', res.error);
+                            }
+                        } catch (error) {
+                            console.error('Failed to attach files:', error);
+                        }
+                    }}
+                >
+                    <ListItemText primaryTypographyProps={{ fontSize: 13 }} primary="Attach file from outside the workspace…" />
+                </ListItemButton>
             </Popover>
             <Popover
                 open={toolsOpen}
@@ -0,0 +1,60 @@
+import React from 'react';
+import { Box, Tabs, Tab, IconButton, Tooltip, CircularProgress } from '@mui/material';
+import { AddRounded, CloseRounded } from '@mui/icons-material';
+import { ConversationTabItem } from '../../../types/ui';
+
+type Props = {
+    tabs: ConversationTabItem[];
+    // '' selects the main conversation
+    active: string;
+    mainBusy: boolean;
+    onSelect: (id: string) => void;
+    onOpen: () => void;
+    onClose: (id: string) => void;
+};
+
+// Tabs of the conversations running next to the main one in the workspace
+export default function ConversationTabs({ tabs, active, mainBusy, onSelect, onOpen, onClose }: Props) {
+    const busyIcon = <CircularProgress size={10} sx={{ ml: 0.75 }} />;
+    return (
+        <Box sx={{ display: 'flex', alignItems: 'center', borderBottom: 1, borderColor: 'divider', minHeight: 32 }}>
+            <Tabs
+                value={active}
+                onChange={(_, v) => onSelect(String(v))}
+                variant="scrollable"
+                scrollButtons="auto"
+                sx={{ minHeight: 32, flex: 1, '& .MuiTab-root': { minHeight: 32, py: 0.5, textTransform: 'none' } }}
+            >
+                <Tab value="" label={<Box sx={{ display: 'flex', alignItems: 'center' }}>Main{mainBusy && busyIcon}</Box>} />
+                {tabs.map(t => (
+                    <Tab
+                        key={t.id}
+                        value={t.id}
+                        label={
+                            <Box sx={{ display: 'flex', alignItems: 'center', maxWidth: 180 }}>
+                                <Box component="span" sx={{ overflow: 'hidden', textOverflow: 'ellipsis', whiteSpace: 'nowrap' }}>
+                                    {t.title || 'New conversation'}
+                                </Box>
+                                {t.busy && busyIcon}
+                                <Box
+                                    component="span"
+                                    role="button"
+                                    aria-label="Close tab"
+                                    onClick={(e) => { e.stopPropagation(); onClose(t.id); }}
+                                    sx={{ display: 'inline-flex', ml: 0.5, opacity: 0.6, '&:hover': { opacity: 1 } }}
+                                >
+                                    <CloseRounded sx={{ fontSize: 14 }} />
+                                </Box>
+                            </Box>
+                        }
+                    />
+                ))}
+            </Tabs>
+            <Tooltip title="New conversation tab">
+                <IconButton size="small" onClick={onOpen} sx={{ mx: 0.5 }}>
+                    <AddRounded fontSize="small" />
+                </IconButton>
+            </Tooltip>
+        </Box>
+    );
+}
@@ -0,0 +1,63 @@
+import { Dialog, DialogTitle, DialogContent, DialogActions, Button, List, ListItem, ListItemText, Switch, TextField, Alert } from '@mui/material';
+import { useEffect, useState } from 'react';
+import * as AppBridge from '../../../../wailsjs/go/bridge/App';
+
+type Props = {
+    open: boolean;
+    onClose: () => void;
+};
+
+type ToolInfo = { name: string; description: string; enabled: boolean };
+
+export default function ConversationToolsDialog(props: Props) {
+    const { open, onClose } = props;
+    const [tools, setTools] = useState<ToolInfo[]>([]);
+    const [filter, setFilter] = useState<string>('');
+    const [error, setError] = useState<string>('');
+
+    const refresh = () => {
+        (AppBridge as any).GetTools?.().then((all: any) => {
+            const list: ToolInfo[] = (Array.isArray(all) ? all : []).map((t: any) => ({
+                name: String(t?.name || ''),
+                description: String(t?.description || ''),
+                enabled: t?.enabled !== false,
+            }));
+            list.sort((a, b) => a.name.localeCompare(b.name));
+            setTools(list);
+        }).catch(() => setTools([]));
+    };
+
+    useEffect(() => {
+        if (open) { setError(''); refresh(); }
+    }, [open]);
+
+    const toggle = (name: string, enabled: boolean) => {
+        (AppBridge as any).SetToolEnabled?.(name, enabled).then((err: string) => {
+            setError(err || '');
+            refresh();
+        });
+    };
+
+    const q = filter.trim().toLowerCase();
+    const shown = tools.filter((t) => !q || t.name.toLowerCase().includes(q) || t.description.toLowerCase().includes(q));
+
+    return (
+        <Dialog open={open} onClose={onClose} maxWidth="sm" fullWidth>
+            <DialogTitle>Tools for this conversation</DialogTitle>
+            <DialogContent dividers>
+                <TextField size="small" fullWidth placeholder="Filter tools…" value={filter} onChange={(e) => setFilter(e.target.value)} sx={{ mb: 1 }} />
+                {error && <Alert severity="error" sx={{ mb: 1 }}>{error}</Alert>}
+                <List dense>
+                    {shown.map((t) => (
+                        <ListItem key={t.name} secondaryAction={<Switch edge="end" checked={t.enabled} onChange={(e) => toggle(t.name, e.target.checked)} />}>
+                            <ListItemText primary={t.name} secondary={t.description} secondaryTypographyProps={{ noWrap: true }} />
+                        </ListItem>
+                    ))}
+                </List>
+            </DialogContent>
+            <DialogActions>
+                <Button onClick={onClose}>Close</Button>
+            </DialogActions>
+        </Dialog>
+    );
+}
@@ -0,0 +1,87 @@
+import React from 'react';
+import { Alert, Button, Dialog, DialogActions, DialogContent, DialogTitle, TextField } from '@mui/material';
+import * as Bridge from '../../../../wailsjs/go/bridge/App';
+
+type Props = {
+    open: boolean;
+    onClose: () => void;
+};
+
+// DescribeChangesDialog shows the PR description generated from the current session,
+// with refine (structured model output), copy, export and publish (GitHub CLI) actions.
+export default function DescribeChangesDialog({ open, onClose }: Props) {
+    const [markdown, setMarkdown] = React.useState('');
+    const [error, setError] = React.useState('');
+    const [notice, setNotice] = React.useState('');
+    const [busy, setBusy] = React.useState(false);
+
+    React.useEffect(() => {
+        if (!open) return;
+        setError('');
+        setNotice('');
+        (Bridge as any).DescribeChanges().then((res: any) => {
+            if (res?.error) setError(res.error);
+            setMarkdown(res?.markdown || '');
+        });
+    }, [open]);
+
+    const run = async (fn: () => Promise<any>, done: (res: any) => string) => {
+        setBusy(true);
+        setError('');
+        try {
+            const res = await fn();
+            if (res?.error) setError(res.error);
+            else setNotice(done(res));
+        } finally {
+            setBusy(false);
+        }
+    };
+
+    return (
+        <Dialog open={open} onClose={onClose} maxWidth="md" fullWidth>
+            <DialogTitle>Describe changes</DialogTitle>
+            <DialogContent>
+                {error && <Alert severity="error" sx={{ mb: 2 }}>{error}</Alert>}
+                {notice && <Alert severity="success" sx={{ mb: 2 }}>{notice}</Alert>}
+                <TextField
+                    value={markdown}
+                    InputProps={{ readOnly: true, sx: { fontFamily: 'monospace', fontSize: 13 } }}
+                    multiline
+                    minRows={12}
+                    maxRows={24}
+                    fullWidth
+                />
+            </DialogContent>
+            <DialogActions>
+                <Button
+                    disabled={!markdown}
+                    onClick={() => navigator.clipboard.writeText(markdown).then(() => setNotice('Copied to clipboard'))}
+                >
+                    Copy
+                </Button>
+                <Button
+                    disabled={!markdown || busy}
+                    onClick={() => run(() => (Bridge as any).RefineChangeDescription(), (res) => {
+                        setMarkdown(res?.markdown || markdown);
+                        return 'Title, summary and review notes written by the model';
+                    })}
+                >
+                    Refine with model
+                </Button>
+                <Button
+                    disabled={!markdown || busy}
+                    onClick={() => run(() => (Bridge as any).ExportChangeDescription(), (res) => res?.path ? `Saved to ${res.path}` : '')}
+                >
+                    Export
+                </Button>
+                <Button
+                    disabled={!markdown || busy}
+                    onClick={() => run(() => (Bridge as any).PublishChangeDescription(true), (res) => `${res.updated ? 'Updated' : 'Created'} ${res.url}`)}
+                >
+                    Publish to PR
+                </Button>
+                <Button onClick={onClose}>Close</Button>
+            </DialogActions>
+        </Dialog>
+    );
+}
@@ -0,0 +1,126 @@
+import React from 'react';
+import { Box, Button, Card, CardContent, TextField, Typography } from '@mui/material';
+
+export type QuestionRequest = {
+    id: string;
+    question: string;
+    options: string[];
+    freeText: boolean;
+    context?: string;
+};
+
+type Props = {
+    request: QuestionRequest;
+    onAnswer: (selectedIndex: number, text: string) => void;
+};
+
+// QuestionCard renders an ask_user question: options as buttons (1-9 select them)
+// and, when accepted, a free-text answer. Skip answers with nothing.
+export default function QuestionCard({ request, onAnswer }: Props) {
+    const [text, setText] = React.useState('');
+    const inputRef = React.useRef<HTMLInputElement | null>(null);
+
+    React.useEffect(() => {
+        setText('');
+        if (request.freeText) {
+            inputRef.current?.focus();
+        }
+    }, [request.id, request.freeText]);
+
+    React.useEffect(() => {
+        const onKey = (e: KeyboardEvent) => {
+            if (document.activeElement === inputRef.current) return;
+            const n = Number(e.key);
+            if (Number.isInteger(n) && n >= 1 && n <= request.options.length) {
+                e.preventDefault();
+                onAnswer(n - 1, '');
+            }
+        };
+        window.addEventListener('keydown', onKey);
+        return () => window.removeEventListener('keydown', onKey);
+    }, [request.options.length, onAnswer]);
+
+    const submitText = () => {
+        if (text.trim()) onAnswer(-1, text.trim());
+    };
+
+    return (
+        <Card
+            sx={{
+                mt: 2,
+                borderRadius: 2,
+                backgroundColor: 'background.paper',
+                border: '1px solid',
+                borderColor: 'primary.main',
+                boxShadow: '0 4px 8px rgba(0, 0, 0, 0.3)',
+            }}
+        >
+            <CardContent sx={{ pb: 2 }}>
+                <Typography variant="h6" sx={{ mb: request.context ? 0.5 : 2, fontWeight: 600, color: 'grey.100' }}>
+                    {request.question}
+                </Typography>
+                {request.context && (
+                    <Typography variant="body2" color="text.secondary" sx={{ mb: 2 }}>
+                        {request.context}
+                    </Typography>
+                )}
+                {request.options.length > 0 && (
+                    <Box sx={{ display: 'flex', flexDirection: 'column', gap: 1 }}>
+                        {request.options.map((option, index) => (
+                            <Button
+                                key={index}
+                                variant="outlined"
+                                onClick={() => onAnswer(index, '')}
+                                sx={{
+                                    justifyContent: 'flex-start',
+                                    textAlign: 'left',
+                                    py: 1.5,
+                                    px: 2,
+                                    borderRadius: 2,
+                                    textTransform: 'none',
+                                    backgroundColor: 'grey.800',
+                                    color: 'grey.100',
+                                    borderColor: 'grey.600',
+                                    '&:hover': { backgroundColor: 'grey.700', borderColor: 'grey.500' },
+                                }}
+                            >
+                                <Typography variant="body1" sx={{ color: 'inherit' }}>
+                                    {index + 1}. {option}
+                                </Typography>
+                            </Button>
+                        ))}
+                    </Box>
+                )}
+                {request.freeText && (
+                    <TextField
+                        inputRef={inputRef}
+                        value={text}
+                        onChange={(e) => setText(e.target.value)}
+                        onKeyDown={(e) => {
+                            if (e.key === 'Enter' && !e.shiftKey) {
+                                e.preventDefault();
+                                submitText();
+                            }
+                        }}
+                        placeholder={request.options.length > 0 ? 'Or type another answer…' : 'Type your answer…'}
+                        size="small"
+                        fullWidth
+                        multiline
+                        maxRows={4}
+                        sx={{ mt: request.options.length > 0 ? 2 : 0 }}
+                    />
+                )}
+                <Box sx={{ display: 'flex', justifyContent: 'flex-end', gap: 1, mt: 2 }}>
+                    <Button size="small" color="inherit" onClick={() => onAnswer(-1, '')}>
+                        Skip
+                    </Button>
+                    {request.freeText && (
+                        <Button size="small" variant="contained" disabled={!text.trim()} onClick={submitText}>
+                            Answer
+                        </Button>
+                    )}
+                </Box>
+            </CardContent>
+        </Card>
+    );
+}
@@ -1,5 +1,7 @@
 // Shared model definitions used across the application
 
+import * as Bridge from '../wailsjs/go/bridge/App';
+
 export interface ModelOption {
     id: string;
     name: string;
@@ -37,6 +39,12 @@ export const ALL_AVAILABLE_MODELS: ModelOption[] = [
     { id: 'openai:gpt-4.1-mini', name: 'GPT-4.1-mini', provider: 'openai', group: 'Production' },
     { id: 'openai:gpt-4.1-nano', name: 'GPT-4.1-nano', provider: 'openai', group: 'Production' },
 
+    // Google Gemini models
+    { id: 'gemini:gemini-2.5-pro', name: 'Gemini 2.5 Pro', provider: 'gemini', group: 'Gemini' },
+    { id: 'gemini:gemini-2.5-flash', name: 'Gemini 2.5 Flash', provider: 'gemini', group: 'Gemini' },
+    { id: 'gemini:gemini-2.5-flash-lite', name: 'Gemini 2.5 Flash-Lite', provider: 'gemini', group: 'Gemini' },
+    { id: 'gemini:gemini-2.0-flash', name: 'Gemini 2.0 Flash', provider: 'gemini', group: 'Gemini' },
+
     // Local models (Ollama)
     { id: 'ollama:llama3.1:8b', name: 'Llama 3.1 (8B)', provider: 'ollama', group: 'Local' },
     { id: 'ollama:llama3:8b', name: 'Llama 3 (8B)', provider: 'ollama', group: 'Local' },
@@ -102,10 +110,26 @@ export async function fetchOpenRouterModels(): Promise<ModelOption[]> {
     }
 }
 
-// Get all models including dynamic OpenRouter models
+// Azure OpenAI deployments configured in settings, offered as "azure:<deployment>"
+async function fetchAzureModels(): Promise<ModelOption[]> {
+    try {
+        const azure = await (Bridge as any).GetAzureOpenAISettings?.();
+        if (!azure?.endpoint) return [];
+        return (azure.deployments || []).map((d: string) => ({
+            id: `azure:${d}`,
+            name: d,
+            provider: 'azure',
+            group: 'Azure OpenAI',
+        }));
+    } catch (error) {
+        return [];
+    }
+}
+
+// Get all models including dynamic OpenRouter models and Azure deployments
 export async function getAllModels(): Promise<ModelOption[]> {
-    // Start with static models
-    let allModels = [...ALL_AVAILABLE_MODELS];
+    // Start with static models and the configured Azure deployments
+    let allModels = [...ALL_AVAILABLE_MODELS, ...(await fetchAzureModels())];
 
     // Try to fetch dynamic OpenRouter models and merge them
     try {
@@ -43,10 +43,23 @@ export interface EditorTabItem {
   scrollTop?: number;
 }
 
+export interface ConversationTags {
+  task_type?: string;
+  topics?: string[];
+  packages?: string[];
+}
+
 export interface ConversationListItem {
   id: string;
   title: string;
   updated_at?: string;
+  tags?: ConversationTags;
 }
 
 
+
+export interface ConversationTabItem {
+  id: string;
+  title: string;
+  busy: boolean;
+}
@@ -0,0 +1,90 @@
+import * as Bridge from '../../wailsjs/go/bridge/App';
+
+// Models of other workspace files are shown in peek views under this scheme
+const PEEK_SCHEME = 'loom-peek';
+
+let registered = false;
+
+// registerSymbolNavigation backs Monaco's go to / peek definition, find references and
+// outline with the workspace symbol index. currentPath returns the workspace-relative
+// path of the file in the editor; other models are the peek views it created.
+export function registerSymbolNavigation(monaco: any, currentPath: () => string) {
+    if (registered) return;
+    registered = true;
+
+    const pathOf = (model: any): string => {
+        if (model.uri.scheme === PEEK_SCHEME) return model.uri.path.replace(/^\//, '');
+        return currentPath();
+    };
+    // Locations in the open file use its own model; other files get a read-only peek model
+    const uriFor = async (model: any, file: string) => {
+        if (file === pathOf(model)) return model.uri;
+        const uri = monaco.Uri.from({ scheme: PEEK_SCHEME, path: '/' + file });
+        if (!monaco.editor.getModel(uri)) {
+            const res: any = await (Bridge as any).ReadWorkspaceFile(file);
+            if (!monaco.editor.getModel(uri)) monaco.editor.createModel(res?.content ?? '', undefined, uri);
+        }
+        return uri;
+    };
+    const range = (start: number, end: number) => new monaco.Range(start, 1, Math.max(start, end), 1);
+
+    monaco.languages.registerDefinitionProvider('*', {
+        provideDefinition: async (model: any, position: any) => {
+            const path = pathOf(model);
+            if (!path) return [];
+            const res: any = await (Bridge as any).PeekDefinition(path, position.lineNumber, position.column);
+            if (!res || res.error) return [];
+            return Promise.all((res.definitions || []).map(async (d: any) => ({
+                uri: await uriFor(model, d.symbol.file),
+                range: range(d.symbol.span[0], d.symbol.span[2]),
+            })));
+        },
+    });
+
+    monaco.languages.registerReferenceProvider('*', {
+        provideReferences: async (model: any, position: any) => {
+            const path = pathOf(model);
+            if (!path) return [];
+            const res: any = await (Bridge as any).FindReferences(path, position.lineNumber, position.column);
+            if (!res || res.error) return [];
+            return Promise.all((res.references || []).map(async (r: any) => ({
+                uri: await uriFor(model, r.file),
+                range: range(r.line_start, r.line_end),
+            })));
+        },
+    });
+
+    monaco.languages.registerDocumentSymbolProvider('*', {
+        provideDocumentSymbols: async (model: any) => {
+            const path = pathOf(model);
+            if (!path) return [];
+            const res: any = await (Bridge as any).GetDocumentSymbols(path);
+            if (!res || res.error) return [];
+            const kinds = monaco.languages.SymbolKind;
+            const kindOf = (k: string) => ({
+                func: kinds.Function, prototype: kinds.Function, class: kinds.Class, record: kinds.Class,
+                struct: kinds.Struct, union: kinds.Struct, interface: kinds.Interface, annotation: kinds.Interface,
+                enum: kinds.Enum, var: kinds.Variable, package: kinds.Package, namespace: kinds.Namespace,
+            } as Record<string, number>)[k] ?? kinds.Variable;
+            const convert = (n: any): any => ({
+                name: n.name,
+                detail: '',
+                kind: kindOf(n.kind),
+                tags: [],
+                range: range(n.span[0], n.span[1]),
+                selectionRange: range(n.span[0], n.span[0]),
+                children: (n.children || []).map(convert),
+            });
+            return (res.symbols || []).map(convert);
+        },
+    });
+
+    // "Go to definition" in another file opens it in a Loom tab
+    monaco.editor.registerEditorOpener?.({
+        openCodeEditor: (_source: any, resource: any) => {
+            if (resource.scheme !== PEEK_SCHEME) return false;
+            (Bridge as any).OpenFileInUI(resource.path.replace(/^\//, ''));
+            return true;
+        },
+    });
+}
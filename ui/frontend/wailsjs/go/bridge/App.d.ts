@@ -34,7 +34,7 @@ export function GetConversations():Promise<Record<string, any>>;
 
 export function GetGlobalUsage():Promise<Record<string, any>>;
 
-export function GetMemories():Promise<Array<Record<string, string>>>;
+export function GetMemories():Promise<Array<Record<string, any>>>;
 
 export function GetPersonalities():Promise<Record<string, any>>;
 
@@ -0,0 +1,24 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/loom/loom/internal/hygiene"
+)
+
+// runHygiene prints the repository hygiene report and returns the process exit code:
+// 1 when a high severity issue was found or the audit failed, 0 otherwise.
+func runHygiene(workspace string) int {
+	rep, err := hygiene.Audit(context.Background(), workspace)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	fmt.Print(hygiene.Format(rep))
+	if rep.HasHigh() {
+		return 1
+	}
+	return 0
+}
@@ -3,16 +3,22 @@ package main
 import (
 	"context"
 	"embed"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
 	"log"
 	"os"
 	"path/filepath"
 	"strings"
 
 	"github.com/loom/loom/internal/adapter"
+	"github.com/loom/loom/internal/adapter/openai"
 	"github.com/loom/loom/internal/bridge"
 	"github.com/loom/loom/internal/config"
 	"github.com/loom/loom/internal/engine"
 	"github.com/loom/loom/internal/memory"
+	"github.com/loom/loom/internal/proxy"
 	"github.com/loom/loom/internal/tool"
 	"github.com/wailsapp/wails/v2"
 	"github.com/wailsapp/wails/v2/pkg/menu"
@@ -32,6 +38,34 @@ var assets embed.FS
 func main() {
 	// Set up logging to show all levels
 	log.SetFlags(log.LstdFlags | log.Lshortfile)
+	// `loom run [flags] [prompt]` executes one prompt without the window
+	headless := len(os.Args) > 1 && os.Args[1] == "run"
+	// Keep a log file next to the settings for `loom report`; headless runs keep their
+	// output to the conversation
+	logOutput := io.Writer(os.Stderr)
+	if headless {
+		logOutput = io.Discard
+	}
+	log.SetOutput(bridge.CaptureLogs(logOutput))
+	var runOpts runOptions
+	if headless {
+		opts, err := parseRunArgs(os.Args[2:], os.Stdin)
+		if errors.Is(err, flag.ErrHelp) {
+			os.Exit(0)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "loom run: %v\n", err)
+			os.Exit(2)
+		}
+		runOpts = opts
+	}
+
+	// `loom doctor [workspace]` prints the health report instead of opening the window
+	doctor := len(os.Args) > 1 && os.Args[1] == "doctor"
+	// `loom hygiene [workspace]` prints the repository hygiene report
+	hygieneCheck := len(os.Args) > 1 && os.Args[1] == "hygiene"
+	// `loom report [workspace]` writes a redacted diagnostic bundle for bug reports
+	report := len(os.Args) > 1 && os.Args[1] == "report"
 
 	// Get current working directory as default workspace path
 	workspacePath, err := os.Getwd()
@@ -47,18 +81,40 @@ func main() {
 	if err != nil {
 		log.Printf("Warning: Failed to load settings: %v", err)
 	}
+	// Route every outgoing request through the configured proxy before anything connects
+	if err := proxy.Apply(settings.Proxy); err != nil {
+		log.Printf("Warning: proxy settings not applied: %v", err)
+	}
 	// Prefer last workspace from settings if present (normalize to abs path and expand ~)
-	if settings.LastWorkspace != "" {
+	if (doctor || hygieneCheck || report) && len(os.Args) > 2 {
+		workspacePath = normalizeWorkspacePath(os.Args[2])
+	} else if headless {
+		// Scripts run in the directory they mean, not the last workspace opened in the window
+		if runOpts.workspace != "" {
+			workspacePath = runOpts.workspace
+		}
+		workspacePath = normalizeWorkspacePath(workspacePath)
+	} else if settings.LastWorkspace != "" {
 		workspacePath = normalizeWorkspacePath(settings.LastWorkspace)
 	} else {
 		workspacePath = normalizeWorkspacePath(workspacePath)
 	}
+	if hygieneCheck {
+		os.Exit(runHygiene(workspacePath))
+	}
 	if settings.OpenAIAPIKey != "" && configAdapter.Provider == adapter.ProviderOpenAI {
 		configAdapter.APIKey = settings.OpenAIAPIKey
 	}
 	if settings.AnthropicAPIKey != "" && configAdapter.Provider == adapter.ProviderAnthropic {
 		configAdapter.APIKey = settings.AnthropicAPIKey
 	}
+	if settings.GeminiAPIKey != "" && configAdapter.Provider == adapter.ProviderGemini {
+		configAdapter.APIKey = settings.GeminiAPIKey
+	}
+	if settings.AzureOpenAI.APIKey != "" && configAdapter.Provider == adapter.ProviderAzureOpenAI {
+		configAdapter.APIKey = settings.AzureOpenAI.APIKey
+	}
+	configAdapter.Azure = openai.AzureConfig{Endpoint: settings.AzureOpenAI.Endpoint, APIVersion: settings.AzureOpenAI.APIVersion}
 	if settings.OllamaEndpoint != "" && configAdapter.Provider == adapter.ProviderOllama {
 		configAdapter.Endpoint = settings.OllamaEndpoint
 	}
@@ -70,6 +126,15 @@ func main() {
 			configAdapter.Model = modelID
 		}
 	}
+	if runOpts.model != "" {
+		prov, modelID, err := adapter.GetProviderFromModel(runOpts.model)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "loom run: %v\n", err)
+			os.Exit(2)
+		}
+		configAdapter.Provider = prov
+		configAdapter.Model = modelID
+	}
 
 	llm, err := adapter.New(configAdapter)
 	if err != nil {
@@ -129,10 +194,34 @@ func main() {
 	// Connect the engine to the bridge
 	eng.SetBridge(app)
 
+	// LOOM_DEMO=1 replays the built-in trace; any other value is a trace file path.
+	// Demo mode is set before the registry rebuild below so write tools start disabled.
+	if demo := strings.TrimSpace(os.Getenv("LOOM_DEMO")); demo != "" || configAdapter.Provider == adapter.ProviderDemo {
+		tracePath := demo
+		if demo == "1" || strings.EqualFold(demo, "true") {
+			tracePath = ""
+		} else if demo == "" && configAdapter.Model != adapter.DemoBuiltinModel {
+			tracePath = configAdapter.Model
+		}
+		if msg := app.SetDemoMode(true, tracePath); msg != "" {
+			log.Printf("Warning: Failed to start demo mode: %s", msg)
+		}
+	}
+
 	// Centralize MCP setup via the bridge so we don't double-start servers later
 	// This will rebuild the registry (core + MCP) and wire it into the engine.
 	app.SetWorkspace(workspacePath)
 
+	if doctor {
+		os.Exit(runDoctor(app))
+	}
+	if report {
+		os.Exit(runReport(app))
+	}
+	if headless {
+		os.Exit(runHeadless(app, eng, runOpts))
+	}
+
 	// Run the application
 	// Build the application menu
 	m := menu.NewMenu()
@@ -218,6 +307,11 @@ func main() {
 			registry.WithUI(app)
 			appCtx = ctx
 		},
+		OnShutdown: func(ctx context.Context) {
+			// Let other instances open the workspace without waiting for the lock to go stale
+			app.ReleaseWorkspace()
+			app.CloseShellSessions()
+		},
 		Bind: []interface{}{
 			app,
 		},
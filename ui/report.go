@@ -0,0 +1,43 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/loom/loom/internal/bridge"
+)
+
+// runReport writes a redacted bug report bundle to the current directory and returns
+// the process exit code.
+func runReport(app *bridge.App) int {
+	// Keep startup logging out of the output
+	log.SetOutput(io.Discard)
+	app.WaitForMCP(doctorMCPWait)
+	r := app.BuildBugReport()
+	app.StopMCP()
+
+	name := fmt.Sprintf("loom-report-%s.zip", r.GeneratedAt.Format("20060102-150405"))
+	f, err := os.OpenFile(name, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	if err := bridge.WriteBugReport(f, r); err != nil {
+		f.Close()
+		os.Remove(name)
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	if err := f.Close(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	abs, _ := filepath.Abs(name)
+	fmt.Printf("Wrote %s (%d log lines, generated in %s).\n", abs, len(r.Logs), time.Since(r.GeneratedAt).Round(time.Millisecond))
+	fmt.Println("API keys are removed and home directory paths are shortened to ~. Review the files before attaching the archive to an issue.")
+	return 0
+}
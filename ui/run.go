@@ -0,0 +1,260 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/loom/loom/internal/bridge"
+	"github.com/loom/loom/internal/engine"
+	"github.com/loom/loom/internal/tool"
+)
+
+// runOptions are the flags of `loom run`.
+type runOptions struct {
+	workspace string
+	model     string
+	prompt    string
+	// approve answers every approval prompt with yes instead of no
+	approve bool
+	timeout time.Duration
+	verbose bool
+}
+
+// maxToolOutputLines bounds the tool output `loom run` prints per call unless -verbose is set.
+const maxToolOutputLines = 20
+
+// parseRunArgs parses `loom run [flags] [prompt...]`. The prompt comes from the
+// remaining arguments or from -f, where "-" reads standard input.
+func parseRunArgs(args []string, stdin io.Reader) (runOptions, error) {
+	var opts runOptions
+	var promptFile string
+	fs := flag.NewFlagSet("loom run", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	fs.StringVar(&opts.workspace, "w", "", "workspace directory (default: current directory)")
+	fs.StringVar(&opts.model, "model", "", "model to use as provider:model_id (default: the last selected model)")
+	fs.StringVar(&promptFile, "f", "", `read the prompt from a file, or "-" for standard input`)
+	fs.BoolVar(&opts.approve, "yes", false, "approve edits, commands and other actions that ask for approval")
+	fs.DurationVar(&opts.timeout, "timeout", 0, "stop the run after this long, e.g. 30m (default: no limit)")
+	fs.BoolVar(&opts.verbose, "verbose", false, "print complete tool output")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: loom run [flags] [prompt]")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return opts, err
+	}
+	prompt := strings.Join(fs.Args(), " ")
+	if promptFile != "" {
+		if prompt != "" {
+			return opts, errors.New("pass the prompt as arguments or with -f, not both")
+		}
+		var data []byte
+		var err error
+		if promptFile == "-" {
+			data, err = io.ReadAll(stdin)
+		} else {
+			data, err = os.ReadFile(promptFile)
+		}
+		if err != nil {
+			return opts, fmt.Errorf("read prompt: %w", err)
+		}
+		prompt = string(data)
+	}
+	opts.prompt = strings.TrimSpace(prompt)
+	if opts.prompt == "" {
+		return opts, errors.New("no prompt given")
+	}
+	return opts, nil
+}
+
+// runHeadless executes one prompt without the window, streaming the conversation to
+// stdout, and returns the process exit code: 0 only when the run summary says the run
+// completed and no check failed, 1 otherwise.
+func runHeadless(app *bridge.App, eng *engine.Engine, opts runOptions) int {
+	app.WaitForMCP(doctorMCPWait)
+	defer func() {
+		app.StopMCP()
+		app.CloseShellSessions()
+		app.ReleaseWorkspace()
+	}()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if opts.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.timeout)
+		defer cancel()
+	}
+
+	out := &headlessBridge{eng: eng, w: os.Stdout, approve: opts.approve, verbose: opts.verbose}
+	eng.SetBridge(out)
+	err := eng.Run(ctx, opts.prompt)
+	out.endAssistant()
+	switch {
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		fmt.Fprintf(os.Stderr, "loom run: timed out after %s\n", opts.timeout)
+		return 1
+	case err != nil:
+		fmt.Fprintf(os.Stderr, "loom run: %v\n", err)
+		return 1
+	}
+	s := out.runSummary()
+	if s == nil {
+		fmt.Fprintln(os.Stderr, "loom run: the run left no summary")
+		return 1
+	}
+	if code := s.ExitCode(); code != 0 {
+		reason := s.Outcome
+		if s.Outcome == engine.RunCompleted {
+			reason = "checks failed"
+		}
+		fmt.Fprintf(os.Stderr, "loom run: %s\n", reason)
+		return code
+	}
+	return 0
+}
+
+// headlessBridge is the engine's UI for `loom run`: it prints the conversation as plain
+// text and answers approval prompts, choices and questions itself.
+type headlessBridge struct {
+	eng     *engine.Engine
+	w       io.Writer
+	approve bool
+	verbose bool
+
+	mu sync.Mutex
+	// assistant is the part of the current assistant message already printed
+	assistant string
+	// summary is the last run summary the engine emitted
+	summary *engine.RunSummary
+}
+
+// EmitRunSummary keeps the summary; its outcome decides the exit status.
+func (h *headlessBridge) EmitRunSummary(s engine.RunSummary) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.summary = &s
+}
+
+func (h *headlessBridge) runSummary() *engine.RunSummary {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.summary
+}
+
+// endAssistant finishes the assistant message being streamed, if any.
+func (h *headlessBridge) endAssistant() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.endAssistantLocked()
+}
+
+func (h *headlessBridge) endAssistantLocked() {
+	if h.assistant != "" {
+		fmt.Fprintln(h.w)
+		h.assistant = ""
+	}
+}
+
+func (h *headlessBridge) printf(format string, args ...any) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.endAssistantLocked()
+	fmt.Fprintf(h.w, format, args...)
+}
+
+func (h *headlessBridge) SendChat(role, text string) {
+	switch role {
+	case "user":
+		h.printf("> %s\n\n", text)
+	case "tool":
+		h.printf("%s\n", indent(h.clip(text)))
+	default:
+		h.printf("[%s] %s\n", role, text)
+	}
+}
+
+// EmitAssistant receives the whole message so far; only the new part is printed.
+func (h *headlessBridge) EmitAssistant(text string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if !strings.HasPrefix(text, h.assistant) {
+		h.endAssistantLocked()
+	}
+	fmt.Fprint(h.w, text[len(h.assistant):])
+	h.assistant = text
+}
+
+func (h *headlessBridge) EmitReasoning(string, bool) {}
+
+func (h *headlessBridge) EmitBilling(string, string, int64, int64, float64, float64, float64) {}
+
+func (h *headlessBridge) PromptApproval(actionID, summary, diff string) bool {
+	decision := "denied (pass -yes to approve)"
+	if h.approve {
+		decision = "approved"
+	}
+	if strings.TrimSpace(diff) != "" {
+		h.printf("[approval] %s\n%s\n[approval] %s\n", summary, indent(h.clip(diff)), decision)
+	} else {
+		h.printf("[approval] %s: %s\n", summary, decision)
+	}
+	// The engine registered the request before prompting and waits for the answer
+	go h.eng.ResolveApproval(actionID, h.approve)
+	return h.approve
+}
+
+func (h *headlessBridge) PromptChoice(actionID, question string, options []string) int {
+	h.printf("[choice] %s (no one to answer; skipped)\n", question)
+	go h.eng.ResolveChoice(actionID, -1)
+	return -1
+}
+
+func (h *headlessBridge) PromptQuestion(actionID string, question tool.AskUserArgs) {
+	h.printf("[question] %s (no one to answer; skipped)\n", question.Question)
+	go h.eng.ResolveQuestion(actionID, -1, "")
+}
+
+func (h *headlessBridge) SetBusy(bool) {}
+
+func (h *headlessBridge) OpenFileInUI(string) {}
+
+func (h *headlessBridge) EmitStatus(engine.Status) {}
+
+// EmitToolPreview announces each tool call once its arguments are complete.
+func (h *headlessBridge) EmitToolPreview(p engine.ToolPreview) {
+	if !p.Done {
+		return
+	}
+	line := "→ " + p.Name
+	if p.Action != "" {
+		line += " " + p.Action
+	}
+	if p.Path != "" {
+		line += " " + p.Path
+	}
+	h.printf("%s\n", line)
+}
+
+// clip shortens tool output to maxToolOutputLines unless verbose output was requested.
+func (h *headlessBridge) clip(text string) string {
+	text = strings.TrimRight(text, "\n")
+	lines := strings.Split(text, "\n")
+	if h.verbose || len(lines) <= maxToolOutputLines {
+		return text
+	}
+	return strings.Join(lines[:maxToolOutputLines], "\n") + fmt.Sprintf("\n… %d more lines (-verbose prints them)", len(lines)-maxToolOutputLines)
+}
+
+func indent(text string) string {
+	return "    " + strings.ReplaceAll(text, "\n", "\n    ")
+}